@@ -73,6 +73,26 @@ func ExchangeRate() string {
 	return os.Getenv(siaExchangeRate)
 }
 
+// RenterChaosAllowed returns whether the renter's chaos testing mode may be
+// enabled. It is always allowed in testing builds and otherwise requires the
+// siaRenterChaos environment variable to be set to "true".
+func RenterChaosAllowed() bool {
+	return Release == "testing" || os.Getenv(siaRenterChaos) == "true"
+}
+
+// OTLPTracesEndpoint returns the URL of the OpenTelemetry collector traces are
+// exported to using OTLP/HTTP. An empty string is returned if exporting traces
+// is disabled.
+func OTLPTracesEndpoint() string {
+	if endpoint := os.Getenv(otelExporterOTLPTracesEndpoint); endpoint != "" {
+		return endpoint
+	}
+	if endpoint := os.Getenv(otelExporterOTLPEndpoint); endpoint != "" {
+		return strings.TrimSuffix(endpoint, "/") + "/v1/traces"
+	}
+	return ""
+}
+
 // apiPasswordFilePath returns the path to the API's password file. The password
 // file is stored in the Sia data directory.
 func apiPasswordFilePath() string {
@@ -20,4 +20,17 @@ var (
 	// siaExchangeRate is the environment variable that can be set to
 	// show amounts (additionally) in a different currency
 	siaExchangeRate = "SIA_EXCHANGE_RATE"
+
+	// siaRenterChaos is the environment variable that can be set to "true" to
+	// allow enabling the renter's chaos testing mode outside of testing builds
+	siaRenterChaos = "SIA_RENTER_CHAOS"
+
+	// otelExporterOTLPEndpoint is the standard OpenTelemetry environment
+	// variable that sets the base URL of an OTLP/HTTP collector
+	otelExporterOTLPEndpoint = "OTEL_EXPORTER_OTLP_ENDPOINT"
+
+	// otelExporterOTLPTracesEndpoint is the standard OpenTelemetry environment
+	// variable that sets the full URL traces are exported to. It takes
+	// precedence over otelExporterOTLPEndpoint
+	otelExporterOTLPTracesEndpoint = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"
 )
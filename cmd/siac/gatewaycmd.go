@@ -246,7 +246,7 @@ func gatewayblocklistsetcmd(cmd *cobra.Command, addresses []string) {
 // gatewaylistcmd is the handler for the command `siac gateway list`.
 // Prints a list of all peers.
 func gatewaylistcmd() {
-	info, err := httpClient.GatewayGet()
+	info, err := httpClient.GatewayPeersGet()
 	if err != nil {
 		die("Could not get peer list:", err)
 	}
@@ -256,9 +256,11 @@ func gatewaylistcmd() {
 	}
 	fmt.Println(len(info.Peers), "active peers:")
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "Version\tOutbound\tAddress")
+	fmt.Fprintln(w, "Version\tOutbound\tAddress\tScore\tUptime\tRelay Latency\tMisbehavior")
 	for _, peer := range info.Peers {
-		fmt.Fprintf(w, "%v\t%v\t%v\n", peer.Version, yesNo(!peer.Inbound), peer.NetAddress)
+		s := peer.Score
+		fmt.Fprintf(w, "%v\t%v\t%v\t%.2f\t%v\t%v\t%v\n", peer.Version, yesNo(!peer.Inbound), peer.NetAddress,
+			s.Score, s.Uptime.Round(time.Second), s.RelayLatency.Round(time.Millisecond), s.Misbehavior)
 	}
 	if err := w.Flush(); err != nil {
 		die("failed to flush writer")
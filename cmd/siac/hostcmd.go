@@ -7,6 +7,7 @@ import (
 	"sort"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -28,7 +29,9 @@ You can revert this by running:
 	siac host config acceptingcontracts false
 You may also supply a specific address to be announced, e.g.:
 	siac host announce my-host-domain.com:9001
-Doing so will override the standard connectivity checks.`,
+Doing so will override the standard connectivity checks.
+Use --dry-run to check the address and the fee of the announcement without
+submitting it.`,
 		Run: hostannouncecmd,
 	}
 
@@ -70,15 +73,35 @@ Available settings:
      registrysize:       filesize
      customregistrypath: string
 
+     batchstorageproofs: boolean
+     maxprooffee:        currency
+     prooffeemultiplier: float
+
+     writebatchmaxlatency: milliseconds
+
+     pricetablegraceperiod: seconds
+
+     readcachesize: filesize
+
+     maxprograminstructions: int
+     maxprogramdatalength:   filesize
+     maxprogramduration:     seconds
+
+     monitoringaddress: string
+
+     collateralbudgetalertthreshold: currency
+     walletbalancealertthreshold:    currency
+
 Currency units can be specified, e.g. 10SC; run 'siac help wallet' for details.
 
 Durations (maxduration and windowsize) must be specified in either blocks (b),
 hours (h), days (d), or weeks (w). A block is approximately 10 minutes, so one
 hour is six blocks, a day is 144 blocks, and a week is 1008 blocks.
 
-Timeouts (ephemeralaccountexpiry) must be specified in either seconds (s),
-hours (h), days (d), or weeks (w). One hour is 3600 seconds, a day is 86400
-seconds, and a week is 604800 seconds.
+Timeouts (ephemeralaccountexpiry, pricetablegraceperiod and
+maxprogramduration) must be specified in either seconds (s), hours (h), days
+(d), or weeks (w). One hour is 3600 seconds, a day is 86400 seconds, and a week
+is 604800 seconds.
 
 For a description of each parameter, see doc/API.md.
 
@@ -109,8 +132,19 @@ Available output types:
 
 	hostFolderCmd = &cobra.Command{
 		Use:   "folder",
-		Short: "Add, remove, or resize a storage folder",
-		Long:  "Add, remove, or resize a storage folder.",
+		Short: "Add, drain, remove, or resize a storage folder",
+		Long:  "Add, drain, remove, or resize a storage folder.",
+	}
+
+	hostFolderDrainCmd = &cobra.Command{
+		Use:   "drain [path]",
+		Short: "Drain a storage folder in the background",
+		Long: `Migrate the data of a storage folder to the remaining storage folders in the
+background and remove the folder once it is empty. The folder doesn't accept new
+data while it is being drained. Use the rate flag to limit the number of sectors
+migrated per second and the pause and resume flags to pause and resume the
+drain. A drain is not resumed automatically after restarting siad.`,
+		Run: wrap(hostfolderdraincmd),
 	}
 
 	hostFolderRemoveCmd = &cobra.Command{
@@ -163,11 +197,16 @@ func hostcmd() {
 	if err != nil {
 		die("Could not fetch storage info:", err)
 	}
+	sh, err := httpClient.HostStorageHealthGet()
+	if err != nil {
+		die("Could not fetch storage health:", err)
+	}
 
 	es := hg.ExternalSettings
 	fm := hg.FinancialMetrics
 	is := hg.InternalSettings
 	nm := hg.NetworkMetrics
+	rcm := hg.ReadCacheMetrics
 
 	// calculate total storage available and remaining
 	var totalstorage, storageremaining uint64
@@ -238,6 +277,25 @@ Host Internal Settings:
 	registrysize:       %v
 	customregistrypath: %v
 
+	batchstorageproofs: %v
+	maxprooffee:        %v
+	prooffeemultiplier: %v
+
+	writebatchmaxlatency: %vms
+
+	pricetablegraceperiod: %vs
+
+	readcachesize: %v
+
+	maxprograminstructions: %v
+	maxprogramdatalength:   %v
+	maxprogramduration:     %vs
+
+	monitoringaddress: %v
+
+	collateralbudgetalertthreshold: %v
+	walletbalancealertthreshold:    %v
+
 Host Financials:
 	Contract Count:               %v
 	Transaction Fee Compensation: %v
@@ -264,6 +322,13 @@ RPC Stats:
 	Revise Calls:       %v
 	Settings Calls:     %v
 	FormContract Calls: %v
+
+Read Cache:
+	Hits:      %v
+	Misses:    %v
+	Bypassed:  %v
+	Evictions: %v
+	Size:      %v (%v sectors)
 `,
 			connectabilityString,
 			es.Version,
@@ -292,6 +357,25 @@ RPC Stats:
 			modules.FilesizeUnits(is.RegistrySize),
 			is.CustomRegistryPath,
 
+			yesNo(is.BatchStorageProofs),
+			currencyUnits(is.MaxProofFee),
+			is.ProofFeeMultiplier,
+
+			is.WriteBatchMaxLatency.Milliseconds(),
+
+			is.PriceTableGracePeriod.Seconds(),
+
+			modules.FilesizeUnits(is.ReadCacheSize),
+
+			is.MaxProgramInstructions,
+			modules.FilesizeUnits(is.MaxProgramDataLength),
+			is.MaxProgramDuration.Seconds(),
+
+			is.MonitoringAddress,
+
+			currencyUnits(is.CollateralBudgetAlertThreshold),
+			currencyUnits(is.WalletBalanceAlertThreshold),
+
 			fm.ContractCount, currencyUnits(fm.ContractCompensation),
 			currencyUnits(fm.PotentialContractCompensation),
 			currencyUnits(fm.TransactionFeeExpenses),
@@ -310,7 +394,10 @@ RPC Stats:
 
 			nm.ErrorCalls, nm.UnrecognizedCalls, nm.DownloadCalls,
 			nm.RenewCalls, nm.ReviseCalls, nm.SettingsCalls,
-			nm.FormContractCalls)
+			nm.FormContractCalls,
+
+			rcm.Hits, rcm.Misses, rcm.Bypassed, rcm.Evictions,
+			modules.FilesizeUnits(rcm.Size), rcm.Sectors)
 	} else {
 		fmt.Printf(`Host info:
 	Connectability Status: %v
@@ -364,6 +451,38 @@ RPC Stats:
 	if err := w.Flush(); err != nil {
 		die("failed to flush writer")
 	}
+
+	// display the progress of draining storage folders
+	for _, folder := range sg.Folders {
+		if !folder.Draining {
+			continue
+		}
+		var pct float64
+		if folder.ProgressDenominator > 0 {
+			pct = 100 * float64(folder.ProgressNumerator) / float64(folder.ProgressDenominator)
+		}
+		status := "draining"
+		if folder.DrainPaused {
+			status = "paused"
+		}
+		fmt.Printf("Draining %v: %.2f%% migrated (%v)\n", folder.Path, pct, status)
+		if folder.DrainError != "" {
+			fmt.Println("  Error:", folder.DrainError)
+		}
+	}
+
+	// display the results of the sector scrubber
+	fmt.Println("\nStorage Health:")
+	if sh.CycleStarted.IsZero() {
+		fmt.Println("  No sectors scrubbed yet")
+	} else {
+		fmt.Printf("  Current Cycle:       %v / %v sectors (started %v)\n", sh.CycleSectorsScrubbed, sh.CycleSectorsTotal, sh.CycleStarted.Format(time.RFC822))
+		if !sh.LastCycleCompleted.IsZero() {
+			fmt.Printf("  Last Completed:      %v\n", sh.LastCycleCompleted.Format(time.RFC822))
+		}
+	}
+	fmt.Printf("  Unreadable Sectors:  %v\n", sh.UnreadableSectors)
+	fmt.Printf("  Quarantined Sectors: %v\n", sh.QuarantinedSectors)
 }
 
 // hostconfigcmd is the handler for the command `siac host config [setting] [value]`.
@@ -372,7 +491,7 @@ func hostconfigcmd(param, value string) {
 	var err error
 	switch param {
 	// currency (convert to hastings)
-	case "collateralbudget", "maxcollateral", "minbaserpcprice", "mincontractprice", "minsectoraccessprice", "maxephemeralaccountbalance", "maxephemeralaccountrisk":
+	case "collateralbudget", "maxcollateral", "minbaserpcprice", "mincontractprice", "minsectoraccessprice", "maxephemeralaccountbalance", "maxephemeralaccountrisk", "maxprooffee", "collateralbudgetalertthreshold", "walletbalancealertthreshold":
 		value, err = types.ParseCurrency(value)
 		if err != nil {
 			die("Could not parse "+param+":", err)
@@ -399,7 +518,7 @@ func hostconfigcmd(param, value string) {
 		value = c.String()
 
 	// bool (allow "yes" and "no")
-	case "acceptingcontracts":
+	case "acceptingcontracts", "batchstorageproofs":
 		switch strings.ToLower(value) {
 		case "yes":
 			value = "true"
@@ -415,21 +534,21 @@ func hostconfigcmd(param, value string) {
 		}
 
 	// filesize (convert to bytes)
-	case "registrysize":
+	case "registrysize", "readcachesize", "maxprogramdatalength":
 		value, err = parseFilesize(value)
 		if err != nil {
 			die("Could not parse "+param+":", err)
 		}
 
 	// timeout (convert to seconds)
-	case "ephemeralaccountexpiry":
+	case "ephemeralaccountexpiry", "pricetablegraceperiod", "maxprogramduration":
 		value, err = parseTimeout(value)
 		if err != nil {
 			die("Could not parse "+param+":", err)
 		}
 
 	// other valid settings
-	case "maxdownloadbatchsize", "maxrevisebatchsize", "netaddress", "customregistrypath":
+	case "maxdownloadbatchsize", "maxrevisebatchsize", "netaddress", "customregistrypath", "prooffeemultiplier", "writebatchmaxlatency", "monitoringaddress", "maxprograminstructions":
 
 	// invalid settings
 	default:
@@ -483,10 +602,43 @@ func hostcontractcmd() {
 	}
 }
 
+// hostannouncedryrun prints the announcement the host would submit for addr
+// without submitting it.
+func hostannouncedryrun(addr modules.NetAddress) {
+	preview, err := httpClient.HostAnnounceDryRunPost(addr)
+	if err != nil {
+		die("Could not build host announcement:", err)
+	}
+	fmt.Printf(`Announcement Preview:
+  Address:              %v
+  Additional Addresses: %v
+  Public Key:           %v
+  Fee:                  %v
+  Transaction Size:     %v
+`, preview.NetAddress, preview.AdditionalNetAddresses, preview.PublicKey, currencyUnits(preview.Fee), modules.FilesizeUnits(preview.Size))
+	if preview.AlreadyAnnounced {
+		fmt.Println("\nWARNING: The host already announced this address. Announcing it again pays the fee a second time.")
+	}
+	fmt.Println("\nThe announcement was not submitted. Run the command without --dry-run to submit it.")
+}
+
 // hostannouncecmd is the handler for the command `siac host announce`.
 // Announces yourself as a host to the network. Optionally takes an address to
 // announce as.
 func hostannouncecmd(cmd *cobra.Command, args []string) {
+	if len(args) > 1 {
+		_ = cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+	if hostAnnounceDryRun {
+		var addr modules.NetAddress
+		if len(args) == 1 {
+			addr = modules.NetAddress(args[0])
+		}
+		hostannouncedryrun(addr)
+		return
+	}
+
 	var err error
 	switch len(args) {
 	case 0:
@@ -550,6 +702,35 @@ func hostfolderremovecmd(path string) {
 	fmt.Println("Removed folder", path)
 }
 
+// hostfolderdraincmd starts, updates, pauses or resumes the drain of a folder
+// in the host.
+func hostfolderdraincmd(path string) {
+	if hostFolderDrainPause && hostFolderDrainResume {
+		die("Can't use both --pause and --resume")
+	}
+	path = abs(path)
+	switch {
+	case hostFolderDrainPause:
+		err := httpClient.HostStorageFoldersDrainPausePost(path, true)
+		if err != nil {
+			die("Could not pause drain:", err)
+		}
+		fmt.Println("Paused draining folder", path)
+	case hostFolderDrainResume:
+		err := httpClient.HostStorageFoldersDrainPausePost(path, false)
+		if err != nil {
+			die("Could not resume drain:", err)
+		}
+		fmt.Println("Resumed draining folder", path)
+	default:
+		err := httpClient.HostStorageFoldersDrainPost(path, hostFolderDrainRate)
+		if err != nil {
+			die("Could not drain folder:", err)
+		}
+		fmt.Println("Draining folder", path)
+	}
+}
+
 // hostfolderresizecmd resizes a folder in the host.
 func hostfolderresizecmd(path, newsize string) {
 	newsize, err := parseFilesize(newsize)
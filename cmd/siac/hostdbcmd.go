@@ -57,8 +57,10 @@ var (
 func printScoreBreakdown(info *api.HostdbHostsGET) {
 	fmt.Println("\n  Score Breakdown:")
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "\t\tAddress Changes:\t %.3f\n", info.ScoreBreakdown.AddressChangeAdjustment)
 	fmt.Fprintf(w, "\t\tAge:\t %.3f\n", info.ScoreBreakdown.AgeAdjustment)
 	fmt.Fprintf(w, "\t\tBase Price:\t %.3f\n", info.ScoreBreakdown.BasePriceAdjustment)
+	fmt.Fprintf(w, "\t\tBenchmark:\t %.3f\n", info.ScoreBreakdown.BenchmarkAdjustment)
 	fmt.Fprintf(w, "\t\tBurn:\t %.3f\n", info.ScoreBreakdown.BurnAdjustment)
 	fmt.Fprintf(w, "\t\tCollateral:\t %.3f\n", info.ScoreBreakdown.CollateralAdjustment/1e96)
 	fmt.Fprintf(w, "\t\tDuration:\t %.3f\n", info.ScoreBreakdown.DurationAdjustment)
@@ -388,6 +390,19 @@ func hostdbviewcmd(pubkey string) {
 	fmt.Println("  Last IP Net Change:       ", info.Entry.LastIPNetChange)
 	fmt.Println("  Number of IP Net Changes: ", len(info.Entry.IPNets))
 
+	// Print the addresses the host announced.
+	if len(info.Entry.AddressHistory) > 0 {
+		fmt.Println("\n  Address History:")
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "\t\tBlock Height\tAddress")
+		for _, addr := range info.Entry.AddressHistory {
+			fmt.Fprintf(w, "\t\t%v\t%v\n", addr.BlockHeight, addr.NetAddress)
+		}
+		if err := w.Flush(); err != nil {
+			die("failed to flush writer")
+		}
+	}
+
 	fmt.Println("\n  Host Settings:")
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 
@@ -447,5 +462,14 @@ func hostdbviewcmd(pubkey string) {
 	fmt.Println("  Recent Successful Interactions:   ", info.Entry.RecentSuccessfulInteractions)
 	fmt.Printf("  Overall Uptime:                    %.3f\n", uptimeRatio)
 
+	// Print the benchmark statistics if the host was benchmarked.
+	if b := info.Entry.Benchmark; b.NumSamples > 0 {
+		fmt.Println("\n  Benchmark Samples:                ", b.NumSamples)
+		fmt.Println("  Last Benchmark:                   ", b.LastBenchmark)
+		fmt.Println("  Round Trip Time:                  ", b.RTT)
+		fmt.Println("  Time To First Byte:               ", b.TTFB)
+		fmt.Println("  Throughput:                       ", ratelimitUnits(int64(b.Throughput)))
+	}
+
 	fmt.Println()
 }
@@ -31,7 +31,11 @@ var (
 	daemonTraceProfile     bool   // Indicates that the Trace profile should be started
 
 	// Host Flags
+	hostAnnounceDryRun     bool   // only report the announcement
 	hostContractOutputType string // output type for host contracts
+	hostFolderDrainPause   bool   // pause a folder drain
+	hostFolderDrainRate    uint64 // sectors per second migrated by a folder drain
+	hostFolderDrainResume  bool   // resume a folder drain
 	hostFolderRemoveForce  bool   // force folder remove
 
 	// Renter Flags
@@ -41,6 +45,7 @@ var (
 	renterBubbleAll           bool   // Bubble the entire directory tree
 	renterDeleteRoot          bool   // Delete path start from root instead of the UserFolder.
 	renterDownloadAsync       bool   // Downloads files asynchronously
+	renterDownloadPolicy      string // The policy used for downloads.
 	renterDownloadRecursive   bool   // Downloads folders recursively.
 	renterDownloadRoot        bool   // Download path start from root instead of the UserFolder.
 	renterFuseMountAllowOther bool   // Mount fuse with 'AllowOther' set to true.
@@ -67,6 +72,11 @@ var (
 	allowanceMaxStoragePrice           string // max allowed price to store data on a host
 	allowanceMaxUploadBandwidthPrice   string // max allowed price to upload data to a host
 
+	allowanceExcludedCountries string // countries hosts must not be located in
+	allowanceExcludedSubnets   string // subnets hosts must not be located in
+	allowanceMinHostAge        string // min age of hosts since their first announcement
+	allowanceMinHostVersion    string // min version of hosts
+
 	// Skykey Flags
 	skykeyID              string // ID used to identify a Skykey.
 	skykeyName            string // Name used to identify a Skykey.
@@ -298,9 +308,13 @@ func initCmds() *cobra.Command {
 
 	root.AddCommand(hostCmd)
 	hostCmd.AddCommand(hostAnnounceCmd, hostConfigCmd, hostContractCmd, hostFolderCmd, hostSectorCmd)
-	hostFolderCmd.AddCommand(hostFolderAddCmd, hostFolderRemoveCmd, hostFolderResizeCmd)
+	hostFolderCmd.AddCommand(hostFolderAddCmd, hostFolderDrainCmd, hostFolderRemoveCmd, hostFolderResizeCmd)
 	hostSectorCmd.AddCommand(hostSectorDeleteCmd)
+	hostAnnounceCmd.Flags().BoolVarP(&hostAnnounceDryRun, "dry-run", "", false, "Report the announcement and its fee without submitting it")
 	hostContractCmd.Flags().StringVarP(&hostContractOutputType, "type", "t", "value", "Select output type")
+	hostFolderDrainCmd.Flags().BoolVarP(&hostFolderDrainPause, "pause", "", false, "Pause the drain of the folder")
+	hostFolderDrainCmd.Flags().Uint64VarP(&hostFolderDrainRate, "rate", "r", 0, "Number of sectors migrated per second, 0 for unlimited")
+	hostFolderDrainCmd.Flags().BoolVarP(&hostFolderDrainResume, "resume", "", false, "Resume the drain of the folder")
 	hostFolderRemoveCmd.Flags().BoolVarP(&hostFolderRemoveForce, "force", "f", false, "Force the removal of the folder and its data")
 
 	root.AddCommand(hostdbCmd)
@@ -317,18 +331,20 @@ func initCmds() *cobra.Command {
 		renterFilesListCmd, renterFilesRenameCmd, renterFilesUnstuckCmd, renterFilesUploadCmd,
 		renterFuseCmd, renterLostCmd, renterPricesCmd, renterRatelimitCmd, renterSetAllowanceCmd,
 		renterSetLocalPathCmd, renterTriggerContractRecoveryScanCmd, renterUploadsCmd, renterWorkersCmd,
-		renterHealthSummaryCmd)
+		renterHealthSummaryCmd, renterMigrationPlanCmd)
 	renterWorkersCmd.AddCommand(renterWorkersAccountsCmd, renterWorkersDownloadsCmd, renterWorkersPriceTableCmd, renterWorkersReadJobsCmd, renterWorkersHasSectorJobSCmd, renterWorkersUploadsCmd, renterWorkersReadRegistryCmd, renterWorkersUpdateRegistryCmd)
 
 	renterAllowanceCmd.AddCommand(renterAllowanceCancelCmd)
 	renterBubbleCmd.Flags().BoolVarP(&renterBubbleAll, "all", "A", false, "Bubble the entire directory tree")
 	renterContractsCmd.AddCommand(renterContractsViewCmd)
+	renterMigrationPlanCmd.AddCommand(renterMigrationPlanApproveCmd)
 	renterFilesUploadCmd.AddCommand(renterFilesUploadPauseCmd, renterFilesUploadResumeCmd)
 
 	renterContractsCmd.Flags().BoolVarP(&renterAllContracts, "all", "A", false, "Show all expired contracts in addition to active contracts")
 	renterDownloadsCmd.Flags().BoolVarP(&renterShowHistory, "history", "H", false, "Show download history in addition to the download queue")
 	renterFilesDeleteCmd.Flags().BoolVar(&renterDeleteRoot, "root", false, "Delete files and folders from root instead of from the user home directory")
 	renterFilesDownloadCmd.Flags().BoolVarP(&renterDownloadAsync, "async", "A", false, "Download file asynchronously")
+	renterFilesDownloadCmd.Flags().StringVar(&renterDownloadPolicy, "policy", "balanced", "Download policy, one of 'balanced', 'cheapest' or 'latency'")
 	renterFilesDownloadCmd.Flags().BoolVarP(&renterDownloadRecursive, "recursive", "R", false, "Download folder recursively")
 	renterFilesDownloadCmd.Flags().BoolVar(&renterDownloadRoot, "root", false, "Download files and folders from root instead of from the user home directory")
 	renterFilesListCmd.Flags().BoolVarP(&renterListRecursive, "recursive", "R", false, "Recursively list files and folders")
@@ -352,6 +368,10 @@ func initCmds() *cobra.Command {
 	renterSetAllowanceCmd.Flags().StringVar(&allowanceMaxSectorAccessPrice, "max-sector-access-price", "", "the maximum price that the renter will pay to access a sector on a host")
 	renterSetAllowanceCmd.Flags().StringVar(&allowanceMaxStoragePrice, "max-storage-price", "", "the maximum price that the renter will pay to store data on a host")
 	renterSetAllowanceCmd.Flags().StringVar(&allowanceMaxUploadBandwidthPrice, "max-upload-bandwidth-price", "", "the maximum price that the renter will pay to upload data to a host")
+	renterSetAllowanceCmd.Flags().StringVar(&allowanceMinHostVersion, "min-host-version", "", "the minimum version of new hosts, 'none' to disable the filter")
+	renterSetAllowanceCmd.Flags().StringVar(&allowanceMinHostAge, "min-host-age", "", "the minimum age of new hosts since their first announcement in blocks (b), hours (h), days (d) or weeks (w)")
+	renterSetAllowanceCmd.Flags().StringVar(&allowanceExcludedCountries, "excluded-countries", "", "comma-separated country codes new hosts must not be located in, 'none' to disable the filter")
+	renterSetAllowanceCmd.Flags().StringVar(&allowanceExcludedSubnets, "excluded-subnets", "", "comma-separated CIDR subnets new hosts must not be located in, 'none' to disable the filter")
 
 	renterFuseCmd.AddCommand(renterFuseMountCmd, renterFuseUnmountCmd)
 	renterFuseMountCmd.Flags().BoolVarP(&renterFuseMountAllowOther, "allow-other", "", false, "Allow users other than the user that mounted the fuse directory to access and use the fuse directory")
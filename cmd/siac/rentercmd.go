@@ -124,6 +124,23 @@ the command 'siac renter lost' to see the renter's lost files.`,
 		Run:   wrap(rentercontractsviewcmd),
 	}
 
+	renterMigrationPlanCmd = &cobra.Command{
+		Use:   "migrationplan",
+		Short: "View the contracts queued for replacement",
+		Long: `View the contracts the contractor queued for replacement because the scores
+of their hosts dropped, together with the churn budget of the current period.
+Deferred contracts are churned once the churn budget allows for it.`,
+		Run: wrap(rentermigrationplancmd),
+	}
+
+	renterMigrationPlanApproveCmd = &cobra.Command{
+		Use:   "approve [contract-id]...",
+		Short: "Approve the replacement of deferred contracts",
+		Long: `Approve churning the provided deferred contracts during the next contract
+maintenance regardless of the churn budget.`,
+		Run: rentermigrationplanapprovecmd,
+	}
+
 	renterDownloadsCmd = &cobra.Command{
 		Use:   "downloads",
 		Short: "View the download queue",
@@ -212,7 +229,8 @@ local path where the Sia folder is mounted.`,
 		Use:   "upload [source] [path]",
 		Short: "Upload a file or folder",
 		Long: `Upload a file or folder to [path] on the Sia network. The --data-pieces and --parity-pieces
-flags can be used to set a custom redundancy for the file.`,
+flags can be used to set a custom redundancy for the file. If [source] is "-", the data is read
+from stdin and streamed to the network as it arrives without being staged on disk.`,
 		Run: wrap(renterfilesuploadcmd),
 	}
 
@@ -577,6 +595,15 @@ func renterdownloadscmd() {
 	}
 }
 
+// filterString returns the string of an allowance host filter or "none" if
+// the filter is disabled.
+func filterString(filter string) string {
+	if filter == "" {
+		return "none"
+	}
+	return filter
+}
+
 // renterallowancecmd is the handler for the command `siac renter allowance`.
 // displays the current allowance.
 func renterallowancecmd() {
@@ -611,6 +638,12 @@ Price Protections:
   MaxSectorAccessPrice:      %v per million accesses
   MaxStoragePrice:           %v per TB per Month
   MaxUploadBandwidthPrice:   %v per TB
+
+Host Filters:
+  MinHostVersion:            %v
+  MinHostAge:                %v blocks
+  ExcludedCountries:         %v
+  ExcludedSubnets:           %v
 `, currencyUnitsWithExchangeRate(allowance.Funds, rate), allowance.Period, allowance.RenewWindow,
 		allowance.Hosts,
 		modules.FilesizeUnits(allowance.ExpectedStorage),
@@ -622,7 +655,10 @@ Price Protections:
 		currencyUnits(allowance.MaxDownloadBandwidthPrice.Mul(modules.BytesPerTerabyte)),
 		currencyUnits(allowance.MaxSectorAccessPrice.Mul64(1e6)),
 		currencyUnits(allowance.MaxStoragePrice.Mul(modules.BlockBytesPerMonthTerabyte)),
-		currencyUnits(allowance.MaxUploadBandwidthPrice.Mul(modules.BytesPerTerabyte)))
+		currencyUnits(allowance.MaxUploadBandwidthPrice.Mul(modules.BytesPerTerabyte)),
+		filterString(allowance.MinHostVersion), allowance.MinHostAge,
+		filterString(strings.Join(allowance.ExcludedCountries, ", ")),
+		filterString(strings.Join(allowance.ExcludedSubnets, ", ")))
 
 	// Show detailed current Period spending metrics
 	renterallowancespending(rg)
@@ -862,6 +898,46 @@ func rentersetallowancecmd(_ *cobra.Command, _ []string) {
 		req = req.WithMaxUploadBandwidthPrice(price)
 		changedFields++
 	}
+	// parse minhostversion
+	if allowanceMinHostVersion != "" {
+		if allowanceMinHostVersion == "none" {
+			allowanceMinHostVersion = ""
+		}
+		req = req.WithMinHostVersion(allowanceMinHostVersion)
+		changedFields++
+	}
+	// parse minhostage
+	if allowanceMinHostAge != "" {
+		blocks, err := parsePeriod(allowanceMinHostAge)
+		if err != nil {
+			die("Could not parse min host age:", err)
+		}
+		var minHostAge types.BlockHeight
+		_, err = fmt.Sscan(blocks, &minHostAge)
+		if err != nil {
+			die("Could not parse min host age:", err)
+		}
+		req = req.WithMinHostAge(minHostAge)
+		changedFields++
+	}
+	// parse excludedcountries
+	if allowanceExcludedCountries != "" {
+		var countries []string
+		if allowanceExcludedCountries != "none" {
+			countries = strings.Split(allowanceExcludedCountries, ",")
+		}
+		req = req.WithExcludedCountries(countries...)
+		changedFields++
+	}
+	// parse excludedsubnets
+	if allowanceExcludedSubnets != "" {
+		var subnets []string
+		if allowanceExcludedSubnets != "none" {
+			subnets = strings.Split(allowanceExcludedSubnets, ",")
+		}
+		req = req.WithExcludedSubnets(subnets...)
+		changedFields++
+	}
 
 	// check if any fields were updated.
 	if changedFields == 0 {
@@ -1636,6 +1712,8 @@ func renterfilesdownloadcmd(path, destination string) {
 	if err != nil {
 		die("Couldn't parse SiaPath:", err)
 	}
+	// Validate the policy before starting any downloads.
+	parseDownloadPolicy()
 	// If root is not set we need to rebase.
 	if !renterDownloadRoot {
 		siaPath, err = siaPath.Rebase(modules.RootSiaPath(), modules.UserFolder)
@@ -1693,6 +1771,62 @@ func rentercontractrecoveryscanprogresscmd() {
 	}
 }
 
+// rentermigrationplancmd is the handler for the command `siac renter
+// migrationplan`. Lists the contracts queued for replacement.
+func rentermigrationplancmd() {
+	cs, err := httpClient.RenterContractorChurnStatus()
+	if err != nil {
+		die("Could not get churn status:", err)
+	}
+	plan, err := httpClient.RenterContractorMigrationPlanGet()
+	if err != nil {
+		die("Could not get migration plan:", err)
+	}
+	maxContracts := "unlimited"
+	if cs.MaxPeriodChurnContracts > 0 {
+		maxContracts = fmt.Sprint(cs.MaxPeriodChurnContracts)
+	}
+	fmt.Printf(`Churn Budget:
+  Churned Data:       %v / %v
+  Churned Contracts:  %v / %v
+`, sizeString(cs.AggregateCurrentPeriodChurn), sizeString(cs.MaxPeriodChurn), cs.CurrentPeriodChurnedContracts, maxContracts)
+
+	if len(plan.Migrations) == 0 {
+		fmt.Println("\nNo contracts are queued for replacement.")
+		return
+	}
+	fmt.Printf("\nMigration Plan (%v):\n", plan.Timestamp.Format(time.RFC822))
+	w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  Contract ID\tHost\tSize\tStatus\tApproved\tReason")
+	for _, m := range plan.Migrations {
+		fmt.Fprintf(w, "  %v\t%v\t%v\t%v\t%v\t%v\n", m.ContractID, m.HostPublicKey, sizeString(m.Size), m.Status, yesNo(m.Approved), m.Reason)
+	}
+	if err := w.Flush(); err != nil {
+		die("failed to flush writer:", err)
+	}
+}
+
+// rentermigrationplanapprovecmd is the handler for the command `siac renter
+// migrationplan approve`. Approves the replacement of deferred contracts.
+func rentermigrationplanapprovecmd(cmd *cobra.Command, args []string) {
+	if len(args) == 0 {
+		_ = cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+	var ids []types.FileContractID
+	for _, arg := range args {
+		var id types.FileContractID
+		if err := id.LoadString(arg); err != nil {
+			die("Could not parse contract id:", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := httpClient.RenterContractorMigrationPlanApprovePost(ids); err != nil {
+		die("Could not approve migrations:", err)
+	}
+	fmt.Printf("Approved the replacement of %v contract(s).\n", len(ids))
+}
+
 // renterfileslistcmd is the handler for the command `siac renter ls`. Lists
 // files known to the renter on the network.
 func renterfileslistcmd(cmd *cobra.Command, args []string) {
@@ -2006,17 +2140,31 @@ func renterfilesunstuckcmd() {
 // If [source] is a directory, all files inside it will be uploaded and named
 // relative to [path].
 func renterfilesuploadcmd(source, path string) {
-	stat, err := os.Stat(source)
-	if err != nil {
-		die("Could not stat file or folder:", err)
-	}
-
 	// Check for and parse any redundancy settings
 	numDataPieces, numParityPieces, err := api.ParseDataAndParityPieces(dataPieces, parityPieces)
 	if err != nil {
 		die("Could not parse data and parity pieces:", err)
 	}
 
+	// Stream the data from stdin.
+	if source == "-" {
+		siaPath, err := modules.NewSiaPath(path)
+		if err != nil {
+			die("Couldn't parse SiaPath:", err)
+		}
+		err = httpClient.RenterUploadStreamPost(os.Stdin, siaPath, uint64(numDataPieces), uint64(numParityPieces), false)
+		if err != nil {
+			die("Could not upload data from stdin:", err)
+		}
+		fmt.Printf("Uploaded data from stdin as '%s'.\n", path)
+		return
+	}
+
+	stat, err := os.Stat(source)
+	if err != nil {
+		die("Could not stat file or folder:", err)
+	}
+
 	if stat.IsDir() {
 		// folder
 		var files []string
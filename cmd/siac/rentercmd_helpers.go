@@ -96,6 +96,15 @@ func contractStats(contracts []api.RenterContract) (size uint64, spent, remainin
 	return
 }
 
+// parseDownloadPolicy parses the policy flag of the download command.
+func parseDownloadPolicy() modules.DownloadPolicy {
+	var policy modules.DownloadPolicy
+	if err := policy.FromString(renterDownloadPolicy); err != nil {
+		die("Couldn't parse download policy:", err)
+	}
+	return policy
+}
+
 // downloadDir downloads the dir at the specified siaPath to the specified
 // location. It returns all the files for which a download was initialized as
 // tracked files and the ones which were ignored as skipped. Errors are composed
@@ -125,7 +134,7 @@ func downloadDir(siaPath modules.SiaPath, destination string) (tfs []trackedFile
 		}
 		// Download file.
 		totalSize += file.Filesize
-		_, err = httpClient.RenterDownloadFullGet(file.SiaPath, dst, true, true)
+		_, err = httpClient.RenterDownloadPolicyGet(file.SiaPath, dst, parseDownloadPolicy(), true, true)
 		if err != nil {
 			err = errors.AddContext(err, "Failed to start download")
 			return
@@ -527,7 +536,7 @@ func renterFilesDownload(path, destination string) {
 	// the call will return before the download has completed. The call is made
 	// as an async call.
 	start := time.Now()
-	cancelID, err := httpClient.RenterDownloadFullGet(siaPath, destination, true, true)
+	cancelID, err := httpClient.RenterDownloadPolicyGet(siaPath, destination, parseDownloadPolicy(), true, true)
 	if err != nil {
 		die("Download could not be started:", err)
 	}
@@ -31,6 +31,12 @@ func passwordPrompt(prompt string) (string, error) {
 // verifyAPISecurity checks that the security values are consistent with a
 // sane, secure system.
 func verifyAPISecurity(config Config) error {
+	// The public API only serves a read-only subset of the endpoints and
+	// therefore can't share its address with the regular API.
+	if config.Siad.PublicAPIaddr != "" && config.Siad.PublicAPIaddr == config.Siad.APIaddr {
+		return errors.New("the public API can't listen on the same address as the API")
+	}
+
 	// Make sure that only the loopback address is allowed unless the
 	// --disable-api-security flag has been used.
 	if !config.Siad.AllowAPIBind {
@@ -110,6 +116,7 @@ func processModules(modules string) (string, error) {
 func processConfig(config Config) (Config, error) {
 	var err1, err2 error
 	config.Siad.APIaddr = processNetAddr(config.Siad.APIaddr)
+	config.Siad.PublicAPIaddr = processNetAddr(config.Siad.PublicAPIaddr)
 	config.Siad.RPCaddr = processNetAddr(config.Siad.RPCaddr)
 	config.Siad.HostAddr = processNetAddr(config.Siad.HostAddr)
 	config.Siad.Modules, err1 = processModules(config.Siad.Modules)
@@ -225,6 +232,15 @@ func startDaemon(config Config) (err error) {
 		return err
 	}
 
+	// Serve the read-only public API if enabled.
+	if config.Siad.PublicAPIaddr != "" {
+		err = srv.ServePublicAPI(config.Siad.PublicAPIaddr, config.Siad.PublicAPIRateLimit)
+		if err != nil {
+			return errors.Compose(errors.AddContext(err, "failed to serve public API"), srv.Close())
+		}
+		fmt.Println("Serving public API on", srv.PublicAPIAddress())
+	}
+
 	// Attempt to auto-unlock the wallet using the SIA_WALLET_PASSWORD env variable
 	tryAutoUnlock(srv)
 
@@ -1,7 +1,14 @@
 package main
 
 import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"go.sia.tech/siad/build"
 )
 
 // TestUnitProcessNetAddr probes the 'processNetAddr' function.
@@ -206,4 +213,60 @@ func TestVerifyAPISecurity(t *testing.T) {
 	if err != nil {
 		t.Error("public + securityOff with authentication was rejected:", err)
 	}
+
+	// Check that the public API can't share the address of the API.
+	var publicAPISameAddr Config
+	publicAPISameAddr.Siad.APIaddr = "127.0.0.1:9980"
+	publicAPISameAddr.Siad.PublicAPIaddr = "127.0.0.1:9980"
+	err = verifyAPISecurity(publicAPISameAddr)
+	if err == nil {
+		t.Error("public API on the same address as the API was accepted")
+	}
+}
+
+// TestMigrateDataDir tests that migrateDataDir only moves data once the
+// migration is confirmed.
+func TestMigrateDataDir(t *testing.T) {
+	dir := build.TempDir("siad", t.Name())
+	consensusFile := filepath.Join(dir, "consensus", "consensus.db")
+	if err := os.MkdirAll(filepath.Dir(consensusFile), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(consensusFile, []byte("data"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var config Config
+	config.Siad.SiaDir = dir
+	config.Siad.ConsensusDir = filepath.Join(dir, "elsewhere", "consensus")
+
+	// Decline the migration.
+	var out bytes.Buffer
+	if err := migrateDataDir(config, strings.NewReader("n\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Migration aborted.") {
+		t.Fatal("migration wasn't aborted:", out.String())
+	}
+	if _, err := os.Stat(consensusFile); err != nil {
+		t.Fatal("data was moved:", err)
+	}
+
+	// Confirm the migration.
+	out.Reset()
+	if err := migrateDataDir(config, strings.NewReader("y\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(config.Siad.ConsensusDir, "consensus.db"))
+	if err != nil || string(data) != "data" {
+		t.Fatal("data wasn't migrated:", err)
+	}
+
+	// A second run has nothing to do.
+	out.Reset()
+	if err := migrateDataDir(config, strings.NewReader(""), &out); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Nothing to migrate.") {
+		t.Fatal("unexpected output:", out.String())
+	}
 }
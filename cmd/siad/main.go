@@ -39,6 +39,12 @@ type Config struct {
 		SiaMuxWSAddr  string
 		AllowAPIBind  bool
 
+		// PublicAPIaddr is the address of the read-only public API. It is
+		// disabled if empty. PublicAPIRateLimit is the number of requests
+		// per minute a client of the public API may make.
+		PublicAPIaddr      string
+		PublicAPIRateLimit uint64
+
 		Modules           string
 		NoBootstrap       bool
 		RequiredUserAgent string
@@ -53,6 +59,17 @@ type Config struct {
 		// put the apipassword file. This variable should not be altered if it
 		// is not set by a user flag.
 		SiaDir string
+
+		// The following directories allow for storing some of the data
+		// outside of SiaDir. Empty directories default to their location
+		// within SiaDir.
+		ConsensusDir string
+		HostDir      string
+		RenterDir    string
+		LogDir       string
+
+		// AssumeYes skips the confirmation of a data directory migration.
+		AssumeYes bool
 	}
 }
 
@@ -180,6 +197,15 @@ func main() {
 		Run:   versionCmd,
 	})
 
+	migrateCmd := &cobra.Command{
+		Use:   "migrate-datadir",
+		Short: "Move existing data to the configured data paths",
+		Long:  "Move the data of the consensus set, host, renter and the daemon logs from the sia directory to the paths configured with the --consensus-directory, --host-directory, --renter-directory and --log-directory flags. siad needs to be stopped during the migration.",
+		Run:   migrateDataDirCmd,
+	}
+	migrateCmd.Flags().BoolVarP(&globalConfig.Siad.AssumeYes, "yes", "y", false, "don't ask for confirmation before migrating")
+	root.AddCommand(migrateCmd)
+
 	root.AddCommand(&cobra.Command{
 		Use:   "modules",
 		Short: "List available modules for use with -M, --modules flag",
@@ -189,10 +215,14 @@ func main() {
 
 	// Set default values, which have the lowest priority.
 	root.Flags().StringVarP(&globalConfig.Siad.RequiredUserAgent, "agent", "", "Sia-Agent", "required substring for the user agent")
-	root.Flags().StringVarP(&globalConfig.Siad.HostAddr, "host-addr", "", ":9982", "which port the host listens on")
+	root.Flags().StringVarP(&globalConfig.Siad.HostAddr, "host-addr", "", ":9982", "which port the host listens on, can be a comma-separated list of addresses")
 	root.Flags().StringVarP(&globalConfig.Siad.ProfileDir, "profile-directory", "", "profiles", "location of the profiling directory")
 	root.Flags().StringVarP(&globalConfig.Siad.APIaddr, "api-addr", "", "localhost:9980", "which host:port the API server listens on")
-	root.Flags().StringVarP(&globalConfig.Siad.SiaDir, "sia-directory", "d", "", "location of the sia directory")
+	root.PersistentFlags().StringVarP(&globalConfig.Siad.SiaDir, "sia-directory", "d", "", "location of the sia directory")
+	root.PersistentFlags().StringVarP(&globalConfig.Siad.ConsensusDir, "consensus-directory", "", "", "location of the consensus data, defaults to the consensus folder of the sia directory")
+	root.PersistentFlags().StringVarP(&globalConfig.Siad.HostDir, "host-directory", "", "", "location of the host metadata, defaults to the host folder of the sia directory")
+	root.PersistentFlags().StringVarP(&globalConfig.Siad.RenterDir, "renter-directory", "", "", "location of the renter metadata, defaults to the renter folder of the sia directory")
+	root.PersistentFlags().StringVarP(&globalConfig.Siad.LogDir, "log-directory", "", "", "location of the daemon logs, defaults to the sia directory")
 	root.Flags().BoolVarP(&globalConfig.Siad.NoBootstrap, "no-bootstrap", "", false, "disable bootstrapping on this run")
 	root.Flags().StringVarP(&globalConfig.Siad.Profile, "profile", "", "", "enable profiling with flags 'cmt' for CPU, memory, trace")
 	root.Flags().StringVarP(&globalConfig.Siad.RPCaddr, "rpc-addr", "", ":9981", "which port the gateway listens on")
@@ -202,6 +232,8 @@ func main() {
 	root.Flags().BoolVarP(&globalConfig.Siad.AuthenticateAPI, "authenticate-api", "", true, "enable API password protection")
 	root.Flags().BoolVarP(&globalConfig.Siad.TempPassword, "temp-password", "", false, "enter a temporary API password during startup")
 	root.Flags().BoolVarP(&globalConfig.Siad.AllowAPIBind, "disable-api-security", "", false, "allow siad to listen on a non-localhost address (DANGEROUS)")
+	root.Flags().StringVarP(&globalConfig.Siad.PublicAPIaddr, "public-api-addr", "", "", "which host:port the unauthenticated, read-only public API listens on, disabled if empty")
+	root.Flags().Uint64VarP(&globalConfig.Siad.PublicAPIRateLimit, "public-api-ratelimit", "", 60, "max number of requests per minute per client IP of the public API, 0 for unlimited")
 
 	// If globalConfig.Siad.SiaDir is not set, use the environment variable provided.
 	if globalConfig.Siad.SiaDir == "" {
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/node"
+)
+
+// migrateDataDir moves the data in the sia directory to the data paths of the
+// config. The planned moves are printed to w and need to be confirmed using r
+// unless AssumeYes is set.
+func migrateDataDir(config Config, r io.Reader, w io.Writer) error {
+	moves, err := node.PlanDataPathMigration(config.Siad.SiaDir, dataPaths(config))
+	if err != nil {
+		return errors.AddContext(err, "unable to plan migration")
+	}
+	if len(moves) == 0 {
+		fmt.Fprintln(w, "Nothing to migrate.")
+		return nil
+	}
+
+	// Print the plan and ask for confirmation.
+	fmt.Fprintln(w, "The following data will be moved:")
+	for _, m := range moves {
+		fmt.Fprintf(w, "  %v: %v -> %v\n", m.Name, m.From, m.To)
+	}
+	if !config.Siad.AssumeYes {
+		fmt.Fprint(w, "Make sure siad is stopped. Continue? [y/N] ")
+		answer, err := bufio.NewReader(r).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return errors.AddContext(err, "failed to read answer")
+		}
+		if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+			fmt.Fprintln(w, "Migration aborted.")
+			return nil
+		}
+	}
+
+	// Perform the moves one at a time.
+	for _, m := range moves {
+		fmt.Fprintf(w, "Moving %v data...\n", m.Name)
+		if err := node.MigrateDataPath(m); err != nil {
+			return errors.AddContext(err, fmt.Sprintf("failed to move %v data", m.Name))
+		}
+	}
+	fmt.Fprintln(w, "Migration complete. Start siad with the same directory flags to use the new paths.")
+	return nil
+}
+
+// migrateDataDirCmd is a passthrough function for migrateDataDir.
+func migrateDataDirCmd(*cobra.Command, []string) {
+	if err := migrateDataDir(globalConfig, os.Stdin, os.Stdout); err != nil {
+		die(err)
+	}
+}
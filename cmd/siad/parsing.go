@@ -48,5 +48,16 @@ func parseModules(config Config) node.NodeParams {
 	params.SiaMuxTCPAddress = config.Siad.SiaMuxTCPAddr
 	params.SiaMuxWSAddress = config.Siad.SiaMuxWSAddr
 	params.Dir = config.Siad.SiaDir
+	params.Paths = dataPaths(config)
 	return params
 }
+
+// dataPaths returns the data paths configured in the provided config.
+func dataPaths(config Config) node.DataPaths {
+	return node.DataPaths{
+		ConsensusDir: config.Siad.ConsensusDir,
+		HostDir:      config.Siad.HostDir,
+		RenterDir:    config.Siad.RenterDir,
+		LogDir:       config.Siad.LogDir,
+	}
+}
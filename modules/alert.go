@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/url"
 	"strings"
 	"sync"
 
@@ -53,12 +54,119 @@ const (
 	// registered if the host has insufficient collateral budget left to form or
 	// renew a contract
 	AlertIDHostInsufficientCollateral = "host-insufficient-collateral"
+	// AlertIDHostSectorCorruption is the id of the alert that is registered
+	// while the host's scrubber has quarantined corrupted sectors.
+	AlertIDHostSectorCorruption = "host-sector-corruption"
+	// AlertIDHostProofsInDanger is the id of the alert that is registered
+	// while at least one of the host's storage proofs is unconfirmed close to
+	// its deadline.
+	AlertIDHostProofsInDanger = "host-proofs-in-danger"
+	// AlertIDHostLowCollateralBudget is the id of the alert that is
+	// registered while the host's remaining collateral budget is below the
+	// configured threshold.
+	AlertIDHostLowCollateralBudget = "host-low-collateral-budget"
+	// AlertIDHostLowWalletBalance is the id of the alert that is registered
+	// while the host's confirmed wallet balance is below the configured
+	// threshold.
+	AlertIDHostLowWalletBalance = "host-low-wallet-balance"
+	// AlertIDHostMissedProofs is the id of the alert that is registered while
+	// the host recently missed storage proofs.
+	AlertIDHostMissedProofs = "host-missed-proofs"
+	// AlertIDHostNotConnectable is the id of the alert that is registered
+	// while the host isn't connectable at its netaddress.
+	AlertIDHostNotConnectable = "host-not-connectable"
+	// AlertIDRenterReadOnlyMode is the id of the alert that is registered
+	// while the renter is in read-only mode and won't upload, repair or renew.
+	AlertIDRenterReadOnlyMode = "renter-read-only-mode"
+	// AlertIDRenterAllowanceSpent is the id of the alert that is registered
+	// if the renter spent more of its allowance than the configured
+	// threshold.
+	AlertIDRenterAllowanceSpent = "allowance-spent"
+	// AlertIDRenterRenewalExceedsFunds is the id of the alert that is
+	// registered if the estimated renewal cost of the renter's contracts
+	// exceeds the remaining funds of the allowance.
+	AlertIDRenterRenewalExceedsFunds = "renewal-exceeds-funds"
+)
+
+// The following consts are the categories of alerts. The category of an alert
+// can be used to route alerts to different sinks.
+const (
+	// AlertCategoryGeneral is the category of alerts which don't fit into any
+	// of the other categories.
+	AlertCategoryGeneral AlertCategory = "general"
+	// AlertCategoryContracts is the category of alerts related to file
+	// contracts.
+	AlertCategoryContracts AlertCategory = "contracts"
+	// AlertCategoryFiles is the category of alerts related to the renter's
+	// files.
+	AlertCategoryFiles AlertCategory = "files"
+	// AlertCategoryFunds is the category of alerts related to a lack of funds.
+	AlertCategoryFunds AlertCategory = "funds"
+	// AlertCategoryNetwork is the category of alerts related to connectivity.
+	AlertCategoryNetwork AlertCategory = "network"
+	// AlertCategoryStorage is the category of alerts related to disks and
+	// storage.
+	AlertCategoryStorage AlertCategory = "storage"
+)
+
+// The following consts are the sinks alerts can be routed to.
+const (
+	// AlertSinkLog routes alerts to the daemon's alert log.
+	AlertSinkLog = "log"
+	// AlertSinkWebhook routes alerts to a webhook using a HTTP POST request.
+	AlertSinkWebhook = "webhook"
+)
+
+var (
+	// alertCategories maps the static AlertIDs to their categories. IDs which
+	// are not part of the map belong to AlertCategoryGeneral.
+	alertCategories = map[AlertID]AlertCategory{
+		AlertIDWalletLockedDuringMaintenance: AlertCategoryFunds,
+		AlertIDRenterAllowanceLowFunds:       AlertCategoryFunds,
+		AlertIDRenterContractRenewalError:    AlertCategoryContracts,
+		AlertIDGatewayOffline:                AlertCategoryNetwork,
+		AlertIDHostDiskTrouble:               AlertCategoryStorage,
+		AlertIDHostInsufficientCollateral:    AlertCategoryFunds,
+		AlertIDHostSectorCorruption:          AlertCategoryStorage,
+		AlertIDHostProofsInDanger:            AlertCategoryContracts,
+		AlertIDHostLowCollateralBudget:       AlertCategoryFunds,
+		AlertIDHostLowWalletBalance:          AlertCategoryFunds,
+		AlertIDHostMissedProofs:              AlertCategoryContracts,
+		AlertIDHostNotConnectable:            AlertCategoryNetwork,
+		AlertIDRenterReadOnlyMode:            AlertCategoryFunds,
+		AlertIDRenterAllowanceSpent:          AlertCategoryFunds,
+		AlertIDRenterRenewalExceedsFunds:     AlertCategoryFunds,
+	}
+
+	// alertIDLowRedundancyPrefix is the prefix of all low redundancy AlertIDs.
+	alertIDLowRedundancyPrefix = "low-redundancy:"
+
+	// alertIDUploadVerificationPrefix is the prefix of all AlertIDs of failed
+	// upload verifications.
+	alertIDUploadVerificationPrefix = "upload-verification:"
 )
 
 // AlertIDSiafileLowRedundancy uses a Siafile's UID to create a unique AlertID
 // for a low redundancy alert.
 func AlertIDSiafileLowRedundancy(uid string) AlertID {
-	return AlertID(fmt.Sprintf("low-redundancy:%v", uid))
+	return AlertID(fmt.Sprintf("%v%v", alertIDLowRedundancyPrefix, uid))
+}
+
+// AlertIDSiafileUploadVerification uses a Siafile's UID to create a unique
+// AlertID for a failed upload verification.
+func AlertIDSiafileUploadVerification(uid string) AlertID {
+	return AlertID(fmt.Sprintf("%v%v", alertIDUploadVerificationPrefix, uid))
+}
+
+// AlertCategoryByID returns the category of the alert with the given id.
+func AlertCategoryByID(id AlertID) AlertCategory {
+	if category, ok := alertCategories[id]; ok {
+		return category
+	}
+	if strings.HasPrefix(string(id), alertIDLowRedundancyPrefix) || strings.HasPrefix(string(id), alertIDUploadVerificationPrefix) {
+		return AlertCategoryFiles
+	}
+	return AlertCategoryGeneral
 }
 
 type (
@@ -70,21 +178,50 @@ type (
 
 	// Alert is a type that contains essential information about an alert.
 	Alert struct {
+		// Acknowledged indicates whether the alert was acknowledged by the
+		// user.
+		Acknowledged bool `json:"acknowledged"`
+		// Category groups alerts by their subject.
+		Category AlertCategory `json:"category"`
 		// Cause is the cause for the Alert.
 		// e.g. "Wallet is locked"
 		Cause string `json:"cause"`
+		// ID is the unique id of the Alert.
+		ID AlertID `json:"id"`
 		// Msg is the message the Alert is meant to convey to the user.
 		// e.g. "Contractor can't form new contrats"
 		Msg string `json:"msg"`
 		// Module contains information about what module the alert originated from.
 		Module string `json:"module"`
+		// Muted indicates whether the alert was muted by the user. Muted
+		// alerts are not routed to any sinks.
+		Muted bool `json:"muted"`
 		// Severity categorizes the Alerts to allow for an easy way to filter them.
 		Severity AlertSeverity `json:"severity"`
 	}
 
+	// AlertCategory describes the subject of an alert.
+	AlertCategory string
+
 	// AlertID is a helper type for an Alert's ID.
 	AlertID string
 
+	// AlertRoute describes which alerts are routed to a sink.
+	AlertRoute struct {
+		// Categories limits the route to alerts of the given categories. An
+		// empty slice matches all categories.
+		Categories []AlertCategory `json:"categories"`
+		// Modules limits the route to alerts of the given modules, e.g.
+		// "host". An empty slice matches all modules.
+		Modules []string `json:"modules,omitempty"`
+		// MinSeverity is the lowest severity of alerts which are routed.
+		MinSeverity AlertSeverity `json:"minseverity"`
+		// Sink is the type of sink the alerts are routed to.
+		Sink string `json:"sink"`
+		// URL is the url of the webhook for the webhook sink.
+		URL string `json:"url,omitempty"`
+	}
+
 	// AlertSeverity describes the severity of an alert.
 	AlertSeverity uint64
 )
@@ -102,6 +239,52 @@ func (x Alert) EqualsWithErrorCause(y Alert, causeErr string) bool {
 	return firstCheck && causeCheck
 }
 
+// Matches returns true if the alert should be routed to the route's sink.
+func (ar AlertRoute) Matches(a Alert) bool {
+	if a.Muted || a.Severity < ar.MinSeverity {
+		return false
+	}
+	if len(ar.Modules) > 0 {
+		var matches bool
+		for _, module := range ar.Modules {
+			matches = matches || module == a.Module
+		}
+		if !matches {
+			return false
+		}
+	}
+	if len(ar.Categories) == 0 {
+		return true
+	}
+	for _, category := range ar.Categories {
+		if category == a.Category {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the route for errors.
+func (ar AlertRoute) Validate() error {
+	switch ar.Sink {
+	case AlertSinkLog:
+	case AlertSinkWebhook:
+		u, err := url.Parse(ar.URL)
+		if err != nil {
+			return fmt.Errorf("invalid webhook url: %v", err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("webhook url needs to use http or https, got '%v'", u.Scheme)
+		}
+	default:
+		return fmt.Errorf("unknown alert sink '%v'", ar.Sink)
+	}
+	if ar.MinSeverity < SeverityWarning || ar.MinSeverity > SeverityCritical {
+		return fmt.Errorf("invalid minimum severity %v", ar.MinSeverity)
+	}
+	return nil
+}
+
 // MarshalJSON defines a JSON encoding for the AlertSeverity.
 func (a AlertSeverity) MarshalJSON() ([]byte, error) {
 	switch a {
@@ -192,7 +375,9 @@ func (a *GenericAlerter) RegisterAlert(id AlertID, msg, cause string, severity A
 	a.mu.Lock()
 	defer a.mu.Unlock()
 	a.alerts[id] = Alert{
+		Category: AlertCategoryByID(id),
 		Cause:    cause,
+		ID:       id,
 		Module:   a.module,
 		Msg:      msg,
 		Severity: severity,
@@ -224,9 +409,10 @@ func PrintAlerts(alerts []Alert, as AlertSeverity) {
 		fmt.Printf(`
 ------------------
   Module:   %s
+  Category: %s
   Severity: %s
   Message:  %s
-  Cause:    %s`, a.Module, a.Severity.String(), a.Msg, a.Cause)
+  Cause:    %s`, a.Module, a.Category, a.Severity.String(), a.Msg, a.Cause)
 	}
 	fmt.Printf("\n------------------\n\n")
 }
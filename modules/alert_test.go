@@ -96,3 +96,89 @@ func TestAlertsSorted(t *testing.T) {
 		}
 	}
 }
+
+// TestAlertCategoryByID is a unit test for AlertCategoryByID.
+func TestAlertCategoryByID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		id       AlertID
+		category AlertCategory
+	}{
+		{AlertIDGatewayOffline, AlertCategoryNetwork},
+		{AlertIDHostDiskTrouble, AlertCategoryStorage},
+		{AlertIDHostSectorCorruption, AlertCategoryStorage},
+		{AlertIDRenterAllowanceLowFunds, AlertCategoryFunds},
+		{AlertIDRenterContractRenewalError, AlertCategoryContracts},
+		{AlertIDSiafileLowRedundancy("uid"), AlertCategoryFiles},
+		{AlertID("unknown"), AlertCategoryGeneral},
+	}
+	for _, test := range tests {
+		if category := AlertCategoryByID(test.id); category != test.category {
+			t.Errorf("%v: expected %v but got %v", test.id, test.category, category)
+		}
+	}
+}
+
+// TestAlertRoute is a unit test for matching and validating AlertRoutes.
+func TestAlertRoute(t *testing.T) {
+	t.Parallel()
+
+	// Check matching.
+	route := AlertRoute{
+		Categories:  []AlertCategory{AlertCategoryFunds},
+		MinSeverity: SeverityError,
+		Sink:        AlertSinkLog,
+	}
+	alert := Alert{Category: AlertCategoryFunds, Severity: SeverityCritical}
+	if !route.Matches(alert) {
+		t.Fatal("alert should match")
+	}
+	alert.Severity = SeverityWarning
+	if route.Matches(alert) {
+		t.Fatal("alert with lower severity shouldn't match")
+	}
+	alert.Severity = SeverityError
+	alert.Category = AlertCategoryStorage
+	if route.Matches(alert) {
+		t.Fatal("alert with different category shouldn't match")
+	}
+	route.Categories = nil
+	if !route.Matches(alert) {
+		t.Fatal("route without categories should match all categories")
+	}
+	alert.Module = "host"
+	route.Modules = []string{"renter"}
+	if route.Matches(alert) {
+		t.Fatal("alert of different module shouldn't match")
+	}
+	route.Modules = append(route.Modules, "host")
+	if !route.Matches(alert) {
+		t.Fatal("alert should match")
+	}
+	alert.Muted = true
+	if route.Matches(alert) {
+		t.Fatal("muted alert shouldn't match")
+	}
+
+	// Check validation.
+	tests := []struct {
+		route AlertRoute
+		valid bool
+	}{
+		{AlertRoute{MinSeverity: SeverityWarning, Sink: AlertSinkLog}, true},
+		{AlertRoute{MinSeverity: SeverityCritical, Sink: AlertSinkWebhook, URL: "https://example.com/hook"}, true},
+		{AlertRoute{MinSeverity: SeverityWarning, Sink: AlertSinkWebhook, URL: "ftp://example.com"}, false},
+		{AlertRoute{MinSeverity: SeverityWarning, Sink: AlertSinkWebhook}, false},
+		{AlertRoute{MinSeverity: SeverityWarning, Sink: "email"}, false},
+		{AlertRoute{MinSeverity: SeverityUnknown, Sink: AlertSinkLog}, false},
+	}
+	for i, test := range tests {
+		err := test.route.Validate()
+		if test.valid && err != nil {
+			t.Errorf("%v: expected route to be valid: %v", i, err)
+		} else if !test.valid && err == nil {
+			t.Errorf("%v: expected route to be invalid", i)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package modules
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+)
+
+// The following consts are the scopes an API token can carry. The API
+// password grants access to all calls.
+const (
+	// APITokenScopeRead grants access to password protected calls which only
+	// read information, e.g. the wallet's unspent outputs.
+	APITokenScopeRead APITokenScope = "read"
+	// APITokenScopeWalletSpend grants access to the calls which create
+	// addresses and spend or sign with the wallet's funds.
+	APITokenScopeWalletSpend APITokenScope = "wallet-spend"
+	// APITokenScopeHostAdmin grants access to all calls which manage the
+	// host.
+	APITokenScopeHostAdmin APITokenScope = "host-admin"
+	// APITokenScopeRenterWrite grants access to all calls which manage the
+	// renter and its files.
+	APITokenScopeRenterWrite APITokenScope = "renter-write"
+)
+
+const (
+	// apiTokenIDSize is the number of random bytes of an API token's id.
+	apiTokenIDSize = 8
+	// apiTokenSecretSize is the number of random bytes of an API token's
+	// secret.
+	apiTokenSecretSize = 32
+	// apiTokenNameMaxLen is the maximum length of an API token's name.
+	apiTokenNameMaxLen = 64
+)
+
+var (
+	// ErrAPITokenNotFound is returned when trying to revoke an unknown token.
+	ErrAPITokenNotFound = errors.New("API token not found")
+
+	// apiTokenScopes contains all known scopes.
+	apiTokenScopes = map[APITokenScope]struct{}{
+		APITokenScopeRead:        {},
+		APITokenScopeWalletSpend: {},
+		APITokenScopeHostAdmin:   {},
+		APITokenScopeRenterWrite: {},
+	}
+)
+
+type (
+	// APIToken is a token which grants access to the API calls covered by
+	// its scopes. Only the hash of the token's secret is persisted, so the
+	// secret is only known when the token is created.
+	APIToken struct {
+		// ID identifies the token, e.g. to revoke it.
+		ID string `json:"id"`
+		// Name is a description of the token chosen by the user.
+		Name string `json:"name"`
+		// Scopes are the scopes the token carries.
+		Scopes []APITokenScope `json:"scopes"`
+		// CreatedAt is the time the token was created at.
+		CreatedAt time.Time `json:"createdat"`
+		// SecretHash is the hash of the token's secret.
+		SecretHash crypto.Hash `json:"secrethash"`
+	}
+
+	// APITokenScope describes a set of API calls an APIToken grants access
+	// to.
+	APITokenScope string
+)
+
+// ParseAPITokenScopes parses a comma separated list of scopes.
+func ParseAPITokenScopes(s string) ([]APITokenScope, error) {
+	var scopes []APITokenScope
+	for _, scope := range strings.Split(s, ",") {
+		scope = strings.TrimSpace(scope)
+		if scope == "" {
+			continue
+		}
+		scopes = append(scopes, APITokenScope(scope))
+	}
+	if err := validateAPITokenScopes(scopes); err != nil {
+		return nil, err
+	}
+	return scopes, nil
+}
+
+// validateAPITokenScopes checks that at least one scope is provided and that
+// all scopes are known.
+func validateAPITokenScopes(scopes []APITokenScope) error {
+	if len(scopes) == 0 {
+		return errors.New("at least one scope needs to be provided")
+	}
+	for _, scope := range scopes {
+		if _, exists := apiTokenScopes[scope]; !exists {
+			return fmt.Errorf("unknown API token scope '%v'", scope)
+		}
+	}
+	return nil
+}
+
+// HasScope returns true if the token carries one of the provided scopes.
+func (t APIToken) HasScope(scopes ...APITokenScope) bool {
+	for _, s := range t.Scopes {
+		for _, scope := range scopes {
+			if s == scope {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// newAPIToken creates a new token and returns it together with its secret.
+func newAPIToken(name string, scopes []APITokenScope) (APIToken, string, error) {
+	if name == "" || len(name) > apiTokenNameMaxLen {
+		return APIToken{}, "", fmt.Errorf("token name needs to be between 1 and %v characters long", apiTokenNameMaxLen)
+	}
+	if err := validateAPITokenScopes(scopes); err != nil {
+		return APIToken{}, "", err
+	}
+	secret := hex.EncodeToString(fastrand.Bytes(apiTokenSecretSize))
+	return APIToken{
+		ID:         hex.EncodeToString(fastrand.Bytes(apiTokenIDSize)),
+		Name:       name,
+		Scopes:     append([]APITokenScope{}, scopes...),
+		CreatedAt:  time.Now(),
+		SecretHash: crypto.HashBytes([]byte(secret)),
+	}, secret, nil
+}
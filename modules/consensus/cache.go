@@ -0,0 +1,136 @@
+package consensus
+
+import (
+	"sync"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// cacheRecentBlocksDepth is the number of blocks below the current block
+	// for which BlockAtHeight is served from the cache. Blocks can be large,
+	// so this is kept small. It covers the lookups of the most recent blocks
+	// that modules like the transaction pool perform after every block.
+	cacheRecentBlocksDepth = 6
+
+	// cacheMaxSiacoinOutputs is the maximum number of siacoin output
+	// existence checks the cache remembers before it is reset.
+	cacheMaxSiacoinOutputs = 1e5
+)
+
+// consensusCache is a read-through cache for hot consensus lookups. It avoids
+// opening a bolt transaction for queries that are answered over and over
+// again between two blocks, such as the current block, the most recent blocks
+// and the existence of the siacoin outputs spent by transactions that are
+// submitted to the transaction pool.
+//
+// The cache only ever reflects the committed state of the database. It is
+// reset every time blocks are applied or reverted, while the consensus set is
+// still locked.
+type consensusCache struct {
+	currentBlock   *types.Block
+	height         types.BlockHeight
+	recentBlocks   map[types.BlockHeight]types.Block
+	siacoinOutputs map[types.SiacoinOutputID]bool
+	mu             sync.Mutex
+}
+
+// newConsensusCache creates a new, empty cache.
+func newConsensusCache() *consensusCache {
+	return &consensusCache{
+		recentBlocks:   make(map[types.BlockHeight]types.Block),
+		siacoinOutputs: make(map[types.SiacoinOutputID]bool),
+	}
+}
+
+// loadCurrentBlock fetches the current block and height from the database if
+// they are not cached yet.
+func (c *consensusCache) loadCurrentBlock(db *persist.BoltDatabase) {
+	if c.currentBlock != nil {
+		return
+	}
+	_ = db.View(func(tx *bolt.Tx) error {
+		pb := currentProcessedBlock(tx)
+		c.currentBlock = &pb.Block
+		c.height = pb.Height
+		return nil
+	})
+}
+
+// managedBlockAtHeight returns the block at the given height. Blocks close to
+// the current block are cached.
+func (c *consensusCache) managedBlockAtHeight(db *persist.BoltDatabase, height types.BlockHeight) (block types.Block, exists bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if block, exists := c.recentBlocks[height]; exists {
+		return block, true
+	}
+	_ = db.View(func(tx *bolt.Tx) error {
+		id, err := getPath(tx, height)
+		if err != nil {
+			return err
+		}
+		pb, err := getBlockMap(tx, id)
+		if err != nil {
+			return err
+		}
+		block = pb.Block
+		exists = true
+		return nil
+	})
+	c.loadCurrentBlock(db)
+	if exists && height <= c.height && c.height-height < cacheRecentBlocksDepth {
+		c.recentBlocks[height] = block
+	}
+	return block, exists
+}
+
+// managedCurrentBlock returns the latest block in the heaviest known
+// blockchain.
+func (c *consensusCache) managedCurrentBlock(db *persist.BoltDatabase) types.Block {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loadCurrentBlock(db)
+	return *c.currentBlock
+}
+
+// managedHeight returns the height of the current block.
+func (c *consensusCache) managedHeight(db *persist.BoltDatabase) types.BlockHeight {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loadCurrentBlock(db)
+	return c.height
+}
+
+// managedSiacoinOutputExists returns whether the siacoin output with the
+// given id exists in the database.
+func (c *consensusCache) managedSiacoinOutputExists(db *persist.BoltDatabase, id types.SiacoinOutputID) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if exists, cached := c.siacoinOutputs[id]; cached {
+		return exists
+	}
+	var exists bool
+	_ = db.View(func(tx *bolt.Tx) error {
+		exists = isSiacoinOutput(tx, id)
+		return nil
+	})
+	if len(c.siacoinOutputs) >= cacheMaxSiacoinOutputs {
+		c.siacoinOutputs = make(map[types.SiacoinOutputID]bool)
+	}
+	c.siacoinOutputs[id] = exists
+	return exists
+}
+
+// managedReset clears the cache. It needs to be called every time the
+// database is updated.
+func (c *consensusCache) managedReset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.currentBlock = nil
+	c.height = 0
+	c.recentBlocks = make(map[types.BlockHeight]types.Block)
+	c.siacoinOutputs = make(map[types.SiacoinOutputID]bool)
+}
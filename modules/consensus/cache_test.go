@@ -0,0 +1,131 @@
+package consensus
+
+import (
+	"testing"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestConsensusCache checks that the cache serves the same data as the
+// database and that it is reset when a block is applied.
+func TestConsensusCache(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := blankConsensusSetTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	cs := cst.cs
+
+	// Mine a few blocks.
+	for i := 0; i < 3; i++ {
+		_, err := cst.miner.AddBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The current block should be cached.
+	height := cs.Height()
+	current := cs.CurrentBlock()
+	if height != 3 {
+		t.Fatal("wrong height", height)
+	}
+	if cs.staticCache.currentBlock == nil || cs.staticCache.currentBlock.ID() != current.ID() {
+		t.Fatal("current block wasn't cached")
+	}
+
+	// Recent blocks should be cached, the genesis block shouldn't.
+	b, exists := cs.BlockAtHeight(height)
+	if !exists || b.ID() != current.ID() {
+		t.Fatal("wrong block at current height")
+	}
+	if _, cached := cs.staticCache.recentBlocks[height]; !cached {
+		t.Fatal("recent block wasn't cached")
+	}
+	b, exists = cs.BlockAtHeight(0)
+	if !exists || b.ID() != types.GenesisID {
+		t.Fatal("wrong genesis block")
+	}
+	if _, cached := cs.staticCache.recentBlocks[0]; cached && height >= cacheRecentBlocksDepth {
+		t.Fatal("old block shouldn't be cached")
+	}
+	if _, exists = cs.BlockAtHeight(height + 1); exists {
+		t.Fatal("block above the current height shouldn't exist")
+	}
+
+	// Check the existence of a miner payout and a random output.
+	payoutID := current.MinerPayoutID(0)
+	var randomID types.SiacoinOutputID
+	randomID[0] = 1
+	if cs.staticCache.managedSiacoinOutputExists(cs.db, payoutID) {
+		t.Fatal("immature payout shouldn't exist yet")
+	}
+	if cs.staticCache.managedSiacoinOutputExists(cs.db, randomID) {
+		t.Fatal("random output shouldn't exist")
+	}
+	if len(cs.staticCache.siacoinOutputs) != 2 {
+		t.Fatal("outputs weren't cached", len(cs.staticCache.siacoinOutputs))
+	}
+
+	// Mining a block should reset the cache.
+	_, err = cst.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cs.staticCache.currentBlock != nil || len(cs.staticCache.recentBlocks) != 0 || len(cs.staticCache.siacoinOutputs) != 0 {
+		t.Fatal("cache wasn't reset")
+	}
+	if cs.Height() != height+1 {
+		t.Fatal("wrong height after mining a block", cs.Height())
+	}
+}
+
+// TestCheckSiacoinOutputsExist checks that transaction sets which spend
+// missing siacoin outputs are rejected early while outputs created within the
+// set are allowed.
+func TestCheckSiacoinOutputsExist(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cst, err := blankConsensusSetTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cst.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// A transaction spending a random output is rejected.
+	parent := types.Transaction{
+		SiacoinInputs:  []types.SiacoinInput{{ParentID: types.SiacoinOutputID{1}}},
+		SiacoinOutputs: []types.SiacoinOutput{{Value: types.NewCurrency64(1)}},
+	}
+	_, err = cst.cs.TryTransactionSet([]types.Transaction{parent})
+	if err != errMissingSiacoinOutput {
+		t.Fatal("expected errMissingSiacoinOutput but got", err)
+	}
+
+	// A transaction spending an output of its parent passes the check.
+	parent.SiacoinInputs = nil
+	child := types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{{ParentID: parent.SiacoinOutputID(0)}},
+	}
+	if err := cst.cs.checkSiacoinOutputsExist([]types.Transaction{child}); err != errMissingSiacoinOutput {
+		t.Fatal("expected errMissingSiacoinOutput but got", err)
+	}
+	if err := cst.cs.checkSiacoinOutputsExist([]types.Transaction{parent, child}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -8,6 +8,7 @@ package consensus
 
 import (
 	"errors"
+	"sync"
 
 	"gitlab.com/NebulousLabs/bolt"
 	"gitlab.com/NebulousLabs/demotemutex"
@@ -86,6 +87,16 @@ type ConsensusSet struct {
 	blockRuleHelper blockRuleHelper
 	blockValidator  blockValidator
 
+	// quiesceMu is held as a readlock while blocks are accepted and as a
+	// writelock while the consensus set is quiesced. Blocking the acceptance
+	// of blocks before acquiring mu allows for reading the consensus set
+	// while it is quiesced.
+	quiesceMu sync.RWMutex
+
+	// staticCache caches hot lookups which would otherwise require a
+	// transaction on the database.
+	staticCache *consensusCache
+
 	// Utilities
 	db         *persist.BoltDatabase
 	staticDeps modules.Dependencies
@@ -119,8 +130,9 @@ func consensusSetBlockingStartup(gateway modules.Gateway, persistDir string, dep
 		blockRuleHelper: stdBlockRuleHelper{},
 		blockValidator:  NewBlockValidator(),
 
-		staticDeps: deps,
-		persistDir: persistDir,
+		staticCache: newConsensusCache(),
+		staticDeps:  deps,
+		persistDir:  persistDir,
 	}
 	// Create the diffs for the genesis transaction outputs
 	for _, transaction := range types.GenesisBlock.Transactions {
@@ -231,20 +243,7 @@ func NewCustomConsensusSet(gateway modules.Gateway, bootstrap bool, persistDir s
 
 // BlockAtHeight returns the block at a given height.
 func (cs *ConsensusSet) BlockAtHeight(height types.BlockHeight) (block types.Block, exists bool) {
-	_ = cs.db.View(func(tx *bolt.Tx) error {
-		id, err := getPath(tx, height)
-		if err != nil {
-			return err
-		}
-		pb, err := getBlockMap(tx, id)
-		if err != nil {
-			return err
-		}
-		block = pb.Block
-		exists = true
-		return nil
-	})
-	return block, exists
+	return cs.staticCache.managedBlockAtHeight(cs.db, height)
 }
 
 // BlockByID returns the block for a given BlockID.
@@ -292,13 +291,7 @@ func (cs *ConsensusSet) Close() error {
 func (cs *ConsensusSet) managedCurrentBlock() (block types.Block) {
 	cs.mu.RLock()
 	defer cs.mu.RUnlock()
-
-	_ = cs.db.View(func(tx *bolt.Tx) error {
-		pb := currentProcessedBlock(tx)
-		block = pb.Block
-		return nil
-	})
-	return block
+	return cs.staticCache.managedCurrentBlock(cs.db)
 }
 
 // CurrentBlock returns the latest block in the heaviest known blockchain.
@@ -315,13 +308,7 @@ func (cs *ConsensusSet) CurrentBlock() (block types.Block) {
 	// there are no race conditions when trying to synchronize nodes.
 	cs.mu.Lock()
 	defer cs.mu.Unlock()
-
-	_ = cs.db.View(func(tx *bolt.Tx) error {
-		pb := currentProcessedBlock(tx)
-		block = pb.Block
-		return nil
-	})
-	return block
+	return cs.staticCache.managedCurrentBlock(cs.db)
 }
 
 // Height returns the height of the consensus set.
@@ -338,12 +325,7 @@ func (cs *ConsensusSet) Height() (height types.BlockHeight) {
 	// there are no race conditions when trying to synchronize nodes.
 	cs.mu.Lock()
 	defer cs.mu.Unlock()
-
-	_ = cs.db.View(func(tx *bolt.Tx) error {
-		height = blockHeight(tx)
-		return nil
-	})
-	return height
+	return cs.staticCache.managedHeight(cs.db)
 }
 
 // InCurrentPath returns true if the block presented is in the current path,
@@ -235,11 +235,21 @@ func generateAndApplyDiff(tx *bolt.Tx, pb *processedBlock) error {
 	// applied.
 	createDSCOBucket(tx, pb.Height+types.MaturityDelay)
 
+	// Check the parts of the transactions that don't depend on the consensus
+	// state, such as the signatures, in parallel.
+	currentHeight := blockHeight(tx)
+	standaloneErrs := standaloneValidTransactions(pb.Block.Transactions, currentHeight)
+
 	// Validate and apply each transaction in the block. They cannot be
 	// validated all at once because some transactions may not be valid until
-	// previous transactions have been applied.
-	for _, txn := range pb.Block.Transactions {
-		err := validTransaction(tx, txn)
+	// previous transactions have been applied. The transactions are processed
+	// in order to make sure the error of the first invalid transaction is
+	// returned.
+	for i, txn := range pb.Block.Transactions {
+		if err := standaloneErrs[i]; err != nil {
+			return err
+		}
+		err := validTransactionState(tx, txn, currentHeight)
 		if err != nil {
 			return err
 		}
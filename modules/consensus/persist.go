@@ -149,3 +149,19 @@ func (cs *ConsensusSet) initPersist() error {
 	}
 	return nil
 }
+
+// Quiesce implements the modules.Quiescer interface. It waits for blocks which
+// are currently being accepted to be applied and blocks the acceptance of new
+// blocks until resume is called.
+func (cs *ConsensusSet) Quiesce() (resume func(), err error) {
+	if err := cs.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer cs.tg.Done()
+	cs.quiesceMu.Lock()
+	resumeDB := cs.db.Quiesce()
+	return func() {
+		resumeDB()
+		cs.quiesceMu.Unlock()
+	}, nil
+}
@@ -132,6 +132,42 @@ func blockHistory(tx *bolt.Tx) (blockIDs [32]types.BlockID) {
 	return blockIDs
 }
 
+// isInvalidBlockErr returns true if err indicates that a block or header is
+// invalid. Errors of valid blocks which can't be added to the consensus set
+// yet, e.g. orphans, don't count.
+func isInvalidBlockErr(err error) bool {
+	return errors.Contains(err, errDoSBlock) ||
+		errors.Contains(err, errNonLinearChain) ||
+		errors.Contains(err, modules.ErrBlockUnsolved) ||
+		errors.Contains(err, ErrBadMinerPayouts) ||
+		errors.Contains(err, ErrEarlyTimestamp) ||
+		errors.Contains(err, ErrExtremeFutureTimestamp) ||
+		errors.Contains(err, ErrLargeBlock)
+}
+
+// managedReportInvalidBlocks reports a peer to the gateway if err indicates
+// that the blocks it sent are invalid. Blocks with invalid transactions are
+// detected by checking whether they were marked as DoS blocks.
+func (cs *ConsensusSet) managedReportInvalidBlocks(addr modules.NetAddress, blocks []types.Block, err error) {
+	if err == nil {
+		return
+	}
+	invalid := isInvalidBlockErr(err)
+	if !invalid {
+		cs.mu.RLock()
+		for _, b := range blocks {
+			if _, dos := cs.dosBlocks[b.ID()]; dos {
+				invalid = true
+				break
+			}
+		}
+		cs.mu.RUnlock()
+	}
+	if invalid {
+		cs.gateway.ReportMisbehavior(addr, err)
+	}
+}
+
 // managedReceiveBlocks is the calling end of the SendBlocks RPC, without the
 // threadgroup wrapping.
 func (cs *ConsensusSet) managedReceiveBlocks(conn modules.PeerConn) (returnErr error) {
@@ -226,6 +262,7 @@ func (cs *ConsensusSet) managedReceiveBlocks(conn modules.PeerConn) (returnErr e
 		if extended {
 			chainExtended = true
 		}
+		cs.managedReportInvalidBlocks(conn.RPCAddr(), newBlocks, acceptErr)
 		// ErrNonExtendingBlock must be ignored until headers-first block
 		// sharing is implemented, block already in database should also be
 		// ignored.
@@ -426,6 +463,13 @@ func (cs *ConsensusSet) threadedRPCRelayHeader(conn modules.PeerConn) error {
 		return cs.validateHeader(boltTxWrapper{tx}, h)
 	})
 	cs.mu.RUnlock()
+	// Report the relay to the gateway to score the peer. Known and orphan
+	// headers are still relays of valid headers.
+	if err == nil || errors.Contains(err, errOrphan) || errors.Contains(err, modules.ErrBlockKnown) {
+		cs.gateway.ReportRelay(conn.RPCAddr(), crypto.Hash(h.ID()))
+	} else if isInvalidBlockErr(err) {
+		cs.gateway.ReportMisbehavior(conn.RPCAddr(), err)
+	}
 	// WARN: orphan multithreading logic (dangerous areas, see below)
 	//
 	// If the header is valid and extends the heaviest chain, fetch the
@@ -530,6 +574,7 @@ func (cs *ConsensusSet) managedReceiveBlock(id types.BlockID) modules.RPCFunc {
 		if chainExtended {
 			cs.managedBroadcastBlock(block)
 		}
+		cs.managedReportInvalidBlocks(conn.RPCAddr(), []types.Block{block}, err)
 		if err != nil {
 			return err
 		}
@@ -3,6 +3,9 @@ package consensus
 import (
 	"bytes"
 	"math/big"
+	"runtime"
+	"sync"
+	"sync/atomic"
 
 	"gitlab.com/NebulousLabs/bolt"
 	"gitlab.com/NebulousLabs/errors"
@@ -332,10 +335,15 @@ func validTransaction(tx *bolt.Tx, t types.Transaction) error {
 	if err != nil {
 		return err
 	}
+	return validTransactionState(tx, t, currentHeight)
+}
 
+// validTransactionState checks that all fields of a transaction, which already
+// passed StandaloneValid, are valid within the current consensus state.
+func validTransactionState(tx *bolt.Tx, t types.Transaction, currentHeight types.BlockHeight) error {
 	// Check that each portion of the transaction is legal given the current
 	// consensus set.
-	err = validSiacoins(tx, t)
+	err := validSiacoins(tx, t)
 	if err != nil {
 		return err
 	}
@@ -358,6 +366,61 @@ func validTransaction(tx *bolt.Tx, t types.Transaction) error {
 	return nil
 }
 
+// standaloneValidTransactions runs StandaloneValid on all transactions in
+// parallel. Checking the signatures is the most expensive part of validating
+// a block and doesn't depend on the consensus state, so the work is spread
+// across a pool of GOMAXPROCS workers. The returned slice contains the error
+// of every transaction at the transaction's index, which allows the caller to
+// report the same error that sequential validation would report.
+func standaloneValidTransactions(txns []types.Transaction, currentHeight types.BlockHeight) []error {
+	errs := make([]error, len(txns))
+	numWorkers := runtime.GOMAXPROCS(0)
+	if numWorkers > len(txns) {
+		numWorkers = len(txns)
+	}
+	var next uint64
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				j := atomic.AddUint64(&next, 1) - 1
+				if j >= uint64(len(txns)) {
+					return
+				}
+				errs[j] = txns[j].StandaloneValid(currentHeight)
+			}
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+// checkSiacoinOutputsExist checks that the siacoin outputs spent by a
+// transaction set either exist in the consensus set or are created by the set
+// itself. The check uses the cache, so it is cheap to repeat for transaction
+// sets that are submitted to the transaction pool multiple times.
+func (cs *ConsensusSet) checkSiacoinOutputsExist(txns []types.Transaction) error {
+	created := make(map[types.SiacoinOutputID]struct{})
+	for _, txn := range txns {
+		for i := range txn.SiacoinOutputs {
+			created[txn.SiacoinOutputID(uint64(i))] = struct{}{}
+		}
+	}
+	for _, txn := range txns {
+		for _, sci := range txn.SiacoinInputs {
+			if _, exists := created[sci.ParentID]; exists {
+				continue
+			}
+			if !cs.staticCache.managedSiacoinOutputExists(cs.db, sci.ParentID) {
+				return errMissingSiacoinOutput
+			}
+		}
+	}
+	return nil
+}
+
 // tryTransactionSet applies the input transactions to the consensus set to
 // determine if they are valid. An error is returned IFF they are not a valid
 // set in the current consensus set. The size of the transactions and the set
@@ -370,13 +433,21 @@ func (cs *ConsensusSet) tryTransactionSet(txns []types.Transaction) (modules.Con
 	// consensus set get reverted.
 	diffHolder := new(processedBlock)
 
+	// Reject sets which spend siacoin outputs that don't exist before
+	// acquiring the database's write lock. Outputs created within the set are
+	// skipped since they only exist after applying the set.
+	err := cs.checkSiacoinOutputsExist(txns)
+	if err != nil {
+		return modules.ConsensusChange{}, err
+	}
+
 	// Boltdb will only roll back a tx if an error is returned. In the case of
 	// TryTransactionSet, we want to roll back the tx even if there is no
 	// error. So errSuccess is returned. An alternate method would be to
 	// manually manage the tx instead of using 'Update', but that has safety
 	// concerns and is more difficult to implement correctly.
 	errSuccess := errors.New("success")
-	err := cs.db.Update(func(tx *bolt.Tx) error {
+	err = cs.db.Update(func(tx *bolt.Tx) error {
 		diffHolder.Height = blockHeight(tx)
 		for _, txn := range txns {
 			err := validTransaction(tx, txn)
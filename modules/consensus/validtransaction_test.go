@@ -968,3 +968,37 @@ func TestValidArbitraryData(t *testing.T) {
 		t.Error("expected errUnsignedFoundationUpdate, got", err)
 	}
 }
+
+// TestStandaloneValidTransactions checks that validating the transactions in
+// parallel returns the same errors as validating them sequentially.
+func TestStandaloneValidTransactions(t *testing.T) {
+	t.Parallel()
+
+	// Create a mix of valid and invalid transactions.
+	txns := make([]types.Transaction, 100)
+	for i := range txns {
+		switch fastrand.Intn(3) {
+		case 0:
+			// Frivolous signature.
+			txns[i].TransactionSignatures = []types.TransactionSignature{{ParentID: crypto.Hash{byte(i)}}}
+		case 1:
+			// Zero miner fee.
+			txns[i].MinerFees = []types.Currency{types.ZeroCurrency}
+		}
+	}
+	errs := standaloneValidTransactions(txns, 0)
+	if len(errs) != len(txns) {
+		t.Fatalf("expected %v errors but got %v", len(txns), len(errs))
+	}
+	for i, txn := range txns {
+		expected := txn.StandaloneValid(0)
+		if (expected == nil) != (errs[i] == nil) || (expected != nil && expected.Error() != errs[i].Error()) {
+			t.Fatalf("%v: expected error %v but got %v", i, expected, errs[i])
+		}
+	}
+
+	// No transactions should result in no errors.
+	if errs := standaloneValidTransactions(nil, 0); len(errs) != 0 {
+		t.Fatal("expected no errors", errs)
+	}
+}
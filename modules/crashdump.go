@@ -0,0 +1,303 @@
+package modules
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"runtime/pprof"
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+)
+
+const (
+	// CrashDumpDir is the name of the directory within the node's directory
+	// which contains the crash dump bundles.
+	CrashDumpDir = "crashdumps"
+
+	// AlertIDCrashDump is the id of the alert that is registered on startup
+	// if the daemon wrote a crash dump bundle before it crashed.
+	AlertIDCrashDump = "crash-dump"
+
+	// crashDumpLogTailSize is the number of bytes of every registered log
+	// which are copied into a crash dump bundle.
+	crashDumpLogTailSize = 1 << 18 // 256 KiB
+
+	// crashDumpTimeFormat is the format of the timestamp prefix of a bundle's
+	// directory name. It sorts lexicographically.
+	crashDumpTimeFormat = "20060102T150405.000000000Z"
+)
+
+var (
+	// crashDumpSettingsTimeout is the max amount of time spent on collecting
+	// the settings of a module. The panicking goroutine might hold a lock the
+	// settings require.
+	crashDumpSettingsTimeout = build.Select(build.Var{
+		Standard: 5 * time.Second,
+		Dev:      3 * time.Second,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// crashDumps is the process-wide crash dumper. It is disabled until
+	// EnableCrashDumps is called.
+	crashDumps = &crashDumper{
+		logs:     make(map[string]string),
+		settings: make(map[string]func() (interface{}, error)),
+		staticAlerter: &GenericAlerter{
+			alerts: make(map[AlertID]Alert),
+			module: "daemon",
+		},
+	}
+)
+
+type (
+	// crashDumper writes a diagnostic bundle to disk when a module goroutine
+	// panics. A bundle contains the panic and all goroutine stacks, the
+	// version of the daemon, the tails of the registered logs and the
+	// non-sensitive settings of the modules.
+	crashDumper struct {
+		dir      string
+		logs     map[string]string
+		settings map[string]func() (interface{}, error)
+
+		staticAlerter *GenericAlerter
+		mu            sync.Mutex
+	}
+
+	// CrashDumpVersion is the version information contained in a crash dump
+	// bundle.
+	CrashDumpVersion struct {
+		BinaryName  string `json:"binaryname"`
+		NodeVersion string `json:"nodeversion"`
+		GitRevision string `json:"gitrevision"`
+		BuildTime   string `json:"buildtime"`
+		Release     string `json:"release"`
+		GoVersion   string `json:"goversion"`
+		OS          string `json:"os"`
+		Arch        string `json:"arch"`
+		Module      string `json:"module"`
+		Time        string `json:"time"`
+	}
+)
+
+// EnableCrashDumps enables the crash dumps of the process. Bundles are written
+// to dir. If dir contains bundles of a previous run, an alert pointing to the
+// most recent one is registered.
+func EnableCrashDumps(dir string) error {
+	if err := os.MkdirAll(dir, DefaultDirPerm); err != nil {
+		return errors.AddContext(err, "failed to create crash dump dir")
+	}
+	crashDumps.mu.Lock()
+	crashDumps.dir = dir
+	crashDumps.mu.Unlock()
+
+	bundles, err := CrashDumpBundles(dir)
+	if err != nil {
+		return errors.AddContext(err, "failed to list crash dump bundles")
+	}
+	if len(bundles) == 0 {
+		crashDumps.staticAlerter.UnregisterAlert(AlertIDCrashDump)
+		return nil
+	}
+	latest := filepath.Join(dir, bundles[len(bundles)-1])
+	msg := fmt.Sprintf("siad crashed, please attach the diagnostic bundle in %v to a bug report at %v and remove it afterwards", latest, build.IssuesURL)
+	cause := fmt.Sprintf("%v crash dump bundle(s) found in %v", len(bundles), dir)
+	crashDumps.staticAlerter.RegisterAlert(AlertIDCrashDump, msg, cause, SeverityError)
+	return nil
+}
+
+// CrashDumpAlerter returns the alerter of the crash dumps.
+func CrashDumpAlerter() Alerter {
+	return crashDumps.staticAlerter
+}
+
+// CrashDumpBundles returns the names of the crash dump bundles in dir sorted
+// from oldest to newest.
+func CrashDumpBundles(dir string) ([]string, error) {
+	fis, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var bundles []string
+	for _, fi := range fis {
+		if fi.IsDir() {
+			bundles = append(bundles, fi.Name())
+		}
+	}
+	sort.Strings(bundles)
+	return bundles, nil
+}
+
+// RegisterCrashDumpLog registers a log file whose tail is added to crash dump
+// bundles under the provided name.
+func RegisterCrashDumpLog(name, path string) {
+	crashDumps.mu.Lock()
+	defer crashDumps.mu.Unlock()
+	crashDumps.logs[name] = path
+}
+
+// RegisterCrashDumpSettings registers a function which returns the settings of
+// a module to be added to crash dump bundles. The settings must not contain
+// sensitive information such as seeds, keys or passwords.
+func RegisterCrashDumpSettings(module string, settings func() (interface{}, error)) {
+	crashDumps.mu.Lock()
+	defer crashDumps.mu.Unlock()
+	crashDumps.settings[module] = settings
+}
+
+// RecoverPanic writes a crash dump bundle if the calling goroutine panics and
+// panics again afterwards. It needs to be deferred at the start of a module's
+// goroutine.
+//
+//	defer modules.RecoverPanic("renter")
+func RecoverPanic(module string) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if path, err := WriteCrashDump(module, r, debug.Stack()); err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to write crash dump bundle:", err)
+	} else if path != "" {
+		fmt.Fprintln(os.Stderr, "Wrote crash dump bundle to", path)
+	}
+	panic(r)
+}
+
+// WriteCrashDump writes a crash dump bundle for a panic of a module and
+// returns its path. If crash dumps are not enabled, no bundle is written and
+// an empty path is returned.
+func WriteCrashDump(module string, r interface{}, stack []byte) (string, error) {
+	crashDumps.mu.Lock()
+	defer crashDumps.mu.Unlock()
+	if crashDumps.dir == "" {
+		return "", nil
+	}
+	now := time.Now().UTC()
+	path := filepath.Join(crashDumps.dir, fmt.Sprintf("%v-%v", now.Format(crashDumpTimeFormat), module))
+	if err := os.MkdirAll(path, DefaultDirPerm); err != nil {
+		return "", errors.AddContext(err, "failed to create bundle dir")
+	}
+
+	// Write the panic first since it is the most important part of the
+	// bundle.
+	panicMsg := fmt.Sprintf("panic in module %v: %v\n\n%s", module, r, stack)
+	err := ioutil.WriteFile(filepath.Join(path, "panic.txt"), []byte(panicMsg), DefaultFilePerm)
+	err = errors.Compose(err, crashDumpWriteGoroutines(filepath.Join(path, "goroutines.txt")))
+	err = errors.Compose(err, crashDumpWriteJSON(filepath.Join(path, "version.json"), CrashDumpVersion{
+		BinaryName:  build.BinaryName,
+		NodeVersion: build.NodeVersion,
+		GitRevision: build.GitRevision,
+		BuildTime:   build.BuildTime,
+		Release:     build.Release,
+		GoVersion:   runtime.Version(),
+		OS:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+		Module:      module,
+		Time:        now.Format(time.RFC3339Nano),
+	}))
+	err = errors.Compose(err, crashDumpWriteJSON(filepath.Join(path, "settings.json"), crashDumps.collectSettings()))
+	for name, logPath := range crashDumps.logs {
+		dst := filepath.Join(path, "logs", name)
+		err = errors.Compose(err, crashDumpCopyTail(logPath, dst, crashDumpLogTailSize))
+	}
+	return path, err
+}
+
+// collectSettings returns the settings of all registered modules. Modules
+// whose settings can't be collected in time are reported as such.
+func (cd *crashDumper) collectSettings() map[string]interface{} {
+	settings := make(map[string]interface{})
+	for module, f := range cd.settings {
+		type result struct {
+			s   interface{}
+			err error
+		}
+		c := make(chan result, 1)
+		go func(f func() (interface{}, error)) {
+			s, err := f()
+			c <- result{s, err}
+		}(f)
+		select {
+		case res := <-c:
+			if res.err != nil {
+				settings[module] = "failed to get settings: " + res.err.Error()
+			} else {
+				settings[module] = res.s
+			}
+		case <-time.After(crashDumpSettingsTimeout):
+			settings[module] = "timed out getting settings"
+		}
+	}
+	return settings
+}
+
+// crashDumpWriteGoroutines writes the stacks of all goroutines to a file.
+func crashDumpWriteGoroutines(path string) (err error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, DefaultFilePerm)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, f.Close())
+	}()
+	return pprof.Lookup("goroutine").WriteTo(f, 2)
+}
+
+// crashDumpWriteJSON writes an object to a file as indented JSON.
+func crashDumpWriteJSON(path string, obj interface{}) error {
+	b, err := json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, DefaultFilePerm)
+}
+
+// crashDumpCopyTail copies up to the last n bytes of a file to dst. Partial
+// first lines are dropped. Missing files are ignored.
+func crashDumpCopyTail(src, dst string, n int64) (err error) {
+	f, err := os.Open(src)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, f.Close())
+	}()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	offset := fi.Size() - n
+	if offset < 0 {
+		offset = 0
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	tail, err := ioutil.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		if i := bytes.IndexByte(tail, '\n'); i >= 0 {
+			tail = tail[i+1:]
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), DefaultDirPerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(dst, tail, DefaultFilePerm)
+}
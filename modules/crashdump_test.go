@@ -0,0 +1,173 @@
+package modules
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.sia.tech/siad/build"
+)
+
+// TestCrashDump tests writing a crash dump bundle and the alert which is
+// registered for it on startup.
+func TestCrashDump(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	testDir := build.TempDir("modules", t.Name())
+	if err := os.RemoveAll(testDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(testDir, DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(testDir, CrashDumpDir)
+
+	// Without crash dumps being enabled, no bundle is written.
+	crashDumps.mu.Lock()
+	oldDir := crashDumps.dir
+	crashDumps.dir = ""
+	crashDumps.mu.Unlock()
+	defer func() {
+		crashDumps.mu.Lock()
+		crashDumps.dir = oldDir
+		crashDumps.mu.Unlock()
+	}()
+	path, err := WriteCrashDump("test", "boom", nil)
+	if err != nil || path != "" {
+		t.Fatal("bundle shouldn't be written", path, err)
+	}
+
+	// Enable the crash dumps. There are no bundles yet so there shouldn't
+	// be an alert.
+	if err := EnableCrashDumps(dir); err != nil {
+		t.Fatal(err)
+	}
+	if crit, errs, warn := CrashDumpAlerter().Alerts(); len(crit)+len(errs)+len(warn) != 0 {
+		t.Fatal("unexpected alerts", crit, errs, warn)
+	}
+
+	// Register a log which is larger than the tail and settings.
+	logPath := filepath.Join(testDir, "test.log")
+	line := strings.Repeat("a", 99) + "\n"
+	logData := strings.Repeat(line, crashDumpLogTailSize/len(line)+10) + "last line\n"
+	if err := ioutil.WriteFile(logPath, []byte(logData), DefaultFilePerm); err != nil {
+		t.Fatal(err)
+	}
+	RegisterCrashDumpLog("test.log", logPath)
+	RegisterCrashDumpLog("missing.log", filepath.Join(testDir, "missing.log"))
+	RegisterCrashDumpSettings("test", func() (interface{}, error) {
+		return map[string]int{"setting": 42}, nil
+	})
+	RegisterCrashDumpSettings("failing", func() (interface{}, error) {
+		return nil, errors.New("failed")
+	})
+	defer func() {
+		crashDumps.mu.Lock()
+		delete(crashDumps.logs, "test.log")
+		delete(crashDumps.logs, "missing.log")
+		delete(crashDumps.settings, "test")
+		delete(crashDumps.settings, "failing")
+		crashDumps.mu.Unlock()
+	}()
+
+	// Panic in a goroutine which recovers using RecoverPanic. The panic
+	// should be propagated after the bundle was written.
+	done := make(chan interface{})
+	go func() {
+		defer func() {
+			done <- recover()
+		}()
+		defer RecoverPanic("test")
+		panic("boom")
+	}()
+	if r := <-done; r != "boom" {
+		t.Fatal("panic wasn't propagated", r)
+	}
+
+	// Check the bundle.
+	bundles, err := CrashDumpBundles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bundles) != 1 || !strings.HasSuffix(bundles[0], "-test") {
+		t.Fatal("unexpected bundles", bundles)
+	}
+	path = filepath.Join(dir, bundles[0])
+	b, err := ioutil.ReadFile(filepath.Join(path, "panic.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "panic in module test: boom") || !strings.Contains(string(b), "TestCrashDump") {
+		t.Fatal("unexpected panic.txt", string(b))
+	}
+	b, err = ioutil.ReadFile(filepath.Join(path, "goroutines.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), "goroutine") {
+		t.Fatal("unexpected goroutines.txt", string(b))
+	}
+	var version CrashDumpVersion
+	b, err = ioutil.ReadFile(filepath.Join(path, "version.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(b, &version); err != nil {
+		t.Fatal(err)
+	}
+	if version.NodeVersion != build.NodeVersion || version.Module != "test" || version.GoVersion == "" {
+		t.Fatal("unexpected version", version)
+	}
+	var settings map[string]interface{}
+	b, err = ioutil.ReadFile(filepath.Join(path, "settings.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(b, &settings); err != nil {
+		t.Fatal(err)
+	}
+	if s, ok := settings["test"].(map[string]interface{}); !ok || s["setting"] != float64(42) {
+		t.Fatal("unexpected settings", settings)
+	}
+	if s, ok := settings["failing"].(string); !ok || !strings.Contains(s, "failed") {
+		t.Fatal("unexpected settings", settings)
+	}
+	b, err = ioutil.ReadFile(filepath.Join(path, "logs", "test.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) > crashDumpLogTailSize || !strings.HasPrefix(string(b), line) || !strings.HasSuffix(string(b), "last line\n") {
+		t.Fatal("unexpected log tail", len(b))
+	}
+	if _, err := os.Stat(filepath.Join(path, "logs", "missing.log")); !os.IsNotExist(err) {
+		t.Fatal("missing log shouldn't be in bundle", err)
+	}
+
+	// Enabling the crash dumps again, as on startup, should register an
+	// alert pointing to the bundle.
+	if err := EnableCrashDumps(dir); err != nil {
+		t.Fatal(err)
+	}
+	_, errs, _ := CrashDumpAlerter().Alerts()
+	if len(errs) != 1 || errs[0].ID != AlertIDCrashDump || !strings.Contains(errs[0].Msg, path) {
+		t.Fatal("unexpected alerts", errs)
+	}
+
+	// Once the bundle is removed, the alert is removed on startup.
+	if err := os.RemoveAll(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := EnableCrashDumps(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, errs, _ := CrashDumpAlerter().Alerts(); len(errs) != 0 {
+		t.Fatal("unexpected alerts", errs)
+	}
+}
@@ -2,10 +2,13 @@ package modules
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net"
 	"os"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -135,8 +138,44 @@ type (
 		pd *ProductionDependencies
 		*os.File
 	}
+
+	// throttledFile is a File whose I/O is throttled by the disk throttles of
+	// the volume which contains it.
+	throttledFile struct {
+		File
+	}
 )
 
+// Read reads from the file after waiting for the volume's disk throttles.
+func (tf throttledFile) Read(b []byte) (int, error) {
+	persist.GlobalDiskThrottles.ThrottleRead(tf.Name(), len(b))
+	return tf.File.Read(b)
+}
+
+// ReadAt reads from the file after waiting for the volume's disk throttles.
+func (tf throttledFile) ReadAt(b []byte, off int64) (int, error) {
+	persist.GlobalDiskThrottles.ThrottleRead(tf.Name(), len(b))
+	return tf.File.ReadAt(b, off)
+}
+
+// Sync syncs the file after waiting for the volume's disk throttles.
+func (tf throttledFile) Sync() error {
+	persist.GlobalDiskThrottles.ThrottleWrite(tf.Name(), 0)
+	return tf.File.Sync()
+}
+
+// Write writes to the file after waiting for the volume's disk throttles.
+func (tf throttledFile) Write(b []byte) (int, error) {
+	persist.GlobalDiskThrottles.ThrottleWrite(tf.Name(), len(b))
+	return tf.File.Write(b)
+}
+
+// WriteAt writes to the file after waiting for the volume's disk throttles.
+func (tf throttledFile) WriteAt(b []byte, off int64) (int, error) {
+	persist.GlobalDiskThrottles.ThrottleWrite(tf.Name(), len(b))
+	return tf.File.WriteAt(b, off)
+}
+
 // Close will close a file, checking whether the file handle is open somewhere
 // else before closing completely. This check is performed on Windows but not
 // Linux, therefore a mock is used to ensure that linux testing picks up
@@ -183,13 +222,12 @@ func (*ProductionDependencies) AtLeastOne() uint64 {
 // CreateFile gives the host the ability to create files on the operating
 // system.
 func (pd *ProductionDependencies) CreateFile(s string) (File, error) {
-	if !build.DEBUG {
-		return os.Create(s)
-	}
-
 	f, err := os.Create(s)
 	if err != nil {
-		return f, err
+		return nil, err
+	}
+	if !build.DEBUG {
+		return throttledFile{f}, nil
 	}
 
 	pd.mu.Lock()
@@ -199,10 +237,10 @@ func (pd *ProductionDependencies) CreateFile(s string) (File, error) {
 	v := pd.openFiles[s]
 	pd.openFiles[s] = v + 1
 	pd.mu.Unlock()
-	return &ProductionFile{
+	return throttledFile{&ProductionFile{
 		pd:   pd,
 		File: f,
-	}, nil
+	}}, nil
 }
 
 // Destruct checks that all resources have been cleaned up correctly.
@@ -222,7 +260,7 @@ func (pd *ProductionDependencies) Destruct() {
 // DialTimeout creates a tcp connection to a certain address with the specified
 // timeout.
 func (*ProductionDependencies) DialTimeout(addr NetAddress, timeout time.Duration) (net.Conn, error) {
-	return net.DialTimeout("tcp", string(addr), timeout)
+	return GlobalProxy.Dial(&net.Dialer{Timeout: timeout}, "tcp", string(addr))
 }
 
 // Disrupt can be used to inject specific behavior into a module by overwriting
@@ -278,13 +316,12 @@ func (pd *ProductionDependencies) Open(s string) (File, error) {
 
 // OpenFile opens a file with the specified mode and permissions.
 func (pd *ProductionDependencies) OpenFile(s string, i int, fm os.FileMode) (File, error) {
-	if !build.DEBUG {
-		return os.OpenFile(s, i, fm)
-	}
-
 	f, err := os.OpenFile(s, i, fm)
 	if err != nil {
-		return f, err
+		return nil, err
+	}
+	if !build.DEBUG {
+		return throttledFile{f}, nil
 	}
 
 	pd.mu.Lock()
@@ -294,10 +331,10 @@ func (pd *ProductionDependencies) OpenFile(s string, i int, fm os.FileMode) (Fil
 	v := pd.openFiles[s]
 	pd.openFiles[s] = v + 1
 	pd.mu.Unlock()
-	return &ProductionFile{
+	return throttledFile{&ProductionFile{
 		pd:   pd,
 		File: f,
-	}, nil
+	}}, nil
 }
 
 // RandRead fills the input bytes with random data.
@@ -371,6 +408,13 @@ type Resolver interface {
 	LookupIP(string) ([]net.IP, error)
 }
 
+// ASNResolver is an optional interface of a Resolver which allows looking up
+// the autonomous system an IP address belongs to and the country the address
+// is registered in.
+type ASNResolver interface {
+	LookupASN(net.IP) (asn uint32, country string, err error)
+}
+
 // ProductionResolver is the hostname resolver used in production builds.
 type ProductionResolver struct{}
 
@@ -385,6 +429,50 @@ func (ProductionResolver) LookupIP(host string) ([]net.IP, error) {
 	return net.LookupIP(host)
 }
 
+// LookupASN looks up the autonomous system number and the country code of the
+// IP using the DNS interface of the Team Cymru IP to ASN mapping. In testing
+// builds it returns 0 and an empty country which means that both are unknown.
+func (ProductionResolver) LookupASN(ip net.IP) (uint32, string, error) {
+	if build.Release == "testing" {
+		return 0, "", nil
+	}
+	var query string
+	if ip4 := ip.To4(); ip4 != nil {
+		query = fmt.Sprintf("%d.%d.%d.%d.origin.asn.cymru.com", ip4[3], ip4[2], ip4[1], ip4[0])
+	} else if ip6 := ip.To16(); ip6 != nil {
+		nibbles := make([]string, 0, 2*len(ip6))
+		for i := len(ip6) - 1; i >= 0; i-- {
+			nibbles = append(nibbles, fmt.Sprintf("%x.%x", ip6[i]&0xf, ip6[i]>>4))
+		}
+		query = strings.Join(nibbles, ".") + ".origin6.asn.cymru.com"
+	} else {
+		return 0, "", fmt.Errorf("invalid IP %v", ip)
+	}
+	records, err := net.LookupTXT(query)
+	if err != nil {
+		return 0, "", err
+	}
+	// The records have the format "ASN | prefix | country | registry | date".
+	// If the prefix is announced by multiple ASes, the first one is used.
+	for _, record := range records {
+		parts := strings.Split(record, "|")
+		fields := strings.Fields(parts[0])
+		if len(fields) == 0 {
+			continue
+		}
+		asn, err := strconv.ParseUint(fields[0], 10, 32)
+		if err != nil {
+			continue
+		}
+		var country string
+		if len(parts) > 2 {
+			country = strings.ToUpper(strings.TrimSpace(parts[2]))
+		}
+		return uint32(asn), country, nil
+	}
+	return 0, "", fmt.Errorf("no ASN found for %v", ip)
+}
+
 // Resolver returns the ProductionResolver.
 func (*ProductionDependencies) Resolver() Resolver {
 	return ProductionResolver{}
@@ -0,0 +1,334 @@
+package modules
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"go.sia.tech/siad/types"
+)
+
+// The following consts are the types of events which are published on the
+// EventBus.
+const (
+	// EventTypeAlertRegistered is published when a module registers a new
+	// alert.
+	EventTypeAlertRegistered EventType = "alert.registered"
+	// EventTypeAlertUnregistered is published when an alert is resolved.
+	EventTypeAlertUnregistered EventType = "alert.unregistered"
+	// EventTypeConsensusChange is published when blocks are applied to or
+	// reverted from the consensus set.
+	EventTypeConsensusChange EventType = "consensus.change"
+	// EventTypeContractFormed is published when the renter forms or renews a
+	// contract.
+	EventTypeContractFormed EventType = "contract.formed"
+	// EventTypeContractArchived is published when a contract of the renter
+	// expires or is replaced by a renewed contract.
+	EventTypeContractArchived EventType = "contract.archived"
+	// EventTypeHostCollateralLow is published when the host's remaining
+	// collateral budget drops below the collateral budget alert threshold.
+	EventTypeHostCollateralLow EventType = "host.collateral.low"
+	// EventTypeHostContractFormed is published when the host forms or renews
+	// a contract with a renter.
+	EventTypeHostContractFormed EventType = "host.contract.formed"
+	// EventTypeHostFolderError is published when reads or writes of one of
+	// the host's storage folders fail or its drain is paused by an error.
+	EventTypeHostFolderError EventType = "host.folder.error"
+	// EventTypeHostObligationFailed is published when a storage obligation of
+	// the host is resolved as failed or rejected.
+	EventTypeHostObligationFailed EventType = "host.obligation.failed"
+	// EventTypeHostStorageProofMissed is published when the host misses the
+	// storage proof of an obligation and loses its collateral.
+	EventTypeHostStorageProofMissed EventType = "host.storageproof.missed"
+	// EventTypeWalletTransactionUnconfirmed is published when a transaction
+	// related to the wallet enters the transaction pool.
+	EventTypeWalletTransactionUnconfirmed EventType = "wallet.transaction.unconfirmed"
+	// EventTypeWalletTransactionConfirmed is published when a transaction
+	// related to the wallet is confirmed.
+	EventTypeWalletTransactionConfirmed EventType = "wallet.transaction.confirmed"
+)
+
+// The following consts are the sinks events can be routed to. Events can also
+// be streamed using the WebSocket API.
+const (
+	// EventSinkLog routes events to the daemon's event log.
+	EventSinkLog = "log"
+	// EventSinkWebhook routes events to a webhook using a HTTP POST request.
+	EventSinkWebhook = "webhook"
+)
+
+// eventSubscriptionBufferSize is the number of events buffered for a
+// subscription. If a subscriber doesn't keep up, new events are dropped.
+const eventSubscriptionBufferSize = 1000
+
+// eventTypes contains all known event types.
+var eventTypes = map[EventType]struct{}{
+	EventTypeAlertRegistered:              {},
+	EventTypeAlertUnregistered:            {},
+	EventTypeConsensusChange:              {},
+	EventTypeContractFormed:               {},
+	EventTypeContractArchived:             {},
+	EventTypeHostCollateralLow:            {},
+	EventTypeHostContractFormed:           {},
+	EventTypeHostFolderError:              {},
+	EventTypeHostObligationFailed:         {},
+	EventTypeHostStorageProofMissed:       {},
+	EventTypeWalletTransactionUnconfirmed: {},
+	EventTypeWalletTransactionConfirmed:   {},
+}
+
+type (
+	// Event is a single event published on the EventBus.
+	Event struct {
+		// Type is the type of the event.
+		Type EventType `json:"type"`
+		// Module is the module the event originated from.
+		Module string `json:"module"`
+		// Timestamp is the time at which the event was published.
+		Timestamp time.Time `json:"timestamp"`
+		// Data contains the type specific payload of the event.
+		Data interface{} `json:"data"`
+	}
+
+	// EventType describes the type of an event.
+	EventType string
+
+	// EventConsensusChange is the data of an EventTypeConsensusChange event.
+	EventConsensusChange struct {
+		ID             ConsensusChangeID `json:"id"`
+		BlockHeight    types.BlockHeight `json:"blockheight"`
+		AppliedBlocks  []types.BlockID   `json:"appliedblocks"`
+		RevertedBlocks []types.BlockID   `json:"revertedblocks"`
+		Synced         bool              `json:"synced"`
+	}
+
+	// EventContract is the data of the contract events.
+	EventContract struct {
+		ID            types.FileContractID `json:"id"`
+		HostPublicKey types.SiaPublicKey   `json:"hostpublickey"`
+		StartHeight   types.BlockHeight    `json:"startheight"`
+		EndHeight     types.BlockHeight    `json:"endheight"`
+	}
+
+	// EventHostCollateral is the data of an EventTypeHostCollateralLow
+	// event.
+	EventHostCollateral struct {
+		Budget    types.Currency `json:"budget"`
+		Locked    types.Currency `json:"locked"`
+		Remaining types.Currency `json:"remaining"`
+		Threshold types.Currency `json:"threshold"`
+	}
+
+	// EventHostObligation is the data of the host's contract and obligation
+	// events.
+	EventHostObligation struct {
+		ID                types.FileContractID `json:"id"`
+		Status            string               `json:"status"`
+		DataSize          uint64               `json:"datasize"`
+		LockedCollateral  types.Currency       `json:"lockedcollateral"`
+		RiskedCollateral  types.Currency       `json:"riskedcollateral"`
+		NegotiationHeight types.BlockHeight    `json:"negotiationheight"`
+		ExpirationHeight  types.BlockHeight    `json:"expirationheight"`
+		ProofDeadline     types.BlockHeight    `json:"proofdeadline"`
+	}
+
+	// EventHostStorageFolder is the data of an EventTypeHostFolderError
+	// event.
+	EventHostStorageFolder struct {
+		Index        uint16 `json:"index"`
+		Path         string `json:"path"`
+		FailedReads  uint64 `json:"failedreads"`
+		FailedWrites uint64 `json:"failedwrites"`
+		DrainError   string `json:"drainerror,omitempty"`
+	}
+
+	// EventRoute describes which events are routed to a sink.
+	EventRoute struct {
+		// Types limits the route to events of the given types. An empty slice
+		// matches all types.
+		Types []EventType `json:"types"`
+		// Sink is the type of sink the events are routed to.
+		Sink string `json:"sink"`
+		// URL is the url of the webhook for the webhook sink.
+		URL string `json:"url,omitempty"`
+		// Secret is the optional secret the events routed to the webhook sink
+		// are signed with. See EventSignature.
+		Secret string `json:"secret,omitempty"`
+	}
+
+	// EventBus is a publish/subscribe bus which decouples the sources of
+	// events from the sinks they are routed to.
+	EventBus struct {
+		closed        bool
+		nextID        uint64
+		subscriptions map[uint64]*EventSubscription
+		mu            sync.Mutex
+	}
+
+	// EventSubscription is a subscription to events of the EventBus. Events
+	// are received on C until the subscription is closed.
+	EventSubscription struct {
+		// C is the channel the subscribed events are sent to.
+		C <-chan Event
+
+		c       chan Event
+		closed  bool
+		dropped uint64
+		types   []EventType
+
+		staticID  uint64
+		staticBus *EventBus
+	}
+)
+
+// ValidateEventTypes checks that all provided event types are known.
+func ValidateEventTypes(types []EventType) error {
+	for _, t := range types {
+		if _, exists := eventTypes[t]; !exists {
+			return fmt.Errorf("unknown event type '%v'", t)
+		}
+	}
+	return nil
+}
+
+// matchesEventType returns true if t is in types or types is empty.
+func matchesEventType(types []EventType, t EventType) bool {
+	if len(types) == 0 {
+		return true
+	}
+	for _, et := range types {
+		if et == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Matches returns true if the event should be routed to the route's sink.
+func (er EventRoute) Matches(e Event) bool {
+	return matchesEventType(er.Types, e.Type)
+}
+
+// Validate checks the route for errors.
+func (er EventRoute) Validate() error {
+	switch er.Sink {
+	case EventSinkLog:
+	case EventSinkWebhook:
+		u, err := url.Parse(er.URL)
+		if err != nil {
+			return fmt.Errorf("invalid webhook url: %v", err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return fmt.Errorf("webhook url needs to use http or https, got '%v'", u.Scheme)
+		}
+	default:
+		return fmt.Errorf("unknown event sink '%v'", er.Sink)
+	}
+	if er.Secret != "" && er.Sink != EventSinkWebhook {
+		return fmt.Errorf("only events routed to the %v sink can be signed", EventSinkWebhook)
+	}
+	return ValidateEventTypes(er.Types)
+}
+
+// EventSignature returns the signature of an event delivered to a webhook at
+// the given unix timestamp. It is the hex encoded HMAC-SHA256 of the
+// timestamp, a "." and the body of the request, keyed with the route's
+// secret. Including the timestamp allows webhooks to reject replayed
+// deliveries.
+func EventSignature(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = fmt.Fprintf(mac, "%d.", timestamp)
+	_, _ = mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewEventBus creates a new EventBus without any subscriptions.
+func NewEventBus() *EventBus {
+	return &EventBus{
+		subscriptions: make(map[uint64]*EventSubscription),
+	}
+}
+
+// Publish sends an event of the given type to all matching subscriptions.
+// Publish never blocks. Subscriptions which don't keep up miss the event.
+func (eb *EventBus) Publish(module string, t EventType, data interface{}) {
+	e := Event{
+		Type:      t,
+		Module:    module,
+		Timestamp: time.Now(),
+		Data:      data,
+	}
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+	for _, sub := range eb.subscriptions {
+		if !matchesEventType(sub.types, t) {
+			continue
+		}
+		select {
+		case sub.c <- e:
+		default:
+			sub.dropped++
+		}
+	}
+}
+
+// Subscribe creates a new subscription for events of the given types. If no
+// types are provided, the subscription receives all events. The channel of the
+// subscription is closed once the subscription or the bus is closed.
+func (eb *EventBus) Subscribe(types ...EventType) *EventSubscription {
+	c := make(chan Event, eventSubscriptionBufferSize)
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+	sub := &EventSubscription{
+		C:         c,
+		c:         c,
+		types:     append([]EventType(nil), types...),
+		staticID:  eb.nextID,
+		staticBus: eb,
+	}
+	eb.nextID++
+	if eb.closed {
+		sub.closed = true
+		close(sub.c)
+		return sub
+	}
+	eb.subscriptions[sub.staticID] = sub
+	return sub
+}
+
+// Close closes all subscriptions of the bus. Subscriptions created after
+// closing the bus are closed right away.
+func (eb *EventBus) Close() {
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+	eb.closed = true
+	for id, sub := range eb.subscriptions {
+		sub.closed = true
+		close(sub.c)
+		delete(eb.subscriptions, id)
+	}
+}
+
+// Close removes the subscription from the bus and closes its channel.
+func (es *EventSubscription) Close() {
+	es.staticBus.mu.Lock()
+	defer es.staticBus.mu.Unlock()
+	if es.closed {
+		return
+	}
+	es.closed = true
+	delete(es.staticBus.subscriptions, es.staticID)
+	close(es.c)
+}
+
+// Dropped returns the number of events the subscription missed because its
+// buffer was full.
+func (es *EventSubscription) Dropped() uint64 {
+	es.staticBus.mu.Lock()
+	defer es.staticBus.mu.Unlock()
+	return es.dropped
+}
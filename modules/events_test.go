@@ -0,0 +1,132 @@
+package modules
+
+import (
+	"testing"
+)
+
+// TestEventBus tests publishing events on the EventBus.
+func TestEventBus(t *testing.T) {
+	t.Parallel()
+
+	eb := NewEventBus()
+	all := eb.Subscribe()
+	alerts := eb.Subscribe(EventTypeAlertRegistered, EventTypeAlertUnregistered)
+
+	// Publish a consensus event. Only the subscription for all events should
+	// receive it.
+	eb.Publish("consensus", EventTypeConsensusChange, EventConsensusChange{BlockHeight: 1})
+	e := <-all.C
+	if e.Type != EventTypeConsensusChange || e.Module != "consensus" || e.Timestamp.IsZero() {
+		t.Fatal("unexpected event", e)
+	}
+	if cc, ok := e.Data.(EventConsensusChange); !ok || cc.BlockHeight != 1 {
+		t.Fatal("unexpected data", e.Data)
+	}
+	select {
+	case e := <-alerts.C:
+		t.Fatal("subscription shouldn't receive event", e)
+	default:
+	}
+
+	// Publish an alert event. Both subscriptions should receive it.
+	eb.Publish("renter", EventTypeAlertRegistered, Alert{})
+	if e := <-all.C; e.Type != EventTypeAlertRegistered {
+		t.Fatal("unexpected event", e)
+	}
+	if e := <-alerts.C; e.Type != EventTypeAlertRegistered {
+		t.Fatal("unexpected event", e)
+	}
+
+	// Fill the buffer of the subscription. Events beyond the buffer should be
+	// dropped without blocking the publisher.
+	for i := 0; i < eventSubscriptionBufferSize+10; i++ {
+		eb.Publish("renter", EventTypeAlertRegistered, Alert{})
+	}
+	if all.Dropped() != 10 || alerts.Dropped() != 10 {
+		t.Fatal("wrong number of dropped events", all.Dropped(), alerts.Dropped())
+	}
+
+	// Closing a subscription should close its channel and remove it from the
+	// bus. Closing it twice is fine.
+	alerts.Close()
+	alerts.Close()
+	for range alerts.C {
+	}
+	if len(eb.subscriptions) != 1 {
+		t.Fatal("subscription wasn't removed", len(eb.subscriptions))
+	}
+
+	// Closing the bus closes the remaining subscriptions and new ones.
+	eb.Close()
+	for range all.C {
+	}
+	if _, ok := <-eb.Subscribe().C; ok {
+		t.Fatal("subscription of closed bus should be closed")
+	}
+	eb.Publish("renter", EventTypeAlertRegistered, Alert{})
+}
+
+// TestEventRoute is a unit test for matching and validating event routes.
+func TestEventRoute(t *testing.T) {
+	t.Parallel()
+
+	// Check matching.
+	route := EventRoute{
+		Types: []EventType{EventTypeContractFormed},
+		Sink:  EventSinkLog,
+	}
+	if !route.Matches(Event{Type: EventTypeContractFormed}) {
+		t.Fatal("event should match")
+	}
+	if route.Matches(Event{Type: EventTypeContractArchived}) {
+		t.Fatal("event with different type shouldn't match")
+	}
+	route.Types = nil
+	if !route.Matches(Event{Type: EventTypeContractArchived}) {
+		t.Fatal("route without types should match all types")
+	}
+
+	// Check validation.
+	tests := []struct {
+		route EventRoute
+		valid bool
+	}{
+		{EventRoute{Sink: EventSinkLog}, true},
+		{EventRoute{Types: []EventType{EventTypeConsensusChange}, Sink: EventSinkWebhook, URL: "https://example.com/hook"}, true},
+		{EventRoute{Types: []EventType{"unknown"}, Sink: EventSinkLog}, false},
+		{EventRoute{Sink: EventSinkWebhook, URL: "ftp://example.com"}, false},
+		{EventRoute{Sink: EventSinkWebhook}, false},
+		{EventRoute{Sink: "email"}, false},
+		{EventRoute{Types: []EventType{EventTypeHostStorageProofMissed}, Sink: EventSinkWebhook, URL: "https://example.com/hook", Secret: "foo"}, true},
+		{EventRoute{Sink: EventSinkLog, Secret: "foo"}, false},
+	}
+	for i, test := range tests {
+		err := test.route.Validate()
+		if test.valid && err != nil {
+			t.Errorf("%v: expected route to be valid: %v", i, err)
+		} else if !test.valid && err == nil {
+			t.Errorf("%v: expected route to be invalid", i)
+		}
+	}
+}
+
+// TestEventSignature is a unit test for EventSignature.
+func TestEventSignature(t *testing.T) {
+	t.Parallel()
+
+	// Compare against a signature computed with openssl.
+	sig := EventSignature("secret", 1600000000, []byte(`{"type":"host.folder.error"}`))
+	if sig != "a2335e0f86b2349f4e3b50494e84f21890cfdade87b19cec9678f436f48d46c8" {
+		t.Fatal("unexpected signature", sig)
+	}
+	// The timestamp, the body and the secret are all covered.
+	if EventSignature("secret", 1600000001, []byte(`{"type":"host.folder.error"}`)) == sig {
+		t.Fatal("signature should depend on the timestamp")
+	}
+	if EventSignature("secret", 1600000000, []byte(`{"type":"host.collateral.low"}`)) == sig {
+		t.Fatal("signature should depend on the body")
+	}
+	if EventSignature("other", 1600000000, []byte(`{"type":"host.folder.error"}`)) == sig {
+		t.Fatal("signature should depend on the secret")
+	}
+}
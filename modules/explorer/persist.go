@@ -82,3 +82,9 @@ func (e *Explorer) initPersist() error {
 
 	return nil
 }
+
+// Quiesce implements the modules.Quiescer interface. It blocks updates to the
+// explorer's database until resume is called.
+func (e *Explorer) Quiesce() (resume func(), err error) {
+	return e.db.Quiesce(), nil
+}
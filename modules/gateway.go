@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
 )
 
 const (
@@ -114,6 +115,29 @@ type (
 		Version    string     `json:"version"`
 	}
 
+	// PeerScore contains the quality metrics of a peer and the resulting
+	// score. The gateway prefers peers with a high score when it selects
+	// outbound peers.
+	PeerScore struct {
+		// Score is the combined score of the peer between 0 and 1.
+		Score float64 `json:"score"`
+
+		// RelayLatency is the moving average of the delay between the gateway
+		// first receiving a block header or transaction set and the peer
+		// relaying it. Relays counts the number of relays the average is
+		// based on.
+		RelayLatency time.Duration `json:"relaylatency"`
+		Relays       uint64        `json:"relays"`
+
+		// Uptime is the total amount of time the gateway was connected to the
+		// peer.
+		Uptime time.Duration `json:"uptime"`
+
+		// Misbehavior counts the number of times the peer misbehaved, e.g. by
+		// relaying an invalid block.
+		Misbehavior uint64 `json:"misbehavior"`
+	}
+
 	// A PeerConn is the connection type used when communicating with peers during
 	// an RPC. It is identical to a net.Conn with the additional RPCAddr method.
 	// This method acts as an identifier for peers and is the address that the
@@ -184,10 +208,28 @@ type (
 		// to.
 		Peers() []Peer
 
+		// PeerScores returns the scores of the peers that the Gateway is
+		// currently connected to.
+		PeerScores() map[NetAddress]PeerScore
+
+		// ReportMisbehavior reports that a peer misbehaved, e.g. by relaying
+		// an invalid block. It lowers the score of the peer.
+		ReportMisbehavior(NetAddress, error)
+
+		// ReportRelay reports that a peer relayed the object with the given
+		// id, e.g. a block header or a transaction set. It is used to
+		// measure how fast peers relay new objects.
+		ReportRelay(NetAddress, crypto.Hash)
+
 		// RegisterRPC registers a function to handle incoming connections that
 		// supply the given RPC ID.
 		RegisterRPC(string, RPCFunc)
 
+		// TryRegisterRPC registers a function to handle incoming connections
+		// that supply the given RPC ID unless a function was already
+		// registered for it. It returns whether the function was registered.
+		TryRegisterRPC(string, RPCFunc) bool
+
 		// RateLimits returns the currently set bandwidth limits of the gateway.
 		RateLimits() (int64, int64)
 
@@ -45,7 +45,7 @@ func (g *Gateway) staticDial(addr modules.NetAddress) (net.Conn, error) {
 		dialer.LocalAddr = newLocalAddr(g.myAddr)
 	}
 
-	conn, err := dialer.Dial("tcp", string(addr))
+	conn, err := modules.GlobalProxy.Dial(dialer, "tcp", string(addr))
 	if err != nil {
 		return nil, err
 	}
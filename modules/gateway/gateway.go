@@ -102,6 +102,7 @@ import (
 	"gitlab.com/NebulousLabs/ratelimit"
 	"gitlab.com/NebulousLabs/threadgroup"
 
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/persist"
 
@@ -151,6 +152,12 @@ type Gateway struct {
 	peers     map[modules.NetAddress]*peer
 	peerTG    threadgroup.ThreadGroup
 
+	// relays contains the time the gateway first received the objects which
+	// were recently relayed by its peers. relayQueue contains the same
+	// objects in the order they were received to prune the oldest ones.
+	relays     map[crypto.Hash]time.Time
+	relayQueue []relayedObject
+
 	// Utilities.
 	log           *persist.Logger
 	mu            sync.RWMutex
@@ -177,7 +184,7 @@ func (g *Gateway) addToBlocklist(addresses []string) error {
 			// and remove the peer from the peer map
 			if peerAddr.Host() == addr {
 				err = errors.Compose(err, peer.sess.Close())
-				delete(g.peers, peerAddr)
+				g.removePeer(peerAddr)
 			}
 		}
 		// Check Gateway node map for address
@@ -377,6 +384,7 @@ func NewCustomGateway(addr string, bootstrap bool, persistDir string, deps modul
 		blocklist: make(map[string]struct{}),
 		nodes:     make(map[modules.NetAddress]*node),
 		peers:     make(map[modules.NetAddress]*peer),
+		relays:    make(map[crypto.Hash]time.Time),
 
 		persistDir:    persistDir,
 		staticAlerter: modules.NewAlerter("gateway"),
@@ -539,6 +547,7 @@ func NewCustomGateway(addr string, bootstrap bool, persistDir string, deps modul
 // threadedOnlineCheck periodically calls 'Online' to register the
 // GatewayOffline alert.
 func (g *Gateway) threadedOnlineCheck() {
+	defer modules.RecoverPanic("gateway")
 	if err := g.threads.Add(); err != nil {
 		return
 	}
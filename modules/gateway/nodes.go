@@ -24,6 +24,12 @@ var (
 type node struct {
 	NetAddress      modules.NetAddress `json:"netaddress"`
 	WasOutboundPeer bool               `json:"wasoutboundpeer"`
+
+	// Quality metrics of the node which are used to score it.
+	RelayLatency time.Duration `json:"relaylatency"`
+	Relays       uint64        `json:"relays"`
+	Uptime       time.Duration `json:"uptime"`
+	Misbehavior  uint64        `json:"misbehavior"`
 }
 
 // addNode adds an address to the set of nodes on the network.
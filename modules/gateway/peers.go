@@ -46,6 +46,10 @@ type peer struct {
 	m    *connmonitor.Monitor
 	rl   *ratelimit.RateLimit
 	sess streamSession
+
+	// connectedAt is the time the peer was added to the peer list. It is used
+	// to track the uptime of the peer's node.
+	connectedAt time.Time
 }
 
 // sessionHeader is sent after the initial version exchange. It prevents peers
@@ -80,10 +84,24 @@ func (p *peer) accept() (modules.PeerConn, error) {
 // addPeer adds a peer to the Gateway's peer list, spawns a listener thread to
 // handle its requests and increments the remotePeers accordingly
 func (g *Gateway) addPeer(p *peer) {
+	p.connectedAt = time.Now()
 	g.peers[p.NetAddress] = p
 	go g.threadedListenPeer(p)
 }
 
+// removePeer removes a peer from the Gateway's peer list and adds the duration
+// of the connection to the uptime of the peer's node.
+func (g *Gateway) removePeer(addr modules.NetAddress) {
+	p, exists := g.peers[addr]
+	if !exists {
+		return
+	}
+	if n, exists := g.nodes[addr]; exists && !p.connectedAt.IsZero() {
+		n.Uptime += time.Since(p.connectedAt)
+	}
+	delete(g.peers, addr)
+}
+
 // callInitRPCs calls the rpcs that are registered to be called upon connecting
 // to a peer.
 func (g *Gateway) callInitRPCs(addr modules.NetAddress) {
@@ -312,7 +330,7 @@ func (g *Gateway) acceptPeer(p *peer) {
 	kick := addrs[fastrand.Intn(len(addrs))]
 
 	g.peers[kick].sess.Close()
-	delete(g.peers, kick)
+	g.removePeer(kick)
 	g.log.Printf("INFO: disconnected from %v to make room for %v\n", kick, p.NetAddress)
 	g.addPeer(p)
 }
@@ -559,7 +577,7 @@ func (g *Gateway) Disconnect(addr modules.NetAddress) error {
 	g.mu.Lock()
 	// Peer is removed from the peer list as well as the node list, to prevent
 	// the node from being re-connected while looking for a replacement peer.
-	delete(g.peers, addr)
+	g.removePeer(addr)
 	delete(g.nodes, addr)
 	g.mu.Unlock()
 
@@ -1,8 +1,9 @@
 package gateway
 
 import (
+	"sort"
+
 	"gitlab.com/NebulousLabs/errors"
-	"gitlab.com/NebulousLabs/fastrand"
 
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
@@ -161,23 +162,19 @@ func (g *Gateway) permanentPeerManager(closedChan chan struct{}) {
 }
 
 // buildPeerManagerNodeList returns the gateway's node list in the order that
-// permanentPeerManager should attempt to connect to them.
+// permanentPeerManager should attempt to connect to them. Nodes which were
+// outbound peers before come first. Within both groups the nodes are in a
+// random order which prefers nodes with a high score.
 func (g *Gateway) buildPeerManagerNodeList() []modules.NetAddress {
-	// flatten the node map, inserting in random order
-	nodes := make([]modules.NetAddress, len(g.nodes))
-	perm := fastrand.Perm(len(nodes))
+	nodes := make([]*node, 0, len(g.nodes))
 	for _, node := range g.nodes {
-		nodes[perm[0]] = node.NetAddress
-		perm = perm[1:]
+		nodes = append(nodes, node)
 	}
+	addrs := g.scoredNodeOrder(nodes)
 
-	// swap the outbound nodes to the front of the list
-	numOutbound := 0
-	for i, node := range nodes {
-		if g.nodes[node].WasOutboundPeer {
-			nodes[numOutbound], nodes[i] = nodes[i], nodes[numOutbound]
-			numOutbound++
-		}
-	}
-	return nodes
+	// move the outbound nodes to the front of the list
+	sort.SliceStable(addrs, func(i, j int) bool {
+		return g.nodes[addrs[i]].WasOutboundPeer && !g.nodes[addrs[j]].WasOutboundPeer
+	})
+	return addrs
 }
@@ -115,6 +115,7 @@ func (g *Gateway) saveSyncNodes() error {
 
 // threadedSaveLoop periodically saves the gateway nodes.
 func (g *Gateway) threadedSaveLoop() {
+	defer modules.RecoverPanic("gateway")
 	for {
 		select {
 		case <-g.threads.StopChan():
@@ -50,7 +50,7 @@ func (g *Gateway) managedRPC(addr modules.NetAddress, name string, fn modules.RP
 		g.log.Debugf("Could not initiate RPC with %v; disconnecting", addr)
 		peer.sess.Close()
 		g.mu.Lock()
-		delete(g.peers, addr)
+		g.removePeer(addr)
 		g.mu.Unlock()
 		return err
 	}
@@ -97,6 +97,18 @@ func (g *Gateway) RegisterRPC(name string, fn modules.RPCFunc) {
 	g.handlers[handlerName(name)] = fn
 }
 
+// TryRegisterRPC registers an RPCFunc with the gateway unless an RPCFunc was
+// already registered for the same name. It returns whether fn was registered.
+func (g *Gateway) TryRegisterRPC(name string, fn modules.RPCFunc) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.handlers[handlerName(name)]; ok {
+		return false
+	}
+	g.handlers[handlerName(name)] = fn
+	return true
+}
+
 // UnregisterRPC unregisters an RPC and removes the corresponding RPCFunc from
 // g.handlers. Future calls to the RPC by peers will fail.
 func (g *Gateway) UnregisterRPC(name string) {
@@ -134,6 +146,7 @@ func (g *Gateway) UnregisterConnectCall(name string) {
 // threadedListenPeer listens for new streams on a peer connection and serves them via
 // threadedHandleConn.
 func (g *Gateway) threadedListenPeer(p *peer) {
+	defer modules.RecoverPanic("gateway")
 	// threadedListenPeer registers to the peerTG instead of the primary thread
 	// group because peer connections can be lifetime in length, but can also
 	// be short-lived. The fact that they can be lifetime means that they can't
@@ -165,7 +178,7 @@ func (g *Gateway) threadedListenPeer(p *peer) {
 		// Close the session and remove p from the peer list.
 		p.sess.Close()
 		g.mu.Lock()
-		delete(g.peers, p.NetAddress)
+		g.removePeer(p.NetAddress)
 		g.mu.Unlock()
 	}()
 
@@ -199,6 +212,7 @@ func (g *Gateway) threadedListenPeer(p *peer) {
 // threadedHandleConn reads header data from a connection, then routes it to the
 // appropriate handler for further processing.
 func (g *Gateway) threadedHandleConn(conn modules.PeerConn) {
+	defer modules.RecoverPanic("gateway")
 	defer func() {
 		_ = conn.Close()
 	}()
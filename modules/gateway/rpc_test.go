@@ -60,6 +60,32 @@ func TestRegisterRPC(t *testing.T) {
 	g.RegisterRPC("Foo", func(conn modules.PeerConn) error { return nil })
 }
 
+// TestTryRegisterRPC tests that TryRegisterRPC only registers an RPC which
+// wasn't registered yet.
+func TestTryRegisterRPC(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	g := newTestingGateway(t)
+	defer func() {
+		if err := g.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	if !g.TryRegisterRPC("Foo", func(conn modules.PeerConn) error { return nil }) {
+		t.Fatal("RPC wasn't registered")
+	}
+	if g.TryRegisterRPC("Foo", func(conn modules.PeerConn) error { return nil }) {
+		t.Fatal("RPC was registered twice")
+	}
+	g.UnregisterRPC("Foo")
+	if !g.TryRegisterRPC("Foo", func(conn modules.PeerConn) error { return nil }) {
+		t.Fatal("RPC wasn't registered after unregistering it")
+	}
+}
+
 // TestUnregisterRPC tests that unregistering an RPC causes calls to it to
 // fail, and checks that unregistering a non-registered RPC causes a panic.
 func TestUnregisterRPC(t *testing.T) {
@@ -0,0 +1,166 @@
+package gateway
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+const (
+	// maxTrackedRelays is the max number of relayed objects the gateway
+	// remembers to measure the relay latency of its peers.
+	maxTrackedRelays = 10e3
+
+	// relayLatencyWeight is the weight of a new sample in the moving average
+	// of a node's relay latency.
+	relayLatencyWeight = 0.1
+
+	// scoreLatencyReference is the relay latency at which the latency factor
+	// of a node's score is 0.5.
+	scoreLatencyReference = 10 * time.Second
+
+	// scoreUptimeReference is the uptime at which the uptime factor of a
+	// node's score is 0.75. New nodes start at 0.5.
+	scoreUptimeReference = 24 * time.Hour
+)
+
+var (
+	// relayWindow is the amount of time the gateway remembers when it first
+	// received a relayed object. After that, a relay of the object is treated
+	// like the first one.
+	relayWindow = build.Select(build.Var{
+		Standard: 10 * time.Minute,
+		Dev:      2 * time.Minute,
+		Testing:  30 * time.Second,
+	}).(time.Duration)
+)
+
+// relayedObject is an object which was relayed to the gateway.
+type relayedObject struct {
+	id   crypto.Hash
+	time time.Time
+}
+
+// score returns the score of the node. The score is the product of factors
+// between 0 and 1 for the node's uptime, relay latency and misbehavior. A
+// node without any history has a score of 0.5.
+func (n *node) score(uptime time.Duration) modules.PeerScore {
+	uptimeFactor := 0.5 + 0.5*float64(uptime)/float64(uptime+scoreUptimeReference)
+	latencyFactor := float64(scoreLatencyReference) / float64(scoreLatencyReference+n.RelayLatency)
+	misbehaviorFactor := 1 / float64(1+n.Misbehavior)
+	return modules.PeerScore{
+		Score:        uptimeFactor * latencyFactor * misbehaviorFactor,
+		RelayLatency: n.RelayLatency,
+		Relays:       n.Relays,
+		Uptime:       uptime,
+		Misbehavior:  n.Misbehavior,
+	}
+}
+
+// nodeScore returns the score of a node including the uptime of its current
+// connection.
+func (g *Gateway) nodeScore(n *node) modules.PeerScore {
+	uptime := n.Uptime
+	if p, connected := g.peers[n.NetAddress]; connected && !p.connectedAt.IsZero() {
+		uptime += time.Since(p.connectedAt)
+	}
+	return n.score(uptime)
+}
+
+// pruneRelays removes the relayed objects which were received before the
+// relay window or which exceed the max number of tracked relays.
+func (g *Gateway) pruneRelays(now time.Time) {
+	var i int
+	for i < len(g.relayQueue) && (now.Sub(g.relayQueue[i].time) > relayWindow || len(g.relayQueue)-i > maxTrackedRelays) {
+		delete(g.relays, g.relayQueue[i].id)
+		i++
+	}
+	g.relayQueue = g.relayQueue[i:]
+}
+
+// scoredNodeOrder returns the addresses of the provided nodes in a random
+// order which is weighted by the nodes' scores. Nodes with a higher score are
+// more likely to be at the front of the list.
+func (g *Gateway) scoredNodeOrder(nodes []*node) []modules.NetAddress {
+	// Use the weighted random sampling of Efraimidis and Spirakis. Every node
+	// is assigned the key r^(1/score) where r is uniformly random within
+	// (0,1]. Sorting by key is equivalent to drawing the nodes one by one with
+	// a probability proportional to their score.
+	keys := make(map[modules.NetAddress]float64, len(nodes))
+	addrs := make([]modules.NetAddress, 0, len(nodes))
+	for _, n := range nodes {
+		r := float64(fastrand.Uint64n(1<<53)+1) / (1 << 53)
+		keys[n.NetAddress] = math.Pow(r, 1/g.nodeScore(n).Score)
+		addrs = append(addrs, n.NetAddress)
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return keys[addrs[i]] > keys[addrs[j]]
+	})
+	return addrs
+}
+
+// PeerScores returns the scores of the peers that the Gateway is currently
+// connected to. Peers which are not in the node list are scored as new nodes.
+func (g *Gateway) PeerScores() map[modules.NetAddress]modules.PeerScore {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	scores := make(map[modules.NetAddress]modules.PeerScore, len(g.peers))
+	for addr := range g.peers {
+		n, exists := g.nodes[addr]
+		if !exists {
+			n = &node{NetAddress: addr}
+		}
+		scores[addr] = g.nodeScore(n)
+	}
+	return scores
+}
+
+// ReportMisbehavior reports that a peer misbehaved, e.g. by relaying an invalid
+// block. It lowers the score of the peer's node.
+func (g *Gateway) ReportMisbehavior(addr modules.NetAddress, err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	n, exists := g.nodes[addr]
+	if !exists {
+		return
+	}
+	n.Misbehavior++
+	g.log.Debugf("INFO: peer %v misbehaved (%v times in total): %v", addr, n.Misbehavior, err)
+}
+
+// ReportRelay reports that a peer relayed the object with the given id. The
+// delay between the gateway first receiving the object and the peer relaying
+// it is added to the relay latency of the peer's node.
+func (g *Gateway) ReportRelay(addr modules.NetAddress, id crypto.Hash) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.relays == nil {
+		g.relays = make(map[crypto.Hash]time.Time)
+	}
+	now := time.Now()
+	g.pruneRelays(now)
+	first, seen := g.relays[id]
+	if !seen {
+		first = now
+		g.relays[id] = now
+		g.relayQueue = append(g.relayQueue, relayedObject{id: id, time: now})
+	}
+
+	n, exists := g.nodes[addr]
+	if !exists {
+		return
+	}
+	latency := now.Sub(first)
+	if n.Relays == 0 {
+		n.RelayLatency = latency
+	} else {
+		n.RelayLatency = time.Duration(float64(n.RelayLatency)*(1-relayLatencyWeight) + float64(latency)*relayLatencyWeight)
+	}
+	n.Relays++
+}
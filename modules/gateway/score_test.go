@@ -0,0 +1,151 @@
+package gateway
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestNodeScore tests the factors of a node's score.
+func TestNodeScore(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		n      node
+		uptime time.Duration
+		score  float64
+	}{
+		{node{}, 0, 0.5},
+		{node{}, scoreUptimeReference, 0.75},
+		{node{RelayLatency: scoreLatencyReference}, 0, 0.25},
+		{node{Misbehavior: 1}, 0, 0.25},
+		{node{RelayLatency: scoreLatencyReference, Misbehavior: 3}, scoreUptimeReference, 0.75 * 0.5 * 0.25},
+	}
+	for _, test := range tests {
+		s := test.n.score(test.uptime)
+		if math.Abs(s.Score-test.score) > 1e-9 {
+			t.Errorf("expected score %v but got %v for %+v", test.score, s.Score, test.n)
+		}
+		if s.Uptime != test.uptime || s.RelayLatency != test.n.RelayLatency || s.Misbehavior != test.n.Misbehavior {
+			t.Errorf("unexpected metrics %+v", s)
+		}
+	}
+}
+
+// TestPeerScoring tests reporting relays and misbehavior, tracking the uptime
+// of peers and persisting the resulting scores.
+func TestPeerScoring(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	g := newTestingGateway(t)
+	fast, slow := modules.NetAddress("1.1.1.1:9981"), modules.NetAddress("2.2.2.2:9981")
+	g.mu.Lock()
+	g.nodes[fast] = &node{NetAddress: fast}
+	g.nodes[slow] = &node{NetAddress: slow}
+	g.mu.Unlock()
+
+	// The fast node relays an object first. The slow node relays it two
+	// seconds later.
+	id := crypto.Hash{1}
+	g.ReportRelay(fast, id)
+	g.mu.Lock()
+	g.relays[id] = g.relays[id].Add(-2 * time.Second)
+	g.mu.Unlock()
+	g.ReportRelay(slow, id)
+	g.ReportMisbehavior(slow, errors.New("invalid block"))
+
+	// Unknown nodes are ignored.
+	g.ReportRelay("3.3.3.3:9981", id)
+	g.ReportMisbehavior("3.3.3.3:9981", errors.New("invalid block"))
+
+	// Simulate an hour long connection to the fast node.
+	g.mu.Lock()
+	g.peers[fast] = &peer{Peer: modules.Peer{NetAddress: fast}, connectedAt: time.Now().Add(-time.Hour)}
+	g.mu.Unlock()
+	scores := g.PeerScores()
+	if len(scores) != 1 || scores[fast].Uptime < time.Hour {
+		t.Fatal("unexpected scores", scores)
+	}
+	g.mu.Lock()
+	g.removePeer(fast)
+	fastNode, slowNode := *g.nodes[fast], *g.nodes[slow]
+	g.mu.Unlock()
+
+	if fastNode.Relays != 1 || fastNode.RelayLatency > time.Second || fastNode.Uptime < time.Hour || fastNode.Misbehavior != 0 {
+		t.Fatal("unexpected fast node", fastNode)
+	}
+	if slowNode.Relays != 1 || slowNode.RelayLatency < 2*time.Second || slowNode.Misbehavior != 1 {
+		t.Fatal("unexpected slow node", slowNode)
+	}
+	if fastNode.score(fastNode.Uptime).Score <= slowNode.score(slowNode.Uptime).Score {
+		t.Fatal("fast node should have a higher score")
+	}
+
+	// Another relay of the slow node should be added to the moving average.
+	id2 := crypto.Hash{2}
+	g.ReportRelay(fast, id2)
+	g.ReportRelay(slow, id2)
+	g.mu.Lock()
+	latency := g.nodes[slow].RelayLatency
+	fastNode = *g.nodes[fast]
+	g.mu.Unlock()
+	expected := time.Duration(float64(slowNode.RelayLatency) * (1 - relayLatencyWeight))
+	if latency < expected || latency > expected+time.Second {
+		t.Fatal("unexpected moving average", latency, expected)
+	}
+
+	// The scores should be persisted.
+	g.mu.Lock()
+	err := g.saveSyncNodes()
+	g.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Close(); err != nil {
+		t.Fatal(err)
+	}
+	g, err = New("localhost:0", false, g.persistDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := g.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	g.mu.RLock()
+	loaded := *g.nodes[fast]
+	g.mu.RUnlock()
+	if loaded != fastNode {
+		t.Fatal("scores weren't persisted", loaded, fastNode)
+	}
+}
+
+// TestScoredNodeOrder tests that nodes with a higher score are more likely to
+// be at the front of the node list.
+func TestScoredNodeOrder(t *testing.T) {
+	t.Parallel()
+
+	good := &node{NetAddress: "good"}
+	bad := &node{NetAddress: "bad", Misbehavior: 9}
+	g := &Gateway{}
+
+	// The good node has a score of 0.5 and the bad one of 0.05 which means
+	// that the good node should come first in about 91% of the cases.
+	var goodFirst int
+	for i := 0; i < 1000; i++ {
+		if g.scoredNodeOrder([]*node{bad, good})[0] == good.NetAddress {
+			goodFirst++
+		}
+	}
+	if goodFirst < 800 || goodFirst == 1000 {
+		t.Fatal("unexpected order", goodFirst)
+	}
+}
@@ -105,6 +105,16 @@ func (g *Gateway) threadedLearnHostname() {
 	}
 
 	for {
+		// Don't discover our clearnet address while connecting through a
+		// proxy which disables announcements. Discovering it would leak it to
+		// the peers and the discovery services.
+		if modules.GlobalProxy.AnnouncementsDisabled() {
+			if !g.managedSleep(rediscoverIPIntervalFailure) {
+				return // shutdown interrupted sleep
+			}
+			continue
+		}
+
 		host, err := g.managedLearnHostname(nil)
 		if err != nil {
 			g.log.Println("WARN: failed to discover external IP:", err)
@@ -1,6 +1,7 @@
 package modules
 
 import (
+	"io"
 	"time"
 
 	"go.sia.tech/siad/build"
@@ -63,6 +64,29 @@ const (
 	DefaultMaxDuration = 144 * 30 * 6 // 6 months.
 )
 
+// The following consts are the error codes of failed RPCs in the host's RPC
+// trace.
+const (
+	// HostRPCErrorCodeInternal is the error code of RPCs which failed due to
+	// an error which doesn't match any of the other codes.
+	HostRPCErrorCodeInternal = "internal"
+	// HostRPCErrorCodePayment is the error code of RPCs which failed because
+	// the payment was invalid or insufficient.
+	HostRPCErrorCodePayment = "payment"
+	// HostRPCErrorCodePriceTable is the error code of RPCs which failed
+	// because the price table was unknown or expired.
+	HostRPCErrorCodePriceTable = "pricetable"
+	// HostRPCErrorCodeShutdown is the error code of RPCs which failed because
+	// the host was shutting down.
+	HostRPCErrorCodeShutdown = "shutdown"
+	// HostRPCErrorCodeTimeout is the error code of RPCs which failed because
+	// the stream timed out.
+	HostRPCErrorCodeTimeout = "timeout"
+	// HostRPCErrorCodeUnknownRPC is the error code of calls to an unknown
+	// RPC.
+	HostRPCErrorCodeUnknownRPC = "unknownrpc"
+)
+
 var (
 	// DefaultMaxDownloadBatchSize defines the maximum number of bytes that the
 	// host will allow to be requested by a single download request. 17 MiB has
@@ -225,6 +249,61 @@ var (
 	HostWorkingStatusWorking = HostWorkingStatus("working")
 )
 
+var (
+	// MissedProofCauseChainNotSynced indicates that the host's consensus set
+	// wasn't synced when it tried to submit the storage proof.
+	MissedProofCauseChainNotSynced = MissedProofCause("chain not synced")
+
+	// MissedProofCauseDiskError indicates that the host was unable to read
+	// the sector required for the storage proof from disk.
+	MissedProofCauseDiskError = MissedProofCause("disk error")
+
+	// MissedProofCauseFeeTooHigh indicates that the host didn't submit the
+	// storage proof since the transaction fee exceeded the contract's value.
+	MissedProofCauseFeeTooHigh = MissedProofCause("fee too high")
+
+	// MissedProofCauseInsufficientFunds indicates that the host's wallet was
+	// unable to fund the storage proof transaction.
+	MissedProofCauseInsufficientFunds = MissedProofCause("insufficient funds")
+
+	// MissedProofCauseSectorMissing indicates that the sector required for
+	// the storage proof was not found on the host.
+	MissedProofCauseSectorMissing = MissedProofCause("sector missing")
+
+	// MissedProofCauseTransactionRejected indicates that the storage proof
+	// transaction was rejected by the transaction pool.
+	MissedProofCauseTransactionRejected = MissedProofCause("transaction rejected")
+
+	// MissedProofCauseUnknown indicates that the host never attempted to
+	// submit the storage proof, e.g. because it was offline during the proof
+	// window.
+	MissedProofCauseUnknown = MissedProofCause("unknown")
+)
+
+var (
+	// HostAccountTransactionDeposit is the type of deposits into an ephemeral
+	// account.
+	HostAccountTransactionDeposit = HostAccountTransactionType("deposit")
+
+	// HostAccountTransactionRefund is the type of refunds into an ephemeral
+	// account.
+	HostAccountTransactionRefund = HostAccountTransactionType("refund")
+
+	// HostAccountTransactionWithdrawal is the type of withdrawals from an
+	// ephemeral account.
+	HostAccountTransactionWithdrawal = HostAccountTransactionType("withdrawal")
+)
+
+var (
+	// ProofFeeTypeRevision is the type of the transactions the host submits to
+	// get the final revision of a contract confirmed.
+	ProofFeeTypeRevision = ProofFeeType("revision")
+
+	// ProofFeeTypeStorageProof is the type of the transactions containing the
+	// host's storage proofs.
+	ProofFeeTypeStorageProof = ProofFeeType("storageproof")
+)
+
 type (
 	// HostFinancialMetrics provides financial statistics for the host,
 	// including money that is locked in contracts. Though verbose, these
@@ -289,6 +368,122 @@ type (
 
 		CustomRegistryPath string `json:"customregistrypath"`
 		RegistrySize       uint64 `json:"registrysize"`
+
+		// AdditionalNetAddresses are announced in addition to the host's
+		// NetAddress, e.g. an IPv6 or an onion address.
+		AdditionalNetAddresses []NetAddress `json:"additionalnetaddresses"`
+
+		// ProofFeeMultiplier is multiplied with the fee estimation of the
+		// transaction pool to get the fee of the host's storage proof and
+		// final revision transactions. A multiplier of 0 uses the estimation
+		// as is. MaxProofFee caps the fee of a single transaction, 0 means
+		// no cap. If BatchStorageProofs is set, proofs for contracts whose
+		// proof windows align are submitted in a single transaction.
+		BatchStorageProofs bool           `json:"batchstorageproofs"`
+		MaxProofFee        types.Currency `json:"maxprooffee"`
+		ProofFeeMultiplier float64        `json:"prooffeemultiplier"`
+
+		// WriteBatchMaxLatency is the max amount of time a write program
+		// waits for other write programs to commit their storage obligations
+		// in a single database transaction. Batching reduces the number of
+		// fsyncs. A latency of 0 disables batching.
+		WriteBatchMaxLatency time.Duration `json:"writebatchmaxlatency"`
+
+		// PriceTableGracePeriod is the amount of time after issuing a new
+		// price table to a renter during which the host still accepts the
+		// renter's previous price table for in-flight RPCs, even if it
+		// expired in the meantime. It is advertised in the price tables the
+		// host issues. A grace period of 0 disables it.
+		PriceTableGracePeriod time.Duration `json:"pricetablegraceperiod"`
+
+		// ReadCacheSize is the memory budget in bytes of the host's sector
+		// cache which serves frequently downloaded and prefetched sectors
+		// from memory. A size of 0 disables the cache.
+		ReadCacheSize uint64 `json:"readcachesize"`
+
+		// MonitoringAddress is the address of an optional plain HTTP
+		// endpoint which serves the host's external settings and price
+		// table to monitoring services. An empty address disables it.
+		MonitoringAddress string `json:"monitoringaddress"`
+
+		// MaxProgramInstructions, MaxProgramDataLength and
+		// MaxProgramDuration limit the number of instructions, the length
+		// of the data and the execution time of the MDM programs the host
+		// executes. They are advertised in the external settings. A limit of
+		// 0 disables it.
+		MaxProgramInstructions uint64        `json:"maxprograminstructions"`
+		MaxProgramDataLength   uint64        `json:"maxprogramdatalength"`
+		MaxProgramDuration     time.Duration `json:"maxprogramduration"`
+
+		// CollateralBudgetAlertThreshold and WalletBalanceAlertThreshold
+		// cause the host to register an alert while its remaining collateral
+		// budget or its confirmed wallet balance are below them. A threshold
+		// of 0 disables the alert.
+		CollateralBudgetAlertThreshold types.Currency `json:"collateralbudgetalertthreshold"`
+		WalletBalanceAlertThreshold    types.Currency `json:"walletbalancealertthreshold"`
+	}
+
+	// HostPricingPolicy configures the host's dynamic pricing. If enabled,
+	// the host periodically derives the prices it advertises from its
+	// internal settings and the configured rules instead of using the
+	// internal settings as they are.
+	HostPricingPolicy struct {
+		Enabled bool `json:"enabled"`
+
+		// UtilizationRules raise the storage price as the host's storage
+		// utilization crosses the rules' thresholds. The multiplier of the
+		// rule with the highest crossed threshold is applied.
+		UtilizationRules []HostPricingUtilizationRule `json:"utilizationrules"`
+
+		// FiatCurrency is the currency the fiat prices are pegged to. The
+		// exchange rate is fetched from the host's exchange rate feed. A fiat
+		// price of 0 means that the corresponding price isn't pegged and the
+		// price of the internal settings is used instead. The storage price
+		// is per TB per month, the bandwidth prices are per TB.
+		FiatCurrency      string  `json:"fiatcurrency"`
+		FiatStoragePrice  float64 `json:"fiatstorageprice"`
+		FiatUploadPrice   float64 `json:"fiatuploadprice"`
+		FiatDownloadPrice float64 `json:"fiatdownloadprice"`
+
+		// MaxCollateralBudgetUtilization caps the fraction of the collateral
+		// budget which may be locked in contracts. The host reduces the
+		// advertised max collateral so that new contracts don't exceed the
+		// cap. A value of 0 means no cap.
+		MaxCollateralBudgetUtilization float64 `json:"maxcollateralbudgetutilization"`
+	}
+
+	// HostPricingUtilizationRule multiplies the host's storage price once the
+	// fraction of used storage reaches the rule's utilization.
+	HostPricingUtilizationRule struct {
+		Utilization            float64 `json:"utilization"`
+		StoragePriceMultiplier float64 `json:"storagepricemultiplier"`
+	}
+
+	// HostPricingStatus contains the host's pricing policy and the prices the
+	// host derived from it during the most recent update.
+	HostPricingStatus struct {
+		Policy HostPricingPolicy `json:"policy"`
+
+		LastUpdate                  time.Time `json:"lastupdate"`
+		ExchangeRate                float64   `json:"exchangerate"`
+		StorageUtilization          float64   `json:"storageutilization"`
+		CollateralBudgetUtilization float64   `json:"collateralbudgetutilization"`
+
+		StoragePrice           types.Currency `json:"storageprice"`
+		UploadBandwidthPrice   types.Currency `json:"uploadbandwidthprice"`
+		DownloadBandwidthPrice types.Currency `json:"downloadbandwidthprice"`
+		MaxCollateral          types.Currency `json:"maxcollateral"`
+
+		RecentErr     string    `json:"recenterr"`
+		RecentErrTime time.Time `json:"recenterrtime"`
+	}
+
+	// ExchangeRateFeed provides the exchange rates the host uses to peg its
+	// prices to a fiat currency.
+	ExchangeRateFeed interface {
+		// SiacoinPrice returns the price of one siacoin in the given fiat
+		// currency.
+		SiacoinPrice(currency string) (float64, error)
 	}
 
 	// HostNetworkMetrics reports the quantity of each type of RPC call that
@@ -303,6 +498,302 @@ type (
 		UnrecognizedCalls uint64 `json:"unrecognizedcalls"`
 	}
 
+	// HostReadCacheMetrics contains the statistics of the host's sector cache
+	// since the host was started. Misses include the reads which bypassed
+	// the cache.
+	HostReadCacheMetrics struct {
+		Hits      uint64 `json:"hits"`
+		Misses    uint64 `json:"misses"`
+		Bypassed  uint64 `json:"bypassed"`
+		Evictions uint64 `json:"evictions"`
+		Sectors   uint64 `json:"sectors"`
+		Size      uint64 `json:"size"`
+		MaxSize   uint64 `json:"maxsize"`
+	}
+
+	// HostRPCTrace contains the traced RPC calls which were made to the host
+	// over the SiaMux.
+	HostRPCTrace struct {
+		// LatencyBuckets are the upper bounds of the buckets of the latency
+		// histograms.
+		LatencyBuckets []time.Duration `json:"latencybuckets"`
+
+		// Stats contains the aggregated statistics of every RPC since the
+		// host was started.
+		Stats []HostRPCStats `json:"stats"`
+
+		// Recent contains the most recent calls, starting with the oldest.
+		Recent []HostRPCTraceEntry `json:"recent"`
+	}
+
+	// HostRPCStats contains the aggregated statistics of a single RPC.
+	HostRPCStats struct {
+		RPC             string        `json:"rpc"`
+		Calls           uint64        `json:"calls"`
+		BytesUploaded   uint64        `json:"bytesuploaded"`
+		BytesDownloaded uint64        `json:"bytesdownloaded"`
+		TotalLatency    time.Duration `json:"totallatency"`
+
+		// Errors contains the number of failed calls per error code.
+		Errors map[string]uint64 `json:"errors"`
+
+		// LatencyHistogram contains the number of calls per latency bucket.
+		// It contains one more element than the buckets for the calls which
+		// took longer than the largest bucket.
+		LatencyHistogram []uint64 `json:"latencyhistogram"`
+	}
+
+	// HostRPCTraceEntry is a single traced RPC call.
+	HostRPCTraceEntry struct {
+		RPC             string        `json:"rpc"`
+		Start           time.Time     `json:"start"`
+		Duration        time.Duration `json:"duration"`
+		BytesUploaded   uint64        `json:"bytesuploaded"`
+		BytesDownloaded uint64        `json:"bytesdownloaded"`
+		ErrorCode       string        `json:"errorcode,omitempty"`
+		Error           string        `json:"error,omitempty"`
+	}
+
+	// HostMDMMetrics contains the execution statistics of the instructions the
+	// host's MDM executed since the host was started.
+	HostMDMMetrics struct {
+		// Instructions contains the aggregated statistics of every
+		// instruction, sorted by name.
+		Instructions []HostMDMInstructionStats `json:"instructions"`
+	}
+
+	// HostMDMInstructionStats contains the aggregated statistics of a single
+	// MDM instruction.
+	HostMDMInstructionStats struct {
+		Instruction    string         `json:"instruction"`
+		Executions     uint64         `json:"executions"`
+		Failures       uint64         `json:"failures"`
+		BytesProcessed uint64         `json:"bytesprocessed"`
+		BudgetConsumed types.Currency `json:"budgetconsumed"`
+		TotalTime      time.Duration  `json:"totaltime"`
+	}
+
+	// HostBandwidthUsage is the number of bytes the host sent and received.
+	HostBandwidthUsage struct {
+		Upload   uint64 `json:"upload"`
+		Download uint64 `json:"download"`
+	}
+
+	// HostBandwidthDay contains the bandwidth the host used on a single day.
+	HostBandwidthDay struct {
+		// Day is the start of the day in UTC.
+		Day      time.Time `json:"day"`
+		Upload   uint64    `json:"upload"`
+		Download uint64    `json:"download"`
+
+		// RPCs contains the bandwidth used per RPC.
+		RPCs map[string]HostBandwidthUsage `json:"rpcs"`
+	}
+
+	// HostBandwidthMetrics contains the bandwidth the host used within a
+	// period, e.g. a billing period of the host's ISP.
+	HostBandwidthMetrics struct {
+		Start    time.Time `json:"start"`
+		End      time.Time `json:"end"`
+		Upload   uint64    `json:"upload"`
+		Download uint64    `json:"download"`
+
+		// RPCs contains the bandwidth used per RPC within the period.
+		RPCs map[string]HostBandwidthUsage `json:"rpcs"`
+
+		// Days contains the bandwidth used on every day of the period the
+		// host was online on, sorted by day.
+		Days []HostBandwidthDay `json:"days"`
+	}
+
+	// HostAnnouncementPreview describes the announcement transaction the host
+	// would submit to the network without submitting it.
+	HostAnnouncementPreview struct {
+		// NetAddress is the address to be announced and
+		// AdditionalNetAddresses are announced in addition to it.
+		NetAddress             NetAddress         `json:"netaddress"`
+		AdditionalNetAddresses []NetAddress       `json:"additionalnetaddresses"`
+		PublicKey              types.SiaPublicKey `json:"publickey"`
+
+		// Fee is the miner fee paid for the announcement and Size is the size
+		// of the transaction set in bytes.
+		Fee  types.Currency `json:"fee"`
+		Size uint64         `json:"size"`
+
+		// AlreadyAnnounced indicates that the host already announced its
+		// current address. Announcing it again pays the fee a second time.
+		AlreadyAnnounced bool `json:"alreadyannounced"`
+
+		// TransactionSet is the signed announcement transaction together with
+		// its parents.
+		TransactionSet []types.Transaction `json:"transactionset"`
+	}
+
+	// HostRescanStatus contains the progress of a rescan of the blockchain for
+	// the host's storage obligations and announcements.
+	HostRescanStatus struct {
+		// Active indicates whether the rescan is still running.
+		Active bool `json:"active"`
+
+		// StartHeight and EndHeight are the bounds of the rescanned range and
+		// CurrentHeight is the next height to be scanned.
+		StartHeight   types.BlockHeight `json:"startheight"`
+		EndHeight     types.BlockHeight `json:"endheight"`
+		CurrentHeight types.BlockHeight `json:"currentheight"`
+
+		// ObligationsUpdated is the number of storage obligations which were
+		// updated because a transaction affecting them was found.
+		// AnnouncementsFound is the number of announcements of the host.
+		ObligationsUpdated uint64 `json:"obligationsupdated"`
+		AnnouncementsFound uint64 `json:"announcementsfound"`
+
+		StartTime time.Time `json:"starttime"`
+		EndTime   time.Time `json:"endtime"`
+
+		// Error is the error which caused the rescan to stop early.
+		Error string `json:"error,omitempty"`
+	}
+
+	// HostMissedProof contains information about a storage proof the host
+	// failed to submit and the collateral it lost because of that.
+	HostMissedProof struct {
+		// BurntCollateral is the collateral the host lost due to the missed
+		// proof and LostRevenue is the revenue it would have earned.
+		BurntCollateral types.Currency `json:"burntcollateral"`
+		LostRevenue     types.Currency `json:"lostrevenue"`
+
+		// Cause is the probable cause of the missed proof and Error the error
+		// the host encountered during its last attempt at submitting the
+		// proof, if there was any.
+		Cause MissedProofCause `json:"cause"`
+		Error string           `json:"error"`
+
+		ExpirationHeight types.BlockHeight    `json:"expirationheight"`
+		MissedHeight     types.BlockHeight    `json:"missedheight"`
+		ObligationID     types.FileContractID `json:"obligationid"`
+		ProofDeadline    types.BlockHeight    `json:"proofdeadline"`
+		Timestamp        time.Time            `json:"timestamp"`
+	}
+
+	// MissedProofCause describes the probable cause of a missed storage
+	// proof.
+	MissedProofCause string
+
+	// HostAccountTransaction is an entry of the host's ephemeral account
+	// audit log. The origin is the remote address of the stream which caused
+	// the transaction.
+	HostAccountTransaction struct {
+		Account   string                     `json:"account"`
+		Amount    types.Currency             `json:"amount"`
+		Origin    string                     `json:"origin"`
+		Timestamp time.Time                  `json:"timestamp"`
+		Type      HostAccountTransactionType `json:"type"`
+	}
+
+	// HostAccountTransactionType is the type of a transaction of an ephemeral
+	// account.
+	HostAccountTransactionType string
+
+	// HostProofFeeTransaction contains the fee of a storage proof or final
+	// revision transaction the host submitted.
+	HostProofFeeTransaction struct {
+		Fee           types.Currency         `json:"fee"`
+		Height        types.BlockHeight      `json:"height"`
+		ObligationIDs []types.FileContractID `json:"obligationids"`
+		TransactionID types.TransactionID    `json:"transactionid"`
+		Type          ProofFeeType           `json:"type"`
+	}
+
+	// HostProofFeePeriod contains the fees the host spent on storage proof
+	// and final revision transactions within a range of block heights.
+	HostProofFeePeriod struct {
+		StartHeight types.BlockHeight `json:"startheight"`
+		EndHeight   types.BlockHeight `json:"endheight"`
+
+		ProofFees    types.Currency `json:"prooffees"`
+		RevisionFees types.Currency `json:"revisionfees"`
+
+		// StorageProofs is the number of submitted storage proofs and
+		// ProofTransactions the number of transactions they were submitted
+		// in, which is lower than StorageProofs if proofs were batched.
+		StorageProofs        uint64 `json:"storageproofs"`
+		ProofTransactions    uint64 `json:"prooftransactions"`
+		RevisionTransactions uint64 `json:"revisiontransactions"`
+	}
+
+	// HostProofFeeReport contains the fees the host spent on storage proof
+	// and final revision transactions per period.
+	HostProofFeeReport struct {
+		Periods []HostProofFeePeriod `json:"periods"`
+
+		TotalProofFees    types.Currency `json:"totalprooffees"`
+		TotalRevisionFees types.Currency `json:"totalrevisionfees"`
+	}
+
+	// ProofFeeType is the type of a transaction the host paid a fee for to
+	// get paid for a contract.
+	ProofFeeType string
+
+	// HostObligationStatusSummary contains the totals of the host's storage
+	// obligations with the same status. Revenue is the contract compensation
+	// plus the storage and bandwidth revenue of the obligations, which is
+	// expected for unresolved, earned for succeeded and lost for failed and
+	// rejected obligations.
+	HostObligationStatusSummary struct {
+		Count            uint64         `json:"count"`
+		DataSize         uint64         `json:"datasize"`
+		LockedCollateral types.Currency `json:"lockedcollateral"`
+		Revenue          types.Currency `json:"revenue"`
+		RiskedCollateral types.Currency `json:"riskedcollateral"`
+	}
+
+	// HostProofDeadlineWindow contains the unresolved storage obligations
+	// whose proof deadline lies within a range of block heights.
+	HostProofDeadlineWindow struct {
+		StartHeight types.BlockHeight `json:"startheight"`
+		EndHeight   types.BlockHeight `json:"endheight"`
+
+		// AtRisk is the number of obligations whose storage proof should
+		// have been confirmed by now but wasn't.
+		AtRisk           uint64         `json:"atrisk"`
+		Obligations      uint64         `json:"obligations"`
+		Revenue          types.Currency `json:"revenue"`
+		RiskedCollateral types.Currency `json:"riskedcollateral"`
+	}
+
+	// HostObligationsSummary aggregates the host's storage obligations by
+	// status and lists the upcoming proof deadlines.
+	HostObligationsSummary struct {
+		BlockHeight types.BlockHeight `json:"blockheight"`
+
+		Unresolved HostObligationStatusSummary `json:"unresolved"`
+		Rejected   HostObligationStatusSummary `json:"rejected"`
+		Succeeded  HostObligationStatusSummary `json:"succeeded"`
+		Failed     HostObligationStatusSummary `json:"failed"`
+
+		// AtRisk is the total number of unresolved obligations whose proof is
+		// at risk and Deadlines contains the windows with at least one proof
+		// deadline, sorted by height. Obligations whose deadline already
+		// passed are part of the first window.
+		AtRisk    uint64                    `json:"atrisk"`
+		Deadlines []HostProofDeadlineWindow `json:"deadlines"`
+	}
+
+	// HostSettingsPreview describes the effects of applying proposed internal
+	// settings to the host. Settings with errors are rejected by the host,
+	// warnings describe effects on the host's existing obligations and
+	// accounts the operator should be aware of.
+	HostSettingsPreview struct {
+		Errors   []string `json:"errors"`
+		Warnings []string `json:"warnings"`
+
+		// SettingsHash is the hash of the host's current internal settings.
+		// Passing it to ApplyInternalSettings guarantees that the previewed
+		// settings are only applied if the current settings didn't change in
+		// the meantime.
+		SettingsHash crypto.Hash `json:"settingshash"`
+	}
+
 	// StorageObligation contains information about a storage obligation that
 	// the host has accepted.
 	StorageObligation struct {
@@ -315,6 +806,7 @@ type (
 		PotentialDownloadRevenue types.Currency       `json:"potentialdownloadrevenue"`
 		PotentialStorageRevenue  types.Currency       `json:"potentialstoragerevenue"`
 		PotentialUploadRevenue   types.Currency       `json:"potentialuploadrevenue"`
+		ProofAttempts            uint64               `json:"proofattempts"`
 		RiskedCollateral         types.Currency       `json:"riskedcollateral"`
 		SectorRootsCount         uint64               `json:"sectorrootscount"`
 		TransactionFeesAdded     types.Currency       `json:"transactionfeesadded"`
@@ -384,12 +876,29 @@ type (
 		// running out of storage unexpectedly.
 		AddStorageFolder(path string, size uint64) error
 
+		// AccountTransactions returns the deposits, withdrawals and refunds of
+		// an ephemeral account recorded in the host's audit log.
+		AccountTransactions(id AccountID) ([]HostAccountTransaction, error)
+
 		// Announce submits a host announcement to the blockchain.
 		Announce() error
 
 		// AnnounceAddress submits an announcement using the given address.
 		AnnounceAddress(NetAddress) error
 
+		// PauseStorageFolderDrain pauses or resumes the drain of a storage
+		// folder.
+		PauseStorageFolderDrain(index uint16, paused bool) error
+
+		// PreviewAnnouncement builds the announcement transaction for the
+		// given address, or the host's current address if empty, without
+		// submitting it.
+		PreviewAnnouncement(NetAddress) (HostAnnouncementPreview, error)
+
+		// ApplyInternalSettings sets the hosting parameters of the host if
+		// the hash of its current internal settings matches settingsHash.
+		ApplyInternalSettings(settings HostInternalSettings, settingsHash crypto.Hash) error
+
 		// The host needs to be able to shut down.
 		Close() error
 
@@ -405,6 +914,15 @@ type (
 		// requests to remove data.
 		DeleteSector(sectorRoot crypto.Hash) error
 
+		// DrainStorageFolder migrates the sectors of a storage folder to the
+		// other storage folders in the background at the given rate and
+		// removes the storage folder once it's empty.
+		DrainStorageFolder(index uint16, sectorsPerSecond uint64) error
+
+		// ExportAccountTransactions writes the ephemeral account transactions
+		// recorded between start and end to w as CSV.
+		ExportAccountTransactions(w io.Writer, start, end time.Time) error
+
 		// ExternalSettings returns the settings of the host as seen by an
 		// untrusted node querying the host for settings.
 		ExternalSettings() HostExternalSettings
@@ -412,22 +930,64 @@ type (
 		// BandwidthCounters returns the Hosts's upload and download bandwidth
 		BandwidthCounters() (uint64, uint64, time.Time, error)
 
+		// BandwidthMetrics returns the bandwidth the host used between start
+		// and end per day and per RPC. Unlike the BandwidthCounters, the
+		// metrics are persisted across restarts.
+		BandwidthMetrics(start, end time.Time) (HostBandwidthMetrics, error)
+
 		// FinancialMetrics returns the financial statistics of the host.
 		FinancialMetrics() HostFinancialMetrics
 
+		// MetricsReconciliation returns the result of reconciling the
+		// persisted financial metrics with the metrics journal at startup.
+		MetricsReconciliation() MetricsReconciliation
+
 		// InternalSettings returns the host's internal settings, including
 		// potentially private or sensitive information.
 		InternalSettings() HostInternalSettings
 
+		// MDMMetrics returns the execution statistics of the instructions the
+		// host's MDM executed.
+		MDMMetrics() HostMDMMetrics
+
+		// MissedProofs returns all storage proofs the host failed to submit.
+		MissedProofs() ([]HostMissedProof, error)
+
 		// NetworkMetrics returns information on the types of RPC calls that
 		// have been made to the host.
 		NetworkMetrics() HostNetworkMetrics
 
+		// ObligationsSummary aggregates the host's storage obligations by
+		// status and groups the proof deadlines of the unresolved obligations
+		// into windows of the provided number of blocks.
+		ObligationsSummary(window types.BlockHeight) (HostObligationsSummary, error)
+
+		// ReadCacheMetrics returns the statistics of the host's sector cache.
+		ReadCacheMetrics() HostReadCacheMetrics
+
+		// RPCTrace returns the latency, bandwidth and error statistics of the
+		// RPCs the host handled over the SiaMux.
+		RPCTrace() HostRPCTrace
+
 		PaymentProcessor
 
 		// PriceTable returns the host's current price table.
 		PriceTable() RPCPriceTable
 
+		// PricingStatus returns the host's pricing policy and the prices it
+		// derived from it.
+		PricingStatus() HostPricingStatus
+
+		// PreviewInternalSettings validates the settings against the host's
+		// current obligations without applying them.
+		PreviewInternalSettings(HostInternalSettings) (HostSettingsPreview, error)
+
+		// ProofFees returns the fees the host spent on storage proof and final
+		// revision transactions submitted between start and end, both
+		// inclusive, grouped into periods of the provided number of blocks.
+		// The end is capped at the host's current block height.
+		ProofFees(start, end, period types.BlockHeight) (HostProofFeeReport, error)
+
 		// PruneStaleStorageObligations will delete storage obligations from the
 		// host that, for whatever reason, did not make it on the block chain.
 		// As these stale storage obligations have an impact on the host
@@ -468,6 +1028,15 @@ type (
 		// storage folder.
 		ResetStorageFolderHealth(index uint16) error
 
+		// Rescan starts a rescan of the blocks between start and end, both
+		// inclusive, for transactions affecting the host's storage obligations
+		// and for announcements of the host. It is meant to be used after
+		// restoring the host from a backup.
+		Rescan(start, end types.BlockHeight) error
+
+		// RescanStatus returns the progress of the most recent rescan.
+		RescanStatus() HostRescanStatus
+
 		// ResizeStorageFolder will grow or shrink a storage folder on the host.
 		// The host may not check that there is enough space on-disk to support
 		// growing the storage folder, but should gracefully handle running out
@@ -482,6 +1051,10 @@ type (
 		// SetInternalSettings sets the hosting parameters of the host.
 		SetInternalSettings(HostInternalSettings) error
 
+		// SetPricingPolicy sets the policy the host uses to update its prices
+		// automatically.
+		SetPricingPolicy(HostPricingPolicy) error
+
 		// StorageObligation returns the storage obligation matching the id or
 		// an error if it does not exist
 		StorageObligation(obligationID types.FileContractID) (StorageObligation, error)
@@ -494,6 +1067,10 @@ type (
 		// host.
 		StorageFolders() []StorageFolderMetadata
 
+		// StorageHealth returns the results of the scrubber which verifies
+		// the integrity of the host's sectors.
+		StorageHealth() StorageHealth
+
 		// WorkingStatus returns the working state of the host, determined by if
 		// settings calls are increasing.
 		WorkingStatus() HostWorkingStatus
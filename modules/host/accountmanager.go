@@ -43,6 +43,10 @@ var (
 	// withdrawal message that has been spent already.
 	ErrWithdrawalSpent = errors.New("withdrawal message was already spent")
 
+	// ErrAccountBalanceQueryReplayed occurs when a signed account balance
+	// query is used more than once.
+	ErrAccountBalanceQueryReplayed = errors.New("account balance query was already used")
+
 	// ErrZeroAccountID occurs when an account is opened with the ZeroAccountID.
 	ErrZeroAccountID = errors.New("can't open an account with an empty account id")
 
@@ -291,8 +295,9 @@ func newFingerprintMap() *fingerprintMap {
 	}
 }
 
-// callDeposit calls managedDeposit with refund set to 'false'.
-func (am *accountManager) callDeposit(id modules.AccountID, amount types.Currency, syncChan chan struct{}) error {
+// callDeposit calls managedDeposit with refund set to 'false'. The deposit is
+// recorded in the account transaction log with the given origin.
+func (am *accountManager) callDeposit(id modules.AccountID, amount types.Currency, syncChan chan struct{}, origin string) error {
 	// disrupt if the 'lowerDeposit' dependency is set, this dependency will
 	// alter the deposit amount without the renter being aware of it, used to
 	// test the balance sync after unclean shutdown
@@ -300,19 +305,30 @@ func (am *accountManager) callDeposit(id modules.AccountID, amount types.Currenc
 		amount = amount.Sub(types.SiacoinPrecision.Div64(10))
 	}
 
-	return am.managedDeposit(id, amount, false, syncChan)
+	err := am.managedDeposit(id, amount, false, syncChan)
+	if err != nil {
+		return err
+	}
+	am.h.staticAccountTransactionLog.managedRecord(id, modules.HostAccountTransactionDeposit, amount, origin)
+	return nil
 }
 
 // callRefund calls managedDeposit with refund set to 'true' and a closed
-// syncChan.
-func (am *accountManager) callRefund(id modules.AccountID, amount types.Currency) error {
+// syncChan. The refund is recorded in the account transaction log with the
+// given origin.
+func (am *accountManager) callRefund(id modules.AccountID, amount types.Currency, origin string) error {
 	// Nothing to refund.
 	if amount.IsZero() {
 		return nil
 	}
 	syncChan := make(chan struct{})
 	close(syncChan)
-	return am.managedDeposit(id, amount, true, syncChan)
+	err := am.managedDeposit(id, amount, true, syncChan)
+	if err != nil {
+		return err
+	}
+	am.h.staticAccountTransactionLog.managedRecord(id, modules.HostAccountTransactionRefund, amount, origin)
+	return nil
 }
 
 // managedDeposit will deposit the amount into the ephemeral account with given
@@ -382,7 +398,7 @@ func (am *accountManager) managedDeposit(id modules.AccountID, amount types.Curr
 // caller can specify a priority. This priority defines the order in which the
 // withdrawals get processed in the event they are blocked due to insufficient
 // funds.
-func (am *accountManager) callWithdraw(msg *modules.WithdrawalMessage, sig crypto.Signature, priority int64, bh types.BlockHeight) error {
+func (am *accountManager) callWithdraw(msg *modules.WithdrawalMessage, sig crypto.Signature, priority int64, bh types.BlockHeight, origin string) error {
 	// Gather some variables
 	his := am.h.managedInternalSettings()
 	maxRisk := his.MaxEphemeralAccountRisk
@@ -405,7 +421,12 @@ func (am *accountManager) callWithdraw(msg *modules.WithdrawalMessage, sig crypt
 	}
 
 	// Wait for the withdrawal to be committed.
-	return errors.AddContext(am.staticWaitForWithdrawalResult(commitResultChan), "Withdraw failed")
+	err = am.staticWaitForWithdrawalResult(commitResultChan)
+	if err != nil {
+		return errors.AddContext(err, "Withdraw failed")
+	}
+	am.h.staticAccountTransactionLog.managedRecord(msg.Account, modules.HostAccountTransactionWithdrawal, msg.Amount, origin)
+	return nil
 }
 
 // callConsensusChanged is called by the host whenever it processed a change to
@@ -956,6 +977,43 @@ func (am *accountManager) callAccountBalance(id modules.AccountID) types.Currenc
 	return account.balance
 }
 
+// callAccountsAboveBalance returns the number of accounts with a balance
+// greater than the provided balance.
+func (am *accountManager) callAccountsAboveBalance(balance types.Currency) int {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	var n int
+	for _, acc := range am.accounts {
+		if acc.balance.Cmp(balance) > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// callSignedAccountBalance returns the balance of the account of a signed
+// balance query after validating the query. The query's fingerprint is added
+// to the in-memory fingerprints to prevent it from being replayed. Unlike the
+// fingerprints of withdrawals, it is not persisted since a replayed query only
+// reveals the balance to someone who already saw the signed query.
+func (am *accountManager) callSignedAccountBalance(msg modules.AccountBalanceMessage, sig crypto.Signature, bh types.BlockHeight) (types.Currency, error) {
+	if err := msg.Validate(bh, bh+bucketBlockRange, sig); err != nil {
+		return types.ZeroCurrency, err
+	}
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	fp := msg.Hash()
+	if am.fingerprints.has(fp) {
+		return types.ZeroCurrency, ErrAccountBalanceQueryReplayed
+	}
+	am.fingerprints.add(fp, msg.Expiry, bh)
+	account, exists := am.accounts[msg.Account]
+	if !exists {
+		return types.ZeroCurrency, nil
+	}
+	return account.balance, nil
+}
+
 // openAccount will return an account object. If the account does not exist it
 // will be created.
 func (am *accountManager) openAccount(id modules.AccountID) (*account, error) {
@@ -90,7 +90,7 @@ func TestAccountMaxBalance(t *testing.T) {
 		t.Fatal(err)
 	}
 	// A refund should ignore the max account balance.
-	err = am.callRefund(accountID, exceedingBalance)
+	err = am.callRefund(accountID, exceedingBalance, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1193,7 +1193,7 @@ func managedAccountIndexCheck(am *accountManager) string {
 
 // callWithdraw will perform the withdrawal using a timestamp for the priority
 func callWithdraw(am *accountManager, msg *modules.WithdrawalMessage, sig crypto.Signature, bh types.BlockHeight) error {
-	return am.callWithdraw(msg, sig, time.Now().UnixNano(), bh)
+	return am.callWithdraw(msg, sig, time.Now().UnixNano(), bh, "")
 }
 
 // callDeposit will perform the deposit on the account manager and close out the
@@ -1211,7 +1211,7 @@ func callDeposit(am *accountManager, id modules.AccountID, amount types.Currency
 	go func() {
 		defer wg.Done()
 		<-startChan
-		err = am.callDeposit(id, amount, syncChan)
+		err = am.callDeposit(id, amount, syncChan, "")
 	}()
 	go func() {
 		defer wg.Done()
@@ -8,6 +8,7 @@ import (
 	"regexp"
 	"strconv"
 	"sync"
+	"time"
 
 	"gitlab.com/NebulousLabs/errors"
 
@@ -75,6 +76,10 @@ type (
 
 		staticFingerprintManager *fingerprintManager
 
+		// quiesceMu is held as a readlock while accounts are written to disk
+		// and as a writelock while the persister is quiesced.
+		quiesceMu sync.RWMutex
+
 		mu sync.Mutex
 		h  *Host
 	}
@@ -228,6 +233,8 @@ func (ap *accountsPersister) callLoadData() (*accountsPersisterData, error) {
 // callSaveAccount will persist the given account data at the location
 // corresponding to the given index.
 func (ap *accountsPersister) callSaveAccount(data *accountData, index uint32) error {
+	ap.quiesceMu.RLock()
+	defer ap.quiesceMu.RUnlock()
 	ap.managedLockIndex(index)
 	defer ap.managedUnlockIndex(index)
 
@@ -258,6 +265,9 @@ func (ap *accountsPersister) callQueueSaveFingerprint(hash crypto.Hash, expiry t
 // callBatchDeleteAccount will overwrite the accounts at given indexes with
 // zero-bytes. Effectively deleting it.
 func (ap *accountsPersister) callBatchDeleteAccount(indexes []uint32) (deleted []uint32, err error) {
+	ap.quiesceMu.RLock()
+	defer ap.quiesceMu.RUnlock()
+
 	results := make([]error, len(indexes))
 	zeroBytes := make([]byte, accountSize)
 
@@ -333,8 +343,37 @@ func (ap *accountsPersister) callRotateFingerprintBuckets() (err error) {
 	return nil
 }
 
+// callQuiesce syncs the accounts and fingerprints to disk and blocks further
+// writes until resume is called.
+func (ap *accountsPersister) callQuiesce() (resume func(), err error) {
+	fm := ap.staticFingerprintManager
+	ap.quiesceMu.Lock()
+	fm.mu.Lock()
+	resume = func() {
+		fm.mu.Unlock()
+		ap.quiesceMu.Unlock()
+	}
+	err = errors.Compose(ap.accounts.Sync(), fm.current.Sync(), fm.next.Sync())
+	if err != nil {
+		resume()
+		return nil, errors.AddContext(err, "failed to sync accounts")
+	}
+	return resume, nil
+}
+
 // callClose will cleanly shutdown the account persister's open file handles
 func (ap *accountsPersister) callClose() error {
+	// Save the fingerprints which are still queued because the save loop was
+	// stopped while collecting a batch.
+	for {
+		fp, ok := ap.staticFingerprintManager.managedPopQueuedFingerprint()
+		if !ok {
+			break
+		}
+		if err := ap.staticFingerprintManager.managedSave(fp); err != nil {
+			ap.h.log.Println("ERROR: could not save fingerprint", err)
+		}
+	}
 	ap.staticFingerprintManager.mu.Lock()
 	err1 := ap.staticFingerprintManager.syncAndClose()
 	ap.staticFingerprintManager.mu.Unlock()
@@ -478,7 +517,10 @@ func (ap *accountsPersister) loadFingerprints(path string, m map[crypto.Hash]str
 
 // threadedSaveFingerprintsLoop continuously checks if fingerprints got added to
 // the queue and will save them. The loop blocks until it receives a message on
-// the wakeChan, or until it receives a stop signal.
+// the wakeChan, or until it receives a stop signal. If the daemon profile sets
+// an account save batch interval, the loop waits for that long after being
+// woken up to write the fingerprints which were added in the meantime in one
+// go.
 //
 // Note: threadgroup counter must be inside for loop. If not, calling 'Flush'
 // on the threadgroup would deadlock.
@@ -491,16 +533,11 @@ func (fm *fingerprintManager) threadedSaveFingerprintsLoop() {
 			}
 			defer fm.h.tg.Done()
 
-			fm.staticSaveFingerprintsQueue.mu.Lock()
-			if len(fm.staticSaveFingerprintsQueue.queue) == 0 {
-				fm.staticSaveFingerprintsQueue.mu.Unlock()
+			fp, ok := fm.managedPopQueuedFingerprint()
+			if !ok {
 				return
 			}
 
-			fp := fm.staticSaveFingerprintsQueue.queue[0]
-			fm.staticSaveFingerprintsQueue.queue = fm.staticSaveFingerprintsQueue.queue[1:]
-			fm.staticSaveFingerprintsQueue.mu.Unlock()
-
 			err := fm.managedSave(fp)
 			if err != nil {
 				fm.h.log.Fatal("Could not save fingerprint", err)
@@ -514,11 +551,33 @@ func (fm *fingerprintManager) threadedSaveFingerprintsLoop() {
 
 		select {
 		case <-fm.wakeChan:
-			continue
 		case <-fm.h.tg.StopChan():
 			return
 		}
+
+		// Collect the fingerprints of a batch. The queued fingerprints are
+		// saved on shutdown if the loop is stopped in the meantime.
+		if interval := modules.CurrentProfile().AccountSaveBatchInterval; interval > 0 {
+			select {
+			case <-time.After(interval):
+			case <-fm.h.tg.StopChan():
+				return
+			}
+		}
+	}
+}
+
+// managedPopQueuedFingerprint removes the first fingerprint from the save
+// queue and returns it. If the queue is empty, false is returned.
+func (fm *fingerprintManager) managedPopQueuedFingerprint() (fingerprint, bool) {
+	fm.staticSaveFingerprintsQueue.mu.Lock()
+	defer fm.staticSaveFingerprintsQueue.mu.Unlock()
+	if len(fm.staticSaveFingerprintsQueue.queue) == 0 {
+		return fingerprint{}, false
 	}
+	fp := fm.staticSaveFingerprintsQueue.queue[0]
+	fm.staticSaveFingerprintsQueue.queue = fm.staticSaveFingerprintsQueue.queue[1:]
+	return fp, true
 }
 
 // managedSave will persist the given fingerprint into the appropriate bucket
@@ -145,6 +145,75 @@ func TestFingerprintsReload(t *testing.T) {
 	}
 }
 
+// TestFingerprintsBatchedReload verifies fingerprints are batched when running
+// with the low power profile and that fingerprints which are still queued are
+// saved on shutdown.
+func TestFingerprintsBatchedReload(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	// Not parallel since the profile is process-wide.
+
+	if err := modules.SetProfile(modules.ProfileLowPower); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := modules.SetProfile(modules.ProfileDefault); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	ht, err := blankHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := ht.Close()
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+	am := ht.host.staticAccountManager
+
+	// Prepare an account
+	sk, accountID := prepareAccount()
+	err = callDeposit(am, accountID, types.NewCurrency64(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Withdraw a couple of times and wait for the first batch to be saved.
+	var msgs []*modules.WithdrawalMessage
+	for i := 0; i < 5; i++ {
+		msg, sig := prepareWithdrawal(accountID, types.NewCurrency64(1), am.h.BlockHeight()+10, sk)
+		if err := callWithdraw(am, msg, sig, am.h.BlockHeight()); err != nil {
+			t.Fatal(err)
+		}
+		msgs = append(msgs, msg)
+	}
+	time.Sleep(modules.CurrentProfile().AccountSaveBatchInterval * 5)
+
+	// Withdraw again and reload the host right away. The fingerprint is most
+	// likely still queued.
+	msg, sig := prepareWithdrawal(accountID, types.NewCurrency64(1), am.h.BlockHeight()+10, sk)
+	if err := callWithdraw(am, msg, sig, am.h.BlockHeight()); err != nil {
+		t.Fatal(err)
+	}
+	msgs = append(msgs, msg)
+	err = reloadHost(ht)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Verify all fingerprints got reloaded
+	am = ht.host.staticAccountManager
+	for i, msg := range msgs {
+		if !am.fingerprints.has(crypto.HashObject(*msg)) {
+			t.Fatalf("Fingerprint %v not found after reload", i)
+		}
+	}
+}
+
 // TestFingerprintsRotate will verify if mining blocks properly rotates the
 // fingerprints, both in-memory and on-disk.
 func TestFingerprintsRotate(t *testing.T) {
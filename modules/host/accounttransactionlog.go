@@ -0,0 +1,232 @@
+package host
+
+import (
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/siamux"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// accountTransactionLogFlushInterval is the interval at which the account
+	// transaction log is written to the database.
+	accountTransactionLogFlushInterval = build.Select(build.Var{
+		Standard: 10 * time.Second,
+		Dev:      5 * time.Second,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// accountTransactionsCSVHeader is the header of the CSV export of the
+	// account transaction log.
+	accountTransactionsCSVHeader = []string{"timestamp", "account", "type", "amount", "origin"}
+)
+
+// accountTransactionLog is an append-only audit log of the deposits,
+// withdrawals and refunds of the host's ephemeral accounts. Transactions are
+// collected in memory and periodically appended to the host's database to
+// avoid a database transaction for every payment.
+type accountTransactionLog struct {
+	pending []modules.HostAccountTransaction
+
+	staticDB *persist.BoltDatabase
+
+	mu sync.Mutex
+}
+
+// newAccountTransactionLog creates a new log which persists the transactions
+// in db.
+func newAccountTransactionLog(db *persist.BoltDatabase) *accountTransactionLog {
+	return &accountTransactionLog{
+		staticDB: db,
+	}
+}
+
+// streamOrigin returns the origin of an account transaction caused by an RPC
+// on the given stream.
+func streamOrigin(stream siamux.Stream) string {
+	return stream.RemoteAddr().String()
+}
+
+// managedRecord adds a transaction of an account to the log.
+func (l *accountTransactionLog) managedRecord(id modules.AccountID, txnType modules.HostAccountTransactionType, amount types.Currency, origin string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pending = append(l.pending, modules.HostAccountTransaction{
+		Account:   id.String(),
+		Amount:    amount,
+		Origin:    origin,
+		Timestamp: time.Now(),
+		Type:      txnType,
+	})
+}
+
+// managedFlush appends the pending transactions to the database.
+func (l *accountTransactionLog) managedFlush() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.pending) == 0 {
+		return nil
+	}
+	err := l.staticDB.Update(func(tx *bolt.Tx) error {
+		for _, txn := range l.pending {
+			b, err := tx.Bucket(bucketAccountTransactions).CreateBucketIfNotExists([]byte(txn.Account))
+			if err != nil {
+				return err
+			}
+			seq, err := b.NextSequence()
+			if err != nil {
+				return err
+			}
+			key := make([]byte, 16)
+			binary.BigEndian.PutUint64(key, uint64(txn.Timestamp.UnixNano()))
+			binary.BigEndian.PutUint64(key[8:], seq)
+			txnBytes, err := json.Marshal(txn)
+			if err != nil {
+				return errors.AddContext(err, "failed to encode account transaction")
+			}
+			if err := b.Put(key, txnBytes); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	l.pending = l.pending[:0]
+	return nil
+}
+
+// managedTransactions returns the transactions of the given account which
+// happened between start and end, sorted by time. If account is empty, the
+// transactions of all accounts are returned.
+func (l *accountTransactionLog) managedTransactions(account string, start, end time.Time) ([]modules.HostAccountTransaction, error) {
+	// Transactions are keyed by their unix nano timestamp, so the range can't
+	// start before the epoch.
+	if epoch := time.Unix(0, 0); start.Before(epoch) {
+		start = epoch
+	}
+	inRange := func(txn modules.HostAccountTransaction) bool {
+		return !txn.Timestamp.Before(start) && !txn.Timestamp.After(end)
+	}
+	startKey := make([]byte, 8)
+	binary.BigEndian.PutUint64(startKey, uint64(start.UnixNano()))
+
+	// Hold the lock while reading the database to avoid returning transactions
+	// which are being flushed twice.
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var txns []modules.HostAccountTransaction
+	err := l.staticDB.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketAccountTransactions).ForEach(func(id, _ []byte) error {
+			if account != "" && string(id) != account {
+				return nil
+			}
+			c := tx.Bucket(bucketAccountTransactions).Bucket(id).Cursor()
+			for k, v := c.Seek(startKey); k != nil; k, v = c.Next() {
+				var txn modules.HostAccountTransaction
+				if err := json.Unmarshal(v, &txn); err != nil {
+					return errors.AddContext(err, "failed to decode account transaction")
+				}
+				if txn.Timestamp.After(end) {
+					break
+				}
+				txns = append(txns, txn)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, txn := range l.pending {
+		if (account == "" || txn.Account == account) && inRange(txn) {
+			txns = append(txns, txn)
+		}
+	}
+	sort.SliceStable(txns, func(i, j int) bool {
+		return txns[i].Timestamp.Before(txns[j].Timestamp)
+	})
+	return txns, nil
+}
+
+// threadedFlushAccountTransactionLog periodically writes the account
+// transaction log to the database.
+func (h *Host) threadedFlushAccountTransactionLog() {
+	for {
+		select {
+		case <-h.tg.StopChan():
+			return
+		case <-time.After(accountTransactionLogFlushInterval):
+		}
+		func() {
+			if err := h.tg.Add(); err != nil {
+				return
+			}
+			defer h.tg.Done()
+			if err := h.staticAccountTransactionLog.managedFlush(); err != nil {
+				h.log.Println("ERROR: failed to persist account transaction log:", err)
+			}
+		}()
+	}
+}
+
+// AccountTransactions returns the deposits, withdrawals and refunds of an
+// ephemeral account recorded in the host's audit log.
+func (h *Host) AccountTransactions(id modules.AccountID) ([]modules.HostAccountTransaction, error) {
+	if err := h.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer h.tg.Done()
+	if id.IsZeroAccount() {
+		return nil, ErrZeroAccountID
+	}
+	return h.staticAccountTransactionLog.managedTransactions(id.String(), time.Unix(0, 0), time.Unix(0, math.MaxInt64))
+}
+
+// ExportAccountTransactions writes the ephemeral account transactions
+// recorded between start and end to w as CSV.
+func (h *Host) ExportAccountTransactions(w io.Writer, start, end time.Time) error {
+	if err := h.tg.Add(); err != nil {
+		return err
+	}
+	defer h.tg.Done()
+	if end.Before(start) {
+		return errors.New("end of the export is before its start")
+	}
+	txns, err := h.staticAccountTransactionLog.managedTransactions("", start, end)
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(accountTransactionsCSVHeader); err != nil {
+		return err
+	}
+	for _, txn := range txns {
+		err := cw.Write([]string{
+			txn.Timestamp.UTC().Format(time.RFC3339Nano),
+			txn.Account,
+			string(txn.Type),
+			txn.Amount.String(),
+			txn.Origin,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,107 @@
+package host
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+)
+
+// TestAccountTransactionLog tests that the deposits, withdrawals and refunds
+// of an ephemeral account are recorded in the account transaction log.
+func TestAccountTransactionLog(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rhp, err := newRenterHostPair(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := rhp.Close()
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+	h := rhp.staticHT.host
+
+	// The zero account is rejected.
+	if _, err := h.AccountTransactions(modules.ZeroAccountID); !errors.Contains(err, ErrZeroAccountID) {
+		t.Fatal("expected ErrZeroAccountID", err)
+	}
+
+	// Fund the account, pay for a balance query from the account and overpay
+	// a balance query by contract to get a refund.
+	start := time.Now()
+	deposit := h.managedInternalSettings().MaxEphemeralAccountBalance.Div64(2)
+	if _, err := rhp.managedFundEphemeralAccount(deposit, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rhp.AccountBalance(false); err != nil {
+		t.Fatal(err)
+	}
+	overpay := rhp.pt.AccountBalanceCost.Mul64(2)
+	if _, err := rhp.managedAccountBalance(true, overpay, rhp.staticAccountID, rhp.staticAccountID); err != nil {
+		t.Fatal(err)
+	}
+
+	// Check the log before and after flushing it.
+	checkTransactions := func() {
+		txns, err := h.AccountTransactions(rhp.staticAccountID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(txns) != 3 {
+			t.Fatal("wrong number of transactions", len(txns))
+		}
+		expected := []struct {
+			txnType modules.HostAccountTransactionType
+			amount  string
+		}{
+			{modules.HostAccountTransactionDeposit, deposit.Sub(rhp.pt.FundAccountCost).String()},
+			{modules.HostAccountTransactionWithdrawal, rhp.pt.AccountBalanceCost.String()},
+			{modules.HostAccountTransactionRefund, overpay.Sub(rhp.pt.AccountBalanceCost).String()},
+		}
+		for i, txn := range txns {
+			if txn.Type != expected[i].txnType || txn.Amount.String() != expected[i].amount {
+				t.Fatal("wrong transaction", i, txn)
+			}
+			if txn.Account != rhp.staticAccountID.String() || txn.Origin == "" || txn.Timestamp.Before(start) {
+				t.Fatal("wrong transaction", i, txn)
+			}
+		}
+	}
+	checkTransactions()
+	if err := h.staticAccountTransactionLog.managedFlush(); err != nil {
+		t.Fatal(err)
+	}
+	checkTransactions()
+
+	// Export the transactions.
+	var buf bytes.Buffer
+	if err := h.ExportAccountTransactions(&buf, start, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 4 || records[0][0] != "timestamp" || records[1][2] != string(modules.HostAccountTransactionDeposit) {
+		t.Fatal("wrong export", records)
+	}
+
+	// An export of a period without transactions only contains the header.
+	buf.Reset()
+	if err := h.ExportAccountTransactions(&buf, time.Unix(0, 0), start.Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if records, err := csv.NewReader(&buf).ReadAll(); err != nil || len(records) != 1 {
+		t.Fatal("expected only the header", records, err)
+	}
+}
@@ -1,6 +1,14 @@
 package host
 
-import "go.sia.tech/siad/modules"
+import (
+	"fmt"
+	"time"
+
+	"gitlab.com/NebulousLabs/bolt"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
 
 // Alerts implements the modules.Alerter interface for the host.
 func (h *Host) Alerts() (crit, err, warn []modules.Alert) {
@@ -22,3 +30,107 @@ func (h *Host) tryUnregisterInsufficientCollateralBudgetAlert() {
 		h.staticAlerter.UnregisterAlert(modules.AlertIDHostInsufficientCollateral)
 	}
 }
+
+// threadedCheckAlerts periodically checks the host's remaining collateral
+// budget and wallet balance against the alert thresholds and builds the missed
+// proofs alert from the missed proofs in the database.
+//
+// Note: threadgroup counter must be inside for loop. If not, calling 'Flush'
+// on the threadgroup would deadlock.
+func (h *Host) threadedCheckAlerts() {
+	defer modules.RecoverPanic("host")
+	for {
+		func() {
+			if err := h.tg.Add(); err != nil {
+				return
+			}
+			defer h.tg.Done()
+			h.managedCheckAlerts()
+		}()
+
+		select {
+		case <-h.tg.StopChan():
+			return
+		case <-time.After(alertCheckFrequency):
+		}
+	}
+}
+
+// managedCheckAlerts registers or unregisters the host's threshold based
+// alerts.
+func (h *Host) managedCheckAlerts() {
+	h.mu.Lock()
+	settings := h.settings
+	remainingBudget := types.ZeroCurrency
+	if h.settings.CollateralBudget.Cmp(h.financialMetrics.LockedStorageCollateral) > 0 {
+		remainingBudget = h.settings.CollateralBudget.Sub(h.financialMetrics.LockedStorageCollateral)
+	}
+	h.updateMissedProofsAlert()
+	h.mu.Unlock()
+
+	// Check the remaining collateral budget.
+	threshold := settings.CollateralBudgetAlertThreshold
+	if !threshold.IsZero() && remainingBudget.Cmp(threshold) < 0 {
+		cause := fmt.Sprintf("%v of the collateral budget of %v remaining, threshold is %v", remainingBudget.HumanString(), settings.CollateralBudget.HumanString(), threshold.HumanString())
+		h.staticAlerter.RegisterAlert(modules.AlertIDHostLowCollateralBudget, AlertMSGHostLowCollateralBudget, cause, modules.SeverityWarning)
+	} else {
+		h.staticAlerter.UnregisterAlert(modules.AlertIDHostLowCollateralBudget)
+	}
+
+	// Check the wallet balance. The wallet is queried without holding the
+	// host's lock.
+	threshold = settings.WalletBalanceAlertThreshold
+	if threshold.IsZero() {
+		h.staticAlerter.UnregisterAlert(modules.AlertIDHostLowWalletBalance)
+		return
+	}
+	balance, _, _, err := h.wallet.ConfirmedBalance()
+	if err != nil {
+		h.log.Debugln("WARN: failed to get wallet balance for alerts:", err)
+		return
+	}
+	if balance.Cmp(threshold) < 0 {
+		cause := fmt.Sprintf("confirmed balance is %v, threshold is %v", balance.HumanString(), threshold.HumanString())
+		h.staticAlerter.RegisterAlert(modules.AlertIDHostLowWalletBalance, AlertMSGHostLowWalletBalance, cause, modules.SeverityWarning)
+	} else {
+		h.staticAlerter.UnregisterAlert(modules.AlertIDHostLowWalletBalance)
+	}
+}
+
+// updateMissedProofsAlert registers the missed proofs alert while the
+// database contains proofs which were missed within the last
+// missedProofsAlertWindow blocks.
+func (h *Host) updateMissedProofsAlert() {
+	var mps []modules.HostMissedProof
+	err := h.db.View(func(tx *bolt.Tx) (err error) {
+		mps, err = missedProofs(tx)
+		return err
+	})
+	if err != nil {
+		h.log.Println("WARN: failed to load missed proofs for alerts:", err)
+		return
+	}
+	var recent int
+	for _, mp := range mps {
+		if mp.MissedHeight+missedProofsAlertWindow > h.blockHeight {
+			recent++
+		}
+	}
+	if recent == 0 {
+		h.staticAlerter.UnregisterAlert(modules.AlertIDHostMissedProofs)
+		return
+	}
+	cause := fmt.Sprintf("%v storage proofs were missed within the last %v blocks, check /host/missedproofs for details", recent, missedProofsAlertWindow)
+	h.staticAlerter.RegisterAlert(modules.AlertIDHostMissedProofs, AlertMSGHostMissedProofs, cause, modules.SeverityCritical)
+}
+
+// updateConnectabilityAlert registers the not connectable alert while the
+// host isn't connectable at its netaddress.
+func (h *Host) updateConnectabilityAlert(addr modules.NetAddress, status modules.HostConnectabilityStatus, err error) {
+	if status == modules.HostConnectabilityStatusConnectable {
+		h.staticAlerter.UnregisterAlert(modules.AlertIDHostNotConnectable)
+		return
+	}
+	cause := fmt.Sprintf("failed to connect to %v: %v", addr, err)
+	h.staticAlerter.RegisterAlert(modules.AlertIDHostNotConnectable, AlertMSGHostNotConnectable, cause, modules.SeverityError)
+}
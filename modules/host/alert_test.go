@@ -0,0 +1,112 @@
+package host
+
+import (
+	"errors"
+	"testing"
+
+	"gitlab.com/NebulousLabs/bolt"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// hasAlert is a helper that returns true if the host registered an alert with
+// the given id.
+func (h *Host) hasAlert(id modules.AlertID) bool {
+	crit, err, warn := h.staticAlerter.Alerts()
+	for _, alert := range append(crit, append(err, warn...)...) {
+		if alert.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// TestCheckAlerts checks that the host registers and unregisters its
+// threshold based alerts.
+func TestCheckAlerts(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	h := ht.host
+
+	// By default the thresholds are disabled.
+	h.managedCheckAlerts()
+	if h.hasAlert(modules.AlertIDHostLowCollateralBudget) || h.hasAlert(modules.AlertIDHostLowWalletBalance) {
+		t.Fatal("alerts shouldn't be registered")
+	}
+
+	// Set thresholds which can't be met.
+	settings := h.InternalSettings()
+	huge := types.SiacoinPrecision.Mul64(1e12)
+	settings.CollateralBudgetAlertThreshold = settings.CollateralBudget.Add(types.NewCurrency64(1))
+	settings.WalletBalanceAlertThreshold = huge
+	if err := h.SetInternalSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	h.managedCheckAlerts()
+	if !h.hasAlert(modules.AlertIDHostLowCollateralBudget) || !h.hasAlert(modules.AlertIDHostLowWalletBalance) {
+		t.Fatal("alerts should be registered")
+	}
+
+	// Lower the thresholds again.
+	settings.CollateralBudgetAlertThreshold = types.NewCurrency64(1)
+	settings.WalletBalanceAlertThreshold = types.NewCurrency64(1)
+	if err := h.SetInternalSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	h.managedCheckAlerts()
+	if h.hasAlert(modules.AlertIDHostLowCollateralBudget) || h.hasAlert(modules.AlertIDHostLowWalletBalance) {
+		t.Fatal("alerts should be unregistered")
+	}
+
+	// A missed proof is part of the alert until the window passes.
+	h.mu.Lock()
+	height := h.blockHeight
+	h.mu.Unlock()
+	putMP := func(missedHeight types.BlockHeight) {
+		err := h.db.Update(func(tx *bolt.Tx) error {
+			return putMissedProof(tx, modules.HostMissedProof{ObligationID: types.FileContractID{1}, MissedHeight: missedHeight})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	putMP(height)
+	h.managedCheckAlerts()
+	if !h.hasAlert(modules.AlertIDHostMissedProofs) {
+		t.Fatal("missed proofs alert should be registered")
+	}
+	// The alert is built from the database, so it is restored after a
+	// restart cleared the registered alerts.
+	h.staticAlerter.UnregisterAlert(modules.AlertIDHostMissedProofs)
+	h.managedCheckAlerts()
+	if !h.hasAlert(modules.AlertIDHostMissedProofs) {
+		t.Fatal("missed proofs alert should be restored")
+	}
+	putMP(height - missedProofsAlertWindow)
+	h.managedCheckAlerts()
+	if h.hasAlert(modules.AlertIDHostMissedProofs) {
+		t.Fatal("missed proofs alert should be unregistered")
+	}
+
+	// The connectability alert follows the connectability status.
+	h.updateConnectabilityAlert("127.0.0.1:1", modules.HostConnectabilityStatusNotConnectable, errors.New("refused"))
+	if !h.hasAlert(modules.AlertIDHostNotConnectable) {
+		t.Fatal("not connectable alert should be registered")
+	}
+	h.updateConnectabilityAlert("127.0.0.1:1", modules.HostConnectabilityStatusConnectable, nil)
+	if h.hasAlert(modules.AlertIDHostNotConnectable) {
+		t.Fatal("not connectable alert should be unregistered")
+	}
+}
@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"net"
 
+	"gitlab.com/NebulousLabs/encoding"
 	"gitlab.com/NebulousLabs/errors"
 
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
 )
 
 var (
@@ -22,6 +24,39 @@ func differentTypeIPs(ip1, ip2 net.IP) bool {
 	return (ip1.To4() == nil) != (ip2.To4() == nil)
 }
 
+// equalNetAddresses returns true if both slices contain the same addresses in
+// the same order.
+func equalNetAddresses(a, b []modules.NetAddress) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// verifyAdditionalNetAddresses checks that the additional addresses of the
+// host are valid, unique and fit into an announcement.
+func verifyAdditionalNetAddresses(addrs []modules.NetAddress) error {
+	if len(addrs) >= modules.MaxAnnouncementAddresses {
+		return fmt.Errorf("at most %v additional addresses are allowed", modules.MaxAnnouncementAddresses-1)
+	}
+	seen := make(map[modules.NetAddress]struct{})
+	for _, addr := range addrs {
+		if err := addr.IsValid(); err != nil {
+			return fmt.Errorf("address %v is invalid: %v", addr, err)
+		}
+		if _, exists := seen[addr]; exists {
+			return fmt.Errorf("address %v is a duplicate", addr)
+		}
+		seen[addr] = struct{}{}
+	}
+	return nil
+}
+
 // staticVerifyAnnouncementAddress checks that the address is sane and not local.
 func (h *Host) staticVerifyAnnouncementAddress(addr modules.NetAddress) error {
 	// Check that the address is sane, and that the address is also not local.
@@ -31,6 +66,10 @@ func (h *Host) staticVerifyAnnouncementAddress(addr modules.NetAddress) error {
 	if addr.IsLocal() && build.Release == "standard" {
 		return errors.New("announcement requested with local net address")
 	}
+	// Onion addresses can't be resolved without Tor.
+	if addr.IsOnion() {
+		return nil
+	}
 	// Make sure that the host resolves to 1 or 2 IPs and if it resolves to 2
 	// the type should be different.
 	ips, err := h.dependencies.LookupIP(addr.Host())
@@ -49,11 +88,19 @@ func (h *Host) staticVerifyAnnouncementAddress(addr modules.NetAddress) error {
 	return nil
 }
 
-// managedAnnounce creates an announcement transaction and submits it to the network.
-func (h *Host) managedAnnounce(addr modules.NetAddress) (err error) {
-	// Verify address first.
-	if err := h.staticVerifyAnnouncementAddress(addr); err != nil {
-		return err
+// managedBuildAnnouncement creates and signs an announcement transaction for
+// addr and the host's additional addresses. The caller is responsible for
+// either submitting the returned transaction set or dropping the builder.
+func (h *Host) managedBuildAnnouncement(addr modules.NetAddress) (addrs []modules.NetAddress, fee types.Currency, txnSet []types.Transaction, txnBuilder modules.TransactionBuilder, err error) {
+	h.mu.RLock()
+	addrs = append([]modules.NetAddress{addr}, h.settings.AdditionalNetAddresses...)
+	h.mu.RUnlock()
+
+	// Verify the addresses first.
+	for _, addr := range addrs {
+		if err := h.staticVerifyAnnouncementAddress(addr); err != nil {
+			return nil, types.Currency{}, nil, nil, err
+		}
 	}
 
 	// The wallet needs to be unlocked to add fees to the transaction, and the
@@ -61,10 +108,10 @@ func (h *Host) managedAnnounce(addr modules.NetAddress) (err error) {
 	// to.
 	unlocked, err := h.wallet.Unlocked()
 	if err != nil {
-		return err
+		return nil, types.Currency{}, nil, nil, err
 	}
 	if !unlocked {
-		return errAnnWalletLocked
+		return nil, types.Currency{}, nil, nil, errAnnWalletLocked
 	}
 
 	h.mu.Lock()
@@ -73,35 +120,47 @@ func (h *Host) managedAnnounce(addr modules.NetAddress) (err error) {
 	err = h.checkUnlockHash()
 	h.mu.Unlock()
 	if err != nil {
-		return err
+		return nil, types.Currency{}, nil, nil, err
 	}
 
 	// Create the announcement that's going to be added to the arbitrary data
 	// field of the transaction.
-	signedAnnouncement, err := modules.CreateAnnouncement(addr, pubKey, secKey)
+	signedAnnouncement, err := modules.CreateMultiAddressAnnouncement(addrs, pubKey, secKey)
 	if err != nil {
-		return err
+		return nil, types.Currency{}, nil, nil, err
 	}
 
 	// Create a transaction, with a fee, that contains the full announcement.
-	txnBuilder, err := h.wallet.StartTransaction()
+	txnBuilder, err = h.wallet.StartTransaction()
 	if err != nil {
-		return err
+		return nil, types.Currency{}, nil, nil, err
 	}
 	defer func() {
 		if err != nil {
 			txnBuilder.Drop()
 		}
 	}()
-	_, fee := h.tpool.FeeEstimation()
-	fee = fee.Mul64(600) // Estimated txn size (in bytes) of a host announcement.
+	_, fee = h.tpool.FeeEstimation()
+	// Estimated txn size (in bytes) of a host announcement plus an estimate
+	// of the size of each additional address.
+	fee = fee.Mul64(600 + 100*uint64(len(addrs)-1))
 	err = txnBuilder.FundSiacoins(fee)
 	if err != nil {
-		return err
+		return nil, types.Currency{}, nil, nil, err
 	}
 	_ = txnBuilder.AddMinerFee(fee)
 	_ = txnBuilder.AddArbitraryData(signedAnnouncement)
-	txnSet, err := txnBuilder.Sign(true)
+	txnSet, err = txnBuilder.Sign(true)
+	if err != nil {
+		return nil, types.Currency{}, nil, nil, err
+	}
+	return addrs, fee, txnSet, txnBuilder, nil
+}
+
+// managedAnnounce creates an announcement transaction for addr and the host's
+// additional addresses and submits it to the network.
+func (h *Host) managedAnnounce(addr modules.NetAddress) error {
+	addrs, _, txnSet, txnBuilder, err := h.managedBuildAnnouncement(addr)
 	if err != nil {
 		return err
 	}
@@ -109,26 +168,21 @@ func (h *Host) managedAnnounce(addr modules.NetAddress) (err error) {
 	// Add the transactions to the transaction pool.
 	err = h.tpool.AcceptTransactionSet(txnSet)
 	if err != nil {
+		txnBuilder.Drop()
 		return err
 	}
 
 	h.mu.Lock()
 	h.announced = true
 	h.mu.Unlock()
-	h.log.Printf("INFO: Successfully announced as %v", addr)
+	h.log.Printf("INFO: Successfully announced as %v", addrs)
 	return nil
 }
 
-// Announce creates a host announcement transaction.
-func (h *Host) Announce() error {
-	err := h.tg.Add()
-	if err != nil {
-		return err
-	}
-	defer h.tg.Done()
-
-	// Grab the internal net address and internal auto address, and compare
-	// them.
+// managedAnnouncementAddress returns the address the host announces if no
+// address is specified. The address set by the user is preferred over the
+// automatically discovered one.
+func (h *Host) managedAnnouncementAddress() (modules.NetAddress, error) {
 	h.mu.RLock()
 	userSet := h.settings.NetAddress
 	autoSet := h.autoAddress
@@ -136,21 +190,73 @@ func (h *Host) Announce() error {
 
 	// Check that we have at least one address to work with.
 	if userSet == "" && autoSet == "" {
-		return errors.New("cannot announce because address could not be determined")
+		return "", errors.New("cannot announce because address could not be determined")
 	}
-
-	// Prefer using the userSet address, otherwise use the automatic address.
-	var annAddr modules.NetAddress
 	if userSet != "" {
-		annAddr = userSet
-	} else {
-		annAddr = autoSet
+		return userSet, nil
+	}
+	if modules.GlobalProxy.AnnouncementsDisabled() {
+		return "", modules.ErrAnnouncementsDisabled
+	}
+	return autoSet, nil
+}
+
+// Announce creates a host announcement transaction.
+func (h *Host) Announce() error {
+	err := h.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer h.tg.Done()
+
+	annAddr, err := h.managedAnnouncementAddress()
+	if err != nil {
+		return err
 	}
 
 	// Address has cleared inspection, perform the announcement.
 	return h.managedAnnounce(annAddr)
 }
 
+// PreviewAnnouncement builds the announcement transaction for addr without
+// submitting it to the network. If addr is empty, the address which Announce
+// would use is previewed.
+func (h *Host) PreviewAnnouncement(addr modules.NetAddress) (modules.HostAnnouncementPreview, error) {
+	err := h.tg.Add()
+	if err != nil {
+		return modules.HostAnnouncementPreview{}, err
+	}
+	defer h.tg.Done()
+
+	currentAddr, err := h.managedAnnouncementAddress()
+	if addr == "" && err != nil {
+		return modules.HostAnnouncementPreview{}, err
+	} else if addr == "" {
+		addr = currentAddr
+	}
+
+	addrs, fee, txnSet, txnBuilder, err := h.managedBuildAnnouncement(addr)
+	if err != nil {
+		return modules.HostAnnouncementPreview{}, build.ExtendErr("unable to build host announcement", err)
+	}
+	// Release the outputs funding the transaction again.
+	txnBuilder.Drop()
+
+	h.mu.RLock()
+	alreadyAnnounced := h.announced && addr == currentAddr
+	pubKey := h.publicKey
+	h.mu.RUnlock()
+	return modules.HostAnnouncementPreview{
+		NetAddress:             addrs[0],
+		AdditionalNetAddresses: addrs[1:],
+		PublicKey:              pubKey,
+		Fee:                    fee,
+		Size:                   uint64(len(encoding.Marshal(txnSet))),
+		AlreadyAnnounced:       alreadyAnnounced,
+		TransactionSet:         txnSet,
+	}, nil
+}
+
 // AnnounceAddress submits a host announcement to the blockchain to announce a
 // specific address. If there is no error, the host's address will be updated
 // to the supplied address.
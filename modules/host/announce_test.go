@@ -4,6 +4,8 @@ import (
 	"net"
 	"testing"
 
+	"gitlab.com/NebulousLabs/errors"
+
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
 )
@@ -13,9 +15,10 @@ import (
 type announcementFinder struct {
 	cs modules.ConsensusSet
 
-	// Announcements that have been seen. The two slices are wedded.
-	netAddresses []modules.NetAddress
-	publicKeys   []types.SiaPublicKey
+	// Announcements that have been seen. The three slices are wedded.
+	netAddresses        []modules.NetAddress
+	additionalAddresses [][]modules.NetAddress
+	publicKeys          []types.SiaPublicKey
 }
 
 // ProcessConsensusChange receives consensus changes from the consensus set and
@@ -24,9 +27,10 @@ func (af *announcementFinder) ProcessConsensusChange(cc modules.ConsensusChange)
 	for _, block := range cc.AppliedBlocks {
 		for _, txn := range block.Transactions {
 			for _, arb := range txn.ArbitraryData {
-				addr, pubKey, err := modules.DecodeAnnouncement(arb)
+				addrs, pubKey, err := modules.DecodeMultiAddressAnnouncement(arb)
 				if err == nil {
-					af.netAddresses = append(af.netAddresses, addr)
+					af.netAddresses = append(af.netAddresses, addrs[0])
+					af.additionalAddresses = append(af.additionalAddresses, addrs[1:])
 					af.publicKeys = append(af.publicKeys, pubKey)
 				}
 			}
@@ -103,6 +107,137 @@ func TestHostAnnounce(t *testing.T) {
 	}
 }
 
+// TestHostAnnounceProxy checks that the host doesn't announce its automatically
+// discovered address while the proxy disables announcements.
+func TestHostAnnounceProxy(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	// Not parallel since the proxy is process-wide.
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err = ht.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	err = modules.GlobalProxy.SetSettings(modules.ProxySettings{
+		Address:              "127.0.0.1:9050",
+		DisableAnnouncements: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := modules.GlobalProxy.SetSettings(modules.ProxySettings{}); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// The automatically discovered address isn't announced.
+	if err := ht.host.Announce(); !errors.Contains(err, modules.ErrAnnouncementsDisabled) {
+		t.Fatal("expected announcement to be disabled", err)
+	}
+
+	// An address set by the user is still announced.
+	settings := ht.host.InternalSettings()
+	settings.NetAddress = ht.host.autoAddress
+	if err := ht.host.SetInternalSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	if err := ht.host.Announce(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestHostPreviewAnnouncement checks that previewing an announcement doesn't
+// submit it and reports whether the host already announced its address.
+func TestHostPreviewAnnouncement(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	af, err := newAnnouncementFinder(ht.cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := af.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Preview the announcement of the host's current address.
+	preview, err := ht.host.PreviewAnnouncement("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.NetAddress != ht.host.autoAddress {
+		t.Fatal("preview has wrong address", preview.NetAddress)
+	}
+	if !preview.PublicKey.Equals(ht.host.publicKey) {
+		t.Fatal("preview has wrong host key")
+	}
+	if preview.Fee.IsZero() || preview.Size == 0 || len(preview.TransactionSet) == 0 {
+		t.Fatal("preview is missing the transaction", preview.Fee, preview.Size)
+	}
+	if preview.AlreadyAnnounced {
+		t.Fatal("host shouldn't be announced yet")
+	}
+
+	// The announcement shouldn't have been submitted.
+	_, err = ht.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(af.publicKeys) != 0 {
+		t.Fatal("preview shouldn't submit the announcement")
+	}
+
+	// Announce the host. The outputs used by the preview should have been
+	// released again.
+	err = ht.host.Announce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ht.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(af.publicKeys) != 1 {
+		t.Fatal("could not find host announcement in blockchain")
+	}
+
+	// The host's address is announced now but a different address isn't.
+	preview, err = ht.host.PreviewAnnouncement("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !preview.AlreadyAnnounced {
+		t.Fatal("host should be announced")
+	}
+	preview, err = ht.host.PreviewAnnouncement("foo.com:1234")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.AlreadyAnnounced || preview.NetAddress != "foo.com:1234" {
+		t.Fatal("wrong preview for different address", preview.NetAddress, preview.AlreadyAnnounced)
+	}
+}
+
 // TestHostAnnounceAddress checks that the host announce address function is
 // operating correctly.
 func TestHostAnnounceAddress(t *testing.T) {
@@ -154,6 +289,81 @@ func TestHostAnnounceAddress(t *testing.T) {
 	}
 }
 
+// TestHostAnnounceAdditionalAddresses checks that the host announces its
+// additional addresses.
+func TestHostAnnounceAdditionalAddresses(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	af, err := newAnnouncementFinder(ht.cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := af.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Invalid additional addresses are rejected.
+	is := ht.host.InternalSettings()
+	invalid := [][]modules.NetAddress{
+		{"invalid"},
+		{"foo.com:1234", "foo.com:1234"},
+		{"a.com:1", "b.com:1", "c.com:1", "d.com:1"},
+	}
+	for _, addrs := range invalid {
+		is.AdditionalNetAddresses = addrs
+		if err := ht.host.SetInternalSettings(is); err == nil {
+			t.Fatal("expected addresses to be rejected", addrs)
+		}
+	}
+
+	// Announce the host and set the additional addresses afterwards. The host
+	// should need to announce again.
+	if err := ht.host.Announce(); err != nil {
+		t.Fatal(err)
+	}
+	additional := []modules.NetAddress{"[2001:db8::1]:1234", "xyz.onion:1234"}
+	is.AdditionalNetAddresses = additional
+	if err := ht.host.SetInternalSettings(is); err != nil {
+		t.Fatal(err)
+	}
+	ht.host.mu.RLock()
+	announced := ht.host.announced
+	ht.host.mu.RUnlock()
+	if announced {
+		t.Fatal("host shouldn't be announced after changing its addresses")
+	}
+
+	// Announce again and check the announcement.
+	if err := ht.host.Announce(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ht.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if len(af.netAddresses) != 2 {
+		t.Fatal("could not find host announcements in blockchain")
+	}
+	if af.netAddresses[1] != ht.host.autoAddress {
+		t.Error("announcement has wrong address")
+	}
+	if len(af.additionalAddresses[0]) != 0 || !equalNetAddresses(af.additionalAddresses[1], additional) {
+		t.Error("announcement has wrong additional addresses", af.additionalAddresses)
+	}
+}
+
 // TestHostAnnounceCheckUnlockHash verifies that the host's unlock hash is
 // checked when an announcement is performed.
 func TestHostAnnounceCheckUnlockHash(t *testing.T) {
@@ -0,0 +1,263 @@
+package host
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+)
+
+const (
+	// bandwidthLedgerDay is the granularity of the bandwidth ledger.
+	bandwidthLedgerDay = 24 * time.Hour
+)
+
+var (
+	// bandwidthLedgerFlushInterval is the interval at which the bandwidth
+	// ledger is written to the database.
+	bandwidthLedgerFlushInterval = build.Select(build.Var{
+		Standard: time.Minute,
+		Dev:      30 * time.Second,
+		Testing:  time.Second,
+	}).(time.Duration)
+)
+
+type (
+	// bandwidthLedger records the bandwidth the host uses per day and per RPC.
+	// The bandwidth is accumulated in memory and periodically added to the
+	// host's database.
+	bandwidthLedger struct {
+		pending map[int64]*modules.HostBandwidthDay
+
+		staticDB *persist.BoltDatabase
+
+		mu sync.Mutex
+	}
+
+	// countingConn is a net.Conn which counts the bytes read from and written
+	// to it.
+	countingConn struct {
+		net.Conn
+		atomicRead    uint64
+		atomicWritten uint64
+	}
+)
+
+// Read implements the io.Reader interface.
+func (cc *countingConn) Read(b []byte) (int, error) {
+	n, err := cc.Conn.Read(b)
+	atomic.AddUint64(&cc.atomicRead, uint64(n))
+	return n, err
+}
+
+// Write implements the io.Writer interface.
+func (cc *countingConn) Write(b []byte) (int, error) {
+	n, err := cc.Conn.Write(b)
+	atomic.AddUint64(&cc.atomicWritten, uint64(n))
+	return n, err
+}
+
+// newBandwidthLedger creates a new ledger which persists the bandwidth in db.
+func newBandwidthLedger(db *persist.BoltDatabase) *bandwidthLedger {
+	return &bandwidthLedger{
+		pending:  make(map[int64]*modules.HostBandwidthDay),
+		staticDB: db,
+	}
+}
+
+// bandwidthDayKey returns the database key of the day containing t.
+func bandwidthDayKey(t time.Time) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(t.UTC().Truncate(bandwidthLedgerDay).Unix()))
+	return key
+}
+
+// addBandwidth adds the bandwidth of an RPC to a day.
+func addBandwidth(day *modules.HostBandwidthDay, rpc string, usage modules.HostBandwidthUsage) {
+	day.Upload += usage.Upload
+	day.Download += usage.Download
+	rpcUsage := day.RPCs[rpc]
+	rpcUsage.Upload += usage.Upload
+	rpcUsage.Download += usage.Download
+	day.RPCs[rpc] = rpcUsage
+}
+
+// mergeBandwidthDays adds the bandwidth of the day src to dst.
+func mergeBandwidthDays(dst *modules.HostBandwidthDay, src modules.HostBandwidthDay) {
+	for rpc, usage := range src.RPCs {
+		addBandwidth(dst, rpc, usage)
+	}
+}
+
+// managedRecord adds the bandwidth used by an RPC at time t to the ledger.
+func (bl *bandwidthLedger) managedRecord(t time.Time, rpc string, upload, download uint64) {
+	if upload == 0 && download == 0 {
+		return
+	}
+	day := t.UTC().Truncate(bandwidthLedgerDay)
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	pending, exists := bl.pending[day.Unix()]
+	if !exists {
+		pending = &modules.HostBandwidthDay{
+			Day:  day,
+			RPCs: make(map[string]modules.HostBandwidthUsage),
+		}
+		bl.pending[day.Unix()] = pending
+	}
+	addBandwidth(pending, rpc, modules.HostBandwidthUsage{
+		Upload:   upload,
+		Download: download,
+	})
+}
+
+// managedFlush adds the pending bandwidth to the database.
+func (bl *bandwidthLedger) managedFlush() error {
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	if len(bl.pending) == 0 {
+		return nil
+	}
+	err := bl.staticDB.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucketBandwidth)
+		for _, pending := range bl.pending {
+			key := bandwidthDayKey(pending.Day)
+			day := modules.HostBandwidthDay{
+				Day:  pending.Day,
+				RPCs: make(map[string]modules.HostBandwidthUsage),
+			}
+			if dayBytes := b.Get(key); dayBytes != nil {
+				if err := json.Unmarshal(dayBytes, &day); err != nil {
+					return errors.AddContext(err, "failed to decode bandwidth day")
+				}
+			}
+			mergeBandwidthDays(&day, *pending)
+			dayBytes, err := json.Marshal(day)
+			if err != nil {
+				return errors.AddContext(err, "failed to encode bandwidth day")
+			}
+			if err := b.Put(key, dayBytes); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	bl.pending = make(map[int64]*modules.HostBandwidthDay)
+	return nil
+}
+
+// managedMetrics returns the bandwidth used between start and end. Both the
+// persisted and the pending bandwidth are taken into account.
+func (bl *bandwidthLedger) managedMetrics(start, end time.Time) (modules.HostBandwidthMetrics, error) {
+	days := make(map[int64]*modules.HostBandwidthDay)
+	addDay := func(d modules.HostBandwidthDay) {
+		day, exists := days[d.Day.Unix()]
+		if !exists {
+			day = &modules.HostBandwidthDay{
+				Day:  d.Day,
+				RPCs: make(map[string]modules.HostBandwidthUsage),
+			}
+			days[d.Day.Unix()] = day
+		}
+		mergeBandwidthDays(day, d)
+	}
+
+	// Hold the lock while reading the database to avoid counting a day which
+	// is being flushed twice.
+	bl.mu.Lock()
+	defer bl.mu.Unlock()
+	first := start.UTC().Truncate(bandwidthLedgerDay)
+	err := bl.staticDB.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketBandwidth).Cursor()
+		for k, v := c.Seek(bandwidthDayKey(first)); k != nil; k, v = c.Next() {
+			var day modules.HostBandwidthDay
+			if err := json.Unmarshal(v, &day); err != nil {
+				return errors.AddContext(err, "failed to decode bandwidth day")
+			}
+			if day.Day.After(end) {
+				break
+			}
+			addDay(day)
+		}
+		return nil
+	})
+	if err != nil {
+		return modules.HostBandwidthMetrics{}, err
+	}
+	for _, day := range bl.pending {
+		if !day.Day.Before(first) && !day.Day.After(end) {
+			addDay(*day)
+		}
+	}
+
+	metrics := modules.HostBandwidthMetrics{
+		Start: start,
+		End:   end,
+		RPCs:  make(map[string]modules.HostBandwidthUsage),
+		Days:  make([]modules.HostBandwidthDay, 0, len(days)),
+	}
+	for _, day := range days {
+		metrics.Upload += day.Upload
+		metrics.Download += day.Download
+		for rpc, usage := range day.RPCs {
+			rpcUsage := metrics.RPCs[rpc]
+			rpcUsage.Upload += usage.Upload
+			rpcUsage.Download += usage.Download
+			metrics.RPCs[rpc] = rpcUsage
+		}
+		metrics.Days = append(metrics.Days, *day)
+	}
+	sort.Slice(metrics.Days, func(i, j int) bool {
+		return metrics.Days[i].Day.Before(metrics.Days[j].Day)
+	})
+	return metrics, nil
+}
+
+// BandwidthMetrics returns the bandwidth the host used between start and end
+// per day and per RPC. The days are in UTC and a day is included if it starts
+// before end and ends after start.
+func (h *Host) BandwidthMetrics(start, end time.Time) (modules.HostBandwidthMetrics, error) {
+	if err := h.tg.Add(); err != nil {
+		return modules.HostBandwidthMetrics{}, err
+	}
+	defer h.tg.Done()
+	if end.Before(start) {
+		return modules.HostBandwidthMetrics{}, errors.New("end of the period is before its start")
+	}
+	return h.staticBandwidthLedger.managedMetrics(start, end)
+}
+
+// threadedFlushBandwidthLedger periodically writes the bandwidth ledger to
+// the database.
+func (h *Host) threadedFlushBandwidthLedger() {
+	defer modules.RecoverPanic("host")
+	for {
+		select {
+		case <-h.tg.StopChan():
+			return
+		case <-time.After(bandwidthLedgerFlushInterval):
+		}
+		func() {
+			if err := h.tg.Add(); err != nil {
+				return
+			}
+			defer h.tg.Done()
+			if err := h.staticBandwidthLedger.managedFlush(); err != nil {
+				h.log.Println("ERROR: failed to persist bandwidth ledger:", err)
+			}
+		}()
+	}
+}
@@ -0,0 +1,110 @@
+package host
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/bolt"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/persist"
+)
+
+// TestBandwidthLedger is a unit test for the bandwidthLedger.
+func TestBandwidthLedger(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir("host", t.Name())
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	db, err := persist.OpenDatabase(dbMetadata, filepath.Join(dir, dbFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucketBandwidth)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	day1 := time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)
+	day2 := day1.Add(bandwidthLedgerDay)
+	day3 := day2.Add(bandwidthLedgerDay)
+
+	// Record some bandwidth on the first two days and flush it.
+	bl := newBandwidthLedger(db)
+	bl.managedRecord(day1.Add(time.Hour), "Download", 10, 100)
+	bl.managedRecord(day1.Add(2*time.Hour), "Download", 10, 100)
+	bl.managedRecord(day1.Add(3*time.Hour), "LoopEnter", 1, 2)
+	bl.managedRecord(day2.Add(time.Hour), "ExecuteProgram", 5, 50)
+	bl.managedRecord(day2, "ExecuteProgram", 0, 0)
+	if err := bl.managedFlush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Record more bandwidth on the second and third day which isn't flushed.
+	bl.managedRecord(day2.Add(2*time.Hour), "ExecuteProgram", 5, 50)
+	bl.managedRecord(day3.Add(time.Hour), "Settings", 3, 3)
+
+	// Query all days.
+	m, err := bl.managedMetrics(day1, day3.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Days) != 3 {
+		t.Fatal("wrong number of days", len(m.Days))
+	}
+	if !m.Days[0].Day.Equal(day1) || !m.Days[1].Day.Equal(day2) || !m.Days[2].Day.Equal(day3) {
+		t.Fatal("wrong days", m.Days)
+	}
+	if m.Upload != 34 || m.Download != 305 {
+		t.Fatal("wrong totals", m.Upload, m.Download)
+	}
+	if u := m.RPCs["Download"]; u.Upload != 20 || u.Download != 200 {
+		t.Fatal("wrong rpc usage", u)
+	}
+	if u := m.Days[1].RPCs["ExecuteProgram"]; u.Upload != 10 || u.Download != 100 {
+		t.Fatal("wrong rpc usage", u)
+	}
+	if len(m.Days[0].RPCs) != 2 || m.Days[0].Upload != 21 || m.Days[0].Download != 202 {
+		t.Fatal("wrong first day", m.Days[0])
+	}
+
+	// A day is included if the period overlaps with it.
+	m, err = bl.managedMetrics(day1.Add(time.Hour), day2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Days) != 2 || m.Upload != 31 {
+		t.Fatal("wrong period", m.Days, m.Upload)
+	}
+
+	// Flushing twice doesn't count the bandwidth twice and the ledger is
+	// loaded from disk by a new ledger.
+	if err := bl.managedFlush(); err != nil {
+		t.Fatal(err)
+	}
+	if err := bl.managedFlush(); err != nil {
+		t.Fatal(err)
+	}
+	m, err = newBandwidthLedger(db).managedMetrics(day1, day3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Days) != 3 || m.Upload != 34 || m.Download != 305 {
+		t.Fatal("wrong persisted metrics", m.Days, m.Upload, m.Download)
+	}
+}
@@ -12,6 +12,26 @@ const (
 	// AlertMSGHostInsufficientCollateral indicates that a host has insufficient
 	// collateral budget remaining
 	AlertMSGHostInsufficientCollateral = "host has insufficient collateral budget"
+
+	// AlertMSGHostProofsInDanger indicates that storage proofs of the host
+	// are unconfirmed close to their deadline
+	AlertMSGHostProofsInDanger = "storage proofs are in danger of being missed"
+
+	// AlertMSGHostLowCollateralBudget indicates that the host's remaining
+	// collateral budget is below the configured threshold
+	AlertMSGHostLowCollateralBudget = "host's remaining collateral budget is low"
+
+	// AlertMSGHostLowWalletBalance indicates that the host's wallet balance is
+	// below the configured threshold
+	AlertMSGHostLowWalletBalance = "host's wallet balance is low"
+
+	// AlertMSGHostMissedProofs indicates that the host recently missed
+	// storage proofs
+	AlertMSGHostMissedProofs = "host missed storage proofs"
+
+	// AlertMSGHostNotConnectable indicates that the host isn't connectable at
+	// its netaddress
+	AlertMSGHostNotConnectable = "host is not connectable"
 )
 
 const (
@@ -26,6 +46,11 @@ const (
 	// contract revision, or a storage proof.
 	resubmissionTimeout = 3
 
+	// maxProofFeeEscalations is the maximum number of times the fee of a
+	// storage proof is doubled when the proof is resubmitted because it
+	// didn't get confirmed.
+	maxProofFeeEscalations = 4
+
 	// rpcRequestInterval is the amount of time that the renter has to send
 	// the next RPC ID in the new RPC loop. (More time is alloted for sending
 	// the actual RPC request object.)
@@ -45,14 +70,6 @@ var (
 		Testing:  time.Second * 3,
 	}).(time.Duration)
 
-	// connectablityCheckFrequency defines how often the host's connectability
-	// check is run.
-	connectabilityCheckFrequency = build.Select(build.Var{
-		Standard: time.Minute * 10,
-		Dev:      time.Minute * 5,
-		Testing:  time.Second * 10,
-	}).(time.Duration)
-
 	// connectabilityCheckTimeout defines how long a connectability check's dial
 	// will be allowed to block before it times out.
 	connectabilityCheckTimeout = build.Select(build.Var{
@@ -61,6 +78,39 @@ var (
 		Testing:  time.Second * 90,
 	}).(time.Duration)
 
+	// proofRetryInterval is the number of blocks the host waits for a storage
+	// proof to be confirmed before resubmitting it with a higher fee.
+	proofRetryInterval = build.Select(build.Var{
+		Standard: types.BlockHeight(6),
+		Dev:      types.BlockHeight(3),
+		Testing:  types.BlockHeight(2),
+	}).(types.BlockHeight)
+
+	// proofDangerThreshold is the number of blocks before the proof deadline
+	// at which an unconfirmed storage proof is considered to be in danger of
+	// being missed.
+	proofDangerThreshold = build.Select(build.Var{
+		Standard: types.BlockHeight(36),
+		Dev:      types.BlockHeight(10),
+		Testing:  types.BlockHeight(5),
+	}).(types.BlockHeight)
+
+	// alertCheckFrequency defines how frequently the host checks its
+	// collateral budget and wallet balance against the alert thresholds.
+	alertCheckFrequency = build.Select(build.Var{
+		Standard: time.Minute * 10,
+		Dev:      time.Minute,
+		Testing:  time.Second * 3,
+	}).(time.Duration)
+
+	// missedProofsAlertWindow is the number of blocks for which a missed
+	// storage proof is part of the missed proofs alert.
+	missedProofsAlertWindow = build.Select(build.Var{
+		Standard: types.BlockHeight(1008), // 1 week.
+		Dev:      types.BlockHeight(100),
+		Testing:  types.BlockHeight(10),
+	}).(types.BlockHeight)
+
 	// defaultCollateralBudget defines the maximum number of siacoins that the
 	// host is going to allocate towards collateral. The number has been chosen
 	// as a number that is large, but not so large that someone would be
@@ -147,6 +197,13 @@ var (
 // All of the following variables define the names of buckets used by the host
 // in the database.
 var (
+	// bucketAccountTransactions contains a bucket for every ephemeral account,
+	// keyed by the account id. The account buckets contain the account's
+	// serialized 'modules.HostAccountTransaction's sorted by their timestamp,
+	// stored as a big endian uint64 unix nano timestamp, followed by a big
+	// endian uint64 sequence number.
+	bucketAccountTransactions = []byte("BucketAccountTransactions")
+
 	// bucketActionItems maps a blockchain height to a list of storage
 	// obligations that need to be managed in some way at that height. The
 	// height is stored as a big endian uint64, which means that bolt will
@@ -157,6 +214,25 @@ var (
 	// using the id.
 	bucketActionItems = []byte("BucketActionItems")
 
+	// bucketBandwidth maps the start of a day, stored as a big endian uint64
+	// unix timestamp, to a serialized 'modules.HostBandwidthDay' containing
+	// the bandwidth the host used on that day.
+	bucketBandwidth = []byte("BucketBandwidth")
+
+	// bucketMissedProofs contains a set of serialized
+	// 'modules.HostMissedProof's sorted by their file contract id.
+	bucketMissedProofs = []byte("BucketMissedProofs")
+
+	// bucketProofFees contains a set of serialized
+	// 'modules.HostProofFeeTransaction's sorted by the height they were
+	// submitted at, stored as a big endian uint64, followed by their
+	// transaction id.
+	bucketProofFees = []byte("BucketProofFees")
+
+	// bucketRegistrySubscriptionSets contains a set of serialized
+	// 'subscriptionSet's sorted by their token.
+	bucketRegistrySubscriptionSets = []byte("BucketRegistrySubscriptionSets")
+
 	// bucketStorageObligations contains a set of serialized
 	// 'storageObligations' sorted by their file contract id.
 	bucketStorageObligations = []byte("BucketStorageObligations")
@@ -12,6 +12,10 @@ const (
 	// AlertMSGHostDiskTrouble indicates that one or multiple of a host's disks
 	// are encountering problems
 	AlertMSGHostDiskTrouble = "disk problem detected"
+
+	// AlertMSGHostSectorCorruption indicates that the scrubber found corrupted
+	// sectors.
+	AlertMSGHostSectorCorruption = "corrupted sectors detected"
 )
 
 const (
@@ -139,4 +143,21 @@ var (
 		Standard: time.Second * 60 * 5,
 		Testing:  time.Second * 8,
 	}).(time.Duration)
+
+	// scrubCycleInterval specifies the amount of time that the contract
+	// manager waits between scrubbing all of its sectors.
+	scrubCycleInterval = build.Select(build.Var{
+		Dev:      time.Minute * 10,
+		Standard: time.Hour * 24,
+		Testing:  time.Minute,
+	}).(time.Duration)
+
+	// scrubSectorInterval specifies the amount of time that the contract
+	// manager waits between scrubbing two sectors. It limits the disk
+	// bandwidth used by the scrubber.
+	scrubSectorInterval = build.Select(build.Var{
+		Dev:      time.Millisecond * 50,
+		Standard: time.Millisecond * 250,
+		Testing:  time.Millisecond,
+	}).(time.Duration)
 )
@@ -93,6 +93,14 @@ type ContractManager struct {
 	// or modified.
 	lockedSectors map[sectorID]*sectorLock
 
+	// quarantinedSectors contains the sectors which the scrubber found to be
+	// corrupted. They can't be read until they are removed. scrubStatus
+	// contains the statistics of the scrubber. The quarantine is not
+	// persisted, corrupted sectors are found again by the scrubber after a
+	// restart.
+	quarantinedSectors map[sectorID]struct{}
+	scrubStatus        scrubStatus
+
 	// Utilities.
 	dependencies  modules.Dependencies
 	staticAlerter *modules.GenericAlerter
@@ -107,6 +115,21 @@ func (cm *ContractManager) Close() error {
 	return errors.AddContext(cm.tg.Stop(), "error while stopping contract manager")
 }
 
+// Quiesce implements the modules.Quiescer interface. It commits the changes in
+// the WAL and then blocks further changes to the WAL until resume is called.
+// Sector data which is written to a storage folder while the contract manager
+// is quiesced only becomes part of the contract manager's state once the WAL
+// is committed again.
+func (cm *ContractManager) Quiesce() (resume func(), err error) {
+	if err := cm.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer cm.tg.Done()
+	cm.wal.mu.Lock()
+	cm.wal.commit()
+	return cm.wal.mu.Unlock, nil
+}
+
 // newContractManager returns a contract manager that is ready to be used with
 // the provided dependencies.
 func newContractManager(dependencies modules.Dependencies, persistDir string) (_ *ContractManager, err error) {
@@ -114,7 +137,8 @@ func newContractManager(dependencies modules.Dependencies, persistDir string) (_
 		storageFolders:  make(map[uint16]*storageFolder),
 		sectorLocations: make(map[sectorID]sectorLocation),
 
-		lockedSectors: make(map[sectorID]*sectorLock),
+		lockedSectors:      make(map[sectorID]*sectorLock),
+		quarantinedSectors: make(map[sectorID]struct{}),
 
 		dependencies: dependencies,
 		persistDir:   persistDir,
@@ -224,6 +248,10 @@ func newContractManager(dependencies modules.Dependencies, persistDir string) (_
 	// and adds them if they are discovered.
 	go cm.threadedFolderRecheck()
 
+	// Spin up the thread that periodically verifies the integrity of the
+	// sectors.
+	go cm.threadedScrubSectors()
+
 	// Simulate an error to make sure the cleanup code is triggered correctly.
 	if cm.dependencies.Disrupt("erroredStartup") {
 		err = errors.New("startup disrupted")
@@ -0,0 +1,234 @@
+package contractmanager
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+var (
+	// ErrSectorCorrupted is returned when trying to read a sector which was
+	// quarantined by the scrubber because its data doesn't match its root.
+	ErrSectorCorrupted = errors.New("sector is corrupted")
+)
+
+type (
+	// scrubStatus contains the statistics of the scrubber which periodically
+	// re-reads all sectors to verify their integrity.
+	scrubStatus struct {
+		// The fields below describe the current or most recent scrub cycle.
+		cycleStart    time.Time
+		cycleScrubbed uint64
+		cycleTotal    uint64
+		lastCycleEnd  time.Time
+
+		// The fields below are totals since startup.
+		scrubbed   uint64
+		unreadable uint64
+		corrupted  uint64
+	}
+)
+
+// StorageHealth returns the results of the scrubber which periodically
+// verifies the integrity of the host's sectors.
+func (cm *ContractManager) StorageHealth() modules.StorageHealth {
+	cm.wal.mu.Lock()
+	defer cm.wal.mu.Unlock()
+	return modules.StorageHealth{
+		CycleStarted:         cm.scrubStatus.cycleStart,
+		CycleSectorsScrubbed: cm.scrubStatus.cycleScrubbed,
+		CycleSectorsTotal:    cm.scrubStatus.cycleTotal,
+		LastCycleCompleted:   cm.scrubStatus.lastCycleEnd,
+
+		SectorsScrubbed:    cm.scrubStatus.scrubbed,
+		UnreadableSectors:  cm.scrubStatus.unreadable,
+		CorruptedSectors:   cm.scrubStatus.corrupted,
+		QuarantinedSectors: uint64(len(cm.quarantinedSectors)),
+	}
+}
+
+// managedQuarantineSector marks a sector as corrupted. Quarantined sectors
+// can't be read anymore to prevent the host from serving corrupted data. A
+// sector is removed from the quarantine once it is removed from the contract
+// manager.
+func (cm *ContractManager) managedQuarantineSector(id sectorID) {
+	cm.wal.mu.Lock()
+	defer cm.wal.mu.Unlock()
+	if _, exists := cm.quarantinedSectors[id]; exists {
+		return
+	}
+	cm.quarantinedSectors[id] = struct{}{}
+	cm.scrubStatus.corrupted++
+	cm.updateCorruptionAlert()
+}
+
+// unquarantineSector removes a sector from the quarantine. It is called when
+// the sector is removed from the contract manager.
+func (cm *ContractManager) unquarantineSector(id sectorID) {
+	if _, exists := cm.quarantinedSectors[id]; !exists {
+		return
+	}
+	delete(cm.quarantinedSectors, id)
+	cm.updateCorruptionAlert()
+}
+
+// updateCorruptionAlert registers or unregisters the alert for corrupted
+// sectors depending on the number of quarantined sectors.
+func (cm *ContractManager) updateCorruptionAlert() {
+	if len(cm.quarantinedSectors) == 0 {
+		cm.staticAlerter.UnregisterAlert(modules.AlertIDHostSectorCorruption)
+		return
+	}
+	cause := fmt.Sprintf("%v sectors were quarantined", len(cm.quarantinedSectors))
+	cm.staticAlerter.RegisterAlert(modules.AlertIDHostSectorCorruption, AlertMSGHostSectorCorruption, cause, modules.SeverityCritical)
+}
+
+// managedScrubSector reads the sector with the given id from the given location
+// and verifies that the data matches the sector's id. It returns false if the
+// sector wasn't scrubbed because it was moved or removed in the meantime.
+func (cm *ContractManager) managedScrubSector(id sectorID, sf *storageFolder, index uint32) (bool, error) {
+	cm.wal.managedLockSector(id)
+	defer cm.wal.managedUnlockSector(id)
+
+	// Make sure the sector is still stored at the expected location.
+	cm.wal.mu.Lock()
+	location, exists := cm.sectorLocations[id]
+	cm.wal.mu.Unlock()
+	if !exists || location.storageFolder != sf.index || location.index != index {
+		return false, nil
+	}
+	if atomic.LoadUint64(&sf.atomicUnavailable) == 1 {
+		return false, nil
+	}
+
+	sectorData, err := readSector(sf.sectorFile, index)
+	if err != nil {
+		atomic.AddUint64(&sf.atomicFailedReads, 1)
+		return true, errors.AddContext(err, "unable to read sector")
+	}
+	atomic.AddUint64(&sf.atomicSuccessfulReads, 1)
+
+	// Sectors are identified by their salted Merkle root. If the data was
+	// corrupted, its root won't match the sector's id anymore.
+	if cm.managedSectorID(crypto.MerkleRoot(sectorData)) != id {
+		return true, ErrSectorCorrupted
+	}
+	return true, nil
+}
+
+// managedScrubStorageFolder scrubs all sectors of a storage folder. It returns
+// false if the scrub was interrupted by shutdown.
+func (cm *ContractManager) managedScrubStorageFolder(sf *storageFolder) bool {
+	cm.wal.mu.Lock()
+	_, exists := cm.storageFolders[sf.index]
+	usage := append([]uint64(nil), sf.usage...)
+	cm.wal.mu.Unlock()
+	if !exists || atomic.LoadUint64(&sf.atomicUnavailable) == 1 {
+		return true
+	}
+
+	sectorLookupBytes, err := readFullMetadata(sf.metadataFile, len(usage)*storageFolderGranularity)
+	if err != nil {
+		atomic.AddUint64(&sf.atomicFailedReads, 1)
+		cm.log.Printf("Unable to scrub storage folder %v: %v", sf.path, err)
+		return true
+	}
+	atomic.AddUint64(&sf.atomicSuccessfulReads, 1)
+
+	for i, usageElement := range usage {
+		for j := 0; j < storageFolderGranularity; j++ {
+			if usageElement&(1<<uint(j)) == 0 {
+				continue
+			}
+			sectorIndex := uint32(i*storageFolderGranularity + j)
+			readHead := int(sectorIndex) * sectorMetadataDiskSize
+			var id sectorID
+			copy(id[:], sectorLookupBytes[readHead:readHead+12])
+
+			scrubbed, err := cm.managedScrubSector(id, sf, sectorIndex)
+			if !scrubbed {
+				continue
+			}
+			cm.wal.mu.Lock()
+			cm.scrubStatus.cycleScrubbed++
+			cm.scrubStatus.scrubbed++
+			if err != nil && !errors.Contains(err, ErrSectorCorrupted) {
+				cm.scrubStatus.unreadable++
+			}
+			cm.wal.mu.Unlock()
+			if errors.Contains(err, ErrSectorCorrupted) {
+				cm.log.Printf("Scrubber found a corrupted sector in storage folder %v at index %v", sf.path, sectorIndex)
+				cm.managedQuarantineSector(id)
+			} else if err != nil {
+				cm.log.Printf("Scrubber was unable to read a sector in storage folder %v at index %v: %v", sf.path, sectorIndex, err)
+			}
+
+			// Limit the rate at which sectors are scrubbed to not starve the
+			// host's other disk operations.
+			select {
+			case <-cm.tg.StopChan():
+				return false
+			case <-time.After(scrubSectorInterval):
+			}
+		}
+	}
+	return true
+}
+
+// managedScrubCycle scrubs all sectors of all available storage folders once.
+func (cm *ContractManager) managedScrubCycle() {
+	cm.wal.mu.Lock()
+	var sfs []*storageFolder
+	var total uint64
+	for _, sf := range cm.storageFolders {
+		if atomic.LoadUint64(&sf.atomicUnavailable) == 1 {
+			continue
+		}
+		sfs = append(sfs, sf)
+		total += sf.sectors
+	}
+	cm.scrubStatus.cycleStart = time.Now()
+	cm.scrubStatus.cycleScrubbed = 0
+	cm.scrubStatus.cycleTotal = total
+	cm.wal.mu.Unlock()
+
+	for _, sf := range sfs {
+		if !cm.managedScrubStorageFolder(sf) {
+			return
+		}
+	}
+
+	cm.wal.mu.Lock()
+	cm.scrubStatus.lastCycleEnd = time.Now()
+	cm.wal.mu.Unlock()
+}
+
+// threadedScrubSectors periodically re-reads all sectors of the contract
+// manager and verifies their Merkle roots to detect silent disk corruption
+// before it causes a failed storage proof.
+func (cm *ContractManager) threadedScrubSectors() {
+	defer modules.RecoverPanic("contractmanager")
+	// Don't spawn the loop if 'noScrub' disruption is set.
+	if cm.dependencies.Disrupt("noScrub") {
+		return
+	}
+
+	for {
+		select {
+		case <-cm.tg.StopChan():
+			return
+		case <-time.After(scrubCycleInterval):
+		}
+
+		if err := cm.tg.Add(); err != nil {
+			return
+		}
+		cm.managedScrubCycle()
+		cm.tg.Done()
+	}
+}
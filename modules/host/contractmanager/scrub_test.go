@@ -0,0 +1,118 @@
+package contractmanager
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestScrubSectors tests that the scrubber finds and quarantines corrupted
+// sectors.
+func TestScrubSectors(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+	cm := cmt.cm
+
+	// Add a storage folder with a few sectors.
+	storageFolderDir := filepath.Join(cmt.persistDir, "storageFolderOne")
+	if err := os.MkdirAll(storageFolderDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	err = cm.AddStorageFolder(storageFolderDir, modules.SectorSize*storageFolderGranularity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var roots []crypto.Hash
+	var datas [][]byte
+	for i := 0; i < 3; i++ {
+		root, data := randSector()
+		if err := cm.AddSector(root, data); err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, root)
+		datas = append(datas, data)
+	}
+
+	// Scrub the sectors. None of them should be corrupted.
+	cm.managedScrubCycle()
+	sh := cm.StorageHealth()
+	if sh.CycleSectorsScrubbed != 3 || sh.CycleSectorsTotal != 3 || sh.SectorsScrubbed != 3 {
+		t.Fatal("wrong number of scrubbed sectors", sh)
+	}
+	if sh.CorruptedSectors != 0 || sh.QuarantinedSectors != 0 || sh.UnreadableSectors != 0 {
+		t.Fatal("no sector should be corrupted", sh)
+	}
+	if sh.CycleStarted.IsZero() || sh.LastCycleCompleted.Before(sh.CycleStarted) {
+		t.Fatal("cycle should be completed", sh)
+	}
+
+	// Corrupt the first sector on disk.
+	id := cm.managedSectorID(roots[0])
+	cm.wal.mu.Lock()
+	location := cm.sectorLocations[id]
+	sf := cm.storageFolders[location.storageFolder]
+	cm.wal.mu.Unlock()
+	_, err = sf.sectorFile.WriteAt(fastrand.Bytes(64), int64(uint64(location.index)*modules.SectorSize))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Scrub again. The corrupted sector should be quarantined.
+	cm.managedScrubCycle()
+	sh = cm.StorageHealth()
+	if sh.SectorsScrubbed != 6 || sh.CorruptedSectors != 1 || sh.QuarantinedSectors != 1 {
+		t.Fatal("corrupted sector should be quarantined", sh)
+	}
+	if _, err := cm.ReadSector(roots[0]); !errors.Contains(err, ErrSectorCorrupted) {
+		t.Fatal("expected ErrSectorCorrupted", err)
+	}
+	for i := 1; i < len(roots); i++ {
+		data, err := cm.ReadSector(roots[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, datas[i]) {
+			t.Fatal("wrong sector data")
+		}
+	}
+	crit, _, _ := cm.Alerts()
+	if !hasAlert(crit, modules.AlertIDHostSectorCorruption) {
+		t.Fatal("corruption alert should be registered")
+	}
+
+	// Removing the corrupted sector should remove it from the quarantine.
+	if err := cm.RemoveSector(roots[0]); err != nil {
+		t.Fatal(err)
+	}
+	sh = cm.StorageHealth()
+	if sh.CorruptedSectors != 1 || sh.QuarantinedSectors != 0 {
+		t.Fatal("sector should have been removed from the quarantine", sh)
+	}
+	crit, _, _ = cm.Alerts()
+	if hasAlert(crit, modules.AlertIDHostSectorCorruption) {
+		t.Fatal("corruption alert should be unregistered")
+	}
+}
+
+// hasAlert returns true if the alert with the given id is part of alerts.
+func hasAlert(alerts []modules.Alert, id modules.AlertID) bool {
+	for _, alert := range alerts {
+		if alert.ID == id {
+			return true
+		}
+	}
+	return false
+}
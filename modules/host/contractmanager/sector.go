@@ -134,10 +134,14 @@ func (cm *ContractManager) ReadPartialSector(root crypto.Hash, offset, length ui
 	cm.wal.mu.Lock()
 	sl, exists1 := cm.sectorLocations[id]
 	sf, exists2 := cm.storageFolders[sl.storageFolder]
+	_, quarantined := cm.quarantinedSectors[id]
 	cm.wal.mu.Unlock()
 	if !exists1 {
 		return nil, ErrSectorNotFound
 	}
+	if quarantined {
+		return nil, ErrSectorCorrupted
+	}
 	if !exists2 {
 		cm.log.Critical("Unable to load storage folder despite having sector metadata")
 		return nil, ErrSectorNotFound
@@ -250,6 +250,7 @@ func (wal *writeAheadLog) managedDeleteSector(id sectorID) error {
 
 		// Delete the sector and mark the usage as available.
 		delete(wal.cm.sectorLocations, id)
+		wal.cm.unquarantineSector(id)
 		sf.availableSectors[id] = location.index
 
 		// Block until the change has been committed.
@@ -311,6 +312,7 @@ func (wal *writeAheadLog) managedRemoveSector(id sectorID) error {
 		if location.count == 0 {
 			// Delete the sector and mark it as available.
 			delete(wal.cm.sectorLocations, id)
+			wal.cm.unquarantineSector(id)
 			sf.availableSectors[id] = location.index
 		} else {
 			// Reduce the sector usage.
@@ -114,6 +114,10 @@ type storageFolder struct {
 	// makes it easy to do delayed-syncing.
 	metadataFile modules.File
 	sectorFile   modules.File
+
+	// drain is set while the storage folder is being drained. A storage
+	// folder which is being drained doesn't accept new sectors.
+	drain *storageFolderDrain
 }
 
 // mostSignificantBit returns the index of the most significant bit of an input
@@ -287,6 +291,10 @@ func (cm *ContractManager) availableStorageFolders() []*storageFolder {
 		if atomic.LoadUint64(&sf.atomicUnavailable) == 1 {
 			continue
 		}
+		// Skip storage folders which are being drained.
+		if sf.drain != nil {
+			continue
+		}
 		sfs = append(sfs, sf)
 	}
 	return sfs
@@ -295,6 +303,7 @@ func (cm *ContractManager) availableStorageFolders() []*storageFolder {
 // threadedFolderRecheck checks the unavailable storage folders and looks to see
 // if they have been mounted or restored by the user.
 func (cm *ContractManager) threadedFolderRecheck() {
+	defer modules.RecoverPanic("contractmanager")
 	// Don't spawn the loop if 'noRecheck' disruption is set.
 	if cm.dependencies.Disrupt("noRecheck") {
 		return
@@ -377,10 +386,14 @@ func (cm *ContractManager) ResizeStorageFolder(index uint16, newSize uint64, for
 
 	cm.wal.mu.Lock()
 	sf, exists := cm.storageFolders[index]
+	draining := exists && sf.drain != nil
 	cm.wal.mu.Unlock()
 	if !exists || atomic.LoadUint64(&sf.atomicUnavailable) == 1 {
 		return errStorageFolderNotFound
 	}
+	if draining {
+		return errStorageFolderDraining
+	}
 
 	if newSize/modules.SectorSize < MinimumSectorsPerStorageFolder {
 		return ErrSmallStorageFolder
@@ -431,6 +444,14 @@ func (cm *ContractManager) StorageFolders() []modules.StorageFolderMetadata {
 			Index:             sf.index,
 			Path:              sf.path,
 		}
+		if sf.drain != nil {
+			sfm.Draining = true
+			sfm.DrainPaused = sf.drain.paused
+			sfm.DrainRate = sf.drain.rate
+			if sf.drain.err != nil {
+				sfm.DrainError = sf.drain.err.Error()
+			}
+		}
 
 		// Set some of the values to extreme numbers if the storage folder is
 		// unavailable, to flag the user's attention.
@@ -0,0 +1,284 @@
+package contractmanager
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+)
+
+var (
+	// errStorageFolderDraining is returned when trying to remove or resize a
+	// storage folder which is being drained.
+	errStorageFolderDraining = errors.New("storage folder is being drained")
+
+	// errStorageFolderNotDraining is returned when trying to pause or resume
+	// the drain of a storage folder which isn't being drained.
+	errStorageFolderNotDraining = errors.New("storage folder is not being drained")
+)
+
+type (
+	// storageFolderDrain contains the state of a storage folder which is
+	// drained in the background. While a storage folder is drained, it doesn't
+	// accept new sectors and its sectors are migrated to the other storage
+	// folders one at a time. Once all sectors are migrated, the storage folder
+	// is removed.
+	//
+	// The drain is not persisted. If the contract manager is shut down during
+	// a drain, the drain needs to be started again.
+	storageFolderDrain struct {
+		// rate is the number of sectors migrated per second. A rate of 0
+		// means that the sectors are migrated as fast as possible.
+		rate uint64
+
+		// paused indicates whether the drain is paused. resumeChan is closed
+		// when a paused drain is resumed.
+		paused     bool
+		resumeChan chan struct{}
+
+		// err is the error which caused the drain to pause itself.
+		err error
+	}
+)
+
+// DrainStorageFolder starts draining the storage folder with the given index
+// in the background, migrating up to sectorsPerSecond sectors per second to
+// the other storage folders and removing the storage folder once it's empty.
+// A rate of 0 migrates the sectors as fast as possible. If the storage folder
+// is already being drained, the rate of the drain is updated.
+func (cm *ContractManager) DrainStorageFolder(index uint16, sectorsPerSecond uint64) error {
+	err := cm.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cm.tg.Done()
+
+	cm.wal.mu.Lock()
+	defer cm.wal.mu.Unlock()
+	sf, exists := cm.storageFolders[index]
+	if !exists || atomic.LoadUint64(&sf.atomicUnavailable) == 1 {
+		return errStorageFolderNotFound
+	}
+	if sf.drain != nil {
+		sf.drain.rate = sectorsPerSecond
+		return nil
+	}
+	sf.drain = &storageFolderDrain{
+		rate: sectorsPerSecond,
+	}
+	go cm.threadedDrainStorageFolder(sf)
+	return nil
+}
+
+// PauseStorageFolderDrain pauses or resumes the drain of the storage folder
+// with the given index.
+func (cm *ContractManager) PauseStorageFolderDrain(index uint16, paused bool) error {
+	err := cm.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cm.tg.Done()
+
+	cm.wal.mu.Lock()
+	defer cm.wal.mu.Unlock()
+	sf, exists := cm.storageFolders[index]
+	if !exists {
+		return errStorageFolderNotFound
+	}
+	if sf.drain == nil {
+		return errStorageFolderNotDraining
+	}
+	if paused {
+		sf.drain.pause(nil)
+	} else {
+		sf.drain.resume()
+	}
+	return nil
+}
+
+// pause pauses the drain. The provided error indicates why the drain was
+// paused if it wasn't paused by the user.
+func (d *storageFolderDrain) pause(err error) {
+	d.err = err
+	if d.paused {
+		return
+	}
+	d.paused = true
+	d.resumeChan = make(chan struct{})
+}
+
+// resume resumes a paused drain.
+func (d *storageFolderDrain) resume() {
+	d.err = nil
+	if !d.paused {
+		return
+	}
+	d.paused = false
+	close(d.resumeChan)
+}
+
+// managedWaitForDrain blocks until the drain of the storage folder is allowed
+// to migrate the next sector. It returns false if the contract manager is
+// shutting down.
+func (cm *ContractManager) managedWaitForDrain(sf *storageFolder, lastMove time.Time) bool {
+	for {
+		cm.wal.mu.Lock()
+		paused, resumeChan, rate := sf.drain.paused, sf.drain.resumeChan, sf.drain.rate
+		cm.wal.mu.Unlock()
+
+		// Wait for the drain to be resumed.
+		if paused {
+			select {
+			case <-resumeChan:
+				continue
+			case <-cm.tg.StopChan():
+				return false
+			}
+		}
+
+		// Wait until the next sector may be migrated.
+		var wait time.Duration
+		if rate > 0 {
+			wait = time.Until(lastMove.Add(time.Second / time.Duration(rate)))
+		}
+		if wait <= 0 {
+			return true
+		}
+		select {
+		case <-time.After(wait):
+			// Check again in case the drain was paused or the rate was
+			// changed in the meantime.
+		case <-cm.tg.StopChan():
+			return false
+		}
+	}
+}
+
+// managedDrainPass migrates the sectors of the storage folder which are
+// stored in it at the beginning of the pass. It returns the number of migrated
+// sectors and the number of sectors which failed to migrate.
+func (cm *ContractManager) managedDrainPass(sf *storageFolder) (moved, failed uint64, _ error) {
+	wal := &cm.wal
+	sectorLookupBytes, err := readFullMetadata(sf.metadataFile, len(sf.usage)*storageFolderGranularity)
+	if err != nil {
+		atomic.AddUint64(&sf.atomicFailedReads, 1)
+		return 0, 0, build.ExtendErr("unable to read sector metadata", err)
+	}
+	atomic.AddUint64(&sf.atomicSuccessfulReads, 1)
+
+	wal.mu.Lock()
+	usage := append([]uint64(nil), sf.usage...)
+	wal.mu.Unlock()
+
+	var lastMove time.Time
+	for i, usageElement := range usage {
+		for j := 0; j < storageFolderGranularity; j++ {
+			if usageElement&(1<<uint(j)) == 0 {
+				continue
+			}
+			readHead := (i*storageFolderGranularity + j) * sectorMetadataDiskSize
+			var id sectorID
+			copy(id[:], sectorLookupBytes[readHead:readHead+12])
+
+			// Skip sectors which were deleted or already moved.
+			wal.mu.Lock()
+			location, exists := wal.cm.sectorLocations[id]
+			wal.mu.Unlock()
+			if !exists || location.storageFolder != sf.index {
+				continue
+			}
+
+			if !cm.managedWaitForDrain(sf, lastMove) {
+				return moved, failed, errors.New("contract manager is shutting down")
+			}
+			lastMove = time.Now()
+			err := wal.managedMoveSector(id)
+			if err != nil && err.Error() == modules.V1420HostOutOfStorageErrString {
+				return moved, failed, err
+			} else if errors.Contains(err, errDiskTrouble) {
+				wal.cm.staticAlerter.RegisterAlert(modules.AlertIDHostDiskTrouble, AlertMSGHostDiskTrouble, "", modules.SeverityCritical)
+			}
+			if err != nil {
+				failed++
+				wal.cm.log.Println("Unable to migrate sector during drain:", err)
+				continue
+			}
+			moved++
+			atomic.AddUint64(&sf.atomicProgressNumerator, modules.SectorSize)
+		}
+	}
+	return moved, failed, nil
+}
+
+// threadedDrainStorageFolder migrates the sectors of a storage folder which is
+// being drained and removes the storage folder once it's empty. If the
+// sectors can't be migrated, the drain pauses itself until it is resumed by
+// the user.
+func (cm *ContractManager) threadedDrainStorageFolder(sf *storageFolder) {
+	if err := cm.tg.Add(); err != nil {
+		return
+	}
+	defer cm.tg.Done()
+
+	wal := &cm.wal
+	wal.mu.Lock()
+	atomic.StoreUint64(&sf.atomicProgressNumerator, 0)
+	atomic.StoreUint64(&sf.atomicProgressDenominator, sf.sectors*modules.SectorSize)
+	wal.mu.Unlock()
+	defer func() {
+		atomic.StoreUint64(&sf.atomicProgressNumerator, 0)
+		atomic.StoreUint64(&sf.atomicProgressDenominator, 0)
+	}()
+
+	for {
+		moved, failed, err := cm.managedDrainPass(sf)
+		select {
+		case <-cm.tg.StopChan():
+			return
+		default:
+		}
+
+		// Wait for the moves to be synced before checking whether the storage
+		// folder is empty.
+		wal.mu.Lock()
+		syncChan := wal.syncChan
+		wal.mu.Unlock()
+		<-syncChan
+
+		wal.mu.Lock()
+		remaining := sf.sectors
+		if err == nil && failed > 0 && moved == 0 {
+			err = ErrPartialRelocation
+		}
+		if err != nil {
+			// Pause the drain until the user resumes it, e.g. after adding
+			// more storage.
+			cm.log.Printf("Draining storage folder %v paused: %v", sf.path, err)
+			sf.drain.pause(err)
+		}
+		wal.mu.Unlock()
+		if remaining == 0 {
+			break
+		}
+		if err != nil && !cm.managedWaitForDrain(sf, time.Time{}) {
+			return
+		}
+	}
+
+	// The storage folder is empty. Lock it to wait for any remaining reads and
+	// remove it.
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	wal.mu.Lock()
+	_, exists := cm.storageFolders[sf.index]
+	wal.mu.Unlock()
+	if !exists {
+		return
+	}
+	wal.managedSubmitStorageFolderRemoval(sf)
+	cm.log.Println(fmt.Sprintf("Drained and removed storage folder %v", sf.path))
+}
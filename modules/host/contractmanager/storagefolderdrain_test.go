@@ -0,0 +1,138 @@
+package contractmanager
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestDrainStorageFolder tests draining a storage folder with sectors in it.
+func TestDrainStorageFolder(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	// Add a storage folder and give it a few sectors.
+	storageFolderOne := filepath.Join(cmt.persistDir, "storageFolderOne")
+	storageFolderTwo := filepath.Join(cmt.persistDir, "storageFolderTwo")
+	for _, dir := range []string{storageFolderOne, storageFolderTwo} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = cmt.cm.AddStorageFolder(storageFolderOne, modules.SectorSize*storageFolderGranularity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var roots []crypto.Hash
+	var datas [][]byte
+	for i := 0; i < 5; i++ {
+		root, data := randSector()
+		if err := cmt.cm.AddSector(root, data); err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, root)
+		datas = append(datas, data)
+	}
+	sfs := cmt.cm.StorageFolders()
+	if len(sfs) != 1 {
+		t.Fatal("expected 1 storage folder", len(sfs))
+	}
+	drainIndex := sfs[0].Index
+
+	// Draining without another storage folder should pause the drain since
+	// the sectors can't be migrated.
+	err = cmt.cm.DrainStorageFolder(drainIndex, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		sfs := cmt.cm.StorageFolders()
+		if len(sfs) != 1 || !sfs[0].Draining || !sfs[0].DrainPaused || sfs[0].DrainError == "" {
+			return errors.New("drain should have paused itself")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A draining folder can't be removed or resized.
+	if err := cmt.cm.RemoveStorageFolder(drainIndex, false); !errors.Contains(err, errStorageFolderDraining) {
+		t.Fatal("expected errStorageFolderDraining", err)
+	}
+	if err := cmt.cm.ResizeStorageFolder(drainIndex, modules.SectorSize*storageFolderGranularity*2, false); !errors.Contains(err, errStorageFolderDraining) {
+		t.Fatal("expected errStorageFolderDraining", err)
+	}
+
+	// Add a second storage folder. New sectors should only be added to the
+	// new folder.
+	err = cmt.cm.AddStorageFolder(storageFolderTwo, modules.SectorSize*storageFolderGranularity*2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, data := randSector()
+	if err := cmt.cm.AddSector(root, data); err != nil {
+		t.Fatal(err)
+	}
+	roots = append(roots, root)
+	datas = append(datas, data)
+	for _, sf := range cmt.cm.StorageFolders() {
+		if sf.Index == drainIndex && sf.Capacity-sf.CapacityRemaining != 5*modules.SectorSize {
+			t.Fatal("sector was added to the draining folder")
+		}
+	}
+
+	// Update the rate and resume the drain. The folder should be removed once
+	// all sectors are migrated.
+	if err := cmt.cm.DrainStorageFolder(drainIndex, 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmt.cm.PauseStorageFolderDrain(drainIndex, false); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		sfs := cmt.cm.StorageFolders()
+		if len(sfs) != 1 {
+			return errors.New("drained folder wasn't removed")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sfs = cmt.cm.StorageFolders()
+	if sfs[0].Path != storageFolderTwo || sfs[0].Capacity-sfs[0].CapacityRemaining != 6*modules.SectorSize {
+		t.Fatal("sectors weren't migrated to the remaining folder")
+	}
+	if _, err := os.Stat(filepath.Join(storageFolderOne, sectorFile)); !os.IsNotExist(err) {
+		t.Fatal("sector file should have been removed")
+	}
+	for i, root := range roots {
+		sectorData, err := cmt.cm.ReadSector(root)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(sectorData, datas[i]) {
+			t.Fatal("migrated sector has the wrong data")
+		}
+	}
+
+	// Pausing the drain of a folder which isn't drained should fail.
+	if err := cmt.cm.PauseStorageFolderDrain(sfs[0].Index, true); !errors.Contains(err, errStorageFolderNotDraining) {
+		t.Fatal("expected errStorageFolderNotDraining", err)
+	}
+}
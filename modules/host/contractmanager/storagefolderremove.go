@@ -61,6 +61,10 @@ func (cm *ContractManager) RemoveStorageFolder(index uint16, force bool) error {
 		cm.wal.mu.Unlock()
 		return errStorageFolderNotFound
 	}
+	if sf.drain != nil {
+		cm.wal.mu.Unlock()
+		return errStorageFolderDraining
+	}
 	cm.wal.mu.Unlock()
 
 	// Lock the storage folder for the duration of the operation.
@@ -80,19 +84,25 @@ func (cm *ContractManager) RemoveStorageFolder(index uint16, force bool) error {
 	cm.wal.mu.Unlock()
 	<-syncChan
 
-	// Submit a storage folder removal to the WAL and wait until the update is
-	// synced.
-	cm.wal.mu.Lock()
-	cm.wal.appendChange(stateChange{
+	// Remove the storage folder.
+	cm.wal.managedSubmitStorageFolderRemoval(sf)
+	return nil
+}
+
+// managedSubmitStorageFolderRemoval submits the removal of an empty storage
+// folder to the WAL and waits until the removal is synced. The caller needs to
+// hold the storage folder's lock.
+func (wal *writeAheadLog) managedSubmitStorageFolderRemoval(sf *storageFolder) {
+	wal.mu.Lock()
+	wal.appendChange(stateChange{
 		StorageFolderRemovals: []storageFolderRemoval{{
-			Index: index,
+			Index: sf.index,
 			Path:  sf.path,
 		}},
 	})
 
 	// Wait until the removal action has been synchronized.
-	syncChan = cm.wal.syncChan
-	cm.wal.mu.Unlock()
+	syncChan := wal.syncChan
+	wal.mu.Unlock()
 	<-syncChan
-	return nil
 }
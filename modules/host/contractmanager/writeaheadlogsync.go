@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
 )
 
 // syncResources will call Sync on all resources that the WAL has open. The
@@ -158,7 +159,7 @@ func (wal *writeAheadLog) syncResources() {
 // False does not indiciate an error, it can also indicate that there was
 // nothing to do.
 //
-// commit should only be called from threadedSyncLoop.
+// commit should only be called from threadedSyncLoop and Quiesce.
 func (wal *writeAheadLog) commit() {
 	// Sync all open, non-WAL files on the host.
 	wal.syncResources()
@@ -286,6 +287,7 @@ func (wal *writeAheadLog) spawnSyncLoop() (err error) {
 // transactions to the contract manager are batched automatically and
 // occasionally committed together.
 func (wal *writeAheadLog) threadedSyncLoop(threadsStopped chan struct{}, syncLoopStopped chan struct{}) {
+	defer modules.RecoverPanic("contractmanager")
 	// Provide a place for the testing to disable the sync loop.
 	if wal.cm.dependencies.Disrupt("threadedSyncLoopStart") {
 		close(syncLoopStopped)
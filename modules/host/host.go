@@ -88,9 +88,10 @@ import (
 
 const (
 	// Names of the various persistent files in the host.
-	dbFilename   = modules.HostDir + ".db"
-	logFile      = modules.HostDir + ".log"
-	settingsFile = modules.HostDir + ".json"
+	dbFilename         = modules.HostDir + ".db"
+	logFile            = modules.HostDir + ".log"
+	metricsJournalFile = modules.HostDir + "metrics.journal"
+	settingsFile       = modules.HostDir + ".json"
 )
 
 var (
@@ -115,6 +116,15 @@ var (
 		Testing:  1 * time.Minute,
 	}).(time.Duration)
 
+	// defaultPriceTableGracePeriod is the default amount of time after
+	// issuing a new price table to a renter during which the host still
+	// accepts the renter's previous price table for in-flight RPCs.
+	defaultPriceTableGracePeriod = build.Select(build.Var{
+		Standard: time.Minute,
+		Dev:      30 * time.Second,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+
 	// pruneExpiredRPCPriceTableFrequency is the frequency at which the host
 	// checks if it can expire price tables that have an expiry in the past.
 	pruneExpiredRPCPriceTableFrequency = build.Select(build.Var{
@@ -162,6 +172,12 @@ type Host struct {
 	staticMDM                   *mdm.MDM
 	staticRegistry              *registry.Registry
 	staticRegistrySubscriptions *registrySubscriptions
+	staticRPCTracer             *rpcTracer
+	staticSubscriptionSets      *subscriptionSets
+	staticSectorCache           *sectorCache
+	staticAccountTransactionLog *accountTransactionLog
+	staticBandwidthLedger       *bandwidthLedger
+	staticWriteBatch            *writeBatch
 
 	// Host ACID fields - these fields need to be updated in serial, ACID
 	// transactions.
@@ -180,12 +196,28 @@ type Host struct {
 	revisionNumber       uint64
 	workingStatus        modules.HostWorkingStatus
 	connectabilityStatus modules.HostConnectabilityStatus
+	rescanStatus         modules.HostRescanStatus
+	monitoringServer     *monitoringServer // Serves the settings if a MonitoringAddress is configured
+
+	// The journal of the financial metrics, making them crash-consistent, and
+	// the result of reconciling them with the persisted metrics at startup.
+	staticMetricsJournal  *modules.MetricsJournal
+	metricsReconciliation modules.MetricsReconciliation
+
+	// The host's dynamic pricing. The policy is persisted, the state derived
+	// from it is not.
+	pricingPolicy modules.HostPricingPolicy
+	pricing       hostPricing
 
 	// A map of storage obligations that are currently being modified. Locks on
 	// storage obligations can be long-running, and each storage obligation can
 	// be locked separately.
 	lockedStorageObligations map[types.FileContractID]*lockedObligation
 
+	// The storage obligations with an unconfirmed storage proof close to its
+	// deadline. The host alerts the user while the set isn't empty.
+	proofsInDanger map[types.FileContractID]struct{}
+
 	// A collection of rpc price tables, covered by its own RW mutex. It
 	// contains the host's current price table and the set of price tables the
 	// host has communicated to all renters, thus guaranteeing a set of prices
@@ -199,14 +231,15 @@ type Host struct {
 	atomicStreamDownload uint64
 
 	// Misc state.
-	db            *persist.BoltDatabase
-	listener      net.Listener
-	log           *persist.Logger
-	mu            sync.RWMutex
-	staticMonitor *connmonitor.Monitor
-	persistDir    string
-	port          string
-	tg            siasync.ThreadGroup
+	db             *persist.BoltDatabase
+	listener       net.Listener
+	extraListeners []net.Listener
+	log            *persist.Logger
+	mu             sync.RWMutex
+	staticMonitor  *connmonitor.Monitor
+	persistDir     string
+	port           string
+	tg             siasync.ThreadGroup
 }
 
 // hostPrices is a helper type that wraps both the host's RPC price table and
@@ -214,11 +247,28 @@ type Host struct {
 // covered by a read write mutex to help lock contention. It contains a separate
 // minheap that enables efficiently purging expired price tables from the
 // 'guaranteed' map.
+//
+// Price tables are versioned per account the renter pays with. When a renter
+// is issued a new price table, its previous one is still accepted within the
+// grace period advertised in it, even if it expires in the meantime.
 type hostPrices struct {
 	current       modules.RPCPriceTable
 	guaranteed    map[modules.UniqueID]*hostRPCPriceTable
 	staticMinHeap priceTableHeap
-	mu            sync.RWMutex
+
+	// latest contains the most recent price table issued to every account
+	// and superseded contains the time until which the price tables that
+	// were superseded before they expired are still accepted.
+	latest     map[modules.AccountID]priceTableVersion
+	superseded map[modules.UniqueID]time.Time
+
+	mu sync.RWMutex
+}
+
+// priceTableVersion is the version of a price table issued to an account.
+type priceTableVersion struct {
+	uid     modules.UniqueID
+	version uint64
 }
 
 // managedCurrent returns the host's current price table
@@ -244,14 +294,45 @@ func (hp *hostPrices) managedSetCurrent(pt modules.RPCPriceTable) {
 	hp.current = pt
 }
 
+// managedAcceptedUntil returns the time until which the host accepts the given
+// price table. That is its expiry, unless it was superseded by a newer price
+// table before it expired, in which case it is accepted until the end of the
+// grace period that started when it was superseded.
+func (hp *hostPrices) managedAcceptedUntil(pt *hostRPCPriceTable) time.Time {
+	hp.mu.RLock()
+	defer hp.mu.RUnlock()
+	until := pt.Expiry()
+	if grace, exists := hp.superseded[pt.UID]; exists && grace.After(until) {
+		until = grace
+	}
+	return until
+}
+
 // managedTrack adds the given price table to the 'guaranteed' map, that holds
 // all of the price tables the host has recently guaranteed to renters. It will
-// also add it to the heap which facilates efficient pruning of that map.
-func (hp *hostPrices) managedTrack(pt *hostRPCPriceTable) {
+// also add it to the heap which facilates efficient pruning of that map. The
+// price table supersedes the previous price table issued to the account. The
+// version of the price table and the UID of the previous one are returned.
+func (hp *hostPrices) managedTrack(pt *hostRPCPriceTable, account modules.AccountID) (version uint64, previous modules.UniqueID) {
 	hp.mu.Lock()
 	hp.guaranteed[pt.UID] = pt
+	version = 1
+	if latest, exists := hp.latest[account]; exists {
+		version = latest.version + 1
+		if prev, tracked := hp.guaranteed[latest.uid]; tracked {
+			previous = latest.uid
+			if now := time.Now(); now.Before(prev.Expiry()) {
+				hp.superseded[previous] = now.Add(prev.GracePeriod)
+			}
+		}
+	}
+	hp.latest[account] = priceTableVersion{
+		uid:     pt.UID,
+		version: version,
+	}
 	hp.mu.Unlock()
 	hp.staticMinHeap.Push(pt)
+	return
 }
 
 // managedPruneExpired removes all of the price tables that have expired from
@@ -272,6 +353,13 @@ func (hp *hostPrices) managedPruneExpired() {
 			continue
 		}
 		delete(hp.guaranteed, uid)
+		delete(hp.superseded, uid)
+	}
+	// Forget about the accounts whose latest price table was pruned.
+	for account, latest := range hp.latest {
+		if _, exists := hp.guaranteed[latest.uid]; !exists {
+			delete(hp.latest, account)
+		}
 	}
 	hp.mu.Unlock()
 }
@@ -341,6 +429,7 @@ func (h *Host) managedUpdatePriceTable() {
 
 		// TODO: hardcoded MDM costs should be updated to use better values.
 		HasSectorBaseCost:   types.NewCurrency64(1),
+		BatchHasSectorCost:  types.NewCurrency64(1),
 		MemoryTimeCost:      types.NewCurrency64(1),
 		DropSectorsBaseCost: types.NewCurrency64(1),
 		DropSectorsUnitCost: types.NewCurrency64(1),
@@ -400,6 +489,7 @@ func (h *Host) managedUpdatePriceTable() {
 // Note: threadgroup counter must be inside for loop. If not, calling 'Flush'
 // on the threadgroup would deadlock.
 func (h *Host) threadedPruneExpiredPriceTables() {
+	defer modules.RecoverPanic("host")
 	for {
 		func() {
 			if err := h.tg.Add(); err != nil {
@@ -449,13 +539,18 @@ func newHost(dependencies modules.Dependencies, smDeps modules.Dependencies, cs
 		staticMux:                mux,
 		dependencies:             dependencies,
 		lockedStorageObligations: make(map[types.FileContractID]*lockedObligation),
+		proofsInDanger:           make(map[types.FileContractID]struct{}),
 		staticPriceTables: &hostPrices{
 			guaranteed: make(map[modules.UniqueID]*hostRPCPriceTable),
+			latest:     make(map[modules.AccountID]priceTableVersion),
+			superseded: make(map[modules.UniqueID]time.Time),
 			staticMinHeap: priceTableHeap{
 				heap: make([]*hostRPCPriceTable, 0),
 			},
 		},
 		staticRegistrySubscriptions: newRegistrySubscriptions(),
+		staticSectorCache:           newSectorCache(defaultReadCacheSize),
+		staticWriteBatch:            new(writeBatch),
 		persistDir:                  persistDir,
 	}
 
@@ -511,6 +606,27 @@ func newHost(dependencies modules.Dependencies, smDeps modules.Dependencies, cs
 	if err != nil {
 		return nil, err
 	}
+	h.staticSectorCache.managedSetMaxSize(h.settings.ReadCacheSize)
+
+	// Open the metrics journal and reconcile the loaded financial metrics
+	// with it.
+	h.staticMetricsJournal, err = modules.NewMetricsJournal(filepath.Join(h.persistDir, metricsJournalFile))
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to open metrics journal")
+	}
+	h.tg.AfterStop(func() {
+		err := h.staticMetricsJournal.Close()
+		if err != nil {
+			h.log.Println("Could not close metrics journal:", err)
+		}
+	})
+	h.mu.Lock()
+	err = h.reconcileFinancialMetrics()
+	h.mu.Unlock()
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to reconcile financial metrics")
+	}
+
 	h.tg.AfterStop(func() {
 		err := h.saveSync()
 		if err != nil {
@@ -518,6 +634,42 @@ func newHost(dependencies modules.Dependencies, smDeps modules.Dependencies, cs
 		}
 	})
 
+	// Create the bandwidth ledger and make sure it is persisted before the
+	// database is closed.
+	h.staticBandwidthLedger = newBandwidthLedger(h.db)
+	h.tg.AfterStop(func() {
+		err := h.staticBandwidthLedger.managedFlush()
+		if err != nil {
+			h.log.Println("Could not persist bandwidth ledger upon shutdown:", err)
+		}
+	})
+	go h.threadedFlushBandwidthLedger()
+
+	// Create the account transaction log and make sure it is persisted before
+	// the database is closed.
+	h.staticAccountTransactionLog = newAccountTransactionLog(h.db)
+	h.tg.AfterStop(func() {
+		err := h.staticAccountTransactionLog.managedFlush()
+		if err != nil {
+			h.log.Println("Could not persist account transaction log upon shutdown:", err)
+		}
+	})
+	go h.threadedFlushAccountTransactionLog()
+
+	// Load the subscription sets and make sure the updated ones are persisted
+	// before the database is closed.
+	h.staticSubscriptionSets, err = newSubscriptionSets(h.db)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to load subscription sets")
+	}
+	h.tg.AfterStop(func() {
+		err := h.staticSubscriptionSets.managedFlush()
+		if err != nil {
+			h.log.Println("Could not persist subscription sets upon shutdown:", err)
+		}
+	})
+	go h.threadedFlushSubscriptionSets()
+
 	// Load the registry.
 	err = h.managedInitRegistry()
 	if err != nil {
@@ -545,6 +697,19 @@ func newHost(dependencies modules.Dependencies, smDeps modules.Dependencies, cs
 	// Create bandwidth monitor
 	h.staticMonitor = connmonitor.NewMonitor()
 
+	// Create the RPC tracer. The traces are exported to an OpenTelemetry
+	// collector if one is configured.
+	var exporter *otlpExporter
+	endpoint := build.OTLPTracesEndpoint()
+	if endpoint != "" {
+		exporter = newOTLPExporter(endpoint, h.publicKey.String())
+	}
+	h.staticRPCTracer = newRPCTracer(exporter)
+	if exporter != nil {
+		go h.threadedExportRPCTraces()
+		h.tg.OnStop(h.managedExportRPCTraces)
+	}
+
 	// Initialize the networking. We need to hold the lock while doing so since
 	// the previous load subscribed the host to the consensus set.
 	h.mu.Lock()
@@ -558,9 +723,32 @@ func newHost(dependencies modules.Dependencies, smDeps modules.Dependencies, cs
 	// Initialize the RPC price table
 	h.managedUpdatePriceTable()
 
+	// Start the monitoring endpoint if configured and make sure it is closed
+	// on shutdown. A failure to start it shouldn't prevent the host from
+	// starting.
+	h.mu.Lock()
+	err = h.updateMonitoringServer(h.settings.MonitoringAddress)
+	h.mu.Unlock()
+	if err != nil {
+		h.log.Println("WARN: monitoring endpoint not started:", err)
+	}
+	h.tg.OnStop(func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if err := h.updateMonitoringServer(""); err != nil {
+			h.log.Println("WARN: failed to stop monitoring endpoint:", err)
+		}
+	})
+
 	// Ensure the expired RPC tables get pruned as to not leak memory
 	go h.threadedPruneExpiredPriceTables()
 
+	// Periodically update the prices according to the pricing policy.
+	go h.threadedUpdatePricing()
+
+	// Periodically check the host's funds against the alert thresholds.
+	go h.threadedCheckAlerts()
+
 	return h, nil
 }
 
@@ -625,9 +813,20 @@ func (h *Host) BandwidthCounters() (uint64, uint64, time.Time, error) {
 func (h *Host) PriceTable() modules.RPCPriceTable {
 	pt := h.staticPriceTables.managedCurrent()
 	pt.Validity = rpcPriceGuaranteePeriod
+	pt.GracePeriod = h.managedInternalSettings().PriceTableGracePeriod
 	return pt
 }
 
+// MDMMetrics returns the execution statistics of the instructions the host's
+// MDM executed since the host was started.
+func (h *Host) MDMMetrics() modules.HostMDMMetrics {
+	if err := h.tg.Add(); err != nil {
+		return modules.HostMDMMetrics{}
+	}
+	defer h.tg.Done()
+	return h.staticMDM.Metrics()
+}
+
 // WorkingStatus returns the working state of the host, where working is
 // defined as having received more than workingStatusThreshold settings calls
 // over the period of workingStatusFrequency.
@@ -668,6 +867,13 @@ func (h *Host) PublicKey() types.SiaPublicKey {
 
 // SetInternalSettings updates the host's internal HostInternalSettings object.
 func (h *Host) SetInternalSettings(settings modules.HostInternalSettings) error {
+	return h.managedSetInternalSettings(settings, nil)
+}
+
+// managedSetInternalSettings updates the host's internal settings. If
+// settingsHash is not nil, the settings are only updated if the hash of the
+// current settings matches it.
+func (h *Host) managedSetInternalSettings(settings modules.HostInternalSettings, settingsHash *crypto.Hash) error {
 	err := h.tg.Add()
 	if err != nil {
 		return err
@@ -681,28 +887,25 @@ func (h *Host) SetInternalSettings(settings modules.HostInternalSettings) error
 	defer h.managedUpdatePriceTable()
 	defer h.mu.Unlock()
 
-	// The host should not be accepting file contracts if it does not have an
-	// unlock hash.
-	if settings.AcceptingContracts {
-		err := h.checkUnlockHash()
-		if err != nil {
-			return errors.New("internal settings not updated, no unlock hash: " + err.Error())
-		}
+	if settingsHash != nil && *settingsHash != internalSettingsHash(h.settings) {
+		return ErrSettingsChanged
 	}
 
-	if settings.NetAddress != "" {
-		err := settings.NetAddress.IsValid()
-		if err != nil {
-			return errors.New("internal settings not updated, invalid NetAddress: " + err.Error())
-		}
+	// Reject settings which are invalid or break the host's obligations.
+	if errs, _ := h.checkInternalSettings(settings); len(errs) > 0 {
+		return errors.AddContext(errors.Compose(errs...), "internal settings not updated")
 	}
 
 	// Check if the net address for the host has changed. If it has, and it's
 	// not equal to the auto address, then the host is going to need to make
-	// another blockchain announcement.
+	// another blockchain announcement. The same is true if the additional
+	// addresses change.
 	if h.settings.NetAddress != settings.NetAddress && settings.NetAddress != h.autoAddress {
 		h.announced = false
 	}
+	if !equalNetAddresses(h.settings.AdditionalNetAddresses, settings.AdditionalNetAddresses) {
+		h.announced = false
+	}
 
 	// Translate the size of the registry in bytes to the number of entries. Adjust
 	// the input in case it's not a multiple of 64 times the size of a persisted
@@ -728,8 +931,17 @@ func (h *Host) SetInternalSettings(settings modules.HostInternalSettings) error
 		}
 	}
 
+	// Restart the monitoring endpoint if its address changed.
+	if h.settings.MonitoringAddress != settings.MonitoringAddress {
+		err = h.updateMonitoringServer(settings.MonitoringAddress)
+		if err != nil {
+			return err
+		}
+	}
+
 	h.settings = settings
 	h.revisionNumber++
+	h.staticSectorCache.managedSetMaxSize(settings.ReadCacheSize)
 
 	// The locked storage collateral was altered, we potentially want to
 	// unregister the insufficient collateral budget alert
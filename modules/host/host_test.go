@@ -792,7 +792,21 @@ func (p *renterHostPair) managedSign(rev types.FileContractRevision) crypto.Sign
 }
 
 // AccountBalance returns the account balance of the specified account.
-func (p *renterHostPair) managedAccountBalance(payByFC bool, fundAmt types.Currency, fundAcc, balanceAcc modules.AccountID) (_ types.Currency, err error) {
+func (p *renterHostPair) managedAccountBalance(payByFC bool, fundAmt types.Currency, fundAcc, balanceAcc modules.AccountID) (types.Currency, error) {
+	return p.managedCallAccountBalance(modules.RPCAccountBalance, payByFC, fundAmt, fundAcc, modules.AccountBalanceRequest{
+		Account: balanceAcc,
+	})
+}
+
+// managedSignedAccountBalance returns the account balance of the account
+// specified in the signed request.
+func (p *renterHostPair) managedSignedAccountBalance(payByFC bool, fundAmt types.Currency, fundAcc modules.AccountID, req modules.SignedAccountBalanceRequest) (types.Currency, error) {
+	return p.managedCallAccountBalance(modules.RPCSignedAccountBalance, payByFC, fundAmt, fundAcc, req)
+}
+
+// managedCallAccountBalance calls either the AccountBalance or the
+// SignedAccountBalance RPC with the provided request.
+func (p *renterHostPair) managedCallAccountBalance(rpc types.Specifier, payByFC bool, fundAmt types.Currency, fundAcc modules.AccountID, req interface{}) (_ types.Currency, err error) {
 	stream := p.managedNewStream()
 	defer func() {
 		err = errors.Compose(err, stream.Close())
@@ -805,7 +819,7 @@ func (p *renterHostPair) managedAccountBalance(payByFC bool, fundAmt types.Curre
 	}
 
 	// initiate the RPC
-	err = modules.RPCWrite(stream, modules.RPCAccountBalance)
+	err = modules.RPCWrite(stream, rpc)
 	if err != nil {
 		return types.ZeroCurrency, err
 	}
@@ -830,9 +844,7 @@ func (p *renterHostPair) managedAccountBalance(payByFC bool, fundAmt types.Curre
 	}
 
 	// send the request.
-	err = modules.RPCWrite(stream, modules.AccountBalanceRequest{
-		Account: balanceAcc,
-	})
+	err = modules.RPCWrite(stream, req)
 	if err != nil {
 		return types.ZeroCurrency, err
 	}
@@ -853,6 +865,176 @@ func (p *renterHostPair) managedAccountBalance(payByFC bool, fundAmt types.Curre
 	return abr.Balance, nil
 }
 
+// managedContractCollateral fetches the collateral of the pair's contract from
+// the host. The request is signed with the provided key.
+func (p *renterHostPair) managedContractCollateral(fundAmt types.Currency, sk crypto.SecretKey) (_ modules.ContractCollateral, err error) {
+	stream := p.managedNewStream()
+	defer func() {
+		err = errors.Compose(err, stream.Close())
+	}()
+
+	// Fetch the price table.
+	pt, err := p.managedFetchPriceTable()
+	if err != nil {
+		return modules.ContractCollateral{}, err
+	}
+
+	// initiate the RPC
+	err = modules.RPCWrite(stream, modules.RPCContractCollateral)
+	if err != nil {
+		return modules.ContractCollateral{}, err
+	}
+
+	// Write the pricetable uid.
+	err = modules.RPCWrite(stream, pt.UID)
+	if err != nil {
+		return modules.ContractCollateral{}, err
+	}
+
+	// provide payment
+	err = p.managedPayByEphemeralAccount(stream, fundAmt)
+	if err != nil {
+		return modules.ContractCollateral{}, err
+	}
+
+	// send the signed request.
+	hash := modules.ContractCollateralRequestHash(p.staticFCID, pt.UID)
+	err = modules.RPCWrite(stream, modules.ContractCollateralRequest{
+		FileContractID: p.staticFCID,
+		Signature:      crypto.SignHash(hash, sk),
+	})
+	if err != nil {
+		return modules.ContractCollateral{}, err
+	}
+
+	// read the response.
+	var ccr modules.ContractCollateralResponse
+	err = modules.RPCRead(stream, &ccr)
+	if err != nil {
+		return modules.ContractCollateral{}, err
+	}
+
+	// expect clean stream close
+	err = modules.RPCRead(stream, struct{}{})
+	if !errors.Contains(err, io.ErrClosedPipe) {
+		return modules.ContractCollateral{}, err
+	}
+	return ccr.Collateral, nil
+}
+
+// managedSectorStats fetches the stats of the sectors with the given roots
+// from the host.
+func (p *renterHostPair) managedSectorStats(payByFC bool, fundAmt types.Currency, roots []crypto.Hash) (_ []modules.SectorStat, err error) {
+	stream := p.managedNewStream()
+	defer func() {
+		err = errors.Compose(err, stream.Close())
+	}()
+
+	// Fetch the price table.
+	pt, err := p.managedFetchPriceTable()
+	if err != nil {
+		return nil, err
+	}
+
+	// initiate the RPC
+	err = modules.RPCWrite(stream, modules.RPCSectorStats)
+	if err != nil {
+		return nil, err
+	}
+
+	// Write the pricetable uid.
+	err = modules.RPCWrite(stream, pt.UID)
+	if err != nil {
+		return nil, err
+	}
+
+	// provide payment
+	if payByFC {
+		err = p.managedPayByContract(stream, fundAmt, p.staticAccountID)
+	} else {
+		err = p.managedPayByEphemeralAccount(stream, fundAmt)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// send the request.
+	err = modules.RPCWrite(stream, modules.SectorStatsRequest{Roots: roots})
+	if err != nil {
+		return nil, err
+	}
+
+	// read the response.
+	var ssr modules.SectorStatsResponse
+	err = modules.RPCReadMaxLen(stream, &ssr, modules.SectorStatsResponseMaxLen)
+	if err != nil {
+		return nil, err
+	}
+
+	// expect clean stream close
+	err = modules.RPCRead(stream, struct{}{})
+	if !errors.Contains(err, io.ErrClosedPipe) {
+		return nil, err
+	}
+	return ssr.Stats, nil
+}
+
+// managedBatchHasSector performs the BatchHasSector RPC with the host.
+func (p *renterHostPair) managedBatchHasSector(payByFC bool, fundAmt types.Currency, roots []crypto.Hash) (_ []bool, err error) {
+	stream := p.managedNewStream()
+	defer func() {
+		err = errors.Compose(err, stream.Close())
+	}()
+
+	// Fetch the price table.
+	pt, err := p.managedFetchPriceTable()
+	if err != nil {
+		return nil, err
+	}
+
+	// initiate the RPC
+	err = modules.RPCWrite(stream, modules.RPCBatchHasSector)
+	if err != nil {
+		return nil, err
+	}
+
+	// Write the pricetable uid.
+	err = modules.RPCWrite(stream, pt.UID)
+	if err != nil {
+		return nil, err
+	}
+
+	// provide payment
+	if payByFC {
+		err = p.managedPayByContract(stream, fundAmt, p.staticAccountID)
+	} else {
+		err = p.managedPayByEphemeralAccount(stream, fundAmt)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// send the request.
+	err = modules.RPCWrite(stream, modules.BatchHasSectorRequest{Roots: roots})
+	if err != nil {
+		return nil, err
+	}
+
+	// read the response.
+	var resp modules.BatchHasSectorResponse
+	err = modules.RPCReadMaxLen(stream, &resp, modules.BatchHasSectorResponseMaxLen)
+	if err != nil {
+		return nil, err
+	}
+
+	// expect clean stream close
+	err = modules.RPCRead(stream, struct{}{})
+	if !errors.Contains(err, io.ErrClosedPipe) {
+		return nil, err
+	}
+	return resp.Availables, nil
+}
+
 // managedBeginSubscription begins a subscription on a new stream and returns
 // it.
 func (p *renterHostPair) managedBeginSubscription(amount types.Currency, subscriber types.Specifier) (_ siamux.Stream, err error) {
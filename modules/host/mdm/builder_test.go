@@ -76,6 +76,13 @@ func (tb *testProgramBuilder) AddDropSectorsInstruction(numSectors uint64, merkl
 	tb.staticValues.AddDropSectorsInstruction(numSectors)
 }
 
+// AddExpectRevisionInstruction adds an expectrevision instruction to the
+// builder, keeping track of running values.
+func (tb *testProgramBuilder) AddExpectRevisionInstruction(revisionNumber uint64) {
+	tb.staticPB.AddExpectRevisionInstruction(revisionNumber)
+	tb.staticValues.AddExpectRevisionInstruction()
+}
+
 // AddHasSectorInstruction adds a hassector instruction to the builder, keeping track of running values.
 func (tb *testProgramBuilder) AddHasSectorInstruction(merkleRoot crypto.Hash) {
 	tb.staticPB.AddHasSectorInstruction(merkleRoot)
@@ -96,6 +103,13 @@ func (tb *testProgramBuilder) AddReadSectorInstruction(length, offset uint64, me
 	tb.staticValues.AddReadSectorInstruction(length)
 }
 
+// AddReadSectorWithPrefetchInstruction adds a ReadSector instruction with
+// prefetch hints to the builder, keeping track of running values.
+func (tb *testProgramBuilder) AddReadSectorWithPrefetchInstruction(length, offset uint64, merkleRoot crypto.Hash, prefetch []crypto.Hash, merkleProof bool) {
+	tb.staticPB.AddReadSectorWithPrefetchInstruction(length, offset, merkleRoot, prefetch, merkleProof)
+	tb.staticValues.AddReadSectorWithPrefetchInstruction(length, len(prefetch))
+}
+
 // AddRevisionInstruction adds a revision instruction to the builder, keeping
 // track of running values.
 func (tb *testProgramBuilder) AddRevisionInstruction() {
@@ -110,6 +124,20 @@ func (tb *testProgramBuilder) AddSwapSectorInstruction(sector1Idx, sector2Idx ui
 	tb.staticValues.AddSwapSectorInstruction()
 }
 
+// AddTruncateSectorInstruction adds a TruncateSector instruction to the
+// builder, keeping track of running values.
+func (tb *testProgramBuilder) AddTruncateSectorInstruction(sectorIdx, length uint64, merkleProof bool) {
+	tb.staticPB.AddTruncateSectorInstruction(sectorIdx, length, merkleProof)
+	tb.staticValues.AddTruncateSectorInstruction()
+}
+
+// AddUpdateSectorRangeInstruction adds an UpdateSectorRange instruction to the
+// builder, keeping track of running values.
+func (tb *testProgramBuilder) AddUpdateSectorRangeInstruction(sectorIdx, offset uint64, data []byte, merkleProof bool) {
+	tb.staticPB.AddUpdateSectorRangeInstruction(sectorIdx, offset, data, merkleProof)
+	tb.staticValues.AddUpdateSectorRangeInstruction(data)
+}
+
 // AddUpdateRegistryInstruction adds an UpdateRegistry instruction to the
 // builder, keeping track of running values.
 func (tb *testProgramBuilder) AddUpdateRegistryInstruction(spk types.SiaPublicKey, rv modules.SignedRegistryValue) {
@@ -0,0 +1,97 @@
+package mdm
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// instructionExpectRevision is an instruction which fails if the revision
+// number of the host's contract doesn't match the revision number the renter
+// expects. It protects renters from reading data through a stale or diverged
+// revision.
+type instructionExpectRevision struct {
+	commonInstruction
+
+	revisionNumberOffset uint64
+}
+
+// staticDecodeExpectRevisionInstruction creates a new 'ExpectRevision'
+// instruction from the provided generic instruction.
+func (p *program) staticDecodeExpectRevisionInstruction(instruction modules.Instruction) (instruction, error) {
+	// Check specifier.
+	if instruction.Specifier != modules.SpecifierExpectRevision {
+		return nil, fmt.Errorf("expected specifier %v but got %v",
+			modules.SpecifierExpectRevision, instruction.Specifier)
+	}
+	// Check args.
+	if len(instruction.Args) != modules.RPCIExpectRevisionLen {
+		return nil, fmt.Errorf("expected instruction to have len %v but was %v",
+			modules.RPCIExpectRevisionLen, len(instruction.Args))
+	}
+	// Read args.
+	revisionNumberOffset := binary.LittleEndian.Uint64(instruction.Args[:8])
+	return &instructionExpectRevision{
+		commonInstruction: commonInstruction{
+			staticData:        p.staticData,
+			staticMerkleProof: false,
+			staticState:       p.staticProgramState,
+		},
+		revisionNumberOffset: revisionNumberOffset,
+	}, nil
+}
+
+// Batch declares whether or not this instruction can be batched together with
+// the previous instruction.
+func (i instructionExpectRevision) Batch() bool {
+	return true
+}
+
+// Collateral is zero for the ExpectRevision instruction.
+func (i *instructionExpectRevision) Collateral() types.Currency {
+	return modules.MDMExpectRevisionCollateral()
+}
+
+// Cost returns the cost of executing this instruction.
+func (i *instructionExpectRevision) Cost() (executionCost, _ types.Currency, err error) {
+	executionCost = modules.MDMExpectRevisionCost(i.staticState.priceTable)
+	return
+}
+
+// Memory returns the memory allocated by this instruction beyond the end of its
+// lifetime.
+func (i *instructionExpectRevision) Memory() uint64 {
+	return modules.MDMExpectRevisionMemory()
+}
+
+// Execute executes the 'ExpectRevision' instruction.
+func (i *instructionExpectRevision) Execute(prevOutput output) (output, types.Currency) {
+	// Fetch the operands.
+	expected, err := i.staticData.Uint64(i.revisionNumberOffset)
+	if err != nil {
+		return errOutput(err), types.ZeroCurrency
+	}
+
+	// Compare the revision numbers.
+	revTxn := i.staticState.staticRevisionTxn
+	if len(revTxn.FileContractRevisions) == 0 {
+		return errOutput(errors.New("program has no contract to compare the revision of")), types.ZeroCurrency
+	}
+	actual := revTxn.FileContractRevisions[0].NewRevisionNumber
+	if actual != expected {
+		return errOutput(errors.AddContext(modules.ErrMDMUnexpectedRevision, fmt.Sprintf("expected revision %v but host has revision %v", expected, actual))), types.ZeroCurrency
+	}
+
+	return output{
+		NewSize:       prevOutput.NewSize,       // size stays the same
+		NewMerkleRoot: prevOutput.NewMerkleRoot, // root stays the same
+	}, types.ZeroCurrency
+}
+
+// Time returns the execution time of an 'ExpectRevision' instruction.
+func (i *instructionExpectRevision) Time() (uint64, error) {
+	return modules.MDMTimeExpectRevision, nil
+}
@@ -0,0 +1,85 @@
+package mdm
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestInstructionExpectRevision tests executing a program with an
+// ExpectRevisionInstruction.
+func TestInstructionExpectRevision(t *testing.T) {
+	host := newTestHost()
+	mdm := New(host)
+	defer mdm.Stop()
+
+	so := host.newTestStorageObligation(true)
+	so.sectorRoots = randomSectorRoots(1)
+	sectorRoot := so.sectorRoots[0]
+	sectorData, err := host.ReadSector(sectorRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rev := so.RecentRevision()
+	ics := rev.NewFileSize
+	imr := rev.NewFileMerkleRoot
+
+	// Expect the right revision and read a sector.
+	pt := newTestPriceTable()
+	tb := newTestProgramBuilder(pt, 0)
+	tb.AddExpectRevisionInstruction(rev.NewRevisionNumber)
+	tb.AddReadSectorInstruction(modules.SectorSize, 0, sectorRoot, false)
+	outputs, err := mdm.ExecuteProgramWithBuilder(tb, so, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = outputs[0].assert(ics, imr, []crypto.Hash{}, []byte{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = outputs[1].assert(ics, imr, []crypto.Hash{}, sectorData, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Expect an outdated revision. The instruction should fail and the read
+	// shouldn't be executed.
+	tb = newTestProgramBuilder(pt, 0)
+	tb.AddExpectRevisionInstruction(rev.NewRevisionNumber - 1)
+	tb.AddReadSectorInstruction(modules.SectorSize, 0, sectorRoot, false)
+	program, programData := tb.Program()
+	values := tb.Cost()
+	_, _, collateral, _ := values.Cost()
+	_, outputChan, err := mdm.ExecuteProgram(context.Background(), pt, program, values.Budget(false), collateral, so, 0, uint64(len(programData)), bytes.NewReader(programData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	outputs = outputs[:0]
+	for output := range outputChan {
+		outputs = append(outputs, output)
+	}
+	if len(outputs) != 1 {
+		t.Fatal("expected exactly one output but got", len(outputs))
+	}
+	if !errors.Contains(outputs[0].Error, modules.ErrMDMUnexpectedRevision) {
+		t.Fatal("expected ErrMDMUnexpectedRevision but got", outputs[0].Error)
+	}
+	if len(outputs[0].Output) != 0 {
+		t.Fatal("expected empty output")
+	}
+
+	// Expect a revision the host doesn't have yet.
+	tb = newTestProgramBuilder(pt, 0)
+	tb.AddExpectRevisionInstruction(rev.NewRevisionNumber + 1)
+	outputs, _, err = mdm.ExecuteProgramWithBuilderCustomBudget(tb, so, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Contains(outputs[0].Error, modules.ErrMDMUnexpectedRevision) {
+		t.Fatal("expected ErrMDMUnexpectedRevision but got", outputs[0].Error)
+	}
+}
@@ -18,6 +18,11 @@ type instructionReadSector struct {
 	lengthOffset     uint64
 	offsetOffset     uint64
 	merkleRootOffset uint64
+
+	// prefetchOffset and numPrefetch describe the optional list of sector
+	// roots the renter expects to read next.
+	prefetchOffset uint64
+	numPrefetch    uint64
 }
 
 // staticDecodeReadSectorInstruction creates a new 'ReadSector' instruction from the
@@ -29,14 +34,24 @@ func (p *program) staticDecodeReadSectorInstruction(instruction modules.Instruct
 			modules.SpecifierReadSector, instruction.Specifier)
 	}
 	// Check args.
-	if len(instruction.Args) != modules.RPCIReadSectorLen {
-		return nil, fmt.Errorf("expected instruction to have len %v but was %v",
-			modules.RPCIReadSectorLen, len(instruction.Args))
+	if len(instruction.Args) != modules.RPCIReadSectorLen &&
+		len(instruction.Args) != modules.RPCIReadSectorWithPrefetchLen {
+		return nil, fmt.Errorf("expected instruction to have len %v or %v but was %v",
+			modules.RPCIReadSectorLen, modules.RPCIReadSectorWithPrefetchLen, len(instruction.Args))
 	}
 	// Read args.
 	rootOffset := binary.LittleEndian.Uint64(instruction.Args[:8])
 	offsetOffset := binary.LittleEndian.Uint64(instruction.Args[8:16])
 	lengthOffset := binary.LittleEndian.Uint64(instruction.Args[16:24])
+	var prefetchOffset, numPrefetch uint64
+	if len(instruction.Args) == modules.RPCIReadSectorWithPrefetchLen {
+		prefetchOffset = binary.LittleEndian.Uint64(instruction.Args[25:33])
+		numPrefetch = binary.LittleEndian.Uint64(instruction.Args[33:41])
+	}
+	if numPrefetch > modules.MDMMaxPrefetchSectors {
+		return nil, fmt.Errorf("instruction can't prefetch more than %v sectors but tried to prefetch %v",
+			modules.MDMMaxPrefetchSectors, numPrefetch)
+	}
 
 	// Return instruction.
 	return &instructionReadSector{
@@ -48,6 +63,8 @@ func (p *program) staticDecodeReadSectorInstruction(instruction modules.Instruct
 		lengthOffset:     lengthOffset,
 		merkleRootOffset: rootOffset,
 		offsetOffset:     offsetOffset,
+		prefetchOffset:   prefetchOffset,
+		numPrefetch:      numPrefetch,
 	}, nil
 }
 
@@ -73,7 +90,7 @@ func executeReadSector(previousOutput output, ps *programState, length, offset u
 		return errOutput(err), nil
 	}
 
-	sectorData, err := ps.sectors.readSector(ps.host, sectorRoot)
+	sectorData, err := ps.sectors.readSector(ps.host, sectorRoot, ps.staticBypassReadCache)
 	if err != nil {
 		return errOutput(err), nil
 	}
@@ -111,7 +128,21 @@ func (i *instructionReadSector) Execute(previousOutput output) (output, types.Cu
 	if err != nil {
 		return errOutput(err), types.ZeroCurrency
 	}
+	prefetch := make([]crypto.Hash, 0, i.numPrefetch)
+	for j := uint64(0); j < i.numPrefetch; j++ {
+		root, err := i.staticData.Hash(i.prefetchOffset + j*crypto.HashSize)
+		if err != nil {
+			return errOutput(err), types.ZeroCurrency
+		}
+		prefetch = append(prefetch, root)
+	}
 	output, _ := executeReadSector(previousOutput, i.staticState, length, offset, sectorRoot, i.staticMerkleProof)
+
+	// Warm the sectors which are read next after reading the requested one to
+	// avoid competing with it for the disk.
+	if output.Error == nil && len(prefetch) > 0 {
+		i.staticState.host.PrefetchSectors(prefetch)
+	}
 	return output, types.ZeroCurrency
 }
 
@@ -116,3 +116,93 @@ func TestInstructionReadOutsideSector(t *testing.T) {
 	// Check output.
 	outputs[0].assert(0, imr, []crypto.Hash{}, sectorData, nil)
 }
+
+// TestInstructionReadSectorPrefetch tests that the prefetch hints of a
+// ReadSector instruction are passed on to the host.
+func TestInstructionReadSectorPrefetch(t *testing.T) {
+	host := newTestHost()
+	mdm := New(host)
+	defer mdm.Stop()
+
+	pt := newTestPriceTable()
+	so := host.newTestStorageObligation(true)
+	so.AddRandomSectors(3)
+	root := so.sectorRoots[0]
+	sectorData, err := host.ReadSector(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	duration := types.BlockHeight(fastrand.Uint64n(5))
+
+	// Read the first sector and hint at the other two.
+	tb := newTestProgramBuilder(pt, duration)
+	tb.AddReadSectorWithPrefetchInstruction(modules.SectorSize, 0, root, so.sectorRoots[1:], true)
+	ics := so.ContractSize()
+	imr := so.MerkleRoot()
+	outputs, err := mdm.ExecuteProgramWithBuilder(tb, so, duration, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := outputs[0].assert(ics, imr, []crypto.Hash{}, sectorData, nil); err != nil {
+		t.Fatal(err)
+	}
+	host.mu.Lock()
+	prefetched := host.prefetched
+	host.mu.Unlock()
+	if len(prefetched) != 2 || prefetched[0] != so.sectorRoots[1] || prefetched[1] != so.sectorRoots[2] {
+		t.Fatal("wrong sectors were prefetched", prefetched)
+	}
+
+	// Hinting at too many sectors is not allowed.
+	i := modules.NewReadSectorWithPrefetchInstruction(0, 8, 16, 48, modules.MDMMaxPrefetchSectors+1, true)
+	if _, err := new(program).staticDecodeReadSectorInstruction(i); err == nil {
+		t.Fatal("expected decoding to fail")
+	}
+}
+
+// TestInstructionReadSectorBypassCache tests that the reads of programs which
+// read many sectors bypass the host's sector cache.
+func TestInstructionReadSectorBypassCache(t *testing.T) {
+	host := newTestHost()
+	mdm := New(host)
+	defer mdm.Stop()
+
+	pt := newTestPriceTable()
+	so := host.newTestStorageObligation(true)
+	so.AddRandomSectors(1)
+	root := so.sectorRoots[0]
+	duration := types.BlockHeight(fastrand.Uint64n(5))
+
+	// execute executes a program with n reads and returns the number of reads
+	// which bypassed the cache.
+	execute := func(n int) int {
+		host.mu.Lock()
+		host.bypassedReads = 0
+		host.mu.Unlock()
+		tb := newTestProgramBuilder(pt, duration)
+		for i := 0; i < n; i++ {
+			tb.AddReadSectorInstruction(modules.SectorSize, 0, root, false)
+		}
+		outputs, err := mdm.ExecuteProgramWithBuilder(tb, so, duration, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, output := range outputs {
+			if output.Error != nil {
+				t.Fatal(output.Error)
+			}
+		}
+		host.mu.Lock()
+		defer host.mu.Unlock()
+		return host.bypassedReads
+	}
+
+	// Programs up to the threshold use the cache.
+	if bypassed := execute(readCacheBypassReads); bypassed != 0 {
+		t.Fatal("reads shouldn't bypass the cache", bypassed)
+	}
+	// Larger programs bypass it.
+	if bypassed := execute(readCacheBypassReads + 1); bypassed != readCacheBypassReads+1 {
+		t.Fatal("reads should bypass the cache", bypassed)
+	}
+}
@@ -0,0 +1,99 @@
+package mdm
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// instructionTruncateSector is an instruction that zeroes out the data of an
+// existing sector of a file contract beyond a certain length.
+type instructionTruncateSector struct {
+	commonInstruction
+
+	sectorIdxOffset uint64
+	lengthOffset    uint64
+}
+
+// staticDecodeTruncateSectorInstruction creates a new 'TruncateSector'
+// instruction from the provided generic instruction.
+func (p *program) staticDecodeTruncateSectorInstruction(instruction modules.Instruction) (instruction, error) {
+	// Check specifier.
+	if instruction.Specifier != modules.SpecifierTruncateSector {
+		return nil, fmt.Errorf("expected specifier %v but got %v",
+			modules.SpecifierTruncateSector, instruction.Specifier)
+	}
+	// Check args.
+	if len(instruction.Args) != modules.RPCITruncateSectorLen {
+		return nil, fmt.Errorf("expected instruction to have len %v but was %v",
+			modules.RPCITruncateSectorLen, len(instruction.Args))
+	}
+	// Read args.
+	return &instructionTruncateSector{
+		commonInstruction: commonInstruction{
+			staticData:        p.staticData,
+			staticMerkleProof: instruction.Args[16] == 1,
+			staticState:       p.staticProgramState,
+		},
+		sectorIdxOffset: binary.LittleEndian.Uint64(instruction.Args[:8]),
+		lengthOffset:    binary.LittleEndian.Uint64(instruction.Args[8:16]),
+	}, nil
+}
+
+// Batch declares whether or not this instruction can be batched together with
+// the previous instruction.
+func (i instructionTruncateSector) Batch() bool {
+	return false
+}
+
+// Execute executes the 'TruncateSector' instruction.
+func (i *instructionTruncateSector) Execute(prevOutput output) (output, types.Currency) {
+	// Fetch the data.
+	sectorIdx, err := i.staticData.Uint64(i.sectorIdxOffset)
+	if err != nil {
+		return errOutput(err), types.ZeroCurrency
+	}
+	length, err := i.staticData.Uint64(i.lengthOffset)
+	if err != nil {
+		return errOutput(err), types.ZeroCurrency
+	}
+	if length > modules.SectorSize {
+		return errOutput(fmt.Errorf("length is out of bounds %v > %v", length, modules.SectorSize)), types.ZeroCurrency
+	}
+
+	ps := i.staticState
+	oldRoot, newRoot, newMerkleRoot, err := ps.sectors.updateSector(ps.host, sectorIdx, func(sectorData []byte) {
+		truncated := sectorData[length:]
+		for j := range truncated {
+			truncated[j] = 0
+		}
+	})
+	if err != nil {
+		return errOutput(err), types.ZeroCurrency
+	}
+	return updatedSectorOutput(prevOutput, ps, sectorIdx, oldRoot, newRoot, newMerkleRoot, i.staticMerkleProof), types.ZeroCurrency
+}
+
+// Collateral returns the collateral cost of truncating a sector.
+func (i *instructionTruncateSector) Collateral() types.Currency {
+	return modules.MDMTruncateSectorCollateral()
+}
+
+// Cost returns the Cost of this `TruncateSector` instruction.
+func (i *instructionTruncateSector) Cost() (executionCost, storage types.Currency, err error) {
+	executionCost = modules.MDMTruncateSectorCost(i.staticState.priceTable)
+	return
+}
+
+// Memory returns the memory allocated by the 'TruncateSector' instruction
+// beyond the lifetime of the instruction.
+func (i *instructionTruncateSector) Memory() uint64 {
+	return modules.MDMTruncateSectorMemory()
+}
+
+// Time returns the execution time of a 'TruncateSector' instruction.
+func (i *instructionTruncateSector) Time() (uint64, error) {
+	return modules.MDMTimeTruncateSector, nil
+}
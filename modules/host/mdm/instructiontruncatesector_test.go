@@ -0,0 +1,76 @@
+package mdm
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestInstructionTruncateSector tests executing a program with multiple Append
+// instructions followed by a TruncateSector instruction.
+func TestInstructionTruncateSector(t *testing.T) {
+	host := newTestHost()
+	mdm := New(host)
+	defer mdm.Stop()
+
+	// Prepare a priceTable and duration.
+	pt := newTestPriceTable()
+	duration := types.BlockHeight(fastrand.Uint64n(5))
+
+	// Append 2 sectors and truncate the first one.
+	so := host.newTestStorageObligation(true)
+	tb := newTestProgramBuilder(pt, duration)
+	sectors := [][]byte{
+		fastrand.Bytes(int(modules.SectorSize)),
+		fastrand.Bytes(int(modules.SectorSize)),
+	}
+	oldRoots := []crypto.Hash{crypto.MerkleRoot(sectors[0]), crypto.MerkleRoot(sectors[1])}
+	tb.AddAppendInstruction(sectors[0], false)
+	tb.AddAppendInstruction(sectors[1], false)
+	length := fastrand.Uint64n(modules.SectorSize)
+	tb.AddTruncateSectorInstruction(0, length, true)
+
+	// Execute it.
+	outputs, err := mdm.ExecuteProgramWithBuilder(tb, so, duration, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Compute the expected sector and roots.
+	expectedSector := make([]byte, modules.SectorSize)
+	copy(expectedSector, sectors[0][:length])
+	newRoots := []crypto.Hash{crypto.MerkleRoot(expectedSector), oldRoots[1]}
+	nmr := cachedMerkleRoot(newRoots)
+	ranges := []crypto.ProofRange{{Start: 0, End: 1}}
+	expectedProof := crypto.MerkleDiffProof(ranges, uint64(len(newRoots)), nil, newRoots)
+	expectedOutput := encoding.Marshal([]crypto.Hash{oldRoots[0], newRoots[0]})
+
+	// Assert the output.
+	err = outputs[2].assert(2*modules.SectorSize, nmr, expectedProof, expectedOutput, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Make sure the storage obligation was updated.
+	if so.MerkleRoot() != nmr {
+		t.Fatal("wrong merkle root after truncate")
+	}
+	if !bytes.Equal(so.sectorMap[newRoots[0]], expectedSector) {
+		t.Fatal("sector wasn't truncated correctly")
+	}
+
+	// Truncating a sector beyond its size should fail.
+	tb = newTestProgramBuilder(pt, duration)
+	tb.AddTruncateSectorInstruction(0, modules.SectorSize+1, false)
+	_, err = mdm.ExecuteProgramWithBuilder(tb, so, duration, true)
+	if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("length is out of bounds %v > %v", modules.SectorSize+1, modules.SectorSize)) {
+		t.Fatal("expected execution to fail with out of bounds error", err)
+	}
+}
@@ -0,0 +1,145 @@
+package mdm
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// instructionUpdateSectorRange is an instruction that overwrites a range of
+// an existing sector of a file contract.
+type instructionUpdateSectorRange struct {
+	commonInstruction
+
+	sectorIdxOffset uint64
+	offsetOffset    uint64
+	lengthOffset    uint64
+	dataOffset      uint64
+}
+
+// staticDecodeUpdateSectorRangeInstruction creates a new 'UpdateSectorRange'
+// instruction from the provided generic instruction.
+func (p *program) staticDecodeUpdateSectorRangeInstruction(instruction modules.Instruction) (instruction, error) {
+	// Check specifier.
+	if instruction.Specifier != modules.SpecifierUpdateSectorRange {
+		return nil, fmt.Errorf("expected specifier %v but got %v",
+			modules.SpecifierUpdateSectorRange, instruction.Specifier)
+	}
+	// Check args.
+	if len(instruction.Args) != modules.RPCIUpdateSectorRangeLen {
+		return nil, fmt.Errorf("expected instruction to have len %v but was %v",
+			modules.RPCIUpdateSectorRangeLen, len(instruction.Args))
+	}
+	// Read args.
+	return &instructionUpdateSectorRange{
+		commonInstruction: commonInstruction{
+			staticData:        p.staticData,
+			staticMerkleProof: instruction.Args[32] == 1,
+			staticState:       p.staticProgramState,
+		},
+		sectorIdxOffset: binary.LittleEndian.Uint64(instruction.Args[:8]),
+		offsetOffset:    binary.LittleEndian.Uint64(instruction.Args[8:16]),
+		lengthOffset:    binary.LittleEndian.Uint64(instruction.Args[16:24]),
+		dataOffset:      binary.LittleEndian.Uint64(instruction.Args[24:32]),
+	}, nil
+}
+
+// Batch declares whether or not this instruction can be batched together with
+// the previous instruction.
+func (i instructionUpdateSectorRange) Batch() bool {
+	return false
+}
+
+// Execute executes the 'UpdateSectorRange' instruction.
+func (i *instructionUpdateSectorRange) Execute(prevOutput output) (output, types.Currency) {
+	// Fetch the data.
+	sectorIdx, err := i.staticData.Uint64(i.sectorIdxOffset)
+	if err != nil {
+		return errOutput(err), types.ZeroCurrency
+	}
+	offset, err := i.staticData.Uint64(i.offsetOffset)
+	if err != nil {
+		return errOutput(err), types.ZeroCurrency
+	}
+	length, err := i.staticData.Uint64(i.lengthOffset)
+	if err != nil {
+		return errOutput(err), types.ZeroCurrency
+	}
+
+	// Validate the range before fetching the data.
+	switch {
+	case length == 0:
+		return errOutput(errors.New("length cannot be zero")), types.ZeroCurrency
+	case offset > modules.SectorSize || length > modules.SectorSize-offset:
+		return errOutput(fmt.Errorf("update is out of bounds %v + %v > %v", offset, length, modules.SectorSize)), types.ZeroCurrency
+	}
+	data, err := i.staticData.Bytes(i.dataOffset, length)
+	if err != nil {
+		return errOutput(err), types.ZeroCurrency
+	}
+
+	ps := i.staticState
+	oldRoot, newRoot, newMerkleRoot, err := ps.sectors.updateSector(ps.host, sectorIdx, func(sectorData []byte) {
+		copy(sectorData[offset:], data)
+	})
+	if err != nil {
+		return errOutput(err), types.ZeroCurrency
+	}
+	return updatedSectorOutput(prevOutput, ps, sectorIdx, oldRoot, newRoot, newMerkleRoot, i.staticMerkleProof), types.ZeroCurrency
+}
+
+// Collateral returns the collateral cost of updating a sector.
+func (i *instructionUpdateSectorRange) Collateral() types.Currency {
+	return modules.MDMUpdateSectorRangeCollateral()
+}
+
+// Cost returns the Cost of this `UpdateSectorRange` instruction.
+func (i *instructionUpdateSectorRange) Cost() (executionCost, storage types.Currency, err error) {
+	executionCost = modules.MDMUpdateSectorRangeCost(i.staticState.priceTable)
+	return
+}
+
+// Memory returns the memory allocated by the 'UpdateSectorRange' instruction
+// beyond the lifetime of the instruction.
+func (i *instructionUpdateSectorRange) Memory() uint64 {
+	return modules.MDMUpdateSectorRangeMemory()
+}
+
+// Time returns the execution time of an 'UpdateSectorRange' instruction.
+func (i *instructionUpdateSectorRange) Time() (uint64, error) {
+	return modules.MDMTimeUpdateSectorRange, nil
+}
+
+// updatedSectorOutput creates the output of an instruction which replaced the
+// sector at sectorIdx. If a proof is requested, the old and new root of the
+// sector are returned as the data. The renter needs the old root to verify the
+// proof against the old contract merkle root and the new one to verify it
+// against the new contract merkle root.
+func updatedSectorOutput(prevOutput output, ps *programState, sectorIdx uint64, oldRoot, newRoot, newMerkleRoot crypto.Hash, merkleProof bool) output {
+	// If no proof was requested we are done.
+	if !merkleProof {
+		return output{
+			NewSize:       prevOutput.NewSize,
+			NewMerkleRoot: newMerkleRoot,
+		}
+	}
+	ranges := []crypto.ProofRange{
+		{
+			Start: sectorIdx,
+			End:   sectorIdx + 1,
+		},
+	}
+	roots := ps.sectors.merkleRoots
+	proof := crypto.MerkleDiffProof(ranges, uint64(len(roots)), nil, roots)
+	return output{
+		NewSize:       prevOutput.NewSize,
+		NewMerkleRoot: newMerkleRoot,
+		Output:        encoding.Marshal([]crypto.Hash{oldRoot, newRoot}),
+		Proof:         proof,
+	}
+}
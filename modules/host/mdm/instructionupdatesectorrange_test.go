@@ -0,0 +1,151 @@
+package mdm
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestInstructionUpdateSectorRange tests executing a program with multiple
+// Append instructions followed by an UpdateSectorRange instruction.
+func TestInstructionUpdateSectorRange(t *testing.T) {
+	host := newTestHost()
+	mdm := New(host)
+	defer mdm.Stop()
+
+	// Prepare a priceTable and duration.
+	pt := newTestPriceTable()
+	duration := types.BlockHeight(fastrand.Uint64n(5))
+
+	// Run basic case.
+	t.Run("Basic", func(t *testing.T) {
+		testInstructionUpdateSectorRangeBasic(t, host, mdm, pt, duration, true)
+	})
+	// Run basic case but without requesting a proof.
+	t.Run("NoProof", func(t *testing.T) {
+		testInstructionUpdateSectorRangeBasic(t, host, mdm, pt, duration, false)
+	})
+	// Run case for an update that doesn't fit into the sector.
+	t.Run("OutOfBounds", func(t *testing.T) {
+		testInstructionUpdateSectorRangeOutOfBounds(t, host, mdm, pt, duration)
+	})
+}
+
+// testInstructionUpdateSectorRangeBasic appends 3 sectors to a contract and
+// then overwrites a random range of the second one.
+func testInstructionUpdateSectorRangeBasic(t *testing.T, host *TestHost, mdm *MDM, pt *modules.RPCPriceTable, duration types.BlockHeight, merkleProof bool) {
+	so := host.newTestStorageObligation(true)
+	tb := newTestProgramBuilder(pt, duration)
+
+	// Append 3 sectors.
+	sectors := make([][]byte, 3)
+	oldRoots := make([]crypto.Hash, len(sectors))
+	for i := range sectors {
+		sectors[i] = fastrand.Bytes(int(modules.SectorSize))
+		oldRoots[i] = crypto.MerkleRoot(sectors[i])
+		tb.AddAppendInstruction(sectors[i], false)
+	}
+	imr := cachedMerkleRoot(oldRoots)
+
+	// Overwrite a random range of the second sector.
+	idx := uint64(1)
+	offset := fastrand.Uint64n(modules.SectorSize)
+	data := fastrand.Bytes(int(fastrand.Uint64n(modules.SectorSize-offset) + 1))
+	tb.AddUpdateSectorRangeInstruction(idx, offset, data, merkleProof)
+
+	// Execute it.
+	outputs, err := mdm.ExecuteProgramWithBuilder(tb, so, duration, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := outputs[len(sectors)]
+
+	// Compute the expected sector and roots.
+	expectedSector := append([]byte{}, sectors[idx]...)
+	copy(expectedSector[offset:], data)
+	newRoots := append([]crypto.Hash{}, oldRoots...)
+	newRoots[idx] = crypto.MerkleRoot(expectedSector)
+	nmr := cachedMerkleRoot(newRoots)
+
+	// Compute the expected output.
+	ranges := []crypto.ProofRange{
+		{
+			Start: idx,
+			End:   idx + 1,
+		},
+	}
+	var expectedProof []crypto.Hash
+	var expectedOutput []byte
+	if merkleProof {
+		expectedProof = crypto.MerkleDiffProof(ranges, uint64(len(newRoots)), nil, newRoots)
+		expectedOutput = encoding.Marshal([]crypto.Hash{oldRoots[idx], newRoots[idx]})
+	}
+
+	// Assert the output.
+	err = output.assert(uint64(len(sectors))*modules.SectorSize, nmr, expectedProof, expectedOutput, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Verify the proof against both the old and the new merkle root.
+	if merkleProof {
+		var leafHashes []crypto.Hash
+		err = encoding.Unmarshal(output.Output, &leafHashes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !crypto.VerifyDiffProof(ranges, uint64(len(oldRoots)), output.Proof, leafHashes[:1], imr) {
+			t.Fatal("failed to verify proof against old root")
+		}
+		if !crypto.VerifyDiffProof(ranges, uint64(len(newRoots)), output.Proof, leafHashes[1:], nmr) {
+			t.Fatal("failed to verify proof against new root")
+		}
+	}
+
+	// Make sure the storage obligation was updated.
+	if so.MerkleRoot() != nmr {
+		t.Fatal("wrong merkle root after update")
+	}
+	if len(so.sectorMap) != len(sectors) {
+		t.Fatalf("wrong sectorMap len %v != %v", len(so.sectorMap), len(sectors))
+	}
+	if _, exists := so.sectorMap[oldRoots[idx]]; exists {
+		t.Fatal("old sector shouldn't exist")
+	}
+	if !bytes.Equal(so.sectorMap[newRoots[idx]], expectedSector) {
+		t.Fatal("sector wasn't updated correctly")
+	}
+}
+
+// testInstructionUpdateSectorRangeOutOfBounds tests updating ranges that don't
+// fit into a sector as well as updating a sector that doesn't exist.
+func testInstructionUpdateSectorRangeOutOfBounds(t *testing.T, host *TestHost, mdm *MDM, pt *modules.RPCPriceTable, duration types.BlockHeight) {
+	tests := []struct {
+		idx    uint64
+		offset uint64
+		length uint64
+		err    error
+	}{
+		{1, 0, 1, fmt.Errorf("idx out-of-bounds: %v >= %v", 1, 1)},
+		{0, modules.SectorSize, 1, fmt.Errorf("update is out of bounds %v + %v > %v", modules.SectorSize, 1, modules.SectorSize)},
+		{0, 1, modules.SectorSize, fmt.Errorf("update is out of bounds %v + %v > %v", 1, modules.SectorSize, modules.SectorSize)},
+	}
+	for _, test := range tests {
+		so := host.newTestStorageObligation(true)
+		tb := newTestProgramBuilder(pt, duration)
+		tb.AddAppendInstruction(fastrand.Bytes(int(modules.SectorSize)), false)
+		tb.AddUpdateSectorRangeInstruction(test.idx, test.offset, fastrand.Bytes(int(test.length)), false)
+
+		_, err := mdm.ExecuteProgramWithBuilder(tb, so, duration, true)
+		if err == nil || !strings.Contains(err.Error(), test.err.Error()) {
+			t.Fatalf("expected error '%v' but got '%v'", test.err, err)
+		}
+	}
+}
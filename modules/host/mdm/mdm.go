@@ -34,7 +34,10 @@ type StorageObligationSnapshot interface {
 type Host interface {
 	BlockHeight() types.BlockHeight
 	HasSector(crypto.Hash) bool
+	PrefetchSectors(sectorRoots []crypto.Hash)
+	ProgramLimits() modules.MDMProgramLimits
 	ReadSector(sectorRoot crypto.Hash) ([]byte, error)
+	ReadSectorBypassCache(sectorRoot crypto.Hash) ([]byte, error)
 	RegistryUpdate(rv modules.SignedRegistryValue, pubKey types.SiaPublicKey, expiry types.BlockHeight) (modules.SignedRegistryValue, error)
 	RegistryGet(sid modules.RegistryEntryID) (types.SiaPublicKey, modules.SignedRegistryValue, bool)
 }
@@ -48,14 +51,16 @@ type Host interface {
 // batched into atomic sets called 'programs' that are either entirely applied
 // or are not applied at all.
 type MDM struct {
-	host Host
-	tg   threadgroup.ThreadGroup
+	host          Host
+	staticMetrics *metrics
+	tg            threadgroup.ThreadGroup
 }
 
 // New creates a new MDM.
 func New(h Host) *MDM {
 	return &MDM{
-		host: h,
+		host:          h,
+		staticMetrics: newMetrics(),
 	}
 }
 
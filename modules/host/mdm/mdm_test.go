@@ -24,6 +24,9 @@ type (
 	TestHost struct {
 		generateSectors bool
 		blockHeight     types.BlockHeight
+		bypassedReads   int
+		limits          modules.MDMProgramLimits
+		prefetched      []crypto.Hash
 		sectors         map[crypto.Hash][]byte
 		registry        map[modules.RegistryEntryID]TestRegistryValue
 		mu              sync.Mutex
@@ -83,6 +86,20 @@ func (h *TestHost) HasSector(sectorRoot crypto.Hash) bool {
 	return exists
 }
 
+// PrefetchSectors records the roots of the sectors to prefetch.
+func (h *TestHost) PrefetchSectors(sectorRoots []crypto.Hash) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.prefetched = append(h.prefetched, sectorRoots...)
+}
+
+// ProgramLimits returns the program limits of the host.
+func (h *TestHost) ProgramLimits() modules.MDMProgramLimits {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.limits
+}
+
 // RegistryGet retrieves a value from the registry.
 func (h *TestHost) RegistryGet(sid modules.RegistryEntryID) (types.SiaPublicKey, modules.SignedRegistryValue, bool) {
 	h.mu.Lock()
@@ -116,6 +133,15 @@ func (h *TestHost) RegistryUpdate(rv modules.SignedRegistryValue, pubKey types.S
 	return oldRV.SignedRegistryValue, nil
 }
 
+// ReadSectorBypassCache counts the reads which bypass the cache and otherwise
+// behaves like ReadSector.
+func (h *TestHost) ReadSectorBypassCache(sectorRoot crypto.Hash) ([]byte, error) {
+	h.mu.Lock()
+	h.bypassedReads++
+	h.mu.Unlock()
+	return h.ReadSector(sectorRoot)
+}
+
 // ReadSector implements the Host interface by returning a random sector for
 // each root. Calling ReadSector multiple times on the same root will result in
 // the same data.
@@ -0,0 +1,62 @@
+package mdm
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// metrics keeps track of the execution statistics of the instructions the MDM
+// executed.
+type metrics struct {
+	instructions map[modules.InstructionSpecifier]*modules.HostMDMInstructionStats
+	mu           sync.Mutex
+}
+
+// newMetrics creates a new, empty metrics object.
+func newMetrics() *metrics {
+	return &metrics{
+		instructions: make(map[modules.InstructionSpecifier]*modules.HostMDMInstructionStats),
+	}
+}
+
+// managedRecord adds the execution of a single instruction to the metrics.
+func (m *metrics) managedRecord(specifier modules.InstructionSpecifier, d time.Duration, bytesProcessed uint64, budgetConsumed types.Currency, failed bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats, exists := m.instructions[specifier]
+	if !exists {
+		stats = &modules.HostMDMInstructionStats{
+			Instruction: types.Specifier(specifier).String(),
+		}
+		m.instructions[specifier] = stats
+	}
+	stats.Executions++
+	if failed {
+		stats.Failures++
+	}
+	stats.BytesProcessed += bytesProcessed
+	stats.BudgetConsumed = stats.BudgetConsumed.Add(budgetConsumed)
+	stats.TotalTime += d
+}
+
+// Metrics returns the execution statistics of every instruction the MDM
+// executed since it was created, sorted by the name of the instruction.
+func (mdm *MDM) Metrics() modules.HostMDMMetrics {
+	m := mdm.staticMetrics
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := make([]modules.HostMDMInstructionStats, 0, len(m.instructions))
+	for _, s := range m.instructions {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Instruction < stats[j].Instruction
+	})
+	return modules.HostMDMMetrics{
+		Instructions: stats,
+	}
+}
@@ -0,0 +1,67 @@
+package mdm
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestMetrics tests that the MDM keeps track of the execution statistics of
+// the instructions it executed.
+func TestMetrics(t *testing.T) {
+	host := newTestHost()
+	mdm := New(host)
+	defer mdm.Stop()
+
+	// The metrics should be empty initially.
+	if metrics := mdm.Metrics(); len(metrics.Instructions) != 0 {
+		t.Fatal("expected no instructions", metrics.Instructions)
+	}
+
+	// Execute a program which appends a sector and checks for it twice.
+	so := host.newTestStorageObligation(true)
+	pt := newTestPriceTable()
+	duration := types.BlockHeight(fastrand.Uint64n(5))
+	sectorData := fastrand.Bytes(int(modules.SectorSize))
+	tb := newTestProgramBuilder(pt, duration)
+	tb.AddAppendInstruction(sectorData, false)
+	tb.AddHasSectorInstruction(crypto.MerkleRoot(sectorData))
+	tb.AddHasSectorInstruction(crypto.MerkleRoot(sectorData))
+	_, err := mdm.ExecuteProgramWithBuilder(tb, so, duration, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Check the metrics.
+	metrics := mdm.Metrics()
+	if len(metrics.Instructions) != 2 {
+		t.Fatalf("expected 2 instructions but got %v", len(metrics.Instructions))
+	}
+	appendStats, hasSectorStats := metrics.Instructions[0], metrics.Instructions[1]
+	if appendStats.Instruction != "Append" || hasSectorStats.Instruction != "HasSector" {
+		t.Fatal("instructions aren't sorted by name", appendStats.Instruction, hasSectorStats.Instruction)
+	}
+	if appendStats.Executions != 1 || hasSectorStats.Executions != 2 {
+		t.Fatal("wrong number of executions", appendStats.Executions, hasSectorStats.Executions)
+	}
+	if appendStats.Failures != 0 || hasSectorStats.Failures != 0 {
+		t.Fatal("wrong number of failures", appendStats.Failures, hasSectorStats.Failures)
+	}
+	// Append reads a sector from the program data. HasSector reads a root
+	// and returns a single byte.
+	if appendStats.BytesProcessed != modules.SectorSize {
+		t.Fatal("wrong number of bytes processed", appendStats.BytesProcessed)
+	}
+	if hasSectorStats.BytesProcessed != 2*(crypto.HashSize+1) {
+		t.Fatal("wrong number of bytes processed", hasSectorStats.BytesProcessed)
+	}
+	if appendStats.BudgetConsumed.IsZero() || hasSectorStats.BudgetConsumed.IsZero() {
+		t.Fatal("expected consumed budget")
+	}
+	if appendStats.TotalTime == 0 {
+		t.Fatal("expected execution time")
+	}
+}
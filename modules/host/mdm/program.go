@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"time"
 
 	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/threadgroup"
@@ -15,6 +16,13 @@ import (
 )
 
 var (
+	// readCacheBypassReads is the max number of read instructions a program
+	// can contain for its reads to go through the host's sector cache.
+	// Programs which read many sectors are usually bulk downloads of data
+	// which is read once. It matches the threshold the host uses for legacy
+	// downloads.
+	readCacheBypassReads = 8
+
 	// ErrEmptyProgram is returned if the program doesn't contain any instructions.
 	ErrEmptyProgram = errors.New("can't execute program without instructions")
 
@@ -39,6 +47,10 @@ type programState struct {
 	// program cache
 	sectors sectors
 
+	// staticBypassReadCache indicates whether the program's reads bypass the
+	// host's sector cache.
+	staticBypassReadCache bool
+
 	// statistic related fields
 	potentialStorageRevenue types.Currency
 	riskedCollateral        types.Currency
@@ -54,6 +66,7 @@ type programState struct {
 // FileContract which has to be signed by the renter and the host.
 type program struct {
 	instructions       []instruction
+	specifiers         []modules.InstructionSpecifier
 	staticData         *programData
 	staticProgramState *programState
 
@@ -67,7 +80,8 @@ type program struct {
 	outputChan chan Output
 	outputErr  error // contains the error of the first instruction of the program that failed
 
-	tg *threadgroup.ThreadGroup
+	staticMetrics *metrics
+	tg            *threadgroup.ThreadGroup
 }
 
 // outputFromError is a convenience function to wrap an error in an Output.
@@ -91,6 +105,8 @@ func decodeInstruction(p *program, i modules.Instruction) (instruction, error) {
 		return p.staticDecodeAppendInstruction(i)
 	case modules.SpecifierDropSectors:
 		return p.staticDecodeDropSectorsInstruction(i)
+	case modules.SpecifierExpectRevision:
+		return p.staticDecodeExpectRevisionInstruction(i)
 	case modules.SpecifierHasSector:
 		return p.staticDecodeHasSectorInstruction(i)
 	case modules.SpecifierReadSector:
@@ -101,6 +117,10 @@ func decodeInstruction(p *program, i modules.Instruction) (instruction, error) {
 		return p.staticDecodeRevisionInstruction(i)
 	case modules.SpecifierSwapSector:
 		return p.staticDecodeSwapSectorInstruction(i)
+	case modules.SpecifierTruncateSector:
+		return p.staticDecodeTruncateSectorInstruction(i)
+	case modules.SpecifierUpdateSectorRange:
+		return p.staticDecodeUpdateSectorRangeInstruction(i)
 	case modules.SpecifierUpdateRegistry:
 		return p.staticDecodeUpdateRegistryInstruction(i)
 	case modules.SpecifierReadRegistry:
@@ -119,8 +139,22 @@ func (mdm *MDM) ExecuteProgram(ctx context.Context, pt *modules.RPCPriceTable, p
 	if len(p) == 0 {
 		return nil, nil, ErrEmptyProgram
 	}
-	// Derive a new context to use and close it on error.
-	ctx, cancel := context.WithCancel(ctx)
+	// Check the program against the host's limits.
+	limits := mdm.host.ProgramLimits()
+	if limits.MaxInstructions > 0 && uint64(len(p)) > limits.MaxInstructions {
+		return nil, nil, errors.AddContext(modules.ErrMDMProgramTooManyInstructions, fmt.Sprintf("%v > %v", len(p), limits.MaxInstructions))
+	}
+	if limits.MaxDataLength > 0 && programDataLen > limits.MaxDataLength {
+		return nil, nil, errors.AddContext(modules.ErrMDMProgramDataTooLong, fmt.Sprintf("%v > %v", programDataLen, limits.MaxDataLength))
+	}
+	// Derive a new context to use and close it on error. If the host limits
+	// the execution time of programs, the context expires after it.
+	var cancel context.CancelFunc
+	if limits.MaxDuration > 0 {
+		ctx, cancel = context.WithTimeout(ctx, limits.MaxDuration)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
 	defer func() {
 		if err != nil {
 			cancel()
@@ -140,16 +174,23 @@ func (mdm *MDM) ExecuteProgram(ctx context.Context, pt *modules.RPCPriceTable, p
 		usedMemory:             modules.MDMInitMemory(),
 		staticCollateralBudget: collateralBudget,
 		staticData:             openProgramData(data, programDataLen),
+		staticMetrics:          mdm.staticMetrics,
 		tg:                     &mdm.tg,
 	}
 	// Convert the instructions.
+	var numReads int
 	for _, i := range p {
 		instruction, err := decodeInstruction(program, i)
 		if err != nil {
 			return nil, nil, errors.Compose(err, program.staticData.Close())
 		}
 		program.instructions = append(program.instructions, instruction)
+		program.specifiers = append(program.specifiers, i.Specifier)
+		if i.Specifier == modules.SpecifierReadSector || i.Specifier == modules.SpecifierReadOffset {
+			numReads++
+		}
 	}
+	program.staticProgramState.staticBypassReadCache = numReads > readCacheBypassReads
 	// Increment the execution cost of the program.
 	err = program.addCost(modules.MDMInitCost(pt, program.staticData.Len(), uint64(len(program.instructions))))
 	if err != nil {
@@ -230,8 +271,12 @@ func (p *program) executeInstructions(ctx context.Context, fcSize uint64, fcRoot
 	for idx, i := range p.instructions {
 		select {
 		case <-ctx.Done(): // Check for interrupt
-			p.outputChan <- outputFromError(ErrInterrupted, p.additionalCollateral, p.executionCost, p.failureRefund)
-			return ErrInterrupted
+			err := ErrInterrupted
+			if errors.Contains(ctx.Err(), context.DeadlineExceeded) {
+				err = modules.ErrMDMProgramTimeout
+			}
+			p.outputChan <- outputFromError(err, p.additionalCollateral, p.executionCost, p.failureRefund)
+			return err
 		default:
 		}
 		// Increment collateral first.
@@ -244,11 +289,11 @@ func (p *program) executeInstructions(ctx context.Context, fcSize uint64, fcRoot
 		// Add the memory the next instruction is going to allocate to the
 		// total.
 		p.usedMemory += i.Memory()
-		time, err := i.Time()
+		instructionTime, err := i.Time()
 		if err != nil {
 			p.outputChan <- outputFromError(err, p.additionalCollateral, p.executionCost, p.failureRefund)
 		}
-		memoryCost := modules.MDMMemoryCost(p.staticProgramState.priceTable, p.usedMemory, time)
+		memoryCost := modules.MDMMemoryCost(p.staticProgramState.priceTable, p.usedMemory, instructionTime)
 		// Get the instruction cost and storageCost.
 		instructionCost, failureRefund, err := i.Cost()
 		if err != nil {
@@ -269,11 +314,21 @@ func (p *program) executeInstructions(ctx context.Context, fcSize uint64, fcRoot
 		// batched and if it's not the last instruction in the program.
 		batch := idx < len(p.instructions)-1 && p.instructions[idx+1].Batch()
 		// Execute next instruction.
+		start := time.Now()
+		bytesRead := p.staticData.managedBytesRead()
 		output, refund = i.Execute(output)
 		// Issue potential refund.
 		if !refund.IsZero() {
 			p.refundCost(refund)
 		}
+		// Update the metrics. The bytes processed by an instruction are the
+		// bytes it read from the program data and the bytes it returned.
+		bytesProcessed := p.staticData.managedBytesRead() - bytesRead + uint64(len(output.Output))
+		budgetConsumed := types.ZeroCurrency
+		if cost.Cmp(refund) > 0 {
+			budgetConsumed = cost.Sub(refund)
+		}
+		p.staticMetrics.managedRecord(p.specifiers[idx], time.Since(start), bytesProcessed, budgetConsumed, output.Error != nil)
 		p.outputChan <- Output{
 			output:               output,
 			Batch:                batch,
@@ -3,7 +3,9 @@ package mdm
 import (
 	"bytes"
 	"context"
+	"io"
 	"testing"
+	"time"
 
 	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
@@ -133,3 +135,67 @@ func TestNewProgramLowCollateralBudget(t *testing.T) {
 		t.Fatal("shouldn't be able to finalize program")
 	}
 }
+
+// TestNewProgramLimits tests that programs exceeding the host's limits are
+// rejected or interrupted.
+func TestNewProgramLimits(t *testing.T) {
+	host := newTestHost()
+	mdm := New(host)
+	pt := newTestPriceTable()
+	duration := types.BlockHeight(fastrand.Uint64n(5))
+	pb := newTestProgramBuilder(pt, duration)
+	pb.AddHasSectorInstruction(crypto.Hash{})
+	pb.AddHasSectorInstruction(crypto.Hash{})
+	program, data := pb.Program()
+	dataLen := uint64(len(data))
+	budget := func() *modules.RPCBudget { return pb.Cost().Budget(true) }
+
+	// A program with too many instructions is rejected.
+	host.limits = modules.MDMProgramLimits{MaxInstructions: 1}
+	_, _, err := mdm.ExecuteProgram(context.Background(), pt, program, budget(), types.ZeroCurrency, host.newTestStorageObligation(true), duration, dataLen, bytes.NewReader(data))
+	if !errors.Contains(err, modules.ErrMDMProgramTooManyInstructions) {
+		t.Fatal("expected ErrMDMProgramTooManyInstructions", err)
+	}
+
+	// A program with too much data is rejected.
+	host.limits = modules.MDMProgramLimits{MaxDataLength: dataLen - 1}
+	_, _, err = mdm.ExecuteProgram(context.Background(), pt, program, budget(), types.ZeroCurrency, host.newTestStorageObligation(true), duration, dataLen, bytes.NewReader(data))
+	if !errors.Contains(err, modules.ErrMDMProgramDataTooLong) {
+		t.Fatal("expected ErrMDMProgramDataTooLong", err)
+	}
+
+	// A program within the limits is executed.
+	host.limits = modules.MDMProgramLimits{MaxInstructions: 2, MaxDataLength: dataLen, MaxDuration: time.Minute}
+	_, outputs, err := mdm.ExecuteProgram(context.Background(), pt, program, budget(), types.ZeroCurrency, host.newTestStorageObligation(true), duration, dataLen, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for output := range outputs {
+		if output.Error != nil {
+			t.Fatal(output.Error)
+		}
+	}
+
+	// A program which runs longer than the max duration is interrupted. The
+	// data is only sent after the max duration passed which blocks the first
+	// instruction.
+	host.limits = modules.MDMProgramLimits{MaxDuration: 50 * time.Millisecond}
+	r, w := io.Pipe()
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		_, _ = w.Write(data)
+	}()
+	_, outputs, err = mdm.ExecuteProgram(context.Background(), pt, program, budget(), types.ZeroCurrency, host.newTestStorageObligation(true), duration, dataLen, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lastErr error
+	numOutputs := 0
+	for output := range outputs {
+		lastErr = output.Error
+		numOutputs++
+	}
+	if numOutputs != 2 || !errors.Contains(lastErr, modules.ErrMDMProgramTimeout) {
+		t.Fatal("expected the second instruction to time out", numOutputs, lastErr)
+	}
+}
@@ -29,6 +29,9 @@ type programData struct {
 	// readErr contains the first error encountered by threadedFetchData.
 	readErr error
 
+	// bytesRead is the total number of bytes returned by managedBytes.
+	bytesRead uint64
+
 	// requests are queued up calls to 'bytes' waiting for the requested data to
 	// arrive.
 	requests []dataRequest
@@ -130,6 +133,7 @@ func (pd *programData) managedBytes(offset, length uint64) ([]byte, error) {
 	// Check if data is available already.
 	if uint64(len(pd.data)) >= offset+length {
 		defer pd.mu.Unlock()
+		pd.bytesRead += length
 		return pd.data[offset:][:length], nil
 	}
 	// Check for previous error.
@@ -157,9 +161,18 @@ func (pd *programData) managedBytes(offset, length uint64) ([]byte, error) {
 	} else if outOfBounds && pd.readErr != nil {
 		return nil, pd.readErr
 	}
+	pd.bytesRead += length
 	return pd.data[offset:][:length], nil
 }
 
+// managedBytesRead returns the total number of bytes that were read from the
+// program data so far.
+func (pd *programData) managedBytesRead() uint64 {
+	pd.mu.Lock()
+	defer pd.mu.Unlock()
+	return pd.bytesRead
+}
+
 // Uint64 returns the next 8 bytes at the specified offset within the program
 // data as an uint64. This call will block if the data at the specified offset
 // hasn't been fetched yet.
@@ -102,6 +102,44 @@ func (s *sectors) swapSectors(idx1, idx2 uint64) (crypto.Hash, error) {
 	return cachedMerkleRoot(s.merkleRoots), nil
 }
 
+// updateSector applies the update to a copy of the sector at idx and replaces
+// the sector with the updated one. It returns the old and new roots of the
+// sector and the new merkle root.
+func (s *sectors) updateSector(host Host, idx uint64, update func(sectorData []byte)) (oldRoot, newRoot, merkleRoot crypto.Hash, err error) {
+	if idx >= uint64(len(s.merkleRoots)) {
+		return crypto.Hash{}, crypto.Hash{}, crypto.Hash{}, fmt.Errorf("idx out-of-bounds: %v >= %v", idx, len(s.merkleRoots))
+	}
+	oldRoot = s.merkleRoots[idx]
+
+	// Read the sector and update a copy of it. The old sector is replaced so
+	// there is no point in caching it.
+	sectorData, err := s.readSector(host, oldRoot, true)
+	if err != nil {
+		return crypto.Hash{}, crypto.Hash{}, crypto.Hash{}, err
+	}
+	newData := make([]byte, len(sectorData))
+	copy(newData, sectorData)
+	update(newData)
+	newRoot = crypto.MerkleRoot(newData)
+
+	// Update the program cache. The old sector is removed like it would be by
+	// dropping it and the new one is gained like it would be by appending it.
+	if _, gained := s.sectorsGained[oldRoot]; gained {
+		delete(s.sectorsGained, oldRoot)
+	} else {
+		s.sectorsRemoved[oldRoot] = struct{}{}
+	}
+	if _, removed := s.sectorsRemoved[newRoot]; removed {
+		delete(s.sectorsRemoved, newRoot)
+	} else {
+		s.sectorsGained[newRoot] = newData
+	}
+
+	// Update the roots.
+	s.merkleRoots[idx] = newRoot
+	return oldRoot, newRoot, cachedMerkleRoot(s.merkleRoots), nil
+}
+
 // translateOffset translates an offset within a filecontract into a relative
 // offset within a sector and the sector's index within the contract.
 func (s *sectors) translateOffset(offset uint64) (uint64, uint64, error) {
@@ -115,13 +153,17 @@ func (s *sectors) translateOffset(offset uint64) (uint64, uint64, error) {
 	return relOff, secOff, nil
 }
 
-// readSector reads data from the given root, returning the entire sector.
-func (s *sectors) readSector(host Host, sectorRoot crypto.Hash) ([]byte, error) {
+// readSector reads data from the given root, returning the entire sector. If
+// bypassCache is set, the sector isn't added to the host's sector cache.
+func (s *sectors) readSector(host Host, sectorRoot crypto.Hash, bypassCache bool) ([]byte, error) {
 	// The root exists. First check the gained sectors.
 	if data, exists := s.sectorsGained[sectorRoot]; exists {
 		return data, nil
 	}
 
 	// Check the host.
+	if bypassCache {
+		return host.ReadSectorBypassCache(sectorRoot)
+	}
 	return host.ReadSector(sectorRoot)
 }
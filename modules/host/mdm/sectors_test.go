@@ -280,7 +280,7 @@ func TestReadSector(t *testing.T) {
 
 	// Read data for each existing sector.
 	for _, root := range sectorRoots[:initialContractSectors] {
-		data, err := s.readSector(host, root)
+		data, err := s.readSector(host, root, false)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -289,7 +289,7 @@ func TestReadSector(t *testing.T) {
 		}
 	}
 	for _, root := range sectorRoots[initialContractSectors:] {
-		data, err := s.readSector(host, root)
+		data, err := s.readSector(host, root, false)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -301,7 +301,7 @@ func TestReadSector(t *testing.T) {
 	// These sectors should not exist.
 	for i := 0; i < initialContractSectors; i++ {
 		root := randomSector()
-		if _, err := s.readSector(host, root); err == nil {
+		if _, err := s.readSector(host, root, false); err == nil {
 			t.Fatalf("found a root %v which shouldn't exist", root)
 		}
 	}
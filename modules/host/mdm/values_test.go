@@ -74,6 +74,19 @@ func (v *TestValues) AddDropSectorsInstruction(numSectors uint64) {
 	v.addInstruction(collateral, cost, types.ZeroCurrency, types.ZeroCurrency, memory, time, newData, readonly, batch)
 }
 
+// AddExpectRevisionInstruction adds an expectrevision instruction to the
+// builder, keeping track of running values.
+func (v *TestValues) AddExpectRevisionInstruction() {
+	collateral := modules.MDMExpectRevisionCollateral()
+	cost := modules.MDMExpectRevisionCost(v.staticPT)
+	memory := modules.MDMExpectRevisionMemory()
+	time := uint64(modules.MDMTimeExpectRevision)
+	newData := 8
+	readonly := true
+	batch := true
+	v.addInstruction(collateral, cost, types.ZeroCurrency, types.ZeroCurrency, memory, time, newData, readonly, batch)
+}
+
 // AddHasSectorInstruction adds a hassector instruction to the builder, keeping
 // track of running values.
 func (v *TestValues) AddHasSectorInstruction() {
@@ -113,6 +126,19 @@ func (v *TestValues) AddReadSectorInstruction(length uint64) {
 	v.addInstruction(collateral, cost, types.ZeroCurrency, types.ZeroCurrency, memory, time, newData, readonly, batch)
 }
 
+// AddReadSectorWithPrefetchInstruction adds a readsector instruction with
+// prefetch hints to the builder, keeping track of running values.
+func (v *TestValues) AddReadSectorWithPrefetchInstruction(length uint64, numPrefetch int) {
+	collateral := modules.MDMReadCollateral()
+	cost := modules.MDMReadCost(v.staticPT, length)
+	memory := modules.MDMReadMemory()
+	time := uint64(modules.MDMTimeReadSector)
+	newData := 8 + 8 + crypto.HashSize + numPrefetch*crypto.HashSize
+	readonly := true
+	batch := false
+	v.addInstruction(collateral, cost, types.ZeroCurrency, types.ZeroCurrency, memory, time, newData, readonly, batch)
+}
+
 // AddRevisionInstruction adds a revision instruction to the builder, keeping
 // track of running values.
 func (v *TestValues) AddRevisionInstruction() {
@@ -138,6 +164,32 @@ func (v *TestValues) AddSwapSectorInstruction() {
 	v.addInstruction(collateral, cost, types.ZeroCurrency, types.ZeroCurrency, memory, time, newData, readonly, batch)
 }
 
+// AddTruncateSectorInstruction adds a revision instruction to the builder,
+// keeping track of running values.
+func (v *TestValues) AddTruncateSectorInstruction() {
+	collateral := modules.MDMTruncateSectorCollateral()
+	cost := modules.MDMTruncateSectorCost(v.staticPT)
+	memory := modules.MDMTruncateSectorMemory()
+	time := uint64(modules.MDMTimeTruncateSector)
+	newData := 8 + 8
+	readonly := false
+	batch := false
+	v.addInstruction(collateral, cost, types.ZeroCurrency, types.ZeroCurrency, memory, time, newData, readonly, batch)
+}
+
+// AddUpdateSectorRangeInstruction adds a revision instruction to the builder,
+// keeping track of running values.
+func (v *TestValues) AddUpdateSectorRangeInstruction(data []byte) {
+	collateral := modules.MDMUpdateSectorRangeCollateral()
+	cost := modules.MDMUpdateSectorRangeCost(v.staticPT)
+	memory := modules.MDMUpdateSectorRangeMemory()
+	time := uint64(modules.MDMTimeUpdateSectorRange)
+	newData := 8 + 8 + 8 + len(data)
+	readonly := false
+	batch := false
+	v.addInstruction(collateral, cost, types.ZeroCurrency, types.ZeroCurrency, memory, time, newData, readonly, batch)
+}
+
 // AddUpdateRegistryInstruction adds a revision instruction to the builder, keeping
 // track of running values.
 func (v *TestValues) AddUpdateRegistryInstruction(spk types.SiaPublicKey, rv modules.SignedRegistryValue) {
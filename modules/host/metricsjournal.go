@@ -0,0 +1,85 @@
+package host
+
+import (
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// financialMetricsFields maps the names of the host's financial metrics in the
+// metrics journal to the metrics.
+var financialMetricsFields = map[string]func(*modules.HostFinancialMetrics) *types.Currency{
+	"accountfunding":                    func(fm *modules.HostFinancialMetrics) *types.Currency { return &fm.AccountFunding },
+	"potentialaccountfunding":           func(fm *modules.HostFinancialMetrics) *types.Currency { return &fm.PotentialAccountFunding },
+	"contractcompensation":              func(fm *modules.HostFinancialMetrics) *types.Currency { return &fm.ContractCompensation },
+	"potentialcontractcompensation":     func(fm *modules.HostFinancialMetrics) *types.Currency { return &fm.PotentialContractCompensation },
+	"lockedstoragecollateral":           func(fm *modules.HostFinancialMetrics) *types.Currency { return &fm.LockedStorageCollateral },
+	"lostrevenue":                       func(fm *modules.HostFinancialMetrics) *types.Currency { return &fm.LostRevenue },
+	"loststoragecollateral":             func(fm *modules.HostFinancialMetrics) *types.Currency { return &fm.LostStorageCollateral },
+	"potentialstoragerevenue":           func(fm *modules.HostFinancialMetrics) *types.Currency { return &fm.PotentialStorageRevenue },
+	"riskedstoragecollateral":           func(fm *modules.HostFinancialMetrics) *types.Currency { return &fm.RiskedStorageCollateral },
+	"storagerevenue":                    func(fm *modules.HostFinancialMetrics) *types.Currency { return &fm.StorageRevenue },
+	"transactionfeeexpenses":            func(fm *modules.HostFinancialMetrics) *types.Currency { return &fm.TransactionFeeExpenses },
+	"downloadbandwidthrevenue":          func(fm *modules.HostFinancialMetrics) *types.Currency { return &fm.DownloadBandwidthRevenue },
+	"potentialdownloadbandwidthrevenue": func(fm *modules.HostFinancialMetrics) *types.Currency { return &fm.PotentialDownloadBandwidthRevenue },
+	"potentialuploadbandwidthrevenue":   func(fm *modules.HostFinancialMetrics) *types.Currency { return &fm.PotentialUploadBandwidthRevenue },
+	"uploadbandwidthrevenue":            func(fm *modules.HostFinancialMetrics) *types.Currency { return &fm.UploadBandwidthRevenue },
+}
+
+// financialMetricsCounters returns the financial metrics as counters of the
+// metrics journal.
+func financialMetricsCounters(fm modules.HostFinancialMetrics) map[string]types.Currency {
+	counters := make(map[string]types.Currency, len(financialMetricsFields)+1)
+	for name, field := range financialMetricsFields {
+		counters[name] = *field(&fm)
+	}
+	counters["contractcount"] = types.NewCurrency64(fm.ContractCount)
+	return counters
+}
+
+// financialMetricsFromCounters returns the financial metrics for the counters
+// of the metrics journal.
+func financialMetricsFromCounters(counters map[string]types.Currency) (fm modules.HostFinancialMetrics) {
+	for name, field := range financialMetricsFields {
+		*field(&fm) = counters[name]
+	}
+	if count, exists := counters["contractcount"]; exists {
+		fm.ContractCount, _ = count.Uint64()
+	}
+	return fm
+}
+
+// recordFinancialMetrics records the current financial metrics in the metrics
+// journal. A failure is only logged since the metrics can be recomputed from
+// the storage obligations.
+func (h *Host) recordFinancialMetrics() {
+	if h.staticMetricsJournal == nil {
+		return
+	}
+	if err := h.staticMetricsJournal.Record(financialMetricsCounters(h.financialMetrics)); err != nil {
+		h.log.Println("WARN: failed to record financial metrics:", err)
+	}
+}
+
+// reconcileFinancialMetrics reconciles the financial metrics loaded from the
+// host's persist file with the metrics journal. Updates which were not
+// persisted before a crash are restored from the journal.
+func (h *Host) reconcileFinancialMetrics() error {
+	reconciled, report, err := h.staticMetricsJournal.Reconcile(financialMetricsCounters(h.financialMetrics))
+	if err != nil {
+		return err
+	}
+	h.financialMetrics = financialMetricsFromCounters(reconciled)
+	h.metricsReconciliation = report
+	for _, drift := range report.Drifts {
+		h.log.Printf("Restored financial metric %v from the metrics journal, persisted value was %v, journaled value is %v", drift.Counter, drift.Persisted, drift.Journaled)
+	}
+	return nil
+}
+
+// MetricsReconciliation returns the result of reconciling the host's persisted
+// financial metrics with the metrics journal at startup.
+func (h *Host) MetricsReconciliation() modules.MetricsReconciliation {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.metricsReconciliation
+}
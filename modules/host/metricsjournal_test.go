@@ -0,0 +1,98 @@
+package host
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestFinancialMetricsCounters is a unit test for converting the financial
+// metrics to and from the counters of the metrics journal.
+func TestFinancialMetricsCounters(t *testing.T) {
+	t.Parallel()
+
+	fm := modules.HostFinancialMetrics{
+		ContractCount:           3,
+		StorageRevenue:          types.SiacoinPrecision,
+		LockedStorageCollateral: types.SiacoinPrecision.Mul64(2),
+		UploadBandwidthRevenue:  types.NewCurrency64(42),
+	}
+	counters := financialMetricsCounters(fm)
+	if len(counters) != len(financialMetricsFields)+1 {
+		t.Fatal("wrong number of counters", len(counters))
+	}
+	if converted := financialMetricsFromCounters(counters); !reflect.DeepEqual(converted, fm) {
+		t.Fatal("metrics don't match", converted, fm)
+	}
+}
+
+// TestHostMetricsJournal checks that the host records its financial metrics in
+// the metrics journal and reports metrics that drifted from the persisted ones
+// on startup.
+func TestHostMetricsJournal(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// add a storage obligation, which should be recorded in the journal
+	so, err := ht.newTesterStorageObligation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host.managedLockStorageObligation(so.id())
+	err = ht.host.managedAddStorageObligation(so)
+	ht.host.managedUnlockStorageObligation(so.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count := ht.host.staticMetricsJournal.Counters()["contractcount"]; !count.Equals64(1) {
+		t.Fatal("contract count wasn't recorded", count)
+	}
+
+	// close the host and record an update which didn't make it into the
+	// persist file, as if the host crashed before saving
+	if err := ht.host.Close(); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(ht.persistDir, modules.HostDir, metricsJournalFile)
+	mj, err := modules.NewMetricsJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mj.Record(map[string]types.Currency{"storagerevenue": types.SiacoinPrecision}); err != nil {
+		t.Fatal(err)
+	}
+	if err := mj.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// reload the host, the drift should be reported
+	ht.host, err = New(ht.cs, ht.gateway, ht.tpool, ht.wallet, ht.mux, "localhost:0", filepath.Join(ht.persistDir, modules.HostDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mr := ht.host.MetricsReconciliation()
+	if len(mr.Drifts) != 1 {
+		t.Fatal("expected one drift", mr.Drifts)
+	}
+	if d := mr.Drifts[0]; d.Counter != "storagerevenue" || !d.Persisted.IsZero() || !d.Journaled.Equals(types.SiacoinPrecision) {
+		t.Fatal("wrong drift", d)
+	}
+	if count := ht.host.FinancialMetrics().ContractCount; count != 1 {
+		t.Fatal("expected one contract, got", count)
+	}
+}
@@ -0,0 +1,101 @@
+package host
+
+import (
+	"encoding/json"
+	"time"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/host/contractmanager"
+	"go.sia.tech/siad/types"
+)
+
+// buildProofFailureCause returns the probable cause for a failure to build a
+// storage proof.
+func buildProofFailureCause(err error) modules.MissedProofCause {
+	if errors.Contains(err, contractmanager.ErrSectorNotFound) {
+		return modules.MissedProofCauseSectorMissing
+	}
+	return modules.MissedProofCauseDiskError
+}
+
+// newMissedProof creates the record of a missed storage proof for a failed
+// storage obligation.
+func newMissedProof(so storageObligation, height types.BlockHeight) modules.HostMissedProof {
+	cause := so.ProofFailureCause
+	if cause == "" {
+		cause = modules.MissedProofCauseUnknown
+	}
+	return modules.HostMissedProof{
+		BurntCollateral: so.RiskedCollateral,
+		LostRevenue:     so.ContractCost.Add(so.PotentialStorageRevenue).Add(so.PotentialDownloadRevenue).Add(so.PotentialUploadRevenue).Add(so.PotentialAccountFunding),
+
+		Cause: cause,
+		Error: so.ProofFailureError,
+
+		ExpirationHeight: so.expiration(),
+		MissedHeight:     height,
+		ObligationID:     so.id(),
+		ProofDeadline:    so.proofDeadline(),
+		Timestamp:        time.Now(),
+	}
+}
+
+// putMissedProof places a missed proof into the database, overwriting the
+// existing one for the same contract if there is one.
+func putMissedProof(tx *bolt.Tx, mp modules.HostMissedProof) error {
+	mpBytes, err := json.Marshal(mp)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(bucketMissedProofs).Put(mp.ObligationID[:], mpBytes)
+}
+
+// managedRecordProofFailure updates the storage obligation in the database
+// with the cause of a failed attempt at submitting a storage proof. If the
+// proof is missed in the end, the cause of the last failure is reported as its
+// probable cause.
+func (h *Host) managedRecordProofFailure(so storageObligation, cause modules.MissedProofCause, failureErr error) {
+	so.ProofFailureCause = cause
+	so.ProofFailureError = ""
+	if failureErr != nil {
+		so.ProofFailureError = failureErr.Error()
+	}
+	err := h.db.Update(func(tx *bolt.Tx) error {
+		return putStorageObligation(tx, so)
+	})
+	if err != nil {
+		h.log.Printf("contract %s action: Error recording storage proof failure: %s", so.id(), err)
+	}
+}
+
+// MissedProofs returns all storage proofs the host failed to submit.
+func (h *Host) MissedProofs() ([]modules.HostMissedProof, error) {
+	if err := h.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer h.tg.Done()
+
+	var mps []modules.HostMissedProof
+	err := h.db.View(func(tx *bolt.Tx) (err error) {
+		mps, err = missedProofs(tx)
+		return err
+	})
+	return mps, err
+}
+
+// missedProofs returns all missed proofs from the database.
+func missedProofs(tx *bolt.Tx) ([]modules.HostMissedProof, error) {
+	var mps []modules.HostMissedProof
+	err := tx.Bucket(bucketMissedProofs).ForEach(func(_, v []byte) error {
+		var mp modules.HostMissedProof
+		if err := json.Unmarshal(v, &mp); err != nil {
+			return errors.AddContext(err, "unable to unmarshal missed proof")
+		}
+		mps = append(mps, mp)
+		return nil
+	})
+	return mps, err
+}
@@ -0,0 +1,96 @@
+package host
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/host/contractmanager"
+	"go.sia.tech/siad/types"
+)
+
+// TestBuildProofFailureCause is a unit test for buildProofFailureCause.
+func TestBuildProofFailureCause(t *testing.T) {
+	t.Parallel()
+
+	err := errors.AddContext(contractmanager.ErrSectorNotFound, "failed to read sector")
+	if cause := buildProofFailureCause(err); cause != modules.MissedProofCauseSectorMissing {
+		t.Fatal("wrong cause", cause)
+	}
+	if cause := buildProofFailureCause(errors.New("disk failure")); cause != modules.MissedProofCauseDiskError {
+		t.Fatal("wrong cause", cause)
+	}
+}
+
+// TestMissedProofs checks that a failed storage obligation is recorded as a
+// missed proof with the cause of the last proof failure.
+func TestMissedProofs(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// create a storage obligation
+	so, err := ht.newTesterStorageObligation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host.managedLockStorageObligation(so.id())
+	defer ht.host.managedUnlockStorageObligation(so.id())
+	so.RiskedCollateral = types.SiacoinPrecision
+	if err := ht.host.managedAddStorageObligation(so); err != nil {
+		t.Fatal(err)
+	}
+
+	// record a proof failure
+	ht.host.managedRecordProofFailure(so, modules.MissedProofCauseDiskError, errors.New("disk failure"))
+	so, err = ht.host.managedGetStorageObligation(so.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if so.ProofFailureCause != modules.MissedProofCauseDiskError || so.ProofFailureError != "disk failure" {
+		t.Fatal("proof failure wasn't recorded", so.ProofFailureCause, so.ProofFailureError)
+	}
+
+	// no proofs should be missed yet
+	mps, err := ht.host.MissedProofs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mps) != 0 {
+		t.Fatal("expected no missed proofs", len(mps))
+	}
+
+	// fail the obligation
+	ht.host.mu.Lock()
+	err = ht.host.removeStorageObligation(so, obligationFailed)
+	ht.host.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mps, err = ht.host.MissedProofs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mps) != 1 {
+		t.Fatal("expected one missed proof", len(mps))
+	}
+	mp := mps[0]
+	if mp.ObligationID != so.id() || mp.Cause != modules.MissedProofCauseDiskError || mp.Error != "disk failure" {
+		t.Fatal("unexpected missed proof", mp)
+	}
+	if !mp.BurntCollateral.Equals(so.RiskedCollateral) {
+		t.Fatal("wrong burnt collateral", mp.BurntCollateral)
+	}
+}
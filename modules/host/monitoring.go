@@ -0,0 +1,184 @@
+package host
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+)
+
+var (
+	// monitoringRateLimitWindow is the window within which the number of
+	// requests a single IP can make to the monitoring endpoint is limited.
+	monitoringRateLimitWindow = build.Select(build.Var{
+		Standard: time.Minute,
+		Dev:      30 * time.Second,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+
+	// monitoringRequestsPerWindow is the number of requests a single IP can
+	// make to the monitoring endpoint within monitoringRateLimitWindow.
+	monitoringRequestsPerWindow = build.Select(build.Var{
+		Standard: 30,
+		Dev:      30,
+		Testing:  5,
+	}).(int)
+)
+
+const (
+	// monitoringTimeout is the read and write timeout of the monitoring
+	// endpoint's connections.
+	monitoringTimeout = 10 * time.Second
+)
+
+// monitoringServer serves the host's external settings and price table over
+// plain HTTP. This allows monitoring services to track the host's prices
+// without implementing the host's protocols. The server is read-only and
+// limits the number of requests per IP.
+type monitoringServer struct {
+	staticAddress  string
+	staticHost     *Host
+	staticListener net.Listener
+	staticServer   *http.Server
+
+	requests    map[string]int
+	windowStart time.Time
+	mu          sync.Mutex
+}
+
+// newMonitoringServer starts serving the monitoring endpoint on the provided
+// address.
+func (h *Host) newMonitoringServer(address string) (*monitoringServer, error) {
+	l, err := h.dependencies.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	ms := &monitoringServer{
+		staticAddress:  address,
+		staticHost:     h,
+		staticListener: l,
+		requests:       make(map[string]int),
+		windowStart:    time.Now(),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/settings", ms.settingsHandler)
+	mux.HandleFunc("/pricetable", ms.priceTableHandler)
+	ms.staticServer = &http.Server{
+		Handler:      ms.rateLimit(mux),
+		ReadTimeout:  monitoringTimeout,
+		WriteTimeout: monitoringTimeout,
+	}
+	go func() {
+		err := ms.staticServer.Serve(l)
+		if err != nil && !errors.Contains(err, http.ErrServerClosed) {
+			h.log.Println("WARN: monitoring endpoint stopped serving:", err)
+		}
+	}()
+	return ms, nil
+}
+
+// Close stops the server and closes its listener.
+func (ms *monitoringServer) Close() error {
+	return ms.staticServer.Close()
+}
+
+// managedAllow returns whether the IP may make another request within the
+// current window.
+func (ms *monitoringServer) managedAllow(ip string) bool {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	if time.Since(ms.windowStart) >= monitoringRateLimitWindow {
+		ms.requests = make(map[string]int)
+		ms.windowStart = time.Now()
+	}
+	if ms.requests[ip] >= monitoringRequestsPerWindow {
+		return false
+	}
+	ms.requests[ip]++
+	return true
+}
+
+// rateLimit wraps the handler to only allow GET requests and to limit the
+// number of requests per IP.
+func (ms *monitoringServer) rateLimit(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		ip, _, err := net.SplitHostPort(req.RemoteAddr)
+		if err != nil {
+			ip = req.RemoteAddr
+		}
+		if !ms.managedAllow(ip) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(monitoringRateLimitWindow.Seconds())))
+			http.Error(w, "too many requests", http.StatusTooManyRequests)
+			return
+		}
+		handler.ServeHTTP(w, req)
+	})
+}
+
+// settingsHandler writes the host's external settings.
+func (ms *monitoringServer) settingsHandler(w http.ResponseWriter, req *http.Request) {
+	h := ms.staticHost
+	if err := h.tg.Add(); err != nil {
+		http.Error(w, "host is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	defer h.tg.Done()
+	writeMonitoringJSON(w, h.managedExternalSettings())
+}
+
+// priceTableHandler writes a snapshot of the host's current price table.
+func (ms *monitoringServer) priceTableHandler(w http.ResponseWriter, req *http.Request) {
+	h := ms.staticHost
+	if err := h.tg.Add(); err != nil {
+		http.Error(w, "host is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	defer h.tg.Done()
+	writeMonitoringJSON(w, h.PriceTable())
+}
+
+// writeMonitoringJSON writes obj to w as JSON.
+func writeMonitoringJSON(w http.ResponseWriter, obj interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(obj); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// updateMonitoringServer restarts the monitoring endpoint on the provided
+// address. An empty address disables the endpoint. If the endpoint can't be
+// started on the new address, the previous endpoint is restored.
+func (h *Host) updateMonitoringServer(address string) error {
+	var prevAddress string
+	if h.monitoringServer != nil {
+		prevAddress = h.monitoringServer.staticAddress
+		if err := h.monitoringServer.Close(); err != nil {
+			h.log.Println("WARN: failed to close monitoring endpoint:", err)
+		}
+		h.monitoringServer = nil
+	}
+	if address == "" {
+		return nil
+	}
+	ms, err := h.newMonitoringServer(address)
+	if err != nil {
+		if prevAddress != "" {
+			h.monitoringServer, _ = h.newMonitoringServer(prevAddress)
+		}
+		return errors.AddContext(err, "failed to start monitoring endpoint")
+	}
+	h.monitoringServer = ms
+	return nil
+}
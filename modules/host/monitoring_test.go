@@ -0,0 +1,116 @@
+package host
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"go.sia.tech/siad/modules"
+)
+
+// TestMonitoringServer tests serving the host's settings and price table over
+// the monitoring endpoint.
+func TestMonitoringServer(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	ht, err := blankHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	h := ht.host
+
+	// The endpoint is disabled by default.
+	h.mu.RLock()
+	ms := h.monitoringServer
+	h.mu.RUnlock()
+	if ms != nil {
+		t.Fatal("monitoring endpoint shouldn't be running")
+	}
+
+	// Invalid addresses are rejected.
+	is := h.InternalSettings()
+	is.MonitoringAddress = "invalid"
+	if err := h.SetInternalSettings(is); err == nil {
+		t.Fatal("expected invalid address to be rejected")
+	}
+
+	// Enable the endpoint.
+	is.MonitoringAddress = "localhost:0"
+	if err := h.SetInternalSettings(is); err != nil {
+		t.Fatal(err)
+	}
+	h.mu.RLock()
+	ms = h.monitoringServer
+	h.mu.RUnlock()
+	if ms == nil {
+		t.Fatal("monitoring endpoint should be running")
+	}
+	baseURL := "http://" + ms.staticListener.Addr().String()
+
+	// get is a helper to fetch the resource at the path.
+	get := func(path string, obj interface{}) int {
+		resp, err := http.Get(baseURL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(resp.Body).Decode(obj); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return resp.StatusCode
+	}
+
+	var es modules.HostExternalSettings
+	if status := get("/settings", &es); status != http.StatusOK {
+		t.Fatal("unexpected status", status)
+	}
+	if es.NetAddress != h.ExternalSettings().NetAddress || !es.StoragePrice.Equals(h.ExternalSettings().StoragePrice) {
+		t.Fatal("wrong settings", es)
+	}
+	var pt modules.RPCPriceTable
+	if status := get("/pricetable", &pt); status != http.StatusOK {
+		t.Fatal("unexpected status", status)
+	}
+	if pt.UID != h.PriceTable().UID {
+		t.Fatal("wrong price table", pt)
+	}
+
+	// The endpoint is read-only.
+	resp, err := http.Post(baseURL+"/settings", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatal("unexpected status", resp.StatusCode)
+	}
+
+	// Exceeding the rate limit is rejected.
+	for i := 2; i < monitoringRequestsPerWindow; i++ {
+		if status := get("/settings", &es); status != http.StatusOK {
+			t.Fatal("unexpected status", status)
+		}
+	}
+	if status := get("/settings", &es); status != http.StatusTooManyRequests {
+		t.Fatal("unexpected status", status)
+	}
+
+	// Disable the endpoint.
+	is.MonitoringAddress = ""
+	if err := h.SetInternalSettings(is); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := http.Get(baseURL + "/settings"); err == nil {
+		t.Fatal("expected endpoint to be closed")
+	}
+}
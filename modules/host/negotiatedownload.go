@@ -91,7 +91,7 @@ func (h *Host) managedDownloadIteration(conn net.Conn, so *storageObligation) er
 
 		// Load the sectors and build the data payload.
 		for _, request := range requests {
-			sectorData, err := h.ReadSector(request.MerkleRoot)
+			sectorData, err := h.readDownloadSector(request.MerkleRoot, totalSize)
 			if err != nil {
 				return extendErr("failed to load sector: ", ErrorInternal(err.Error()))
 			}
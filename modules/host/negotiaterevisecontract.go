@@ -130,7 +130,7 @@ func (h *Host) managedRevisionIteration(conn net.Conn, so *storageObligation, fi
 				}
 
 				// Get the data for the new sector.
-				sector, err := h.ReadSector(so.SectorRoots[modification.SectorIndex])
+				sector, err := h.ReadSectorBypassCache(so.SectorRoots[modification.SectorIndex])
 				if err != nil {
 					return extendErr("could not read sector: ", ErrorInternal(err.Error()))
 				}
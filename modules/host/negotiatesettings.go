@@ -64,6 +64,9 @@ func (h *Host) externalSettings(maxFeeEstimation types.Currency) modules.HostExt
 		maxCollateral = h.settings.CollateralBudget.Sub(h.financialMetrics.LockedStorageCollateral)
 	}
 
+	// Apply the pricing policy.
+	prices := h.dynamicPrices(totalStorage, remainingStorage, maxCollateral)
+
 	// Extract the port from the SiaMux's address
 	_, port, err := net.SplitHostPort(h.staticMux.Address().String())
 	if err != nil {
@@ -83,18 +86,22 @@ func (h *Host) externalSettings(maxFeeEstimation types.Currency) modules.HostExt
 		WindowSize:           h.settings.WindowSize,
 
 		Collateral:    h.settings.Collateral,
-		MaxCollateral: maxCollateral,
+		MaxCollateral: prices.maxCollateral,
 
 		BaseRPCPrice:           h.settings.MinBaseRPCPrice,
 		ContractPrice:          contractPrice,
-		DownloadBandwidthPrice: h.settings.MinDownloadBandwidthPrice,
+		DownloadBandwidthPrice: prices.download,
 		SectorAccessPrice:      h.settings.MinSectorAccessPrice,
-		StoragePrice:           h.settings.MinStoragePrice,
-		UploadBandwidthPrice:   h.settings.MinUploadBandwidthPrice,
+		StoragePrice:           prices.storage,
+		UploadBandwidthPrice:   prices.upload,
 
 		EphemeralAccountExpiry:     h.settings.EphemeralAccountExpiry,
 		MaxEphemeralAccountBalance: h.settings.MaxEphemeralAccountBalance,
 
+		MaxProgramInstructions: h.settings.MaxProgramInstructions,
+		MaxProgramDataLength:   h.settings.MaxProgramDataLength,
+		MaxProgramDuration:     h.settings.MaxProgramDuration,
+
 		RevisionNumber: h.revisionNumber,
 		Version:        modules.RHPVersion,
 
@@ -15,16 +15,15 @@ package host
 // have to keep all the files following a renew in order to get the money.
 
 import (
-	"encoding/hex"
 	"fmt"
 	"io"
 	"net"
+	"strings"
 	"sync/atomic"
 	"time"
 
 	"gitlab.com/NebulousLabs/encoding"
 	"gitlab.com/NebulousLabs/errors"
-	"gitlab.com/NebulousLabs/fastrand"
 	connmonitor "gitlab.com/NebulousLabs/monitor"
 	"gitlab.com/NebulousLabs/siamux"
 	"go.sia.tech/siad/build"
@@ -148,7 +147,7 @@ func (h *Host) threadedTrackConnectabilityStatus(closeChan chan struct{}) {
 			Cancel:  h.tg.StopChan(),
 			Timeout: connectabilityCheckTimeout,
 		}
-		conn, err := dialer.Dial("tcp", string(activeAddr))
+		conn, err := modules.GlobalProxy.Dial(dialer, "tcp", string(activeAddr))
 
 		var status modules.HostConnectabilityStatus
 		if err != nil {
@@ -158,35 +157,52 @@ func (h *Host) threadedTrackConnectabilityStatus(closeChan chan struct{}) {
 			status = modules.HostConnectabilityStatusConnectable
 		}
 		h.mu.Lock()
+		if status != h.connectabilityStatus {
+			h.log.Printf("Connectability status changed from %v to %v", h.connectabilityStatus, status)
+		}
 		h.connectabilityStatus = status
 		h.mu.Unlock()
+		h.updateConnectabilityAlert(activeAddr, status, err)
 
 		select {
 		case <-h.tg.StopChan():
 			return
-		case <-time.After(connectabilityCheckFrequency):
+		case <-time.After(modules.CurrentProfile().ConnectabilityCheckFrequency):
 		}
 	}
 }
 
 // initNetworking performs actions like port forwarding, and gets the
-// host established on the network.
+// host established on the network. The address can be a comma-separated list
+// of addresses, e.g. to listen on a specific IPv4 and IPv6 address. The first
+// address determines the host's port.
 func (h *Host) initNetworking(address string) (err error) {
-	// Create the listener and setup the close procedures.
-	h.listener, err = h.dependencies.Listen("tcp", address)
-	if err != nil {
-		return err
+	// Create the listeners and setup the close procedures.
+	var listenerClosedChans []chan struct{}
+	for i, addr := range strings.Split(address, ",") {
+		l, err := h.dependencies.Listen("tcp", strings.TrimSpace(addr))
+		if err != nil {
+			return errors.Compose(err, h.closeListeners())
+		}
+		if i == 0 {
+			h.listener = l
+		} else {
+			h.extraListeners = append(h.extraListeners, l)
+		}
+		listenerClosedChans = append(listenerClosedChans, make(chan struct{}))
 	}
-	// Automatically close the listener when h.tg.Stop() is called.
-	threadedListenerClosedChan := make(chan struct{})
+	// Automatically close the listeners when h.tg.Stop() is called.
 	h.tg.OnStop(func() {
-		err := h.listener.Close()
+		err := h.closeListeners()
 		if err != nil {
 			h.log.Println("WARN: closing the listener failed:", err)
 		}
 
-		// Wait until the threadedListener has returned to continue shutdown.
-		<-threadedListenerClosedChan
+		// Wait until the threadedListeners have returned to continue
+		// shutdown.
+		for _, c := range listenerClosedChans {
+			<-c
+		}
 	})
 
 	// Set the initial working state of the host
@@ -244,8 +260,11 @@ func (h *Host) initNetworking(address string) (err error) {
 		})
 	}()
 
-	// Launch the listener.
-	go h.threadedListen(threadedListenerClosedChan)
+	// Launch the listeners.
+	go h.threadedListen(h.listener, listenerClosedChans[0])
+	for i, l := range h.extraListeners {
+		go h.threadedListen(l, listenerClosedChans[i+1])
+	}
 
 	// Create a listener for the SiaMux.
 	if !h.dependencies.Disrupt("DisableHostSiamux") {
@@ -265,12 +284,24 @@ func (h *Host) initNetworking(address string) (err error) {
 // threadedHandleConn handles an incoming connection to the host, typically an
 // RPC.
 func (h *Host) threadedHandleConn(conn net.Conn) {
+	defer modules.RecoverPanic("host")
 	err := h.tg.Add()
 	if err != nil {
 		return
 	}
 	defer h.tg.Done()
 
+	// Record the bandwidth used by the conn once the method terminates.
+	start := time.Now()
+	name := rpcNameUnknown
+	cc := &countingConn{Conn: conn}
+	conn = cc
+	defer func() {
+		upload := atomic.LoadUint64(&cc.atomicWritten)
+		download := atomic.LoadUint64(&cc.atomicRead)
+		h.staticBandwidthLedger.managedRecord(start, name, upload, download)
+	}()
+
 	// Close the conn on host.Close or when the method terminates, whichever
 	// comes first.
 	connCloseChan := make(chan struct{})
@@ -320,18 +351,23 @@ func (h *Host) threadedHandleConn(conn net.Conn) {
 	switch id {
 	// new RPCs: enter an infinite request/response loop
 	case modules.RPCLoopEnter:
+		name = rpcName(id)
 		err = extendErr("incoming RPCLoopEnter failed: ", h.managedRPCLoop(conn))
 	// old RPCs: handle a single request/response
 	case modules.RPCDownload:
+		name = rpcName(id)
 		atomic.AddUint64(&h.atomicDownloadCalls, 1)
 		err = extendErr("incoming RPCDownload failed: ", h.managedRPCDownload(conn))
 	case modules.RPCFormContract:
+		name = rpcName(id)
 		atomic.AddUint64(&h.atomicFormContractCalls, 1)
 		err = extendErr("incoming RPCFormContract failed: ", h.managedRPCFormContract(conn))
 	case modules.RPCReviseContract:
+		name = rpcName(id)
 		atomic.AddUint64(&h.atomicReviseCalls, 1)
 		err = extendErr("incoming RPCReviseContract failed: ", h.managedRPCReviseContract(conn))
 	case modules.RPCSettings:
+		name = rpcName(id)
 		atomic.AddUint64(&h.atomicSettingsCalls, 1)
 		err = extendErr("incoming RPCSettings failed: ", h.managedRPCSettings(conn))
 	case rpcSettingsDeprecated:
@@ -349,12 +385,31 @@ func (h *Host) threadedHandleConn(conn net.Conn) {
 
 // threadedHandleStream handles incoming SiaMux streams.
 func (h *Host) threadedHandleStream(stream siamux.Stream) {
-	var uid [8]byte
-	fastrand.Read(uid[:])
-	uidStr := hex.EncodeToString(uid[:])
-
-	fmt.Println(uidStr, time.Now(), "threadedHandleStream")
+	// trace the RPC once the stream was closed
 	start := time.Now()
+	name := rpcNameUnknown
+	var err error
+	var errCode string
+	defer func() {
+		entry := modules.HostRPCTraceEntry{
+			RPC:      name,
+			Start:    start,
+			Duration: time.Since(start),
+		}
+		l := stream.Limit()
+		entry.BytesUploaded = l.Uploaded()
+		entry.BytesDownloaded = l.Downloaded()
+		if err != nil {
+			entry.ErrorCode = errCode
+			if entry.ErrorCode == "" {
+				entry.ErrorCode = rpcErrorCode(err)
+			}
+			entry.Error = err.Error()
+		}
+		h.staticRPCTracer.managedRecord(entry)
+		h.staticBandwidthLedger.managedRecord(start, name, entry.BytesUploaded, entry.BytesDownloaded)
+	}()
+
 	// close the stream when the method terminates
 	var cleanup afterCloseFn
 	defer func() {
@@ -388,7 +443,7 @@ func (h *Host) threadedHandleStream(stream siamux.Stream) {
 		}
 	}
 
-	err := h.tg.Add()
+	err = h.tg.Add()
 	if err != nil {
 		return
 	}
@@ -410,59 +465,75 @@ func (h *Host) threadedHandleStream(stream siamux.Stream) {
 		if wErr := modules.RPCWriteError(stream, err); wErr != nil {
 			h.managedLogError(wErr)
 		}
+		errCode = modules.HostRPCErrorCodeUnknownRPC
 		atomic.AddUint64(&h.atomicUnrecognizedCalls, 1)
 		return
 	}
 
-	var out string
+	name = rpcName(rpcID)
 	switch rpcID {
 	case modules.RPCAccountBalance:
-		fmt.Println(uidStr, time.Now(), "RPCAccountBalance")
-		out, err = h.managedRPCAccountBalance(stream)
-		fmt.Println(uidStr, time.Now(), "RPCAccountBalance Output:\n", out)
+		err = h.managedRPCAccountBalance(stream)
 	case modules.RPCExecuteProgram:
-		fmt.Println(uidStr, time.Now(), "RPCExecuteProgram")
 		err = h.managedRPCExecuteProgram(stream)
+	case modules.RPCContractCollateral:
+		err = h.managedRPCContractCollateral(stream)
+	case modules.RPCExecuteProgramBatch:
+		err = h.managedRPCExecuteProgramBatch(stream)
 	case modules.RPCUpdatePriceTable:
-		fmt.Println(uidStr, time.Now(), "RPCUpdatePriceTable")
-		out, err = h.managedRPCUpdatePriceTable(stream)
-		fmt.Println(uidStr, time.Now(), "RPCUpdatePriceTable Output:\n", out)
+		err = h.managedRPCUpdatePriceTable(stream)
 	case modules.RPCFundAccount:
-		fmt.Println(uidStr, time.Now(), "RPCFundAccount")
 		err = h.managedRPCFundEphemeralAccount(stream)
 	case modules.RPCLatestRevision:
-		fmt.Println(uidStr, time.Now(), "RPCLatestRevision")
 		err = h.managedRPCLatestRevision(stream)
 	case modules.RPCRegistrySubscription:
-		fmt.Println(uidStr, time.Now(), "RPCRegistrySubscription")
 		cleanup, err = h.managedRPCRegistrySubscribe(stream)
 	case modules.RPCRenewContract:
-		fmt.Println(uidStr, time.Now(), "RPCRenewContract")
 		err = h.managedRPCRenewContract(stream)
+	case modules.RPCSectorStats:
+		err = h.managedRPCSectorStats(stream)
+	case modules.RPCBatchHasSector:
+		err = h.managedRPCBatchHasSector(stream)
+	case modules.RPCSignedAccountBalance:
+		err = h.managedRPCSignedAccountBalance(stream)
 	default:
+		// don't trace the id of unknown RPCs to avoid creating an entry in
+		// the stats for every random id
+		name = rpcNameUnknown
 		h.log.Debugf("WARN: incoming stream %v requested unknown RPC \"%v\"", stream.RemoteAddr().String(), rpcID)
 		err = errors.New(fmt.Sprintf("Unrecognized RPC id %v", rpcID))
+		errCode = modules.HostRPCErrorCodeUnknownRPC
 		atomic.AddUint64(&h.atomicUnrecognizedCalls, 1)
 	}
 
 	if err != nil {
-		fmt.Println(uidStr, time.Now(), "RPC error", err)
 		err = errors.Compose(err, modules.RPCWriteError(stream, err))
 		atomic.AddUint64(&h.atomicErroredCalls, 1)
 		h.managedLogError(err)
 	}
-	fmt.Println(uidStr, time.Now(), string(rpcID[:]), "took", time.Since(start))
+}
+
+// closeListeners closes all the listeners of the host.
+func (h *Host) closeListeners() (err error) {
+	if h.listener != nil {
+		err = h.listener.Close()
+	}
+	for _, l := range h.extraListeners {
+		err = errors.Compose(err, l.Close())
+	}
+	return err
 }
 
 // threadedListen listens for incoming RPCs and spawns an appropriate handler for each.
-func (h *Host) threadedListen(closeChan chan struct{}) {
+func (h *Host) threadedListen(listener net.Listener, closeChan chan struct{}) {
+	defer modules.RecoverPanic("host")
 	defer close(closeChan)
 
 	// Receive connections until an error is returned by the listener. When an
 	// error is returned, there will be no more calls to receive.
 	for {
 		// Block until there is a connection to handle.
-		conn, err := h.listener.Accept()
+		conn, err := listener.Accept()
 		if err != nil {
 			return
 		}
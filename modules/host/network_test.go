@@ -1,7 +1,10 @@
 package host
 
 import (
+	"encoding/json"
 	"fmt"
+	"net"
+	"path/filepath"
 	"strings"
 	"sync/atomic"
 	"testing"
@@ -197,3 +200,75 @@ func TestUnrecognizedRPCID(t *testing.T) {
 		t.Fatalf("Expected err '%v', but received '%v'", fmt.Sprintf("Unrecognized RPC id %v", randomRPCID), err)
 	}
 }
+
+// TestHostMultipleListeners checks that the host listens on all of the
+// provided addresses.
+func TestHostMultipleListeners(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Restart the host with two listeners.
+	if err := ht.host.Close(); err != nil {
+		t.Fatal(err)
+	}
+	hostDir := filepath.Join(ht.persistDir, modules.HostDir)
+	ht.host, err = New(ht.cs, ht.gateway, ht.tpool, ht.wallet, ht.mux, "localhost:0, 127.0.0.1:0", hostDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ht.host.extraListeners) != 1 {
+		t.Fatal("expected an additional listener", len(ht.host.extraListeners))
+	}
+
+	// Fetch the host's settings through the additional listener.
+	conn, err := net.Dial("tcp", ht.host.extraListeners[0].Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	s, _, err := modules.NewRenterSession(conn, ht.host.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.WriteRequest(modules.RPCLoopSettings, nil); err != nil {
+		t.Fatal(err)
+	}
+	var resp modules.LoopSettingsResponse
+	if err := s.ReadResponse(&resp, modules.RPCMinLen); err != nil {
+		t.Fatal(err)
+	}
+	var settings modules.HostExternalSettings
+	if err := json.Unmarshal(resp.Settings, &settings); err != nil {
+		t.Fatal(err)
+	}
+	if settings.NetAddress != ht.host.NetAddress() {
+		t.Fatal("wrong settings", settings.NetAddress)
+	}
+	if err := s.WriteRequest(modules.RPCLoopExit, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Starting the host fails if one of the addresses is invalid.
+	if err := ht.host.Close(); err != nil {
+		t.Fatal(err)
+	}
+	_, err = New(ht.cs, ht.gateway, ht.tpool, ht.wallet, ht.mux, "localhost:0,invalid", hostDir)
+	if err == nil {
+		t.Fatal("expected invalid address to fail")
+	}
+	ht.host, err = New(ht.cs, ht.gateway, ht.tpool, ht.wallet, ht.mux, "localhost:0", hostDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
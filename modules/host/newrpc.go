@@ -254,7 +254,7 @@ func (h *Host) managedRPCLoopWrite(s *rpcSession) error {
 				return ErrIllegalOffsetAndLength
 			}
 			// Update sector roots.
-			sector, err := h.ReadSector(newRoots[sectorIndex])
+			sector, err := h.ReadSectorBypassCache(newRoots[sectorIndex])
 			if err != nil {
 				err = errors.Compose(err, s.writeError(err))
 				return err
@@ -489,9 +489,10 @@ func (h *Host) managedRPCLoopRead(s *rpcSession) error {
 	}
 
 	// calculate expected cost and verify against renter's revision
-	var estBandwidth uint64
+	var estBandwidth, readSize uint64
 	sectorAccesses := make(map[crypto.Hash]struct{})
 	for _, sec := range req.Sections {
+		readSize += uint64(sec.Length)
 		// use the worst-case proof size of 2*tree depth (this occurs when
 		// proving across the two leaves in the center of the tree)
 		estHashesPerProof := 2 * bits.Len64(modules.SectorSize/crypto.SegmentSize)
@@ -537,7 +538,7 @@ func (h *Host) managedRPCLoopRead(s *rpcSession) error {
 	// enter response loop
 	for i, sec := range req.Sections {
 		// Fetch the requested data.
-		sectorData, err := h.ReadSector(sec.MerkleRoot)
+		sectorData, err := h.readDownloadSector(sec.MerkleRoot, readSize)
 		if err != nil {
 			err = errors.Compose(err, s.writeError(err))
 			return err
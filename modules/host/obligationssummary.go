@@ -0,0 +1,127 @@
+package host
+
+import (
+	"encoding/json"
+	"sort"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errInvalidDeadlineWindow is returned when the obligations summary is
+	// requested with a deadline window of 0 blocks.
+	errInvalidDeadlineWindow = errors.New("deadline window must be at least 1 block")
+)
+
+// proofAtRisk returns whether the storage proof of the obligation is at risk
+// at the given height. A proof is at risk if the obligation is unresolved and
+// the proof wasn't confirmed even though the host had the chance to resubmit
+// it.
+func (so storageObligation) proofAtRisk(height types.BlockHeight) bool {
+	return so.ObligationStatus == obligationUnresolved &&
+		!so.ProofConfirmed &&
+		so.requiresProof() &&
+		height >= so.expiration()+resubmissionTimeout
+}
+
+// revenue returns the revenue of the obligation, which is the contract
+// compensation plus the storage and bandwidth revenue.
+func (so storageObligation) revenue() types.Currency {
+	return so.ContractCost.Add(so.PotentialStorageRevenue).Add(so.PotentialDownloadRevenue).Add(so.PotentialUploadRevenue)
+}
+
+// addObligation adds an obligation to the totals of its status.
+func addObligation(s *modules.HostObligationStatusSummary, so storageObligation) {
+	s.Count++
+	s.DataSize += so.fileSize()
+	s.LockedCollateral = s.LockedCollateral.Add(so.LockedCollateral)
+	s.Revenue = s.Revenue.Add(so.revenue())
+	s.RiskedCollateral = s.RiskedCollateral.Add(so.RiskedCollateral)
+}
+
+// buildObligationsSummary aggregates the obligations at the given height.
+func buildObligationsSummary(sos []storageObligation, height, window types.BlockHeight) modules.HostObligationsSummary {
+	summary := modules.HostObligationsSummary{
+		BlockHeight: height,
+		Deadlines:   []modules.HostProofDeadlineWindow{},
+	}
+	windows := make(map[types.BlockHeight]*modules.HostProofDeadlineWindow)
+	for _, so := range sos {
+		switch so.ObligationStatus {
+		case obligationUnresolved:
+			addObligation(&summary.Unresolved, so)
+		case obligationRejected:
+			addObligation(&summary.Rejected, so)
+			continue
+		case obligationSucceeded:
+			addObligation(&summary.Succeeded, so)
+			continue
+		case obligationFailed:
+			addObligation(&summary.Failed, so)
+			continue
+		}
+
+		// Add the unresolved obligation to the window of its deadline.
+		var index types.BlockHeight
+		if deadline := so.proofDeadline(); deadline > height {
+			index = (deadline - height) / window
+		}
+		w, exists := windows[index]
+		if !exists {
+			w = &modules.HostProofDeadlineWindow{
+				StartHeight: height + index*window,
+				EndHeight:   height + (index+1)*window - 1,
+			}
+			windows[index] = w
+		}
+		w.Obligations++
+		w.Revenue = w.Revenue.Add(so.revenue())
+		w.RiskedCollateral = w.RiskedCollateral.Add(so.RiskedCollateral)
+		if so.proofAtRisk(height) {
+			w.AtRisk++
+			summary.AtRisk++
+		}
+	}
+	for _, w := range windows {
+		summary.Deadlines = append(summary.Deadlines, *w)
+	}
+	sort.Slice(summary.Deadlines, func(i, j int) bool {
+		return summary.Deadlines[i].StartHeight < summary.Deadlines[j].StartHeight
+	})
+	return summary
+}
+
+// ObligationsSummary aggregates the host's storage obligations by status and
+// groups the proof deadlines of the unresolved obligations into windows of the
+// provided number of blocks, starting at the current block height.
+func (h *Host) ObligationsSummary(window types.BlockHeight) (modules.HostObligationsSummary, error) {
+	if err := h.tg.Add(); err != nil {
+		return modules.HostObligationsSummary{}, err
+	}
+	defer h.tg.Done()
+	if window == 0 {
+		return modules.HostObligationsSummary{}, errInvalidDeadlineWindow
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	var sos []storageObligation
+	err := h.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketStorageObligations).ForEach(func(_, soBytes []byte) error {
+			var so storageObligation
+			if err := json.Unmarshal(soBytes, &so); err != nil {
+				return errors.AddContext(err, "unable to unmarshal storage obligation")
+			}
+			sos = append(sos, so)
+			return nil
+		})
+	})
+	if err != nil {
+		return modules.HostObligationsSummary{}, err
+	}
+	return buildObligationsSummary(sos, h.blockHeight, window), nil
+}
@@ -0,0 +1,126 @@
+package host
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/types"
+)
+
+// TestBuildObligationsSummary is a unit test for buildObligationsSummary.
+func TestBuildObligationsSummary(t *testing.T) {
+	t.Parallel()
+
+	obligation := func(status storageObligationStatus, windowStart, windowEnd types.BlockHeight, proofConfirmed bool) storageObligation {
+		return storageObligation{
+			ContractCost:            types.NewCurrency64(1),
+			LockedCollateral:        types.NewCurrency64(10),
+			PotentialStorageRevenue: types.NewCurrency64(2),
+			RiskedCollateral:        types.NewCurrency64(5),
+			ObligationStatus:        status,
+			ProofConfirmed:          proofConfirmed,
+			RevisionTransactionSet: []types.Transaction{{
+				FileContractRevisions: []types.FileContractRevision{{
+					NewFileSize:           100,
+					NewWindowStart:        windowStart,
+					NewWindowEnd:          windowEnd,
+					NewValidProofOutputs:  []types.SiacoinOutput{{Value: types.NewCurrency64(1)}},
+					NewMissedProofOutputs: []types.SiacoinOutput{{Value: types.ZeroCurrency}},
+				}},
+			}},
+		}
+	}
+	height := types.BlockHeight(100)
+	sos := []storageObligation{
+		// The proof window opened long enough ago for the proof to be at
+		// risk.
+		obligation(obligationUnresolved, height-resubmissionTimeout, height+5, false),
+		// The proof was confirmed.
+		obligation(obligationUnresolved, height-resubmissionTimeout, height+5, true),
+		// The proof window didn't open yet.
+		obligation(obligationUnresolved, height+20, height+25, false),
+		obligation(obligationSucceeded, 10, 20, true),
+		obligation(obligationFailed, 10, 20, false),
+		obligation(obligationFailed, 10, 20, false),
+	}
+	summary := buildObligationsSummary(sos, height, 10)
+
+	if summary.BlockHeight != height || summary.Rejected.Count != 0 || summary.Succeeded.Count != 1 || summary.Failed.Count != 2 {
+		t.Fatal("wrong counts", summary)
+	}
+	u := summary.Unresolved
+	if u.Count != 3 || u.DataSize != 300 || !u.LockedCollateral.Equals64(30) || !u.Revenue.Equals64(9) || !u.RiskedCollateral.Equals64(15) {
+		t.Fatal("wrong unresolved totals", u)
+	}
+	if !summary.Failed.Revenue.Equals64(6) || !summary.Failed.RiskedCollateral.Equals64(10) {
+		t.Fatal("wrong failed totals", summary.Failed)
+	}
+
+	// Only the unresolved obligations have deadlines.
+	if summary.AtRisk != 1 || len(summary.Deadlines) != 2 {
+		t.Fatal("wrong deadlines", summary.AtRisk, summary.Deadlines)
+	}
+	first, second := summary.Deadlines[0], summary.Deadlines[1]
+	if first.StartHeight != 100 || first.EndHeight != 109 || first.Obligations != 2 || first.AtRisk != 1 || !first.RiskedCollateral.Equals64(10) {
+		t.Fatal("wrong first window", first)
+	}
+	if second.StartHeight != 120 || second.EndHeight != 129 || second.Obligations != 1 || second.AtRisk != 0 || !second.Revenue.Equals64(3) {
+		t.Fatal("wrong second window", second)
+	}
+}
+
+// TestObligationsSummary checks that the host summarizes its storage
+// obligations.
+func TestObligationsSummary(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	if _, err := ht.host.ObligationsSummary(0); !errors.Contains(err, errInvalidDeadlineWindow) {
+		t.Fatal("expected invalid window", err)
+	}
+	summary, err := ht.host.ObligationsSummary(types.BlocksPerDay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Unresolved.Count != 0 || len(summary.Deadlines) != 0 {
+		t.Fatal("expected no obligations", summary)
+	}
+
+	// Add a storage obligation.
+	so, err := ht.newTesterStorageObligation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host.managedLockStorageObligation(so.id())
+	defer ht.host.managedUnlockStorageObligation(so.id())
+	so.RiskedCollateral = types.SiacoinPrecision
+	if err := ht.host.managedAddStorageObligation(so); err != nil {
+		t.Fatal(err)
+	}
+	summary, err = ht.host.ObligationsSummary(types.BlocksPerDay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Unresolved.Count != 1 || !summary.Unresolved.RiskedCollateral.Equals(types.SiacoinPrecision) || summary.AtRisk != 0 {
+		t.Fatal("wrong summary", summary)
+	}
+	if len(summary.Deadlines) != 1 {
+		t.Fatal("expected 1 deadline window", summary.Deadlines)
+	}
+	if w := summary.Deadlines[0]; w.StartHeight != summary.BlockHeight || w.EndHeight != summary.BlockHeight+types.BlocksPerDay-1 || w.Obligations != 1 || !w.RiskedCollateral.Equals(types.SiacoinPrecision) {
+		t.Fatal("wrong deadlines", summary.Deadlines)
+	}
+}
@@ -2,7 +2,6 @@ package host
 
 import (
 	"fmt"
-	"time"
 
 	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/siamux"
@@ -17,104 +16,73 @@ import (
 // amount paid and an error in case of failure. The account id will only be
 // valid if the payment method is PayByEphemeralAccount, it will be an empty
 // string otherwise.
-func (h *Host) ProcessPayment(stream siamux.Stream, bh types.BlockHeight) (modules.PaymentDetails, string, error) {
-	var out string
-	start := time.Now()
-	out += "ProcessPayment"
-
+func (h *Host) ProcessPayment(stream siamux.Stream, bh types.BlockHeight) (modules.PaymentDetails, error) {
 	// read the PaymentRequest
 	var pr modules.PaymentRequest
 	if err := modules.RPCRead(stream, &pr); err != nil {
-		return nil, out, errors.AddContext(err, "Could not read payment request")
+		return nil, errors.AddContext(err, "Could not read payment request")
 	}
-	out += fmt.Sprintf("read payment req took %v\n", time.Since(start))
-	start = time.Now()
 
 	// process payment depending on the payment method
 	if pr.Type == modules.PayByEphemeralAccount {
-		pd, out2, err := h.staticPayByEphemeralAccount(stream, bh)
-		out += out2
-		return pd, out, err
+		return h.staticPayByEphemeralAccount(stream, bh)
 	}
 	if pr.Type == modules.PayByContract {
-		pd, out2, err := h.managedPayByContract(stream, bh)
-		out += out2
-		return pd, out, err
+		return h.managedPayByContract(stream, bh)
 	}
 
-	return nil, out, errors.Compose(fmt.Errorf("Could not handle payment method %v", pr.Type), modules.ErrUnknownPaymentMethod)
+	return nil, errors.Compose(fmt.Errorf("Could not handle payment method %v", pr.Type), modules.ErrUnknownPaymentMethod)
 }
 
 // staticPayByEphemeralAccount processes a PayByEphemeralAccountRequest coming
 // in over the given stream.
-func (h *Host) staticPayByEphemeralAccount(stream siamux.Stream, bh types.BlockHeight) (modules.PaymentDetails, string, error) {
-	var out string
-	start := time.Now()
-	out += "staticPayByEphemeralAccount"
-
+func (h *Host) staticPayByEphemeralAccount(stream siamux.Stream, bh types.BlockHeight) (modules.PaymentDetails, error) {
 	// read the PayByEphemeralAccountRequest
 	var req modules.PayByEphemeralAccountRequest
 	if err := modules.RPCRead(stream, &req); err != nil {
-		return nil, out, errors.AddContext(err, "Could not read PayByEphemeralAccountRequest")
+		return nil, errors.AddContext(err, "Could not read PayByEphemeralAccountRequest")
 	}
-	out += fmt.Sprintf("read PayByEphemeralAccountRequest req took %v\n", time.Since(start))
-	start = time.Now()
 
 	// process the request
-	if err := h.staticAccountManager.callWithdraw(&req.Message, req.Signature, req.Priority, bh); err != nil {
-		return nil, out, errors.AddContext(err, "Withdraw failed")
+	if err := h.staticAccountManager.callWithdraw(&req.Message, req.Signature, req.Priority, bh, streamOrigin(stream)); err != nil {
+		return nil, errors.AddContext(err, "Withdraw failed")
 	}
-	out += fmt.Sprintf("callWithdraw took %v\n", time.Since(start))
-	start = time.Now()
 
 	// Payment done through EAs don't move collateral
-	return newPaymentDetails(req.Message.Account, req.Message.Amount), out, nil
+	return newPaymentDetails(req.Message.Account, req.Message.Amount), nil
 }
 
 // managedPayByContract processes a PayByContractRequest coming in over the
 // given stream.
-func (h *Host) managedPayByContract(stream siamux.Stream, bh types.BlockHeight) (modules.PaymentDetails, string, error) {
-	var out string
-	start := time.Now()
-	out += "managedPayByContract"
-
+func (h *Host) managedPayByContract(stream siamux.Stream, bh types.BlockHeight) (modules.PaymentDetails, error) {
 	// read the PayByContractRequest
 	var pbcr modules.PayByContractRequest
 	if err := modules.RPCRead(stream, &pbcr); err != nil {
-		return nil, out, errors.AddContext(err, "Could not read PayByContractRequest")
+		return nil, errors.AddContext(err, "Could not read PayByContractRequest")
 	}
 	fcid := pbcr.ContractID
 	accountID := pbcr.RefundAccount
 
-	out += fmt.Sprintf("read PayByContractRequest req took %v\n", time.Since(start))
-	start = time.Now()
-
 	// sanity check accountID. Should always be provided.
 	if accountID.IsZeroAccount() {
-		return nil, out, errors.New("no account id provided for refunds")
+		return nil, errors.New("no account id provided for refunds")
 	}
 
 	// lock the storage obligation
 	h.managedLockStorageObligation(fcid)
 	defer h.managedUnlockStorageObligation(fcid)
 
-	out += fmt.Sprintf("lock SO took %v\n", time.Since(start))
-	start = time.Now()
-
 	// simulate a missing obligation.
 	if h.dependencies.Disrupt("StorageObligationNotFound") {
-		return nil, out, errors.AddContext(errNoStorageObligation, "Could not fetch storage obligation")
+		return nil, errors.AddContext(errNoStorageObligation, "Could not fetch storage obligation")
 	}
 
 	// get the storage obligation
 	so, err := h.managedGetStorageObligation(fcid)
 	if err != nil {
-		return nil, out, errors.AddContext(err, "Could not fetch storage obligation")
+		return nil, errors.AddContext(err, "Could not fetch storage obligation")
 	}
 
-	out += fmt.Sprintf("get SO took %v\n", time.Since(start))
-	start = time.Now()
-
 	// get the current blockheight
 	h.mu.RLock()
 	sk := h.secretKey
@@ -123,29 +91,23 @@ func (h *Host) managedPayByContract(stream siamux.Stream, bh types.BlockHeight)
 	// extract the proposed revision
 	currentRevision, err := so.recentRevision()
 	if err != nil {
-		return nil, out, errors.AddContext(err, "Could not find the most recent revision")
+		return nil, errors.AddContext(err, "Could not find the most recent revision")
 	}
 	paymentRevision := revisionFromRequest(currentRevision, pbcr)
 
 	// verify the payment revision
 	amount, err := verifyPayByContractRevision(currentRevision, paymentRevision, bh)
 	if err != nil {
-		return nil, out, errors.AddContext(err, "Invalid payment revision")
+		return nil, errors.AddContext(err, "Invalid payment revision")
 	}
 
-	out += fmt.Sprintf("verify rev took %v\n", time.Since(start))
-	start = time.Now()
-
 	// sign the revision
 	renterSignature := signatureFromRequest(currentRevision, pbcr)
 	txn, err := createRevisionSignature(paymentRevision, renterSignature, sk, bh)
 	if err != nil {
-		return nil, out, errors.AddContext(err, "Could not create revision signature")
+		return nil, errors.AddContext(err, "Could not create revision signature")
 	}
 
-	out += fmt.Sprintf("sign rev took %v\n", time.Since(start))
-	start = time.Now()
-
 	// extract the payment output & update the storage obligation with the
 	// host's signature
 	so.RevisionTransactionSet = []types.Transaction{{
@@ -156,12 +118,9 @@ func (h *Host) managedPayByContract(stream siamux.Stream, bh types.BlockHeight)
 	// update the storage obligation
 	err = h.managedModifyStorageObligation(so, nil, nil)
 	if err != nil {
-		return nil, out, errors.AddContext(err, "Could not modify storage obligation")
+		return nil, errors.AddContext(err, "Could not modify storage obligation")
 	}
 
-	out += fmt.Sprintf("update SO took %v\n", time.Since(start))
-	start = time.Now()
-
 	// send the response
 	var sig crypto.Signature
 	copy(sig[:], txn.HostSignature().Signature[:])
@@ -169,13 +128,10 @@ func (h *Host) managedPayByContract(stream siamux.Stream, bh types.BlockHeight)
 		Signature: sig,
 	})
 	if err != nil {
-		return nil, out, errors.AddContext(err, "Could not send PayByContractResponse")
+		return nil, errors.AddContext(err, "Could not send PayByContractResponse")
 	}
 
-	out += fmt.Sprintf("send response took %v\n", time.Since(start))
-	start = time.Now()
-
-	return newPaymentDetails(accountID, amount), out, nil
+	return newPaymentDetails(accountID, amount), nil
 }
 
 // managedFundAccount processes a PayByContractRequest coming in over the given
@@ -246,7 +202,7 @@ func (h *Host) managedFundAccount(stream siamux.Stream, request modules.FundAcco
 	// fsynced we'll close this so the account manager can properly lower the
 	// host's outstanding risk induced by the (immediate) deposit.
 	syncChan := make(chan struct{})
-	err = h.staticAccountManager.callDeposit(request.Account, deposit, syncChan)
+	err = h.staticAccountManager.callDeposit(request.Account, deposit, syncChan, streamOrigin(stream))
 	if err != nil {
 		return types.ZeroCurrency, errors.AddContext(err, "Could not deposit funds")
 	}
@@ -390,7 +390,7 @@ func testPayByContract(t *testing.T, pair *renterHostPair) {
 
 	// Manually add money to the refund account.
 	refund := types.NewCurrency64(fastrand.Uint64n(100) + 1)
-	err = pair.staticHT.host.staticAccountManager.callRefund(refundAccount, refund)
+	err = pair.staticHT.host.staticAccountManager.callRefund(refundAccount, refund, "")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -676,9 +676,9 @@ func (s testStream) Read(b []byte) (n int, err error)  { return s.c.Read(b) }
 func (s testStream) Write(b []byte) (n int, err error) { return s.c.Write(b) }
 func (s testStream) Close() error                      { return s.c.Close() }
 
-func (s testStream) LocalAddr() net.Addr            { panic("not implemented") }
+func (s testStream) LocalAddr() net.Addr            { return s.c.LocalAddr() }
 func (s testStream) Mux() *mux.Mux                  { panic("not implemented") }
-func (s testStream) RemoteAddr() net.Addr           { panic("not implemented") }
+func (s testStream) RemoteAddr() net.Addr           { return s.c.RemoteAddr() }
 func (s testStream) SetDeadline(t time.Time) error  { panic("not implemented") }
 func (s testStream) SetPriority(priority int) error { panic("not implemented") }
 
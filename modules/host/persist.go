@@ -30,6 +30,9 @@ type persistence struct {
 	SecretKey        crypto.SecretKey             `json:"secretkey"`
 	Settings         modules.HostInternalSettings `json:"settings"`
 	UnlockHash       types.UnlockHash             `json:"unlockhash"`
+
+	// Dynamic pricing.
+	PricingPolicy modules.HostPricingPolicy `json:"pricingpolicy"`
 }
 
 // persistData returns the data in the Host that will be saved to disk.
@@ -48,6 +51,9 @@ func (h *Host) persistData() persistence {
 		SecretKey:        h.secretKey,
 		Settings:         h.settings,
 		UnlockHash:       h.unlockHash,
+
+		// Dynamic pricing.
+		PricingPolicy: h.pricingPolicy,
 	}
 }
 
@@ -75,6 +81,14 @@ func (h *Host) establishDefaults() error {
 		EphemeralAccountExpiry:     modules.DefaultEphemeralAccountExpiry,
 		MaxEphemeralAccountBalance: modules.DefaultMaxEphemeralAccountBalance,
 		MaxEphemeralAccountRisk:    defaultMaxEphemeralAccountRisk,
+
+		WriteBatchMaxLatency:  defaultWriteBatchMaxLatency,
+		PriceTableGracePeriod: defaultPriceTableGracePeriod,
+		ReadCacheSize:         defaultReadCacheSize,
+
+		MaxProgramInstructions: defaultMaxProgramInstructions,
+		MaxProgramDataLength:   defaultMaxProgramDataLength,
+		MaxProgramDuration:     defaultMaxProgramDuration,
 	}
 
 	// Load the host's key pair, use the same keys as the SiaMux.
@@ -121,6 +135,7 @@ func (h *Host) loadPersistObject(p *persistence) {
 		h.settings.NetAddress = ""
 	}
 	h.unlockHash = p.UnlockHash
+	h.pricingPolicy = p.PricingPolicy
 }
 
 // initDB will check that the database has been initialized and if not, will
@@ -142,7 +157,12 @@ func (h *Host) initDB() (err error) {
 		// The storage obligation bucket does not exist, which means the
 		// database needs to be initialized. Create the database buckets.
 		buckets := [][]byte{
+			bucketAccountTransactions,
 			bucketActionItems,
+			bucketBandwidth,
+			bucketMissedProofs,
+			bucketProofFees,
+			bucketRegistrySubscriptionSets,
 			bucketStorageObligations,
 		}
 		for _, bucket := range buckets {
@@ -169,6 +189,13 @@ func (h *Host) load() error {
 	// the most recent version, but older versions need to be updated to the
 	// more recent structures.
 	p := new(persistence)
+	// Hosts which persisted their settings before the read cache size was
+	// configurable use the default.
+	p.Settings.ReadCacheSize = defaultReadCacheSize
+	// The same is true for the program limits.
+	p.Settings.MaxProgramInstructions = defaultMaxProgramInstructions
+	p.Settings.MaxProgramDataLength = defaultMaxProgramDataLength
+	p.Settings.MaxProgramDuration = defaultMaxProgramDuration
 	err = h.dependencies.LoadFile(modules.Hostv151PersistMetadata, p, filepath.Join(h.persistDir, settingsFile))
 	if err == nil {
 		// Copy in the persistence.
@@ -261,3 +288,39 @@ func (h *Host) load() error {
 func (h *Host) saveSync() error {
 	return persist.SaveJSON(modules.Hostv151PersistMetadata, h.persistData(), filepath.Join(h.persistDir, settingsFile))
 }
+
+// Quiesce implements the modules.Quiescer interface. It blocks writes to the
+// host's database, the WAL of its storage manager, the registry and the
+// ephemeral accounts until resume is called. The host's settings are always
+// saved atomically and therefore aren't blocked.
+func (h *Host) Quiesce() (resume func(), err error) {
+	if err := h.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer h.tg.Done()
+
+	// Quiesce the persisted state of the host one part at a time. If one of
+	// them fails, the already quiesced parts are resumed.
+	quiescers := map[string]func() (func(), error){
+		"registry":           h.staticRegistry.Quiesce,
+		"ephemeral accounts": h.staticAccountManager.staticAccountsPersister.callQuiesce,
+	}
+	if sm, ok := h.StorageManager.(modules.Quiescer); ok {
+		quiescers["storage manager"] = sm.Quiesce
+	}
+	resumeFuncs := []func(){h.db.Quiesce()}
+	resume = func() {
+		for i := len(resumeFuncs) - 1; i >= 0; i-- {
+			resumeFuncs[i]()
+		}
+	}
+	for name, quiesce := range quiescers {
+		r, err := quiesce()
+		if err != nil {
+			resume()
+			return nil, errors.AddContext(err, "failed to quiesce "+name)
+		}
+		resumeFuncs = append(resumeFuncs, r)
+	}
+	return resume, nil
+}
@@ -0,0 +1,288 @@
+package host
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// pricingUpdateInterval is the interval at which the host refreshes the
+	// exchange rate of its pricing policy and updates its price table.
+	pricingUpdateInterval = build.Select(build.Var{
+		Standard: 10 * time.Minute,
+		Dev:      time.Minute,
+		Testing:  3 * time.Second,
+	}).(time.Duration)
+
+	// errNoExchangeRateFeed is returned when the pricing policy pegs prices
+	// to a fiat currency but the host has no exchange rate feed.
+	errNoExchangeRateFeed = errors.New("no exchange rate feed configured")
+)
+
+type (
+	// hostPricing contains the state of the host's dynamic pricing which
+	// isn't persisted.
+	hostPricing struct {
+		feed          modules.ExchangeRateFeed
+		exchangeRate  float64
+		lastUpdate    time.Time
+		recentErr     error
+		recentErrTime time.Time
+	}
+
+	// dynamicPrices are the prices the host derives from its internal
+	// settings and its pricing policy.
+	dynamicPrices struct {
+		storage       types.Currency
+		upload        types.Currency
+		download      types.Currency
+		maxCollateral types.Currency
+	}
+)
+
+// checkPricingPolicy returns an error if the pricing policy is invalid.
+func checkPricingPolicy(policy modules.HostPricingPolicy) error {
+	validFraction := func(f float64) bool {
+		return !math.IsNaN(f) && f >= 0 && f <= 1
+	}
+	for _, rule := range policy.UtilizationRules {
+		if !validFraction(rule.Utilization) {
+			return fmt.Errorf("utilization %v must be between 0 and 1", rule.Utilization)
+		}
+		if math.IsNaN(rule.StoragePriceMultiplier) || math.IsInf(rule.StoragePriceMultiplier, 0) || rule.StoragePriceMultiplier <= 0 {
+			return fmt.Errorf("storage price multiplier %v must be positive", rule.StoragePriceMultiplier)
+		}
+	}
+	for _, price := range []float64{policy.FiatStoragePrice, policy.FiatUploadPrice, policy.FiatDownloadPrice} {
+		if math.IsNaN(price) || math.IsInf(price, 0) || price < 0 {
+			return fmt.Errorf("fiat price %v must not be negative", price)
+		}
+		if price > 0 && policy.FiatCurrency == "" {
+			return errors.New("fiat prices require a fiat currency")
+		}
+	}
+	if !validFraction(policy.MaxCollateralBudgetUtilization) {
+		return fmt.Errorf("max collateral budget utilization %v must be between 0 and 1", policy.MaxCollateralBudgetUtilization)
+	}
+	return nil
+}
+
+// fiatToHastings converts a fiat amount to hastings using the price of one
+// siacoin in fiat.
+func fiatToHastings(amount, siacoinPrice float64) types.Currency {
+	sc := new(big.Rat).SetFloat64(amount / siacoinPrice)
+	if sc == nil {
+		return types.ZeroCurrency
+	}
+	return types.SiacoinPrecision.MulRat(sc)
+}
+
+// fraction returns x/y as a float64 or 0 if y is zero.
+func fraction(x, y types.Currency) float64 {
+	if y.IsZero() {
+		return 0
+	}
+	f, _ := new(big.Rat).SetFrac(x.Big(), y.Big()).Float64()
+	return f
+}
+
+// storageUtilization returns the fraction of the host's storage which is in
+// use.
+func storageUtilization(total, remaining uint64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(total-remaining) / float64(total)
+}
+
+// dynamicPrices applies the host's pricing policy to its internal settings.
+// The maxCollateral is the max collateral the host can offer based on its
+// wallet balance and collateral budget.
+func (h *Host) dynamicPrices(total, remaining uint64, maxCollateral types.Currency) dynamicPrices {
+	prices := dynamicPrices{
+		storage:       h.settings.MinStoragePrice,
+		upload:        h.settings.MinUploadBandwidthPrice,
+		download:      h.settings.MinDownloadBandwidthPrice,
+		maxCollateral: maxCollateral,
+	}
+	policy := h.pricingPolicy
+	if !policy.Enabled {
+		return prices
+	}
+
+	// Peg the prices to the fiat currency. If the exchange rate is unknown,
+	// the prices of the internal settings are used.
+	if rate := h.pricing.exchangeRate; policy.FiatCurrency != "" && rate > 0 {
+		if policy.FiatStoragePrice > 0 {
+			prices.storage = fiatToHastings(policy.FiatStoragePrice, rate).Div(modules.BlockBytesPerMonthTerabyte)
+		}
+		if policy.FiatUploadPrice > 0 {
+			prices.upload = fiatToHastings(policy.FiatUploadPrice, rate).Div(modules.BytesPerTerabyte)
+		}
+		if policy.FiatDownloadPrice > 0 {
+			prices.download = fiatToHastings(policy.FiatDownloadPrice, rate).Div(modules.BytesPerTerabyte)
+		}
+	}
+
+	// Apply the storage price multiplier of the rule with the highest crossed
+	// utilization threshold.
+	utilization := storageUtilization(total, remaining)
+	threshold, multiplier := -1.0, 1.0
+	for _, rule := range policy.UtilizationRules {
+		if utilization >= rule.Utilization && rule.Utilization > threshold {
+			threshold, multiplier = rule.Utilization, rule.StoragePriceMultiplier
+		}
+	}
+	prices.storage = prices.storage.MulRat(new(big.Rat).SetFloat64(multiplier))
+
+	// Cap the collateral the host offers to not exceed the configured
+	// fraction of its collateral budget.
+	if policy.MaxCollateralBudgetUtilization > 0 {
+		budgetCap := h.settings.CollateralBudget.MulRat(new(big.Rat).SetFloat64(policy.MaxCollateralBudgetUtilization))
+		locked := h.financialMetrics.LockedStorageCollateral
+		if locked.Cmp(budgetCap) >= 0 {
+			prices.maxCollateral = types.ZeroCurrency
+		} else if locked.Add(prices.maxCollateral).Cmp(budgetCap) > 0 {
+			prices.maxCollateral = budgetCap.Sub(locked)
+		}
+	}
+	return prices
+}
+
+// managedUpdatePricing refreshes the exchange rate of the host's pricing
+// policy and updates the host's price table to reflect the current prices.
+func (h *Host) managedUpdatePricing() {
+	h.mu.RLock()
+	policy := h.pricingPolicy
+	feed := h.pricing.feed
+	h.mu.RUnlock()
+
+	var rate float64
+	var err error
+	if policy.Enabled && policy.FiatCurrency != "" {
+		if feed == nil {
+			err = errNoExchangeRateFeed
+		} else if rate, err = feed.SiacoinPrice(policy.FiatCurrency); err == nil && (math.IsNaN(rate) || math.IsInf(rate, 0) || rate <= 0) {
+			err = fmt.Errorf("invalid exchange rate %v", rate)
+		}
+	}
+
+	h.mu.Lock()
+	if policy.FiatCurrency != h.pricingPolicy.FiatCurrency {
+		// The policy was changed in the meantime.
+		h.mu.Unlock()
+		return
+	}
+	if err != nil {
+		h.log.Printf("WARN: failed to update exchange rate for %v: %v", policy.FiatCurrency, err)
+		h.pricing.recentErr = err
+		h.pricing.recentErrTime = time.Now()
+	} else {
+		h.pricing.exchangeRate = rate
+	}
+	h.pricing.lastUpdate = time.Now()
+	h.mu.Unlock()
+
+	h.managedUpdatePriceTable()
+}
+
+// threadedUpdatePricing periodically updates the host's prices according to
+// its pricing policy.
+//
+// Note: threadgroup counter must be inside for loop. If not, calling 'Flush'
+// on the threadgroup would deadlock.
+func (h *Host) threadedUpdatePricing() {
+	defer modules.RecoverPanic("host")
+	for {
+		select {
+		case <-h.tg.StopChan():
+			return
+		case <-time.After(pricingUpdateInterval):
+		}
+
+		func() {
+			if err := h.tg.Add(); err != nil {
+				return
+			}
+			defer h.tg.Done()
+			h.managedUpdatePricing()
+		}()
+	}
+}
+
+// PricingStatus returns the host's pricing policy and the prices it derived
+// from it.
+func (h *Host) PricingStatus() modules.HostPricingStatus {
+	es := h.managedExternalSettings()
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	var recentErrStr string
+	if h.pricing.recentErr != nil {
+		recentErrStr = h.pricing.recentErr.Error()
+	}
+	return modules.HostPricingStatus{
+		Policy: h.pricingPolicy,
+
+		LastUpdate:                  h.pricing.lastUpdate,
+		ExchangeRate:                h.pricing.exchangeRate,
+		StorageUtilization:          storageUtilization(es.TotalStorage, es.RemainingStorage),
+		CollateralBudgetUtilization: fraction(h.financialMetrics.LockedStorageCollateral, h.settings.CollateralBudget),
+
+		StoragePrice:           es.StoragePrice,
+		UploadBandwidthPrice:   es.UploadBandwidthPrice,
+		DownloadBandwidthPrice: es.DownloadBandwidthPrice,
+		MaxCollateral:          es.MaxCollateral,
+
+		RecentErr:     recentErrStr,
+		RecentErrTime: h.pricing.recentErrTime,
+	}
+}
+
+// SetExchangeRateFeed sets the feed the host uses to fetch the exchange rate
+// of the fiat currency its pricing policy pegs its prices to.
+func (h *Host) SetExchangeRateFeed(feed modules.ExchangeRateFeed) {
+	h.mu.Lock()
+	h.pricing.feed = feed
+	h.mu.Unlock()
+	h.managedUpdatePricing()
+}
+
+// SetPricingPolicy sets the policy the host uses to update its prices
+// automatically.
+func (h *Host) SetPricingPolicy(policy modules.HostPricingPolicy) error {
+	err := h.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer h.tg.Done()
+
+	if err := checkPricingPolicy(policy); err != nil {
+		return errors.AddContext(err, "invalid pricing policy")
+	}
+
+	h.mu.Lock()
+	if policy.FiatCurrency != h.pricingPolicy.FiatCurrency {
+		h.pricing.exchangeRate = 0
+	}
+	h.pricingPolicy = policy
+	h.pricing.recentErr = nil
+	h.pricing.recentErrTime = time.Time{}
+	err = h.saveSync()
+	h.mu.Unlock()
+	if err != nil {
+		return errors.AddContext(err, "pricing policy updated, but failed saving to disk")
+	}
+
+	// Apply the new policy right away.
+	h.managedUpdatePricing()
+	return nil
+}
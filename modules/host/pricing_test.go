@@ -0,0 +1,159 @@
+package host
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// testExchangeRateFeed is an exchange rate feed with a fixed rate.
+type testExchangeRateFeed struct {
+	rate float64
+	err  error
+}
+
+// SiacoinPrice implements modules.ExchangeRateFeed.
+func (f *testExchangeRateFeed) SiacoinPrice(string) (float64, error) {
+	return f.rate, f.err
+}
+
+// TestPricingPolicy tests that the host derives its prices from its pricing
+// policy.
+func TestPricingPolicy(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	h := ht.host
+	is := h.InternalSettings()
+
+	// Invalid policies are rejected.
+	invalid := []modules.HostPricingPolicy{
+		{UtilizationRules: []modules.HostPricingUtilizationRule{{Utilization: 1.5, StoragePriceMultiplier: 2}}},
+		{UtilizationRules: []modules.HostPricingUtilizationRule{{Utilization: 0.5, StoragePriceMultiplier: 0}}},
+		{FiatStoragePrice: 1},
+		{FiatCurrency: "usd", FiatUploadPrice: -1},
+		{MaxCollateralBudgetUtilization: 2},
+	}
+	for _, policy := range invalid {
+		if err := h.SetPricingPolicy(policy); err == nil {
+			t.Fatal("invalid policy should be rejected", policy)
+		}
+	}
+
+	// A disabled policy doesn't change the prices.
+	policy := modules.HostPricingPolicy{
+		UtilizationRules: []modules.HostPricingUtilizationRule{
+			{Utilization: 0, StoragePriceMultiplier: 2},
+			{Utilization: 0.5, StoragePriceMultiplier: 4},
+		},
+	}
+	if err := h.SetPricingPolicy(policy); err != nil {
+		t.Fatal(err)
+	}
+	if es := h.ExternalSettings(); !es.StoragePrice.Equals(is.MinStoragePrice) {
+		t.Fatal("disabled policy shouldn't change the storage price", es.StoragePrice, is.MinStoragePrice)
+	}
+
+	// The host has no stored data. Only the first rule applies.
+	policy.Enabled = true
+	if err := h.SetPricingPolicy(policy); err != nil {
+		t.Fatal(err)
+	}
+	expected := is.MinStoragePrice.Mul64(2)
+	if es := h.ExternalSettings(); !es.StoragePrice.Equals(expected) {
+		t.Fatal("wrong storage price", es.StoragePrice, expected)
+	}
+	if pt := h.PriceTable(); !pt.WriteStoreCost.Equals(expected) {
+		t.Fatal("price table wasn't updated", pt.WriteStoreCost, expected)
+	}
+
+	// Peg the prices to a fiat currency without a feed. The prices of the
+	// internal settings are used and the error is reported.
+	policy.FiatCurrency = "usd"
+	policy.FiatStoragePrice = 2
+	policy.FiatDownloadPrice = 10
+	if err := h.SetPricingPolicy(policy); err != nil {
+		t.Fatal(err)
+	}
+	ps := h.PricingStatus()
+	if ps.RecentErr != errNoExchangeRateFeed.Error() || !ps.StoragePrice.Equals(expected) {
+		t.Fatal("expected errNoExchangeRateFeed", ps)
+	}
+
+	// Add a feed. 1 SC is worth 0.01 usd.
+	h.SetExchangeRateFeed(&testExchangeRateFeed{rate: 0.01})
+	expected = types.SiacoinPrecision.Mul64(200).Div(modules.BlockBytesPerMonthTerabyte).Mul64(2)
+	expectedDownload := types.SiacoinPrecision.Mul64(1000).Div(modules.BytesPerTerabyte)
+	ps = h.PricingStatus()
+	if ps.ExchangeRate != 0.01 {
+		t.Fatal("wrong exchange rate", ps.ExchangeRate)
+	}
+	if !ps.StoragePrice.Equals(expected) || !ps.DownloadBandwidthPrice.Equals(expectedDownload) {
+		t.Fatal("wrong fiat prices", ps)
+	}
+	if !ps.UploadBandwidthPrice.Equals(is.MinUploadBandwidthPrice) {
+		t.Fatal("upload price shouldn't be pegged", ps)
+	}
+	if pt := h.PriceTable(); !pt.WriteStoreCost.Equals(expected) || !pt.DownloadBandwidthCost.Equals(expectedDownload) {
+		t.Fatal("price table wasn't updated", pt)
+	}
+
+	// A failing feed keeps the last known rate.
+	feedErr := errors.New("feed unavailable")
+	h.SetExchangeRateFeed(&testExchangeRateFeed{err: feedErr})
+	ps = h.PricingStatus()
+	if ps.ExchangeRate != 0.01 || ps.RecentErr != feedErr.Error() {
+		t.Fatal("last known rate should be used", ps)
+	}
+
+	// Cap the collateral budget utilization. Lock half the budget. With a
+	// cap of 60%, only 10% of the budget can be offered as collateral.
+	h.mu.Lock()
+	h.financialMetrics.LockedStorageCollateral = is.CollateralBudget.Div64(2)
+	h.mu.Unlock()
+	policy.MaxCollateralBudgetUtilization = 0.6
+	if err := h.SetPricingPolicy(policy); err != nil {
+		t.Fatal(err)
+	}
+	ps = h.PricingStatus()
+	if ps.CollateralBudgetUtilization != 0.5 {
+		t.Fatal("wrong collateral budget utilization", ps.CollateralBudgetUtilization)
+	}
+	if ps.MaxCollateral.Cmp(is.CollateralBudget.Div64(10)) > 0 {
+		t.Fatal("max collateral should be capped", ps.MaxCollateral)
+	}
+	policy.MaxCollateralBudgetUtilization = 0.4
+	if err := h.SetPricingPolicy(policy); err != nil {
+		t.Fatal(err)
+	}
+	if ps = h.PricingStatus(); !ps.MaxCollateral.IsZero() {
+		t.Fatal("max collateral should be zero", ps.MaxCollateral)
+	}
+
+	// The policy is persisted.
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	ht.host, err = New(ht.cs, ht.gateway, ht.tpool, ht.wallet, ht.mux, "localhost:0", filepath.Join(ht.persistDir, modules.HostDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ps = ht.host.PricingStatus()
+	if !ps.Policy.Enabled || len(ps.Policy.UtilizationRules) != 2 || ps.Policy.FiatCurrency != "usd" || ps.Policy.MaxCollateralBudgetUtilization != 0.4 {
+		t.Fatal("policy wasn't persisted", ps.Policy)
+	}
+}
@@ -0,0 +1,35 @@
+package host
+
+import (
+	"time"
+
+	"go.sia.tech/siad/modules"
+)
+
+const (
+	// defaultMaxProgramInstructions is the default for the max number of
+	// instructions of a program the host executes.
+	defaultMaxProgramInstructions = 1 << 14
+
+	// defaultMaxProgramDuration is the default for the max amount of time the
+	// host spends executing a single program.
+	defaultMaxProgramDuration = 10 * time.Minute
+)
+
+var (
+	// defaultMaxProgramDataLength is the default for the max length of the
+	// data of a program the host executes.
+	defaultMaxProgramDataLength = 64 * modules.SectorSize // 256 MiB
+)
+
+// ProgramLimits returns the limits the host enforces on the MDM programs it
+// executes.
+func (h *Host) ProgramLimits() modules.MDMProgramLimits {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return modules.MDMProgramLimits{
+		MaxInstructions: h.settings.MaxProgramInstructions,
+		MaxDataLength:   h.settings.MaxProgramDataLength,
+		MaxDuration:     h.settings.MaxProgramDuration,
+	}
+}
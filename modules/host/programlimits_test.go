@@ -0,0 +1,52 @@
+package host
+
+import (
+	"testing"
+	"time"
+)
+
+// TestProgramLimits tests that the host's program limits are advertised in the
+// external settings and passed to the MDM.
+func TestProgramLimits(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	h := ht.host
+
+	settings := h.InternalSettings()
+	if settings.MaxProgramInstructions != defaultMaxProgramInstructions || settings.MaxProgramDataLength != defaultMaxProgramDataLength || settings.MaxProgramDuration != defaultMaxProgramDuration {
+		t.Fatal("wrong defaults", settings.MaxProgramInstructions, settings.MaxProgramDataLength, settings.MaxProgramDuration)
+	}
+
+	// A negative duration is rejected.
+	settings.MaxProgramDuration = -time.Second
+	if err := h.SetInternalSettings(settings); err == nil {
+		t.Fatal("expected negative duration to be rejected")
+	}
+
+	// Update the limits.
+	settings.MaxProgramInstructions = 10
+	settings.MaxProgramDataLength = 1 << 20
+	settings.MaxProgramDuration = time.Minute
+	if err := h.SetInternalSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	es := h.ExternalSettings()
+	if es.MaxProgramInstructions != 10 || es.MaxProgramDataLength != 1<<20 || es.MaxProgramDuration != time.Minute {
+		t.Fatal("limits weren't advertised", es.MaxProgramInstructions, es.MaxProgramDataLength, es.MaxProgramDuration)
+	}
+	limits := h.ProgramLimits()
+	if limits.MaxInstructions != 10 || limits.MaxDataLength != 1<<20 || limits.MaxDuration != time.Minute {
+		t.Fatal("wrong program limits", limits)
+	}
+}
@@ -0,0 +1,365 @@
+package host
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// maxProofFeeMultiplier is the highest ProofFeeMultiplier a host can set.
+	maxProofFeeMultiplier = 10
+
+	// maxProofFeePeriods is the maximum number of periods a proof fee report
+	// can contain.
+	maxProofFeePeriods = 1000
+)
+
+var (
+	// errInvalidProofFeeRange is returned if the end of a proof fee report is
+	// before its start.
+	errInvalidProofFeeRange = errors.New("end height of the report is before its start height")
+
+	// errInvalidProofFeePeriod is returned if the period of a proof fee report
+	// is 0 or results in too many periods.
+	errInvalidProofFeePeriod = fmt.Errorf("period needs to be greater than 0 and the report can't contain more than %v periods", maxProofFeePeriods)
+)
+
+// storageProofBatch collects the storage proofs built while handling the
+// action items of a consensus change, so that they can be submitted in a
+// single transaction. The fee of the batch is escalated according to the
+// highest attempt of its proofs.
+type storageProofBatch struct {
+	attempt uint64
+	proofs  []types.StorageProof
+	mu      sync.Mutex
+}
+
+// managedAdd adds a storage proof to the batch unless the batch already
+// contains a proof for the same contract.
+func (b *storageProofBatch) managedAdd(sp types.StorageProof, attempt uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, proof := range b.proofs {
+		if proof.ParentID == sp.ParentID {
+			return
+		}
+	}
+	b.proofs = append(b.proofs, sp)
+	if attempt > b.attempt {
+		b.attempt = attempt
+	}
+}
+
+// verifyProofFeeSettings checks that the proof fee settings of the host are
+// valid.
+func verifyProofFeeSettings(settings modules.HostInternalSettings) error {
+	m := settings.ProofFeeMultiplier
+	if math.IsNaN(m) || m < 0 || m > maxProofFeeMultiplier {
+		return fmt.Errorf("ProofFeeMultiplier needs to be between 0 and %v", maxProofFeeMultiplier)
+	}
+	return nil
+}
+
+// proofFeeKey returns the database key of a proof fee transaction.
+func proofFeeKey(height types.BlockHeight, txnID types.TransactionID) []byte {
+	key := make([]byte, 8+len(txnID))
+	binary.BigEndian.PutUint64(key, uint64(height))
+	copy(key[8:], txnID[:])
+	return key
+}
+
+// putProofFeeTransaction places a proof fee transaction into the database.
+func putProofFeeTransaction(tx *bolt.Tx, pft modules.HostProofFeeTransaction) error {
+	pftBytes, err := json.Marshal(pft)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(bucketProofFees).Put(proofFeeKey(pft.Height, pft.TransactionID), pftBytes)
+}
+
+// proofFeeEscalation returns the factor by which the fee of a storage proof is
+// multiplied on the provided attempt. The fee doubles with every resubmission
+// up to maxProofFeeEscalations times.
+func proofFeeEscalation(attempt uint64) uint64 {
+	if attempt > maxProofFeeEscalations {
+		attempt = maxProofFeeEscalations
+	}
+	return 1 << attempt
+}
+
+// managedProofTransactionFee returns the fee of a storage proof or final
+// revision transaction of the provided size, taking the proof fee settings of
+// the host and the number of previous submission attempts into account.
+func (h *Host) managedProofTransactionFee(txnSize, attempt uint64) types.Currency {
+	h.mu.RLock()
+	multiplier := h.settings.ProofFeeMultiplier
+	maxFee := h.settings.MaxProofFee
+	h.mu.RUnlock()
+
+	_, feeRecommendation := h.tpool.FeeEstimation()
+	fee := feeRecommendation.Mul64(txnSize).Mul64(proofFeeEscalation(attempt))
+	if multiplier != 0 {
+		fee = fee.MulFloat(multiplier)
+	}
+	if !maxFee.IsZero() && fee.Cmp(maxFee) > 0 {
+		fee = maxFee
+	}
+	return fee
+}
+
+// managedRecordProofFee records the fee of a storage proof or final revision
+// transaction the host submitted.
+func (h *Host) managedRecordProofFee(pft modules.HostProofFeeTransaction) {
+	h.mu.RLock()
+	pft.Height = h.blockHeight
+	h.mu.RUnlock()
+	err := h.db.Update(func(tx *bolt.Tx) error {
+		return putProofFeeTransaction(tx, pft)
+	})
+	if err != nil {
+		h.log.Println("WARN: failed to record proof fee:", err)
+	}
+}
+
+// managedSubmitStorageProofs submits a transaction containing the provided
+// storage proofs to the transaction pool and returns its id and fee. If the
+// proofs were submitted before, the fee is escalated according to the attempt
+// and the transaction replaces the previous one if it is still in the pool. If
+// the submission fails, the probable cause of a missed proof is returned as
+// well.
+func (h *Host) managedSubmitStorageProofs(sps []types.StorageProof, attempt uint64) (types.TransactionID, types.Currency, modules.MissedProofCause, error) {
+	builder, err := h.wallet.StartTransaction()
+	if err != nil {
+		return types.TransactionID{}, types.ZeroCurrency, modules.MissedProofCauseInsufficientFunds, errors.AddContext(err, "failed to start storage proof transaction")
+	}
+	txnSize := uint64(txnFeeSizeBuffer)
+	for _, sp := range sps {
+		txnSize += uint64(len(encoding.Marshal(sp)))
+	}
+	fee := h.managedProofTransactionFee(txnSize, attempt)
+	err = builder.FundSiacoins(fee)
+	if err != nil {
+		builder.Drop()
+		return types.TransactionID{}, types.ZeroCurrency, modules.MissedProofCauseInsufficientFunds, errors.AddContext(err, "failed to fund storage proof transaction fee")
+	}
+	builder.AddMinerFee(fee)
+	for _, sp := range sps {
+		builder.AddStorageProof(sp)
+	}
+	storageProofSet, err := builder.Sign(true)
+	if err != nil {
+		builder.Drop()
+		return types.TransactionID{}, types.ZeroCurrency, modules.MissedProofCauseTransactionRejected, errors.AddContext(err, "failed to sign storage proof transaction")
+	}
+	err = h.tpool.AcceptTransactionSet(storageProofSet)
+	if err != nil && attempt > 0 {
+		// The previous proof transaction might still be stuck in the pool,
+		// try to replace it with the one paying the higher fee.
+		if replaceErr := h.tpool.ReplaceTransactionSet(storageProofSet); replaceErr != nil {
+			err = errors.Compose(err, replaceErr)
+		} else {
+			err = nil
+		}
+	}
+	if err != nil {
+		builder.Drop()
+		return types.TransactionID{}, types.ZeroCurrency, modules.MissedProofCauseTransactionRejected, errors.AddContext(err, "failed to submit storage proof transaction to transaction pool")
+	}
+
+	// Record the fee.
+	txnID := storageProofSet[len(storageProofSet)-1].ID()
+	ids := make([]types.FileContractID, 0, len(sps))
+	for _, sp := range sps {
+		ids = append(ids, sp.ParentID)
+	}
+	h.managedRecordProofFee(modules.HostProofFeeTransaction{
+		Fee:           fee,
+		ObligationIDs: ids,
+		TransactionID: txnID,
+		Type:          modules.ProofFeeTypeStorageProof,
+	})
+	return txnID, fee, "", nil
+}
+
+// managedSubmitStorageProofBatch submits the proofs of a batch in a single
+// transaction and splits its fee between the obligations proportionally to
+// the size of their proofs. If the transaction is rejected, the proofs are
+// submitted one by one so that a single invalid proof doesn't cause the host
+// to miss all the others.
+func (h *Host) managedSubmitStorageProofBatch(sps []types.StorageProof, attempt uint64) {
+	if len(sps) > 1 {
+		txnID, fee, _, err := h.managedSubmitStorageProofs(sps, attempt)
+		if err == nil {
+			var totalSize uint64
+			sizes := make([]uint64, len(sps))
+			for i, sp := range sps {
+				sizes[i] = uint64(len(encoding.Marshal(sp)))
+				totalSize += sizes[i]
+			}
+			remaining := fee
+			for i, sp := range sps {
+				share := remaining
+				if i < len(sps)-1 {
+					share = fee.Mul64(sizes[i]).Div64(totalSize)
+				}
+				remaining = remaining.Sub(share)
+				h.managedUpdateObligation(sp.ParentID, func(so *storageObligation) {
+					so.ProofTransactionID = txnID
+					so.TransactionFeesAdded = so.TransactionFeesAdded.Add(share)
+				})
+			}
+			return
+		}
+		h.log.Printf("Failed to submit batch of %v storage proofs, submitting them individually: %v", len(sps), err)
+	}
+	for _, sp := range sps {
+		txnID, fee, cause, err := h.managedSubmitStorageProofs([]types.StorageProof{sp}, attempt)
+		if err != nil {
+			h.log.Printf("contract %s action: %s", sp.ParentID, err)
+		}
+		var prevTxnID types.TransactionID
+		h.managedUpdateObligation(sp.ParentID, func(so *storageObligation) {
+			if err != nil {
+				so.ProofFailureCause = cause
+				so.ProofFailureError = err.Error()
+				prevTxnID = so.ProofTransactionID
+				return
+			}
+			so.ProofTransactionID = txnID
+			so.TransactionFeesAdded = so.TransactionFeesAdded.Add(fee)
+		})
+		if err != nil {
+			h.managedRebroadcastProof(sp.ParentID, prevTxnID)
+		}
+	}
+}
+
+// managedUpdateObligation locks a storage obligation, applies the update to
+// it and writes it back to the database.
+func (h *Host) managedUpdateObligation(soid types.FileContractID, update func(*storageObligation)) {
+	h.managedLockStorageObligation(soid)
+	defer h.managedUnlockStorageObligation(soid)
+	err := h.db.Update(func(tx *bolt.Tx) error {
+		so, err := h.getStorageObligation(tx, soid)
+		if err != nil {
+			return err
+		}
+		update(&so)
+		return putStorageObligation(tx, so)
+	})
+	if err != nil {
+		h.log.Printf("contract %s action: Error updating storage obligation: %s", soid, err)
+	}
+}
+
+// threadedHandleActionItems handles the action items of a consensus change.
+// If the host batches its storage proofs, the proofs built for the action
+// items are submitted in a single transaction once all of them are handled.
+func (h *Host) threadedHandleActionItems(soids []types.FileContractID) {
+	defer modules.RecoverPanic("host")
+	err := h.tg.Add()
+	if err != nil {
+		return
+	}
+	defer h.tg.Done()
+
+	h.mu.RLock()
+	batching := h.settings.BatchStorageProofs
+	h.mu.RUnlock()
+	var batch *storageProofBatch
+	if batching {
+		batch = new(storageProofBatch)
+	}
+
+	var wg sync.WaitGroup
+	for _, soid := range soids {
+		wg.Add(1)
+		go func(soid types.FileContractID) {
+			defer wg.Done()
+			h.managedHandleActionItem(soid, batch)
+		}(soid)
+	}
+	wg.Wait()
+	if batch != nil {
+		h.managedSubmitStorageProofBatch(batch.proofs, batch.attempt)
+	}
+}
+
+// ProofFees returns the fees the host spent on storage proof and final
+// revision transactions submitted between start and end, both inclusive,
+// grouped into periods of the provided number of blocks. The end is capped at
+// the host's current block height.
+func (h *Host) ProofFees(start, end, period types.BlockHeight) (modules.HostProofFeeReport, error) {
+	if err := h.tg.Add(); err != nil {
+		return modules.HostProofFeeReport{}, err
+	}
+	defer h.tg.Done()
+	if end < start {
+		return modules.HostProofFeeReport{}, errInvalidProofFeeRange
+	}
+	h.mu.RLock()
+	if h.blockHeight >= start && end > h.blockHeight {
+		end = h.blockHeight
+	}
+	h.mu.RUnlock()
+	if period == 0 || (end-start)/period >= maxProofFeePeriods {
+		return modules.HostProofFeeReport{}, errInvalidProofFeePeriod
+	}
+
+	// Create the periods.
+	numPeriods := (end-start)/period + 1
+	report := modules.HostProofFeeReport{
+		Periods: make([]modules.HostProofFeePeriod, 0, numPeriods),
+	}
+	for i := types.BlockHeight(0); i < numPeriods; i++ {
+		p := modules.HostProofFeePeriod{
+			StartHeight: start + i*period,
+			EndHeight:   start + (i+1)*period - 1,
+		}
+		if p.EndHeight > end || p.EndHeight < p.StartHeight {
+			p.EndHeight = end
+		}
+		report.Periods = append(report.Periods, p)
+	}
+
+	// Add the transactions to their periods.
+	err := h.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketProofFees).Cursor()
+		for k, v := c.Seek(proofFeeKey(start, types.TransactionID{})); k != nil; k, v = c.Next() {
+			var pft modules.HostProofFeeTransaction
+			if err := json.Unmarshal(v, &pft); err != nil {
+				return errors.AddContext(err, "unable to unmarshal proof fee transaction")
+			}
+			if pft.Height > end {
+				break
+			}
+			p := &report.Periods[(pft.Height-start)/period]
+			switch pft.Type {
+			case modules.ProofFeeTypeStorageProof:
+				p.ProofFees = p.ProofFees.Add(pft.Fee)
+				p.ProofTransactions++
+				p.StorageProofs += uint64(len(pft.ObligationIDs))
+				report.TotalProofFees = report.TotalProofFees.Add(pft.Fee)
+			case modules.ProofFeeTypeRevision:
+				p.RevisionFees = p.RevisionFees.Add(pft.Fee)
+				p.RevisionTransactions++
+				report.TotalRevisionFees = report.TotalRevisionFees.Add(pft.Fee)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return modules.HostProofFeeReport{}, err
+	}
+	return report, nil
+}
@@ -0,0 +1,275 @@
+package host
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestProofFeeSettings tests that the proof fee settings are validated and
+// applied to the fee of proof transactions.
+func TestProofFeeSettings(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	h := ht.host
+
+	// Invalid multipliers are rejected.
+	settings := h.InternalSettings()
+	for _, m := range []float64{-1, maxProofFeeMultiplier + 1} {
+		settings.ProofFeeMultiplier = m
+		if err := h.SetInternalSettings(settings); err == nil {
+			t.Fatal("expected multiplier to be rejected", m)
+		}
+	}
+
+	// The multiplier is applied to the fee estimation.
+	_, feeRecommendation := h.tpool.FeeEstimation()
+	if fee := h.managedProofTransactionFee(100, 0); !fee.Equals(feeRecommendation.Mul64(100)) {
+		t.Fatal("wrong fee", fee)
+	}
+	settings.ProofFeeMultiplier = 2
+	if err := h.SetInternalSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	if fee := h.managedProofTransactionFee(100, 0); !fee.Equals(feeRecommendation.Mul64(200)) {
+		t.Fatal("wrong fee", fee)
+	}
+
+	// The fee is escalated for resubmitted proofs.
+	if fee := h.managedProofTransactionFee(100, 2); !fee.Equals(feeRecommendation.Mul64(800)) {
+		t.Fatal("wrong fee", fee)
+	}
+
+	// The fee is capped at the max fee.
+	settings.MaxProofFee = feeRecommendation.Mul64(150)
+	if err := h.SetInternalSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	if fee := h.managedProofTransactionFee(100, 0); !fee.Equals(settings.MaxProofFee) {
+		t.Fatal("wrong fee", fee)
+	}
+}
+
+// TestProofFees is a unit test for the proof fee report.
+func TestProofFees(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	h := ht.host
+
+	// Add some transactions. They are above the host's block height to avoid
+	// the end of the report being capped.
+	const base = 1e6
+	pfts := []modules.HostProofFeeTransaction{
+		{Fee: types.NewCurrency64(1), Height: base + 1, ObligationIDs: make([]types.FileContractID, 2), Type: modules.ProofFeeTypeStorageProof},
+		{Fee: types.NewCurrency64(2), Height: base + 1, ObligationIDs: make([]types.FileContractID, 1), Type: modules.ProofFeeTypeRevision},
+		{Fee: types.NewCurrency64(4), Height: base + 5, ObligationIDs: make([]types.FileContractID, 1), Type: modules.ProofFeeTypeStorageProof},
+		{Fee: types.NewCurrency64(8), Height: base + 11, ObligationIDs: make([]types.FileContractID, 1), Type: modules.ProofFeeTypeStorageProof},
+	}
+	err = h.db.Update(func(tx *bolt.Tx) error {
+		for i, pft := range pfts {
+			pft.TransactionID[0] = byte(i)
+			if err := putProofFeeTransaction(tx, pft); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Invalid ranges and periods are rejected.
+	if _, err := h.ProofFees(base+2, base+1, 1); !errors.Contains(err, errInvalidProofFeeRange) {
+		t.Fatal("expected errInvalidProofFeeRange", err)
+	}
+	if _, err := h.ProofFees(base, base+1, 0); !errors.Contains(err, errInvalidProofFeePeriod) {
+		t.Fatal("expected errInvalidProofFeePeriod", err)
+	}
+	if _, err := h.ProofFees(base, base+maxProofFeePeriods, 1); !errors.Contains(err, errInvalidProofFeePeriod) {
+		t.Fatal("expected errInvalidProofFeePeriod", err)
+	}
+
+	// Get the report of 10 blocks in periods of 4 blocks.
+	report, err := h.ProofFees(base+1, base+10, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Periods) != 3 {
+		t.Fatal("wrong number of periods", len(report.Periods))
+	}
+	if p := report.Periods[2]; p.StartHeight != base+9 || p.EndHeight != base+10 {
+		t.Fatal("wrong last period", p)
+	}
+	p := report.Periods[0]
+	if !p.ProofFees.Equals64(1) || !p.RevisionFees.Equals64(2) || p.StorageProofs != 2 || p.ProofTransactions != 1 || p.RevisionTransactions != 1 {
+		t.Fatal("wrong first period", p)
+	}
+	if p := report.Periods[1]; !p.ProofFees.Equals64(4) || p.ProofTransactions != 1 {
+		t.Fatal("wrong second period", p)
+	}
+	if !report.TotalProofFees.Equals64(5) || !report.TotalRevisionFees.Equals64(2) {
+		t.Fatal("wrong totals", report.TotalProofFees, report.TotalRevisionFees)
+	}
+}
+
+// TestBatchedStorageProofs tests that the host submits the storage proofs of
+// obligations with aligned proof windows in a single transaction if batching
+// is enabled.
+func TestBatchedStorageProofs(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	h := ht.host
+
+	// Enable batching.
+	settings := h.InternalSettings()
+	settings.BatchStorageProofs = true
+	if err := h.SetInternalSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+
+	// Add two obligations with the same proof window and a sector each.
+	var sos []storageObligation
+	for i := 0; i < 2; i++ {
+		so, err := ht.newTesterStorageObligation()
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.managedLockStorageObligation(so.id())
+		err = h.managedAddStorageObligation(so)
+		h.managedUnlockStorageObligation(so.id())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Move some money to the host to pay for the sector.
+		sectorRoot, sectorData := randSector()
+		so.SectorRoots = []crypto.Hash{sectorRoot}
+		sectorCost := types.SiacoinPrecision.Mul64(550)
+		so.PotentialStorageRevenue = so.PotentialStorageRevenue.Add(sectorCost)
+		validPayouts, missedPayouts := so.payouts()
+		validPayouts[0].Value = validPayouts[0].Value.Sub(sectorCost)
+		validPayouts[1].Value = validPayouts[1].Value.Add(sectorCost)
+		missedPayouts[0].Value = missedPayouts[0].Value.Sub(sectorCost)
+		missedPayouts[1].Value = missedPayouts[1].Value.Add(sectorCost)
+		revisionSet := []types.Transaction{{
+			FileContractRevisions: []types.FileContractRevision{{
+				ParentID:              so.id(),
+				UnlockConditions:      types.UnlockConditions{},
+				NewRevisionNumber:     2,
+				NewFileSize:           uint64(len(sectorData)),
+				NewFileMerkleRoot:     sectorRoot,
+				NewWindowStart:        so.expiration(),
+				NewWindowEnd:          so.proofDeadline(),
+				NewValidProofOutputs:  validPayouts,
+				NewMissedProofOutputs: missedPayouts,
+				NewUnlockHash:         types.UnlockConditions{}.UnlockHash(),
+			}},
+		}}
+		so.RevisionTransactionSet = revisionSet
+		h.managedLockStorageObligation(so.id())
+		err = h.managedModifyStorageObligation(so, nil, map[crypto.Hash][]byte{sectorRoot: sectorData})
+		h.managedUnlockStorageObligation(so.id())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ht.tpool.AcceptTransactionSet(revisionSet); err != nil {
+			t.Fatal(err)
+		}
+		sos = append(sos, so)
+	}
+	if sos[0].expiration() != sos[1].expiration() {
+		t.Fatal("obligations should expire at the same height")
+	}
+
+	// Mine until the host submits the storage proofs.
+	h.mu.RLock()
+	bh := h.blockHeight
+	h.mu.RUnlock()
+	for i := bh; i <= sos[0].expiration()+resubmissionTimeout; i++ {
+		if _, err := ht.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(time.Second)
+	if err := h.tg.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ht.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Both proofs should be confirmed and their fees split between the
+	// obligations.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		var fees types.Currency
+		for _, so := range sos {
+			h.mu.RLock()
+			err := h.db.View(func(tx *bolt.Tx) (err error) {
+				so, err = h.getStorageObligation(tx, so.id())
+				return err
+			})
+			h.mu.RUnlock()
+			if err != nil {
+				return err
+			}
+			if !so.ProofConfirmed {
+				return errors.New("proof wasn't confirmed")
+			}
+			fees = fees.Add(so.TransactionFeesAdded)
+		}
+		report, err := h.ProofFees(0, bh+1000, 1000)
+		if err != nil {
+			return err
+		}
+		if len(report.Periods) != 1 || report.Periods[0].ProofTransactions != 1 || report.Periods[0].StorageProofs != 2 {
+			return errors.New("proofs weren't batched")
+		}
+		if !fees.Equals(report.TotalProofFees) {
+			return errors.New("fees weren't split between the obligations")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,75 @@
+package host
+
+import (
+	"fmt"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// proofInDanger returns whether the storage proof of the obligation is in
+// danger of being missed at the given height. A proof is in danger if it is
+// still unconfirmed within proofDangerThreshold blocks of its deadline.
+func (so storageObligation) proofInDanger(height types.BlockHeight) bool {
+	return so.ObligationStatus == obligationUnresolved &&
+		!so.ProofConfirmed &&
+		so.requiresProof() &&
+		height+proofDangerThreshold >= so.proofDeadline()
+}
+
+// nextProofRetry returns the height at which the host checks whether the
+// storage proof of the obligation submitted at the given height got confirmed
+// and resubmits it otherwise. The host doesn't retry after the deadline.
+func (so storageObligation) nextProofRetry(height types.BlockHeight) (types.BlockHeight, bool) {
+	retry := height + proofRetryInterval
+	return retry, retry < so.proofDeadline()
+}
+
+// updateProofDanger adds the obligation to or removes it from the set of
+// obligations with a storage proof in danger and registers or unregisters the
+// host's alert accordingly.
+func (h *Host) updateProofDanger(soid types.FileContractID, inDanger bool) {
+	_, exists := h.proofsInDanger[soid]
+	if inDanger == exists {
+		return
+	}
+	if inDanger {
+		h.proofsInDanger[soid] = struct{}{}
+	} else {
+		delete(h.proofsInDanger, soid)
+	}
+	if len(h.proofsInDanger) == 0 {
+		h.staticAlerter.UnregisterAlert(modules.AlertIDHostProofsInDanger)
+		return
+	}
+	cause := fmt.Sprintf("%v storage proofs are unconfirmed close to their deadline", len(h.proofsInDanger))
+	h.staticAlerter.RegisterAlert(modules.AlertIDHostProofsInDanger, AlertMSGHostProofsInDanger, cause, modules.SeverityError)
+}
+
+// managedUpdateProofDanger checks whether the storage proof of the obligation
+// is in danger at the given height and updates the host's alert.
+func (h *Host) managedUpdateProofDanger(so storageObligation, height types.BlockHeight) {
+	inDanger := so.proofInDanger(height)
+	if inDanger {
+		h.log.Printf("contract %s action: storage proof is unconfirmed after %v attempts, deadline is %v and current height is %v", so.id(), so.ProofAttempts, so.proofDeadline(), height)
+	}
+	h.mu.Lock()
+	h.updateProofDanger(so.id(), inDanger)
+	h.mu.Unlock()
+}
+
+// managedRebroadcastProof rebroadcasts the most recent proof transaction of
+// the obligation if it is still waiting in the transaction pool. This gives
+// the previous proof a chance to be confirmed if the host failed to replace
+// it with one paying a higher fee.
+func (h *Host) managedRebroadcastProof(soid types.FileContractID, txnID types.TransactionID) {
+	if txnID == (types.TransactionID{}) {
+		return
+	}
+	txn, parents, exists := h.tpool.Transaction(txnID)
+	if !exists {
+		return
+	}
+	h.log.Debugf("contract %s action: rebroadcasting storage proof transaction %v", soid, txnID)
+	h.tpool.Broadcast(append(parents, txn))
+}
@@ -0,0 +1,108 @@
+package host
+
+import (
+	"testing"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestProofFeeEscalation is a unit test for proofFeeEscalation.
+func TestProofFeeEscalation(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		attempt uint64
+		factor  uint64
+	}{
+		{0, 1},
+		{1, 2},
+		{2, 4},
+		{maxProofFeeEscalations, 1 << maxProofFeeEscalations},
+		{maxProofFeeEscalations + 10, 1 << maxProofFeeEscalations},
+	}
+	for _, test := range tests {
+		if factor := proofFeeEscalation(test.attempt); factor != test.factor {
+			t.Errorf("attempt %v: expected %v but was %v", test.attempt, test.factor, factor)
+		}
+	}
+}
+
+// TestProofRetry is a unit test for proofInDanger and nextProofRetry.
+func TestProofRetry(t *testing.T) {
+	t.Parallel()
+	deadline := types.BlockHeight(100)
+	so := storageObligation{
+		RevisionTransactionSet: []types.Transaction{{
+			FileContractRevisions: []types.FileContractRevision{{
+				NewWindowStart:        deadline - 50,
+				NewWindowEnd:          deadline,
+				NewValidProofOutputs:  []types.SiacoinOutput{{Value: types.NewCurrency64(1)}},
+				NewMissedProofOutputs: []types.SiacoinOutput{{Value: types.ZeroCurrency}},
+			}},
+		}},
+	}
+
+	// The proof is in danger close to the deadline.
+	if so.proofInDanger(deadline - proofDangerThreshold - 1) {
+		t.Fatal("proof shouldn't be in danger")
+	}
+	if !so.proofInDanger(deadline - proofDangerThreshold) {
+		t.Fatal("proof should be in danger")
+	}
+	so.ProofConfirmed = true
+	if so.proofInDanger(deadline) {
+		t.Fatal("confirmed proof shouldn't be in danger")
+	}
+
+	// The proof is retried until the deadline.
+	if retry, ok := so.nextProofRetry(deadline - proofRetryInterval - 1); !ok || retry != deadline-1 {
+		t.Fatal("wrong retry", retry, ok)
+	}
+	if _, ok := so.nextProofRetry(deadline - proofRetryInterval); ok {
+		t.Fatal("proof shouldn't be retried at the deadline")
+	}
+}
+
+// TestUpdateProofDanger checks that the host alerts the user while storage
+// proofs are in danger.
+func TestUpdateProofDanger(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	h := ht.host
+
+	hasAlert := func() bool {
+		_, errs, _ := h.Alerts()
+		for _, a := range errs {
+			if a.ID == modules.AlertIDHostProofsInDanger {
+				return true
+			}
+		}
+		return false
+	}
+	id1, id2 := types.FileContractID{1}, types.FileContractID{2}
+	h.mu.Lock()
+	h.updateProofDanger(id1, true)
+	h.updateProofDanger(id2, true)
+	h.updateProofDanger(id1, false)
+	h.mu.Unlock()
+	if !hasAlert() {
+		t.Fatal("expected alert")
+	}
+	h.mu.Lock()
+	h.updateProofDanger(id2, false)
+	h.mu.Unlock()
+	if hasAlert() {
+		t.Fatal("alert should be unregistered")
+	}
+}
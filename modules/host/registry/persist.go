@@ -309,6 +309,8 @@ func (r *Registry) staticSaveEntry(v *value, used bool) error {
 	if err != nil {
 		return errors.AddContext(err, "Save: failed to marshal persistedEntry")
 	}
+	r.quiesceMu.RLock()
+	defer r.quiesceMu.RUnlock()
 	_, err = r.staticFile.WriteAt(b, v.staticIndex*PersistedEntrySize)
 	if err != nil {
 		return errors.AddContext(err, "failed to save entry")
@@ -54,6 +54,10 @@ type (
 		staticFile *os.File
 		usage      bitfield
 		mu         sync.Mutex
+
+		// quiesceMu is held as a readlock while entries are written to disk
+		// and as a writelock while the registry is quiesced.
+		quiesceMu sync.RWMutex
 	}
 
 	// values represents the value associated with a registered key.
@@ -126,6 +130,20 @@ func (r *Registry) Close() error {
 	return r.staticFile.Close()
 }
 
+// Quiesce implements the modules.Quiescer interface. It syncs the registry to
+// disk and blocks writing entries until resume is called.
+func (r *Registry) Quiesce() (resume func(), err error) {
+	r.mu.Lock()
+	r.quiesceMu.Lock()
+	f := r.staticFile
+	r.mu.Unlock()
+	if err := f.Sync(); err != nil {
+		r.quiesceMu.Unlock()
+		return nil, errors.AddContext(err, "failed to sync registry")
+	}
+	return r.quiesceMu.Unlock, nil
+}
+
 // Get fetches the data associated with a key and tweak from the registry.
 func (r *Registry) Get(sid modules.RegistryEntryID) (types.SiaPublicKey, modules.SignedRegistryValue, bool) {
 	r.mu.Lock()
@@ -0,0 +1,221 @@
+package host
+
+import (
+	"fmt"
+	"time"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errRescanInProgress is returned when trying to start a rescan while
+	// another one is still running.
+	errRescanInProgress = errors.New("a rescan is already in progress")
+
+	// errInvalidRescanRange is returned when trying to rescan a range of
+	// blocks which is empty or exceeds the host's block height.
+	errInvalidRescanRange = errors.New("invalid rescan range")
+
+	// rescanBatchSize is the number of blocks which are scanned while holding
+	// the host's lock.
+	rescanBatchSize = build.Select(build.Var{
+		Standard: types.BlockHeight(100),
+		Dev:      types.BlockHeight(10),
+		Testing:  types.BlockHeight(2),
+	}).(types.BlockHeight)
+)
+
+// Rescan starts a rescan of the blocks between start and end, both inclusive,
+// for transactions affecting the host's storage obligations and for
+// announcements of the host. Unlike a full rescan, which happens when the host
+// and the consensus set are out of sync, it only ever marks transactions as
+// confirmed and leaves everything outside of the range untouched. It is meant
+// to be used after restoring the host from a backup.
+func (h *Host) Rescan(start, end types.BlockHeight) error {
+	if err := h.tg.Add(); err != nil {
+		return err
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.rescanStatus.Active {
+		h.tg.Done()
+		return errRescanInProgress
+	}
+	if start > end || end > h.blockHeight {
+		h.tg.Done()
+		return errors.AddContext(errInvalidRescanRange, fmt.Sprintf("range %v-%v is outside of 0-%v", start, end, h.blockHeight))
+	}
+	h.rescanStatus = modules.HostRescanStatus{
+		Active:        true,
+		StartHeight:   start,
+		EndHeight:     end,
+		CurrentHeight: start,
+		StartTime:     time.Now(),
+	}
+	go func() {
+		defer h.tg.Done()
+		h.threadedRescan(start, end)
+	}()
+	return nil
+}
+
+// RescanStatus returns the progress of the most recent rescan.
+func (h *Host) RescanStatus() modules.HostRescanStatus {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.rescanStatus
+}
+
+// threadedRescan scans the blocks between start and end in batches and
+// updates the host's rescan status after every batch.
+func (h *Host) threadedRescan(start, end types.BlockHeight) {
+	updated := make(map[types.FileContractID]struct{})
+	err := func() error {
+		for height := start; height <= end; height += rescanBatchSize {
+			select {
+			case <-h.tg.StopChan():
+				return errors.New("host was shut down")
+			default:
+			}
+
+			// Fetch the blocks of the batch before acquiring the lock.
+			batchEnd := height + rescanBatchSize - 1
+			if batchEnd > end {
+				batchEnd = end
+			}
+			var blocks []types.Block
+			for bh := height; bh <= batchEnd; bh++ {
+				block, exists := h.cs.BlockAtHeight(bh)
+				if !exists {
+					return fmt.Errorf("block at height %v doesn't exist", bh)
+				}
+				blocks = append(blocks, block)
+			}
+
+			h.mu.Lock()
+			err := h.rescanBlocks(blocks, updated)
+			h.rescanStatus.CurrentHeight = batchEnd + 1
+			h.mu.Unlock()
+			if err != nil {
+				return errors.AddContext(err, fmt.Sprintf("failed to scan blocks %v-%v", height, batchEnd))
+			}
+		}
+		return nil
+	}()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// Re-queue the action items of the unresolved obligations which were
+	// updated to make sure that the host continues with them.
+	var requeued []types.FileContractID
+	for soid := range updated {
+		var so storageObligation
+		vErr := h.db.View(func(tx *bolt.Tx) (err error) {
+			so, err = h.getStorageObligation(tx, soid)
+			return
+		})
+		if vErr != nil || so.ObligationStatus != obligationUnresolved {
+			continue
+		}
+		qErr := h.queueActionItem(h.blockHeight+resubmissionTimeout, soid)
+		if qErr != nil {
+			err = errors.Compose(err, errors.AddContext(qErr, "failed to queue action item"))
+			continue
+		}
+		requeued = append(requeued, soid)
+	}
+	if sErr := h.saveSync(); sErr != nil {
+		err = errors.Compose(err, errors.AddContext(sErr, "failed to save host"))
+	}
+
+	h.rescanStatus.Active = false
+	h.rescanStatus.EndTime = time.Now()
+	if err != nil {
+		h.rescanStatus.Error = err.Error()
+		h.log.Printf("ERROR: rescan of blocks %v-%v failed: %v", start, end, err)
+	} else {
+		h.log.Printf("INFO: rescan of blocks %v-%v updated %v obligations and found %v announcements", start, end, h.rescanStatus.ObligationsUpdated, h.rescanStatus.AnnouncementsFound)
+	}
+	h.log.Debugf("rescan re-queued the action items of %v obligations", len(requeued))
+}
+
+// rescanBlocks marks the transactions of the host's storage obligations within
+// the provided blocks as confirmed and looks for announcements of the host.
+// The ids of the updated obligations are added to updated.
+func (h *Host) rescanBlocks(blocks []types.Block, updated map[types.FileContractID]struct{}) error {
+	// markUpdated marks an obligation as updated the first time it changes.
+	markUpdated := func(soid types.FileContractID) {
+		if _, exists := updated[soid]; !exists {
+			updated[soid] = struct{}{}
+			h.rescanStatus.ObligationsUpdated++
+		}
+	}
+	return h.db.Update(func(tx *bolt.Tx) error {
+		for _, block := range blocks {
+			for _, txn := range block.Transactions {
+				for i := range txn.FileContracts {
+					fcid := txn.FileContractID(uint64(i))
+					so, err := h.getStorageObligation(tx, fcid)
+					if err != nil || so.OriginConfirmed {
+						continue
+					}
+					so.OriginConfirmed = true
+					if err := putStorageObligation(tx, so); err != nil {
+						return err
+					}
+					markUpdated(fcid)
+				}
+				for _, fcr := range txn.FileContractRevisions {
+					so, err := h.getStorageObligation(tx, fcr.ParentID)
+					if err != nil || so.RevisionConfirmed {
+						continue
+					}
+					// Only the most recent revision counts as confirmed.
+					recent, err := so.recentRevision()
+					if err != nil || recent.NewRevisionNumber != fcr.NewRevisionNumber {
+						continue
+					}
+					so.RevisionConfirmed = true
+					if err := putStorageObligation(tx, so); err != nil {
+						return err
+					}
+					markUpdated(fcr.ParentID)
+				}
+				for _, sp := range txn.StorageProofs {
+					so, err := h.getStorageObligation(tx, sp.ParentID)
+					if err != nil || so.ProofConfirmed {
+						continue
+					}
+					so.ProofConfirmed = true
+					if err := putStorageObligation(tx, so); err != nil {
+						return err
+					}
+					markUpdated(sp.ParentID)
+				}
+				for _, arb := range txn.ArbitraryData {
+					addr, pk, err := modules.DecodeAnnouncement(arb)
+					if err != nil || !pk.Equals(h.publicKey) {
+						continue
+					}
+					h.rescanStatus.AnnouncementsFound++
+					// The host only counts as announced if the announcement
+					// contains its current address.
+					netAddr := h.settings.NetAddress
+					if netAddr == "" {
+						netAddr = h.autoAddress
+					}
+					if addr == netAddr {
+						h.announced = true
+					}
+				}
+			}
+		}
+		return nil
+	})
+}
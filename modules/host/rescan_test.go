@@ -0,0 +1,149 @@
+package host
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+)
+
+// TestHostRescan tests that a rescan restores the confirmations of the host's
+// storage obligations and its announcement after they were lost.
+func TestHostRescan(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	h := ht.host
+
+	// Announce the host and add a storage obligation.
+	err = h.Announce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	so, err := ht.newTesterStorageObligation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.managedLockStorageObligation(so.id())
+	err = h.managedAddStorageObligation(so)
+	h.managedUnlockStorageObligation(so.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ht.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = h.tg.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Invalid ranges are rejected.
+	h.mu.RLock()
+	height := h.blockHeight
+	h.mu.RUnlock()
+	if err := h.Rescan(height, height+1); !errors.Contains(err, errInvalidRescanRange) {
+		t.Fatal("expected errInvalidRescanRange", err)
+	}
+	if err := h.Rescan(2, 1); !errors.Contains(err, errInvalidRescanRange) {
+		t.Fatal("expected errInvalidRescanRange", err)
+	}
+
+	// Emulate restoring an outdated backup by resetting the confirmation and
+	// the announcement.
+	h.mu.Lock()
+	h.announced = false
+	err = h.db.Update(func(tx *bolt.Tx) error {
+		so, err := h.getStorageObligation(tx, so.id())
+		if err != nil {
+			return err
+		}
+		if !so.OriginConfirmed {
+			return errors.New("obligation should be confirmed")
+		}
+		so.OriginConfirmed = false
+		return putStorageObligation(tx, so)
+	})
+	h.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Rescan the last few blocks.
+	err = h.Rescan(height-4, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if h.RescanStatus().Active {
+			return errors.New("rescan still active")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := h.RescanStatus()
+	if status.Error != "" {
+		t.Fatal(status.Error)
+	}
+	if status.StartHeight != height-4 || status.EndHeight != height || status.CurrentHeight != height+1 {
+		t.Fatal("wrong heights", status)
+	}
+	if status.ObligationsUpdated != 1 || status.AnnouncementsFound != 1 {
+		t.Fatal("wrong counts", status)
+	}
+	if status.EndTime.Before(status.StartTime) {
+		t.Fatal("wrong times", status)
+	}
+
+	// The confirmation and the announcement should be restored.
+	h.mu.RLock()
+	announced := h.announced
+	err = h.db.View(func(tx *bolt.Tx) error {
+		so, err = h.getStorageObligation(tx, so.id())
+		return err
+	})
+	h.mu.RUnlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !announced {
+		t.Fatal("host should be announced")
+	}
+	if !so.OriginConfirmed {
+		t.Fatal("obligation should be confirmed")
+	}
+
+	// Rescanning again doesn't update anything.
+	err = h.Rescan(height-4, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if h.RescanStatus().Active {
+			return errors.New("rescan still active")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status := h.RescanStatus(); status.ObligationsUpdated != 0 || status.AnnouncementsFound != 1 {
+		t.Fatal("wrong counts", status)
+	}
+}
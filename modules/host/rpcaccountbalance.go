@@ -1,72 +1,111 @@
 package host
 
 import (
-	"fmt"
-	"time"
-
 	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/siamux"
 	"go.sia.tech/siad/modules"
 )
 
 // managedRPCAccountBalance handles the RPC which returns the balance of the
-// requested account.
-// TODO: Should we require a signature for retrieving the balance?
-func (h *Host) managedRPCAccountBalance(stream siamux.Stream) (string, error) {
-	out := ""
-	start := time.Now()
+// requested account. Since the request is unsigned, only the balance of the
+// account which paid for the RPC can be requested. The balance of other
+// accounts requires the SignedAccountBalance RPC.
+func (h *Host) managedRPCAccountBalance(stream siamux.Stream) error {
 	// read the price table
 	pt, err := h.staticReadPriceTableID(stream)
 	if err != nil {
-		return out, errors.AddContext(err, "failed to read price table")
+		return errors.AddContext(err, "failed to read price table")
 	}
-	out += fmt.Sprintf("PT read took %v\n", time.Since(start))
 
 	// Process payment.
-	start = time.Now()
-	pd, out2, err := h.ProcessPayment(stream, pt.HostBlockHeight)
-	out += out2
+	pd, err := h.ProcessPayment(stream, pt.HostBlockHeight)
 	if err != nil {
-		return out, errors.AddContext(err, "failed to process payment")
+		return errors.AddContext(err, "failed to process payment")
 	}
-	out += fmt.Sprintf("process payment took %v\n", time.Since(start))
 
 	// Check payment.
 	if pd.Amount().Cmp(pt.AccountBalanceCost) < 0 {
-		return out, modules.ErrInsufficientPaymentForRPC
+		return modules.ErrInsufficientPaymentForRPC
 	}
 
 	// Refund excessive payment.
-	start = time.Now()
 	refund := pd.Amount().Sub(pt.AccountBalanceCost)
-	err = h.staticAccountManager.callRefund(pd.AccountID(), refund)
+	err = h.staticAccountManager.callRefund(pd.AccountID(), refund, streamOrigin(stream))
 	if err != nil {
-		return out, errors.AddContext(err, "failed to refund client")
+		return errors.AddContext(err, "failed to refund client")
 	}
-	out += fmt.Sprintf("refund took %v\n", time.Since(start))
 
 	// Read request
-	start = time.Now()
 	var abr modules.AccountBalanceRequest
 	err = modules.RPCRead(stream, &abr)
 	if err != nil {
-		return out, errors.AddContext(err, "Failed to read AccountBalanceRequest")
+		return errors.AddContext(err, "Failed to read AccountBalanceRequest")
+	}
+
+	// Only the paying account may query its balance without a signature.
+	if abr.Account != pd.AccountID() {
+		return modules.ErrUnauthorizedAccountBalanceQuery
 	}
-	out += fmt.Sprintf("REQ read took %v\n", time.Since(start))
 
 	// Get account balance.
-	start = time.Now()
 	balance := h.staticAccountManager.callAccountBalance(abr.Account)
-	out += fmt.Sprintf("account balance took %v\n", time.Since(start))
 
-	start = time.Now()
 	// Send response.
 	err = modules.RPCWrite(stream, modules.AccountBalanceResponse{
 		Balance: balance,
 	})
 	if err != nil {
-		return out, errors.AddContext(err, "Failed to send AccountBalanceResponse")
+		return errors.AddContext(err, "Failed to send AccountBalanceResponse")
+	}
+	return nil
+}
+
+// managedRPCSignedAccountBalance handles the RPC which returns the balance of
+// an account for a query signed by the account's secret key.
+func (h *Host) managedRPCSignedAccountBalance(stream siamux.Stream) error {
+	// read the price table
+	pt, err := h.staticReadPriceTableID(stream)
+	if err != nil {
+		return errors.AddContext(err, "failed to read price table")
+	}
+
+	// Process payment.
+	pd, err := h.ProcessPayment(stream, pt.HostBlockHeight)
+	if err != nil {
+		return errors.AddContext(err, "failed to process payment")
+	}
+
+	// Check payment.
+	if pd.Amount().Cmp(pt.AccountBalanceCost) < 0 {
+		return modules.ErrInsufficientPaymentForRPC
+	}
+
+	// Refund excessive payment.
+	refund := pd.Amount().Sub(pt.AccountBalanceCost)
+	err = h.staticAccountManager.callRefund(pd.AccountID(), refund, streamOrigin(stream))
+	if err != nil {
+		return errors.AddContext(err, "failed to refund client")
+	}
+
+	// Read request
+	var sabr modules.SignedAccountBalanceRequest
+	err = modules.RPCRead(stream, &sabr)
+	if err != nil {
+		return errors.AddContext(err, "Failed to read SignedAccountBalanceRequest")
+	}
+
+	// Get account balance.
+	balance, err := h.staticAccountManager.callSignedAccountBalance(sabr.Message, sabr.Signature, pt.HostBlockHeight)
+	if err != nil {
+		return errors.AddContext(err, "failed to verify account balance query")
+	}
+
+	// Send response.
+	err = modules.RPCWrite(stream, modules.AccountBalanceResponse{
+		Balance: balance,
+	})
+	if err != nil {
+		return errors.AddContext(err, "Failed to send AccountBalanceResponse")
 	}
-	out += fmt.Sprintf("send took %v\n", time.Since(start))
-	return out, nil
+	return nil
 }
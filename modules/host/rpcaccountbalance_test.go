@@ -1,10 +1,16 @@
 package host
 
 import (
+	"fmt"
 	"strings"
 	"testing"
+	"time"
 
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
 )
 
 // TestAccountBalance verifies the AccountBalance RPC.
@@ -34,6 +40,10 @@ func TestAccountBalance(t *testing.T) {
 	t.Run("RandomAccountZeroBalance", func(t *testing.T) {
 		testAccountBalanceRandom(t, rhp)
 	})
+	// Test that the balance of other accounts requires a signed query.
+	t.Run("Signed", func(t *testing.T) {
+		testAccountBalanceSigned(t, rhp)
+	})
 	// Test that case that checks for a correct refund.
 	t.Run("Refund", func(t *testing.T) {
 		testAccountBalanceRefund(t, rhp)
@@ -42,6 +52,10 @@ func TestAccountBalance(t *testing.T) {
 	t.Run("ErrInsufficientBudget", func(t *testing.T) {
 		testAccountBalanceErrInsufficientBudget(t, rhp)
 	})
+	// Test that the calls were traced.
+	t.Run("Trace", func(t *testing.T) {
+		testAccountBalanceTrace(t, rhp)
+	})
 }
 
 // testAccountBalanceBasic tests the basic happy-flow functionality of the
@@ -97,9 +111,10 @@ func testAccountBalanceBasic(t *testing.T, rhp *renterHostPair) {
 // testAccountBalanceRandom tests checking the balance for a random account.
 func testAccountBalanceRandom(t *testing.T, rhp *renterHostPair) {
 	// create random account id.
-	_, accountID := prepareAccount()
+	sk, accountID := prepareAccount()
 	// fetch the balance and pay for it by contract.
-	balance, err := rhp.managedAccountBalance(true, rhp.pt.AccountBalanceCost, rhp.staticAccountID, accountID)
+	req := modules.NewSignedAccountBalanceRequest(accountID, rhp.pt.HostBlockHeight, sk)
+	balance, err := rhp.managedSignedAccountBalance(true, rhp.pt.AccountBalanceCost, rhp.staticAccountID, req)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -108,6 +123,55 @@ func testAccountBalanceRandom(t *testing.T, rhp *renterHostPair) {
 	}
 }
 
+// testAccountBalanceSigned tests that the balance of an account other than the
+// paying account can only be fetched with a valid signed query.
+func testAccountBalanceSigned(t *testing.T, rhp *renterHostPair) {
+	// Fund an account.
+	sk, accountID := prepareAccount()
+	err := callDeposit(rhp.staticHT.host.staticAccountManager, accountID, types.SiacoinPrecision)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An unsigned query for the account is rejected.
+	_, err = rhp.managedAccountBalance(true, rhp.pt.AccountBalanceCost, rhp.staticAccountID, accountID)
+	if err == nil || !strings.Contains(err.Error(), modules.ErrUnauthorizedAccountBalanceQuery.Error()) {
+		t.Fatal("expected ErrUnauthorizedAccountBalanceQuery but got:", err)
+	}
+
+	// A signed query returns the balance. The query is paid for by the
+	// ephemeral account of the pair.
+	req := modules.NewSignedAccountBalanceRequest(accountID, rhp.pt.HostBlockHeight, sk)
+	balance, err := rhp.managedSignedAccountBalance(false, rhp.pt.AccountBalanceCost, rhp.staticAccountID, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !balance.Equals(types.SiacoinPrecision) {
+		t.Fatalf("expected balance to be %v but was %v", types.SiacoinPrecision, balance)
+	}
+
+	// Replaying the query is rejected.
+	_, err = rhp.managedSignedAccountBalance(true, rhp.pt.AccountBalanceCost, rhp.staticAccountID, req)
+	if err == nil || !strings.Contains(err.Error(), ErrAccountBalanceQueryReplayed.Error()) {
+		t.Fatal("expected ErrAccountBalanceQueryReplayed but got:", err)
+	}
+
+	// A query signed by another key is rejected.
+	otherSK, _ := prepareAccount()
+	req = modules.NewSignedAccountBalanceRequest(accountID, rhp.pt.HostBlockHeight, otherSK)
+	_, err = rhp.managedSignedAccountBalance(true, rhp.pt.AccountBalanceCost, rhp.staticAccountID, req)
+	if err == nil || !strings.Contains(err.Error(), modules.ErrAccountBalanceQueryInvalidSignature.Error()) {
+		t.Fatal("expected ErrAccountBalanceQueryInvalidSignature but got:", err)
+	}
+
+	// An expired query is rejected.
+	req = modules.NewSignedAccountBalanceRequest(accountID, rhp.pt.HostBlockHeight-1, sk)
+	_, err = rhp.managedSignedAccountBalance(true, rhp.pt.AccountBalanceCost, rhp.staticAccountID, req)
+	if err == nil || !strings.Contains(err.Error(), modules.ErrAccountBalanceQueryExpired.Error()) {
+		t.Fatal("expected ErrAccountBalanceQueryExpired but got:", err)
+	}
+}
+
 // testAccountBalanceRefund tests the refund a host is expected to grant.
 func testAccountBalanceRefund(t *testing.T, rhp *renterHostPair) {
 	host := rhp.staticHT.host
@@ -136,3 +200,28 @@ func testAccountBalanceErrInsufficientBudget(t *testing.T, rhp *renterHostPair)
 		t.Fatal("expected ErrInsufficientPaymentForRPC but got: ", err)
 	}
 }
+
+// testAccountBalanceTrace verifies that the previous AccountBalance calls were
+// traced by the host.
+func testAccountBalanceTrace(t *testing.T, rhp *renterHostPair) {
+	// The call is traced after the stream is closed so we might need to wait
+	// for the last one.
+	err := build.Retry(100, 10*time.Millisecond, func() error {
+		for _, stats := range rhp.staticHT.host.RPCTrace().Stats {
+			if stats.RPC != rpcName(modules.RPCAccountBalance) {
+				continue
+			}
+			if stats.Calls == 0 || stats.BytesUploaded == 0 || stats.BytesDownloaded == 0 {
+				return fmt.Errorf("wrong stats %+v", stats)
+			}
+			if stats.Errors[modules.HostRPCErrorCodePayment] == 0 {
+				return fmt.Errorf("insufficient payment wasn't traced %v", stats.Errors)
+			}
+			return nil
+		}
+		return errors.New("AccountBalance wasn't traced")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
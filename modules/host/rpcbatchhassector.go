@@ -0,0 +1,63 @@
+package host
+
+import (
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/siamux"
+	"go.sia.tech/siad/modules"
+)
+
+// managedRPCBatchHasSector handles the RPC which returns whether the host
+// stores the requested sectors. It is charged once per batch which makes it a
+// lot cheaper than a program with one HasSector instruction per root when
+// auditing contracts with many sectors.
+func (h *Host) managedRPCBatchHasSector(stream siamux.Stream) error {
+	// read the price table
+	pt, err := h.staticReadPriceTableID(stream)
+	if err != nil {
+		return errors.AddContext(err, "failed to read price table")
+	}
+
+	// Process payment.
+	pd, err := h.ProcessPayment(stream, pt.HostBlockHeight)
+	if err != nil {
+		return errors.AddContext(err, "failed to process payment")
+	}
+
+	// Read request
+	var req modules.BatchHasSectorRequest
+	err = modules.RPCReadMaxLen(stream, &req, modules.BatchHasSectorRequestMaxLen)
+	if err != nil {
+		return errors.AddContext(err, "Failed to read BatchHasSectorRequest")
+	}
+	if len(req.Roots) > modules.MaxBatchHasSectorRoots {
+		return modules.ErrTooManyBatchHasSectorRoots
+	}
+
+	// Check payment.
+	cost := modules.BatchHasSectorRPCCost(pt)
+	if pd.Amount().Cmp(cost) < 0 {
+		return modules.ErrInsufficientPaymentForRPC
+	}
+
+	// Refund excessive payment.
+	refund := pd.Amount().Sub(cost)
+	err = h.staticAccountManager.callRefund(pd.AccountID(), refund, streamOrigin(stream))
+	if err != nil {
+		return errors.AddContext(err, "failed to refund client")
+	}
+
+	// Check the sectors.
+	availables := make([]bool, len(req.Roots))
+	for i, root := range req.Roots {
+		availables[i] = h.HasSector(root)
+	}
+
+	// Send response.
+	err = modules.RPCWrite(stream, modules.BatchHasSectorResponse{
+		Availables: availables,
+	})
+	if err != nil {
+		return errors.AddContext(err, "Failed to send BatchHasSectorResponse")
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package host
+
+import (
+	"strings"
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestBatchHasSector verifies the BatchHasSector RPC.
+func TestBatchHasSector(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// create a blank host tester
+	rhp, err := newRenterHostPair(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := rhp.Close()
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+	host := rhp.staticHT.host
+
+	// Add a sector to the host.
+	sectorData := fastrand.Bytes(int(modules.SectorSize))
+	sectorRoot := crypto.MerkleRoot(sectorData)
+	err = host.AddSector(sectorRoot, sectorData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Check a large batch of random roots containing the sector.
+	roots := make([]crypto.Hash, 1000)
+	for i := range roots {
+		fastrand.Read(roots[i][:])
+	}
+	roots[500] = sectorRoot
+	cost := modules.BatchHasSectorRPCCost(rhp.pt)
+	availables, err := rhp.managedBatchHasSector(true, cost, roots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(availables) != len(roots) {
+		t.Fatalf("expected %v responses but got %v", len(roots), len(availables))
+	}
+	for i, available := range availables {
+		if available != (i == 500) {
+			t.Fatal("unexpected response for root", i, available)
+		}
+	}
+
+	// The batch should be cheaper than a program checking the same roots.
+	pb := modules.NewProgramBuilder(rhp.pt, 0)
+	for _, root := range roots {
+		pb.AddHasSectorInstruction(root)
+	}
+	programCost, _, _ := pb.Cost(true)
+	if cost.Cmp(programCost) >= 0 {
+		t.Fatal("batch should be cheaper than the program", cost, programCost)
+	}
+
+	// Paying less than the cost should fail.
+	_, err = rhp.managedBatchHasSector(true, cost.Sub64(1), roots)
+	if err == nil || !strings.Contains(err.Error(), modules.ErrInsufficientPaymentForRPC.Error()) {
+		t.Fatal("expected ErrInsufficientPaymentForRPC but got:", err)
+	}
+
+	// Checking too many roots should fail.
+	roots = make([]crypto.Hash, modules.MaxBatchHasSectorRoots+1)
+	_, err = rhp.managedBatchHasSector(true, cost, roots)
+	if err == nil || !strings.Contains(err.Error(), modules.ErrTooManyBatchHasSectorRoots.Error()) {
+		t.Fatal("expected ErrTooManyBatchHasSectorRoots but got:", err)
+	}
+}
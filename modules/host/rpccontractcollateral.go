@@ -0,0 +1,84 @@
+package host
+
+import (
+	"fmt"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/siamux"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// managedRPCContractCollateral handles the RPC which returns the collateral
+// the host put into a contract. It allows a renter to verify that the host
+// actually risks the collateral its settings claim. The request needs to be
+// signed by the renter of the contract.
+func (h *Host) managedRPCContractCollateral(stream siamux.Stream) error {
+	// read the price table
+	pt, err := h.staticReadPriceTableID(stream)
+	if err != nil {
+		return errors.AddContext(err, "failed to read price table")
+	}
+
+	// Process payment.
+	pd, err := h.ProcessPayment(stream, pt.HostBlockHeight)
+	if err != nil {
+		return errors.AddContext(err, "failed to process payment")
+	}
+
+	// Read request
+	var ccr modules.ContractCollateralRequest
+	err = modules.RPCRead(stream, &ccr)
+	if err != nil {
+		return errors.AddContext(err, "Failed to read ContractCollateralRequest")
+	}
+
+	// Check payment.
+	cost := modules.ContractCollateralCost(pt)
+	if pd.Amount().Cmp(cost) < 0 {
+		return modules.ErrInsufficientPaymentForRPC
+	}
+
+	// Refund excessive payment.
+	refund := pd.Amount().Sub(cost)
+	err = h.staticAccountManager.callRefund(pd.AccountID(), refund, streamOrigin(stream))
+	if err != nil {
+		return errors.AddContext(err, "failed to refund client")
+	}
+
+	// Read storage obligation.
+	so, err := h.managedGetStorageObligationSnapshot(ccr.FileContractID)
+	if err != nil {
+		return errors.AddContext(err, fmt.Sprintf("failed to get storage obligation for contract with id %v", ccr.FileContractID))
+	}
+
+	// Verify that the request was signed by the renter of the contract.
+	rev := so.RecentRevision()
+	var renterPK crypto.PublicKey
+	copy(renterPK[:], rev.UnlockConditions.PublicKeys[0].Key)
+	hash := modules.ContractCollateralRequestHash(ccr.FileContractID, pt.UID)
+	if err := crypto.VerifyHash(hash, renterPK, ccr.Signature); err != nil {
+		return modules.ErrContractCollateralInvalidSignature
+	}
+
+	// Send response.
+	missedVoid, err := rev.MissedVoidPayout()
+	if err != nil {
+		return errors.AddContext(err, "failed to get missed void payout")
+	}
+	err = modules.RPCWrite(stream, modules.ContractCollateralResponse{
+		Collateral: modules.ContractCollateral{
+			LockedCollateral: so.LockedCollateral(),
+			RiskedCollateral: so.RiskedCollateral(),
+			MissedHostPayout: rev.MissedHostPayout(),
+			MissedVoidPayout: missedVoid,
+			FileSize:         rev.NewFileSize,
+			RevisionNumber:   rev.NewRevisionNumber,
+			ProofDeadline:    so.ProofDeadline(),
+		},
+	})
+	if err != nil {
+		return errors.AddContext(err, "Failed to send ContractCollateralResponse")
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package host
+
+import (
+	"strings"
+	"testing"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestContractCollateral verifies the ContractCollateral RPC.
+func TestContractCollateral(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// create a blank host tester
+	rhp, err := newRenterHostPair(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := rhp.Close()
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+	host := rhp.staticHT.host
+
+	// Fund the ephemeral account.
+	_, err = rhp.managedFundEphemeralAccount(rhp.pt.FundAccountCost.Add(modules.ContractCollateralCost(rhp.pt).Mul64(10)), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Fetch the collateral of the contract and compare it to the storage
+	// obligation.
+	cost := modules.ContractCollateralCost(rhp.pt)
+	collateral, err := rhp.managedContractCollateral(cost, rhp.staticRenterSK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	so, err := host.managedGetStorageObligationSnapshot(rhp.staticFCID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rev := so.RecentRevision()
+	missedVoid, err := rev.MissedVoidPayout()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !collateral.LockedCollateral.Equals(so.LockedCollateral()) ||
+		!collateral.RiskedCollateral.Equals(so.RiskedCollateral()) ||
+		!collateral.MissedHostPayout.Equals(rev.MissedHostPayout()) ||
+		!collateral.MissedVoidPayout.Equals(missedVoid) ||
+		collateral.FileSize != rev.NewFileSize ||
+		collateral.RevisionNumber != rev.NewRevisionNumber ||
+		collateral.ProofDeadline != so.ProofDeadline() {
+		t.Fatal("unexpected collateral", collateral)
+	}
+
+	// Paying less than the cost should fail.
+	_, err = rhp.managedContractCollateral(cost.Sub64(1), rhp.staticRenterSK)
+	if err == nil || !strings.Contains(err.Error(), modules.ErrInsufficientPaymentForRPC.Error()) {
+		t.Fatal("expected ErrInsufficientPaymentForRPC but got:", err)
+	}
+
+	// A request which isn't signed by the renter should fail.
+	sk, _ := crypto.GenerateKeyPair()
+	_, err = rhp.managedContractCollateral(cost, sk)
+	if err == nil || !strings.Contains(err.Error(), modules.ErrContractCollateralInvalidSignature.Error()) {
+		t.Fatal("expected ErrContractCollateralInvalidSignature but got:", err)
+	}
+}
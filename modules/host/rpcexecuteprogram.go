@@ -36,7 +36,7 @@ func (h *Host) managedRPCExecuteProgram(stream siamux.Stream) error {
 	}
 
 	// Process payment.
-	pd, _, err := h.ProcessPayment(stream, pt.HostBlockHeight)
+	pd, err := h.ProcessPayment(stream, pt.HostBlockHeight)
 	if err != nil {
 		return errors.AddContext(err, "failed to process payment")
 	}
@@ -53,6 +53,7 @@ func (h *Host) managedRPCExecuteProgram(stream siamux.Stream) error {
 
 	// Refund all the money we didn't use at the end of the RPC.
 	refundAccount := pd.AccountID()
+	refundOrigin := streamOrigin(stream)
 	programRefund := pd.Amount()
 	err = h.tg.Add()
 	if err != nil {
@@ -63,7 +64,7 @@ func (h *Host) managedRPCExecuteProgram(stream siamux.Stream) error {
 			defer h.tg.Done()
 			// The total refund is the remaining value of the budget + the
 			// potential program refund.
-			depositErr := h.staticAccountManager.callRefund(refundAccount, programRefund.Add(budget.Remaining()))
+			depositErr := h.staticAccountManager.callRefund(refundAccount, programRefund.Add(budget.Remaining()), refundOrigin)
 			if depositErr != nil {
 				h.log.Print("ERROR: failed to refund renter", depositErr)
 			}
@@ -149,9 +150,48 @@ func (h *Host) managedRPCExecuteProgram(stream siamux.Stream) error {
 	}
 
 	// Handle outputs.
-	executionFailed := false
+	output, refund, executionFailed, err := h.managedWriteProgramOutputs(stream, buffer, program, outputs)
+	programRefund = refund
+	if err != nil {
+		return err
+	}
+
+	// Reset the deadline (set both read and write)
+	err = stream.SetDeadline(time.Now().Add(defaultConnectionDeadline))
+	if err != nil {
+		return errors.AddContext(err, "failed to set deadline on stream")
+	}
+
+	// If the execution failed we return without an error. The peer will notice
+	// the error in the last instruction and know that the communication is over
+	// at this point. Nothing more to do than return the promised refund.
+	if executionFailed {
+		return nil
+	}
+
+	// Call finalize if the program is not readonly.
+	if !readonly {
+		err := h.managedFinalizeWriteProgram(stream, fcid, finalize, output, bh)
+		if err != nil {
+			return errors.AddContext(err, "failed to finalize write program")
+		}
+	}
+	//	else {
+	//		// TODO: finalize spending for readonly programs once the MR is ready. (#4236)
+	//	}
+
+	// The program was finalized and we don't want to refund the programRefund
+	// anymore.
+	programRefund = types.ZeroCurrency
+	return nil
+}
+
+// managedWriteProgramOutputs writes the outputs of an executing program to
+// the stream until the output channel is closed. It returns the last output,
+// the refund that is owed to the renter if the program is not finalized and
+// whether one of the instructions failed.
+func (h *Host) managedWriteProgramOutputs(stream siamux.Stream, buffer *bytes.Buffer, program modules.Program, outputs <-chan mdm.Output) (output mdm.Output, programRefund types.Currency, executionFailed bool, err error) {
 	numOutputs := 0
-	var output mdm.Output
 	for output = range outputs {
 		// Remember number of returned outputs.
 		numOutputs++
@@ -183,7 +223,7 @@ func (h *Host) managedRPCExecuteProgram(stream siamux.Stream) error {
 		if output.ExecutionCost.Cmp(output.FailureRefund) < 0 {
 			err = errors.New("executionCost can never be smaller than the storage cost")
 			build.Critical(err)
-			return err
+			return output, programRefund, executionFailed, err
 		}
 		// The additional storage cost is refunded if the program is not
 		// committed.
@@ -194,7 +234,7 @@ func (h *Host) managedRPCExecuteProgram(stream siamux.Stream) error {
 		// Send the response to the peer.
 		err = modules.RPCWrite(buffer, resp)
 		if err != nil {
-			return errors.AddContext(err, "failed to send output to peer")
+			return output, programRefund, executionFailed, errors.AddContext(err, "failed to send output to peer")
 		}
 
 		instructionSpecifier := program[numOutputs-1].Specifier
@@ -208,13 +248,13 @@ func (h *Host) managedRPCExecuteProgram(stream siamux.Stream) error {
 		// Write output.
 		_, err = buffer.Write(output.Output)
 		if err != nil {
-			return errors.AddContext(err, "failed to send output data to peer")
+			return output, programRefund, executionFailed, errors.AddContext(err, "failed to send output data to peer")
 		}
 
 		// Increase the write deadline just before writing to it.
 		err = stream.SetWriteDeadline(time.Now().Add(modules.MDMProgramWriteResponseTime))
 		if err != nil {
-			return errors.AddContext(err, "failed to set write deadline on stream")
+			return output, programRefund, executionFailed, errors.AddContext(err, "failed to set write deadline on stream")
 		}
 
 		// Disrupt if the delay write dependency is set
@@ -237,51 +277,54 @@ func (h *Host) managedRPCExecuteProgram(stream siamux.Stream) error {
 		// Write contents of the buffer.
 		_, err = buffer.WriteTo(stream)
 		if err != nil {
-			return errors.AddContext(err, "failed to send data to peer")
+			return output, programRefund, executionFailed, errors.AddContext(err, "failed to send data to peer")
 		}
 	}
 
 	// Sanity check that we received at least 1 output.
 	if numOutputs == 0 {
-		err := errors.New("program returned 0 outputs - should never happen")
+		err = errors.New("program returned 0 outputs - should never happen")
 		build.Critical(err)
-		return err
+		return output, programRefund, executionFailed, err
 	}
+	return output, programRefund, executionFailed, nil
+}
 
-	// Reset the deadline (set both read and write)
-	err = stream.SetDeadline(time.Now().Add(defaultConnectionDeadline))
+// managedFinalizeWriteProgram conducts the additional steps required to
+// finalize a write program. The blockheight is passed in to make sure we are
+// using the same as when we ran the MDMD.
+func (h *Host) managedFinalizeWriteProgram(stream io.ReadWriter, fcid types.FileContractID, finalize mdm.FnFinalize, lastOutput mdm.Output, bh types.BlockHeight) error {
+	// Get the new revision from the renter.
+	var req modules.RPCExecuteProgramRevisionSigningRequest
+	err := modules.RPCReadMaxLen(stream, &req, maxRPCExecuteProgramRevisionSigningRequestSize)
 	if err != nil {
-		return errors.AddContext(err, "failed to set deadline on stream")
+		return errors.AddContext(err, "failed to get new revision from renter")
 	}
 
-	// If the execution failed we return without an error. The peer will notice
-	// the error in the last instruction and know that the communication is over
-	// at this point. Nothing more to do than return the promised refund.
-	if executionFailed {
-		return nil
+	// Verify and sign the revision.
+	so, txn, err := h.managedSignExecuteProgramRevision(fcid, req, lastOutput, bh)
+	if err != nil {
+		return err
 	}
 
-	// Call finalize if the program is not readonly.
-	if !readonly {
-		err := h.managedFinalizeWriteProgram(stream, fcid, finalize, output, bh)
-		if err != nil {
-			return errors.AddContext(err, "failed to finalize write program")
-		}
+	// Send the response to the renter.
+	resp := modules.RPCExecuteProgramRevisionSigningResponse{
+		Signature: txn.TransactionSignatures[1].Signature,
+	}
+	err = modules.RPCWrite(stream, resp)
+	if err != nil {
+		return errors.AddContext(err, "failed to send signature to renter")
 	}
-	//	else {
-	//		// TODO: finalize spending for readonly programs once the MR is ready. (#4236)
-	//	}
 
-	// The program was finalized and we don't want to refund the programRefund
-	// anymore.
-	programRefund = types.ZeroCurrency
-	return nil
+	// Finalize the program.
+	return errors.AddContext(finalize(so), "program finalizer failed")
 }
 
-// managedFinalizeWriteProgram conducts the additional steps required to
-// finalize a write program. The blockheight is passed in to make sure we are
-// using the same as when we ran the MDMD.
-func (h *Host) managedFinalizeWriteProgram(stream io.ReadWriter, fcid types.FileContractID, finalize mdm.FnFinalize, lastOutput mdm.Output, bh types.BlockHeight) error {
+// managedSignExecuteProgramRevision verifies the revision the renter sent for
+// a write program and countersigns it. It returns the storage obligation with
+// the updated revision transaction set, ready to be passed to the program's
+// finalizer.
+func (h *Host) managedSignExecuteProgramRevision(fcid types.FileContractID, req modules.RPCExecuteProgramRevisionSigningRequest, lastOutput mdm.Output, bh types.BlockHeight) (storageObligation, types.Transaction, error) {
 	h.mu.Lock()
 	sk := h.secretKey
 	h.mu.Unlock()
@@ -289,25 +332,18 @@ func (h *Host) managedFinalizeWriteProgram(stream io.ReadWriter, fcid types.File
 	// Get the storage obligation with write access.
 	so, err := h.managedGetStorageObligation(fcid)
 	if err != nil {
-		return errors.AddContext(err, "Failed to get storage obligation for finalizing the program")
-	}
-
-	// Get the new revision from the renter.
-	var req modules.RPCExecuteProgramRevisionSigningRequest
-	err = modules.RPCReadMaxLen(stream, &req, maxRPCExecuteProgramRevisionSigningRequestSize)
-	if err != nil {
-		return errors.AddContext(err, "failed to get new revision from renter")
+		return storageObligation{}, types.Transaction{}, errors.AddContext(err, "Failed to get storage obligation for finalizing the program")
 	}
 
 	// Construct the new revision.
 	currentRevision, err := so.recentRevision()
 	if err != nil {
-		return errors.AddContext(err, "failed to get current revision")
+		return storageObligation{}, types.Transaction{}, errors.AddContext(err, "failed to get current revision")
 	}
 	transfer := lastOutput.AdditionalCollateral.Add(lastOutput.FailureRefund)
 	newRevision, err := currentRevision.ExecuteProgramRevision(req.NewRevisionNumber, transfer, lastOutput.NewMerkleRoot, lastOutput.NewSize)
 	if err != nil {
-		return errors.AddContext(err, "failed to construct execute program revision")
+		return storageObligation{}, types.Transaction{}, errors.AddContext(err, "failed to construct execute program revision")
 	}
 
 	// The host is expected to move the additional storage cost and collateral
@@ -317,7 +353,7 @@ func (h *Host) managedFinalizeWriteProgram(stream io.ReadWriter, fcid types.File
 	// Verify the revision.
 	err = verifyExecuteProgramRevision(currentRevision, newRevision, bh, maxTransfer, lastOutput.NewSize, lastOutput.NewMerkleRoot)
 	if err != nil {
-		return errors.AddContext(err, "revision verification failed")
+		return storageObligation{}, types.Transaction{}, errors.AddContext(err, "revision verification failed")
 	}
 
 	// Sign the revision.
@@ -329,25 +365,14 @@ func (h *Host) managedFinalizeWriteProgram(stream io.ReadWriter, fcid types.File
 	}
 	txn, err := createRevisionSignature(newRevision, renterSig, sk, bh)
 	if err != nil {
-		return errors.AddContext(err, "failed to create signature")
+		return storageObligation{}, types.Transaction{}, errors.AddContext(err, "failed to create signature")
 	}
 
 	// Update the storage obligation revision. No need to call
 	// `managedModifyStorageObligation since that will be done by the
 	// finalize(so) function.
 	so.RevisionTransactionSet = []types.Transaction{txn}
-
-	// Send the response to the renter.
-	resp := modules.RPCExecuteProgramRevisionSigningResponse{
-		Signature: txn.TransactionSignatures[1].Signature,
-	}
-	err = modules.RPCWrite(stream, resp)
-	if err != nil {
-		return errors.AddContext(err, "failed to send signature to renter")
-	}
-
-	// Finalize the program.
-	return errors.AddContext(finalize(so), "program finalizer failed")
+	return so, txn, nil
 }
 
 // verifyExecuteProgramRevision verifies that the new revision is sane in
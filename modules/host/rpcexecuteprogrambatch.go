@@ -0,0 +1,299 @@
+package host
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/siamux"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/host/mdm"
+	"go.sia.tech/siad/types"
+)
+
+// maxRPCExecuteProgramBatchRevisionSigningRequestSize is the max size we
+// allocate for reading a RPCExecuteProgramBatchRevisionSigningRequest.
+const maxRPCExecuteProgramBatchRevisionSigningRequestSize = modules.MaxExecuteProgramBatchSize * maxRPCExecuteProgramRevisionSigningRequestSize
+
+// batchWriteProgram contains the information required to finalize a write
+// program of a batch after all programs of the batch were executed.
+type batchWriteProgram struct {
+	fcid       types.FileContractID
+	finalize   mdm.FnFinalize
+	lastOutput mdm.Output
+}
+
+// managedRPCExecuteProgramBatch handles incoming ExecuteProgramBatch RPCs. It
+// executes a sequence of programs, which may target different contracts of
+// the same renter, using a single payment. The write programs of the batch
+// are finalized together after all programs were executed successfully.
+func (h *Host) managedRPCExecuteProgramBatch(stream siamux.Stream) error {
+	// read the price table
+	pt, err := h.staticReadPriceTableID(stream)
+	if err != nil {
+		return errors.AddContext(err, "failed to read price table")
+	}
+
+	// Process payment.
+	pd, err := h.ProcessPayment(stream, pt.HostBlockHeight)
+	if err != nil {
+		return errors.AddContext(err, "failed to process payment")
+	}
+
+	// Add limit to the stream. The budget is shared by all programs of the
+	// batch.
+	budget := modules.NewBudget(pd.Amount())
+	bandwidthLimit := modules.NewBudgetLimit(budget, pt.UploadBandwidthCost, pt.DownloadBandwidthCost)
+	err = stream.SetLimit(bandwidthLimit)
+	if err != nil {
+		return errors.AddContext(err, "failed to set budget limit on stream")
+	}
+
+	// Refund all the money we didn't use at the end of the RPC. The program
+	// refund is the sum of the refunds of all executed programs, which are
+	// only kept by the host if the batch is finalized.
+	refundAccount := pd.AccountID()
+	refundOrigin := streamOrigin(stream)
+	programRefund := types.ZeroCurrency
+	err = h.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		go func() {
+			defer h.tg.Done()
+			depositErr := h.staticAccountManager.callRefund(refundAccount, programRefund.Add(budget.Remaining()), refundOrigin)
+			if depositErr != nil {
+				h.log.Print("ERROR: failed to refund renter", depositErr)
+			}
+		}()
+	}()
+
+	// Read request
+	var req modules.RPCExecuteProgramBatchRequest
+	err = modules.RPCReadMaxLen(stream, &req, maxRPCExecuteProgramRequestSize)
+	if err != nil {
+		return errors.AddContext(err, "Failed to read RPCExecuteProgramBatchRequest")
+	}
+
+	// Validate the batch and figure out which contracts need to be locked.
+	writeContracts, err := validateProgramBatch(req.Programs)
+	if err != nil {
+		return err
+	}
+
+	// Acquire a lock on the storage obligations of all write programs. The
+	// locks are acquired in a deterministic order to avoid deadlocks between
+	// batches that modify the same contracts.
+	for _, fcid := range writeContracts {
+		h.managedLockStorageObligation(fcid)
+		defer h.managedUnlockStorageObligation(fcid)
+	}
+
+	// Get a context that can be used to interrupt the programs and cancel it
+	// on shutdown.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	h.tg.OnStop(cancel)
+
+	// Create a buffer
+	buffer := bytes.NewBuffer(nil)
+
+	// Flush the buffer. Upon success this should be a no-op. If we return early
+	// this will make sure that the cancellation token and anything else in the
+	// buffer are written to the stream.
+	defer func() {
+		if buffer.Len() > 0 {
+			_, err = buffer.WriteTo(stream)
+			if err != nil {
+				h.log.Print("failed to flush buffer", err)
+			}
+		}
+	}()
+
+	// Return 16 bytes of data as a placeholder for a future cancellation
+	// token. A single token covers the whole batch.
+	var ct modules.MDMCancellationToken
+	err = modules.RPCWrite(buffer, ct)
+	if err != nil {
+		return errors.AddContext(err, "Failed to write cancellation token")
+	}
+
+	// Execute the programs one after another.
+	bh := h.BlockHeight()
+	var renterKey string
+	var writes []batchWriteProgram
+	for i, epr := range req.Programs {
+		program := modules.Program(epr.Program)
+
+		// Get a snapshot of the storage obligation if required and make sure
+		// all contracts belong to the same renter.
+		sos := ZeroStorageObligationSnapshot()
+		if program.RequiresSnapshot() {
+			sos, err = h.managedGetStorageObligationSnapshot(epr.FileContractID)
+			if err != nil {
+				return errors.AddContext(err, fmt.Sprintf("failed to get storage obligation snapshot for contract %v", epr.FileContractID))
+			}
+			uc := sos.RecentRevision().UnlockConditions
+			if len(uc.PublicKeys) == 0 {
+				return fmt.Errorf("contract %v has no renter key", epr.FileContractID)
+			}
+			if renterKey == "" {
+				renterKey = uc.PublicKeys[0].String()
+			} else if renterKey != uc.PublicKeys[0].String() {
+				return modules.ErrProgramBatchRenterMismatch
+			}
+		}
+		collateralBudget := sos.UnallocatedCollateral()
+		duration := sos.ProofDeadline() - bh
+
+		// Read the program's data. The MDM fetches the data in the
+		// background so we need to read it upfront to know where the data of
+		// the next program starts.
+		data := bytes.NewBuffer(nil)
+		_, err = io.CopyN(data, stream, int64(epr.ProgramDataLength))
+		if err != nil {
+			return errors.AddContext(err, fmt.Sprintf("failed to read data of program %v", i))
+		}
+
+		// Execute the program.
+		finalize, outputs, err := h.staticMDM.ExecuteProgram(ctx, pt, program, budget, collateralBudget, sos, duration, epr.ProgramDataLength, data)
+		if err != nil {
+			return errors.AddContext(err, fmt.Sprintf("Failed to start execution of program %v", i))
+		}
+
+		// Handle outputs.
+		output, refund, executionFailed, err := h.managedWriteProgramOutputs(stream, buffer, program, outputs)
+		programRefund = programRefund.Add(refund)
+		if err != nil {
+			return err
+		}
+
+		// If the execution failed we return without an error. The peer will
+		// notice the error in the last instruction and know that the batch
+		// was aborted. None of the programs are finalized.
+		if executionFailed {
+			return nil
+		}
+
+		// Remember the write programs for finalizing them later.
+		if !program.ReadOnly() {
+			writes = append(writes, batchWriteProgram{
+				fcid:       epr.FileContractID,
+				finalize:   finalize,
+				lastOutput: output,
+			})
+		}
+	}
+
+	// Make sure the renter received all outputs before we wait for the
+	// revisions.
+	if buffer.Len() > 0 {
+		_, err = buffer.WriteTo(stream)
+		if err != nil {
+			return errors.AddContext(err, "failed to send data to peer")
+		}
+	}
+
+	// Reset the deadline (set both read and write)
+	err = stream.SetDeadline(time.Now().Add(defaultConnectionDeadline))
+	if err != nil {
+		return errors.AddContext(err, "failed to set deadline on stream")
+	}
+
+	// Finalize the write programs.
+	if len(writes) > 0 {
+		err = h.managedFinalizeWriteProgramBatch(stream, writes, bh)
+		if err != nil {
+			return errors.AddContext(err, "failed to finalize write programs")
+		}
+	}
+
+	// The batch was finalized and we don't want to refund the programRefund
+	// anymore.
+	programRefund = types.ZeroCurrency
+	return nil
+}
+
+// managedFinalizeWriteProgramBatch conducts the additional steps required to
+// finalize the write programs of a batch. All revisions are verified before
+// any of the programs is finalized.
+func (h *Host) managedFinalizeWriteProgramBatch(stream io.ReadWriter, writes []batchWriteProgram, bh types.BlockHeight) error {
+	// Get the new revisions from the renter.
+	var req modules.RPCExecuteProgramBatchRevisionSigningRequest
+	err := modules.RPCReadMaxLen(stream, &req, maxRPCExecuteProgramBatchRevisionSigningRequestSize)
+	if err != nil {
+		return errors.AddContext(err, "failed to get new revisions from renter")
+	}
+	if len(req.Revisions) != len(writes) {
+		return fmt.Errorf("expected %v revisions but got %v", len(writes), len(req.Revisions))
+	}
+
+	// Verify and sign the revisions.
+	sos := make([]storageObligation, len(writes))
+	resp := modules.RPCExecuteProgramBatchRevisionSigningResponse{
+		Signatures: make([][]byte, len(writes)),
+	}
+	for i, write := range writes {
+		so, txn, err := h.managedSignExecuteProgramRevision(write.fcid, req.Revisions[i], write.lastOutput, bh)
+		if err != nil {
+			return errors.AddContext(err, fmt.Sprintf("failed to sign revision for contract %v", write.fcid))
+		}
+		sos[i] = so
+		resp.Signatures[i] = txn.TransactionSignatures[1].Signature
+	}
+
+	// Send the response to the renter.
+	err = modules.RPCWrite(stream, resp)
+	if err != nil {
+		return errors.AddContext(err, "failed to send signatures to renter")
+	}
+
+	// Finalize the programs.
+	for i, write := range writes {
+		err = write.finalize(sos[i])
+		if err != nil {
+			return errors.AddContext(err, fmt.Sprintf("program finalizer failed for contract %v", write.fcid))
+		}
+	}
+	return nil
+}
+
+// validateProgramBatch checks that the batch is not empty, doesn't exceed the
+// size limits and that no contract modified by a write program is targeted by
+// another program of the batch. It returns the sorted ids of the contracts
+// that are modified by the batch.
+func validateProgramBatch(programs []modules.RPCExecuteProgramRequest) ([]types.FileContractID, error) {
+	if len(programs) == 0 {
+		return nil, modules.ErrEmptyProgramBatch
+	}
+	if len(programs) > modules.MaxExecuteProgramBatchSize {
+		return nil, modules.ErrProgramBatchTooLarge
+	}
+	var dataLength uint64
+	targets := make(map[types.FileContractID]int)
+	var writeContracts []types.FileContractID
+	for _, epr := range programs {
+		dataLength += epr.ProgramDataLength
+		if epr.ProgramDataLength > modules.MaxExecuteProgramBatchDataLength || dataLength > modules.MaxExecuteProgramBatchDataLength {
+			return nil, modules.ErrProgramBatchTooLarge
+		}
+		targets[epr.FileContractID]++
+		if !modules.Program(epr.Program).ReadOnly() {
+			writeContracts = append(writeContracts, epr.FileContractID)
+		}
+	}
+	for _, fcid := range writeContracts {
+		if targets[fcid] > 1 {
+			return nil, modules.ErrProgramBatchContractConflict
+		}
+	}
+	sort.Slice(writeContracts, func(i, j int) bool {
+		return bytes.Compare(writeContracts[i][:], writeContracts[j][:]) < 0
+	})
+	return writeContracts, nil
+}
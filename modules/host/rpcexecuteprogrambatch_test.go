@@ -0,0 +1,424 @@
+package host
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// managedAddContract adds another storage obligation between the pair's
+// renter and host and returns its id. The host puts up the given collateral.
+func (p *renterHostPair) managedAddContract(collateral types.Currency) (types.FileContractID, error) {
+	so, err := p.staticHT.newTesterStorageObligation()
+	if err != nil {
+		return types.FileContractID{}, err
+	}
+	so, err = p.staticHT.addNoOpRevision(so, p.staticRenterPK)
+	if err != nil {
+		return types.FileContractID{}, err
+	}
+	rev := &so.RevisionTransactionSet[len(so.RevisionTransactionSet)-1].FileContractRevisions[0]
+	rev.NewValidProofOutputs = append([]types.SiacoinOutput(nil), rev.NewValidProofOutputs...)
+	rev.NewMissedProofOutputs = append([]types.SiacoinOutput(nil), rev.NewMissedProofOutputs...)
+	rev.NewValidProofOutputs[1].Value = collateral
+	rev.NewMissedProofOutputs[1].Value = collateral
+	p.staticHT.host.managedLockStorageObligation(so.id())
+	defer p.staticHT.host.managedUnlockStorageObligation(so.id())
+	err = p.staticHT.host.managedAddStorageObligation(so)
+	if err != nil {
+		return types.FileContractID{}, err
+	}
+	return so.id(), nil
+}
+
+// managedExecuteProgramBatch executes a batch of MDM programs on the host
+// using an EA payment and returns the responses of every program. If finalize
+// is true, the write programs of the batch are finalized by signing a new
+// revision for each of them.
+func (p *renterHostPair) managedExecuteProgramBatch(eprs []modules.RPCExecuteProgramRequest, programData [][]byte, budget types.Currency, finalize bool) (_ [][]executeProgramResponse, err error) {
+	p.mdmMu.Lock()
+	defer p.mdmMu.Unlock()
+
+	pt, err := p.managedFetchPriceTable()
+	if err != nil {
+		return nil, err
+	}
+
+	// Write the specifier, price table, payment, request and data.
+	buffer := bytes.NewBuffer(nil)
+	err = modules.RPCWrite(buffer, modules.RPCExecuteProgramBatch)
+	if err != nil {
+		return nil, err
+	}
+	err = modules.RPCWrite(buffer, pt.UID)
+	if err != nil {
+		return nil, err
+	}
+	err = modules.RPCWrite(buffer, modules.PaymentRequest{Type: modules.PayByEphemeralAccount})
+	if err != nil {
+		return nil, err
+	}
+	pbear := modules.NewPayByEphemeralAccountRequest(p.staticAccountID, pt.HostBlockHeight, budget, p.staticAccountKey)
+	err = modules.RPCWrite(buffer, pbear)
+	if err != nil {
+		return nil, err
+	}
+	err = modules.RPCWrite(buffer, modules.RPCExecuteProgramBatchRequest{Programs: eprs})
+	if err != nil {
+		return nil, err
+	}
+	for _, data := range programData {
+		_, err = buffer.Write(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// create stream
+	stream := p.managedNewStream()
+	defer func() {
+		err = errors.Compose(err, stream.Close())
+	}()
+	_, err = stream.Write(buffer.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	// Read the cancellation token.
+	var ct modules.MDMCancellationToken
+	err = modules.RPCRead(stream, &ct)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read the responses.
+	responses := make([][]executeProgramResponse, len(eprs))
+	var revisions []modules.RPCExecuteProgramRevisionSigningRequest
+	var newRevisions []types.FileContractRevision
+	for i, epr := range eprs {
+		responses[i] = make([]executeProgramResponse, len(epr.Program))
+		for j := range epr.Program {
+			err = modules.RPCRead(stream, &responses[i][j])
+			if err != nil {
+				return nil, err
+			}
+			responses[i][j].Output = make([]byte, responses[i][j].OutputLength)
+			_, err = io.ReadFull(stream, responses[i][j].Output)
+			if err != nil {
+				return nil, err
+			}
+			// If the response contains an error the batch was aborted.
+			if responses[i][j].Error != nil {
+				return responses[:i+1], nil
+			}
+		}
+		if epr.Program.ReadOnly() {
+			continue
+		}
+		// Prepare the revision for the write program.
+		lastOutput := responses[i][len(responses[i])-1]
+		req, newRevision, err := p.managedExecuteProgramRevision(epr.FileContractID, lastOutput)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, req)
+		newRevisions = append(newRevisions, newRevision)
+	}
+
+	// when we purposefully don't finalize, we can't wait for the host to close
+	// the stream.
+	if !finalize {
+		return responses, nil
+	}
+
+	// Finalize the write programs.
+	if len(revisions) > 0 {
+		err = modules.RPCWrite(stream, modules.RPCExecuteProgramBatchRevisionSigningRequest{Revisions: revisions})
+		if err != nil {
+			return nil, err
+		}
+		var resp modules.RPCExecuteProgramBatchRevisionSigningResponse
+		err = modules.RPCRead(stream, &resp)
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Signatures) != len(revisions) {
+			return nil, errors.New("wrong number of signatures")
+		}
+		for i, newRevision := range newRevisions {
+			txn := types.Transaction{
+				FileContractRevisions: []types.FileContractRevision{newRevision},
+				TransactionSignatures: []types.TransactionSignature{
+					{
+						ParentID:       crypto.Hash(newRevision.ParentID),
+						PublicKeyIndex: 0,
+						CoveredFields:  types.CoveredFields{FileContractRevisions: []uint64{0}},
+						Signature:      revisions[i].Signature,
+					},
+					{
+						ParentID:       crypto.Hash(newRevision.ParentID),
+						PublicKeyIndex: 1,
+						CoveredFields:  types.CoveredFields{FileContractRevisions: []uint64{0}},
+						Signature:      resp.Signatures[i],
+					},
+				},
+			}
+			err = modules.VerifyFileContractRevisionTransactionSignatures(newRevision, txn.TransactionSignatures, p.staticHT.host.BlockHeight())
+			if err != nil {
+				return nil, errors.AddContext(err, "signature verification failed")
+			}
+		}
+	}
+
+	// The next read should return io.EOF since the host closes the connection
+	// after the RPC is done.
+	err = modules.RPCRead(stream, struct{}{})
+	if !errors.Contains(err, io.ErrClosedPipe) {
+		return nil, err
+	}
+	return responses, nil
+}
+
+// managedExecuteProgramRevision creates the signed revision request that
+// finalizes a write program on the contract with the given id.
+func (p *renterHostPair) managedExecuteProgramRevision(fcid types.FileContractID, lastOutput executeProgramResponse) (modules.RPCExecuteProgramRevisionSigningRequest, types.FileContractRevision, error) {
+	so, err := p.staticHT.host.managedGetStorageObligation(fcid)
+	if err != nil {
+		return modules.RPCExecuteProgramRevisionSigningRequest{}, types.FileContractRevision{}, err
+	}
+	recent, err := so.recentRevision()
+	if err != nil {
+		return modules.RPCExecuteProgramRevisionSigningRequest{}, types.FileContractRevision{}, err
+	}
+	transfer := lastOutput.AdditionalCollateral.Add(lastOutput.FailureRefund)
+	newRevision, err := recent.ExecuteProgramRevision(recent.NewRevisionNumber+1, transfer, lastOutput.NewMerkleRoot, lastOutput.NewSize)
+	if err != nil {
+		return modules.RPCExecuteProgramRevisionSigningRequest{}, types.FileContractRevision{}, err
+	}
+	renterSig := p.managedSign(newRevision)
+	return modules.RPCExecuteProgramRevisionSigningRequest{
+		Signature:         renterSig[:],
+		NewRevisionNumber: newRevision.NewRevisionNumber,
+	}, newRevision, nil
+}
+
+// TestExecuteProgramBatch tests executing a batch of append programs that
+// target different contracts of the same renter.
+func TestExecuteProgramBatch(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// create a testing pair.
+	rhp, err := newRenterHostPair(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := rhp.Close()
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+	host := rhp.staticHT.host
+
+	// add a second contract for the same renter.
+	fcid2, err := rhp.managedAddContract(types.SiacoinPrecision)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fcids := []types.FileContractID{rhp.staticFCID, fcid2}
+
+	// fund an account.
+	pt := rhp.managedPriceTable()
+	maxBalance := host.managedInternalSettings().MaxEphemeralAccountBalance
+	_, err = rhp.managedFundEphemeralAccount(maxBalance.Add(pt.FundAccountCost), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// helper to create an append program for a contract.
+	appendProgram := func(fcid types.FileContractID) (modules.RPCExecuteProgramRequest, []byte, crypto.Hash) {
+		so, err := host.managedGetStorageObligation(fcid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		duration := so.proofDeadline() - host.BlockHeight()
+		sector := fastrand.Bytes(int(modules.SectorSize))
+		pb := modules.NewProgramBuilder(pt, duration)
+		err = pb.AddAppendInstruction(sector, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		program, data := pb.Program()
+		return modules.RPCExecuteProgramRequest{
+			FileContractID:    fcid,
+			Program:           program,
+			ProgramDataLength: uint64(len(data)),
+		}, data, crypto.MerkleRoot(sector)
+	}
+
+	// remember the revision numbers before executing the batch.
+	revNums := make([]uint64, len(fcids))
+	for i, fcid := range fcids {
+		so, err := host.managedGetStorageObligation(fcid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rev, err := so.recentRevision()
+		if err != nil {
+			t.Fatal(err)
+		}
+		revNums[i] = rev.NewRevisionNumber
+	}
+
+	// execute a batch that appends a sector to both contracts.
+	var eprs []modules.RPCExecuteProgramRequest
+	var data [][]byte
+	var roots []crypto.Hash
+	for _, fcid := range fcids {
+		epr, d, root := appendProgram(fcid)
+		eprs = append(eprs, epr)
+		data = append(data, d)
+		roots = append(roots, root)
+	}
+	budget := maxBalance.Div64(2)
+	resps, err := rhp.managedExecuteProgramBatch(eprs, data, budget, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resps) != len(eprs) {
+		t.Fatalf("expected %v program responses but got %v", len(eprs), len(resps))
+	}
+	for i, resp := range resps {
+		if len(resp) != 1 {
+			t.Fatalf("expected 1 response but got %v", len(resp))
+		}
+		if resp[0].Error != nil {
+			t.Fatal(resp[0].Error)
+		}
+		if resp[0].NewMerkleRoot != roots[i] {
+			t.Fatal("wrong merkle root", resp[0].NewMerkleRoot, roots[i])
+		}
+	}
+
+	// both contracts should have been updated.
+	for i, fcid := range fcids {
+		so, err := host.managedGetStorageObligation(fcid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rev, err := so.recentRevision()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rev.NewRevisionNumber != revNums[i]+1 {
+			t.Fatalf("revision number wasn't incremented by 1 %v %v", revNums[i], rev.NewRevisionNumber)
+		}
+		if len(so.SectorRoots) != 1 || so.SectorRoots[0] != roots[i] {
+			t.Fatal("contract doesn't contain the appended sector", so.SectorRoots)
+		}
+		if !host.HasSector(roots[i]) {
+			t.Fatal("host doesn't have the appended sector")
+		}
+	}
+
+	// executing a batch that targets a contract of another renter should fail.
+	rhp2, err := newRenterHostPairCustomHostTester(rhp.staticHT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rhp2.staticRenterMux.Close(); err != nil {
+			t.Error(err)
+		}
+	}()
+	eprs, data = eprs[:0], data[:0]
+	for _, fcid := range []types.FileContractID{rhp.staticFCID, rhp2.staticFCID} {
+		epr, d, _ := appendProgram(fcid)
+		eprs = append(eprs, epr)
+		data = append(data, d)
+	}
+	_, err = rhp.managedExecuteProgramBatch(eprs, data, budget, true)
+	if err == nil || !strings.Contains(err.Error(), modules.ErrProgramBatchRenterMismatch.Error()) {
+		t.Fatal("expected renter mismatch error but got", err)
+	}
+
+	// the first contract shouldn't have been updated.
+	so, err := host.managedGetStorageObligation(rhp.staticFCID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(so.SectorRoots) != 1 {
+		t.Fatal("contract was updated by aborted batch", len(so.SectorRoots))
+	}
+}
+
+// TestValidateProgramBatch is a unit test for validateProgramBatch.
+func TestValidateProgramBatch(t *testing.T) {
+	t.Parallel()
+
+	pt := modules.RPCPriceTable{}
+	readProgram := func(fcid types.FileContractID) modules.RPCExecuteProgramRequest {
+		pb := modules.NewProgramBuilder(&pt, 0)
+		pb.AddHasSectorInstruction(crypto.Hash{})
+		program, _ := pb.Program()
+		return modules.RPCExecuteProgramRequest{FileContractID: fcid, Program: program}
+	}
+	writeProgram := func(fcid types.FileContractID) modules.RPCExecuteProgramRequest {
+		pb := modules.NewProgramBuilder(&pt, 0)
+		pb.AddDropSectorsInstruction(1, false)
+		program, _ := pb.Program()
+		return modules.RPCExecuteProgramRequest{FileContractID: fcid, Program: program}
+	}
+	fcid1 := types.FileContractID{1}
+	fcid2 := types.FileContractID{2}
+
+	// empty batch
+	_, err := validateProgramBatch(nil)
+	if !errors.Contains(err, modules.ErrEmptyProgramBatch) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// too many programs
+	programs := make([]modules.RPCExecuteProgramRequest, modules.MaxExecuteProgramBatchSize+1)
+	for i := range programs {
+		programs[i] = readProgram(fcid1)
+	}
+	_, err = validateProgramBatch(programs)
+	if !errors.Contains(err, modules.ErrProgramBatchTooLarge) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// too much data
+	tooMuchData := readProgram(fcid1)
+	tooMuchData.ProgramDataLength = modules.MaxExecuteProgramBatchDataLength + 1
+	_, err = validateProgramBatch([]modules.RPCExecuteProgramRequest{tooMuchData})
+	if !errors.Contains(err, modules.ErrProgramBatchTooLarge) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// a contract modified by a write program can't be targeted by another
+	// program.
+	_, err = validateProgramBatch([]modules.RPCExecuteProgramRequest{writeProgram(fcid1), readProgram(fcid1)})
+	if !errors.Contains(err, modules.ErrProgramBatchContractConflict) {
+		t.Fatal("unexpected error", err)
+	}
+
+	// valid batch, the write contracts should be sorted.
+	writeContracts, err := validateProgramBatch([]modules.RPCExecuteProgramRequest{writeProgram(fcid2), readProgram(types.FileContractID{}), readProgram(types.FileContractID{}), writeProgram(fcid1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(writeContracts) != 2 || writeContracts[0] != fcid1 || writeContracts[1] != fcid2 {
+		t.Fatal("unexpected write contracts", writeContracts)
+	}
+}
@@ -39,7 +39,7 @@ func (h *Host) managedRPCLatestRevision(stream siamux.Stream) (err error) {
 	}
 
 	// Process payment.
-	pd, _, err := h.ProcessPayment(stream, pt.HostBlockHeight)
+	pd, err := h.ProcessPayment(stream, pt.HostBlockHeight)
 	if err != nil {
 		return errors.AddContext(err, "failed to process payment")
 	}
@@ -52,7 +52,7 @@ func (h *Host) managedRPCLatestRevision(stream siamux.Stream) (err error) {
 	// Refund excessive payment.
 	refund := pd.Amount().Sub(pt.LatestRevisionCost)
 	if !refund.IsZero() {
-		err = h.staticAccountManager.callRefund(pd.AccountID(), refund)
+		err = h.staticAccountManager.callRefund(pd.AccountID(), refund, streamOrigin(stream))
 		if err != nil {
 			return errors.AddContext(err, "failed to refund excessive payment")
 		}
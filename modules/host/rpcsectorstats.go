@@ -0,0 +1,67 @@
+package host
+
+import (
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/siamux"
+	"go.sia.tech/siad/modules"
+)
+
+// managedRPCSectorStats handles the RPC which returns whether the host stores
+// the requested sectors and how large they are. It allows a renter to check a
+// batch of roots across all of its contracts with the host at once.
+func (h *Host) managedRPCSectorStats(stream siamux.Stream) error {
+	// read the price table
+	pt, err := h.staticReadPriceTableID(stream)
+	if err != nil {
+		return errors.AddContext(err, "failed to read price table")
+	}
+
+	// Process payment.
+	pd, err := h.ProcessPayment(stream, pt.HostBlockHeight)
+	if err != nil {
+		return errors.AddContext(err, "failed to process payment")
+	}
+
+	// Read request
+	var ssr modules.SectorStatsRequest
+	err = modules.RPCReadMaxLen(stream, &ssr, modules.SectorStatsRequestMaxLen)
+	if err != nil {
+		return errors.AddContext(err, "Failed to read SectorStatsRequest")
+	}
+	if len(ssr.Roots) > modules.MaxSectorStatsRoots {
+		return modules.ErrTooManySectorStatsRoots
+	}
+
+	// Check payment.
+	cost := modules.SectorStatsCost(pt, uint64(len(ssr.Roots)))
+	if pd.Amount().Cmp(cost) < 0 {
+		return modules.ErrInsufficientPaymentForRPC
+	}
+
+	// Refund excessive payment.
+	refund := pd.Amount().Sub(cost)
+	err = h.staticAccountManager.callRefund(pd.AccountID(), refund, streamOrigin(stream))
+	if err != nil {
+		return errors.AddContext(err, "failed to refund client")
+	}
+
+	// Collect the stats. The host only stores full sectors.
+	stats := make([]modules.SectorStat, len(ssr.Roots))
+	for i, root := range ssr.Roots {
+		if h.HasSector(root) {
+			stats[i] = modules.SectorStat{
+				Exists: true,
+				Size:   modules.SectorSize,
+			}
+		}
+	}
+
+	// Send response.
+	err = modules.RPCWrite(stream, modules.SectorStatsResponse{
+		Stats: stats,
+	})
+	if err != nil {
+		return errors.AddContext(err, "Failed to send SectorStatsResponse")
+	}
+	return nil
+}
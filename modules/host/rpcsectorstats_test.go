@@ -0,0 +1,72 @@
+package host
+
+import (
+	"strings"
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestSectorStats verifies the SectorStats RPC.
+func TestSectorStats(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// create a blank host tester
+	rhp, err := newRenterHostPair(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := rhp.Close()
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+	host := rhp.staticHT.host
+
+	// Add a sector to the host.
+	sectorData := fastrand.Bytes(int(modules.SectorSize))
+	sectorRoot := crypto.MerkleRoot(sectorData)
+	err = host.AddSector(sectorRoot, sectorData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Request the stats of the sector and a random one.
+	var randomRoot crypto.Hash
+	fastrand.Read(randomRoot[:])
+	roots := []crypto.Hash{sectorRoot, randomRoot}
+	cost := modules.SectorStatsCost(rhp.pt, uint64(len(roots)))
+	stats, err := rhp.managedSectorStats(true, cost, roots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != len(roots) {
+		t.Fatalf("expected %v stats but got %v", len(roots), len(stats))
+	}
+	if !stats[0].Exists || stats[0].Size != modules.SectorSize {
+		t.Fatal("unexpected stat for existing sector", stats[0])
+	}
+	if stats[1].Exists || stats[1].Size != 0 {
+		t.Fatal("unexpected stat for random sector", stats[1])
+	}
+
+	// Paying less than the cost should fail.
+	_, err = rhp.managedSectorStats(true, cost.Sub64(1), roots)
+	if err == nil || !strings.Contains(err.Error(), modules.ErrInsufficientPaymentForRPC.Error()) {
+		t.Fatal("expected ErrInsufficientPaymentForRPC but got:", err)
+	}
+
+	// Requesting too many roots should fail.
+	roots = make([]crypto.Hash, modules.MaxSectorStatsRoots+1)
+	cost = modules.SectorStatsCost(rhp.pt, uint64(len(roots)))
+	_, err = rhp.managedSectorStats(true, cost, roots)
+	if err == nil || !strings.Contains(err.Error(), modules.ErrTooManySectorStatsRoots.Error()) {
+		t.Fatal("expected ErrTooManySectorStatsRoots but got:", err)
+	}
+}
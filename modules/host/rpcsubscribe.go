@@ -10,6 +10,8 @@ import (
 	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
 	"gitlab.com/NebulousLabs/siamux"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
 )
@@ -35,6 +37,18 @@ type (
 		subscriptions    map[modules.RegistryEntryID]struct{}
 		mu               sync.Mutex
 
+		// batched indicates whether notifications are batched. It's set once
+		// the subscriber subscribes with a filtered request. pending contains
+		// the updates which are sent with the next batch, only the latest
+		// update of an entry is kept.
+		batched        bool
+		flushScheduled bool
+		pending        map[modules.RegistryEntryID]modules.RPCRegistrySubscriptionNotificationEntryUpdate
+
+		// token is the token of the subscription set the session is bound
+		// to. It's nil if the session isn't bound to a set.
+		token *crypto.Hash
+
 		staticBudget     *modules.RPCBudget
 		staticID         subscriptionInfoID
 		staticStream     siamux.Stream
@@ -45,6 +59,14 @@ type (
 )
 
 var (
+	// subscriptionNotificationBatchInterval is the interval at which batched
+	// notifications are sent to a subscriber.
+	subscriptionNotificationBatchInterval = build.Select(build.Var{
+		Dev:      500 * time.Millisecond,
+		Standard: time.Second,
+		Testing:  100 * time.Millisecond,
+	}).(time.Duration)
+
 	// ErrSubscriptionRequestLimitReached is returned if too many subscribe or
 	// unsubscribe requests are sent at once.
 	ErrSubscriptionRequestLimitReached = errors.New("number of requests exceeds limit")
@@ -63,6 +85,7 @@ func newSubscriptionInfo(stream siamux.Stream, budget *modules.RPCBudget, notifi
 		notificationCost: notificationsCost,
 		latestRevNum:     make(map[modules.RegistryEntryID]uint64),
 		subscriptions:    make(map[modules.RegistryEntryID]struct{}),
+		pending:          make(map[modules.RegistryEntryID]modules.RPCRegistrySubscriptionNotificationEntryUpdate),
 		staticBudget:     budget,
 		staticStream:     stream,
 		staticSubscriber: hex.EncodeToString(subscriber[:]),
@@ -71,6 +94,17 @@ func newSubscriptionInfo(stream siamux.Stream, budget *modules.RPCBudget, notifi
 	return info
 }
 
+// managedToken returns the token of the subscription set the session is bound
+// to and whether it is bound to a set at all.
+func (info *subscriptionInfo) managedToken() (crypto.Hash, bool) {
+	info.mu.Lock()
+	defer info.mu.Unlock()
+	if info.token == nil {
+		return crypto.Hash{}, false
+	}
+	return *info.token, true
+}
+
 // AddSubscriptions adds one or multiple subscriptions.
 func (rs *registrySubscriptions) AddSubscriptions(info *subscriptionInfo, entryIDs ...modules.RegistryEntryID) {
 	// Add to the info first.
@@ -130,9 +164,16 @@ func (h *Host) managedHandleSubscribeRequest(info *subscriptionInfo, pt *modules
 	// Send initial values.
 	ids := make([]modules.RegistryEntryID, 0, len(rsrs))
 	rvs := make([]modules.SignedRegistryValue, 0, len(ids))
+	entries := make([]subscriptionSetEntry, 0, len(rsrs))
 	for _, rsr := range rsrs {
 		ids = append(ids, modules.DeriveRegistryEntryID(rsr.PubKey, rsr.Tweak))
 		_, rv, found := h.staticRegistry.Get(modules.DeriveRegistryEntryID(rsr.PubKey, rsr.Tweak))
+		entries = append(entries, subscriptionSetEntry{
+			PubKey:   rsr.PubKey,
+			Tweak:    rsr.Tweak,
+			Revision: rv.Revision,
+			Known:    found,
+		})
 		if !found {
 			continue
 		}
@@ -149,6 +190,87 @@ func (h *Host) managedHandleSubscribeRequest(info *subscriptionInfo, pt *modules
 
 	// Add the subscriptions.
 	h.staticRegistrySubscriptions.AddSubscriptions(info, ids...)
+	if token, bound := info.managedToken(); bound {
+		err = h.staticSubscriptionSets.managedAddEntries(token, entries)
+		if err != nil {
+			return errors.AddContext(err, "failed to add entries to subscription set")
+		}
+	}
+
+	// Write initial values to the stream.
+	err = modules.RPCWrite(stream, rvs)
+	if err != nil {
+		return errors.AddContext(err, "failed to write initial values to stream")
+	}
+	return nil
+}
+
+// managedHandleSubscribeFilteredRequest handles a new filtered subscription.
+// Only revisions greater than the requested min revisions are sent to the
+// subscriber and notifications are batched from now on.
+func (h *Host) managedHandleSubscribeFilteredRequest(info *subscriptionInfo, pt *modules.RPCPriceTable) error {
+	stream := info.staticStream
+
+	// Read the requests.
+	var rsrs []modules.RPCRegistrySubscriptionFilteredRequest
+	err := modules.RPCRead(stream, &rsrs)
+	if err != nil {
+		return errors.AddContext(err, "failed to read filtered subscription request")
+	}
+
+	// Send initial values which are newer than the min revision.
+	ids := make([]modules.RegistryEntryID, 0, len(rsrs))
+	minRevisions := make(map[modules.RegistryEntryID]uint64, len(rsrs))
+	rvs := make([]modules.SignedRegistryValue, 0, len(rsrs))
+	entries := make([]subscriptionSetEntry, 0, len(rsrs))
+	for _, rsr := range rsrs {
+		id := modules.DeriveRegistryEntryID(rsr.PubKey, rsr.Tweak)
+		ids = append(ids, id)
+		minRevisions[id] = rsr.MinRevision
+		_, rv, found := h.staticRegistry.Get(id)
+		if found && rv.Revision > rsr.MinRevision {
+			minRevisions[id] = rv.Revision
+			rvs = append(rvs, rv)
+		}
+		entries = append(entries, subscriptionSetEntry{
+			PubKey:   rsr.PubKey,
+			Tweak:    rsr.Tweak,
+			Revision: minRevisions[id],
+			Known:    true,
+		})
+	}
+
+	// Compute the subscription cost.
+	cost := modules.MDMSubscribeCost(pt, uint64(len(rvs)), uint64(len(ids)))
+
+	// Withdraw from the budget.
+	if !info.staticBudget.Withdraw(cost) {
+		return errors.AddContext(modules.ErrInsufficientPaymentForRPC, "managedHandleSubscribeFilteredRequest")
+	}
+
+	// Remember the min revisions to filter out notifications and switch to
+	// batched notifications. Pending updates at or below the new min revision
+	// are dropped.
+	info.mu.Lock()
+	info.batched = true
+	for id, minRevision := range minRevisions {
+		if latest, exists := info.latestRevNum[id]; !exists || latest < minRevision {
+			info.latestRevNum[id] = minRevision
+		}
+		if update, exists := info.pending[id]; exists && update.Entry.Revision <= minRevision {
+			delete(info.pending, id)
+		}
+	}
+	info.mu.Unlock()
+
+	// Add the subscriptions.
+	h.staticRegistrySubscriptions.AddSubscriptions(info, ids...)
+	if token, bound := info.managedToken(); bound {
+		err = h.staticSubscriptionSets.managedAddEntries(token, entries)
+		if err != nil {
+			return errors.AddContext(err, "failed to add entries to subscription set")
+		}
+	}
 
 	// Write initial values to the stream.
 	err = modules.RPCWrite(stream, rvs)
@@ -158,6 +280,84 @@ func (h *Host) managedHandleSubscribeRequest(info *subscriptionInfo, pt *modules
 	return nil
 }
 
+// managedHandleResumeRequest binds the session to a subscription set and
+// subscribes to the set's entries. The subscriber receives the latest values
+// of the entries which were updated since the revision of the set it provided.
+func (h *Host) managedHandleResumeRequest(info *subscriptionInfo, pt *modules.RPCPriceTable) error {
+	stream := info.staticStream
+
+	// Read the request.
+	var rsr modules.RPCRegistrySubscriptionResumeRequest
+	err := modules.RPCRead(stream, &rsr)
+	if err != nil {
+		return errors.AddContext(err, "failed to read resume request")
+	}
+
+	// Unbind the session from the set it was bound to before.
+	if token, bound := info.managedToken(); bound {
+		h.staticSubscriptionSets.managedUnbind(token)
+		info.mu.Lock()
+		info.token = nil
+		info.mu.Unlock()
+	}
+
+	// Bind the session to the set and fetch the missed updates.
+	get := func(id modules.RegistryEntryID) (modules.SignedRegistryValue, bool) {
+		_, rv, found := h.staticRegistry.Get(id)
+		return rv, found
+	}
+	revision, entries, updates, err := h.staticSubscriptionSets.managedResume(rsr.Token, rsr.Since, get)
+	if err != nil {
+		return errors.AddContext(err, "failed to resume subscription set")
+	}
+
+	// Compute the subscription cost and withdraw from the budget.
+	cost := modules.MDMSubscribeCost(pt, uint64(len(updates)), uint64(len(entries)))
+	if !info.staticBudget.Withdraw(cost) {
+		h.staticSubscriptionSets.managedUnbind(rsr.Token)
+		return errors.AddContext(modules.ErrInsufficientPaymentForRPC, "managedHandleResumeRequest")
+	}
+
+	// Bind the session and remember the revisions the subscriber knows about.
+	ids := make([]modules.RegistryEntryID, 0, len(entries))
+	info.mu.Lock()
+	info.token = &rsr.Token
+	for _, entry := range entries {
+		id := modules.DeriveRegistryEntryID(entry.PubKey, entry.Tweak)
+		ids = append(ids, id)
+		if !entry.Known {
+			continue
+		}
+		if latest, exists := info.latestRevNum[id]; !exists || latest < entry.Revision {
+			info.latestRevNum[id] = entry.Revision
+		}
+	}
+	info.mu.Unlock()
+
+	// Add the subscriptions.
+	h.staticRegistrySubscriptions.AddSubscriptions(info, ids...)
+
+	// Write the response and the updates to the stream.
+	buf := new(bytes.Buffer)
+	err = modules.RPCWrite(buf, modules.RPCRegistrySubscriptionResumeResponse{
+		Revision:         revision,
+		NumSubscriptions: uint64(len(entries)),
+		NumUpdates:       uint64(len(updates)),
+	})
+	if err != nil {
+		return errors.AddContext(err, "failed to write resume response to buffer")
+	}
+	err = modules.RPCWrite(buf, updates)
+	if err != nil {
+		return errors.AddContext(err, "failed to write updates to buffer")
+	}
+	_, err = buf.WriteTo(stream)
+	if err != nil {
+		return errors.AddContext(err, "failed to write resume response to stream")
+	}
+	return nil
+}
+
 // managedHandleStopSubscription gracefully disables notifications and waits for
 // ongoing notifications to be sent.
 func (h *Host) managedHandleStopSubscription(info *subscriptionInfo) error {
@@ -185,6 +385,12 @@ func (h *Host) managedHandleUnsubscribeRequest(info *subscriptionInfo, pt *modul
 
 	// Remove the subscription.
 	h.staticRegistrySubscriptions.RemoveSubscriptions(info, ids)
+	if token, bound := info.managedToken(); bound {
+		err = h.staticSubscriptionSets.managedRemoveEntries(token, ids)
+		if err != nil {
+			return errors.AddContext(err, "failed to remove entries from subscription set")
+		}
+	}
 
 	// Respond with "OK".
 	err = modules.RPCWrite(stream, modules.RPCRegistrySubscriptionNotificationType{
@@ -254,7 +460,7 @@ func (h *Host) managedHandleExtendSubscriptionRequest(stream siamux.Stream, oldD
 // is done.
 func (h *Host) managedHandlePrepayBandwidth(stream siamux.Stream, info *subscriptionInfo, pt *modules.RPCPriceTable) error {
 	// Process payment.
-	pd, _, err := h.ProcessPayment(stream, pt.HostBlockHeight)
+	pd, err := h.ProcessPayment(stream, pt.HostBlockHeight)
 	if err != nil {
 		return errors.AddContext(err, "managedHandlePrepaybandwidth: failed to process payment")
 	}
@@ -318,6 +524,19 @@ func (h *Host) threadedNotifySubscribers(pubKey types.SiaPublicKey, rv modules.S
 			}
 			info.latestRevNum[id] = rv.Revision
 
+			// Queue the update if notifications are batched.
+			if info.batched {
+				info.pending[id] = modules.RPCRegistrySubscriptionNotificationEntryUpdate{
+					Entry:  rv,
+					PubKey: pubKey,
+				}
+				if !info.flushScheduled {
+					info.flushScheduled = true
+					go h.threadedFlushNotifications(info)
+				}
+				return
+			}
+
 			// Withdraw the base notification cost.
 			ok := info.staticBudget.Withdraw(info.notificationCost)
 			if !ok {
@@ -338,10 +557,85 @@ func (h *Host) threadedNotifySubscribers(pubKey types.SiaPublicKey, rv modules.S
 				h.log.Debug("failed to write notification to buffer", err)
 				return
 			}
+
+			// Remember that the subscriber knows about the update.
+			if info.token != nil {
+				h.staticSubscriptionSets.managedRecordUpdate(*info.token, id, rv.Revision)
+			}
 		}(info)
 	}
 }
 
+// threadedFlushNotifications sends the pending updates of a subscriber with
+// batched notifications after waiting for the batch interval. Updates are sent
+// in batches of up to SubscriptionNotificationBatchSize entries.
+func (h *Host) threadedFlushNotifications(info *subscriptionInfo) {
+	err := h.tg.Add()
+	if err != nil {
+		return
+	}
+	defer h.tg.Done()
+
+	// Wait for more updates to accumulate.
+	select {
+	case <-h.tg.StopChan():
+		return
+	case <-time.After(subscriptionNotificationBatchInterval):
+	}
+
+	info.mu.Lock()
+	defer info.mu.Unlock()
+	info.flushScheduled = false
+	if info.closed || len(info.pending) == 0 {
+		return
+	}
+	updates := make([]modules.RPCRegistrySubscriptionNotificationEntryUpdate, 0, len(info.pending))
+	for id, update := range info.pending {
+		// Skip updates for entries the subscriber unsubscribed from.
+		if _, subscribed := info.subscriptions[id]; subscribed {
+			updates = append(updates, update)
+		}
+		delete(info.pending, id)
+	}
+
+	for len(updates) > 0 {
+		n := len(updates)
+		if n > modules.SubscriptionNotificationBatchSize {
+			n = modules.SubscriptionNotificationBatchSize
+		}
+		batch := updates[:n]
+		updates = updates[n:]
+
+		// Withdraw the notification cost of every update in the batch.
+		ok := info.staticBudget.Withdraw(info.notificationCost.Mul64(uint64(len(batch))))
+		if !ok {
+			return
+		}
+
+		// Get a response stream and notify the subscriber.
+		err := func() error {
+			stream, err := subscriptionResponseStream(info, h.staticMux)
+			if err != nil {
+				return errors.AddContext(err, "failed to open stream for notifying subscriber")
+			}
+			defer stream.Close()
+			return sendBatchedNotification(stream, batch)
+		}()
+		if err != nil {
+			h.log.Debug("failed to send batched notification", err)
+			return
+		}
+
+		// Remember that the subscriber knows about the updates.
+		if info.token != nil {
+			for _, update := range batch {
+				id := modules.DeriveRegistryEntryID(update.PubKey, update.Entry.Tweak)
+				h.staticSubscriptionSets.managedRecordUpdate(*info.token, id, update.Entry.Revision)
+			}
+		}
+	}
+}
+
 // subscriptionResponseStream opens a response stream using the given siamux to
 // a subsriber.
 func subscriptionResponseStream(info *subscriptionInfo, sm *siamux.SiaMux) (siamux.Stream, error) {
@@ -366,7 +660,7 @@ func (h *Host) managedRPCRegistrySubscribe(stream siamux.Stream) (_ afterCloseFn
 	}
 
 	// Process bandwidth payment.
-	pd, _, err := h.ProcessPayment(stream, pt.HostBlockHeight)
+	pd, err := h.ProcessPayment(stream, pt.HostBlockHeight)
 	if err != nil {
 		return nil, errors.AddContext(err, "failed to process payment")
 	}
@@ -388,7 +682,7 @@ func (h *Host) managedRPCRegistrySubscribe(stream siamux.Stream) (_ afterCloseFn
 	refund := func() {
 		// Refund the unused budget
 		if !budget.Remaining().IsZero() {
-			err = errors.Compose(err, h.staticAccountManager.callRefund(pd.AccountID(), budget.Remaining()))
+			err = errors.Compose(err, h.staticAccountManager.callRefund(pd.AccountID(), budget.Remaining(), streamOrigin(stream)))
 		}
 	}
 	err = stream.SetLimit(bandwidthLimit)
@@ -415,6 +709,9 @@ func (h *Host) managedRPCRegistrySubscribe(stream siamux.Stream) (_ afterCloseFn
 		}
 		info.mu.Unlock()
 		h.staticRegistrySubscriptions.RemoveSubscriptions(info, entryIDs)
+		if token, bound := info.managedToken(); bound {
+			h.staticSubscriptionSets.managedUnbind(token)
+		}
 	}()
 
 	// The subscription RPC is a request/response loop that continues for as
@@ -431,8 +728,12 @@ func (h *Host) managedRPCRegistrySubscribe(stream siamux.Stream) (_ afterCloseFn
 		switch requestType {
 		case modules.SubscriptionRequestSubscribe:
 			err = h.managedHandleSubscribeRequest(info, pt)
+		case modules.SubscriptionRequestSubscribeFiltered:
+			err = h.managedHandleSubscribeFilteredRequest(info, pt)
 		case modules.SubscriptionRequestUnsubscribe:
 			err = h.managedHandleUnsubscribeRequest(info, pt)
+		case modules.SubscriptionRequestResume:
+			err = h.managedHandleResumeRequest(info, pt)
 		case modules.SubscriptionRequestExtend:
 			pt, deadline, err = h.managedHandleExtendSubscriptionRequest(stream, deadline, info, bandwidthLimit)
 		case modules.SubscriptionRequestPrepay:
@@ -473,3 +774,24 @@ func sendNotification(stream io.Writer, spk types.SiaPublicKey, rv modules.Signe
 	}
 	return nil
 }
+
+// sendBatchedNotification marshals a batch of entry notifications and writes
+// it to the provided writer.
+func sendBatchedNotification(stream io.Writer, updates []modules.RPCRegistrySubscriptionNotificationEntryUpdate) error {
+	buf := new(bytes.Buffer)
+	err := modules.RPCWrite(buf, modules.RPCRegistrySubscriptionNotificationType{
+		Type: modules.SubscriptionResponseRegistryValues,
+	})
+	if err != nil {
+		return errors.AddContext(err, "failed to write notification header to buffer")
+	}
+	err = modules.RPCWrite(buf, updates)
+	if err != nil {
+		return errors.AddContext(err, "failed to write entries to buffer")
+	}
+	_, err = buf.WriteTo(stream)
+	if err != nil {
+		return errors.AddContext(err, "failed to write notification to stream")
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"math"
 	"reflect"
 	"strings"
@@ -170,6 +171,12 @@ func TestRPCSubscribe(t *testing.T) {
 	t.Run("Concurrent", func(t *testing.T) {
 		testRPCSubscribeConcurrent(t, rhp)
 	})
+	t.Run("Filtered", func(t *testing.T) {
+		testRPCSubscribeFiltered(t, rhp)
+	})
+	t.Run("Resume", func(t *testing.T) {
+		testRPCSubscribeResume(t, rhp)
+	})
 }
 
 // testRPCSubscribeBasic tests subscribing to an entry and unsubscribing without
@@ -996,3 +1003,255 @@ func testRPCSubscribeConcurrent(t *testing.T, rhp *renterHostPair) {
 		t.Fatalf("wrong balance %v != %v", currentBalance, expected)
 	}
 }
+
+// testRPCSubscribeFiltered tests that a filtered subscription only returns
+// revisions above the requested min revision and that the following
+// notifications are batched.
+func testRPCSubscribeFiltered(t *testing.T, rhp *renterHostPair) {
+	// Prepare a listener for the worker.
+	notificationReader, notificationWriter := io.Pipe()
+	var sub types.Specifier
+	fastrand.Read(sub[:])
+	err := rhp.staticRenterMux.NewListener(hex.EncodeToString(sub[:]), func(stream siamux.Stream) {
+		defer func() {
+			if err := stream.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+		io.Copy(notificationWriter, stream)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err = rhp.staticRenterMux.CloseListener(hex.EncodeToString(sub[:]))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Create two registry values and set them on the host.
+	expiry := types.BlockHeight(1000)
+	host := rhp.staticHT.host
+	rv1, spk1, sk1 := randomRegistryValue()
+	rv2, spk2, sk2 := randomRegistryValue()
+	rv2.Revision++ // make sure the revision is greater than 0
+	rv2 = rv2.Sign(sk2)
+	if _, err = host.RegistryUpdate(rv1, spk1, expiry); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = host.RegistryUpdate(rv2, spk2, expiry); err != nil {
+		t.Fatal(err)
+	}
+
+	// fund the account.
+	currentBalance := host.staticAccountManager.callAccountBalance(rhp.staticAccountID)
+	expectedBalance := modules.DefaultHostExternalSettings().MaxEphemeralAccountBalance
+	_, err = rhp.managedFundEphemeralAccount(rhp.pt.FundAccountCost.Add(expectedBalance).Sub(currentBalance), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// begin the subscription loop.
+	stream, err := rhp.BeginSubscription(expectedBalance.Div64(2), sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Subscribe to both entries. The first one is already known, so only the
+	// second one should be returned.
+	rvs, err := modules.RPCSubscribeToRVsFiltered(stream, []modules.RPCRegistrySubscriptionFilteredRequest{
+		{PubKey: spk1, Tweak: rv1.Tweak, MinRevision: rv1.Revision},
+		{PubKey: spk2, Tweak: rv2.Tweak, MinRevision: rv2.Revision - 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rvs) != 1 || !reflect.DeepEqual(rvs[0].Entry, rv2) {
+		t.Fatal("expected only the second entry to be returned", rvs)
+	}
+	err = assertNumSubscriptions(host, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Update the first entry twice and the second one once.
+	rv1.Revision++
+	rv1 = rv1.Sign(sk1)
+	if _, err = host.RegistryUpdate(rv1, spk1, expiry); err != nil {
+		t.Fatal(err)
+	}
+	rv1.Revision++
+	rv1 = rv1.Sign(sk1)
+	if _, err = host.RegistryUpdate(rv1, spk1, expiry); err != nil {
+		t.Fatal(err)
+	}
+	rv2.Revision++
+	rv2 = rv2.Sign(sk2)
+	if _, err = host.RegistryUpdate(rv2, spk2, expiry); err != nil {
+		t.Fatal(err)
+	}
+
+	// Both updates should arrive within a single batch which only contains
+	// the latest revision of the first entry.
+	var snt modules.RPCRegistrySubscriptionNotificationType
+	err = modules.RPCRead(notificationReader, &snt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snt.Type != modules.SubscriptionResponseRegistryValues {
+		t.Fatal("notification has wrong type", snt.Type)
+	}
+	var sneus []modules.RPCRegistrySubscriptionNotificationEntryUpdate
+	err = modules.RPCReadMaxLen(notificationReader, &sneus, modules.SubscriptionNotificationBatchMaxLen)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sneus) != 2 {
+		t.Fatal("expected 2 updates in batch", len(sneus))
+	}
+	for _, sneu := range sneus {
+		if sneu.PubKey.Equals(spk1) && !reflect.DeepEqual(sneu.Entry, rv1) {
+			t.Fatal("wrong entry for first subscription")
+		} else if sneu.PubKey.Equals(spk2) && !reflect.DeepEqual(sneu.Entry, rv2) {
+			t.Fatal("wrong entry for second subscription")
+		}
+	}
+
+	// Close the subscription.
+	if err := rhp.StopSubscription(stream); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testRPCSubscribeResume tests resuming the subscriptions of a subscription set
+// in a new session and receiving the updates which were missed in between.
+func testRPCSubscribeResume(t *testing.T, rhp *renterHostPair) {
+	// Prepare a listener for the worker.
+	var sub types.Specifier
+	fastrand.Read(sub[:])
+	err := rhp.staticRenterMux.NewListener(hex.EncodeToString(sub[:]), func(stream siamux.Stream) {
+		defer func() {
+			if err := stream.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+		io.Copy(ioutil.Discard, stream)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err = rhp.staticRenterMux.CloseListener(hex.EncodeToString(sub[:]))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Create two registry values and set them on the host.
+	expiry := types.BlockHeight(1000)
+	host := rhp.staticHT.host
+	rv1, spk1, sk1 := randomRegistryValue()
+	rv2, spk2, _ := randomRegistryValue()
+	if _, err = host.RegistryUpdate(rv1, spk1, expiry); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = host.RegistryUpdate(rv2, spk2, expiry); err != nil {
+		t.Fatal(err)
+	}
+
+	// fund the account.
+	currentBalance := host.staticAccountManager.callAccountBalance(rhp.staticAccountID)
+	expectedBalance := modules.DefaultHostExternalSettings().MaxEphemeralAccountBalance
+	_, err = rhp.managedFundEphemeralAccount(rhp.pt.FundAccountCost.Add(expectedBalance).Sub(currentBalance), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Begin a subscription and bind it to a new set.
+	var token crypto.Hash
+	fastrand.Read(token[:])
+	stream, err := rhp.BeginSubscription(expectedBalance.Div64(4), sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, updates, err := modules.RPCResumeSubscription(stream, token, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Revision != 0 || resp.NumSubscriptions != 0 || len(updates) != 0 {
+		t.Fatal("new set should be empty", resp, updates)
+	}
+
+	// Subscribe to both entries. The set should contain them afterwards.
+	_, err = modules.RPCSubscribeToRVsFiltered(stream, []modules.RPCRegistrySubscriptionFilteredRequest{
+		{PubKey: spk1, Tweak: rv1.Tweak, MinRevision: rv1.Revision},
+		{PubKey: spk2, Tweak: rv2.Tweak, MinRevision: rv2.Revision},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rhp.StopSubscription(stream); err != nil {
+		t.Fatal(err)
+	}
+
+	// Update the first entry while there is no session.
+	rv1.Revision++
+	rv1 = rv1.Sign(sk1)
+	if _, err = host.RegistryUpdate(rv1, spk1, expiry); err != nil {
+		t.Fatal(err)
+	}
+
+	// The set should survive a restart of the host.
+	err = host.staticSubscriptionSets.managedFlush()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := newSubscriptionSets(host.db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if set, exists := reloaded.sets[token]; !exists || len(set.entries) != 2 {
+		t.Fatal("set wasn't persisted", set)
+	}
+
+	// Resume the set in a new session. Only the missed update should be
+	// returned and the session should be subscribed to both entries.
+	stream, err = rhp.BeginSubscription(expectedBalance.Div64(4), sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, updates, err = modules.RPCResumeSubscription(stream, token, resp.Revision)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Revision != 1 || resp.NumSubscriptions != 2 {
+		t.Fatal("unexpected response", resp)
+	}
+	if len(updates) != 1 || !updates[0].PubKey.Equals(spk1) || !reflect.DeepEqual(updates[0].Entry, rv1) {
+		t.Fatal("expected the missed update", updates)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		_, err := assertSubscriptionInfos(host, spk1, rv1.Tweak, 1)
+		if err != nil {
+			return err
+		}
+		_, err = assertSubscriptionInfos(host, spk2, rv2.Tweak, 1)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Resuming again with the latest revision doesn't return any updates.
+	resp, updates, err = modules.RPCResumeSubscription(stream, token, resp.Revision)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Revision != 1 || resp.NumSubscriptions != 2 || len(updates) != 0 {
+		t.Fatal("unexpected response", resp, updates)
+	}
+	if err := rhp.StopSubscription(stream); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,209 @@
+package host
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/siamux/mux"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	siasync "go.sia.tech/siad/sync"
+	"go.sia.tech/siad/types"
+)
+
+// rpcNameUnknown is the name under which RPCs with an unknown or unreadable id
+// are traced.
+const rpcNameUnknown = "unknown"
+
+var (
+	// rpcTraceBufferSize is the number of recent RPC calls the host keeps in
+	// its trace.
+	rpcTraceBufferSize = build.Select(build.Var{
+		Standard: 1000,
+		Dev:      1000,
+		Testing:  10,
+	}).(int)
+
+	// rpcLatencyBuckets are the upper bounds of the buckets of the RPC
+	// latency histograms.
+	rpcLatencyBuckets = []time.Duration{
+		time.Millisecond,
+		5 * time.Millisecond,
+		10 * time.Millisecond,
+		50 * time.Millisecond,
+		100 * time.Millisecond,
+		500 * time.Millisecond,
+		time.Second,
+		5 * time.Second,
+		10 * time.Second,
+		time.Minute,
+	}
+
+	// rpcErrorCodes maps the errors returned by RPCs to their error codes.
+	rpcErrorCodes = []struct {
+		code string
+		errs []error
+	}{
+		{modules.HostRPCErrorCodePriceTable, []error{
+			modules.ErrPriceTableNotFound,
+			modules.ErrPriceTableExpired,
+			modules.ErrExpiredRPCPriceTable,
+		}},
+		{modules.HostRPCErrorCodePayment, []error{
+			modules.ErrInsufficientPaymentForRPC,
+			modules.ErrInvalidAccount,
+			modules.ErrInvalidPaymentMethod,
+			modules.ErrUnknownPaymentMethod,
+			modules.ErrWithdrawalsInactive,
+			modules.ErrWithdrawalExpired,
+			modules.ErrWithdrawalExtremeFuture,
+			modules.ErrWithdrawalInvalidSignature,
+			ErrAccountExpired,
+			ErrBalanceInsufficient,
+			ErrBalanceMaxExceeded,
+			ErrWithdrawalSpent,
+			ErrZeroAccountID,
+		}},
+		{modules.HostRPCErrorCodeShutdown, []error{
+			ErrDepositCancelled,
+			ErrWithdrawalCancelled,
+			siasync.ErrStopped,
+		}},
+		{modules.HostRPCErrorCodeTimeout, []error{
+			mux.ErrStreamTimedOut,
+		}},
+	}
+)
+
+// rpcTracer records the RPC calls made to the host. It keeps the most recent
+// calls in a ring buffer and aggregates statistics for every RPC.
+type rpcTracer struct {
+	recent     []modules.HostRPCTraceEntry
+	nextRecent int
+	stats      map[string]*modules.HostRPCStats
+
+	// staticExporter exports the traced calls to an OpenTelemetry collector.
+	// It is nil if exporting is disabled.
+	staticExporter *otlpExporter
+
+	mu sync.Mutex
+}
+
+// newRPCTracer creates a new tracer. If exporter is not nil, every recorded
+// call is also queued for exporting.
+func newRPCTracer(exporter *otlpExporter) *rpcTracer {
+	return &rpcTracer{
+		recent:         make([]modules.HostRPCTraceEntry, 0, rpcTraceBufferSize),
+		stats:          make(map[string]*modules.HostRPCStats),
+		staticExporter: exporter,
+	}
+}
+
+// rpcName returns the name of an RPC used in the trace. Trailing padding and
+// version bytes are removed from the id.
+func rpcName(id types.Specifier) string {
+	return strings.TrimRightFunc(string(id[:]), func(r rune) bool {
+		return !unicode.IsPrint(r)
+	})
+}
+
+// rpcErrorCode returns the error code for an error returned by an RPC.
+func rpcErrorCode(err error) string {
+	for _, ec := range rpcErrorCodes {
+		for _, e := range ec.errs {
+			if errors.Contains(err, e) {
+				return ec.code
+			}
+		}
+	}
+	return modules.HostRPCErrorCodeInternal
+}
+
+// managedRecord adds a call to the trace.
+func (rt *rpcTracer) managedRecord(entry modules.HostRPCTraceEntry) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	// Add the call to the ring buffer.
+	if len(rt.recent) < rpcTraceBufferSize {
+		rt.recent = append(rt.recent, entry)
+	} else {
+		rt.recent[rt.nextRecent] = entry
+	}
+	rt.nextRecent = (rt.nextRecent + 1) % rpcTraceBufferSize
+
+	// Update the stats of the RPC.
+	stats, exists := rt.stats[entry.RPC]
+	if !exists {
+		stats = &modules.HostRPCStats{
+			RPC:              entry.RPC,
+			Errors:           make(map[string]uint64),
+			LatencyHistogram: make([]uint64, len(rpcLatencyBuckets)+1),
+		}
+		rt.stats[entry.RPC] = stats
+	}
+	stats.Calls++
+	stats.BytesUploaded += entry.BytesUploaded
+	stats.BytesDownloaded += entry.BytesDownloaded
+	stats.TotalLatency += entry.Duration
+	if entry.ErrorCode != "" {
+		stats.Errors[entry.ErrorCode]++
+	}
+	bucket := sort.Search(len(rpcLatencyBuckets), func(i int) bool {
+		return entry.Duration <= rpcLatencyBuckets[i]
+	})
+	stats.LatencyHistogram[bucket]++
+
+	if rt.staticExporter != nil {
+		rt.staticExporter.managedQueue(entry)
+	}
+}
+
+// managedTrace returns a copy of the trace.
+func (rt *rpcTracer) managedTrace() modules.HostRPCTrace {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	trace := modules.HostRPCTrace{
+		LatencyBuckets: append([]time.Duration(nil), rpcLatencyBuckets...),
+		Stats:          make([]modules.HostRPCStats, 0, len(rt.stats)),
+		Recent:         make([]modules.HostRPCTraceEntry, 0, len(rt.recent)),
+	}
+	for _, stats := range rt.stats {
+		s := *stats
+		s.Errors = make(map[string]uint64, len(stats.Errors))
+		for code, n := range stats.Errors {
+			s.Errors[code] = n
+		}
+		s.LatencyHistogram = append([]uint64(nil), stats.LatencyHistogram...)
+		trace.Stats = append(trace.Stats, s)
+	}
+	sort.Slice(trace.Stats, func(i, j int) bool {
+		return trace.Stats[i].RPC < trace.Stats[j].RPC
+	})
+
+	// Once the ring buffer is full, the oldest entry is the next one to be
+	// overwritten.
+	if len(rt.recent) == rpcTraceBufferSize {
+		trace.Recent = append(trace.Recent, rt.recent[rt.nextRecent:]...)
+		trace.Recent = append(trace.Recent, rt.recent[:rt.nextRecent]...)
+	} else {
+		trace.Recent = append(trace.Recent, rt.recent...)
+	}
+	return trace
+}
+
+// RPCTrace returns the latency, bandwidth and error statistics of the RPCs the
+// host handled over the SiaMux.
+func (h *Host) RPCTrace() modules.HostRPCTrace {
+	if err := h.tg.Add(); err != nil {
+		return modules.HostRPCTrace{}
+	}
+	defer h.tg.Done()
+	return h.staticRPCTracer.managedTrace()
+}
@@ -0,0 +1,186 @@
+package host
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/siamux/mux"
+
+	"go.sia.tech/siad/modules"
+)
+
+// TestRPCErrorCode is a unit test for rpcErrorCode.
+func TestRPCErrorCode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		err  error
+		code string
+	}{
+		{errors.AddContext(modules.ErrPriceTableNotFound, "failed"), modules.HostRPCErrorCodePriceTable},
+		{errors.Compose(ErrBalanceInsufficient, errors.New("write failed")), modules.HostRPCErrorCodePayment},
+		{ErrWithdrawalCancelled, modules.HostRPCErrorCodeShutdown},
+		{errors.AddContext(mux.ErrStreamTimedOut, "failed to read"), modules.HostRPCErrorCodeTimeout},
+		{errors.New("unknown"), modules.HostRPCErrorCodeInternal},
+	}
+	for _, test := range tests {
+		if code := rpcErrorCode(test.err); code != test.code {
+			t.Errorf("expected code %v for '%v' but got %v", test.code, test.err, code)
+		}
+	}
+}
+
+// TestRPCTracer is a unit test for the rpcTracer.
+func TestRPCTracer(t *testing.T) {
+	t.Parallel()
+
+	rt := newRPCTracer(nil)
+	start := time.Now()
+	total := rpcTraceBufferSize + 5
+	for i := 0; i < total; i++ {
+		entry := modules.HostRPCTraceEntry{
+			RPC:             rpcName(modules.RPCAccountBalance),
+			Start:           start.Add(time.Duration(i) * time.Second),
+			Duration:        2 * time.Millisecond,
+			BytesUploaded:   10,
+			BytesDownloaded: 20,
+		}
+		if i%2 == 0 {
+			entry.ErrorCode = modules.HostRPCErrorCodePayment
+			entry.Error = ErrBalanceInsufficient.Error()
+		}
+		rt.managedRecord(entry)
+	}
+	rt.managedRecord(modules.HostRPCTraceEntry{
+		RPC:      rpcName(modules.RPCExecuteProgram),
+		Start:    start,
+		Duration: 2 * time.Minute,
+	})
+
+	trace := rt.managedTrace()
+	if len(trace.LatencyBuckets) != len(rpcLatencyBuckets) {
+		t.Fatal("wrong number of buckets", len(trace.LatencyBuckets))
+	}
+	if len(trace.Stats) != 2 {
+		t.Fatal("wrong number of stats", len(trace.Stats))
+	}
+	ab, ep := trace.Stats[0], trace.Stats[1]
+	if ab.RPC != "AccountBalance" || ep.RPC != "ExecuteProgram" {
+		t.Fatal("wrong rpc names", ab.RPC, ep.RPC)
+	}
+	if ab.Calls != uint64(total) || ab.BytesUploaded != uint64(10*total) || ab.BytesDownloaded != uint64(20*total) {
+		t.Fatal("wrong stats", ab)
+	}
+	if ab.TotalLatency != time.Duration(total)*2*time.Millisecond {
+		t.Fatal("wrong latency", ab.TotalLatency)
+	}
+	if ab.Errors[modules.HostRPCErrorCodePayment] != uint64((total+1)/2) {
+		t.Fatal("wrong errors", ab.Errors)
+	}
+	if ab.LatencyHistogram[1] != uint64(total) {
+		t.Fatal("wrong histogram", ab.LatencyHistogram)
+	}
+	if ep.LatencyHistogram[len(rpcLatencyBuckets)] != 1 {
+		t.Fatal("call not in overflow bucket", ep.LatencyHistogram)
+	}
+
+	// The ring buffer should contain the most recent calls starting with the
+	// oldest one.
+	if len(trace.Recent) != rpcTraceBufferSize {
+		t.Fatal("wrong number of recent calls", len(trace.Recent))
+	}
+	if trace.Recent[len(trace.Recent)-1].RPC != ep.RPC {
+		t.Fatal("last call should be the most recent one")
+	}
+	for i := 0; i < len(trace.Recent)-1; i++ {
+		expected := start.Add(time.Duration(total-rpcTraceBufferSize+1+i) * time.Second)
+		if !trace.Recent[i].Start.Equal(expected) {
+			t.Fatal("wrong order of recent calls", i)
+		}
+	}
+
+	// The trace is a copy.
+	trace.Stats[0].Errors[modules.HostRPCErrorCodePayment] = 0
+	trace.Stats[0].LatencyHistogram[1] = 0
+	trace = rt.managedTrace()
+	if trace.Stats[0].Errors[modules.HostRPCErrorCodePayment] == 0 || trace.Stats[0].LatencyHistogram[1] == 0 {
+		t.Fatal("trace wasn't copied")
+	}
+}
+
+// TestOTLPExporter tests that the exporter sends the queued calls to the
+// collector.
+func TestOTLPExporter(t *testing.T) {
+	t.Parallel()
+
+	reqs := make(chan otlpTraceRequest, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req otlpTraceRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		reqs <- req
+	}))
+	defer srv.Close()
+
+	e := newOTLPExporter(srv.URL, "ed25519:abc")
+
+	// Flushing an empty queue doesn't send a request.
+	if _, err := e.managedFlush(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-reqs:
+		t.Fatal("unexpected request")
+	default:
+	}
+
+	// Fill the queue beyond its limit.
+	start := time.Now()
+	for i := 0; i < rpcTraceExportQueueSize+3; i++ {
+		e.managedQueue(modules.HostRPCTraceEntry{
+			RPC:       "AccountBalance",
+			Start:     start,
+			Duration:  time.Second,
+			ErrorCode: modules.HostRPCErrorCodeTimeout,
+			Error:     mux.ErrStreamTimedOut.Error(),
+		})
+	}
+	dropped, err := e.managedFlush()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dropped != 3 {
+		t.Fatal("wrong number of dropped calls", dropped)
+	}
+	req := <-reqs
+	if len(req.ResourceSpans) != 1 || len(req.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatal("wrong request layout")
+	}
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != rpcTraceExportQueueSize {
+		t.Fatal("wrong number of spans", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "AccountBalance" || span.Kind != otlpSpanKindServer || span.Status.Code != otlpStatusCodeError {
+		t.Fatal("wrong span", span)
+	}
+	if len(span.TraceID) != 32 || len(span.SpanID) != 16 {
+		t.Fatal("wrong ids", span.TraceID, span.SpanID)
+	}
+	if span.EndTimeUnixNano == span.StartTimeUnixNano {
+		t.Fatal("wrong end time")
+	}
+
+	// A failing collector returns an error.
+	srv.Close()
+	e.managedQueue(modules.HostRPCTraceEntry{RPC: "AccountBalance", Start: start})
+	if _, err := e.managedFlush(); err == nil {
+		t.Fatal("expected error")
+	}
+}
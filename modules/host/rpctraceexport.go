@@ -0,0 +1,269 @@
+package host
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+)
+
+const (
+	// otlpSpanKindServer is the OTLP span kind of a span which covers the
+	// handling of a remote request.
+	otlpSpanKindServer = 2
+
+	// otlpStatusCodeOK and otlpStatusCodeError are the OTLP status codes of
+	// successful and failed spans.
+	otlpStatusCodeOK    = 1
+	otlpStatusCodeError = 2
+)
+
+var (
+	// rpcTraceExportInterval is the interval at which queued RPC traces are
+	// exported to the OpenTelemetry collector.
+	rpcTraceExportInterval = build.Select(build.Var{
+		Standard: 10 * time.Second,
+		Dev:      5 * time.Second,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// rpcTraceExportQueueSize is the maximum number of RPC traces which are
+	// queued for exporting. Traces are dropped if the collector can't keep up.
+	rpcTraceExportQueueSize = build.Select(build.Var{
+		Standard: 10000,
+		Dev:      1000,
+		Testing:  100,
+	}).(int)
+
+	// rpcTraceExportTimeout is the timeout of a single export request.
+	rpcTraceExportTimeout = build.Select(build.Var{
+		Standard: 10 * time.Second,
+		Dev:      5 * time.Second,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+)
+
+type (
+	// otlpExporter exports traced RPC calls as spans to an OpenTelemetry
+	// collector using OTLP/HTTP with JSON encoding.
+	otlpExporter struct {
+		queue   []modules.HostRPCTraceEntry
+		dropped uint64
+
+		staticClient   *http.Client
+		staticEndpoint string
+		staticResource otlpResource
+
+		mu sync.Mutex
+	}
+
+	// otlpTraceRequest is the body of an OTLP/HTTP trace export request.
+	otlpTraceRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+
+	// otlpResourceSpans are the spans produced by a single resource.
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+
+	// otlpResource describes the entity producing the spans.
+	otlpResource struct {
+		Attributes []otlpAttribute `json:"attributes"`
+	}
+
+	// otlpScopeSpans are the spans produced by a single instrumentation scope.
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+
+	// otlpScope is an instrumentation scope.
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+
+	// otlpSpan is a single traced operation.
+	otlpSpan struct {
+		TraceID           string          `json:"traceId"`
+		SpanID            string          `json:"spanId"`
+		Name              string          `json:"name"`
+		Kind              int             `json:"kind"`
+		StartTimeUnixNano string          `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+		Attributes        []otlpAttribute `json:"attributes"`
+		Status            otlpStatus      `json:"status"`
+	}
+
+	// otlpStatus is the status of a span.
+	otlpStatus struct {
+		Code    int    `json:"code"`
+		Message string `json:"message,omitempty"`
+	}
+
+	// otlpAttribute is a key value pair describing a span or resource.
+	otlpAttribute struct {
+		Key   string       `json:"key"`
+		Value otlpAnyValue `json:"value"`
+	}
+
+	// otlpAnyValue is the value of an attribute. Only one of the fields is
+	// set. OTLP encodes 64-bit integers as strings in JSON.
+	otlpAnyValue struct {
+		StringValue *string `json:"stringValue,omitempty"`
+		IntValue    *string `json:"intValue,omitempty"`
+	}
+)
+
+// newOTLPExporter creates an exporter which sends the spans of the host with
+// the provided public key to the collector at endpoint.
+func newOTLPExporter(endpoint string, pk string) *otlpExporter {
+	return &otlpExporter{
+		staticClient:   &http.Client{Timeout: rpcTraceExportTimeout},
+		staticEndpoint: endpoint,
+		staticResource: otlpResource{
+			Attributes: []otlpAttribute{
+				otlpStringAttribute("service.name", "siad"),
+				otlpStringAttribute("service.version", build.NodeVersion),
+				otlpStringAttribute("sia.host.publickey", pk),
+			},
+		},
+	}
+}
+
+// otlpStringAttribute returns an attribute with a string value.
+func otlpStringAttribute(key, value string) otlpAttribute {
+	return otlpAttribute{Key: key, Value: otlpAnyValue{StringValue: &value}}
+}
+
+// otlpIntAttribute returns an attribute with an integer value.
+func otlpIntAttribute(key string, value uint64) otlpAttribute {
+	v := strconv.FormatUint(value, 10)
+	return otlpAttribute{Key: key, Value: otlpAnyValue{IntValue: &v}}
+}
+
+// otlpSpanFromEntry converts a traced RPC call into a span. Every call is the
+// root of its own trace since the renter doesn't propagate a trace context.
+func otlpSpanFromEntry(entry modules.HostRPCTraceEntry) otlpSpan {
+	span := otlpSpan{
+		TraceID:           hex.EncodeToString(fastrand.Bytes(16)),
+		SpanID:            hex.EncodeToString(fastrand.Bytes(8)),
+		Name:              entry.RPC,
+		Kind:              otlpSpanKindServer,
+		StartTimeUnixNano: strconv.FormatInt(entry.Start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(entry.Start.Add(entry.Duration).UnixNano(), 10),
+		Attributes: []otlpAttribute{
+			otlpStringAttribute("rpc.system", "siamux"),
+			otlpStringAttribute("rpc.method", entry.RPC),
+			otlpIntAttribute("sia.rpc.bytes_uploaded", entry.BytesUploaded),
+			otlpIntAttribute("sia.rpc.bytes_downloaded", entry.BytesDownloaded),
+		},
+		Status: otlpStatus{Code: otlpStatusCodeOK},
+	}
+	if entry.ErrorCode != "" {
+		span.Attributes = append(span.Attributes, otlpStringAttribute("sia.rpc.error_code", entry.ErrorCode))
+		span.Status = otlpStatus{
+			Code:    otlpStatusCodeError,
+			Message: entry.Error,
+		}
+	}
+	return span
+}
+
+// managedQueue queues a traced RPC call for exporting. The call is dropped if
+// the queue is full.
+func (e *otlpExporter) managedQueue(entry modules.HostRPCTraceEntry) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.queue) >= rpcTraceExportQueueSize {
+		e.dropped++
+		return
+	}
+	e.queue = append(e.queue, entry)
+}
+
+// managedFlush exports all queued calls. The number of calls which were
+// dropped since the last flush is returned.
+func (e *otlpExporter) managedFlush() (dropped uint64, err error) {
+	e.mu.Lock()
+	queue := e.queue
+	dropped = e.dropped
+	e.queue = nil
+	e.dropped = 0
+	e.mu.Unlock()
+	if len(queue) == 0 {
+		return dropped, nil
+	}
+
+	spans := make([]otlpSpan, 0, len(queue))
+	for _, entry := range queue {
+		spans = append(spans, otlpSpanFromEntry(entry))
+	}
+	body, err := json.Marshal(otlpTraceRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: e.staticResource,
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: "go.sia.tech/siad/modules/host"},
+				Spans: spans,
+			}},
+		}},
+	})
+	if err != nil {
+		return dropped, errors.AddContext(err, "failed to encode spans")
+	}
+	resp, err := e.staticClient.Post(e.staticEndpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return dropped, errors.AddContext(err, "failed to send spans")
+	}
+	defer func() {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return dropped, fmt.Errorf("collector responded with status %v", resp.Status)
+	}
+	return dropped, nil
+}
+
+// managedExportRPCTraces exports the queued RPC traces and logs any failures.
+func (h *Host) managedExportRPCTraces() {
+	dropped, err := h.staticRPCTracer.staticExporter.managedFlush()
+	if err != nil {
+		h.log.Debugln("WARN: failed to export RPC traces:", err)
+	}
+	if dropped > 0 {
+		h.log.Debugf("WARN: dropped %v RPC traces since the export queue was full", dropped)
+	}
+}
+
+// threadedExportRPCTraces periodically exports the queued RPC traces to the
+// OpenTelemetry collector.
+func (h *Host) threadedExportRPCTraces() {
+	for {
+		select {
+		case <-h.tg.StopChan():
+			return
+		case <-time.After(rpcTraceExportInterval):
+		}
+		func() {
+			if err := h.tg.Add(); err != nil {
+				return
+			}
+			defer h.tg.Done()
+			h.managedExportRPCTraces()
+		}()
+	}
+}
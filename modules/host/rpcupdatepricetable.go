@@ -47,7 +47,16 @@ func (hpt *hostRPCPriceTable) Expiry() time.Time {
 	return hpt.creation.Add(hpt.Validity)
 }
 
-// PopExpired returns the UIDs for all rpc price tables that have expired
+// GraceExpiry returns the latest time until which the host might accept the
+// price table for in-flight RPCs. It is the price table's expiry extended by
+// its grace period, which is the case if the price table was superseded right
+// before it expired.
+func (hpt *hostRPCPriceTable) GraceExpiry() time.Time {
+	return hpt.Expiry().Add(hpt.GracePeriod)
+}
+
+// PopExpired returns the UIDs for all rpc price tables that have expired and
+// whose grace period ran out
 func (pth *priceTableHeap) PopExpired() (expired []modules.UniqueID) {
 	pth.mu.Lock()
 	defer pth.mu.Unlock()
@@ -55,7 +64,7 @@ func (pth *priceTableHeap) PopExpired() (expired []modules.UniqueID) {
 	now := time.Now()
 	for pth.heap.Len() > 0 {
 		pt := heap.Pop(&pth.heap).(*hostRPCPriceTable)
-		if now.Before(pt.Expiry()) {
+		if now.Before(pt.GraceExpiry()) {
 			heap.Push(&pth.heap, pt)
 			break
 		}
@@ -74,7 +83,7 @@ func (pth *priceTableHeap) Push(pt *hostRPCPriceTable) {
 // Implementation of heap.Interface for rpcPriceTableHeap.
 func (pth rpcPriceTableHeap) Len() int { return len(pth) }
 func (pth rpcPriceTableHeap) Less(i, j int) bool {
-	return pth[i].Expiry().Before(pth[j].Expiry())
+	return pth[i].GraceExpiry().Before(pth[j].GraceExpiry())
 }
 func (pth rpcPriceTableHeap) Swap(i, j int) { pth[i], pth[j] = pth[j], pth[i] }
 func (pth *rpcPriceTableHeap) Push(x interface{}) {
@@ -102,6 +111,10 @@ func (h *Host) managedPriceTableForRenter() *modules.RPCPriceTable {
 	// set the validity to signal how long these prices are guaranteed for
 	pt.Validity = rpcPriceGuaranteePeriod
 
+	// set the grace period to signal how long the price table is still
+	// accepted for in-flight RPCs after the renter was issued a new one
+	pt.GracePeriod = h.managedInternalSettings().PriceTableGracePeriod
+
 	// set the host's current blockheight, this allows the renter to create
 	// valid withdrawal messages in case it is not synced yet
 	pt.HostBlockHeight = h.BlockHeight()
@@ -111,63 +124,59 @@ func (h *Host) managedPriceTableForRenter() *modules.RPCPriceTable {
 // managedRPCUpdatePriceTable returns a copy of the host's current rpc price
 // table. These prices are valid for the duration of the
 // rpcPriceGuaranteePeriod, which is defined by the price table's Expiry
-func (h *Host) managedRPCUpdatePriceTable(stream siamux.Stream) (out string, err error) {
-	start := time.Now()
+func (h *Host) managedRPCUpdatePriceTable(stream siamux.Stream) (err error) {
 	pt := *h.managedPriceTableForRenter()
 
 	// json encode the price table
 	ptBytes, err := json.Marshal(pt)
 	if err != nil {
-		return out, errors.AddContext(err, "Failed to JSON encode the price table")
+		return errors.AddContext(err, "Failed to JSON encode the price table")
 	}
 
 	// send it to the renter
 	uptResp := modules.RPCUpdatePriceTableResponse{PriceTableJSON: ptBytes}
 	if err = modules.RPCWrite(stream, uptResp); err != nil {
-		return out, errors.AddContext(err, "Failed to write response")
+		return errors.AddContext(err, "Failed to write response")
 	}
-	out += fmt.Sprintf("send PT took %v\n", time.Since(start))
 
 	// Note that we have sent the price table before processing payment for this
 	// RPC. This allows the renter to check for price gouging and close out the
 	// stream if it does not agree with pricing. The price table has not yet
 	// been added to the map, which means that the renter has to pay for it in
 	// order for it to became active and accepted by the host.
-	start = time.Now()
-	payment, out2, err := h.ProcessPayment(stream, pt.HostBlockHeight)
-	out += out2
+	payment, err := h.ProcessPayment(stream, pt.HostBlockHeight)
 	if errors.Contains(err, io.ErrClosedPipe) {
-		out += fmt.Sprintf("err close pipe - process payment took %v\n", time.Since(start))
-		return out, nil // renter didn't intend to pay
+		return nil // renter didn't intend to pay
 	}
 	if err != nil {
-		return out, errors.AddContext(err, "Failed to process payment")
+		return errors.AddContext(err, "Failed to process payment")
 	}
-	out += fmt.Sprintf("process payment took %v\n", time.Since(start))
 
 	// Check payment.
 	if payment.Amount().Cmp(pt.UpdatePriceTableCost) < 0 {
-		return out, modules.ErrInsufficientPaymentForRPC
+		return modules.ErrInsufficientPaymentForRPC
 	}
 
 	// refund the money we didn't use.
 	defer func() {
-		start = time.Now()
 		refund := payment.Amount().Sub(pt.UpdatePriceTableCost)
-		err = errors.Compose(err, h.staticAccountManager.callRefund(payment.AccountID(), refund))
-		out += fmt.Sprintf("refund took %v\n", time.Since(start))
+		err = errors.Compose(err, h.staticAccountManager.callRefund(payment.AccountID(), refund, streamOrigin(stream)))
 	}()
 
 	// after payment has been received, track the price table in the host's list
-	// of price tables and signal the renter we consider the price table valid
-	start = time.Now()
-	h.staticPriceTables.managedTrack(&hostRPCPriceTable{pt, time.Now()})
-	var tracked modules.RPCTrackedPriceTableResponse
+	// of price tables and signal the renter we consider the price table valid.
+	// The renter's previous price table is still accepted within its grace
+	// period to not fail RPCs that race the update.
+	version, previous := h.staticPriceTables.managedTrack(&hostRPCPriceTable{pt, time.Now()}, payment.AccountID())
+	tracked := modules.RPCTrackedPriceTableResponse{
+		UID:         pt.UID,
+		PreviousUID: previous,
+		Version:     version,
+	}
 	if err = modules.RPCWrite(stream, tracked); err != nil {
-		return out, errors.AddContext(err, "Failed to signal renter we tracked the price table")
+		return errors.AddContext(err, "Failed to signal renter we tracked the price table")
 	}
-	out += fmt.Sprintf("send PT took %v\n", time.Since(start))
-	return out, nil
+	return nil
 }
 
 // staticReadPriceTableID receives a stream and reads the price table's UID from
@@ -196,8 +205,10 @@ func (h *Host) staticReadPriceTableID(stream siamux.Stream) (*modules.RPCPriceTa
 		return nil, errors.AddContext(modules.ErrPriceTableNotFound, fmt.Sprint(uid))
 	}
 
-	// make sure the table isn't expired.
-	if time.Now().After(pt.Expiry()) {
+	// make sure the table isn't expired. Tables which were superseded by a
+	// newer table before they expired are still accepted within their grace
+	// period to not fail RPCs which race the price table update.
+	if time.Now().After(h.staticPriceTables.managedAcceptedUntil(pt)) {
 		return nil, errors.AddContext(modules.ErrPriceTableExpired, fmt.Sprint(uid))
 	}
 	return &pt.RPCPriceTable, nil
@@ -105,6 +105,171 @@ func TestPriceTableMinHeap(t *testing.T) {
 	}
 }
 
+// TestPriceTableGracePeriod verifies expired price tables are only popped from
+// the min heap once their grace period ran out.
+func TestPriceTableGracePeriod(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	pth := priceTableHeap{heap: make([]*hostRPCPriceTable, 0)}
+
+	// expired but within its grace period
+	pt1 := hostRPCPriceTable{
+		modules.RPCPriceTable{Validity: rpcPriceGuaranteePeriod, GracePeriod: defaultPriceTableGracePeriod},
+		now.Add(-rpcPriceGuaranteePeriod - defaultPriceTableGracePeriod/2),
+	}
+	pth.Push(&pt1)
+
+	// expired and past its grace period
+	pt2 := hostRPCPriceTable{
+		modules.RPCPriceTable{Validity: rpcPriceGuaranteePeriod, GracePeriod: defaultPriceTableGracePeriod},
+		now.Add(-rpcPriceGuaranteePeriod - 2*defaultPriceTableGracePeriod),
+	}
+	fastrand.Read(pt2.UID[:])
+	pth.Push(&pt2)
+
+	if !now.After(pt1.Expiry()) || !now.Before(pt1.GraceExpiry()) {
+		t.Fatal("expected pt1 to be expired but within its grace period")
+	}
+
+	// verify only pt2 gets popped
+	expired := pth.PopExpired()
+	if len(expired) != 1 || expired[0] != pt2.UID {
+		t.Fatal("expected only pt2 to be expired", expired)
+	}
+	if pth.heap.Len() != 1 {
+		t.Fatal("expected pt1 to remain in the heap")
+	}
+}
+
+// TestPriceTableVersions verifies the host versions the price tables it issues
+// per account and returns the UID of the previous one.
+func TestPriceTableVersions(t *testing.T) {
+	t.Parallel()
+
+	hp := &hostPrices{
+		guaranteed: make(map[modules.UniqueID]*hostRPCPriceTable),
+		latest:     make(map[modules.AccountID]priceTableVersion),
+		superseded: make(map[modules.UniqueID]time.Time),
+		staticMinHeap: priceTableHeap{
+			heap: make([]*hostRPCPriceTable, 0),
+		},
+	}
+	newPriceTable := func(creation time.Time) *hostRPCPriceTable {
+		pt := &hostRPCPriceTable{
+			modules.RPCPriceTable{Validity: rpcPriceGuaranteePeriod, GracePeriod: defaultPriceTableGracePeriod},
+			creation,
+		}
+		fastrand.Read(pt.UID[:])
+		return pt
+	}
+	accountA, _ := modules.NewAccountID()
+	accountB, _ := modules.NewAccountID()
+
+	// track a price table for account A
+	pt1 := newPriceTable(time.Now())
+	version, previous := hp.managedTrack(pt1, accountA)
+	if version != 1 || previous != (modules.UniqueID{}) {
+		t.Fatal("unexpected version or previous UID", version, previous)
+	}
+
+	// issue a new price table to account A
+	pt2 := newPriceTable(time.Now())
+	version, previous = hp.managedTrack(pt2, accountA)
+	if version != 2 || previous != pt1.UID {
+		t.Fatal("unexpected version or previous UID", version, previous)
+	}
+
+	// versions are tracked per account
+	version, previous = hp.managedTrack(newPriceTable(time.Now()), accountB)
+	if version != 1 || previous != (modules.UniqueID{}) {
+		t.Fatal("unexpected version or previous UID", version, previous)
+	}
+
+	// once the previous price table is pruned, it's not returned anymore
+	hp.mu.Lock()
+	delete(hp.guaranteed, pt2.UID)
+	hp.mu.Unlock()
+	version, previous = hp.managedTrack(newPriceTable(time.Now()), accountA)
+	if version != 3 || previous != (modules.UniqueID{}) {
+		t.Fatal("unexpected version or previous UID", version, previous)
+	}
+
+	// the versions of an account are forgotten once its latest price table
+	// was pruned
+	expired := newPriceTable(time.Now().Add(-rpcPriceGuaranteePeriod - defaultPriceTableGracePeriod))
+	hp.managedTrack(expired, accountB)
+	hp.managedPruneExpired()
+	version, _ = hp.managedTrack(newPriceTable(time.Now()), accountB)
+	if version != 1 {
+		t.Fatal("unexpected version", version)
+	}
+}
+
+// TestPriceTableSupersededGracePeriod verifies the host only accepts an expired
+// price table within its grace period if it was superseded by a newer price
+// table before it expired.
+func TestPriceTableSupersededGracePeriod(t *testing.T) {
+	t.Parallel()
+
+	hp := &hostPrices{
+		guaranteed: make(map[modules.UniqueID]*hostRPCPriceTable),
+		latest:     make(map[modules.AccountID]priceTableVersion),
+		superseded: make(map[modules.UniqueID]time.Time),
+		staticMinHeap: priceTableHeap{
+			heap: make([]*hostRPCPriceTable, 0),
+		},
+	}
+	newPriceTable := func(creation time.Time) *hostRPCPriceTable {
+		pt := &hostRPCPriceTable{
+			modules.RPCPriceTable{Validity: rpcPriceGuaranteePeriod, GracePeriod: defaultPriceTableGracePeriod},
+			creation,
+		}
+		fastrand.Read(pt.UID[:])
+		return pt
+	}
+	accountA, _ := modules.NewAccountID()
+	accountB, _ := modules.NewAccountID()
+
+	// a price table which is about to expire is only accepted until its
+	// expiry
+	pt := newPriceTable(time.Now().Add(-rpcPriceGuaranteePeriod + time.Second))
+	hp.managedTrack(pt, accountA)
+	if hp.managedAcceptedUntil(pt) != pt.Expiry() {
+		t.Fatal("expected price table to be accepted until its expiry")
+	}
+
+	// once it's superseded, it's accepted for the grace period
+	start := time.Now()
+	hp.managedTrack(newPriceTable(time.Now()), accountA)
+	until := hp.managedAcceptedUntil(pt)
+	if until.Before(start.Add(defaultPriceTableGracePeriod)) || !until.Before(pt.GraceExpiry()) {
+		t.Fatal("expected superseded price table to be accepted within its grace period", until)
+	}
+
+	// an expired price table which is superseded isn't accepted anymore
+	expired := newPriceTable(time.Now().Add(-rpcPriceGuaranteePeriod - time.Second))
+	hp.managedTrack(expired, accountB)
+	hp.managedTrack(newPriceTable(time.Now()), accountB)
+	if hp.managedAcceptedUntil(expired) != expired.Expiry() {
+		t.Fatal("expected expired price table to not be accepted")
+	}
+
+	// superseded price tables are forgotten once they are pruned
+	old := newPriceTable(time.Now().Add(-rpcPriceGuaranteePeriod - defaultPriceTableGracePeriod))
+	hp.managedTrack(old, accountB)
+	hp.mu.Lock()
+	hp.superseded[old.UID] = old.GraceExpiry()
+	hp.mu.Unlock()
+	hp.managedPruneExpired()
+	hp.mu.RLock()
+	_, exists := hp.superseded[old.UID]
+	hp.mu.RUnlock()
+	if exists {
+		t.Fatal("expected superseded price table to be pruned")
+	}
+}
+
 // TestPruneExpiredPriceTables verifies the rpc price tables get pruned from the
 // host's price table map if they have expired.
 func TestPruneExpiredPriceTables(t *testing.T) {
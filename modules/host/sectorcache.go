@@ -0,0 +1,248 @@
+package host
+
+import (
+	"container/list"
+	"sync"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+var (
+	// defaultReadCacheSize is the default memory budget of the host's sector
+	// cache in bytes.
+	defaultReadCacheSize = 32 * modules.SectorSize // 128 MiB
+
+	// readCacheBypassThreshold is the max number of bytes a single legacy
+	// download request can read for its reads to go through the sector
+	// cache. Large sequential reads
+	// are usually bulk downloads of data which is read once. Caching them
+	// would evict the frequently read sectors.
+	readCacheBypassThreshold = 8 * modules.SectorSize // 32 MiB
+)
+
+type (
+	// sectorCache is an in-memory LRU cache of sectors. Sectors are added
+	// when they are downloaded or when renters hint that they will read them
+	// next. This allows for serving frequently downloaded sectors and
+	// sequential streaming downloads without waiting for the disk. Sectors
+	// are addressed by their Merkle root which means a cached sector can't
+	// become outdated. It is only evicted once the memory budget is used up.
+	sectorCache struct {
+		entries map[crypto.Hash]*list.Element
+		lru     *list.List
+		pending map[crypto.Hash]struct{}
+		size    uint64
+		maxSize uint64
+
+		// Cache statistics.
+		hits      uint64
+		misses    uint64
+		bypassed  uint64
+		evictions uint64
+
+		mu sync.Mutex
+	}
+
+	// sectorCacheEntry is an entry of the sectorCache.
+	sectorCacheEntry struct {
+		root crypto.Hash
+		data []byte
+	}
+)
+
+// newSectorCache creates a new sectorCache which holds up to maxSize bytes of
+// sectors.
+func newSectorCache(maxSize uint64) *sectorCache {
+	return &sectorCache{
+		entries: make(map[crypto.Hash]*list.Element),
+		lru:     list.New(),
+		pending: make(map[crypto.Hash]struct{}),
+		maxSize: maxSize,
+	}
+}
+
+// evict evicts the least recently used sectors until the cache fits within
+// its memory budget.
+func (sc *sectorCache) evict() {
+	for sc.size > sc.maxSize {
+		oldest := sc.lru.Back()
+		entry := oldest.Value.(*sectorCacheEntry)
+		sc.lru.Remove(oldest)
+		delete(sc.entries, entry.root)
+		sc.size -= uint64(len(entry.data))
+		sc.evictions++
+	}
+}
+
+// managedAdd adds a copy of a sector to the cache and evicts the least
+// recently used sectors if the cache is full. Sectors which exceed the memory
+// budget on their own are not added.
+func (sc *sectorCache) managedAdd(root crypto.Hash, data []byte) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	delete(sc.pending, root)
+	if e, exists := sc.entries[root]; exists {
+		sc.lru.MoveToFront(e)
+		return
+	}
+	if uint64(len(data)) > sc.maxSize {
+		return
+	}
+	data = append([]byte(nil), data...)
+	sc.entries[root] = sc.lru.PushFront(&sectorCacheEntry{root: root, data: data})
+	sc.size += uint64(len(data))
+	sc.evict()
+}
+
+// managedGet returns a copy of a cached sector. The sector is copied since
+// callers of ReadSector are free to modify the returned data. The lookup is
+// counted as a hit or a miss.
+func (sc *sectorCache) managedGet(root crypto.Hash) ([]byte, bool) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	e, exists := sc.entries[root]
+	if !exists {
+		sc.misses++
+		return nil, false
+	}
+	sc.hits++
+	sc.lru.MoveToFront(e)
+	data := e.Value.(*sectorCacheEntry).data
+	return append([]byte(nil), data...), true
+}
+
+// managedMarkBypassed counts a read which bypassed the cache.
+func (sc *sectorCache) managedMarkBypassed() {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.bypassed++
+}
+
+// managedMarkPending returns the roots which are neither cached nor already
+// being prefetched and marks them as pending. Nothing is prefetched if the
+// cache is disabled.
+func (sc *sectorCache) managedMarkPending(roots []crypto.Hash) []crypto.Hash {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if sc.maxSize == 0 {
+		return nil
+	}
+	var toFetch []crypto.Hash
+	for _, root := range roots {
+		_, cached := sc.entries[root]
+		_, pending := sc.pending[root]
+		if cached || pending {
+			continue
+		}
+		sc.pending[root] = struct{}{}
+		toFetch = append(toFetch, root)
+	}
+	return toFetch
+}
+
+// managedUnmarkPending removes the pending mark of a root which couldn't be
+// prefetched.
+func (sc *sectorCache) managedUnmarkPending(root crypto.Hash) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	delete(sc.pending, root)
+}
+
+// managedSetMaxSize updates the memory budget of the cache and evicts sectors
+// which no longer fit.
+func (sc *sectorCache) managedSetMaxSize(maxSize uint64) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.maxSize = maxSize
+	sc.evict()
+}
+
+// managedMetrics returns the statistics of the cache.
+func (sc *sectorCache) managedMetrics() modules.HostReadCacheMetrics {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return modules.HostReadCacheMetrics{
+		Hits:      sc.hits,
+		Misses:    sc.misses,
+		Bypassed:  sc.bypassed,
+		Evictions: sc.evictions,
+		Sectors:   uint64(sc.lru.Len()),
+		Size:      sc.size,
+		MaxSize:   sc.maxSize,
+	}
+}
+
+// PrefetchSectors warms the sectors with the provided roots into the host's
+// sector cache in the background. Sectors the host doesn't store are ignored.
+// The sectors are read one after another in the order of the hint since the
+// renter is expected to read them in that order.
+func (h *Host) PrefetchSectors(roots []crypto.Hash) {
+	toFetch := h.staticSectorCache.managedMarkPending(roots)
+	if len(toFetch) == 0 {
+		return
+	}
+	if err := h.tg.Add(); err != nil {
+		for _, root := range toFetch {
+			h.staticSectorCache.managedUnmarkPending(root)
+		}
+		return
+	}
+	go func() {
+		defer h.tg.Done()
+		for _, root := range toFetch {
+			if !h.StorageManager.HasSector(root) {
+				h.staticSectorCache.managedUnmarkPending(root)
+				continue
+			}
+			data, err := h.StorageManager.ReadSector(root)
+			if err != nil {
+				h.log.Debugf("failed to prefetch sector %v: %v", root, err)
+				h.staticSectorCache.managedUnmarkPending(root)
+				continue
+			}
+			h.staticSectorCache.managedAdd(root, data)
+		}
+	}()
+}
+
+// ReadSector reads a sector for a download. Cached sectors are served from
+// the host's sector cache. Other sectors are read from the storage manager
+// and added to the cache.
+func (h *Host) ReadSector(root crypto.Hash) ([]byte, error) {
+	if data, cached := h.staticSectorCache.managedGet(root); cached {
+		return data, nil
+	}
+	data, err := h.StorageManager.ReadSector(root)
+	if err != nil {
+		return nil, err
+	}
+	h.staticSectorCache.managedAdd(root, data)
+	return data, nil
+}
+
+// ReadSectorBypassCache reads a sector without adding it to the host's sector
+// cache. It is used for large sequential reads and for reads which are not
+// downloads, e.g. storage proofs, to avoid evicting frequently read sectors.
+// Cached sectors are still served from the cache.
+func (h *Host) ReadSectorBypassCache(root crypto.Hash) ([]byte, error) {
+	if data, cached := h.staticSectorCache.managedGet(root); cached {
+		return data, nil
+	}
+	h.staticSectorCache.managedMarkBypassed()
+	return h.StorageManager.ReadSector(root)
+}
+
+// readDownloadSector reads a sector of a legacy download request which reads
+// downloadSize bytes in total. Large downloads bypass the sector cache.
+func (h *Host) readDownloadSector(root crypto.Hash, downloadSize uint64) ([]byte, error) {
+	if downloadSize > readCacheBypassThreshold {
+		return h.ReadSectorBypassCache(root)
+	}
+	return h.ReadSector(root)
+}
+
+// ReadCacheMetrics returns the statistics of the host's sector cache.
+func (h *Host) ReadCacheMetrics() modules.HostReadCacheMetrics {
+	return h.staticSectorCache.managedMetrics()
+}
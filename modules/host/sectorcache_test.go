@@ -0,0 +1,242 @@
+package host
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestSectorCache is a unit test for the sectorCache's LRU eviction and memory
+// budget.
+func TestSectorCache(t *testing.T) {
+	t.Parallel()
+
+	sc := newSectorCache(2)
+	roots := []crypto.Hash{{1}, {2}, {3}}
+	sc.managedAdd(roots[0], []byte{1})
+	sc.managedAdd(roots[1], []byte{2})
+
+	// Using the first sector makes the second one the least recently used.
+	data, cached := sc.managedGet(roots[0])
+	if !cached || !bytes.Equal(data, []byte{1}) {
+		t.Fatal("sector should be cached", data)
+	}
+	// Modifying the returned data doesn't affect the cache.
+	data[0] = 0
+	if data, _ := sc.managedGet(roots[0]); data[0] != 1 {
+		t.Fatal("cached sector was modified")
+	}
+	sc.managedAdd(roots[2], []byte{3})
+	if _, cached := sc.managedGet(roots[1]); cached {
+		t.Fatal("least recently used sector should have been evicted")
+	}
+	if _, cached := sc.managedGet(roots[0]); !cached {
+		t.Fatal("sector shouldn't have been evicted")
+	}
+
+	// Cached and pending sectors aren't fetched again.
+	toFetch := sc.managedMarkPending(roots)
+	if len(toFetch) != 1 || toFetch[0] != roots[1] {
+		t.Fatal("wrong sectors to fetch", toFetch)
+	}
+	if toFetch := sc.managedMarkPending(roots); len(toFetch) != 0 {
+		t.Fatal("pending sector shouldn't be fetched again", toFetch)
+	}
+
+	// Check the statistics.
+	m := sc.managedMetrics()
+	if m.Hits != 3 || m.Misses != 1 || m.Evictions != 1 || m.Sectors != 2 || m.Size != 2 || m.MaxSize != 2 {
+		t.Fatal("wrong metrics", m)
+	}
+
+	// Sectors which exceed the budget on their own aren't added.
+	sc.managedAdd(crypto.Hash{4}, []byte{1, 2, 3})
+	if m := sc.managedMetrics(); m.Sectors != 2 || m.Size != 2 {
+		t.Fatal("sector shouldn't have been added", m)
+	}
+
+	// Shrinking the budget evicts the least recently used sectors.
+	sc.managedSetMaxSize(1)
+	if _, cached := sc.managedGet(roots[0]); !cached {
+		t.Fatal("most recently used sector should be cached")
+	}
+	if m := sc.managedMetrics(); m.Sectors != 1 || m.Size != 1 || m.Evictions != 2 {
+		t.Fatal("wrong metrics", m)
+	}
+
+	// A disabled cache doesn't prefetch.
+	sc.managedSetMaxSize(0)
+	if toFetch := sc.managedMarkPending([]crypto.Hash{{5}}); len(toFetch) != 0 {
+		t.Fatal("disabled cache shouldn't prefetch", toFetch)
+	}
+	if m := sc.managedMetrics(); m.Sectors != 0 || m.Size != 0 {
+		t.Fatal("disabled cache should be empty", m)
+	}
+}
+
+// TestPrefetchSectors makes sure that prefetched sectors are served from the
+// host's sector cache.
+func TestPrefetchSectors(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	sectorData := fastrand.Bytes(int(modules.SectorSize))
+	sectorRoot := crypto.MerkleRoot(sectorData)
+	if err := ht.host.AddSector(sectorRoot, sectorData); err != nil {
+		t.Fatal(err)
+	}
+
+	// Sectors the host doesn't store are ignored.
+	ht.host.PrefetchSectors([]crypto.Hash{sectorRoot, {}})
+	err = build.Retry(100, 10*time.Millisecond, func() error {
+		if _, cached := ht.host.staticSectorCache.managedGet(sectorRoot); !cached {
+			return errors.New("sector wasn't prefetched")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, cached := ht.host.staticSectorCache.managedGet(crypto.Hash{}); cached {
+		t.Fatal("unknown sector shouldn't be cached")
+	}
+	data, err := ht.host.ReadSector(sectorRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, sectorData) {
+		t.Fatal("wrong data")
+	}
+}
+
+// TestReadCache tests that the host caches downloaded sectors within its
+// configured memory budget and that reads can bypass the cache.
+func TestReadCache(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	h := ht.host
+
+	is := h.InternalSettings()
+	if is.ReadCacheSize != defaultReadCacheSize {
+		t.Fatal("wrong default", is.ReadCacheSize)
+	}
+
+	addSector := func() ([]byte, crypto.Hash) {
+		data := fastrand.Bytes(int(modules.SectorSize))
+		root := crypto.MerkleRoot(data)
+		if err := h.AddSector(root, data); err != nil {
+			t.Fatal(err)
+		}
+		return data, root
+	}
+	read := func(root crypto.Hash, bypass bool, expected []byte) {
+		var data []byte
+		var err error
+		if bypass {
+			data, err = h.ReadSectorBypassCache(root)
+		} else {
+			data, err = h.ReadSector(root)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, expected) {
+			t.Fatal("wrong data")
+		}
+	}
+
+	// A sector which bypasses the cache isn't cached.
+	data1, root1 := addSector()
+	read(root1, true, data1)
+	if m := h.ReadCacheMetrics(); m.Hits != 0 || m.Misses != 1 || m.Bypassed != 1 || m.Sectors != 0 {
+		t.Fatal("wrong metrics", m)
+	}
+
+	// A downloaded sector is cached and served from memory afterwards, also
+	// for reads which bypass the cache.
+	read(root1, false, data1)
+	read(root1, false, data1)
+	read(root1, true, data1)
+	if m := h.ReadCacheMetrics(); m.Hits != 2 || m.Misses != 2 || m.Bypassed != 1 || m.Sectors != 1 || m.Size != modules.SectorSize {
+		t.Fatal("wrong metrics", m)
+	}
+
+	// Lower the budget to a single sector. Downloading another sector evicts
+	// the first one.
+	is.ReadCacheSize = modules.SectorSize
+	if err := h.SetInternalSettings(is); err != nil {
+		t.Fatal(err)
+	}
+	data2, root2 := addSector()
+	read(root2, false, data2)
+	if m := h.ReadCacheMetrics(); m.Sectors != 1 || m.Evictions != 1 || m.MaxSize != modules.SectorSize {
+		t.Fatal("wrong metrics", m)
+	}
+	if _, cached := h.staticSectorCache.managedGet(root1); cached {
+		t.Fatal("sector should have been evicted")
+	}
+
+	// Large legacy downloads bypass the cache.
+	data3, root3 := addSector()
+	if _, err := h.readDownloadSector(root3, readCacheBypassThreshold+1); err != nil {
+		t.Fatal(err)
+	}
+	if _, cached := h.staticSectorCache.managedGet(root3); cached {
+		t.Fatal("sector of large download shouldn't be cached")
+	}
+	read(root3, false, data3)
+
+	// Disabling the cache empties it and the setting is persisted.
+	is.ReadCacheSize = 0
+	if err := h.SetInternalSettings(is); err != nil {
+		t.Fatal(err)
+	}
+	read(root2, false, data2)
+	if m := h.ReadCacheMetrics(); m.Sectors != 0 || m.Size != 0 || m.MaxSize != 0 {
+		t.Fatal("wrong metrics", m)
+	}
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+	ht.host, err = NewCustomHost(modules.ProdDependencies, ht.cs, ht.gateway, ht.tpool, ht.wallet, ht.mux, "localhost:0", filepath.Join(ht.persistDir, modules.HostDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if is := ht.host.InternalSettings(); is.ReadCacheSize != 0 {
+		t.Fatal("setting wasn't persisted", is.ReadCacheSize)
+	}
+	if m := ht.host.ReadCacheMetrics(); m.MaxSize != 0 {
+		t.Fatal("wrong metrics", m)
+	}
+}
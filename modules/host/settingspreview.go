@@ -0,0 +1,142 @@
+package host
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/host/registry"
+)
+
+var (
+	// ErrSettingsChanged is returned by ApplyInternalSettings if the host's
+	// settings were changed since they were previewed.
+	ErrSettingsChanged = errors.New("the host's internal settings changed since they were previewed")
+
+	// errCollateralBudgetBelowLocked is returned if the collateral budget of
+	// new settings is lower than the collateral locked in the host's
+	// contracts.
+	errCollateralBudgetBelowLocked = errors.New("collateral budget is lower than the collateral locked in the host's contracts")
+)
+
+// internalSettingsHash returns the hash of the provided internal settings.
+// The settings are encoded as JSON since the siad encoding doesn't support the
+// floats within the settings.
+func internalSettingsHash(settings modules.HostInternalSettings) crypto.Hash {
+	b, err := json.Marshal(settings)
+	if err != nil {
+		// Marshaling the settings can't fail.
+		panic(err)
+	}
+	return crypto.HashBytes(b)
+}
+
+// checkInternalSettings validates the provided settings against the host's
+// current settings and obligations. The returned errors prevent the settings
+// from being applied, the warnings describe effects of the settings the host
+// operator should be aware of.
+func (h *Host) checkInternalSettings(settings modules.HostInternalSettings) (errs []error, warnings []string) {
+	// The host should not be accepting file contracts if it does not have an
+	// unlock hash.
+	if settings.AcceptingContracts {
+		if err := h.checkUnlockHash(); err != nil {
+			errs = append(errs, errors.AddContext(err, "no unlock hash"))
+		}
+	}
+	if settings.NetAddress != "" {
+		if err := settings.NetAddress.IsValid(); err != nil {
+			errs = append(errs, errors.AddContext(err, "invalid NetAddress"))
+		}
+	}
+	if err := verifyAdditionalNetAddresses(settings.AdditionalNetAddresses); err != nil {
+		errs = append(errs, errors.AddContext(err, "invalid AdditionalNetAddresses"))
+	}
+	if err := verifyProofFeeSettings(settings); err != nil {
+		errs = append(errs, errors.AddContext(err, "invalid proof fee settings"))
+	}
+	if err := verifyWriteBatchSettings(settings); err != nil {
+		errs = append(errs, errors.AddContext(err, "invalid write batch settings"))
+	}
+	if settings.MonitoringAddress != "" {
+		if _, _, err := net.SplitHostPort(settings.MonitoringAddress); err != nil {
+			errs = append(errs, errors.AddContext(err, "invalid MonitoringAddress"))
+		}
+	}
+	if p := settings.PriceTableGracePeriod; p < 0 || p > rpcPriceGuaranteePeriod {
+		errs = append(errs, fmt.Errorf("PriceTableGracePeriod needs to be between 0 and %v", rpcPriceGuaranteePeriod))
+	}
+	if settings.MaxProgramDuration < 0 {
+		errs = append(errs, errors.New("MaxProgramDuration can't be negative"))
+	}
+	if l := settings.MaxProgramDataLength; l > 0 && l < modules.SectorSize {
+		warnings = append(warnings, fmt.Sprintf("a MaxProgramDataLength of %v prevents renters from uploading full sectors", l))
+	}
+
+	// The collateral locked in the host's contracts can't exceed the budget.
+	locked := h.financialMetrics.LockedStorageCollateral
+	if settings.CollateralBudget.Cmp(locked) < 0 {
+		errs = append(errs, errors.AddContext(errCollateralBudgetBelowLocked, fmt.Sprintf("%v are locked", locked.HumanString())))
+	} else if locked.Add(settings.MaxCollateral).Cmp(settings.CollateralBudget) > 0 {
+		warnings = append(warnings, fmt.Sprintf("only %v of the collateral budget are unlocked, which is less than the MaxCollateral of a new contract", settings.CollateralBudget.Sub(locked).HumanString()))
+	}
+
+	// The registry can't shrink below the number of entries it contains.
+	registrySize := modules.RoundRegistrySize(settings.RegistrySize)
+	if entries := h.staticRegistry.Len(); registrySize/modules.RegistryEntrySize < entries {
+		errs = append(errs, errors.AddContext(registry.ErrInvalidTruncate, fmt.Sprintf("registry contains %v entries", entries)))
+	}
+	if h.settings.CustomRegistryPath != settings.CustomRegistryPath {
+		warnings = append(warnings, "the registry will be migrated to a new location")
+	}
+
+	if h.settings.AcceptingContracts && !settings.AcceptingContracts && h.financialMetrics.ContractCount > 0 {
+		warnings = append(warnings, fmt.Sprintf("the host will stop accepting contracts, its %v existing contracts are not affected", h.financialMetrics.ContractCount))
+	}
+	if (h.settings.NetAddress != settings.NetAddress && settings.NetAddress != h.autoAddress) || !equalNetAddresses(h.settings.AdditionalNetAddresses, settings.AdditionalNetAddresses) {
+		warnings = append(warnings, "the host's addresses changed, it needs to announce itself again")
+	}
+	return errs, warnings
+}
+
+// PreviewInternalSettings validates the settings against the host's current
+// obligations without applying them.
+func (h *Host) PreviewInternalSettings(settings modules.HostInternalSettings) (modules.HostSettingsPreview, error) {
+	if err := h.tg.Add(); err != nil {
+		return modules.HostSettingsPreview{}, err
+	}
+	defer h.tg.Done()
+
+	// Check the balances of the ephemeral accounts before acquiring the
+	// host's lock to avoid holding both locks at once.
+	accounts := h.staticAccountManager.callAccountsAboveBalance(settings.MaxEphemeralAccountBalance)
+
+	h.mu.RLock()
+	errs, warnings := h.checkInternalSettings(settings)
+	preview := modules.HostSettingsPreview{
+		Errors:       make([]string, 0, len(errs)),
+		Warnings:     warnings,
+		SettingsHash: internalSettingsHash(h.settings),
+	}
+	h.mu.RUnlock()
+
+	for _, err := range errs {
+		preview.Errors = append(preview.Errors, err.Error())
+	}
+	if accounts > 0 {
+		preview.Warnings = append(preview.Warnings, fmt.Sprintf("%v ephemeral accounts have a balance above the MaxEphemeralAccountBalance, deposits into them will be rejected", accounts))
+	}
+	if preview.Warnings == nil {
+		preview.Warnings = make([]string, 0)
+	}
+	return preview, nil
+}
+
+// ApplyInternalSettings sets the hosting parameters of the host if the hash of
+// its current internal settings matches settingsHash.
+func (h *Host) ApplyInternalSettings(settings modules.HostInternalSettings, settingsHash crypto.Hash) error {
+	return h.managedSetInternalSettings(settings, &settingsHash)
+}
@@ -0,0 +1,84 @@
+package host
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/types"
+)
+
+// TestPreviewInternalSettings tests that proposed settings are validated
+// against the host's obligations and only applied if the settings didn't
+// change since they were previewed.
+func TestPreviewInternalSettings(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	h := ht.host
+
+	// Lock some collateral.
+	locked := types.SiacoinPrecision.Mul64(1000)
+	h.mu.Lock()
+	h.financialMetrics.LockedStorageCollateral = locked
+	h.mu.Unlock()
+
+	// A budget which leaves less than the MaxCollateral unlocked causes a
+	// warning.
+	settings := h.InternalSettings()
+	settings.MaxCollateral = types.SiacoinPrecision.Mul64(100)
+	settings.CollateralBudget = locked.Add(settings.MaxCollateral.Div64(2))
+	preview, err := h.PreviewInternalSettings(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(preview.Errors) != 0 || len(preview.Warnings) != 1 {
+		t.Fatal("expected a single warning", preview)
+	}
+
+	// A budget below the locked collateral is an error and is rejected.
+	settings.CollateralBudget = locked.Sub64(1)
+	preview, err = h.PreviewInternalSettings(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(preview.Errors) != 1 {
+		t.Fatal("expected a single error", preview)
+	}
+	if err := h.SetInternalSettings(settings); !errors.Contains(err, errCollateralBudgetBelowLocked) {
+		t.Fatal("expected errCollateralBudgetBelowLocked", err)
+	}
+
+	// Previewing doesn't change the settings, so they can be applied with the
+	// hash of the preview.
+	settings.CollateralBudget = locked.Mul64(2)
+	preview, err = h.PreviewInternalSettings(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(preview.Errors) != 0 {
+		t.Fatal("unexpected errors", preview.Errors)
+	}
+	if err := h.ApplyInternalSettings(settings, preview.SettingsHash); err != nil {
+		t.Fatal(err)
+	}
+	if !h.InternalSettings().CollateralBudget.Equals(settings.CollateralBudget) {
+		t.Fatal("settings weren't applied")
+	}
+
+	// The settings changed, so applying settings with the old hash fails.
+	settings.CollateralBudget = locked.Mul64(3)
+	if err := h.ApplyInternalSettings(settings, preview.SettingsHash); !errors.Contains(err, ErrSettingsChanged) {
+		t.Fatal("expected ErrSettingsChanged", err)
+	}
+}
@@ -176,6 +176,18 @@ type storageObligation struct {
 	RevisionConfirmed   bool
 	RevisionConstructed bool
 
+	// ProofFailureCause and ProofFailureError describe why the most recent
+	// attempt at submitting the storage proof failed.
+	ProofFailureCause modules.MissedProofCause
+	ProofFailureError string
+
+	// ProofAttempts is the number of times the host submitted the storage
+	// proof and ProofTransactionID is the id of the most recently submitted
+	// proof transaction. Every resubmission pays a higher fee than the
+	// previous one.
+	ProofAttempts      uint64
+	ProofTransactionID types.TransactionID
+
 	h *Host
 }
 
@@ -224,11 +236,13 @@ func (h *Host) managedGetStorageObligationSnapshot(id types.FileContractID) (Sto
 	revTxn := so.RevisionTransactionSet[len(so.RevisionTransactionSet)-1]
 
 	return StorageObligationSnapshot{
-		staticContractSize:  so.fileSize(),
-		staticMerkleRoot:    so.merkleRoot(),
-		staticProofDeadline: so.proofDeadline(),
-		staticRevisionTxn:   revTxn,
-		staticSectorRoots:   so.SectorRoots,
+		staticContractSize:     so.fileSize(),
+		staticLockedCollateral: so.LockedCollateral,
+		staticMerkleRoot:       so.merkleRoot(),
+		staticProofDeadline:    so.proofDeadline(),
+		staticRevisionTxn:      revTxn,
+		staticRiskedCollateral: so.RiskedCollateral,
+		staticSectorRoots:      so.SectorRoots,
 	}, nil
 }
 
@@ -261,13 +275,15 @@ func putStorageObligation(tx *bolt.Tx, so storageObligation) error {
 // a deep-copy and can be accessed without locking at the cost of being a frozen
 // readonly representation of an SO which only exists in memory. Note that this
 // snapshot only contains the properties required by the MDM to execute a
-// program. This can be extended in the future to support other use cases.
+// program and by the RPCs which report on a contract.
 type StorageObligationSnapshot struct {
-	staticContractSize  uint64
-	staticMerkleRoot    crypto.Hash
-	staticProofDeadline types.BlockHeight
-	staticRevisionTxn   types.Transaction
-	staticSectorRoots   []crypto.Hash
+	staticContractSize     uint64
+	staticLockedCollateral types.Currency
+	staticMerkleRoot       crypto.Hash
+	staticProofDeadline    types.BlockHeight
+	staticRevisionTxn      types.Transaction
+	staticRiskedCollateral types.Currency
+	staticSectorRoots      []crypto.Hash
 }
 
 // ZeroStorageObligationSnapshot returns the storage obligation snapshot of an
@@ -289,6 +305,17 @@ func ZeroStorageObligationSnapshot() StorageObligationSnapshot {
 	}
 }
 
+// LockedCollateral returns the collateral the host locked in the contract.
+func (sos StorageObligationSnapshot) LockedCollateral() types.Currency {
+	return sos.staticLockedCollateral
+}
+
+// RiskedCollateral returns the collateral the host risks for the data stored
+// in the contract.
+func (sos StorageObligationSnapshot) RiskedCollateral() types.Currency {
+	return sos.staticRiskedCollateral
+}
+
 // ContractSize returns the size of the underlying contract, which is static and
 // is the value of the contract size at the time the snapshot was taken.
 func (sos StorageObligationSnapshot) ContractSize() uint64 {
@@ -344,6 +371,7 @@ func (so *storageObligation) StorageObligation() modules.StorageObligation {
 		PotentialDownloadRevenue: so.PotentialDownloadRevenue,
 		PotentialStorageRevenue:  so.PotentialStorageRevenue,
 		PotentialUploadRevenue:   so.PotentialUploadRevenue,
+		ProofAttempts:            so.ProofAttempts,
 		RiskedCollateral:         so.RiskedCollateral,
 		SectorRootsCount:         uint64(len(so.SectorRoots)),
 		TransactionFeesAdded:     so.TransactionFeesAdded,
@@ -373,7 +401,7 @@ func (so storageObligation) Update(sectorRoots []crypto.Hash, sectorsRemoved map
 	for sector := range sectorsRemoved {
 		sr = append(sr, sector)
 	}
-	return so.h.managedModifyStorageObligation(so, sr, sectorsGained)
+	return so.h.managedModifyStorageObligationBatched(so, sr, sectorsGained, true)
 }
 
 // expiration returns the height at which the storage obligation expires.
@@ -801,6 +829,7 @@ func (h *Host) updateFinancialMetricsAddSO(so storageObligation) {
 	h.financialMetrics.PotentialUploadBandwidthRevenue = h.financialMetrics.PotentialUploadBandwidthRevenue.Add(so.PotentialUploadRevenue)
 	h.financialMetrics.RiskedStorageCollateral = h.financialMetrics.RiskedStorageCollateral.Add(so.RiskedCollateral)
 	h.financialMetrics.TransactionFeeExpenses = h.financialMetrics.TransactionFeeExpenses.Add(so.TransactionFeesAdded)
+	h.recordFinancialMetrics()
 }
 
 // updateFinancialMetricsAddSO updates the host's financial metrics for a
@@ -827,6 +856,7 @@ func (h *Host) updateFinancialMetricsUpdateSO(oldSO, newSO storageObligation) {
 	h.financialMetrics.PotentialUploadBandwidthRevenue = h.financialMetrics.PotentialUploadBandwidthRevenue.Sub(oldSO.PotentialUploadRevenue)
 	h.financialMetrics.RiskedStorageCollateral = h.financialMetrics.RiskedStorageCollateral.Sub(oldSO.RiskedCollateral)
 	h.financialMetrics.TransactionFeeExpenses = h.financialMetrics.TransactionFeeExpenses.Sub(oldSO.TransactionFeesAdded)
+	h.recordFinancialMetrics()
 
 	// The locked storage collateral was altered, we potentially want to
 	// unregister the insufficient collateral budget alert
@@ -843,6 +873,13 @@ func (h *Host) updateFinancialMetricsUpdateSO(oldSO, newSO storageObligation) {
 // will need to appear in 'sectorsRemoved' multiple times. Same with
 // 'sectorsGained'.
 func (h *Host) managedModifyStorageObligation(so storageObligation, sectorsRemoved []crypto.Hash, sectorsGained map[crypto.Hash][]byte) error {
+	return h.managedModifyStorageObligationBatched(so, sectorsRemoved, sectorsGained, false)
+}
+
+// managedModifyStorageObligationBatched is like managedModifyStorageObligation
+// but allows for committing the storage obligation together with the storage
+// obligations of other write programs.
+func (h *Host) managedModifyStorageObligationBatched(so storageObligation, sectorsRemoved []crypto.Hash, sectorsGained map[crypto.Hash][]byte, batched bool) error {
 	// Sanity check - all of the sector data should be modules.SectorSize
 	for _, data := range sectorsGained {
 		if uint64(len(data)) != modules.SectorSize {
@@ -903,16 +940,50 @@ func (h *Host) managedModifyStorageObligation(so storageObligation, sectorsRemov
 		return err
 	}
 
+	// Update the database to contain the new storage obligation. Write
+	// programs commit their storage obligations in batches if the host is
+	// configured to do so.
+	h.mu.RLock()
+	maxLatency := h.settings.WriteBatchMaxLatency
+	h.mu.RUnlock()
+	if batched && maxLatency > 0 {
+		_, err = h.managedCommitBatched(so, maxLatency)
+	} else {
+		err = h.managedCommitStorageObligation(so)
+	}
+	if err != nil {
+		// Because there was an error, all of the sectors that got added need
+		// to be reverted.
+		for sectorRoot := range sectorsGained {
+			// Error is not checked because there's nothing useful that can be
+			// done about an error.
+			_ = h.RemoveSector(sectorRoot)
+		}
+		return err
+	}
+	// Call removeSector for all of the sectors that have been removed.
+	for k := range sectorsRemoved {
+		// Error is not checkeed because there's nothing useful that can be
+		// done about an error. Failing to remove a sector is not a terrible
+		// place to be, especially if the host can run consistency checks.
+		_ = h.RemoveSector(sectorsRemoved[k])
+	}
+	return nil
+}
+
+// managedCommitStorageObligation replaces a storage obligation in the database
+// and updates the financial metrics of the host accordingly.
+func (h *Host) managedCommitStorageObligation(so storageObligation) error {
 	// Lock the host while we update storage obligation and financial metrics.
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
 	// Update the database to contain the new storage obligation.
 	var oldSO storageObligation
-	err = h.db.Update(func(tx *bolt.Tx) error {
+	err := h.db.Update(func(tx *bolt.Tx) (err error) {
 		// Get the old storage obligation as a reference to know how to upate
 		// the host financial stats.
-		oldSO, err = h.getStorageObligation(tx, soid)
+		oldSO, err = h.getStorageObligation(tx, so.id())
 		if err != nil {
 			return err
 		}
@@ -921,22 +992,8 @@ func (h *Host) managedModifyStorageObligation(so storageObligation, sectorsRemov
 		return putStorageObligation(tx, so)
 	})
 	if err != nil {
-		// Because there was an error, all of the sectors that got added need
-		// to be reverted.
-		for sectorRoot := range sectorsGained {
-			// Error is not checked because there's nothing useful that can be
-			// done about an error.
-			_ = h.RemoveSector(sectorRoot)
-		}
 		return err
 	}
-	// Call removeSector for all of the sectors that have been removed.
-	for k := range sectorsRemoved {
-		// Error is not checkeed because there's nothing useful that can be
-		// done about an error. Failing to remove a sector is not a terrible
-		// place to be, especially if the host can run consistency checks.
-		_ = h.RemoveSector(sectorsRemoved[k])
-	}
 
 	// Update the financial information for the storage obligation
 	h.updateFinancialMetricsUpdateSO(oldSO, so)
@@ -992,6 +1049,7 @@ func (h *Host) removeStorageObligation(so storageObligation, sos storageObligati
 	if sos == obligationUnresolved {
 		h.log.Critical("storage obligation 'unresolved' during call to removeStorageObligation, id", so.id())
 	}
+	h.updateProofDanger(so.id(), false)
 
 	if sos == obligationRejected {
 		if h.financialMetrics.TransactionFeeExpenses.Cmp(so.TransactionFeesAdded) >= 0 {
@@ -1057,6 +1115,17 @@ func (h *Host) removeStorageObligation(so storageObligation, sos storageObligati
 		h.financialMetrics.LostStorageCollateral = h.financialMetrics.LostStorageCollateral.Add(so.RiskedCollateral)
 		h.financialMetrics.LostRevenue = h.financialMetrics.LostRevenue.Add(so.ContractCost).Add(so.PotentialStorageRevenue).Add(so.PotentialDownloadRevenue).Add(so.PotentialUploadRevenue).Add(so.PotentialAccountFunding)
 
+		// Record the missed proof and alert the user.
+		mp := newMissedProof(so, h.blockHeight)
+		h.log.Printf("Missed storage proof for contract %v, probable cause: %v", so.id(), mp.Cause)
+		err := h.db.Update(func(tx *bolt.Tx) error {
+			return putMissedProof(tx, mp)
+		})
+		if err != nil {
+			h.log.Println("WARN: failed to record missed proof:", err)
+		}
+		h.updateMissedProofsAlert()
+
 		// The locked storage collateral was altered, we potentially want to
 		// unregister the insufficient collateral budget alert
 		h.tryUnregisterInsufficientCollateralBudgetAlert()
@@ -1067,6 +1136,7 @@ func (h *Host) removeStorageObligation(so storageObligation, sos storageObligati
 	// ended up, and the sector roots are removed because they are large
 	// objects with little purpose once storage proofs are no longer needed.
 	h.financialMetrics.ContractCount--
+	h.recordFinancialMetrics()
 	so.ObligationStatus = sos
 	so.SectorRoots = nil
 	return h.db.Update(func(tx *bolt.Tx) error {
@@ -1130,18 +1200,15 @@ func (h *Host) resetFinancialMetrics() error {
 		return err
 	}
 	h.financialMetrics = fm
+	h.recordFinancialMetrics()
 	return nil
 }
 
-// threadedHandleActionItem will look at a storage obligation and determine
-// which action is necessary for the storage obligation to succeed.
-func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
-	err := h.tg.Add()
-	if err != nil {
-		return
-	}
-	defer h.tg.Done()
-
+// managedHandleActionItem will look at a storage obligation and determine
+// which action is necessary for the storage obligation to succeed. If batch
+// isn't nil, a storage proof is added to the batch instead of being submitted
+// right away.
+func (h *Host) managedHandleActionItem(soid types.FileContractID, batch *storageProofBatch) {
 	// Lock the storage obligation in question.
 	h.managedLockStorageObligation(soid)
 	defer func() {
@@ -1152,7 +1219,7 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 	var so storageObligation
 	h.mu.RLock()
 	blockHeight := h.blockHeight
-	err = h.db.View(func(tx *bolt.Tx) error {
+	err := h.db.View(func(tx *bolt.Tx) (err error) {
 		so, err = h.getStorageObligation(tx, soid)
 		return err
 	})
@@ -1259,7 +1326,7 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 			return
 		}
 		txnSize := uint64(len(encoding.MarshalAll(so.RevisionTransactionSet)) + txnFeeSizeBuffer)
-		requiredFee := feeRecommendation.Mul64(txnSize)
+		requiredFee := h.managedProofTransactionFee(txnSize, 0)
 		err = builder.FundSiacoins(requiredFee)
 		if err != nil {
 			h.log.Printf("contract %s action: failed to build revision txn: Error funding transaction fees: %s", soid, err)
@@ -1279,6 +1346,13 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 		if err != nil {
 			h.log.Printf("contract %s action: failed to build revision txn: Error submitting transaction to transaction pool: %s", soid, err)
 			builder.Drop()
+		} else {
+			h.managedRecordProofFee(modules.HostProofFeeTransaction{
+				Fee:           requiredFee,
+				ObligationIDs: []types.FileContractID{soid},
+				TransactionID: feeAddedRevisionTransactionSet[len(feeAddedRevisionTransactionSet)-1].ID(),
+				Type:          modules.ProofFeeTypeRevision,
+			})
 		}
 		so.TransactionFeesAdded = so.TransactionFeesAdded.Add(requiredFee)
 		// return
@@ -1307,6 +1381,9 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 		// be removed.
 		if so.proofDeadline() < blockHeight {
 			h.log.Debugln("storage proof not confirmed by deadline, id", so.id())
+			if so.ProofFailureCause == "" && !h.cs.Synced() {
+				so.ProofFailureCause = modules.MissedProofCauseChainNotSynced
+			}
 			h.mu.Lock()
 			err := h.removeStorageObligation(so, obligationFailed)
 			h.mu.Unlock()
@@ -1320,6 +1397,7 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 		segmentIndex, err := h.cs.StorageProofSegment(so.id())
 		if err != nil {
 			h.log.Printf("contract %s action: Host got an error when fetching a storage proof segment: %s", soid, err)
+			h.managedRecordProofFailure(so, modules.MissedProofCauseChainNotSynced, err)
 			return
 		}
 
@@ -1327,55 +1405,63 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 		sp, err := h.managedBuildStorageProof(so, segmentIndex)
 		if err != nil {
 			h.log.Printf("contract %s action: Host encountered an error when building the storage proof: %s", soid, err)
+			h.managedRecordProofFailure(so, buildProofFailureCause(err), err)
 			return
 		}
 
-		// Create and build the transaction with the storage proof.
-		builder, err := h.wallet.StartTransaction()
-		if err != nil {
-			h.log.Printf("contract %s action: Failed to start storage proof transaction: %s", soid, err)
-			return
-		}
-		_, feeRecommendation := h.tpool.FeeEstimation()
-		txnSize := uint64(len(encoding.Marshal(sp)) + txnFeeSizeBuffer)
-		requiredFee := feeRecommendation.Mul64(txnSize)
+		// Check that the fee doesn't exceed the value of the contract.
+		proofSize := uint64(len(encoding.Marshal(sp)) + txnFeeSizeBuffer)
+		requiredFee := h.managedProofTransactionFee(proofSize, 0)
 		if so.value().Cmp(requiredFee) < 0 {
 			// There's no sense submitting the storage proof if the fee is more
 			// than the anticipated revenue.
 			h.log.Printf("contract %s action: Host not submitting storage proof due to a value that does not sufficiently exceed the fee cost", soid)
-			builder.Drop()
-			return
-		}
-		err = builder.FundSiacoins(requiredFee)
-		if err != nil {
-			h.log.Printf("contract %s action: failed to build storage proof trransaction: Host error when funding a storage proof transaction fee: %s", soid, err)
-			builder.Drop()
+			h.managedRecordProofFailure(so, modules.MissedProofCauseFeeTooHigh, nil)
 			return
 		}
-		builder.AddMinerFee(requiredFee)
-		builder.AddStorageProof(sp)
-		storageProofSet, err := builder.Sign(true)
-		if err != nil {
-			h.log.Printf("contract %s action: failed to build storage proof trransaction: Host error when signing the storage proof transaction: %s", soid, err)
-			builder.Drop()
-			return
+
+		// If the proof was submitted before but didn't get confirmed, escalate
+		// the fee as far as the value of the contract allows.
+		attempt := so.ProofAttempts
+		for attempt > 0 && so.value().Cmp(h.managedProofTransactionFee(proofSize, attempt)) < 0 {
+			attempt--
 		}
-		err = h.tpool.AcceptTransactionSet(storageProofSet)
-		if err != nil {
-			h.log.Printf("contract %s action: failed to build storage proof trransaction: Host unable to submit storage proof transaction to transaction pool: %s", soid, err)
-			builder.Drop()
-			return
+		if so.ProofAttempts > 0 {
+			h.log.Printf("contract %s action: storage proof not confirmed after %v attempts, resubmitting with a higher fee", soid, so.ProofAttempts)
 		}
-		so.TransactionFeesAdded = so.TransactionFeesAdded.Add(requiredFee)
 
 		// Queue another action item to check whether the storage proof
-		// got confirmed.
+		// got confirmed. Until the deadline, the proof is resubmitted if it
+		// isn't.
 		h.mu.Lock()
-		err = h.queueActionItem(so.proofDeadline(), so.id())
+		if so.ProofAttempts == 0 {
+			err = h.queueActionItem(so.proofDeadline(), so.id())
+		}
+		if retry, ok := so.nextProofRetry(blockHeight); ok {
+			err = errors.Compose(err, h.queueActionItem(retry, so.id()))
+		}
 		h.mu.Unlock()
+		so.ProofAttempts++
 		if err != nil {
 			h.log.Printf("contract %s action: Error queuing action item: %s", soid, err)
 		}
+		h.managedUpdateProofDanger(so, blockHeight)
+
+		// Submit the proof or add it to the batch, which is submitted once
+		// all action items are handled.
+		if batch != nil {
+			batch.managedAdd(sp, attempt)
+		} else {
+			txnID, fee, cause, err := h.managedSubmitStorageProofs([]types.StorageProof{sp}, attempt)
+			if err != nil {
+				h.log.Printf("contract %s action: %s", soid, err)
+				h.managedRecordProofFailure(so, cause, err)
+				h.managedRebroadcastProof(soid, so.ProofTransactionID)
+				return
+			}
+			so.ProofTransactionID = txnID
+			so.TransactionFeesAdded = so.TransactionFeesAdded.Add(fee)
+		}
 	}
 
 	// Save the storage obligation to account for any fee changes.
@@ -1412,7 +1498,7 @@ func (h *Host) managedBuildStorageProof(so storageObligation, segmentIndex uint6
 	sectorIndex := segmentIndex / (modules.SectorSize / crypto.SegmentSize)
 	// Pull the corresponding sector into memory.
 	sectorRoot := so.SectorRoots[sectorIndex]
-	sectorBytes, err := h.ReadSector(sectorRoot)
+	sectorBytes, err := h.ReadSectorBypassCache(sectorRoot)
 	if err != nil {
 		return types.StorageProof{}, errors.AddContext(err, "managedBuildStorageProof: failed to read sector")
 	}
@@ -0,0 +1,327 @@
+package host
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// subscriptionSetFlushInterval is the interval at which the updated
+	// revisions of the subscription sets are written to the database.
+	subscriptionSetFlushInterval = build.Select(build.Var{
+		Standard: time.Minute,
+		Dev:      30 * time.Second,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// subscriptionSetExpiry is the amount of time after which a subscription
+	// set which isn't bound to a session is removed.
+	subscriptionSetExpiry = build.Select(build.Var{
+		Standard: 7 * 24 * time.Hour,
+		Dev:      time.Hour,
+		Testing:  time.Minute,
+	}).(time.Duration)
+)
+
+type (
+	// subscriptionSets contains the persisted subscription sets of renters.
+	// A subscription session which is bound to a set keeps the set up to date
+	// with its subscriptions, which allows the renter to resume them after
+	// either side restarted. Changes to the subscriptions are written to the
+	// database right away while the updated revisions of the entries are
+	// periodically flushed.
+	subscriptionSets struct {
+		sets  map[crypto.Hash]*subscriptionSet
+		dirty map[crypto.Hash]struct{}
+
+		staticDB *persist.BoltDatabase
+
+		mu sync.Mutex
+	}
+
+	// subscriptionSet is the set of subscriptions of a renter identified by
+	// a renter-provided token. The revision of the set is incremented
+	// whenever one of its entries is updated, which allows a resuming renter
+	// to only fetch the entries which were updated since it last resumed.
+	subscriptionSet struct {
+		Token    crypto.Hash            `json:"token"`
+		Revision uint64                 `json:"revision"`
+		LastUsed time.Time              `json:"lastused"`
+		Entries  []subscriptionSetEntry `json:"entries"`
+
+		// bound is the number of sessions which are bound to the set. Sets
+		// which are bound don't expire.
+		bound   int
+		entries map[modules.RegistryEntryID]*subscriptionSetEntry
+	}
+
+	// subscriptionSetEntry is a subscribed entry of a subscription set.
+	subscriptionSetEntry struct {
+		PubKey types.SiaPublicKey `json:"pubkey"`
+		Tweak  crypto.Hash        `json:"tweak"`
+
+		// Revision is the latest revision of the entry the renter knows
+		// about. Known is false if the renter doesn't know about any revision
+		// of the entry yet.
+		Revision uint64 `json:"revision"`
+		Known    bool   `json:"known"`
+
+		// UpdatedAt is the revision of the set at which the entry was last
+		// updated.
+		UpdatedAt uint64 `json:"updatedat"`
+	}
+)
+
+// newSubscriptionSets loads the subscription sets from the database.
+func newSubscriptionSets(db *persist.BoltDatabase) (*subscriptionSets, error) {
+	ss := &subscriptionSets{
+		sets:     make(map[crypto.Hash]*subscriptionSet),
+		dirty:    make(map[crypto.Hash]struct{}),
+		staticDB: db,
+	}
+	err := db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketRegistrySubscriptionSets).ForEach(func(_, v []byte) error {
+			set := new(subscriptionSet)
+			if err := json.Unmarshal(v, set); err != nil {
+				return errors.AddContext(err, "unable to unmarshal subscription set")
+			}
+			set.entries = make(map[modules.RegistryEntryID]*subscriptionSetEntry, len(set.Entries))
+			for i := range set.Entries {
+				entry := set.Entries[i]
+				set.entries[modules.DeriveRegistryEntryID(entry.PubKey, entry.Tweak)] = &entry
+			}
+			set.Entries = nil
+			ss.sets[set.Token] = set
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ss, nil
+}
+
+// putSubscriptionSet places a subscription set into the database, overwriting
+// the existing one with the same token if there is one.
+func putSubscriptionSet(tx *bolt.Tx, set *subscriptionSet) error {
+	persisted := *set
+	persisted.Entries = make([]subscriptionSetEntry, 0, len(set.entries))
+	for _, entry := range set.entries {
+		persisted.Entries = append(persisted.Entries, *entry)
+	}
+	setBytes, err := json.Marshal(persisted)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(bucketRegistrySubscriptionSets).Put(set.Token[:], setBytes)
+}
+
+// save writes a subscription set to the database.
+func (ss *subscriptionSets) save(set *subscriptionSet) error {
+	err := ss.staticDB.Update(func(tx *bolt.Tx) error {
+		return putSubscriptionSet(tx, set)
+	})
+	if err != nil {
+		return errors.AddContext(err, "failed to save subscription set")
+	}
+	delete(ss.dirty, set.Token)
+	return nil
+}
+
+// managedResume binds a session to the subscription set of the token,
+// creating the set if it doesn't exist yet. Entries whose value changed since
+// the renter last learned about them are marked as updated. It returns the
+// revision of the set, its entries and the latest values of the entries which
+// were updated after the revision since.
+func (ss *subscriptionSets) managedResume(token crypto.Hash, since uint64, get func(modules.RegistryEntryID) (modules.SignedRegistryValue, bool)) (uint64, []subscriptionSetEntry, []modules.RPCRegistrySubscriptionNotificationEntryUpdate, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	set, exists := ss.sets[token]
+	if !exists {
+		set = &subscriptionSet{
+			Token:   token,
+			entries: make(map[modules.RegistryEntryID]*subscriptionSetEntry),
+		}
+		ss.sets[token] = set
+	}
+	set.bound++
+	set.LastUsed = time.Now()
+
+	// If the renter claims to know about a revision the set never reached,
+	// the set was lost and the renter needs all values.
+	if since > set.Revision {
+		since = 0
+	}
+
+	entries := make([]subscriptionSetEntry, 0, len(set.entries))
+	var updates []modules.RPCRegistrySubscriptionNotificationEntryUpdate
+	for id, entry := range set.entries {
+		rv, found := get(id)
+		if found && (!entry.Known || rv.Revision > entry.Revision) {
+			set.Revision++
+			entry.Revision = rv.Revision
+			entry.Known = true
+			entry.UpdatedAt = set.Revision
+		}
+		if found && entry.UpdatedAt > since {
+			updates = append(updates, modules.RPCRegistrySubscriptionNotificationEntryUpdate{
+				Entry:  rv,
+				PubKey: entry.PubKey,
+			})
+		}
+		entries = append(entries, *entry)
+	}
+	if err := ss.save(set); err != nil {
+		set.bound--
+		return 0, nil, nil, err
+	}
+	return set.Revision, entries, updates, nil
+}
+
+// managedUnbind unbinds a session from the subscription set of the token.
+func (ss *subscriptionSets) managedUnbind(token crypto.Hash) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	set, exists := ss.sets[token]
+	if !exists {
+		return
+	}
+	set.bound--
+	set.LastUsed = time.Now()
+	ss.dirty[token] = struct{}{}
+}
+
+// managedAddEntries adds entries to the subscription set of the token. The
+// revision of an entry which is already part of the set is only updated if
+// the new one is greater.
+func (ss *subscriptionSets) managedAddEntries(token crypto.Hash, entries []subscriptionSetEntry) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	set, exists := ss.sets[token]
+	if !exists {
+		return nil
+	}
+	for i := range entries {
+		entry := entries[i]
+		id := modules.DeriveRegistryEntryID(entry.PubKey, entry.Tweak)
+		existing, exists := set.entries[id]
+		if !exists {
+			entry.UpdatedAt = set.Revision
+			set.entries[id] = &entry
+			continue
+		}
+		if entry.Known && (!existing.Known || entry.Revision > existing.Revision) {
+			existing.Revision = entry.Revision
+			existing.Known = true
+		}
+	}
+	return ss.save(set)
+}
+
+// managedRemoveEntries removes entries from the subscription set of the
+// token.
+func (ss *subscriptionSets) managedRemoveEntries(token crypto.Hash, ids []modules.RegistryEntryID) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	set, exists := ss.sets[token]
+	if !exists {
+		return nil
+	}
+	for _, id := range ids {
+		delete(set.entries, id)
+	}
+	return ss.save(set)
+}
+
+// managedRecordUpdate records that the renter was notified about a new
+// revision of an entry of the subscription set of the token.
+func (ss *subscriptionSets) managedRecordUpdate(token crypto.Hash, id modules.RegistryEntryID, revision uint64) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	set, exists := ss.sets[token]
+	if !exists {
+		return
+	}
+	entry, exists := set.entries[id]
+	if !exists || (entry.Known && revision <= entry.Revision) {
+		return
+	}
+	set.Revision++
+	entry.Revision = revision
+	entry.Known = true
+	entry.UpdatedAt = set.Revision
+	ss.dirty[token] = struct{}{}
+}
+
+// managedFlush writes the updated subscription sets to the database and
+// removes the sets which expired.
+func (ss *subscriptionSets) managedFlush() error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	var expired []crypto.Hash
+	for token, set := range ss.sets {
+		if set.bound <= 0 && time.Since(set.LastUsed) > subscriptionSetExpiry {
+			expired = append(expired, token)
+		}
+	}
+	if len(ss.dirty) == 0 && len(expired) == 0 {
+		return nil
+	}
+	err := ss.staticDB.Update(func(tx *bolt.Tx) error {
+		for _, token := range expired {
+			if err := tx.Bucket(bucketRegistrySubscriptionSets).Delete(token[:]); err != nil {
+				return err
+			}
+		}
+		for token := range ss.dirty {
+			set, exists := ss.sets[token]
+			if !exists || set.bound <= 0 && time.Since(set.LastUsed) > subscriptionSetExpiry {
+				continue
+			}
+			if err := putSubscriptionSet(tx, set); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.AddContext(err, "failed to flush subscription sets")
+	}
+	for _, token := range expired {
+		delete(ss.sets, token)
+	}
+	ss.dirty = make(map[crypto.Hash]struct{})
+	return nil
+}
+
+// threadedFlushSubscriptionSets periodically writes the updated subscription
+// sets to the database.
+func (h *Host) threadedFlushSubscriptionSets() {
+	defer modules.RecoverPanic("host")
+	for {
+		select {
+		case <-h.tg.StopChan():
+			return
+		case <-time.After(subscriptionSetFlushInterval):
+		}
+		func() {
+			if err := h.tg.Add(); err != nil {
+				return
+			}
+			defer h.tg.Done()
+			if err := h.staticSubscriptionSets.managedFlush(); err != nil {
+				h.log.Println("ERROR: failed to persist subscription sets:", err)
+			}
+		}()
+	}
+}
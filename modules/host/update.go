@@ -255,6 +255,7 @@ func (h *Host) ProcessConsensusChange(cc modules.ConsensusChange) {
 							continue
 						}
 						so.ProofConfirmed = true
+						h.updateProofDanger(so.id(), false)
 						err = putStorageObligation(tx, so)
 						if err != nil {
 							continue
@@ -296,8 +297,8 @@ func (h *Host) ProcessConsensusChange(cc modules.ConsensusChange) {
 	if err != nil {
 		h.log.Println(err)
 	}
-	for i := range actionItems {
-		go h.threadedHandleActionItem(actionItems[i])
+	if len(actionItems) > 0 {
+		go h.threadedHandleActionItems(actionItems)
 	}
 
 	// Update the host's recent change pointer to point to the most recent
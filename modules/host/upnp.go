@@ -27,10 +27,15 @@ func (h *Host) managedLearnHostname() {
 	h.mu.RUnlock()
 
 	// If the settings indicate that an address has been manually set, there is
-	// no reason to learn the hostname.
+	// no reason to learn the hostname. The hostname is also not learned while
+	// connecting through a proxy which disables announcements.
 	if netAddr != "" {
 		return
 	}
+	if modules.GlobalProxy.AnnouncementsDisabled() {
+		h.log.Println("Not scanning for the host's address since announcements are disabled by the proxy settings.")
+		return
+	}
 	h.log.Println("No manually set net address. Scanning to automatically determine address.")
 
 	// Use the gateway to get the external ip.
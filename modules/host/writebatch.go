@@ -0,0 +1,157 @@
+package host
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+)
+
+const (
+	// defaultWriteBatchMaxLatency is the default for the max amount of time
+	// a write program waits for other write programs to commit their storage
+	// obligations together.
+	defaultWriteBatchMaxLatency = 10 * time.Millisecond
+
+	// maxWriteBatchMaxLatency is the largest WriteBatchMaxLatency the host
+	// accepts.
+	maxWriteBatchMaxLatency = time.Second
+
+	// writeBatchMaxSize is the max number of storage obligations which are
+	// committed in a single batch. A full batch is committed right away.
+	writeBatchMaxSize = 100
+)
+
+type (
+	// writeBatch batches the storage obligation updates of concurrent write
+	// programs into a single database transaction. Every database transaction
+	// requires an fsync which dominates the latency of small writes on
+	// spinning disks. Committing the updates of multiple programs together
+	// amortizes the fsync across all of them.
+	//
+	// The durability guarantees are the same as without batching. A write
+	// program is only finalized once the transaction containing its storage
+	// obligation was committed. If the transaction fails, the updates of the
+	// batch are retried one by one so that a single failing update doesn't
+	// fail the other programs of the batch.
+	writeBatch struct {
+		pending        []*writeBatchUpdate
+		flushScheduled bool
+
+		// Batching statistics.
+		batches uint64
+		updates uint64
+
+		mu sync.Mutex
+	}
+
+	// writeBatchUpdate is a storage obligation update waiting to be
+	// committed. done is closed once the update was committed or failed.
+	writeBatchUpdate struct {
+		so    storageObligation
+		oldSO storageObligation
+		err   error
+		done  chan struct{}
+	}
+)
+
+// verifyWriteBatchSettings checks that the write batch settings of the host
+// are valid.
+func verifyWriteBatchSettings(settings modules.HostInternalSettings) error {
+	if l := settings.WriteBatchMaxLatency; l < 0 || l > maxWriteBatchMaxLatency {
+		return fmt.Errorf("WriteBatchMaxLatency needs to be between 0 and %v", maxWriteBatchMaxLatency)
+	}
+	return nil
+}
+
+// managedCommitBatched queues the storage obligation to be committed with the
+// storage obligations of other write programs and blocks until it was
+// committed. The update is committed after at most maxLatency.
+func (h *Host) managedCommitBatched(so storageObligation, maxLatency time.Duration) (storageObligation, error) {
+	update := &writeBatchUpdate{
+		so:   so,
+		done: make(chan struct{}),
+	}
+
+	wb := h.staticWriteBatch
+	wb.mu.Lock()
+	wb.pending = append(wb.pending, update)
+	full := len(wb.pending) >= writeBatchMaxSize
+	schedule := !full && !wb.flushScheduled
+	if schedule {
+		wb.flushScheduled = true
+	}
+	wb.mu.Unlock()
+
+	// Commit a full batch right away, otherwise wait for more updates.
+	if full {
+		h.managedFlushWriteBatch()
+	} else if schedule {
+		time.AfterFunc(maxLatency, h.managedFlushWriteBatch)
+	}
+	<-update.done
+	return update.oldSO, update.err
+}
+
+// managedFlushWriteBatch commits all pending storage obligation updates in a
+// single database transaction.
+func (h *Host) managedFlushWriteBatch() {
+	wb := h.staticWriteBatch
+	wb.mu.Lock()
+	batch := wb.pending
+	wb.pending = nil
+	wb.flushScheduled = false
+	if len(batch) > 0 {
+		wb.batches++
+		wb.updates += uint64(len(batch))
+	}
+	wb.mu.Unlock()
+	if len(batch) == 0 {
+		return
+	}
+
+	// The database update and the financial metrics need to be updated under
+	// the same lock to keep them consistent.
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	err := h.db.Update(func(tx *bolt.Tx) (err error) {
+		for _, update := range batch {
+			update.oldSO, err = h.getStorageObligation(tx, update.so.id())
+			if err != nil {
+				return err
+			}
+			if err = putStorageObligation(tx, update.so); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil && len(batch) > 1 {
+		// Retry the updates one by one.
+		for _, update := range batch {
+			update.err = h.db.Update(func(tx *bolt.Tx) (err error) {
+				update.oldSO, err = h.getStorageObligation(tx, update.so.id())
+				if err != nil {
+					return err
+				}
+				return putStorageObligation(tx, update.so)
+			})
+		}
+	} else {
+		for _, update := range batch {
+			update.err = err
+		}
+	}
+	for _, update := range batch {
+		if update.err == nil {
+			h.updateFinancialMetricsUpdateSO(update.oldSO, update.so)
+		} else {
+			update.err = errors.AddContext(update.err, "failed to commit storage obligation")
+		}
+		close(update.done)
+	}
+}
@@ -0,0 +1,119 @@
+package host
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/bolt"
+
+	"go.sia.tech/siad/types"
+)
+
+// TestWriteBatch tests that the storage obligation updates of concurrent write
+// programs are committed in batches.
+func TestWriteBatch(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := ht.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	h := ht.host
+
+	// The max latency is limited.
+	settings := h.InternalSettings()
+	if settings.WriteBatchMaxLatency != defaultWriteBatchMaxLatency {
+		t.Fatal("wrong default", settings.WriteBatchMaxLatency)
+	}
+	settings.WriteBatchMaxLatency = 2 * maxWriteBatchMaxLatency
+	if err := h.SetInternalSettings(settings); err == nil {
+		t.Fatal("expected invalid latency to be rejected")
+	}
+	settings.WriteBatchMaxLatency = 200 * time.Millisecond
+	if err := h.SetInternalSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+
+	// Add a few storage obligations. The last one isn't added to the
+	// database, so its update is expected to fail.
+	var sos []storageObligation
+	for i := 0; i < 5; i++ {
+		so, err := ht.newTesterStorageObligation()
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.managedLockStorageObligation(so.id())
+		if i < 4 {
+			err = h.managedAddStorageObligation(so)
+		}
+		h.managedUnlockStorageObligation(so.id())
+		if err != nil {
+			t.Fatal(err)
+		}
+		sos = append(sos, so)
+	}
+	h.mu.RLock()
+	revenueBefore := h.financialMetrics.PotentialStorageRevenue
+	h.mu.RUnlock()
+
+	// Update them concurrently.
+	errs := make([]error, len(sos))
+	var wg sync.WaitGroup
+	for i := range sos {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			so := sos[i]
+			h.managedLockStorageObligation(so.id())
+			defer h.managedUnlockStorageObligation(so.id())
+			so.PotentialStorageRevenue = so.PotentialStorageRevenue.Add(types.SiacoinPrecision)
+			errs[i] = so.Update(so.SectorRoots, nil, nil)
+		}(i)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if i < 4 && err != nil {
+			t.Fatal(err)
+		} else if i == 4 && err == nil {
+			t.Fatal("update of missing storage obligation should fail")
+		}
+	}
+
+	// The updates should have been batched.
+	wb := h.staticWriteBatch
+	wb.mu.Lock()
+	batches, updates := wb.batches, wb.updates
+	wb.mu.Unlock()
+	if updates != 5 || batches >= updates {
+		t.Fatal("updates weren't batched", batches, updates)
+	}
+
+	// The storage obligations and financial metrics should be updated.
+	for _, so := range sos[:4] {
+		var dbSO storageObligation
+		err := h.db.View(func(tx *bolt.Tx) (err error) {
+			dbSO, err = h.getStorageObligation(tx, so.id())
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !dbSO.PotentialStorageRevenue.Equals(so.PotentialStorageRevenue.Add(types.SiacoinPrecision)) {
+			t.Fatal("storage obligation wasn't updated")
+		}
+	}
+	h.mu.RLock()
+	revenueAfter := h.financialMetrics.PotentialStorageRevenue
+	h.mu.RUnlock()
+	if !revenueAfter.Equals(revenueBefore.Add(types.SiacoinPrecision.Mul64(4))) {
+		t.Fatal("financial metrics weren't updated", revenueBefore, revenueAfter)
+	}
+}
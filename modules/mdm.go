@@ -71,6 +71,10 @@ const (
 	// MDMTimeDropSingleSector is the time for dropping a single sector.
 	MDMTimeDropSingleSector = 1
 
+	// MDMTimeExpectRevision is the time for executing an 'ExpectRevision'
+	// instruction.
+	MDMTimeExpectRevision = 1
+
 	// MDMTimeHasSector is the time for executing a 'HasSector' instruction.
 	MDMTimeHasSector = 1
 
@@ -94,6 +98,15 @@ const (
 	// MDMTimeSwapSector is the time for executing an 'SwapSector' instruction.
 	MDMTimeSwapSector = 1
 
+	// MDMTimeTruncateSector is the time for executing a 'TruncateSector'
+	// instruction. It involves reading and rewriting a sector.
+	MDMTimeTruncateSector = MDMTimeReadSector + MDMTimeWriteSector
+
+	// MDMTimeUpdateSectorRange is the time for executing an
+	// 'UpdateSectorRange' instruction. It involves reading and rewriting a
+	// sector.
+	MDMTimeUpdateSectorRange = MDMTimeReadSector + MDMTimeWriteSector
+
 	// MDMTimeWriteSector is the time for executing a 'WriteSector' instruction.
 	MDMTimeWriteSector = 10000
 
@@ -113,6 +126,10 @@ const (
 	// Instruction.
 	RPCIDropSectorsLen = 9
 
+	// RPCIExpectRevisionLen is the expected length of the 'Args' of an
+	// ExpectRevision instruction.
+	RPCIExpectRevisionLen = 8
+
 	// RPCIHasSectorLen is the expected length of the 'Args' of a HasSector
 	// instruction.
 	RPCIHasSectorLen = 8
@@ -121,6 +138,16 @@ const (
 	// instruction.
 	RPCIReadSectorLen = 25
 
+	// RPCIReadSectorWithPrefetchLen is the expected length of the 'Args' of a
+	// ReadSector instruction with prefetch hints.
+	// rootOffset + offsetOffset + lengthOffset + proof + prefetchOffset +
+	// numPrefetch = 3 * 8 bytes + 1 + 2 * 8 bytes = 41 byte
+	RPCIReadSectorWithPrefetchLen = 41
+
+	// MDMMaxPrefetchSectors is the max number of sector roots a ReadSector
+	// instruction may hint at for prefetching.
+	MDMMaxPrefetchSectors = 8
+
 	// RPCIReadOffsetLen is the expected length of the 'Args' of a ReadOffset
 	// instruction.
 	RPCIReadOffsetLen = 17
@@ -133,6 +160,14 @@ const (
 	// instructon.
 	RPCISwapSectorLen = 17 // 2 uint64 offsets + merkle proof flag
 
+	// RPCITruncateSectorLen is the expected length of the 'Args' of a
+	// TruncateSector instruction.
+	RPCITruncateSectorLen = 17 // sector index offset + length offset + merkle proof flag
+
+	// RPCIUpdateSectorRangeLen is the expected length of the 'Args' of an
+	// UpdateSectorRange instruction.
+	RPCIUpdateSectorRangeLen = 33 // sector index, offset, length and data offsets + merkle proof flag
+
 	// RPCIUpdateRegistryLen is the expected length of the 'Args' of an
 	// UpdateRegistry instruction.
 	// tweakOffset + revisionOffset + signatureOffset + pubKeyOffset +
@@ -185,6 +220,10 @@ var (
 	// SpecifierDropSectors is the specifier for the DropSectors instruction.
 	SpecifierDropSectors = InstructionSpecifier{'D', 'r', 'o', 'p', 'S', 'e', 'c', 't', 'o', 'r', 's'}
 
+	// SpecifierExpectRevision is the specifier for the ExpectRevision
+	// instruction.
+	SpecifierExpectRevision = InstructionSpecifier{'E', 'x', 'p', 'e', 'c', 't', 'R', 'e', 'v', 'i', 's', 'i', 'o', 'n'}
+
 	// SpecifierHasSector is the specifier for the HasSector instruction.
 	SpecifierHasSector = InstructionSpecifier{'H', 'a', 's', 'S', 'e', 'c', 't', 'o', 'r'}
 
@@ -200,6 +239,14 @@ var (
 	// SpecifierSwapSector is the specifier for the SwapSector instruction.
 	SpecifierSwapSector = InstructionSpecifier{'S', 'w', 'a', 'p', 'S', 'e', 'c', 't', 'o', 'r'}
 
+	// SpecifierTruncateSector is the specifier for the TruncateSector
+	// instruction.
+	SpecifierTruncateSector = InstructionSpecifier{'T', 'r', 'u', 'n', 'c', 'a', 't', 'e', 'S', 'e', 'c', 't', 'o', 'r'}
+
+	// SpecifierUpdateSectorRange is the specifier for the UpdateSectorRange
+	// instruction.
+	SpecifierUpdateSectorRange = InstructionSpecifier{'U', 'p', 'd', 'a', 't', 'e', 'S', 'e', 'c', 'R', 'a', 'n', 'g', 'e'}
+
 	// SpecifierUpdateRegistry is the specifier for the UpdateRegistry
 	// instruction.
 	SpecifierUpdateRegistry = InstructionSpecifier{'U', 'p', 'd', 'a', 't', 'e', 'R', 'e', 'g', 'i', 's', 't', 'r', 'y'}
@@ -216,6 +263,11 @@ var (
 	// be paid for with the provided budget.
 	ErrInsufficientBandwidthBudget = errors.New("insufficient budget for bandwidth")
 
+	// ErrMDMUnexpectedRevision is the error returned by an ExpectRevision
+	// instruction if the host's revision of the contract doesn't match the
+	// revision the renter expects.
+	ErrMDMUnexpectedRevision = errors.New("host's contract revision doesn't match the expected revision")
+
 	// ErrMDMInsufficientBudget is the error returned if the remaining budget of
 	// an MDM program is not sufficient to execute the next instruction.
 	ErrMDMInsufficientBudget = errors.New("remaining budget is insufficient")
@@ -224,9 +276,29 @@ var (
 	// collateral budget of an MDM program is not sufficient to execute the next
 	// instruction.
 	ErrMDMInsufficientCollateralBudget = errors.New("remaining collateral budget is insufficient")
+
+	// ErrMDMProgramTooManyInstructions is the error returned if a program
+	// contains more instructions than the host's MaxProgramInstructions.
+	ErrMDMProgramTooManyInstructions = errors.New("program contains too many instructions")
+
+	// ErrMDMProgramDataTooLong is the error returned if the data of a program
+	// is longer than the host's MaxProgramDataLength.
+	ErrMDMProgramDataTooLong = errors.New("program data is too long")
+
+	// ErrMDMProgramTimeout is the error returned if the execution of a
+	// program takes longer than the host's MaxProgramDuration.
+	ErrMDMProgramTimeout = errors.New("program exceeded the max execution time")
 )
 
 type (
+	// MDMProgramLimits are the limits the host enforces on the programs it
+	// executes. A limit of 0 means that the host doesn't enforce it.
+	MDMProgramLimits struct {
+		MaxInstructions uint64
+		MaxDataLength   uint64
+		MaxDuration     time.Duration
+	}
+
 	// MDMInstructionRevisionResponse is the format of the MDM's revision
 	// instruction's output.
 	MDMInstructionRevisionResponse struct {
@@ -296,6 +368,12 @@ func MDMInitCost(pt *RPCPriceTable, programLen, numInstructions uint64) types.Cu
 	return MDMMemoryCost(pt, programLen, time).Add(pt.InitBaseCost)
 }
 
+// MDMExpectRevisionCost is the cost of executing an 'ExpectRevision'
+// instruction.
+func MDMExpectRevisionCost(pt *RPCPriceTable) types.Currency {
+	return pt.RevisionBaseCost
+}
+
 // MDMHasSectorCost is the cost of executing a 'HasSector' instruction.
 func MDMHasSectorCost(pt *RPCPriceTable) types.Currency {
 	cost := pt.HasSectorBaseCost
@@ -320,6 +398,18 @@ func MDMSwapSectorCost(pt *RPCPriceTable) types.Currency {
 	return pt.SwapSectorCost
 }
 
+// MDMTruncateSectorCost is the cost of executing a 'TruncateSector'
+// instruction. The host reads the sector and writes the truncated sector.
+func MDMTruncateSectorCost(pt *RPCPriceTable) types.Currency {
+	return MDMReadCost(pt, SectorSize).Add(MDMWriteCost(pt, SectorSize))
+}
+
+// MDMUpdateSectorRangeCost is the cost of executing an 'UpdateSectorRange'
+// instruction. The host reads the sector and writes the updated sector.
+func MDMUpdateSectorRangeCost(pt *RPCPriceTable) types.Currency {
+	return MDMReadCost(pt, SectorSize).Add(MDMWriteCost(pt, SectorSize))
+}
+
 // V154MDMUpdateRegistryCost is the cost of executing a 'UpdateRegistry'
 // instruction in host versions 1.5.4 and below.
 func V154MDMUpdateRegistryCost(pt *RPCPriceTable) (_, _ types.Currency) {
@@ -417,6 +507,12 @@ func MDMInitMemory() uint64 {
 	return 1 << 20 // 1 MiB
 }
 
+// MDMExpectRevisionMemory returns the additional memory consumption of an
+// 'ExpectRevision' instruction.
+func MDMExpectRevisionMemory() uint64 {
+	return 0 // 'ExpectRevision' doesn't hold on to any memory beyond the lifetime of the instruction.
+}
+
 // MDMHasSectorMemory returns the additional memory consumption of a 'HasSector'
 // instruction.
 func MDMHasSectorMemory() uint64 {
@@ -440,6 +536,18 @@ func MDMSwapSectorMemory() uint64 {
 	return 0 // 'SwapSector' doesn't hold on to any memory beyond the lifetime of the instruction.
 }
 
+// MDMTruncateSectorMemory returns the additional memory consumption of a
+// 'TruncateSector' instruction.
+func MDMTruncateSectorMemory() uint64 {
+	return SectorSize // The truncated sector is kept in the program's memory until the program is finalized.
+}
+
+// MDMUpdateSectorRangeMemory returns the additional memory consumption of an
+// 'UpdateSectorRange' instruction.
+func MDMUpdateSectorRangeMemory() uint64 {
+	return SectorSize // The updated sector is kept in the program's memory until the program is finalized.
+}
+
 // MDMUpdateRegistryMemory returns the additional memory consumption of a
 // 'UpdateRegistry' instruction.
 func MDMUpdateRegistryMemory() uint64 {
@@ -483,6 +591,12 @@ func MDMDropSectorsCollateral() types.Currency {
 	return types.ZeroCurrency
 }
 
+// MDMExpectRevisionCollateral returns the additional collateral an
+// 'ExpectRevision' instruction requires the host to put up.
+func MDMExpectRevisionCollateral() types.Currency {
+	return types.ZeroCurrency
+}
+
 // MDMHasSectorCollateral returns the additional collateral a 'HasSector'
 // instruction requires the host to put up.
 func MDMHasSectorCollateral() types.Currency {
@@ -507,6 +621,18 @@ func MDMSwapSectorCollateral() types.Currency {
 	return types.ZeroCurrency
 }
 
+// MDMTruncateSectorCollateral returns the additional collateral a
+// 'TruncateSector' instruction requires the host to put up.
+func MDMTruncateSectorCollateral() types.Currency {
+	return types.ZeroCurrency
+}
+
+// MDMUpdateSectorRangeCollateral returns the additional collateral an
+// 'UpdateSectorRange' instruction requires the host to put up.
+func MDMUpdateSectorRangeCollateral() types.Currency {
+	return types.ZeroCurrency
+}
+
 // MDMUpdateRegistryCollateral returns the additional collateral a
 // 'UpdateRegistry' instruction requires the host to put up.
 func MDMUpdateRegistryCollateral() types.Currency {
@@ -527,12 +653,17 @@ func (p Program) ReadOnly() bool {
 			return false
 		case SpecifierDropSectors:
 			return false
+		case SpecifierExpectRevision:
 		case SpecifierHasSector:
 		case SpecifierReadOffset:
 		case SpecifierReadSector:
 		case SpecifierRevision:
 		case SpecifierSwapSector:
 			return false
+		case SpecifierTruncateSector:
+			return false
+		case SpecifierUpdateSectorRange:
+			return false
 		case SpecifierUpdateRegistry:
 			// considered read-only cause it doesn't update a contract
 		case SpecifierReadRegistry:
@@ -555,6 +686,8 @@ func (p Program) RequiresSnapshot() bool {
 			return true
 		case SpecifierDropSectors:
 			return true
+		case SpecifierExpectRevision:
+			return true
 		case SpecifierHasSector:
 		case SpecifierReadOffset:
 			return true
@@ -563,6 +696,10 @@ func (p Program) RequiresSnapshot() bool {
 			return true
 		case SpecifierSwapSector:
 			return true
+		case SpecifierTruncateSector:
+			return true
+		case SpecifierUpdateSectorRange:
+			return true
 		case SpecifierUpdateRegistry:
 		case SpecifierReadRegistry:
 		case SpecifierReadRegistryEID:
@@ -201,6 +201,11 @@ func TestRequiresReadonlyAndSnapshot(t *testing.T) {
 			false,
 			true,
 		},
+		{
+			SpecifierExpectRevision,
+			true,
+			true,
+		},
 		{
 			SpecifierHasSector,
 			true,
@@ -226,6 +231,16 @@ func TestRequiresReadonlyAndSnapshot(t *testing.T) {
 			false,
 			true,
 		},
+		{
+			SpecifierTruncateSector,
+			false,
+			true,
+		},
+		{
+			SpecifierUpdateSectorRange,
+			false,
+			true,
+		},
 	}
 
 	for i, test := range tests {
@@ -84,6 +84,27 @@ func (pb *ProgramBuilder) AddDropSectorsInstruction(numSectors uint64, merklePro
 	pb.readonly = false
 }
 
+// AddExpectRevisionInstruction adds an ExpectRevision instruction to the
+// program. The host fails the instruction, and therefore the remaining program,
+// if the revision number of its contract doesn't match revisionNumber. It is
+// usually the first instruction of a program.
+func (pb *ProgramBuilder) AddExpectRevisionInstruction(revisionNumber uint64) {
+	// Compute the argument offsets.
+	revisionNumberOffset := uint64(pb.programData.Len())
+	// Extend the programData.
+	binary.Write(pb.programData, binary.LittleEndian, revisionNumber)
+	// Create the instruction.
+	i := NewExpectRevisionInstruction(revisionNumberOffset)
+	// Append instruction
+	pb.program = append(pb.program, i)
+	// Update cost, collateral and memory usage.
+	collateral := MDMExpectRevisionCollateral()
+	cost := MDMExpectRevisionCost(pb.staticPT)
+	memory := MDMExpectRevisionMemory()
+	time := uint64(MDMTimeExpectRevision)
+	pb.addInstruction(collateral, cost, types.ZeroCurrency, memory, time)
+}
+
 // AddHasSectorInstruction adds a HasSector instruction to the program.
 func (pb *ProgramBuilder) AddHasSectorInstruction(merkleRoot crypto.Hash) {
 	// Compute the argument offsets.
@@ -144,6 +165,38 @@ func (pb *ProgramBuilder) AddReadSectorInstruction(length, offset uint64, merkle
 	pb.addInstruction(collateral, cost, types.ZeroCurrency, memory, time)
 }
 
+// AddReadSectorWithPrefetchInstruction adds a ReadSector instruction to the
+// program which hints at the sectors that are going to be read next. The host
+// warms those sectors into its cache while the program is running. At most
+// MDMMaxPrefetchSectors roots are sent, the rest are ignored.
+func (pb *ProgramBuilder) AddReadSectorWithPrefetchInstruction(length, offset uint64, merkleRoot crypto.Hash, prefetch []crypto.Hash, merkleProof bool) {
+	if len(prefetch) > MDMMaxPrefetchSectors {
+		prefetch = prefetch[:MDMMaxPrefetchSectors]
+	}
+	// Compute the argument offsets.
+	lengthOffset := uint64(pb.programData.Len())
+	offsetOffset := lengthOffset + 8
+	merkleRootOffset := offsetOffset + 8
+	prefetchOffset := merkleRootOffset + crypto.HashSize
+	// Extend the programData.
+	binary.Write(pb.programData, binary.LittleEndian, length)
+	binary.Write(pb.programData, binary.LittleEndian, offset)
+	binary.Write(pb.programData, binary.LittleEndian, merkleRoot[:])
+	for _, root := range prefetch {
+		binary.Write(pb.programData, binary.LittleEndian, root[:])
+	}
+	// Create the instruction.
+	i := NewReadSectorWithPrefetchInstruction(lengthOffset, offsetOffset, merkleRootOffset, prefetchOffset, uint64(len(prefetch)), merkleProof)
+	// Append instruction
+	pb.program = append(pb.program, i)
+	// Update cost, collateral and memory usage.
+	collateral := MDMReadCollateral()
+	cost := MDMReadCost(pb.staticPT, length)
+	memory := MDMReadMemory()
+	time := uint64(MDMTimeReadSector)
+	pb.addInstruction(collateral, cost, types.ZeroCurrency, memory, time)
+}
+
 // AddRevisionInstruction adds a Revision instruction to the program.
 func (pb *ProgramBuilder) AddRevisionInstruction() {
 	// Compute the argument offsets.
@@ -181,6 +234,55 @@ func (pb *ProgramBuilder) AddSwapSectorInstruction(sector1Idx, sector2Idx uint64
 	pb.readonly = false
 }
 
+// AddTruncateSectorInstruction adds a TruncateSector instruction to the
+// program. The data of the sector at sectorIdx beyond length is zeroed out.
+func (pb *ProgramBuilder) AddTruncateSectorInstruction(sectorIdx, length uint64, merkleProof bool) {
+	// Compute the argument offsets.
+	sectorIdxOffset := uint64(pb.programData.Len())
+	lengthOffset := sectorIdxOffset + 8
+	// Extend the programData.
+	binary.Write(pb.programData, binary.LittleEndian, sectorIdx)
+	binary.Write(pb.programData, binary.LittleEndian, length)
+	// Create the instruction.
+	i := NewTruncateSectorInstruction(sectorIdxOffset, lengthOffset, merkleProof)
+	// Append instruction
+	pb.program = append(pb.program, i)
+	// Update cost, collateral and memory usage.
+	collateral := MDMTruncateSectorCollateral()
+	cost := MDMTruncateSectorCost(pb.staticPT)
+	memory := MDMTruncateSectorMemory()
+	time := uint64(MDMTimeTruncateSector)
+	pb.addInstruction(collateral, cost, types.ZeroCurrency, memory, time)
+	pb.readonly = false
+}
+
+// AddUpdateSectorRangeInstruction adds an UpdateSectorRange instruction to the
+// program. The data of the sector at sectorIdx starting at offset is
+// overwritten with the provided data.
+func (pb *ProgramBuilder) AddUpdateSectorRangeInstruction(sectorIdx, offset uint64, data []byte, merkleProof bool) {
+	// Compute the argument offsets.
+	sectorIdxOffset := uint64(pb.programData.Len())
+	offsetOffset := sectorIdxOffset + 8
+	lengthOffset := offsetOffset + 8
+	dataOffset := lengthOffset + 8
+	// Extend the programData.
+	binary.Write(pb.programData, binary.LittleEndian, sectorIdx)
+	binary.Write(pb.programData, binary.LittleEndian, offset)
+	binary.Write(pb.programData, binary.LittleEndian, uint64(len(data)))
+	pb.programData.Write(data)
+	// Create the instruction.
+	i := NewUpdateSectorRangeInstruction(sectorIdxOffset, offsetOffset, lengthOffset, dataOffset, merkleProof)
+	// Append instruction
+	pb.program = append(pb.program, i)
+	// Update cost, collateral and memory usage.
+	collateral := MDMUpdateSectorRangeCollateral()
+	cost := MDMUpdateSectorRangeCost(pb.staticPT)
+	memory := MDMUpdateSectorRangeMemory()
+	time := uint64(MDMTimeUpdateSectorRange)
+	pb.addInstruction(collateral, cost, types.ZeroCurrency, memory, time)
+	pb.readonly = false
+}
+
 // V156AddUpdateRegistryInstruction adds an UpdateRegistry instruction to the
 // program.
 func (pb *ProgramBuilder) V156AddUpdateRegistryInstruction(spk types.SiaPublicKey, rv SignedRegistryValue) error {
@@ -575,6 +677,16 @@ func NewReadSectorInstruction(lengthOffset, offsetOffset, merkleRootOffset uint6
 	return i
 }
 
+// NewReadSectorWithPrefetchInstruction creates a modules.Instruction from
+// arguments.
+func NewReadSectorWithPrefetchInstruction(lengthOffset, offsetOffset, merkleRootOffset, prefetchOffset, numPrefetch uint64, merkleProof bool) Instruction {
+	i := NewReadSectorInstruction(lengthOffset, offsetOffset, merkleRootOffset, merkleProof)
+	i.Args = append(i.Args, make([]byte, RPCIReadSectorWithPrefetchLen-RPCIReadSectorLen)...)
+	binary.LittleEndian.PutUint64(i.Args[25:33], prefetchOffset)
+	binary.LittleEndian.PutUint64(i.Args[33:41], numPrefetch)
+	return i
+}
+
 // NewSwapSectorInstruction creates a modules.Instruction from arguments.
 func NewSwapSectorInstruction(sector1Offset, sector2Offset uint64, merkleProof bool) Instruction {
 	i := Instruction{
@@ -589,6 +701,47 @@ func NewSwapSectorInstruction(sector1Offset, sector2Offset uint64, merkleProof b
 	return i
 }
 
+// NewTruncateSectorInstruction creates a modules.Instruction from arguments.
+func NewTruncateSectorInstruction(sectorIdxOffset, lengthOffset uint64, merkleProof bool) Instruction {
+	i := Instruction{
+		Specifier: SpecifierTruncateSector,
+		Args:      make([]byte, RPCITruncateSectorLen),
+	}
+	binary.LittleEndian.PutUint64(i.Args[:8], sectorIdxOffset)
+	binary.LittleEndian.PutUint64(i.Args[8:16], lengthOffset)
+	if merkleProof {
+		i.Args[16] = 1
+	}
+	return i
+}
+
+// NewUpdateSectorRangeInstruction creates a modules.Instruction from
+// arguments.
+func NewUpdateSectorRangeInstruction(sectorIdxOffset, offsetOffset, lengthOffset, dataOffset uint64, merkleProof bool) Instruction {
+	i := Instruction{
+		Specifier: SpecifierUpdateSectorRange,
+		Args:      make([]byte, RPCIUpdateSectorRangeLen),
+	}
+	binary.LittleEndian.PutUint64(i.Args[:8], sectorIdxOffset)
+	binary.LittleEndian.PutUint64(i.Args[8:16], offsetOffset)
+	binary.LittleEndian.PutUint64(i.Args[16:24], lengthOffset)
+	binary.LittleEndian.PutUint64(i.Args[24:32], dataOffset)
+	if merkleProof {
+		i.Args[32] = 1
+	}
+	return i
+}
+
+// NewExpectRevisionInstruction creates a modules.Instruction from arguments.
+func NewExpectRevisionInstruction(revisionNumberOffset uint64) Instruction {
+	i := Instruction{
+		Specifier: SpecifierExpectRevision,
+		Args:      make([]byte, RPCIExpectRevisionLen),
+	}
+	binary.LittleEndian.PutUint64(i.Args[:8], revisionNumberOffset)
+	return i
+}
+
 // NewRevisionInstruction creates a modules.Instruction from arguments.
 func NewRevisionInstruction(merkleRootOffset uint64) Instruction {
 	return Instruction{
@@ -0,0 +1,136 @@
+package modules
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The following consts are the types of metrics supported by the
+// MetricsRegistry.
+const (
+	// MetricTypeCounter is a metric which only ever increases, e.g. the
+	// number of RPCs the host handled.
+	MetricTypeCounter MetricType = "counter"
+	// MetricTypeGauge is a metric which can go up and down, e.g. the current
+	// block height.
+	MetricTypeGauge MetricType = "gauge"
+)
+
+type (
+	// Metric is a single sample of a metric.
+	Metric struct {
+		// Name is the name of the metric. Samples with the same name but
+		// different labels belong to the same metric.
+		Name string
+		// Help is a short description of the metric.
+		Help string
+		// Type is the type of the metric.
+		Type MetricType
+		// Labels distinguish the samples of a metric.
+		Labels map[string]string
+		// Value is the value of the sample.
+		Value float64
+	}
+
+	// MetricType describes the type of a metric.
+	MetricType string
+
+	// MetricsCollector returns the current samples of the metrics of a source.
+	MetricsCollector func() []Metric
+
+	// MetricsRegistry collects the metrics of the modules. Modules publish
+	// their metrics by registering a collector which is called whenever the
+	// metrics are gathered.
+	MetricsRegistry struct {
+		collectors map[string]MetricsCollector
+		mu         sync.Mutex
+	}
+)
+
+// NewMetricsRegistry creates a new MetricsRegistry without any collectors.
+func NewMetricsRegistry() *MetricsRegistry {
+	return &MetricsRegistry{
+		collectors: make(map[string]MetricsCollector),
+	}
+}
+
+// Register registers the collector of a source, replacing the source's
+// previous collector.
+func (mr *MetricsRegistry) Register(source string, collector MetricsCollector) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	mr.collectors[source] = collector
+}
+
+// Unregister removes the collector of a source.
+func (mr *MetricsRegistry) Unregister(source string) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	delete(mr.collectors, source)
+}
+
+// Gather calls all collectors and returns the samples sorted by name. The
+// samples of a metric keep the order in which they were collected.
+func (mr *MetricsRegistry) Gather() []Metric {
+	mr.mu.Lock()
+	collectors := make([]MetricsCollector, 0, len(mr.collectors))
+	for _, c := range mr.collectors {
+		collectors = append(collectors, c)
+	}
+	mr.mu.Unlock()
+
+	// Call the collectors without holding the lock since they might query
+	// the modules.
+	var metrics []Metric
+	for _, c := range collectors {
+		metrics = append(metrics, c()...)
+	}
+	sort.SliceStable(metrics, func(i, j int) bool {
+		return metrics[i].Name < metrics[j].Name
+	})
+	return metrics
+}
+
+// WritePrometheus writes the samples in the Prometheus text exposition
+// format. The help and type of a metric are taken from its first sample.
+func WritePrometheus(w io.Writer, metrics []Metric) error {
+	bw := bufio.NewWriter(w)
+	for i, m := range metrics {
+		if i == 0 || metrics[i-1].Name != m.Name {
+			fmt.Fprintf(bw, "# HELP %v %v\n", m.Name, escapeMetricHelp(m.Help))
+			fmt.Fprintf(bw, "# TYPE %v %v\n", m.Name, m.Type)
+		}
+		bw.WriteString(m.Name)
+		if len(m.Labels) > 0 {
+			names := make([]string, 0, len(m.Labels))
+			for name := range m.Labels {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			labels := make([]string, 0, len(names))
+			for _, name := range names {
+				labels = append(labels, fmt.Sprintf("%v=\"%v\"", name, escapeMetricLabel(m.Labels[name])))
+			}
+			fmt.Fprintf(bw, "{%v}", strings.Join(labels, ","))
+		}
+		fmt.Fprintf(bw, " %v\n", strconv.FormatFloat(m.Value, 'g', -1, 64))
+	}
+	return bw.Flush()
+}
+
+// escapeMetricHelp escapes backslashes and line feeds in the help of a
+// metric.
+func escapeMetricHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+// escapeMetricLabel escapes backslashes, double quotes and line feeds in the
+// value of a label.
+func escapeMetricLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
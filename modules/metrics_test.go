@@ -0,0 +1,49 @@
+package modules
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestMetricsRegistry tests gathering metrics from the MetricsRegistry and
+// writing them in the Prometheus format.
+func TestMetricsRegistry(t *testing.T) {
+	t.Parallel()
+
+	mr := NewMetricsRegistry()
+	mr.Register("consensus", func() []Metric {
+		return []Metric{{Name: "sia_consensus_height", Help: "The current block height.", Type: MetricTypeGauge, Value: 300000}}
+	})
+	mr.Register("gateway", func() []Metric {
+		return []Metric{
+			{Name: "sia_gateway_peers", Help: "The number of peers.", Type: MetricTypeGauge, Labels: map[string]string{"direction": "inbound"}, Value: 2},
+			{Name: "sia_gateway_peers", Help: "The number of peers.", Type: MetricTypeGauge, Labels: map[string]string{"direction": "outbound"}, Value: 8},
+		}
+	})
+	mr.Register("host", func() []Metric {
+		return []Metric{{Name: "sia_host_rpc_calls_total", Help: "The number of RPCs\nhandled.", Type: MetricTypeCounter, Labels: map[string]string{"rpc": `a"b`, "a": "x"}, Value: 0.5}}
+	})
+	mr.Register("tpool", func() []Metric {
+		return []Metric{{Name: "sia_tpool_transactions", Type: MetricTypeGauge, Value: 1}}
+	})
+	mr.Unregister("tpool")
+
+	var buf bytes.Buffer
+	if err := WritePrometheus(&buf, mr.Gather()); err != nil {
+		t.Fatal(err)
+	}
+	expected := `# HELP sia_consensus_height The current block height.
+# TYPE sia_consensus_height gauge
+sia_consensus_height 300000
+# HELP sia_gateway_peers The number of peers.
+# TYPE sia_gateway_peers gauge
+sia_gateway_peers{direction="inbound"} 2
+sia_gateway_peers{direction="outbound"} 8
+# HELP sia_host_rpc_calls_total The number of RPCs\nhandled.
+# TYPE sia_host_rpc_calls_total counter
+sia_host_rpc_calls_total{a="x",rpc="a\"b"} 0.5
+`
+	if buf.String() != expected {
+		t.Fatalf("unexpected output:\n%v", buf.String())
+	}
+}
@@ -0,0 +1,292 @@
+package modules
+
+// The metrics journal makes statistical counters, such as the revenue of a
+// host, crash-consistent. Modules usually persist their counters together
+// with the rest of their state at intervals which means that updates since
+// the last save are lost if the process crashes.
+//
+// The journal is a JSON file which starts with the metadata and the initial
+// values of the counters, followed by one checksummed update per line. Every
+// update contains the new values of the changed counters and is synced to
+// disk before the call returns. A partially written update at the end of the
+// file is ignored when the journal is opened. Once the journal contains enough
+// updates it is compacted by replacing it with a file containing only the
+// current values.
+//
+// At startup, a module reconciles the counters it persisted with the counters
+// of the journal. The journal's values take precedence and the differences are
+// reported to the user.
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// metricsJournalCompactionThreshold is the number of updates after which
+	// the metrics journal is compacted.
+	metricsJournalCompactionThreshold = 1000
+)
+
+var (
+	// metricsJournalMetadata is the metadata of the metrics journal.
+	metricsJournalMetadata = persist.Metadata{
+		Header:  "Metrics Journal",
+		Version: "1.5.6",
+	}
+
+	// errMetricsJournalBadChecksum is returned when decoding an update with
+	// an invalid checksum.
+	errMetricsJournalBadChecksum = errors.New("bad checksum")
+)
+
+type (
+	// MetricsJournal is a write-ahead journal for the statistical counters of
+	// a module.
+	MetricsJournal struct {
+		counters   map[string]types.Currency
+		f          *os.File
+		numUpdates int
+		staticPath string
+		mu         sync.Mutex
+	}
+
+	// MetricsReconciliation is the result of reconciling the persisted
+	// counters of a module with its metrics journal at startup.
+	MetricsReconciliation struct {
+		Timestamp time.Time      `json:"timestamp"`
+		Drifts    []MetricsDrift `json:"drifts"`
+	}
+
+	// MetricsDrift describes a counter whose persisted value differed from
+	// the value in the metrics journal.
+	MetricsDrift struct {
+		Counter   string         `json:"counter"`
+		Persisted types.Currency `json:"persisted"`
+		Journaled types.Currency `json:"journaled"`
+	}
+
+	// metricsJournalUpdate is a single update of the metrics journal.
+	metricsJournalUpdate struct {
+		Counters json.RawMessage `json:"counters"`
+		Checksum crypto.Hash     `json:"checksum"`
+	}
+)
+
+// NewMetricsJournal opens the metrics journal at the provided path and
+// replays its updates. If the journal doesn't exist yet, an empty one is
+// created.
+func NewMetricsJournal(path string) (*MetricsJournal, error) {
+	mj := &MetricsJournal{
+		counters:   make(map[string]types.Currency),
+		staticPath: path,
+	}
+	_, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		if err := mj.compact(); err != nil {
+			return nil, errors.AddContext(err, "unable to create metrics journal")
+		}
+		return mj, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(f)
+	var meta persist.Metadata
+	if err := dec.Decode(&meta); err != nil {
+		return nil, errors.Compose(errors.AddContext(err, "unable to decode metrics journal metadata"), f.Close())
+	} else if meta != metricsJournalMetadata {
+		return nil, errors.Compose(fmt.Errorf("unexpected metrics journal metadata %v", meta), f.Close())
+	}
+	if err := dec.Decode(&mj.counters); err != nil {
+		return nil, errors.Compose(errors.AddContext(err, "unable to decode initial counters"), f.Close())
+	}
+	if mj.counters == nil {
+		mj.counters = make(map[string]types.Currency)
+	}
+	for {
+		var update metricsJournalUpdate
+		err := dec.Decode(&update)
+		if errors.Contains(err, io.EOF) || errors.Contains(err, io.ErrUnexpectedEOF) {
+			// An unexpected EOF means that the last update was only
+			// partially written.
+			break
+		} else if err != nil {
+			// The rest of the journal can't be decoded anymore.
+			break
+		}
+		counters, err := update.decode()
+		if err != nil {
+			continue
+		}
+		for counter, value := range counters {
+			mj.counters[counter] = value
+		}
+		mj.numUpdates++
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+
+	// Compact the journal to get rid of any corrupted updates and to be able
+	// to append to it.
+	if err := mj.compact(); err != nil {
+		return nil, errors.AddContext(err, "unable to compact metrics journal")
+	}
+	return mj, nil
+}
+
+// decode verifies the checksum of an update and decodes its counters.
+func (u metricsJournalUpdate) decode() (map[string]types.Currency, error) {
+	if crypto.HashBytes(u.Counters) != u.Checksum {
+		return nil, errMetricsJournalBadChecksum
+	}
+	var counters map[string]types.Currency
+	err := json.Unmarshal(u.Counters, &counters)
+	return counters, err
+}
+
+// compact replaces the journal with a file containing only the current values
+// of the counters and opens it for appending updates.
+func (mj *MetricsJournal) compact() error {
+	if mj.f != nil {
+		if err := mj.f.Close(); err != nil {
+			return err
+		}
+		mj.f = nil
+	}
+	tmpPath := mj.staticPath + "_temp"
+	f, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	err = errors.Compose(enc.Encode(metricsJournalMetadata), enc.Encode(mj.counters), f.Sync(), f.Close())
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, mj.staticPath); err != nil {
+		return err
+	}
+	mj.f, err = os.OpenFile(mj.staticPath, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	mj.numUpdates = 0
+	return nil
+}
+
+// Close closes the journal.
+func (mj *MetricsJournal) Close() error {
+	mj.mu.Lock()
+	defer mj.mu.Unlock()
+	if mj.f == nil {
+		return nil
+	}
+	err := mj.f.Close()
+	mj.f = nil
+	return err
+}
+
+// Counters returns the current values of the counters in the journal.
+func (mj *MetricsJournal) Counters() map[string]types.Currency {
+	mj.mu.Lock()
+	defer mj.mu.Unlock()
+	counters := make(map[string]types.Currency, len(mj.counters))
+	for counter, value := range mj.counters {
+		counters[counter] = value
+	}
+	return counters
+}
+
+// Record appends the new values of the provided counters to the journal and
+// syncs it to disk. Counters whose values didn't change are not written.
+func (mj *MetricsJournal) Record(counters map[string]types.Currency) error {
+	mj.mu.Lock()
+	defer mj.mu.Unlock()
+	if mj.f == nil {
+		return errors.New("metrics journal is closed")
+	}
+	changed := make(map[string]types.Currency)
+	for counter, value := range counters {
+		if old, exists := mj.counters[counter]; !exists || !old.Equals(value) {
+			changed[counter] = value
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(changed)
+	if err != nil {
+		return err
+	}
+	update, err := json.Marshal(metricsJournalUpdate{
+		Counters: data,
+		Checksum: crypto.HashBytes(data),
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := mj.f.Write(append(update, '\n')); err != nil {
+		return errors.AddContext(err, "unable to append update to metrics journal")
+	}
+	if err := mj.f.Sync(); err != nil {
+		return errors.AddContext(err, "unable to sync metrics journal")
+	}
+	for counter, value := range changed {
+		mj.counters[counter] = value
+	}
+	mj.numUpdates++
+	if mj.numUpdates >= metricsJournalCompactionThreshold {
+		return mj.compact()
+	}
+	return nil
+}
+
+// Reconcile compares the persisted values of a module's counters with the
+// values in the journal. It returns the reconciled counters, which use the
+// journal's value for every counter the journal knows about, and a report of
+// the counters which drifted. Counters which are unknown to the journal are
+// added to it.
+func (mj *MetricsJournal) Reconcile(persisted map[string]types.Currency) (map[string]types.Currency, MetricsReconciliation, error) {
+	mj.mu.Lock()
+	reconciled := make(map[string]types.Currency, len(persisted))
+	report := MetricsReconciliation{
+		Timestamp: time.Now(),
+	}
+	for counter, value := range persisted {
+		journaled, exists := mj.counters[counter]
+		if !exists {
+			reconciled[counter] = value
+			continue
+		}
+		reconciled[counter] = journaled
+		if !journaled.Equals(value) {
+			report.Drifts = append(report.Drifts, MetricsDrift{
+				Counter:   counter,
+				Persisted: value,
+				Journaled: journaled,
+			})
+		}
+	}
+	mj.mu.Unlock()
+	sort.Slice(report.Drifts, func(i, j int) bool {
+		return report.Drifts[i].Counter < report.Drifts[j].Counter
+	})
+	return reconciled, report, mj.Record(reconciled)
+}
@@ -0,0 +1,155 @@
+package modules
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/types"
+)
+
+// TestMetricsJournal tests recording, replaying and compacting the metrics
+// journal.
+func TestMetricsJournal(t *testing.T) {
+	t.Parallel()
+
+	testDir := build.TempDir("modules", t.Name())
+	if err := os.RemoveAll(testDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(testDir, DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(testDir, "metrics.journal")
+
+	mj, err := NewMetricsJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mj.Counters()) != 0 {
+		t.Fatal("new journal should be empty", mj.Counters())
+	}
+	for i := uint64(1); i <= 10; i++ {
+		err := mj.Record(map[string]types.Currency{
+			"a": types.NewCurrency64(i),
+			"b": types.NewCurrency64(42),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := mj.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Append a partially written update to simulate a crash.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"counters":{"a":"1`); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reopen the journal. The updates should be replayed and the torn update
+	// ignored.
+	mj, err = NewMetricsJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counters := mj.Counters()
+	if len(counters) != 2 || !counters["a"].Equals64(10) || !counters["b"].Equals64(42) {
+		t.Fatal("wrong counters after replay", counters)
+	}
+
+	// The journal should have been compacted on open.
+	if mj.numUpdates != 0 {
+		t.Fatal("journal wasn't compacted", mj.numUpdates)
+	}
+
+	// Recording enough updates should compact the journal again.
+	for i := uint64(0); i < metricsJournalCompactionThreshold; i++ {
+		if err := mj.Record(map[string]types.Currency{"a": types.NewCurrency64(i + 100)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if mj.numUpdates != 0 {
+		t.Fatal("journal wasn't compacted", mj.numUpdates)
+	}
+	if err := mj.Close(); err != nil {
+		t.Fatal(err)
+	}
+	mj, err = NewMetricsJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := mj.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if a := mj.Counters()["a"]; !a.Equals64(metricsJournalCompactionThreshold + 99) {
+		t.Fatal("wrong counter after compaction", a)
+	}
+}
+
+// TestMetricsJournalReconcile tests reconciling persisted counters with the
+// metrics journal.
+func TestMetricsJournalReconcile(t *testing.T) {
+	t.Parallel()
+
+	testDir := build.TempDir("modules", t.Name())
+	if err := os.RemoveAll(testDir); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(testDir, DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	mj, err := NewMetricsJournal(filepath.Join(testDir, "metrics.journal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := mj.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	err = mj.Record(map[string]types.Currency{
+		"a": types.NewCurrency64(1),
+		"b": types.NewCurrency64(2),
+		"c": types.NewCurrency64(3),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// "b" and "c" drifted, "d" is unknown to the journal.
+	reconciled, report, err := mj.Reconcile(map[string]types.Currency{
+		"a": types.NewCurrency64(1),
+		"b": types.NewCurrency64(1),
+		"c": types.NewCurrency64(5),
+		"d": types.NewCurrency64(4),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reconciled["a"].Equals64(1) || !reconciled["b"].Equals64(2) || !reconciled["c"].Equals64(3) || !reconciled["d"].Equals64(4) {
+		t.Fatal("wrong reconciled counters", reconciled)
+	}
+	if report.Timestamp.IsZero() || len(report.Drifts) != 2 {
+		t.Fatal("wrong report", report)
+	}
+	if d := report.Drifts[0]; d.Counter != "b" || !d.Persisted.Equals64(1) || !d.Journaled.Equals64(2) {
+		t.Fatal("wrong drift", d)
+	}
+	if d := report.Drifts[1]; d.Counter != "c" || !d.Persisted.Equals64(5) || !d.Journaled.Equals64(3) {
+		t.Fatal("wrong drift", d)
+	}
+	if d := mj.Counters()["d"]; !d.Equals64(4) {
+		t.Fatal("unknown counter wasn't added to the journal", d)
+	}
+}
@@ -4,11 +4,13 @@ import (
 	"time"
 
 	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
 )
 
 // threadedMine starts a gothread that does CPU mining. threadedMine is the
 // only function that should be setting the mining flag to true.
 func (m *Miner) threadedMine() {
+	defer modules.RecoverPanic("miner")
 	if err := m.tg.Add(); err != nil {
 		return
 	}
@@ -23,7 +23,7 @@ import (
 const (
 	// RHPVersion is the version of the Sia renter-host protocol currently
 	// implemented by the host module.
-	RHPVersion = "1.5.7"
+	RHPVersion = "1.5.11"
 
 	// MinimumSupportedRenterHostProtocolVersion is the minimum version of Sia
 	// that supports the currently used version of the renter-host protocol.
@@ -47,6 +47,10 @@ const (
 	// updated to include the new string while also still checking the old
 	// string as well to preserve compatibility.
 	V1420ContractNotRecognizedErrString = "no record of that contract"
+
+	// MaxAnnouncementAddresses is the maximum number of addresses a host can
+	// announce in a single announcement.
+	MaxAnnouncementAddresses = 4
 )
 
 const (
@@ -192,6 +196,10 @@ var (
 	// announcement will follow this prefix.
 	PrefixHostAnnouncement = types.NewSpecifier("HostAnnouncement")
 
+	// PrefixHostAnnouncementAddresses is used to indicate that a host
+	// announcement is followed by the additional addresses of the host.
+	PrefixHostAnnouncementAddresses = types.NewSpecifier("HostAddresses")
+
 	// PrefixFileContractIdentifier is used to indicate that a transaction's
 	// Arbitrary Data field contains a file contract identifier. The identifier
 	// and its signature will follow this prefix.
@@ -262,6 +270,17 @@ type (
 		PublicKey  types.SiaPublicKey
 	}
 
+	// HostAnnouncementAddresses is an optional extension of a
+	// HostAnnouncement which contains the additional addresses of the host,
+	// e.g. an IPv6 or an onion address. 'Specifier' is always
+	// 'PrefixHostAnnouncementAddresses'. The extension follows the signed
+	// announcement and is followed by a signature of both the announcement and
+	// the extension. Nodes which don't know about the extension ignore it.
+	HostAnnouncementAddresses struct {
+		Specifier types.Specifier
+		Addresses []NetAddress
+	}
+
 	// HostExternalSettings are the parameters advertised by the host. These
 	// are the values that the renter will request from the host in order to
 	// build its database.
@@ -335,6 +354,16 @@ type (
 		EphemeralAccountExpiry     time.Duration  `json:"ephemeralaccountexpiry"`
 		MaxEphemeralAccountBalance types.Currency `json:"maxephemeralaccountbalance"`
 
+		// MaxProgramInstructions, MaxProgramDataLength and
+		// MaxProgramDuration are the limits the host enforces on the MDM
+		// programs it executes. Programs exceeding the instruction count or
+		// data length are rejected before they are executed, programs running
+		// longer than the duration are interrupted. A limit of 0 means that
+		// the host doesn't enforce it.
+		MaxProgramInstructions uint64        `json:"maxprograminstructions"`
+		MaxProgramDataLength   uint64        `json:"maxprogramdatalength"`
+		MaxProgramDuration     time.Duration `json:"maxprogramduration"`
+
 		// Because the host has a public key, and settings are signed, and
 		// because settings may be MITM'd, settings need a revision number so
 		// that a renter can compare multiple sets of settings and determine
@@ -881,32 +910,109 @@ func CreateAnnouncement(addr NetAddress, pk types.SiaPublicKey, sk crypto.Secret
 	return append(annBytes, sig[:]...), nil
 }
 
+// CreateMultiAddressAnnouncement creates a host announcement for multiple
+// addresses. The first address is announced like in CreateAnnouncement, which
+// means that nodes that don't support multiple addresses will use it. The
+// remaining addresses are appended to the announcement as a
+// HostAnnouncementAddresses extension.
+func CreateMultiAddressAnnouncement(addrs []NetAddress, pk types.SiaPublicKey, sk crypto.SecretKey) ([]byte, error) {
+	if len(addrs) == 0 {
+		return nil, errors.New("no address to announce")
+	}
+	if len(addrs) > MaxAnnouncementAddresses {
+		return nil, fmt.Errorf("can't announce more than %v addresses", MaxAnnouncementAddresses)
+	}
+	ann, err := CreateAnnouncement(addrs[0], pk, sk)
+	if err != nil || len(addrs) == 1 {
+		return ann, err
+	}
+	for _, addr := range addrs[1:] {
+		if err := addr.IsValid(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Sign the announcement together with the extension to prevent the
+	// extension from being attached to a different announcement.
+	ext := HostAnnouncementAddresses{
+		Specifier: PrefixHostAnnouncementAddresses,
+		Addresses: addrs[1:],
+	}
+	ha := HostAnnouncement{
+		Specifier:  PrefixHostAnnouncement,
+		NetAddress: addrs[0],
+		PublicKey:  pk,
+	}
+	sig := crypto.SignHash(crypto.HashAll(ha, ext), sk)
+	ann = append(ann, encoding.Marshal(ext)...)
+	return append(ann, sig[:]...), nil
+}
+
 // DecodeAnnouncement decodes announcement bytes into a host announcement,
 // verifying the prefix and the signature.
 func DecodeAnnouncement(fullAnnouncement []byte) (na NetAddress, spk types.SiaPublicKey, err error) {
+	dec := encoding.NewDecoder(bytes.NewReader(fullAnnouncement), len(fullAnnouncement)*3)
+	ha, err := decodeAnnouncement(dec)
+	if err != nil {
+		return "", types.SiaPublicKey{}, err
+	}
+	return ha.NetAddress, ha.PublicKey, nil
+}
+
+// DecodeMultiAddressAnnouncement decodes announcement bytes into all the
+// addresses of a host, verifying the prefix and the signatures. The first
+// address is the one returned by DecodeAnnouncement. An invalid extension is
+// ignored to make sure that all nodes agree on the first address.
+func DecodeMultiAddressAnnouncement(fullAnnouncement []byte) ([]NetAddress, types.SiaPublicKey, error) {
+	dec := encoding.NewDecoder(bytes.NewReader(fullAnnouncement), len(fullAnnouncement)*3)
+	ha, err := decodeAnnouncement(dec)
+	if err != nil {
+		return nil, types.SiaPublicKey{}, err
+	}
+	addrs := []NetAddress{ha.NetAddress}
+
+	// Check for the extension.
+	var ext HostAnnouncementAddresses
+	var sig crypto.Signature
+	if err := dec.DecodeAll(&ext, &sig); err != nil {
+		return addrs, ha.PublicKey, nil
+	}
+	if ext.Specifier != PrefixHostAnnouncementAddresses || len(ext.Addresses) >= MaxAnnouncementAddresses {
+		return addrs, ha.PublicKey, nil
+	}
+	var pk crypto.PublicKey
+	copy(pk[:], ha.PublicKey.Key)
+	if err := crypto.VerifyHash(crypto.HashAll(ha, ext), pk, sig); err != nil {
+		return addrs, ha.PublicKey, nil
+	}
+	return append(addrs, ext.Addresses...), ha.PublicKey, nil
+}
+
+// decodeAnnouncement reads a host announcement from dec, verifying the prefix
+// and the signature.
+func decodeAnnouncement(dec *encoding.Decoder) (HostAnnouncement, error) {
 	// Read the first part of the announcement to get the intended host
 	// announcement.
 	var ha HostAnnouncement
-	dec := encoding.NewDecoder(bytes.NewReader(fullAnnouncement), len(fullAnnouncement)*3)
-	err = dec.Decode(&ha)
+	err := dec.Decode(&ha)
 	if err != nil {
-		return "", types.SiaPublicKey{}, err
+		return HostAnnouncement{}, err
 	}
 
 	// Check that the announcement was registered as a host announcement.
 	if ha.Specifier != PrefixHostAnnouncement {
-		return "", types.SiaPublicKey{}, ErrAnnNotAnnouncement
+		return HostAnnouncement{}, ErrAnnNotAnnouncement
 	}
 	// Check that the public key is a recognized type of public key.
 	if ha.PublicKey.Algorithm != types.SignatureEd25519 {
-		return "", types.SiaPublicKey{}, ErrAnnUnrecognizedSignature
+		return HostAnnouncement{}, ErrAnnUnrecognizedSignature
 	}
 
 	// Read the signature out of the reader.
 	var sig crypto.Signature
 	err = dec.Decode(&sig)
 	if err != nil {
-		return "", types.SiaPublicKey{}, err
+		return HostAnnouncement{}, err
 	}
 	// Verify the signature.
 	var pk crypto.PublicKey
@@ -914,9 +1020,9 @@ func DecodeAnnouncement(fullAnnouncement []byte) (na NetAddress, spk types.SiaPu
 	annHash := crypto.HashObject(ha)
 	err = crypto.VerifyHash(annHash, pk, sig)
 	if err != nil {
-		return "", types.SiaPublicKey{}, err
+		return HostAnnouncement{}, err
 	}
-	return ha.NetAddress, ha.PublicKey, nil
+	return ha, nil
 }
 
 // IsOOSErr is a helper function to determine whether an error from a host is
@@ -1140,9 +1246,116 @@ func RPCSubscribeToRVs(stream siamux.Stream, requests []RPCRegistrySubscriptionR
 	if err != nil {
 		return nil, err
 	}
+	pubKeys := make([]types.SiaPublicKey, 0, len(requests))
+	for _, req := range requests {
+		pubKeys = append(pubKeys, req.PubKey)
+	}
+	return verifyInitialRVs(pubKeys, rvs)
+}
+
+// RPCSubscribeToRVsFiltered subscribes to the given publickey/tweak pairs.
+// Only revisions greater than the requests' MinRevision are returned as
+// initial values or sent as notifications. Notifications of a session with
+// filtered subscriptions are batched by the host.
+func RPCSubscribeToRVsFiltered(stream siamux.Stream, requests []RPCRegistrySubscriptionFilteredRequest) ([]RPCRegistrySubscriptionNotificationEntryUpdate, error) {
+	// Send the type of the request.
+	buf := bytes.NewBuffer(nil)
+	err := RPCWrite(buf, SubscriptionRequestSubscribeFiltered)
+	if err != nil {
+		return nil, err
+	}
+	// Send the request.
+	err = RPCWrite(buf, requests)
+	if err != nil {
+		return nil, err
+	}
+	// Write buffer to stream.
+	_, err = buf.WriteTo(stream)
+	if err != nil {
+		return nil, err
+	}
+	// Read response.
+	var rvs []SignedRegistryValue
+	err = RPCRead(stream, &rvs)
+	if err != nil {
+		return nil, err
+	}
+	pubKeys := make([]types.SiaPublicKey, 0, len(requests))
+	minRevisions := make(map[RegistryEntryID]uint64, len(requests))
+	for _, req := range requests {
+		pubKeys = append(pubKeys, req.PubKey)
+		minRevisions[DeriveRegistryEntryID(req.PubKey, req.Tweak)] = req.MinRevision
+	}
+	initialNotifications, err := verifyInitialRVs(pubKeys, rvs)
+	if err != nil {
+		return nil, err
+	}
+	// Make sure the host didn't send revisions we filtered out.
+	for _, notification := range initialNotifications {
+		minRevision, exists := minRevisions[DeriveRegistryEntryID(notification.PubKey, notification.Entry.Tweak)]
+		if !exists {
+			return nil, errors.New("host returned an rv we didn't subscribe to")
+		}
+		if notification.Entry.Revision <= minRevision {
+			return nil, fmt.Errorf("host returned an rv with revision %v which isn't greater than the min revision %v", notification.Entry.Revision, minRevision)
+		}
+	}
+	return initialNotifications, nil
+}
+
+// RPCResumeSubscription binds the session to the subscription set of the
+// token and resumes the set's subscriptions. It returns the current revision
+// of the set and the latest values of the entries which were updated after
+// the revision since.
+func RPCResumeSubscription(stream siamux.Stream, token crypto.Hash, since uint64) (RPCRegistrySubscriptionResumeResponse, []RPCRegistrySubscriptionNotificationEntryUpdate, error) {
+	// Send the type of the request and the request.
+	buf := bytes.NewBuffer(nil)
+	err := RPCWrite(buf, SubscriptionRequestResume)
+	if err != nil {
+		return RPCRegistrySubscriptionResumeResponse{}, nil, err
+	}
+	err = RPCWrite(buf, RPCRegistrySubscriptionResumeRequest{
+		Token: token,
+		Since: since,
+	})
+	if err != nil {
+		return RPCRegistrySubscriptionResumeResponse{}, nil, err
+	}
+	_, err = buf.WriteTo(stream)
+	if err != nil {
+		return RPCRegistrySubscriptionResumeResponse{}, nil, err
+	}
+	// Read the response and the updates.
+	var resp RPCRegistrySubscriptionResumeResponse
+	err = RPCRead(stream, &resp)
+	if err != nil {
+		return RPCRegistrySubscriptionResumeResponse{}, nil, err
+	}
+	if resp.NumUpdates > resp.NumSubscriptions {
+		return RPCRegistrySubscriptionResumeResponse{}, nil, fmt.Errorf("host returned more updates than subscriptions %v > %v", resp.NumUpdates, resp.NumSubscriptions)
+	}
+	var updates []RPCRegistrySubscriptionNotificationEntryUpdate
+	err = RPCReadMaxLen(stream, &updates, resp.NumUpdates*2*RegistryEntrySize+RPCMinLen)
+	if err != nil {
+		return RPCRegistrySubscriptionResumeResponse{}, nil, err
+	}
+	if uint64(len(updates)) != resp.NumUpdates {
+		return RPCRegistrySubscriptionResumeResponse{}, nil, fmt.Errorf("host returned %v updates instead of %v", len(updates), resp.NumUpdates)
+	}
+	for _, update := range updates {
+		if err := update.Entry.Verify(update.PubKey.ToPublicKey()); err != nil {
+			return RPCRegistrySubscriptionResumeResponse{}, nil, errors.AddContext(err, "host returned an invalid rv")
+		}
+	}
+	return resp, updates, nil
+}
+
+// verifyInitialRVs verifies the initial values returned by the host when
+// subscribing to entries of the provided public keys.
+func verifyInitialRVs(pubKeys []types.SiaPublicKey, rvs []SignedRegistryValue) ([]RPCRegistrySubscriptionNotificationEntryUpdate, error) {
 	// Check the length of the response.
-	if len(rvs) > len(requests) {
-		return nil, fmt.Errorf("host returned more rvs than we subscribed to %v > %v", len(rvs), len(requests))
+	if len(rvs) > len(pubKeys) {
+		return nil, fmt.Errorf("host returned more rvs than we subscribed to %v > %v", len(rvs), len(pubKeys))
 	}
 	// Verify response. The rvs should be returned in the same order as
 	// requested so we start by verifying against the first request and work our
@@ -1150,19 +1363,18 @@ func RPCSubscribeToRVs(stream siamux.Stream, requests []RPCRegistrySubscriptionR
 	// after running out of requests, something is wrong.
 	left := rvs
 	var initialNotifications []RPCRegistrySubscriptionNotificationEntryUpdate
-	for _, req := range requests {
+	for _, pubKey := range pubKeys {
 		if len(left) == 0 {
 			return initialNotifications, nil // all returned notifications were verified
 		}
 		rv := left[0]
-		err = rv.Verify(req.PubKey.ToPublicKey())
-		if err != nil {
+		if err := rv.Verify(pubKey.ToPublicKey()); err != nil {
 			continue // try next request
 		}
 		left = left[1:]
 		initialNotifications = append(initialNotifications, RPCRegistrySubscriptionNotificationEntryUpdate{
 			Entry:  rv,
-			PubKey: req.PubKey,
+			PubKey: pubKey,
 		})
 	}
 	if len(left) > 0 {
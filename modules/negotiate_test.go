@@ -86,6 +86,87 @@ func TestAnnouncementHandling(t *testing.T) {
 	}
 }
 
+// TestMultiAddressAnnouncement checks that CreateMultiAddressAnnouncement and
+// DecodeMultiAddressAnnouncement work together correctly and that the
+// announcement is compatible with DecodeAnnouncement.
+func TestMultiAddressAnnouncement(t *testing.T) {
+	t.Parallel()
+
+	sk, pk := crypto.GenerateKeyPair()
+	spk := types.SiaPublicKey{
+		Algorithm: types.SignatureEd25519,
+		Key:       pk[:],
+	}
+	addrs := []NetAddress{"f.o:1234", "[2001:db8::1]:1234", "xyz.onion:1234"}
+
+	// An announcement with a single address is a regular announcement.
+	annBytes, err := CreateMultiAddressAnnouncement(addrs[:1], spk, sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decAddrs, _, err := DecodeMultiAddressAnnouncement(annBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decAddrs) != 1 || decAddrs[0] != addrs[0] {
+		t.Fatal("wrong addresses", decAddrs)
+	}
+
+	// Decode an announcement with multiple addresses.
+	annBytes, err = CreateMultiAddressAnnouncement(addrs, spk, sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decAddrs, decPubKey, err := DecodeMultiAddressAnnouncement(annBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decAddrs) != len(addrs) {
+		t.Fatal("wrong number of addresses", decAddrs)
+	}
+	for i := range addrs {
+		if decAddrs[i] != addrs[i] {
+			t.Fatal("wrong addresses", decAddrs)
+		}
+	}
+	if !decPubKey.Equals(spk) {
+		t.Fatal("decoded announcement has the wrong public key")
+	}
+
+	// Nodes which don't support multiple addresses only see the first one.
+	decAddr, _, err := DecodeAnnouncement(annBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decAddr != addrs[0] {
+		t.Fatal("wrong address", decAddr)
+	}
+
+	// A corrupted extension is ignored.
+	annBytes[len(annBytes)-1]++
+	decAddrs, _, err = DecodeMultiAddressAnnouncement(annBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decAddrs) != 1 || decAddrs[0] != addrs[0] {
+		t.Fatal("corrupted extension wasn't ignored", decAddrs)
+	}
+
+	// Invalid and too many addresses are rejected.
+	_, err = CreateMultiAddressAnnouncement([]NetAddress{"f.o:1234", "invalid"}, spk, sk)
+	if err == nil {
+		t.Fatal("expected invalid address to be rejected")
+	}
+	tooMany := make([]NetAddress, MaxAnnouncementAddresses+1)
+	for i := range tooMany {
+		tooMany[i] = addrs[0]
+	}
+	_, err = CreateMultiAddressAnnouncement(tooMany, spk, sk)
+	if err == nil {
+		t.Fatal("expected too many addresses to be rejected")
+	}
+}
+
 // TestNegotiationResponses tests the WriteNegotiationAcceptance,
 // WriteNegotiationRejection, and ReadNegotiationAcceptance functions.
 func TestNegotiationResponses(t *testing.T) {
@@ -170,3 +170,56 @@ func (na NetAddress) IsStdValid() error {
 
 	return nil
 }
+
+// IsOnion returns true if the NetAddress is a Tor onion service address.
+func (na NetAddress) IsOnion() bool {
+	return strings.HasSuffix(strings.TrimSuffix(na.Host(), "."), ".onion")
+}
+
+// IsIPv6 returns true if the host of the NetAddress is an IPv6 address.
+func (na NetAddress) IsIPv6() bool {
+	ip := net.ParseIP(na.Host())
+	return ip != nil && ip.To4() == nil
+}
+
+// SortNetAddresses returns the addresses of a host in the order in which they
+// should be dialed. IPv4 addresses and hostnames are preferred over IPv6
+// addresses. Onion addresses are omitted since they can only be dialed through
+// a Tor proxy. Duplicates are removed and the order of addresses with the same
+// preference is preserved.
+func SortNetAddresses(addrs []NetAddress) []NetAddress {
+	var preferred, ipv6 []NetAddress
+	seen := make(map[NetAddress]struct{})
+	for _, addr := range addrs {
+		if _, exists := seen[addr]; exists || addr.IsOnion() {
+			continue
+		}
+		seen[addr] = struct{}{}
+		if addr.IsIPv6() {
+			ipv6 = append(ipv6, addr)
+		} else {
+			preferred = append(preferred, addr)
+		}
+	}
+	return append(preferred, ipv6...)
+}
+
+// DialNetAddresses dials the addresses of a host in the order returned by
+// SortNetAddresses and returns the first connection that can be established
+// together with the address it was established to. The addresses are dialed
+// through the GlobalProxy if one is configured.
+func DialNetAddresses(dialer *net.Dialer, addrs []NetAddress) (net.Conn, NetAddress, error) {
+	sorted := SortNetAddresses(addrs)
+	if len(sorted) == 0 {
+		return nil, "", errors.New("no dialable address")
+	}
+	var errs []string
+	for _, addr := range sorted {
+		conn, err := GlobalProxy.Dial(dialer, "tcp", string(addr))
+		if err == nil {
+			return conn, addr, nil
+		}
+		errs = append(errs, err.Error())
+	}
+	return nil, "", errors.New("failed to dial any address: " + strings.Join(errs, "; "))
+}
@@ -330,3 +330,67 @@ func TestIsLocal(t *testing.T) {
 		}
 	}
 }
+
+// TestSortNetAddresses checks that SortNetAddresses orders addresses by
+// preference.
+func TestSortNetAddresses(t *testing.T) {
+	addrs := []NetAddress{
+		"[2001:db8::1]:9982",
+		"xyz.onion:9982",
+		"host.com:9982",
+		"1.2.3.4:9982",
+		"host.com:9982",
+	}
+	sorted := SortNetAddresses(addrs)
+	expected := []NetAddress{"host.com:9982", "1.2.3.4:9982", "[2001:db8::1]:9982"}
+	if len(sorted) != len(expected) {
+		t.Fatal("wrong addresses", sorted)
+	}
+	for i := range expected {
+		if sorted[i] != expected[i] {
+			t.Fatal("wrong order", sorted)
+		}
+	}
+	if !NetAddress("xyz.onion:9982").IsOnion() || NetAddress("host.com:9982").IsOnion() {
+		t.Fatal("IsOnion failed")
+	}
+	if !NetAddress("[2001:db8::1]:9982").IsIPv6() || NetAddress("1.2.3.4:9982").IsIPv6() {
+		t.Fatal("IsIPv6 failed")
+	}
+}
+
+// TestDialNetAddresses checks that DialNetAddresses falls back to the next
+// address if an address can't be dialed.
+func TestDialNetAddresses(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	// Get an address which isn't listening.
+	l2, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := NetAddress(l2.Addr().String())
+	if err := l2.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	addr := NetAddress(l.Addr().String())
+	conn, dialed, err := DialNetAddresses(&net.Dialer{}, []NetAddress{closed, addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if dialed != addr {
+		t.Fatal("wrong address dialed", dialed)
+	}
+
+	// Dialing fails if no address can be dialed.
+	_, _, err = DialNetAddresses(&net.Dialer{}, []NetAddress{closed, "xyz.onion:9982"})
+	if err == nil {
+		t.Fatal("expected dial to fail")
+	}
+}
@@ -0,0 +1,230 @@
+package modules
+
+import (
+	"errors"
+	"fmt"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// ErrPSSTIncomplete is returned when a partially signed transaction is
+	// finalized before all of its inputs are sufficiently signed.
+	ErrPSSTIncomplete = errors.New("partially signed transaction is missing signatures")
+
+	// ErrPSSTInputSigned is returned when a signature is added to an input
+	// which already has all of its required signatures.
+	ErrPSSTInputSigned = errors.New("input already has all of its required signatures")
+
+	// ErrPSSTKeyUsed is returned when a signature is added for a public key
+	// which already signed the input.
+	ErrPSSTKeyUsed = errors.New("public key already signed the input")
+
+	// ErrPSSTUnknownInput is returned when a signature references an input
+	// which isn't part of the partially signed transaction.
+	ErrPSSTUnknownInput = errors.New("input isn't part of the partially signed transaction")
+)
+
+type (
+	// PartiallySignedTransaction is a transaction which is passed between
+	// the signers of its inputs until every input is sufficiently signed.
+	// Besides the transaction, it describes the signatures every input
+	// requires and which of them were already added. This allows for
+	// signing multisig inputs one signer at a time without having to
+	// hand-craft the transaction signatures.
+	PartiallySignedTransaction struct {
+		Transaction types.Transaction      `json:"transaction"`
+		Inputs      []PartiallySignedInput `json:"inputs"`
+	}
+
+	// PartiallySignedInput describes the signatures required by a siacoin or
+	// siafund input of a PartiallySignedTransaction.
+	PartiallySignedInput struct {
+		ParentID         crypto.Hash            `json:"parentid"`
+		FundType         types.Specifier        `json:"fundtype"`
+		UnlockConditions types.UnlockConditions `json:"unlockconditions"`
+
+		// CoveredFields are the fields covered by the signatures which are
+		// added to the input.
+		CoveredFields types.CoveredFields `json:"coveredfields"`
+
+		// SignaturesRequired is the number of signatures required by the
+		// input and SignedKeys contains the indices of the public keys
+		// which signed the input so far.
+		SignaturesRequired uint64   `json:"signaturesrequired"`
+		SignedKeys         []uint64 `json:"signedkeys"`
+	}
+)
+
+// NewPartiallySignedTransaction creates a PartiallySignedTransaction for the
+// given transaction. The transaction must not contain any signatures yet since
+// the signatures are covering the whole transaction.
+func NewPartiallySignedTransaction(txn types.Transaction) (PartiallySignedTransaction, error) {
+	if len(txn.TransactionSignatures) > 0 {
+		return PartiallySignedTransaction{}, errors.New("transaction must not contain signatures")
+	}
+	psst := PartiallySignedTransaction{
+		Transaction: txn,
+	}
+	seen := make(map[crypto.Hash]struct{})
+	addInput := func(parentID crypto.Hash, fundType types.Specifier, uc types.UnlockConditions) error {
+		if _, exists := seen[parentID]; exists {
+			return fmt.Errorf("input %v is spent twice", parentID)
+		}
+		seen[parentID] = struct{}{}
+		if uc.SignaturesRequired > uint64(len(uc.PublicKeys)) {
+			return fmt.Errorf("input %v requires more signatures than it has public keys", parentID)
+		}
+		psst.Inputs = append(psst.Inputs, PartiallySignedInput{
+			ParentID:           parentID,
+			FundType:           fundType,
+			UnlockConditions:   uc,
+			CoveredFields:      types.FullCoveredFields,
+			SignaturesRequired: uc.SignaturesRequired,
+		})
+		return nil
+	}
+	for _, sci := range txn.SiacoinInputs {
+		if err := addInput(crypto.Hash(sci.ParentID), types.SpecifierSiacoinOutput, sci.UnlockConditions); err != nil {
+			return PartiallySignedTransaction{}, err
+		}
+	}
+	for _, sfi := range txn.SiafundInputs {
+		if err := addInput(crypto.Hash(sfi.ParentID), types.SpecifierSiafundOutput, sfi.UnlockConditions); err != nil {
+			return PartiallySignedTransaction{}, err
+		}
+	}
+	return psst, nil
+}
+
+// Complete returns whether all the inputs of the transaction are sufficiently
+// signed.
+func (psst PartiallySignedTransaction) Complete() bool {
+	for _, input := range psst.Inputs {
+		if !input.Complete() {
+			return false
+		}
+	}
+	return true
+}
+
+// Complete returns whether the input is sufficiently signed.
+func (input PartiallySignedInput) Complete() bool {
+	return uint64(len(input.SignedKeys)) >= input.SignaturesRequired
+}
+
+// MissingKeys returns the indices of the public keys which can still sign the
+// input.
+func (input PartiallySignedInput) MissingKeys() []uint64 {
+	if input.Complete() {
+		return nil
+	}
+	var missing []uint64
+	for i := range input.UnlockConditions.PublicKeys {
+		if !input.signedBy(uint64(i)) {
+			missing = append(missing, uint64(i))
+		}
+	}
+	return missing
+}
+
+// signedBy returns whether the public key with the given index signed the
+// input.
+func (input PartiallySignedInput) signedBy(pubKeyIndex uint64) bool {
+	for _, signed := range input.SignedKeys {
+		if signed == pubKeyIndex {
+			return true
+		}
+	}
+	return false
+}
+
+// input returns the index of the input with the given parent id.
+func (psst *PartiallySignedTransaction) input(parentID crypto.Hash) (int, error) {
+	for i, input := range psst.Inputs {
+		if input.ParentID == parentID {
+			return i, nil
+		}
+	}
+	return 0, ErrPSSTUnknownInput
+}
+
+// newSignature returns the transaction signature the public key with the
+// given index adds to the input with the given parent id.
+func (psst *PartiallySignedTransaction) newSignature(parentID crypto.Hash, pubKeyIndex uint64) (PartiallySignedInput, types.TransactionSignature, error) {
+	i, err := psst.input(parentID)
+	if err != nil {
+		return PartiallySignedInput{}, types.TransactionSignature{}, err
+	}
+	input := psst.Inputs[i]
+	if input.Complete() {
+		return PartiallySignedInput{}, types.TransactionSignature{}, ErrPSSTInputSigned
+	}
+	if pubKeyIndex >= uint64(len(input.UnlockConditions.PublicKeys)) {
+		return PartiallySignedInput{}, types.TransactionSignature{}, types.ErrInvalidPubKeyIndex
+	}
+	if input.signedBy(pubKeyIndex) {
+		return PartiallySignedInput{}, types.TransactionSignature{}, ErrPSSTKeyUsed
+	}
+	return input, types.TransactionSignature{
+		ParentID:       parentID,
+		PublicKeyIndex: pubKeyIndex,
+		CoveredFields:  input.CoveredFields,
+	}, nil
+}
+
+// SigHash returns the hash the public key with the given index needs to sign
+// to add its signature to the input with the given parent id.
+func (psst PartiallySignedTransaction) SigHash(parentID crypto.Hash, pubKeyIndex uint64, height types.BlockHeight) (crypto.Hash, error) {
+	_, sig, err := psst.newSignature(parentID, pubKeyIndex)
+	if err != nil {
+		return crypto.Hash{}, err
+	}
+	txn := psst.Transaction
+	txn.TransactionSignatures = append(append([]types.TransactionSignature(nil), txn.TransactionSignatures...), sig)
+	return txn.SigHash(len(txn.TransactionSignatures)-1, height), nil
+}
+
+// AddSignature adds the signature of the public key with the given index to
+// the input with the given parent id. The signature is verified before it is
+// added.
+func (psst *PartiallySignedTransaction) AddSignature(parentID crypto.Hash, pubKeyIndex uint64, signature crypto.Signature, height types.BlockHeight) error {
+	input, sig, err := psst.newSignature(parentID, pubKeyIndex)
+	if err != nil {
+		return err
+	}
+	sigHash, err := psst.SigHash(parentID, pubKeyIndex, height)
+	if err != nil {
+		return err
+	}
+	pk := input.UnlockConditions.PublicKeys[pubKeyIndex]
+	if pk.Algorithm != types.SignatureEd25519 {
+		return fmt.Errorf("unsupported signature algorithm %v", pk.Algorithm)
+	}
+	var edPK crypto.PublicKey
+	copy(edPK[:], pk.Key)
+	if err := crypto.VerifyHash(sigHash, edPK, signature); err != nil {
+		return err
+	}
+	sig.Signature = signature[:]
+	psst.Transaction.TransactionSignatures = append(psst.Transaction.TransactionSignatures, sig)
+
+	i, _ := psst.input(parentID)
+	psst.Inputs[i].SignedKeys = append(psst.Inputs[i].SignedKeys, pubKeyIndex)
+	return nil
+}
+
+// Finalize returns the signed transaction once all of its inputs are
+// sufficiently signed. The transaction is checked for validity at the given
+// height.
+func (psst PartiallySignedTransaction) Finalize(height types.BlockHeight) (types.Transaction, error) {
+	if !psst.Complete() {
+		return types.Transaction{}, ErrPSSTIncomplete
+	}
+	txn := psst.Transaction
+	if err := txn.StandaloneValid(height); err != nil {
+		return types.Transaction{}, err
+	}
+	return txn, nil
+}
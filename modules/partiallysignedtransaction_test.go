@@ -0,0 +1,115 @@
+package modules
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+)
+
+// TestPartiallySignedTransaction tests signing a 2-of-3 multisig input one
+// signature at a time.
+func TestPartiallySignedTransaction(t *testing.T) {
+	// Create the keys of the multisig address.
+	var sks []crypto.SecretKey
+	var pks []types.SiaPublicKey
+	for i := 0; i < 3; i++ {
+		sk, pk := crypto.GenerateKeyPair()
+		sks = append(sks, sk)
+		pks = append(pks, types.Ed25519PublicKey(pk))
+	}
+	uc := types.UnlockConditions{
+		PublicKeys:         pks,
+		SignaturesRequired: 2,
+	}
+
+	// Create a transaction which spends an output of the address.
+	var parentID types.SiacoinOutputID
+	fastrand.Read(parentID[:])
+	txn := types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{{
+			ParentID:         parentID,
+			UnlockConditions: uc,
+		}},
+		SiacoinOutputs: []types.SiacoinOutput{{
+			Value: types.SiacoinPrecision,
+		}},
+	}
+	psst, err := NewPartiallySignedTransaction(txn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(psst.Inputs) != 1 || psst.Inputs[0].SignaturesRequired != 2 || psst.Complete() {
+		t.Fatal("unexpected psst", psst)
+	}
+
+	// Transactions which are already signed are rejected.
+	signedTxn := txn
+	signedTxn.TransactionSignatures = []types.TransactionSignature{{}}
+	if _, err := NewPartiallySignedTransaction(signedTxn); err == nil {
+		t.Fatal("expected transaction with signatures to be rejected")
+	}
+
+	// sign is a helper to sign the input with the key at the given index.
+	height := types.BlockHeight(fastrand.Intn(1e6))
+	id := crypto.Hash(parentID)
+	sign := func(pkIndex uint64) error {
+		sigHash, err := psst.SigHash(id, pkIndex, height)
+		if err != nil {
+			return err
+		}
+		return psst.AddSignature(id, pkIndex, crypto.SignHash(sigHash, sks[pkIndex]), height)
+	}
+
+	// Add the first signature. The transaction can't be finalized yet.
+	if err := sign(2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := psst.Finalize(height); !errors.Contains(err, ErrPSSTIncomplete) {
+		t.Fatal("expected ErrPSSTIncomplete but got", err)
+	}
+	if missing := psst.Inputs[0].MissingKeys(); len(missing) != 2 || missing[0] != 0 || missing[1] != 1 {
+		t.Fatal("unexpected missing keys", missing)
+	}
+
+	// The same key can't sign twice and invalid signatures are rejected.
+	if err := sign(2); !errors.Contains(err, ErrPSSTKeyUsed) {
+		t.Fatal("expected ErrPSSTKeyUsed but got", err)
+	}
+	sigHash, err := psst.SigHash(id, 0, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := psst.AddSignature(id, 0, crypto.SignHash(sigHash, sks[1]), height); err == nil {
+		t.Fatal("expected invalid signature to be rejected")
+	}
+	if err := psst.AddSignature(crypto.Hash{}, 0, crypto.Signature{}, height); !errors.Contains(err, ErrPSSTUnknownInput) {
+		t.Fatal("expected ErrPSSTUnknownInput but got", err)
+	}
+
+	// Add the second signature. The input is complete afterwards.
+	if err := sign(0); err != nil {
+		t.Fatal(err)
+	}
+	if !psst.Complete() {
+		t.Fatal("psst should be complete")
+	}
+	if err := sign(1); !errors.Contains(err, ErrPSSTInputSigned) {
+		t.Fatal("expected ErrPSSTInputSigned but got", err)
+	}
+
+	// Finalize the transaction.
+	finalTxn, err := psst.Finalize(height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(finalTxn.TransactionSignatures) != 2 {
+		t.Fatal("wrong number of signatures", len(finalTxn.TransactionSignatures))
+	}
+	if err := finalTxn.StandaloneValid(height); err != nil {
+		t.Fatal(err)
+	}
+}
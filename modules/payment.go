@@ -61,7 +61,7 @@ type PaymentProcessor interface {
 	// ProcessPayment takes a stream and handles the payment request objects
 	// sent by the caller. Returns an object that implements the PaymentDetails
 	// interface, or an error in case of failure.
-	ProcessPayment(stream siamux.Stream, bh types.BlockHeight) (PaymentDetails, string, error)
+	ProcessPayment(stream siamux.Stream, bh types.BlockHeight) (PaymentDetails, error)
 }
 
 // PaymentDetails is an interface that defines method that give more information
@@ -194,6 +194,12 @@ func (aid *AccountID) UnmarshalSia(r io.Reader) error {
 	return err
 }
 
+// String returns the string representation of the account id, which is the
+// string representation of its public key.
+func (aid AccountID) String() string {
+	return aid.spk
+}
+
 // SPK returns the account id as a types.SiaPublicKey.
 func (aid AccountID) SPK() (spk types.SiaPublicKey) {
 	if aid.IsZeroAccount() {
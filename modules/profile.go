@@ -0,0 +1,151 @@
+package modules
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.sia.tech/siad/build"
+)
+
+const (
+	// ProfileDefault is the profile which tunes the background loops of the
+	// daemon for desktop and server hardware.
+	ProfileDefault = "default"
+
+	// ProfileLowPower is the profile which reduces the background work of the
+	// daemon for Raspberry-Pi-class hardware. Hosts are scanned less often and
+	// by fewer threads, the health of the renter's files is checked less
+	// often and ephemeral account writes are batched.
+	ProfileLowPower = "lowpower"
+)
+
+// ProfileSettings are the effective values of the background loops which are
+// tuned by a daemon profile.
+type ProfileSettings struct {
+	Name string `json:"name"`
+
+	// HostDBScanningThreads is the max number of threads which scan hosts
+	// concurrently.
+	HostDBScanningThreads int `json:"hostdbscanningthreads"`
+
+	// HostDBMinScanSleep and HostDBMaxScanSleep are the bounds of the random
+	// amount of time the hostdb waits between two full rounds of scans.
+	HostDBMinScanSleep time.Duration `json:"hostdbminscansleep"`
+	HostDBMaxScanSleep time.Duration `json:"hostdbmaxscansleep"`
+
+	// HealthCheckInterval is the max amount of time which passes between two
+	// health checks of a renter's directory.
+	HealthCheckInterval time.Duration `json:"healthcheckinterval"`
+
+	// ConnectabilityCheckFrequency is the amount of time between two checks of
+	// whether the host is connectable.
+	ConnectabilityCheckFrequency time.Duration `json:"connectabilitycheckfrequency"`
+
+	// AccountSaveBatchInterval is the amount of time the host collects
+	// ephemeral account fingerprints before writing them to disk in one batch.
+	// A value of 0 writes them as soon as they are added.
+	AccountSaveBatchInterval time.Duration `json:"accountsavebatchinterval"`
+}
+
+var (
+	// profiles are the settings of the available profiles.
+	profiles = map[string]ProfileSettings{
+		ProfileDefault: {
+			Name: ProfileDefault,
+			HostDBScanningThreads: build.Select(build.Var{
+				Standard: int(80),
+				Dev:      int(4),
+				Testing:  int(3),
+			}).(int),
+			HostDBMinScanSleep: build.Select(build.Var{
+				Standard: time.Hour + time.Minute*20,
+				Dev:      time.Minute * 3,
+				Testing:  time.Second * 1,
+			}).(time.Duration),
+			HostDBMaxScanSleep: build.Select(build.Var{
+				Standard: time.Hour * 8,
+				Dev:      time.Minute * 10,
+				Testing:  time.Second * 5,
+			}).(time.Duration),
+			HealthCheckInterval: build.Select(build.Var{
+				Standard: time.Hour,
+				Dev:      15 * time.Minute,
+				Testing:  5 * time.Second,
+			}).(time.Duration),
+			ConnectabilityCheckFrequency: build.Select(build.Var{
+				Standard: time.Minute * 10,
+				Dev:      time.Minute * 5,
+				Testing:  time.Second * 10,
+			}).(time.Duration),
+			AccountSaveBatchInterval: 0,
+		},
+		ProfileLowPower: {
+			Name: ProfileLowPower,
+			HostDBScanningThreads: build.Select(build.Var{
+				Standard: int(8),
+				Dev:      int(2),
+				Testing:  int(1),
+			}).(int),
+			HostDBMinScanSleep: build.Select(build.Var{
+				Standard: time.Hour * 4,
+				Dev:      time.Minute * 10,
+				Testing:  time.Second * 3,
+			}).(time.Duration),
+			HostDBMaxScanSleep: build.Select(build.Var{
+				Standard: time.Hour * 24,
+				Dev:      time.Minute * 30,
+				Testing:  time.Second * 15,
+			}).(time.Duration),
+			HealthCheckInterval: build.Select(build.Var{
+				Standard: time.Hour * 4,
+				Dev:      time.Hour,
+				Testing:  15 * time.Second,
+			}).(time.Duration),
+			ConnectabilityCheckFrequency: build.Select(build.Var{
+				Standard: time.Hour,
+				Dev:      time.Minute * 15,
+				Testing:  time.Second * 30,
+			}).(time.Duration),
+			AccountSaveBatchInterval: build.Select(build.Var{
+				Standard: 5 * time.Second,
+				Dev:      2 * time.Second,
+				Testing:  100 * time.Millisecond,
+			}).(time.Duration),
+		},
+	}
+
+	// currentProfile is the profile the daemon is running with.
+	currentProfile   = profiles[ProfileDefault]
+	currentProfileMu sync.Mutex
+)
+
+// CurrentProfile returns the settings of the profile the daemon is running
+// with. The background loops read it every iteration which means a new
+// profile applies without restarting the daemon.
+func CurrentProfile() ProfileSettings {
+	currentProfileMu.Lock()
+	defer currentProfileMu.Unlock()
+	return currentProfile
+}
+
+// Profiles returns the names of the available profiles.
+func Profiles() []string {
+	return []string{ProfileDefault, ProfileLowPower}
+}
+
+// SetProfile sets the profile the daemon is running with. An empty name
+// selects the default profile.
+func SetProfile(name string) error {
+	if name == "" {
+		name = ProfileDefault
+	}
+	settings, exists := profiles[name]
+	if !exists {
+		return fmt.Errorf("unknown profile '%v', available profiles are %v", name, Profiles())
+	}
+	currentProfileMu.Lock()
+	defer currentProfileMu.Unlock()
+	currentProfile = settings
+	return nil
+}
@@ -0,0 +1,146 @@
+package modules
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"gitlab.com/NebulousLabs/errors"
+	"golang.org/x/net/proxy"
+)
+
+var (
+	// GlobalProxy is the process-wide outbound proxy. The gateway dials its
+	// peers through it and the renter and the host dial hosts through it.
+	GlobalProxy = &Proxy{}
+
+	// ErrAnnouncementsDisabled is returned when trying to announce an
+	// automatically discovered address while the proxy disables
+	// announcements.
+	ErrAnnouncementsDisabled = errors.New("announcing the automatically discovered address is disabled while connecting through a proxy, set the host's netaddress to announce an address")
+)
+
+type (
+	// ProxySettings are the settings of the outbound proxy. An empty address
+	// disables the proxy.
+	ProxySettings struct {
+		// Address is the host:port of a SOCKS5 proxy, e.g. the SOCKS port of
+		// a Tor daemon.
+		Address  string `json:"address"`
+		Username string `json:"username"`
+		Password string `json:"password,omitempty"`
+
+		// DisableAnnouncements prevents the daemon from discovering its
+		// clearnet address and announcing it to the gateway's peers or as the
+		// host's address while the proxy is enabled.
+		DisableAnnouncements bool `json:"disableannouncements"`
+	}
+
+	// Proxy dials outbound connections either through a SOCKS5 proxy or
+	// directly if no proxy is configured.
+	Proxy struct {
+		settings ProxySettings
+		mu       sync.Mutex
+	}
+)
+
+// Validate checks the proxy settings.
+func (ps ProxySettings) Validate() error {
+	if ps.Address == "" {
+		if ps.Username != "" || ps.Password != "" || ps.DisableAnnouncements {
+			return errors.New("proxy address is required")
+		}
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(ps.Address); err != nil {
+		return errors.AddContext(err, "invalid proxy address")
+	}
+	if ps.Password != "" && ps.Username == "" {
+		return errors.New("proxy password requires a username")
+	}
+	return nil
+}
+
+// Enabled returns whether outbound connections are dialed through a proxy.
+func (p *Proxy) Enabled() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.settings.Address != ""
+}
+
+// AnnouncementsDisabled returns whether the daemon must not discover and
+// announce its clearnet address.
+func (p *Proxy) AnnouncementsDisabled() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.settings.Address != "" && p.settings.DisableAnnouncements
+}
+
+// Settings returns the settings of the proxy.
+func (p *Proxy) Settings() ProxySettings {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.settings
+}
+
+// SetSettings validates and sets the settings of the proxy. They apply to all
+// connections which are dialed afterwards.
+func (p *Proxy) SetSettings(settings ProxySettings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.settings = settings
+	return nil
+}
+
+// Dial dials addr using dialer. If a proxy is configured, the connection is
+// established through the proxy and dialer is used to connect to the proxy.
+// The timeout and cancel channel of dialer apply to the whole connection
+// attempt.
+func (p *Proxy) Dial(dialer *net.Dialer, network, addr string) (net.Conn, error) {
+	settings := p.Settings()
+	if settings.Address == "" {
+		return dialer.Dial(network, addr)
+	}
+	var auth *proxy.Auth
+	if settings.Username != "" {
+		auth = &proxy.Auth{
+			User:     settings.Username,
+			Password: settings.Password,
+		}
+	}
+	// Dial the proxy without a local address since it is meant for the
+	// target.
+	forward := &net.Dialer{
+		Timeout: dialer.Timeout,
+	}
+	socks, err := proxy.SOCKS5("tcp", settings.Address, auth, forward)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to create proxy dialer")
+	}
+
+	ctx := context.Background()
+	if dialer.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, dialer.Timeout)
+		defer cancel()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if dialer.Cancel != nil {
+		go func() {
+			select {
+			case <-dialer.Cancel:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+	conn, err := socks.(proxy.ContextDialer).DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to dial through proxy")
+	}
+	return conn, nil
+}
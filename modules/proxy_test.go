@@ -0,0 +1,213 @@
+package modules
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// testSOCKS5Server is a minimal SOCKS5 server which supports the CONNECT
+// command without authentication. It counts the connections it proxied.
+type testSOCKS5Server struct {
+	listener net.Listener
+	proxied  chan string
+}
+
+// newTestSOCKS5Server starts a testSOCKS5Server on localhost.
+func newTestSOCKS5Server(t *testing.T) *testSOCKS5Server {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testSOCKS5Server{
+		listener: l,
+		proxied:  make(chan string, 10),
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.handle(conn)
+		}
+	}()
+	return s
+}
+
+// handle handles a single client connection.
+func (s *testSOCKS5Server) handle(conn net.Conn) {
+	defer conn.Close()
+	// Greeting: version, number of methods, methods.
+	buf := make([]byte, 262)
+	if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+		return
+	}
+	if _, err := io.ReadFull(conn, buf[:buf[1]]); err != nil {
+		return
+	}
+	if _, err := conn.Write([]byte{5, 0}); err != nil {
+		return
+	}
+	// Request: version, command, reserved, address type, address, port.
+	if _, err := io.ReadFull(conn, buf[:4]); err != nil {
+		return
+	}
+	var host string
+	switch buf[3] {
+	case 1:
+		if _, err := io.ReadFull(conn, buf[:4]); err != nil {
+			return
+		}
+		host = net.IP(buf[:4]).String()
+	case 3:
+		if _, err := io.ReadFull(conn, buf[:1]); err != nil {
+			return
+		}
+		n := int(buf[0])
+		if _, err := io.ReadFull(conn, buf[:n]); err != nil {
+			return
+		}
+		host = string(buf[:n])
+	default:
+		return
+	}
+	if _, err := io.ReadFull(conn, buf[:2]); err != nil {
+		return
+	}
+	addr := net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(buf[:2]))))
+	target, err := net.Dial("tcp", addr)
+	if err != nil {
+		conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer target.Close()
+	if _, err := conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0}); err != nil {
+		return
+	}
+	s.proxied <- addr
+	go io.Copy(target, conn)
+	io.Copy(conn, target)
+}
+
+// TestProxySettingsValidate tests the validation of the proxy settings.
+func TestProxySettingsValidate(t *testing.T) {
+	tests := []struct {
+		settings ProxySettings
+		valid    bool
+	}{
+		{ProxySettings{}, true},
+		{ProxySettings{Address: "127.0.0.1:9050"}, true},
+		{ProxySettings{Address: "localhost:9050", Username: "user", Password: "pass", DisableAnnouncements: true}, true},
+		{ProxySettings{Address: "127.0.0.1"}, false},
+		{ProxySettings{Address: "127.0.0.1:9050", Password: "pass"}, false},
+		{ProxySettings{DisableAnnouncements: true}, false},
+		{ProxySettings{Username: "user"}, false},
+	}
+	for i, test := range tests {
+		if err := test.settings.Validate(); (err == nil) != test.valid {
+			t.Errorf("%v: expected valid to be %v but got %v", i, test.valid, err)
+		}
+	}
+}
+
+// TestProxyDial tests dialing directly and through a SOCKS5 proxy.
+func TestProxyDial(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Start a server which echoes a single byte.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				b := make([]byte, 1)
+				if _, err := io.ReadFull(conn, b); err == nil {
+					conn.Write(b)
+				}
+			}()
+		}
+	}()
+	echo := func(conn net.Conn) {
+		defer conn.Close()
+		if _, err := conn.Write([]byte{42}); err != nil {
+			t.Fatal(err)
+		}
+		b := make([]byte, 1)
+		if _, err := io.ReadFull(conn, b); err != nil || b[0] != 42 {
+			t.Fatal("unexpected echo", b, err)
+		}
+	}
+
+	socks := newTestSOCKS5Server(t)
+	defer socks.listener.Close()
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+
+	// Without a proxy the server is dialed directly.
+	p := &Proxy{}
+	if p.Enabled() || p.AnnouncementsDisabled() {
+		t.Fatal("proxy shouldn't be enabled")
+	}
+	conn, err := p.Dial(dialer, "tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	echo(conn)
+	select {
+	case addr := <-socks.proxied:
+		t.Fatal("connection shouldn't be proxied", addr)
+	default:
+	}
+
+	// With a proxy the server is dialed through the proxy.
+	err = p.SetSettings(ProxySettings{Address: socks.listener.Addr().String(), DisableAnnouncements: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Enabled() || !p.AnnouncementsDisabled() {
+		t.Fatal("proxy should be enabled")
+	}
+	conn, err = p.Dial(dialer, "tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	echo(conn)
+	select {
+	case addr := <-socks.proxied:
+		if addr != l.Addr().String() {
+			t.Fatal("wrong address proxied", addr)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("connection wasn't proxied")
+	}
+
+	// Dialing through the proxy can be cancelled.
+	cancel := make(chan struct{})
+	close(cancel)
+	_, err = p.Dial(&net.Dialer{Cancel: cancel}, "tcp", l.Addr().String())
+	if err == nil {
+		t.Fatal("expected cancelled dial to fail")
+	}
+
+	// Invalid settings are rejected and don't change the proxy.
+	if err := p.SetSettings(ProxySettings{Address: "invalid"}); err == nil {
+		t.Fatal("expected invalid settings to be rejected")
+	}
+	if p.Settings().Address != socks.listener.Addr().String() {
+		t.Fatal("settings were changed", p.Settings())
+	}
+}
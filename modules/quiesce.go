@@ -0,0 +1,10 @@
+package modules
+
+// Quiescer is implemented by modules which are able to temporarily block the
+// writes to their persisted state. While a module is quiesced, its files on
+// disk are consistent and can be backed up by an external tool.
+type Quiescer interface {
+	// Quiesce flushes pending writes to disk and blocks new writes until the
+	// returned function is called. Reads are not blocked.
+	Quiesce() (resume func(), err error)
+}
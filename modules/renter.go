@@ -5,7 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"gitlab.com/NebulousLabs/errors"
@@ -26,6 +29,11 @@ type (
 		RefundAddress types.UnlockHash
 		RenterSeed    EphemeralRenterSeed
 
+		// PreFunded indicates that the transaction builder used to form the
+		// contract already contains an input of exactly Funding siacoins,
+		// e.g. from a funding transaction shared by a batch of contracts.
+		PreFunded bool
+
 		// TODO: add optional keypair
 	}
 )
@@ -64,6 +72,10 @@ var (
 	// manually by the user.
 	ErrDownloadCancelled = errors.New("download was cancelled")
 
+	// ErrInvalidHTTPHeader is returned when trying to attach HTTP headers to a
+	// file which can't be sent.
+	ErrInvalidHTTPHeader = errors.New("invalid HTTP header")
+
 	// ErrNotEnoughWorkersInWorkerPool is an error that is returned whenever an
 	// operation expects a certain number of workers but there aren't that many
 	// available.
@@ -93,6 +105,10 @@ type DataSourceID crypto.Hash
 // mode
 type FilterMode int
 
+// DownloadPolicy is the helper type for the enum constants which determine the
+// trade-off between latency and cost of a download.
+type DownloadPolicy int
+
 // FileListFunc is a type that's passed in to functions related to iterating
 // over the filesystem.
 type FileListFunc func(FileInfo)
@@ -146,6 +162,24 @@ const (
 	HostDBActiveWhitelist
 )
 
+// DownloadPolicyBalanced, DownloadPolicyCheapest and DownloadPolicyLatency
+// are the policies a download can be performed with. Balanced downloads use a
+// moderate amount of overdrive and all hosts. Cheapest downloads don't use any
+// overdrive and prefer the cheapest hosts. Latency downloads use a lot of
+// overdrive and prefer the fastest hosts.
+const (
+	DownloadPolicyBalanced DownloadPolicy = iota
+	DownloadPolicyCheapest
+	DownloadPolicyLatency
+)
+
+// ContractMigrationStatusChurned and ContractMigrationStatusDeferred are the
+// statuses of a contract in the contractor's migration plan.
+const (
+	ContractMigrationStatusChurned  = "churned"
+	ContractMigrationStatusDeferred = "deferred"
+)
+
 // Filesystem related consts.
 const (
 	// DefaultDirPerm defines the default permissions used for a new dir if no
@@ -191,6 +225,36 @@ func (fm *FilterMode) FromString(s string) error {
 	return nil
 }
 
+// String returns the string value for the DownloadPolicy
+func (dp DownloadPolicy) String() string {
+	switch dp {
+	case DownloadPolicyBalanced:
+		return "balanced"
+	case DownloadPolicyCheapest:
+		return "cheapest"
+	case DownloadPolicyLatency:
+		return "latency"
+	default:
+		return ""
+	}
+}
+
+// FromString assigns the DownloadPolicy from the provided string. An empty
+// string results in the default policy.
+func (dp *DownloadPolicy) FromString(s string) error {
+	switch s {
+	case "", "balanced":
+		*dp = DownloadPolicyBalanced
+	case "cheapest":
+		*dp = DownloadPolicyCheapest
+	case "latency":
+		*dp = DownloadPolicyLatency
+	default:
+		return fmt.Errorf("could not assign DownloadPolicy from string %v", s)
+	}
+	return nil
+}
+
 // IsHostsFault indicates if a returned error is the host's fault.
 func IsHostsFault(err error) bool {
 	return errors.Contains(err, ErrHostFault)
@@ -216,6 +280,13 @@ const (
 	// contract revision that have each been signed by all parties.
 	EstimatedFileContractTransactionSetSize = 2048
 
+	// EstimatedFileContractTransactionSetSizeBatched is the estimated
+	// blockchain size of a transaction set between a renter and a host that
+	// contains a file contract which is funded by a batched funding
+	// transaction. The renter's setup transaction is shared with other
+	// contracts and paid for separately.
+	EstimatedFileContractTransactionSetSizeBatched = 1536
+
 	// EstimatedFileContractRevisionAndProofTransactionSetSize is the
 	// estimated blockchain size of a transaction set used by the host to
 	// provide the storage proof at the end of the contract duration.
@@ -279,18 +350,19 @@ type Allowance struct {
 	// period.
 	MaxPeriodChurn uint64 `json:"maxperiodchurn"`
 
+	// MaxPeriodChurnContracts is the maximum number of contracts which can be
+	// churned in a single period. A value of 0 means that the number of
+	// contracts is not limited.
+	MaxPeriodChurnContracts uint64 `json:"maxperiodchurncontracts"`
+
 	// The following fields provide price gouging protection for the user. By
 	// setting a particular maximum price for each mechanism that a host can use
 	// to charge users, the workers know to avoid hosts that go outside of the
 	// safety range.
 	//
 	// The intention is that if the fields are not set, a reasonable value will
-	// be derived from the other allowance settings. The intention is that the
-	// hostdb will pay attention to these limits when forming contracts,
-	// understanding that a certain feature (such as storage) will not be used
-	// if the host price is above the limit. If the hostdb believes that a host
-	// is valuable for its other, more reasonably priced features, the hostdb
-	// may choose to form a contract with the host anyway.
+	// be derived from the other allowance settings. The contractor doesn't
+	// form new contracts with hosts whose prices are above the limits.
 	//
 	// NOTE: If the allowance max price fields are ever extended, all of the
 	// price gouging checks throughout the worker code and contract formation
@@ -301,6 +373,21 @@ type Allowance struct {
 	MaxSectorAccessPrice      types.Currency `json:"maxsectoraccessprice"`
 	MaxStoragePrice           types.Currency `json:"maxstorageprice"`
 	MaxUploadBandwidthPrice   types.Currency `json:"maxuploadbandwidthprice"`
+
+	// The following fields are hard filters for the hosts the contractor forms
+	// new contracts with. While the host score only makes a host less likely
+	// to be picked, a host which doesn't pass a filter is never picked.
+	// Existing contracts are not affected. Empty fields disable the filter.
+	//
+	// MinHostVersion is the minimum version a host needs to run. MinHostAge is
+	// the minimum number of blocks since the host's first announcement.
+	// ExcludedCountries are the ISO 3166 codes of the countries hosts must not
+	// be located in, hosts with an unknown country pass the filter.
+	// ExcludedSubnets are the CIDR subnets hosts must not be located in.
+	MinHostVersion    string            `json:"minhostversion"`
+	MinHostAge        types.BlockHeight `json:"minhostage"`
+	ExcludedCountries []string          `json:"excludedcountries"`
+	ExcludedSubnets   []string          `json:"excludedsubnets"`
 }
 
 // Active returns true if and only if this allowance has been set in the
@@ -340,6 +427,68 @@ type ContractWatchStatus struct {
 	WindowEnd                 types.BlockHeight `json:"windowend"`
 }
 
+// DirDefaults are the defaults of a siadir which are applied to new files
+// created beneath it. Unset fields are inherited from the parent directory and
+// explicitly provided upload params take precedence over the defaults.
+type DirDefaults struct {
+	// DataPieces and ParityPieces are the erasure coding parameters. They are
+	// either both set or both unset.
+	DataPieces   int `json:"datapieces,omitempty"`
+	ParityPieces int `json:"paritypieces,omitempty"`
+
+	// FileMode is the mode of new files. It replaces the mode of the source
+	// file for regular uploads.
+	FileMode os.FileMode `json:"filemode,omitempty"`
+
+	// UserTags are the tags assigned to new files. Tags of a directory replace
+	// the tags of its parent rather than extending them.
+	UserTags []string `json:"usertags,omitempty"`
+
+	// Bucket is the bucket new files are assigned to.
+	Bucket string `json:"bucket,omitempty"`
+}
+
+// Inherit returns the defaults with all of the unset fields taken from the
+// defaults of the parent directory.
+func (dd DirDefaults) Inherit(parent DirDefaults) DirDefaults {
+	if dd.DataPieces == 0 && dd.ParityPieces == 0 {
+		dd.DataPieces = parent.DataPieces
+		dd.ParityPieces = parent.ParityPieces
+	}
+	if dd.FileMode == 0 {
+		dd.FileMode = parent.FileMode
+	}
+	if len(dd.UserTags) == 0 {
+		dd.UserTags = parent.UserTags
+	}
+	if dd.Bucket == "" {
+		dd.Bucket = parent.Bucket
+	}
+	dd.UserTags = append([]string(nil), dd.UserTags...)
+	return dd
+}
+
+// Validate checks the directory defaults for errors.
+func (dd DirDefaults) Validate() error {
+	if (dd.DataPieces == 0) != (dd.ParityPieces == 0) {
+		return errors.New("data pieces and parity pieces need to be set together")
+	}
+	if dd.DataPieces != 0 {
+		if _, err := NewRSSubCode(dd.DataPieces, dd.ParityPieces, crypto.SegmentSize); err != nil {
+			return errors.AddContext(err, "invalid erasure coding parameters")
+		}
+	}
+	if dd.FileMode&^os.ModePerm != 0 {
+		return fmt.Errorf("file mode %v contains more than permission bits", dd.FileMode)
+	}
+	for _, tag := range dd.UserTags {
+		if tag == "" || strings.ContainsAny(tag, ", ") {
+			return fmt.Errorf("invalid user tag '%v'", tag)
+		}
+	}
+	return nil
+}
+
 // DirectoryInfo provides information about a siadir
 type DirectoryInfo struct {
 	// The following fields are aggregate values of the siadir. These values are
@@ -377,6 +526,9 @@ type DirectoryInfo struct {
 	StuckHealth         float64     `json:"stuckhealth"`
 	StuckSize           uint64      `json:"stucksize"`
 	UID                 uint64      `json:"uid"`
+	// Defaults are the defaults of the siadir itself. They don't include
+	// the defaults inherited from its parents.
+	Defaults DirDefaults `json:"defaults"`
 }
 
 // Name implements os.FileInfo.
@@ -425,6 +577,30 @@ type FileUploadParams struct {
 	DisablePartialChunk bool
 	Repair              bool
 
+	// Append indicates that the streamed data should be appended to an
+	// existing SiaFile. It is only supported by the upload streamer and
+	// requires the last chunk of the existing file to be full.
+	Append bool
+
+	// Offset is the offset within an existing SiaFile at which a streamed
+	// repair starts. It needs to be a multiple of the chunk size and allows
+	// for filling the holes of sparse files without streaming the whole file.
+	Offset uint64
+
+	// Resume indicates that the streamed data continues an upload stream to
+	// the SiaPath which was interrupted. The data needs to start at the
+	// offset returned by UploadStreamResumeOffset.
+	Resume bool
+
+	// UserTags and Bucket are assigned to the new SiaFile. If they are left
+	// blank, the defaults of the directory the file is uploaded to are used.
+	UserTags []string
+	Bucket   string
+
+	// Expiry is assigned to the new SiaFile. The zero value means that the
+	// file doesn't expire.
+	Expiry FileExpiry
+
 	// CipherType was added later. If it is left blank, the renter will use the
 	// default encryption method (as of writing, Threefish)
 	CipherType crypto.CipherType
@@ -435,35 +611,229 @@ type FileUploadParams struct {
 	CipherKey crypto.CipherKey
 }
 
+// FileSearchParams are the parameters of a file search. A file matches if its
+// path relative to the searched folder contains Query and if it is tagged
+// with all of the Tags. Both comparisons are case-insensitive. Offset and
+// Limit select the page of the results which are sorted by siapath. A Limit
+// of 0 returns all results.
+type FileSearchParams struct {
+	Query  string
+	Tags   []string
+	Offset uint64
+	Limit  uint64
+}
+
+// FileSearchResult is a page of the files matching a search. Total is the
+// number of matching files across all pages.
+type FileSearchResult struct {
+	Files []FileInfo `json:"files"`
+	Total uint64     `json:"total"`
+}
+
+// FileExpiry describes when the renter removes a file automatically. A zero
+// Time means that the file doesn't expire. Expired files are deleted unless
+// Archive is set, in which case they are moved into the versions namespace
+// like overwritten files and deleted once the versions are collected.
+type FileExpiry struct {
+	Time    time.Time `json:"time"`
+	Archive bool      `json:"archive,omitempty"`
+}
+
+// Expired returns whether the file expired at the given time.
+func (fe FileExpiry) Expired(now time.Time) bool {
+	return !fe.Time.IsZero() && !now.Before(fe.Time)
+}
+
+// FileRepairPriority is the priority with which the renter repairs a file.
+// Chunks of files with a higher priority are repaired before the chunks of
+// files with a lower priority, regardless of their health. The zero value is
+// the normal priority.
+type FileRepairPriority int8
+
+const (
+	// FileRepairPriorityLow is the priority of files which are only repaired
+	// once all other files were repaired.
+	FileRepairPriorityLow FileRepairPriority = -1
+	// FileRepairPriorityNormal is the default priority of files.
+	FileRepairPriorityNormal FileRepairPriority = 0
+	// FileRepairPriorityHigh is the priority of files which are repaired
+	// before all other files.
+	FileRepairPriorityHigh FileRepairPriority = 1
+)
+
+// ErrInvalidFileRepairPriority is returned when parsing an unknown repair
+// priority.
+var ErrInvalidFileRepairPriority = errors.New("repair priority must be 'low', 'normal' or 'high'")
+
+// ParseFileRepairPriority parses a repair priority from its name.
+func ParseFileRepairPriority(name string) (FileRepairPriority, error) {
+	switch name {
+	case "low":
+		return FileRepairPriorityLow, nil
+	case "normal":
+		return FileRepairPriorityNormal, nil
+	case "high":
+		return FileRepairPriorityHigh, nil
+	default:
+		return 0, ErrInvalidFileRepairPriority
+	}
+}
+
+// String implements the fmt.Stringer interface.
+func (p FileRepairPriority) String() string {
+	switch p {
+	case FileRepairPriorityLow:
+		return "low"
+	case FileRepairPriorityNormal:
+		return "normal"
+	case FileRepairPriorityHigh:
+		return "high"
+	default:
+		return fmt.Sprintf("unknown(%d)", int8(p))
+	}
+}
+
+// MarshalText implements the encoding.TextMarshaler interface.
+func (p FileRepairPriority) MarshalText() ([]byte, error) {
+	if p < FileRepairPriorityLow || p > FileRepairPriorityHigh {
+		return nil, ErrInvalidFileRepairPriority
+	}
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface.
+func (p *FileRepairPriority) UnmarshalText(b []byte) (err error) {
+	*p, err = ParseFileRepairPriority(string(b))
+	return err
+}
+
+// RepairQueue is the renter's queue of chunks which are being repaired or are
+// waiting to be repaired, grouped by file.
+type RepairQueue struct {
+	// Throughput is the number of bytes per second the renter repaired
+	// recently. It is used to estimate when the files are repaired and is 0
+	// if the renter didn't repair any chunks recently.
+	Throughput uint64            `json:"throughput"`
+	Files      []RepairQueueFile `json:"files"`
+}
+
+// RepairQueueFile describes the queued chunks of a file in the repair queue.
+// The files are ordered by when their chunks are repaired.
+type RepairQueueFile struct {
+	SiaPath         SiaPath            `json:"siapath"`
+	Priority        FileRepairPriority `json:"priority"`
+	Health          float64            `json:"health"`
+	QueuedChunks    uint64             `json:"queuedchunks"`
+	RepairingChunks uint64             `json:"repairingchunks"`
+	RemainingBytes  uint64             `json:"remainingbytes"`
+
+	// ETA is the estimated time until the queued chunks of the file are
+	// repaired. It is 0 if the renter's throughput is unknown.
+	ETA time.Duration `json:"eta"`
+}
+
+// FileRecoveryReport describes the outcome of recovering the data of a file
+// directly from the renter's contracts. Chunks which couldn't be recovered are
+// written as zeros and listed in LostChunks. UnreachableHosts contains the
+// hosts which the renter failed to download a piece from.
+type FileRecoveryReport struct {
+	Size             uint64               `json:"size"`
+	RecoveredChunks  uint64               `json:"recoveredchunks"`
+	LostChunks       []uint64             `json:"lostchunks"`
+	UnreachableHosts []types.SiaPublicKey `json:"unreachablehosts"`
+}
+
+// RenterFailureDomainViolation describes a file which stores several pieces
+// of a chunk on hosts sharing a failure domain. Chunks contains the indices of
+// the affected chunks and Domains the failure domains shared by their hosts.
+type RenterFailureDomainViolation struct {
+	SiaPath SiaPath  `json:"siapath"`
+	Chunks  []uint64 `json:"chunks"`
+	Domains []string `json:"domains"`
+}
+
+// RenterFailureDomainReport lists the files of the renter which violate the
+// policy of spreading the pieces of a chunk across failure domains.
+type RenterFailureDomainReport struct {
+	FilesChecked uint64                         `json:"fileschecked"`
+	Violations   []RenterFailureDomainViolation `json:"violations"`
+}
+
+// RenterFailureDomainFixReport describes the outcome of fixing failure domain
+// violations. FixedChunks had DroppedPieces removed to be re-uploaded to
+// uncorrelated hosts by the repair loop. SkippedChunks would have dropped
+// below the minimum number of pieces and were left unchanged.
+type RenterFailureDomainFixReport struct {
+	FixedChunks   uint64 `json:"fixedchunks"`
+	SkippedChunks uint64 `json:"skippedchunks"`
+	DroppedPieces uint64 `json:"droppedpieces"`
+}
+
+// FileHTTPHeaders are the HTTP headers which are set when a file is served by
+// the /renter/stream and /renter/download endpoints. Empty headers are not
+// set.
+type FileHTTPHeaders struct {
+	CacheControl       string `json:"cachecontrol,omitempty"`
+	ContentDisposition string `json:"contentdisposition,omitempty"`
+	ContentType        string `json:"contenttype,omitempty"`
+}
+
+// Validate checks that the headers can be sent as they are.
+func (h FileHTTPHeaders) Validate() error {
+	for _, value := range []string{h.CacheControl, h.ContentDisposition, h.ContentType} {
+		if strings.ContainsAny(value, "\r\n") {
+			return ErrInvalidHTTPHeader
+		}
+	}
+	if h.ContentType != "" {
+		if _, _, err := mime.ParseMediaType(h.ContentType); err != nil {
+			return errors.Compose(ErrInvalidHTTPHeader, errors.AddContext(err, "invalid content type"))
+		}
+	}
+	if h.ContentDisposition != "" {
+		if _, _, err := mime.ParseMediaType(h.ContentDisposition); err != nil {
+			return errors.Compose(ErrInvalidHTTPHeader, errors.AddContext(err, "invalid content disposition"))
+		}
+	}
+	return nil
+}
+
 // FileInfo provides information about a file.
 type FileInfo struct {
-	AccessTime       time.Time         `json:"accesstime"`
-	Available        bool              `json:"available"`
-	ChangeTime       time.Time         `json:"changetime"`
-	CipherType       string            `json:"ciphertype"`
-	CreateTime       time.Time         `json:"createtime"`
-	Expiration       types.BlockHeight `json:"expiration"`
-	Filesize         uint64            `json:"filesize"`
-	Health           float64           `json:"health"`
-	LocalPath        string            `json:"localpath"`
-	MaxHealth        float64           `json:"maxhealth"`
-	MaxHealthPercent float64           `json:"maxhealthpercent"`
-	ModificationTime time.Time         `json:"modtime,siamismatch"` // Stays as 'modtime' in json for compatibility
-	FileMode         os.FileMode       `json:"mode,siamismatch"`    // Field is called FileMode for fuse compatibility
-	NumStuckChunks   uint64            `json:"numstuckchunks"`
-	OnDisk           bool              `json:"ondisk"`
-	Recoverable      bool              `json:"recoverable"`
-	Redundancy       float64           `json:"redundancy"`
-	Renewing         bool              `json:"renewing"`
-	RepairBytes      uint64            `json:"repairbytes"`
-	Skylinks         []string          `json:"skylinks"`
-	SiaPath          SiaPath           `json:"siapath"`
-	Stuck            bool              `json:"stuck"`
-	StuckBytes       uint64            `json:"stuckbytes"`
-	StuckHealth      float64           `json:"stuckhealth"`
-	UID              uint64            `json:"uid"`
-	UploadedBytes    uint64            `json:"uploadedbytes"`
-	UploadProgress   float64           `json:"uploadprogress"`
+	AccessTime       time.Time          `json:"accesstime"`
+	Available        bool               `json:"available"`
+	Bucket           string             `json:"bucket"`
+	ChangeTime       time.Time          `json:"changetime"`
+	CipherType       string             `json:"ciphertype"`
+	CreateTime       time.Time          `json:"createtime"`
+	Expiration       types.BlockHeight  `json:"expiration"`
+	Filesize         uint64             `json:"filesize"`
+	Health           float64            `json:"health"`
+	HTTPHeaders      FileHTTPHeaders    `json:"httpheaders"`
+	Expiry           FileExpiry         `json:"expiry"`
+	RepairPriority   FileRepairPriority `json:"repairpriority"`
+	LastVerifiedTime time.Time          `json:"lastverifiedtime"`
+	LocalPath        string             `json:"localpath"`
+	MaxHealth        float64            `json:"maxhealth"`
+	MaxHealthPercent float64            `json:"maxhealthpercent"`
+	ModificationTime time.Time          `json:"modtime,siamismatch"` // Stays as 'modtime' in json for compatibility
+	FileMode         os.FileMode        `json:"mode,siamismatch"`    // Field is called FileMode for fuse compatibility
+	NumStuckChunks   uint64             `json:"numstuckchunks"`
+	OnDisk           bool               `json:"ondisk"`
+	Recoverable      bool               `json:"recoverable"`
+	Redundancy       float64            `json:"redundancy"`
+	Renewing         bool               `json:"renewing"`
+	RepairBytes      uint64             `json:"repairbytes"`
+	Skylinks         []string           `json:"skylinks"`
+	SiaPath          SiaPath            `json:"siapath"`
+	Stuck            bool               `json:"stuck"`
+	StuckBytes       uint64             `json:"stuckbytes"`
+	StuckHealth      float64            `json:"stuckhealth"`
+	UID              uint64             `json:"uid"`
+	UploadedBytes    uint64             `json:"uploadedbytes"`
+	UploadProgress   float64            `json:"uploadprogress"`
+	UserTags         []string           `json:"usertags"`
+	Verified         bool               `json:"verified"`
 }
 
 // Name implements os.FileInfo.
@@ -507,10 +877,31 @@ type HostDBEntry struct {
 
 	LastHistoricUpdate types.BlockHeight `json:"lasthistoricupdate"`
 
+	// Benchmark contains the rolling statistics about the latency and
+	// throughput of the host that are measured by the renter's workers.
+	Benchmark HostBenchmark `json:"benchmark"`
+
+	// AdditionalNetAddresses are the addresses the host announced in addition
+	// to its NetAddress.
+	AdditionalNetAddresses []NetAddress `json:"additionalnetaddresses"`
+
+	// AddressHistory contains the most recent NetAddresses the host announced,
+	// sorted from oldest to newest. Frequent changes indicate an unstable
+	// host, e.g. one that is hosted from a dynamic IP without a DDNS.
+	AddressHistory []HostDBAddress `json:"addresshistory"`
+
 	// Measurements related to the IP subnet mask.
 	IPNets          []string  `json:"ipnets"`
 	LastIPNetChange time.Time `json:"lastipnetchange"`
 
+	// ASN is the number of the autonomous system the host's address belongs
+	// to. 0 if it is unknown.
+	ASN uint32 `json:"asn"`
+
+	// Country is the ISO 3166 code of the country the host's address is
+	// registered in. Empty if it is unknown.
+	Country string `json:"country"`
+
 	// The public key of the host, stored separately to minimize risk of certain
 	// MitM based vulnerabilities.
 	PublicKey types.SiaPublicKey `json:"publickey"`
@@ -520,12 +911,147 @@ type HostDBEntry struct {
 	Filtered bool `json:"filtered"`
 }
 
+// NetAddresses returns all the announced addresses of the host, starting with
+// its NetAddress.
+func (he HostDBEntry) NetAddresses() []NetAddress {
+	return append([]NetAddress{he.NetAddress}, he.AdditionalNetAddresses...)
+}
+
+// HostDBAddress is an entry in the address history of a host. It contains an
+// address the host announced and the block height at which the renter first
+// saw the announcement.
+type HostDBAddress struct {
+	NetAddress  NetAddress        `json:"netaddress"`
+	BlockHeight types.BlockHeight `json:"blockheight"`
+}
+
 // HostDBScan represents a single scan event.
 type HostDBScan struct {
 	Timestamp time.Time `json:"timestamp"`
 	Success   bool      `json:"success"`
 }
 
+// HostDBScanRecord is a detailed record of a single scan of a host. Unlike the
+// ScanHistory of a HostDBEntry, which only contains the inputs of the host's
+// uptime score, scan records also contain the latency of the scan, the host's
+// score after the scan and the settings the host reported.
+type HostDBScanRecord struct {
+	Timestamp time.Time      `json:"timestamp"`
+	Success   bool           `json:"success"`
+	Error     string         `json:"error,omitempty"`
+	Latency   time.Duration  `json:"latency"`
+	Score     types.Currency `json:"score"`
+
+	// Settings are the settings the host reported during the scan. They are
+	// only set if they changed since the previous record which contains
+	// settings.
+	Settings *HostExternalSettings `json:"settings,omitempty"`
+}
+
+// HostBenchmark contains rolling statistics about the performance of a host.
+// The statistics are exponential moving averages of the samples taken by the
+// renter's workers. Fields which were never measured are zero.
+type HostBenchmark struct {
+	RTT        time.Duration `json:"rtt"`
+	TTFB       time.Duration `json:"ttfb"`
+	Throughput uint64        `json:"throughput"`
+
+	NumSamples    uint64    `json:"numsamples"`
+	LastBenchmark time.Time `json:"lastbenchmark"`
+}
+
+// HostBenchmarkSample is a single benchmark of a host. The RTT is measured
+// with a HasSector program, the TTFB and throughput with small and large
+// ReadOffset programs respectively. Measurements that couldn't be taken are
+// zero.
+type HostBenchmarkSample struct {
+	RTT        time.Duration
+	TTFB       time.Duration
+	Throughput uint64
+}
+
+// HostDBPriceSnapshot contains aggregates of the prices of all active hosts in
+// the network at a certain block height. Prices are the median of the prices
+// of all active hosts and use the same units as the host's settings.
+type HostDBPriceSnapshot struct {
+	BlockHeight types.BlockHeight `json:"blockheight"`
+	NumHosts    uint64            `json:"numhosts"`
+	Timestamp   time.Time         `json:"timestamp"`
+
+	MedianCollateral             types.Currency `json:"mediancollateral"`
+	MedianContractPrice          types.Currency `json:"mediancontractprice"`
+	MedianDownloadBandwidthPrice types.Currency `json:"mediandownloadbandwidthprice"`
+	MedianStoragePrice           types.Currency `json:"medianstorageprice"`
+	MedianUploadBandwidthPrice   types.Currency `json:"medianuploadbandwidthprice"`
+
+	// MedianCollateralRatio is the median of the hosts' ratios between
+	// collateral and storage price.
+	MedianCollateralRatio float64 `json:"mediancollateralratio"`
+}
+
+// HostDBDump is a snapshot of the state of a hostdb. It can be used to
+// bootstrap the hostdb of another renter or be merged into it to combine the
+// scan histories of multiple renters.
+type HostDBDump struct {
+	Version     string            `json:"version"`
+	BlockHeight types.BlockHeight `json:"blockheight"`
+	Timestamp   time.Time         `json:"timestamp"`
+
+	FilterMode    FilterMode            `json:"filtermode"`
+	FilteredHosts []types.SiaPublicKey  `json:"filteredhosts"`
+	Hosts         []HostDBDumpHost      `json:"hosts"`
+	PriceIndex    []HostDBPriceSnapshot `json:"priceindex"`
+}
+
+// HostDBDumpHost is the state of a single host in a HostDBDump. The score is
+// the weight the exporting hostdb assigned to the host. It depends on the
+// exporting renter's allowance and is recomputed on import.
+type HostDBDumpHost struct {
+	Entry       HostDBEntry        `json:"entry"`
+	ScanRecords []HostDBScanRecord `json:"scanrecords"`
+	Score       types.Currency     `json:"score"`
+}
+
+// AllowanceSimulation is the outcome of replaying the recorded settings of the
+// hosts in the hostdb against an allowance. Every sample describes how the
+// allowance would have fared at a certain point in time.
+type AllowanceSimulation struct {
+	Allowance Allowance                   `json:"allowance"`
+	Samples   []AllowanceSimulationSample `json:"samples"`
+
+	// MinQualifyingHosts is the lowest number of qualifying hosts across all
+	// samples and MaxTotalCost the highest expected cost.
+	MinQualifyingHosts uint64         `json:"minqualifyinghosts"`
+	MaxTotalCost       types.Currency `json:"maxtotalcost"`
+
+	// SufficientSamples is the number of samples in which enough hosts
+	// qualified and the expected cost was covered by the allowance's funds.
+	SufficientSamples uint64 `json:"sufficientsamples"`
+}
+
+// AllowanceSimulationSample describes how an allowance would have fared at a
+// certain point in time. The costs are the expected costs of a single period
+// with a full set of hosts, based on the prices of the best scoring
+// qualifying hosts at that time.
+type AllowanceSimulationSample struct {
+	Timestamp time.Time `json:"timestamp"`
+
+	// KnownHosts is the number of hosts with recorded settings at the time
+	// of the sample and QualifyingHosts the number of those which were
+	// online, accepting contracts and within the allowance's price limits.
+	KnownHosts      uint64 `json:"knownhosts"`
+	QualifyingHosts uint64 `json:"qualifyinghosts"`
+
+	ContractCost types.Currency `json:"contractcost"`
+	DownloadCost types.Currency `json:"downloadcost"`
+	StorageCost  types.Currency `json:"storagecost"`
+	UploadCost   types.Currency `json:"uploadcost"`
+	TotalCost    types.Currency `json:"totalcost"`
+
+	EnoughHosts bool `json:"enoughhosts"`
+	WithinFunds bool `json:"withinfunds"`
+}
+
 // HostScoreBreakdown provides a piece-by-piece explanation of why a host has
 // the score that they do.
 //
@@ -538,8 +1064,10 @@ type HostScoreBreakdown struct {
 	ConversionRate float64        `json:"conversionrate"`
 
 	AcceptContractAdjustment   float64 `json:"acceptcontractadjustment"`
+	AddressChangeAdjustment    float64 `json:"addresschangeadjustment"`
 	AgeAdjustment              float64 `json:"ageadjustment"`
 	BasePriceAdjustment        float64 `json:"basepriceadjustment"`
+	BenchmarkAdjustment        float64 `json:"benchmarkadjustment"`
 	BurnAdjustment             float64 `json:"burnadjustment"`
 	CollateralAdjustment       float64 `json:"collateraladjustment"`
 	DurationAdjustment         float64 `json:"durationadjustment"`
@@ -618,6 +1146,24 @@ type RenterSettings struct {
 	MaxUploadSpeed   int64         `json:"maxuploadspeed"`
 	MaxDownloadSpeed int64         `json:"maxdownloadspeed"`
 	UploadsStatus    UploadsStatus `json:"uploadsstatus"`
+
+	// VerifyUploads enables the verification of uploaded files. Once a file
+	// with a local copy reaches full redundancy, the renter downloads a
+	// sample of it and compares it against the local copy.
+	VerifyUploads bool `json:"verifyuploads"`
+
+	// MaxFileVersions is the number of previous versions of a file that are
+	// retained when the file is overwritten. 0 disables versioning.
+	MaxFileVersions uint64 `json:"maxfileversions"`
+}
+
+// FileVersion is a previous version of a file which was retained when the file
+// was overwritten.
+type FileVersion struct {
+	ID         string    `json:"id"`
+	SiaPath    SiaPath   `json:"siapath"`
+	Filesize   uint64    `json:"filesize"`
+	ArchivedAt time.Time `json:"archivedat"`
 }
 
 // UploadsStatus contains information about the Renter's Uploads
@@ -626,6 +1172,487 @@ type UploadsStatus struct {
 	PauseEndTime time.Time `json:"pauseendtime"`
 }
 
+// RenterChaosSettings configures the renter's chaos testing mode. While
+// enabled, the renter randomly injects failures and delays into the
+// communication with its hosts to validate that applications built on top of
+// the renter degrade gracefully. Probabilities are in the range [0, 1].
+type RenterChaosSettings struct {
+	Enabled bool `json:"enabled"`
+
+	// DelayProbability is the probability of delaying a new stream to a host
+	// by a random duration of up to MaxDelay.
+	DelayProbability float64       `json:"delayprobability"`
+	MaxDelay         time.Duration `json:"maxdelay"`
+
+	// DropPriceTableProbability is the probability of dropping a price table
+	// received from a host.
+	DropPriceTableProbability float64 `json:"droppricetableprobability"`
+
+	// FailureProbability is the probability of failing to open a new stream
+	// to a host.
+	FailureProbability float64 `json:"failureprobability"`
+}
+
+// RenterChaosReport reports the failures induced by the renter's chaos testing
+// mode and the failures visible to the user since the mode was configured.
+type RenterChaosReport struct {
+	Settings RenterChaosSettings `json:"settings"`
+	Since    time.Time           `json:"since"`
+
+	// Induced faults.
+	InducedDelays      uint64 `json:"induceddelays"`
+	InducedFailures    uint64 `json:"inducedfailures"`
+	DroppedPriceTables uint64 `json:"droppedpricetables"`
+
+	// User visible operations, i.e. downloads and streamed uploads, and how
+	// many of them failed.
+	UserOperations uint64 `json:"useroperations"`
+	UserFailures   uint64 `json:"userfailures"`
+}
+
+// Validate checks the chaos settings for errors.
+func (rcs RenterChaosSettings) Validate() error {
+	probabilities := []float64{rcs.DelayProbability, rcs.DropPriceTableProbability, rcs.FailureProbability}
+	for _, p := range probabilities {
+		if p < 0 || p > 1 {
+			return fmt.Errorf("probability %v is not in the range [0, 1]", p)
+		}
+	}
+	if rcs.MaxDelay < 0 {
+		return errors.New("max delay can't be negative")
+	}
+	return nil
+}
+
+// ReadOnlySettings contains the thresholds which cause the renter to enter
+// read-only mode automatically. A zero threshold disables the corresponding
+// check.
+type ReadOnlySettings struct {
+	// MinAllowanceRemaining is the amount of unspent allowance below which the
+	// renter enters read-only mode.
+	MinAllowanceRemaining types.Currency `json:"minallowanceremaining"`
+	// MinWalletBalance is the confirmed wallet balance below which the renter
+	// enters read-only mode.
+	MinWalletBalance types.Currency `json:"minwalletbalance"`
+}
+
+// RenterHostBandwidthLimits are the bandwidth limits of the worker of a single
+// host. They apply in addition to the renter's global bandwidth limits. A
+// limit of 0 means that the bandwidth isn't limited.
+type RenterHostBandwidthLimits struct {
+	HostPublicKey types.SiaPublicKey `json:"hostpublickey"`
+	// MaxDownloadSpeed and MaxUploadSpeed are the maximum number of bytes
+	// per second the renter downloads from and uploads to the host.
+	MaxDownloadSpeed int64 `json:"maxdownloadspeed"`
+	MaxUploadSpeed   int64 `json:"maxuploadspeed"`
+}
+
+// The following consts are the bulk operations of the renter which can be
+// restricted to the windows of the renter's scheduler.
+const (
+	// RenterSchedulerJobArchive is the upload and repair of files with the
+	// low repair priority.
+	RenterSchedulerJobArchive RenterSchedulerJob = "archive"
+	// RenterSchedulerJobAudit is the verification of uploaded files against
+	// their local copies.
+	RenterSchedulerJobAudit RenterSchedulerJob = "audit"
+	// RenterSchedulerJobRepair is the repair of chunks which lost redundancy
+	// but are still recoverable. Chunks of files with the high repair
+	// priority are never restricted.
+	RenterSchedulerJobRepair RenterSchedulerJob = "repair"
+)
+
+// renterSchedulerDays are the names of the weekdays used by the windows of
+// the renter's scheduler.
+var renterSchedulerDays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+type (
+	// RenterSchedulerJob is a bulk operation of the renter which can be
+	// restricted to off-peak windows.
+	RenterSchedulerJob string
+
+	// RenterSchedulerSettings configure the renter's scheduler. The
+	// restricted jobs only run during one of the windows, which keeps the
+	// bandwidth of the renter available for interactive downloads outside of
+	// the windows. Jobs which aren't restricted run at any time.
+	RenterSchedulerSettings struct {
+		Jobs    []RenterSchedulerJob    `json:"jobs"`
+		Windows []RenterSchedulerWindow `json:"windows"`
+	}
+
+	// RenterSchedulerWindow is a recurring time window in the local time of
+	// the renter.
+	RenterSchedulerWindow struct {
+		// Days are the weekdays the window starts on, e.g. "mon". A window
+		// without days starts every day.
+		Days []string `json:"days,omitempty"`
+
+		// Start and End are the times of the day the window starts and ends
+		// in the format "15:04". If End isn't after Start, the window ends on
+		// the following day.
+		Start string `json:"start"`
+		End   string `json:"end"`
+
+		// Budget is the number of bytes the restricted jobs can transfer
+		// during a single occurrence of the window. A budget of 0 means that
+		// the bandwidth isn't limited.
+		Budget uint64 `json:"budget"`
+	}
+
+	// RenterSchedulerStatus contains the settings of the renter's scheduler
+	// and the state of its windows.
+	RenterSchedulerStatus struct {
+		Settings RenterSchedulerSettings `json:"settings"`
+
+		// Active indicates whether one of the windows is active. WindowEnd is
+		// the end of the active window and BudgetUsed is the number of bytes
+		// the restricted jobs transferred during it.
+		Active     bool      `json:"active"`
+		WindowEnd  time.Time `json:"windowend"`
+		BudgetUsed uint64    `json:"budgetused"`
+
+		// NextWindowStart is the time the next window starts.
+		NextWindowStart time.Time `json:"nextwindowstart"`
+
+		// Paused are the restricted jobs which can't run right now, either
+		// because no window is active or because its budget is exhausted.
+		Paused []RenterSchedulerJob `json:"paused"`
+	}
+)
+
+// Restricts returns whether the job is restricted to the windows of the
+// scheduler.
+func (rs RenterSchedulerSettings) Restricts(job RenterSchedulerJob) bool {
+	for _, j := range rs.Jobs {
+		if j == job {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the scheduler settings for errors.
+func (rs RenterSchedulerSettings) Validate() error {
+	seen := make(map[RenterSchedulerJob]struct{})
+	for _, job := range rs.Jobs {
+		switch job {
+		case RenterSchedulerJobArchive, RenterSchedulerJobAudit, RenterSchedulerJobRepair:
+		default:
+			return fmt.Errorf("unknown job %q", job)
+		}
+		if _, exists := seen[job]; exists {
+			return fmt.Errorf("job %q is specified more than once", job)
+		}
+		seen[job] = struct{}{}
+	}
+	if len(rs.Jobs) > 0 && len(rs.Windows) == 0 {
+		return errors.New("restricted jobs require at least one window")
+	}
+	for i, w := range rs.Windows {
+		if err := w.Validate(); err != nil {
+			return errors.AddContext(err, fmt.Sprintf("invalid window %v", i))
+		}
+	}
+	return nil
+}
+
+// Validate checks the window for errors.
+func (w RenterSchedulerWindow) Validate() error {
+	for _, day := range w.Days {
+		if _, exists := renterSchedulerDays[day]; !exists {
+			return fmt.Errorf("unknown day %q", day)
+		}
+	}
+	if _, err := time.Parse("15:04", w.Start); err != nil {
+		return errors.AddContext(err, "invalid start")
+	}
+	if _, err := time.Parse("15:04", w.End); err != nil {
+		return errors.AddContext(err, "invalid end")
+	}
+	return nil
+}
+
+// Occurrence returns the start and end of the occurrence of the window which
+// contains t. The returned bool is false if the window isn't active at t.
+func (w RenterSchedulerWindow) Occurrence(t time.Time) (start, end time.Time, ok bool) {
+	// An occurrence lasts at most a day, so it either started on the day of
+	// t or on the day before.
+	for i := -1; i <= 0; i++ {
+		start, end, ok := w.occurrenceOn(t, i)
+		if ok && !t.Before(start) && t.Before(end) {
+			return start, end, true
+		}
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+// NextStart returns the start of the next occurrence of the window after t.
+func (w RenterSchedulerWindow) NextStart(t time.Time) time.Time {
+	for i := 0; i <= 7; i++ {
+		start, _, ok := w.occurrenceOn(t, i)
+		if ok && start.After(t) {
+			return start
+		}
+	}
+	return time.Time{}
+}
+
+// occurrenceOn returns the occurrence of the window which starts the given
+// number of days after the day of t. The returned bool is false if the window
+// doesn't start on that day or if it is invalid.
+func (w RenterSchedulerWindow) occurrenceOn(t time.Time, days int) (start, end time.Time, ok bool) {
+	s, err := time.Parse("15:04", w.Start)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	e, err := time.Parse("15:04", w.End)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	y, m, d := t.Date()
+	start = time.Date(y, m, d+days, s.Hour(), s.Minute(), 0, 0, t.Location())
+	end = time.Date(y, m, d+days, e.Hour(), e.Minute(), 0, 0, t.Location())
+	if !end.After(start) {
+		end = time.Date(y, m, d+days+1, e.Hour(), e.Minute(), 0, 0, t.Location())
+	}
+	if len(w.Days) == 0 {
+		return start, end, true
+	}
+	for _, day := range w.Days {
+		if renterSchedulerDays[day] == start.Weekday() {
+			return start, end, true
+		}
+	}
+	return time.Time{}, time.Time{}, false
+}
+
+// ErrPinnedBytesBudgetExceeded is returned when pinning a range would exceed
+// the renter's pinned bytes budget.
+var ErrPinnedBytesBudgetExceeded = errors.New("pinned bytes budget exceeded")
+
+// RenterPinnedRange is a byte range of a file which the renter keeps in
+// memory to serve streams of the file without downloading the data.
+type RenterPinnedRange struct {
+	SiaPath SiaPath `json:"siapath"`
+	Offset  uint64  `json:"offset"`
+	Length  uint64  `json:"length"`
+
+	// Cached indicates whether the data of the range was loaded into memory.
+	// It is ignored when the range is persisted.
+	Cached bool `json:"cached"`
+}
+
+// RenterPinnedCache describes the ranges the renter keeps in memory. The
+// total length of the ranges can't exceed the budget.
+type RenterPinnedCache struct {
+	Budget      uint64              `json:"budget"`
+	PinnedBytes uint64              `json:"pinnedbytes"`
+	Ranges      []RenterPinnedRange `json:"ranges"`
+}
+
+// RenterFileSpending is the money the renter spent on a file. The spending is
+// estimated from the prices of the hosts at the time of the upload or download
+// and it is kept for files which were deleted.
+type RenterFileSpending struct {
+	SiaPath  SiaPath        `json:"siapath"`
+	Download types.Currency `json:"download"`
+	Storage  types.Currency `json:"storage"`
+	Upload   types.Currency `json:"upload"`
+}
+
+// RenterHostSpending is the money the renter spent on a host, aggregated
+// across all current and old contracts with the host.
+type RenterHostSpending struct {
+	HostPublicKey types.SiaPublicKey `json:"hostpublickey"`
+	Contracts     uint64             `json:"contracts"`
+
+	Download    types.Currency `json:"download"`
+	Fees        types.Currency `json:"fees"`
+	FundAccount types.Currency `json:"fundaccount"`
+	Maintenance types.Currency `json:"maintenance"`
+	Storage     types.Currency `json:"storage"`
+	Upload      types.Currency `json:"upload"`
+}
+
+const (
+	// RenterSpendingByFiles exports the renter's spending per file.
+	RenterSpendingByFiles = "files"
+
+	// RenterSpendingByHosts exports the renter's spending per host.
+	RenterSpendingByHosts = "hosts"
+)
+
+// RenterSpending contains the breakdown of the renter's spending per file and
+// per host.
+type RenterSpending struct {
+	Files []RenterFileSpending `json:"files"`
+	Hosts []RenterHostSpending `json:"hosts"`
+}
+
+// ReadOnlyStatus contains information about the renter's read-only mode.
+// While in read-only mode the renter won't upload, repair or renew contracts
+// but downloads continue to work.
+type ReadOnlyStatus struct {
+	// Active indicates whether the renter is currently in read-only mode.
+	Active bool `json:"active"`
+	// Manual indicates that read-only mode was enabled through the API and
+	// will only be left once it is disabled through the API again.
+	Manual bool `json:"manual"`
+	// Replica indicates that the renter is read-only because it is a
+	// secondary replica. Unlike the other causes, it doesn't suspend contract
+	// renewals.
+	Replica bool `json:"replica"`
+	// Reason is a human readable explanation for why read-only mode is
+	// active.
+	Reason string `json:"reason"`
+	// Since is the time at which the renter entered read-only mode.
+	Since time.Time `json:"since"`
+	// Settings are the thresholds used to trigger read-only mode.
+	Settings ReadOnlySettings `json:"settings"`
+}
+
+const (
+	// ReplicationRoleNone disables the replication of the renter's metadata.
+	ReplicationRoleNone = ""
+
+	// ReplicationRolePrimary is the role of a renter which periodically
+	// exports the metadata of its files for a secondary renter.
+	ReplicationRolePrimary = "primary"
+
+	// ReplicationRoleSecondary is the role of a read-only renter which
+	// periodically imports the metadata exported by a primary renter and
+	// serves downloads for its files using its own contracts.
+	ReplicationRoleSecondary = "secondary"
+)
+
+// ReplicationSettings configure the replication of a renter's metadata to a
+// read-only secondary renter. The primary renter writes the metadata of all
+// its files to Path and the secondary renter mirrors the files from there.
+type ReplicationSettings struct {
+	// Role is the role of the renter. It is either empty, "primary" or
+	// "secondary".
+	Role string `json:"role"`
+	// Path is the location of the metadata export on disk. It needs to be
+	// shared between the primary and the secondary, e.g. through a network
+	// file system or by copying the file periodically.
+	Path string `json:"path"`
+	// Secret encrypts the metadata export. The export contains the encryption
+	// keys of the files which means it should be set if the export leaves the
+	// machine. The primary and the secondary need to use the same secret.
+	Secret string `json:"secret,omitempty"`
+	// Interval is the amount of time between two exports or imports. 0 uses
+	// the default interval.
+	Interval time.Duration `json:"interval"`
+}
+
+// ReplicationStatus contains information about the replication of a renter's
+// metadata.
+type ReplicationStatus struct {
+	Settings ReplicationSettings `json:"settings"`
+
+	// LastSync is the time of the last successful export or import and
+	// LastSyncError is the error of the last attempt if it failed.
+	LastSync      time.Time `json:"lastsync"`
+	LastSyncError string    `json:"lastsyncerror"`
+
+	// ImportedFiles is the number of files the secondary imported from the
+	// last export.
+	ImportedFiles uint64 `json:"importedfiles"`
+
+	// MissingHosts are the hosts which store data of the imported files but
+	// which the secondary has no contract with. The secondary can't download
+	// from these hosts.
+	MissingHosts []types.SiaPublicKey `json:"missinghosts"`
+}
+
+// Validate checks the replication settings for errors.
+func (rs ReplicationSettings) Validate() error {
+	switch rs.Role {
+	case ReplicationRoleNone:
+		return nil
+	case ReplicationRolePrimary, ReplicationRoleSecondary:
+	default:
+		return fmt.Errorf("unknown replication role '%v'", rs.Role)
+	}
+	if rs.Path == "" {
+		return errors.New("replication path is required")
+	}
+	if !filepath.IsAbs(rs.Path) {
+		return errors.New("replication path needs to be absolute")
+	}
+	if rs.Interval < 0 {
+		return errors.New("replication interval can't be negative")
+	}
+	return nil
+}
+
+// MetadataMirrorSettings configure the mirroring of the renter's metadata to
+// its hosts. While enabled, the renter periodically uploads the index of its
+// contracts and the siafiles which changed since the last mirror to its hosts
+// in small erasure-coded blobs. The metadata can be recovered from the hosts
+// with only the seed.
+type MetadataMirrorSettings struct {
+	Enabled bool `json:"enabled"`
+	// Interval is the amount of time between two mirrors. 0 uses the default
+	// interval.
+	Interval time.Duration `json:"interval"`
+}
+
+// MetadataMirrorStatus contains information about the mirroring of the
+// renter's metadata to its hosts.
+type MetadataMirrorStatus struct {
+	Settings MetadataMirrorSettings `json:"settings"`
+
+	// LastMirror is the time of the last successful mirror and
+	// LastMirrorError is the error of the last attempt if it failed.
+	LastMirror      time.Time `json:"lastmirror"`
+	LastMirrorError string    `json:"lastmirrorerror"`
+
+	// Sequence is the sequence number of the most recently mirrored blob and
+	// ChainLength is the number of blobs which need to be fetched to recover
+	// the metadata.
+	Sequence    uint64 `json:"sequence"`
+	ChainLength uint64 `json:"chainlength"`
+
+	// MirroredFiles is the number of siafiles which were mirrored by the last
+	// mirror.
+	MirroredFiles uint64 `json:"mirroredfiles"`
+}
+
+// MetadataMirrorRecovery is the result of recovering the renter's metadata
+// from the mirror on its hosts.
+type MetadataMirrorRecovery struct {
+	// Sequence is the sequence number of the blob the recovery started at.
+	Sequence uint64 `json:"sequence"`
+
+	// RecoveredFiles is the number of siafiles which were recovered and
+	// SkippedFiles is the number of mirrored siafiles which already existed.
+	RecoveredFiles uint64 `json:"recoveredfiles"`
+	SkippedFiles   uint64 `json:"skippedfiles"`
+
+	// Contracts is the number of contracts in the mirrored index and
+	// MissingContracts are the contracts of the index the renter doesn't
+	// know about. They can be recovered with a recovery scan.
+	Contracts        uint64                 `json:"contracts"`
+	MissingContracts []types.FileContractID `json:"missingcontracts"`
+}
+
+// Validate checks the metadata mirror settings for errors.
+func (ms MetadataMirrorSettings) Validate() error {
+	if ms.Interval < 0 {
+		return errors.New("metadata mirror interval can't be negative")
+	}
+	return nil
+}
+
 // HostDBScans represents a sortable slice of scans.
 type HostDBScans []HostDBScan
 
@@ -816,7 +1843,7 @@ type ContractorSpending struct {
 	Unspent types.Currency `json:"unspent"`
 	// ContractSpendingDeprecated was renamed to TotalAllocated and always has the
 	// same value as TotalAllocated.
-	ContractSpendingDeprecated types.Currency `json:"contractspending,siamismatch"`
+	ContractSpendingDeprecated types.Currency `json:"contractspending,siamismatch" deprecated:"2"`
 	// WithheldFunds are the funds from the previous period that are tied up
 	// in contracts and have not been released yet
 	WithheldFunds types.Currency `json:"withheldfunds"`
@@ -845,6 +1872,84 @@ func (cs ContractorSpending) SpendingBreakdown() (totalSpent, unspentAllocated,
 	return totalSpent, unspentAllocated, unspentUnallocated
 }
 
+// SpendingForecast projects the spending of the renter for the remainder of
+// the current period.
+type SpendingForecast struct {
+	// Assumptions describes the assumptions the forecast is based on.
+	Assumptions []string `json:"assumptions"`
+
+	// BlockHeight is the height the forecast was made at. PeriodStart and
+	// PeriodEnd mark the current period and RemainingBlocks is the number of
+	// blocks until the end of the period.
+	BlockHeight     types.BlockHeight `json:"blockheight"`
+	PeriodEnd       types.BlockHeight `json:"periodend"`
+	PeriodStart     types.BlockHeight `json:"periodstart"`
+	RemainingBlocks types.BlockHeight `json:"remainingblocks"`
+
+	// Allowance is the allowance's funds and Spent the amount of money spent
+	// in the current period so far.
+	Allowance types.Currency `json:"allowance"`
+	Spent     types.Currency `json:"spent"`
+
+	// The projected spending for the remainder of the period.
+	ProjectedBandwidthSpending types.Currency `json:"projectedbandwidthspending"`
+	ProjectedRenewalSpending   types.Currency `json:"projectedrenewalspending"`
+	ProjectedStorageSpending   types.Currency `json:"projectedstoragespending"`
+
+	// ProjectedTotal is the projected spending for the whole period and
+	// ProjectedUnspent the amount of the allowance that is projected to be
+	// left at the end of the period.
+	ProjectedTotal   types.Currency `json:"projectedtotal"`
+	ProjectedUnspent types.Currency `json:"projectedunspent"`
+
+	// ExhaustedEarly indicates that the allowance is projected to run out
+	// before the end of the period at ExhaustionHeight.
+	ExhaustedEarly   bool              `json:"exhaustedearly"`
+	ExhaustionHeight types.BlockHeight `json:"exhaustionheight"`
+}
+
+// ContractLockedFunds contains the renter funds which are locked in a file
+// contract. The funds are released to the wallet once the contract's proof
+// window ended and the payout matured, at the latest at ReleaseHeight.
+type ContractLockedFunds struct {
+	ID            types.FileContractID `json:"id"`
+	HostPublicKey types.SiaPublicKey   `json:"hostpublickey"`
+	LockedFunds   types.Currency       `json:"lockedfunds"`
+	EndHeight     types.BlockHeight    `json:"endheight"`
+	ReleaseHeight types.BlockHeight    `json:"releaseheight"`
+}
+
+// LockedFundsRelease contains the funds released to the wallet at a height and
+// the funds which remain locked in contracts afterwards.
+type LockedFundsRelease struct {
+	Height    types.BlockHeight `json:"height"`
+	Released  types.Currency    `json:"released"`
+	Remaining types.Currency    `json:"remaining"`
+}
+
+// ContractorLockedFunds contains the renter funds locked in unexpired file
+// contracts and the timeline of their release.
+type ContractorLockedFunds struct {
+	BlockHeight types.BlockHeight     `json:"blockheight"`
+	Total       types.Currency        `json:"total"`
+	Contracts   []ContractLockedFunds `json:"contracts"`
+	Releases    []LockedFundsRelease  `json:"releases"`
+}
+
+// AllowanceAlertSettings contains the rules the contractor evaluates to warn
+// the user about the spending of the allowance. A zero value disables the
+// corresponding rule.
+type AllowanceAlertSettings struct {
+	// SpentThreshold is the fraction of the allowance's funds which can be
+	// spent in the current period before an alert is registered. e.g. 0.8
+	// warns once 80% of the allowance was spent.
+	SpentThreshold float64 `json:"spentthreshold"`
+	// RenewalExceedsFunds registers an alert if the estimated cost of
+	// renewing the contracts which expire in the current period exceeds the
+	// remaining funds of the allowance.
+	RenewalExceedsFunds bool `json:"renewalexceedsfunds"`
+}
+
 // ContractorChurnStatus contains the current churn budgets for the Contractor's
 // churnLimiter and the aggregate churn for the current period.
 type ContractorChurnStatus struct {
@@ -853,6 +1958,59 @@ type ContractorChurnStatus struct {
 	AggregateCurrentPeriodChurn uint64 `json:"aggregatecurrentperiodchurn"`
 	// MaxPeriodChurn is the (adjustable) maximum churn allowed per period.
 	MaxPeriodChurn uint64 `json:"maxperiodchurn"`
+	// CurrentPeriodChurnedContracts is the number of contracts churned in this
+	// period.
+	CurrentPeriodChurnedContracts uint64 `json:"currentperiodchurnedcontracts"`
+	// MaxPeriodChurnContracts is the maximum number of contracts which can be
+	// churned per period. 0 means unlimited.
+	MaxPeriodChurnContracts uint64 `json:"maxperiodchurncontracts"`
+}
+
+// ContractorMigrationPlan contains the contracts the contractor wants to
+// replace because their hosts' scores dropped, as of the last contract
+// maintenance.
+type ContractorMigrationPlan struct {
+	// Timestamp is the time at which the plan was created. It is zero if no
+	// plan was created yet.
+	Timestamp time.Time `json:"timestamp"`
+	// Migrations are the contracts queued for replacement.
+	Migrations []ContractMigration `json:"migrations"`
+}
+
+// ContractMigration describes a contract queued for replacement by the
+// contractor.
+type ContractMigration struct {
+	ContractID    types.FileContractID `json:"contractid"`
+	HostPublicKey types.SiaPublicKey   `json:"hostpublickey"`
+	Size          uint64               `json:"size"`
+	Score         types.Currency       `json:"score"`
+
+	// Reason explains why the contract should be replaced.
+	Reason string `json:"reason"`
+	// Status is either ContractMigrationStatusChurned if the contract was
+	// marked !GoodForRenew or ContractMigrationStatusDeferred if the churn
+	// budget didn't allow for it yet.
+	Status string `json:"status"`
+	// Approved indicates whether the user approved churning the contract
+	// regardless of the churn budget.
+	Approved bool `json:"approved"`
+}
+
+// ContractKeyStoreStatus contains information about the encrypted store which
+// holds the secret keys of the renter's contracts.
+type ContractKeyStoreStatus struct {
+	// Initialized indicates whether the key store was created.
+	Initialized bool `json:"initialized"`
+	// Unlocked indicates whether the keys within the store can be used to sign
+	// contract revisions.
+	Unlocked bool `json:"unlocked"`
+	// NumKeys is the number of keys within the store. It is only known while
+	// the store is unlocked.
+	NumKeys uint64 `json:"numkeys"`
+	// NumUnprotectedKeys is the number of contracts which still keep their
+	// secret key within their contract file. These are moved into the store
+	// when it is unlocked.
+	NumUnprotectedKeys uint64 `json:"numunprotectedkeys"`
 }
 
 // UploadedBackup contains metadata about an uploaded backup.
@@ -908,6 +2066,13 @@ type (
 		// PriceTable information
 		PriceTableStatus WorkerPriceTableStatus `json:"pricetablestatus"`
 
+		// SiaMux session information
+		SessionStatus WorkerSessionStatus `json:"sessionstatus"`
+
+		// Bandwidth limits of the host, 0 if unlimited
+		MaxDownloadSpeed int64 `json:"maxdownloadspeed"`
+		MaxUploadSpeed   int64 `json:"maxuploadspeed"`
+
 		// Job Queues
 		DownloadSnapshotJobQueueSize int `json:"downloadsnapshotjobqueuesize"`
 		UploadSnapshotJobQueueSize   int `json:"uploadsnapshotjobqueuesize"`
@@ -957,6 +2122,25 @@ type (
 		RecentErrTime time.Time `json:"recenterrtime"`
 	}
 
+	// WorkerSessionStatus contains detailed information about the SiaMux
+	// session the worker uses to open streams to the host.
+	WorkerSessionStatus struct {
+		Active   bool      `json:"active"`
+		LastUsed time.Time `json:"lastused"`
+
+		StreamsOpened   uint64 `json:"streamsopened"`
+		StreamFailures  uint64 `json:"streamfailures"`
+		SessionsOpened  uint64 `json:"sessionsopened"`
+		SessionsReused  uint64 `json:"sessionsreused"`
+		AvgOpenStreamMS uint64 `json:"avgopenstreamms"`
+
+		KeepalivesSent    uint64 `json:"keepalivessent"`
+		KeepaliveFailures uint64 `json:"keepalivefailures"`
+
+		RecentErr     string    `json:"recenterr"`
+		RecentErrTime time.Time `json:"recenterrtime"`
+	}
+
 	// WorkerReadJobsStatus contains detailed information about the read jobs
 	WorkerReadJobsStatus struct {
 		AvgJobTime64k uint64 `json:"avgjobtime64k"` // in ms
@@ -1022,6 +2206,18 @@ type Renter interface {
 	// watchdog, and a bool indicating whether or not the watchdog is aware of it.
 	ContractStatus(fcID types.FileContractID) (ContractWatchStatus, bool)
 
+	// ChangeContractKeyStorePassword re-encrypts the contract key store with a
+	// new password.
+	ChangeContractKeyStorePassword(password, newPassword string) error
+
+	// ContractKeyStoreStatus returns information about the contract key store.
+	ContractKeyStoreStatus() ContractKeyStoreStatus
+
+	// UnlockContractKeyStore unlocks the contract key store with the wallet
+	// password. If the store doesn't exist yet, it is created and the secret
+	// keys of all contracts are moved into it.
+	UnlockContractKeyStore(password string) error
+
 	// CreateBackup creates a backup of the renter's siafiles. If a secret is not
 	// nil, the backup will be encrypted using the provided secret.
 	CreateBackup(dst string, secret []byte) error
@@ -1045,6 +2241,15 @@ type Renter interface {
 	// ContractorChurnStatus returns contract churn stats for the current period.
 	ContractorChurnStatus() ContractorChurnStatus
 
+	// ContractorMigrationPlan returns the contracts the contractor queued for
+	// replacement during the last contract maintenance.
+	ContractorMigrationPlan() ContractorMigrationPlan
+
+	// ApproveContractMigrations approves churning the provided deferred
+	// contracts during the next contract maintenance regardless of the churn
+	// budget.
+	ApproveContractMigrations(ids []types.FileContractID) error
+
 	// ContractUtility provides the contract utility for a given host key.
 	ContractUtility(pk types.SiaPublicKey) (ContractUtility, bool)
 
@@ -1069,6 +2274,22 @@ type Renter interface {
 	// billing period.
 	PeriodSpending() (ContractorSpending, error)
 
+	// SpendingForecast projects the spending for the remainder of the current
+	// billing period.
+	SpendingForecast() (SpendingForecast, error)
+
+	// LockedFunds returns the renter funds locked in unexpired file
+	// contracts and when they are released to the wallet.
+	LockedFunds() (ContractorLockedFunds, error)
+
+	// AllowanceAlertSettings returns the rules used to alert the user about
+	// the spending of the allowance.
+	AllowanceAlertSettings() AllowanceAlertSettings
+
+	// SetAllowanceAlertSettings updates the rules used to alert the user about
+	// the spending of the allowance.
+	SetAllowanceAlertSettings(settings AllowanceAlertSettings) error
+
 	// RecoverableContracts returns the contracts that the contractor deems
 	// recoverable. That means they are not expired yet and also not part of the
 	// active contracts. Usually this should return an empty slice unless the host
@@ -1082,6 +2303,18 @@ type Renter interface {
 	// RefreshedContract checks if the contract was previously refreshed
 	RefreshedContract(fcid types.FileContractID) bool
 
+	// SetFileHTTPHeaders sets the HTTP headers which are used when serving
+	// a file.
+	SetFileHTTPHeaders(siaPath SiaPath, headers FileHTTPHeaders) error
+
+	// SetFileExpiry sets the time after which a file is removed
+	// automatically.
+	SetFileExpiry(siaPath SiaPath, expiry FileExpiry) error
+
+	// SetFileRepairPriority sets the priority with which a file is
+	// repaired.
+	SetFileRepairPriority(siaPath SiaPath, priority FileRepairPriority) error
+
 	// SetFileStuck sets the 'stuck' status of a file.
 	SetFileStuck(siaPath SiaPath, stuck bool) error
 
@@ -1127,11 +2360,23 @@ type Renter interface {
 	// File returns information on specific file queried by user
 	File(siaPath SiaPath) (FileInfo, error)
 
+	// FileCached returns information on a specific file using cached values
+	// for health and redundancy.
+	FileCached(siaPath SiaPath) (FileInfo, error)
+
+	// FileVersions returns the retained previous versions of a file, ordered
+	// from newest to oldest.
+	FileVersions(siaPath SiaPath) ([]FileVersion, error)
+
 	// FileList returns information on all of the files stored by the renter at the
 	// specified folder. The 'cached' argument specifies whether cached values
 	// should be returned or not.
 	FileList(siaPath SiaPath, recursive, cached bool, flf FileListFunc) error
 
+	// SearchFiles searches the files within the specified folder using the
+	// renter's search index and returns the requested page of the results.
+	SearchFiles(siaPath SiaPath, params FileSearchParams) (FileSearchResult, error)
+
 	// Filter returns the renter's hostdb's filterMode and filteredHosts
 	Filter() (FilterMode, map[string]types.SiaPublicKey, error)
 
@@ -1145,16 +2390,45 @@ type Renter interface {
 	// hostdb is completed.
 	InitialScanComplete() (bool, error)
 
+	// HostDBExport returns a dump of the state of the hostdb.
+	HostDBExport() (HostDBDump, error)
+
+	// HostDBImport restores the hostdb from a dump or merges the dump's scan
+	// histories into it.
+	HostDBImport(dump HostDBDump, merge bool) error
+
+	// HostDBPriceIndex returns the historic price snapshots of the host
+	// network tracked by the hostdb.
+	HostDBPriceIndex() ([]HostDBPriceSnapshot, error)
+
+	// HostDBScanHistory returns the detailed scan history of a host tracked
+	// by the hostdb.
+	HostDBScanHistory(pk types.SiaPublicKey) ([]HostDBScanRecord, error)
+
+	// HostDBSimulateAllowance replays the recorded settings of the hosts in
+	// the hostdb over the provided window against an allowance.
+	HostDBSimulateAllowance(a Allowance, window time.Duration) (AllowanceSimulation, error)
+
 	// PriceEstimation estimates the cost in siacoins of performing various
 	// storage and data operations.
 	PriceEstimation(allowance Allowance) (RenterPriceEstimation, Allowance, error)
 
+	// PruneFileVersions deletes all but the 'keep' most recent versions of a
+	// file.
+	PruneFileVersions(siaPath SiaPath, keep uint64) error
+
 	// RenameFile changes the path of a file.
 	RenameFile(siaPath, newSiaPath SiaPath) error
 
+	// RestoreFileVersion replaces a file with one of its previous versions.
+	RestoreFileVersion(siaPath SiaPath, id string) error
+
 	// RenameDir changes the path of a dir.
 	RenameDir(oldPath, newPath SiaPath) error
 
+	// CopyDir copies a dir and all of its contents to a new path.
+	CopyDir(oldPath, newPath SiaPath) error
+
 	// EstimateHostScore will return the score for a host with the provided
 	// settings, assuming perfect age and uptime adjustments
 	EstimateHostScore(entry HostDBEntry, allowance Allowance) (HostScoreBreakdown, error)
@@ -1175,6 +2449,10 @@ type Renter interface {
 	// SetSettings sets the Renter's settings.
 	SetSettings(RenterSettings) error
 
+	// SectorStats fetches the stats of the sectors with the given roots from
+	// all hosts that support it, using a single batched request per host.
+	SectorStats(roots []crypto.Hash, timeout time.Duration) (map[string][]SectorStat, error)
+
 	// SetFileTrackingPath sets the on-disk location of an uploaded file to a
 	// new value. Useful if files need to be moved on disk.
 	SetFileTrackingPath(siaPath SiaPath, newPath string) error
@@ -1190,6 +2468,122 @@ type Renter interface {
 	// ResumeRepairsAndUploads resumes the renter's repairs and uploads
 	ResumeRepairsAndUploads() error
 
+	// RepairQueue returns the chunks which are being repaired or waiting to
+	// be repaired, grouped by file in the order they are repaired.
+	RepairQueue() RepairQueue
+
+	// ReadOnlyStatus returns the status of the renter's read-only mode.
+	ReadOnlyStatus() (ReadOnlyStatus, error)
+
+	// CreateSparseFile creates a file of the provided size which consists
+	// only of holes. The holes can be filled using a streamed repair.
+	CreateSparseFile(up FileUploadParams, size uint64) error
+
+	// PunchHoles turns the chunks within the range [offset;offset+length) of a
+	// file into holes which read as zeroes.
+	PunchHoles(siaPath SiaPath, offset, length uint64) error
+
+	// ExportFile writes the metadata of a file, including its host public
+	// keys and piece mappings, to w in a portable, versioned format.
+	ExportFile(siaPath SiaPath, w io.Writer) error
+
+	// ImportFile adds a file which was previously exported with ExportFile
+	// to the renter.
+	ImportFile(siaPath SiaPath, r io.Reader) error
+
+	// RecoverFile writes the data of a file to w by downloading its sectors
+	// directly from the renter's contracts without using the workers. It is
+	// a last resort for recovering files when downloads fail.
+	RecoverFile(siaPath SiaPath, w io.Writer) (FileRecoveryReport, error)
+
+	// RecoverExportedFile works like RecoverFile but uses the metadata of a
+	// file which was exported with ExportFile instead of a file known to the
+	// renter.
+	RecoverExportedFile(export io.Reader, w io.Writer) (FileRecoveryReport, error)
+
+	// ChaosReport returns the report of the renter's chaos testing mode.
+	ChaosReport() (RenterChaosReport, error)
+
+	// SetChaosSettings configures the renter's chaos testing mode and resets
+	// its report.
+	SetChaosSettings(settings RenterChaosSettings) error
+
+	// FailureDomainReport returns the files which store several pieces of a
+	// chunk on hosts sharing a failure domain.
+	FailureDomainReport() (RenterFailureDomainReport, error)
+
+	// FixFailureDomainViolations drops the correlated pieces of the chunks
+	// of the provided files, or of all files if none are provided, to have
+	// them re-uploaded to uncorrelated hosts.
+	FixFailureDomainViolations(siaPaths []SiaPath) (RenterFailureDomainFixReport, error)
+
+	// HostBandwidthLimits returns the bandwidth limits of all hosts which
+	// have one.
+	HostBandwidthLimits() []RenterHostBandwidthLimits
+
+	// SetHostBandwidthLimits sets the bandwidth limits of the worker of a
+	// host. Setting both limits to 0 removes the limits of the host.
+	SetHostBandwidthLimits(limits RenterHostBandwidthLimits) error
+
+	// SchedulerStatus returns the settings of the renter's scheduler and the
+	// state of its windows.
+	SchedulerStatus() RenterSchedulerStatus
+
+	// SetSchedulerSettings sets the jobs which are restricted to the windows
+	// of the renter's scheduler.
+	SetSchedulerSettings(settings RenterSchedulerSettings) error
+
+	// PinnedCache returns the ranges of files the renter keeps in memory.
+	PinnedCache() RenterPinnedCache
+
+	// PinFile keeps a range of a file in memory to serve streams of the file
+	// without downloading the data. A length of 0 pins the remainder of the
+	// file.
+	PinFile(siaPath SiaPath, offset, length uint64) error
+
+	// UnpinFile removes all the pinned ranges of a file from memory.
+	UnpinFile(siaPath SiaPath) error
+
+	// SetPinnedBytesBudget sets the max number of bytes the renter keeps in
+	// memory for pinned ranges.
+	SetPinnedBytesBudget(budget uint64) error
+
+	// Spending returns the breakdown of the renter's spending per file and
+	// per host.
+	Spending() (RenterSpending, error)
+
+	// ExportSpending writes the breakdown of the renter's spending per file or
+	// per host to w in CSV format.
+	ExportSpending(w io.Writer, by string) error
+
+	// SetReadOnlyMode manually enables or disables the renter's read-only
+	// mode.
+	SetReadOnlyMode(enabled bool) error
+
+	// SetReadOnlySettings updates the thresholds which cause the renter to
+	// enter read-only mode automatically.
+	SetReadOnlySettings(settings ReadOnlySettings) error
+
+	// ReplicationStatus returns the status of the replication of the
+	// renter's metadata.
+	ReplicationStatus() (ReplicationStatus, error)
+
+	// SetReplicationSettings sets the role of the renter in the replication
+	// of its metadata.
+	SetReplicationSettings(settings ReplicationSettings) error
+
+	// MetadataMirrorStatus returns the status of the mirroring of the
+	// renter's metadata to its hosts.
+	MetadataMirrorStatus() (MetadataMirrorStatus, error)
+
+	// SetMetadataMirrorSettings enables or disables the mirroring of the
+	// renter's metadata to its hosts.
+	SetMetadataMirrorSettings(settings MetadataMirrorSettings) error
+
+	// RecoverMetadataMirror recovers the renter's siafiles from the metadata
+	// mirrored to its hosts.
+	RecoverMetadataMirror() (MetadataMirrorRecovery, error)
+
 	// Streamer creates a io.ReadSeeker that can be used to stream downloads
 	// from the Sia network and also returns the fileName of the streamed
 	// resource.
@@ -1202,6 +2596,10 @@ type Renter interface {
 	// reached and upload the data to the Sia network.
 	UploadStreamFromReader(up FileUploadParams, reader io.Reader) error
 
+	// UploadStreamResumeOffset returns the offset within the stream at which
+	// an interrupted upload stream to the siapath can be resumed.
+	UploadStreamResumeOffset(siaPath SiaPath) (uint64, error)
+
 	// CreateDir creates a directory for the renter
 	CreateDir(siaPath SiaPath, mode os.FileMode) error
 
@@ -1211,6 +2609,14 @@ type Renter interface {
 	// DirList lists the directories in a siadir
 	DirList(siaPath SiaPath) ([]DirectoryInfo, error)
 
+	// DirDefaults returns the defaults of a siadir as well as the effective
+	// defaults which include the defaults inherited from its parents.
+	DirDefaults(siaPath SiaPath) (defaults, effective DirDefaults, err error)
+
+	// SetDirDefaults sets the defaults of a siadir which are applied to new
+	// files created beneath it.
+	SetDirDefaults(siaPath SiaPath, defaults DirDefaults) error
+
 	// WorkerPoolStatus returns the current status of the Renter's worker pool
 	WorkerPoolStatus() (WorkerPoolStatus, error)
 
@@ -1250,6 +2656,7 @@ type RenterDownloadParameters struct {
 	SiaPath          SiaPath
 	Destination      string
 	DisableDiskFetch bool
+	Policy           DownloadPolicy
 }
 
 // HealthPercentage returns the health in a more human understandable format out
@@ -1311,6 +2718,10 @@ type HostDB interface {
 	// provided settings.
 	EstimateHostScore(HostDBEntry, Allowance) (HostScoreBreakdown, error)
 
+	// Export returns a dump of the hostdb's hosts, scans, filter and price
+	// index.
+	Export() (HostDBDump, error)
+
 	// Filter returns the hostdb's filterMode and filteredHosts
 	Filter() (FilterMode, map[string]types.SiaPublicKey, error)
 
@@ -1328,6 +2739,10 @@ type HostDB interface {
 	// a host for a given key
 	IncrementFailedInteractions(types.SiaPublicKey) error
 
+	// Import restores the hostdb from a dump. If merge is set, the scan
+	// histories of the dump are merged into the hostdb instead.
+	Import(dump HostDBDump, merge bool) error
+
 	// initialScanComplete returns a boolean indicating if the initial scan of the
 	// hostdb is completed.
 	InitialScanComplete() (bool, error)
@@ -1336,11 +2751,27 @@ type HostDB interface {
 	// enabled or not.
 	IPViolationsCheck() (bool, error)
 
+	// PriceIndex returns the historic price snapshots of the host network,
+	// sorted by block height.
+	PriceIndex() ([]HostDBPriceSnapshot, error)
+
+	// RecordBenchmark adds a benchmark sample of a host to its rolling
+	// benchmark statistics.
+	RecordBenchmark(types.SiaPublicKey, HostBenchmarkSample) error
+
 	// RandomHosts returns a set of random hosts, weighted by their estimated
 	// usefulness / attractiveness to the renter. RandomHosts will not return
 	// any offline or inactive hosts.
 	RandomHosts(int, []types.SiaPublicKey, []types.SiaPublicKey) ([]HostDBEntry, error)
 
+	// ScanHistory returns the detailed records of the recent scans of a host,
+	// sorted by time.
+	ScanHistory(pk types.SiaPublicKey) ([]HostDBScanRecord, error)
+
+	// SimulateAllowance replays the recorded settings of the hosts over the
+	// provided window against an allowance.
+	SimulateAllowance(a Allowance, window time.Duration) (AllowanceSimulation, error)
+
 	// RandomHostsWithAllowance is the same as RandomHosts but accepts an
 	// allowance as an argument to be used instead of the allowance set in the
 	// renter.
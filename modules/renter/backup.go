@@ -152,86 +152,93 @@ func (r *Renter) LoadBackup(src string, secret []byte) (err error) {
 	defer func() {
 		err = errors.Compose(err, f.Close())
 	}()
+	// Verify the backup and wrap it in a gzip reader.
+	gzr, err := openBackup(f, secret)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, gzr.Close())
+	}()
+	// Wrap the gzip reader in a tar reader.
+	tr := tar.NewReader(gzr)
+	// Untar the files.
+	if err := r.managedUntarDir(tr); err != nil {
+		return errors.AddContext(err, "failed to untar dir")
+	}
+	// Unmarshal the allowance if available. This needs to happen after adding
+	// decryption and confirming the hash but before adding decompression.
+	dec := json.NewDecoder(gzr)
+	var allowance modules.Allowance
+	if err := dec.Decode(&allowance); err != nil {
+		// legacy backup without allowance
+		r.log.Println("WARN: Decoding the backup's allowance failed: ", err)
+	}
+	// If the backup contained a valid allowance and we currently don't have an
+	// allowance set, import it.
+	if !reflect.DeepEqual(allowance, modules.Allowance{}) &&
+		reflect.DeepEqual(r.hostContractor.Allowance(), modules.Allowance{}) {
+		if err := r.hostContractor.SetAllowance(allowance); err != nil {
+			return errors.AddContext(err, "unable to set allowance from backup")
+		}
+	}
+	return nil
+}
+
+// openBackup verifies the checksum of a backup and returns a reader for its
+// decrypted and decompressed body.
+func openBackup(f *os.File, secret []byte) (*gzip.Reader, error) {
 	archive := io.Reader(f)
 
 	// Read the checksum.
 	var chks crypto.Hash
-	_, err = io.ReadFull(f, chks[:])
+	_, err := io.ReadFull(f, chks[:])
 	if err != nil {
-		return err
+		return nil, err
 	}
 	// Read the header.
 	dec := json.NewDecoder(archive)
 	var bh backupHeader
 	if err := dec.Decode(&bh); err != nil {
-		return err
+		return nil, err
 	}
 	// Check the version number.
 	if bh.Version != encryptionVersion {
-		return errors.New("unknown version")
+		return nil, errors.New("unknown version")
 	}
 	// Wrap the file in the correct streamcipher. Consider the data remaining in
 	// the decoder's buffer by using a multireader.
 	archive = io.MultiReader(dec.Buffered(), archive)
 	_, err = archive.Read(make([]byte, 1)) // Ignore first byte of buffer to get to the body of the backup
 	if err != nil {
-		return err
+		return nil, err
 	}
 	archive, err = wrapReaderInCipher(io.MultiReader(archive, f), bh, secret)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	// Pipe the remaining file into the hasher to verify that the hash is
 	// correct.
 	h := crypto.NewHash()
 	n, err := io.Copy(h, archive)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	// Verify the hash.
 	if !bytes.Equal(h.Sum(nil), chks[:]) {
-		return errors.New("checksum doesn't match")
+		return nil, errors.New("checksum doesn't match")
 	}
 	// Seek back to the beginning of the body.
 	if _, err := f.Seek(-n, io.SeekCurrent); err != nil {
-		return err
+		return nil, err
 	}
 	// Wrap the file again.
 	archive, err = wrapReaderInCipher(f, bh, secret)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	// Wrap the potentially encrypted reader in a gzip reader.
-	gzr, err := gzip.NewReader(archive)
-	if err != nil {
-		return err
-	}
-	defer func() {
-		err = errors.Compose(err, gzr.Close())
-	}()
-	// Wrap the gzip reader in a tar reader.
-	tr := tar.NewReader(gzr)
-	// Untar the files.
-	if err := r.managedUntarDir(tr); err != nil {
-		return errors.AddContext(err, "failed to untar dir")
-	}
-	// Unmarshal the allowance if available. This needs to happen after adding
-	// decryption and confirming the hash but before adding decompression.
-	dec = json.NewDecoder(gzr)
-	var allowance modules.Allowance
-	if err := dec.Decode(&allowance); err != nil {
-		// legacy backup without allowance
-		r.log.Println("WARN: Decoding the backup's allowance failed: ", err)
-	}
-	// If the backup contained a valid allowance and we currently don't have an
-	// allowance set, import it.
-	if !reflect.DeepEqual(allowance, modules.Allowance{}) &&
-		reflect.DeepEqual(r.hostContractor.Allowance(), modules.Allowance{}) {
-		if err := r.hostContractor.SetAllowance(allowance); err != nil {
-			return errors.AddContext(err, "unable to set allowance from backup")
-		}
-	}
-	return nil
+	return gzip.NewReader(archive)
 }
 
 // managedTarSiaFiles creates a tarball from the renter's siafiles and writes
@@ -0,0 +1,156 @@
+package renter
+
+import (
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+)
+
+var (
+	// errChaosFailure is the error returned for failures induced by the
+	// renter's chaos testing mode.
+	errChaosFailure = errors.New("failure induced by chaos testing mode")
+
+	// errChaosDroppedPriceTable is the error returned for price tables dropped
+	// by the renter's chaos testing mode.
+	errChaosDroppedPriceTable = errors.New("price table dropped by chaos testing mode")
+
+	// errChaosNotAllowed is returned when the user tries to enable the chaos
+	// testing mode without it being allowed.
+	errChaosNotAllowed = errors.New("chaos testing mode is only available in testing builds or if SIA_RENTER_CHAOS is set to true")
+)
+
+// chaosMode randomly injects failures and delays into the communication of the
+// renter with its hosts and keeps track of the induced failures as well as the
+// failures that were visible to the user.
+type chaosMode struct {
+	report modules.RenterChaosReport
+	mu     sync.Mutex
+}
+
+// newChaosMode creates a new chaosMode which is disabled.
+func newChaosMode() *chaosMode {
+	return &chaosMode{
+		report: modules.RenterChaosReport{
+			Since: time.Now(),
+		},
+	}
+}
+
+// roll returns true with the provided probability.
+func roll(probability float64) bool {
+	if probability <= 0 {
+		return false
+	}
+	return float64(fastrand.Uint64n(1<<53))/(1<<53) < probability
+}
+
+// managedReport returns the report of the chaos mode.
+func (cm *chaosMode) managedReport() modules.RenterChaosReport {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	return cm.report
+}
+
+// managedSetSettings updates the settings of the chaos mode and resets its
+// report.
+func (cm *chaosMode) managedSetSettings(settings modules.RenterChaosSettings) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.report = modules.RenterChaosReport{
+		Settings: settings,
+		Since:    time.Now(),
+	}
+}
+
+// managedInjectFault returns an induced failure or the duration of an induced
+// delay before opening a new stream to a host.
+func (cm *chaosMode) managedInjectFault() (time.Duration, error) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	settings := cm.report.Settings
+	if !settings.Enabled {
+		return 0, nil
+	}
+	if roll(settings.FailureProbability) {
+		cm.report.InducedFailures++
+		return 0, errChaosFailure
+	}
+	if settings.MaxDelay > 0 && roll(settings.DelayProbability) {
+		cm.report.InducedDelays++
+		return time.Duration(fastrand.Uint64n(uint64(settings.MaxDelay))) + 1, nil
+	}
+	return 0, nil
+}
+
+// managedDropPriceTable returns whether a price table received from a host
+// should be dropped.
+func (cm *chaosMode) managedDropPriceTable() bool {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	if !cm.report.Settings.Enabled || !roll(cm.report.Settings.DropPriceTableProbability) {
+		return false
+	}
+	cm.report.DroppedPriceTables++
+	return true
+}
+
+// managedRecordOperation records the result of a user visible operation while
+// the chaos mode is enabled.
+func (cm *chaosMode) managedRecordOperation(err error) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	if !cm.report.Settings.Enabled {
+		return
+	}
+	cm.report.UserOperations++
+	if err != nil {
+		cm.report.UserFailures++
+	}
+}
+
+// staticInjectChaos injects a failure or a delay into the creation of a new
+// stream to a host if the chaos mode is enabled.
+func (r *Renter) staticInjectChaos() error {
+	delay, err := r.staticChaos.managedInjectFault()
+	if err != nil || delay == 0 {
+		return err
+	}
+	select {
+	case <-r.tg.StopChan():
+		return errors.New("renter shut down")
+	case <-time.After(delay):
+	}
+	return nil
+}
+
+// ChaosReport returns the report of the renter's chaos testing mode.
+func (r *Renter) ChaosReport() (modules.RenterChaosReport, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.RenterChaosReport{}, err
+	}
+	defer r.tg.Done()
+	return r.staticChaos.managedReport(), nil
+}
+
+// SetChaosSettings configures the renter's chaos testing mode and resets its
+// report. The chaos testing mode is not persisted.
+func (r *Renter) SetChaosSettings(settings modules.RenterChaosSettings) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if settings.Enabled && !build.RenterChaosAllowed() {
+		return errChaosNotAllowed
+	}
+	if err := settings.Validate(); err != nil {
+		return errors.AddContext(err, "invalid chaos settings")
+	}
+	r.staticChaos.managedSetSettings(settings)
+	return nil
+}
@@ -0,0 +1,92 @@
+package renter
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+)
+
+// TestChaosMode is a unit test for the chaosMode.
+func TestChaosMode(t *testing.T) {
+	t.Parallel()
+
+	// A disabled chaos mode doesn't inject anything.
+	cm := newChaosMode()
+	cm.managedSetSettings(modules.RenterChaosSettings{
+		FailureProbability:        1,
+		DropPriceTableProbability: 1,
+	})
+	if delay, err := cm.managedInjectFault(); delay != 0 || err != nil {
+		t.Fatal("unexpected fault", delay, err)
+	}
+	if cm.managedDropPriceTable() {
+		t.Fatal("price table shouldn't be dropped")
+	}
+	cm.managedRecordOperation(errors.New("failure"))
+	if report := cm.managedReport(); report.UserOperations != 0 || report.UserFailures != 0 {
+		t.Fatal("unexpected report", report)
+	}
+
+	// Enable it with guaranteed failures.
+	cm.managedSetSettings(modules.RenterChaosSettings{
+		Enabled:                   true,
+		FailureProbability:        1,
+		DropPriceTableProbability: 1,
+	})
+	if _, err := cm.managedInjectFault(); !errors.Contains(err, errChaosFailure) {
+		t.Fatal("expected induced failure", err)
+	}
+	if !cm.managedDropPriceTable() {
+		t.Fatal("price table should be dropped")
+	}
+
+	// Switch to guaranteed delays.
+	cm.managedSetSettings(modules.RenterChaosSettings{
+		Enabled:          true,
+		DelayProbability: 1,
+		MaxDelay:         time.Second,
+	})
+	delay, err := cm.managedInjectFault()
+	if err != nil || delay <= 0 || delay > time.Second {
+		t.Fatal("unexpected delay", delay, err)
+	}
+	cm.managedRecordOperation(nil)
+	cm.managedRecordOperation(errors.New("failure"))
+	report := cm.managedReport()
+	if report.InducedDelays != 1 || report.InducedFailures != 0 || report.DroppedPriceTables != 0 {
+		t.Fatal("report should have been reset", report)
+	}
+	if report.UserOperations != 2 || report.UserFailures != 1 {
+		t.Fatal("unexpected report", report)
+	}
+}
+
+// TestRenterChaosSettingsValidate is a unit test for validating the chaos
+// settings.
+func TestRenterChaosSettingsValidate(t *testing.T) {
+	t.Parallel()
+
+	valid := []modules.RenterChaosSettings{
+		{},
+		{Enabled: true, FailureProbability: 1, DelayProbability: 0.5, MaxDelay: time.Second},
+	}
+	for _, s := range valid {
+		if err := s.Validate(); err != nil {
+			t.Error(err)
+		}
+	}
+	invalid := []modules.RenterChaosSettings{
+		{FailureProbability: 1.1},
+		{DelayProbability: -0.1},
+		{DropPriceTableProbability: 2},
+		{MaxDelay: -time.Second},
+	}
+	for _, s := range invalid {
+		if err := s.Validate(); err == nil {
+			t.Error("expected error", s)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package renter
+
+import (
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+)
+
+// threadedCompactSiaFiles periodically compacts the siafiles of the renter
+// which accumulated too much waste in their metadata.
+func (r *Renter) threadedCompactSiaFiles() {
+	defer modules.RecoverPanic("renter")
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	for {
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-time.After(siaFileCompactionInterval):
+		}
+		err := r.managedCompactSiaFiles()
+		if errors.Contains(err, errSiaFileWalkInterrupted) {
+			return
+		}
+		if err != nil {
+			r.log.Println("WARN: failed to compact siafiles:", err)
+		}
+	}
+}
+
+// managedCompactSiaFiles walks over all the siafiles of the renter, repairs
+// the metadata of corrupted chunks and compacts the files with a waste ratio
+// above siaFileCompactionThreshold.
+func (r *Renter) managedCompactSiaFiles() error {
+	return r.managedWalkSiaFiles(func(siaPath modules.SiaPath) {
+		err := r.managedCompactSiaFile(siaPath)
+		if err != nil {
+			r.log.Printf("WARN: failed to compact siafile %v: %v", siaPath, err)
+		}
+	})
+}
+
+// managedCompactSiaFile compacts the siafile at the given path if its waste
+// ratio exceeds siaFileCompactionThreshold. Corrupted chunks are repaired
+// first since they can't be compacted.
+func (r *Renter) managedCompactSiaFile(siaPath modules.SiaPath) (err error) {
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, entry.Close())
+	}()
+	if err := r.managedRepairCorruptChunks(entry); err != nil {
+		return errors.AddContext(err, "failed to repair corrupted chunks")
+	}
+	ratio, err := entry.WasteRatio()
+	if err != nil {
+		return errors.AddContext(err, "failed to compute waste ratio")
+	}
+	if ratio <= siaFileCompactionThreshold {
+		return nil
+	}
+	return entry.Compact()
+}
@@ -0,0 +1,94 @@
+package renter
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+)
+
+// TestCompactSiaFiles tests that managedCompactSiaFiles compacts siafiles with
+// a waste ratio above the threshold.
+func TestCompactSiaFiles(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Create a file.
+	siaPath, rsc := testingFileParamsCustom(1, 4)
+	entry, err := rt.renter.createRenterTestFileWithParams(siaPath, rsc, crypto.RandomCipherType())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Add pieces from enough hosts for the header of the file to grow beyond
+	// a single page.
+	var hosts []types.SiaPublicKey
+	for i := 0; i < 100; i++ {
+		hk := types.SiaPublicKey{
+			Algorithm: types.SignatureEd25519,
+			Key:       fastrand.Bytes(32),
+		}
+		hosts = append(hosts, hk)
+		err = entry.AddPiece(hk, 0, uint64(i%rsc.NumPieces()), crypto.Hash{})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Only keep 10 of the hosts. This prunes the table of the file but leaves
+	// a header which is larger than necessary.
+	err = entry.UpdateUsedHosts(hosts[:10])
+	if err != nil {
+		t.Fatal(err)
+	}
+	ratio, err := entry.WasteRatio()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ratio <= siaFileCompactionThreshold {
+		t.Fatal("file should exceed the compaction threshold", ratio)
+	}
+	if err := entry.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Compact the files.
+	err = rt.renter.managedCompactSiaFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The file should no longer contain waste.
+	entry, err = rt.renter.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := entry.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	ratio, err = entry.WasteRatio()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ratio != 0 {
+		t.Fatal("file should have been compacted", ratio)
+	}
+	if len(entry.HostPublicKeys()) != 10 {
+		t.Fatal("expected 10 hosts after compaction but got", len(entry.HostPublicKeys()))
+	}
+}
@@ -21,6 +21,66 @@ const (
 	// AlertSiafileLowRedundancyThreshold is the health threshold at which we start
 	// registering the LowRedundancy alert for a Siafile.
 	AlertSiafileLowRedundancyThreshold = 0.75
+	// AlertMSGSiafileUnrecoverable indicates that a file is below 1x
+	// redundancy and can't be recovered from the network.
+	AlertMSGSiafileUnrecoverable = "The SiaFile mentioned in the 'Cause' is below 1x redundancy and can't be recovered from the network"
+	// AlertSiafileUnrecoverableThreshold is the health above which the
+	// LowRedundancy alert of a Siafile becomes critical. A health above 1
+	// means that at least one chunk has less than the minimum number of
+	// pieces required to recover it.
+	AlertSiafileUnrecoverableThreshold = 1
+)
+
+const (
+	// AlertMSGRenterReadOnlyMode indicates that the renter is in read-only
+	// mode.
+	AlertMSGRenterReadOnlyMode = "The renter is in read-only mode. Uploads, repairs and contract renewals are suspended until the cause is resolved"
+
+	// readOnlyReasonManual is the reason reported for read-only mode when it
+	// was enabled through the API.
+	readOnlyReasonManual = "read-only mode was enabled manually"
+
+	// readOnlyReasonReplica is the reason reported for read-only mode when the
+	// renter is a secondary replica.
+	readOnlyReasonReplica = "renter is a secondary replica"
+)
+
+const (
+	// AlertMSGSiafileUploadVerification indicates that the uploaded data of a
+	// file doesn't match its local copy.
+	AlertMSGSiafileUploadVerification = "The uploaded data of the SiaFile mentioned in the 'Cause' doesn't match its local copy"
+
+	// uploadVerificationSamples is the number of ranges of a file which are
+	// downloaded and compared against the local copy to verify an upload.
+	uploadVerificationSamples = 3
+
+	// uploadVerificationSampleSize is the max length of a single range which
+	// is compared when verifying an upload.
+	uploadVerificationSampleSize = 1 << 16 // 64 KiB
+)
+
+const (
+	// metadataMirrorRebaseInterval is the number of blobs after which the
+	// renter mirrors all of its siafiles again. It bounds the number of blobs
+	// which need to be downloaded to recover the metadata.
+	metadataMirrorRebaseInterval = 100
+)
+
+const (
+	// downloadPrefetchChunks is the number of chunks following a downloaded
+	// chunk whose pieces the hosts are asked to prefetch.
+	downloadPrefetchChunks = 2
+
+	// minSectorPrefetchVersion is the minimum version a host needs to support
+	// prefetch hints in ReadSector instructions.
+	minSectorPrefetchVersion = "1.5.10"
+)
+
+const (
+	// holeWriteSegments is the number of segments per data piece which are
+	// recovered at once when writing the zeroes of a hole to a download
+	// destination.
+	holeWriteSegments = 1 << 10
 )
 
 // AlertCauseSiafileLowRedundancy creates a customized "cause" for a siafile
@@ -29,6 +89,12 @@ func AlertCauseSiafileLowRedundancy(siaPath modules.SiaPath, health, redundancy
 	return fmt.Sprintf("Siafile '%v' has a health of %v and redundancy of %v", siaPath.String(), health, redundancy)
 }
 
+// AlertCauseSiafileUploadVerification creates a customized "cause" for a
+// siafile whose uploaded data doesn't match its local copy.
+func AlertCauseSiafileUploadVerification(siaPath modules.SiaPath, localPath string, offset int64) string {
+	return fmt.Sprintf("Siafile '%v' doesn't match its local copy '%v' at offset %v", siaPath.String(), localPath, offset)
+}
+
 // Default redundancy parameters.
 var (
 	// syncCheckInterval is how often the repair heap checks the consensus code
@@ -41,6 +107,23 @@ var (
 		Testing:  time.Second,
 	}).(time.Duration)
 
+	// readOnlyModeCheckInterval is how often the renter checks its funds
+	// against the read-only mode thresholds.
+	readOnlyModeCheckInterval = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: time.Minute * 10,
+		Testing:  time.Second * 3,
+	}).(time.Duration)
+
+	// defaultReplicationInterval is how often a primary renter exports its
+	// metadata and how often a secondary renter imports it if the user didn't
+	// specify an interval.
+	defaultReplicationInterval = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: time.Minute * 10,
+		Testing:  time.Second * 2,
+	}).(time.Duration)
+
 	// cachedUtilitiesUpdateInterval is how often the renter updates the
 	// cachedUtilities.
 	cachedUtilitiesUpdateInterval = build.Select(build.Var{
@@ -48,6 +131,126 @@ var (
 		Standard: time.Minute * 10,
 		Testing:  time.Second * 3,
 	}).(time.Duration)
+
+	// siaFileCompactionInterval is how often the renter checks its siafiles
+	// for waste and compacts them if necessary.
+	siaFileCompactionInterval = build.Select(build.Var{
+		Dev:      time.Minute * 10,
+		Standard: time.Hour * 24,
+		Testing:  time.Second * 5,
+	}).(time.Duration)
+
+	// repairThroughputWindow is the window of recently repaired chunks which
+	// is used to estimate the repair throughput of the renter.
+	repairThroughputWindow = build.Select(build.Var{
+		Dev:      time.Minute * 2,
+		Standard: time.Minute * 10,
+		Testing:  time.Second * 10,
+	}).(time.Duration)
+
+	// fileExpiryCheckInterval is how often the renter checks its siafiles for
+	// files which expired.
+	fileExpiryCheckInterval = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: time.Minute * 10,
+		Testing:  time.Second * 3,
+	}).(time.Duration)
+
+	// defaultPinnedBytesBudget is the default for the max number of bytes the
+	// renter keeps in memory for pinned ranges of files.
+	defaultPinnedBytesBudget = build.Select(build.Var{
+		Dev:      uint64(1 << 26), // 64 MiB
+		Standard: uint64(1 << 28), // 256 MiB
+		Testing:  uint64(1 << 20), // 1 MiB
+	}).(uint64)
+
+	// pinnedCacheLoadInterval is how often the renter checks whether the
+	// pinned ranges of files need to be loaded into memory.
+	pinnedCacheLoadInterval = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: time.Minute * 10,
+		Testing:  time.Second * 3,
+	}).(time.Duration)
+
+	// fileSpendingSaveInterval is how often the renter saves its spending per
+	// file to disk.
+	fileSpendingSaveInterval = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: time.Minute * 5,
+		Testing:  time.Second * 3,
+	}).(time.Duration)
+
+	// searchIndexRebuildInterval is how often the renter rebuilds its search
+	// index from the siafiles on disk.
+	searchIndexRebuildInterval = build.Select(build.Var{
+		Dev:      time.Minute * 10,
+		Standard: time.Hour * 6,
+		Testing:  time.Second * 5,
+	}).(time.Duration)
+
+	// uploadVerificationInterval is how often the renter checks for fully
+	// redundant files which need to be verified against their local copy.
+	uploadVerificationInterval = build.Select(build.Var{
+		Dev:      time.Minute * 5,
+		Standard: time.Hour,
+		Testing:  time.Second * 3,
+	}).(time.Duration)
+
+	// schedulerMaxSleep is the maximum amount of time the scheduler waits
+	// before waking up the repair loop again, even if none of its windows
+	// started.
+	schedulerMaxSleep = build.Select(build.Var{
+		Dev:      time.Minute * 10,
+		Standard: time.Hour,
+		Testing:  time.Second * 10,
+	}).(time.Duration)
+
+	// defaultMetadataMirrorInterval is how often the renter mirrors its
+	// metadata to its hosts if the user didn't specify an interval.
+	defaultMetadataMirrorInterval = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: time.Minute * 10,
+		Testing:  time.Second * 2,
+	}).(time.Duration)
+
+	// metadataMirrorRegistryTimeout is how long the renter waits for the
+	// registry entries of the metadata mirror to be updated or looked up.
+	metadataMirrorRegistryTimeout = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: time.Minute,
+		Testing:  time.Second * 10,
+	}).(time.Duration)
+
+	// metadataMirrorDataPieces and metadataMirrorParityPieces are the erasure
+	// coding parameters of the blobs mirrored to the hosts. Every piece is
+	// stored on a different host.
+	metadataMirrorDataPieces = build.Select(build.Var{
+		Dev:      1,
+		Standard: 2,
+		Testing:  1,
+	}).(int)
+	metadataMirrorParityPieces = build.Select(build.Var{
+		Dev:      2,
+		Standard: 4,
+		Testing:  2,
+	}).(int)
+
+	// corruptChunkVerificationTimeout is how long the renter waits for hosts
+	// to confirm the pieces of a corrupted chunk before repairing its
+	// metadata with the pieces confirmed so far.
+	corruptChunkVerificationTimeout = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: time.Minute * 5,
+		Testing:  time.Second * 10,
+	}).(time.Duration)
+
+	// numSiaFileWALs is the number of writeaheadlogs the updates of the
+	// SiaFiles are sharded across.
+	numSiaFileWALs = build.Select(build.Var{
+		Dev:      4,
+		Standard: 16,
+		Testing:  4,
+	}).(int)
 )
 
 // Default memory usage parameters.
@@ -193,14 +396,6 @@ const (
 )
 
 var (
-	// healthCheckInterval defines the maximum amount of time that should pass
-	// in between checking the health of a file or directory.
-	healthCheckInterval = build.Select(build.Var{
-		Dev:      15 * time.Minute,
-		Standard: 1 * time.Hour,
-		Testing:  5 * time.Second,
-	}).(time.Duration)
-
 	// healthLoopErrorSleepDuration indicates how long the health loop should
 	// sleep before retrying if there is an error preventing progress.
 	healthLoopErrorSleepDuration = build.Select(build.Var{
@@ -365,6 +560,10 @@ const (
 	// PriceEstimationSafetyFactor is the factor of safety used in the price
 	// estimation to account for any missed costs
 	PriceEstimationSafetyFactor = 1.2
+
+	// siaFileCompactionThreshold is the waste ratio above which a siafile is
+	// compacted by the compaction loop.
+	siaFileCompactionThreshold = 0.25
 )
 
 // Deprecated consts.
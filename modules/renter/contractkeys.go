@@ -0,0 +1,104 @@
+package renter
+
+import (
+	mnemonics "gitlab.com/NebulousLabs/entropy-mnemonics"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// contractKeyStoreKeys returns the keys which might be derived from the
+// provided password. Just like the wallet, the contract key store accepts
+// either the wallet password or the seed of a wallet that is encrypted with
+// its seed.
+func contractKeyStoreKeys(password string) (keys []crypto.CipherKey) {
+	dicts := []mnemonics.DictionaryID{"english", "german", "japanese"}
+	for _, dict := range dicts {
+		seed, err := modules.StringToSeed(password, dict)
+		if err != nil {
+			continue
+		}
+		keys = append(keys, crypto.NewWalletKey(crypto.HashObject(seed)))
+	}
+	return append(keys, crypto.NewWalletKey(crypto.HashObject(password)))
+}
+
+// ChangeContractKeyStorePassword re-encrypts the contract key store with a new
+// password.
+func (r *Renter) ChangeContractKeyStorePassword(password, newPassword string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if newPassword == "" {
+		return errors.New("the new password can't be empty")
+	}
+	newKey := crypto.NewWalletKey(crypto.HashObject(newPassword))
+	var err error
+	for _, key := range contractKeyStoreKeys(password) {
+		changeErr := r.hostContractor.ChangeContractKeyStoreKey(key, newKey)
+		if changeErr == nil {
+			return nil
+		}
+		err = errors.Compose(err, changeErr)
+	}
+	return err
+}
+
+// ContractKeyStoreStatus returns information about the contract key store.
+func (r *Renter) ContractKeyStoreStatus() modules.ContractKeyStoreStatus {
+	return r.hostContractor.ContractKeyStoreStatus()
+}
+
+// UnlockContractKeyStore unlocks the contract key store with the wallet
+// password. If the store doesn't exist yet, it is created and the secret keys
+// of all contracts are moved into it.
+func (r *Renter) UnlockContractKeyStore(password string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	keys := contractKeyStoreKeys(password)
+
+	// A new store is only created with the wallet's key to make sure the user
+	// doesn't lock themselves out with a typo.
+	if !r.hostContractor.ContractKeyStoreStatus().Initialized {
+		for _, key := range keys {
+			isMaster, err := r.w.IsMasterKey(key)
+			if err != nil {
+				return errors.AddContext(err, "failed to verify wallet password")
+			}
+			if !isMaster {
+				continue
+			}
+			if err := r.hostContractor.UnlockContractKeyStore(key); err != nil {
+				return err
+			}
+			r.managedResetWorkerMaintenance()
+			return nil
+		}
+		return modules.ErrBadEncryptionKey
+	}
+	var err error
+	for _, key := range keys {
+		unlockErr := r.hostContractor.UnlockContractKeyStore(key)
+		if unlockErr == nil {
+			r.managedResetWorkerMaintenance()
+			return nil
+		}
+		err = errors.Compose(err, unlockErr)
+	}
+	return err
+}
+
+// managedResetWorkerMaintenance takes all workers off their maintenance
+// cooldown and schedules a price table update. Workers might have been put on
+// cooldown for failing to pay with their contract while the contract key store
+// was locked.
+func (r *Renter) managedResetWorkerMaintenance() {
+	for _, w := range r.staticWorkerPool.callWorkers() {
+		w.managedResetMaintenanceCooldown()
+		w.staticSchedulePriceTableUpdate(false)
+	}
+}
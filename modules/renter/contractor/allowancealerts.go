@@ -0,0 +1,104 @@
+package contractor
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// errInvalidSpentThreshold is returned if the spent threshold of the
+// allowance alerts is not within [0, 1].
+var errInvalidSpentThreshold = errors.New("spent threshold must be between 0 and 1")
+
+// AllowanceAlertSettings returns the rules used to alert the user about the
+// spending of the allowance.
+func (c *Contractor) AllowanceAlertSettings() modules.AllowanceAlertSettings {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.allowanceAlerts
+}
+
+// SetAllowanceAlertSettings updates the rules used to alert the user about
+// the spending of the allowance. The rules are evaluated right away.
+func (c *Contractor) SetAllowanceAlertSettings(settings modules.AllowanceAlertSettings) error {
+	if err := c.tg.Add(); err != nil {
+		return err
+	}
+	defer c.tg.Done()
+	if math.IsNaN(settings.SpentThreshold) || settings.SpentThreshold < 0 || settings.SpentThreshold > 1 {
+		return errInvalidSpentThreshold
+	}
+
+	c.mu.Lock()
+	c.allowanceAlerts = settings
+	err := c.save()
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	go c.threadedCheckAllowanceAlerts()
+	return nil
+}
+
+// threadedCheckAllowanceAlerts evaluates the allowance alert rules against the
+// spending forecast of the current period and registers or unregisters the
+// corresponding alerts.
+func (c *Contractor) threadedCheckAllowanceAlerts() {
+	defer modules.RecoverPanic("contractor")
+	if err := c.tg.Add(); err != nil {
+		return
+	}
+	defer c.tg.Done()
+	forecast, err := c.SpendingForecast()
+	if err != nil {
+		c.log.Println("WARN: failed to get spending forecast for allowance alerts:", err)
+		return
+	}
+	c.mu.RLock()
+	settings := c.allowanceAlerts
+	c.mu.RUnlock()
+
+	spentCause, renewalCause := checkAllowanceAlerts(settings, forecast)
+	if spentCause != "" {
+		c.staticAlerter.RegisterAlert(modules.AlertIDRenterAllowanceSpent, AlertMSGAllowanceSpent, spentCause, modules.SeverityWarning)
+	} else {
+		c.staticAlerter.UnregisterAlert(modules.AlertIDRenterAllowanceSpent)
+	}
+	if renewalCause != "" {
+		c.staticAlerter.RegisterAlert(modules.AlertIDRenterRenewalExceedsFunds, AlertMSGRenewalExceedsFunds, renewalCause, modules.SeverityWarning)
+	} else {
+		c.staticAlerter.UnregisterAlert(modules.AlertIDRenterRenewalExceedsFunds)
+	}
+}
+
+// checkAllowanceAlerts evaluates the allowance alert rules against a spending
+// forecast. It returns the causes of the alerts that should be registered. An
+// empty cause means that the corresponding alert should be unregistered.
+func checkAllowanceAlerts(settings modules.AllowanceAlertSettings, forecast modules.SpendingForecast) (spentCause, renewalCause string) {
+	// Without an allowance there is nothing to spend.
+	if forecast.Allowance.IsZero() {
+		return "", ""
+	}
+	remaining := types.ZeroCurrency
+	if forecast.Allowance.Cmp(forecast.Spent) > 0 {
+		remaining = forecast.Allowance.Sub(forecast.Spent)
+	}
+
+	// Check the spent threshold.
+	if settings.SpentThreshold > 0 {
+		spentRatio, _ := new(big.Rat).SetFrac(forecast.Spent.Big(), forecast.Allowance.Big()).Float64()
+		if spentRatio >= settings.SpentThreshold {
+			spentCause = fmt.Sprintf("%v of the allowance of %v were spent which exceeds the threshold of %.0f%%", forecast.Spent.HumanString(), forecast.Allowance.HumanString(), settings.SpentThreshold*100)
+		}
+	}
+
+	// Check the renewal estimate.
+	if settings.RenewalExceedsFunds && forecast.ProjectedRenewalSpending.Cmp(remaining) > 0 {
+		renewalCause = fmt.Sprintf("renewing the contracts is estimated to cost %v but only %v of the allowance remain", forecast.ProjectedRenewalSpending.HumanString(), remaining.HumanString())
+	}
+	return
+}
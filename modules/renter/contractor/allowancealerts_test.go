@@ -0,0 +1,55 @@
+package contractor
+
+import (
+	"testing"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestCheckAllowanceAlerts is a unit test for checkAllowanceAlerts.
+func TestCheckAllowanceAlerts(t *testing.T) {
+	t.Parallel()
+
+	sc := types.SiacoinPrecision
+	forecast := func(allowance, spent, renewal uint64) modules.SpendingForecast {
+		return modules.SpendingForecast{
+			Allowance:                sc.Mul64(allowance),
+			Spent:                    sc.Mul64(spent),
+			ProjectedRenewalSpending: sc.Mul64(renewal),
+		}
+	}
+	allRules := modules.AllowanceAlertSettings{
+		SpentThreshold:      0.8,
+		RenewalExceedsFunds: true,
+	}
+
+	tests := []struct {
+		settings modules.AllowanceAlertSettings
+		forecast modules.SpendingForecast
+		spent    bool
+		renewal  bool
+	}{
+		// Disabled rules never trigger.
+		{modules.AllowanceAlertSettings{}, forecast(100, 100, 100), false, false},
+		// No allowance.
+		{allRules, forecast(0, 10, 10), false, false},
+		// Below the threshold and enough funds for the renewal.
+		{allRules, forecast(100, 79, 21), false, false},
+		// Threshold reached.
+		{allRules, forecast(100, 80, 0), true, false},
+		// Renewal exceeds the remaining funds.
+		{allRules, forecast(100, 50, 51), false, true},
+		// Overspent allowance.
+		{allRules, forecast(100, 110, 1), true, true},
+	}
+	for i, test := range tests {
+		spentCause, renewalCause := checkAllowanceAlerts(test.settings, test.forecast)
+		if (spentCause != "") != test.spent {
+			t.Errorf("%v: expected spent alert %v but got cause '%v'", i, test.spent, spentCause)
+		}
+		if (renewalCause != "") != test.renewal {
+			t.Errorf("%v: expected renewal alert %v but got cause '%v'", i, test.renewal, renewalCause)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package contractor
 
 import (
+	"fmt"
 	"sort"
 	"sync"
+	"time"
 
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
@@ -18,6 +20,10 @@ type contractScoreAndUtil struct {
 	util     modules.ContractUtility
 }
 
+// errContractNotDeferred is returned when approving the migration of a
+// contract which isn't deferred in the migration plan.
+var errContractNotDeferred = errors.New("contract is not deferred in the migration plan")
+
 // churnLimiter keeps track of the aggregate number of bytes stored in contracts
 // marked !GFR (AKA churned contracts) in the current period.
 type churnLimiter struct {
@@ -30,14 +36,26 @@ type churnLimiter struct {
 	// churned in the current period.
 	aggregateCurrentPeriodChurn uint64
 
+	// currentPeriodChurnedContracts is the number of non-empty contracts
+	// churned in the current period.
+	currentPeriodChurnedContracts uint64
+
+	// migrationPlan contains the contracts which were queued for churn during
+	// the last contract maintenance. approvedMigrations are the deferred
+	// contracts the user approved to be churned regardless of the budget.
+	migrationPlan      modules.ContractorMigrationPlan
+	approvedMigrations map[types.FileContractID]struct{}
+
 	mu         sync.Mutex
 	contractor *Contractor
 }
 
 // churnLimiterPersist is the persisted state of a churnLimiter.
 type churnLimiterPersist struct {
-	AggregateCurrentPeriodChurn uint64 `json:"aggregatecurrentperiodchurn"`
-	RemainingChurnBudget        int    `json:"remainingchurnbudget"`
+	AggregateCurrentPeriodChurn   uint64                 `json:"aggregatecurrentperiodchurn"`
+	RemainingChurnBudget          int                    `json:"remainingchurnbudget"`
+	CurrentPeriodChurnedContracts uint64                 `json:"currentperiodchurnedcontracts"`
+	ApprovedMigrations            []types.FileContractID `json:"approvedmigrations"`
 }
 
 // managedMaxPeriodChurn returns the MaxPeriodChurn of the churnLimiter.
@@ -45,36 +63,108 @@ func (cl *churnLimiter) managedMaxPeriodChurn() uint64 {
 	return cl.contractor.Allowance().MaxPeriodChurn
 }
 
+// managedMaxPeriodChurnContracts returns the MaxPeriodChurnContracts of the
+// churnLimiter.
+func (cl *churnLimiter) managedMaxPeriodChurnContracts() uint64 {
+	return cl.contractor.Allowance().MaxPeriodChurnContracts
+}
+
 // callPersistData returns the churnLimiterPersist corresponding to this
 // churnLimiter's state
 func (cl *churnLimiter) callPersistData() churnLimiterPersist {
 	cl.mu.Lock()
 	defer cl.mu.Unlock()
-	return churnLimiterPersist{cl.aggregateCurrentPeriodChurn, cl.remainingChurnBudget}
+	approved := make([]types.FileContractID, 0, len(cl.approvedMigrations))
+	for id := range cl.approvedMigrations {
+		approved = append(approved, id)
+	}
+	return churnLimiterPersist{
+		AggregateCurrentPeriodChurn:   cl.aggregateCurrentPeriodChurn,
+		RemainingChurnBudget:          cl.remainingChurnBudget,
+		CurrentPeriodChurnedContracts: cl.currentPeriodChurnedContracts,
+		ApprovedMigrations:            approved,
+	}
 }
 
 // newChurnLimiterFromPersist creates a new churnLimiter using persisted state.
 func newChurnLimiterFromPersist(contractor *Contractor, persistData churnLimiterPersist) *churnLimiter {
-	return &churnLimiter{
-		contractor:                  contractor,
-		aggregateCurrentPeriodChurn: persistData.AggregateCurrentPeriodChurn,
-		remainingChurnBudget:        persistData.RemainingChurnBudget,
+	cl := &churnLimiter{
+		contractor:                    contractor,
+		aggregateCurrentPeriodChurn:   persistData.AggregateCurrentPeriodChurn,
+		remainingChurnBudget:          persistData.RemainingChurnBudget,
+		currentPeriodChurnedContracts: persistData.CurrentPeriodChurnedContracts,
+		approvedMigrations:            make(map[types.FileContractID]struct{}),
 	}
+	for _, id := range persistData.ApprovedMigrations {
+		cl.approvedMigrations[id] = struct{}{}
+	}
+	return cl
 }
 
 // newChurnLimiter returns a new churnLimiter.
 func newChurnLimiter(contractor *Contractor) *churnLimiter {
-	return &churnLimiter{contractor: contractor}
+	return &churnLimiter{
+		contractor:         contractor,
+		approvedMigrations: make(map[types.FileContractID]struct{}),
+	}
 }
 
 // ChurnStatus returns the current period's aggregate churn and the max churn
 // per period.
 func (c *Contractor) ChurnStatus() modules.ContractorChurnStatus {
 	aggregateChurn, maxChurn := c.staticChurnLimiter.managedAggregateAndMaxChurn()
+	churnedContracts, maxChurnContracts := c.staticChurnLimiter.managedChurnedAndMaxContracts()
 	return modules.ContractorChurnStatus{
-		AggregateCurrentPeriodChurn: aggregateChurn,
-		MaxPeriodChurn:              maxChurn,
+		AggregateCurrentPeriodChurn:   aggregateChurn,
+		MaxPeriodChurn:                maxChurn,
+		CurrentPeriodChurnedContracts: churnedContracts,
+		MaxPeriodChurnContracts:       maxChurnContracts,
+	}
+}
+
+// MigrationPlan returns the contracts which were queued for churn during the
+// last contract maintenance.
+func (c *Contractor) MigrationPlan() modules.ContractorMigrationPlan {
+	cl := c.staticChurnLimiter
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	plan := modules.ContractorMigrationPlan{
+		Timestamp:  cl.migrationPlan.Timestamp,
+		Migrations: make([]modules.ContractMigration, 0, len(cl.migrationPlan.Migrations)),
+	}
+	for _, m := range cl.migrationPlan.Migrations {
+		_, m.Approved = cl.approvedMigrations[m.ContractID]
+		plan.Migrations = append(plan.Migrations, m)
+	}
+	return plan
+}
+
+// ApproveMigrations approves churning the provided contracts, which need to be
+// deferred in the current migration plan, during the next contract maintenance
+// regardless of the churn budget.
+func (c *Contractor) ApproveMigrations(ids []types.FileContractID) error {
+	cl := c.staticChurnLimiter
+	cl.mu.Lock()
+	deferred := make(map[types.FileContractID]struct{})
+	for _, m := range cl.migrationPlan.Migrations {
+		if m.Status == modules.ContractMigrationStatusDeferred {
+			deferred[m.ContractID] = struct{}{}
+		}
+	}
+	for _, id := range ids {
+		if _, exists := deferred[id]; !exists {
+			cl.mu.Unlock()
+			return errors.AddContext(errContractNotDeferred, id.String())
+		}
+	}
+	for _, id := range ids {
+		cl.approvedMigrations[id] = struct{}{}
 	}
+	cl.mu.Unlock()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.save()
 }
 
 // callResetAggregateChurn resets the aggregate churn for this period. This
@@ -82,7 +172,9 @@ func (c *Contractor) ChurnStatus() modules.ContractorChurnStatus {
 func (cl *churnLimiter) callResetAggregateChurn() {
 	cl.mu.Lock()
 	cl.contractor.log.Println("Aggregate Churn for last period: ", cl.aggregateCurrentPeriodChurn)
+	cl.contractor.log.Println("Churned contracts for last period: ", cl.currentPeriodChurnedContracts)
 	cl.aggregateCurrentPeriodChurn = 0
+	cl.currentPeriodChurnedContracts = 0
 	cl.mu.Unlock()
 }
 
@@ -100,6 +192,7 @@ func (cl *churnLimiter) callNotifyChurnedContract(contract modules.RenterContrac
 
 	cl.aggregateCurrentPeriodChurn += size
 	cl.remainingChurnBudget -= int(size)
+	cl.currentPeriodChurnedContracts++
 	cl.contractor.log.Debugf("Increasing aggregate churn by %d to %d (MaxPeriodChurn: %d)", size, cl.aggregateCurrentPeriodChurn, maxPeriodChurn)
 	cl.contractor.log.Debugf("Remaining churn budget: %d", cl.remainingChurnBudget)
 }
@@ -143,15 +236,21 @@ func (cl *churnLimiter) managedProcessSuggestedUpdates(queue []contractScoreAndU
 		return queue[i].score.Cmp(queue[j].score) < 0
 	})
 
+	plan := modules.ContractorMigrationPlan{
+		Timestamp:  time.Now(),
+		Migrations: make([]modules.ContractMigration, 0, len(queue)),
+	}
 	var queuedContract contractScoreAndUtil
 	for len(queue) > 0 {
 		queuedContract, queue = queue[0], queue[1:]
 
 		// Churn a contract if it went from GFR in the previous util
 		// (queuedContract.contract.Utility) to !GFR in the suggested util
-		// (queuedContract.util) and the churnLimit has not been reached.
+		// (queuedContract.util) and either the churnLimit has not been
+		// reached or the user approved the churn.
 		turnedNotGFR := queuedContract.contract.Utility.GoodForRenew && !queuedContract.util.GoodForRenew
-		churningThisContract := turnedNotGFR && cl.managedCanChurnContract(queuedContract.contract)
+		approved := cl.managedMigrationApproved(queuedContract.contract.ID)
+		churningThisContract := turnedNotGFR && (approved || cl.managedCanChurnContract(queuedContract.contract))
 		if turnedNotGFR && !churningThisContract {
 			cl.contractor.log.Debugln("Avoiding churn on contract: ", queuedContract.contract.ID)
 			currentBudget, periodBudget := cl.managedChurnBudget()
@@ -163,15 +262,57 @@ func (cl *churnLimiter) managedProcessSuggestedUpdates(queue []contractScoreAndU
 			cl.contractor.log.Println("Churning contract for bad score: ", queuedContract.contract.ID, queuedContract.score)
 		}
 
+		// Add the contract to the migration plan.
+		if turnedNotGFR {
+			status := modules.ContractMigrationStatusDeferred
+			if churningThisContract {
+				status = modules.ContractMigrationStatusChurned
+			}
+			plan.Migrations = append(plan.Migrations, modules.ContractMigration{
+				ContractID:    queuedContract.contract.ID,
+				HostPublicKey: queuedContract.contract.HostPublicKey,
+				Size:          queuedContract.contract.Transaction.FileContractRevisions[0].NewFileSize,
+				Score:         queuedContract.score,
+				Reason:        fmt.Sprintf("host score %v is below the minimum score for renewal", queuedContract.score),
+				Status:        status,
+				Approved:      approved,
+			})
+		}
+
 		// Apply changes.
 		err := cl.contractor.managedAcquireAndUpdateContractUtility(queuedContract.contract.ID, queuedContract.util)
 		if err != nil {
 			return err
 		}
 	}
+	cl.managedUpdateMigrationPlan(plan)
 	return nil
 }
 
+// managedMigrationApproved returns true if the user approved churning the
+// contract.
+func (cl *churnLimiter) managedMigrationApproved(id types.FileContractID) bool {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	_, approved := cl.approvedMigrations[id]
+	return approved
+}
+
+// managedUpdateMigrationPlan replaces the migration plan. Approvals of
+// contracts which are no longer deferred are dropped.
+func (cl *churnLimiter) managedUpdateMigrationPlan(plan modules.ContractorMigrationPlan) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	cl.migrationPlan = plan
+	approved := make(map[types.FileContractID]struct{})
+	for _, m := range plan.Migrations {
+		if _, exists := cl.approvedMigrations[m.ContractID]; exists && m.Status == modules.ContractMigrationStatusDeferred {
+			approved[m.ContractID] = struct{}{}
+		}
+	}
+	cl.approvedMigrations = approved
+}
+
 // managedChurnBudget returns the current remaining churn budget, and the remaining
 // budget for the period.
 func (cl *churnLimiter) managedChurnBudget() (int, int) {
@@ -181,6 +322,16 @@ func (cl *churnLimiter) managedChurnBudget() (int, int) {
 	return cl.remainingChurnBudget, int(maxPeriodChurn) - int(cl.aggregateCurrentPeriodChurn)
 }
 
+// managedChurnedAndMaxContracts returns the number of contracts churned in the
+// current period, and the maximum number of contracts allowed to be churned
+// per period.
+func (cl *churnLimiter) managedChurnedAndMaxContracts() (uint64, uint64) {
+	maxPeriodChurnContracts := cl.managedMaxPeriodChurnContracts()
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	return cl.currentPeriodChurnedContracts, maxPeriodChurnContracts
+}
+
 // managedAggregateAndMaxChurn returns the aggregate churn for the current period,
 // and the maximum churn allowed per period.
 func (cl *churnLimiter) managedAggregateAndMaxChurn() (uint64, uint64) {
@@ -195,10 +346,17 @@ func (cl *churnLimiter) managedAggregateAndMaxChurn() (uint64, uint64) {
 func (cl *churnLimiter) managedCanChurnContract(contract modules.RenterContract) bool {
 	size := contract.Transaction.FileContractRevisions[0].NewFileSize
 	maxPeriodChurn := cl.managedMaxPeriodChurn()
+	maxPeriodChurnContracts := cl.managedMaxPeriodChurnContracts()
 	maxChurnBudget := cl.managedMaxChurnBudget()
 	cl.mu.Lock()
 	defer cl.mu.Unlock()
 
+	// Don't churn more contracts than allowed per period. Empty contracts
+	// don't count towards the limit.
+	if size > 0 && maxPeriodChurnContracts > 0 && cl.currentPeriodChurnedContracts >= maxPeriodChurnContracts {
+		return false
+	}
+
 	// Allow any size contract to be churned if the current budget is the max
 	// budget. This allows large contracts to be churned if there is enough budget
 	// remaining for the period, even if the contract is larger than the
@@ -1,9 +1,15 @@
 package contractor
 
 import (
+	"io/ioutil"
+	"os"
 	"testing"
 
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
 	"go.sia.tech/siad/types"
 )
 
@@ -81,3 +87,112 @@ func TestCanChurnContract(t *testing.T) {
 		t.Fatal("Expected not to be able to churn contract")
 	}
 }
+
+// TestCanChurnContractLimit tests that managedCanChurnContract respects the
+// max number of churned contracts per period.
+func TestCanChurnContractLimit(t *testing.T) {
+	allowance := modules.DefaultAllowance
+	allowance.MaxPeriodChurn = 1000
+	allowance.MaxPeriodChurnContracts = 2
+	cl := newChurnLimiter(&Contractor{
+		allowance: allowance,
+	})
+	cl.remainingChurnBudget = 500
+
+	// Test: below the contract limit.
+	cl.currentPeriodChurnedContracts = 1
+	if !cl.managedCanChurnContract(contractWithSize(100)) {
+		t.Fatal("Expected to be able to churn contract")
+	}
+
+	// Test: contract limit reached.
+	cl.currentPeriodChurnedContracts = 2
+	if cl.managedCanChurnContract(contractWithSize(100)) {
+		t.Fatal("Expected not to be able to churn contract")
+	}
+
+	// Test: empty contracts don't count towards the limit.
+	if !cl.managedCanChurnContract(contractWithSize(0)) {
+		t.Fatal("Expected to be able to churn contract")
+	}
+
+	// Test: no limit.
+	cl.contractor.allowance.MaxPeriodChurnContracts = 0
+	if !cl.managedCanChurnContract(contractWithSize(100)) {
+		t.Fatal("Expected to be able to churn contract")
+	}
+
+	// Test: churned contracts are counted and reset with the period.
+	log, err := persist.NewLogger(ioutil.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cl.contractor.log = log
+	cl.currentPeriodChurnedContracts = 0
+	cl.callNotifyChurnedContract(contractWithSize(100))
+	cl.callNotifyChurnedContract(contractWithSize(0))
+	if cl.currentPeriodChurnedContracts != 1 {
+		t.Fatal("wrong number of churned contracts", cl.currentPeriodChurnedContracts)
+	}
+	cl.callResetAggregateChurn()
+	if cl.currentPeriodChurnedContracts != 0 {
+		t.Fatal("churned contracts weren't reset", cl.currentPeriodChurnedContracts)
+	}
+}
+
+// TestApproveMigrations tests approving deferred contracts of the migration
+// plan.
+func TestApproveMigrations(t *testing.T) {
+	persistDir := build.TempDir("contractor", t.Name())
+	if err := os.MkdirAll(persistDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	c := &Contractor{
+		persistDir: persistDir,
+		synced:     make(chan struct{}),
+	}
+	c.staticWatchdog = newWatchdog(c)
+	c.staticChurnLimiter = newChurnLimiter(c)
+
+	churned, deferred := types.FileContractID{1}, types.FileContractID{2}
+	c.staticChurnLimiter.managedUpdateMigrationPlan(modules.ContractorMigrationPlan{
+		Migrations: []modules.ContractMigration{
+			{ContractID: churned, Status: modules.ContractMigrationStatusChurned},
+			{ContractID: deferred, Status: modules.ContractMigrationStatusDeferred},
+		},
+	})
+
+	// Only deferred contracts can be approved.
+	if err := c.ApproveMigrations([]types.FileContractID{churned}); !errors.Contains(err, errContractNotDeferred) {
+		t.Fatal("expected approval to fail", err)
+	}
+	if err := c.ApproveMigrations([]types.FileContractID{{3}}); !errors.Contains(err, errContractNotDeferred) {
+		t.Fatal("expected approval to fail", err)
+	}
+	if err := c.ApproveMigrations([]types.FileContractID{deferred}); err != nil {
+		t.Fatal(err)
+	}
+	plan := c.MigrationPlan()
+	if len(plan.Migrations) != 2 || plan.Migrations[0].Approved || !plan.Migrations[1].Approved {
+		t.Fatal("wrong plan", plan)
+	}
+	if !c.staticChurnLimiter.managedMigrationApproved(deferred) {
+		t.Fatal("contract should be approved")
+	}
+
+	// The approval should be persisted.
+	cl := newChurnLimiterFromPersist(c, c.staticChurnLimiter.callPersistData())
+	if !cl.managedMigrationApproved(deferred) {
+		t.Fatal("approval wasn't persisted")
+	}
+
+	// Once the contract is no longer deferred, the approval is dropped.
+	c.staticChurnLimiter.managedUpdateMigrationPlan(modules.ContractorMigrationPlan{
+		Migrations: []modules.ContractMigration{
+			{ContractID: deferred, Status: modules.ContractMigrationStatusChurned, Approved: true},
+		},
+	})
+	if c.staticChurnLimiter.managedMigrationApproved(deferred) {
+		t.Fatal("approval should have been dropped")
+	}
+}
@@ -17,6 +17,14 @@ var (
 	// funds.
 	AlertMSGAllowanceLowFunds = "At least one contract formation/renewal failed due to the allowance being low on funds"
 
+	// AlertMSGAllowanceSpent indicates that the renter spent more of its
+	// allowance than the configured threshold.
+	AlertMSGAllowanceSpent = "The renter spent more of its allowance than the configured threshold"
+
+	// AlertMSGRenewalExceedsFunds indicates that the estimated cost of
+	// renewing the renter's contracts exceeds the remaining allowance.
+	AlertMSGRenewalExceedsFunds = "The estimated cost of renewing the renter's contracts exceeds the remaining allowance"
+
 	// AlertMSGFailedContractRenewal indicates that the contract renewal failed
 	AlertMSGFailedContractRenewal = "Contractor is attempting to renew/refresh contracts but failed"
 
@@ -43,6 +51,11 @@ var (
 	// the minimum amount of funds to put into a new contract
 	MinInitialContractFundingDivFactor = uint64(20)
 
+	// txnFeeTargetBlocks is the number of blocks within which the
+	// contractor's transactions should be confirmed. It determines the fee
+	// which is estimated by the transaction pool.
+	txnFeeTargetBlocks = types.BlockHeight(2)
+
 	// consecutiveRenewalsBeforeReplacement is the number of times a contract
 	// attempt to be renewed before it is marked as !goodForRenew.
 	consecutiveRenewalsBeforeReplacement = build.Select(build.Var{
@@ -242,7 +242,7 @@ func (c *Contractor) managedEstimateRenewFundingRequirements(contract modules.Re
 
 	// Get an estimate for how much money we will be charged before going into
 	// the transaction pool.
-	_, maxTxnFee := c.tpool.FeeEstimation()
+	maxTxnFee := c.tpool.EstimateFee(txnFeeTargetBlocks).FeePerByte
 	txnFees := maxTxnFee.Mul64(modules.EstimatedFileContractTransactionSetSize)
 
 	// Add them all up and then return the estimate plus 33% for error margin
@@ -348,6 +348,26 @@ func (c *Contractor) managedFindMinAllowedHostScores() (types.Currency, types.Cu
 // managedNewContract negotiates an initial file contract with the specified
 // host, saves it, and returns it.
 func (c *Contractor) managedNewContract(host modules.HostDBEntry, contractFunding types.Currency, endHeight types.BlockHeight) (_ types.Currency, _ modules.RenterContract, err error) {
+	return c.managedNewContractWithBuilder(host, contractFunding, endHeight, nil)
+}
+
+// managedNewContractWithBuilder negotiates an initial file contract with the
+// specified host, saves it, and returns it. If txnBuilder is not nil, it needs
+// to be pre-funded with exactly contractFunding siacoins. It is dropped if the
+// contract can't be formed.
+func (c *Contractor) managedNewContractWithBuilder(host modules.HostDBEntry, contractFunding types.Currency, endHeight types.BlockHeight, txnBuilder modules.TransactionBuilder) (_ types.Currency, _ modules.RenterContract, err error) {
+	// A pre-funded builder is dropped if we fail before handing it to
+	// FormContract. Afterwards it is handled the same way as a builder we
+	// created ourselves.
+	preFunded := txnBuilder != nil
+	handedOff := false
+	if preFunded {
+		defer func() {
+			if err != nil && !handedOff {
+				txnBuilder.Drop()
+			}
+		}()
+	}
 	// reject hosts that are too expensive
 	if host.StoragePrice.Cmp(maxStoragePrice) > 0 {
 		return types.ZeroCurrency, modules.RenterContract{}, errTooExpensive
@@ -408,18 +428,22 @@ func (c *Contractor) managedNewContract(host modules.HostDBEntry, contractFundin
 		EndHeight:     endHeight,
 		RefundAddress: uc.UnlockHash(),
 		RenterSeed:    renterSeed.EphemeralRenterSeed(endHeight),
+		PreFunded:     preFunded,
 	}
 	c.mu.RUnlock()
 
 	// wipe the renter seed once we are done using it.
 	defer fastrand.Read(params.RenterSeed[:])
 
-	// create transaction builder and trigger contract formation.
-	txnBuilder, err := c.wallet.StartTransaction()
-	if err != nil {
-		return types.ZeroCurrency, modules.RenterContract{}, err
+	// create transaction builder if necessary and trigger contract formation.
+	if !preFunded {
+		txnBuilder, err = c.wallet.StartTransaction()
+		if err != nil {
+			return types.ZeroCurrency, modules.RenterContract{}, err
+		}
 	}
 
+	handedOff = true
 	contract, formationTxnSet, sweepTxn, sweepParents, err := c.staticContracts.FormContract(params, txnBuilder, c.tpool, c.hdb, c.tg.StopChan())
 	if err != nil {
 		txnBuilder.Drop()
@@ -997,6 +1021,7 @@ func (c *Contractor) callUpdateUtility(safeContract *proto.SafeContract, newUtil
 // signal is being sent. If so, maintenance returns, yielding to whatever thread
 // issued the interrupt.
 func (c *Contractor) threadedContractMaintenance() {
+	defer modules.RecoverPanic("contractor")
 	err := c.tg.Add()
 	if err != nil {
 		return
@@ -1058,12 +1083,19 @@ func (c *Contractor) threadedContractMaintenance() {
 	// work.
 	c.mu.RLock()
 	wantedHosts := c.allowance.Hosts
+	renewalsSuspended := c.renewalsSuspended
 	c.mu.RUnlock()
 	if wantedHosts <= 0 {
 		c.log.Debugln("Exiting contract maintenance because the number of desired hosts is <= zero.")
 		return
 	}
 
+	// If renewals are suspended, there is no remaining work either.
+	if renewalsSuspended {
+		c.log.Println("Exiting contract maintenance because contract formation and renewals are suspended.")
+		return
+	}
+
 	// The rest of this function needs to know a few of the stateful variables
 	// from the contractor, build those up under a lock so that the rest of the
 	// function can execute without lock contention.
@@ -1386,12 +1418,12 @@ func (c *Contractor) threadedContractMaintenance() {
 	c.log.Debugln("trying to form contracts with hosts, pulled this many hosts from hostdb:", len(hosts))
 
 	// Calculate the anticipated transaction fee.
-	_, maxFee := c.tpool.FeeEstimation()
+	maxFee := c.tpool.EstimateFee(txnFeeTargetBlocks).FeePerByte
 	txnFee := maxFee.Mul64(modules.EstimatedFileContractTransactionSetSize)
 
-	// Form contracts with the hosts one at a time, until we have enough
+	// Form contracts with the hosts in batches, until we have enough
 	// contracts.
-	for _, host := range hosts {
+	for i := 0; i < len(hosts) && neededContracts > 0; {
 		// Return here if an interrupt or kill signal has been sent.
 		select {
 		case <-c.tg.StopChan():
@@ -1403,26 +1435,6 @@ func (c *Contractor) threadedContractMaintenance() {
 		default:
 		}
 
-		// If no more contracts are needed, break.
-		if neededContracts <= 0 {
-			break
-		}
-
-		// Calculate the contract funding with host
-		contractFunds := host.ContractPrice.Add(txnFee).Mul64(ContractFeeFundingMulFactor)
-
-		// Check that the contract funding is reasonable compared to the max and
-		// min initial funding. This is to protect against increases to
-		// allowances being used up to fast and not being able to spread the
-		// funds across new contracts properly, as well as protecting against
-		// contracts renewing too quickly
-		if contractFunds.Cmp(maxInitialContractFunds) > 0 {
-			contractFunds = maxInitialContractFunds
-		}
-		if contractFunds.Cmp(minInitialContractFunds) < 0 {
-			contractFunds = minInitialContractFunds
-		}
-
 		// Confirm the wallet is still unlocked
 		unlocked, err := c.wallet.Unlocked()
 		if !unlocked || err != nil {
@@ -1431,60 +1443,104 @@ func (c *Contractor) threadedContractMaintenance() {
 			return
 		}
 
-		// Determine if we have enough money to form a new contract.
-		if fundsRemaining.Cmp(contractFunds) < 0 || c.staticDeps.Disrupt("LowFundsFormation") {
-			registerLowFundsAlert = true
-			c.log.Println("WARN: need to form new contracts, but unable to because of a low allowance")
-			break
-		}
+		// Collect the next batch of hosts which we can afford to form
+		// contracts with.
+		var batch []formationCandidate
+		batchFunds := formationBatchFee(maxFee, maxFormationBatchSize)
+		lowFunds := false
+		for ; i < len(hosts) && len(batch) < neededContracts && len(batch) < maxFormationBatchSize; i++ {
+			host := hosts[i]
+
+			// Skip hosts which don't pass the allowance's host filters.
+			if err := checkHostFilters(host, allowance, blockHeight); err != nil {
+				c.log.Debugf("Not forming a contract with %v: %v", host.NetAddress, err)
+				continue
+			}
 
-		// If we are using a custom resolver we need to replace the domain name
-		// with 127.0.0.1 to be able to form contracts.
-		if c.staticDeps.Disrupt("customResolver") {
-			port := host.NetAddress.Port()
-			host.NetAddress = modules.NetAddress(fmt.Sprintf("127.0.0.1:%s", port))
-		}
+			// Calculate the contract funding with host
+			contractFunds := host.ContractPrice.Add(txnFee).Mul64(ContractFeeFundingMulFactor)
 
-		// Attempt forming a contract with this host.
-		start := time.Now()
-		fundsSpent, newContract, err := c.managedNewContract(host, contractFunds, endHeight)
-		if err != nil {
-			c.log.Printf("Attempted to form a contract with %v, time spent %v, but negotiation failed: %v\n", host.NetAddress, time.Since(start).Round(time.Millisecond), err)
-			continue
-		}
-		fundsRemaining = fundsRemaining.Sub(fundsSpent)
-		neededContracts--
-
-		sb, err := c.hdb.ScoreBreakdown(host)
-		if err == nil {
-			c.log.Println("A new contract has been formed with a host:", newContract.ID)
-			c.log.Println("Score:    ", sb.Score)
-			c.log.Println("Age Adjustment:        ", sb.AgeAdjustment)
-			c.log.Println("Base Price Adjustment: ", sb.BasePriceAdjustment)
-			c.log.Println("Burn Adjustment:       ", sb.BurnAdjustment)
-			c.log.Println("Collateral Adjustment: ", sb.CollateralAdjustment)
-			c.log.Println("Duration Adjustment:   ", sb.DurationAdjustment)
-			c.log.Println("Interaction Adjustment:", sb.InteractionAdjustment)
-			c.log.Println("Price Adjustment:      ", sb.PriceAdjustment)
-			c.log.Println("Storage Adjustment:    ", sb.StorageRemainingAdjustment)
-			c.log.Println("Uptime Adjustment:     ", sb.UptimeAdjustment)
-			c.log.Println("Version Adjustment:    ", sb.VersionAdjustment)
+			// Check that the contract funding is reasonable compared to the max and
+			// min initial funding. This is to protect against increases to
+			// allowances being used up to fast and not being able to spread the
+			// funds across new contracts properly, as well as protecting against
+			// contracts renewing too quickly
+			if contractFunds.Cmp(maxInitialContractFunds) > 0 {
+				contractFunds = maxInitialContractFunds
+			}
+			if contractFunds.Cmp(minInitialContractFunds) < 0 {
+				contractFunds = minInitialContractFunds
+			}
+
+			// Determine if we have enough money to form a new contract.
+			if fundsRemaining.Cmp(batchFunds.Add(contractFunds)) < 0 || c.staticDeps.Disrupt("LowFundsFormation") {
+				registerLowFundsAlert = true
+				lowFunds = true
+				c.log.Println("WARN: need to form new contracts, but unable to because of a low allowance")
+				break
+			}
+
+			// If we are using a custom resolver we need to replace the domain name
+			// with 127.0.0.1 to be able to form contracts.
+			if c.staticDeps.Disrupt("customResolver") {
+				port := host.NetAddress.Port()
+				host.NetAddress = modules.NetAddress(fmt.Sprintf("127.0.0.1:%s", port))
+			}
+			batch = append(batch, formationCandidate{
+				host:    host,
+				funding: contractFunds,
+			})
+			batchFunds = batchFunds.Add(contractFunds)
 		}
 
-		// Add this contract to the contractor and save.
-		err = c.managedAcquireAndUpdateContractUtility(newContract.ID, modules.ContractUtility{
-			GoodForUpload: true,
-			GoodForRenew:  true,
-		})
-		if err != nil {
-			c.log.Println("Failed to update the contract utilities", err)
-			return
+		// Attempt forming contracts with the hosts of the batch.
+		results, batchFee := c.managedFormContracts(batch, endHeight)
+		fundsRemaining = fundsRemaining.Sub(batchFee)
+		for _, res := range results {
+			host, newContract := res.candidate.host, res.contract
+			if res.err != nil {
+				c.log.Printf("Attempted to form a contract with %v, time spent %v, but negotiation failed: %v\n", host.NetAddress, res.duration.Round(time.Millisecond), res.err)
+				continue
+			}
+			fundsRemaining = fundsRemaining.Sub(res.fundsSpent)
+			neededContracts--
+
+			sb, err := c.hdb.ScoreBreakdown(host)
+			if err == nil {
+				c.log.Println("A new contract has been formed with a host:", newContract.ID)
+				c.log.Println("Score:    ", sb.Score)
+				c.log.Println("Address Adjustment:    ", sb.AddressChangeAdjustment)
+				c.log.Println("Age Adjustment:        ", sb.AgeAdjustment)
+				c.log.Println("Base Price Adjustment: ", sb.BasePriceAdjustment)
+				c.log.Println("Benchmark Adjustment:  ", sb.BenchmarkAdjustment)
+				c.log.Println("Burn Adjustment:       ", sb.BurnAdjustment)
+				c.log.Println("Collateral Adjustment: ", sb.CollateralAdjustment)
+				c.log.Println("Duration Adjustment:   ", sb.DurationAdjustment)
+				c.log.Println("Interaction Adjustment:", sb.InteractionAdjustment)
+				c.log.Println("Price Adjustment:      ", sb.PriceAdjustment)
+				c.log.Println("Storage Adjustment:    ", sb.StorageRemainingAdjustment)
+				c.log.Println("Uptime Adjustment:     ", sb.UptimeAdjustment)
+				c.log.Println("Version Adjustment:    ", sb.VersionAdjustment)
+			}
+
+			// Add this contract to the contractor and save.
+			err = c.managedAcquireAndUpdateContractUtility(newContract.ID, modules.ContractUtility{
+				GoodForUpload: true,
+				GoodForRenew:  true,
+			})
+			if err != nil {
+				c.log.Println("Failed to update the contract utilities", err)
+				return
+			}
+			c.mu.Lock()
+			err = c.save()
+			c.mu.Unlock()
+			if err != nil {
+				c.log.Println("Unable to save the contractor:", err)
+			}
 		}
-		c.mu.Lock()
-		err = c.save()
-		c.mu.Unlock()
-		if err != nil {
-			c.log.Println("Unable to save the contractor:", err)
+		if lowFunds {
+			break
 		}
 	}
 }
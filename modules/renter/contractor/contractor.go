@@ -77,6 +77,15 @@ type Contractor struct {
 	currentPeriod types.BlockHeight
 	lastChange    modules.ConsensusChangeID
 
+	// renewalsSuspended indicates that the contractor shouldn't form, renew or
+	// refresh any contracts. It is set by the renter while it is in read-only
+	// mode to avoid partial renewals when funds are low.
+	renewalsSuspended bool
+
+	// allowanceAlerts are the rules which are evaluated after every block to
+	// alert the user about the spending of the allowance.
+	allowanceAlerts modules.AllowanceAlertSettings
+
 	// recentRecoveryChange is the first ConsensusChange that was missed while
 	// trying to find recoverable contracts. This is where we need to start
 	// rescanning the blockchain for recoverable contracts the next time the wallet
@@ -144,6 +153,29 @@ func (c *Contractor) ContractPublicKey(pk types.SiaPublicKey) (crypto.PublicKey,
 	return c.staticContracts.PublicKey(id)
 }
 
+// ChangeContractKeyStoreKey re-encrypts the contract key store with a new key.
+func (c *Contractor) ChangeContractKeyStoreKey(oldKey, newKey crypto.CipherKey) error {
+	return c.staticContracts.ChangeKeyStoreKey(oldKey, newKey)
+}
+
+// ContractKeyStoreStatus returns information about the contract key store.
+func (c *Contractor) ContractKeyStoreStatus() modules.ContractKeyStoreStatus {
+	return c.staticContracts.KeyStoreStatus()
+}
+
+// UnlockContractKeyStore unlocks the contract key store and moves the secret
+// keys of all contracts into it.
+func (c *Contractor) UnlockContractKeyStore(key crypto.CipherKey) error {
+	return c.staticContracts.UnlockKeyStore(key)
+}
+
+// SetHostRateLimit sets a ratelimit which is applied to all RHP2 connections
+// to the host in addition to the renter's ratelimit. Passing a nil ratelimit
+// removes the ratelimit of the host.
+func (c *Contractor) SetHostRateLimit(pk types.SiaPublicKey, rl *ratelimit.RateLimit) {
+	c.staticContracts.SetHostRateLimit(pk, rl)
+}
+
 // InitRecoveryScan starts scanning the whole blockchain for recoverable
 // contracts within a separate thread.
 func (c *Contractor) InitRecoveryScan() (err error) {
@@ -244,6 +276,18 @@ func (c *Contractor) CurrentPeriod() types.BlockHeight {
 	return c.currentPeriod
 }
 
+// SuspendRenewals suspends or resumes the formation, renewal and refreshing of
+// contracts. Contract maintenance that doesn't spend money, like marking the
+// utility of contracts, continues while renewals are suspended.
+func (c *Contractor) SuspendRenewals(suspend bool) {
+	c.mu.Lock()
+	c.renewalsSuspended = suspend
+	c.mu.Unlock()
+	if !suspend {
+		go c.threadedContractMaintenance()
+	}
+}
+
 // UpdateWorkerPool updates the workerpool currently in use by the contractor.
 func (c *Contractor) UpdateWorkerPool(wp modules.WorkerPool) {
 	c.mu.Lock()
@@ -273,6 +317,9 @@ func (c *Contractor) ProvidePayment(stream io.ReadWriter, pt *modules.RPCPriceTa
 		return errContractNotFound
 	}
 	defer c.staticContracts.Return(sc)
+	if err := sc.HasSecretKey(); err != nil {
+		return err
+	}
 
 	// create a new revision
 	current := sc.LastRevision()
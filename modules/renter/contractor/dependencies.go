@@ -44,7 +44,7 @@ type (
 	}
 	transactionPool interface {
 		AcceptTransactionSet([]types.Transaction) error
-		FeeEstimation() (min types.Currency, max types.Currency)
+		EstimateFee(targetBlocks types.BlockHeight) modules.TransactionPoolFeeEstimate
 	}
 
 	hostDB interface {
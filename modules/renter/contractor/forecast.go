@@ -0,0 +1,107 @@
+package contractor
+
+import (
+	"fmt"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// SpendingForecast projects the spending of the renter for the remainder of
+// the current period based on the spending rate of the period so far and the
+// contracts which need to be renewed before the period ends.
+func (c *Contractor) SpendingForecast() (modules.SpendingForecast, error) {
+	if err := c.tg.Add(); err != nil {
+		return modules.SpendingForecast{}, err
+	}
+	defer c.tg.Done()
+
+	spending, err := c.PeriodSpending()
+	if err != nil {
+		return modules.SpendingForecast{}, err
+	}
+	c.mu.RLock()
+	allowance := c.allowance
+	blockHeight := c.blockHeight
+	periodStart := c.currentPeriod
+	c.mu.RUnlock()
+
+	// Estimate the renewal fees by assuming that every contract which is good
+	// for renewal and expires before the end of the period is renewed for the
+	// same fees it was formed for.
+	var renewalFees types.Currency
+	periodEnd := periodStart + allowance.Period
+	for _, contract := range c.staticContracts.ViewAll() {
+		if !contract.Utility.GoodForRenew || contract.EndHeight > periodEnd {
+			continue
+		}
+		renewalFees = renewalFees.Add(contract.ContractFee).Add(contract.TxnFee).Add(contract.SiafundFee)
+	}
+	return forecastSpending(allowance, spending, renewalFees, periodStart, blockHeight), nil
+}
+
+// forecastSpending projects the spending for the remainder of the period by
+// extrapolating the spending rate of the period so far.
+func forecastSpending(allowance modules.Allowance, spending modules.ContractorSpending, renewalFees types.Currency, periodStart, blockHeight types.BlockHeight) modules.SpendingForecast {
+	periodEnd := periodStart + allowance.Period
+	var remaining types.BlockHeight
+	if blockHeight < periodEnd {
+		remaining = periodEnd - blockHeight
+	}
+	// Count at least one elapsed block to avoid dividing by zero at the start
+	// of the period.
+	elapsed := types.BlockHeight(1)
+	if blockHeight > periodStart {
+		elapsed = blockHeight - periodStart
+	}
+
+	bandwidth := spending.DownloadSpending.Add(spending.UploadSpending).Add(spending.FundAccountSpending).Add(spending.MaintenanceSpending.Sum())
+	storage := spending.StorageSpending
+	spent := spending.ContractFees.Add(bandwidth).Add(storage)
+
+	f := modules.SpendingForecast{
+		Assumptions: []string{
+			fmt.Sprintf("storage and bandwidth spending continue at the average rate of the last %v blocks", elapsed),
+			"contracts which expire before the end of the period are renewed for the same fees they were formed for",
+			"no new contracts are formed and prices stay the same",
+		},
+
+		BlockHeight:     blockHeight,
+		PeriodEnd:       periodEnd,
+		PeriodStart:     periodStart,
+		RemainingBlocks: remaining,
+
+		Allowance: allowance.Funds,
+		Spent:     spent,
+
+		ProjectedBandwidthSpending: bandwidth.Mul64(uint64(remaining)).Div64(uint64(elapsed)),
+		ProjectedRenewalSpending:   renewalFees,
+		ProjectedStorageSpending:   storage.Mul64(uint64(remaining)).Div64(uint64(elapsed)),
+	}
+	f.ProjectedTotal = spent.Add(f.ProjectedBandwidthSpending).Add(f.ProjectedRenewalSpending).Add(f.ProjectedStorageSpending)
+	if allowance.Funds.Cmp(f.ProjectedTotal) >= 0 {
+		f.ProjectedUnspent = allowance.Funds.Sub(f.ProjectedTotal)
+		return f
+	}
+
+	// The allowance is projected to run out early. Estimate the height at
+	// which that happens. The renewal fees are assumed to be spent right away.
+	f.ExhaustedEarly = true
+	committed := spent.Add(renewalFees)
+	if allowance.Funds.Cmp(committed) <= 0 {
+		f.ExhaustionHeight = blockHeight
+		return f
+	}
+	rate := bandwidth.Add(storage).Div64(uint64(elapsed))
+	if rate.IsZero() {
+		// The rate can only be zero due to rounding.
+		f.ExhaustionHeight = periodEnd
+		return f
+	}
+	blocksLeft, err := allowance.Funds.Sub(committed).Div(rate).Uint64()
+	if err != nil || blocksLeft > uint64(remaining) {
+		blocksLeft = uint64(remaining)
+	}
+	f.ExhaustionHeight = blockHeight + types.BlockHeight(blocksLeft)
+	return f
+}
@@ -0,0 +1,69 @@
+package contractor
+
+import (
+	"testing"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestForecastSpending is a unit test for forecastSpending.
+func TestForecastSpending(t *testing.T) {
+	t.Parallel()
+
+	sc := types.SiacoinPrecision
+	allowance := modules.Allowance{
+		Funds:  sc.Mul64(1000),
+		Period: 100,
+	}
+
+	// Spend 10SC on storage and 10SC on bandwidth in the first 10 blocks of
+	// the period. The renewals cost 5SC.
+	spending := modules.ContractorSpending{
+		ContractFees:     sc.Mul64(5),
+		StorageSpending:  sc.Mul64(10),
+		UploadSpending:   sc.Mul64(5),
+		DownloadSpending: sc.Mul64(5),
+	}
+	f := forecastSpending(allowance, spending, sc.Mul64(5), 100, 110)
+	if f.PeriodEnd != 200 || f.RemainingBlocks != 90 {
+		t.Fatal("wrong period", f.PeriodEnd, f.RemainingBlocks)
+	}
+	if !f.Spent.Equals(sc.Mul64(25)) {
+		t.Fatal("wrong spent", f.Spent)
+	}
+	if !f.ProjectedStorageSpending.Equals(sc.Mul64(90)) || !f.ProjectedBandwidthSpending.Equals(sc.Mul64(90)) {
+		t.Fatal("wrong projection", f.ProjectedStorageSpending, f.ProjectedBandwidthSpending)
+	}
+	if !f.ProjectedTotal.Equals(sc.Mul64(210)) || !f.ProjectedUnspent.Equals(sc.Mul64(790)) {
+		t.Fatal("wrong total", f.ProjectedTotal, f.ProjectedUnspent)
+	}
+	if f.ExhaustedEarly || len(f.Assumptions) == 0 {
+		t.Fatal("unexpected forecast", f)
+	}
+
+	// Reduce the allowance so that it runs out early. 30SC are committed and
+	// 2SC are spent per block, so the remaining 20SC last for 10 blocks.
+	allowance.Funds = sc.Mul64(50)
+	f = forecastSpending(allowance, spending, sc.Mul64(5), 100, 110)
+	if !f.ExhaustedEarly || f.ExhaustionHeight != 120 {
+		t.Fatal("unexpected exhaustion", f.ExhaustedEarly, f.ExhaustionHeight)
+	}
+	if !f.ProjectedUnspent.IsZero() {
+		t.Fatal("unspent should be zero", f.ProjectedUnspent)
+	}
+
+	// If the committed spending exceeds the allowance, it's exhausted right
+	// away.
+	allowance.Funds = sc.Mul64(20)
+	f = forecastSpending(allowance, spending, sc.Mul64(5), 100, 110)
+	if !f.ExhaustedEarly || f.ExhaustionHeight != 110 {
+		t.Fatal("unexpected exhaustion", f.ExhaustedEarly, f.ExhaustionHeight)
+	}
+
+	// At the start of the period nothing was spent.
+	f = forecastSpending(allowance, modules.ContractorSpending{}, types.ZeroCurrency, 100, 100)
+	if f.ExhaustedEarly || !f.ProjectedTotal.IsZero() || f.RemainingBlocks != 100 {
+		t.Fatal("unexpected forecast", f)
+	}
+}
@@ -0,0 +1,189 @@
+package contractor
+
+import (
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// minFormationBatchSize is the minimum number of contracts which need to
+	// be formed at once for the contractor to fund them using a shared
+	// funding transaction. Smaller batches are formed one at a time.
+	minFormationBatchSize = 3
+
+	// maxFormationBatchSize is the maximum number of contracts which are
+	// funded by the same funding transaction and negotiated concurrently.
+	maxFormationBatchSize = 10
+
+	// estimatedFormationBatchBaseSize is the estimated blockchain size of a
+	// funding transaction for a batch of contracts without its outputs,
+	// including the parent transaction created by the wallet to fund it.
+	estimatedFormationBatchBaseSize = 1024
+
+	// estimatedFormationBatchOutputSize is the estimated blockchain size of a
+	// single output of a funding transaction for a batch of contracts.
+	estimatedFormationBatchOutputSize = 64
+)
+
+type (
+	// formationCandidate is a host the contractor wants to form a contract
+	// with together with the funding for that contract.
+	formationCandidate struct {
+		host    modules.HostDBEntry
+		funding types.Currency
+	}
+
+	// formationResult is the outcome of trying to form a contract with a
+	// formationCandidate.
+	formationResult struct {
+		candidate  formationCandidate
+		fundsSpent types.Currency
+		contract   modules.RenterContract
+		duration   time.Duration
+		err        error
+	}
+)
+
+// formationBatchFee returns the fee paid for the funding transaction of a batch
+// of contracts with the provided size.
+func formationBatchFee(maxFee types.Currency, batchSize int) types.Currency {
+	return maxFee.Mul64(estimatedFormationBatchBaseSize + estimatedFormationBatchOutputSize*uint64(batchSize))
+}
+
+// managedFundFormationBatch creates and broadcasts a single transaction which
+// contains one output for every provided funding. For every output a
+// transaction builder is returned which spends it. The builders can then be
+// used to form contracts without each contract requiring its own setup
+// transaction. The fee paid for the funding transaction is returned as well.
+func (c *Contractor) managedFundFormationBatch(fundings []types.Currency) (_ []modules.TransactionBuilder, _ types.Currency, err error) {
+	// Compute the total funding and the fee of the funding transaction.
+	maxFee := c.tpool.EstimateFee(txnFeeTargetBlocks).FeePerByte
+	fee := formationBatchFee(maxFee, len(fundings))
+	total := fee
+	for _, funding := range fundings {
+		total = total.Add(funding)
+	}
+
+	// Create the funding transaction.
+	fundingBuilder, err := c.wallet.StartTransaction()
+	if err != nil {
+		return nil, types.ZeroCurrency, errors.AddContext(err, "failed to start funding transaction")
+	}
+	builders := make([]modules.TransactionBuilder, 0, len(fundings))
+	ucs := make([]types.UnlockConditions, 0, len(fundings))
+	defer func() {
+		if err != nil {
+			for _, b := range builders {
+				b.Drop()
+			}
+			fundingBuilder.Drop()
+			err = errors.Compose(err, c.wallet.MarkAddressUnused(ucs...))
+		}
+	}()
+	err = fundingBuilder.FundSiacoins(total)
+	if err != nil {
+		return nil, types.ZeroCurrency, errors.AddContext(err, "failed to fund funding transaction")
+	}
+	outputs := make([]types.SiacoinOutput, len(fundings))
+	outputIndices := make([]uint64, len(fundings))
+	for i, funding := range fundings {
+		uc, err := c.wallet.NextAddress()
+		if err != nil {
+			return nil, types.ZeroCurrency, errors.AddContext(err, "failed to get address for funding output")
+		}
+		ucs = append(ucs, uc)
+		outputs[i] = types.SiacoinOutput{
+			Value:      funding,
+			UnlockHash: uc.UnlockHash(),
+		}
+		outputIndices[i] = fundingBuilder.AddSiacoinOutput(outputs[i])
+	}
+	fundingBuilder.AddMinerFee(fee)
+	txnSet, err := fundingBuilder.Sign(true)
+	if err != nil {
+		return nil, types.ZeroCurrency, errors.AddContext(err, "failed to sign funding transaction")
+	}
+	fundingTxn := txnSet[len(txnSet)-1]
+
+	// Create a builder for every output of the funding transaction.
+	for i, output := range outputs {
+		b, err := c.wallet.StartTransaction()
+		if err != nil {
+			return nil, types.ZeroCurrency, errors.AddContext(err, "failed to start contract transaction")
+		}
+		builders = append(builders, b)
+		err = b.FundSiacoinsFromOutput(fundingTxn.SiacoinOutputID(outputIndices[i]), output)
+		if err != nil {
+			return nil, types.ZeroCurrency, errors.AddContext(err, "failed to fund contract transaction")
+		}
+	}
+
+	// Broadcast the funding transaction. The contract transactions depend on
+	// it being in the transaction pool.
+	err = c.tpool.AcceptTransactionSet(txnSet)
+	if err != nil {
+		return nil, types.ZeroCurrency, errors.AddContext(err, "failed to broadcast funding transaction")
+	}
+	return builders, fee, nil
+}
+
+// managedFormContracts forms contracts with the provided candidates. Batches
+// of at least minFormationBatchSize candidates are funded by a shared funding
+// transaction and negotiated concurrently. Smaller batches, or batches for
+// which the funding transaction couldn't be created, are formed one at a time.
+// The returned fee is the fee paid for the funding transaction, if any.
+func (c *Contractor) managedFormContracts(batch []formationCandidate, endHeight types.BlockHeight) ([]formationResult, types.Currency) {
+	results := make([]formationResult, len(batch))
+	formContract := func(i int, txnBuilder modules.TransactionBuilder) {
+		start := time.Now()
+		fundsSpent, contract, err := c.managedNewContractWithBuilder(batch[i].host, batch[i].funding, endHeight, txnBuilder)
+		results[i] = formationResult{
+			candidate:  batch[i],
+			fundsSpent: fundsSpent,
+			contract:   contract,
+			duration:   time.Since(start),
+			err:        err,
+		}
+	}
+
+	// Try to fund the batch.
+	var builders []modules.TransactionBuilder
+	fee := types.ZeroCurrency
+	if len(batch) >= minFormationBatchSize {
+		fundings := make([]types.Currency, 0, len(batch))
+		for _, candidate := range batch {
+			fundings = append(fundings, candidate.funding)
+		}
+		var err error
+		builders, fee, err = c.managedFundFormationBatch(fundings)
+		if err != nil {
+			c.log.Println("WARN: failed to fund contract formation batch, forming contracts one at a time:", err)
+			builders = nil
+		}
+	}
+
+	// Form the contracts one at a time if the batch wasn't funded.
+	if builders == nil {
+		for i := range batch {
+			formContract(i, nil)
+		}
+		return results, types.ZeroCurrency
+	}
+
+	// Otherwise negotiate them concurrently.
+	var wg sync.WaitGroup
+	for i := range batch {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			formContract(i, builders[i])
+		}(i)
+	}
+	wg.Wait()
+	return results, fee
+}
@@ -294,6 +294,93 @@ func TestIntegrationFormContract(t *testing.T) {
 	}
 }
 
+// TestIntegrationFormContractBatch tests forming a contract using a builder
+// which was funded by a shared funding transaction.
+func TestIntegrationFormContractBatch(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	h, c, _, cf, err := newTestingTrio(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tryClose(cf, t)
+
+	// acquire the contract maintenance lock for the duration of the test. This
+	// prevents theadedContractMaintenance from running.
+	c.maintenanceLock.Lock()
+	defer c.maintenanceLock.Unlock()
+
+	// get the host's entry from the db
+	hostEntry, ok, err := c.hdb.Host(h.PublicKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("no entry for host in db")
+	}
+
+	// set an allowance but don't use SetAllowance to avoid automatic contract
+	// formation.
+	c.mu.Lock()
+	c.allowance = modules.DefaultAllowance
+	c.mu.Unlock()
+
+	// fund a batch of contracts
+	funding := types.SiacoinPrecision.Mul64(50)
+	builders, fee, err := c.managedFundFormationBatch([]types.Currency{funding, funding, funding})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(builders) != 3 {
+		t.Fatal("wrong number of builders", len(builders))
+	}
+	if fee.IsZero() {
+		t.Fatal("funding transaction should pay a fee")
+	}
+
+	// every builder should spend a different output of the same funding
+	// transaction
+	var fundingTxnID types.TransactionID
+	spent := make(map[types.SiacoinOutputID]struct{})
+	for i, b := range builders {
+		txn, parents := b.View()
+		if len(txn.SiacoinInputs) != 1 || len(parents) != 0 {
+			t.Fatal("builder should have a single input and no parents", len(txn.SiacoinInputs), len(parents))
+		}
+		spent[txn.SiacoinInputs[0].ParentID] = struct{}{}
+		unconfirmed, err := b.UnconfirmedParents()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(unconfirmed) == 0 {
+			t.Fatal("funding transaction should be an unconfirmed parent")
+		}
+		id := unconfirmed[len(unconfirmed)-1].ID()
+		if i > 0 && id != fundingTxnID {
+			t.Fatal("builders weren't funded by the same transaction")
+		}
+		fundingTxnID = id
+	}
+	if len(spent) != len(builders) {
+		t.Fatal("builders spend the same output")
+	}
+
+	// form a contract with the host using the first builder and drop the
+	// others
+	_, _, err = c.managedNewContractWithBuilder(hostEntry, funding, c.blockHeight+100, builders[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, b := range builders[1:] {
+		b.Drop()
+	}
+	if len(c.Contracts()) != 1 {
+		t.Fatal("expected 1 contract", len(c.Contracts()))
+	}
+}
+
 // TestFormContractSmallAllowance tests to make sure that a contract doesn't
 // form when there are insufficient funds in the allowance
 func TestFormContractSmallAllowance(t *testing.T) {
@@ -0,0 +1,103 @@
+package contractor
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// ErrAllowanceInvalidMinHostVersion is returned if the allowance's minimum
+	// host version is not a valid version.
+	ErrAllowanceInvalidMinHostVersion = errors.New("minimum host version is not a valid version")
+	// ErrAllowanceInvalidExcludedCountry is returned if one of the allowance's
+	// excluded countries is not a two letter country code.
+	ErrAllowanceInvalidExcludedCountry = errors.New("excluded countries must be two letter country codes")
+	// ErrAllowanceInvalidExcludedSubnet is returned if one of the allowance's
+	// excluded subnets is not a valid CIDR subnet.
+	ErrAllowanceInvalidExcludedSubnet = errors.New("excluded subnets must be valid CIDR subnets")
+)
+
+// validateHostFilters checks that the host filters of an allowance are valid.
+func validateHostFilters(a modules.Allowance) error {
+	if a.MinHostVersion != "" && !build.IsVersion(a.MinHostVersion) {
+		return ErrAllowanceInvalidMinHostVersion
+	}
+	for _, country := range a.ExcludedCountries {
+		if len(country) != 2 || strings.ToUpper(country) != country {
+			return ErrAllowanceInvalidExcludedCountry
+		}
+	}
+	for _, subnet := range a.ExcludedSubnets {
+		if _, _, err := net.ParseCIDR(subnet); err != nil {
+			return errors.Compose(ErrAllowanceInvalidExcludedSubnet, err)
+		}
+	}
+	return nil
+}
+
+// subnetsOverlap returns true if the two subnets share at least one address.
+func subnetsOverlap(a, b *net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
+
+// hostInSubnet returns true if the host is reachable from an address of the
+// provided subnet.
+func hostInSubnet(host modules.HostDBEntry, subnet *net.IPNet) bool {
+	if ip := net.ParseIP(host.NetAddress.Host()); ip != nil && subnet.Contains(ip) {
+		return true
+	}
+	for _, ipNet := range host.IPNets {
+		_, hostNet, err := net.ParseCIDR(ipNet)
+		if err == nil && subnetsOverlap(hostNet, subnet) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkHostFilters returns an error if the host doesn't pass the price caps
+// and host filters of the allowance.
+func checkHostFilters(host modules.HostDBEntry, a modules.Allowance, blockHeight types.BlockHeight) error {
+	exceeds := func(price, max types.Currency) bool {
+		return !max.IsZero() && price.Cmp(max) > 0
+	}
+	switch {
+	case exceeds(host.BaseRPCPrice, a.MaxRPCPrice):
+		return fmt.Errorf("rpc price %v exceeds the allowance's max rpc price %v", host.BaseRPCPrice, a.MaxRPCPrice)
+	case exceeds(host.ContractPrice, a.MaxContractPrice):
+		return fmt.Errorf("contract price %v exceeds the allowance's max contract price %v", host.ContractPrice, a.MaxContractPrice)
+	case exceeds(host.DownloadBandwidthPrice, a.MaxDownloadBandwidthPrice):
+		return fmt.Errorf("download bandwidth price %v exceeds the allowance's max download bandwidth price %v", host.DownloadBandwidthPrice, a.MaxDownloadBandwidthPrice)
+	case exceeds(host.SectorAccessPrice, a.MaxSectorAccessPrice):
+		return fmt.Errorf("sector access price %v exceeds the allowance's max sector access price %v", host.SectorAccessPrice, a.MaxSectorAccessPrice)
+	case exceeds(host.StoragePrice, a.MaxStoragePrice):
+		return fmt.Errorf("storage price %v exceeds the allowance's max storage price %v", host.StoragePrice, a.MaxStoragePrice)
+	case exceeds(host.UploadBandwidthPrice, a.MaxUploadBandwidthPrice):
+		return fmt.Errorf("upload bandwidth price %v exceeds the allowance's max upload bandwidth price %v", host.UploadBandwidthPrice, a.MaxUploadBandwidthPrice)
+	}
+	if a.MinHostVersion != "" && build.VersionCmp(host.Version, a.MinHostVersion) < 0 {
+		return fmt.Errorf("host version %v is below the allowance's min host version %v", host.Version, a.MinHostVersion)
+	}
+	if a.MinHostAge > 0 && (host.FirstSeen > blockHeight || blockHeight-host.FirstSeen < a.MinHostAge) {
+		return fmt.Errorf("host was first seen at height %v which is less than %v blocks ago", host.FirstSeen, a.MinHostAge)
+	}
+	for _, country := range a.ExcludedCountries {
+		if host.Country == country {
+			return fmt.Errorf("host is located in excluded country %v", country)
+		}
+	}
+	for _, subnet := range a.ExcludedSubnets {
+		_, ipNet, err := net.ParseCIDR(subnet)
+		if err == nil && hostInSubnet(host, ipNet) {
+			return fmt.Errorf("host is located in excluded subnet %v", subnet)
+		}
+	}
+	return nil
+}
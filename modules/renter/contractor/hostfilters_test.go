@@ -0,0 +1,77 @@
+package contractor
+
+import (
+	"testing"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestValidateHostFilters is a unit test for validateHostFilters.
+func TestValidateHostFilters(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		allowance modules.Allowance
+		valid     bool
+	}{
+		{modules.Allowance{}, true},
+		{modules.Allowance{MinHostVersion: "1.5.4"}, true},
+		{modules.Allowance{MinHostVersion: "v1.5.4"}, false},
+		{modules.Allowance{ExcludedCountries: []string{"US", "CN"}}, true},
+		{modules.Allowance{ExcludedCountries: []string{"us"}}, false},
+		{modules.Allowance{ExcludedCountries: []string{"USA"}}, false},
+		{modules.Allowance{ExcludedSubnets: []string{"10.0.0.0/8", "2001:db8::/32"}}, true},
+		{modules.Allowance{ExcludedSubnets: []string{"10.0.0.0"}}, false},
+	}
+	for i, test := range tests {
+		if err := validateHostFilters(test.allowance); (err == nil) != test.valid {
+			t.Errorf("%v: expected valid to be %v but got %v", i, test.valid, err)
+		}
+	}
+}
+
+// TestCheckHostFilters is a unit test for checkHostFilters.
+func TestCheckHostFilters(t *testing.T) {
+	t.Parallel()
+
+	host := modules.HostDBEntry{
+		FirstSeen: 100,
+		IPNets:    []string{"1.2.3.0/24"},
+		Country:   "DE",
+	}
+	host.NetAddress = "1.2.3.4:9982"
+	host.Version = "1.5.4"
+	host.StoragePrice = types.NewCurrency64(100)
+	host.UploadBandwidthPrice = types.NewCurrency64(100)
+
+	tests := []struct {
+		allowance modules.Allowance
+		passes    bool
+	}{
+		{modules.Allowance{}, true},
+		{modules.Allowance{MaxStoragePrice: types.NewCurrency64(100)}, true},
+		{modules.Allowance{MaxStoragePrice: types.NewCurrency64(99)}, false},
+		{modules.Allowance{MaxUploadBandwidthPrice: types.NewCurrency64(99)}, false},
+		{modules.Allowance{MinHostVersion: "1.5.4"}, true},
+		{modules.Allowance{MinHostVersion: "1.5.5"}, false},
+		{modules.Allowance{MinHostAge: 50}, true},
+		{modules.Allowance{MinHostAge: 51}, false},
+		{modules.Allowance{ExcludedCountries: []string{"US"}}, true},
+		{modules.Allowance{ExcludedCountries: []string{"US", "DE"}}, false},
+		{modules.Allowance{ExcludedSubnets: []string{"1.2.4.0/24"}}, true},
+		{modules.Allowance{ExcludedSubnets: []string{"1.2.3.4/32"}}, false},
+		{modules.Allowance{ExcludedSubnets: []string{"1.0.0.0/8"}}, false},
+	}
+	for i, test := range tests {
+		if err := checkHostFilters(host, test.allowance, 150); (err == nil) != test.passes {
+			t.Errorf("%v: expected passes to be %v but got %v", i, test.passes, err)
+		}
+	}
+
+	// A host with an unknown country passes the country filter.
+	host.Country = ""
+	if err := checkHostFilters(host, modules.Allowance{ExcludedCountries: []string{"DE"}}, 150); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,89 @@
+package contractor
+
+import (
+	"sort"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// LockedFunds returns the renter funds locked in the contractor's unexpired
+// contracts and when they are released to the wallet. Old contracts are
+// included until their funds are released.
+func (c *Contractor) LockedFunds() (modules.ContractorLockedFunds, error) {
+	if err := c.tg.Add(); err != nil {
+		return modules.ContractorLockedFunds{}, err
+	}
+	defer c.tg.Done()
+
+	contracts := c.staticContracts.ViewAll()
+	c.mu.RLock()
+	blockHeight := c.blockHeight
+	for _, contract := range c.oldContracts {
+		contracts = append(contracts, contract)
+	}
+	var unspent []modules.RenterContract
+	for _, contract := range contracts {
+		// Double-spent contracts never locked any funds.
+		if _, doubleSpent := c.doubleSpentContracts[contract.ID]; !doubleSpent {
+			unspent = append(unspent, contract)
+		}
+	}
+	c.mu.RUnlock()
+	return lockedFunds(unspent, blockHeight), nil
+}
+
+// contractReleaseHeight returns the height at which the renter funds of a
+// contract are released to the wallet at the latest. The renter's payout is
+// created when the proof window ends and can be spent once it matured.
+func contractReleaseHeight(contract modules.RenterContract) types.BlockHeight {
+	windowEnd := contract.EndHeight
+	if revs := contract.Transaction.FileContractRevisions; len(revs) > 0 {
+		windowEnd = revs[0].NewWindowEnd
+	}
+	return windowEnd + types.MaturityDelay
+}
+
+// lockedFunds returns the funds locked in the contracts which aren't released
+// at the given height yet, sorted by release height.
+func lockedFunds(contracts []modules.RenterContract, blockHeight types.BlockHeight) modules.ContractorLockedFunds {
+	lf := modules.ContractorLockedFunds{
+		BlockHeight: blockHeight,
+		Contracts:   []modules.ContractLockedFunds{},
+		Releases:    []modules.LockedFundsRelease{},
+	}
+	for _, contract := range contracts {
+		releaseHeight := contractReleaseHeight(contract)
+		if releaseHeight <= blockHeight || contract.RenterFunds.IsZero() {
+			continue
+		}
+		lf.Contracts = append(lf.Contracts, modules.ContractLockedFunds{
+			ID:            contract.ID,
+			HostPublicKey: contract.HostPublicKey,
+			LockedFunds:   contract.RenterFunds,
+			EndHeight:     contract.EndHeight,
+			ReleaseHeight: releaseHeight,
+		})
+		lf.Total = lf.Total.Add(contract.RenterFunds)
+	}
+	sort.Slice(lf.Contracts, func(i, j int) bool {
+		return lf.Contracts[i].ReleaseHeight < lf.Contracts[j].ReleaseHeight
+	})
+
+	// Build the release timeline from the sorted contracts.
+	remaining := lf.Total
+	for _, contract := range lf.Contracts {
+		remaining = remaining.Sub(contract.LockedFunds)
+		if n := len(lf.Releases); n > 0 && lf.Releases[n-1].Height == contract.ReleaseHeight {
+			lf.Releases[n-1].Released = lf.Releases[n-1].Released.Add(contract.LockedFunds)
+			lf.Releases[n-1].Remaining = remaining
+			continue
+		}
+		lf.Releases = append(lf.Releases, modules.LockedFundsRelease{
+			Height:    contract.ReleaseHeight,
+			Released:  contract.LockedFunds,
+			Remaining: remaining,
+		})
+	}
+	return lf
+}
@@ -0,0 +1,74 @@
+package contractor
+
+import (
+	"testing"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestLockedFunds is a unit test for lockedFunds.
+func TestLockedFunds(t *testing.T) {
+	t.Parallel()
+
+	contract := func(id byte, funds uint64, endHeight, windowEnd types.BlockHeight) modules.RenterContract {
+		return modules.RenterContract{
+			ID:          types.FileContractID{id},
+			RenterFunds: types.NewCurrency64(funds),
+			EndHeight:   endHeight,
+			Transaction: types.Transaction{
+				FileContractRevisions: []types.FileContractRevision{{NewWindowEnd: windowEnd}},
+			},
+		}
+	}
+	height := types.BlockHeight(100)
+	contracts := []modules.RenterContract{
+		contract(1, 10, 200, 220),
+		// Released at the same height as the first contract.
+		contract(2, 20, 210, 220),
+		contract(3, 5, 150, 160),
+		// No funds left.
+		contract(4, 0, 200, 220),
+		// Already released.
+		contract(5, 100, 50, height-types.MaturityDelay),
+	}
+	lf := lockedFunds(contracts, height)
+	if lf.BlockHeight != height || !lf.Total.Equals64(35) {
+		t.Fatal("wrong total", lf.Total)
+	}
+
+	// The contracts are sorted by release height.
+	if len(lf.Contracts) != 3 {
+		t.Fatal("expected 3 contracts", lf.Contracts)
+	}
+	first := lf.Contracts[0]
+	if first.ID != (types.FileContractID{3}) || first.EndHeight != 150 || first.ReleaseHeight != 160+types.MaturityDelay || !first.LockedFunds.Equals64(5) {
+		t.Fatal("wrong first contract", first)
+	}
+
+	// Releases at the same height are merged.
+	expected := []struct {
+		height    types.BlockHeight
+		released  uint64
+		remaining uint64
+	}{
+		{160 + types.MaturityDelay, 5, 30},
+		{220 + types.MaturityDelay, 30, 0},
+	}
+	if len(lf.Releases) != len(expected) {
+		t.Fatal("wrong number of releases", lf.Releases)
+	}
+	for i, e := range expected {
+		r := lf.Releases[i]
+		if r.Height != e.height || !r.Released.Equals64(e.released) || !r.Remaining.Equals64(e.remaining) {
+			t.Fatalf("%v: wrong release %v", i, r)
+		}
+	}
+
+	// Without a revision the end height is used as the window end.
+	c := contract(6, 1, 300, 0)
+	c.Transaction = types.Transaction{}
+	if rh := contractReleaseHeight(c); rh != 300+types.MaturityDelay {
+		t.Fatal("wrong release height", rh)
+	}
+}
@@ -71,8 +71,10 @@ func (c *Contractor) managedCheckHostScore(contract modules.RenterContract, sb m
 			c.log.Printf("Marking contract as having no utility because of host score: %v", contract.ID)
 			c.log.Println("Min Score:", minScoreGFR)
 			c.log.Println("Score:    ", sb.Score)
+			c.log.Println("Address Adjustment:    ", sb.AddressChangeAdjustment)
 			c.log.Println("Age Adjustment:        ", sb.AgeAdjustment)
 			c.log.Println("Base Price Adjustment: ", sb.BasePriceAdjustment)
+			c.log.Println("Benchmark Adjustment:  ", sb.BenchmarkAdjustment)
 			c.log.Println("Burn Adjustment:       ", sb.BurnAdjustment)
 			c.log.Println("Collateral Adjustment: ", sb.CollateralAdjustment)
 			c.log.Println("Duration Adjustment:   ", sb.DurationAdjustment)
@@ -101,8 +103,10 @@ func (c *Contractor) managedCheckHostScore(contract modules.RenterContract, sb m
 			c.log.Printf("Marking contract as not good for upload because of a poor score: %v", contract.ID)
 			c.log.Println("Min Score:", minScoreGFU)
 			c.log.Println("Score:    ", sb.Score)
+			c.log.Println("Address Adjustment:    ", sb.AddressChangeAdjustment)
 			c.log.Println("Age Adjustment:        ", sb.AgeAdjustment)
 			c.log.Println("Base Price Adjustment: ", sb.BasePriceAdjustment)
+			c.log.Println("Benchmark Adjustment:  ", sb.BenchmarkAdjustment)
 			c.log.Println("Burn Adjustment:       ", sb.BurnAdjustment)
 			c.log.Println("Collateral Adjustment: ", sb.CollateralAdjustment)
 			c.log.Println("Duration Adjustment:   ", sb.DurationAdjustment)
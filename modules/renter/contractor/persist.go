@@ -39,6 +39,8 @@ type contractorPersist struct {
 	RenewedTo            map[string]types.FileContractID `json:"renewedto"`
 	Synced               bool                            `json:"synced"`
 
+	AllowanceAlerts modules.AllowanceAlertSettings `json:"allowancealerts"`
+
 	// Subsystem persistence:
 	ChurnLimiter churnLimiterPersist `json:"churnlimiter"`
 	WatchdogData watchdogPersist     `json:"watchdogdata"`
@@ -62,6 +64,8 @@ func (c *Contractor) persistData() contractorPersist {
 		RenewedTo:            make(map[string]types.FileContractID),
 		DoubleSpentContracts: make(map[string]types.BlockHeight),
 		Synced:               synced,
+
+		AllowanceAlerts: c.allowanceAlerts,
 	}
 	for k, v := range c.renewedFrom {
 		data.RenewedFrom[k.String()] = v
@@ -122,6 +126,7 @@ func (c *Contractor) load() error {
 		close(c.synced)
 	}
 	c.recentRecoveryChange = data.RecentRecoveryChange
+	c.allowanceAlerts = data.AllowanceAlerts
 	var fcid types.FileContractID
 	for k, v := range data.RenewedFrom {
 		if err := fcid.LoadString(k); err != nil {
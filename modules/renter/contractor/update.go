@@ -164,5 +164,6 @@ func (c *Contractor) ProcessConsensusChange(cc modules.ConsensusChange) {
 	// maintenance.
 	if cc.Synced {
 		go c.threadedContractMaintenance()
+		go c.threadedCheckAllowanceAlerts()
 	}
 }
@@ -793,7 +793,7 @@ func (w *watchdog) sweepContractInputs(fcID types.FileContractID, contractData *
 	}
 
 	// Estimate a transaction fee and add it to the txn.
-	_, maxFee := w.tpool.FeeEstimation()
+	maxFee := w.tpool.EstimateFee(txnFeeTargetBlocks).FeePerByte
 	txnFee := maxFee.Mul64(uint64(setSize)) // Estimated transaction size in bytes
 	sweepBuilder.AddMinerFee(txnFee)
 
@@ -0,0 +1,102 @@
+package renter
+
+import (
+	"context"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules/renter/filesystem"
+	"go.sia.tech/siad/modules/renter/filesystem/siafile"
+	"go.sia.tech/siad/types"
+)
+
+// managedRepairCorruptChunks repairs the metadata of the chunks of a siafile
+// which don't match their checksum. The pieces which can still be decoded from
+// a corrupted chunk are verified with their hosts and only the confirmed
+// pieces are kept. Chunks without enough confirmed pieces are marked as stuck
+// which makes the repair loop re-upload them from the local file if possible.
+func (r *Renter) managedRepairCorruptChunks(entry *filesystem.FileNode) error {
+	corrupt, err := entry.CorruptChunks()
+	if err != nil {
+		return errors.AddContext(err, "failed to check chunks for corruption")
+	}
+	for _, chunkIndex := range corrupt {
+		pieces, err := entry.RecoverablePieces(chunkIndex)
+		if err != nil {
+			return errors.AddContext(err, "failed to recover pieces of corrupted chunk")
+		}
+		err = entry.RepairChunkMetadata(chunkIndex, r.managedVerifyPieces(pieces))
+		if err != nil {
+			return errors.AddContext(err, "failed to repair metadata of corrupted chunk")
+		}
+		r.log.Printf("Repaired metadata of corrupted chunk %v of siafile %v", chunkIndex, entry.SiaFilePath())
+	}
+	return nil
+}
+
+// managedVerifyPieces asks the hosts of the provided pieces whether they still
+// store them and returns only the pieces which they confirmed. Pieces of hosts
+// without a worker or which don't respond in time are dropped.
+func (r *Renter) managedVerifyPieces(pieces [][]siafile.Piece) [][]siafile.Piece {
+	// Group the roots by host.
+	hostKeys := make(map[string]types.SiaPublicKey)
+	hostRoots := make(map[string][]crypto.Hash)
+	for _, pieceSet := range pieces {
+		for _, piece := range pieceSet {
+			hk := piece.HostPubKey.String()
+			hostKeys[hk] = piece.HostPubKey
+			hostRoots[hk] = append(hostRoots[hk], piece.MerkleRoot)
+		}
+	}
+
+	// Launch a HasSector job for every host.
+	ctx, cancel := context.WithTimeout(r.tg.StopCtx(), corruptChunkVerificationTimeout)
+	defer cancel()
+	responseChan := make(chan *jobHasSectorResponse, len(hostRoots))
+	var launched int
+	for hk, roots := range hostRoots {
+		w, err := r.staticWorkerPool.callWorker(hostKeys[hk])
+		if err != nil {
+			continue
+		}
+		jhs := w.newJobHasSector(ctx, responseChan, roots...)
+		if _, err := w.staticJobHasSectorQueue.callAddWithEstimate(jhs); err != nil {
+			r.log.Debugf("unable to add has sector job to %v, err %v", hk, err)
+			continue
+		}
+		launched++
+	}
+
+	// Collect the roots confirmed by the hosts.
+	confirmed := make(map[string]map[crypto.Hash]struct{})
+LOOP:
+	for i := 0; i < launched; i++ {
+		var resp *jobHasSectorResponse
+		select {
+		case resp = <-responseChan:
+		case <-ctx.Done():
+			break LOOP
+		}
+		if resp.staticErr != nil {
+			continue
+		}
+		hk := resp.staticWorker.staticHostPubKeyStr
+		confirmed[hk] = make(map[crypto.Hash]struct{})
+		for j, available := range resp.staticAvailables {
+			if available && j < len(hostRoots[hk]) {
+				confirmed[hk][hostRoots[hk][j]] = struct{}{}
+			}
+		}
+	}
+
+	// Only keep the confirmed pieces.
+	verified := make([][]siafile.Piece, len(pieces))
+	for pieceIndex, pieceSet := range pieces {
+		for _, piece := range pieceSet {
+			if _, ok := confirmed[piece.HostPubKey.String()][piece.MerkleRoot]; ok {
+				verified[pieceIndex] = append(verified[pieceIndex], piece)
+			}
+		}
+	}
+	return verified
+}
@@ -0,0 +1,99 @@
+package renter
+
+import (
+	"os"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+)
+
+// TestRepairCorruptChunks tests that managedRepairCorruptChunks repairs the
+// metadata of corrupted chunks and drops the pieces which can't be confirmed
+// by the hosts.
+func TestRepairCorruptChunks(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Create a file with a piece for every pieceIndex of the first chunk.
+	siaPath, rsc := testingFileParamsCustom(1, 4)
+	entry, err := rt.renter.createRenterTestFileWithParams(siaPath, rsc, crypto.RandomCipherType())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := entry.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	for pieceIndex := 0; pieceIndex < rsc.NumPieces(); pieceIndex++ {
+		hk := types.SiaPublicKey{
+			Algorithm: types.SignatureEd25519,
+			Key:       fastrand.Bytes(32),
+		}
+		err = entry.AddPiece(hk, 0, uint64(pieceIndex), crypto.Hash{})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Corrupt the first chunk on disk.
+	f, err := os.OpenFile(entry.SiaFilePath(), os.O_RDWR, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.WriteAt([]byte{0xff}, entry.Metadata().ChunkOffset+100)
+	if err := errors.Compose(err, f.Close()); err != nil {
+		t.Fatal(err)
+	}
+	corrupt, err := entry.CorruptChunks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(corrupt) != 1 {
+		t.Fatal("expected 1 corrupted chunk but got", len(corrupt))
+	}
+
+	// Repair the file. The renter has no workers for the hosts of the pieces
+	// so none of them can be confirmed and the chunk should be stuck.
+	if err := rt.renter.managedRepairCorruptChunks(entry); err != nil {
+		t.Fatal(err)
+	}
+	corrupt, err = entry.CorruptChunks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(corrupt) != 0 {
+		t.Fatal("expected no corrupted chunks after repair but got", len(corrupt))
+	}
+	pieces, err := entry.Pieces(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pieceSet := range pieces {
+		if len(pieceSet) != 0 {
+			t.Fatal("unconfirmed pieces should have been dropped")
+		}
+	}
+	stuck, err := entry.StuckChunkByIndex(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stuck {
+		t.Fatal("chunk should be stuck after repair")
+	}
+}
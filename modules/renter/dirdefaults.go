@@ -0,0 +1,121 @@
+package renter
+
+import (
+	"os"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+)
+
+// DirDefaults returns the defaults of a siadir as well as the effective
+// defaults which include the defaults inherited from its parents.
+func (r *Renter) DirDefaults(siaPath modules.SiaPath) (defaults, effective modules.DirDefaults, err error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.DirDefaults{}, modules.DirDefaults{}, err
+	}
+	defer r.tg.Done()
+	md, err := r.staticFileSystem.DirMetadata(siaPath)
+	if err != nil {
+		return modules.DirDefaults{}, modules.DirDefaults{}, err
+	}
+	effective, err = r.managedEffectiveDirDefaults(siaPath)
+	if err != nil {
+		return modules.DirDefaults{}, modules.DirDefaults{}, err
+	}
+	return md.Defaults, effective, nil
+}
+
+// SetDirDefaults sets the defaults of a siadir which are applied to new files
+// created beneath it.
+func (r *Renter) SetDirDefaults(siaPath modules.SiaPath, defaults modules.DirDefaults) (err error) {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if err := defaults.Validate(); err != nil {
+		return errors.AddContext(err, "invalid directory defaults")
+	}
+	dir, err := r.staticFileSystem.OpenSiaDir(siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, dir.Close())
+	}()
+	return dir.SetDefaults(defaults)
+}
+
+// managedEffectiveDirDefaults returns the defaults of a siadir combined with
+// the defaults inherited from its parents. Directories which don't exist yet
+// don't contribute any defaults.
+func (r *Renter) managedEffectiveDirDefaults(siaPath modules.SiaPath) (modules.DirDefaults, error) {
+	var defaults modules.DirDefaults
+	for {
+		md, err := r.staticFileSystem.DirMetadata(siaPath)
+		if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+			return modules.DirDefaults{}, errors.AddContext(err, "failed to get directory metadata")
+		}
+		if err == nil {
+			defaults = defaults.Inherit(md.Defaults)
+		}
+		if siaPath.IsRoot() {
+			return defaults, nil
+		}
+		siaPath, err = siaPath.Dir()
+		if err != nil {
+			return modules.DirDefaults{}, err
+		}
+	}
+}
+
+// managedApplyDirDefaults fills in the upload params of a new file which
+// weren't provided explicitly with the effective defaults of the directory the
+// file is created in. The returned mode is the default file mode or 0 if the
+// directories don't specify one.
+func (r *Renter) managedApplyDirDefaults(up *modules.FileUploadParams) (os.FileMode, error) {
+	dirSiaPath, err := up.SiaPath.Dir()
+	if err != nil {
+		return 0, err
+	}
+	defaults, err := r.managedEffectiveDirDefaults(dirSiaPath)
+	if err != nil {
+		return 0, err
+	}
+	if up.ErasureCode == nil && defaults.DataPieces > 0 {
+		up.ErasureCode, err = modules.NewRSSubCode(defaults.DataPieces, defaults.ParityPieces, crypto.SegmentSize)
+		if err != nil {
+			return 0, errors.AddContext(err, "failed to create erasure coder from directory defaults")
+		}
+	}
+	if len(up.UserTags) == 0 {
+		up.UserTags = defaults.UserTags
+	}
+	if up.Bucket == "" {
+		up.Bucket = defaults.Bucket
+	}
+	return defaults.FileMode, nil
+}
+
+// staticSetUserMetadata persists the user tags, the bucket and the expiry of
+// the upload params in a newly created SiaFile.
+func staticSetUserMetadata(entry *filesystem.FileNode, up modules.FileUploadParams) error {
+	if len(up.UserTags) > 0 {
+		if err := entry.SetUserTags(up.UserTags); err != nil {
+			return errors.AddContext(err, "failed to set user tags")
+		}
+	}
+	if up.Bucket != "" {
+		if err := entry.SetBucket(up.Bucket); err != nil {
+			return errors.AddContext(err, "failed to set bucket")
+		}
+	}
+	if !up.Expiry.Time.IsZero() {
+		if err := entry.SetExpiry(up.Expiry); err != nil {
+			return errors.AddContext(err, "failed to set expiry")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package renter
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/siatest/dependencies"
+)
+
+// TestDirDefaultsInherit is a unit test for DirDefaults.Inherit and
+// DirDefaults.Validate.
+func TestDirDefaultsInherit(t *testing.T) {
+	parent := modules.DirDefaults{
+		DataPieces:   2,
+		ParityPieces: 4,
+		FileMode:     0600,
+		UserTags:     []string{"a", "b"},
+		Bucket:       "parent",
+	}
+	// Empty defaults inherit everything.
+	if dd := (modules.DirDefaults{}).Inherit(parent); !reflect.DeepEqual(dd, parent) {
+		t.Fatal("defaults weren't inherited", dd)
+	}
+	// Set fields override the parent's fields. Tags are replaced.
+	child := modules.DirDefaults{
+		DataPieces:   1,
+		ParityPieces: 1,
+		UserTags:     []string{"c"},
+	}
+	expected := modules.DirDefaults{
+		DataPieces:   1,
+		ParityPieces: 1,
+		FileMode:     0600,
+		UserTags:     []string{"c"},
+		Bucket:       "parent",
+	}
+	if dd := child.Inherit(parent); !reflect.DeepEqual(dd, expected) {
+		t.Fatal("unexpected defaults", dd)
+	}
+
+	// Check validation.
+	tests := []struct {
+		defaults modules.DirDefaults
+		valid    bool
+	}{
+		{modules.DirDefaults{}, true},
+		{parent, true},
+		{modules.DirDefaults{DataPieces: 1}, false},
+		{modules.DirDefaults{ParityPieces: 1}, false},
+		{modules.DirDefaults{FileMode: os.ModeDir | 0600}, false},
+		{modules.DirDefaults{UserTags: []string{""}}, false},
+		{modules.DirDefaults{UserTags: []string{"a,b"}}, false},
+	}
+	for i, test := range tests {
+		if err := test.defaults.Validate(); (err == nil) != test.valid {
+			t.Errorf("%v: expected valid %v but got %v", i, test.valid, err)
+		}
+	}
+}
+
+// TestDirDefaults tests that the defaults of directories are inherited and
+// applied to new files.
+func TestDirDefaults(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTesterWithDependency(t.Name(), &dependencies.DependencyDisableRepairAndHealthLoops{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	// Create a dir with a subdir and set defaults on both.
+	parentPath := modules.RandomSiaPath()
+	childPath, err := parentPath.Join("child")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.CreateDir(childPath, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	parentDefaults := modules.DirDefaults{
+		DataPieces:   2,
+		ParityPieces: 3,
+		FileMode:     0600,
+		UserTags:     []string{"parent"},
+	}
+	if err := r.SetDirDefaults(parentPath, parentDefaults); err != nil {
+		t.Fatal(err)
+	}
+	childDefaults := modules.DirDefaults{
+		UserTags: []string{"child"},
+		Bucket:   "bucket",
+	}
+	if err := r.SetDirDefaults(childPath, childDefaults); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetDirDefaults(childPath, modules.DirDefaults{DataPieces: 1}); err == nil {
+		t.Fatal("invalid defaults shouldn't be accepted")
+	}
+
+	// Check the defaults of the child.
+	defaults, effective, err := r.DirDefaults(childPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(defaults, childDefaults) {
+		t.Fatal("wrong defaults", defaults)
+	}
+	expected := childDefaults.Inherit(parentDefaults)
+	if !reflect.DeepEqual(effective, expected) {
+		t.Fatal("wrong effective defaults", effective)
+	}
+	di, err := r.staticFileSystem.DirInfo(childPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(di.Defaults, childDefaults) {
+		t.Fatal("wrong defaults in dir info", di.Defaults)
+	}
+
+	// Directories which don't exist yet inherit the defaults of their
+	// parents.
+	missingPath, err := childPath.Join("missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	effective, err = r.managedEffectiveDirDefaults(missingPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(effective, expected) {
+		t.Fatal("wrong effective defaults", effective)
+	}
+
+	// Create a file within the child. It should use the defaults.
+	filePath, err := childPath.Join("file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	up := modules.FileUploadParams{
+		SiaPath:    filePath,
+		CipherType: crypto.TypePlain,
+	}
+	if err := r.CreateSparseFile(up, 100); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := r.File(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.FileMode != 0600 || fi.Bucket != "bucket" || !reflect.DeepEqual(fi.UserTags, []string{"child"}) {
+		t.Fatal("defaults weren't applied", fi.FileMode, fi.Bucket, fi.UserTags)
+	}
+	entry, err := r.staticFileSystem.OpenSiaFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ec := entry.ErasureCode()
+	if err := entry.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if ec.MinPieces() != 2 || ec.NumPieces() != 5 {
+		t.Fatal("wrong erasure code", ec.MinPieces(), ec.NumPieces())
+	}
+
+	// Explicitly provided params take precedence over the defaults.
+	up.SiaPath, err = childPath.Join("file2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	up.ErasureCode, err = modules.NewRSSubCode(1, 1, crypto.SegmentSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	up.UserTags = []string{"explicit"}
+	up.Bucket = "explicit"
+	if err := r.CreateSparseFile(up, 100); err != nil {
+		t.Fatal(err)
+	}
+	fi, err = r.File(up.SiaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Bucket != "explicit" || !reflect.DeepEqual(fi.UserTags, []string{"explicit"}) {
+		t.Fatal("explicit params weren't used", fi.Bucket, fi.UserTags)
+	}
+	// Sparse files have the redundancy of their erasure code.
+	if fi.Redundancy != 2 {
+		t.Fatal("explicit erasure code wasn't used", fi.Redundancy)
+	}
+}
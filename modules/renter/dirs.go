@@ -26,7 +26,17 @@ func (r *Renter) DeleteDir(siaPath modules.SiaPath) error {
 		return err
 	}
 	defer r.tg.Done()
-	return r.staticFileSystem.DeleteDir(siaPath)
+
+	// The files of a secondary renter are managed by the primary renter.
+	if r.managedReplicationRole() == modules.ReplicationRoleSecondary {
+		return ErrRenterSecondary
+	}
+	if err := r.staticFileSystem.DeleteDir(siaPath); err != nil {
+		return err
+	}
+	r.staticSearchIndex.callRemoveDir(siaPath)
+	r.managedUpdatePinnedCache(r.staticPinnedCache.callRemoveDir(siaPath))
+	return nil
 }
 
 // DirList lists the directories in a siadir
@@ -65,9 +75,51 @@ func (r *Renter) RenameDir(oldPath, newPath modules.SiaPath) error {
 	}
 	defer r.tg.Done()
 
+	// The files of a secondary renter are managed by the primary renter.
+	if r.managedReplicationRole() == modules.ReplicationRoleSecondary {
+		return ErrRenterSecondary
+	}
+
 	// Special case: do not allow a user to rename a dir to root.
 	if newPath.IsRoot() {
 		return errors.New("cannot rename a file to the root directory")
 	}
-	return r.staticFileSystem.RenameDir(oldPath, newPath)
+	if err := r.staticFileSystem.RenameDir(oldPath, newPath); err != nil {
+		return err
+	}
+	r.staticSearchIndex.callRenameDir(oldPath, newPath)
+	r.managedUpdatePinnedCache(r.staticPinnedCache.callRenameDir(oldPath, newPath))
+	r.staticFileSpending.callRenameDir(oldPath, newPath)
+	return nil
+}
+
+// CopyDir copies an existing directory and all of its contents to a new path.
+// The copied files reference the same pieces on the hosts as the originals
+// which means that they don't need to be uploaded again.
+func (r *Renter) CopyDir(oldPath, newPath modules.SiaPath) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	// Special case: do not allow a user to copy a dir to root.
+	if newPath.IsRoot() {
+		return errors.New("cannot copy a directory to the root directory")
+	}
+	if err := r.staticFileSystem.CopyDir(oldPath, newPath); err != nil {
+		return err
+	}
+	r.staticSearchIndex.callWake()
+
+	// Bubble the parent of the copy to add the copy to its aggregate
+	// metadata.
+	newDirSiaPath, err := newPath.Dir()
+	if err != nil {
+		return err
+	}
+	bubblePaths := r.newUniqueRefreshPaths()
+	if err := bubblePaths.callAdd(newDirSiaPath); err != nil {
+		r.log.Printf("failed to add directory '%v' to bubble paths:  %v", newDirSiaPath, err)
+	}
+	return bubblePaths.callRefreshAll()
 }
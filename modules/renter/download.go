@@ -17,6 +17,7 @@ import (
 	"gitlab.com/NebulousLabs/errors"
 
 	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/modules/renter/filesystem/siafile"
 	"go.sia.tech/siad/types"
@@ -79,6 +80,10 @@ type (
 
 		staticMemoryManager *memoryManager
 
+		// policy determines which hosts are preferred for downloading the
+		// pieces of the download's chunks.
+		policy modules.DownloadPolicy
+
 		// staticSpendingCategory specifies what field to update when we track
 		// the amount of money spent from an ephemeral account
 		staticSpendingCategory spendingCategory
@@ -317,7 +322,9 @@ func (r *Renter) managedDownload(p modules.RenterDownloadParameters) (_ *downloa
 	if err != nil {
 		return nil, err
 	}
-	// Create the download object.
+	// Create the download object. The latency target and overdrive depend on
+	// the policy of the download.
+	latencyTarget, overdrive := downloadPolicySettings(p.Policy)
 	d, err := r.managedNewDownload(downloadParams{
 		destination:       dw,
 		destinationType:   destinationType,
@@ -325,11 +332,12 @@ func (r *Renter) managedDownload(p modules.RenterDownloadParameters) (_ *downloa
 		disableLocalFetch: p.DisableDiskFetch,
 		file:              snap,
 
-		latencyTarget: 25e3 * time.Millisecond, // TODO: high default until full latency support is added.
+		latencyTarget: latencyTarget,
 		length:        p.Length,
 		needsMemory:   true,
 		offset:        p.Offset,
-		overdrive:     3, // TODO: moderate default until full overdrive support is added.
+		overdrive:     overdrive,
+		policy:        p.Policy,
 		priority:      5, // TODO: moderate default until full priority support is added.
 
 		staticMemoryManager:    r.userDownloadMemoryManager, // user initiated download
@@ -414,9 +422,36 @@ func (r *Renter) managedNewDownload(params downloadParams) (*download, error) {
 		return nil
 	})
 
+	// Record the result of the download for the chaos testing mode.
+	d.onComplete(func(err error) error {
+		r.staticChaos.managedRecordOperation(err)
+		return nil
+	})
+
 	return d, nil
 }
 
+// prefetchRoots returns the roots of the pieces the host with the provided key
+// stores for the chunks following chunkIndex. Streams download files in
+// sequential ranges which means that those pieces are likely to be requested
+// from the host next.
+func prefetchRoots(file *siafile.Snapshot, chunkIndex uint64, hostKey string) []crypto.Hash {
+	var roots []crypto.Hash
+	for i := chunkIndex + 1; i <= chunkIndex+downloadPrefetchChunks && i < file.NumChunks(); i++ {
+		if file.IsHole(i) {
+			continue
+		}
+		for _, pieceSet := range file.Pieces(i) {
+			for _, piece := range pieceSet {
+				if piece.HostPubKey.String() == hostKey {
+					roots = append(roots, piece.MerkleRoot)
+				}
+			}
+		}
+	}
+	return roots
+}
+
 // Start starts a download previously created with `managedNewDownload`.
 func (d *download) Start() error {
 	// Nothing more to do for 0-byte files or 0-length downloads.
@@ -450,6 +485,10 @@ func (d *download) Start() error {
 	for chunkIndex := minChunk; chunkIndex <= maxChunk; chunkIndex++ {
 		// Create the map.
 		chunkMaps[chunkIndex-minChunk] = make(map[string]downloadPieceInfo)
+		// Holes don't have any pieces.
+		if params.file.IsHole(chunkIndex) {
+			continue
+		}
 		// Get the pieces for the chunk.
 		pieces := params.file.Pieces(chunkIndex)
 		for pieceIndex, pieceSet := range pieces {
@@ -461,8 +500,9 @@ func (d *download) Start() error {
 					d.r.log.Println("ERROR: Worker has multiple pieces uploaded for the same chunk.", params.file.SiaPath(), chunkIndex, pieceIndex, piece.HostPubKey.String())
 				}
 				chunkMaps[chunkIndex-minChunk][piece.HostPubKey.String()] = downloadPieceInfo{
-					index: uint64(pieceIndex),
-					root:  piece.MerkleRoot,
+					index:    uint64(pieceIndex),
+					root:     piece.MerkleRoot,
+					prefetch: prefetchRoots(params.file, chunkIndex, piece.HostPubKey.String()),
 				}
 			}
 		}
@@ -532,6 +572,12 @@ func (d *download) Start() error {
 		// and once we can assign overdrive dynamically.
 		udc.staticOverdrive = params.overdrive
 
+		// Holes are read as zeroes without downloading anything.
+		if params.file.IsHole(i) {
+			go udc.threadedWriteHole()
+			continue
+		}
+
 		// Add this chunk to the chunk heap, and notify the download loop that
 		// there is work to do.
 		d.r.managedAddChunkToDownloadHeap(udc)
@@ -19,6 +19,10 @@ import (
 type downloadPieceInfo struct {
 	index uint64
 	root  crypto.Hash
+
+	// prefetch contains the roots of the pieces the host stores for the
+	// following chunks of the file.
+	prefetch []crypto.Hash
 }
 
 // unfinishedDownloadChunk contains a chunk for a download that is in progress.
@@ -72,6 +76,12 @@ type unfinishedDownloadChunk struct {
 	workersRemaining  int       // Number of workers still able to fetch the chunk.
 	workersStandby    []*worker // Set of workers that are able to work on this download, but are not needed unless other workers fail.
 
+	// preferredWorkers is the set of workers which are preferred by the
+	// download's policy. Workers that are not in the set are put on standby.
+	// The set is cleared as soon as a preferred worker fails, after which all
+	// workers are treated equally. A nil set means all workers are preferred.
+	preferredWorkers map[string]struct{}
+
 	// Memory management variables.
 	memoryAllocated uint64
 
@@ -239,6 +249,33 @@ func (udc *unfinishedDownloadChunk) threadedRecoverLogicalData() error {
 	return nil
 }
 
+// threadedWriteHole writes the zeroes of a chunk which is part of a hole of
+// the file to the download destination without contacting any hosts.
+func (udc *unfinishedDownloadChunk) threadedWriteHole() {
+	// Recover the zeroes from zero-filled data pieces in batches to avoid
+	// allocating the whole chunk.
+	ec := udc.erasureCode
+	pieces := make([][]byte, ec.NumPieces())
+	for i := 0; i < ec.MinPieces(); i++ {
+		pieces[i] = make([]byte, holeWriteSegments*crypto.SegmentSize)
+	}
+	batchSize := uint64(ec.MinPieces()) * holeWriteSegments * crypto.SegmentSize
+	for written := uint64(0); written < udc.staticFetchLength; written += batchSize {
+		length := udc.staticFetchLength - written
+		if length > batchSize {
+			length = batchSize
+		}
+		err := udc.destination.WritePieces(ec, pieces, 0, udc.staticWriteOffset+int64(written), length)
+		if err != nil {
+			udc.mu.Lock()
+			udc.fail(errors.AddContext(err, "unable to write hole to download destination"))
+			udc.mu.Unlock()
+			return
+		}
+	}
+	udc.managedFinalizeRecovery()
+}
+
 // bytesToRecover returns the number of bytes we need to recover from the
 // erasure coded segments. The number of bytes we need to recover doesn't
 // always match the chunkFetchLength. e.g. a user might want to fetch 500 bytes
@@ -18,6 +18,8 @@ import (
 	"os"
 	"sync/atomic"
 	"time"
+
+	"go.sia.tech/siad/modules"
 )
 
 // downloadChunkHeap is a heap that is sorted first by file priority, then by
@@ -127,8 +129,14 @@ func (r *Renter) managedDistributeDownloadChunkToWorkers(udc *unfinishedDownload
 	// Distribute the chunk to workers, marking the number of workers
 	// that have received the work.
 	r.staticWorkerPool.mu.RLock()
+	workers := make([]*worker, 0, len(r.staticWorkerPool.workers))
+	for _, worker := range r.staticWorkerPool.workers {
+		workers = append(workers, worker)
+	}
+	preferredWorkers := udc.preferredDownloadWorkers(workers)
 	udc.mu.Lock()
 	udc.workersRemaining = len(r.staticWorkerPool.workers)
+	udc.preferredWorkers = preferredWorkers
 	udc.mu.Unlock()
 	for _, worker := range r.staticWorkerPool.workers {
 		go worker.threadedPerformDownloadChunkJob(udc)
@@ -269,6 +277,7 @@ func (r *Renter) managedTryFetchChunkFromDisk(chunk *unfinishedDownloadChunk) bo
 // threadedDownloadLoop utilizes the worker pool to make progress on any queued
 // downloads.
 func (r *Renter) threadedDownloadLoop() {
+	defer modules.RecoverPanic("renter")
 	err := r.tg.Add()
 	if err != nil {
 		return
@@ -0,0 +1,79 @@
+package renter
+
+import (
+	"sort"
+	"time"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// downloadPolicySettings returns the latency target and overdrive of a
+// download with the given policy. Latency downloads use the same settings as
+// streams and cheapest downloads use the same settings as repair downloads.
+func downloadPolicySettings(policy modules.DownloadPolicy) (time.Duration, int) {
+	switch policy {
+	case modules.DownloadPolicyCheapest:
+		return 200e3 * time.Millisecond, 0
+	case modules.DownloadPolicyLatency:
+		return 50 * time.Millisecond, 5
+	default:
+		return 25e3 * time.Millisecond, 3
+	}
+}
+
+// preferredDownloadWorkers returns the set of workers which are preferred to
+// download a piece of the chunk according to the download's policy. For the
+// cheapest policy these are the workers with the lowest expected cost, for the
+// latency policy the workers with the lowest expected job time. As many
+// workers are preferred as the chunk wants to be downloading pieces
+// simultaneously. The balanced policy doesn't prefer any workers in which case
+// 'nil' is returned.
+func (udc *unfinishedDownloadChunk) preferredDownloadWorkers(workers []*worker) map[string]struct{} {
+	policy := udc.download.staticParams.policy
+	if policy != modules.DownloadPolicyCheapest && policy != modules.DownloadPolicyLatency {
+		return nil
+	}
+
+	// Collect the workers which are able to download a piece of the chunk.
+	type candidate struct {
+		hostKey string
+		cost    types.Currency
+		jobTime time.Duration
+	}
+	var candidates []candidate
+	for _, w := range workers {
+		if _, exists := udc.staticChunkMap[w.staticHostPubKeyStr]; !exists {
+			continue
+		}
+		jrq := w.staticJobLowPrioReadQueue
+		candidates = append(candidates, candidate{
+			hostKey: w.staticHostPubKeyStr,
+			cost:    jrq.callExpectedJobCost(udc.staticPieceSize),
+			jobTime: jrq.callExpectedJobTime(udc.staticPieceSize),
+		})
+	}
+
+	// Sort the candidates according to the policy. Workers without a job time
+	// estimate are considered the slowest.
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if policy == modules.DownloadPolicyCheapest {
+			return candidates[i].cost.Cmp(candidates[j].cost) < 0
+		}
+		if candidates[i].jobTime == 0 || candidates[j].jobTime == 0 {
+			return candidates[j].jobTime == 0 && candidates[i].jobTime != 0
+		}
+		return candidates[i].jobTime < candidates[j].jobTime
+	})
+
+	// Prefer the best workers.
+	desired := udc.erasureCode.MinPieces() + udc.staticOverdrive
+	if desired > len(candidates) {
+		desired = len(candidates)
+	}
+	preferred := make(map[string]struct{}, desired)
+	for _, c := range candidates[:desired] {
+		preferred[c.hostKey] = struct{}{}
+	}
+	return preferred
+}
@@ -0,0 +1,82 @@
+package renter
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestPreferredDownloadWorkers is a unit test for preferredDownloadWorkers.
+func TestPreferredDownloadWorkers(t *testing.T) {
+	t.Parallel()
+
+	// Create 6 workers. The cheaper a worker, the slower it is. The last worker
+	// doesn't have a piece of the chunk.
+	var workers []*worker
+	chunkMap := make(map[string]downloadPieceInfo)
+	for i := 0; i < 6; i++ {
+		w := new(worker)
+		w.staticHostPubKeyStr = fmt.Sprint(i)
+		w.newPriceTable()
+		w.staticPriceTable().staticPriceTable = newDefaultPriceTable()
+		w.staticPriceTable().staticPriceTable.DownloadBandwidthCost = types.SiacoinPrecision.Mul64(uint64(i + 1))
+		w.initJobLowPrioReadQueue()
+		w.staticJobLowPrioReadQueue.weightedJobTime1m = float64(time.Duration(6-i) * time.Second)
+		workers = append(workers, w)
+		if i < 5 {
+			chunkMap[w.staticHostPubKeyStr] = downloadPieceInfo{index: uint64(i)}
+		}
+	}
+	// The fastest worker doesn't have an estimate yet.
+	workers[4].staticJobLowPrioReadQueue.weightedJobTime1m = 0
+
+	ec, err := modules.NewRSCode(2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newChunk := func(policy modules.DownloadPolicy) *unfinishedDownloadChunk {
+		latencyTarget, overdrive := downloadPolicySettings(policy)
+		return &unfinishedDownloadChunk{
+			erasureCode:         ec,
+			staticChunkMap:      chunkMap,
+			staticLatencyTarget: latencyTarget,
+			staticOverdrive:     overdrive,
+			staticPieceSize:     1 << 20,
+			download: &download{
+				staticParams: downloadParams{policy: policy},
+			},
+		}
+	}
+	keys := func(m map[string]struct{}) string {
+		var s []string
+		for i := 0; i < len(workers); i++ {
+			if _, exists := m[fmt.Sprint(i)]; exists {
+				s = append(s, fmt.Sprint(i))
+			}
+		}
+		return fmt.Sprint(s)
+	}
+
+	// Balanced downloads don't prefer any workers.
+	if preferred := newChunk(modules.DownloadPolicyBalanced).preferredDownloadWorkers(workers); preferred != nil {
+		t.Fatal("balanced download shouldn't prefer any workers", keys(preferred))
+	}
+	// Cheapest downloads prefer the MinPieces cheapest workers.
+	if preferred := newChunk(modules.DownloadPolicyCheapest).preferredDownloadWorkers(workers); keys(preferred) != "[0 1]" {
+		t.Fatal("unexpected preferred workers", keys(preferred))
+	}
+	// Latency downloads prefer the fastest workers. Since they want more
+	// workers than there are, all workers with a piece are preferred.
+	if preferred := newChunk(modules.DownloadPolicyLatency).preferredDownloadWorkers(workers); keys(preferred) != "[0 1 2 3 4]" {
+		t.Fatal("unexpected preferred workers", keys(preferred))
+	}
+	// Without overdrive, the fastest workers with an estimate are preferred.
+	udc := newChunk(modules.DownloadPolicyLatency)
+	udc.staticOverdrive = 0
+	if preferred := udc.preferredDownloadWorkers(workers); keys(preferred) != "[2 3]" {
+		t.Fatal("unexpected preferred workers", keys(preferred))
+	}
+}
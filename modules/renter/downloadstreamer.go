@@ -166,7 +166,68 @@ func (s *streamer) managedFillCache() bool {
 		fetchLen = fileSize - fetchOffset
 	}
 
-	// Perform the actual download.
+	// Serve the data from the renter's pinned cache if the fetch offset is
+	// pinned. Otherwise download the data.
+	data := s.r.staticPinnedCache.callData(s.staticFile.SiaPath(), s.staticFile.UID(), uint64(fetchOffset), uint64(fetchLen))
+	if data == nil {
+		var ok bool
+		data, ok = s.managedDownload(fetchOffset, fetchLen)
+		if !ok {
+			return false
+		}
+	}
+
+	// Update the cache.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Before updating the cache, check if the stream has caught up in the
+	// current cache. If the stream has caught up, the cache is not filling fast
+	// enough and the target cache size should be increased.
+	//
+	// streamOffsetInTail checks if the stream offset is in the final quarter of
+	// the cache. If it is, we consider the cache to be not filling fast enough,
+	// and we extend the size of the cache.
+	//
+	// A final check for cacheExists is performed, because if there currently is
+	// no cache at all, this must be the first fetch, and there is no reason to
+	// extend the cache size.
+	cacheLen = int64(len(s.cache))
+	streamOffsetInCache := s.cacheOffset <= s.offset && s.offset <= s.cacheOffset+cacheLen // NOTE: it's '<=' so that we also count being 1 byte beyond the cache
+	streamOffsetInTail := streamOffsetInCache && s.offset >= s.cacheOffset+(cacheLen/4)+(cacheLen/2)
+	targetCacheUnderLimit := s.targetCacheSize < maxStreamerCacheSize
+	cacheExists := cacheLen > 0
+	if cacheExists && partialDownloadsSupported && targetCacheUnderLimit && streamOffsetInTail {
+		if s.targetCacheSize*2 > maxStreamerCacheSize {
+			s.targetCacheSize = maxStreamerCacheSize
+		} else {
+			s.targetCacheSize *= 2
+		}
+	}
+
+	// Update the cache based on whether the entire cache needs to be replaced
+	// or whether only some of the cache is being replaced. The whole cache
+	// needs to be replaced in the even that partial downloads are not
+	// supported, and also in the event that the stream offset is complete
+	// outside the previous cache.
+	if !partialDownloadsSupported || streamOffset >= cacheOffset+cacheLen || streamOffset < cacheOffset {
+		s.cache = data
+		s.cacheOffset = fetchOffset
+	} else {
+		s.cache = s.cache[streamOffset-cacheOffset:]
+		s.cache = append(s.cache, data...)
+		s.cacheOffset = streamOffset
+	}
+
+	// Return true, indicating that this function should be called again,
+	// because there may be more cache that has been requested or used since the
+	// previous request.
+	return true
+}
+
+// managedDownload downloads the data for the cache of the streamer. If the
+// download fails, the error is stored in readErr and false is returned.
+func (s *streamer) managedDownload(fetchOffset, fetchLen int64) ([]byte, bool) {
 	buffer := bytes.NewBuffer([]byte{})
 	ddw := newDownloadDestinationWriter(buffer)
 	d, err := s.r.managedNewDownload(downloadParams{
@@ -193,7 +254,7 @@ func (s *streamer) managedFillCache() bool {
 		s.readErr = readErr
 		s.mu.Unlock()
 		s.r.log.Println("Error downloading for stream file:", readErr)
-		return false
+		return nil, false
 	}
 	// Register some cleanup for when the download is done.
 	d.OnComplete(func(_ error) error {
@@ -202,7 +263,7 @@ func (s *streamer) managedFillCache() bool {
 	})
 	// Start the download.
 	if err := d.Start(); err != nil {
-		return false
+		return nil, false
 	}
 	// Block until the download has completed.
 	select {
@@ -215,7 +276,7 @@ func (s *streamer) managedFillCache() bool {
 			s.readErr = readErr
 			s.mu.Unlock()
 			s.r.log.Println("Error during stream download:", readErr)
-			return false
+			return nil, false
 		}
 	case <-s.r.tg.StopChan():
 		stopErr := errors.New("download interrupted by shutdown")
@@ -224,55 +285,9 @@ func (s *streamer) managedFillCache() bool {
 		s.readErr = readErr
 		s.mu.Unlock()
 		s.r.log.Debugln(stopErr)
-		return false
+		return nil, false
 	}
-
-	// Update the cache.
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	// Before updating the cache, check if the stream has caught up in the
-	// current cache. If the stream has caught up, the cache is not filling fast
-	// enough and the target cache size should be increased.
-	//
-	// streamOffsetInTail checks if the stream offset is in the final quarter of
-	// the cache. If it is, we consider the cache to be not filling fast enough,
-	// and we extend the size of the cache.
-	//
-	// A final check for cacheExists is performed, because if there currently is
-	// no cache at all, this must be the first fetch, and there is no reason to
-	// extend the cache size.
-	cacheLen = int64(len(s.cache))
-	streamOffsetInCache := s.cacheOffset <= s.offset && s.offset <= s.cacheOffset+cacheLen // NOTE: it's '<=' so that we also count being 1 byte beyond the cache
-	streamOffsetInTail := streamOffsetInCache && s.offset >= s.cacheOffset+(cacheLen/4)+(cacheLen/2)
-	targetCacheUnderLimit := s.targetCacheSize < maxStreamerCacheSize
-	cacheExists := cacheLen > 0
-	if cacheExists && partialDownloadsSupported && targetCacheUnderLimit && streamOffsetInTail {
-		if s.targetCacheSize*2 > maxStreamerCacheSize {
-			s.targetCacheSize = maxStreamerCacheSize
-		} else {
-			s.targetCacheSize *= 2
-		}
-	}
-
-	// Update the cache based on whether the entire cache needs to be replaced
-	// or whether only some of the cache is being replaced. The whole cache
-	// needs to be replaced in the even that partial downloads are not
-	// supported, and also in the event that the stream offset is complete
-	// outside the previous cache.
-	if !partialDownloadsSupported || streamOffset >= cacheOffset+cacheLen || streamOffset < cacheOffset {
-		s.cache = buffer.Bytes()
-		s.cacheOffset = fetchOffset
-	} else {
-		s.cache = s.cache[streamOffset-cacheOffset:]
-		s.cache = append(s.cache, buffer.Bytes()...)
-		s.cacheOffset = streamOffset
-	}
-
-	// Return true, indicating that this function should be called again,
-	// because there may be more cache that has been requested or used since the
-	// previous request.
-	return true
+	return buffer.Bytes(), true
 }
 
 // threadedFillCache is a background thread that keeps the cache full as data is
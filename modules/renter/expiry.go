@@ -0,0 +1,121 @@
+package renter
+
+import (
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+)
+
+// threadedExpireFiles periodically removes the files of the renter which
+// expired.
+func (r *Renter) threadedExpireFiles() {
+	defer modules.RecoverPanic("renter")
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	for {
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-time.After(fileExpiryCheckInterval):
+		}
+		// The files of a secondary renter are removed by the primary.
+		if r.managedReplicationRole() == modules.ReplicationRoleSecondary {
+			continue
+		}
+		err := r.managedExpireFiles(time.Now())
+		if errors.Contains(err, errSiaFileWalkInterrupted) {
+			return
+		}
+		if err != nil {
+			r.log.Println("WARN: failed to expire files:", err)
+		}
+	}
+}
+
+// managedExpireFiles walks over all the siafiles of the renter and removes the
+// ones which expired at the given time.
+func (r *Renter) managedExpireFiles(now time.Time) error {
+	return r.managedWalkSiaFiles(func(siaPath modules.SiaPath) {
+		fi, err := r.staticFileSystem.CachedFileInfo(siaPath)
+		if errors.Contains(err, filesystem.ErrNotExist) {
+			return
+		}
+		if err != nil {
+			r.log.Printf("WARN: failed to get expiry of siafile %v: %v", siaPath, err)
+			return
+		}
+		if !fi.Expiry.Expired(now) {
+			return
+		}
+		if err := r.managedExpireFile(siaPath, fi.Expiry); err != nil {
+			r.log.Printf("WARN: failed to remove expired siafile %v: %v", siaPath, err)
+			return
+		}
+		r.log.Printf("Removed siafile %v which expired at %v", siaPath, fi.Expiry.Time)
+	})
+}
+
+// managedExpireFile removes an expired file. The file is deleted unless its
+// expiry asks for it to be archived. Archived files are moved into the
+// versions namespace and deleted once the versions are collected. Files which
+// are versions themselves are always deleted.
+func (r *Renter) managedExpireFile(siaPath modules.SiaPath, expiry modules.FileExpiry) error {
+	if !expiry.Archive {
+		return r.DeleteFile(siaPath)
+	}
+	versionsDir, err := fileVersionsDir(siaPath)
+	if errors.Contains(err, errVersionSiaPath) {
+		return r.DeleteFile(siaPath)
+	}
+	if err != nil {
+		return err
+	}
+	// Clear the expiry before archiving the file. Otherwise it would expire
+	// again once it is restored.
+	if err := r.SetFileExpiry(siaPath, modules.FileExpiry{}); err != nil {
+		return errors.AddContext(err, "failed to clear expiry")
+	}
+	if err := r.managedMoveToVersions(siaPath, versionsDir); err != nil {
+		return errors.Compose(err, r.SetFileExpiry(siaPath, expiry))
+	}
+	// Only enforce the limit on the number of versions if versioning is
+	// enabled. Otherwise the archived file would be deleted right away.
+	id := r.mu.RLock()
+	maxVersions := r.persist.MaxFileVersions
+	r.mu.RUnlock(id)
+	if maxVersions == 0 {
+		return nil
+	}
+	return r.managedPruneFileVersions(versionsDir, maxVersions, time.Time{})
+}
+
+// SetFileExpiry sets the time after which the file at siaPath is removed
+// automatically.
+func (r *Renter) SetFileExpiry(siaPath modules.SiaPath, expiry modules.FileExpiry) (err error) {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	// The files of a secondary renter are managed by the primary renter.
+	if r.managedReplicationRole() == modules.ReplicationRoleSecondary {
+		return ErrRenterSecondary
+	}
+
+	// Open the file.
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, entry.Close())
+	}()
+	// Update the file.
+	return entry.SetExpiry(expiry)
+}
@@ -0,0 +1,111 @@
+package renter
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+)
+
+// TestExpireFiles tests that expired files are deleted or archived.
+func TestExpireFiles(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+	_, rsc := testingFileParams()
+
+	// Enable versioning to keep archived files around.
+	id := r.mu.Lock()
+	r.persist.MaxFileVersions = 1
+	r.mu.Unlock(id)
+
+	// Create a file which doesn't expire, one which expires and one which is
+	// archived once it expires.
+	now := time.Now()
+	expiries := []modules.FileExpiry{
+		{},
+		{Time: now},
+		{Time: now, Archive: true},
+	}
+	var paths []modules.SiaPath
+	for _, expiry := range expiries {
+		siaPath := modules.RandomSiaPath()
+		entry, err := r.createRenterTestFileWithParams(siaPath, rsc, crypto.TypePlain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := entry.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.SetFileExpiry(siaPath, expiry); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, siaPath)
+	}
+
+	// Nothing expires before the expiry time.
+	if err := r.managedExpireFiles(now.Add(-time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	for _, siaPath := range paths {
+		if _, err := r.File(siaPath); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Expire the files.
+	if err := r.managedExpireFiles(now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.File(paths[0]); err != nil {
+		t.Fatal(err)
+	}
+	for _, siaPath := range paths[1:] {
+		if _, err := r.File(siaPath); !errors.Contains(err, filesystem.ErrNotExist) {
+			t.Fatal("expected expired file to be removed", err)
+		}
+	}
+
+	// Only the archived file has a version which no longer expires after
+	// being restored.
+	versions, err := r.FileVersions(paths[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 0 {
+		t.Fatal("expected no versions", versions)
+	}
+	versions, err = r.FileVersions(paths[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 1 {
+		t.Fatal("expected 1 version", versions)
+	}
+	if err := r.RestoreFileVersion(paths[2], versions[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := r.File(paths[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fi.Expiry.Time.IsZero() {
+		t.Fatal("restored file shouldn't expire", fi.Expiry)
+	}
+}
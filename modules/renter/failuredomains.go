@@ -0,0 +1,293 @@
+package renter
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem/siafile"
+	"go.sia.tech/siad/types"
+)
+
+// failureDomains caches the failure domains of the hosts the renter has
+// contracts with. Hosts which share a failure domain are likely to become
+// unavailable at the same time, e.g. because they are in the same subnet, use
+// the same network provider or are run by the same operator.
+type failureDomains struct {
+	domains map[string][]string
+	mu      sync.Mutex
+}
+
+// newFailureDomains creates a new, empty failureDomains cache.
+func newFailureDomains() *failureDomains {
+	return &failureDomains{
+		domains: make(map[string][]string),
+	}
+}
+
+// hostFailureDomains returns the failure domains of a host. A host is part of
+// the domain of every subnet it is reachable from, the domain of its network's
+// autonomous system and the domain of the address it announced to receive its
+// payouts, which identifies the operator of the host.
+func hostFailureDomains(entry modules.HostDBEntry) []string {
+	var domains []string
+	for _, ipNet := range entry.IPNets {
+		domains = append(domains, "subnet:"+ipNet)
+	}
+	if entry.ASN != 0 {
+		domains = append(domains, fmt.Sprintf("asn:%d", entry.ASN))
+	}
+	if entry.UnlockHash != (types.UnlockHash{}) {
+		domains = append(domains, "operator:"+entry.UnlockHash.String())
+	}
+	return domains
+}
+
+// managedDomains returns the failure domains of the hosts by host key. The
+// returned map must not be modified.
+func (fd *failureDomains) managedDomains() map[string][]string {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	return fd.domains
+}
+
+// managedUpdate refreshes the failure domains of the hosts of the provided
+// contracts. The map is replaced instead of updated in place to allow for
+// sharing it with the chunks which are being uploaded.
+func (fd *failureDomains) managedUpdate(hdb modules.HostDB, contracts []modules.RenterContract) {
+	domains := make(map[string][]string, len(contracts))
+	for _, c := range contracts {
+		entry, exists, err := hdb.Host(c.HostPublicKey)
+		if err != nil || !exists {
+			continue
+		}
+		domains[c.HostPublicKey.String()] = hostFailureDomains(entry)
+	}
+	fd.mu.Lock()
+	fd.domains = domains
+	fd.mu.Unlock()
+}
+
+// avoidCorrelatedHosts marks the failure domains of a host which stores or
+// uploads a piece of the chunk as used and removes the unused hosts which
+// share one of the used domains. The rule is soft: correlated hosts are only
+// removed if enough other hosts remain to upload the missing pieces.
+func (uc *unfinishedUploadChunk) avoidCorrelatedHosts(hostKey string) {
+	if uc.usedDomains == nil {
+		uc.usedDomains = make(map[string]struct{})
+	}
+	for _, domain := range uc.staticFailureDomains[hostKey] {
+		uc.usedDomains[domain] = struct{}{}
+	}
+	var correlated []string
+	for hk := range uc.unusedHosts {
+		for _, domain := range uc.staticFailureDomains[hk] {
+			if _, used := uc.usedDomains[domain]; used {
+				correlated = append(correlated, hk)
+				break
+			}
+		}
+	}
+	needed := uc.staticPiecesNeeded - uc.piecesCompleted - uc.piecesRegistered
+	if len(correlated) == 0 || len(uc.unusedHosts)-len(correlated) < needed {
+		return
+	}
+	for _, hk := range correlated {
+		delete(uc.unusedHosts, hk)
+	}
+}
+
+// sharedFailureDomains returns the failure domains which contain more than one
+// of the hosts storing the pieces of a chunk, sorted alphabetically.
+func sharedFailureDomains(pieces [][]siafile.Piece, domains map[string][]string) []string {
+	hostsPerDomain := make(map[string]map[string]struct{})
+	for _, pieceSet := range pieces {
+		for _, piece := range pieceSet {
+			hk := piece.HostPubKey.String()
+			for _, domain := range domains[hk] {
+				if hostsPerDomain[domain] == nil {
+					hostsPerDomain[domain] = make(map[string]struct{})
+				}
+				hostsPerDomain[domain][hk] = struct{}{}
+			}
+		}
+	}
+	var shared []string
+	for domain, hosts := range hostsPerDomain {
+		if len(hosts) > 1 {
+			shared = append(shared, domain)
+		}
+	}
+	sort.Strings(shared)
+	return shared
+}
+
+// uncorrelatedPieces returns the pieces of a chunk without the pieces stored
+// on hosts which share a failure domain with the host of a piece that comes
+// before them. It also returns the number of dropped pieces.
+func uncorrelatedPieces(pieces [][]siafile.Piece, domains map[string][]string) ([][]siafile.Piece, int) {
+	kept := make([][]siafile.Piece, len(pieces))
+	keptHosts := make(map[string]struct{})
+	usedDomains := make(map[string]struct{})
+	var dropped int
+	for pieceIndex, pieceSet := range pieces {
+	PIECES:
+		for _, piece := range pieceSet {
+			hk := piece.HostPubKey.String()
+			if _, exists := keptHosts[hk]; !exists {
+				for _, domain := range domains[hk] {
+					if _, used := usedDomains[domain]; used {
+						dropped++
+						continue PIECES
+					}
+				}
+				keptHosts[hk] = struct{}{}
+				for _, domain := range domains[hk] {
+					usedDomains[domain] = struct{}{}
+				}
+			}
+			kept[pieceIndex] = append(kept[pieceIndex], piece)
+		}
+	}
+	return kept, dropped
+}
+
+// FailureDomainReport returns the files of the renter which store several
+// pieces of a chunk on hosts sharing a failure domain.
+func (r *Renter) FailureDomainReport() (modules.RenterFailureDomainReport, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.RenterFailureDomainReport{}, err
+	}
+	defer r.tg.Done()
+	r.staticFailureDomains.managedUpdate(r.hostDB, r.hostContractor.Contracts())
+	domains := r.staticFailureDomains.managedDomains()
+
+	var report modules.RenterFailureDomainReport
+	err := r.managedWalkSiaFiles(func(siaPath modules.SiaPath) {
+		violation, err := r.managedFailureDomainViolation(siaPath, domains)
+		if err != nil {
+			r.log.Printf("WARN: failed to check failure domains of siafile %v: %v", siaPath, err)
+			return
+		}
+		report.FilesChecked++
+		if len(violation.Chunks) > 0 {
+			report.Violations = append(report.Violations, violation)
+		}
+	})
+	return report, err
+}
+
+// managedFailureDomainViolation returns the chunks of a file which store
+// several pieces on hosts sharing a failure domain.
+func (r *Renter) managedFailureDomainViolation(siaPath modules.SiaPath, domains map[string][]string) (violation modules.RenterFailureDomainViolation, err error) {
+	violation.SiaPath = siaPath
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return violation, err
+	}
+	defer func() {
+		err = errors.Compose(err, entry.Close())
+	}()
+	shared := make(map[string]struct{})
+	for chunkIndex := uint64(0); chunkIndex < entry.NumChunks(); chunkIndex++ {
+		pieces, err := entry.Pieces(chunkIndex)
+		if err != nil {
+			return violation, err
+		}
+		chunkShared := sharedFailureDomains(pieces, domains)
+		if len(chunkShared) == 0 {
+			continue
+		}
+		violation.Chunks = append(violation.Chunks, chunkIndex)
+		for _, domain := range chunkShared {
+			shared[domain] = struct{}{}
+		}
+	}
+	for domain := range shared {
+		violation.Domains = append(violation.Domains, domain)
+	}
+	sort.Strings(violation.Domains)
+	return violation, nil
+}
+
+// FixFailureDomainViolations drops the pieces of the provided files' chunks
+// which are stored on hosts sharing a failure domain with the host of another
+// piece of the chunk. The repair loop then re-uploads the dropped pieces to
+// uncorrelated hosts. Chunks which would end up with less than the minimum
+// number of pieces are skipped. If no files are provided, all files are fixed.
+func (r *Renter) FixFailureDomainViolations(siaPaths []modules.SiaPath) (modules.RenterFailureDomainFixReport, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.RenterFailureDomainFixReport{}, err
+	}
+	defer r.tg.Done()
+	r.staticFailureDomains.managedUpdate(r.hostDB, r.hostContractor.Contracts())
+	domains := r.staticFailureDomains.managedDomains()
+
+	var report modules.RenterFailureDomainFixReport
+	if len(siaPaths) > 0 {
+		for _, siaPath := range siaPaths {
+			if err := r.managedFixFailureDomainViolations(siaPath, domains, &report); err != nil {
+				return report, errors.AddContext(err, fmt.Sprintf("failed to fix failure domains of %v", siaPath))
+			}
+		}
+		return report, nil
+	}
+	err := r.managedWalkSiaFiles(func(siaPath modules.SiaPath) {
+		if err := r.managedFixFailureDomainViolations(siaPath, domains, &report); err != nil {
+			r.log.Printf("WARN: failed to fix failure domains of siafile %v: %v", siaPath, err)
+		}
+	})
+	return report, err
+}
+
+// managedFixFailureDomainViolations fixes the chunks of a single file and
+// adds the outcome to the report.
+func (r *Renter) managedFixFailureDomainViolations(siaPath modules.SiaPath, domains map[string][]string, report *modules.RenterFailureDomainFixReport) (err error) {
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, entry.Close())
+	}()
+	minPieces := entry.ErasureCode().MinPieces()
+	var fixed bool
+	for chunkIndex := uint64(0); chunkIndex < entry.NumChunks(); chunkIndex++ {
+		// Partial chunks are shared with other files and can't be changed.
+		if entry.IsIncludedPartialChunk(chunkIndex) || entry.IsIncompletePartialChunk(chunkIndex) {
+			continue
+		}
+		pieces, err := entry.Pieces(chunkIndex)
+		if err != nil {
+			return err
+		}
+		kept, dropped := uncorrelatedPieces(pieces, domains)
+		if dropped == 0 {
+			continue
+		}
+		var uniquePieces int
+		for _, pieceSet := range kept {
+			if len(pieceSet) > 0 {
+				uniquePieces++
+			}
+		}
+		if uniquePieces < minPieces {
+			report.SkippedChunks++
+			continue
+		}
+		if err := entry.RepairChunkMetadata(chunkIndex, kept); err != nil {
+			return err
+		}
+		r.log.Printf("Dropped %v correlated pieces of chunk %v of siafile %v", dropped, chunkIndex, siaPath)
+		report.FixedChunks++
+		report.DroppedPieces += uint64(dropped)
+		fixed = true
+	}
+	if !fixed {
+		return nil
+	}
+	return r.managedQueueBubbleForFile(siaPath)
+}
@@ -0,0 +1,116 @@
+package renter
+
+import (
+	"reflect"
+	"testing"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem/siafile"
+	"go.sia.tech/siad/types"
+)
+
+// TestHostFailureDomains is a unit test for hostFailureDomains.
+func TestHostFailureDomains(t *testing.T) {
+	t.Parallel()
+
+	var entry modules.HostDBEntry
+	if domains := hostFailureDomains(entry); len(domains) != 0 {
+		t.Fatal("host without information shouldn't have domains", domains)
+	}
+	entry.IPNets = []string{"1.2.3.0/24", "2001:db8::/54"}
+	entry.ASN = 13335
+	entry.UnlockHash = types.UnlockHash{1}
+	expected := []string{
+		"subnet:1.2.3.0/24",
+		"subnet:2001:db8::/54",
+		"asn:13335",
+		"operator:" + types.UnlockHash{1}.String(),
+	}
+	if domains := hostFailureDomains(entry); !reflect.DeepEqual(domains, expected) {
+		t.Fatal("wrong domains", domains)
+	}
+}
+
+// TestFailureDomainPieces is a unit test for sharedFailureDomains and
+// uncorrelatedPieces.
+func TestFailureDomainPieces(t *testing.T) {
+	t.Parallel()
+
+	// Create 4 hosts where host 0 and 1 share a subnet and host 1 and 3
+	// share an operator.
+	var hosts []types.SiaPublicKey
+	for i := 0; i < 4; i++ {
+		hosts = append(hosts, types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{byte(i)}})
+	}
+	domains := map[string][]string{
+		hosts[0].String(): {"subnet:a"},
+		hosts[1].String(): {"subnet:a", "operator:x"},
+		hosts[2].String(): {"subnet:b"},
+		hosts[3].String(): {"subnet:c", "operator:x"},
+	}
+	piece := func(host int) siafile.Piece {
+		return siafile.Piece{HostPubKey: hosts[host]}
+	}
+
+	// A chunk with uncorrelated hosts isn't changed.
+	pieces := [][]siafile.Piece{{piece(0)}, {piece(2)}, {piece(3)}}
+	if shared := sharedFailureDomains(pieces, domains); len(shared) != 0 {
+		t.Fatal("unexpected shared domains", shared)
+	}
+	if kept, dropped := uncorrelatedPieces(pieces, domains); dropped != 0 || !reflect.DeepEqual(kept, pieces) {
+		t.Fatal("pieces shouldn't be dropped", kept, dropped)
+	}
+
+	// A host storing multiple pieces doesn't share a domain with itself.
+	pieces = [][]siafile.Piece{{piece(1)}, {piece(1)}, {piece(2)}}
+	if shared := sharedFailureDomains(pieces, domains); len(shared) != 0 {
+		t.Fatal("unexpected shared domains", shared)
+	}
+
+	// Correlated hosts are detected and the pieces of later hosts dropped.
+	pieces = [][]siafile.Piece{{piece(0)}, {piece(1), piece(2)}, {piece(3)}}
+	shared := sharedFailureDomains(pieces, domains)
+	if !reflect.DeepEqual(shared, []string{"operator:x", "subnet:a"}) {
+		t.Fatal("wrong shared domains", shared)
+	}
+	kept, dropped := uncorrelatedPieces(pieces, domains)
+	expected := [][]siafile.Piece{{piece(0)}, {piece(2)}, {piece(3)}}
+	if dropped != 1 || !reflect.DeepEqual(kept, expected) {
+		t.Fatal("wrong pieces kept", kept, dropped)
+	}
+}
+
+// TestAvoidCorrelatedHosts is a unit test for avoidCorrelatedHosts.
+func TestAvoidCorrelatedHosts(t *testing.T) {
+	t.Parallel()
+
+	newChunk := func(piecesNeeded int) *unfinishedUploadChunk {
+		return &unfinishedUploadChunk{
+			staticPiecesNeeded: piecesNeeded,
+			staticFailureDomains: map[string][]string{
+				"a": {"subnet:1"},
+				"b": {"subnet:1"},
+				"c": {"subnet:2"},
+				"d": {"subnet:3"},
+			},
+			unusedHosts: map[string]struct{}{"b": {}, "c": {}, "d": {}},
+		}
+	}
+
+	// Host b shares a subnet with a and is removed since c and d are enough
+	// to upload the missing pieces.
+	uc := newChunk(3)
+	uc.piecesCompleted = 1
+	uc.avoidCorrelatedHosts("a")
+	if _, exists := uc.unusedHosts["b"]; exists || len(uc.unusedHosts) != 2 {
+		t.Fatal("correlated host should have been removed", uc.unusedHosts)
+	}
+
+	// If the remaining hosts aren't enough, the correlated host is kept.
+	uc = newChunk(4)
+	uc.piecesCompleted = 1
+	uc.avoidCorrelatedHosts("a")
+	if len(uc.unusedHosts) != 3 {
+		t.Fatal("correlated host shouldn't have been removed", uc.unusedHosts)
+	}
+}
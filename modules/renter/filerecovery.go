@@ -0,0 +1,203 @@
+package renter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/contractor"
+	"go.sia.tech/siad/modules/renter/filesystem/siafile"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errRecoverPartialChunks is returned when trying to recover a file with
+	// partial chunks. The pieces of partial chunks are stored in a combined
+	// chunk which isn't described by the file's metadata.
+	errRecoverPartialChunks = errors.New("can't recover a file with partial chunks")
+
+	// errRecoverNotEnoughPieces is returned when fewer pieces than required to
+	// recover a chunk could be downloaded.
+	errRecoverNotEnoughPieces = errors.New("not enough pieces could be downloaded to recover the chunk")
+)
+
+// fileRecovery downloads the sectors of a file directly from the hosts
+// storing them. Unlike a regular download, it doesn't rely on the workers and
+// only needs a contract with the hosts. The sessions are opened on demand and
+// hosts which can't be reached are skipped for the rest of the recovery. A
+// fileRecovery is not safe for concurrent use.
+type fileRecovery struct {
+	staticRenter   *Renter
+	staticSnapshot *siafile.Snapshot
+
+	sessions    map[string]contractor.Session
+	unreachable map[string]types.SiaPublicKey
+}
+
+// RecoverFile writes the data of the file at siaPath to w by downloading its
+// sectors directly from the renter's contracts.
+func (r *Renter) RecoverFile(siaPath modules.SiaPath, w io.Writer) (modules.FileRecoveryReport, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.FileRecoveryReport{}, err
+	}
+	defer r.tg.Done()
+
+	// Create a snapshot of the file.
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return modules.FileRecoveryReport{}, err
+	}
+	snap, err := entry.Snapshot(siaPath)
+	if err := errors.Compose(err, entry.Close()); err != nil {
+		return modules.FileRecoveryReport{}, err
+	}
+	return r.managedRecoverSnapshot(snap, w)
+}
+
+// RecoverExportedFile writes the data of a file which was exported using
+// ExportFile to w by downloading its sectors directly from the renter's
+// contracts. The file doesn't need to be known to the renter.
+func (r *Renter) RecoverExportedFile(export io.Reader, w io.Writer) (modules.FileRecoveryReport, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.FileRecoveryReport{}, err
+	}
+	defer r.tg.Done()
+
+	snap, err := siafile.ImportSnapshot(modules.RootSiaPath(), export)
+	if err != nil {
+		return modules.FileRecoveryReport{}, errors.AddContext(err, "failed to read exported file")
+	}
+	return r.managedRecoverSnapshot(snap, w)
+}
+
+// managedRecoverSnapshot recovers the chunks of the snapshot one at a time
+// and writes them to w. Chunks which can't be recovered are written as zeros
+// to preserve the offsets of the remaining data.
+func (r *Renter) managedRecoverSnapshot(snap *siafile.Snapshot, w io.Writer) (report modules.FileRecoveryReport, err error) {
+	if len(snap.PartialChunks()) > 0 {
+		return modules.FileRecoveryReport{}, errRecoverPartialChunks
+	}
+	fr := &fileRecovery{
+		staticRenter:   r,
+		staticSnapshot: snap,
+		sessions:       make(map[string]contractor.Session),
+		unreachable:    make(map[string]types.SiaPublicKey),
+	}
+	defer func() {
+		err = errors.Compose(err, fr.close())
+	}()
+
+	report.Size = snap.Size()
+	chunkSize := snap.ChunkSize()
+	for chunkIndex := uint64(0); chunkIndex < snap.NumChunks(); chunkIndex++ {
+		select {
+		case <-r.tg.StopChan():
+			return modules.FileRecoveryReport{}, errors.New("renter shut down before the file was recovered")
+		default:
+		}
+		offset := chunkIndex * chunkSize
+		if offset >= report.Size {
+			break
+		}
+		length := chunkSize
+		if offset+length > report.Size {
+			length = report.Size - offset
+		}
+
+		// Holes don't have any pieces.
+		if snap.IsHole(chunkIndex) {
+			if _, err := w.Write(make([]byte, length)); err != nil {
+				return modules.FileRecoveryReport{}, errors.AddContext(err, "failed to write hole")
+			}
+			continue
+		}
+
+		var buf bytes.Buffer
+		err := fr.recoverChunk(chunkIndex, length, &buf)
+		if err != nil {
+			r.log.Printf("WARN: failed to recover chunk %v of %v: %v", chunkIndex, snap.SiaPath(), err)
+			report.LostChunks = append(report.LostChunks, chunkIndex)
+			buf.Reset()
+			buf.Write(make([]byte, length))
+		} else {
+			report.RecoveredChunks++
+		}
+		if _, err := buf.WriteTo(w); err != nil {
+			return modules.FileRecoveryReport{}, errors.AddContext(err, fmt.Sprintf("failed to write chunk %v", chunkIndex))
+		}
+	}
+	for _, hpk := range fr.unreachable {
+		report.UnreachableHosts = append(report.UnreachableHosts, hpk)
+	}
+	return report, nil
+}
+
+// recoverChunk downloads the minimum number of pieces required to
+// recover the chunk with the given index and writes the first length bytes of
+// the chunk to w.
+func (fr *fileRecovery) recoverChunk(chunkIndex, length uint64, w io.Writer) error {
+	snap := fr.staticSnapshot
+	ec := snap.ErasureCode()
+	pieces := make([][]byte, ec.NumPieces())
+	downloaded := 0
+	for pieceIndex, pieceSet := range snap.Pieces(chunkIndex) {
+		for _, piece := range pieceSet {
+			data, err := fr.downloadPiece(piece)
+			if err != nil {
+				continue
+			}
+			key := snap.MasterKey().Derive(chunkIndex, uint64(pieceIndex))
+			if _, err := key.DecryptBytesInPlace(data, 0); err != nil {
+				return errors.AddContext(err, "failed to decrypt piece")
+			}
+			pieces[pieceIndex] = data
+			downloaded++
+			break
+		}
+		if downloaded == ec.MinPieces() {
+			break
+		}
+	}
+	if downloaded < ec.MinPieces() {
+		return errRecoverNotEnoughPieces
+	}
+	return ec.Recover(pieces, length, w)
+}
+
+// downloadPiece downloads a piece from the host storing it. The
+// Merkle root of the data is verified by the session.
+func (fr *fileRecovery) downloadPiece(piece siafile.Piece) ([]byte, error) {
+	hostKey := piece.HostPubKey.String()
+	if _, unreachable := fr.unreachable[hostKey]; unreachable {
+		return nil, errors.New("host is unreachable")
+	}
+	session, exists := fr.sessions[hostKey]
+	if !exists {
+		var err error
+		session, err = fr.staticRenter.hostContractor.Session(piece.HostPubKey, fr.staticRenter.tg.StopChan())
+		if err != nil {
+			fr.unreachable[hostKey] = piece.HostPubKey
+			return nil, errors.AddContext(err, "failed to open session")
+		}
+		fr.sessions[hostKey] = session
+	}
+	data, err := session.Download(piece.MerkleRoot, 0, uint32(fr.staticSnapshot.PieceSize()))
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to download piece")
+	}
+	if uint64(len(data)) != fr.staticSnapshot.PieceSize() {
+		return nil, fmt.Errorf("expected %v bytes but got %v", fr.staticSnapshot.PieceSize(), len(data))
+	}
+	return data, nil
+}
+
+// close closes all the sessions opened during the recovery.
+func (fr *fileRecovery) close() (err error) {
+	for _, session := range fr.sessions {
+		err = errors.Compose(err, session.Close())
+	}
+	return err
+}
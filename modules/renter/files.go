@@ -1,11 +1,20 @@
 package renter
 
 import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
 	"go.sia.tech/siad/modules"
 
 	"gitlab.com/NebulousLabs/errors"
 )
 
+// errSiaFileWalkInterrupted is returned by managedWalkSiaFiles if the renter
+// shut down while the siafiles were being walked.
+var errSiaFileWalkInterrupted = errors.New("walking the siafiles was interrupted by shutdown")
+
 // DeleteFile removes a file entry from the renter and deletes its data from
 // the hosts it is stored on.
 func (r *Renter) DeleteFile(siaPath modules.SiaPath) error {
@@ -15,11 +24,18 @@ func (r *Renter) DeleteFile(siaPath modules.SiaPath) error {
 	}
 	defer r.tg.Done()
 
+	// The files of a secondary renter are managed by the primary renter.
+	if r.managedReplicationRole() == modules.ReplicationRoleSecondary {
+		return ErrRenterSecondary
+	}
+
 	// Perform the delete operation.
 	err = r.staticFileSystem.DeleteFile(siaPath)
 	if err != nil {
 		return errors.AddContext(err, "unable to delete siafile from filesystem")
 	}
+	r.staticSearchIndex.callRemove(siaPath)
+	r.managedUpdatePinnedCache(r.staticPinnedCache.callRemove(siaPath))
 
 	// Update the filesystem metadata.
 	//
@@ -94,11 +110,19 @@ func (r *Renter) RenameFile(currentName, newName modules.SiaPath) error {
 	}
 	defer r.tg.Done()
 
+	// The files of a secondary renter are managed by the primary renter.
+	if r.managedReplicationRole() == modules.ReplicationRoleSecondary {
+		return ErrRenterSecondary
+	}
+
 	// Rename file.
 	err := r.staticFileSystem.RenameFile(currentName, newName)
 	if err != nil {
 		return err
 	}
+	r.staticSearchIndex.callRename(currentName, newName)
+	r.managedUpdatePinnedCache(r.staticPinnedCache.callRename(currentName, newName))
+	r.staticFileSpending.callRename(currentName, newName)
 
 	// Call callThreadedBubbleMetadata on the old and new directories to make
 	// sure the system metadata is updated to reflect the move.
@@ -122,6 +146,53 @@ func (r *Renter) RenameFile(currentName, newName modules.SiaPath) error {
 	return bubblePaths.callRefreshAll()
 }
 
+// ExportFile writes the metadata of a file, including its host public keys and
+// piece mappings, to w in a portable format which can be imported again using
+// ImportFile.
+func (r *Renter) ExportFile(siaPath modules.SiaPath, w io.Writer) (err error) {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	// Open the file.
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, entry.Close())
+	}()
+	return entry.Export(w)
+}
+
+// ImportFile adds a file which was previously exported using ExportFile to the
+// renter. If a different file already exists at siaPath, a suffix is added to
+// the path of the imported file.
+func (r *Renter) ImportFile(siaPath modules.SiaPath, src io.Reader) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	err := r.staticFileSystem.ImportSiaFile(src, siaPath)
+	if err != nil {
+		return err
+	}
+	// The imported file might have been renamed to avoid a conflict so the
+	// search index is rebuilt instead of adding the file directly.
+	r.staticSearchIndex.callWake()
+	// Update the metadata of the directory the file was imported to.
+	dirSiaPath, err := siaPath.Dir()
+	if err != nil {
+		return err
+	}
+	bubblePaths := r.newUniqueRefreshPaths()
+	err = bubblePaths.callAdd(dirSiaPath)
+	if err != nil {
+		r.log.Printf("failed to add directory '%v' to bubble paths:  %v", dirSiaPath, err)
+	}
+	return bubblePaths.callRefreshAll()
+}
+
 // SetFileStuck sets the Stuck field of the whole siafile to stuck.
 func (r *Renter) SetFileStuck(siaPath modules.SiaPath, stuck bool) (err error) {
 	if err := r.tg.Add(); err != nil {
@@ -139,3 +210,62 @@ func (r *Renter) SetFileStuck(siaPath modules.SiaPath, stuck bool) (err error) {
 	// Update the file.
 	return entry.SetAllStuck(stuck)
 }
+
+// SetFileHTTPHeaders sets the HTTP headers which are used when serving the
+// file at siaPath over HTTP.
+func (r *Renter) SetFileHTTPHeaders(siaPath modules.SiaPath, headers modules.FileHTTPHeaders) (err error) {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if err := headers.Validate(); err != nil {
+		return err
+	}
+	// Open the file.
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, entry.Close())
+	}()
+	// Update the file.
+	return entry.SetHTTPHeaders(headers)
+}
+
+// managedWalkSiaFiles calls fn for the siapath of every siafile of the renter.
+// Files which can't be stat'ed, e.g. because they were deleted in the
+// meantime, are skipped.
+func (r *Renter) managedWalkSiaFiles(fn func(modules.SiaPath)) error {
+	root := r.staticFileSystem.DirPath(modules.RootSiaPath())
+	return r.staticFileSystem.Walk(modules.RootSiaPath(), func(path string, info os.FileInfo, statErr error) error {
+		// Stop walking if the renter is shutting down.
+		select {
+		case <-r.tg.StopChan():
+			return errSiaFileWalkInterrupted
+		default:
+		}
+		// This error is non-nil if filepath.Walk couldn't stat a file or
+		// folder. The file might have been deleted in the meantime so we
+		// continue with the next one.
+		if statErr != nil {
+			return nil
+		}
+		// Nothing to do for non-siafiles.
+		if info.IsDir() || filepath.Ext(path) != modules.SiaFileExtension {
+			return nil
+		}
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			r.log.Printf("WARN: failed to get relative path of siafile %v: %v", path, err)
+			return nil
+		}
+		siaPath, err := modules.NewSiaPath(filepath.ToSlash(strings.TrimSuffix(relPath, modules.SiaFileExtension)))
+		if err != nil {
+			r.log.Printf("WARN: failed to get siapath of siafile %v: %v", path, err)
+			return nil
+		}
+		fn(siaPath)
+		return nil
+	})
+}
@@ -134,6 +134,17 @@ func (n *DirNode) Path() (string, error) {
 	return sd.Path(), nil
 }
 
+// SetDefaults is a wrapper for SiaDir.SetDefaults.
+func (n *DirNode) SetDefaults(defaults modules.DirDefaults) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	sd, err := n.siaDir()
+	if err != nil {
+		return err
+	}
+	return sd.SetDefaults(defaults)
+}
+
 // UpdateBubbledMetadata is a wrapper for SiaDir.UpdateBubbledMetadata.
 func (n *DirNode) UpdateBubbledMetadata(md siadir.Metadata) error {
 	n.mu.Lock()
@@ -353,7 +364,7 @@ func (n *DirNode) managedNewSiaFileFromExisting(sf *siafile.SiaFile, chunks siaf
 	// Add the node to the dir.
 	fileName := strings.TrimSuffix(filepath.Base(currentPath), modules.SiaFileExtension)
 	fn := &FileNode{
-		node:    newNode(n, currentPath, fileName, 0, n.staticWal, n.staticLog),
+		node:    newNode(n, currentPath, fileName, 0, n.staticWALs, n.staticLog),
 		SiaFile: sf,
 	}
 	n.files[fileName] = fn
@@ -376,13 +387,13 @@ func (n *DirNode) managedNewSiaFileFromLegacyData(fileName string, fd siafile.Fi
 		return nil, ErrExists
 	}
 	// Otherwise create the file.
-	sf, err := siafile.NewFromLegacyData(fd, path, n.staticWal)
+	sf, err := siafile.NewFromLegacyData(fd, path, n.staticWALs.WAL(path))
 	if err != nil {
 		return nil, err
 	}
 	// Add it to the node.
 	fn := &FileNode{
-		node:    newNode(n, path, key, 0, n.staticWal, n.staticLog),
+		node:    newNode(n, path, key, 0, n.staticWALs, n.staticLog),
 		SiaFile: sf,
 	}
 	n.files[key] = fn
@@ -609,6 +620,7 @@ func (n *DirNode) managedInfo(siaPath modules.SiaPath) (modules.DirectoryInfo, e
 		StuckSize:           metadata.StuckSize,
 		SiaPath:             siaPath,
 		UID:                 n.staticUID,
+		Defaults:            metadata.Defaults,
 	}, nil
 }
 
@@ -656,7 +668,8 @@ func (n *DirNode) managedNewSiaFile(fileName string, source string, ec modules.E
 	if exists := n.childExists(fileName); exists {
 		return ErrExists
 	}
-	_, err := siafile.New(filepath.Join(n.absPath(), fileName+modules.SiaFileExtension), source, n.staticWal, ec, mk, fileSize, fileMode, nil, disablePartialUpload)
+	path := filepath.Join(n.absPath(), fileName+modules.SiaFileExtension)
+	_, err := siafile.New(path, source, n.staticWALs.WAL(path), ec, mk, fileSize, fileMode, nil, disablePartialUpload)
 	return errors.AddContext(err, "NewSiaFile: failed to create file")
 }
 
@@ -713,7 +726,7 @@ func (n *DirNode) readonlyOpenFile(fileName string) (*FileNode, error) {
 	}
 	// Load file from disk.
 	filePath := filepath.Join(n.absPath(), fileName+modules.SiaFileExtension)
-	sf, err := siafile.LoadSiaFile(filePath, n.staticWal)
+	sf, err := siafile.LoadSiaFile(filePath, n.staticWALs.WAL(filePath))
 	if errors.Contains(err, siafile.ErrUnknownPath) || os.IsNotExist(err) {
 		return nil, ErrNotExist
 	}
@@ -721,7 +734,7 @@ func (n *DirNode) readonlyOpenFile(fileName string) (*FileNode, error) {
 		return nil, errors.AddContext(err, fmt.Sprintf("failed to load SiaFile '%v' from disk", filePath))
 	}
 	fn = &FileNode{
-		node:    newNode(n, filePath, fileName, 0, n.staticWal, n.staticLog),
+		node:    newNode(n, filePath, fileName, 0, n.staticWALs, n.staticLog),
 		SiaFile: sf,
 	}
 	// Clone the node, give it a new UID and return it.
@@ -755,7 +768,7 @@ func (n *DirNode) openDir(dirName string) (*DirNode, error) {
 	}
 	// Add the dir to the opened dirs.
 	dir = &DirNode{
-		node:        newNode(n, dirPath, dirName, 0, n.staticWal, n.staticLog),
+		node:        newNode(n, dirPath, dirName, 0, n.staticWALs, n.staticLog),
 		directories: make(map[string]*DirNode),
 		files:       make(map[string]*FileNode),
 		lazySiaDir:  new(*siadir.SiaDir),
@@ -885,18 +898,19 @@ func (n *DirNode) managedRename(newName string, oldParent, newParent *DirNode) e
 		dirsToLock = append(dirsToLock, d.childDirs()...)
 	}
 	newBase := filepath.Join(newParent.absPath(), newName)
-	// Rename the dir.
+	// Rename the dir. The rename is committed to the WAL first to make sure an
+	// interrupted rename is completed on startup.
 	dir, err := n.siaDir()
 	if err != nil {
 		return err
 	}
-	err = dir.Rename(newBase)
-	if os.IsExist(err) {
-		return ErrExists
-	}
+	err = createAndApplyTransaction(n.staticWALs.WAL(newBase), createRenameUpdate(n.absPath(), newBase))
 	if err != nil {
 		return err
 	}
+	if err := dir.SetPath(newBase); err != nil {
+		return err
+	}
 	// Remove dir from old parent and add it to new parent.
 	oldParent.removeDir(n)
 	// Update parent and name.
@@ -4,6 +4,7 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"time"
 
 	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/build"
@@ -137,19 +138,26 @@ func (n *FileNode) managedFileInfo(siaPath modules.SiaPath, offline map[string]b
 		return modules.FileInfo{}, errors.AddContext(err, "failed to get upload progress and bytes")
 	}
 	maxHealth := math.Max(health, stuckHealth)
+	lastVerified, modTime := n.LastVerifiedTime(), n.ModTime()
 	fileInfo := modules.FileInfo{
 		AccessTime:       n.AccessTime(),
 		Available:        redundancy >= 1,
+		Bucket:           n.Bucket(),
 		ChangeTime:       n.ChangeTime(),
 		CipherType:       n.MasterKey().Type().String(),
 		CreateTime:       n.CreateTime(),
 		Expiration:       n.Expiration(contracts),
+		FileMode:         n.Mode(),
 		Filesize:         n.Size(),
 		Health:           health,
+		HTTPHeaders:      n.HTTPHeaders(),
+		Expiry:           n.Expiry(),
+		RepairPriority:   n.RepairPriority(),
+		LastVerifiedTime: lastVerified,
 		LocalPath:        localPath,
 		MaxHealth:        maxHealth,
 		MaxHealthPercent: modules.HealthPercentage(maxHealth),
-		ModificationTime: n.ModTime(),
+		ModificationTime: modTime,
 		NumStuckChunks:   numStuckChunks,
 		OnDisk:           onDisk,
 		Recoverable:      onDisk || redundancy >= 1,
@@ -163,6 +171,8 @@ func (n *FileNode) managedFileInfo(siaPath modules.SiaPath, offline map[string]b
 		UID:              n.staticUID,
 		UploadedBytes:    uploadedBytes,
 		UploadProgress:   uploadProgress,
+		UserTags:         n.UserTags(),
+		Verified:         verified(lastVerified, modTime),
 	}
 	return fileInfo, nil
 }
@@ -224,12 +234,18 @@ func (n *FileNode) staticCachedInfo(siaPath modules.SiaPath) (modules.FileInfo,
 	fileInfo := modules.FileInfo{
 		AccessTime:       md.AccessTime,
 		Available:        md.CachedUserRedundancy >= 1,
+		Bucket:           md.Bucket,
 		ChangeTime:       md.ChangeTime,
 		CipherType:       md.StaticMasterKeyType.String(),
 		CreateTime:       md.CreateTime,
 		Expiration:       md.CachedExpiration,
+		FileMode:         md.Mode,
 		Filesize:         uint64(md.FileSize),
 		Health:           md.CachedHealth,
+		HTTPHeaders:      md.HTTPHeaders,
+		Expiry:           md.Expiry,
+		RepairPriority:   md.RepairPriority,
+		LastVerifiedTime: md.LastVerifiedTime,
 		LocalPath:        localPath,
 		MaxHealth:        maxHealth,
 		MaxHealthPercent: modules.HealthPercentage(maxHealth),
@@ -247,6 +263,14 @@ func (n *FileNode) staticCachedInfo(siaPath modules.SiaPath) (modules.FileInfo,
 		UID:              n.staticUID,
 		UploadedBytes:    md.CachedUploadedBytes,
 		UploadProgress:   md.CachedUploadProgress,
+		UserTags:         append([]string(nil), md.UserTags...),
+		Verified:         verified(md.LastVerifiedTime, md.ModTime),
 	}
 	return fileInfo, nil
 }
+
+// verified returns whether the uploaded data of a file was verified against its
+// local copy after the last modification of its content.
+func verified(lastVerified, modTime time.Time) bool {
+	return !lastVerified.IsZero() && !lastVerified.Before(modTime)
+}
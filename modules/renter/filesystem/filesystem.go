@@ -12,7 +12,6 @@ import (
 
 	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
-	"gitlab.com/NebulousLabs/writeaheadlog"
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
@@ -45,14 +44,14 @@ type (
 	// node is a struct that contains the common fields of every node.
 	node struct {
 		// fields that all copies of a node share.
-		path      *string
-		parent    *DirNode
-		name      *string
-		staticWal *writeaheadlog.WAL
-		threads   map[threadUID]struct{} // tracks all the threadUIDs of evey copy of the node
-		staticLog *persist.Logger
-		staticUID uint64
-		mu        *sync.Mutex
+		path       *string
+		parent     *DirNode
+		name       *string
+		staticWALs *WALPool
+		threads    map[threadUID]struct{} // tracks all the threadUIDs of evey copy of the node
+		staticLog  *persist.Logger
+		staticUID  uint64
+		mu         *sync.Mutex
 
 		// fields that differ between copies of the same node.
 		threadUID threadUID // unique ID of a copy of a node
@@ -61,17 +60,17 @@ type (
 )
 
 // newNode is a convenience function to initialize a node.
-func newNode(parent *DirNode, path, name string, uid threadUID, wal *writeaheadlog.WAL, log *persist.Logger) node {
+func newNode(parent *DirNode, path, name string, uid threadUID, wals *WALPool, log *persist.Logger) node {
 	return node{
-		path:      &path,
-		parent:    parent,
-		name:      &name,
-		staticLog: log,
-		staticUID: newInode(),
-		staticWal: wal,
-		threads:   make(map[threadUID]struct{}),
-		threadUID: uid,
-		mu:        new(sync.Mutex),
+		path:       &path,
+		parent:     parent,
+		name:       &name,
+		staticLog:  log,
+		staticUID:  newInode(),
+		staticWALs: wals,
+		threads:    make(map[threadUID]struct{}),
+		threadUID:  uid,
+		mu:         new(sync.Mutex),
 	}
 }
 
@@ -147,12 +146,13 @@ func (n *node) managedAbsPath() string {
 }
 
 // New creates a new FileSystem at the specified root path. The folder will be
-// created if it doesn't exist already.
-func New(root string, log *persist.Logger, wal *writeaheadlog.WAL) (*FileSystem, error) {
+// created if it doesn't exist already. The updates of the SiaFiles are
+// sharded across the WALs of the provided pool.
+func New(root string, log *persist.Logger, wals *WALPool) (*FileSystem, error) {
 	fs := &FileSystem{
 		DirNode: DirNode{
 			// The root doesn't require a parent, a name or uid.
-			node:        newNode(nil, root, "", 0, wal, log),
+			node:        newNode(nil, root, "", 0, wals, log),
 			directories: make(map[string]*DirNode),
 			files:       make(map[string]*FileNode),
 			lazySiaDir:  new(*siadir.SiaDir),
@@ -174,10 +174,28 @@ func New(root string, log *persist.Logger, wal *writeaheadlog.WAL) (*FileSystem,
 func (fs *FileSystem) AddSiaFileFromReader(rs io.ReadSeeker, siaPath modules.SiaPath) (err error) {
 	// Load the file.
 	path := fs.FilePath(siaPath)
-	sf, chunks, err := siafile.LoadSiaFileFromReaderWithChunks(rs, path, fs.staticWal)
+	sf, chunks, err := siafile.LoadSiaFileFromReaderWithChunks(rs, path, fs.staticWALs.WAL(path))
 	if err != nil {
 		return err
 	}
+	return fs.managedAddSiaFile(sf, chunks, siaPath)
+}
+
+// ImportSiaFile adds a SiaFile which was exported using SiaFile.Export to the
+// set and stores it on disk. Conflicts with existing files are resolved the
+// same way as in AddSiaFileFromReader.
+func (fs *FileSystem) ImportSiaFile(r io.Reader, siaPath modules.SiaPath) error {
+	path := fs.FilePath(siaPath)
+	sf, chunks, err := siafile.Import(r, path, fs.staticWALs.WAL(path))
+	if err != nil {
+		return errors.AddContext(err, "failed to import siafile")
+	}
+	return fs.managedAddSiaFile(sf, chunks, siaPath)
+}
+
+// managedAddSiaFile adds a SiaFile which was loaded from a reader together
+// with its chunks to the set and stores it on disk.
+func (fs *FileSystem) managedAddSiaFile(sf *siafile.SiaFile, chunks siafile.Chunks, siaPath modules.SiaPath) (err error) {
 	// Create dir with same Mode as file if it doesn't exist already and open
 	// it.
 	dirSiaPath, err := siaPath.Dir()
@@ -375,6 +393,18 @@ func (fs *FileSystem) DirSiaPath(n *DirNode) (sp modules.SiaPath) {
 	return fs.managedSiaPath(&n.node)
 }
 
+// DirMetadata returns the metadata of a SiaDir.
+func (fs *FileSystem) DirMetadata(siaPath modules.SiaPath) (_ siadir.Metadata, err error) {
+	dir, err := fs.OpenSiaDir(siaPath)
+	if err != nil {
+		return siadir.Metadata{}, err
+	}
+	defer func() {
+		err = errors.Compose(err, dir.Close())
+	}()
+	return dir.Metadata()
+}
+
 // UpdateDirMetadata updates the metadata of a SiaDir.
 func (fs *FileSystem) UpdateDirMetadata(siaPath modules.SiaPath, metadata siadir.Metadata) (err error) {
 	dir, err := fs.OpenSiaDir(siaPath)
@@ -98,12 +98,13 @@ func newSiaPath(path string) modules.SiaPath {
 
 // newTestFileSystem creates a new filesystem for testing.
 func newTestFileSystem(root string) *FileSystem {
-	wal, _ := newTestWAL()
+	wal1, _ := newTestWAL()
+	wal2, _ := newTestWAL()
 	logger, err := persist.NewLogger(ioutil.Discard)
 	if err != nil {
 		panic(err.Error())
 	}
-	fs, err := New(root, logger, wal)
+	fs, err := New(root, logger, NewWALPool(wal1, wal2))
 	if err != nil {
 		panic(err.Error())
 	}
@@ -1025,7 +1026,7 @@ func TestAddSiaFileFromReader(t *testing.T) {
 		t.Fatal(err)
 	}
 	reader := bytes.NewReader(b)
-	newSF, newChunks, err := siafile.LoadSiaFileFromReaderWithChunks(reader, sf.SiaFilePath(), sfs.staticWal)
+	newSF, newChunks, err := siafile.LoadSiaFileFromReaderWithChunks(reader, sf.SiaFilePath(), sfs.staticWALs.WAL(sf.SiaFilePath()))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1057,7 +1058,7 @@ func TestAddSiaFileFromReader(t *testing.T) {
 	}
 	// Reload newSF with the new expected path.
 	newSFPath := filepath.Join(filepath.Dir(sf.SiaFilePath()), newSFSiaPath.String()+"_1"+modules.SiaFileExtension)
-	newSF, err = siafile.LoadSiaFile(newSFPath, sfs.staticWal)
+	newSF, err = siafile.LoadSiaFile(newSFPath, sfs.staticWALs.WAL(newSFPath))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1101,6 +1102,50 @@ func TestAddSiaFileFromReader(t *testing.T) {
 	}
 }
 
+// TestImportSiaFile tests importing an exported SiaFile into the FileSystem.
+func TestImportSiaFile(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// Create a fileset with file and export the file.
+	sf, sfs, err := newTestFileSystemWithFile(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var export bytes.Buffer
+	if err := sf.Export(&export); err != nil {
+		t.Fatal(err)
+	}
+	siaPath := sfs.FileSiaPath(sf)
+	if err := sf.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Delete the file and import it again.
+	if err := sfs.DeleteFile(siaPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := sfs.ImportSiaFile(bytes.NewReader(export.Bytes()), siaPath); err != nil {
+		t.Fatal(err)
+	}
+	imported, err := sfs.OpenSiaFile(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := imported.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if imported.Size() != sf.Size() || !bytes.Equal(imported.MasterKey().Key(), sf.MasterKey().Key()) {
+		t.Fatal("imported file doesn't match exported file")
+	}
+	// Importing garbage should fail.
+	if err := sfs.ImportSiaFile(bytes.NewReader(fastrand.Bytes(100)), siaPath); err == nil {
+		t.Fatal("importing garbage should fail")
+	}
+}
+
 // TestSiaFileSetDeleteOpen checks that deleting an entry from the set followed
 // by creating a Siafile with the same name without closing the deleted entry
 // works as expected.
@@ -148,11 +148,23 @@ func (sd *SiaDir) SetPath(targetPath string) error {
 func (sd *SiaDir) UpdateBubbledMetadata(metadata Metadata) error {
 	sd.mu.Lock()
 	defer sd.mu.Unlock()
+	metadata.Defaults = sd.metadata.Defaults
 	metadata.Mode = sd.metadata.Mode
 	metadata.Version = sd.metadata.Version
 	return sd.updateMetadata(metadata)
 }
 
+// SetDefaults sets the defaults of the SiaDir which are applied to new files
+// and saves the changes to disk.
+func (sd *SiaDir) SetDefaults(defaults modules.DirDefaults) error {
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+	md := sd.metadata
+	md.Defaults = defaults
+	md.Defaults.UserTags = append([]string(nil), defaults.UserTags...)
+	return sd.updateMetadata(md)
+}
+
 // UpdateLastHealthCheckTime updates the SiaDir LastHealthCheckTime and
 // AggregateLastHealthCheckTime and saves the changes to disk
 func (sd *SiaDir) UpdateLastHealthCheckTime(aggregateLastHealthCheckTime, lastHealthCheckTime time.Time) error {
@@ -225,6 +237,7 @@ func (sd *SiaDir) updateMetadata(metadata Metadata) error {
 	sd.metadata.StuckHealth = metadata.StuckHealth
 	sd.metadata.StuckSize = metadata.StuckSize
 
+	sd.metadata.Defaults = metadata.Defaults
 	sd.metadata.Version = metadata.Version
 
 	// Testing check to ensure new fields aren't missed
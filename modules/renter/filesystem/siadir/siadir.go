@@ -87,6 +87,10 @@ type (
 		StuckHealth         float64     `json:"stuckhealth"`
 		StuckSize           uint64      `json:"stucksize"`
 
+		// Defaults are the defaults which are applied to new files created
+		// beneath the siadir. They are set by the user and not bubbled.
+		Defaults modules.DirDefaults `json:"defaults"`
+
 		// Version is the used version of the header file.
 		Version string `json:"version"`
 	}
@@ -0,0 +1,208 @@
+package siafile
+
+import (
+	"os"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/writeaheadlog"
+)
+
+// compaction contains the compacted state of a SiaFile together with the
+// number of bytes that compacting the file would reclaim.
+type compaction struct {
+	// chunks are the compacted full chunks of the file. Partial chunks are
+	// stored in the partials siafile and therefore not part of a compaction.
+	chunks []chunk
+
+	// pubKeyTable is the garbage-collected pubKeyTable.
+	pubKeyTable []HostPublicKey
+
+	// chunkOffset is the page aligned offset of the first chunk after
+	// shrinking the header to the smallest number of pages possible.
+	chunkOffset int64
+
+	// wasted is the number of bytes within the metadata, pubKeyTable and
+	// chunks which are garbage. total is the number of bytes the file
+	// currently uses for them.
+	wasted int64
+	total  int64
+}
+
+// Compact rewrites the SiaFile with a garbage-collected pubKeyTable, without
+// duplicate pieces and with the smallest header possible. All changes are
+// applied atomically using the writeaheadlog.
+func (sf *SiaFile) Compact() (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if sf.deleted {
+		return errors.AddContext(ErrDeleted, "can't compact deleted file")
+	}
+	c, err := sf.compaction()
+	if err != nil {
+		return errors.AddContext(err, "failed to compute compaction")
+	}
+	// Nothing to do if there is no waste.
+	if c.wasted == 0 {
+		return nil
+	}
+	// Backup the changed metadata before changing it. Revert the change on
+	// error.
+	oldPubKeyTable := append([]HostPublicKey{}, sf.pubKeyTable...)
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+			sf.pubKeyTable = oldPubKeyTable
+		}
+	}(sf.staticMetadata.backup())
+
+	// Get the current size of the file to know how much to truncate when the
+	// header shrinks.
+	fi, err := os.Stat(sf.siaFilePath)
+	if err != nil {
+		return errors.AddContext(err, "failed to stat siafile")
+	}
+	shrinkage := sf.staticMetadata.ChunkOffset - c.chunkOffset
+
+	// Update the header. Since the chunkOffset was computed to fit the new
+	// metadata and pubKeyTable, saveHeaderUpdates won't allocate a new page.
+	sf.pubKeyTable = c.pubKeyTable
+	sf.staticMetadata.ChunkOffset = c.chunkOffset
+	updates, err := sf.saveHeaderUpdates()
+	if err != nil {
+		return errors.AddContext(err, "failed to create header updates")
+	}
+	// Write all the chunks to their new location.
+	for _, chunk := range c.chunks {
+		updates = append(updates, sf.saveChunkUpdate(chunk))
+	}
+	// Cut off the chunk data which is no longer needed after moving the chunks
+	// to the front.
+	if shrinkage > 0 {
+		updates = append(updates, writeaheadlog.TruncateUpdate(sf.siaFilePath, fi.Size()-shrinkage))
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
+// WasteRatio returns the ratio of the bytes within the header and chunks of
+// the SiaFile which would be reclaimed by calling Compact.
+func (sf *SiaFile) WasteRatio() (float64, error) {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	if sf.deleted {
+		return 0, errors.AddContext(ErrDeleted, "can't compute waste ratio of deleted file")
+	}
+	c, err := sf.compaction()
+	if err != nil {
+		return 0, err
+	}
+	if c.total == 0 {
+		return 0, nil
+	}
+	return float64(c.wasted) / float64(c.total), nil
+}
+
+// compaction computes the compacted state of the SiaFile without modifying
+// it. A pubKeyTable entry is garbage if no piece references it or if it is no
+// longer used and the file has enough used hosts. The latter mirrors the
+// condition under which UpdateUsedHosts prunes the table. A piece is garbage
+// if its host was removed from the table or if the same piece is stored
+// multiple times for the same host.
+func (sf *SiaFile) compaction() (c compaction, err error) {
+	// Load the full chunks and find the referenced entries of the table.
+	referenced := make(map[uint32]struct{})
+	err = sf.iterateChunksReadonly(func(chunk chunk) error {
+		if _, ok := sf.isIncludedPartialChunk(uint64(chunk.Index)); ok {
+			return nil
+		}
+		if sf.isIncompletePartialChunk(uint64(chunk.Index)) {
+			return nil
+		}
+		for _, pieceSet := range chunk.Pieces {
+			for _, piece := range pieceSet {
+				referenced[piece.HostTableOffset] = struct{}{}
+			}
+		}
+		c.total += marshaledChunkSize(chunk.numPieces())
+		c.chunks = append(c.chunks, chunk)
+		return nil
+	})
+	if err != nil {
+		return compaction{}, errors.AddContext(err, "failed to read chunks")
+	}
+
+	// Garbage-collect the pubKeyTable.
+	var usedHosts int
+	for _, entry := range sf.pubKeyTable {
+		if entry.Used {
+			usedHosts++
+		}
+	}
+	pruneUnused := usedHosts > sf.staticMetadata.staticErasureCode.NumPieces()
+	offsetMap := make(map[uint32]uint32)
+	for i, entry := range sf.pubKeyTable {
+		_, isReferenced := referenced[uint32(i)]
+		if !isReferenced || (pruneUnused && !entry.Used) {
+			continue
+		}
+		c.pubKeyTable = append(c.pubKeyTable, entry)
+		offsetMap[uint32(i)] = uint32(len(c.pubKeyTable) - 1)
+	}
+
+	// Remove the pieces of removed hosts and duplicate pieces.
+	for _, chunk := range c.chunks {
+		for pieceIndex, pieceSet := range chunk.Pieces {
+			var newPieceSet []piece
+			seen := make(map[piece]struct{})
+			for _, p := range pieceSet {
+				newOffset, exists := offsetMap[p.HostTableOffset]
+				if !exists {
+					continue
+				}
+				p.HostTableOffset = newOffset
+				if _, duplicate := seen[p]; duplicate {
+					continue
+				}
+				seen[p] = struct{}{}
+				newPieceSet = append(newPieceSet, p)
+			}
+			c.wasted += int64(len(pieceSet)-len(newPieceSet)) * marshaledPieceSize
+			chunk.Pieces[pieceIndex] = newPieceSet
+		}
+	}
+
+	// Compute the size of the header after compacting it.
+	oldPubKeyTable, err := marshalPubKeyTable(sf.pubKeyTable)
+	if err != nil {
+		return compaction{}, errors.AddContext(err, "failed to marshal pubkey table")
+	}
+	newPubKeyTable, err := marshalPubKeyTable(c.pubKeyTable)
+	if err != nil {
+		return compaction{}, errors.AddContext(err, "failed to marshal pubkey table")
+	}
+	md := sf.staticMetadata.backup()
+	for c.chunkOffset = pageSize; ; c.chunkOffset += pageSize {
+		md.ChunkOffset = c.chunkOffset
+		md.PubKeyTableOffset = c.chunkOffset - int64(len(newPubKeyTable))
+		metadata, err := marshalMetadata(md)
+		if err != nil {
+			return compaction{}, errors.AddContext(err, "failed to marshal metadata")
+		}
+		if int64(len(metadata))+int64(len(newPubKeyTable)) <= c.chunkOffset {
+			break
+		}
+	}
+	// Never grow the header. This might happen if the metadata grew since the
+	// header was last saved.
+	if c.chunkOffset > sf.staticMetadata.ChunkOffset {
+		c.chunkOffset = sf.staticMetadata.ChunkOffset
+	}
+	// The garbage within the header is either reclaimed by shrinking the
+	// header or, if the header can't shrink, by the smaller pubKeyTable.
+	headerWaste := sf.staticMetadata.ChunkOffset - c.chunkOffset
+	if tableWaste := int64(len(oldPubKeyTable) - len(newPubKeyTable)); tableWaste > headerWaste {
+		headerWaste = tableWaste
+	}
+	c.wasted += headerWaste
+	c.total += sf.staticMetadata.ChunkOffset
+	return c, nil
+}
@@ -0,0 +1,142 @@
+package siafile
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+)
+
+// TestCompact tests that compacting a SiaFile garbage-collects the
+// pubKeyTable, removes duplicate pieces and shrinks the header.
+func TestCompact(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a siafile without partial chunk.
+	siaFilePath, _, source, rc, sk, fileSize, numChunks, fileMode := newTestFileParams(1, false)
+	sf, wal, _ := customTestFileAndWAL(siaFilePath, source, rc, sk, fileSize, numChunks, fileMode)
+
+	// Add enough hostkeys to the file for the header to grow beyond a single
+	// page.
+	sf.addRandomHostKeys(300)
+	updates, err := sf.saveHeaderUpdates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sf.createAndApplyTransaction(updates...); err != nil {
+		t.Fatal(err)
+	}
+	if sf.staticMetadata.ChunkOffset <= pageSize {
+		t.Fatal("header should span multiple pages")
+	}
+
+	// Add a piece for the first 3 hosts to every pieceSet. The piece of the
+	// first host is added twice.
+	hks := sf.HostPublicKeys()
+	for i, hk := range []types.SiaPublicKey{hks[0], hks[1], hks[2], hks[0]} {
+		err := sf.iterateChunksReadonly(func(chunk chunk) error {
+			for pieceIndex := range chunk.Pieces {
+				root := crypto.Hash{byte(i % 3)}
+				if err := sf.AddPiece(hk, uint64(chunk.Index), uint64(pieceIndex), root); err != nil {
+					t.Fatal(err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Mark the second host as unused. Since the file has enough used hosts,
+	// it should be removed from the table.
+	sf.pubKeyTable[1].Used = false
+
+	// There should be waste.
+	ratio, err := sf.WasteRatio()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ratio <= 0 || ratio >= 1 {
+		t.Fatal("invalid waste ratio", ratio)
+	}
+	fi, err := os.Stat(sf.siaFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldChunkOffset := sf.staticMetadata.ChunkOffset
+
+	// Compact the file.
+	if err := sf.Compact(); err != nil {
+		t.Fatal(err)
+	}
+
+	// There should be no waste left.
+	ratio, err = sf.WasteRatio()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ratio != 0 {
+		t.Fatal("expected no waste after compaction but got", ratio)
+	}
+	// The header should be back to a single page and the file should have
+	// shrunk accordingly.
+	if sf.staticMetadata.ChunkOffset != pageSize {
+		t.Fatal("header should have been shrunk to a single page", sf.staticMetadata.ChunkOffset)
+	}
+	fi2, err := os.Stat(sf.siaFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi2.Size() != fi.Size()-(oldChunkOffset-pageSize) {
+		t.Fatalf("expected file size %v but was %v", fi.Size()-(oldChunkOffset-pageSize), fi2.Size())
+	}
+	// Only the first and third host should remain.
+	if len(sf.pubKeyTable) != 2 {
+		t.Fatal("expected 2 hosts in the table but got", len(sf.pubKeyTable))
+	}
+	if !reflect.DeepEqual(sf.pubKeyTable[0].PublicKey, hks[0]) || !reflect.DeepEqual(sf.pubKeyTable[1].PublicKey, hks[2]) {
+		t.Fatal("wrong hosts remaining in table")
+	}
+	// Every pieceSet should contain a single piece of each of the remaining
+	// hosts.
+	checkPieces := func(sf *SiaFile) {
+		err := sf.iterateChunksReadonly(func(chunk chunk) error {
+			for _, pieceSet := range chunk.Pieces {
+				expected := []piece{
+					{HostTableOffset: 0, MerkleRoot: crypto.Hash{0}},
+					{HostTableOffset: 1, MerkleRoot: crypto.Hash{2}},
+				}
+				if !reflect.DeepEqual(pieceSet, expected) {
+					t.Fatal("unexpected pieces", pieceSet)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkPieces(sf)
+
+	// Reload the file and check again.
+	sf2, err := LoadSiaFile(sf.siaFilePath, wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(sf.pubKeyTable, sf2.pubKeyTable) {
+		t.Fatal("pubKeyTable doesn't match after reload")
+	}
+	checkPieces(sf2)
+
+	// Compacting a deleted file should fail.
+	sf.deleted = true
+	if err := sf.Compact(); err == nil {
+		t.Fatal("compacting deleted file should fail")
+	}
+}
@@ -31,15 +31,25 @@ const (
 	marshaledPieceSize = 4 + 4 + crypto.HashSize
 
 	// marshaledChunkOverhead is the size of a marshaled chunk on disk minus the
-	// encoded pieces. It consists of the 16 byte extension info, a 2 byte
-	// length prefix for the pieces, and a 1 byte length for the Stuck field.
-	marshaledChunkOverhead = 16 + 2 + 1
+	// encoded pieces. It consists of the 16 byte checksum, a 2 byte length
+	// prefix for the pieces, and a 1 byte length for the Stuck field.
+	marshaledChunkOverhead = chunkChecksumSize + 2 + 1
+
+	// chunkChecksumSize is the size of the checksum at the beginning of a
+	// marshaled chunk. The checksum uses the space which used to be reserved
+	// for the chunk's extension info.
+	chunkChecksumSize = 16
 
 	// pubKeyTablePruneThreshold is the number of unused hosts a SiaFile can
 	// store in its host key table before it is pruned.
 	pubKeyTablePruneThreshold = 50
 )
 
+// metadataVersionChecksums is the version of the SiaFile format which added
+// checksums to the header and the chunks of a SiaFile. Files of older versions
+// might not have a header checksum.
+var metadataVersionChecksums = [16]byte{1}
+
 // Constants to indicate which part of the partial upload the combined chunk is
 // currently at.
 const (
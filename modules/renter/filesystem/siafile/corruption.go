@@ -0,0 +1,186 @@
+package siafile
+
+import (
+	"fmt"
+	"io"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/writeaheadlog"
+)
+
+// CorruptChunks returns the indices of the full chunks of the SiaFile which
+// don't match their checksum or can't be decoded. Partial chunks are stored in
+// the partials siafile and are not checked.
+func (sf *SiaFile) CorruptChunks() (corrupt []uint64, err error) {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	if sf.deleted {
+		return nil, errors.AddContext(ErrDeleted, "can't check deleted file for corruption")
+	}
+	for chunkIndex := 0; chunkIndex < sf.numChunks; chunkIndex++ {
+		if sf.isPartialChunk(uint64(chunkIndex)) {
+			continue
+		}
+		raw, err := sf.readChunkBytes(chunkIndex)
+		if err != nil {
+			return nil, err
+		}
+		_, err = unmarshalChunk(uint32(sf.staticMetadata.staticErasureCode.NumPieces()), raw, sf.staticMetadata.hasChecksums())
+		if err != nil {
+			corrupt = append(corrupt, uint64(chunkIndex))
+		}
+	}
+	return corrupt, nil
+}
+
+// RecoverablePieces returns the pieces which can still be decoded from a
+// corrupted chunk. The pieces are not guaranteed to be correct and should be
+// verified with the hosts before using them to repair the chunk.
+func (sf *SiaFile) RecoverablePieces(chunkIndex uint64) ([][]Piece, error) {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	if sf.deleted {
+		return nil, errors.AddContext(ErrDeleted, "can't recover pieces of deleted file")
+	}
+	if chunkIndex >= uint64(sf.numChunks) {
+		return nil, fmt.Errorf("index %v out of bounds (%v)", chunkIndex, sf.numChunks)
+	}
+	if sf.isPartialChunk(chunkIndex) {
+		return nil, errors.New("can't recover pieces of partial chunk")
+	}
+	raw, err := sf.readChunkBytes(int(chunkIndex))
+	if err != nil {
+		return nil, err
+	}
+	c := recoverChunk(uint32(sf.staticMetadata.staticErasureCode.NumPieces()), uint32(len(sf.pubKeyTable)), raw)
+	pieces := make([][]Piece, len(c.Pieces))
+	for pieceIndex := range c.Pieces {
+		for _, piece := range c.Pieces[pieceIndex] {
+			pieces[pieceIndex] = append(pieces[pieceIndex], Piece{
+				HostPubKey: sf.pubKeyTable[piece.HostTableOffset].PublicKey,
+				MerkleRoot: piece.MerkleRoot,
+			})
+		}
+	}
+	return pieces, nil
+}
+
+// RepairChunkMetadata replaces the metadata of a corrupted chunk with the
+// provided pieces and a valid checksum. If the pieces are not enough to
+// recover the chunk, it is marked as stuck.
+func (sf *SiaFile) RepairChunkMetadata(chunkIndex uint64, pieces [][]Piece) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if sf.deleted {
+		return errors.AddContext(ErrDeleted, "can't repair chunk of deleted file")
+	}
+	if chunkIndex >= uint64(sf.numChunks) {
+		return fmt.Errorf("index %v out of bounds (%v)", chunkIndex, sf.numChunks)
+	}
+	if sf.isPartialChunk(chunkIndex) {
+		return errors.New("can't repair metadata of partial chunk")
+	}
+	ec := sf.staticMetadata.staticErasureCode
+	if len(pieces) != ec.NumPieces() {
+		return fmt.Errorf("expected %v pieceSets but got %v", ec.NumPieces(), len(pieces))
+	}
+	// Backup the changed metadata before changing it. Revert the change on
+	// error.
+	oldPubKeyTable := append([]HostPublicKey{}, sf.pubKeyTable...)
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+			sf.pubKeyTable = oldPubKeyTable
+		}
+	}(sf.staticMetadata.backup())
+
+	// Recover the stuck status of the old chunk to keep NumStuckChunks
+	// consistent.
+	raw, err := sf.readChunkBytes(int(chunkIndex))
+	if err != nil {
+		return err
+	}
+	oldChunk := recoverChunk(uint32(ec.NumPieces()), uint32(len(sf.pubKeyTable)), raw)
+
+	// Build the new chunk. Hosts which are not part of the table yet are
+	// added to it.
+	c := chunk{
+		Index:  int(chunkIndex),
+		Pieces: make([][]piece, ec.NumPieces()),
+		Stuck:  oldChunk.Stuck,
+	}
+	tableChanged := false
+	for pieceIndex, pieceSet := range pieces {
+		for _, p := range pieceSet {
+			tableIndex := -1
+			for i, hpk := range sf.pubKeyTable {
+				if hpk.PublicKey.Equals(p.HostPubKey) {
+					tableIndex = i
+					break
+				}
+			}
+			if tableIndex == -1 {
+				sf.pubKeyTable = append(sf.pubKeyTable, HostPublicKey{
+					PublicKey: p.HostPubKey,
+					Used:      true,
+				})
+				tableIndex = len(sf.pubKeyTable) - 1
+				tableChanged = true
+			}
+			c.Pieces[pieceIndex] = append(c.Pieces[pieceIndex], piece{
+				HostTableOffset: uint32(tableIndex),
+				MerkleRoot:      p.MerkleRoot,
+			})
+		}
+	}
+	if marshaledChunkSize(c.numPieces()) > int64(sf.staticMetadata.StaticPagesPerChunk)*pageSize {
+		sf.defragChunk(&c)
+	}
+	// A chunk which can't be recovered from the hosts is marked as stuck.
+	var uniquePieces int
+	for _, pieceSet := range c.Pieces {
+		if len(pieceSet) > 0 {
+			uniquePieces++
+		}
+	}
+	if uniquePieces < ec.MinPieces() && !c.Stuck {
+		c.Stuck = true
+		sf.staticMetadata.NumStuckChunks++
+	}
+
+	// Save the header and the chunk.
+	var updates []writeaheadlog.Update
+	if tableChanged {
+		updates, err = sf.saveHeaderUpdates()
+	} else {
+		updates, err = sf.saveMetadataUpdates()
+	}
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(append(updates, sf.saveChunkUpdate(c))...)
+}
+
+// isPartialChunk returns whether the chunk with the given index is a partial
+// chunk.
+func (sf *SiaFile) isPartialChunk(chunkIndex uint64) bool {
+	_, included := sf.isIncludedPartialChunk(chunkIndex)
+	return included || sf.isIncompletePartialChunk(chunkIndex)
+}
+
+// readChunkBytes reads the raw bytes of the full chunk with the given index
+// from disk.
+func (sf *SiaFile) readChunkBytes(chunkIndex int) (_ []byte, err error) {
+	chunkBytes := make([]byte, int(sf.staticMetadata.StaticPagesPerChunk)*pageSize)
+	f, err := sf.deps.Open(sf.siaFilePath)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to open file to read chunk")
+	}
+	defer func() {
+		err = errors.Compose(err, f.Close())
+	}()
+	if _, err := f.ReadAt(chunkBytes, sf.chunkOffset(chunkIndex)); err != nil && !errors.Contains(err, io.EOF) {
+		return nil, errors.AddContext(err, "failed to read chunk from disk")
+	}
+	return chunkBytes, nil
+}
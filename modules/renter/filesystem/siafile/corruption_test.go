@@ -0,0 +1,199 @@
+package siafile
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/types"
+)
+
+// TestHeaderChecksum tests that loading a SiaFile detects a corrupted header
+// and that files without a header checksum can still be loaded.
+func TestHeaderChecksum(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	siaFilePath, _, source, rc, sk, fileSize, numChunks, fileMode := newTestFileParams(1, false)
+	sf, wal, _ := customTestFileAndWAL(siaFilePath, source, rc, sk, fileSize, numChunks, fileMode)
+	if sf.staticMetadata.StaticVersion != metadataVersionChecksums {
+		t.Fatal("new file has wrong version", sf.staticMetadata.StaticVersion)
+	}
+	if _, err := LoadSiaFile(sf.siaFilePath, wal); err != nil {
+		t.Fatal(err)
+	}
+
+	// writeMetadata overwrites the metadata on disk without updating the
+	// checksum.
+	writeMetadata := func(md Metadata) {
+		raw, err := marshalMetadata(md)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := os.OpenFile(sf.siaFilePath, os.O_RDWR, 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer closeFileInTest(t, f)
+		if _, err := f.WriteAt(raw, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Change the local path on disk. The metadata can still be decoded but
+	// doesn't match the checksum anymore.
+	md := sf.staticMetadata.backup()
+	md.HeaderChecksum = sf.staticMetadata.HeaderChecksum
+	md.LocalPath = string(bytes.Repeat([]byte{'a'}, len(md.LocalPath)))
+	writeMetadata(md)
+	if _, err := LoadSiaFile(sf.siaFilePath, wal); !errors.Contains(err, ErrCorruptHeader) {
+		t.Fatal("expected ErrCorruptHeader but got", err)
+	}
+
+	// A file of the current version without a checksum is corrupted as well.
+	md.HeaderChecksum = crypto.Hash{}
+	writeMetadata(md)
+	if _, err := LoadSiaFile(sf.siaFilePath, wal); !errors.Contains(err, ErrCorruptHeader) {
+		t.Fatal("expected ErrCorruptHeader but got", err)
+	}
+
+	// A legacy file without a checksum can be loaded.
+	md.StaticVersion = [16]byte{}
+	writeMetadata(md)
+	sf2, err := LoadSiaFile(sf.siaFilePath, wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sf2.staticMetadata.LocalPath != md.LocalPath {
+		t.Fatal("wrong local path after loading legacy file")
+	}
+}
+
+// TestRepairChunkMetadata tests detecting corrupted chunks and repairing their
+// metadata.
+func TestRepairChunkMetadata(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	siaFilePath, _, source, rc, sk, fileSize, numChunks, fileMode := newTestFileParams(2, false)
+	sf, wal, _ := customTestFileAndWAL(siaFilePath, source, rc, sk, fileSize, numChunks, fileMode)
+	if sf.numChunks < 2 {
+		t.Fatal("test requires at least 2 chunks")
+	}
+
+	// Add a piece for every pieceIndex of the first chunk.
+	for pieceIndex := 0; pieceIndex < rc.NumPieces(); pieceIndex++ {
+		hk := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{byte(pieceIndex)}}
+		if err := sf.AddPiece(hk, 0, uint64(pieceIndex), crypto.Hash{byte(pieceIndex)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	corrupt, err := sf.CorruptChunks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(corrupt) != 0 {
+		t.Fatal("no chunk should be corrupted", corrupt)
+	}
+
+	// Corrupt the merkle root of the first piece on disk.
+	f, err := os.OpenFile(sf.siaFilePath, os.O_RDWR, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.WriteAt([]byte{0xff}, sf.chunkOffset(0)+marshaledChunkOverhead+8)
+	if err := errors.Compose(err, f.Close()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The chunk should be detected as corrupted.
+	corrupt, err = sf.CorruptChunks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(corrupt) != 1 || corrupt[0] != 0 {
+		t.Fatal("expected the first chunk to be corrupted", corrupt)
+	}
+	if _, err := sf.Pieces(0); !errors.Contains(err, ErrCorruptChunk) {
+		t.Fatal("expected ErrCorruptChunk but got", err)
+	}
+
+	// All pieces are recoverable but the first one has the wrong root.
+	pieces, err := sf.RecoverablePieces(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for pieceIndex, pieceSet := range pieces {
+		if len(pieceSet) != 1 {
+			t.Fatalf("expected 1 piece at index %v but got %v", pieceIndex, len(pieceSet))
+		}
+		if pieceIndex == 0 && pieceSet[0].MerkleRoot == (crypto.Hash{0}) {
+			t.Fatal("root of first piece should be corrupted")
+		}
+	}
+
+	// Repair the chunk without the corrupted piece. It should still have
+	// enough pieces to not be stuck.
+	pieces[0] = nil
+	if err := sf.RepairChunkMetadata(0, pieces); err != nil {
+		t.Fatal(err)
+	}
+	repaired, err := sf.Pieces(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repaired[0]) != 0 || len(repaired[1]) != 1 {
+		t.Fatal("wrong pieces after repair", repaired)
+	}
+	if stuck, err := sf.StuckChunkByIndex(0); err != nil || stuck {
+		t.Fatal("chunk shouldn't be stuck", stuck, err)
+	}
+
+	// Repair the chunk without any pieces. It should be marked as stuck.
+	if err := sf.RepairChunkMetadata(0, make([][]Piece, rc.NumPieces())); err != nil {
+		t.Fatal(err)
+	}
+	if stuck, err := sf.StuckChunkByIndex(0); err != nil || !stuck {
+		t.Fatal("chunk should be stuck", stuck, err)
+	}
+	if sf.staticMetadata.NumStuckChunks != 1 {
+		t.Fatal("expected 1 stuck chunk but got", sf.staticMetadata.NumStuckChunks)
+	}
+
+	// The repaired file can be loaded and read.
+	sf2, err := LoadSiaFile(sf.siaFilePath, wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	corrupt, err = sf2.CorruptChunks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(corrupt) != 0 {
+		t.Fatal("no chunk should be corrupted after repair", corrupt)
+	}
+
+	// Zero out the second chunk on disk. It should be detected as corrupted
+	// as well.
+	f, err = os.OpenFile(sf.siaFilePath, os.O_RDWR, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.WriteAt(make([]byte, int(sf.staticMetadata.StaticPagesPerChunk)*pageSize), sf.chunkOffset(1))
+	if err := errors.Compose(err, f.Close()); err != nil {
+		t.Fatal(err)
+	}
+	corrupt, err = sf2.CorruptChunks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(corrupt) != 1 || corrupt[0] != 1 {
+		t.Fatal("expected the second chunk to be corrupted", corrupt)
+	}
+}
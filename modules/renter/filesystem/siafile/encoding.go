@@ -9,6 +9,7 @@ import (
 
 	"gitlab.com/NebulousLabs/errors"
 
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 )
 
@@ -18,9 +19,8 @@ func marshalChunk(chunk chunk) []byte {
 	chunkBytes := make([]byte, marshaledChunkSize(chunk.numPieces()))
 	buf := bytes.NewBuffer(chunkBytes)
 
-	// Write the extension info.
-	ei := buf.Next(len(chunk.ExtensionInfo))
-	copy(ei, chunk.ExtensionInfo[:])
+	// Reserve space for the checksum.
+	checksum := buf.Next(chunkChecksumSize)
 
 	// Write Stuck bool
 	stuck := buf.Next(1)
@@ -41,9 +41,38 @@ func marshalChunk(chunk chunk) []byte {
 			putPiece(p, uint32(pieceIndex), piece)
 		}
 	}
+
+	// Write the checksum of the marshaled chunk.
+	cs := chunkChecksum(chunkBytes)
+	copy(checksum, cs[:])
 	return chunkBytes
 }
 
+// chunkChecksum computes the checksum of a marshaled chunk. The checksum
+// covers everything but the space reserved for the checksum itself.
+func chunkChecksum(marshaledChunk []byte) (cs [chunkChecksumSize]byte) {
+	h := crypto.HashBytes(marshaledChunk[chunkChecksumSize:])
+	copy(cs[:], h[:])
+	return
+}
+
+// headerChecksum computes the checksum of the header of a SiaFile given its
+// metadata and the marshaled pubKeyTable. The HeaderChecksum field of the
+// metadata is ignored.
+func headerChecksum(md Metadata, pubKeyTable []byte) (crypto.Hash, error) {
+	md.HeaderChecksum = crypto.Hash{}
+	metadata, err := marshalMetadata(md)
+	if err != nil {
+		return crypto.Hash{}, errors.AddContext(err, "failed to marshal metadata")
+	}
+	h := crypto.NewHash()
+	_, _ = h.Write(metadata)
+	_, _ = h.Write(pubKeyTable)
+	var checksum crypto.Hash
+	copy(checksum[:], h.Sum(nil))
+	return checksum, nil
+}
+
 // marshalErasureCoder marshals an erasure coder into its type and params.
 func marshalErasureCoder(ec modules.ErasureCoder) ([4]byte, [8]byte) {
 	ecType := [4]byte(ec.Type())
@@ -99,15 +128,18 @@ func putPiece(out []byte, pieceIndex uint32, piece piece) {
 // find out by taking a look at the erasure coder within the siafile header.
 // Unfortunately it's not enough to simply look at the piece indices when
 // reading the pieces from disk, since there is no guarantee that we already
-// uploaded a piece for each index.
-func unmarshalChunk(numPieces uint32, raw []byte) (chunk chunk, err error) {
+// uploaded a piece for each index. The checksum of the chunk is only verified
+// if verify is true since chunks of SiaFiles which were created before
+// checksums were added don't have one.
+func unmarshalChunk(numPieces uint32, raw []byte, verify bool) (chunk chunk, err error) {
 	// initialize the pieces.
 	chunk.Pieces = make([][]piece, numPieces)
 
-	// read the ExtensionInfo first.
+	// read the checksum first.
 	buf := bytes.NewBuffer(raw)
-	if _, err = io.ReadFull(buf, chunk.ExtensionInfo[:]); err != nil {
-		return chunk, errors.AddContext(err, "failed to unmarshal ExtensionInfo")
+	var checksum [chunkChecksumSize]byte
+	if _, err = io.ReadFull(buf, checksum[:]); err != nil {
+		return chunk, errors.AddContext(err, "failed to unmarshal checksum")
 	}
 
 	// read Stuck byte
@@ -122,6 +154,14 @@ func unmarshalChunk(numPieces uint32, raw []byte) (chunk chunk, err error) {
 	}
 	piecesToLoad := binary.LittleEndian.Uint16(prefixBytes)
 
+	// verify the checksum.
+	if verify {
+		size := marshaledChunkSize(int(piecesToLoad))
+		if size > int64(len(raw)) || chunkChecksum(raw[:size]) != checksum {
+			return chunk, ErrCorruptChunk
+		}
+	}
+
 	// read the pieces one by one.
 	var loadedPieces uint16
 	for pieceBytes := buf.Next(marshaledPieceSize); loadedPieces < piecesToLoad; pieceBytes = buf.Next(marshaledPieceSize) {
@@ -138,6 +178,29 @@ func unmarshalChunk(numPieces uint32, raw []byte) (chunk chunk, err error) {
 	return
 }
 
+// recoverChunk reads as much as possible from a marshaled chunk without
+// verifying its checksum. Pieces with an invalid piece index or an invalid
+// offset into a pubKeyTable with numHosts entries are skipped. It is used to
+// salvage the pieces of a corrupted chunk.
+func recoverChunk(numPieces, numHosts uint32, raw []byte) (c chunk) {
+	c.Pieces = make([][]piece, numPieces)
+	if len(raw) < marshaledChunkOverhead {
+		return
+	}
+	c.Stuck = raw[chunkChecksumSize] == byte(1)
+	piecesToLoad := int(binary.LittleEndian.Uint16(raw[chunkChecksumSize+1 : marshaledChunkOverhead]))
+	raw = raw[marshaledChunkOverhead:]
+	for i := 0; i < piecesToLoad && len(raw) >= marshaledPieceSize; i++ {
+		pieceIndex, piece, err := unmarshalPiece(raw[:marshaledPieceSize])
+		raw = raw[marshaledPieceSize:]
+		if err != nil || pieceIndex >= numPieces || piece.HostTableOffset >= numHosts {
+			continue
+		}
+		c.Pieces[pieceIndex] = append(c.Pieces[pieceIndex], piece)
+	}
+	return
+}
+
 // unmarshalErasureCoder unmarshals an ErasureCoder from the given params.
 func unmarshalErasureCoder(ecType [4]byte, ecParams [8]byte) (modules.ErasureCoder, error) {
 	dataPieces := int(binary.LittleEndian.Uint32(ecParams[:4]))
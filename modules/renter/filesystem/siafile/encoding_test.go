@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
 	"go.sia.tech/siad/modules"
 )
@@ -41,7 +42,7 @@ func TestMarshalUnmarshalChunk(t *testing.T) {
 	chunkBytes = append(chunkBytes, fastrand.Bytes(100)...)
 
 	// Unmarshal the chunk.
-	unmarshaledChunk, err := unmarshalChunk(numPieces, chunkBytes)
+	unmarshaledChunk, err := unmarshalChunk(numPieces, chunkBytes, true)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -53,6 +54,42 @@ func TestMarshalUnmarshalChunk(t *testing.T) {
 	}
 }
 
+// TestChunkChecksum tests that unmarshalChunk detects corrupted chunks and
+// still accepts chunks of files without checksums.
+func TestChunkChecksum(t *testing.T) {
+	chunk := randomChunk()
+	numPieces := uint32(len(chunk.Pieces))
+	chunkBytes := marshalChunk(chunk)
+
+	// Corrupt a random byte after the checksum.
+	corrupted := append([]byte{}, chunkBytes...)
+	corrupted[chunkChecksumSize+fastrand.Intn(len(corrupted)-chunkChecksumSize)] ^= 1
+	if _, err := unmarshalChunk(numPieces, corrupted, true); !errors.Contains(err, ErrCorruptChunk) {
+		t.Fatal("expected ErrCorruptChunk but got", err)
+	}
+	// Corrupt the checksum itself.
+	corrupted = append([]byte{}, chunkBytes...)
+	corrupted[fastrand.Intn(chunkChecksumSize)] ^= 1
+	if _, err := unmarshalChunk(numPieces, corrupted, true); !errors.Contains(err, ErrCorruptChunk) {
+		t.Fatal("expected ErrCorruptChunk but got", err)
+	}
+	// A zeroed-out checksum is detected as well.
+	zeroed := append([]byte{}, chunkBytes...)
+	copy(zeroed[:chunkChecksumSize], make([]byte, chunkChecksumSize))
+	if _, err := unmarshalChunk(numPieces, zeroed, true); !errors.Contains(err, ErrCorruptChunk) {
+		t.Fatal("expected ErrCorruptChunk but got", err)
+	}
+	// A chunk of a file which was created before checksums were added should
+	// be loaded without verification.
+	unmarshaledChunk, err := unmarshalChunk(numPieces, zeroed, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(chunk, unmarshaledChunk) {
+		t.Fatal("Unmarshaled chunk doesn't equal marshaled chunk")
+	}
+}
+
 // TestMarshalUnmarshalErasureCoder tests marshaling and unmarshaling an
 // ErasureCoder.
 func TestMarshalUnmarshalErasureCoder(t *testing.T) {
@@ -0,0 +1,205 @@
+package siafile
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/writeaheadlog"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// The export format of a SiaFile is a stream which starts with the
+// exportSpecifier and the version of the format, followed by the
+// length-prefixed json encoded metadata, the length-prefixed pubKeyTable, the
+// number of chunks and finally the length-prefixed marshaled chunks.
+const (
+	// exportVersion is the current version of the export format.
+	exportVersion = 1
+
+	// exportMaxHeaderSize is the maximum size of the metadata and the
+	// pubKeyTable within an exported SiaFile.
+	exportMaxHeaderSize = 1 << 24 // 16 MiB
+)
+
+var (
+	// exportSpecifier is the specifier at the beginning of an exported
+	// SiaFile.
+	exportSpecifier = types.NewSpecifier("SiaFileExport")
+
+	// errExportPartialChunk is returned when trying to export a SiaFile with
+	// a partial chunk. The partial chunk is stored in a partials siafile
+	// which can't be exported together with the file.
+	errExportPartialChunk = errors.New("can't export a SiaFile with a partial chunk")
+)
+
+// Export writes the metadata, the pubKeyTable and the chunks of the SiaFile to
+// w in a portable format which can be imported again using Import. The chunks
+// are streamed one at a time.
+func (sf *SiaFile) Export(w io.Writer) (err error) {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	if sf.deleted {
+		return errors.AddContext(ErrDeleted, "can't export deleted SiaFile")
+	}
+	if sf.staticMetadata.HasPartialChunk || len(sf.staticMetadata.PartialChunks) > 0 {
+		return errExportPartialChunk
+	}
+	metadata, err := marshalMetadata(sf.staticMetadata)
+	if err != nil {
+		return errors.AddContext(err, "failed to marshal metadata")
+	}
+	pubKeyTable, err := marshalPubKeyTable(sf.pubKeyTable)
+	if err != nil {
+		return errors.AddContext(err, "failed to marshal pubKeyTable")
+	}
+
+	// Write the header of the export.
+	e := encoding.NewEncoder(w)
+	err = e.EncodeAll(exportSpecifier, uint64(exportVersion), metadata, pubKeyTable, uint64(sf.numChunks))
+	if err != nil {
+		return errors.AddContext(err, "failed to write export header")
+	}
+
+	// Stream the chunks.
+	f, err := os.Open(sf.siaFilePath)
+	if err != nil {
+		return errors.AddContext(err, "failed to open file")
+	}
+	defer func() {
+		err = errors.Compose(err, f.Close())
+	}()
+	if _, err := f.Seek(sf.staticMetadata.ChunkOffset, io.SeekStart); err != nil {
+		return errors.AddContext(err, "failed to seek to ChunkOffset")
+	}
+	chunkBytes := make([]byte, int(sf.staticMetadata.StaticPagesPerChunk)*pageSize)
+	for chunkIndex := 0; chunkIndex < sf.numChunks; chunkIndex++ {
+		if _, err := io.ReadFull(f, chunkBytes); err != nil && !errors.Contains(err, io.EOF) && !errors.Contains(err, io.ErrUnexpectedEOF) {
+			return errors.AddContext(err, fmt.Sprintf("failed to read chunk %v", chunkIndex))
+		}
+		// Unmarshal the chunk to verify it and to only export the used part of
+		// the chunk's pages.
+		c, err := unmarshalChunk(uint32(sf.staticMetadata.staticErasureCode.NumPieces()), chunkBytes, sf.staticMetadata.hasChecksums())
+		if err != nil {
+			return errors.AddContext(err, fmt.Sprintf("failed to unmarshal chunk %v", chunkIndex))
+		}
+		if err := e.WritePrefixedBytes(marshalChunk(c)); err != nil {
+			return errors.AddContext(err, fmt.Sprintf("failed to write chunk %v", chunkIndex))
+		}
+		// Clear the buffer for the next chunk since the last chunk might not
+		// fill all of its pages.
+		for i := range chunkBytes {
+			chunkBytes[i] = 0
+		}
+	}
+	return nil
+}
+
+// Import reads a SiaFile which was exported using Export from r. The returned
+// SiaFile is not persisted yet. It is supposed to be saved at path together
+// with the returned chunks using SaveWithChunks.
+func Import(r io.Reader, path string, wal *writeaheadlog.WAL) (*SiaFile, Chunks, error) {
+	// Read and verify the header of the export.
+	d := encoding.NewDecoder(r, 0)
+	var specifier types.Specifier
+	d.ReadFull(specifier[:])
+	version := d.NextUint64()
+	if err := d.Err(); err != nil {
+		return nil, Chunks{}, errors.AddContext(err, "failed to read export header")
+	}
+	if specifier != exportSpecifier {
+		return nil, Chunks{}, errors.New("not an exported SiaFile")
+	}
+	if version != exportVersion {
+		return nil, Chunks{}, fmt.Errorf("unsupported export version %v", version)
+	}
+
+	// Read the metadata.
+	rawMetadata, err := encoding.ReadPrefixedBytes(r, exportMaxHeaderSize)
+	if err != nil {
+		return nil, Chunks{}, errors.AddContext(err, "failed to read metadata")
+	}
+	md, err := unmarshalMetadata(rawMetadata)
+	if err != nil {
+		return nil, Chunks{}, errors.AddContext(err, "failed to unmarshal metadata")
+	}
+	if md.HasPartialChunk || len(md.PartialChunks) > 0 {
+		return nil, Chunks{}, errExportPartialChunk
+	}
+	if md.StaticPagesPerChunk != numChunkPagesRequired(md.staticErasureCode.NumPieces()) {
+		return nil, Chunks{}, errors.New("invalid number of pages per chunk")
+	}
+
+	// Read the pubKeyTable.
+	rawPubKeyTable, err := encoding.ReadPrefixedBytes(r, exportMaxHeaderSize)
+	if err != nil {
+		return nil, Chunks{}, errors.AddContext(err, "failed to read pubKeyTable")
+	}
+	pubKeyTable, err := unmarshalPubKeyTable(rawPubKeyTable)
+	if err != nil {
+		return nil, Chunks{}, errors.AddContext(err, "failed to unmarshal pubKeyTable")
+	}
+	if pubKeyTable == nil {
+		pubKeyTable = []HostPublicKey{}
+	}
+
+	sf := &SiaFile{
+		staticMetadata: md,
+		pubKeyTable:    pubKeyTable,
+		deps:           modules.ProdDependencies,
+		siaFilePath:    path,
+		wal:            wal,
+	}
+
+	// Read the chunks. The number of chunks has to match the size of the
+	// file.
+	numChunks := d.NextUint64()
+	if err := d.Err(); err != nil {
+		return nil, Chunks{}, errors.AddContext(err, "failed to read number of chunks")
+	}
+	expectedChunks := md.FileSize / int64(sf.staticChunkSize())
+	if md.FileSize%int64(sf.staticChunkSize()) != 0 || expectedChunks == 0 {
+		expectedChunks++
+	}
+	if numChunks != uint64(expectedChunks) {
+		return nil, Chunks{}, fmt.Errorf("expected %v chunks but export contains %v", expectedChunks, numChunks)
+	}
+	sf.numChunks = int(numChunks)
+	maxChunkSize := uint64(md.StaticPagesPerChunk) * pageSize
+	var chunks []chunk
+	for chunkIndex := 0; chunkIndex < sf.numChunks; chunkIndex++ {
+		chunkBytes, err := encoding.ReadPrefixedBytes(r, maxChunkSize)
+		if err != nil {
+			return nil, Chunks{}, errors.AddContext(err, fmt.Sprintf("failed to read chunk %v", chunkIndex))
+		}
+		// Exported chunks always have a checksum.
+		c, err := unmarshalChunk(uint32(md.staticErasureCode.NumPieces()), chunkBytes, true)
+		if err != nil {
+			return nil, Chunks{}, errors.AddContext(err, fmt.Sprintf("failed to unmarshal chunk %v", chunkIndex))
+		}
+		for _, pieceSet := range c.Pieces {
+			for _, piece := range pieceSet {
+				if piece.HostTableOffset >= uint32(len(pubKeyTable)) {
+					return nil, Chunks{}, fmt.Errorf("chunk %v references unknown host", chunkIndex)
+				}
+			}
+		}
+		c.Index = chunkIndex
+		chunks = append(chunks, c)
+	}
+	return sf, Chunks{chunks}, nil
+}
+
+// ImportSnapshot reads a SiaFile which was exported using Export from r and
+// creates a snapshot from it without storing the SiaFile on disk.
+func ImportSnapshot(sp modules.SiaPath, r io.Reader) (*Snapshot, error) {
+	sf, chunks, err := Import(r, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	return sf.readlockSnapshot(sp, chunks.chunks)
+}
@@ -0,0 +1,112 @@
+package siafile
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestExportImport tests exporting a SiaFile and importing it again.
+func TestExportImport(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a file and add some pieces.
+	siaFilePath, _, source, rc, sk, fileSize, numChunks, fileMode := newTestFileParams(2, false)
+	sf, wal, _ := customTestFileAndWAL(siaFilePath, source, rc, sk, fileSize, numChunks, fileMode)
+	for chunkIndex := 0; chunkIndex < sf.numChunks; chunkIndex++ {
+		for pieceIndex := 0; pieceIndex < rc.NumPieces(); pieceIndex++ {
+			hk := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: fastrand.Bytes(32)}
+			var root crypto.Hash
+			fastrand.Read(root[:])
+			if err := sf.AddPiece(hk, uint64(chunkIndex), uint64(pieceIndex), root); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// Export the file.
+	var buf bytes.Buffer
+	if err := sf.Export(&buf); err != nil {
+		t.Fatal(err)
+	}
+	export := buf.Bytes()
+
+	// Import it at a new path and save it.
+	importPath := sf.siaFilePath + "_imported"
+	imported, chunks, err := Import(bytes.NewReader(export), importPath, wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := imported.SaveWithChunks(chunks); err != nil {
+		t.Fatal(err)
+	}
+
+	// Load the imported file and compare it to the original.
+	loaded, err := LoadSiaFile(importPath, wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.UID() != sf.UID() || loaded.Size() != sf.Size() || loaded.LocalPath() != sf.LocalPath() {
+		t.Fatal("metadata doesn't match")
+	}
+	if !bytes.Equal(loaded.MasterKey().Key(), sf.MasterKey().Key()) {
+		t.Fatal("master key doesn't match")
+	}
+	if !reflect.DeepEqual(loaded.pubKeyTable, sf.pubKeyTable) {
+		t.Fatal("pubKeyTable doesn't match")
+	}
+	if loaded.numChunks != sf.numChunks {
+		t.Fatalf("expected %v chunks but got %v", sf.numChunks, loaded.numChunks)
+	}
+	for chunkIndex := 0; chunkIndex < sf.numChunks; chunkIndex++ {
+		expected, err := sf.Pieces(uint64(chunkIndex))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pieces, err := loaded.Pieces(uint64(chunkIndex))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(pieces, expected) {
+			t.Fatalf("pieces of chunk %v don't match", chunkIndex)
+		}
+	}
+
+	// A snapshot created from the export should match a snapshot of the
+	// original file.
+	snap, err := ImportSnapshot(modules.RandomSiaPath(), bytes.NewReader(export))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedSnap, err := sf.Snapshot(snap.SiaPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(snap, expectedSnap) {
+		t.Fatal("snapshots don't match")
+	}
+
+	// Importing an invalid or truncated export should fail.
+	invalid := append([]byte{}, export...)
+	invalid[0]++
+	if _, _, err := Import(bytes.NewReader(invalid), importPath, wal); err == nil {
+		t.Fatal("importing invalid export should fail")
+	}
+	if _, _, err := Import(bytes.NewReader(export[:len(export)-1]), importPath, wal); err == nil {
+		t.Fatal("importing truncated export should fail")
+	}
+	// Corrupting a chunk should be detected by its checksum.
+	corrupted := append([]byte{}, export...)
+	corrupted[len(corrupted)-1]++
+	if _, _, err := Import(bytes.NewReader(corrupted), importPath, wal); err == nil {
+		t.Fatal("importing corrupted export should fail")
+	}
+}
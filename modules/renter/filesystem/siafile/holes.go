@@ -0,0 +1,157 @@
+package siafile
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/writeaheadlog"
+)
+
+// Hole is a range of chunks [Start;End) of a SiaFile which are not allocated.
+// Holes don't have any pieces on the network, they are considered to be fully
+// healthy and they read as zeroes. Holes are filled by uploading data for the
+// chunks they contain.
+type Hole struct {
+	Start uint64 `json:"start"`
+	End   uint64 `json:"end"`
+}
+
+// addHole adds the range [start;end) to the sorted holes, merging it with
+// overlapping and adjacent holes. A new slice is returned to avoid modifying
+// backups of the metadata.
+func addHole(holes []Hole, start, end uint64) []Hole {
+	newHoles := make([]Hole, 0, len(holes)+1)
+	merged := Hole{Start: start, End: end}
+	for _, h := range holes {
+		switch {
+		case h.End < merged.Start:
+			newHoles = append(newHoles, h)
+		case h.Start > merged.End:
+			newHoles = append(newHoles, merged)
+			merged = h
+		default:
+			if h.Start < merged.Start {
+				merged.Start = h.Start
+			}
+			if h.End > merged.End {
+				merged.End = h.End
+			}
+		}
+	}
+	return append(newHoles, merged)
+}
+
+// isHole returns whether the chunk with the provided index is part of one of
+// the sorted holes.
+func isHole(holes []Hole, chunkIndex uint64) bool {
+	i := sort.Search(len(holes), func(i int) bool {
+		return holes[i].End > chunkIndex
+	})
+	return i < len(holes) && holes[i].Start <= chunkIndex
+}
+
+// removeHole removes the chunk with the provided index from the sorted holes,
+// splitting the hole it is part of if necessary. A new slice is returned to
+// avoid modifying backups of the metadata.
+func removeHole(holes []Hole, chunkIndex uint64) []Hole {
+	newHoles := make([]Hole, 0, len(holes)+1)
+	for _, h := range holes {
+		if chunkIndex < h.Start || chunkIndex >= h.End {
+			newHoles = append(newHoles, h)
+			continue
+		}
+		if h.Start < chunkIndex {
+			newHoles = append(newHoles, Hole{Start: h.Start, End: chunkIndex})
+		}
+		if chunkIndex+1 < h.End {
+			newHoles = append(newHoles, Hole{Start: chunkIndex + 1, End: h.End})
+		}
+	}
+	if len(newHoles) == 0 {
+		return nil
+	}
+	return newHoles
+}
+
+// Holes returns the holes of the SiaFile.
+func (sf *SiaFile) Holes() []Hole {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return append([]Hole(nil), sf.staticMetadata.Holes...)
+}
+
+// IsHole returns whether the chunk with the provided index is part of a hole.
+func (sf *SiaFile) IsHole(chunkIndex uint64) bool {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.isHole(chunkIndex)
+}
+
+// isHole returns whether the chunk with the provided index is part of a hole.
+func (sf *SiaFile) isHole(chunkIndex uint64) bool {
+	return isHole(sf.staticMetadata.Holes, chunkIndex)
+}
+
+// PunchHoles turns the chunks [start;end) of the SiaFile into holes. The
+// pieces of the chunks are removed from the SiaFile which means that their data
+// is lost.
+func (sf *SiaFile) PunchHoles(start, end uint64) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	if sf.deleted {
+		return errors.AddContext(ErrDeleted, "can't punch holes into deleted file")
+	}
+	if sf.staticMetadata.HasPartialChunk {
+		return errors.New("can't punch holes into a file with a partial chunk")
+	}
+	if start >= end || end > uint64(sf.numChunks) {
+		return fmt.Errorf("invalid chunk range [%v;%v) for file with %v chunks", start, end, sf.numChunks)
+	}
+	// Backup the changed metadata before changing it. Revert the change on
+	// error.
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+
+	// Update cache.
+	defer sf.uploadProgressAndBytes()
+
+	// Remove the pieces of the chunks. Chunks without pieces which aren't
+	// stuck don't need to be updated.
+	var updates []writeaheadlog.Update
+	numPieces := sf.staticMetadata.staticErasureCode.NumPieces()
+	for chunkIndex := start; chunkIndex < end; chunkIndex++ {
+		chunk, err := sf.chunk(int(chunkIndex))
+		if err != nil {
+			return errors.AddContext(err, "failed to get chunk")
+		}
+		if chunk.numPieces() == 0 && !chunk.Stuck {
+			continue
+		}
+		if chunk.Stuck {
+			sf.staticMetadata.NumStuckChunks--
+		}
+		chunk.Pieces = make([][]piece, numPieces)
+		chunk.Stuck = false
+		updates = append(updates, sf.saveChunkUpdate(chunk))
+	}
+
+	// Update the holes and the timestamps.
+	sf.staticMetadata.Holes = addHole(sf.staticMetadata.Holes, start, end)
+	sf.staticMetadata.ChangeTime = time.Now()
+	sf.staticMetadata.ModTime = sf.staticMetadata.ChangeTime
+	mdUpdates, err := sf.saveMetadataUpdates()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(append(updates, mdUpdates...)...)
+}
+
+// IsHole returns whether the chunk with the provided index is part of a hole.
+func (s *Snapshot) IsHole(chunkIndex uint64) bool {
+	return isHole(s.staticHoles, chunkIndex)
+}
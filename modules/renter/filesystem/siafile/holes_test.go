@@ -0,0 +1,141 @@
+package siafile
+
+import (
+	"reflect"
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestHoleRanges is a unit test for addHole, isHole and removeHole.
+func TestHoleRanges(t *testing.T) {
+	t.Parallel()
+
+	var holes []Hole
+	holes = addHole(holes, 5, 10)
+	holes = addHole(holes, 0, 2)
+	holes = addHole(holes, 12, 15)
+	expected := []Hole{{0, 2}, {5, 10}, {12, 15}}
+	if !reflect.DeepEqual(holes, expected) {
+		t.Fatal("unexpected holes", holes)
+	}
+	// Adjacent and overlapping holes are merged.
+	holes = addHole(holes, 2, 3)
+	holes = addHole(holes, 9, 13)
+	expected = []Hole{{0, 3}, {5, 15}}
+	if !reflect.DeepEqual(holes, expected) {
+		t.Fatal("unexpected holes", holes)
+	}
+	for i := uint64(0); i < 20; i++ {
+		hole := i < 3 || (i >= 5 && i < 15)
+		if isHole(holes, i) != hole {
+			t.Fatalf("expected isHole(%v) to be %v", i, hole)
+		}
+	}
+	// Removing a chunk splits the hole.
+	holes = removeHole(holes, 7)
+	holes = removeHole(holes, 0)
+	holes = removeHole(holes, 14)
+	holes = removeHole(holes, 20)
+	expected = []Hole{{1, 3}, {5, 7}, {8, 14}}
+	if !reflect.DeepEqual(holes, expected) {
+		t.Fatal("unexpected holes", holes)
+	}
+	// Removing the last chunk of a hole removes it.
+	holes = removeHole([]Hole{{3, 4}}, 3)
+	if holes != nil {
+		t.Fatal("expected no holes", holes)
+	}
+}
+
+// TestPunchHoles tests punching holes into a SiaFile and filling them again.
+func TestPunchHoles(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	siaFilePath, _, source, rc, sk, fileSize, numChunks, fileMode := newTestFileParams(3, false)
+	sf, _, _ := customTestFileAndWAL(siaFilePath, source, rc, sk, fileSize, numChunks, fileMode)
+
+	// Upload all pieces of every chunk.
+	offline := make(map[string]bool)
+	goodForRenew := make(map[string]bool)
+	for chunkIndex := uint64(0); chunkIndex < sf.NumChunks(); chunkIndex++ {
+		for pieceIndex := 0; pieceIndex < rc.NumPieces(); pieceIndex++ {
+			pk := types.SiaPublicKey{Key: fastrand.Bytes(crypto.EntropySize)}
+			offline[pk.String()] = false
+			goodForRenew[pk.String()] = true
+			if err := sf.AddPiece(pk, chunkIndex, uint64(pieceIndex), crypto.Hash{}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// Invalid ranges are rejected.
+	if err := sf.PunchHoles(1, 1); err == nil {
+		t.Fatal("expected error for empty range")
+	}
+	if err := sf.PunchHoles(0, sf.NumChunks()+1); err == nil {
+		t.Fatal("expected error for range out of bounds")
+	}
+
+	// Punch a hole into the second chunk.
+	if err := sf.PunchHoles(1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if !sf.IsHole(1) || sf.IsHole(0) || sf.IsHole(2) {
+		t.Fatal("unexpected holes", sf.Holes())
+	}
+	pieces, err := sf.Pieces(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pieceSet := range pieces {
+		if len(pieceSet) != 0 {
+			t.Fatal("hole shouldn't have pieces")
+		}
+	}
+	// The file is still fully healthy.
+	if h, _, _, _, _, _, _ := sf.Health(offline, goodForRenew); h != 0 {
+		t.Fatal("expected full health but got", h)
+	}
+	r, _, err := sf.Redundancy(offline, goodForRenew)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := float64(rc.NumPieces()) / float64(rc.MinPieces()); r != expected {
+		t.Fatalf("expected redundancy %v but got %v", expected, r)
+	}
+
+	// The holes are part of the snapshot.
+	snap, err := sf.Snapshot(modules.RandomSiaPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !snap.IsHole(1) || snap.IsHole(0) {
+		t.Fatal("snapshot doesn't contain holes")
+	}
+
+	// The holes survive a reload.
+	sf2, err := LoadSiaFile(sf.siaFilePath, sf.wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(sf2.Holes(), sf.Holes()) {
+		t.Fatal("holes weren't persisted", sf2.Holes(), sf.Holes())
+	}
+
+	// Adding a piece fills the hole.
+	pk := types.SiaPublicKey{Key: fastrand.Bytes(crypto.EntropySize)}
+	if err := sf.AddPiece(pk, 1, 0, crypto.Hash{}); err != nil {
+		t.Fatal(err)
+	}
+	if sf.IsHole(1) || len(sf.Holes()) != 0 {
+		t.Fatal("hole wasn't filled", sf.Holes())
+	}
+}
@@ -60,12 +60,38 @@ type (
 		PartialChunks       []PartialChunkInfo `json:"partialchunks"`       // information about the partial chunk.
 		HasPartialChunk     bool               `json:"haspartialchunk"`     // indicates whether this file is supposed to have a partial chunk or not
 
+		// Holes are the sorted ranges of chunks which are not allocated. They
+		// are used by sparse files.
+		Holes []Hole `json:"holes,omitempty"`
+
+		// UserTags and Bucket are user defined metadata of the file.
+		UserTags []string `json:"usertags,omitempty"`
+		Bucket   string   `json:"bucket,omitempty"`
+
+		// HTTPHeaders are the user defined headers used when serving the file
+		// over HTTP.
+		HTTPHeaders modules.FileHTTPHeaders `json:"httpheaders"`
+
+		// Expiry is the user defined time after which the renter removes the
+		// file.
+		Expiry modules.FileExpiry `json:"expiry"`
+
+		// RepairPriority is the user defined priority with which the renter
+		// repairs the file.
+		RepairPriority modules.FileRepairPriority `json:"repairpriority"`
+
 		// The following fields are the usual unix timestamps of files.
 		ModTime    time.Time `json:"modtime"`    // time of last content modification
 		ChangeTime time.Time `json:"changetime"` // time of last metadata modification
 		AccessTime time.Time `json:"accesstime"` // time of last access
 		CreateTime time.Time `json:"createtime"` // time of file creation
 
+		// LastVerifiedTime is the last time a sample of the uploaded data was
+		// successfully compared against the local copy of the file. The
+		// verification only applies if it happened after the last content
+		// modification.
+		LastVerifiedTime time.Time `json:"lastverifiedtime"`
+
 		// Cached fields. These fields are cached fields and are only meant to be used
 		// to create FileInfos for file related API endpoints. There is no guarantee
 		// that these fields are up-to-date. Neither in memory nor on disk. Updates to
@@ -150,6 +176,11 @@ type (
 		ChunkOffset       int64 `json:"chunkoffset"`
 		PubKeyTableOffset int64 `json:"pubkeytableoffset"`
 
+		// HeaderChecksum is the checksum of the metadata and the pubKeyTable.
+		// It is computed with the HeaderChecksum itself set to zero and
+		// updated every time the header is saved.
+		HeaderChecksum crypto.Hash `json:"headerchecksum"`
+
 		// erasure code settings.
 		//
 		// StaticErasureCodeType specifies the algorithm used for erasure coding
@@ -252,6 +283,21 @@ func (sf *SiaFile) Metadata() Metadata {
 	return md
 }
 
+// Bucket returns the bucket the SiaFile is assigned to.
+func (sf *SiaFile) Bucket() string {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.staticMetadata.Bucket
+}
+
+// LastVerifiedTime returns the last time the uploaded data of the SiaFile was
+// verified against its local copy.
+func (sf *SiaFile) LastVerifiedTime() time.Time {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.staticMetadata.LastVerifiedTime
+}
+
 // Mode returns the FileMode of the SiaFile.
 func (sf *SiaFile) Mode() os.FileMode {
 	sf.mu.RLock()
@@ -311,10 +357,15 @@ func (md Metadata) backup() (b Metadata) {
 	b.LocalPath = md.LocalPath
 	b.DisablePartialChunk = md.DisablePartialChunk
 	b.HasPartialChunk = md.HasPartialChunk
+	b.Bucket = md.Bucket
+	b.HTTPHeaders = md.HTTPHeaders
+	b.Expiry = md.Expiry
+	b.RepairPriority = md.RepairPriority
 	b.ModTime = md.ModTime
 	b.ChangeTime = md.ChangeTime
 	b.AccessTime = md.AccessTime
 	b.CreateTime = md.CreateTime
+	b.LastVerifiedTime = md.LastVerifiedTime
 	b.CachedRepairBytes = md.CachedRepairBytes
 	b.CachedStuckBytes = md.CachedStuckBytes
 	b.CachedRedundancy = md.CachedRedundancy
@@ -337,6 +388,7 @@ func (md Metadata) backup() (b Metadata) {
 	b.GroupID = md.GroupID
 	b.ChunkOffset = md.ChunkOffset
 	b.PubKeyTableOffset = md.PubKeyTableOffset
+	b.HeaderChecksum = md.HeaderChecksum
 	// Special handling for slice since reflect.DeepEqual is false when
 	// comparing empty slice to nil.
 	if md.PartialChunks == nil {
@@ -346,6 +398,18 @@ func (md Metadata) backup() (b Metadata) {
 		b.PartialChunks = make([]PartialChunkInfo, len(md.PartialChunks), cap(md.PartialChunks))
 		copy(b.PartialChunks, md.PartialChunks)
 	}
+	if md.UserTags == nil {
+		b.UserTags = nil
+	} else {
+		b.UserTags = make([]string, len(md.UserTags), cap(md.UserTags))
+		copy(b.UserTags, md.UserTags)
+	}
+	if md.Holes == nil {
+		b.Holes = nil
+	} else {
+		b.Holes = make([]Hole, len(md.Holes), cap(md.Holes))
+		copy(b.Holes, md.Holes)
+	}
 	// If the backup was successful it should match the original.
 	if build.Release == "testing" && !md.equals(b) {
 		fmt.Println("md:\n", md)
@@ -355,6 +419,12 @@ func (md Metadata) backup() (b Metadata) {
 	return
 }
 
+// hasChecksums returns whether the SiaFile's version of the format has
+// checksums for its header and chunks.
+func (md *Metadata) hasChecksums() bool {
+	return md.StaticVersion == metadataVersionChecksums
+}
+
 // restore restores the metadata from a backup created with the backup() method.
 func (md *Metadata) restore(b Metadata) {
 	md.UniqueID = b.UniqueID
@@ -363,10 +433,17 @@ func (md *Metadata) restore(b Metadata) {
 	md.DisablePartialChunk = b.DisablePartialChunk
 	md.PartialChunks = b.PartialChunks
 	md.HasPartialChunk = b.HasPartialChunk
+	md.Holes = b.Holes
+	md.UserTags = b.UserTags
+	md.Bucket = b.Bucket
+	md.HTTPHeaders = b.HTTPHeaders
+	md.Expiry = b.Expiry
+	md.RepairPriority = b.RepairPriority
 	md.ModTime = b.ModTime
 	md.ChangeTime = b.ChangeTime
 	md.AccessTime = b.AccessTime
 	md.CreateTime = b.CreateTime
+	md.LastVerifiedTime = b.LastVerifiedTime
 	md.CachedRepairBytes = b.CachedRepairBytes
 	md.CachedStuckBytes = b.CachedStuckBytes
 	md.CachedRedundancy = b.CachedRedundancy
@@ -389,6 +466,7 @@ func (md *Metadata) restore(b Metadata) {
 	md.GroupID = b.GroupID
 	md.ChunkOffset = b.ChunkOffset
 	md.PubKeyTableOffset = b.PubKeyTableOffset
+	md.HeaderChecksum = b.HeaderChecksum
 	// If the backup was successful it should match the backup.
 	if build.Release == "testing" && !md.equals(b) {
 		fmt.Println("md:\n", md)
@@ -496,6 +574,120 @@ func (sf *SiaFile) SetMode(mode os.FileMode) (err error) {
 	return sf.createAndApplyTransaction(updates...)
 }
 
+// SetBucket sets the bucket the SiaFile is assigned to.
+func (sf *SiaFile) SetBucket(bucket string) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	// backup the changed metadata before changing it. Revert the change on
+	// error.
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+	sf.staticMetadata.Bucket = bucket
+	sf.staticMetadata.ChangeTime = time.Now()
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveMetadataUpdates()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
+// SetLastVerifiedTime sets the last time the uploaded data of the SiaFile was
+// verified against its local copy.
+func (sf *SiaFile) SetLastVerifiedTime(t time.Time) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	// backup the changed metadata before changing it. Revert the change on
+	// error.
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+	sf.staticMetadata.LastVerifiedTime = t
+	sf.staticMetadata.ChangeTime = time.Now()
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveMetadataUpdates()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
+// SetUserTags sets the user tags of the SiaFile.
+func (sf *SiaFile) SetUserTags(tags []string) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	// backup the changed metadata before changing it. Revert the change on
+	// error.
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+	sf.staticMetadata.UserTags = nil
+	if len(tags) > 0 {
+		sf.staticMetadata.UserTags = append([]string(nil), tags...)
+	}
+	sf.staticMetadata.ChangeTime = time.Now()
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveMetadataUpdates()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
+// SetHTTPHeaders sets the HTTP headers of the SiaFile.
+func (sf *SiaFile) SetHTTPHeaders(headers modules.FileHTTPHeaders) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	// backup the changed metadata before changing it. Revert the change on
+	// error.
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+	sf.staticMetadata.HTTPHeaders = headers
+	sf.staticMetadata.ChangeTime = time.Now()
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveMetadataUpdates()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
+// SetExpiry sets the expiry of the SiaFile.
+func (sf *SiaFile) SetExpiry(expiry modules.FileExpiry) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	// backup the changed metadata before changing it. Revert the change on
+	// error.
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+	sf.staticMetadata.Expiry = expiry
+	sf.staticMetadata.ChangeTime = time.Now()
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveMetadataUpdates()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
 // SetLastHealthCheckTime sets the LastHealthCheckTime in memory to the current
 // time but does not update and write to disk.
 //
@@ -530,6 +722,49 @@ func (sf *SiaFile) SetLocalPath(path string) (err error) {
 	return sf.createAndApplyTransaction(updates...)
 }
 
+// SetRepairPriority sets the repair priority of the SiaFile.
+func (sf *SiaFile) SetRepairPriority(priority modules.FileRepairPriority) (err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	// backup the changed metadata before changing it. Revert the change on
+	// error.
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+	sf.staticMetadata.RepairPriority = priority
+	sf.staticMetadata.ChangeTime = time.Now()
+
+	// Save changes to metadata to disk.
+	updates, err := sf.saveMetadataUpdates()
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
+// RepairPriority returns the repair priority of the SiaFile.
+func (sf *SiaFile) RepairPriority() modules.FileRepairPriority {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.staticMetadata.RepairPriority
+}
+
+// Expiry returns the expiry of the SiaFile.
+func (sf *SiaFile) Expiry() modules.FileExpiry {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.staticMetadata.Expiry
+}
+
+// HTTPHeaders returns the HTTP headers of the SiaFile.
+func (sf *SiaFile) HTTPHeaders() modules.FileHTTPHeaders {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.staticMetadata.HTTPHeaders
+}
+
 // Size returns the file's size.
 func (sf *SiaFile) Size() uint64 {
 	sf.mu.RLock()
@@ -537,6 +772,13 @@ func (sf *SiaFile) Size() uint64 {
 	return uint64(sf.staticMetadata.FileSize)
 }
 
+// UserTags returns the user tags of the SiaFile.
+func (sf *SiaFile) UserTags() []string {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return append([]string(nil), sf.staticMetadata.UserTags...)
+}
+
 // UpdateUniqueID creates a new random uid for the SiaFile.
 func (sf *SiaFile) UpdateUniqueID() {
 	sf.staticMetadata.UniqueID = uniqueID()
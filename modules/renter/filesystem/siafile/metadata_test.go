@@ -116,10 +116,23 @@ func TestBackupRestoreMetadata(t *testing.T) {
 		if fastrand.Intn(2) == 0 { // 50% chance to be not nil
 			sf.staticMetadata.PartialChunks = make([]PartialChunkInfo, fastrand.Intn(10))
 		}
+		sf.staticMetadata.UserTags = nil
+		if fastrand.Intn(2) == 0 { // 50% chance to be not nil
+			sf.staticMetadata.UserTags = []string{string(fastrand.Bytes(10))}
+		}
+		sf.staticMetadata.Bucket = string(fastrand.Bytes(10))
+		sf.staticMetadata.HTTPHeaders = modules.FileHTTPHeaders{ContentType: string(fastrand.Bytes(10))}
+		sf.staticMetadata.Expiry = modules.FileExpiry{Time: time.Now(), Archive: true}
+		sf.staticMetadata.RepairPriority = modules.FileRepairPriorityHigh
+		sf.staticMetadata.Holes = nil
+		if fastrand.Intn(2) == 0 { // 50% chance to be not nil
+			sf.staticMetadata.Holes = []Hole{{Start: 0, End: fastrand.Uint64n(10) + 1}}
+		}
 		sf.staticMetadata.ModTime = time.Now()
 		sf.staticMetadata.ChangeTime = time.Now()
 		sf.staticMetadata.AccessTime = time.Now()
 		sf.staticMetadata.CreateTime = time.Now()
+		sf.staticMetadata.LastVerifiedTime = time.Now()
 		sf.staticMetadata.CachedRedundancy = float64(fastrand.Intn(10))
 		sf.staticMetadata.CachedUserRedundancy = float64(fastrand.Intn(10))
 		sf.staticMetadata.CachedHealth = float64(fastrand.Intn(10))
@@ -150,3 +163,121 @@ func TestBackupRestoreMetadata(t *testing.T) {
 		t.Fatalf("metadata wasn't restored successfully %v %v", mdBefore, sf.staticMetadata)
 	}
 }
+
+// TestSetLastVerifiedTime tests that the last verified time of a SiaFile is
+// persisted.
+func TestSetLastVerifiedTime(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	sf, wal, _ := newBlankTestFileAndWAL(1)
+	if !sf.LastVerifiedTime().IsZero() {
+		t.Fatal("new file shouldn't be verified")
+	}
+	verifiedTime := time.Now()
+	if err := sf.SetLastVerifiedTime(verifiedTime); err != nil {
+		t.Fatal(err)
+	}
+	if !sf.LastVerifiedTime().Equal(verifiedTime) {
+		t.Fatal("wrong time", sf.LastVerifiedTime(), verifiedTime)
+	}
+
+	// Reload the file.
+	sf, err := LoadSiaFile(sf.siaFilePath, wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sf.LastVerifiedTime().Equal(verifiedTime) {
+		t.Fatal("wrong time after reload", sf.LastVerifiedTime(), verifiedTime)
+	}
+}
+
+// TestSetHTTPHeaders tests that the HTTP headers of a SiaFile are persisted.
+func TestSetHTTPHeaders(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	sf, wal, _ := newBlankTestFileAndWAL(1)
+	headers := modules.FileHTTPHeaders{
+		CacheControl:       "max-age=60",
+		ContentDisposition: "inline",
+		ContentType:        "text/plain",
+	}
+	if err := sf.SetHTTPHeaders(headers); err != nil {
+		t.Fatal(err)
+	}
+	if sf.HTTPHeaders() != headers {
+		t.Fatal("wrong headers", sf.HTTPHeaders())
+	}
+
+	// Reload the file.
+	sf, err := LoadSiaFile(sf.siaFilePath, wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sf.HTTPHeaders() != headers {
+		t.Fatal("wrong headers after reload", sf.HTTPHeaders())
+	}
+}
+
+// TestSetExpiry tests that the expiry of a SiaFile is persisted.
+func TestSetExpiry(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	sf, wal, _ := newBlankTestFileAndWAL(1)
+	if !sf.Expiry().Time.IsZero() {
+		t.Fatal("new file shouldn't expire")
+	}
+	expiry := modules.FileExpiry{Time: time.Now().Add(time.Hour), Archive: true}
+	if err := sf.SetExpiry(expiry); err != nil {
+		t.Fatal(err)
+	}
+	if !sf.Expiry().Time.Equal(expiry.Time) || !sf.Expiry().Archive {
+		t.Fatal("wrong expiry", sf.Expiry())
+	}
+
+	// Reload the file.
+	sf, err := LoadSiaFile(sf.siaFilePath, wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sf.Expiry().Time.Equal(expiry.Time) || !sf.Expiry().Archive {
+		t.Fatal("wrong expiry after reload", sf.Expiry())
+	}
+}
+
+// TestSetRepairPriority tests that the repair priority of a SiaFile is
+// persisted.
+func TestSetRepairPriority(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	sf, wal, _ := newBlankTestFileAndWAL(1)
+	if sf.RepairPriority() != modules.FileRepairPriorityNormal {
+		t.Fatal("new file should have normal priority", sf.RepairPriority())
+	}
+	if err := sf.SetRepairPriority(modules.FileRepairPriorityHigh); err != nil {
+		t.Fatal(err)
+	}
+	if sf.RepairPriority() != modules.FileRepairPriorityHigh {
+		t.Fatal("wrong priority", sf.RepairPriority())
+	}
+
+	// Reload the file.
+	sf, err := LoadSiaFile(sf.siaFilePath, wal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sf.RepairPriority() != modules.FileRepairPriorityHigh {
+		t.Fatal("wrong priority after reload", sf.RepairPriority())
+	}
+}
@@ -60,7 +60,7 @@ func LoadSiaFileFromReaderWithChunks(r io.ReadSeeker, path string, wal *writeahe
 		if _, err := r.Read(chunkBytes); err != nil && !errors.Contains(err, io.EOF) {
 			return nil, Chunks{}, errors.AddContext(err, fmt.Sprintf("failed to read chunk %v", chunkIndex))
 		}
-		chunk, err := unmarshalChunk(uint32(sf.staticMetadata.staticErasureCode.NumPieces()), chunkBytes)
+		chunk, err := unmarshalChunk(uint32(sf.staticMetadata.staticErasureCode.NumPieces()), chunkBytes, sf.staticMetadata.hasChecksums())
 		if err != nil {
 			return nil, Chunks{}, errors.AddContext(err, fmt.Sprintf("failed to unmarshal chunk %v", chunkIndex))
 		}
@@ -250,6 +250,9 @@ func loadSiaFileFromReader(r io.ReadSeeker, path string, wal *writeaheadlog.WAL,
 	if err != nil {
 		return nil, errors.AddContext(err, "failed to decode metadata")
 	}
+	// Remember the metadata as it was on disk to verify the header checksum
+	// after loading the pubKeyTable.
+	diskMetadata := sf.staticMetadata
 	// COMPATv137 legacy files might not have a unique id.
 	if sf.staticMetadata.UniqueID == "" {
 		sf.staticMetadata.UniqueID = uniqueID()
@@ -291,6 +294,17 @@ func loadSiaFileFromReader(r io.ReadSeeker, path string, wal *writeaheadlog.WAL,
 			return nil, errors.AddContext(err, "failed to unmarshal pubKeyTable")
 		}
 	}
+	// Verify the header checksum. Files which were created before checksums
+	// were added don't have one.
+	if diskMetadata.hasChecksums() {
+		checksum, err := headerChecksum(diskMetadata, rawPubKeyTable)
+		if err != nil {
+			return nil, err
+		}
+		if checksum != diskMetadata.HeaderChecksum {
+			return nil, ErrCorruptHeader
+		}
+	}
 	// Seek to the start of the chunks.
 	off, err := r.Seek(sf.staticMetadata.ChunkOffset, io.SeekStart)
 	if err != nil {
@@ -392,12 +406,13 @@ func readInsertUpdate(update writeaheadlog.Update) (path string, index int64, da
 	return
 }
 
-// allocateHeaderPage allocates a new page for the metadata and publicKeyTable.
-// It returns an update that moves the chunkData back by one pageSize if
-// applied and also updates the ChunkOffset of the metadata.
-func (sf *SiaFile) allocateHeaderPage() (_ writeaheadlog.Update, err error) {
-	// Sanity check the chunk offset.
-	if sf.staticMetadata.ChunkOffset%pageSize != 0 {
+// allocateHeaderPages moves the chunk data, which currently starts at
+// oldChunkOffset on disk, to the ChunkOffset of the metadata. This allocates
+// the pages in between for the metadata and publicKeyTable. It returns an
+// update that moves the chunkData back if applied.
+func (sf *SiaFile) allocateHeaderPages(oldChunkOffset int64) (_ writeaheadlog.Update, err error) {
+	// Sanity check the chunk offsets.
+	if oldChunkOffset%pageSize != 0 || sf.staticMetadata.ChunkOffset%pageSize != 0 {
 		build.Critical("the chunk offset is not page aligned")
 	}
 	// Open the file.
@@ -408,8 +423,8 @@ func (sf *SiaFile) allocateHeaderPage() (_ writeaheadlog.Update, err error) {
 	defer func() {
 		err = errors.Compose(err, f.Close())
 	}()
-	// Seek the chunk offset.
-	_, err = f.Seek(sf.staticMetadata.ChunkOffset, io.SeekStart)
+	// Seek the chunk offset on disk.
+	_, err = f.Seek(oldChunkOffset, io.SeekStart)
 	if err != nil {
 		return writeaheadlog.Update{}, err
 	}
@@ -418,9 +433,6 @@ func (sf *SiaFile) allocateHeaderPage() (_ writeaheadlog.Update, err error) {
 	if err != nil {
 		return writeaheadlog.Update{}, err
 	}
-	// Move the offset back by a pageSize.
-	sf.staticMetadata.ChunkOffset += pageSize
-
 	// Create and return update.
 	return sf.createInsertUpdate(sf.staticMetadata.ChunkOffset, chunkData), nil
 }
@@ -528,7 +540,7 @@ func (sf *SiaFile) chunk(chunkIndex int) (_ chunk, err error) {
 	if _, err := f.ReadAt(chunkBytes, chunkOffset); err != nil && !errors.Contains(err, io.EOF) {
 		return chunk{}, errors.AddContext(err, "failed to read chunk from disk")
 	}
-	c, err := unmarshalChunk(uint32(sf.staticMetadata.staticErasureCode.NumPieces()), chunkBytes)
+	c, err := unmarshalChunk(uint32(sf.staticMetadata.staticErasureCode.NumPieces()), chunkBytes, sf.staticMetadata.hasChecksums())
 	if err != nil {
 		return chunk{}, errors.AddContext(err, "failed to unmarshal chunk")
 	}
@@ -597,7 +609,7 @@ func (sf *SiaFile) iterateChunksReadonly(iterFunc func(chunk chunk) error) (err
 			if _, err := f.Read(chunkBytes); err != nil && !errors.Contains(err, io.EOF) {
 				return errors.AddContext(err, fmt.Sprintf("failed to read chunk %v", chunkIndex))
 			}
-			c, err = unmarshalChunk(uint32(sf.staticMetadata.staticErasureCode.NumPieces()), chunkBytes)
+			c, err = unmarshalChunk(uint32(sf.staticMetadata.staticErasureCode.NumPieces()), chunkBytes, sf.staticMetadata.hasChecksums())
 			if err != nil {
 				return errors.AddContext(err, fmt.Sprintf("failed to unmarshal chunk %v", chunkIndex))
 			}
@@ -820,16 +832,15 @@ func (sf *SiaFile) saveHeaderUpdates() (_ []writeaheadlog.Update, err error) {
 		return nil, errors.AddContext(err, "failed to marshal metadata")
 	}
 
-	// If the metadata and the pubKeyTable overlap, we need to allocate a new
-	// page for them. Afterwards we need to marshal the metadata again since
+	// If the metadata and the pubKeyTable overlap, we need to allocate new
+	// pages for them. Afterwards we need to marshal the metadata again since
 	// ChunkOffset and PubKeyTableOffset change when allocating a new page.
+	// The chunk data is moved only once at the end since the updates are not
+	// applied to disk until all of them were created.
+	oldChunkOffset := sf.staticMetadata.ChunkOffset
 	for int64(len(metadata))+int64(len(pubKeyTable)) > sf.staticMetadata.ChunkOffset {
-		// Create update to move chunkData back by a page.
-		chunkUpdate, err := sf.allocateHeaderPage()
-		if err != nil {
-			return nil, errors.AddContext(err, "failed to allocate new header page")
-		}
-		updates = append(updates, chunkUpdate)
+		// Move the chunk offset back by a page.
+		sf.staticMetadata.ChunkOffset += pageSize
 		// Update the PubKeyTableOffset.
 		sf.staticMetadata.PubKeyTableOffset = sf.staticMetadata.ChunkOffset - int64(len(pubKeyTable))
 		// Marshal the metadata again.
@@ -838,6 +849,25 @@ func (sf *SiaFile) saveHeaderUpdates() (_ []writeaheadlog.Update, err error) {
 			return nil, errors.AddContext(err, "failed to marshal metadata again")
 		}
 	}
+	if sf.staticMetadata.ChunkOffset != oldChunkOffset {
+		// Create update to move chunkData back.
+		chunkUpdate, err := sf.allocateHeaderPages(oldChunkOffset)
+		if err != nil {
+			return nil, errors.AddContext(err, "failed to allocate new header pages")
+		}
+		updates = append(updates, chunkUpdate)
+	}
+
+	// Update the checksum of the header. The checksum has a fixed length which
+	// means that the length of the marshaled metadata doesn't change.
+	sf.staticMetadata.HeaderChecksum, err = headerChecksum(sf.staticMetadata, pubKeyTable)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to compute header checksum")
+	}
+	metadata, err = marshalMetadata(sf.staticMetadata)
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to marshal metadata with checksum")
+	}
 
 	// Create updates for the metadata and pubKeyTable.
 	updates = append(updates, sf.createInsertUpdate(0, metadata))
@@ -866,6 +896,11 @@ func (sf *SiaFile) saveMetadataUpdates() ([]writeaheadlog.Update, error) {
 		build.Critical("never call saveMetadata if the pubKeyTable changed, call saveHeader instead")
 		return sf.saveHeaderUpdates()
 	}
+	// Update the checksum of the header.
+	sf.staticMetadata.HeaderChecksum, err = headerChecksum(sf.staticMetadata, pubKeyTable)
+	if err != nil {
+		return nil, err
+	}
 	// Marshal the metadata.
 	metadata, err := marshalMetadata(sf.staticMetadata)
 	if err != nil {
@@ -67,6 +67,7 @@ func NewFromLegacyData(fd FileData, siaFilePath string, wal *writeaheadlog.WAL)
 			StaticErasureCodeParams: ecParams,
 			StaticPagesPerChunk:     numChunkPagesRequired(fd.ErasureCode.NumPieces()),
 			StaticPieceSize:         fd.PieceSize,
+			StaticVersion:           metadataVersionChecksums,
 			UniqueID:                SiafileUID(fd.UID),
 		},
 		deps:        modules.ProdDependencies,
@@ -274,11 +274,6 @@ func TestNewFile(t *testing.T) {
 		t.Fatal("StaticPagesPerChunk wasn't set correctly")
 	}
 
-	// Marshal the metadata.
-	md, err := marshalMetadata(sf.staticMetadata)
-	if err != nil {
-		t.Fatal(err)
-	}
 	// Marshal the pubKeyTable.
 	pkt, err := marshalPubKeyTable(sf.pubKeyTable)
 	if err != nil {
@@ -300,6 +295,12 @@ func TestNewFile(t *testing.T) {
 	if err := sf.saveFile(chunksMarshaled); err != nil {
 		t.Fatal(err)
 	}
+	// Marshal the metadata. This happens after saving the file since saving
+	// it updates the header checksum.
+	md, err := marshalMetadata(sf.staticMetadata)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	// Open the file.
 	f, err := os.OpenFile(sf.siaFilePath, os.O_RDWR, 777)
@@ -614,7 +615,7 @@ func TestSaveSmallHeader(t *testing.T) {
 	}
 }
 
-// TestSaveLargeHeader tests the saveHeader method for a header that uses more than a single page on disk and forces a call to allocateHeaderPage
+// TestSaveLargeHeader tests the saveHeader method for a header that uses more than a single page on disk and forces a call to allocateHeaderPages
 func TestSaveLargeHeader(t *testing.T) {
 	if testing.Short() {
 		t.SkipNow()
@@ -658,6 +659,9 @@ func TestSaveLargeHeader(t *testing.T) {
 	// Make sure that the checksum was moved correctly.
 	readChunkData := make([]byte, len(chunkData))
 	if _, err := f.ReadAt(readChunkData, sf.staticMetadata.ChunkOffset); err != nil {
+		t.Fatal("Failed to read moved checksum", err)
+	}
+	if !bytes.Equal(readChunkData, chunkData) {
 		t.Fatal("Checksum wasn't moved correctly")
 	}
 
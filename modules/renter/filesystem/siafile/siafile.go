@@ -29,6 +29,14 @@ var (
 	// ErrDeleted is returned when an operation failed due to the siafile being
 	// deleted already.
 	ErrDeleted = errors.New("files was deleted")
+
+	// ErrCorruptHeader is returned when the header of a SiaFile doesn't match
+	// its checksum.
+	ErrCorruptHeader = errors.New("siafile header doesn't match its checksum")
+
+	// ErrCorruptChunk is returned when a chunk of a SiaFile doesn't match its
+	// checksum.
+	ErrCorruptChunk = errors.New("siafile chunk doesn't match its checksum")
 )
 
 type (
@@ -76,10 +84,6 @@ type (
 
 	// chunk represents a single chunk of a file on disk
 	chunk struct {
-		// ExtensionInfo is some reserved space for each chunk that allows us
-		// to indicate if a chunk is special.
-		ExtensionInfo [16]byte
-
 		// Index is the index of the chunk.
 		Index int
 
@@ -224,6 +228,7 @@ func New(siaFilePath, source string, wal *writeaheadlog.WAL, erasureCode modules
 			StaticErasureCodeParams: ecParams,
 			StaticPagesPerChunk:     numChunkPagesRequired(erasureCode.NumPieces()),
 			StaticPieceSize:         modules.SectorSize - masterKey.Type().Overhead(),
+			StaticVersion:           metadataVersionChecksums,
 			UniqueID:                uniqueID(),
 		},
 		deps:            modules.ProdDependencies,
@@ -414,6 +419,11 @@ func (sf *SiaFile) AddPiece(pk types.SiaPublicKey, chunkIndex, pieceIndex uint64
 	sf.staticMetadata.ChangeTime = sf.staticMetadata.AccessTime
 	sf.staticMetadata.ModTime = sf.staticMetadata.AccessTime
 
+	// Adding a piece to a hole fills it.
+	if sf.isHole(chunkIndex) {
+		sf.staticMetadata.Holes = removeHole(sf.staticMetadata.Holes, chunkIndex)
+	}
+
 	// Defrag the chunk if necessary.
 	chunkSize := marshaledChunkSize(chunk.numPieces())
 	maxChunkSize := int64(sf.staticMetadata.StaticPagesPerChunk) * pageSize
@@ -452,6 +462,10 @@ func (sf *SiaFile) AddPiece(pk types.SiaPublicKey, chunkIndex, pieceIndex uint64
 // health = 0 is full redundancy, health <= 1 is recoverable, health > 1 needs
 // to be repaired from disk or repair by upload streaming
 func (sf *SiaFile) chunkHealth(chunk chunk, offlineMap map[string]bool, goodForRenewMap map[string]bool) (h float64, uh float64, _ uint64, err error) {
+	// Holes don't need to be repaired.
+	if sf.isHole(uint64(chunk.Index)) {
+		return 0, 0, 0, nil
+	}
 	// Handle returning health of complete partial chunk.
 	incomplete := sf.isIncompletePartialChunk(uint64(chunk.Index))
 	if cci, ok := sf.isIncludedPartialChunk(uint64(chunk.Index)); ok && !incomplete {
@@ -534,14 +548,38 @@ func (sf *SiaFile) SaveWithChunks(chunks Chunks) (err error) {
 		}
 	}(sf.staticMetadata.backup())
 
+	updates, err := sf.saveWithChunksUpdates(chunks)
+	if err != nil {
+		return err
+	}
+	return sf.createAndApplyTransaction(updates...)
+}
+
+// SaveWithChunksUpdates returns the writeaheadlog updates which save the
+// file's header and the provided chunks to disk without applying them. This
+// allows for saving the file as part of a larger transaction.
+func (sf *SiaFile) SaveWithChunksUpdates(chunks Chunks) (_ []writeaheadlog.Update, err error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	defer func(backup Metadata) {
+		if err != nil {
+			sf.staticMetadata.restore(backup)
+		}
+	}(sf.staticMetadata.backup())
+	return sf.saveWithChunksUpdates(chunks)
+}
+
+// saveWithChunksUpdates creates the writeaheadlog updates which save the
+// file's header and the provided chunks to disk.
+func (sf *SiaFile) saveWithChunksUpdates(chunks Chunks) ([]writeaheadlog.Update, error) {
 	updates, err := sf.saveHeaderUpdates()
 	if err != nil {
-		return errors.AddContext(err, "failed to create header updates")
+		return nil, errors.AddContext(err, "failed to create header updates")
 	}
 	for _, chunk := range chunks.chunks {
 		updates = append(updates, sf.saveChunkUpdate(chunk))
 	}
-	return sf.createAndApplyTransaction(updates...)
+	return updates, nil
 }
 
 // SaveHeader saves the file's header to disk.
@@ -872,6 +910,10 @@ func (sf *SiaFile) Redundancy(offlineMap map[string]bool, goodForRenewMap map[st
 	minRedundancyNoRenewUser := math.MaxFloat64
 	minRedundancyNoRenew := math.MaxFloat64
 	err = sf.iterateChunksReadonly(func(chunk chunk) error {
+		// Holes have full redundancy.
+		if sf.isHole(uint64(chunk.Index)) {
+			return nil
+		}
 		// Loop over chunks and remember how many unique pieces of the chunk
 		// were goodForRenew and how many were not.
 		numPiecesRenew, numPiecesNoRenew := sf.goodPieces(chunk, offlineMap, goodForRenewMap)
@@ -905,6 +947,13 @@ func (sf *SiaFile) Redundancy(offlineMap map[string]bool, goodForRenewMap map[st
 		return 0, 0, err
 	}
 
+	// If all chunks are holes, the file has full redundancy.
+	if minRedundancy == math.MaxFloat64 {
+		r = float64(ec.NumPieces()) / float64(ec.MinPieces())
+		ur = r
+		return
+	}
+
 	// If the redundancyUser is smaller than 1x we return the redundancy that
 	// includes contracts that are not good for renewal. The reason for this is a
 	// better user experience. If the renter operates correctly, redundancyUser
@@ -1452,7 +1501,16 @@ func (sf *SiaFile) uploadProgressAndBytes() (float64, uint64, error) {
 		sf.staticMetadata.CachedUploadProgress = 100
 		return 100, uploaded, nil
 	}
-	desired := uint64(sf.numChunks) * modules.SectorSize * uint64(sf.staticMetadata.staticErasureCode.NumPieces())
+	// Holes don't need to be uploaded.
+	var holeChunks uint64
+	for _, h := range sf.staticMetadata.Holes {
+		holeChunks += h.End - h.Start
+	}
+	desired := (uint64(sf.numChunks) - holeChunks) * modules.SectorSize * uint64(sf.staticMetadata.staticErasureCode.NumPieces())
+	if desired == 0 {
+		sf.staticMetadata.CachedUploadProgress = 100
+		return 100, uploaded, nil
+	}
 	// Update cache.
 	sf.staticMetadata.CachedUploadProgress = math.Min(100*(float64(uploaded)/float64(desired)), 100)
 	return sf.staticMetadata.CachedUploadProgress, uploaded, nil
@@ -25,7 +25,6 @@ func randomChunk() chunk {
 	numPieces := 30
 	chunk := chunk{}
 	chunk.Pieces = make([][]piece, numPieces)
-	fastrand.Read(chunk.ExtensionInfo[:])
 
 	// Add 0-3 pieces for each pieceIndex within the file.
 	for pieceIndex := range chunk.Pieces {
@@ -23,6 +23,7 @@ type (
 		staticPieceSize       uint64
 		staticErasureCode     modules.ErasureCoder
 		staticHasPartialChunk bool
+		staticHoles           []Hole
 		staticMasterKey       crypto.CipherKey
 		staticMode            os.FileMode
 		staticPubKeyTable     []HostPublicKey
@@ -279,12 +280,14 @@ func (sf *SiaFile) readlockSnapshot(sp modules.SiaPath, chunks []chunk) (*Snapsh
 	uid := sf.staticMetadata.UniqueID
 	hasPartial := sf.staticMetadata.HasPartialChunk
 	pcs := sf.staticMetadata.PartialChunks
+	holes := append([]Hole(nil), sf.staticMetadata.Holes...)
 	localPath := sf.staticMetadata.LocalPath
 
 	return &Snapshot{
 		staticChunks:          exportedChunks,
 		staticPartialChunks:   pcs,
 		staticHasPartialChunk: hasPartial,
+		staticHoles:           holes,
 		staticFileSize:        fileSize,
 		staticPieceSize:       sf.staticMetadata.StaticPieceSize,
 		staticErasureCode:     sf.staticMetadata.staticErasureCode,
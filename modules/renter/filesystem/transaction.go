@@ -0,0 +1,298 @@
+package filesystem
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/writeaheadlog"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem/siafile"
+)
+
+const (
+	// updateCreateDirName is the name of a Filesystem update that creates a
+	// directory on disk.
+	updateCreateDirName = "FilesystemCreateDir"
+
+	// updateRenameName is the name of a Filesystem update that renames a file
+	// or directory on disk.
+	updateRenameName = "FilesystemRename"
+
+	// updateWriteFileName is the name of a Filesystem update that replaces the
+	// contents of a file on disk.
+	updateWriteFileName = "FilesystemWriteFile"
+)
+
+var (
+	// ErrCopyIntoSelf is returned when trying to copy a directory into itself.
+	ErrCopyIntoSelf = errors.New("can't copy a directory into itself")
+
+	// errUnknownFilesystemUpdate is returned when applying an update which
+	// doesn't belong to the Filesystem.
+	errUnknownFilesystemUpdate = errors.New("unknown filesystem update")
+)
+
+// IsFilesystemUpdate returns true if the update belongs to the Filesystem.
+// Filesystem transactions might also contain SiaFile updates which are
+// identified by siafile.IsSiaFileUpdate.
+func IsFilesystemUpdate(update writeaheadlog.Update) bool {
+	switch update.Name {
+	case updateCreateDirName, updateRenameName, updateWriteFileName:
+		return true
+	default:
+		return false
+	}
+}
+
+// ApplyUpdates applies Filesystem and SiaFile updates. All of the updates are
+// idempotent which means that they can be applied again when recovering an
+// unfinished transaction on startup.
+func ApplyUpdates(updates ...writeaheadlog.Update) error {
+	for _, u := range updates {
+		var err error
+		switch {
+		case siafile.IsSiaFileUpdate(u):
+			err = siafile.ApplyUpdates(u)
+		case u.Name == updateCreateDirName:
+			err = applyCreateDirUpdate(u)
+		case u.Name == updateRenameName:
+			err = applyRenameUpdate(u)
+		case u.Name == updateWriteFileName:
+			err = applyWriteFileUpdate(u)
+		default:
+			err = errUnknownFilesystemUpdate
+		}
+		if err != nil {
+			return errors.AddContext(err, fmt.Sprintf("failed to apply %v update", u.Name))
+		}
+	}
+	return nil
+}
+
+// createCreateDirUpdate creates an update which creates the directory at path
+// and all of its missing parents.
+func createCreateDirUpdate(path string) writeaheadlog.Update {
+	return writeaheadlog.Update{
+		Name:         updateCreateDirName,
+		Instructions: []byte(path),
+	}
+}
+
+// createRenameUpdate creates an update which renames the file or directory at
+// oldPath to newPath.
+func createRenameUpdate(oldPath, newPath string) writeaheadlog.Update {
+	return writeaheadlog.Update{
+		Name:         updateRenameName,
+		Instructions: encoding.MarshalAll(oldPath, newPath),
+	}
+}
+
+// createWriteFileUpdate creates an update which replaces the contents of the
+// file at path with data.
+func createWriteFileUpdate(path string, data []byte) writeaheadlog.Update {
+	return writeaheadlog.Update{
+		Name:         updateWriteFileName,
+		Instructions: encoding.MarshalAll(path, data),
+	}
+}
+
+// applyCreateDirUpdate applies an update created by createCreateDirUpdate.
+func applyCreateDirUpdate(u writeaheadlog.Update) error {
+	return os.MkdirAll(string(u.Instructions), modules.DefaultDirPerm)
+}
+
+// applyRenameUpdate applies an update created by createRenameUpdate. If the
+// source no longer exists but the destination does, the update was applied
+// before.
+func applyRenameUpdate(u writeaheadlog.Update) error {
+	var oldPath, newPath string
+	if err := encoding.UnmarshalAll(u.Instructions, &oldPath, &newPath); err != nil {
+		return errors.AddContext(err, "failed to unmarshal rename update")
+	}
+	_, errOld := os.Stat(oldPath)
+	_, errNew := os.Stat(newPath)
+	if os.IsNotExist(errOld) && errNew == nil {
+		return nil // already applied
+	}
+	return os.Rename(oldPath, newPath)
+}
+
+// applyWriteFileUpdate applies an update created by createWriteFileUpdate.
+func applyWriteFileUpdate(u writeaheadlog.Update) (err error) {
+	var path string
+	var data []byte
+	if err := encoding.UnmarshalAll(u.Instructions, &path, &data); err != nil {
+		return errors.AddContext(err, "failed to unmarshal write file update")
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, modules.DefaultFilePerm)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, f.Close())
+	}()
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// createAndApplyTransaction writes the updates to the provided WAL as a single
+// transaction and applies them. Once the transaction is committed, it will
+// be completed on startup if siad is interrupted while applying it.
+func createAndApplyTransaction(wal *writeaheadlog.WAL, updates ...writeaheadlog.Update) (err error) {
+	if len(updates) == 0 {
+		return nil
+	}
+	txn, err := wal.NewTransaction(updates)
+	if err != nil {
+		return errors.AddContext(err, "failed to create wal txn")
+	}
+	if err := <-txn.SignalSetupComplete(); err != nil {
+		return errors.AddContext(err, "failed to signal setup completion")
+	}
+	// Starting at this point the changes to be made are written to the WAL.
+	// This means we need to panic in case applying the updates fails.
+	defer func() {
+		if err != nil {
+			panic(err)
+		}
+	}()
+	if err := ApplyUpdates(updates...); err != nil {
+		return errors.AddContext(err, "failed to apply updates")
+	}
+	if err := txn.SignalUpdatesApplied(); err != nil {
+		return errors.AddContext(err, "failed to signal that updates are applied")
+	}
+	return nil
+}
+
+// CopyDir copies the directory at srcSiaPath together with all of its
+// subdirectories and files to dstSiaPath which must not exist yet. The copied
+// files get new UIDs but reference the same pieces on the hosts as the
+// originals. The metadata of all the copied directories and files is written
+// in a single WAL transaction which means that either the whole tree is
+// copied or nothing at all, even if siad is interrupted during the copy.
+func (fs *FileSystem) CopyDir(srcSiaPath, dstSiaPath modules.SiaPath) (err error) {
+	if dstSiaPath.IsRoot() {
+		return ErrExists
+	}
+	if srcSiaPath.IsRoot() || dstSiaPath.Equals(srcSiaPath) || strings.HasPrefix(dstSiaPath.String(), srcSiaPath.String()+"/") {
+		return ErrCopyIntoSelf
+	}
+	// Open the dir to copy.
+	src, err := fs.managedOpenSiaDir(srcSiaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, src.Close())
+	}()
+	md, err := src.Metadata()
+	if err != nil {
+		return err
+	}
+
+	// Create and open the parent of the copy.
+	dstDirSiaPath, err := dstSiaPath.Dir()
+	if err != nil {
+		return err
+	}
+	if err := fs.NewSiaDir(dstDirSiaPath, md.Mode); err != nil {
+		return errors.AddContext(err, fmt.Sprintf("failed to create SiaDir %v", dstDirSiaPath))
+	}
+	dstDir, err := fs.managedOpenSiaDir(dstDirSiaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, dstDir.Close())
+	}()
+	if exists, err := fs.DirExists(dstSiaPath); err != nil {
+		return err
+	} else if exists {
+		return ErrExists
+	}
+
+	// Collect the updates which create the copy.
+	updates, err := fs.managedCopyDirUpdates(srcSiaPath, src.managedAbsPath(), fs.DirPath(dstSiaPath))
+	if err != nil {
+		return errors.AddContext(err, "failed to prepare copy")
+	}
+
+	// Hold the lock of the parent while committing the copy to make sure
+	// nothing is created at the destination in the meantime.
+	dstDir.mu.Lock()
+	defer dstDir.mu.Unlock()
+	if dstDir.childExists(dstSiaPath.Name()) {
+		return ErrExists
+	}
+	return createAndApplyTransaction(fs.staticWALs.WAL(dstDir.absPath()), updates...)
+}
+
+// managedCopyDirUpdates walks the directory at srcPath and returns the updates
+// which recreate all of its subdirectories, their metadata and the SiaFiles
+// within them at dstPath.
+func (fs *FileSystem) managedCopyDirUpdates(srcSiaPath modules.SiaPath, srcPath, dstPath string) ([]writeaheadlog.Update, error) {
+	var updates []writeaheadlog.Update
+	err := filepath.Walk(srcPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcPath, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dstPath, rel)
+
+		switch {
+		case info.IsDir():
+			updates = append(updates, createCreateDirUpdate(target))
+		case info.Name() == modules.SiaDirExtension:
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return errors.AddContext(err, "failed to read dir metadata")
+			}
+			updates = append(updates, createWriteFileUpdate(target, data))
+		case filepath.Ext(path) == modules.SiaFileExtension:
+			siaPath, err := srcSiaPath.Join(filepath.ToSlash(strings.TrimSuffix(rel, modules.SiaFileExtension)))
+			if err != nil {
+				return err
+			}
+			fileUpdates, err := fs.managedCopyFileUpdates(siaPath, target)
+			if err != nil {
+				return errors.AddContext(err, fmt.Sprintf("failed to copy %v", siaPath))
+			}
+			updates = append(updates, fileUpdates...)
+		}
+		return nil
+	})
+	return updates, err
+}
+
+// managedCopyFileUpdates returns the updates which save a copy of the SiaFile
+// with the provided siapath at the provided system path.
+func (fs *FileSystem) managedCopyFileUpdates(siaPath modules.SiaPath, path string) ([]writeaheadlog.Update, error) {
+	sf, err := fs.OpenSiaFile(siaPath)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	err = sf.Export(&buf)
+	if err := errors.Compose(err, sf.Close()); err != nil {
+		return nil, err
+	}
+	cpy, chunks, err := siafile.Import(&buf, path, fs.staticWALs.WAL(path))
+	if err != nil {
+		return nil, err
+	}
+	cpy.UpdateUniqueID()
+	return cpy.SaveWithChunksUpdates(chunks)
+}
@@ -0,0 +1,133 @@
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem/siadir"
+)
+
+// TestCopyDir tests copying a directory tree within the FileSystem.
+func TestCopyDir(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Prepare a filesystem with a small tree.
+	root := filepath.Join(testDir(t.Name()), "fs-root")
+	os.RemoveAll(root)
+	fs := newTestFileSystem(root)
+	files := []string{"dir/file1", "dir/sub/file2", "dir/sub/subsub/file3"}
+	for _, f := range files {
+		fs.addTestSiaFile(newSiaPath(f))
+	}
+	if err := fs.NewSiaDir(newSiaPath("dir/empty"), modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	md, err := fs.DirMetadata(newSiaPath("dir/sub"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	md.Health = 0.5
+	if err := fs.UpdateDirMetadata(newSiaPath("dir/sub"), md); err != nil {
+		t.Fatal(err)
+	}
+
+	// Copy the tree into a dir which doesn't exist yet.
+	if err := fs.CopyDir(newSiaPath("dir"), newSiaPath("copies/dir")); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		src, err := fs.OpenSiaFile(newSiaPath(f))
+		if err != nil {
+			t.Fatal(err)
+		}
+		dst, err := fs.OpenSiaFile(newSiaPath("copies/" + f))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dst.Size() != src.Size() || dst.UID() == src.UID() {
+			t.Fatal("copy doesn't match original", f)
+		}
+		if err := errors.Compose(src.Close(), dst.Close()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if exists, err := fs.DirExists(newSiaPath("copies/dir/empty")); err != nil || !exists {
+		t.Fatal("empty dir wasn't copied", err)
+	}
+	md, err = fs.DirMetadata(newSiaPath("copies/dir/sub"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if md.Health != 0.5 {
+		t.Fatal("dir metadata wasn't copied", md.Health)
+	}
+
+	// Copying again or into itself should fail.
+	if err := fs.CopyDir(newSiaPath("dir"), newSiaPath("copies/dir")); !errors.Contains(err, ErrExists) {
+		t.Fatal("expected ErrExists", err)
+	}
+	if err := fs.CopyDir(newSiaPath("dir"), newSiaPath("dir/sub/dir")); !errors.Contains(err, ErrCopyIntoSelf) {
+		t.Fatal("expected ErrCopyIntoSelf", err)
+	}
+	if err := fs.CopyDir(newSiaPath("missing"), newSiaPath("copies/missing")); !errors.Contains(err, ErrNotExist) {
+		t.Fatal("expected ErrNotExist", err)
+	}
+}
+
+// TestApplyUpdatesIdempotent makes sure that the updates of copies and
+// renames can be applied again when recovering them on startup.
+func TestApplyUpdatesIdempotent(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	root := filepath.Join(testDir(t.Name()), "fs-root")
+	os.RemoveAll(root)
+	fs := newTestFileSystem(root)
+	fs.addTestSiaFile(newSiaPath("dir/sub/file"))
+
+	// Apply the updates of a copy twice.
+	src, err := fs.OpenSiaDir(newSiaPath("dir"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	updates, err := fs.managedCopyDirUpdates(newSiaPath("dir"), src.managedAbsPath(), fs.DirPath(newSiaPath("copy")))
+	if err := errors.Compose(err, src.Close()); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := ApplyUpdates(updates...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sf, err := fs.OpenSiaFile(newSiaPath("copy/sub/file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sf.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Apply a rename twice.
+	oldPath := fs.DirPath(newSiaPath("copy"))
+	newPath := fs.DirPath(newSiaPath("renamed"))
+	for i := 0; i < 2; i++ {
+		if err := ApplyUpdates(createRenameUpdate(oldPath, newPath)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(newPath, "sub", siadir.SiaDirExtension)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(oldPath); !os.IsNotExist(err) {
+		t.Fatal("old dir still exists", err)
+	}
+}
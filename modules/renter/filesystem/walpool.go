@@ -0,0 +1,46 @@
+package filesystem
+
+import (
+	"hash/fnv"
+
+	"gitlab.com/NebulousLabs/writeaheadlog"
+)
+
+// WALPool is a set of writeaheadlogs which the updates of the SiaFiles in the
+// Filesystem are sharded across. Every SiaFile is assigned to one of the WALs
+// by the hash of its path which avoids funneling all updates through a single
+// WAL. Since SiaFile updates are idempotent and only touch a single file, any
+// WAL can be used to apply any update. The assignment therefore doesn't need
+// to be stable across restarts or renames as long as all the WALs are
+// recovered on startup. A SiaFile never has more than one unapplied
+// transaction at a time so the order in which the WALs are recovered doesn't
+// matter either.
+type WALPool struct {
+	staticWALs []*writeaheadlog.WAL
+}
+
+// NewWALPool creates a new WALPool from the provided WALs.
+func NewWALPool(wals ...*writeaheadlog.WAL) *WALPool {
+	if len(wals) == 0 {
+		panic("WALPool requires at least one WAL")
+	}
+	return &WALPool{
+		staticWALs: wals,
+	}
+}
+
+// Len returns the number of WALs in the pool.
+func (p *WALPool) Len() int {
+	return len(p.staticWALs)
+}
+
+// WAL returns the WAL which should be used for the SiaFile at the provided
+// path.
+func (p *WALPool) WAL(path string) *writeaheadlog.WAL {
+	if len(p.staticWALs) == 1 {
+		return p.staticWALs[0]
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(path))
+	return p.staticWALs[h.Sum64()%uint64(len(p.staticWALs))]
+}
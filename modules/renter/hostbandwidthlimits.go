@@ -0,0 +1,131 @@
+package renter
+
+import (
+	"sort"
+	"sync"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/ratelimit"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// hostBandwidthLimits contains the bandwidth limits of individual hosts. The
+// limits are enforced by a ratelimit per host which is shared by all the
+// streams and connections the renter opens to the host.
+type hostBandwidthLimits struct {
+	limits map[string]modules.RenterHostBandwidthLimits
+	rls    map[string]*ratelimit.RateLimit
+	mu     sync.Mutex
+}
+
+// newHostBandwidthLimits creates a new hostBandwidthLimits without any limits.
+func newHostBandwidthLimits() *hostBandwidthLimits {
+	return &hostBandwidthLimits{
+		limits: make(map[string]modules.RenterHostBandwidthLimits),
+		rls:    make(map[string]*ratelimit.RateLimit),
+	}
+}
+
+// managedLimits returns the limits of all hosts which have one, sorted by
+// host key.
+func (hbl *hostBandwidthLimits) managedLimits() []modules.RenterHostBandwidthLimits {
+	hbl.mu.Lock()
+	defer hbl.mu.Unlock()
+	limits := make([]modules.RenterHostBandwidthLimits, 0, len(hbl.limits))
+	for _, l := range hbl.limits {
+		limits = append(limits, l)
+	}
+	sort.Slice(limits, func(i, j int) bool {
+		return limits[i].HostPublicKey.String() < limits[j].HostPublicKey.String()
+	})
+	return limits
+}
+
+// managedHostLimits returns the limits of a single host.
+func (hbl *hostBandwidthLimits) managedHostLimits(pk types.SiaPublicKey) (modules.RenterHostBandwidthLimits, bool) {
+	hbl.mu.Lock()
+	defer hbl.mu.Unlock()
+	l, exists := hbl.limits[pk.String()]
+	return l, exists
+}
+
+// managedRateLimit returns the ratelimit of a host or nil if the host has no
+// limits.
+func (hbl *hostBandwidthLimits) managedRateLimit(pk types.SiaPublicKey) *ratelimit.RateLimit {
+	hbl.mu.Lock()
+	defer hbl.mu.Unlock()
+	return hbl.rls[pk.String()]
+}
+
+// managedSetLimits updates the limits of a host and returns its ratelimit. If
+// both limits are 0, the limits of the host are removed and nil is returned.
+// The ratelimit of a host is updated in place, which means that the new
+// limits also apply to the host's open streams and connections.
+func (hbl *hostBandwidthLimits) managedSetLimits(limits modules.RenterHostBandwidthLimits) *ratelimit.RateLimit {
+	hbl.mu.Lock()
+	defer hbl.mu.Unlock()
+	key := limits.HostPublicKey.String()
+	rl, exists := hbl.rls[key]
+	if limits.MaxDownloadSpeed == 0 && limits.MaxUploadSpeed == 0 {
+		if exists {
+			rl.SetLimits(0, 0, 0)
+		}
+		delete(hbl.limits, key)
+		delete(hbl.rls, key)
+		return nil
+	}
+	if !exists {
+		rl = ratelimit.NewRateLimit(0, 0, 0)
+		hbl.rls[key] = rl
+	}
+	rl.SetLimits(limits.MaxDownloadSpeed, limits.MaxUploadSpeed, 4*4096)
+	hbl.limits[key] = limits
+	return rl
+}
+
+// HostBandwidthLimits returns the bandwidth limits of all hosts which have one.
+func (r *Renter) HostBandwidthLimits() []modules.RenterHostBandwidthLimits {
+	return r.staticHostBandwidthLimits.managedLimits()
+}
+
+// SetHostBandwidthLimits sets the bandwidth limits of the worker of a host.
+// Setting both limits to 0 removes the limits of the host.
+func (r *Renter) SetHostBandwidthLimits(limits modules.RenterHostBandwidthLimits) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if limits.MaxDownloadSpeed < 0 || limits.MaxUploadSpeed < 0 {
+		return errors.New("bandwidth limits cannot be negative")
+	}
+
+	// Persist the change.
+	id := r.mu.Lock()
+	var persisted []modules.RenterHostBandwidthLimits
+	for _, l := range r.persist.HostBandwidthLimits {
+		if !l.HostPublicKey.Equals(limits.HostPublicKey) {
+			persisted = append(persisted, l)
+		}
+	}
+	if limits.MaxDownloadSpeed != 0 || limits.MaxUploadSpeed != 0 {
+		persisted = append(persisted, limits)
+	}
+	r.persist.HostBandwidthLimits = persisted
+	err := r.saveSync()
+	r.mu.Unlock(id)
+	if err != nil {
+		return errors.AddContext(err, "failed to persist host bandwidth limits")
+	}
+	r.managedApplyHostBandwidthLimits(limits)
+	return nil
+}
+
+// managedApplyHostBandwidthLimits applies the bandwidth limits of a host to
+// the streams opened by its worker as well as to the RHP2 connections opened
+// by the contractor.
+func (r *Renter) managedApplyHostBandwidthLimits(limits modules.RenterHostBandwidthLimits) {
+	rl := r.staticHostBandwidthLimits.managedSetLimits(limits)
+	r.hostContractor.SetHostRateLimit(limits.HostPublicKey, rl)
+}
@@ -0,0 +1,119 @@
+package renter
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/ratelimit"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestHostBandwidthLimits is a unit test for the hostBandwidthLimits.
+func TestHostBandwidthLimits(t *testing.T) {
+	t.Parallel()
+
+	hbl := newHostBandwidthLimits()
+	pk1 := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{1}}
+	pk2 := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{2}}
+
+	// A host without limits has no ratelimit.
+	if rl := hbl.managedRateLimit(pk1); rl != nil {
+		t.Fatal("expected no ratelimit")
+	}
+	if _, exists := hbl.managedHostLimits(pk1); exists {
+		t.Fatal("expected no limits")
+	}
+
+	// Set the limits of both hosts.
+	rl1 := hbl.managedSetLimits(modules.RenterHostBandwidthLimits{HostPublicKey: pk2, MaxDownloadSpeed: 100})
+	rl2 := hbl.managedSetLimits(modules.RenterHostBandwidthLimits{HostPublicKey: pk1, MaxUploadSpeed: 200})
+	if rl1 == nil || rl2 == nil || rl1 == rl2 {
+		t.Fatal("expected two different ratelimits")
+	}
+	limits := hbl.managedLimits()
+	if len(limits) != 2 || !limits[0].HostPublicKey.Equals(pk1) || !limits[1].HostPublicKey.Equals(pk2) {
+		t.Fatal("wrong limits", limits)
+	}
+	if l, exists := hbl.managedHostLimits(pk1); !exists || l.MaxUploadSpeed != 200 || l.MaxDownloadSpeed != 0 {
+		t.Fatal("wrong limits", l)
+	}
+
+	// Updating the limits of a host updates its ratelimit in place.
+	rl := hbl.managedSetLimits(modules.RenterHostBandwidthLimits{HostPublicKey: pk1, MaxUploadSpeed: 300, MaxDownloadSpeed: 400})
+	if rl != rl2 || hbl.managedRateLimit(pk1) != rl2 {
+		t.Fatal("ratelimit wasn't updated in place")
+	}
+	if l, _ := hbl.managedHostLimits(pk1); l.MaxUploadSpeed != 300 || l.MaxDownloadSpeed != 400 {
+		t.Fatal("wrong limits", l)
+	}
+
+	// Setting both limits to 0 removes the host's limits.
+	if rl := hbl.managedSetLimits(modules.RenterHostBandwidthLimits{HostPublicKey: pk1}); rl != nil {
+		t.Fatal("expected no ratelimit")
+	}
+	if hbl.managedRateLimit(pk1) != nil {
+		t.Fatal("ratelimit wasn't removed")
+	}
+	if limits := hbl.managedLimits(); len(limits) != 1 || !limits[0].HostPublicKey.Equals(pk2) {
+		t.Fatal("wrong limits", limits)
+	}
+}
+
+// TestHostBandwidthLimitsPersist tests that the bandwidth limits of hosts are
+// persisted across restarts of the renter.
+func TestHostBandwidthLimitsPersist(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Negative limits are rejected.
+	pk := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{1}}
+	err = rt.renter.SetHostBandwidthLimits(modules.RenterHostBandwidthLimits{HostPublicKey: pk, MaxDownloadSpeed: -1})
+	if err == nil {
+		t.Fatal("expected negative limits to be rejected")
+	}
+
+	// Set limits and restart the renter.
+	limits := modules.RenterHostBandwidthLimits{HostPublicKey: pk, MaxDownloadSpeed: 100, MaxUploadSpeed: 200}
+	if err := rt.renter.SetHostBandwidthLimits(limits); err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.renter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var errChan <-chan error
+	rl := ratelimit.NewRateLimit(0, 0, 0)
+	rt.renter, errChan = New(rt.gateway, rt.cs, rt.wallet, rt.tpool, rt.mux, rl, filepath.Join(rt.dir, modules.RenterDir))
+	if err := <-errChan; err != nil {
+		t.Fatal(err)
+	}
+
+	// The limits and the host's ratelimit should be restored.
+	loaded := rt.renter.HostBandwidthLimits()
+	if len(loaded) != 1 || !loaded[0].HostPublicKey.Equals(pk) || loaded[0].MaxDownloadSpeed != 100 || loaded[0].MaxUploadSpeed != 200 {
+		t.Fatal("limits weren't persisted", loaded)
+	}
+	if rt.renter.staticHostBandwidthLimits.managedRateLimit(pk) == nil {
+		t.Fatal("ratelimit wasn't restored")
+	}
+
+	// Removing the limits is persisted as well.
+	if err := rt.renter.SetHostBandwidthLimits(modules.RenterHostBandwidthLimits{HostPublicKey: pk}); err != nil {
+		t.Fatal(err)
+	}
+	if len(rt.renter.HostBandwidthLimits()) != 0 || len(rt.renter.persist.HostBandwidthLimits) != 0 {
+		t.Fatal("limits weren't removed")
+	}
+}
@@ -0,0 +1,74 @@
+package hostdb
+
+import (
+	"math"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// addressChangePenalty is the multiplier that gets applied to the score of
+	// a host for every address change within the addressChangeWindow that
+	// exceeds addressChangesAllowed.
+	addressChangePenalty = 0.5
+
+	// addressChangesAllowed is the number of address changes within the
+	// addressChangeWindow which don't result in a penalty. Hosts occasionally
+	// need to move and shouldn't be penalized for doing so.
+	addressChangesAllowed = 2
+
+	// maxAddressHistory is the maximum number of addresses that are kept in
+	// the address history of a host.
+	maxAddressHistory = 20
+)
+
+var (
+	// addressChangeWindow is the number of blocks within which address changes
+	// are considered when computing the address change adjustment.
+	addressChangeWindow = 30 * types.BlocksPerDay
+)
+
+// updateAddressHistory adds the host's current NetAddress to its address
+// history if it differs from the most recently recorded address. The history
+// of hosts which predate it is seeded with their previous address at the
+// height they were first seen. Announcements which are older than the most
+// recent entry, e.g. because of a rescan, are ignored.
+func updateAddressHistory(entry *modules.HostDBEntry, oldAddress modules.NetAddress, height types.BlockHeight) {
+	if len(entry.AddressHistory) == 0 && oldAddress != "" {
+		entry.AddressHistory = append(entry.AddressHistory, modules.HostDBAddress{
+			NetAddress:  oldAddress,
+			BlockHeight: entry.FirstSeen,
+		})
+	}
+	if n := len(entry.AddressHistory); n > 0 {
+		last := entry.AddressHistory[n-1]
+		if last.NetAddress == entry.NetAddress || last.BlockHeight > height {
+			return
+		}
+	}
+	entry.AddressHistory = append(entry.AddressHistory, modules.HostDBAddress{
+		NetAddress:  entry.NetAddress,
+		BlockHeight: height,
+	})
+	if len(entry.AddressHistory) > maxAddressHistory {
+		entry.AddressHistory = append([]modules.HostDBAddress{}, entry.AddressHistory[len(entry.AddressHistory)-maxAddressHistory:]...)
+	}
+}
+
+// addressChangeAdjustments penalizes hosts which frequently change their
+// address, which is typical for hosts running on a dynamic IP without a DDNS.
+// Every change within the addressChangeWindow beyond addressChangesAllowed
+// halves the score of the host.
+func (hdb *HostDB) addressChangeAdjustments(entry modules.HostDBEntry) float64 {
+	changes := 0
+	for i := 1; i < len(entry.AddressHistory); i++ {
+		if entry.AddressHistory[i].BlockHeight+addressChangeWindow >= hdb.blockHeight {
+			changes++
+		}
+	}
+	if changes <= addressChangesAllowed {
+		return 1
+	}
+	return math.Pow(addressChangePenalty, float64(changes-addressChangesAllowed))
+}
@@ -0,0 +1,111 @@
+package hostdb
+
+import (
+	"fmt"
+	"testing"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestUpdateAddressHistory is a unit test for updateAddressHistory.
+func TestUpdateAddressHistory(t *testing.T) {
+	t.Parallel()
+
+	// A new host starts with its announced address.
+	entry := modules.HostDBEntry{FirstSeen: 10}
+	entry.NetAddress = "foo.com:1234"
+	updateAddressHistory(&entry, "", 10)
+	if len(entry.AddressHistory) != 1 || entry.AddressHistory[0] != (modules.HostDBAddress{NetAddress: "foo.com:1234", BlockHeight: 10}) {
+		t.Fatal("unexpected history", entry.AddressHistory)
+	}
+
+	// Announcing the same address again doesn't change the history.
+	updateAddressHistory(&entry, "foo.com:1234", 20)
+	if len(entry.AddressHistory) != 1 {
+		t.Fatal("unexpected history", entry.AddressHistory)
+	}
+
+	// A new address is added.
+	entry.NetAddress = "bar.com:1234"
+	updateAddressHistory(&entry, "foo.com:1234", 30)
+	if len(entry.AddressHistory) != 2 || entry.AddressHistory[1] != (modules.HostDBAddress{NetAddress: "bar.com:1234", BlockHeight: 30}) {
+		t.Fatal("unexpected history", entry.AddressHistory)
+	}
+
+	// Older announcements are ignored.
+	entry.NetAddress = "foo.com:1234"
+	updateAddressHistory(&entry, "bar.com:1234", 15)
+	if len(entry.AddressHistory) != 2 {
+		t.Fatal("unexpected history", entry.AddressHistory)
+	}
+
+	// Hosts without a history are seeded with their previous address.
+	legacy := modules.HostDBEntry{FirstSeen: 5}
+	legacy.NetAddress = "bar.com:1234"
+	updateAddressHistory(&legacy, "foo.com:1234", 50)
+	expected := []modules.HostDBAddress{{NetAddress: "foo.com:1234", BlockHeight: 5}, {NetAddress: "bar.com:1234", BlockHeight: 50}}
+	if fmt.Sprint(legacy.AddressHistory) != fmt.Sprint(expected) {
+		t.Fatal("unexpected history", legacy.AddressHistory)
+	}
+
+	// The history is capped.
+	for i := 0; i < 2*maxAddressHistory; i++ {
+		entry.NetAddress = modules.NetAddress(fmt.Sprintf("%v.com:1234", i))
+		updateAddressHistory(&entry, "", types.BlockHeight(100+i))
+	}
+	if len(entry.AddressHistory) != maxAddressHistory {
+		t.Fatal("history wasn't capped", len(entry.AddressHistory))
+	}
+	if last := entry.AddressHistory[maxAddressHistory-1]; last.NetAddress != entry.NetAddress {
+		t.Fatal("wrong last address", last)
+	}
+}
+
+// TestAddressChangeAdjustments checks that hosts which change their address
+// frequently are penalized.
+func TestAddressChangeAdjustments(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	hdb := bareHostDB()
+	err := hdb.SetAllowance(DefaultTestAllowance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hdb.blockHeight = 10 * addressChangeWindow
+
+	// Create a history with one address change per day for the last week.
+	entry := DefaultHostDBEntry
+	for i := types.BlockHeight(0); i < 8; i++ {
+		entry.AddressHistory = append(entry.AddressHistory, modules.HostDBAddress{
+			NetAddress:  modules.NetAddress(fmt.Sprintf("%v.com:1234", i)),
+			BlockHeight: hdb.blockHeight - (7-i)*types.BlocksPerDay,
+		})
+	}
+	if adj := hdb.addressChangeAdjustments(entry); adj != 1.0/32 {
+		t.Fatal("unexpected adjustment", adj)
+	}
+	if hdb.weightFunc(DefaultHostDBEntry).Score().Cmp(hdb.weightFunc(entry).Score()) <= 0 {
+		t.Fatal("unstable host should have a smaller weight")
+	}
+
+	// A few changes are allowed.
+	entry.AddressHistory = entry.AddressHistory[:addressChangesAllowed+1]
+	if adj := hdb.addressChangeAdjustments(entry); adj != 1 {
+		t.Fatal("unexpected adjustment", adj)
+	}
+
+	// Once the changes are old enough, they are no longer penalized.
+	entry.AddressHistory = []modules.HostDBAddress{{NetAddress: "foo.com:1234"}}
+	for i := types.BlockHeight(0); i < 8; i++ {
+		entry.AddressHistory = append(entry.AddressHistory, modules.HostDBAddress{
+			NetAddress:  modules.NetAddress(fmt.Sprintf("%v.com:1234", i)),
+			BlockHeight: i,
+		})
+	}
+	if adj := hdb.addressChangeAdjustments(entry); adj != 1 {
+		t.Fatal("unexpected adjustment", adj)
+	}
+}
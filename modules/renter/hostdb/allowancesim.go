@@ -0,0 +1,174 @@
+package hostdb
+
+import (
+	"sort"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errSimulationNoHosts is returned when simulating an allowance without
+	// hosts.
+	errSimulationNoHosts = errors.New("can't simulate an allowance without hosts")
+
+	// errSimulationNoPeriod is returned when simulating an allowance without a
+	// period.
+	errSimulationNoPeriod = errors.New("can't simulate an allowance without a period")
+)
+
+// simulatedHost is the state of a host at a certain point in time according to
+// its scan records.
+type simulatedHost struct {
+	settings modules.HostExternalSettings
+	online   bool
+	score    types.Currency
+}
+
+// simulatedHostAt returns the state of a host at the provided time. If there is
+// no record with settings at or before that time, 'false' is returned.
+func simulatedHostAt(records []modules.HostDBScanRecord, t time.Time) (simulatedHost, bool) {
+	var host simulatedHost
+	var settings *modules.HostExternalSettings
+	for _, record := range records {
+		if record.Timestamp.After(t) {
+			break
+		}
+		if record.Settings != nil {
+			settings = record.Settings
+		}
+		host.online = record.Success
+		host.score = record.Score
+	}
+	if settings == nil {
+		return simulatedHost{}, false
+	}
+	host.settings = *settings
+	return host, true
+}
+
+// simulatedHostQualifies returns whether a contract would have been formed with
+// a host given the provided allowance.
+func simulatedHostQualifies(host simulatedHost, a modules.Allowance) bool {
+	s := host.settings
+	if !host.online || !s.AcceptingContracts || s.MaxDuration < a.Period+a.RenewWindow {
+		return false
+	}
+	exceeds := func(price, max types.Currency) bool {
+		return !max.IsZero() && price.Cmp(max) > 0
+	}
+	return !exceeds(s.BaseRPCPrice, a.MaxRPCPrice) &&
+		!exceeds(s.ContractPrice, a.MaxContractPrice) &&
+		!exceeds(s.DownloadBandwidthPrice, a.MaxDownloadBandwidthPrice) &&
+		!exceeds(s.SectorAccessPrice, a.MaxSectorAccessPrice) &&
+		!exceeds(s.StoragePrice, a.MaxStoragePrice) &&
+		!exceeds(s.UploadBandwidthPrice, a.MaxUploadBandwidthPrice)
+}
+
+// simulateAllowanceAt computes a single sample of an allowance simulation. The
+// expected costs are based on the average prices of the best scoring
+// qualifying hosts, extrapolated to the allowance's number of hosts.
+func simulateAllowanceAt(records map[string][]modules.HostDBScanRecord, a modules.Allowance, t time.Time) modules.AllowanceSimulationSample {
+	sample := modules.AllowanceSimulationSample{Timestamp: t}
+	var qualifying []simulatedHost
+	for _, hostRecords := range records {
+		host, known := simulatedHostAt(hostRecords, t)
+		if !known {
+			continue
+		}
+		sample.KnownHosts++
+		if simulatedHostQualifies(host, a) {
+			qualifying = append(qualifying, host)
+		}
+	}
+	sample.QualifyingHosts = uint64(len(qualifying))
+	sample.EnoughHosts = sample.QualifyingHosts >= a.Hosts
+	if len(qualifying) == 0 {
+		return sample
+	}
+
+	// Contracts are formed with the best scoring hosts.
+	sort.Slice(qualifying, func(i, j int) bool {
+		return qualifying[i].score.Cmp(qualifying[j].score) > 0
+	})
+	if uint64(len(qualifying)) > a.Hosts {
+		qualifying = qualifying[:a.Hosts]
+	}
+
+	// Every host stores and receives its share of the redundant data and
+	// serves its share of the downloads.
+	period := uint64(a.Period)
+	storagePerHost := uint64(float64(a.ExpectedStorage)*a.ExpectedRedundancy) / a.Hosts
+	uploadPerHost := uint64(float64(a.ExpectedUpload*period)*a.ExpectedRedundancy) / a.Hosts
+	downloadPerHost := a.ExpectedDownload * period / a.Hosts
+	for _, host := range qualifying {
+		s := host.settings
+		sample.ContractCost = sample.ContractCost.Add(s.ContractPrice)
+		sample.DownloadCost = sample.DownloadCost.Add(s.DownloadBandwidthPrice.Mul64(downloadPerHost))
+		sample.StorageCost = sample.StorageCost.Add(s.StoragePrice.Mul64(storagePerHost).Mul64(period))
+		sample.UploadCost = sample.UploadCost.Add(s.UploadBandwidthPrice.Mul64(uploadPerHost))
+	}
+	n := uint64(len(qualifying))
+	sample.ContractCost = sample.ContractCost.Mul64(a.Hosts).Div64(n)
+	sample.DownloadCost = sample.DownloadCost.Mul64(a.Hosts).Div64(n)
+	sample.StorageCost = sample.StorageCost.Mul64(a.Hosts).Div64(n)
+	sample.UploadCost = sample.UploadCost.Mul64(a.Hosts).Div64(n)
+	sample.TotalCost = sample.ContractCost.Add(sample.DownloadCost).Add(sample.StorageCost).Add(sample.UploadCost)
+	sample.WithinFunds = sample.TotalCost.Cmp(a.Funds) <= 0
+	return sample
+}
+
+// simulateAllowance replays the scan records of the hosts against an allowance
+// from end-window to end. Samples for which none of the hosts have recorded
+// settings are skipped.
+func simulateAllowance(records map[string][]modules.HostDBScanRecord, a modules.Allowance, window time.Duration, end time.Time) modules.AllowanceSimulation {
+	sim := modules.AllowanceSimulation{Allowance: a}
+	start := end.Add(-window)
+	for t := start; !t.After(end); t = t.Add(allowanceSimulationInterval) {
+		sample := simulateAllowanceAt(records, a, t)
+		if sample.KnownHosts == 0 {
+			continue
+		}
+		if len(sim.Samples) == 0 || sample.QualifyingHosts < sim.MinQualifyingHosts {
+			sim.MinQualifyingHosts = sample.QualifyingHosts
+		}
+		if sample.TotalCost.Cmp(sim.MaxTotalCost) > 0 {
+			sim.MaxTotalCost = sample.TotalCost
+		}
+		if sample.EnoughHosts && sample.WithinFunds {
+			sim.SufficientSamples++
+		}
+		sim.Samples = append(sim.Samples, sample)
+	}
+	return sim
+}
+
+// SimulateAllowance replays the recorded settings of the hosts over the
+// provided window against an allowance. It reports how many hosts would have
+// qualified for contracts and the expected cost of a period at regular
+// intervals. The window is limited by how long the scan records are kept.
+func (hdb *HostDB) SimulateAllowance(a modules.Allowance, window time.Duration) (modules.AllowanceSimulation, error) {
+	if err := hdb.tg.Add(); err != nil {
+		return modules.AllowanceSimulation{}, err
+	}
+	defer hdb.tg.Done()
+	if a.Hosts == 0 {
+		return modules.AllowanceSimulation{}, errSimulationNoHosts
+	}
+	if a.Period == 0 {
+		return modules.AllowanceSimulation{}, errSimulationNoPeriod
+	}
+
+	// Records are only ever appended to or replaced which means a shallow
+	// copy is enough to release the lock during the simulation.
+	hdb.mu.RLock()
+	records := make(map[string][]modules.HostDBScanRecord, len(hdb.scanRecords))
+	for key, hostRecords := range hdb.scanRecords {
+		records[key] = hostRecords
+	}
+	hdb.mu.RUnlock()
+	return simulateAllowance(records, a, window, time.Now()), nil
+}
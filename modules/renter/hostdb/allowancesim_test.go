@@ -0,0 +1,98 @@
+package hostdb
+
+import (
+	"testing"
+	"time"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestSimulateAllowance is a unit test for simulateAllowance.
+func TestSimulateAllowance(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	start := now.Add(-3 * allowanceSimulationInterval)
+	settings := func(storagePrice uint64) *modules.HostExternalSettings {
+		return &modules.HostExternalSettings{
+			AcceptingContracts: true,
+			MaxDuration:        1000,
+			ContractPrice:      types.NewCurrency64(10),
+			StoragePrice:       types.NewCurrency64(storagePrice),
+		}
+	}
+
+	// Host a is cheap throughout the simulation. Host b raises its price in
+	// the second half and host c goes offline at the end. Host d only shows
+	// up in the middle.
+	records := map[string][]modules.HostDBScanRecord{
+		"a": {
+			{Timestamp: start, Success: true, Score: types.NewCurrency64(4), Settings: settings(1)},
+		},
+		"b": {
+			{Timestamp: start, Success: true, Score: types.NewCurrency64(3), Settings: settings(1)},
+			{Timestamp: start.Add(2 * allowanceSimulationInterval), Success: true, Score: types.NewCurrency64(3), Settings: settings(5)},
+		},
+		"c": {
+			{Timestamp: start, Success: true, Score: types.NewCurrency64(2), Settings: settings(1)},
+			{Timestamp: now, Success: false},
+		},
+		"d": {
+			{Timestamp: start.Add(allowanceSimulationInterval), Success: true, Score: types.NewCurrency64(1), Settings: settings(1)},
+		},
+	}
+	a := modules.Allowance{
+		Funds:              types.NewCurrency64(1000),
+		Hosts:              2,
+		Period:             100,
+		RenewWindow:        50,
+		ExpectedStorage:    2,
+		ExpectedRedundancy: 1,
+		MaxStoragePrice:    types.NewCurrency64(2),
+	}
+
+	// Nothing is known before the first records.
+	sim := simulateAllowance(records, a, 4*allowanceSimulationInterval, now)
+	if len(sim.Samples) != 4 {
+		t.Fatal("expected 4 samples", len(sim.Samples))
+	}
+	expected := []struct {
+		known, qualifying uint64
+	}{{3, 3}, {4, 4}, {4, 3}, {4, 2}}
+	for i, sample := range sim.Samples {
+		if sample.KnownHosts != expected[i].known || sample.QualifyingHosts != expected[i].qualifying {
+			t.Fatal("unexpected sample", i, sample.KnownHosts, sample.QualifyingHosts)
+		}
+		// The 2 best qualifying hosts always charge 10 per contract and 1
+		// per byte per block.
+		if !sample.ContractCost.Equals64(20) || !sample.StorageCost.Equals64(200) || !sample.TotalCost.Equals64(220) {
+			t.Fatal("unexpected costs", i, sample.ContractCost, sample.StorageCost, sample.TotalCost)
+		}
+		if !sample.EnoughHosts || !sample.WithinFunds {
+			t.Fatal("sample should be sufficient", i)
+		}
+	}
+	if sim.MinQualifyingHosts != 2 || !sim.MaxTotalCost.Equals64(220) || sim.SufficientSamples != 4 {
+		t.Fatal("unexpected aggregates", sim.MinQualifyingHosts, sim.MaxTotalCost, sim.SufficientSamples)
+	}
+
+	// With less funds and more hosts, the samples aren't sufficient anymore.
+	// Costs are extrapolated to the full set of hosts.
+	a.Funds = types.NewCurrency64(10)
+	a.Hosts = 5
+	sim = simulateAllowance(records, a, 4*allowanceSimulationInterval, now)
+	if sim.SufficientSamples != 0 || sim.Samples[0].EnoughHosts || sim.Samples[0].WithinFunds {
+		t.Fatal("samples shouldn't be sufficient", sim.SufficientSamples)
+	}
+	if !sim.Samples[0].ContractCost.Equals64(50) {
+		t.Fatal("contract cost should be extrapolated", sim.Samples[0].ContractCost)
+	}
+
+	// A host which doesn't allow for long enough contracts doesn't qualify.
+	a.Period = 1000
+	sim = simulateAllowance(records, a, 4*allowanceSimulationInterval, now)
+	if sim.MinQualifyingHosts != 0 || !sim.MaxTotalCost.IsZero() {
+		t.Fatal("no host should qualify", sim.MinQualifyingHosts, sim.MaxTotalCost)
+	}
+}
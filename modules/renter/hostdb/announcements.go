@@ -0,0 +1,244 @@
+package hostdb
+
+import (
+	"bytes"
+	"sort"
+	"time"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// hostAnnouncement is a host announcement which was found in the blockchain.
+// The announcement is kept in its encoded form since it is signed by the host,
+// which allows peers to verify it without trusting the hostdb sharing it.
+type hostAnnouncement struct {
+	Announcement []byte            `json:"announcement"`
+	BlockHeight  types.BlockHeight `json:"blockheight"`
+}
+
+// cacheAnnouncements adds the host announcements of a block to the cache of
+// recent announcements. Only the most recent announcement of every host is
+// kept and the oldest announcements are dropped once the cache is full.
+func (hdb *HostDB) cacheAnnouncements(b types.Block, height types.BlockHeight) {
+	for _, t := range b.Transactions {
+		for _, arb := range t.ArbitraryData {
+			if len(arb) > maxSharedAnnouncementLen {
+				continue
+			}
+			_, pubKey, err := modules.DecodeMultiAddressAnnouncement(arb)
+			if err != nil {
+				continue
+			}
+			if hdb.announcements == nil {
+				hdb.announcements = make(map[string]hostAnnouncement)
+			}
+			key := pubKey.String()
+			if old, exists := hdb.announcements[key]; exists && old.BlockHeight > height {
+				continue
+			}
+			hdb.announcements[key] = hostAnnouncement{
+				Announcement: append([]byte(nil), arb...),
+				BlockHeight:  height,
+			}
+		}
+	}
+	for len(hdb.announcements) > announcementCacheSize {
+		var oldestKey string
+		var oldest types.BlockHeight
+		for key, ann := range hdb.announcements {
+			if oldestKey == "" || ann.BlockHeight < oldest {
+				oldestKey, oldest = key, ann.BlockHeight
+			}
+		}
+		delete(hdb.announcements, oldestKey)
+	}
+}
+
+// uncacheAnnouncements removes the host announcements of a reverted block
+// from the cache of recent announcements.
+func (hdb *HostDB) uncacheAnnouncements(b types.Block) {
+	for _, t := range b.Transactions {
+		for _, arb := range t.ArbitraryData {
+			if len(arb) > maxSharedAnnouncementLen {
+				continue
+			}
+			_, pubKey, err := modules.DecodeMultiAddressAnnouncement(arb)
+			if err != nil {
+				continue
+			}
+			key := pubKey.String()
+			if ann, exists := hdb.announcements[key]; exists && bytes.Equal(ann.Announcement, arb) {
+				delete(hdb.announcements, key)
+			}
+		}
+	}
+}
+
+// recentAnnouncements returns the cached announcements sorted from the most
+// recent to the oldest one.
+func (hdb *HostDB) recentAnnouncements() []hostAnnouncement {
+	anns := make([]hostAnnouncement, 0, len(hdb.announcements))
+	for _, ann := range hdb.announcements {
+		anns = append(anns, ann)
+	}
+	sort.Slice(anns, func(i, j int) bool {
+		return anns[i].BlockHeight > anns[j].BlockHeight
+	})
+	return anns
+}
+
+// managedShareAnnouncements is the receiving end of the
+// ShareHostAnnouncements RPC. It sends the cached announcements to the peer.
+func (hdb *HostDB) managedShareAnnouncements(conn modules.PeerConn) error {
+	if err := hdb.tg.Add(); err != nil {
+		return err
+	}
+	defer hdb.tg.Done()
+	conn.SetDeadline(time.Now().Add(announcementRPCTimeout))
+
+	hdb.mu.RLock()
+	anns := hdb.recentAnnouncements()
+	hdb.mu.RUnlock()
+	return encoding.WriteObject(conn, anns)
+}
+
+// managedRequestAnnouncements is the calling end of the ShareHostAnnouncements
+// RPC. Until the hostdb is synced, it adds the hosts of the announcements it
+// receives from peers to the host tree, which allows a new renter to start
+// scanning hosts before it has processed the whole blockchain. Hosts which are
+// already known are skipped, their addresses are only updated by
+// announcements found in the blockchain.
+func (hdb *HostDB) managedRequestAnnouncements(conn modules.PeerConn) error {
+	if err := hdb.tg.Add(); err != nil {
+		return err
+	}
+	defer hdb.tg.Done()
+
+	// Once synced the blockchain contains all announcements.
+	hdb.mu.RLock()
+	synced := hdb.synced
+	hdb.mu.RUnlock()
+	if synced {
+		return nil
+	}
+
+	conn.SetDeadline(time.Now().Add(announcementRPCTimeout))
+	var anns []hostAnnouncement
+	err := encoding.ReadObject(conn, &anns, uint64(announcementCacheSize)*(maxSharedAnnouncementLen+16)+8)
+	if err != nil {
+		return errors.AddContext(err, "failed to read host announcements")
+	}
+	if len(anns) > announcementCacheSize {
+		err = errors.New("peer sent too many host announcements")
+		hdb.gateway.ReportMisbehavior(conn.RPCAddr(), err)
+		return err
+	}
+
+	// Decode and verify the announcements before touching the host tree.
+	// Invalid announcements are skipped and reported.
+	hosts := make([]modules.HostDBEntry, 0, len(anns))
+	var invalid int
+	for _, ann := range anns {
+		addrs, pubKey, err := modules.DecodeMultiAddressAnnouncement(ann.Announcement)
+		if err != nil || len(ann.Announcement) > maxSharedAnnouncementLen {
+			invalid++
+			continue
+		}
+		var host modules.HostDBEntry
+		host.NetAddress = addrs[0]
+		host.AdditionalNetAddresses = addrs[1:]
+		host.PublicKey = pubKey
+		hosts = append(hosts, host)
+	}
+	if invalid > 0 {
+		hdb.staticLog.Printf("WARN: %v sent %v invalid host announcements", conn.RPCAddr(), invalid)
+		hdb.gateway.ReportMisbehavior(conn.RPCAddr(), errors.New("peer sent invalid host announcements"))
+	}
+
+	var added int
+	for _, host := range hosts {
+		if hdb.managedInsertSharedHost(host) {
+			added++
+		}
+	}
+	if added > 0 {
+		hdb.staticLog.Printf("Added %v hosts from the announcements shared by %v", added, conn.RPCAddr())
+	}
+	return nil
+}
+
+// managedInsertSharedHost adds a host learned from the announcements shared by
+// a peer to the host tree and queues a scan for it. The block height reported
+// by the peer can't be verified, so the host's FirstSeen is the current height
+// of the hostdb and no address history is recorded for it until the
+// announcement is found in the blockchain. It returns false if the host was
+// already known.
+func (hdb *HostDB) managedInsertSharedHost(host modules.HostDBEntry) bool {
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+	if hdb.synced {
+		return false
+	}
+	if _, exists := hdb.staticHostTree.Select(host.PublicKey); exists {
+		return false
+	}
+	if !hdb.sanitizeAnnouncedHost(&host) {
+		return false
+	}
+	host.FirstSeen = hdb.blockHeight
+	if err := hdb.insert(host); err != nil {
+		hdb.staticLog.Println("ERROR: unable to insert shared host entry into host tree:", err)
+		return false
+	}
+	if hdb.sharedHosts == nil {
+		hdb.sharedHosts = make(map[string]struct{})
+	}
+	hdb.sharedHosts[host.PublicKey.String()] = struct{}{}
+	hdb.queueScan(host)
+	return true
+}
+
+// removeSharedHosts removes the hosts which were added from the announcements
+// shared by peers but whose announcements weren't found in the blockchain.
+// Once the hostdb is synced, such a host was never announced.
+func (hdb *HostDB) removeSharedHosts() {
+	for key := range hdb.sharedHosts {
+		var pk types.SiaPublicKey
+		if err := pk.LoadString(key); err != nil {
+			hdb.staticLog.Println("ERROR: unable to load public key of shared host:", err)
+			continue
+		}
+		if err := hdb.remove(pk); err != nil {
+			hdb.staticLog.Println("ERROR: unable to remove unannounced shared host from host tree:", err)
+		}
+	}
+	hdb.sharedHosts = make(map[string]struct{})
+}
+
+// threadedRequestAnnouncements requests the recent host announcements from the
+// peers the gateway is already connected to. Peers which connect later are
+// asked by the gateway's connect call.
+func (hdb *HostDB) threadedRequestAnnouncements() {
+	defer modules.RecoverPanic("hostdb")
+	if err := hdb.tg.Add(); err != nil {
+		return
+	}
+	defer hdb.tg.Done()
+
+	for _, peer := range hdb.gateway.Peers() {
+		hdb.mu.RLock()
+		synced := hdb.synced
+		hdb.mu.RUnlock()
+		if synced {
+			return
+		}
+		err := hdb.gateway.RPC(peer.NetAddress, shareAnnouncementsRPC, hdb.managedRequestAnnouncements)
+		if err != nil {
+			hdb.staticLog.Debugf("Failed to request host announcements from %v: %v", peer.NetAddress, err)
+		}
+	}
+}
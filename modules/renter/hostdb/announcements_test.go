@@ -0,0 +1,212 @@
+package hostdb
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// testPeerConn is a modules.PeerConn backed by a net.Conn.
+type testPeerConn struct {
+	net.Conn
+}
+
+// RPCAddr implements modules.PeerConn.
+func (pc testPeerConn) RPCAddr() modules.NetAddress {
+	return "peer:1234"
+}
+
+// announcementBlock returns a block which contains the provided announcements.
+func announcementBlock(anns ...[]byte) types.Block {
+	return types.Block{
+		Transactions: []types.Transaction{{ArbitraryData: anns}},
+	}
+}
+
+// TestCacheAnnouncements is a unit test for caching the most recent host
+// announcements.
+func TestCacheAnnouncements(t *testing.T) {
+	hdb := bareHostDB()
+
+	// Announce a host twice. Only the most recent announcement is kept.
+	sk, pk := crypto.GenerateKeyPair()
+	spk := types.Ed25519PublicKey(pk)
+	ann1, err := modules.CreateAnnouncement("foo.com:1234", spk, sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ann2, err := modules.CreateAnnouncement("bar.com:1234", spk, sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hdb.cacheAnnouncements(announcementBlock(ann1, []byte("not an announcement")), 1)
+	hdb.cacheAnnouncements(announcementBlock(ann2), 2)
+	if len(hdb.announcements) != 1 || string(hdb.announcements[spk.String()].Announcement) != string(ann2) {
+		t.Fatal("expected the most recent announcement to be cached", hdb.announcements)
+	}
+
+	// Announce more hosts than fit in the cache. The oldest ones are dropped.
+	for i := 0; i < announcementCacheSize; i++ {
+		ann, err := makeSignedAnnouncement(modules.NetAddress(fmt.Sprintf("host%v.com:1234", i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		hdb.cacheAnnouncements(announcementBlock(ann), types.BlockHeight(3+i))
+	}
+	if len(hdb.announcements) != announcementCacheSize {
+		t.Fatal("wrong number of cached announcements", len(hdb.announcements))
+	}
+	if _, exists := hdb.announcements[spk.String()]; exists {
+		t.Fatal("oldest announcement should have been dropped")
+	}
+	recent := hdb.recentAnnouncements()
+	if recent[0].BlockHeight != types.BlockHeight(2+announcementCacheSize) || recent[len(recent)-1].BlockHeight != 3 {
+		t.Fatal("announcements aren't sorted by recency")
+	}
+
+	// Revert the block of the most recent announcement. It is removed from
+	// the cache.
+	hdb.uncacheAnnouncements(announcementBlock(recent[0].Announcement))
+	if len(hdb.announcements) != announcementCacheSize-1 {
+		t.Fatal("reverted announcement wasn't removed", len(hdb.announcements))
+	}
+	if recent := hdb.recentAnnouncements(); recent[0].BlockHeight != types.BlockHeight(1+announcementCacheSize) {
+		t.Fatal("wrong most recent announcement", recent[0].BlockHeight)
+	}
+}
+
+// TestShareAnnouncements tests that an unsynced hostdb adds the hosts of the
+// announcements shared by a peer.
+func TestShareAnnouncements(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	hdbt, err := newHDBTesterDeps(t.Name(), &disableScanLoopDeps{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := hdbt.hdb.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Prepare a peer which knows about an announcement.
+	peer := bareHostDB()
+	sk, pk := crypto.GenerateKeyPair()
+	spk := types.Ed25519PublicKey(pk)
+	ann, err := modules.CreateAnnouncement("foo.com:1234", spk, sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	peer.cacheAnnouncements(announcementBlock(ann), 1)
+
+	// Add an invalid announcement, which should be skipped.
+	peer.announcements["invalid"] = hostAnnouncement{
+		Announcement: []byte("not an announcement"),
+		BlockHeight:  2,
+	}
+
+	// exchange performs the RPC between the peer and the hostdb.
+	exchange := func() error {
+		c1, c2 := net.Pipe()
+		defer c1.Close()
+		defer c2.Close()
+		errChan := make(chan error, 1)
+		go func() {
+			errChan <- peer.managedShareAnnouncements(testPeerConn{c1})
+		}()
+		if err := hdbt.hdb.managedRequestAnnouncements(testPeerConn{c2}); err != nil {
+			return err
+		}
+		c2.Close()
+		<-errChan
+		return nil
+	}
+
+	// A synced hostdb ignores the announcements.
+	if err := exchange(); err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := hdbt.hdb.staticHostTree.Select(spk); exists {
+		t.Fatal("synced hostdb shouldn't add shared hosts")
+	}
+
+	// An unsynced hostdb adds the host.
+	hdbt.hdb.mu.Lock()
+	hdbt.hdb.synced = false
+	hdbt.hdb.blockHeight = 5
+	hdbt.hdb.mu.Unlock()
+	if err := exchange(); err != nil {
+		t.Fatal(err)
+	}
+	host, exists := hdbt.hdb.staticHostTree.Select(spk)
+	if !exists || host.NetAddress != "foo.com:1234" {
+		t.Fatal("shared host wasn't added", host)
+	}
+	if host.FirstSeen != 5 || len(host.AddressHistory) != 0 {
+		t.Fatal("shared host should be first seen at the hostdb's height without an address history", host.FirstSeen, host.AddressHistory)
+	}
+
+	// Find the announcement in the blockchain. The host is seen for the first
+	// time at that height.
+	hdbt.hdb.mu.Lock()
+	hdbt.hdb.blockHeight = 10
+	hdbt.hdb.insertBlockchainHost(host)
+	hdbt.hdb.mu.Unlock()
+	host, _ = hdbt.hdb.staticHostTree.Select(spk)
+	if host.FirstSeen != 10 || len(host.AddressHistory) != 1 {
+		t.Fatal("host wasn't updated when its announcement was found", host.FirstSeen, host.AddressHistory)
+	}
+}
+
+// TestRemoveSharedHosts tests that the shared hosts whose announcements
+// weren't found in the blockchain are removed once the hostdb is synced.
+func TestRemoveSharedHosts(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	hdbt, err := newHDBTesterDeps(t.Name(), &disableScanLoopDeps{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := hdbt.hdb.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Add a shared host which is never announced in the blockchain.
+	_, pk := crypto.GenerateKeyPair()
+	spk := types.Ed25519PublicKey(pk)
+	hdbt.hdb.mu.Lock()
+	hdbt.hdb.synced = false
+	hdbt.hdb.mu.Unlock()
+	var host modules.HostDBEntry
+	host.NetAddress = "foo.com:1234"
+	host.PublicKey = spk
+	if !hdbt.hdb.managedInsertSharedHost(host) {
+		t.Fatal("shared host wasn't added")
+	}
+	if _, exists := hdbt.hdb.staticHostTree.Select(spk); !exists {
+		t.Fatal("shared host isn't in the host tree")
+	}
+
+	// Once synced, the host is removed.
+	hdbt.hdb.ProcessConsensusChange(modules.ConsensusChange{Synced: true})
+	if _, exists := hdbt.hdb.staticHostTree.Select(spk); exists {
+		t.Fatal("unannounced shared host wasn't removed")
+	}
+	hdbt.hdb.mu.Lock()
+	numShared := len(hdbt.hdb.sharedHosts)
+	hdbt.hdb.mu.Unlock()
+	if numShared != 0 {
+		t.Fatal("shared hosts weren't cleared", numShared)
+	}
+}
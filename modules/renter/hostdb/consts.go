@@ -4,9 +4,14 @@ import (
 	"time"
 
 	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/types"
 )
 
 const (
+	// benchmarkDecay defines how much weight the previous benchmark
+	// statistics of a host have when a new benchmark sample is added.
+	benchmarkDecay = 0.8
+
 	// historicInteractionDecay defines the decay of the HistoricSuccessfulInteractions
 	// and HistoricFailedInteractions after every block for a host entry.
 	historicInteractionDecay = 0.9995
@@ -15,6 +20,10 @@ const (
 	// interactions required before decay is applied.
 	historicInteractionDecayLimit = 500
 
+	// announcementRPCTimeout is the amount of time a peer has to complete the
+	// ShareHostAnnouncements RPC.
+	announcementRPCTimeout = time.Minute
+
 	// hostRequestTimeout indicates how long a host has to respond to a dial.
 	hostRequestTimeout = 2 * time.Minute
 
@@ -27,6 +36,10 @@ const (
 	maxHostDowntime       = maxHostDownTimeInDays * 24 * time.Hour
 	maxHostDownTimeInDays = 20
 
+	// maxSharedAnnouncementLen is the maximum length of an encoded host
+	// announcement shared with peers.
+	maxSharedAnnouncementLen = 4096
+
 	// maxSettingsLen indicates how long in bytes the host settings field is
 	// allowed to be before being ignored as a DoS attempt.
 	maxSettingsLen = 10e3
@@ -50,6 +63,10 @@ const (
 	// than half the total weight at this limit.
 	recentInteractionWeightLimit = 0.01
 
+	// shareAnnouncementsRPC is the name of the gateway RPC used to exchange
+	// recent host announcements with peers.
+	shareAnnouncementsRPC = "ShareHostAnnouncements"
+
 	// saveFrequency defines how frequently the hostdb will save to disk. Hostdb
 	// will also save immediately prior to shutdown.
 	saveFrequency = 2 * time.Minute
@@ -96,6 +113,14 @@ const (
 )
 
 var (
+	// announcementCacheSize is the number of recent host announcements the
+	// hostdb keeps to share with its peers.
+	announcementCacheSize = build.Select(build.Var{
+		Standard: 1000,
+		Dev:      100,
+		Testing:  10,
+	}).(int)
+
 	// hostCheckupQuantity specifies the number of hosts that get scanned every
 	// time there is a regular scanning operation.
 	hostCheckupQuantity = build.Select(build.Var{
@@ -103,25 +128,51 @@ var (
 		Dev:      int(6),
 		Testing:  int(5),
 	}).(int)
-
-	// scanningThreads is the number of threads that will be probing hosts for
-	// their settings and checking for reliability.
-	maxScanningThreads = build.Select(build.Var{
-		Standard: int(80),
-		Dev:      int(4),
-		Testing:  int(3),
-	}).(int)
 )
 
 var (
-	// maxScanSleep is the maximum amount of time that the hostdb will sleep
-	// between performing scans of the hosts.
-	maxScanSleep = build.Select(build.Var{
-		Standard: time.Hour * 8,
-		Dev:      time.Minute * 10,
-		Testing:  time.Second * 5,
+	// priceIndexInterval is the number of blocks between two snapshots of the
+	// host network's prices.
+	priceIndexInterval = build.Select(build.Var{
+		Standard: types.BlocksPerDay,
+		Dev:      types.BlockHeight(20),
+		Testing:  types.BlockHeight(5),
+	}).(types.BlockHeight)
+
+	// priceIndexMaxSnapshots is the maximum number of price snapshots the
+	// hostdb keeps. Older snapshots are dropped.
+	priceIndexMaxSnapshots = build.Select(build.Var{
+		Standard: 2 * 365,
+		Dev:      100,
+		Testing:  10,
+	}).(int)
+
+	// allowanceSimulationInterval is the time between two samples of an
+	// allowance simulation.
+	allowanceSimulationInterval = build.Select(build.Var{
+		Standard: 24 * time.Hour,
+		Dev:      time.Hour,
+		Testing:  5 * time.Minute,
+	}).(time.Duration)
+
+	// scanRecordsMaxAge is the maximum age of the scan records the hostdb
+	// keeps for a host. Older records are dropped.
+	scanRecordsMaxAge = build.Select(build.Var{
+		Standard: 4 * 7 * 24 * time.Hour,
+		Dev:      24 * time.Hour,
+		Testing:  time.Hour,
 	}).(time.Duration)
 
+	// scanRecordsMaxRecords is the maximum number of scan records the hostdb
+	// keeps for a host. Older records are dropped.
+	scanRecordsMaxRecords = build.Select(build.Var{
+		Standard: 1000,
+		Dev:      100,
+		Testing:  10,
+	}).(int)
+)
+
+var (
 	// scanCheckInterval is the interval used when waiting for the scanList to
 	// empty itself and for waiting on the consensus set to be synced.
 	scanCheckInterval = build.Select(build.Var{
@@ -129,12 +180,4 @@ var (
 		Dev:      time.Second,
 		Testing:  100 * time.Millisecond,
 	}).(time.Duration)
-
-	// minScanSleep is the minimum amount of time that the hostdb will sleep
-	// between performing scans of the hosts.
-	minScanSleep = build.Select(build.Var{
-		Standard: time.Hour + time.Minute*20,
-		Dev:      time.Minute * 3,
-		Testing:  time.Second * 1,
-	}).(time.Duration)
 )
@@ -0,0 +1,215 @@
+package hostdb
+
+import (
+	"sort"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// dumpVersion is the version of the hostdb dumps created by Export.
+const dumpVersion = "1.0"
+
+var (
+	// errInvalidDumpVersion is returned when importing a dump with an unknown
+	// version.
+	errInvalidDumpVersion = errors.New("unknown hostdb dump version")
+
+	// errInvalidDumpFilterMode is returned when importing a dump without a
+	// valid filter mode.
+	errInvalidDumpFilterMode = errors.New("hostdb dump contains an invalid filter mode")
+)
+
+// lastScanTime returns the time of the most recent successful scan of a host
+// or the zero time if the host was never scanned successfully.
+func lastScanTime(scans modules.HostDBScans) time.Time {
+	for i := len(scans) - 1; i >= 0; i-- {
+		if scans[i].Success {
+			return scans[i].Timestamp
+		}
+	}
+	return time.Time{}
+}
+
+// mergeScans merges two scan histories sorted by time. Like a scan of the
+// hostdb, a scan is only added to the merged history if more than
+// scanTimeElapsedRequirement passed since the previous scan.
+func mergeScans(a, b modules.HostDBScans) modules.HostDBScans {
+	all := append(append(modules.HostDBScans{}, a...), b...)
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].Timestamp.Before(all[j].Timestamp)
+	})
+	merged := make(modules.HostDBScans, 0, len(all))
+	for _, scan := range all {
+		if n := len(merged); n > 0 && !scan.Timestamp.After(merged[n-1].Timestamp.Add(scanTimeElapsedRequirement)) {
+			continue
+		}
+		merged = append(merged, scan)
+	}
+	return merged
+}
+
+// mergeScanRecords merges two lists of scan records sorted by time and prunes
+// the result. Records with the same timestamp are only added once.
+func mergeScanRecords(a, b []modules.HostDBScanRecord, now time.Time) []modules.HostDBScanRecord {
+	all := append(append([]modules.HostDBScanRecord{}, a...), b...)
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].Timestamp.Before(all[j].Timestamp)
+	})
+	merged := make([]modules.HostDBScanRecord, 0, len(all))
+	for _, record := range all {
+		if n := len(merged); n > 0 && record.Timestamp.Equal(merged[n-1].Timestamp) {
+			continue
+		}
+		merged = append(merged, record)
+	}
+	return pruneScanRecords(merged, now)
+}
+
+// mergeHostEntries merges the entry of a host exported by another renter into
+// the local entry. The scan histories are combined and the settings are taken
+// from the entry with the most recent successful scan. The interactions and
+// benchmarks stay local since they describe the renter's own experience with
+// the host.
+func mergeHostEntries(local, remote modules.HostDBEntry) modules.HostDBEntry {
+	merged := local
+	if remote.FirstSeen < merged.FirstSeen {
+		merged.FirstSeen = remote.FirstSeen
+	}
+	if lastScanTime(remote.ScanHistory).After(lastScanTime(local.ScanHistory)) {
+		merged.HostExternalSettings = remote.HostExternalSettings
+	}
+
+	// If the local history was never compressed, the remote's compressed
+	// history is adopted along with all of its scans. Otherwise only the
+	// remote scans after the start of the local history are merged to avoid
+	// counting the same time twice.
+	remoteScans := remote.ScanHistory
+	if local.HistoricUptime == 0 && local.HistoricDowntime == 0 {
+		merged.HistoricUptime = remote.HistoricUptime
+		merged.HistoricDowntime = remote.HistoricDowntime
+	} else if len(local.ScanHistory) > 0 {
+		start := local.ScanHistory[0].Timestamp
+		i := sort.Search(len(remoteScans), func(i int) bool {
+			return !remoteScans[i].Timestamp.Before(start)
+		})
+		remoteScans = remoteScans[i:]
+	}
+	merged.ScanHistory = mergeScans(local.ScanHistory, remoteScans)
+	return merged
+}
+
+// Export returns a dump of the hostdb's hosts including their scan records
+// and scores, the filter and the price index.
+func (hdb *HostDB) Export() (modules.HostDBDump, error) {
+	if err := hdb.tg.Add(); err != nil {
+		return modules.HostDBDump{}, errors.AddContext(err, "error adding hostdb threadgroup:")
+	}
+	defer hdb.tg.Done()
+	hdb.mu.RLock()
+	defer hdb.mu.RUnlock()
+
+	dump := modules.HostDBDump{
+		Version:       dumpVersion,
+		BlockHeight:   hdb.blockHeight,
+		Timestamp:     time.Now(),
+		FilterMode:    hdb.filterMode,
+		FilteredHosts: make([]types.SiaPublicKey, 0, len(hdb.filteredHosts)),
+		Hosts:         []modules.HostDBDumpHost{},
+		PriceIndex:    append([]modules.HostDBPriceSnapshot{}, hdb.priceIndex...),
+	}
+	// A hostdb which never had its filter mode set has no filter.
+	if dump.FilterMode == modules.HostDBFilterError {
+		dump.FilterMode = modules.HostDBDisableFilter
+	}
+	for _, pk := range hdb.filteredHosts {
+		dump.FilteredHosts = append(dump.FilteredHosts, pk)
+	}
+	sort.Slice(dump.FilteredHosts, func(i, j int) bool {
+		return dump.FilteredHosts[i].String() < dump.FilteredHosts[j].String()
+	})
+	for _, entry := range hdb.staticHostTree.All() {
+		dump.Hosts = append(dump.Hosts, modules.HostDBDumpHost{
+			Entry:       entry,
+			ScanRecords: append([]modules.HostDBScanRecord{}, hdb.scanRecords[entry.PublicKey.String()]...),
+			Score:       hdb.weightFunc(entry).Score(),
+		})
+	}
+	return dump, nil
+}
+
+// Import restores the hostdb from a dump. The hosts, scan records, filter and
+// price index of the hostdb are replaced by the ones of the dump. If merge is
+// set, the hosts of the dump are added to the hostdb instead and the scan
+// histories of known hosts are merged, while the filter and the price index
+// stay untouched.
+func (hdb *HostDB) Import(dump modules.HostDBDump, merge bool) error {
+	if err := hdb.tg.Add(); err != nil {
+		return errors.AddContext(err, "error adding hostdb threadgroup:")
+	}
+	defer hdb.tg.Done()
+	if dump.Version != dumpVersion {
+		return errInvalidDumpVersion
+	}
+	if !merge && dump.FilterMode == modules.HostDBFilterError {
+		return errInvalidDumpFilterMode
+	}
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+
+	// When restoring, remove the hosts which are not part of the dump.
+	dumpHosts := make(map[string]struct{}, len(dump.Hosts))
+	for _, host := range dump.Hosts {
+		dumpHosts[host.Entry.PublicKey.String()] = struct{}{}
+	}
+	var err error
+	if !merge {
+		for _, entry := range hdb.staticHostTree.All() {
+			if _, exists := dumpHosts[entry.PublicKey.String()]; !exists {
+				err = errors.Compose(err, hdb.remove(entry.PublicKey))
+				delete(hdb.scanRecords, entry.PublicKey.String())
+			}
+		}
+	}
+
+	// Add the hosts of the dump.
+	if hdb.scanRecords == nil {
+		hdb.scanRecords = make(map[string][]modules.HostDBScanRecord)
+	}
+	now := time.Now()
+	for _, host := range dump.Hosts {
+		entry := host.Entry
+		key := entry.PublicKey.String()
+		if hdb.blockHeight < entry.FirstSeen {
+			entry.FirstSeen = hdb.blockHeight
+		}
+		local, exists := hdb.staticHostTree.Select(entry.PublicKey)
+		if exists && merge {
+			entry = mergeHostEntries(local, entry)
+			hdb.scanRecords[key] = mergeScanRecords(hdb.scanRecords[key], host.ScanRecords, now)
+		} else {
+			hdb.scanRecords[key] = pruneScanRecords(append([]modules.HostDBScanRecord{}, host.ScanRecords...), now)
+		}
+		if exists {
+			err = errors.Compose(err, hdb.modify(entry))
+		} else {
+			err = errors.Compose(err, hdb.insert(entry))
+		}
+
+		// Make sure that all hosts have gone through the initial scanning.
+		if len(entry.ScanHistory) < 2 {
+			hdb.queueScan(entry)
+		}
+	}
+
+	// When restoring, apply the filter and price index of the dump.
+	if !merge {
+		err = errors.Compose(err, hdb.setFilterMode(dump.FilterMode, dump.FilteredHosts))
+		hdb.priceIndex = append([]modules.HostDBPriceSnapshot{}, dump.PriceIndex...)
+	}
+	hdb.staticLog.Printf("Imported %v hosts from a hostdb dump of height %v, merge: %v", len(dump.Hosts), dump.BlockHeight, merge)
+	return errors.Compose(err, hdb.saveSync())
+}
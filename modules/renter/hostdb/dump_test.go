@@ -0,0 +1,187 @@
+package hostdb
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestMergeScans is a unit test for mergeScans.
+func TestMergeScans(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	at := func(i int) time.Time {
+		return now.Add(time.Duration(i) * 2 * scanTimeElapsedRequirement)
+	}
+	a := modules.HostDBScans{{Timestamp: at(0), Success: true}, {Timestamp: at(2), Success: true}}
+	b := modules.HostDBScans{
+		{Timestamp: at(1), Success: false},
+		// Too close to the previous scan.
+		{Timestamp: at(2).Add(scanTimeElapsedRequirement / 2), Success: false},
+		{Timestamp: at(3), Success: false},
+	}
+	merged := mergeScans(a, b)
+	expected := modules.HostDBScans{a[0], b[0], a[1], b[2]}
+	if len(merged) != len(expected) {
+		t.Fatal("wrong number of scans", merged)
+	}
+	for i := range expected {
+		if !merged[i].Timestamp.Equal(expected[i].Timestamp) || merged[i].Success != expected[i].Success {
+			t.Fatalf("%v: expected %v but got %v", i, expected[i], merged[i])
+		}
+	}
+}
+
+// TestMergeHostEntries is a unit test for mergeHostEntries.
+func TestMergeHostEntries(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	at := func(i int) time.Time {
+		return now.Add(time.Duration(i) * 2 * scanTimeElapsedRequirement)
+	}
+	local := makeHostDBEntry()
+	local.FirstSeen = 10
+	local.RecentSuccessfulInteractions = 5
+	local.ScanHistory = modules.HostDBScans{{Timestamp: at(2), Success: true}, {Timestamp: at(4), Success: true}}
+	remote := local
+	remote.FirstSeen = 5
+	remote.RecentSuccessfulInteractions = 100
+	remote.HistoricUptime = time.Hour
+	remote.StoragePrice = local.StoragePrice.Add64(1)
+	remote.ScanHistory = modules.HostDBScans{{Timestamp: at(1), Success: true}, {Timestamp: at(5), Success: true}}
+
+	// The local history was never compressed, so all remote scans and the
+	// remote's historic uptime are adopted. The remote settings are more
+	// recent.
+	merged := mergeHostEntries(local, remote)
+	if merged.FirstSeen != 5 || merged.HistoricUptime != time.Hour || len(merged.ScanHistory) != 4 {
+		t.Fatal("wrong merge", merged.FirstSeen, merged.HistoricUptime, merged.ScanHistory)
+	}
+	if !merged.StoragePrice.Equals(remote.StoragePrice) {
+		t.Fatal("settings of the more recent scan should be used")
+	}
+	if merged.RecentSuccessfulInteractions != 5 {
+		t.Fatal("interactions should stay local")
+	}
+
+	// If the local history was compressed, remote scans before the local
+	// history are dropped.
+	local.HistoricDowntime = time.Minute
+	merged = mergeHostEntries(local, remote)
+	if merged.HistoricUptime != 0 || merged.HistoricDowntime != time.Minute || len(merged.ScanHistory) != 3 {
+		t.Fatal("wrong merge", merged.HistoricUptime, merged.HistoricDowntime, merged.ScanHistory)
+	}
+}
+
+// TestExportImport checks that a hostdb can be restored from a dump of
+// another hostdb and that dumps can be merged.
+func TestExportImport(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	newHDB := func(name string) *HostDB {
+		hdbt, err := newHDBTesterDeps(t.Name()+name, &disableScanLoopDeps{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return hdbt.hdb
+	}
+	addHost := func(hdb *HostDB) modules.HostDBEntry {
+		host := makeHostDBEntry()
+		host.ScanHistory = modules.HostDBScans{
+			{Timestamp: time.Now().Add(-2 * scanTimeElapsedRequirement), Success: true},
+			{Timestamp: time.Now(), Success: true},
+		}
+		hdb.mu.Lock()
+		defer hdb.mu.Unlock()
+		if err := hdb.insert(host); err != nil {
+			t.Fatal(err)
+		}
+		hdb.addScanRecord(host.PublicKey, time.Second, nil)
+		return host
+	}
+	src, dst := newHDB("src"), newHDB("dst")
+	host1, host2 := addHost(src), addHost(src)
+	if err := src.SetFilterMode(modules.HostDBActivateBlacklist, []types.SiaPublicKey{host1.PublicKey}); err != nil {
+		t.Fatal(err)
+	}
+	dstHost := addHost(dst)
+
+	// A hostdb without a filter is exported as such.
+	dump, err := dst.Export()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dump.FilterMode != modules.HostDBDisableFilter {
+		t.Fatal("wrong filter mode", dump.FilterMode)
+	}
+
+	// Export the source hostdb.
+	dump, err = src.Export()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dump.Version != dumpVersion || len(dump.Hosts) != 2 || len(dump.FilteredHosts) != 1 {
+		t.Fatal("wrong dump", dump)
+	}
+	for _, host := range dump.Hosts {
+		if len(host.ScanRecords) != 1 || host.Score.IsZero() {
+			t.Fatal("wrong host", host)
+		}
+	}
+
+	// Merge the dump into the destination hostdb. Its own host and filter
+	// are kept.
+	if err := dst.Import(dump, true); err != nil {
+		t.Fatal(err)
+	}
+	hosts, err := dst.AllHosts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hosts) != 3 {
+		t.Fatal("expected 3 hosts after merging", len(hosts))
+	}
+	if fm, _, err := dst.Filter(); err != nil || fm == modules.HostDBActivateBlacklist {
+		t.Fatal("filter shouldn't be merged", fm, err)
+	}
+
+	// Restore the destination hostdb from the dump.
+	if err := dst.Import(dump, false); err != nil {
+		t.Fatal(err)
+	}
+	for _, host := range []modules.HostDBEntry{host1, host2} {
+		if _, exists, err := dst.Host(host.PublicKey); err != nil || !exists {
+			t.Fatal("host wasn't restored", err)
+		}
+		records, err := dst.ScanHistory(host.PublicKey)
+		if err != nil || len(records) != 1 {
+			t.Fatal("scan records weren't restored", records, err)
+		}
+	}
+	if _, exists, _ := dst.Host(dstHost.PublicKey); exists {
+		t.Fatal("host which isn't part of the dump should be removed")
+	}
+	fm, filtered, err := dst.Filter()
+	if err != nil || fm != modules.HostDBActivateBlacklist || len(filtered) != 1 {
+		t.Fatal("filter wasn't restored", fm, filtered, err)
+	}
+
+	// Invalid dumps are rejected.
+	dump.Version = "0.1"
+	if err := dst.Import(dump, false); !errors.Contains(err, errInvalidDumpVersion) {
+		t.Fatal("expected invalid version", err)
+	}
+	dump.Version = dumpVersion
+	dump.FilterMode = modules.HostDBFilterError
+	if err := dst.Import(dump, false); !errors.Contains(err, errInvalidDumpFilterMode) {
+		t.Fatal("expected invalid filter mode", err)
+	}
+}
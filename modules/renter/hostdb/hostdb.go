@@ -103,6 +103,25 @@ type HostDB struct {
 	filteredHosts map[string]types.SiaPublicKey
 	filterMode    modules.FilterMode
 
+	// priceIndex contains periodic snapshots of the prices of the host
+	// network sorted by block height.
+	priceIndex []modules.HostDBPriceSnapshot
+
+	// scanRecords contains the detailed scan history of every host. The
+	// mapkey is a serialized SiaPublicKey.
+	scanRecords map[string][]modules.HostDBScanRecord
+
+	// announcements contains the most recent host announcement of the hosts
+	// which announced themselves recently. It is shared with peers to help
+	// them bootstrap their hostdb. The mapkey is a serialized SiaPublicKey.
+	announcements map[string]hostAnnouncement
+
+	// sharedHosts contains the hosts which were added from the announcements
+	// shared by peers and whose announcement wasn't found in the blockchain
+	// yet. They are removed once the hostdb is synced. The mapkey is a
+	// serialized SiaPublicKey.
+	sharedHosts map[string]struct{}
+
 	blockHeight types.BlockHeight
 	lastChange  modules.ConsensusChangeID
 }
@@ -346,6 +365,22 @@ func hostdbBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 	if err != nil {
 		return nil, err
 	}
+
+	// Register RPCs to exchange recent host announcements with peers. Another
+	// hostdb might already have registered them on a shared gateway, in which
+	// case that hostdb serves the RPCs.
+	if hdb.gateway.TryRegisterRPC(shareAnnouncementsRPC, hdb.managedShareAnnouncements) {
+		hdb.gateway.RegisterConnectCall(shareAnnouncementsRPC, hdb.managedRequestAnnouncements)
+		err = hdb.tg.OnStop(func() error {
+			hdb.gateway.UnregisterRPC(shareAnnouncementsRPC)
+			hdb.gateway.UnregisterConnectCall(shareAnnouncementsRPC)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	go hdb.threadedRequestAnnouncements()
 	return hdb, nil
 }
 
@@ -562,7 +597,11 @@ func (hdb *HostDB) SetFilterMode(fm modules.FilterMode, hosts []types.SiaPublicK
 	defer hdb.tg.Done()
 	hdb.mu.Lock()
 	defer hdb.mu.Unlock()
+	return hdb.setFilterMode(fm, hosts)
+}
 
+// setFilterMode sets the hostdb filter mode and rebuilds the filtered tree.
+func (hdb *HostDB) setFilterMode(fm modules.FilterMode, hosts []types.SiaPublicKey) error {
 	// Check for error
 	if fm == modules.HostDBFilterError {
 		return errors.New("Cannot set hostdb filter mode, provided filter mode is an error")
@@ -2,6 +2,7 @@ package hostdb
 
 import (
 	"math"
+	"time"
 
 	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/modules"
@@ -73,6 +74,52 @@ func updateHostHistoricInteractions(host *modules.HostDBEntry, bh types.BlockHei
 	host.LastHistoricUpdate = bh
 }
 
+// benchmarkMovingAvg adds a new benchmark measurement to the moving average of
+// a benchmark statistic. Missing measurements are ignored.
+func benchmarkMovingAvg(avg, value float64) float64 {
+	if value == 0 {
+		return avg
+	}
+	if avg == 0 {
+		return value
+	}
+	return avg*benchmarkDecay + value*(1-benchmarkDecay)
+}
+
+// updateHostBenchmark adds a benchmark sample to the rolling benchmark
+// statistics of a host.
+func updateHostBenchmark(host *modules.HostDBEntry, sample modules.HostBenchmarkSample, now time.Time) {
+	b := &host.Benchmark
+	b.RTT = time.Duration(benchmarkMovingAvg(float64(b.RTT), float64(sample.RTT)))
+	b.TTFB = time.Duration(benchmarkMovingAvg(float64(b.TTFB), float64(sample.TTFB)))
+	b.Throughput = uint64(benchmarkMovingAvg(float64(b.Throughput), float64(sample.Throughput)))
+	b.NumSamples++
+	b.LastBenchmark = now
+}
+
+// RecordBenchmark adds a benchmark sample taken by a worker to the rolling
+// benchmark statistics of a host.
+func (hdb *HostDB) RecordBenchmark(key types.SiaPublicKey, sample modules.HostBenchmarkSample) error {
+	if err := hdb.tg.Add(); err != nil {
+		return errors.AddContext(err, "error adding hostdb threadgroup:")
+	}
+	defer hdb.tg.Done()
+
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+
+	// Fetch the host.
+	host, haveHost := hdb.staticHostTree.Select(key)
+	if !haveHost {
+		return errors.AddContext(errHostNotFoundInTree, "unable to record benchmark:")
+	}
+
+	// Update the benchmark. The benchmark affects the score of the host, so
+	// both trees need to be updated.
+	updateHostBenchmark(&host, sample, time.Now())
+	return hdb.modify(host)
+}
+
 // IncrementSuccessfulInteractions increments the number of successful
 // interactions with a host for a given key
 func (hdb *HostDB) IncrementSuccessfulInteractions(key types.SiaPublicKey) error {
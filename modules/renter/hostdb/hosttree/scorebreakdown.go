@@ -18,8 +18,10 @@ type ScoreBreakdown interface {
 // implements the scoreBreakdown interface.
 type HostAdjustments struct {
 	AcceptContractAdjustment   float64
+	AddressChangeAdjustment    float64
 	AgeAdjustment              float64
 	BasePriceAdjustment        float64
+	BenchmarkAdjustment        float64
 	BurnAdjustment             float64
 	CollateralAdjustment       float64
 	DurationAdjustment         float64
@@ -70,8 +72,10 @@ func (h HostAdjustments) HostScoreBreakdown(totalScore types.Currency, ignoreAge
 		ConversionRate: conversionRate(score, totalScore),
 
 		AcceptContractAdjustment:   h.AcceptContractAdjustment,
+		AddressChangeAdjustment:    h.AddressChangeAdjustment,
 		AgeAdjustment:              h.AgeAdjustment,
 		BasePriceAdjustment:        h.BasePriceAdjustment,
+		BenchmarkAdjustment:        h.BenchmarkAdjustment,
 		BurnAdjustment:             h.BurnAdjustment,
 		CollateralAdjustment:       h.CollateralAdjustment,
 		DurationAdjustment:         h.DurationAdjustment,
@@ -89,7 +93,9 @@ func (h HostAdjustments) Score() types.Currency {
 	// Combine the adjustments.
 	fullPenalty := h.AgeAdjustment *
 		h.AcceptContractAdjustment *
+		h.AddressChangeAdjustment *
 		h.BasePriceAdjustment *
+		h.BenchmarkAdjustment *
 		h.BurnAdjustment *
 		h.CollateralAdjustment *
 		h.DurationAdjustment *
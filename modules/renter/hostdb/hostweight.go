@@ -15,6 +15,18 @@ import (
 )
 
 const (
+	// benchmarkMinAdjustment is the lowest adjustment a host can receive for
+	// performing poorly in the benchmarks of the renter's workers.
+	benchmarkMinAdjustment = 0.1
+
+	// benchmarkTargetThroughput is the throughput in bytes per second below
+	// which a host is penalized.
+	benchmarkTargetThroughput = 1 << 20 // 1 MiB/s
+
+	// benchmarkTargetTTFB is the time to first byte above which a host is
+	// penalized.
+	benchmarkTargetTTFB = time.Second
+
 	// collateralExponentiation is the power to which we raise the weight
 	// during collateral adjustment when the collateral is large. This sublinear
 	// number ensures that there is not an overpreference on collateral when
@@ -105,6 +117,25 @@ func (hdb *HostDB) basePriceAdjustments(entry modules.HostDBEntry) float64 {
 	return 1
 }
 
+// benchmarkAdjustments penalizes hosts which were measured to be slow by the
+// benchmarks of the renter's workers. The penalty scales with the square root
+// of how far the host's time to first byte and throughput miss their targets.
+// Hosts which weren't benchmarked yet are not penalized.
+func (hdb *HostDB) benchmarkAdjustments(entry modules.HostDBEntry) float64 {
+	b := entry.Benchmark
+	if b.NumSamples == 0 {
+		return 1
+	}
+	adjustment := 1.0
+	if b.TTFB > benchmarkTargetTTFB {
+		adjustment *= math.Sqrt(float64(benchmarkTargetTTFB) / float64(b.TTFB))
+	}
+	if b.Throughput > 0 && b.Throughput < benchmarkTargetThroughput {
+		adjustment *= math.Sqrt(float64(b.Throughput) / benchmarkTargetThroughput)
+	}
+	return math.Max(adjustment, benchmarkMinAdjustment)
+}
+
 // collateralAdjustments improves the host's weight according to the amount of
 // collateral that they have provided.
 func (hdb *HostDB) collateralAdjustments(entry modules.HostDBEntry, allowance modules.Allowance) float64 {
@@ -397,11 +428,23 @@ func versionAdjustments(entry modules.HostDBEntry) float64 {
 	// we give the current version a very tiny penalty is so that the test suite
 	// complains if we forget to update this file when we bump the version next
 	// time. The value compared against must be higher than the current version.
-	if build.VersionCmp(entry.Version, "1.5.8") < 0 {
+	if build.VersionCmp(entry.Version, "1.5.12") < 0 {
 		base = base * 0.99999 // Safety value to make sure we update the version penalties every time we update the host.
 	}
 
 	// This needs to be "less than the current version" - anything less than the current version should get a penalty.
+	if build.VersionCmp(entry.Version, "1.5.11") < 0 {
+		base = base * 0.99 // Slight penalty against slightly out of date hosts.
+	}
+	if build.VersionCmp(entry.Version, "1.5.10") < 0 {
+		base = base * 0.99 // Slight penalty against slightly out of date hosts.
+	}
+	if build.VersionCmp(entry.Version, "1.5.9") < 0 {
+		base = base * 0.99 // Slight penalty against slightly out of date hosts.
+	}
+	if build.VersionCmp(entry.Version, "1.5.8") < 0 {
+		base = base * 0.99 // Slight penalty against slightly out of date hosts.
+	}
 	if build.VersionCmp(entry.Version, "1.5.7") < 0 {
 		base = base * 0.99 // Slight penalty against slightly out of date hosts.
 	}
@@ -571,8 +614,10 @@ func (hdb *HostDB) managedCalculateHostWeightFn(allowance modules.Allowance) hos
 	return func(entry modules.HostDBEntry) hosttree.ScoreBreakdown {
 		return hosttree.HostAdjustments{
 			AcceptContractAdjustment:   hdb.acceptContractAdjustments(entry),
+			AddressChangeAdjustment:    hdb.addressChangeAdjustments(entry),
 			AgeAdjustment:              hdb.lifetimeAdjustments(entry),
 			BasePriceAdjustment:        hdb.basePriceAdjustments(entry),
+			BenchmarkAdjustment:        hdb.benchmarkAdjustments(entry),
 			BurnAdjustment:             1,
 			CollateralAdjustment:       hdb.collateralAdjustments(entry, allowance),
 			DurationAdjustment:         hdb.durationAdjustments(entry, allowance),
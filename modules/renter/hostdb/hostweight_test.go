@@ -746,3 +746,63 @@ func TestHostWeightAcceptContract(t *testing.T) {
 		t.Error("Entry2 should have smallest weight")
 	}
 }
+
+// TestHostWeightBenchmark checks that hosts which perform poorly in the
+// benchmarks of the renter's workers have a worse score.
+func TestHostWeightBenchmark(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	hdb := bareHostDB()
+	err := hdb.SetAllowance(DefaultTestAllowance)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A host that wasn't benchmarked isn't penalized.
+	entry := DefaultHostDBEntry
+	if ba := hdb.benchmarkAdjustments(entry); ba != 1 {
+		t.Fatal("unexpected adjustment", ba)
+	}
+
+	// Neither is a fast host.
+	updateHostBenchmark(&entry, modules.HostBenchmarkSample{
+		RTT:        50 * time.Millisecond,
+		TTFB:       100 * time.Millisecond,
+		Throughput: 10 * benchmarkTargetThroughput,
+	}, time.Now())
+	if ba := hdb.benchmarkAdjustments(entry); ba != 1 {
+		t.Fatal("unexpected adjustment", ba)
+	}
+
+	// A slow host is penalized.
+	slow := DefaultHostDBEntry
+	updateHostBenchmark(&slow, modules.HostBenchmarkSample{
+		RTT:        time.Second,
+		TTFB:       4 * benchmarkTargetTTFB,
+		Throughput: benchmarkTargetThroughput / 4,
+	}, time.Now())
+	if ba := hdb.benchmarkAdjustments(slow); ba != 0.25 {
+		t.Fatal("unexpected adjustment", ba)
+	}
+	if hdb.weightFunc(entry).Score().Cmp(hdb.weightFunc(slow).Score()) <= 0 {
+		t.Fatal("slow host should have a smaller weight")
+	}
+
+	// Samples are averaged and missing measurements are ignored.
+	updateHostBenchmark(&slow, modules.HostBenchmarkSample{
+		RTT: 2 * time.Second,
+	}, time.Now())
+	b := slow.Benchmark
+	expectedRTT := time.Duration(float64(time.Second)*benchmarkDecay + float64(2*time.Second)*(1-benchmarkDecay))
+	if b.NumSamples != 2 || b.RTT != expectedRTT || b.TTFB != 4*benchmarkTargetTTFB || b.Throughput != benchmarkTargetThroughput/4 {
+		t.Fatal("unexpected benchmark", b)
+	}
+
+	// The adjustment is capped.
+	slow.Benchmark.TTFB = time.Hour
+	if ba := hdb.benchmarkAdjustments(slow); ba != benchmarkMinAdjustment {
+		t.Fatal("unexpected adjustment", ba)
+	}
+}
@@ -32,6 +32,10 @@ type hdbPersist struct {
 	LastChange               modules.ConsensusChangeID
 	FilteredHosts            map[string]types.SiaPublicKey
 	FilterMode               modules.FilterMode
+	PriceIndex               []modules.HostDBPriceSnapshot
+	ScanRecords              map[string][]modules.HostDBScanRecord
+	Announcements            map[string]hostAnnouncement
+	SharedHosts              map[string]struct{}
 }
 
 // persistData returns the data in the hostdb that will be saved to disk.
@@ -43,6 +47,10 @@ func (hdb *HostDB) persistData() (data hdbPersist) {
 	data.LastChange = hdb.lastChange
 	data.FilteredHosts = hdb.filteredHosts
 	data.FilterMode = hdb.filterMode
+	data.PriceIndex = hdb.priceIndex
+	data.ScanRecords = hdb.scanRecords
+	data.Announcements = hdb.announcements
+	data.SharedHosts = hdb.sharedHosts
 	return data
 }
 
@@ -68,13 +76,20 @@ func (hdb *HostDB) load() error {
 	hdb.knownContracts = data.KnownContracts
 	hdb.filteredHosts = data.FilteredHosts
 	hdb.filterMode = data.FilterMode
+	hdb.priceIndex = data.PriceIndex
+	hdb.scanRecords = data.ScanRecords
+	hdb.announcements = data.Announcements
+	hdb.sharedHosts = data.SharedHosts
 
 	if len(hdb.filteredHosts) > 0 {
 		hdb.filteredTree = hosttree.New(hdb.weightFunc, modules.ProdDependencies.Resolver())
 	}
 
 	// Load each of the hosts into the host trees.
+	knownHosts := make(map[string]struct{}, len(data.AllHosts))
 	for _, host := range data.AllHosts {
+		knownHosts[host.PublicKey.String()] = struct{}{}
+
 		// COMPATv1.1.0
 		//
 		// The host did not always track its block height correctly, meaning
@@ -94,12 +109,20 @@ func (hdb *HostDB) load() error {
 			hdb.queueScan(host)
 		}
 	}
+
+	// Drop the scan records of hosts which are no longer in the hostdb.
+	for key := range hdb.scanRecords {
+		if _, exists := knownHosts[key]; !exists {
+			delete(hdb.scanRecords, key)
+		}
+	}
 	return nil
 }
 
 // threadedSaveLoop saves the hostdb to disk every 2 minutes, also saving when
 // given the shutdown signal.
 func (hdb *HostDB) threadedSaveLoop() {
+	defer modules.RecoverPanic("hostdb")
 	err := hdb.tg.Add()
 	if err != nil {
 		return
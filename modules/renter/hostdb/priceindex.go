@@ -0,0 +1,109 @@
+package hostdb
+
+import (
+	"math/big"
+	"sort"
+	"time"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// medianCurrency returns the median of the provided values. The values are
+// sorted in place.
+func medianCurrency(values []types.Currency) types.Currency {
+	if len(values) == 0 {
+		return types.ZeroCurrency
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].Cmp(values[j]) < 0
+	})
+	mid := len(values) / 2
+	if len(values)%2 == 1 {
+		return values[mid]
+	}
+	return values[mid-1].Add(values[mid]).Div64(2)
+}
+
+// medianFloat returns the median of the provided values. The values are sorted
+// in place.
+func medianFloat(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sort.Float64s(values)
+	mid := len(values) / 2
+	if len(values)%2 == 1 {
+		return values[mid]
+	}
+	return (values[mid-1] + values[mid]) / 2
+}
+
+// priceSnapshot computes the aggregated prices of the provided hosts. Only
+// hosts which are online and accepting contracts are considered.
+func priceSnapshot(hosts []modules.HostDBEntry, height types.BlockHeight) modules.HostDBPriceSnapshot {
+	var collaterals, contractPrices, downloadPrices, storagePrices, uploadPrices []types.Currency
+	var collateralRatios []float64
+	for _, host := range hosts {
+		if len(host.ScanHistory) == 0 || !host.ScanHistory[len(host.ScanHistory)-1].Success {
+			continue
+		}
+		if !host.AcceptingContracts {
+			continue
+		}
+		collaterals = append(collaterals, host.Collateral)
+		contractPrices = append(contractPrices, host.ContractPrice)
+		downloadPrices = append(downloadPrices, host.DownloadBandwidthPrice)
+		storagePrices = append(storagePrices, host.StoragePrice)
+		uploadPrices = append(uploadPrices, host.UploadBandwidthPrice)
+		if !host.StoragePrice.IsZero() {
+			ratio, _ := new(big.Rat).SetFrac(host.Collateral.Big(), host.StoragePrice.Big()).Float64()
+			collateralRatios = append(collateralRatios, ratio)
+		}
+	}
+	return modules.HostDBPriceSnapshot{
+		BlockHeight: height,
+		NumHosts:    uint64(len(storagePrices)),
+		Timestamp:   time.Now(),
+
+		MedianCollateral:             medianCurrency(collaterals),
+		MedianContractPrice:          medianCurrency(contractPrices),
+		MedianDownloadBandwidthPrice: medianCurrency(downloadPrices),
+		MedianStoragePrice:           medianCurrency(storagePrices),
+		MedianUploadBandwidthPrice:   medianCurrency(uploadPrices),
+
+		MedianCollateralRatio: medianFloat(collateralRatios),
+	}
+}
+
+// updatePriceIndex adds a new snapshot to the price index if the hostdb is
+// synced and enough blocks passed since the last snapshot.
+func (hdb *HostDB) updatePriceIndex() {
+	// Prices of hosts are only known for the current height.
+	if !hdb.synced || !hdb.initialScanComplete {
+		return
+	}
+	if n := len(hdb.priceIndex); n > 0 && hdb.blockHeight < hdb.priceIndex[n-1].BlockHeight+priceIndexInterval {
+		return
+	}
+	snapshot := priceSnapshot(hdb.staticHostTree.All(), hdb.blockHeight)
+	if snapshot.NumHosts == 0 {
+		return
+	}
+	hdb.priceIndex = append(hdb.priceIndex, snapshot)
+	if len(hdb.priceIndex) > priceIndexMaxSnapshots {
+		hdb.priceIndex = append([]modules.HostDBPriceSnapshot{}, hdb.priceIndex[len(hdb.priceIndex)-priceIndexMaxSnapshots:]...)
+	}
+}
+
+// PriceIndex returns the historic price snapshots of the host network, sorted
+// by block height.
+func (hdb *HostDB) PriceIndex() ([]modules.HostDBPriceSnapshot, error) {
+	if err := hdb.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer hdb.tg.Done()
+	hdb.mu.RLock()
+	defer hdb.mu.RUnlock()
+	return append([]modules.HostDBPriceSnapshot{}, hdb.priceIndex...), nil
+}
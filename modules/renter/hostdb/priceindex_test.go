@@ -0,0 +1,90 @@
+package hostdb
+
+import (
+	"testing"
+	"time"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestPriceSnapshot is a unit test for priceSnapshot.
+func TestPriceSnapshot(t *testing.T) {
+	t.Parallel()
+
+	// newHost is a helper to create an active host with the given storage
+	// price and collateral.
+	newHost := func(storagePrice, collateral uint64) modules.HostDBEntry {
+		var host modules.HostDBEntry
+		host.AcceptingContracts = true
+		host.Collateral = types.NewCurrency64(collateral)
+		host.ContractPrice = types.NewCurrency64(storagePrice * 10)
+		host.StoragePrice = types.NewCurrency64(storagePrice)
+		host.ScanHistory = modules.HostDBScans{{Timestamp: time.Now(), Success: true}}
+		return host
+	}
+
+	// Inactive hosts are ignored.
+	offline := newHost(1000, 1000)
+	offline.ScanHistory[0].Success = false
+	notAccepting := newHost(1000, 1000)
+	notAccepting.AcceptingContracts = false
+	hosts := []modules.HostDBEntry{offline, notAccepting, newHost(30, 30), newHost(10, 20), newHost(20, 60)}
+
+	snapshot := priceSnapshot(hosts, 100)
+	if snapshot.BlockHeight != 100 || snapshot.NumHosts != 3 {
+		t.Fatal("unexpected snapshot", snapshot.BlockHeight, snapshot.NumHosts)
+	}
+	if !snapshot.MedianStoragePrice.Equals64(20) || !snapshot.MedianContractPrice.Equals64(200) || !snapshot.MedianCollateral.Equals64(30) {
+		t.Fatal("wrong medians", snapshot.MedianStoragePrice, snapshot.MedianContractPrice, snapshot.MedianCollateral)
+	}
+	if snapshot.MedianCollateralRatio != 2 {
+		t.Fatal("wrong collateral ratio", snapshot.MedianCollateralRatio)
+	}
+
+	// An even number of hosts uses the mean of the middle values.
+	snapshot = priceSnapshot(hosts[2:4], 100)
+	if !snapshot.MedianStoragePrice.Equals64(20) {
+		t.Fatal("wrong median", snapshot.MedianStoragePrice)
+	}
+
+	// No active hosts.
+	snapshot = priceSnapshot(hosts[:2], 100)
+	if snapshot.NumHosts != 0 || !snapshot.MedianStoragePrice.IsZero() {
+		t.Fatal("unexpected snapshot", snapshot)
+	}
+}
+
+// TestUpdatePriceIndex checks that the hostdb only takes snapshots at the
+// right interval and drops old snapshots.
+func TestUpdatePriceIndex(t *testing.T) {
+	t.Parallel()
+
+	host := makeHostDBEntry()
+	host.AcceptingContracts = true
+
+	hdb := bareHostDB()
+	if err := hdb.staticHostTree.Insert(host); err != nil {
+		t.Fatal(err)
+	}
+
+	// Not synced, no snapshot.
+	hdb.updatePriceIndex()
+	if len(hdb.priceIndex) != 0 {
+		t.Fatal("expected no snapshot")
+	}
+	hdb.synced = true
+	hdb.initialScanComplete = true
+	for i := 0; i < priceIndexMaxSnapshots+1; i++ {
+		hdb.blockHeight = types.BlockHeight(i) * priceIndexInterval
+		hdb.updatePriceIndex()
+		// A second update at the same height shouldn't add a snapshot.
+		hdb.updatePriceIndex()
+	}
+	if len(hdb.priceIndex) != priceIndexMaxSnapshots {
+		t.Fatal("wrong number of snapshots", len(hdb.priceIndex))
+	}
+	if hdb.priceIndex[0].BlockHeight != priceIndexInterval {
+		t.Fatal("oldest snapshot should have been dropped", hdb.priceIndex[0].BlockHeight)
+	}
+}
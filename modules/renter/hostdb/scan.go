@@ -117,7 +117,12 @@ func (hdb *HostDB) queueScan(entry modules.HostDBEntry) {
 	}
 
 	// Sanity check - the scan map and the scan list should have the same
-	// length.
+	// length. The scanning threads might still be working off the entries of
+	// a profile with more threads.
+	maxScanningThreads := modules.CurrentProfile().HostDBScanningThreads
+	if hdb.scanningThreads > maxScanningThreads {
+		maxScanningThreads = hdb.scanningThreads
+	}
 	if build.DEBUG && len(hdb.scanMap) > len(hdb.scanList)+maxScanningThreads {
 		hdb.staticLog.Critical("The hostdb scan map has seemingly grown too large:", len(hdb.scanMap), len(hdb.scanList), maxScanningThreads)
 	}
@@ -181,7 +186,7 @@ func (hdb *HostDB) queueScan(entry modules.HostDBEntry) {
 			}
 
 			// Create new worker thread.
-			if hdb.scanningThreads < maxScanningThreads || !starterThread {
+			if hdb.scanningThreads < modules.CurrentProfile().HostDBScanningThreads || !starterThread {
 				starterThread = true
 				hdb.scanningThreads++
 				if err := hdb.tg.Add(); err != nil {
@@ -228,6 +233,8 @@ func (hdb *HostDB) updateEntry(entry modules.HostDBEntry, netErr error) {
 		newEntry.HostExternalSettings = entry.HostExternalSettings
 		newEntry.IPNets = entry.IPNets
 		newEntry.LastIPNetChange = entry.LastIPNetChange
+		newEntry.ASN = entry.ASN
+		newEntry.Country = entry.Country
 	} else {
 		newEntry = entry
 	}
@@ -361,19 +368,39 @@ func (hdb *HostDB) staticLookupIPNets(address modules.NetAddress) (ipNets []stri
 	return
 }
 
+// staticLookupASN returns the number of the autonomous system the host's
+// address belongs to and the country the address is registered in. If the
+// resolver doesn't support ASN lookups, 0 and an empty country are returned
+// which means that both are unknown.
+func (hdb *HostDB) staticLookupASN(address modules.NetAddress) (uint32, string, error) {
+	resolver := hdb.staticDeps.Resolver()
+	asnResolver, ok := resolver.(modules.ASNResolver)
+	if !ok {
+		return 0, "", nil
+	}
+	addresses, err := resolver.LookupIP(address.Host())
+	if err != nil {
+		return 0, "", err
+	}
+	if len(addresses) == 0 {
+		return 0, "", errors.New("host address didn't resolve to any IP")
+	}
+	return asnResolver.LookupASN(addresses[0])
+}
+
 // managedScanHost will connect to a host and grab the settings, verifying
 // uptime and updating to the host's preferences.
 func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 	// Request settings from the queued host entry.
-	netAddr := entry.NetAddress
+	netAddrs := entry.NetAddresses()
 	pubKey := entry.PublicKey
-	hdb.staticLog.Debugf("Scanning host %v at %v", pubKey, netAddr)
+	hdb.staticLog.Debugf("Scanning host %v at %v", pubKey, netAddrs)
 
 	// If we use a custom resolver for testing, we replace the custom domain
 	// with 127.0.0.1. Otherwise the scan will fail.
 	if hdb.staticDeps.Disrupt("customResolver") {
-		port := netAddr.Port()
-		netAddr = modules.NetAddress(fmt.Sprintf("127.0.0.1:%s", port))
+		port := entry.NetAddress.Port()
+		netAddrs = []modules.NetAddress{modules.NetAddress(fmt.Sprintf("127.0.0.1:%s", port))}
 	}
 
 	// Resolve the host's used subnets and update the timestamp if they
@@ -388,6 +415,16 @@ func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 		hdb.staticLog.Debugln("mangedScanHost: failed to look up IP nets", err)
 	}
 
+	// Resolve the autonomous system and country of the host. The previous
+	// values are kept if the lookup fails.
+	asn, country, err := hdb.staticLookupASN(entry.NetAddress)
+	if err == nil {
+		entry.ASN = asn
+		entry.Country = country
+	} else {
+		hdb.staticLog.Debugln("mangedScanHost: failed to look up ASN", err)
+	}
+
 	// Update historic interactions of entry if necessary
 	hdb.mu.Lock()
 	updateHostHistoricInteractions(&entry, hdb.blockHeight)
@@ -419,7 +456,7 @@ func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 			Timeout: timeout,
 		}
 		start := time.Now()
-		conn, err := dialer.Dial("tcp", string(netAddr))
+		conn, _, err := modules.DialNetAddresses(dialer, netAddrs)
 		latency = time.Since(start)
 		if err != nil {
 			return err
@@ -497,10 +534,12 @@ func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 	oldEntry, exists := hdb.staticHostTree.Select(entry.PublicKey)
 	if exists {
 		entry.NetAddress = oldEntry.NetAddress
+		entry.AdditionalNetAddresses = oldEntry.AdditionalNetAddresses
 	}
 	// Update the host tree to have a new entry, including the new error. Then
 	// delete the entry from the scan map as the scan has been successful.
 	hdb.updateEntry(entry, err)
+	hdb.addScanRecord(entry.PublicKey, latency, err)
 
 	// Add the scan to the initialScanLatencies if it was successful.
 	if success && len(hdb.initialScanLatencies) < minScansForSpeedup {
@@ -559,6 +598,7 @@ func (hdb *HostDB) threadedProbeHosts(scanPool <-chan modules.HostDBEntry) {
 // threadedScan is an ongoing function which will query the full set of hosts
 // every few hours to see who is online and available for uploading.
 func (hdb *HostDB) threadedScan() {
+	defer modules.RecoverPanic("hostdb")
 	err := hdb.tg.Add()
 	if err != nil {
 		return
@@ -660,8 +700,9 @@ func (hdb *HostDB) threadedScan() {
 		// scanning. The minimums and maximums keep the scan time reasonable,
 		// while the randomness prevents the scanning from always happening at
 		// the same time of day or week.
-		sleepRange := uint64(maxScanSleep - minScanSleep)
-		sleepTime := minScanSleep + time.Duration(fastrand.Uint64n(sleepRange))
+		profile := modules.CurrentProfile()
+		sleepRange := uint64(profile.HostDBMaxScanSleep - profile.HostDBMinScanSleep)
+		sleepTime := profile.HostDBMinScanSleep + time.Duration(fastrand.Uint64n(sleepRange))
 
 		// Sleep until it's time for the next scan cycle.
 		select {
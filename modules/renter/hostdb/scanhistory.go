@@ -0,0 +1,96 @@
+package hostdb
+
+import (
+	"bytes"
+	"time"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// lastScanRecordSettings returns the settings of the most recent record which
+// contains settings or nil if none of the records do.
+func lastScanRecordSettings(records []modules.HostDBScanRecord) *modules.HostExternalSettings {
+	for i := len(records) - 1; i >= 0; i-- {
+		if records[i].Settings != nil {
+			return records[i].Settings
+		}
+	}
+	return nil
+}
+
+// pruneScanRecords drops the records which are older than scanRecordsMaxAge
+// and the oldest records exceeding scanRecordsMaxRecords. If the new oldest
+// record doesn't contain settings, it inherits the most recent settings of the
+// dropped records to keep the history of the settings complete.
+func pruneScanRecords(records []modules.HostDBScanRecord, now time.Time) []modules.HostDBScanRecord {
+	drop := 0
+	for drop < len(records) && (len(records)-drop > scanRecordsMaxRecords || now.Sub(records[drop].Timestamp) > scanRecordsMaxAge) {
+		drop++
+	}
+	if drop == 0 {
+		return records
+	}
+	settings := lastScanRecordSettings(records[:drop])
+	records = append([]modules.HostDBScanRecord{}, records[drop:]...)
+	if len(records) > 0 && records[0].Settings == nil {
+		records[0].Settings = settings
+	}
+	return records
+}
+
+// addScanRecord adds a record of a scan to the scan history of a host. It
+// must be called after the scan was applied to the host's entry. The settings
+// of the host are only added to the record if they changed since the last
+// record with settings.
+func (hdb *HostDB) addScanRecord(pk types.SiaPublicKey, latency time.Duration, scanErr error) {
+	// Like updateEntry, ignore failed scans if we don't have Internet access.
+	if scanErr != nil && !hdb.gateway.Online() {
+		return
+	}
+
+	// If the host is no longer in the tree, it was removed from the hostdb
+	// and its history is no longer needed.
+	key := pk.String()
+	entry, exists := hdb.staticHostTree.Select(pk)
+	if !exists {
+		delete(hdb.scanRecords, key)
+		return
+	}
+
+	record := modules.HostDBScanRecord{
+		Timestamp: time.Now(),
+		Success:   scanErr == nil,
+		Latency:   latency,
+		Score:     hdb.weightFunc(entry).Score(),
+	}
+	records := hdb.scanRecords[key]
+	if scanErr != nil {
+		record.Error = scanErr.Error()
+	} else if last := lastScanRecordSettings(records); last == nil || !bytes.Equal(encoding.Marshal(*last), encoding.Marshal(entry.HostExternalSettings)) {
+		settings := entry.HostExternalSettings
+		record.Settings = &settings
+	}
+	if hdb.scanRecords == nil {
+		hdb.scanRecords = make(map[string][]modules.HostDBScanRecord)
+	}
+	hdb.scanRecords[key] = pruneScanRecords(append(records, record), record.Timestamp)
+}
+
+// ScanHistory returns the detailed records of the recent scans of a host,
+// sorted by time.
+func (hdb *HostDB) ScanHistory(pk types.SiaPublicKey) ([]modules.HostDBScanRecord, error) {
+	if err := hdb.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer hdb.tg.Done()
+	hdb.mu.RLock()
+	defer hdb.mu.RUnlock()
+	if _, exists := hdb.staticHostTree.Select(pk); !exists {
+		return nil, errors.AddContext(errHostNotFoundInTree, "unable to get scan history")
+	}
+	return append([]modules.HostDBScanRecord{}, hdb.scanRecords[pk.String()]...), nil
+}
@@ -0,0 +1,155 @@
+package hostdb
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestAddScanRecord checks that scan records are added to the history of a
+// host and that settings are only recorded if they changed.
+func TestAddScanRecord(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	hdbt, err := newHDBTesterDeps(t.Name(), &disableScanLoopDeps{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hdb := hdbt.hdb
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+
+	host := makeHostDBEntry()
+	if err := hdb.staticHostTree.Insert(host); err != nil {
+		t.Fatal(err)
+	}
+
+	// Add a successful scan, a scan with the same settings, a failed scan and
+	// a scan with new settings.
+	hdb.addScanRecord(host.PublicKey, time.Second, nil)
+	hdb.addScanRecord(host.PublicKey, time.Second, nil)
+	hdb.addScanRecord(host.PublicKey, time.Minute, errors.New("failed"))
+	host.StoragePrice = host.StoragePrice.Add64(1)
+	if err := hdb.staticHostTree.Modify(host); err != nil {
+		t.Fatal(err)
+	}
+	hdb.addScanRecord(host.PublicKey, time.Second, nil)
+
+	records := hdb.scanRecords[host.PublicKey.String()]
+	if len(records) != 4 {
+		t.Fatal("wrong number of records", len(records))
+	}
+	if !records[0].Success || records[0].Settings == nil || records[0].Latency != time.Second || records[0].Score.IsZero() {
+		t.Fatal("wrong first record", records[0])
+	}
+	if records[1].Settings != nil {
+		t.Fatal("unchanged settings shouldn't be recorded")
+	}
+	if records[2].Success || records[2].Error != "failed" || records[2].Settings != nil {
+		t.Fatal("wrong failed record", records[2])
+	}
+	if records[3].Settings == nil || !records[3].Settings.StoragePrice.Equals(host.StoragePrice) {
+		t.Fatal("changed settings should be recorded", records[3])
+	}
+
+	// Once the host is removed, its history is dropped with the next scan.
+	if err := hdb.staticHostTree.Remove(host.PublicKey); err != nil {
+		t.Fatal(err)
+	}
+	hdb.addScanRecord(host.PublicKey, time.Second, nil)
+	if _, exists := hdb.scanRecords[host.PublicKey.String()]; exists {
+		t.Fatal("history of removed host wasn't dropped")
+	}
+}
+
+// TestPruneScanRecords checks that old records are dropped and that the
+// oldest remaining record inherits the settings of the dropped records.
+func TestPruneScanRecords(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	settings := &modules.HostExternalSettings{StoragePrice: types.NewCurrency64(1)}
+	var records []modules.HostDBScanRecord
+	for i := 0; i < scanRecordsMaxRecords+2; i++ {
+		records = append(records, modules.HostDBScanRecord{Timestamp: now.Add(time.Duration(i) * time.Second)})
+	}
+	records[0].Settings = settings
+
+	// The oldest records exceeding the limit are dropped.
+	pruned := pruneScanRecords(records, now)
+	if len(pruned) != scanRecordsMaxRecords {
+		t.Fatal("wrong number of records", len(pruned))
+	}
+	if !pruned[0].Timestamp.Equal(records[2].Timestamp) {
+		t.Fatal("wrong records were dropped")
+	}
+	if pruned[0].Settings != settings {
+		t.Fatal("settings weren't inherited")
+	}
+	if records[2].Settings != nil {
+		t.Fatal("original records shouldn't be modified")
+	}
+
+	// Records older than the max age are dropped.
+	pruned = pruneScanRecords(records[:3], now.Add(scanRecordsMaxAge+time.Second+500*time.Millisecond))
+	if len(pruned) != 1 || !pruned[0].Timestamp.Equal(records[2].Timestamp) {
+		t.Fatal("old records weren't dropped", pruned)
+	}
+}
+
+// TestScanHistory checks that the hostdb records the scans of a host and
+// persists the records.
+func TestScanHistory(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	hdbt, err := newHDBTesterDeps(t.Name(), &disableScanLoopDeps{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Unknown hosts don't have a history.
+	host := makeHostDBEntry()
+	if _, err := hdbt.hdb.ScanHistory(host.PublicKey); err == nil {
+		t.Fatal("expected error for unknown host")
+	}
+
+	// Scan an offline host.
+	host.NetAddress = "127.0.0.1:1"
+	hdbt.hdb.managedScanHost(host)
+	records, err := hdbt.hdb.ScanHistory(host.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Success || records[0].Error == "" {
+		t.Fatal("wrong records", records)
+	}
+
+	// Restart the hostdb and check that the records were persisted.
+	if err := hdbt.hdb.Close(); err != nil {
+		t.Fatal(err)
+	}
+	hdb, errChan := NewCustomHostDB(hdbt.gateway, hdbt.cs, hdbt.tpool, hdbt.mux, filepath.Join(hdbt.persistDir, modules.RenterDir), &disableScanLoopDeps{})
+	if err := <-errChan; err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := hdb.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	loaded, err := hdb.ScanHistory(host.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 1 || loaded[0].Error != records[0].Error {
+		t.Fatal("records weren't persisted", loaded)
+	}
+}
@@ -16,14 +16,15 @@ func findHostAnnouncements(b types.Block) (announcements []modules.HostDBEntry)
 		// the HostAnnouncement must be prefaced by the standard host
 		// announcement string
 		for _, arb := range t.ArbitraryData {
-			addr, pubKey, err := modules.DecodeAnnouncement(arb)
+			addrs, pubKey, err := modules.DecodeMultiAddressAnnouncement(arb)
 			if err != nil {
 				continue
 			}
 
 			// Add the announcement to the slice being returned.
 			var host modules.HostDBEntry
-			host.NetAddress = addr
+			host.NetAddress = addrs[0]
+			host.AdditionalNetAddresses = addrs[1:]
 			host.PublicKey = pubKey
 			announcements = append(announcements, host)
 		}
@@ -31,17 +32,36 @@ func findHostAnnouncements(b types.Block) (announcements []modules.HostDBEntry)
 	return
 }
 
-// insertBlockchainHost adds a host entry to the state. The host will be inserted
-// into the set of all hosts, and if it is online and responding to requests it
-// will be put into the list of active hosts.
-func (hdb *HostDB) insertBlockchainHost(host modules.HostDBEntry) {
+// sanitizeAnnouncedHost drops the invalid and local additional addresses of
+// an announced host. It returns false if the host's primary address can't be
+// used.
+func (hdb *HostDB) sanitizeAnnouncedHost(host *modules.HostDBEntry) bool {
 	// Remove garbage hosts and local hosts (but allow local hosts in testing).
 	if err := host.NetAddress.IsValid(); err != nil {
 		hdb.staticLog.Debugf("WARN: host '%v' has an invalid NetAddress: %v", host.NetAddress, err)
-		return
+		return false
 	}
 	// Ignore all local hosts announced through the blockchain.
 	if build.Release == "standard" && host.NetAddress.IsLocal() {
+		return false
+	}
+	// Drop invalid and local additional addresses.
+	var additional []modules.NetAddress
+	for _, addr := range host.AdditionalNetAddresses {
+		if addr.IsValid() != nil || (build.Release == "standard" && addr.IsLocal()) {
+			continue
+		}
+		additional = append(additional, addr)
+	}
+	host.AdditionalNetAddresses = additional
+	return true
+}
+
+// insertBlockchainHost adds a host entry to the state. The host will be inserted
+// into the set of all hosts, and if it is online and responding to requests it
+// will be put into the list of active hosts.
+func (hdb *HostDB) insertBlockchainHost(host modules.HostDBEntry) {
+	if !hdb.sanitizeAnnouncedHost(&host) {
 		return
 	}
 
@@ -49,15 +69,27 @@ func (hdb *HostDB) insertBlockchainHost(host modules.HostDBEntry) {
 	// shutdown occurs before a scan can be performed.
 	oldEntry, exists := hdb.staticHostTree.Select(host.PublicKey)
 	if exists {
-		// Replace the netaddress with the most recently announced netaddress.
+		// Replace the netaddresses with the most recently announced netaddresses.
 		// Also replace the FirstSeen value with the current block height if
 		// the first seen value has been set to zero (no hosts actually have a
 		// first seen height of zero, but due to rescans hosts can end up with
 		// a zero-value FirstSeen field.
+		oldAddress := oldEntry.NetAddress
 		oldEntry.NetAddress = host.NetAddress
+		oldEntry.AdditionalNetAddresses = host.AdditionalNetAddresses
 		if oldEntry.FirstSeen == 0 {
 			oldEntry.FirstSeen = hdb.blockHeight
 		}
+		// A host which was only learned from the announcements shared by a
+		// peer is seen for the first time once its announcement is found in
+		// the blockchain.
+		if _, shared := hdb.sharedHosts[host.PublicKey.String()]; shared {
+			delete(hdb.sharedHosts, host.PublicKey.String())
+			oldEntry.FirstSeen = hdb.blockHeight
+			oldAddress = ""
+		}
+		// Track the change of the address in the host's address history.
+		updateAddressHistory(&oldEntry, oldAddress, hdb.blockHeight)
 		// Resolve the host's used subnets and update the timestamp if they
 		// changed. We only update the timestamp if resolving the ipNets was
 		// successful.
@@ -73,6 +105,7 @@ func (hdb *HostDB) insertBlockchainHost(host modules.HostDBEntry) {
 		}
 	} else {
 		host.FirstSeen = hdb.blockHeight
+		updateAddressHistory(&host, "", hdb.blockHeight)
 		// Insert into hosttree
 		err := hdb.insert(host)
 		if err != nil {
@@ -94,14 +127,30 @@ func (hdb *HostDB) ProcessConsensusChange(cc modules.ConsensusChange) {
 	// behavior.
 	hdb.blockHeight = cc.BlockHeight
 
+	// Drop the cached announcements of blocks that were reverted.
+	for _, block := range cc.RevertedBlocks {
+		hdb.uncacheAnnouncements(block)
+	}
+
 	// Add hosts announced in blocks that were applied.
-	for _, block := range cc.AppliedBlocks {
+	for i, block := range cc.AppliedBlocks {
 		for _, host := range findHostAnnouncements(block) {
 			hdb.staticLog.Debugln("Found a host in a host announcement:", host.NetAddress, host.PublicKey)
 			hdb.insertBlockchainHost(host)
 		}
+		height := cc.BlockHeight - types.BlockHeight(len(cc.AppliedBlocks)-1-i)
+		hdb.cacheAnnouncements(block, height)
 	}
 
 	hdb.synced = cc.Synced
 	hdb.lastChange = cc.ID
+
+	// Once synced, all announcements were found in the blockchain. Shared hosts
+	// which weren't announced are removed.
+	if hdb.synced && len(hdb.sharedHosts) > 0 {
+		hdb.removeSharedHosts()
+	}
+
+	// Take a snapshot of the network's prices if necessary.
+	hdb.updatePriceIndex()
 }
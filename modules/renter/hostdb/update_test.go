@@ -52,3 +52,36 @@ func TestFindHostAnnouncements(t *testing.T) {
 		t.Error("host announcement found when there was an invalid encoding of a host announcement")
 	}
 }
+
+// TestFindHostAnnouncementsMultiAddress checks that findHostAnnouncements
+// parses the additional addresses of an announcement.
+func TestFindHostAnnouncementsMultiAddress(t *testing.T) {
+	sk, pk := crypto.GenerateKeyPair()
+	spk := types.SiaPublicKey{
+		Algorithm: types.SignatureEd25519,
+		Key:       pk[:],
+	}
+	addrs := []modules.NetAddress{"foo.com:1234", "[2001:db8::1]:1234"}
+	annBytes, err := modules.CreateMultiAddressAnnouncement(addrs, spk, sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := types.Block{
+		Transactions: []types.Transaction{
+			{
+				ArbitraryData: [][]byte{annBytes},
+			},
+		},
+	}
+	announcements := findHostAnnouncements(b)
+	if len(announcements) != 1 {
+		t.Fatal("host announcement not found in block")
+	}
+	host := announcements[0]
+	if host.NetAddress != addrs[0] || len(host.AdditionalNetAddresses) != 1 || host.AdditionalNetAddresses[0] != addrs[1] {
+		t.Fatal("wrong addresses", host.NetAddresses())
+	}
+	if !host.PublicKey.Equals(spk) {
+		t.Fatal("wrong public key")
+	}
+}
@@ -130,12 +130,14 @@ func (r *Renter) callCalculateDirectoryMetadata(siaPath modules.SiaPath) (siadir
 			fileSiaPath := bubbledMetadata.sp
 			fileMetadata := bubbledMetadata.bm
 			// If 75% or more of the redundancy is missing, register an alert
-			// for the file.
+			// for the file. The alert becomes critical once the file drops
+			// below 1x redundancy.
 			uid := string(fileMetadata.UID)
-			if maxHealth := math.Max(fileMetadata.Health, fileMetadata.StuckHealth); maxHealth >= AlertSiafileLowRedundancyThreshold {
-				r.staticAlerter.RegisterAlert(modules.AlertIDSiafileLowRedundancy(uid), AlertMSGSiafileLowRedundancy,
+			maxHealth := math.Max(fileMetadata.Health, fileMetadata.StuckHealth)
+			if msg, severity := siafileRedundancyAlert(maxHealth); severity != modules.SeverityUnknown {
+				r.staticAlerter.RegisterAlert(modules.AlertIDSiafileLowRedundancy(uid), msg,
 					AlertCauseSiafileLowRedundancy(fileSiaPath, maxHealth, fileMetadata.Redundancy),
-					modules.SeverityWarning)
+					severity)
 			} else {
 				r.staticAlerter.UnregisterAlert(modules.AlertIDSiafileLowRedundancy(uid))
 			}
@@ -278,6 +280,19 @@ func (r *Renter) callCalculateDirectoryMetadata(siaPath modules.SiaPath) (siadir
 	return metadata, nil
 }
 
+// siafileRedundancyAlert returns the message and severity of the low
+// redundancy alert of a file with the given health. SeverityUnknown means that
+// no alert should be registered.
+func siafileRedundancyAlert(health float64) (string, modules.AlertSeverity) {
+	if health > AlertSiafileUnrecoverableThreshold {
+		return AlertMSGSiafileUnrecoverable, modules.SeverityCritical
+	}
+	if health >= AlertSiafileLowRedundancyThreshold {
+		return AlertMSGSiafileLowRedundancy, modules.SeverityWarning
+	}
+	return "", modules.SeverityUnknown
+}
+
 // managedCachedFileMetadata returns the cached metadata information of
 // a siafiles that needs to be bubbled.
 func (r *Renter) managedCachedFileMetadata(siaPath modules.SiaPath) (bubbledSiaFileMetadata, error) {
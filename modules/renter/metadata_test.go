@@ -126,3 +126,25 @@ func TestDirectoryMetadatas(t *testing.T) {
 		t.Fatal("different metadatas")
 	}
 }
+
+// TestSiafileRedundancyAlert is a unit test for siafileRedundancyAlert.
+func TestSiafileRedundancyAlert(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		health   float64
+		msg      string
+		severity modules.AlertSeverity
+	}{
+		{0, "", modules.SeverityUnknown},
+		{0.5, "", modules.SeverityUnknown},
+		{AlertSiafileLowRedundancyThreshold, AlertMSGSiafileLowRedundancy, modules.SeverityWarning},
+		{1, AlertMSGSiafileLowRedundancy, modules.SeverityWarning},
+		{1.25, AlertMSGSiafileUnrecoverable, modules.SeverityCritical},
+	}
+	for _, test := range tests {
+		msg, severity := siafileRedundancyAlert(test.health)
+		if msg != test.msg || severity != test.severity {
+			t.Fatalf("%v: expected %v %v but got %v %v", test.health, test.msg, test.severity, msg, severity)
+		}
+	}
+}
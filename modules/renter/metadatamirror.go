@@ -0,0 +1,855 @@
+package renter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+	"go.sia.tech/siad/types"
+)
+
+// Metadata Mirror Overview:
+// While the metadata mirror is enabled, the renter periodically uploads the
+// index of its contracts and the siafiles which changed since the last mirror
+// to its hosts. The metadata is encrypted with a key derived from the seed and
+// erasure coded into pieces which are stored in a single sector on different
+// hosts. The location of every piece is published in a registry entry which is
+// signed with a key derived from the seed. Every blob points to the blob which
+// was mirrored before it, which means the metadata can be recovered by walking
+// the chain of blobs back to the last base blob which contained all siafiles.
+
+var (
+	// metadataMirrorKeySpecifier is the specifier used for deriving the secret
+	// used to encrypt the mirrored metadata from the RenterSeed.
+	metadataMirrorKeySpecifier = types.NewSpecifier("metadatamirror")
+
+	// metadataMirrorRegistrySpecifier is the specifier used for deriving the
+	// key pair of the registry entries which point to the mirrored metadata
+	// from the RenterSeed.
+	metadataMirrorRegistrySpecifier = types.NewSpecifier("metadatamirrorRV")
+
+	// metadataMirrorBlobSpecifier is the specifier used to identify a
+	// decrypted blob of mirrored metadata.
+	metadataMirrorBlobSpecifier = types.NewSpecifier("MetadataMirror")
+)
+
+var (
+	// errMetadataMirrorNotFound is returned if no mirrored metadata was found
+	// on the hosts.
+	errMetadataMirrorNotFound = errors.New("no mirrored metadata found")
+)
+
+type (
+	// metadataMirror tracks the state of the mirroring of the renter's
+	// metadata to its hosts.
+	metadataMirror struct {
+		lastMirror    time.Time
+		lastMirrorErr error
+		mirroredFiles uint64
+
+		// chainLength is the number of blobs since the last base blob and
+		// previous points to the most recently mirrored blob.
+		chainLength uint64
+		previous    metadataMirrorPointer
+
+		// mirrored contains the hashes of the siafiles which were mirrored.
+		// It is nil until the first base blob was mirrored which means the
+		// renter mirrors all of its siafiles again after a restart.
+		// contractsHash is the hash of the mirrored contract index.
+		mirrored      map[modules.SiaPath]crypto.Hash
+		contractsHash crypto.Hash
+
+		// wakeChan wakes the mirror loop after the settings were updated.
+		wakeChan chan struct{}
+
+		mu sync.Mutex
+	}
+
+	// metadataMirrorBlob is a blob of mirrored metadata.
+	metadataMirrorBlob struct {
+		Sequence     uint64
+		Base         bool
+		CreationDate types.Timestamp
+		Contracts    []metadataMirrorContract
+		Files        []metadataMirrorFile
+		Previous     metadataMirrorPointer
+	}
+
+	// metadataMirrorContract is an entry of the mirrored contract index.
+	metadataMirrorContract struct {
+		ID            types.FileContractID
+		HostPublicKey types.SiaPublicKey
+		EndHeight     types.BlockHeight
+	}
+
+	// metadataMirrorFile is a mirrored siafile. The siafile is compressed
+	// since most of it is padding.
+	metadataMirrorFile struct {
+		SiaPath modules.SiaPath
+		SiaFile []byte
+	}
+
+	// metadataMirrorPointer points to the pieces of a mirrored blob.
+	metadataMirrorPointer struct {
+		Sequence  uint64
+		Size      uint64
+		MinPieces uint64
+		NumPieces uint64
+		Pieces    []metadataMirrorPiece
+	}
+
+	// metadataMirrorPiece is the location of a piece of a mirrored blob.
+	metadataMirrorPiece struct {
+		Index         uint64
+		HostPublicKey types.SiaPublicKey
+		Root          crypto.Hash
+	}
+
+	// metadataMirrorEntry is the data of the registry entry which publishes
+	// the location of a piece. Hosts are stored by their ed25519 key to fit
+	// the entry into the registry.
+	metadataMirrorEntry struct {
+		Sequence  uint64
+		Size      uint64
+		MinPieces uint64
+		NumPieces uint64
+		Index     uint64
+		HostKey   [crypto.PublicKeySize]byte
+		Root      crypto.Hash
+	}
+)
+
+// newMetadataMirror creates a new metadataMirror.
+func newMetadataMirror() *metadataMirror {
+	return &metadataMirror{
+		wakeChan: make(chan struct{}, 1),
+	}
+}
+
+// managedReset resets the state of the mirror after the settings changed. The
+// next mirror will be a base blob.
+func (mm *metadataMirror) managedReset() {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	mm.lastMirrorErr = nil
+	mm.mirrored = nil
+}
+
+// managedWake wakes the mirror loop.
+func (mm *metadataMirror) managedWake() {
+	select {
+	case mm.wakeChan <- struct{}{}:
+	default:
+	}
+}
+
+// metadataMirrorTweak returns the tweak of the registry entry which publishes
+// the piece with the provided index.
+func metadataMirrorTweak(index uint64) crypto.Hash {
+	return crypto.HashAll(metadataMirrorRegistrySpecifier, index)
+}
+
+// metadataMirrorCipherKey returns the key used to encrypt the blob with the
+// provided sequence number.
+func metadataMirrorCipherKey(secret crypto.Hash, sequence uint64) crypto.CipherKey {
+	nonce := crypto.HashAll(secret, sequence)
+	key, err := crypto.NewSiaKey(crypto.TypeXChaCha20, append(secret[:], nonce[:24]...))
+	if err != nil {
+		panic("this should not be possible when deriving from a valid key")
+	}
+	return key
+}
+
+// encodeMetadataMirrorBlob encrypts a blob and erasure codes it into pieces.
+func encodeMetadataMirrorBlob(blob metadataMirrorBlob, secret crypto.Hash, ec modules.ErasureCoder) ([][]byte, uint64, error) {
+	plaintext := append(metadataMirrorBlobSpecifier[:], encoding.Marshal(blob)...)
+	if uint64(len(plaintext)) > uint64(ec.MinPieces())*modules.SectorSize {
+		return nil, 0, errors.New("blob is too large")
+	}
+	ciphertext := metadataMirrorCipherKey(secret, blob.Sequence).EncryptBytes(plaintext)
+	pieces, err := ec.Encode(ciphertext)
+	if err != nil {
+		return nil, 0, errors.AddContext(err, "failed to erasure code blob")
+	}
+	return pieces, uint64(len(ciphertext)), nil
+}
+
+// decodeMetadataMirrorBlob recovers a blob from its pieces and decrypts it.
+// Missing pieces are nil.
+func decodeMetadataMirrorBlob(pieces [][]byte, size, sequence uint64, secret crypto.Hash, ec modules.ErasureCoder) (metadataMirrorBlob, error) {
+	buf := bytes.NewBuffer(nil)
+	if err := ec.Recover(pieces, size, buf); err != nil {
+		return metadataMirrorBlob{}, errors.AddContext(err, "failed to recover blob")
+	}
+	plaintext, err := metadataMirrorCipherKey(secret, sequence).DecryptBytes(buf.Bytes())
+	if err != nil {
+		return metadataMirrorBlob{}, errors.AddContext(err, "failed to decrypt blob")
+	}
+	if len(plaintext) < types.SpecifierLen || !bytes.Equal(plaintext[:types.SpecifierLen], metadataMirrorBlobSpecifier[:]) {
+		return metadataMirrorBlob{}, errors.New("blob has an invalid specifier")
+	}
+	var blob metadataMirrorBlob
+	if err := encoding.Unmarshal(plaintext[types.SpecifierLen:], &blob); err != nil {
+		return metadataMirrorBlob{}, errors.AddContext(err, "failed to unmarshal blob")
+	}
+	if blob.Sequence != sequence {
+		return metadataMirrorBlob{}, fmt.Errorf("expected blob %v but got %v", sequence, blob.Sequence)
+	}
+	return blob, nil
+}
+
+// splitMetadataMirrorFiles splits the files into batches which fit into a
+// blob together with the overhead of the blob. Files which don't fit into a
+// blob on their own are returned separately.
+func splitMetadataMirrorFiles(files []metadataMirrorFile, overhead, maxSize uint64) (batches [][]metadataMirrorFile, tooLarge []metadataMirrorFile) {
+	var batch []metadataMirrorFile
+	size := overhead
+	for _, f := range files {
+		fileSize := uint64(len(encoding.Marshal(f)))
+		if overhead+fileSize > maxSize {
+			tooLarge = append(tooLarge, f)
+			continue
+		}
+		if size+fileSize > maxSize {
+			batches = append(batches, batch)
+			batch, size = nil, overhead
+		}
+		batch = append(batch, f)
+		size += fileSize
+	}
+	if len(batch) > 0 || len(batches) == 0 {
+		batches = append(batches, batch)
+	}
+	return batches, tooLarge
+}
+
+// siaFileMirrorHash hashes the parts of a siafile which change when it needs
+// to be mirrored again. Cached values like the health are ignored since they
+// change frequently.
+func siaFileMirrorHash(entry *filesystem.FileNode) (crypto.Hash, error) {
+	h := crypto.NewHash()
+	enc := encoding.NewEncoder(h)
+	if err := enc.EncodeAll(entry.Size(), entry.ModTime().UnixNano(), entry.ChangeTime().UnixNano()); err != nil {
+		return crypto.Hash{}, err
+	}
+	for i := uint64(0); i < entry.NumChunks(); i++ {
+		pieces, err := entry.Pieces(i)
+		if err != nil {
+			return crypto.Hash{}, err
+		}
+		if err := enc.Encode(pieces); err != nil {
+			return crypto.Hash{}, err
+		}
+	}
+	var hash crypto.Hash
+	copy(hash[:], h.Sum(nil))
+	return hash, nil
+}
+
+// managedMetadataMirrorKeys derives the secret used to encrypt the mirrored
+// metadata and the key pair of its registry entries from the seed.
+func (r *Renter) managedMetadataMirrorKeys() (crypto.Hash, crypto.SecretKey, types.SiaPublicKey, error) {
+	ws, _, err := r.w.PrimarySeed()
+	if err != nil {
+		return crypto.Hash{}, crypto.SecretKey{}, types.SiaPublicKey{}, errors.AddContext(err, "failed to get wallet's primary seed")
+	}
+	// Derive the renter seed and wipe the memory once we are done using it.
+	rs := modules.DeriveRenterSeed(ws)
+	defer fastrand.Read(rs[:])
+	secret := crypto.HashAll(rs, metadataMirrorKeySpecifier)
+	sk, pk := crypto.GenerateKeyPairDeterministic(crypto.HashAll(rs, metadataMirrorRegistrySpecifier))
+	return secret, sk, types.Ed25519PublicKey(pk), nil
+}
+
+// MetadataMirrorStatus returns the status of the mirroring of the renter's
+// metadata to its hosts.
+func (r *Renter) MetadataMirrorStatus() (modules.MetadataMirrorStatus, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.MetadataMirrorStatus{}, err
+	}
+	defer r.tg.Done()
+	id := r.mu.RLock()
+	settings := r.persist.MetadataMirror
+	r.mu.RUnlock(id)
+
+	mm := r.staticMetadataMirror
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	status := modules.MetadataMirrorStatus{
+		Settings:      settings,
+		LastMirror:    mm.lastMirror,
+		Sequence:      mm.previous.Sequence,
+		ChainLength:   mm.chainLength,
+		MirroredFiles: mm.mirroredFiles,
+	}
+	if mm.lastMirrorErr != nil {
+		status.LastMirrorError = mm.lastMirrorErr.Error()
+	}
+	return status, nil
+}
+
+// SetMetadataMirrorSettings enables or disables the mirroring of the renter's
+// metadata to its hosts.
+func (r *Renter) SetMetadataMirrorSettings(settings modules.MetadataMirrorSettings) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	// Persist the change.
+	id := r.mu.Lock()
+	r.persist.MetadataMirror = settings
+	err := r.saveSync()
+	r.mu.Unlock(id)
+	if err != nil {
+		return errors.AddContext(err, "failed to persist metadata mirror settings")
+	}
+
+	// Start over with a base blob.
+	r.staticMetadataMirror.managedReset()
+	r.staticMetadataMirror.managedWake()
+	return nil
+}
+
+// managedNextMetadataMirrorSequence reserves the next sequence number. The
+// sequence number is the revision of the registry entries and is persisted
+// before it is used to make sure it is never reused.
+func (r *Renter) managedNextMetadataMirrorSequence() (uint64, error) {
+	id := r.mu.Lock()
+	defer r.mu.Unlock(id)
+	r.persist.MetadataMirrorSequence++
+	return r.persist.MetadataMirrorSequence, r.saveSync()
+}
+
+// managedMirrorMetadata uploads the contract index and the siafiles which
+// changed since the last mirror to the hosts.
+func (r *Renter) managedMirrorMetadata() error {
+	secret, sk, spk, err := r.managedMetadataMirrorKeys()
+	if err != nil {
+		return err
+	}
+	defer fastrand.Read(secret[:])
+	defer fastrand.Read(sk[:])
+
+	// Build the contract index.
+	var contracts []metadataMirrorContract
+	for _, c := range r.hostContractor.Contracts() {
+		contracts = append(contracts, metadataMirrorContract{
+			ID:            c.ID,
+			HostPublicKey: c.HostPublicKey,
+			EndHeight:     c.EndHeight,
+		})
+	}
+	contractsHash := crypto.HashObject(contracts)
+
+	mm := r.staticMetadataMirror
+	mm.mu.Lock()
+	base := mm.mirrored == nil || mm.chainLength >= metadataMirrorRebaseInterval
+	previous := mm.previous
+	mirrored := mm.mirrored
+	unchangedContracts := mm.contractsHash == contractsHash
+	mm.mu.Unlock()
+
+	// Collect the siafiles which changed since the last mirror. Snapshots are
+	// skipped since they are backed up separately.
+	var files []metadataMirrorFile
+	hashes := make(map[modules.SiaPath]crypto.Hash)
+	err = r.managedWalkSiaFiles(func(siaPath modules.SiaPath) {
+		if _, err := siaPath.Rebase(modules.BackupFolder, modules.RootSiaPath()); err == nil {
+			return
+		}
+		f, h, changed, err := r.managedMetadataMirrorFile(siaPath, mirrored, base)
+		if err != nil {
+			r.log.Printf("WARN: failed to mirror siafile %v: %v", siaPath, err)
+			return
+		}
+		hashes[siaPath] = h
+		if changed {
+			files = append(files, f)
+		}
+	})
+	if err != nil {
+		return errors.AddContext(err, "failed to walk siafiles")
+	}
+
+	// Nothing to do if nothing changed.
+	if !base && len(files) == 0 && unchangedContracts {
+		mm.mu.Lock()
+		mm.lastMirror = time.Now()
+		mm.mirroredFiles = 0
+		mm.mu.Unlock()
+		return nil
+	}
+
+	// Split the files into blobs.
+	ec, err := modules.NewRSCode(metadataMirrorDataPieces, metadataMirrorParityPieces)
+	if err != nil {
+		return err
+	}
+	overhead := uint64(types.SpecifierLen + len(encoding.Marshal(metadataMirrorBlob{
+		Contracts: contracts,
+		Previous: metadataMirrorPointer{
+			Pieces: make([]metadataMirrorPiece, ec.NumPieces()),
+		},
+	})))
+	batches, tooLarge := splitMetadataMirrorFiles(files, overhead, uint64(ec.MinPieces())*modules.SectorSize)
+	for _, f := range tooLarge {
+		r.log.Printf("WARN: siafile %v is too large to be mirrored", f.SiaPath)
+		delete(hashes, f.SiaPath)
+	}
+
+	// Upload the blobs.
+	var mirroredFiles uint64
+	for i, batch := range batches {
+		sequence, err := r.managedNextMetadataMirrorSequence()
+		if err != nil {
+			return errors.AddContext(err, "failed to persist sequence number")
+		}
+		blob := metadataMirrorBlob{
+			Sequence:     sequence,
+			Base:         base && i == 0,
+			CreationDate: types.CurrentTimestamp(),
+			Contracts:    contracts,
+			Files:        batch,
+		}
+		if !blob.Base {
+			blob.Previous = previous
+		}
+		pointer, err := r.managedUploadMetadataMirrorBlob(blob, secret, sk, spk, ec)
+		if err != nil {
+			return errors.AddContext(err, "failed to upload blob")
+		}
+		previous = pointer
+		mirroredFiles += uint64(len(batch))
+
+		// Update the state after every blob to make sure the chain continues
+		// from the last uploaded blob if a later blob fails.
+		mm.mu.Lock()
+		if blob.Base {
+			mm.chainLength = 0
+			mm.mirrored = make(map[modules.SiaPath]crypto.Hash)
+		}
+		mm.chainLength++
+		mm.previous = pointer
+		for _, f := range batch {
+			mm.mirrored[f.SiaPath] = hashes[f.SiaPath]
+		}
+		mm.mu.Unlock()
+	}
+
+	mm.mu.Lock()
+	mm.contractsHash = contractsHash
+	mm.lastMirror = time.Now()
+	mm.mirroredFiles = mirroredFiles
+	mm.mu.Unlock()
+	return nil
+}
+
+// managedMetadataMirrorFile reads the siafile at the provided path if it
+// changed since it was mirrored last.
+func (r *Renter) managedMetadataMirrorFile(siaPath modules.SiaPath, mirrored map[modules.SiaPath]crypto.Hash, all bool) (_ metadataMirrorFile, _ crypto.Hash, changed bool, err error) {
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return metadataMirrorFile{}, crypto.Hash{}, false, err
+	}
+	defer func() {
+		err = errors.Compose(err, entry.Close())
+	}()
+	h, err := siaFileMirrorHash(entry)
+	if err != nil {
+		return metadataMirrorFile{}, crypto.Hash{}, false, errors.AddContext(err, "failed to hash siafile")
+	}
+	if prev, exists := mirrored[siaPath]; !all && exists && prev == h {
+		return metadataMirrorFile{}, h, false, nil
+	}
+	sr, err := entry.SnapshotReader()
+	if err != nil {
+		return metadataMirrorFile{}, crypto.Hash{}, false, errors.AddContext(err, "failed to get snapshot reader")
+	}
+	buf := bytes.NewBuffer(nil)
+	gzw := gzip.NewWriter(buf)
+	_, err = io.Copy(gzw, sr)
+	err = errors.Compose(err, gzw.Close(), sr.Close())
+	if err != nil {
+		return metadataMirrorFile{}, crypto.Hash{}, false, errors.AddContext(err, "failed to read siafile")
+	}
+	return metadataMirrorFile{SiaPath: siaPath, SiaFile: buf.Bytes()}, h, true, nil
+}
+
+// readMetadataMirrorFile decompresses a mirrored siafile.
+func readMetadataMirrorFile(f metadataMirrorFile) (_ []byte, err error) {
+	gzr, err := gzip.NewReader(bytes.NewReader(f.SiaFile))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = errors.Compose(err, gzr.Close())
+	}()
+	return ioutil.ReadAll(gzr)
+}
+
+// managedUploadMetadataMirrorBlob uploads the pieces of a blob to different
+// hosts and publishes their locations in the registry.
+func (r *Renter) managedUploadMetadataMirrorBlob(blob metadataMirrorBlob, secret crypto.Hash, sk crypto.SecretKey, spk types.SiaPublicKey, ec modules.ErasureCoder) (metadataMirrorPointer, error) {
+	shards, size, err := encodeMetadataMirrorBlob(blob, secret, ec)
+	if err != nil {
+		return metadataMirrorPointer{}, err
+	}
+
+	// Collect the hosts which are good for upload in random order. The
+	// registry entries can only store ed25519 keys.
+	contracts := r.hostContractor.Contracts()
+	hostChan := make(chan types.SiaPublicKey, len(contracts))
+	for _, i := range fastrand.Perm(len(contracts)) {
+		c := contracts[i]
+		if !c.Utility.GoodForUpload || c.HostPublicKey.Algorithm != types.SignatureEd25519 || len(c.HostPublicKey.Key) != crypto.PublicKeySize {
+			continue
+		}
+		hostChan <- c.HostPublicKey
+	}
+	close(hostChan)
+
+	// Upload every piece to a different host. If an upload fails, the piece
+	// is uploaded to the next host.
+	pieces := make([]*metadataMirrorPiece, len(shards))
+	var wg sync.WaitGroup
+	for i := range shards {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for hpk := range hostChan {
+				root, err := r.managedUploadMetadataMirrorPiece(hpk, shards[i])
+				if err != nil {
+					r.log.Debugf("failed to upload metadata mirror piece to host %v: %v", hpk, err)
+					continue
+				}
+				pieces[i] = &metadataMirrorPiece{
+					Index:         uint64(i),
+					HostPublicKey: hpk,
+					Root:          root,
+				}
+				return
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	pointer := metadataMirrorPointer{
+		Sequence:  blob.Sequence,
+		Size:      size,
+		MinPieces: uint64(ec.MinPieces()),
+		NumPieces: uint64(ec.NumPieces()),
+	}
+	for _, piece := range pieces {
+		if piece != nil {
+			pointer.Pieces = append(pointer.Pieces, *piece)
+		}
+	}
+	if len(pointer.Pieces) < ec.MinPieces() {
+		return metadataMirrorPointer{}, fmt.Errorf("only uploaded %v of %v pieces", len(pointer.Pieces), ec.NumPieces())
+	}
+
+	// Publish the locations of the pieces.
+	var published int
+	for _, piece := range pointer.Pieces {
+		entry := metadataMirrorEntry{
+			Sequence:  pointer.Sequence,
+			Size:      pointer.Size,
+			MinPieces: pointer.MinPieces,
+			NumPieces: pointer.NumPieces,
+			Index:     piece.Index,
+			Root:      piece.Root,
+		}
+		copy(entry.HostKey[:], piece.HostPublicKey.Key)
+		rv := modules.NewRegistryValue(metadataMirrorTweak(piece.Index), encoding.Marshal(entry), pointer.Sequence, modules.RegistryTypeWithoutPubkey)
+		err := r.UpdateRegistry(spk, rv.Sign(sk), metadataMirrorRegistryTimeout)
+		if err != nil {
+			r.log.Printf("WARN: failed to publish metadata mirror piece %v: %v", piece.Index, err)
+			continue
+		}
+		published++
+	}
+	if published < ec.MinPieces() {
+		return metadataMirrorPointer{}, fmt.Errorf("only published %v of %v pieces", published, len(pointer.Pieces))
+	}
+	return pointer, nil
+}
+
+// managedUploadMetadataMirrorPiece uploads a piece to a host and returns the
+// root of its sector.
+func (r *Renter) managedUploadMetadataMirrorPiece(hpk types.SiaPublicKey, piece []byte) (_ crypto.Hash, err error) {
+	session, err := r.hostContractor.Session(hpk, r.tg.StopChan())
+	if err != nil {
+		return crypto.Hash{}, errors.AddContext(err, "failed to open session")
+	}
+	defer func() {
+		err = errors.Compose(err, session.Close())
+	}()
+	err = checkUploadSnapshotGouging(r.hostContractor.Allowance(), session.HostSettings())
+	if err != nil {
+		return crypto.Hash{}, errors.AddContext(err, "upload blocked because potential price gouging was detected")
+	}
+	sector := make([]byte, modules.SectorSize)
+	copy(sector, piece)
+	return session.Upload(sector)
+}
+
+// managedMetadataMirrorHead looks up the most recently mirrored blob which
+// can be recovered in the registry.
+func (r *Renter) managedMetadataMirrorHead(spk types.SiaPublicKey) (metadataMirrorPointer, error) {
+	pointers := make(map[uint64]*metadataMirrorPointer)
+	for i := uint64(0); i < uint64(metadataMirrorDataPieces+metadataMirrorParityPieces); i++ {
+		srv, err := r.ReadRegistry(spk, metadataMirrorTweak(i), metadataMirrorRegistryTimeout)
+		if errors.Contains(err, ErrRegistryEntryNotFound) {
+			continue
+		} else if err != nil {
+			r.log.Printf("WARN: failed to look up metadata mirror piece %v: %v", i, err)
+			continue
+		}
+		var entry metadataMirrorEntry
+		if err := encoding.Unmarshal(srv.Data, &entry); err != nil || entry.Sequence != srv.Revision || entry.Index != i {
+			r.log.Printf("WARN: invalid metadata mirror registry entry %v: %v", i, err)
+			continue
+		}
+		pointer, exists := pointers[entry.Sequence]
+		if !exists {
+			pointer = &metadataMirrorPointer{
+				Sequence:  entry.Sequence,
+				Size:      entry.Size,
+				MinPieces: entry.MinPieces,
+				NumPieces: entry.NumPieces,
+			}
+			pointers[entry.Sequence] = pointer
+		}
+		pointer.Pieces = append(pointer.Pieces, metadataMirrorPiece{
+			Index:         entry.Index,
+			HostPublicKey: types.Ed25519PublicKey(crypto.PublicKey(entry.HostKey)),
+			Root:          entry.Root,
+		})
+	}
+	// Pick the most recent blob with enough pieces.
+	var head *metadataMirrorPointer
+	for _, pointer := range pointers {
+		if uint64(len(pointer.Pieces)) < pointer.MinPieces {
+			continue
+		}
+		if head == nil || pointer.Sequence > head.Sequence {
+			head = pointer
+		}
+	}
+	if head == nil {
+		return metadataMirrorPointer{}, errMetadataMirrorNotFound
+	}
+	return *head, nil
+}
+
+// managedDownloadMetadataMirrorBlob downloads the pieces of a blob from its
+// hosts and decodes it.
+func (r *Renter) managedDownloadMetadataMirrorBlob(pointer metadataMirrorPointer, secret crypto.Hash) (metadataMirrorBlob, error) {
+	if pointer.MinPieces == 0 || pointer.NumPieces < pointer.MinPieces {
+		return metadataMirrorBlob{}, errors.New("invalid erasure coding parameters")
+	}
+	ec, err := modules.NewRSCode(int(pointer.MinPieces), int(pointer.NumPieces-pointer.MinPieces))
+	if err != nil {
+		return metadataMirrorBlob{}, err
+	}
+	pieceSize := (pointer.Size + pointer.MinPieces - 1) / pointer.MinPieces
+	if pieceSize > modules.SectorSize {
+		return metadataMirrorBlob{}, errors.New("invalid blob size")
+	}
+	shards := make([][]byte, pointer.NumPieces)
+	var downloaded uint64
+	for _, piece := range pointer.Pieces {
+		if downloaded == pointer.MinPieces {
+			break
+		}
+		if piece.Index >= pointer.NumPieces || shards[piece.Index] != nil {
+			continue
+		}
+		data, err := r.managedDownloadMetadataMirrorPiece(piece, pieceSize)
+		if err != nil {
+			r.log.Printf("WARN: failed to download metadata mirror piece from host %v: %v", piece.HostPublicKey, err)
+			continue
+		}
+		shards[piece.Index] = data
+		downloaded++
+	}
+	if downloaded < pointer.MinPieces {
+		return metadataMirrorBlob{}, fmt.Errorf("only downloaded %v of %v required pieces", downloaded, pointer.MinPieces)
+	}
+	return decodeMetadataMirrorBlob(shards, pointer.Size, pointer.Sequence, secret, ec)
+}
+
+// managedDownloadMetadataMirrorPiece downloads a piece from the host storing
+// it. The Merkle root of the data is verified by the session.
+func (r *Renter) managedDownloadMetadataMirrorPiece(piece metadataMirrorPiece, pieceSize uint64) (_ []byte, err error) {
+	session, err := r.hostContractor.Session(piece.HostPublicKey, r.tg.StopChan())
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to open session")
+	}
+	defer func() {
+		err = errors.Compose(err, session.Close())
+	}()
+	// Merkle proofs require the length to be a multiple of the segment size.
+	length := pieceSize
+	if mod := length % crypto.SegmentSize; mod != 0 {
+		length += crypto.SegmentSize - mod
+	}
+	data, err := session.Download(piece.Root, 0, uint32(length))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(data)) != length {
+		return nil, fmt.Errorf("expected %v bytes but got %v", length, len(data))
+	}
+	return data[:pieceSize], nil
+}
+
+// RecoverMetadataMirror recovers the renter's siafiles from the metadata
+// mirrored to its hosts. Siafiles which already exist are skipped.
+func (r *Renter) RecoverMetadataMirror() (_ modules.MetadataMirrorRecovery, err error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.MetadataMirrorRecovery{}, err
+	}
+	defer r.tg.Done()
+	secret, sk, spk, err := r.managedMetadataMirrorKeys()
+	if err != nil {
+		return modules.MetadataMirrorRecovery{}, err
+	}
+	defer fastrand.Read(secret[:])
+	defer fastrand.Read(sk[:])
+
+	pointer, err := r.managedMetadataMirrorHead(spk)
+	if err != nil {
+		return modules.MetadataMirrorRecovery{}, err
+	}
+
+	dirsToUpdate := r.newUniqueRefreshPaths()
+	defer func() {
+		err = errors.Compose(err, dirsToUpdate.callRefreshAll())
+	}()
+
+	// Walk the chain of blobs back to the last base blob. The most recent
+	// version of a siafile is recovered.
+	recovery := modules.MetadataMirrorRecovery{Sequence: pointer.Sequence}
+	seen := make(map[modules.SiaPath]struct{})
+	for i := uint64(0); ; i++ {
+		if i > 2*metadataMirrorRebaseInterval {
+			return modules.MetadataMirrorRecovery{}, errors.New("chain of mirrored blobs is too long")
+		}
+		blob, err := r.managedDownloadMetadataMirrorBlob(pointer, secret)
+		if err != nil {
+			return modules.MetadataMirrorRecovery{}, errors.AddContext(err, fmt.Sprintf("failed to download blob %v", pointer.Sequence))
+		}
+		if i == 0 {
+			recovery.Contracts = uint64(len(blob.Contracts))
+			recovery.MissingContracts = r.managedMissingMirroredContracts(blob.Contracts)
+		}
+		for _, f := range blob.Files {
+			if _, exists := seen[f.SiaPath]; exists {
+				continue
+			}
+			seen[f.SiaPath] = struct{}{}
+			exists, err := r.staticFileSystem.FileExists(f.SiaPath)
+			if err != nil {
+				return modules.MetadataMirrorRecovery{}, errors.AddContext(err, "failed to check if siafile exists")
+			}
+			if exists {
+				recovery.SkippedFiles++
+				continue
+			}
+			b, err := readMetadataMirrorFile(f)
+			if err != nil {
+				return modules.MetadataMirrorRecovery{}, errors.AddContext(err, "failed to decompress siafile")
+			}
+			if err := r.staticFileSystem.AddSiaFileFromReader(bytes.NewReader(b), f.SiaPath); err != nil {
+				return modules.MetadataMirrorRecovery{}, errors.AddContext(err, "failed to recover siafile")
+			}
+			if err := dirsToUpdate.callAdd(f.SiaPath); err != nil {
+				return modules.MetadataMirrorRecovery{}, errors.AddContext(err, "failed to queue directory for update")
+			}
+			recovery.RecoveredFiles++
+		}
+		if blob.Base || len(blob.Previous.Pieces) == 0 {
+			break
+		}
+		pointer = blob.Previous
+	}
+	return recovery, nil
+}
+
+// managedMissingMirroredContracts returns the contracts of the mirrored index
+// which the renter doesn't know about.
+func (r *Renter) managedMissingMirroredContracts(contracts []metadataMirrorContract) []types.FileContractID {
+	known := make(map[types.FileContractID]struct{})
+	for _, c := range r.hostContractor.Contracts() {
+		known[c.ID] = struct{}{}
+	}
+	for _, c := range r.hostContractor.OldContracts() {
+		known[c.ID] = struct{}{}
+	}
+	var missing []types.FileContractID
+	for _, c := range contracts {
+		if _, exists := known[c.ID]; !exists {
+			missing = append(missing, c.ID)
+		}
+	}
+	return missing
+}
+
+// threadedMirrorMetadata periodically mirrors the renter's metadata to its
+// hosts while the mirror is enabled.
+func (r *Renter) threadedMirrorMetadata() {
+	defer modules.RecoverPanic("renter")
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	mm := r.staticMetadataMirror
+	for {
+		id := r.mu.RLock()
+		settings := r.persist.MetadataMirror
+		r.mu.RUnlock(id)
+
+		// Can't mirror the metadata if the wallet is locked.
+		unlocked, _ := r.w.Unlocked()
+		if settings.Enabled && unlocked {
+			err := r.managedMirrorMetadata()
+			if errors.Contains(err, errSiaFileWalkInterrupted) {
+				return
+			}
+			if err != nil {
+				r.log.Println("WARN: failed to mirror metadata:", err)
+			}
+			mm.mu.Lock()
+			mm.lastMirrorErr = err
+			mm.mu.Unlock()
+		}
+
+		interval := settings.Interval
+		if interval == 0 {
+			interval = defaultMetadataMirrorInterval
+		}
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-mm.wakeChan:
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package renter
+
+import (
+	"bytes"
+	"testing"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestMetadataMirrorBlobEncoding checks that a blob can be recovered from the
+// minimum number of pieces and only with the right secret.
+func TestMetadataMirrorBlobEncoding(t *testing.T) {
+	t.Parallel()
+	ec, err := modules.NewRSCode(2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var secret crypto.Hash
+	fastrand.Read(secret[:])
+	blob := metadataMirrorBlob{
+		Sequence: 5,
+		Base:     true,
+		Files: []metadataMirrorFile{
+			{SiaPath: modules.RandomSiaPath(), SiaFile: fastrand.Bytes(1000)},
+			{SiaPath: modules.RandomSiaPath(), SiaFile: fastrand.Bytes(100)},
+		},
+	}
+	pieces, size, err := encodeMetadataMirrorBlob(blob, secret, ec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pieces) != ec.NumPieces() {
+		t.Fatalf("expected %v pieces but got %v", ec.NumPieces(), len(pieces))
+	}
+
+	// Drop all but the minimum number of pieces.
+	pieces[0], pieces[2], pieces[3] = nil, nil, nil
+	decoded, err := decodeMetadataMirrorBlob(pieces, size, blob.Sequence, secret, ec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(encoding.Marshal(decoded), encoding.Marshal(blob)) {
+		t.Fatal("decoded blob doesn't match")
+	}
+
+	// Decoding with the wrong secret or sequence number fails.
+	pieces, size, err = encodeMetadataMirrorBlob(blob, secret, ec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wrongSecret crypto.Hash
+	fastrand.Read(wrongSecret[:])
+	if _, err := decodeMetadataMirrorBlob(pieces, size, blob.Sequence, wrongSecret, ec); err == nil {
+		t.Fatal("decoding with the wrong secret should fail")
+	}
+	if _, err := decodeMetadataMirrorBlob(pieces, size, blob.Sequence+1, secret, ec); err == nil {
+		t.Fatal("decoding with the wrong sequence number should fail")
+	}
+
+	// Blobs which don't fit into the pieces are rejected.
+	blob.Files = []metadataMirrorFile{{SiaFile: make([]byte, 2*modules.SectorSize)}}
+	if _, _, err := encodeMetadataMirrorBlob(blob, secret, ec); err == nil {
+		t.Fatal("encoding a blob which is too large should fail")
+	}
+}
+
+// TestSplitMetadataMirrorFiles is a unit test for splitMetadataMirrorFiles.
+func TestSplitMetadataMirrorFiles(t *testing.T) {
+	t.Parallel()
+	file := func(size int) metadataMirrorFile {
+		return metadataMirrorFile{SiaPath: modules.RandomSiaPath(), SiaFile: make([]byte, size)}
+	}
+	size := func(f metadataMirrorFile) uint64 {
+		return uint64(len(encoding.Marshal(f)))
+	}
+
+	// Without files there is a single empty batch.
+	batches, tooLarge := splitMetadataMirrorFiles(nil, 100, 1000)
+	if len(batches) != 1 || len(batches[0]) != 0 || len(tooLarge) != 0 {
+		t.Fatal("expected a single empty batch", batches, tooLarge)
+	}
+
+	// Files are split into batches which fit together with the overhead.
+	files := []metadataMirrorFile{file(300), file(300), file(300), file(2000), file(100)}
+	overhead := uint64(100)
+	maxSize := overhead + size(files[0]) + size(files[1])
+	batches, tooLarge = splitMetadataMirrorFiles(files, overhead, maxSize)
+	if len(batches) != 2 || len(batches[0]) != 2 || len(batches[1]) != 2 {
+		t.Fatal("unexpected batches", len(batches))
+	}
+	if len(tooLarge) != 1 || tooLarge[0].SiaPath != files[3].SiaPath {
+		t.Fatal("expected the large file to be returned", tooLarge)
+	}
+	for _, batch := range batches {
+		batchSize := overhead
+		for _, f := range batch {
+			batchSize += size(f)
+		}
+		if batchSize > maxSize {
+			t.Fatalf("batch of size %v exceeds max size %v", batchSize, maxSize)
+		}
+	}
+}
+
+// TestMetadataMirrorEntrySize checks that the registry entry of a piece fits
+// into the registry.
+func TestMetadataMirrorEntrySize(t *testing.T) {
+	t.Parallel()
+	entry := metadataMirrorEntry{
+		Sequence:  fastrand.Uint64n(1000),
+		Size:      modules.SectorSize,
+		MinPieces: 2,
+		NumPieces: 6,
+		Index:     5,
+	}
+	fastrand.Read(entry.HostKey[:])
+	fastrand.Read(entry.Root[:])
+	data := encoding.Marshal(entry)
+	if len(data) > modules.RegistryDataSize {
+		t.Fatalf("entry of size %v doesn't fit into the registry", len(data))
+	}
+	var decoded metadataMirrorEntry
+	if err := encoding.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded != entry {
+		t.Fatal("decoded entry doesn't match")
+	}
+}
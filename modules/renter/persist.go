@@ -1,6 +1,7 @@
 package renter
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 
@@ -22,7 +23,9 @@ const (
 	PersistFilename = "renter.json"
 	// SiaDirMetadata is the name of the metadata file for the sia directory
 	SiaDirMetadata = ".siadir"
-	// walFile is the filename of the renter's writeaheadlog's file.
+	// walFile is the filename of the renter's first writeaheadlog's file.
+	// Before the SiaFile updates were sharded across multiple WALs it was the
+	// renter's only WAL.
 	walFile = modules.RenterDir + ".wal"
 )
 
@@ -60,6 +63,42 @@ type (
 		MaxUploadSpeed   int64
 		UploadedBackups  []modules.UploadedBackup
 		SyncedContracts  []types.FileContractID
+
+		// ReadOnlyManual indicates whether read-only mode was enabled by the
+		// user and ReadOnlySettings contains the thresholds for entering
+		// read-only mode automatically.
+		ReadOnlyManual   bool
+		ReadOnlySettings modules.ReadOnlySettings
+
+		// HostBandwidthLimits are the bandwidth limits of individual hosts.
+		HostBandwidthLimits []modules.RenterHostBandwidthLimits
+
+		// Replication contains the role of the renter in the replication of
+		// its metadata.
+		Replication modules.ReplicationSettings
+
+		// VerifyUploads indicates whether the renter verifies fully
+		// redundant files against their local copy.
+		VerifyUploads bool
+
+		// MaxFileVersions is the number of previous versions of a file that
+		// are retained when the file is overwritten.
+		MaxFileVersions uint64
+
+		// MetadataMirror configures the mirroring of the renter's metadata to
+		// its hosts and MetadataMirrorSequence is the sequence number of the
+		// most recently mirrored blob.
+		MetadataMirror         modules.MetadataMirrorSettings
+		MetadataMirrorSequence uint64
+
+		// PinnedBytesBudget is the max number of bytes kept in memory for the
+		// PinnedRanges of files.
+		PinnedBytesBudget uint64
+		PinnedRanges      []modules.RenterPinnedRange
+
+		// SchedulerSettings are the jobs which are restricted to the windows
+		// of the renter's scheduler.
+		SchedulerSettings modules.RenterSchedulerSettings
 	}
 )
 
@@ -70,7 +109,9 @@ func (r *Renter) saveSync() error {
 
 // managedLoadSettings fetches the saved renter data from disk.
 func (r *Renter) managedLoadSettings() error {
-	r.persist = persistence{}
+	r.persist = persistence{
+		PinnedBytesBudget: defaultPinnedBytesBudget,
+	}
 	err := persist.LoadJSON(settingsMetadata, &r.persist, filepath.Join(r.persistDir, PersistFilename))
 	if os.IsNotExist(err) {
 		// No persistence yet, set the defaults and continue.
@@ -108,11 +149,140 @@ func (r *Renter) managedLoadSettings() error {
 		return err
 	}
 
+	// Load the read-only thresholds. The read-only mode itself is applied by
+	// the thread monitoring the renter's funds.
+	r.staticReadOnlyMode.managedSetSettings(r.persist.ReadOnlySettings)
+
+	// Apply the bandwidth limits of individual hosts.
+	for _, limits := range r.persist.HostBandwidthLimits {
+		r.managedApplyHostBandwidthLimits(limits)
+	}
+
+	// Apply the settings of the scheduler.
+	r.staticScheduler.managedSetSettings(r.persist.SchedulerSettings)
+
+	// Load the pinned ranges. Their data is loaded in the background.
+	r.staticPinnedCache.callLoadPersisted(r.persist.PinnedBytesBudget, r.persist.PinnedRanges)
+
 	// Set the bandwidth limits on the contractor, which was already initialized
 	// without bandwidth limits.
 	return r.setBandwidthLimits(r.persist.MaxDownloadSpeed, r.persist.MaxUploadSpeed)
 }
 
+// walShardFile returns the filename of the renter's i-th writeaheadlog. The
+// first WAL keeps the filename of the renter's original single WAL which makes
+// sure that its unapplied transactions are recovered after upgrading.
+func walShardFile(i int) string {
+	if i == 0 {
+		return walFile
+	}
+	return fmt.Sprintf("%v.%d.wal", modules.RenterDir, i)
+}
+
+// managedApplyWALTxns applies the unapplied transactions of a writeaheadlog
+// and signals them as applied. Transactions which contain updates other than
+// SiaFile and filesystem updates are not applied.
+func (r *Renter) managedApplyWALTxns(txns []*writeaheadlog.Transaction) (allApplied bool, _ error) {
+	allApplied = true
+	for _, txn := range txns {
+		applyTxn := true
+		r.log.Println("applying transaction with", len(txn.Updates), "updates")
+		for _, update := range txn.Updates {
+			if siafile.IsSiaFileUpdate(update) {
+				r.log.Println("Applying a siafile update:", update.Name)
+				if err := siafile.ApplyUpdates(update); err != nil {
+					return false, errors.AddContext(err, "failed to apply SiaFile update")
+				}
+			} else if filesystem.IsFilesystemUpdate(update) {
+				r.log.Println("Applying a filesystem update:", update.Name)
+				if err := filesystem.ApplyUpdates(update); err != nil {
+					return false, errors.AddContext(err, "failed to apply filesystem update")
+				}
+			} else {
+				r.log.Println("wal update not applied, marking transaction as not applied")
+				applyTxn = false
+			}
+		}
+		if !applyTxn {
+			allApplied = false
+			continue
+		}
+		if err := txn.SignalUpdatesApplied(); err != nil {
+			return false, err
+		}
+	}
+	return allApplied, nil
+}
+
+// managedInitWALs opens the writeaheadlogs the SiaFile updates are sharded
+// across and applies their unapplied transactions before the persistence
+// structures are loaded to avoid loading potentially corrupted files. WALs of
+// a previous run which used more shards are recovered and removed afterwards.
+func (r *Renter) managedInitWALs() (*filesystem.WALPool, error) {
+	wals := make([]*writeaheadlog.WAL, 0, numSiaFileWALs)
+	for i := 0; i < numSiaFileWALs; i++ {
+		options := writeaheadlog.Options{
+			StaticLog: r.log.Logger,
+			Path:      filepath.Join(r.persistDir, walShardFile(i)),
+		}
+		txns, wal, err := writeaheadlog.NewWithOptions(options)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.tg.AfterStop(wal.Close); err != nil {
+			return nil, errors.Compose(err, wal.Close())
+		}
+		if len(txns) > 0 {
+			r.log.Println("Wal", i, "initialized", len(txns), "transactions to apply")
+		}
+		if _, err := r.managedApplyWALTxns(txns); err != nil {
+			return nil, err
+		}
+		wals = append(wals, wal)
+	}
+
+	// Recover the WALs which are no longer part of the pool.
+	matches, err := filepath.Glob(filepath.Join(r.persistDir, modules.RenterDir+".*.wal"))
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to find retired writeaheadlogs")
+	}
+	for _, path := range matches {
+		var i int
+		_, err := fmt.Sscanf(filepath.Base(path), modules.RenterDir+".%d.wal", &i)
+		if err != nil || i < numSiaFileWALs {
+			continue
+		}
+		if err := r.managedRetireWAL(path); err != nil {
+			return nil, errors.AddContext(err, "failed to retire writeaheadlog "+path)
+		}
+	}
+	return filesystem.NewWALPool(wals...), nil
+}
+
+// managedRetireWAL applies the unapplied transactions of a writeaheadlog which
+// is no longer part of the pool and removes it. If it contains transactions
+// which can't be applied it is kept.
+func (r *Renter) managedRetireWAL(path string) error {
+	options := writeaheadlog.Options{
+		StaticLog: r.log.Logger,
+		Path:      path,
+	}
+	txns, wal, err := writeaheadlog.NewWithOptions(options)
+	if err != nil {
+		return err
+	}
+	allApplied, err := r.managedApplyWALTxns(txns)
+	if err := errors.Compose(err, wal.Close()); err != nil {
+		return err
+	}
+	if !allApplied {
+		r.log.Println("WARN: retired wal contains transactions which can't be applied:", path)
+		return nil
+	}
+	r.log.Println("Removing retired wal:", path)
+	return os.Remove(path)
+}
+
 // managedInitPersist handles all of the persistence initialization, such as creating
 // the persistence directory and starting the logger.
 func (r *Renter) managedInitPersist() error {
@@ -129,60 +299,30 @@ func (r *Renter) managedInitPersist() error {
 		return err
 	}
 
-	// Initialize the writeaheadlog.
-	options := writeaheadlog.Options{
-		StaticLog: r.log.Logger,
-		Path:      filepath.Join(r.persistDir, walFile),
-	}
-	txns, wal, err := writeaheadlog.NewWithOptions(options)
+	// Initialize the writeaheadlogs.
+	wals, err := r.managedInitWALs()
 	if err != nil {
-		return err
-	}
-	if err := r.tg.AfterStop(wal.Close); err != nil {
-		return err
-	}
-
-	// Apply unapplied wal txns before loading the persistence structure to
-	// avoid loading potentially corrupted files.
-	if len(txns) > 0 {
-		r.log.Println("Wal initialized", len(txns), "transactions to apply")
-	}
-	for _, txn := range txns {
-		applyTxn := true
-		r.log.Println("applying transaction with", len(txn.Updates), "updates")
-		for _, update := range txn.Updates {
-			if siafile.IsSiaFileUpdate(update) {
-				r.log.Println("Applying a siafile update:", update.Name)
-				if err := siafile.ApplyUpdates(update); err != nil {
-					return errors.AddContext(err, "failed to apply SiaFile update")
-				}
-			} else {
-				r.log.Println("wal update not applied, marking transaction as not applied")
-				applyTxn = false
-			}
-		}
-		if applyTxn {
-			if err := txn.SignalUpdatesApplied(); err != nil {
-				return err
-			}
-		}
+		return errors.AddContext(err, "failed to initialize writeaheadlogs")
 	}
 
 	// Create the filesystem.
-	fs, err := filesystem.New(fsRoot, r.log, wal)
+	fs, err := filesystem.New(fsRoot, r.log, wals)
 	if err != nil {
 		return err
 	}
 
-	// Initialize the wal, staticFileSet and the staticDirSet. With the
+	// Initialize the wals, staticFileSet and the staticDirSet. With the
 	// staticDirSet finish the initialization of the files directory
-	r.wal = wal
+	r.staticWALs = wals
 	r.staticFileSystem = fs
 
 	// Load the prior persistence structures.
 	if err := r.managedLoadSettings(); err != nil {
 		return errors.AddContext(err, "failed to load renter's persistence structrue")
 	}
+	if err := r.managedLoadFileSpending(); err != nil {
+		return err
+	}
 
 	// Create the essential dirs in the filesystem.
 	err = fs.NewSiaDir(modules.HomeFolder, modules.DefaultDirPerm)
@@ -3,12 +3,14 @@ package renter
 import (
 	"bytes"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"gitlab.com/NebulousLabs/fastrand"
 	"gitlab.com/NebulousLabs/ratelimit"
+	"gitlab.com/NebulousLabs/writeaheadlog"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/modules/renter/filesystem/siafile"
@@ -204,7 +206,6 @@ func TestRenterPaths(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	wal := rt.renter.wal
 	rc, err := modules.NewRSSubCode(1, 1, crypto.SegmentSize)
 	if err != nil {
 		t.Fatal(err)
@@ -212,15 +213,18 @@ func TestRenterPaths(t *testing.T) {
 	sk := crypto.GenerateSiaKey(crypto.TypeThreefish)
 	fileSize := uint64(modules.SectorSize)
 	fileMode := os.FileMode(0600)
-	f1, err := siafile.New(siaPath1.SiaFileSysPath(rt.renter.staticFileSystem.Root()), "", wal, rc, sk, fileSize, fileMode, nil, true)
+	path1 := siaPath1.SiaFileSysPath(rt.renter.staticFileSystem.Root())
+	f1, err := siafile.New(path1, "", rt.renter.staticWALs.WAL(path1), rc, sk, fileSize, fileMode, nil, true)
 	if err != nil {
 		t.Fatal(err)
 	}
-	f2, err := siafile.New(siaPath2.SiaFileSysPath(rt.renter.staticFileSystem.Root()), "", wal, rc, sk, fileSize, fileMode, nil, true)
+	path2 := siaPath2.SiaFileSysPath(rt.renter.staticFileSystem.Root())
+	f2, err := siafile.New(path2, "", rt.renter.staticWALs.WAL(path2), rc, sk, fileSize, fileMode, nil, true)
 	if err != nil {
 		t.Fatal(err)
 	}
-	f3, err := siafile.New(siaPath3.SiaFileSysPath(rt.renter.staticFileSystem.Root()), "", wal, rc, sk, fileSize, fileMode, nil, true)
+	path3 := siaPath3.SiaFileSysPath(rt.renter.staticFileSystem.Root())
+	f3, err := siafile.New(path3, "", rt.renter.staticWALs.WAL(path3), rc, sk, fileSize, fileMode, nil, true)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -324,3 +328,74 @@ func TestSiafileCompatibility(t *testing.T) {
 		t.Fatal("invalid number of chunks in siafile:", sf.NumChunks())
 	}
 }
+
+// TestRenterWALShards tests that the renter creates its sharded WALs and
+// recovers and removes the WALs of a previous run which used more shards.
+func TestRenterWALShards(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	persistDir := filepath.Join(rt.dir, modules.RenterDir)
+	if rt.renter.staticWALs.Len() != numSiaFileWALs {
+		t.Fatalf("expected %v wals but got %v", numSiaFileWALs, rt.renter.staticWALs.Len())
+	}
+	for i := 0; i < numSiaFileWALs; i++ {
+		if _, err := os.Stat(filepath.Join(persistDir, walShardFile(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = rt.renter.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create a WAL which is no longer part of the pool with an unapplied
+	// transaction that deletes a file.
+	toDelete := filepath.Join(persistDir, "todelete")
+	if err := ioutil.WriteFile(toDelete, []byte{1}, 0600); err != nil {
+		t.Fatal(err)
+	}
+	retiredWAL := filepath.Join(persistDir, walShardFile(numSiaFileWALs))
+	_, wal, err := writeaheadlog.New(retiredWAL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txn, err := wal.NewTransaction([]writeaheadlog.Update{{
+		Name:         "SiaFileDelete",
+		Instructions: []byte(toDelete),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-txn.SignalSetupComplete(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wal.CloseIncomplete(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Restart the renter. The transaction should be applied and the retired
+	// WAL removed.
+	var errChan <-chan error
+	rl := ratelimit.NewRateLimit(0, 0, 0)
+	rt.renter, errChan = New(rt.gateway, rt.cs, rt.wallet, rt.tpool, rt.mux, rl, persistDir)
+	if err := <-errChan; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(toDelete); !os.IsNotExist(err) {
+		t.Fatal("transaction of retired wal wasn't applied", err)
+	}
+	if _, err := os.Stat(retiredWAL); !os.IsNotExist(err) {
+		t.Fatal("retired wal wasn't removed", err)
+	}
+}
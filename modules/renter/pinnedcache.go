@@ -0,0 +1,463 @@
+package renter
+
+import (
+	"bytes"
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+	"go.sia.tech/siad/modules/renter/filesystem/siafile"
+)
+
+var (
+	// errNotPinned is returned when unpinning a file without pinned ranges.
+	errNotPinned = errors.New("file isn't pinned")
+
+	// errInvalidPinnedRange is returned when pinning a range which isn't
+	// within the file.
+	errInvalidPinnedRange = errors.New("pinned range must be within the file")
+)
+
+type (
+	// pinnedRange is a range of a file which is kept in memory. The data is
+	// nil until the range is loaded. The uid is the UID of the siafile the
+	// data was loaded from, which prevents serving stale data after the file
+	// at the siapath was replaced.
+	pinnedRange struct {
+		offset uint64
+		length uint64
+		uid    siafile.SiafileUID
+		data   []byte
+	}
+
+	// pinnedCache contains the pinned ranges of files indexed by siapath. The
+	// total length of the ranges is limited by the budget. Ranges are counted
+	// against the budget as soon as they are pinned, even before they are
+	// loaded.
+	pinnedCache struct {
+		budget uint64
+		ranges map[modules.SiaPath][]*pinnedRange
+
+		// loadNeeded is used to wake up the thread loading the pinned ranges.
+		loadNeeded chan struct{}
+
+		mu sync.Mutex
+	}
+)
+
+// newPinnedCache creates an empty pinned cache.
+func newPinnedCache() *pinnedCache {
+	return &pinnedCache{
+		budget:     defaultPinnedBytesBudget,
+		ranges:     make(map[modules.SiaPath][]*pinnedRange),
+		loadNeeded: make(chan struct{}, 1),
+	}
+}
+
+// pinnedBytes returns the total length of the pinned ranges.
+func (pc *pinnedCache) pinnedBytes() uint64 {
+	var total uint64
+	for _, ranges := range pc.ranges {
+		for _, pr := range ranges {
+			total += pr.length
+		}
+	}
+	return total
+}
+
+// wakeLoader wakes up the thread loading the pinned ranges.
+func (pc *pinnedCache) wakeLoader() {
+	select {
+	case pc.loadNeeded <- struct{}{}:
+	default:
+	}
+}
+
+// callAdd pins a range of a file. Pinning a range which is already pinned is
+// a no-op.
+func (pc *pinnedCache) callAdd(siaPath modules.SiaPath, offset, length uint64) error {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	for _, pr := range pc.ranges[siaPath] {
+		if pr.offset == offset && pr.length == length {
+			return nil
+		}
+	}
+	if pc.pinnedBytes()+length > pc.budget {
+		return modules.ErrPinnedBytesBudgetExceeded
+	}
+	pc.ranges[siaPath] = append(pc.ranges[siaPath], &pinnedRange{
+		offset: offset,
+		length: length,
+	})
+	pc.wakeLoader()
+	return nil
+}
+
+// callLoadPersisted replaces the budget and ranges of the cache with the
+// persisted ones.
+func (pc *pinnedCache) callLoadPersisted(budget uint64, ranges []modules.RenterPinnedRange) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	pc.budget = budget
+	pc.ranges = make(map[modules.SiaPath][]*pinnedRange)
+	for _, r := range ranges {
+		pc.ranges[r.SiaPath] = append(pc.ranges[r.SiaPath], &pinnedRange{
+			offset: r.Offset,
+			length: r.Length,
+		})
+	}
+	pc.wakeLoader()
+}
+
+// callSetBudget updates the budget of the cache. The budget can't be lowered
+// below the number of pinned bytes.
+func (pc *pinnedCache) callSetBudget(budget uint64) error {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if pinned := pc.pinnedBytes(); budget < pinned {
+		return errors.AddContext(modules.ErrPinnedBytesBudgetExceeded, "budget is lower than the number of pinned bytes")
+	}
+	pc.budget = budget
+	return nil
+}
+
+// callRemove unpins all the ranges of a file. It returns false if the file
+// didn't have any pinned ranges.
+func (pc *pinnedCache) callRemove(siaPath modules.SiaPath) bool {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	_, exists := pc.ranges[siaPath]
+	delete(pc.ranges, siaPath)
+	return exists
+}
+
+// callRemoveDir unpins the ranges of all the files within a directory. It
+// returns false if none of the files had pinned ranges.
+func (pc *pinnedCache) callRemoveDir(dir modules.SiaPath) bool {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	var removed bool
+	for siaPath := range pc.ranges {
+		if isSubPath(siaPath, dir) {
+			delete(pc.ranges, siaPath)
+			removed = true
+		}
+	}
+	return removed
+}
+
+// callRename moves the ranges of a file to a new siapath. It returns false if
+// the file didn't have any pinned ranges.
+func (pc *pinnedCache) callRename(oldPath, newPath modules.SiaPath) bool {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	ranges, exists := pc.ranges[oldPath]
+	if !exists {
+		return false
+	}
+	delete(pc.ranges, oldPath)
+	pc.ranges[newPath] = ranges
+	return true
+}
+
+// callRenameDir moves the ranges of all the files within a directory to the
+// new directory. It returns false if none of the files had pinned ranges.
+func (pc *pinnedCache) callRenameDir(oldDir, newDir modules.SiaPath) bool {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	var moved []modules.SiaPath
+	for siaPath := range pc.ranges {
+		if isSubPath(siaPath, oldDir) {
+			moved = append(moved, siaPath)
+		}
+	}
+	for _, siaPath := range moved {
+		newPath, err := siaPath.Rebase(oldDir, newDir)
+		if err != nil {
+			continue // can't happen since siaPath is within oldDir
+		}
+		pc.ranges[newPath] = pc.ranges[siaPath]
+		delete(pc.ranges, siaPath)
+	}
+	return len(moved) > 0
+}
+
+// callStatus returns the budget and the pinned ranges of the cache sorted by
+// siapath and offset.
+func (pc *pinnedCache) callStatus() modules.RenterPinnedCache {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	status := modules.RenterPinnedCache{
+		Budget:      pc.budget,
+		PinnedBytes: pc.pinnedBytes(),
+		Ranges:      []modules.RenterPinnedRange{},
+	}
+	for siaPath, ranges := range pc.ranges {
+		for _, pr := range ranges {
+			status.Ranges = append(status.Ranges, modules.RenterPinnedRange{
+				SiaPath: siaPath,
+				Offset:  pr.offset,
+				Length:  pr.length,
+				Cached:  pr.data != nil,
+			})
+		}
+	}
+	sort.Slice(status.Ranges, func(i, j int) bool {
+		if status.Ranges[i].SiaPath != status.Ranges[j].SiaPath {
+			return status.Ranges[i].SiaPath.String() < status.Ranges[j].SiaPath.String()
+		}
+		return status.Ranges[i].Offset < status.Ranges[j].Offset
+	})
+	return status
+}
+
+// callData returns a copy of the pinned data of a file starting at offset.
+// At most length bytes are returned. If the data isn't cached, nil is
+// returned.
+func (pc *pinnedCache) callData(siaPath modules.SiaPath, uid siafile.SiafileUID, offset, length uint64) []byte {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	for _, pr := range pc.ranges[siaPath] {
+		if pr.data == nil || pr.uid != uid || offset < pr.offset || offset >= pr.offset+pr.length {
+			continue
+		}
+		data := pr.data[offset-pr.offset:]
+		if uint64(len(data)) > length {
+			data = data[:length]
+		}
+		// Return a copy since the streamer's cache might be appended to.
+		return append([]byte(nil), data...)
+	}
+	return nil
+}
+
+// callRangesToLoad returns the ranges of a file which aren't loaded from the
+// siafile with the provided UID.
+func (pc *pinnedCache) callRangesToLoad(siaPath modules.SiaPath, uid siafile.SiafileUID) []pinnedRange {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	var ranges []pinnedRange
+	for _, pr := range pc.ranges[siaPath] {
+		if pr.data == nil || pr.uid != uid {
+			ranges = append(ranges, pinnedRange{offset: pr.offset, length: pr.length})
+		}
+	}
+	return ranges
+}
+
+// callSetData sets the data of a pinned range if the range is still pinned.
+func (pc *pinnedCache) callSetData(siaPath modules.SiaPath, offset, length uint64, uid siafile.SiafileUID, data []byte) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	for _, pr := range pc.ranges[siaPath] {
+		if pr.offset == offset && pr.length == length {
+			pr.uid = uid
+			pr.data = data
+			return
+		}
+	}
+}
+
+// callSiaPaths returns the siapaths of the files with pinned ranges.
+func (pc *pinnedCache) callSiaPaths() []modules.SiaPath {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	siaPaths := make([]modules.SiaPath, 0, len(pc.ranges))
+	for siaPath := range pc.ranges {
+		siaPaths = append(siaPaths, siaPath)
+	}
+	return siaPaths
+}
+
+// PinnedCache returns the ranges of files the renter keeps in memory.
+func (r *Renter) PinnedCache() modules.RenterPinnedCache {
+	return r.staticPinnedCache.callStatus()
+}
+
+// PinFile keeps a range of a file in memory to serve streams of the file
+// without downloading the data. A length of 0 pins the remainder of the file.
+// The data is loaded in the background.
+func (r *Renter) PinFile(siaPath modules.SiaPath, offset, length uint64) (err error) {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	// Check that the range is within the file.
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, entry.Close())
+	}()
+	size := entry.Size()
+	if length == 0 && offset < size {
+		length = size - offset
+	}
+	if length == 0 || offset+length > size {
+		return errInvalidPinnedRange
+	}
+
+	if err := r.staticPinnedCache.callAdd(siaPath, offset, length); err != nil {
+		return err
+	}
+	return r.managedSavePinnedCache()
+}
+
+// UnpinFile removes all the pinned ranges of a file from memory.
+func (r *Renter) UnpinFile(siaPath modules.SiaPath) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if !r.staticPinnedCache.callRemove(siaPath) {
+		return errNotPinned
+	}
+	return r.managedSavePinnedCache()
+}
+
+// SetPinnedBytesBudget sets the max number of bytes the renter keeps in
+// memory for pinned ranges.
+func (r *Renter) SetPinnedBytesBudget(budget uint64) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if err := r.staticPinnedCache.callSetBudget(budget); err != nil {
+		return err
+	}
+	return r.managedSavePinnedCache()
+}
+
+// managedSavePinnedCache persists the budget and the ranges of the pinned
+// cache.
+func (r *Renter) managedSavePinnedCache() error {
+	status := r.staticPinnedCache.callStatus()
+	for i := range status.Ranges {
+		status.Ranges[i].Cached = false
+	}
+	id := r.mu.Lock()
+	defer r.mu.Unlock(id)
+	r.persist.PinnedBytesBudget = status.Budget
+	r.persist.PinnedRanges = status.Ranges
+	return errors.AddContext(r.saveSync(), "failed to persist pinned cache")
+}
+
+// managedUpdatePinnedCache persists the pinned cache after a file with pinned
+// ranges was renamed or deleted.
+func (r *Renter) managedUpdatePinnedCache(changed bool) {
+	if !changed {
+		return
+	}
+	if err := r.managedSavePinnedCache(); err != nil {
+		r.log.Println("WARN: failed to update pinned cache:", err)
+	}
+}
+
+// threadedLoadPinnedRanges loads the pinned ranges which aren't in memory yet.
+// Ranges are reloaded periodically to retry failed loads and to pick up files
+// which were replaced.
+func (r *Renter) threadedLoadPinnedRanges() {
+	defer modules.RecoverPanic("renter")
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	for {
+		r.managedLoadPinnedRanges()
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-r.staticPinnedCache.loadNeeded:
+		case <-time.After(pinnedCacheLoadInterval):
+		}
+	}
+}
+
+// managedLoadPinnedRanges downloads the data of the pinned ranges which
+// aren't loaded from the current siafile at their siapath.
+func (r *Renter) managedLoadPinnedRanges() {
+	for _, siaPath := range r.staticPinnedCache.callSiaPaths() {
+		select {
+		case <-r.tg.StopChan():
+			return
+		default:
+		}
+		entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+		if errors.Contains(err, filesystem.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			r.log.Printf("WARN: failed to open pinned file %v: %v", siaPath, err)
+			continue
+		}
+		uid := entry.UID()
+		snap, err := entry.Snapshot(siaPath)
+		err = errors.Compose(err, entry.Close())
+		if err != nil {
+			r.log.Printf("WARN: failed to snapshot pinned file %v: %v", siaPath, err)
+			continue
+		}
+		for _, pr := range r.staticPinnedCache.callRangesToLoad(siaPath, uid) {
+			if pr.offset+pr.length > snap.Size() {
+				r.log.Printf("WARN: pinned range %v-%v exceeds size of %v", pr.offset, pr.offset+pr.length, siaPath)
+				continue
+			}
+			data, err := r.managedDownloadPinnedRange(snap, pr.offset, pr.length)
+			if err != nil {
+				r.log.Printf("WARN: failed to load pinned range %v-%v of %v: %v", pr.offset, pr.offset+pr.length, siaPath, err)
+				continue
+			}
+			r.staticPinnedCache.callSetData(siaPath, pr.offset, pr.length, uid, data)
+		}
+	}
+}
+
+// managedDownloadPinnedRange downloads a range of a file into memory.
+func (r *Renter) managedDownloadPinnedRange(snap *siafile.Snapshot, offset, length uint64) ([]byte, error) {
+	buffer := bytes.NewBuffer(make([]byte, 0, length))
+	ddw := newDownloadDestinationWriter(buffer)
+	latencyTarget, overdrive := downloadPolicySettings(modules.DownloadPolicyBalanced)
+	d, err := r.managedNewDownload(downloadParams{
+		destination:       ddw,
+		destinationType:   destinationTypeSeekStream,
+		destinationString: "pinnedcache",
+		file:              snap,
+
+		latencyTarget: latencyTarget,
+		length:        length,
+		needsMemory:   true,
+		offset:        offset,
+		overdrive:     overdrive,
+		policy:        modules.DownloadPolicyBalanced,
+		priority:      5,
+
+		staticMemoryManager:    r.userDownloadMemoryManager,
+		staticSpendingCategory: categoryDownload,
+	})
+	if err != nil {
+		return nil, errors.Compose(err, ddw.Close())
+	}
+	d.OnComplete(func(_ error) error {
+		return ddw.Close()
+	})
+	if err := d.Start(); err != nil {
+		return nil, err
+	}
+	select {
+	case <-d.completeChan:
+	case <-r.tg.StopChan():
+		return nil, errors.New("download interrupted by shutdown")
+	}
+	if err := d.Err(); err != nil {
+		return nil, errors.AddContext(err, "download failed")
+	}
+	return buffer.Bytes(), nil
+}
@@ -0,0 +1,223 @@
+package renter
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem/siafile"
+)
+
+// TestPinnedCache is a unit test for the pinnedCache.
+func TestPinnedCache(t *testing.T) {
+	pc := newPinnedCache()
+	if err := pc.callSetBudget(100); err != nil {
+		t.Fatal(err)
+	}
+	dir := modules.RandomSiaPath()
+	a, err := dir.Join("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := modules.RandomSiaPath()
+
+	// Pin ranges until the budget is exceeded. Pinning the same range twice
+	// is a no-op.
+	if err := pc.callAdd(a, 0, 60); err != nil {
+		t.Fatal(err)
+	}
+	if err := pc.callAdd(a, 0, 60); err != nil {
+		t.Fatal(err)
+	}
+	if err := pc.callAdd(b, 0, 50); !errors.Contains(err, modules.ErrPinnedBytesBudgetExceeded) {
+		t.Fatal("expected budget to be exceeded", err)
+	}
+	if err := pc.callAdd(a, 60, 40); err != nil {
+		t.Fatal(err)
+	}
+	if err := pc.callSetBudget(50); !errors.Contains(err, modules.ErrPinnedBytesBudgetExceeded) {
+		t.Fatal("expected budget below the pinned bytes to be rejected", err)
+	}
+	status := pc.callStatus()
+	if status.Budget != 100 || status.PinnedBytes != 100 || len(status.Ranges) != 2 {
+		t.Fatal("wrong status", status)
+	}
+	if status.Ranges[0].Offset != 0 || status.Ranges[1].Offset != 60 || status.Ranges[0].Cached {
+		t.Fatal("wrong ranges", status.Ranges)
+	}
+
+	// Load the first range.
+	var uid siafile.SiafileUID = "uid"
+	if ranges := pc.callRangesToLoad(a, uid); len(ranges) != 2 {
+		t.Fatal("expected 2 ranges to load", ranges)
+	}
+	if data := pc.callData(a, uid, 0, 10); data != nil {
+		t.Fatal("unloaded range shouldn't return data")
+	}
+	data := fastrand.Bytes(60)
+	pc.callSetData(a, 0, 60, uid, data)
+	if ranges := pc.callRangesToLoad(a, uid); len(ranges) != 1 || ranges[0].offset != 60 {
+		t.Fatal("expected the second range to load", ranges)
+	}
+	if ranges := pc.callRangesToLoad(a, "other"); len(ranges) != 2 {
+		t.Fatal("expected both ranges to load for a different uid", ranges)
+	}
+
+	// Data is only returned for the right uid and within the range.
+	if d := pc.callData(a, "other", 0, 10); d != nil {
+		t.Fatal("data shouldn't be returned for a different uid")
+	}
+	if d := pc.callData(a, uid, 60, 10); d != nil {
+		t.Fatal("data shouldn't be returned outside of the loaded range")
+	}
+	d := pc.callData(a, uid, 10, 20)
+	if !bytes.Equal(d, data[10:30]) {
+		t.Fatal("wrong data")
+	}
+	if d = pc.callData(a, uid, 50, 20); !bytes.Equal(d, data[50:]) {
+		t.Fatal("data should be truncated to the range")
+	}
+	// The returned data is a copy.
+	d[0]++
+	if d := pc.callData(a, uid, 50, 1); d[0] != data[50] {
+		t.Fatal("pinned data was modified")
+	}
+
+	// Rename the file and its directory.
+	if pc.callRename(b, a) {
+		t.Fatal("file without ranges shouldn't be renamed")
+	}
+	if !pc.callRename(a, b) {
+		t.Fatal("expected file to be renamed")
+	}
+	if d := pc.callData(b, uid, 0, 10); !bytes.Equal(d, data[:10]) {
+		t.Fatal("data wasn't moved")
+	}
+	if !pc.callRename(b, a) {
+		t.Fatal("expected file to be renamed")
+	}
+	newDir := modules.RandomSiaPath()
+	if !pc.callRenameDir(dir, newDir) {
+		t.Fatal("expected dir to be renamed")
+	}
+	newA, err := a.Rebase(dir, newDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status := pc.callStatus(); len(status.Ranges) != 2 || !status.Ranges[0].SiaPath.Equals(newA) {
+		t.Fatal("ranges weren't moved", status.Ranges)
+	}
+
+	// Remove the ranges.
+	if pc.callRemoveDir(dir) {
+		t.Fatal("old dir shouldn't have ranges")
+	}
+	if !pc.callRemoveDir(newDir) {
+		t.Fatal("expected ranges to be removed")
+	}
+	if status := pc.callStatus(); status.PinnedBytes != 0 || len(status.Ranges) != 0 {
+		t.Fatal("expected no ranges", status)
+	}
+}
+
+// TestPinFile tests pinning files of the renter and streaming them from
+// memory.
+func TestPinFile(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	siaPath := modules.RandomSiaPath()
+	entry, err := rt.renter.createRenterTestFile(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	size, uid := entry.Size(), entry.UID()
+	if err := entry.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Ranges outside of the file are rejected.
+	if err := rt.renter.PinFile(siaPath, size, 0); !errors.Contains(err, errInvalidPinnedRange) {
+		t.Fatal("expected invalid range", err)
+	}
+	if err := rt.renter.PinFile(siaPath, 0, size+1); !errors.Contains(err, errInvalidPinnedRange) {
+		t.Fatal("expected invalid range", err)
+	}
+	if err := rt.renter.UnpinFile(siaPath); !errors.Contains(err, errNotPinned) {
+		t.Fatal("expected file not to be pinned", err)
+	}
+
+	// Pin the whole file and set a budget.
+	if err := rt.renter.SetPinnedBytesBudget(size); err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.renter.PinFile(siaPath, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.renter.PinFile(siaPath, 1, 1); !errors.Contains(err, modules.ErrPinnedBytesBudgetExceeded) {
+		t.Fatal("expected budget to be exceeded", err)
+	}
+
+	// The pinned ranges are persisted.
+	r, err := rt.reloadRenter(rt.renter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pc := r.PinnedCache()
+	if pc.Budget != size || pc.PinnedBytes != size || len(pc.Ranges) != 1 {
+		t.Fatal("pinned cache wasn't persisted", pc)
+	}
+	if pr := pc.Ranges[0]; !pr.SiaPath.Equals(siaPath) || pr.Offset != 0 || pr.Length != size {
+		t.Fatal("wrong range", pr)
+	}
+
+	// The renter doesn't have any hosts to load the data from. Set the data
+	// manually and stream the file from memory.
+	data := fastrand.Bytes(int(size))
+	r.staticPinnedCache.callSetData(siaPath, 0, size, uid, data)
+	if !r.PinnedCache().Ranges[0].Cached {
+		t.Fatal("range should be cached")
+	}
+	_, s, err := r.Streamer(siaPath, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	streamed, err := ioutil.ReadAll(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(streamed, data) {
+		t.Fatal("streamed data doesn't match pinned data")
+	}
+
+	// Renaming the file moves its ranges, deleting it removes them.
+	newSiaPath := modules.RandomSiaPath()
+	if err := r.RenameFile(siaPath, newSiaPath); err != nil {
+		t.Fatal(err)
+	}
+	if pc := r.PinnedCache(); len(pc.Ranges) != 1 || !pc.Ranges[0].SiaPath.Equals(newSiaPath) {
+		t.Fatal("range wasn't renamed", pc.Ranges)
+	}
+	if err := r.DeleteFile(newSiaPath); err != nil {
+		t.Fatal(err)
+	}
+	if pc := r.PinnedCache(); pc.PinnedBytes != 0 || len(pc.Ranges) != 0 {
+		t.Fatal("range wasn't removed", pc)
+	}
+}
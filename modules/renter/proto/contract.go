@@ -59,7 +59,8 @@ type contractHeader struct {
 	Transaction types.Transaction
 
 	// secretKey is the key used by the renter to sign the file contract
-	// transaction.
+	// transaction. It is not persisted within the header if the contract's
+	// key is stored in the contract set's key store.
 	SecretKey crypto.SecretKey
 
 	// Same as modules.RenterContract.
@@ -168,6 +169,11 @@ type SafeContract struct {
 	// applied to the contract file.
 	unappliedTxns []*unappliedWalTxn
 
+	// keyInStore indicates that the secret key of the contract is stored in
+	// the contract set's key store instead of the header file. If the key
+	// store is locked, the header's secret key is not set.
+	keyInStore bool
+
 	staticHeaderFile *os.File
 	staticWal        *writeaheadlog.WAL
 	mu               sync.Mutex
@@ -266,9 +272,32 @@ func (c *SafeContract) Metadata() modules.RenterContract {
 func (c *SafeContract) PublicKey() crypto.PublicKey {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	if c.header.SecretKey == (crypto.SecretKey{}) {
+		// The key store is locked, fall back to the renter's key within the
+		// unlock conditions of the contract.
+		var pk crypto.PublicKey
+		copy(pk[:], c.header.LastRevision().UnlockConditions.PublicKeys[0].Key)
+		return pk
+	}
 	return c.header.SecretKey.PublicKey()
 }
 
+// HasSecretKey returns ErrContractKeysLocked if the secret key of the contract
+// is not available because it is stored in the locked key store.
+func (c *SafeContract) HasSecretKey() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hasSecretKey()
+}
+
+// hasSecretKey is the unlocked version of HasSecretKey.
+func (c *SafeContract) hasSecretKey() error {
+	if c.header.SecretKey == (crypto.SecretKey{}) {
+		return ErrContractKeysLocked
+	}
+	return nil
+}
+
 // RecordPaymentIntent will records the changes we are about to make to the
 // revision in order to pay a host for an RPC.
 func (c *SafeContract) RecordPaymentIntent(rev types.FileContractRevision, amount types.Currency, details modules.SpendingDetails) (*unappliedWalTxn, error) {
@@ -366,11 +395,21 @@ func (c *SafeContract) makeUpdateSetHeader(h contractHeader) writeaheadlog.Updat
 		Name: updateNameSetHeader,
 		Instructions: encoding.Marshal(updateSetHeader{
 			ID:     id,
-			Header: h,
+			Header: c.persistedHeader(h),
 		}),
 	}
 }
 
+// persistedHeader returns the header in the form it is persisted in. If the
+// contract's secret key is stored in the key store, it is removed from the
+// header.
+func (c *SafeContract) persistedHeader(h contractHeader) contractHeader {
+	if c.keyInStore {
+		h.SecretKey = crypto.SecretKey{}
+	}
+	return h
+}
+
 // makeUpdateSetRoot creates an update that sets a given root, existing or not.
 func (c *SafeContract) makeUpdateSetRoot(root crypto.Hash, index int) writeaheadlog.Update {
 	id := c.header.ID()
@@ -443,7 +482,12 @@ func (c *SafeContract) applySetHeader(h contractHeader) error {
 			}
 		}
 	}
-	headerBytes := encoding.Marshal(h)
+	// Headers from the WAL might not contain the secret key. It never changes
+	// so the one in memory is kept.
+	if h.SecretKey == (crypto.SecretKey{}) {
+		h.SecretKey = c.header.SecretKey
+	}
+	headerBytes := encoding.Marshal(c.persistedHeader(h))
 	if _, err := c.staticHeaderFile.WriteAt(headerBytes, 0); err != nil {
 		return err
 	}
@@ -796,6 +840,19 @@ func (c *SafeContract) managedSyncRevision(rev types.FileContractRevision, sigs
 // managedInsertContract inserts a contract into the set in an ACID fashion
 // using the set's WAL.
 func (cs *ContractSet) managedInsertContract(h contractHeader, roots []crypto.Hash) (modules.RenterContract, error) {
+	// Move the secret key into the key store if it is unlocked.
+	if err := h.validate(); err != nil {
+		return modules.RenterContract{}, err
+	}
+	stored, err := cs.staticKeyStore.managedAdd(map[types.FileContractID]crypto.SecretKey{
+		h.ID(): h.SecretKey,
+	})
+	if err != nil {
+		return modules.RenterContract{}, errors.AddContext(err, "failed to add contract key to key store")
+	}
+	if stored {
+		h.SecretKey = crypto.SecretKey{}
+	}
 	insertUpdate, err := makeUpdateInsertContract(h, roots)
 	if err != nil {
 		return modules.RenterContract{}, err
@@ -879,9 +936,16 @@ func (cs *ContractSet) managedApplyInsertContractUpdate(update writeaheadlog.Upd
 			return modules.RenterContract{}, errors.AddContext(err, "failed to create a refcounter")
 		}
 	}
+	// A header without a secret key belongs to a contract whose key is in
+	// the key store.
+	keyInStore := h.SecretKey == (crypto.SecretKey{})
+	if sk, ok := cs.staticKeyStore.managedKey(h.ID()); keyInStore && ok {
+		h.SecretKey = sk
+	}
 	sc := &SafeContract{
 		header:           h,
 		merkleRoots:      merkleRoots,
+		keyInStore:       keyInStore,
 		staticHeaderFile: headerFile,
 		staticWal:        cs.staticWal,
 		staticRC:         rc,
@@ -995,6 +1059,7 @@ func (cs *ContractSet) loadSafeContract(headerFileName, rootsFileName, refCountF
 		header:           header,
 		merkleRoots:      merkleRoots,
 		unappliedTxns:    unappliedTxns,
+		keyInStore:       header.SecretKey == (crypto.SecretKey{}),
 		staticHeaderFile: headerFile,
 		staticWal:        cs.staticWal,
 		staticRC:         rc,
@@ -24,11 +24,14 @@ import (
 type ContractSet struct {
 	contracts  map[types.FileContractID]*SafeContract
 	pubKeys    map[string]types.FileContractID
+	hostRLs    map[string]*ratelimit.RateLimit
 	staticDeps modules.Dependencies
 	staticDir  string
 	mu         sync.Mutex
 	staticRL   *ratelimit.RateLimit
 	staticWal  *writeaheadlog.WAL
+
+	staticKeyStore *keyStore
 }
 
 // Acquire looks up the contract for the specified host key and locks it before
@@ -76,11 +79,23 @@ func (cs *ContractSet) Delete(c *SafeContract) {
 	err := errors.Compose(c.staticHeaderFile.Close(), c.merkleRoots.rootsFile.Close())
 	// remove the files.
 	err = errors.Compose(err, os.Remove(headerPath), os.Remove(rootsPath))
+	// remove the secret key from the key store.
+	err = errors.Compose(err, cs.staticKeyStore.managedRemove(c.header.ID()))
 	if err != nil {
 		build.Critical("Failed to delete SafeContract from disk:", err)
 	}
 }
 
+// ChangeKeyStoreKey re-encrypts the key store with a new key. The old key needs
+// to be the key the store is currently encrypted with.
+func (cs *ContractSet) ChangeKeyStoreKey(oldKey, newKey crypto.CipherKey) error {
+	if err := cs.staticKeyStore.managedChangeKey(oldKey, newKey); err != nil {
+		return err
+	}
+	// The key store is unlocked afterwards.
+	return cs.managedMoveKeysToStore()
+}
+
 // IDs returns the fcid of each contract with in the set. The contracts are not
 // locked.
 func (cs *ContractSet) IDs() []types.FileContractID {
@@ -112,6 +127,31 @@ func (cs *ContractSet) InsertContract(rc modules.RecoverableContract, revTxn typ
 	}, roots)
 }
 
+// KeyStoreStatus returns information about the key store of the set.
+func (cs *ContractSet) KeyStoreStatus() modules.ContractKeyStoreStatus {
+	initialized, unlocked, numKeys := cs.staticKeyStore.managedStatus()
+	cs.mu.Lock()
+	contracts := make([]*SafeContract, 0, len(cs.contracts))
+	for _, sc := range cs.contracts {
+		contracts = append(contracts, sc)
+	}
+	cs.mu.Unlock()
+	var numUnprotected uint64
+	for _, sc := range contracts {
+		sc.mu.Lock()
+		if !sc.keyInStore {
+			numUnprotected++
+		}
+		sc.mu.Unlock()
+	}
+	return modules.ContractKeyStoreStatus{
+		Initialized:        initialized,
+		Unlocked:           unlocked,
+		NumKeys:            uint64(numKeys),
+		NumUnprotectedKeys: numUnprotected,
+	}
+}
+
 // Len returns the number of contracts in the set.
 func (cs *ContractSet) Len() int {
 	cs.mu.Lock()
@@ -133,6 +173,16 @@ func (cs *ContractSet) Return(c *SafeContract) {
 	c.revisionMu.Unlock()
 }
 
+// UnlockKeyStore unlocks the key store of the set with the given key. If the
+// key store doesn't exist yet, it is initialized with the key. Afterwards, the
+// secret keys of all contracts are moved from their headers into the store.
+func (cs *ContractSet) UnlockKeyStore(key crypto.CipherKey) error {
+	if err := cs.staticKeyStore.managedUnlock(key); err != nil {
+		return err
+	}
+	return cs.managedMoveKeysToStore()
+}
+
 // View returns a copy of the contract with the specified host key. The contract
 // is not locked. Certain fields, including the MerkleRoots, are set to nil for
 // safety reasons. If the contract is not present in the set, View returns false
@@ -218,11 +268,14 @@ func NewContractSet(dir string, rl *ratelimit.RateLimit, deps modules.Dependenci
 	cs := &ContractSet{
 		contracts: make(map[types.FileContractID]*SafeContract),
 		pubKeys:   make(map[string]types.FileContractID),
+		hostRLs:   make(map[string]*ratelimit.RateLimit),
 
 		staticDeps: deps,
 		staticDir:  dir,
 		staticRL:   rl,
 		staticWal:  wal,
+
+		staticKeyStore: newKeyStore(dir),
 	}
 	// Set the initial rate limit to 'unlimited' bandwidth with 4kib packets.
 	cs.staticRL = ratelimit.NewRateLimit(0, 0, 0)
@@ -356,3 +409,78 @@ func (cs *ContractSet) managedV146SplitContractHeaderAndRoots(dir string) error
 	cs.mu.Unlock()
 	return nil
 }
+
+// SetHostRateLimit sets a ratelimit which is applied to all connections to the
+// host with the provided key in addition to the ratelimit of the set. Passing a
+// nil ratelimit removes the ratelimit of the host.
+func (cs *ContractSet) SetHostRateLimit(pk types.SiaPublicKey, rl *ratelimit.RateLimit) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if rl == nil {
+		delete(cs.hostRLs, pk.String())
+		return
+	}
+	cs.hostRLs[pk.String()] = rl
+}
+
+// managedHostRateLimit returns the ratelimit of the host with the provided key
+// or nil if there is none.
+func (cs *ContractSet) managedHostRateLimit(pk types.SiaPublicKey) *ratelimit.RateLimit {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	return cs.hostRLs[pk.String()]
+}
+
+// managedMoveKeysToStore moves the secret keys of all contracts which still
+// keep them in their headers into the unlocked key store and strips them from
+// the header files. Contracts which already store their keys in the key store
+// get their secret keys loaded.
+func (cs *ContractSet) managedMoveKeysToStore() error {
+	cs.mu.Lock()
+	contracts := make([]*SafeContract, 0, len(cs.contracts))
+	for _, sc := range cs.contracts {
+		contracts = append(contracts, sc)
+	}
+	cs.mu.Unlock()
+
+	// Add the keys to the store first to make sure they are persisted before
+	// they are removed from the headers.
+	keys := make(map[types.FileContractID]crypto.SecretKey)
+	var toStrip []*SafeContract
+	for _, sc := range contracts {
+		sc.mu.Lock()
+		if sc.keyInStore {
+			if sk, ok := cs.staticKeyStore.managedKey(sc.header.ID()); ok {
+				sc.header.SecretKey = sk
+			}
+		} else {
+			keys[sc.header.ID()] = sc.header.SecretKey
+			toStrip = append(toStrip, sc)
+		}
+		sc.mu.Unlock()
+	}
+	if len(keys) > 0 {
+		stored, err := cs.staticKeyStore.managedAdd(keys)
+		if err != nil {
+			return errors.AddContext(err, "failed to add contract keys to key store")
+		}
+		if !stored {
+			return ErrContractKeysLocked
+		}
+	}
+
+	// Strip the keys from the headers.
+	for _, sc := range toStrip {
+		sc.mu.Lock()
+		sc.keyInStore = true
+		err := sc.applySetHeader(sc.header)
+		if err == nil {
+			err = sc.staticHeaderFile.Sync()
+		}
+		sc.mu.Unlock()
+		if err != nil {
+			return errors.AddContext(err, "failed to remove secret key from contract header")
+		}
+	}
+	return nil
+}
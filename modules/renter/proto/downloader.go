@@ -177,6 +177,9 @@ func (cs *ContractSet) NewDownloader(host modules.HostDBEntry, id types.FileCont
 	}
 	defer cs.Return(sc)
 	contract := sc.header
+	if err := sc.HasSecretKey(); err != nil {
+		return nil, err
+	}
 
 	// check that contract has enough value to support a download
 	sectorPrice := host.DownloadBandwidthPrice.Mul64(modules.SectorSize)
@@ -195,7 +198,7 @@ func (cs *ContractSet) NewDownloader(host modules.HostDBEntry, id types.FileCont
 		}
 	}()
 
-	conn, closeChan, err := initiateRevisionLoop(host, sc, modules.RPCDownload, cancel, cs.staticRL)
+	conn, closeChan, err := initiateRevisionLoop(host, sc, modules.RPCDownload, cancel, cs.staticRL, cs.managedHostRateLimit(host.PublicKey))
 	if err != nil {
 		return nil, errors.AddContext(err, "failed to initiate revision loop")
 	}
@@ -193,6 +193,9 @@ func (cs *ContractSet) NewEditor(host modules.HostDBEntry, id types.FileContract
 	}
 	defer cs.Return(sc)
 	contract := sc.header
+	if err := sc.HasSecretKey(); err != nil {
+		return nil, err
+	}
 
 	// Increase Successful/Failed interactions accordingly
 	defer func() {
@@ -205,7 +208,7 @@ func (cs *ContractSet) NewEditor(host modules.HostDBEntry, id types.FileContract
 		}
 	}()
 
-	conn, closeChan, err := initiateRevisionLoop(host, sc, modules.RPCReviseContract, cancel, cs.staticRL)
+	conn, closeChan, err := initiateRevisionLoop(host, sc, modules.RPCReviseContract, cancel, cs.staticRL, cs.managedHostRateLimit(host.PublicKey))
 	if err != nil {
 		return nil, errors.AddContext(err, "failed to initiate revision loop")
 	}
@@ -229,12 +232,13 @@ func (cs *ContractSet) NewEditor(host modules.HostDBEntry, id types.FileContract
 }
 
 // initiateRevisionLoop initiates either the editor or downloader loop with
-// host, depending on which rpc was passed.
-func initiateRevisionLoop(host modules.HostDBEntry, contract *SafeContract, rpc types.Specifier, cancel <-chan struct{}, rl *ratelimit.RateLimit) (net.Conn, chan struct{}, error) {
-	c, err := (&net.Dialer{
+// host, depending on which rpc was passed. If hostRL is not nil, it is applied
+// in addition to the local and global ratelimits.
+func initiateRevisionLoop(host modules.HostDBEntry, contract *SafeContract, rpc types.Specifier, cancel <-chan struct{}, rl, hostRL *ratelimit.RateLimit) (net.Conn, chan struct{}, error) {
+	c, _, err := modules.DialNetAddresses(&net.Dialer{
 		Cancel:  cancel,
 		Timeout: 45 * time.Second, // TODO: Constant
-	}).Dial("tcp", string(host.NetAddress))
+	}, host.NetAddresses())
 	if err != nil {
 		return nil, nil, err
 	}
@@ -242,6 +246,10 @@ func initiateRevisionLoop(host modules.HostDBEntry, contract *SafeContract, rpc
 	conn := ratelimit.NewRLConn(c, rl, cancel)
 	// Apply the global ratelimit.
 	conn = ratelimit.NewRLConn(conn, modules.GlobalRateLimits, cancel)
+	// Apply the host's ratelimit.
+	if hostRL != nil {
+		conn = ratelimit.NewRLConn(conn, hostRL, cancel)
+	}
 
 	closeChan := make(chan struct{})
 	go func() {
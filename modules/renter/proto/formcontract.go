@@ -24,9 +24,13 @@ func (cs *ContractSet) FormContract(params modules.ContractParams, txnBuilder tr
 	// Extract vars from params, for convenience.
 	allowance, host, funding, startHeight, endHeight, refundAddress := params.Allowance, params.Host, params.Funding, params.StartHeight, params.EndHeight, params.RefundAddress
 
-	// Calculate the anticipated transaction fee.
+	// Calculate the anticipated transaction fee. Pre-funded contracts don't
+	// need to pay for the renter's setup transaction.
 	_, maxFee := tpool.FeeEstimation()
 	txnFee := maxFee.Mul64(modules.EstimatedFileContractTransactionSetSize)
+	if params.PreFunded {
+		txnFee = maxFee.Mul64(modules.EstimatedFileContractTransactionSetSizeBatched)
+	}
 
 	// Calculate the payouts for the renter, host, and whole contract.
 	period := endHeight - startHeight
@@ -41,10 +45,12 @@ func (cs *ContractSet) FormContract(params modules.ContractParams, txnBuilder tr
 	if types.PostTax(startHeight, totalPayout).Cmp(hostPayout) < 0 {
 		return modules.RenterContract{}, nil, types.Transaction{}, nil, errors.New("not enough money to pay both siafund fee and also host payout")
 	}
-	// Fund the transaction.
-	err = txnBuilder.FundSiacoins(funding)
-	if err != nil {
-		return modules.RenterContract{}, nil, types.Transaction{}, nil, err
+	// Fund the transaction unless the caller already did.
+	if !params.PreFunded {
+		err = txnBuilder.FundSiacoins(funding)
+		if err != nil {
+			return modules.RenterContract{}, nil, types.Transaction{}, nil, err
+		}
 	}
 
 	// Make a copy of the transaction builder so far, to be used to by the watchdog
@@ -0,0 +1,225 @@
+package proto
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// keyStoreFilename is the name of the file within the contract set's
+	// directory that contains the encrypted secret keys of the contracts.
+	keyStoreFilename = "contractkeys.json"
+)
+
+var (
+	// ErrContractKeysLocked is returned when a contract needs to be signed
+	// while its secret key is stored in the locked key store.
+	ErrContractKeysLocked = errors.New("the contract key store is locked")
+
+	// errKeyStoreNotInitialized is returned when trying to change the key of
+	// a key store which doesn't exist yet.
+	errKeyStoreNotInitialized = errors.New("the contract key store hasn't been initialized")
+
+	// keyStoreMetadata is the metadata of the key store's persist file.
+	keyStoreMetadata = persist.Metadata{
+		Header:  "Contract Key Store",
+		Version: "1.5.5",
+	}
+)
+
+type (
+	// keyStore stores the secret keys of the contracts in a contract set
+	// encrypted in a separate file. That way the contract files alone are not
+	// enough to sign new revisions. Until the key store is unlocked, it only
+	// knows whether it was initialized.
+	keyStore struct {
+		keys map[types.FileContractID]crypto.SecretKey
+
+		// key is the key used to derive the encryption key of the store. It
+		// is nil as long as the store is locked.
+		key crypto.CipherKey
+
+		staticPath string
+		mu         sync.Mutex
+	}
+
+	// keyStorePersist is the persisted form of the keyStore.
+	keyStorePersist struct {
+		Salt          [32]byte          `json:"salt"`
+		EncryptedKeys crypto.Ciphertext `json:"encryptedkeys"`
+	}
+
+	// keyStoreEntry is the encoded form of a single key within the store.
+	keyStoreEntry struct {
+		ID  types.FileContractID
+		Key crypto.SecretKey
+	}
+)
+
+// newKeyStore creates a locked key store for the contract set in the given
+// directory.
+func newKeyStore(dir string) *keyStore {
+	return &keyStore{
+		keys:       make(map[types.FileContractID]crypto.SecretKey),
+		staticPath: filepath.Join(dir, keyStoreFilename),
+	}
+}
+
+// saltedKeyStoreKey derives the encryption key of the key store from the
+// provided key and salt.
+func saltedKeyStoreKey(key crypto.CipherKey, salt [32]byte) crypto.CipherKey {
+	return crypto.NewWalletKey(crypto.HashAll(key, salt))
+}
+
+// initialized returns whether the key store's file exists.
+func (ks *keyStore) initialized() bool {
+	_, err := os.Stat(ks.staticPath)
+	return err == nil
+}
+
+// load loads and decrypts the keys of the store using the provided key.
+func (ks *keyStore) load(key crypto.CipherKey) (map[types.FileContractID]crypto.SecretKey, error) {
+	var ksp keyStorePersist
+	if err := persist.LoadJSON(keyStoreMetadata, &ksp, ks.staticPath); err != nil {
+		return nil, errors.AddContext(err, "failed to load key store")
+	}
+	plaintext, err := saltedKeyStoreKey(key, ksp.Salt).DecryptBytes(ksp.EncryptedKeys)
+	if err != nil {
+		return nil, errors.Compose(err, modules.ErrBadEncryptionKey)
+	}
+	var entries []keyStoreEntry
+	if err := encoding.Unmarshal(plaintext, &entries); err != nil {
+		return nil, errors.AddContext(err, "failed to decode keys")
+	}
+	keys := make(map[types.FileContractID]crypto.SecretKey, len(entries))
+	for _, entry := range entries {
+		keys[entry.ID] = entry.Key
+	}
+	return keys, nil
+}
+
+// save encrypts the keys of the store with the provided key and a new salt
+// and saves them to disk.
+func (ks *keyStore) save(key crypto.CipherKey, keys map[types.FileContractID]crypto.SecretKey) error {
+	entries := make([]keyStoreEntry, 0, len(keys))
+	for id, sk := range keys {
+		entries = append(entries, keyStoreEntry{ID: id, Key: sk})
+	}
+	var ksp keyStorePersist
+	fastrand.Read(ksp.Salt[:])
+	ksp.EncryptedKeys = saltedKeyStoreKey(key, ksp.Salt).EncryptBytes(encoding.Marshal(entries))
+	return persist.SaveJSON(keyStoreMetadata, ksp, ks.staticPath)
+}
+
+// managedAdd adds the keys to the store and persists them. It returns false
+// if the store is locked, in which case the keys are not added.
+func (ks *keyStore) managedAdd(keys map[types.FileContractID]crypto.SecretKey) (bool, error) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if ks.key == nil {
+		return false, nil
+	}
+	newKeys := make(map[types.FileContractID]crypto.SecretKey, len(ks.keys)+len(keys))
+	for id, sk := range ks.keys {
+		newKeys[id] = sk
+	}
+	for id, sk := range keys {
+		newKeys[id] = sk
+	}
+	if err := ks.save(ks.key, newKeys); err != nil {
+		return false, err
+	}
+	ks.keys = newKeys
+	return true, nil
+}
+
+// managedChangeKey re-encrypts the store with a new key. The old key needs to
+// be able to decrypt the store. Afterwards the store is unlocked.
+func (ks *keyStore) managedChangeKey(oldKey, newKey crypto.CipherKey) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if !ks.initialized() {
+		return errKeyStoreNotInitialized
+	}
+	keys, err := ks.load(oldKey)
+	if err != nil {
+		return err
+	}
+	if err := ks.save(newKey, keys); err != nil {
+		return err
+	}
+	ks.keys = keys
+	ks.key = newKey
+	return nil
+}
+
+// managedKey returns the secret key of the contract with the given id if the
+// store is unlocked and contains it.
+func (ks *keyStore) managedKey(id types.FileContractID) (crypto.SecretKey, bool) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	sk, exists := ks.keys[id]
+	return sk, exists
+}
+
+// managedRemove removes the key of the contract with the given id from the
+// store. If the store is locked, the key is kept.
+func (ks *keyStore) managedRemove(id types.FileContractID) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if _, exists := ks.keys[id]; !exists || ks.key == nil {
+		return nil
+	}
+	keys := make(map[types.FileContractID]crypto.SecretKey, len(ks.keys))
+	for fcid, sk := range ks.keys {
+		if fcid != id {
+			keys[fcid] = sk
+		}
+	}
+	if err := ks.save(ks.key, keys); err != nil {
+		return err
+	}
+	ks.keys = keys
+	return nil
+}
+
+// managedStatus returns whether the store was initialized and unlocked as well
+// as the number of keys it contains.
+func (ks *keyStore) managedStatus() (initialized, unlocked bool, numKeys int) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	return ks.initialized(), ks.key != nil, len(ks.keys)
+}
+
+// managedUnlock unlocks the store using the provided key. If the store wasn't
+// initialized yet, it is created with the key.
+func (ks *keyStore) managedUnlock(key crypto.CipherKey) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	if !ks.initialized() {
+		keys := make(map[types.FileContractID]crypto.SecretKey)
+		if err := ks.save(key, keys); err != nil {
+			return errors.AddContext(err, "failed to initialize key store")
+		}
+		ks.keys = keys
+		ks.key = key
+		return nil
+	}
+	keys, err := ks.load(key)
+	if err != nil {
+		return err
+	}
+	ks.keys = keys
+	ks.key = key
+	return nil
+}
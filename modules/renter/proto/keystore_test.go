@@ -0,0 +1,193 @@
+package proto
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/ratelimit"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// newKeyStoreTestHeader creates a contract header with a new key pair for
+// testing the key store.
+func newKeyStoreTestHeader(id types.FileContractID) contractHeader {
+	sk, pk := crypto.GenerateKeyPair()
+	return contractHeader{
+		Transaction: types.Transaction{
+			FileContractRevisions: []types.FileContractRevision{{
+				ParentID:             id,
+				NewValidProofOutputs: []types.SiacoinOutput{{}, {}},
+				UnlockConditions: types.UnlockConditions{
+					PublicKeys: []types.SiaPublicKey{types.Ed25519PublicKey(pk), {}},
+				},
+			}},
+		},
+		SecretKey: sk,
+	}
+}
+
+// persistedSecretKey reads the secret key of the contract with the given id
+// from its header file.
+func persistedSecretKey(t *testing.T, dir string, id types.FileContractID) crypto.SecretKey {
+	t.Helper()
+	f, err := os.Open(filepath.Join(dir, id.String()+contractHeaderExtension))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	stat, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := loadSafeContractHeader(f, int(stat.Size())*decodeMaxSizeMultiplier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h.SecretKey
+}
+
+// TestKeyStore tests moving the secret keys of contracts into the key store.
+func TestKeyStore(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	testDir := build.TempDir("proto", t.Name())
+	rl := ratelimit.NewRateLimit(0, 0, 0)
+	cs, err := NewContractSet(testDir, rl, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Insert a contract while the key store is locked. Its key should be
+	// persisted in the header.
+	h1 := newKeyStoreTestHeader(types.FileContractID{1})
+	rc1, err := cs.managedInsertContract(h1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if persistedSecretKey(t, testDir, rc1.ID) != h1.SecretKey {
+		t.Fatal("key should be in header")
+	}
+	status := cs.KeyStoreStatus()
+	if status.Initialized || status.Unlocked || status.NumUnprotectedKeys != 1 {
+		t.Fatal("unexpected status", status)
+	}
+
+	// Unlock the key store. The key should be moved into the store.
+	key := crypto.NewWalletKey(crypto.HashObject("password"))
+	if err := cs.UnlockKeyStore(key); err != nil {
+		t.Fatal(err)
+	}
+	status = cs.KeyStoreStatus()
+	if !status.Initialized || !status.Unlocked || status.NumKeys != 1 || status.NumUnprotectedKeys != 0 {
+		t.Fatal("unexpected status", status)
+	}
+	if persistedSecretKey(t, testDir, rc1.ID) != (crypto.SecretKey{}) {
+		t.Fatal("key shouldn't be in header anymore")
+	}
+
+	// Insert another contract and update the first one. Neither key should
+	// end up in the headers but both should still be usable.
+	h2 := newKeyStoreTestHeader(types.FileContractID{2})
+	rc2, err := cs.managedInsertContract(h2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc1 := cs.managedMustAcquire(t, rc1.ID)
+	err = sc1.UpdateUtility(modules.ContractUtility{GoodForUpload: true})
+	cs.Return(sc1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, h := range map[types.FileContractID]contractHeader{rc1.ID: h1, rc2.ID: h2} {
+		if persistedSecretKey(t, testDir, id) != (crypto.SecretKey{}) {
+			t.Fatal("key shouldn't be in header")
+		}
+		sc := cs.managedMustAcquire(t, id)
+		err := sc.HasSecretKey()
+		sk := sc.header.SecretKey
+		cs.Return(sc)
+		if err != nil || sk != h.SecretKey {
+			t.Fatal("key should be available", err)
+		}
+	}
+
+	// Reload the set. The keys should be locked but the public keys should
+	// still be known.
+	if err := cs.Close(); err != nil {
+		t.Fatal(err)
+	}
+	cs, err = NewContractSet(testDir, rl, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc1 = cs.managedMustAcquire(t, rc1.ID)
+	err = sc1.HasSecretKey()
+	pk := sc1.PublicKey()
+	cs.Return(sc1)
+	if !errors.Contains(err, ErrContractKeysLocked) {
+		t.Fatal("expected keys to be locked", err)
+	}
+	if pk != h1.SecretKey.PublicKey() {
+		t.Fatal("wrong public key")
+	}
+	if _, err := cs.NewSession(modules.HostDBEntry{}, rc1.ID, 0, nil, nil, nil); !errors.Contains(err, ErrContractKeysLocked) {
+		t.Fatal("expected session creation to fail", err)
+	}
+
+	// Unlocking with the wrong key should fail.
+	newKey := crypto.NewWalletKey(crypto.HashObject("newpassword"))
+	if err := cs.UnlockKeyStore(newKey); !errors.Contains(err, modules.ErrBadEncryptionKey) {
+		t.Fatal("expected unlock to fail", err)
+	}
+
+	// Change the key. This unlocks the store.
+	if err := cs.ChangeKeyStoreKey(key, newKey); err != nil {
+		t.Fatal(err)
+	}
+	sc1 = cs.managedMustAcquire(t, rc1.ID)
+	err = sc1.HasSecretKey()
+	cs.Return(sc1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// After reloading only the new key works.
+	if err := cs.Close(); err != nil {
+		t.Fatal(err)
+	}
+	cs, err = NewContractSet(testDir, rl, modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cs.UnlockKeyStore(key); !errors.Contains(err, modules.ErrBadEncryptionKey) {
+		t.Fatal("expected unlock to fail", err)
+	}
+	if err := cs.UnlockKeyStore(newKey); err != nil {
+		t.Fatal(err)
+	}
+	sc2 := cs.managedMustAcquire(t, rc2.ID)
+	sk := sc2.header.SecretKey
+	cs.Return(sc2)
+	if sk != h2.SecretKey {
+		t.Fatal("wrong key after unlock")
+	}
+
+	// Deleting a contract removes its key from the store.
+	cs.Delete(cs.managedMustAcquire(t, rc2.ID))
+	if status := cs.KeyStoreStatus(); status.NumKeys != 1 {
+		t.Fatal("key wasn't removed", status)
+	}
+}
@@ -30,6 +30,9 @@ func (cs *ContractSet) Renew(oldContract *SafeContract, params modules.ContractP
 // contract that is identical to the old one, and then clears the old one to be
 // empty.
 func (cs *ContractSet) managedNewRenewAndClear(oldContract *SafeContract, params modules.ContractParams, txnBuilder transactionBuilder, tpool transactionPool, hdb hostDB, cancel <-chan struct{}) (rc modules.RenterContract, formationTxnSet []types.Transaction, err error) {
+	if err := oldContract.HasSecretKey(); err != nil {
+		return modules.RenterContract{}, nil, err
+	}
 	// for convenience
 	contract := oldContract.header
 
@@ -396,6 +399,9 @@ func (cs *ContractSet) RenewContract(conn net.Conn, fcid types.FileContractID, p
 		return modules.RenterContract{}, nil, errors.New("RenewContract: failed to acquire contract to renew")
 	}
 	defer cs.Return(oldSC)
+	if err := oldSC.HasSecretKey(); err != nil {
+		return modules.RenterContract{}, nil, err
+	}
 	oldContract := oldSC.header
 	oldRev := oldContract.LastRevision()
 
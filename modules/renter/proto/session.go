@@ -838,6 +838,9 @@ func (cs *ContractSet) NewSession(host modules.HostDBEntry, id types.FileContrac
 		return nil, errors.New("could not locate contract to create session")
 	}
 	defer cs.Return(sc)
+	if err := sc.HasSecretKey(); err != nil {
+		return nil, err
+	}
 	s, err := cs.managedNewSession(host, currentHeight, hdb, cancel)
 	if err != nil {
 		return nil, errors.AddContext(err, "unable to create a new session with the host")
@@ -883,16 +886,21 @@ func (cs *ContractSet) managedNewSession(host modules.HostDBEntry, currentHeight
 	if cs.staticDeps.Disrupt("customResolver") {
 		port := host.NetAddress.Port()
 		host.NetAddress = modules.NetAddress(fmt.Sprintf("127.0.0.1:%s", port))
+		host.AdditionalNetAddresses = nil
 	}
 
-	c, err := (&net.Dialer{
+	// Dial the host's addresses in order of preference.
+	c, _, err := modules.DialNetAddresses(&net.Dialer{
 		Cancel:  cancel,
 		Timeout: sessionDialTimeout,
-	}).Dial("tcp", string(host.NetAddress))
+	}, host.NetAddresses())
 	if err != nil {
 		return nil, errors.AddContext(err, "unsuccessful dial when creating a new session")
 	}
 	conn := ratelimit.NewRLConn(c, cs.staticRL, cancel)
+	if hostRL := cs.managedHostRateLimit(host.PublicKey); hostRL != nil {
+		conn = ratelimit.NewRLConn(conn, hostRL, cancel)
+	}
 
 	closeChan := make(chan struct{})
 	go func() {
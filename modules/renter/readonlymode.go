@@ -0,0 +1,287 @@
+package renter
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// ErrRenterReadOnly is returned when the user tries to upload a file while
+	// the renter is in read-only mode.
+	ErrRenterReadOnly = errors.New("renter is in read-only mode")
+)
+
+// readOnlyMode tracks whether the renter is in read-only mode. The renter is in
+// read-only mode if it was either enabled manually, if the funds of the renter
+// dropped below one of the configured thresholds or if the renter is a
+// secondary replica.
+type readOnlyMode struct {
+	// automatic indicates that the renter entered read-only mode because of
+	// low funds. manual indicates that the user enabled read-only mode.
+	// replica indicates that the renter is a secondary replica.
+	automatic bool
+	manual    bool
+	replica   bool
+	reason    string
+	since     time.Time
+
+	settings modules.ReadOnlySettings
+
+	// inactive is closed while the renter is not in read-only mode. Threads
+	// which need to wait for read-only mode to end can block on it.
+	inactive chan struct{}
+
+	mu sync.Mutex
+}
+
+// newReadOnlyMode creates a new readOnlyMode which is not active.
+func newReadOnlyMode() *readOnlyMode {
+	inactive := make(chan struct{})
+	close(inactive)
+	return &readOnlyMode{
+		inactive: inactive,
+	}
+}
+
+// active returns whether or not read-only mode is active.
+func (rom *readOnlyMode) active() bool {
+	return rom.automatic || rom.manual || rom.replica
+}
+
+// renewalsSuspended returns whether or not read-only mode suspends contract
+// renewals. A secondary replica needs its contracts to pay for downloads which
+// means that being a replica doesn't suspend renewals.
+func (rom *readOnlyMode) renewalsSuspended() bool {
+	return rom.automatic || rom.manual
+}
+
+// managedActive returns whether or not read-only mode is active.
+func (rom *readOnlyMode) managedActive() bool {
+	rom.mu.Lock()
+	defer rom.mu.Unlock()
+	return rom.active()
+}
+
+// managedInactiveChan returns a channel which is closed once the renter is no
+// longer in read-only mode.
+func (rom *readOnlyMode) managedInactiveChan() <-chan struct{} {
+	rom.mu.Lock()
+	defer rom.mu.Unlock()
+	return rom.inactive
+}
+
+// managedSettings returns the thresholds of the read-only mode.
+func (rom *readOnlyMode) managedSettings() modules.ReadOnlySettings {
+	rom.mu.Lock()
+	defer rom.mu.Unlock()
+	return rom.settings
+}
+
+// managedSetSettings updates the thresholds of the read-only mode.
+func (rom *readOnlyMode) managedSetSettings(settings modules.ReadOnlySettings) {
+	rom.mu.Lock()
+	defer rom.mu.Unlock()
+	rom.settings = settings
+}
+
+// managedStatus returns the status of the read-only mode.
+func (rom *readOnlyMode) managedStatus() modules.ReadOnlyStatus {
+	rom.mu.Lock()
+	defer rom.mu.Unlock()
+	return modules.ReadOnlyStatus{
+		Active:   rom.active(),
+		Manual:   rom.manual,
+		Replica:  rom.replica,
+		Reason:   rom.reason,
+		Since:    rom.since,
+		Settings: rom.settings,
+	}
+}
+
+// managedUpdate sets the automatic, manual and replica flags and returns
+// whether or not the read-only mode and the suspension of renewals were toggled
+// by the update.
+func (rom *readOnlyMode) managedUpdate(automatic, manual, replica bool, reason string) (toggled, renewalsToggled bool) {
+	rom.mu.Lock()
+	defer rom.mu.Unlock()
+	wasActive, wasSuspended := rom.active(), rom.renewalsSuspended()
+	rom.automatic = automatic
+	rom.manual = manual
+	rom.replica = replica
+	isActive, isSuspended := rom.active(), rom.renewalsSuspended()
+
+	// Update the reason. A manual activation takes precedence.
+	if manual {
+		rom.reason = readOnlyReasonManual
+	} else if replica {
+		rom.reason = readOnlyReasonReplica
+	} else if automatic {
+		rom.reason = reason
+	} else {
+		rom.reason = ""
+	}
+
+	// Update the channel and start time if read-only mode was toggled.
+	if !wasActive && isActive {
+		rom.since = time.Now()
+		rom.inactive = make(chan struct{})
+	} else if wasActive && !isActive {
+		rom.since = time.Time{}
+		close(rom.inactive)
+	}
+	return wasActive != isActive, wasSuspended != isSuspended
+}
+
+// readOnlyModeTriggered checks the provided balances against the read-only
+// thresholds and returns whether read-only mode should be entered and why.
+func readOnlyModeTriggered(settings modules.ReadOnlySettings, walletBalance, allowanceRemaining types.Currency, allowanceSet bool) (bool, string) {
+	if !settings.MinWalletBalance.IsZero() && walletBalance.Cmp(settings.MinWalletBalance) < 0 {
+		return true, fmt.Sprintf("wallet balance of %v is below the threshold of %v", walletBalance.HumanString(), settings.MinWalletBalance.HumanString())
+	}
+	if allowanceSet && !settings.MinAllowanceRemaining.IsZero() && allowanceRemaining.Cmp(settings.MinAllowanceRemaining) < 0 {
+		return true, fmt.Sprintf("remaining allowance of %v is below the threshold of %v", allowanceRemaining.HumanString(), settings.MinAllowanceRemaining.HumanString())
+	}
+	return false, ""
+}
+
+// ReadOnlyStatus returns the status of the renter's read-only mode.
+func (r *Renter) ReadOnlyStatus() (modules.ReadOnlyStatus, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.ReadOnlyStatus{}, err
+	}
+	defer r.tg.Done()
+	return r.staticReadOnlyMode.managedStatus(), nil
+}
+
+// SetReadOnlyMode manually enables or disables the renter's read-only mode.
+// Disabling read-only mode manually doesn't leave read-only mode if the funds
+// of the renter are still below the configured thresholds.
+func (r *Renter) SetReadOnlyMode(enabled bool) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	// Persist the change.
+	id := r.mu.Lock()
+	r.persist.ReadOnlyManual = enabled
+	err := r.saveSync()
+	r.mu.Unlock(id)
+	if err != nil {
+		return errors.AddContext(err, "failed to persist read-only mode")
+	}
+	return r.managedUpdateReadOnlyMode()
+}
+
+// SetReadOnlySettings updates the thresholds which cause the renter to enter
+// read-only mode automatically.
+func (r *Renter) SetReadOnlySettings(settings modules.ReadOnlySettings) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	// Persist the change.
+	id := r.mu.Lock()
+	r.persist.ReadOnlySettings = settings
+	err := r.saveSync()
+	r.mu.Unlock(id)
+	if err != nil {
+		return errors.AddContext(err, "failed to persist read-only settings")
+	}
+	r.staticReadOnlyMode.managedSetSettings(settings)
+	return r.managedUpdateReadOnlyMode()
+}
+
+// managedBlockUntilWritable blocks until the renter is no longer in read-only
+// mode. It returns 'false' if the renter shut down before that.
+func (r *Renter) managedBlockUntilWritable() bool {
+	for r.staticReadOnlyMode.managedActive() {
+		select {
+		case <-r.tg.StopChan():
+			return false
+		case <-r.staticReadOnlyMode.managedInactiveChan():
+		}
+	}
+	return true
+}
+
+// managedUpdateReadOnlyMode checks the wallet balance and remaining allowance
+// against the read-only thresholds and enters or leaves read-only mode
+// accordingly.
+func (r *Renter) managedUpdateReadOnlyMode() error {
+	settings := r.staticReadOnlyMode.managedSettings()
+
+	// Fetch the balances.
+	walletBalance, _, _, err := r.w.ConfirmedBalance()
+	if err != nil {
+		return errors.AddContext(err, "failed to get wallet balance")
+	}
+	spending, err := r.hostContractor.PeriodSpending()
+	if err != nil {
+		return errors.AddContext(err, "failed to get period spending")
+	}
+	allowanceSet := !r.hostContractor.Allowance().Funds.IsZero()
+	automatic, reason := readOnlyModeTriggered(settings, walletBalance, spending.Unspent, allowanceSet)
+
+	id := r.mu.RLock()
+	manual := r.persist.ReadOnlyManual
+	replica := r.persist.Replication.Role == modules.ReplicationRoleSecondary
+	r.mu.RUnlock(id)
+
+	// Update the mode. If the suspension of renewals was toggled, update the
+	// contractor.
+	toggled, renewalsToggled := r.staticReadOnlyMode.managedUpdate(automatic, manual, replica, reason)
+	status := r.staticReadOnlyMode.managedStatus()
+	if renewalsToggled {
+		r.hostContractor.SuspendRenewals(automatic || manual)
+	}
+	if toggled {
+		if status.Active {
+			r.log.Println("Entering read-only mode:", status.Reason)
+		} else {
+			r.log.Println("Leaving read-only mode")
+		}
+	}
+
+	// Update the alert. Being a secondary replica is intended and doesn't
+	// require an alert.
+	if !automatic && !manual {
+		r.staticAlerter.UnregisterAlert(modules.AlertIDRenterReadOnlyMode)
+		return nil
+	}
+	severity := modules.AlertSeverity(modules.SeverityCritical)
+	if status.Manual {
+		severity = modules.SeverityWarning
+	}
+	r.staticAlerter.RegisterAlert(modules.AlertIDRenterReadOnlyMode, AlertMSGRenterReadOnlyMode, status.Reason, severity)
+	return nil
+}
+
+// threadedMonitorReadOnlyMode periodically checks whether the renter needs to
+// enter or leave read-only mode.
+func (r *Renter) threadedMonitorReadOnlyMode() {
+	defer modules.RecoverPanic("renter")
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	for {
+		err := r.managedUpdateReadOnlyMode()
+		if err != nil {
+			r.log.Println("WARN: failed to update read-only mode:", err)
+		}
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-time.After(readOnlyModeCheckInterval):
+		}
+	}
+}
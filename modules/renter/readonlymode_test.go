@@ -0,0 +1,112 @@
+package renter
+
+import (
+	"testing"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestReadOnlyModeTriggered is a unit test for readOnlyModeTriggered.
+func TestReadOnlyModeTriggered(t *testing.T) {
+	t.Parallel()
+
+	settings := modules.ReadOnlySettings{
+		MinAllowanceRemaining: types.SiacoinPrecision.Mul64(10),
+		MinWalletBalance:      types.SiacoinPrecision.Mul64(100),
+	}
+	sc := types.SiacoinPrecision
+
+	tests := []struct {
+		settings           modules.ReadOnlySettings
+		walletBalance      types.Currency
+		allowanceRemaining types.Currency
+		allowanceSet       bool
+		triggered          bool
+	}{
+		// Disabled thresholds never trigger.
+		{modules.ReadOnlySettings{}, types.ZeroCurrency, types.ZeroCurrency, true, false},
+		// Enough funds.
+		{settings, sc.Mul64(100), sc.Mul64(10), true, false},
+		// Wallet balance too low.
+		{settings, sc.Mul64(99), sc.Mul64(10), true, true},
+		// Allowance too low.
+		{settings, sc.Mul64(100), sc.Mul64(9), true, true},
+		// Allowance too low but no allowance set.
+		{settings, sc.Mul64(100), sc.Mul64(9), false, false},
+	}
+	for i, test := range tests {
+		triggered, reason := readOnlyModeTriggered(test.settings, test.walletBalance, test.allowanceRemaining, test.allowanceSet)
+		if triggered != test.triggered {
+			t.Errorf("%v: expected %v but got %v", i, test.triggered, triggered)
+		}
+		if triggered && reason == "" {
+			t.Errorf("%v: expected a reason", i)
+		}
+	}
+}
+
+// TestReadOnlyModeUpdate is a unit test for toggling the readOnlyMode.
+func TestReadOnlyModeUpdate(t *testing.T) {
+	t.Parallel()
+
+	rom := newReadOnlyMode()
+	if rom.managedActive() {
+		t.Fatal("read-only mode shouldn't be active")
+	}
+	select {
+	case <-rom.managedInactiveChan():
+	default:
+		t.Fatal("inactive chan should be closed")
+	}
+
+	// Enter read-only mode automatically.
+	if toggled, renewalsToggled := rom.managedUpdate(true, false, false, "low funds"); !toggled || !renewalsToggled {
+		t.Fatal("expected mode and renewals to be toggled")
+	}
+	status := rom.managedStatus()
+	if !status.Active || status.Manual || status.Reason != "low funds" || status.Since.IsZero() {
+		t.Fatal("unexpected status", status)
+	}
+	inactive := rom.managedInactiveChan()
+	select {
+	case <-inactive:
+		t.Fatal("inactive chan shouldn't be closed")
+	default:
+	}
+
+	// Enable it manually as well, this shouldn't toggle the mode.
+	if toggled, renewalsToggled := rom.managedUpdate(true, true, false, "low funds"); toggled || renewalsToggled {
+		t.Fatal("mode shouldn't be toggled")
+	}
+	if status := rom.managedStatus(); !status.Manual || status.Reason != readOnlyReasonManual {
+		t.Fatal("unexpected status", status)
+	}
+
+	// Leave read-only mode.
+	if toggled, _ := rom.managedUpdate(false, false, false, ""); !toggled {
+		t.Fatal("expected mode to be toggled")
+	}
+	select {
+	case <-inactive:
+	default:
+		t.Fatal("inactive chan should be closed")
+	}
+	if status := rom.managedStatus(); status.Active || status.Reason != "" || !status.Since.IsZero() {
+		t.Fatal("unexpected status", status)
+	}
+
+	// Become a secondary replica. The renter should be read-only without
+	// suspending renewals.
+	if toggled, renewalsToggled := rom.managedUpdate(false, false, true, ""); !toggled || renewalsToggled {
+		t.Fatal("expected only the mode to be toggled")
+	}
+	if status := rom.managedStatus(); !status.Active || !status.Replica || status.Reason != readOnlyReasonReplica {
+		t.Fatal("unexpected status", status)
+	}
+
+	// Low funds while being a replica suspend renewals.
+	if toggled, renewalsToggled := rom.managedUpdate(true, false, true, "low funds"); toggled || !renewalsToggled {
+		t.Fatal("expected only renewals to be toggled")
+	}
+}
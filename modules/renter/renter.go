@@ -38,7 +38,6 @@ import (
 	"gitlab.com/NebulousLabs/ratelimit"
 	"gitlab.com/NebulousLabs/siamux"
 	"gitlab.com/NebulousLabs/threadgroup"
-	"gitlab.com/NebulousLabs/writeaheadlog"
 
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/crypto"
@@ -93,6 +92,14 @@ type hostContractor interface {
 	// ChurnStatus returns contract churn stats for the current period.
 	ChurnStatus() modules.ContractorChurnStatus
 
+	// MigrationPlan returns the contracts queued for churn during the last
+	// contract maintenance.
+	MigrationPlan() modules.ContractorMigrationPlan
+
+	// ApproveMigrations approves churning the provided deferred contracts
+	// regardless of the churn budget.
+	ApproveMigrations(ids []types.FileContractID) error
+
 	// ContractUtility returns the utility field for a given contract, along
 	// with a bool indicating if it exists.
 	ContractUtility(types.SiaPublicKey) (modules.ContractUtility, bool)
@@ -101,6 +108,18 @@ type hostContractor interface {
 	// watchdog.
 	ContractStatus(fcID types.FileContractID) (modules.ContractWatchStatus, bool)
 
+	// ChangeContractKeyStoreKey re-encrypts the contract key store with a new
+	// key.
+	ChangeContractKeyStoreKey(oldKey, newKey crypto.CipherKey) error
+
+	// ContractKeyStoreStatus returns information about the contract key
+	// store.
+	ContractKeyStoreStatus() modules.ContractKeyStoreStatus
+
+	// UnlockContractKeyStore unlocks the contract key store and moves the
+	// secret keys of all contracts into it.
+	UnlockContractKeyStore(key crypto.CipherKey) error
+
 	// CurrentPeriod returns the height at which the current allowance period
 	// began.
 	CurrentPeriod() types.BlockHeight
@@ -113,6 +132,22 @@ type hostContractor interface {
 	// billing period.
 	PeriodSpending() (modules.ContractorSpending, error)
 
+	// SpendingForecast projects the spending for the remainder of the current
+	// period.
+	SpendingForecast() (modules.SpendingForecast, error)
+
+	// LockedFunds returns the renter funds locked in unexpired file
+	// contracts and when they are released to the wallet.
+	LockedFunds() (modules.ContractorLockedFunds, error)
+
+	// AllowanceAlertSettings returns the rules used to alert the user about
+	// the spending of the allowance.
+	AllowanceAlertSettings() modules.AllowanceAlertSettings
+
+	// SetAllowanceAlertSettings updates the rules used to alert the user about
+	// the spending of the allowance.
+	SetAllowanceAlertSettings(settings modules.AllowanceAlertSettings) error
+
 	// ProvidePayment takes a stream and a set of payment details and handles
 	// the payment for an RPC by sending and processing payment request and
 	// response objects to the host. It returns an error in case of failure.
@@ -135,6 +170,10 @@ type hostContractor interface {
 	// Session creates a Session from the specified contract ID.
 	Session(types.SiaPublicKey, <-chan struct{}) (contractor.Session, error)
 
+	// SetHostRateLimit sets a ratelimit which is applied to all RHP2
+	// connections to the host. Passing a nil ratelimit removes it.
+	SetHostRateLimit(types.SiaPublicKey, *ratelimit.RateLimit)
+
 	// RecoverableContracts returns the contracts that the contractor deems
 	// recoverable. That means they are not expired yet and also not part of the
 	// active contracts. Usually this should return an empty slice unless the host
@@ -152,6 +191,10 @@ type hostContractor interface {
 	// given contract with that host.
 	RenewContract(conn net.Conn, fcid types.FileContractID, params modules.ContractParams, txnBuilder modules.TransactionBuilder, tpool modules.TransactionPool, hdb modules.HostDB, pt *modules.RPCPriceTable) (modules.RenterContract, []types.Transaction, error)
 
+	// SuspendRenewals suspends or resumes the formation and renewal of
+	// contracts.
+	SuspendRenewals(suspend bool)
+
 	// Synced returns a channel that is closed when the contractor is fully
 	// synced with the peer-to-peer network.
 	Synced() <-chan struct{}
@@ -206,6 +249,10 @@ type Renter struct {
 	// Cache the hosts from the last price estimation result.
 	lastEstimationHosts []modules.HostDBEntry
 
+	// versionsCollectedPeriod is the period in which the versions of the
+	// renter's files were last garbage collected.
+	versionsCollectedPeriod types.BlockHeight
+
 	// staticBubbleScheduler manages the bubble requests for the renter
 	staticBubbleScheduler *bubbleScheduler
 
@@ -257,10 +304,21 @@ type Renter struct {
 	staticAlerter                      *modules.GenericAlerter
 	staticFileSystem                   *filesystem.FileSystem
 	staticFuseManager                  renterFuseManager
+	staticReadOnlyMode                 *readOnlyMode
+	staticReplication                  *replication
+	staticMetadataMirror               *metadataMirror
+	staticSearchIndex                  *searchIndex
+	staticHostBandwidthLimits          *hostBandwidthLimits
+	staticScheduler                    *scheduler
+	staticChaos                        *chaosMode
+	staticFailureDomains               *failureDomains
+	staticPriorityRepairFiles          *priorityRepairFiles
+	staticPinnedCache                  *pinnedCache
+	staticFileSpending                 *fileSpending
 	staticStreamBufferSet              *streamBufferSet
 	tg                                 threadgroup.ThreadGroup
 	tpool                              modules.TransactionPool
-	wal                                *writeaheadlog.WAL
+	staticWALs                         *filesystem.WALPool
 	staticWorkerPool                   *workerPool
 	staticMux                          *siamux.SiaMux
 	memoryManager                      *memoryManager
@@ -624,6 +682,8 @@ func (r *Renter) SetSettings(s modules.RenterSettings) error {
 	id := r.mu.Lock()
 	r.persist.MaxDownloadSpeed = s.MaxDownloadSpeed
 	r.persist.MaxUploadSpeed = s.MaxUploadSpeed
+	r.persist.VerifyUploads = s.VerifyUploads
+	r.persist.MaxFileVersions = s.MaxFileVersions
 	err = r.saveSync()
 	r.mu.Unlock(id)
 	if err != nil {
@@ -724,6 +784,33 @@ func (r *Renter) Host(spk types.SiaPublicKey) (modules.HostDBEntry, bool, error)
 // hostdb is completed.
 func (r *Renter) InitialScanComplete() (bool, error) { return r.hostDB.InitialScanComplete() }
 
+// HostDBExport returns a dump of the state of the hostdb.
+func (r *Renter) HostDBExport() (modules.HostDBDump, error) {
+	return r.hostDB.Export()
+}
+
+// HostDBImport restores the hostdb from a dump or merges the dump's scan
+// histories into it.
+func (r *Renter) HostDBImport(dump modules.HostDBDump, merge bool) error {
+	return r.hostDB.Import(dump, merge)
+}
+
+// HostDBPriceIndex returns the historic price snapshots of the host network.
+func (r *Renter) HostDBPriceIndex() ([]modules.HostDBPriceSnapshot, error) {
+	return r.hostDB.PriceIndex()
+}
+
+// HostDBScanHistory returns the detailed scan history of a host.
+func (r *Renter) HostDBScanHistory(pk types.SiaPublicKey) ([]modules.HostDBScanRecord, error) {
+	return r.hostDB.ScanHistory(pk)
+}
+
+// HostDBSimulateAllowance replays the recorded settings of the hosts in the
+// hostdb over the provided window against an allowance.
+func (r *Renter) HostDBSimulateAllowance(a modules.Allowance, window time.Duration) (modules.AllowanceSimulation, error) {
+	return r.hostDB.SimulateAllowance(a, window)
+}
+
 // ScoreBreakdown returns the score breakdown
 func (r *Renter) ScoreBreakdown(e modules.HostDBEntry) (modules.HostScoreBreakdown, error) {
 	return r.hostDB.ScoreBreakdown(e)
@@ -772,6 +859,22 @@ func (r *Renter) ContractorChurnStatus() modules.ContractorChurnStatus {
 	return r.hostContractor.ChurnStatus()
 }
 
+// ContractorMigrationPlan returns the contracts the contractor queued for
+// replacement during the last contract maintenance.
+func (r *Renter) ContractorMigrationPlan() modules.ContractorMigrationPlan {
+	return r.hostContractor.MigrationPlan()
+}
+
+// ApproveContractMigrations approves churning the provided deferred contracts
+// during the next contract maintenance regardless of the churn budget.
+func (r *Renter) ApproveContractMigrations(ids []types.FileContractID) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	return r.hostContractor.ApproveMigrations(ids)
+}
+
 // InitRecoveryScan starts scanning the whole blockchain for recoverable
 // contracts within a separate thread.
 func (r *Renter) InitRecoveryScan() error {
@@ -794,6 +897,27 @@ func (r *Renter) PeriodSpending() (modules.ContractorSpending, error) {
 	return r.hostContractor.PeriodSpending()
 }
 
+// SpendingForecast returns the host contractor's spending forecast.
+func (r *Renter) SpendingForecast() (modules.SpendingForecast, error) {
+	return r.hostContractor.SpendingForecast()
+}
+
+// LockedFunds returns the renter funds locked in the host contractor's
+// contracts.
+func (r *Renter) LockedFunds() (modules.ContractorLockedFunds, error) {
+	return r.hostContractor.LockedFunds()
+}
+
+// AllowanceAlertSettings returns the host contractor's allowance alert rules.
+func (r *Renter) AllowanceAlertSettings() modules.AllowanceAlertSettings {
+	return r.hostContractor.AllowanceAlertSettings()
+}
+
+// SetAllowanceAlertSettings sets the host contractor's allowance alert rules.
+func (r *Renter) SetAllowanceAlertSettings(settings modules.AllowanceAlertSettings) error {
+	return r.hostContractor.SetAllowanceAlertSettings(settings)
+}
+
 // RecoverableContracts returns the host contractor's recoverable contracts.
 func (r *Renter) RecoverableContracts() []modules.RecoverableContract {
 	return r.hostContractor.RecoverableContracts()
@@ -817,6 +941,10 @@ func (r *Renter) Settings() (modules.RenterSettings, error) {
 		return modules.RenterSettings{}, errors.AddContext(err, "error getting IPViolationsCheck:")
 	}
 	paused, endTime := r.uploadHeap.managedPauseStatus()
+	id := r.mu.RLock()
+	verifyUploads := r.persist.VerifyUploads
+	maxFileVersions := r.persist.MaxFileVersions
+	r.mu.RUnlock(id)
 	return modules.RenterSettings{
 		Allowance:        r.hostContractor.Allowance(),
 		IPViolationCheck: enabled,
@@ -826,6 +954,8 @@ func (r *Renter) Settings() (modules.RenterSettings, error) {
 			Paused:       paused,
 			PauseEndTime: endTime,
 		},
+		VerifyUploads:   verifyUploads,
+		MaxFileVersions: maxFileVersions,
 	}, nil
 }
 
@@ -836,6 +966,7 @@ func (r *Renter) ProcessConsensusChange(cc modules.ConsensusChange) {
 	r.mu.Unlock(id)
 	if cc.Synced {
 		_ = r.tg.Launch(r.staticWorkerPool.callUpdate)
+		_ = r.tg.Launch(r.managedTryCollectFileVersions)
 	}
 }
 
@@ -928,6 +1059,17 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 	r.staticBubbleScheduler = newBubbleScheduler(r)
 	r.staticStreamBufferSet = newStreamBufferSet(&r.tg)
 	r.staticUploadChunkDistributionQueue = newUploadChunkDistributionQueue(r)
+	r.staticReadOnlyMode = newReadOnlyMode()
+	r.staticReplication = newReplication()
+	r.staticMetadataMirror = newMetadataMirror()
+	r.staticSearchIndex = newSearchIndex()
+	r.staticHostBandwidthLimits = newHostBandwidthLimits()
+	r.staticScheduler = newScheduler()
+	r.staticChaos = newChaosMode()
+	r.staticFailureDomains = newFailureDomains()
+	r.staticPriorityRepairFiles = newPriorityRepairFiles()
+	r.staticPinnedCache = newPinnedCache()
+	r.staticFileSpending = newFileSpending(filepath.Join(persistDir, fileSpendingFile))
 	r.staticRRS = newReadRegistryStats(ReadRegistryBackgroundTimeout, readRegistryStatsInterval, readRegistryStatsDecay, readRegistryStatsPercentile)
 	close(r.uploadHeap.pauseChan)
 
@@ -990,6 +1132,12 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 	r.managedUpdateRenterContractsAndUtilities()
 	go r.threadedUpdateRenterContractsAndUtilities()
 
+	// Remove the upload streams of files which were deleted while the renter
+	// was offline.
+	if err := r.managedPruneUploadStreams(); err != nil {
+		r.log.Println("WARN: failed to prune upload streams:", err)
+	}
+
 	// Spin up background threads which are not depending on the renter being
 	// up-to-date with consensus.
 	if !r.deps.Disrupt("DisableRepairAndHealthLoops") {
@@ -1005,6 +1153,9 @@ func renterBlockingStartup(g modules.Gateway, cs modules.ConsensusSet, tpool mod
 	// for bubble updates are processed.
 	go r.staticBubbleScheduler.callThreadedProcessBubbleUpdates()
 
+	// Spin up the thread that maintains the search index.
+	go r.threadedUpdateSearchIndex()
+
 	// Unsubscribe on shutdown.
 	err = r.tg.OnStop(func() error {
 		cs.Unsubscribe(r)
@@ -1043,6 +1194,27 @@ func renterAsyncStartup(r *Renter, cs modules.ConsensusSet) error {
 	if !r.deps.Disrupt("DisableSnapshotSync") {
 		go r.threadedSynchronizeSnapshots()
 	}
+	// Spin up the thread that monitors the funds for read-only mode.
+	go r.threadedMonitorReadOnlyMode()
+	// Spin up the thread that replicates the renter's metadata.
+	go r.threadedReplicate()
+	// Spin up the thread that mirrors the renter's metadata to its hosts.
+	go r.threadedMirrorMetadata()
+	// Spin up the thread that compacts the siafiles.
+	if !r.deps.Disrupt("DisableSiaFileCompaction") {
+		go r.threadedCompactSiaFiles()
+	}
+	// Spin up the thread that verifies uploads against their local copies.
+	go r.threadedVerifyUploads()
+	// Spin up the thread that resumes the restricted bulk jobs when a window
+	// of the scheduler starts.
+	go r.threadedScheduleBulkJobs()
+	// Spin up the thread that removes expired files.
+	go r.threadedExpireFiles()
+	// Spin up the thread that loads the pinned ranges of files into memory.
+	go r.threadedLoadPinnedRanges()
+	// Spin up the thread that periodically saves the spending per file.
+	go r.threadedSaveFileSpending()
 	return nil
 }
 
@@ -448,6 +448,7 @@ func (r *Renter) managedSubDirectories(siaPath modules.SiaPath) ([]modules.SiaPa
 // threadedStuckFileLoop works through the renter directory and finds the stuck
 // chunks and tries to repair them
 func (r *Renter) threadedStuckFileLoop() {
+	defer modules.RecoverPanic("renter")
 	err := r.tg.Add()
 	if err != nil {
 		return
@@ -470,6 +471,11 @@ func (r *Renter) threadedStuckFileLoop() {
 			return
 		}
 
+		// Wait until the renter is no longer in read-only mode.
+		if !r.managedBlockUntilWritable() {
+			return
+		}
+
 		// As we add stuck chunks to the upload heap we want to remember the
 		// directories they came from so we can call bubble to update the
 		// filesystem
@@ -553,6 +559,7 @@ func (r *Renter) threadedStuckFileLoop() {
 // threadedUpdateRenterHealth reads all the siafiles in the renter, calculates
 // the health of each file and updates the folder metadata
 func (r *Renter) threadedUpdateRenterHealth() {
+	defer modules.RecoverPanic("renter")
 	err := r.tg.Add()
 	if err != nil {
 		return
@@ -560,7 +567,7 @@ func (r *Renter) threadedUpdateRenterHealth() {
 	defer r.tg.Done()
 
 	// Loop until the renter has shutdown or until the renter's top level files
-	// directory has a LasHealthCheckTime within the healthCheckInterval
+	// directory has a LasHealthCheckTime within the health check interval
 	for {
 		select {
 		// Check to make sure renter hasn't been shutdown
@@ -588,6 +595,7 @@ func (r *Renter) threadedUpdateRenterHealth() {
 		// folder is inside the health check interval. If so, the whole
 		// filesystem has been checked recently, and we can sleep until the
 		// least recent check is outside the check interval.
+		healthCheckInterval := modules.CurrentProfile().HealthCheckInterval
 		timeSinceLastCheck := time.Since(lastHealthCheckTime)
 		if timeSinceLastCheck < healthCheckInterval {
 			// Sleep until the least recent check is outside the check interval.
@@ -654,6 +662,7 @@ func (r *Renter) callPrepareForBubble(rootDir modules.SiaPath, force bool) (*uni
 	// Initiate helpers
 	urp := r.newUniqueRefreshPaths()
 	aggregateLastHealthCheckTime := time.Now()
+	healthCheckInterval := modules.CurrentProfile().HealthCheckInterval
 
 	// Add the rootDir to urp.
 	err := urp.callAdd(rootDir)
@@ -789,6 +798,10 @@ func (r *Renter) managedUpdateFileMetadata(sf *filesystem.FileNode, offlineMap,
 	sf.SetLastHealthCheckTime()
 	// Update the cached expiration of the siafile.
 	_ = sf.Expiration(contracts)
+	// Track the file if it has a high repair priority.
+	if sf.RepairPriority() == modules.FileRepairPriorityHigh {
+		r.staticPriorityRepairFiles.callUpdate(r.staticFileSystem.FileSiaPath(sf), modules.FileRepairPriorityHigh)
+	}
 	// Save the metadata.
 	err = sf.SaveMetadata()
 	if err != nil {
@@ -0,0 +1,290 @@
+package renter
+
+import (
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+	"go.sia.tech/siad/modules/renter/filesystem/siafile"
+)
+
+type (
+	// priorityRepairFiles tracks the files with a high repair priority. Their
+	// chunks are added to the upload heap before the chunks of the directory
+	// heap's worst directories. Files are added when their priority is set and
+	// when their metadata is updated, which means that the priorities of
+	// existing files are picked up by the health loop after a restart.
+	priorityRepairFiles struct {
+		files map[modules.SiaPath]struct{}
+		mu    sync.Mutex
+	}
+
+	// repairedChunk is a chunk which was repaired recently. It is used to
+	// compute the repair throughput of the renter.
+	repairedChunk struct {
+		bytes     uint64
+		popped    time.Time
+		completed time.Time
+	}
+
+	// queuedChunk is a snapshot of a chunk in the upload heap.
+	queuedChunk struct {
+		fileUID   siafile.SiafileUID
+		fileEntry *filesystem.FileNode
+		priority  modules.FileRepairPriority
+		health    float64
+		length    uint64
+		repairing bool
+	}
+)
+
+// newPriorityRepairFiles creates an empty set of priority repair files.
+func newPriorityRepairFiles() *priorityRepairFiles {
+	return &priorityRepairFiles{
+		files: make(map[modules.SiaPath]struct{}),
+	}
+}
+
+// callUpdate adds or removes the file from the set depending on its priority.
+func (prf *priorityRepairFiles) callUpdate(siaPath modules.SiaPath, priority modules.FileRepairPriority) {
+	prf.mu.Lock()
+	defer prf.mu.Unlock()
+	if priority == modules.FileRepairPriorityHigh {
+		prf.files[siaPath] = struct{}{}
+	} else {
+		delete(prf.files, siaPath)
+	}
+}
+
+// callSiaPaths returns the siapaths of the files in the set.
+func (prf *priorityRepairFiles) callSiaPaths() []modules.SiaPath {
+	prf.mu.Lock()
+	defer prf.mu.Unlock()
+	siaPaths := make([]modules.SiaPath, 0, len(prf.files))
+	for siaPath := range prf.files {
+		siaPaths = append(siaPaths, siaPath)
+	}
+	sort.Slice(siaPaths, func(i, j int) bool {
+		return siaPaths[i].String() < siaPaths[j].String()
+	})
+	return siaPaths
+}
+
+// managedRecordRepair records a chunk which was repaired successfully.
+func (uh *uploadHeap) managedRecordRepair(uuc *unfinishedUploadChunk) {
+	uuc.mu.Lock()
+	rc := repairedChunk{
+		bytes:     uuc.length,
+		popped:    uuc.chunkPoppedFromHeapTime,
+		completed: uuc.chunkCompleteTime,
+	}
+	uuc.mu.Unlock()
+	if rc.popped.IsZero() {
+		rc.popped = rc.completed
+	}
+
+	uh.mu.Lock()
+	defer uh.mu.Unlock()
+	uh.repairedChunks = append(uh.repairedChunks, rc)
+	uh.pruneRepairedChunks(time.Now())
+}
+
+// pruneRepairedChunks removes the chunks which were completed before the
+// repair throughput window.
+func (uh *uploadHeap) pruneRepairedChunks(now time.Time) {
+	i := 0
+	for i < len(uh.repairedChunks) && now.Sub(uh.repairedChunks[i].completed) > repairThroughputWindow {
+		i++
+	}
+	uh.repairedChunks = uh.repairedChunks[i:]
+}
+
+// repairThroughput returns the number of bytes per second repaired within the
+// repair throughput window. The throughput is measured from the time the
+// first of the chunks was popped from the heap.
+func (uh *uploadHeap) repairThroughput(now time.Time) uint64 {
+	uh.pruneRepairedChunks(now)
+	if len(uh.repairedChunks) == 0 {
+		return 0
+	}
+	var bytes uint64
+	start := now
+	for _, rc := range uh.repairedChunks {
+		bytes += rc.bytes
+		if rc.popped.Before(start) {
+			start = rc.popped
+		}
+	}
+	elapsed := now.Sub(start)
+	if elapsed > repairThroughputWindow {
+		elapsed = repairThroughputWindow
+	}
+	if elapsed < time.Second {
+		elapsed = time.Second
+	}
+	return uint64(float64(bytes) / elapsed.Seconds())
+}
+
+// managedQueuedChunks returns snapshots of the chunks which are being repaired
+// followed by the chunks in the heap in the order they are popped, as well as
+// the current repair throughput.
+func (uh *uploadHeap) managedQueuedChunks() ([]queuedChunk, uint64) {
+	uh.mu.Lock()
+	defer uh.mu.Unlock()
+	snapshot := func(uuc *unfinishedUploadChunk, repairing bool) queuedChunk {
+		return queuedChunk{
+			fileUID:   uuc.id.fileUID,
+			fileEntry: uuc.fileEntry,
+			priority:  uuc.staticRepairPriority,
+			health:    uuc.health,
+			length:    uuc.length,
+			repairing: repairing,
+		}
+	}
+	chunks := make([]queuedChunk, 0, len(uh.repairingChunks)+len(uh.heap))
+	for _, uuc := range uh.repairingChunks {
+		chunks = append(chunks, snapshot(uuc, true))
+	}
+	sort.Slice(chunks, func(i, j int) bool {
+		return chunks[i].fileUID < chunks[j].fileUID
+	})
+	heapCopy := append(uploadChunkHeap(nil), uh.heap...)
+	sort.Sort(heapCopy)
+	for _, uuc := range heapCopy {
+		chunks = append(chunks, snapshot(uuc, false))
+	}
+	return chunks, uh.repairThroughput(time.Now())
+}
+
+// RepairQueue returns the chunks which are being repaired or waiting to be
+// repaired, grouped by file in the order they are repaired.
+func (r *Renter) RepairQueue() modules.RepairQueue {
+	chunks, throughput := r.uploadHeap.managedQueuedChunks()
+	queue := modules.RepairQueue{
+		Throughput: throughput,
+		Files:      []modules.RepairQueueFile{},
+	}
+	files := make(map[siafile.SiafileUID]int)
+	var cumulativeBytes uint64
+	for _, chunk := range chunks {
+		cumulativeBytes += chunk.length
+		i, exists := files[chunk.fileUID]
+		if !exists {
+			i = len(queue.Files)
+			files[chunk.fileUID] = i
+			queue.Files = append(queue.Files, modules.RepairQueueFile{
+				SiaPath:  r.staticFileSystem.FileSiaPath(chunk.fileEntry),
+				Priority: chunk.priority,
+			})
+		}
+		f := &queue.Files[i]
+		if chunk.health > f.Health {
+			f.Health = chunk.health
+		}
+		if chunk.repairing {
+			f.RepairingChunks++
+		} else {
+			f.QueuedChunks++
+		}
+		f.RemainingBytes += chunk.length
+		if throughput > 0 {
+			f.ETA = time.Duration(float64(cumulativeBytes) / float64(throughput) * float64(time.Second))
+		}
+	}
+	return queue
+}
+
+// SetFileRepairPriority sets the priority with which the file at siaPath is
+// repaired.
+func (r *Renter) SetFileRepairPriority(siaPath modules.SiaPath, priority modules.FileRepairPriority) (err error) {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	if priority < modules.FileRepairPriorityLow || priority > modules.FileRepairPriorityHigh {
+		return modules.ErrInvalidFileRepairPriority
+	}
+	// Open the file.
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, entry.Close())
+	}()
+	// Update the file.
+	if err := entry.SetRepairPriority(priority); err != nil {
+		return err
+	}
+	r.staticPriorityRepairFiles.callUpdate(siaPath, priority)
+
+	// Wake up the repair loop to repair the file right away.
+	if priority == modules.FileRepairPriorityHigh {
+		select {
+		case r.uploadHeap.repairNeeded <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// managedAddPriorityChunksToHeap adds the chunks of the files with a high
+// repair priority which need to be repaired to the upload heap. It returns the
+// number of chunks which were added.
+func (r *Renter) managedAddPriorityChunksToHeap(hosts map[string]struct{}, offline, goodForRenew map[string]bool) int {
+	var added int
+	for _, siaPath := range r.staticPriorityRepairFiles.callSiaPaths() {
+		if r.uploadHeap.managedLen() >= maxUploadHeapChunks {
+			break
+		}
+		file, err := r.staticFileSystem.OpenSiaFile(siaPath)
+		if errors.Contains(err, filesystem.ErrNotExist) || os.IsNotExist(err) {
+			// The file was deleted or renamed. Renamed files are added back
+			// once their metadata is updated.
+			r.staticPriorityRepairFiles.callUpdate(siaPath, modules.FileRepairPriorityNormal)
+			continue
+		} else if err != nil {
+			r.repairLog.Printf("WARN: unable to open priority file %v: %v", siaPath, err)
+			continue
+		}
+		if file.RepairPriority() != modules.FileRepairPriorityHigh {
+			r.staticPriorityRepairFiles.callUpdate(siaPath, file.RepairPriority())
+		} else if file.NumChunks() > file.NumStuckChunks() && modules.NeedsRepair(file.Metadata().CachedHealth) {
+			added += r.managedPushPriorityChunks(file, hosts, offline, goodForRenew)
+		}
+		if err := file.Close(); err != nil {
+			r.repairLog.Println("WARN: unable to close file:", err)
+		}
+	}
+	return added
+}
+
+// managedPushPriorityChunks builds the unfinished chunks of a file with a high
+// repair priority and pushes them onto the upload heap.
+func (r *Renter) managedPushPriorityChunks(file *filesystem.FileNode, hosts map[string]struct{}, offline, goodForRenew map[string]bool) int {
+	var added int
+	for _, chunk := range r.managedBuildUnfinishedChunks(file, hosts, targetUnstuckChunks, offline, goodForRenew, r.repairMemoryManager) {
+		pushed := false
+		if r.uploadHeap.managedLen() < maxUploadHeapChunks && !r.uploadHeap.managedExists(chunk.id) {
+			var err error
+			pushed, err = r.managedPushChunkForRepair(chunk, chunkTypeLocalChunk)
+			if err != nil {
+				r.repairLog.Println("WARN: error pushing priority chunk for repair:", err)
+			}
+		}
+		if !pushed {
+			if err := chunk.fileEntry.Close(); err != nil {
+				r.repairLog.Println("WARN: unable to close file entry:", err)
+			}
+			continue
+		}
+		added++
+	}
+	return added
+}
@@ -0,0 +1,209 @@
+package renter
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+	"go.sia.tech/siad/modules/renter/filesystem/siafile"
+	"go.sia.tech/siad/siatest/dependencies"
+)
+
+// TestUploadChunkHeapRepairPriority tests that the upload heap orders chunks
+// by the repair priority of their files.
+func TestUploadChunkHeapRepairPriority(t *testing.T) {
+	chunk := func(uid siafile.SiafileUID, priority modules.FileRepairPriority, health float64, blocking bool) *unfinishedUploadChunk {
+		return &unfinishedUploadChunk{
+			id:                   uploadChunkID{fileUID: uid},
+			staticPriority:       blocking,
+			staticRepairPriority: priority,
+			health:               health,
+			onDisk:               true,
+		}
+	}
+	uch := uploadChunkHeap{
+		chunk("low", modules.FileRepairPriorityLow, 2, false),
+		chunk("normal", modules.FileRepairPriorityNormal, 1, false),
+		chunk("high", modules.FileRepairPriorityHigh, 0.5, false),
+		chunk("normal", modules.FileRepairPriorityNormal, 1.5, false),
+		chunk("blocking", modules.FileRepairPriorityLow, 0, true),
+	}
+	sort.Sort(uch)
+
+	// Blocking chunks come first, then the chunks ordered by the priority of
+	// their file and then by health.
+	expected := []struct {
+		uid    siafile.SiafileUID
+		health float64
+	}{
+		{"blocking", 0},
+		{"high", 0.5},
+		{"normal", 1.5},
+		{"normal", 1},
+		{"low", 2},
+	}
+	for i, e := range expected {
+		if uch[i].id.fileUID != e.uid || uch[i].health != e.health {
+			t.Fatalf("%v: expected %v with health %v, got %v with health %v", i, e.uid, e.health, uch[i].id.fileUID, uch[i].health)
+		}
+	}
+}
+
+// TestRepairThroughput tests that the upload heap computes the repair
+// throughput from the recently repaired chunks.
+func TestRepairThroughput(t *testing.T) {
+	now := time.Now()
+	uh := &uploadHeap{}
+	if throughput := uh.repairThroughput(now); throughput != 0 {
+		t.Fatal("expected no throughput without repaired chunks", throughput)
+	}
+
+	// 2 chunks repaired over 4 seconds.
+	uh.repairedChunks = []repairedChunk{
+		{bytes: 100, popped: now.Add(-4 * time.Second), completed: now.Add(-2 * time.Second)},
+		{bytes: 300, popped: now.Add(-3 * time.Second), completed: now.Add(-time.Second)},
+	}
+	if throughput := uh.repairThroughput(now); throughput != 100 {
+		t.Fatal("wrong throughput", throughput)
+	}
+
+	// The elapsed time is at least a second.
+	uh.repairedChunks = []repairedChunk{{bytes: 100, popped: now, completed: now}}
+	if throughput := uh.repairThroughput(now); throughput != 100 {
+		t.Fatal("wrong throughput", throughput)
+	}
+
+	// Chunks which were completed before the window are pruned.
+	uh.repairedChunks = []repairedChunk{
+		{bytes: 100, popped: now.Add(-2 * repairThroughputWindow), completed: now.Add(-repairThroughputWindow - time.Second)},
+	}
+	if throughput := uh.repairThroughput(now); throughput != 0 || len(uh.repairedChunks) != 0 {
+		t.Fatal("expected chunk to be pruned", throughput, len(uh.repairedChunks))
+	}
+}
+
+// TestPriorityRepairFiles tests adding and removing files from the set of
+// priority repair files.
+func TestPriorityRepairFiles(t *testing.T) {
+	prf := newPriorityRepairFiles()
+	a, b := modules.RandomSiaPath(), modules.RandomSiaPath()
+	prf.callUpdate(a, modules.FileRepairPriorityHigh)
+	prf.callUpdate(b, modules.FileRepairPriorityHigh)
+	prf.callUpdate(b, modules.FileRepairPriorityHigh)
+	if siaPaths := prf.callSiaPaths(); len(siaPaths) != 2 || siaPaths[0].String() > siaPaths[1].String() {
+		t.Fatal("wrong siapaths", siaPaths)
+	}
+	prf.callUpdate(a, modules.FileRepairPriorityNormal)
+	prf.callUpdate(b, modules.FileRepairPriorityLow)
+	if siaPaths := prf.callSiaPaths(); len(siaPaths) != 0 {
+		t.Fatal("expected no siapaths", siaPaths)
+	}
+}
+
+// TestRepairQueue tests that the repair queue lists the files in the order
+// they are repaired together with an estimate of when they are repaired.
+func TestRepairQueue(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTesterWithDependency(t.Name(), &dependencies.DependencyDisableRepairAndHealthLoops{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+	_, rsc := testingFileParams()
+
+	// The queue is empty.
+	if rq := r.RepairQueue(); rq.Throughput != 0 || len(rq.Files) != 0 {
+		t.Fatal("expected empty queue", rq)
+	}
+
+	// Create a file with a normal and one with a high repair priority.
+	normalPath, highPath := modules.RandomSiaPath(), modules.RandomSiaPath()
+	var entries []*filesystem.FileNode
+	for _, siaPath := range []modules.SiaPath{normalPath, highPath} {
+		entry, err := r.createRenterTestFileWithParams(siaPath, rsc, crypto.TypePlain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := entry.Close(); err != nil {
+				t.Fatal(err)
+			}
+		}()
+		entries = append(entries, entry)
+	}
+	if err := r.SetFileRepairPriority(highPath, modules.FileRepairPriorityHigh); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetFileRepairPriority(highPath, modules.FileRepairPriority(2)); err != modules.ErrInvalidFileRepairPriority {
+		t.Fatal("expected invalid priority to be rejected", err)
+	}
+	if siaPaths := r.staticPriorityRepairFiles.callSiaPaths(); len(siaPaths) != 1 || !siaPaths[0].Equals(highPath) {
+		t.Fatal("file wasn't added to the priority files", siaPaths)
+	}
+
+	// Push 2 chunks of each file.
+	for i, entry := range entries {
+		for j := 0; j < 2; j++ {
+			uuc := &unfinishedUploadChunk{
+				id: uploadChunkID{
+					fileUID: entry.UID(),
+					index:   uint64(j),
+				},
+				fileEntry:            entry,
+				staticRepairPriority: entry.RepairPriority(),
+				health:               float64(i + j + 1),
+				length:               100,
+				onDisk:               true,
+			}
+			if !r.uploadHeap.managedPush(uuc, chunkTypeLocalChunk) {
+				t.Fatal("unable to push chunk")
+			}
+		}
+	}
+	// Pop one of the chunks of the high priority file to mark it as
+	// repairing.
+	if uuc := r.uploadHeap.managedPop(); uuc.id.fileUID != entries[1].UID() {
+		t.Fatal("expected chunk of the high priority file to be popped first")
+	}
+
+	// Without throughput there is no ETA.
+	rq := r.RepairQueue()
+	if len(rq.Files) != 2 {
+		t.Fatal("expected 2 files", rq.Files)
+	}
+	high, normal := rq.Files[0], rq.Files[1]
+	if !high.SiaPath.Equals(highPath) || high.Priority != modules.FileRepairPriorityHigh || high.RepairingChunks != 1 || high.QueuedChunks != 1 || high.RemainingBytes != 200 || high.Health != 3 || high.ETA != 0 {
+		t.Fatal("wrong high priority file", high)
+	}
+	if !normal.SiaPath.Equals(normalPath) || normal.Priority != modules.FileRepairPriorityNormal || normal.RepairingChunks != 0 || normal.QueuedChunks != 2 || normal.RemainingBytes != 200 || normal.Health != 2 || normal.ETA != 0 {
+		t.Fatal("wrong normal priority file", normal)
+	}
+
+	// Record a repair of 100 bytes per second.
+	now := time.Now()
+	r.uploadHeap.mu.Lock()
+	r.uploadHeap.repairedChunks = []repairedChunk{{bytes: 200, popped: now.Add(-2 * time.Second), completed: now}}
+	r.uploadHeap.mu.Unlock()
+	rq = r.RepairQueue()
+	if rq.Throughput < 99 || rq.Throughput > 100 {
+		t.Fatal("wrong throughput", rq.Throughput)
+	}
+	eta := func(bytes uint64) time.Duration {
+		return time.Duration(float64(bytes) / float64(rq.Throughput) * float64(time.Second))
+	}
+	if rq.Files[0].ETA != eta(200) || rq.Files[1].ETA != eta(400) {
+		t.Fatal("wrong ETAs", rq.Files[0].ETA, rq.Files[1].ETA)
+	}
+}
@@ -0,0 +1,397 @@
+package renter
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// ErrRenterSecondary is returned when the user tries to modify the files
+	// of a secondary renter. They are managed by the primary renter.
+	ErrRenterSecondary = errors.New("files of a secondary renter can only be modified through the primary renter")
+)
+
+// replication tracks the state of the replication of the renter's metadata. A
+// primary renter periodically writes a backup of its siafiles to the
+// configured path. A secondary renter periodically mirrors the siafiles of
+// that backup. It stays in read-only mode and downloads the files using its
+// own contracts and ephemeral accounts.
+type replication struct {
+	lastSync      time.Time
+	lastSyncErr   error
+	importedFiles uint64
+	missingHosts  []types.SiaPublicKey
+
+	// imported contains the hashes of the siafiles a secondary renter
+	// imported. Siafiles which didn't change since the last import are
+	// skipped. lastImportModTime is the modification time of the export
+	// which was imported last.
+	imported          map[modules.SiaPath]crypto.Hash
+	lastImportModTime time.Time
+
+	// wakeChan wakes the replication loop after the settings were updated.
+	wakeChan chan struct{}
+
+	mu sync.Mutex
+}
+
+// newReplication creates a new replication.
+func newReplication() *replication {
+	return &replication{
+		imported: make(map[modules.SiaPath]crypto.Hash),
+		wakeChan: make(chan struct{}, 1),
+	}
+}
+
+// managedReset resets the state of the replication after the settings changed.
+func (rep *replication) managedReset() {
+	rep.mu.Lock()
+	defer rep.mu.Unlock()
+	rep.lastSync = time.Time{}
+	rep.lastSyncErr = nil
+	rep.importedFiles = 0
+	rep.missingHosts = nil
+	rep.imported = make(map[modules.SiaPath]crypto.Hash)
+	rep.lastImportModTime = time.Time{}
+}
+
+// managedWake wakes the replication loop.
+func (rep *replication) managedWake() {
+	select {
+	case rep.wakeChan <- struct{}{}:
+	default:
+	}
+}
+
+// replicationSecret derives the key used to encrypt the metadata export from
+// the user provided secret.
+func replicationSecret(secret string) []byte {
+	if secret == "" {
+		return nil
+	}
+	key := crypto.HashBytes([]byte(secret))
+	return key[:]
+}
+
+// ReplicationStatus returns the status of the replication of the renter's
+// metadata.
+func (r *Renter) ReplicationStatus() (modules.ReplicationStatus, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.ReplicationStatus{}, err
+	}
+	defer r.tg.Done()
+	id := r.mu.RLock()
+	settings := r.persist.Replication
+	r.mu.RUnlock(id)
+
+	rep := r.staticReplication
+	rep.mu.Lock()
+	defer rep.mu.Unlock()
+	status := modules.ReplicationStatus{
+		Settings:      settings,
+		LastSync:      rep.lastSync,
+		ImportedFiles: rep.importedFiles,
+		MissingHosts:  append([]types.SiaPublicKey(nil), rep.missingHosts...),
+	}
+	if rep.lastSyncErr != nil {
+		status.LastSyncError = rep.lastSyncErr.Error()
+	}
+	return status, nil
+}
+
+// SetReplicationSettings sets the role of the renter in the replication of its
+// metadata. A secondary renter enters read-only mode.
+func (r *Renter) SetReplicationSettings(settings modules.ReplicationSettings) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	// Persist the change.
+	id := r.mu.Lock()
+	r.persist.Replication = settings
+	err := r.saveSync()
+	r.mu.Unlock(id)
+	if err != nil {
+		return errors.AddContext(err, "failed to persist replication settings")
+	}
+
+	// Start over with the new settings.
+	r.staticReplication.managedReset()
+	r.staticReplication.managedWake()
+	return r.managedUpdateReadOnlyMode()
+}
+
+// managedReplicationRole returns the role of the renter in the replication of
+// its metadata.
+func (r *Renter) managedReplicationRole() string {
+	id := r.mu.RLock()
+	defer r.mu.RUnlock(id)
+	return r.persist.Replication.Role
+}
+
+// managedSyncReplication exports or imports the renter's metadata depending on
+// its role.
+func (r *Renter) managedSyncReplication() error {
+	id := r.mu.RLock()
+	settings := r.persist.Replication
+	r.mu.RUnlock(id)
+
+	var err error
+	switch settings.Role {
+	case modules.ReplicationRolePrimary:
+		err = r.managedExportReplica(settings)
+	case modules.ReplicationRoleSecondary:
+		err = r.managedImportReplica(settings)
+	default:
+		return nil
+	}
+
+	rep := r.staticReplication
+	rep.mu.Lock()
+	defer rep.mu.Unlock()
+	rep.lastSyncErr = err
+	if err == nil {
+		rep.lastSync = time.Now()
+	}
+	return err
+}
+
+// managedExportReplica writes a backup of the renter's siafiles to the
+// replication path. The backup is written to a temporary file first to make
+// sure the secondary never reads a partial export.
+func (r *Renter) managedExportReplica(settings modules.ReplicationSettings) error {
+	tmpPath := settings.Path + "_temp"
+	if err := r.managedCreateBackup(tmpPath, replicationSecret(settings.Secret)); err != nil {
+		return errors.Compose(errors.AddContext(err, "failed to export metadata"), os.Remove(tmpPath))
+	}
+	return errors.AddContext(os.Rename(tmpPath, settings.Path), "failed to move metadata export")
+}
+
+// managedImportReplica mirrors the siafiles of the export at the replication
+// path. New and changed siafiles are added and siafiles which are no longer
+// part of the export are deleted.
+func (r *Renter) managedImportReplica(settings modules.ReplicationSettings) (err error) {
+	// Nothing to do if the export didn't change since the last import.
+	fi, err := os.Stat(settings.Path)
+	if err != nil {
+		return errors.AddContext(err, "failed to stat metadata export")
+	}
+	rep := r.staticReplication
+	rep.mu.Lock()
+	unchanged := fi.ModTime().Equal(rep.lastImportModTime)
+	rep.mu.Unlock()
+	if unchanged {
+		return nil
+	}
+
+	// Read the siafiles of the export.
+	files, err := readReplicaSiaFiles(settings.Path, replicationSecret(settings.Secret))
+	if err != nil {
+		return errors.AddContext(err, "failed to read metadata export")
+	}
+
+	// Add the new and changed siafiles.
+	dirsToUpdate := r.newUniqueRefreshPaths()
+	defer func() {
+		err = errors.Compose(err, dirsToUpdate.callRefreshAll())
+	}()
+	rep.mu.Lock()
+	imported := rep.imported
+	rep.mu.Unlock()
+	newImported := make(map[modules.SiaPath]crypto.Hash, len(files))
+	for siaPath, b := range files {
+		h := crypto.HashBytes(b)
+		newImported[siaPath] = h
+		if prev, exists := imported[siaPath]; exists && prev == h {
+			continue
+		}
+		// Replace the siafile.
+		err := r.staticFileSystem.DeleteFile(siaPath)
+		if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+			return errors.AddContext(err, "failed to delete outdated siafile")
+		}
+		if err := r.staticFileSystem.AddSiaFileFromReader(bytes.NewReader(b), siaPath); err != nil {
+			return errors.AddContext(err, "failed to import siafile")
+		}
+		if err := dirsToUpdate.callAdd(siaPath); err != nil {
+			return errors.AddContext(err, "failed to queue directory for update")
+		}
+	}
+
+	// Delete the siafiles which are no longer part of the export.
+	var deleted []modules.SiaPath
+	userDir := r.staticFileSystem.DirPath(modules.UserFolder)
+	err = r.staticFileSystem.Walk(modules.UserFolder, func(path string, info os.FileInfo, statErr error) error {
+		if statErr != nil {
+			return statErr
+		}
+		if info.IsDir() || filepath.Ext(path) != modules.SiaFileExtension {
+			return nil
+		}
+		relPath := strings.TrimPrefix(path, userDir)
+		siaPath, err := modules.UserFolder.Join(strings.TrimSuffix(relPath, modules.SiaFileExtension))
+		if err != nil {
+			return err
+		}
+		if _, exists := files[siaPath]; !exists {
+			deleted = append(deleted, siaPath)
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.AddContext(err, "failed to walk siafiles")
+	}
+	for _, siaPath := range deleted {
+		if err := r.staticFileSystem.DeleteFile(siaPath); err != nil {
+			return errors.AddContext(err, "failed to delete siafile")
+		}
+		if err := dirsToUpdate.callAdd(siaPath); err != nil {
+			return errors.AddContext(err, "failed to queue directory for update")
+		}
+	}
+
+	// Find the hosts storing data of the files which the renter has no
+	// contract with.
+	missingHosts, err := r.managedMissingReplicaHosts(newImported)
+	if err != nil {
+		return errors.AddContext(err, "failed to check hosts of imported files")
+	}
+
+	rep.mu.Lock()
+	rep.imported = newImported
+	rep.importedFiles = uint64(len(newImported))
+	rep.missingHosts = missingHosts
+	rep.lastImportModTime = fi.ModTime()
+	rep.mu.Unlock()
+	return nil
+}
+
+// managedMissingReplicaHosts returns the hosts which store pieces of the
+// provided files but which the renter has no contract with.
+func (r *Renter) managedMissingReplicaHosts(files map[modules.SiaPath]crypto.Hash) ([]types.SiaPublicKey, error) {
+	contracted := make(map[string]struct{})
+	for _, c := range r.hostContractor.Contracts() {
+		contracted[c.HostPublicKey.String()] = struct{}{}
+	}
+	missing := make(map[string]types.SiaPublicKey)
+	for siaPath := range files {
+		entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+		if err != nil {
+			return nil, err
+		}
+		for i := uint64(0); i < entry.NumChunks(); i++ {
+			pieces, err := entry.Pieces(i)
+			if err != nil {
+				return nil, errors.Compose(err, entry.Close())
+			}
+			for _, pieceSet := range pieces {
+				for _, piece := range pieceSet {
+					key := piece.HostPubKey.String()
+					if _, exists := contracted[key]; !exists {
+						missing[key] = piece.HostPubKey
+					}
+				}
+			}
+		}
+		if err := entry.Close(); err != nil {
+			return nil, err
+		}
+	}
+	hosts := make([]types.SiaPublicKey, 0, len(missing))
+	for _, hpk := range missing {
+		hosts = append(hosts, hpk)
+	}
+	return hosts, nil
+}
+
+// readReplicaSiaFiles reads the siafiles of a metadata export into memory.
+func readReplicaSiaFiles(path string, secret []byte) (_ map[modules.SiaPath][]byte, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = errors.Compose(err, f.Close())
+	}()
+	gzr, err := openBackup(f, secret)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = errors.Compose(err, gzr.Close())
+	}()
+
+	files := make(map[modules.SiaPath][]byte)
+	tr := tar.NewReader(gzr)
+	for {
+		header, err := tr.Next()
+		if errors.Contains(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, errors.AddContext(err, "could not get next entry in the tar archive")
+		}
+		// Only the siafiles are imported. The directory metadata is
+		// recomputed by the secondary.
+		if header.FileInfo().IsDir() || filepath.Ext(header.Name) != modules.SiaFileExtension {
+			continue
+		}
+		siaPath, err := modules.UserFolder.Join(strings.TrimSuffix(header.Name, modules.SiaFileExtension))
+		if err != nil {
+			return nil, errors.AddContext(err, "invalid siafile path")
+		}
+		b, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, errors.AddContext(err, "could not read siafile")
+		}
+		files[siaPath] = b
+	}
+	return files, nil
+}
+
+// threadedReplicate periodically exports or imports the renter's metadata
+// depending on its role.
+func (r *Renter) threadedReplicate() {
+	defer modules.RecoverPanic("renter")
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	for {
+		id := r.mu.RLock()
+		settings := r.persist.Replication
+		r.mu.RUnlock(id)
+		if err := r.managedSyncReplication(); err != nil {
+			r.log.Println("WARN: failed to sync replication:", err)
+		}
+
+		interval := settings.Interval
+		if interval == 0 {
+			interval = defaultReplicationInterval
+		}
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-r.staticReplication.wakeChan:
+		case <-time.After(interval):
+		}
+	}
+}
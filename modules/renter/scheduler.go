@@ -0,0 +1,214 @@
+package renter
+
+import (
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+)
+
+type (
+	// scheduler restricts the renter's bulk operations to the windows of its
+	// settings and keeps track of the bandwidth the operations used during
+	// each occurrence of a window.
+	scheduler struct {
+		settings modules.RenterSchedulerSettings
+		usage    map[schedulerOccurrence]uint64
+
+		// wakeChan is used to wake the scheduler's thread when the settings
+		// change.
+		wakeChan chan struct{}
+		mu       sync.Mutex
+	}
+
+	// schedulerOccurrence identifies a single occurrence of a window.
+	schedulerOccurrence struct {
+		window int
+		start  int64
+	}
+)
+
+// newScheduler creates a new scheduler which doesn't restrict any jobs.
+func newScheduler() *scheduler {
+	return &scheduler{
+		usage:    make(map[schedulerOccurrence]uint64),
+		wakeChan: make(chan struct{}, 1),
+	}
+}
+
+// managedSetSettings updates the settings of the scheduler and resets the
+// bandwidth used during the windows.
+func (s *scheduler) managedSetSettings(settings modules.RenterSchedulerSettings) {
+	s.mu.Lock()
+	s.settings = settings
+	s.usage = make(map[schedulerOccurrence]uint64)
+	s.mu.Unlock()
+
+	select {
+	case s.wakeChan <- struct{}{}:
+	default:
+	}
+}
+
+// managedAllowed returns whether the job can run at the given time.
+func (s *scheduler) managedAllowed(job modules.RenterSchedulerJob, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.settings.Restricts(job) {
+		return true
+	}
+	_, _, ok := s.activeOccurrence(now)
+	return ok
+}
+
+// managedTryStart returns whether the job can run at the given time and, if it
+// can, records the number of bytes it is going to transfer. A job can start as
+// long as the budget of the active window isn't exhausted, even if the job
+// exceeds the remaining budget.
+func (s *scheduler) managedTryStart(job modules.RenterSchedulerJob, size uint64, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.settings.Restricts(job) {
+		return true
+	}
+	occurrence, _, ok := s.activeOccurrence(now)
+	if !ok {
+		return false
+	}
+	s.usage[occurrence] += size
+	return true
+}
+
+// managedNextChange returns the next time after now at which a window starts
+// or ends. The returned time is zero if the scheduler doesn't have any
+// windows.
+func (s *scheduler) managedNextChange(now time.Time) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var next time.Time
+	for _, w := range s.settings.Windows {
+		times := []time.Time{w.NextStart(now)}
+		if _, end, ok := w.Occurrence(now); ok {
+			times = append(times, end)
+		}
+		for _, t := range times {
+			if !t.IsZero() && (next.IsZero() || t.Before(next)) {
+				next = t
+			}
+		}
+	}
+	return next
+}
+
+// managedStatus returns the status of the scheduler at the given time.
+func (s *scheduler) managedStatus(now time.Time) modules.RenterSchedulerStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := modules.RenterSchedulerStatus{
+		Settings: s.settings,
+	}
+	occurrence, end, ok := s.activeOccurrence(now)
+	if ok {
+		status.Active = true
+		status.WindowEnd = end
+		status.BudgetUsed = s.usage[occurrence]
+	}
+	for _, w := range s.settings.Windows {
+		start := w.NextStart(now)
+		if !start.IsZero() && (status.NextWindowStart.IsZero() || start.Before(status.NextWindowStart)) {
+			status.NextWindowStart = start
+		}
+	}
+	if !ok {
+		status.Paused = append(status.Paused, s.settings.Jobs...)
+	}
+	return status
+}
+
+// activeOccurrence returns the first occurrence of a window which contains
+// now and whose budget isn't exhausted. It also prunes the usage of
+// occurrences which ended.
+func (s *scheduler) activeOccurrence(now time.Time) (schedulerOccurrence, time.Time, bool) {
+	active := make(map[schedulerOccurrence]struct{})
+	var found bool
+	var occurrence schedulerOccurrence
+	var occurrenceEnd time.Time
+	for i, w := range s.settings.Windows {
+		start, end, ok := w.Occurrence(now)
+		if !ok {
+			continue
+		}
+		o := schedulerOccurrence{window: i, start: start.Unix()}
+		active[o] = struct{}{}
+		if found || (w.Budget > 0 && s.usage[o] >= w.Budget) {
+			continue
+		}
+		found = true
+		occurrence = o
+		occurrenceEnd = end
+	}
+	for o := range s.usage {
+		if _, exists := active[o]; !exists {
+			delete(s.usage, o)
+		}
+	}
+	return occurrence, occurrenceEnd, found
+}
+
+// threadedScheduleBulkJobs wakes up the repair loop whenever a window of the
+// scheduler starts, which resumes the restricted repairs and uploads.
+func (r *Renter) threadedScheduleBulkJobs() {
+	defer modules.RecoverPanic("renter")
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	for {
+		wait := schedulerMaxSleep
+		if next := r.staticScheduler.managedNextChange(time.Now()); !next.IsZero() && time.Until(next) < wait {
+			wait = time.Until(next)
+		}
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-r.staticScheduler.wakeChan:
+		case <-time.After(wait):
+		}
+		select {
+		case r.uploadHeap.repairNeeded <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// SchedulerStatus returns the settings of the renter's scheduler and the state
+// of its windows.
+func (r *Renter) SchedulerStatus() modules.RenterSchedulerStatus {
+	return r.staticScheduler.managedStatus(time.Now())
+}
+
+// SetSchedulerSettings sets the jobs which are restricted to the windows of
+// the renter's scheduler.
+func (r *Renter) SetSchedulerSettings(settings modules.RenterSchedulerSettings) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	// Persist the change.
+	id := r.mu.Lock()
+	r.persist.SchedulerSettings = settings
+	err := r.saveSync()
+	r.mu.Unlock(id)
+	if err != nil {
+		return errors.AddContext(err, "failed to persist scheduler settings")
+	}
+	r.staticScheduler.managedSetSettings(settings)
+	return nil
+}
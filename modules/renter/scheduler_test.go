@@ -0,0 +1,159 @@
+package renter
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/ratelimit"
+
+	"go.sia.tech/siad/modules"
+)
+
+// TestScheduler is a unit test for restricting jobs to the windows of the
+// scheduler and their budgets.
+func TestScheduler(t *testing.T) {
+	t.Parallel()
+
+	// 2021-03-01 is a Monday.
+	at := func(day, hour int) time.Time {
+		return time.Date(2021, 3, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	s := newScheduler()
+	if !s.managedAllowed(modules.RenterSchedulerJobRepair, at(1, 12)) {
+		t.Fatal("jobs shouldn't be restricted without settings")
+	}
+	s.managedSetSettings(modules.RenterSchedulerSettings{
+		Jobs: []modules.RenterSchedulerJob{modules.RenterSchedulerJobRepair, modules.RenterSchedulerJobAudit},
+		Windows: []modules.RenterSchedulerWindow{
+			{Days: []string{"mon"}, Start: "20:00", End: "06:00", Budget: 100},
+			{Days: []string{"sat"}, Start: "00:00", End: "00:00"},
+		},
+	})
+
+	// During business hours only the unrestricted jobs can run.
+	if s.managedAllowed(modules.RenterSchedulerJobRepair, at(1, 12)) || s.managedTryStart(modules.RenterSchedulerJobAudit, 10, at(1, 12)) {
+		t.Fatal("restricted jobs shouldn't run outside of the windows")
+	}
+	if !s.managedAllowed(modules.RenterSchedulerJobArchive, at(1, 12)) || !s.managedAllowed("", at(1, 12)) {
+		t.Fatal("unrestricted jobs should run at any time")
+	}
+	status := s.managedStatus(at(1, 12))
+	if status.Active || len(status.Paused) != 2 || !status.NextWindowStart.Equal(at(1, 20)) {
+		t.Fatal("unexpected status", status)
+	}
+	if next := s.managedNextChange(at(1, 12)); !next.Equal(at(1, 20)) {
+		t.Fatal("wrong next change", next)
+	}
+
+	// During the window the jobs can run until the budget is exhausted.
+	if !s.managedTryStart(modules.RenterSchedulerJobRepair, 60, at(1, 21)) {
+		t.Fatal("repair should run during the window")
+	}
+	if !s.managedTryStart(modules.RenterSchedulerJobAudit, 60, at(1, 22)) {
+		t.Fatal("audit should run while the budget isn't exhausted")
+	}
+	status = s.managedStatus(at(1, 23))
+	if status.Active || status.BudgetUsed != 0 || len(status.Paused) != 2 {
+		t.Fatal("window with exhausted budget shouldn't be active", status)
+	}
+	if s.managedTryStart(modules.RenterSchedulerJobRepair, 1, at(2, 1)) {
+		t.Fatal("repair shouldn't run once the budget is exhausted")
+	}
+	if next := s.managedNextChange(at(2, 1)); !next.Equal(at(2, 6)) {
+		t.Fatal("wrong next change", next)
+	}
+
+	// The next occurrence of a window has its own budget and a window without
+	// a budget is never exhausted.
+	if !s.managedTryStart(modules.RenterSchedulerJobRepair, 1<<40, at(6, 12)) {
+		t.Fatal("repair should run during the weekend")
+	}
+	status = s.managedStatus(at(6, 13))
+	if !status.Active || status.BudgetUsed != 1<<40 || !status.WindowEnd.Equal(at(7, 0)) || len(status.Paused) != 0 {
+		t.Fatal("unexpected status", status)
+	}
+	if !s.managedTryStart(modules.RenterSchedulerJobRepair, 1, at(8, 21)) {
+		t.Fatal("repair should run during the next occurrence")
+	}
+	if len(s.usage) != 1 {
+		t.Fatal("usage of past occurrences should be pruned", s.usage)
+	}
+}
+
+// TestUploadChunkSchedulerJob is a unit test for the scheduler jobs of upload
+// chunks.
+func TestUploadChunkSchedulerJob(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		priority        modules.FileRepairPriority
+		piecesCompleted int
+		job             modules.RenterSchedulerJob
+	}{
+		{modules.FileRepairPriorityNormal, 0, ""},
+		{modules.FileRepairPriorityNormal, 9, ""},
+		{modules.FileRepairPriorityNormal, 10, modules.RenterSchedulerJobRepair},
+		{modules.FileRepairPriorityLow, 0, modules.RenterSchedulerJobArchive},
+		{modules.FileRepairPriorityLow, 20, modules.RenterSchedulerJobArchive},
+		{modules.FileRepairPriorityHigh, 10, ""},
+	}
+	for i, test := range tests {
+		uc := &unfinishedUploadChunk{
+			piecesCompleted:      test.piecesCompleted,
+			staticMinimumPieces:  10,
+			staticPiecesNeeded:   30,
+			staticRepairPriority: test.priority,
+		}
+		if job := uc.schedulerJob(); job != test.job {
+			t.Errorf("%v: expected job %q, got %q", i, test.job, job)
+		}
+	}
+}
+
+// TestSchedulerSettingsPersist tests that the settings of the scheduler are
+// persisted across restarts of the renter.
+func TestSchedulerSettingsPersist(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Invalid settings are rejected.
+	err = rt.renter.SetSchedulerSettings(modules.RenterSchedulerSettings{Jobs: []modules.RenterSchedulerJob{modules.RenterSchedulerJobRepair}})
+	if err == nil {
+		t.Fatal("expected settings without windows to be rejected")
+	}
+
+	// Set the settings and restart the renter.
+	settings := modules.RenterSchedulerSettings{
+		Jobs:    []modules.RenterSchedulerJob{modules.RenterSchedulerJobAudit},
+		Windows: []modules.RenterSchedulerWindow{{Days: []string{"sun"}, Start: "01:00", End: "05:00", Budget: 1 << 30}},
+	}
+	if err := rt.renter.SetSchedulerSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.renter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var errChan <-chan error
+	rl := ratelimit.NewRateLimit(0, 0, 0)
+	rt.renter, errChan = New(rt.gateway, rt.cs, rt.wallet, rt.tpool, rt.mux, rl, filepath.Join(rt.dir, modules.RenterDir))
+	if err := <-errChan; err != nil {
+		t.Fatal(err)
+	}
+	if status := rt.renter.SchedulerStatus(); !reflect.DeepEqual(status.Settings, settings) {
+		t.Fatal("settings weren't persisted", status.Settings)
+	}
+}
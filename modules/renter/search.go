@@ -0,0 +1,318 @@
+package renter
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+)
+
+var (
+	// errSearchIndexNotReady is returned by SearchFiles if the search index
+	// wasn't built yet after startup.
+	errSearchIndexNotReady = errors.New("the search index is still being built")
+)
+
+// searchIndex is an in-memory index over the siapaths and user tags of the
+// renter's siafiles. It allows for searching files without opening every
+// siafile on disk. The index is updated by the renter's file operations and
+// periodically rebuilt from disk to pick up changes which weren't reported
+// to it, e.g. files added by a restored backup.
+type searchIndex struct {
+	// files maps the siapath of every indexed file to its lowercase tags and
+	// tags maps every lowercase tag to the files tagged with it.
+	files map[modules.SiaPath][]string
+	tags  map[string]map[modules.SiaPath]struct{}
+
+	// built indicates whether the index was built from disk at least once.
+	built bool
+
+	// wakeChan wakes the rebuild loop to rebuild the index immediately.
+	wakeChan chan struct{}
+
+	mu sync.Mutex
+}
+
+// newSearchIndex creates a new, empty search index.
+func newSearchIndex() *searchIndex {
+	return &searchIndex{
+		files:    make(map[modules.SiaPath][]string),
+		tags:     make(map[string]map[modules.SiaPath]struct{}),
+		wakeChan: make(chan struct{}, 1),
+	}
+}
+
+// normalizeTags lowercases the tags and removes empty and duplicate tags.
+func normalizeTags(tags []string) []string {
+	var normalized []string
+	seen := make(map[string]struct{})
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if _, exists := seen[tag]; exists || tag == "" {
+			continue
+		}
+		seen[tag] = struct{}{}
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// isSubPath returns whether sp is located within the directory dir.
+func isSubPath(sp, dir modules.SiaPath) bool {
+	return dir.IsRoot() || strings.HasPrefix(sp.Path, dir.Path+"/")
+}
+
+// add adds a file to the index, replacing a previous entry for the same
+// siapath.
+func (si *searchIndex) add(sp modules.SiaPath, tags []string) {
+	si.remove(sp)
+	tags = normalizeTags(tags)
+	si.files[sp] = tags
+	for _, tag := range tags {
+		if _, exists := si.tags[tag]; !exists {
+			si.tags[tag] = make(map[modules.SiaPath]struct{})
+		}
+		si.tags[tag][sp] = struct{}{}
+	}
+}
+
+// remove removes a file from the index.
+func (si *searchIndex) remove(sp modules.SiaPath) {
+	tags, exists := si.files[sp]
+	if !exists {
+		return
+	}
+	delete(si.files, sp)
+	for _, tag := range tags {
+		delete(si.tags[tag], sp)
+		if len(si.tags[tag]) == 0 {
+			delete(si.tags, tag)
+		}
+	}
+}
+
+// callAdd adds a file to the index.
+func (si *searchIndex) callAdd(sp modules.SiaPath, tags []string) {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	si.add(sp, tags)
+}
+
+// callRemove removes a file from the index.
+func (si *searchIndex) callRemove(sp modules.SiaPath) {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	si.remove(sp)
+}
+
+// callRemoveDir removes all the files within a directory from the index.
+func (si *searchIndex) callRemoveDir(dir modules.SiaPath) {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	for sp := range si.files {
+		if isSubPath(sp, dir) {
+			si.remove(sp)
+		}
+	}
+}
+
+// callRename moves the entry of a file to a new siapath.
+func (si *searchIndex) callRename(oldPath, newPath modules.SiaPath) {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	tags, exists := si.files[oldPath]
+	if !exists {
+		return
+	}
+	si.remove(oldPath)
+	si.add(newPath, tags)
+}
+
+// callRenameDir moves the entries of all the files within a directory to the
+// new directory.
+func (si *searchIndex) callRenameDir(oldDir, newDir modules.SiaPath) {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	var moved []modules.SiaPath
+	for sp := range si.files {
+		if isSubPath(sp, oldDir) {
+			moved = append(moved, sp)
+		}
+	}
+	for _, sp := range moved {
+		newPath, err := sp.Rebase(oldDir, newDir)
+		if err != nil {
+			continue // can't happen since sp is within oldDir
+		}
+		tags := si.files[sp]
+		si.remove(sp)
+		si.add(newPath, tags)
+	}
+}
+
+// callReplace replaces the content of the index with the provided files and
+// marks the index as built.
+func (si *searchIndex) callReplace(files map[modules.SiaPath][]string) {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	si.files = make(map[modules.SiaPath][]string, len(files))
+	si.tags = make(map[string]map[modules.SiaPath]struct{})
+	for sp, tags := range files {
+		si.add(sp, tags)
+	}
+	si.built = true
+}
+
+// callSearch returns the siapaths of the files within dir which match the
+// query and are tagged with all of the tags, sorted by siapath.
+func (si *searchIndex) callSearch(dir modules.SiaPath, query string, tags []string) ([]modules.SiaPath, error) {
+	si.mu.Lock()
+	defer si.mu.Unlock()
+	if !si.built {
+		return nil, errSearchIndexNotReady
+	}
+
+	// Use the smallest set of tagged files as the candidates if tags were
+	// provided. Otherwise every file is a candidate.
+	tags = normalizeTags(tags)
+	candidates := make(map[modules.SiaPath]struct{})
+	if len(tags) == 0 {
+		for sp := range si.files {
+			candidates[sp] = struct{}{}
+		}
+	} else {
+		smallest := si.tags[tags[0]]
+		for _, tag := range tags[1:] {
+			if len(si.tags[tag]) < len(smallest) {
+				smallest = si.tags[tag]
+			}
+		}
+		for sp := range smallest {
+			candidates[sp] = struct{}{}
+		}
+	}
+
+	query = strings.ToLower(query)
+	var matches []modules.SiaPath
+LOOP:
+	for sp := range candidates {
+		if !isSubPath(sp, dir) {
+			continue
+		}
+		relPath := strings.TrimPrefix(sp.Path, dir.Path)
+		if !strings.Contains(strings.ToLower(relPath), query) {
+			continue
+		}
+		for _, tag := range tags {
+			if _, tagged := si.tags[tag][sp]; !tagged {
+				continue LOOP
+			}
+		}
+		matches = append(matches, sp)
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Path < matches[j].Path
+	})
+	return matches, nil
+}
+
+// callWake wakes the rebuild loop to rebuild the index immediately.
+func (si *searchIndex) callWake() {
+	select {
+	case si.wakeChan <- struct{}{}:
+	default:
+	}
+}
+
+// SearchFiles searches the files within the directory specified by siaPath
+// using the renter's search index and returns the requested page of the
+// results. The file infos contain cached values for health and redundancy.
+func (r *Renter) SearchFiles(siaPath modules.SiaPath, params modules.FileSearchParams) (modules.FileSearchResult, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.FileSearchResult{}, err
+	}
+	defer r.tg.Done()
+
+	matches, err := r.staticSearchIndex.callSearch(siaPath, params.Query, params.Tags)
+	if err != nil {
+		return modules.FileSearchResult{}, err
+	}
+	result := modules.FileSearchResult{
+		Files: []modules.FileInfo{},
+		Total: uint64(len(matches)),
+	}
+
+	// Select the page.
+	if params.Offset >= uint64(len(matches)) {
+		return result, nil
+	}
+	matches = matches[params.Offset:]
+	if params.Limit > 0 && params.Limit < uint64(len(matches)) {
+		matches = matches[:params.Limit]
+	}
+
+	// Fetch the infos of the files. Files which were deleted since they were
+	// indexed are removed from the index and skipped.
+	for _, sp := range matches {
+		fi, err := r.staticFileSystem.CachedFileInfo(sp)
+		if errors.Contains(err, filesystem.ErrNotExist) {
+			r.staticSearchIndex.callRemove(sp)
+			continue
+		}
+		if err != nil {
+			return modules.FileSearchResult{}, errors.AddContext(err, "failed to get file info")
+		}
+		result.Files = append(result.Files, fi)
+	}
+	return result, nil
+}
+
+// threadedUpdateSearchIndex builds the search index after startup and
+// rebuilds it periodically afterwards.
+func (r *Renter) threadedUpdateSearchIndex() {
+	defer modules.RecoverPanic("renter")
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	for {
+		err := r.managedRebuildSearchIndex()
+		if errors.Contains(err, errSiaFileWalkInterrupted) {
+			return
+		}
+		if err != nil {
+			r.log.Println("WARN: failed to rebuild the search index:", err)
+		}
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-r.staticSearchIndex.wakeChan:
+		case <-time.After(searchIndexRebuildInterval):
+		}
+	}
+}
+
+// managedRebuildSearchIndex walks over all the siafiles of the renter and
+// replaces the search index with their current siapaths and tags.
+func (r *Renter) managedRebuildSearchIndex() error {
+	files := make(map[modules.SiaPath][]string)
+	err := r.managedWalkSiaFiles(func(siaPath modules.SiaPath) {
+		fi, err := r.staticFileSystem.CachedFileInfo(siaPath)
+		if err != nil {
+			r.log.Printf("WARN: failed to index siafile %v: %v", siaPath, err)
+			return
+		}
+		files[siaPath] = fi.UserTags
+	})
+	if err != nil {
+		return err
+	}
+	r.staticSearchIndex.callReplace(files)
+	return nil
+}
@@ -0,0 +1,173 @@
+package renter
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+)
+
+// TestSearchIndex is a unit test for the searchIndex.
+func TestSearchIndex(t *testing.T) {
+	t.Parallel()
+
+	sp := func(path string) modules.SiaPath {
+		siaPath, err := modules.NewSiaPath(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return siaPath
+	}
+	search := func(si *searchIndex, dir modules.SiaPath, query string, tags []string, expected ...string) {
+		t.Helper()
+		matches, err := si.callSearch(dir, query, tags)
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths := []string{}
+		for _, match := range matches {
+			paths = append(paths, match.Path)
+		}
+		if expected == nil {
+			expected = []string{}
+		}
+		if !reflect.DeepEqual(paths, expected) {
+			t.Fatalf("expected %v but got %v", expected, paths)
+		}
+	}
+
+	// Searching an index which wasn't built yet should fail.
+	si := newSearchIndex()
+	if _, err := si.callSearch(modules.RootSiaPath(), "", nil); err != errSearchIndexNotReady {
+		t.Fatal("expected errSearchIndexNotReady but got", err)
+	}
+	si.callReplace(map[modules.SiaPath][]string{
+		sp("photos/Vacation/beach.jpg"): {"Photos", "2020"},
+		sp("photos/vacation/hike.jpg"):  {"photos", "2021", "photos"},
+		sp("photos2/cat.jpg"):           {"photos"},
+		sp("docs/taxes.pdf"):            nil,
+	})
+
+	// Substring and tag queries are case-insensitive.
+	root := modules.RootSiaPath()
+	search(si, root, "", nil, "docs/taxes.pdf", "photos/Vacation/beach.jpg", "photos/vacation/hike.jpg", "photos2/cat.jpg")
+	search(si, root, "VACATION", nil, "photos/Vacation/beach.jpg", "photos/vacation/hike.jpg")
+	search(si, root, "", []string{"PHOTOS"}, "photos/Vacation/beach.jpg", "photos/vacation/hike.jpg", "photos2/cat.jpg")
+	search(si, root, "", []string{"photos", "2020"}, "photos/Vacation/beach.jpg")
+	search(si, root, "", []string{"unknown"})
+
+	// Searching a directory only returns its files and matches the query
+	// against the path relative to the directory.
+	search(si, sp("photos"), "", nil, "photos/Vacation/beach.jpg", "photos/vacation/hike.jpg")
+	search(si, sp("photos"), "photos", nil)
+
+	// Rename a file and a directory.
+	si.callRename(sp("docs/taxes.pdf"), sp("docs/taxes2020.pdf"))
+	search(si, root, "taxes", nil, "docs/taxes2020.pdf")
+	si.callRenameDir(sp("photos"), sp("archive/photos"))
+	search(si, root, "", []string{"2021"}, "archive/photos/vacation/hike.jpg")
+	search(si, sp("photos"), "", nil)
+
+	// Remove a file and a directory.
+	si.callRemove(sp("photos2/cat.jpg"))
+	search(si, root, "", []string{"photos"}, "archive/photos/Vacation/beach.jpg", "archive/photos/vacation/hike.jpg")
+	si.callRemoveDir(sp("archive"))
+	search(si, root, "", nil, "docs/taxes2020.pdf")
+	if len(si.tags) != 0 {
+		t.Fatal("expected no tags to be left", si.tags)
+	}
+}
+
+// TestRenterSearchFiles tests searching files after rebuilding the search
+// index from disk.
+func TestRenterSearchFiles(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	// Create a few tagged files.
+	rsc, _ := modules.NewRSCode(1, 1)
+	dir := modules.RandomSiaPath()
+	var paths []modules.SiaPath
+	for i := 0; i < 5; i++ {
+		siaPath, err := dir.Join(fmt.Sprintf("file%v", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = r.staticFileSystem.NewSiaFile(siaPath, "", rsc, crypto.GenerateSiaKey(crypto.RandomCipherType()), 10e3, persist.DefaultDiskPermissionsTest, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := r.staticFileSystem.OpenSiaFile(siaPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tags := []string{"all"}
+		if i%2 == 0 {
+			tags = append(tags, "even")
+		}
+		if err := f.SetUserTags(tags); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, siaPath)
+	}
+	if err := r.managedRebuildSearchIndex(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Search for the even files.
+	result, err := r.SearchFiles(dir, modules.FileSearchParams{Tags: []string{"even"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 3 || len(result.Files) != 3 {
+		t.Fatal("wrong number of results", result.Total, len(result.Files))
+	}
+	for i, fi := range result.Files {
+		if !fi.SiaPath.Equals(paths[2*i]) {
+			t.Fatal("wrong file", fi.SiaPath)
+		}
+	}
+
+	// Fetch the second page of all files.
+	result, err = r.SearchFiles(dir, modules.FileSearchParams{Query: "FILE", Offset: 2, Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 5 || len(result.Files) != 2 {
+		t.Fatal("wrong number of results", result.Total, len(result.Files))
+	}
+	if !result.Files[0].SiaPath.Equals(paths[2]) || !result.Files[1].SiaPath.Equals(paths[3]) {
+		t.Fatal("wrong page", result.Files[0].SiaPath, result.Files[1].SiaPath)
+	}
+
+	// Deleting a file removes it from the results.
+	if err := r.DeleteFile(paths[0]); err != nil {
+		t.Fatal(err)
+	}
+	result, err = r.SearchFiles(dir, modules.FileSearchParams{Tags: []string{"even"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 2 || len(result.Files) != 2 {
+		t.Fatal("wrong number of results", result.Total, len(result.Files))
+	}
+}
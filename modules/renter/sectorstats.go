@@ -0,0 +1,104 @@
+package renter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+// SectorStats fetches the stats of the sectors with the given roots from all
+// hosts that support the SectorStats RPC. Every host receives a single
+// batched request for up to modules.MaxSectorStatsRoots roots. The returned
+// map is keyed by the hosts' public keys and contains one stat per root, in
+// the same order as the roots. Hosts which failed to respond within the
+// timeout are omitted.
+func (r *Renter) SectorStats(roots []crypto.Hash, timeout time.Duration) (map[string][]modules.SectorStat, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+
+	// Create a context. If the timeout is greater than zero, have the context
+	// expire when the timeout triggers.
+	ctx := r.tg.StopCtx()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(r.tg.StopCtx(), timeout)
+		defer cancel()
+	}
+	return r.managedSectorStats(ctx, roots)
+}
+
+// managedSectorStats fetches the stats of the sectors with the given roots
+// from all workers that support the SectorStats RPC.
+func (r *Renter) managedSectorStats(ctx context.Context, roots []crypto.Hash) (map[string][]modules.SectorStat, error) {
+	if len(roots) == 0 {
+		return make(map[string][]modules.SectorStat), nil
+	}
+
+	// Filter out hosts that don't support the RPC or that are gouging.
+	workers := r.staticWorkerPool.callWorkers()
+	numWorkers := 0
+	for _, worker := range workers {
+		cache := worker.staticCache()
+		if build.VersionCmp(cache.staticHostVersion, minSectorStatsVersion) < 0 {
+			continue
+		}
+		pt := worker.staticPriceTable().staticPriceTable
+		err := checkProjectDownloadGouging(pt, cache.staticRenterAllowance)
+		if err != nil {
+			r.log.Debugf("price gouging detected in worker %v, err: %v\n", worker.staticHostPubKeyStr, err)
+			continue
+		}
+		workers[numWorkers] = worker
+		numWorkers++
+	}
+	workers = workers[:numWorkers]
+	if len(workers) == 0 {
+		return nil, errors.AddContext(modules.ErrNotEnoughWorkersInWorkerPool, "cannot perform SectorStats")
+	}
+
+	// Query all workers in parallel. Roots which exceed the maximum of a
+	// single request are split up into multiple requests per worker.
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var errs error
+	stats := make(map[string][]modules.SectorStat, len(workers))
+	for _, w := range workers {
+		wg.Add(1)
+		go func(w *worker) {
+			defer wg.Done()
+			hostStats := make([]modules.SectorStat, 0, len(roots))
+			for start := 0; start < len(roots); start += modules.MaxSectorStatsRoots {
+				end := start + modules.MaxSectorStatsRoots
+				if end > len(roots) {
+					end = len(roots)
+				}
+				batch, err := w.SectorStats(ctx, roots[start:end])
+				if err != nil {
+					mu.Lock()
+					errs = errors.Compose(errs, errors.AddContext(err, w.staticHostPubKeyStr))
+					mu.Unlock()
+					return
+				}
+				hostStats = append(hostStats, batch...)
+			}
+			mu.Lock()
+			stats[w.staticHostPubKeyStr] = hostStats
+			mu.Unlock()
+		}(w)
+	}
+	wg.Wait()
+
+	// Only return an error if none of the hosts responded.
+	if len(stats) == 0 {
+		return nil, errors.AddContext(errs, "SectorStats failed on all workers")
+	}
+	return stats, nil
+}
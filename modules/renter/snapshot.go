@@ -534,6 +534,7 @@ func (r *Renter) managedDownloadSnapshot(uid [16]byte) (ub modules.UploadedBacku
 // threadedSynchronizeSnapshots continuously scans hosts to ensure that all
 // current hosts are storing all known snapshots.
 func (r *Renter) threadedSynchronizeSnapshots() {
+	defer modules.RecoverPanic("renter")
 	if err := r.tg.Add(); err != nil {
 		return
 	}
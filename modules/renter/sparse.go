@@ -0,0 +1,132 @@
+package renter
+
+import (
+	"fmt"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+)
+
+// CreateSparseFile creates a SiaFile of the provided size which consists only
+// of holes. Nothing is uploaded until the holes are filled using a streamed
+// repair.
+func (r *Renter) CreateSparseFile(up modules.FileUploadParams, size uint64) (err error) {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	if up.Repair || up.Append {
+		return errors.New("'repair' and 'append' can't be set when creating a sparse file")
+	}
+	if size == 0 {
+		return errors.New("can't create a sparse file of size 0")
+	}
+	mode, err := r.managedApplyDirDefaults(&up)
+	if err != nil {
+		return errors.AddContext(err, "failed to apply directory defaults")
+	}
+	if mode == 0 {
+		mode = defaultFilePerm
+	}
+	if up.ErasureCode == nil {
+		up.ErasureCode = modules.NewRSSubCodeDefault()
+	}
+	cipherKey := up.CipherKey
+	if cipherKey == nil {
+		cipherKey = crypto.GenerateSiaKey(up.CipherType)
+	}
+
+	// Archive existing file if overwrite flag is set. Ignore ErrUnknownPath.
+	if up.Force {
+		err := r.managedArchiveFile(up.SiaPath)
+		if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+			return err
+		}
+	}
+
+	// Create the SiaFile and turn all of its chunks into holes.
+	err = r.staticFileSystem.NewSiaFile(up.SiaPath, "", up.ErasureCode, cipherKey, size, mode, true)
+	if err != nil {
+		return errors.AddContext(err, "failed to create siafile")
+	}
+	entry, err := r.staticFileSystem.OpenSiaFile(up.SiaPath)
+	if err != nil {
+		return errors.AddContext(err, "failed to open siafile")
+	}
+	defer func() {
+		err = errors.Compose(err, entry.Close())
+	}()
+	if err := staticSetUserMetadata(entry, up); err != nil {
+		return err
+	}
+	r.staticSearchIndex.callAdd(up.SiaPath, up.UserTags)
+	if err := entry.PunchHoles(0, entry.NumChunks()); err != nil {
+		return errors.AddContext(err, "failed to punch holes")
+	}
+	return r.managedQueueBubbleForFile(up.SiaPath)
+}
+
+// PunchHoles turns the chunks within the range [offset;offset+length) of a
+// SiaFile into holes. The offset needs to be aligned with the chunks of the
+// file and the range has to end either at a chunk boundary or at the end of
+// the file. The data within the range is lost.
+func (r *Renter) PunchHoles(siaPath modules.SiaPath, offset, length uint64) (err error) {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, entry.Close())
+	}()
+	start, end, err := holeChunkRange(offset, length, entry.ChunkSize(), entry.Size())
+	if err != nil {
+		return err
+	}
+	if err := entry.PunchHoles(start, end); err != nil {
+		return errors.AddContext(err, "failed to punch holes")
+	}
+	return r.managedQueueBubbleForFile(siaPath)
+}
+
+// holeChunkRange translates a range of a file into the range of chunks which
+// are turned into holes.
+func holeChunkRange(offset, length, chunkSize, fileSize uint64) (start, end uint64, err error) {
+	if length == 0 {
+		return 0, 0, errors.New("length can't be 0")
+	}
+	if offset+length > fileSize || offset+length < offset {
+		return 0, 0, fmt.Errorf("range [%v;%v) is out of bounds for file of size %v", offset, offset+length, fileSize)
+	}
+	if offset%chunkSize != 0 {
+		return 0, 0, fmt.Errorf("offset %v is not a multiple of the chunk size %v", offset, chunkSize)
+	}
+	if (offset+length)%chunkSize != 0 && offset+length != fileSize {
+		return 0, 0, fmt.Errorf("range needs to end at a multiple of the chunk size %v or at the end of the file", chunkSize)
+	}
+	start = offset / chunkSize
+	end = (offset + length) / chunkSize
+	if (offset+length)%chunkSize != 0 {
+		end++
+	}
+	return start, end, nil
+}
+
+// managedQueueBubbleForFile queues a bubble for the directory of a file to
+// update the directory's metadata after the file's health changed.
+func (r *Renter) managedQueueBubbleForFile(siaPath modules.SiaPath) error {
+	dirSiaPath, err := siaPath.Dir()
+	if err != nil {
+		return err
+	}
+	_ = r.staticBubbleScheduler.callQueueBubble(dirSiaPath)
+	return nil
+}
@@ -0,0 +1,34 @@
+package renter
+
+import "testing"
+
+// TestHoleChunkRange is a unit test for holeChunkRange.
+func TestHoleChunkRange(t *testing.T) {
+	t.Parallel()
+
+	chunkSize := uint64(100)
+	fileSize := uint64(450)
+	tests := []struct {
+		offset, length uint64
+		start, end     uint64
+		valid          bool
+	}{
+		{0, 100, 0, 1, true},
+		{100, 200, 1, 3, true},
+		{400, 50, 4, 5, true},   // ends at the end of the file
+		{0, 450, 0, 5, true},    // whole file
+		{0, 0, 0, 0, false},     // empty
+		{50, 50, 0, 0, false},   // unaligned offset
+		{0, 150, 0, 0, false},   // unaligned end
+		{400, 100, 0, 0, false}, // out of bounds
+	}
+	for i, test := range tests {
+		start, end, err := holeChunkRange(test.offset, test.length, chunkSize, fileSize)
+		if (err == nil) != test.valid {
+			t.Fatalf("%v: expected valid %v but got %v", i, test.valid, err)
+		}
+		if test.valid && (start != test.start || end != test.end) {
+			t.Fatalf("%v: expected [%v;%v) but got [%v;%v)", i, test.start, test.end, start, end)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package renter
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// fileSpendingFile is the name of the file the renter persists its
+	// spending per file to.
+	fileSpendingFile = "spending.json"
+)
+
+var (
+	// errInvalidSpendingBreakdown is returned when exporting the spending by
+	// something other than files or hosts.
+	errInvalidSpendingBreakdown = fmt.Errorf("spending can only be exported by %v or %v", modules.RenterSpendingByFiles, modules.RenterSpendingByHosts)
+
+	fileSpendingMetadata = persist.Metadata{
+		Header:  "Renter File Spending",
+		Version: persistVersion,
+	}
+
+	fileSpendingCSVHeader = []string{"siapath", "upload", "download", "storage"}
+	hostSpendingCSVHeader = []string{"hostpublickey", "contracts", "upload", "download", "storage", "fundaccount", "maintenance", "fees"}
+)
+
+// fileSpending is a ledger of the money the renter spent per siapath. The
+// entries follow their files when they are renamed but they are kept when the
+// files are deleted since the money was still spent.
+type fileSpending struct {
+	files map[modules.SiaPath]*modules.RenterFileSpending
+
+	// dirty indicates whether the ledger changed since it was saved.
+	dirty bool
+
+	staticPath string
+	mu         sync.Mutex
+}
+
+// newFileSpending returns an empty ledger which is persisted at path.
+func newFileSpending(path string) *fileSpending {
+	return &fileSpending{
+		files:      make(map[modules.SiaPath]*modules.RenterFileSpending),
+		staticPath: path,
+	}
+}
+
+// callLoad loads the ledger from disk.
+func (fs *fileSpending) callLoad() error {
+	var files []modules.RenterFileSpending
+	err := persist.LoadJSON(fileSpendingMetadata, &files, fs.staticPath)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	for i := range files {
+		fs.files[files[i].SiaPath] = &files[i]
+	}
+	return nil
+}
+
+// callSave saves the ledger to disk if it changed since the last save.
+func (fs *fileSpending) callSave() error {
+	fs.mu.Lock()
+	if !fs.dirty {
+		fs.mu.Unlock()
+		return nil
+	}
+	files := fs.sortedFiles()
+	fs.dirty = false
+	fs.mu.Unlock()
+
+	err := persist.SaveJSON(fileSpendingMetadata, files, fs.staticPath)
+	if err != nil {
+		fs.mu.Lock()
+		fs.dirty = true
+		fs.mu.Unlock()
+	}
+	return err
+}
+
+// callAdd adds money spent on a file to its entry.
+func (fs *fileSpending) callAdd(siaPath modules.SiaPath, download, storage, upload types.Currency) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	entry, exists := fs.files[siaPath]
+	if !exists {
+		entry = &modules.RenterFileSpending{SiaPath: siaPath}
+		fs.files[siaPath] = entry
+	}
+	entry.Download = entry.Download.Add(download)
+	entry.Storage = entry.Storage.Add(storage)
+	entry.Upload = entry.Upload.Add(upload)
+	fs.dirty = true
+}
+
+// callRename moves the entry of a file to its new siapath. If there already
+// is an entry at the new siapath, the entries are merged.
+func (fs *fileSpending) callRename(oldPath, newPath modules.SiaPath) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.move(oldPath, newPath)
+}
+
+// callRenameDir moves the entries of all the files within a directory to the
+// new directory.
+func (fs *fileSpending) callRenameDir(oldDir, newDir modules.SiaPath) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	var moved []modules.SiaPath
+	for siaPath := range fs.files {
+		if isSubPath(siaPath, oldDir) {
+			moved = append(moved, siaPath)
+		}
+	}
+	for _, siaPath := range moved {
+		newPath, err := siaPath.Rebase(oldDir, newDir)
+		if err != nil {
+			continue // can't happen since siaPath is within oldDir
+		}
+		fs.move(siaPath, newPath)
+	}
+}
+
+// callFiles returns the entries of the ledger sorted by siapath.
+func (fs *fileSpending) callFiles() []modules.RenterFileSpending {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.sortedFiles()
+}
+
+// move moves the entry at oldPath to newPath.
+func (fs *fileSpending) move(oldPath, newPath modules.SiaPath) {
+	entry, exists := fs.files[oldPath]
+	if !exists || oldPath.Equals(newPath) {
+		return
+	}
+	delete(fs.files, oldPath)
+	if existing, exists := fs.files[newPath]; exists {
+		existing.Download = existing.Download.Add(entry.Download)
+		existing.Storage = existing.Storage.Add(entry.Storage)
+		existing.Upload = existing.Upload.Add(entry.Upload)
+	} else {
+		entry.SiaPath = newPath
+		fs.files[newPath] = entry
+	}
+	fs.dirty = true
+}
+
+// sortedFiles returns a copy of the entries sorted by siapath.
+func (fs *fileSpending) sortedFiles() []modules.RenterFileSpending {
+	files := make([]modules.RenterFileSpending, 0, len(fs.files))
+	for _, entry := range fs.files {
+		files = append(files, *entry)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].SiaPath.String() < files[j].SiaPath.String()
+	})
+	return files
+}
+
+// uploadSpending estimates the cost of uploading size bytes to a host and
+// storing them for duration blocks.
+func uploadSpending(hs modules.HostExternalSettings, size uint64, duration types.BlockHeight) (storage, upload types.Currency) {
+	storage = hs.StoragePrice.Mul64(size).Mul64(uint64(duration))
+	upload = hs.UploadBandwidthPrice.Mul64(size)
+	return storage, upload
+}
+
+// hostSpending aggregates the spending of the given contracts per host. Since
+// renewed contracts are kept as old contracts, the spending of a host is
+// aggregated across renewals.
+func hostSpending(contracts []modules.RenterContract) []modules.RenterHostSpending {
+	hosts := make(map[string]*modules.RenterHostSpending)
+	for _, c := range contracts {
+		key := c.HostPublicKey.String()
+		hs, exists := hosts[key]
+		if !exists {
+			hs = &modules.RenterHostSpending{HostPublicKey: c.HostPublicKey}
+			hosts[key] = hs
+		}
+		hs.Contracts++
+		hs.Download = hs.Download.Add(c.DownloadSpending)
+		hs.Fees = hs.Fees.Add(c.ContractFee).Add(c.TxnFee).Add(c.SiafundFee)
+		hs.FundAccount = hs.FundAccount.Add(c.FundAccountSpending)
+		hs.Maintenance = hs.Maintenance.Add(c.MaintenanceSpending.Sum())
+		hs.Storage = hs.Storage.Add(c.StorageSpending)
+		hs.Upload = hs.Upload.Add(c.UploadSpending)
+	}
+	spending := make([]modules.RenterHostSpending, 0, len(hosts))
+	for _, hs := range hosts {
+		spending = append(spending, *hs)
+	}
+	sort.Slice(spending, func(i, j int) bool {
+		return spending[i].HostPublicKey.String() < spending[j].HostPublicKey.String()
+	})
+	return spending
+}
+
+// managedHostSpending returns the spending of the renter per host.
+func (r *Renter) managedHostSpending() []modules.RenterHostSpending {
+	contracts := append(r.hostContractor.Contracts(), r.hostContractor.OldContracts()...)
+	return hostSpending(contracts)
+}
+
+// Spending returns the breakdown of the renter's spending per file and per
+// host.
+func (r *Renter) Spending() (modules.RenterSpending, error) {
+	if err := r.tg.Add(); err != nil {
+		return modules.RenterSpending{}, err
+	}
+	defer r.tg.Done()
+	return modules.RenterSpending{
+		Files: r.staticFileSpending.callFiles(),
+		Hosts: r.managedHostSpending(),
+	}, nil
+}
+
+// ExportSpending writes the breakdown of the renter's spending per file or per
+// host to w in CSV format.
+func (r *Renter) ExportSpending(w io.Writer, by string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	var header []string
+	var rows [][]string
+	switch by {
+	case modules.RenterSpendingByFiles:
+		header = fileSpendingCSVHeader
+		for _, fs := range r.staticFileSpending.callFiles() {
+			rows = append(rows, []string{
+				fs.SiaPath.String(),
+				fs.Upload.String(),
+				fs.Download.String(),
+				fs.Storage.String(),
+			})
+		}
+	case modules.RenterSpendingByHosts:
+		header = hostSpendingCSVHeader
+		for _, hs := range r.managedHostSpending() {
+			rows = append(rows, []string{
+				hs.HostPublicKey.String(),
+				fmt.Sprint(hs.Contracts),
+				hs.Upload.String(),
+				hs.Download.String(),
+				hs.Storage.String(),
+				hs.FundAccount.String(),
+				hs.Maintenance.String(),
+				hs.Fees.String(),
+			})
+		}
+	default:
+		return errInvalidSpendingBreakdown
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// threadedSaveFileSpending periodically saves the spending per file. The
+// ledger is saved one last time when the renter shuts down.
+func (r *Renter) threadedSaveFileSpending() {
+	defer modules.RecoverPanic("renter")
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	for {
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-time.After(fileSpendingSaveInterval):
+		}
+		if err := r.staticFileSpending.callSave(); err != nil {
+			r.log.Println("WARN: failed to save file spending:", err)
+		}
+	}
+}
+
+// managedLoadFileSpending loads the spending per file from disk and makes
+// sure it is saved on shutdown.
+func (r *Renter) managedLoadFileSpending() error {
+	if err := r.staticFileSpending.callLoad(); err != nil {
+		return errors.AddContext(err, "failed to load file spending")
+	}
+	return r.tg.AfterStop(r.staticFileSpending.callSave)
+}
@@ -0,0 +1,207 @@
+package renter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+// TestFileSpending is a unit test for the fileSpending ledger.
+func TestFileSpending(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir("renter", t.Name())
+	if err := os.MkdirAll(dir, persist.DefaultDiskPermissionsTest); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, fileSpendingFile)
+	fs := newFileSpending(path)
+	siaDir := modules.RandomSiaPath()
+	a, err := siaDir.Join("a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := modules.RandomSiaPath()
+
+	// Add spending to both files.
+	fs.callAdd(a, types.NewCurrency64(1), types.NewCurrency64(2), types.NewCurrency64(3))
+	fs.callAdd(a, types.NewCurrency64(1), types.ZeroCurrency, types.ZeroCurrency)
+	fs.callAdd(b, types.ZeroCurrency, types.NewCurrency64(10), types.NewCurrency64(10))
+	files := fs.callFiles()
+	if len(files) != 2 {
+		t.Fatal("expected 2 files", files)
+	}
+	for _, f := range files {
+		if f.SiaPath.Equals(a) && (!f.Download.Equals64(2) || !f.Storage.Equals64(2) || !f.Upload.Equals64(3)) {
+			t.Fatal("wrong spending", f)
+		}
+	}
+
+	// Renaming a file onto another one merges their spending.
+	fs.callRename(b, a)
+	files = fs.callFiles()
+	if len(files) != 1 || !files[0].SiaPath.Equals(a) || !files[0].Storage.Equals64(12) || !files[0].Upload.Equals64(13) {
+		t.Fatal("spending wasn't merged", files)
+	}
+
+	// Renaming the directory moves the spending of its files.
+	newDir := modules.RandomSiaPath()
+	fs.callRenameDir(siaDir, newDir)
+	newA, err := a.Rebase(siaDir, newDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files = fs.callFiles(); len(files) != 1 || !files[0].SiaPath.Equals(newA) {
+		t.Fatal("spending wasn't moved", files)
+	}
+
+	// The ledger is persisted.
+	if err := fs.callSave(); err != nil {
+		t.Fatal(err)
+	}
+	loaded := newFileSpending(path)
+	if err := loaded.callLoad(); err != nil {
+		t.Fatal(err)
+	}
+	if files := loaded.callFiles(); len(files) != 1 || !files[0].SiaPath.Equals(newA) || !files[0].Download.Equals64(2) {
+		t.Fatal("ledger wasn't persisted", files)
+	}
+}
+
+// TestHostSpending tests aggregating the spending of contracts per host.
+func TestHostSpending(t *testing.T) {
+	hostA := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{1}}
+	hostB := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: []byte{2}}
+	contract := func(host types.SiaPublicKey, amount uint64) modules.RenterContract {
+		c := types.NewCurrency64(amount)
+		return modules.RenterContract{
+			HostPublicKey:       host,
+			DownloadSpending:    c,
+			FundAccountSpending: c,
+			MaintenanceSpending: modules.MaintenanceSpending{AccountBalanceCost: c, UpdatePriceTableCost: c},
+			StorageSpending:     c,
+			UploadSpending:      c,
+			ContractFee:         c,
+			TxnFee:              c,
+			SiafundFee:          c,
+		}
+	}
+
+	// The renewed contract of host B is aggregated with its current one.
+	spending := hostSpending([]modules.RenterContract{
+		contract(hostB, 1),
+		contract(hostA, 5),
+		contract(hostB, 2),
+	})
+	if len(spending) != 2 {
+		t.Fatal("expected 2 hosts", spending)
+	}
+	a, b := spending[0], spending[1]
+	if !a.HostPublicKey.Equals(hostA) || a.Contracts != 1 || !a.Upload.Equals64(5) || !a.Maintenance.Equals64(10) || !a.Fees.Equals64(15) {
+		t.Fatal("wrong spending for host A", a)
+	}
+	if !b.HostPublicKey.Equals(hostB) || b.Contracts != 2 || !b.Download.Equals64(3) || !b.FundAccount.Equals64(3) || !b.Storage.Equals64(3) || !b.Fees.Equals64(9) {
+		t.Fatal("wrong spending for host B", b)
+	}
+}
+
+// TestExportSpending tests exporting the renter's spending as CSV.
+func TestExportSpending(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+
+	siaPath := modules.RandomSiaPath()
+	entry, err := r.createRenterTestFile(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := entry.Close(); err != nil {
+		t.Fatal(err)
+	}
+	storage, upload := uploadSpending(modules.HostExternalSettings{
+		StoragePrice:         types.NewCurrency64(2),
+		UploadBandwidthPrice: types.NewCurrency64(3),
+	}, 10, 5)
+	if !storage.Equals64(100) || !upload.Equals64(30) {
+		t.Fatal("wrong upload spending", storage, upload)
+	}
+	r.staticFileSpending.callAdd(siaPath, types.ZeroCurrency, storage, upload)
+
+	// Renaming the file moves its spending, deleting it keeps it.
+	newSiaPath := modules.RandomSiaPath()
+	if err := r.RenameFile(siaPath, newSiaPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.DeleteFile(newSiaPath); err != nil {
+		t.Fatal(err)
+	}
+	spending, err := r.Spending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spending.Files) != 1 || !spending.Files[0].SiaPath.Equals(newSiaPath) || len(spending.Hosts) != 0 {
+		t.Fatal("wrong spending", spending)
+	}
+
+	// Export the spending per file.
+	var buf bytes.Buffer
+	if err := r.ExportSpending(&buf, modules.RenterSpendingByFiles); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || len(records[0]) != len(fileSpendingCSVHeader) {
+		t.Fatal("wrong records", records)
+	}
+	if row := records[1]; row[0] != newSiaPath.String() || row[1] != "30" || row[2] != "0" || row[3] != "100" {
+		t.Fatal("wrong row", row)
+	}
+
+	// Export the spending per host.
+	buf.Reset()
+	if err := r.ExportSpending(&buf, modules.RenterSpendingByHosts); err != nil {
+		t.Fatal(err)
+	}
+	if records, err := csv.NewReader(&buf).ReadAll(); err != nil || len(records) != 1 || len(records[0]) != len(hostSpendingCSVHeader) {
+		t.Fatal("wrong records", records, err)
+	}
+	if err := r.ExportSpending(&buf, "contracts"); !errors.Contains(err, errInvalidSpendingBreakdown) {
+		t.Fatal("expected invalid breakdown", err)
+	}
+
+	// The spending is persisted when the renter shuts down.
+	r, err = rt.reloadRenter(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spending, err := r.Spending(); err != nil || len(spending.Files) != 1 || !spending.Files[0].Upload.Equals64(30) {
+		t.Fatal("spending wasn't persisted", spending, err)
+	}
+}
@@ -33,6 +33,11 @@ func (r *Renter) Upload(up modules.FileUploadParams) error {
 	}
 	defer r.tg.Done()
 
+	// Uploads are not allowed while the renter is in read-only mode.
+	if r.staticReadOnlyMode.managedActive() {
+		return ErrRenterReadOnly
+	}
+
 	// Check if the file is a directory.
 	sourceInfo, err := os.Stat(up.Source)
 	if err != nil {
@@ -52,15 +57,20 @@ func (r *Renter) Upload(up modules.FileUploadParams) error {
 		return errors.AddContext(err, "unable to close file after checking permissions")
 	}
 
-	// Delete existing file if overwrite flag is set. Ignore ErrUnknownPath.
+	// Archive existing file if overwrite flag is set. Ignore ErrUnknownPath.
 	if up.Force {
-		err := r.DeleteFile(up.SiaPath)
+		err := r.managedArchiveFile(up.SiaPath)
 		if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
-			return errors.AddContext(err, "unable to delete existing file")
+			return errors.AddContext(err, "unable to archive existing file")
 		}
 	}
 
-	// Fill in any missing upload params with sensible defaults.
+	// Fill in any missing upload params with the defaults of the directory
+	// and sensible defaults.
+	defaultMode, err := r.managedApplyDirDefaults(&up)
+	if err != nil {
+		return errors.AddContext(err, "unable to apply directory defaults")
+	}
 	if up.ErasureCode == nil {
 		up.ErasureCode = modules.NewRSSubCodeDefault()
 	}
@@ -92,7 +102,11 @@ func (r *Renter) Upload(up modules.FileUploadParams) error {
 	cipherKey := crypto.GenerateSiaKey(up.CipherType)
 
 	// Create the Siafile and add to renter
-	err = r.staticFileSystem.NewSiaFile(up.SiaPath, up.Source, up.ErasureCode, cipherKey, uint64(sourceInfo.Size()), sourceInfo.Mode(), up.DisablePartialChunk)
+	mode := sourceInfo.Mode()
+	if defaultMode != 0 {
+		mode = defaultMode
+	}
+	err = r.staticFileSystem.NewSiaFile(up.SiaPath, up.Source, up.ErasureCode, cipherKey, uint64(sourceInfo.Size()), mode, up.DisablePartialChunk)
 	if err != nil {
 		return errors.AddContext(err, "could not create a new sia file")
 	}
@@ -100,6 +114,10 @@ func (r *Renter) Upload(up modules.FileUploadParams) error {
 	if err != nil {
 		return errors.AddContext(err, "could not open the new sia file")
 	}
+	if err := staticSetUserMetadata(entry, up); err != nil {
+		return errors.Compose(err, entry.Close())
+	}
+	r.staticSearchIndex.callAdd(up.SiaPath, up.UserTags)
 
 	// No need to upload zero-byte files.
 	if sourceInfo.Size() == 0 {
@@ -45,9 +45,11 @@ type unfinishedUploadChunk struct {
 	staticMemoryManager *memoryManager
 
 	// Static cached fields.
-	staticIndex    uint64
-	staticSiaPath  string
-	staticPriority bool // indicates if the chunk should get access to priority memory
+	staticIndex          uint64
+	staticRepairPriority modules.FileRepairPriority // the repair priority of the chunk's file
+	staticSchedulerJob   modules.RenterSchedulerJob // the job of the scheduler the chunk's repair belongs to
+	staticSiaPath        string
+	staticPriority       bool // indicates if the chunk should get access to priority memory
 
 	// The logical data is the data that is presented to the user when the user
 	// requests the chunk. The physical data is all of the pieces that get
@@ -64,9 +66,18 @@ type unfinishedUploadChunk struct {
 	// and be confident that the data now is the same as what it used to be.
 	staticExpectedPieceRoots []crypto.Hash
 
+	// staticFailureDomains maps the hosts of the renter to their failure
+	// domains. It is used to avoid placing several pieces of the chunk on
+	// correlated hosts.
+	staticFailureDomains map[string][]string
+
 	// sourceReader is an optional source for the logical chunk data. If
 	// available it will be tried before the repair path or remote repair.
-	sourceReader io.ReadCloser
+	// fixedFileSize indicates that reading the chunk from the sourceReader
+	// doesn't change the size of the file since the chunk already contains
+	// data of the file.
+	sourceReader  io.ReadCloser
+	fixedFileSize bool
 
 	// Performance information.
 	chunkCreationTime        time.Time
@@ -115,6 +126,7 @@ type unfinishedUploadChunk struct {
 	piecesRegistered int                 // number of pieces that are being uploaded, but aren't finished yet (may fail).
 	released         bool                // whether this chunk has been released from the active chunks set.
 	unusedHosts      map[string]struct{} // hosts that aren't yet storing any pieces or performing any work.
+	usedDomains      map[string]struct{} // failure domains of the hosts which are storing or uploading a piece.
 	workersRemaining int                 // number of inactive workers still able to upload a piece.
 	workersStandby   []*worker           // workers that can be used if other workers fail.
 
@@ -217,6 +229,33 @@ func (uc *unfinishedUploadChunk) chunkComplete() bool {
 	return false
 }
 
+// remainingUploadSize returns the number of bytes which need to be uploaded
+// for the chunk to reach full redundancy.
+func (uc *unfinishedUploadChunk) remainingUploadSize() uint64 {
+	if uc.piecesCompleted >= uc.staticPiecesNeeded {
+		return 0
+	}
+	return uint64(uc.staticPiecesNeeded-uc.piecesCompleted) * uc.fileEntry.PieceSize()
+}
+
+// schedulerJob returns the job of the scheduler the repair of the chunk
+// belongs to. Chunks of files with the low repair priority are archived and
+// chunks which are still recoverable are repaired. Chunks of files with the
+// high repair priority and chunks which aren't recoverable yet, e.g. the
+// chunks of new uploads, don't belong to a job and are never restricted.
+func (uc *unfinishedUploadChunk) schedulerJob() modules.RenterSchedulerJob {
+	switch {
+	case uc.staticRepairPriority == modules.FileRepairPriorityLow:
+		return modules.RenterSchedulerJobArchive
+	case uc.staticRepairPriority == modules.FileRepairPriorityHigh:
+		return ""
+	case uc.piecesCompleted >= uc.staticMinimumPieces:
+		return modules.RenterSchedulerJobRepair
+	default:
+		return ""
+	}
+}
+
 // readDataPieces reads dataPieces from a io.Reader and stores them in a
 // [][]byte ready to be encoded using an ErasureCoder.
 func readDataPieces(r io.Reader, ec modules.ErasureCoder, pieceSize uint64) ([][]byte, uint64, error) {
@@ -355,6 +394,7 @@ func (r *Renter) managedDownloadLogicalChunkData(chunk *unfinishedUploadChunk) e
 // threadedFetchAndRepairChunk will fetch the logical data for a chunk, create
 // the physical pieces for the chunk, and then distribute them.
 func (r *Renter) threadedFetchAndRepairChunk(chunk *unfinishedUploadChunk) {
+	defer modules.RecoverPanic("renter")
 	err := r.tg.Add()
 	if err != nil {
 		return
@@ -523,6 +563,11 @@ func (r *Renter) staticFetchLogicalDataFromReader(uc *unfinishedUploadChunk) (er
 		return errors.AddContext(err, "source data does not match previously uploaded data - blocking corrupt repair")
 	}
 
+	// Chunks which already contain data of the file don't change its size.
+	if uc.fixedFileSize {
+		return nil
+	}
+
 	// Adjust the filesize. Since we don't know the length of the stream
 	// beforehand we simply assume that a whole chunk will be added to the
 	// file. That's why we subtract the difference between the size of a
@@ -549,9 +594,18 @@ func (r *Renter) managedFetchLogicalChunkData(uc *unfinishedUploadChunk) error {
 		return nil
 	}
 
-	// No source reader available. Check if there's potentially a local file. If
-	// there is no local file, fall back to doing a remote repair.
-	// disk.
+	// No source reader available. Check if the chunk was spilled to disk by
+	// an interrupted upload stream.
+	if r.staticSpilledChunkExists(uc.fileEntry.UID(), uc.staticIndex) {
+		err := r.readSpilledChunk(uc)
+		if err == nil {
+			return nil
+		}
+		r.log.Printf("failed to fetch spilled chunk %v of %v: %v", uc.staticIndex, uc.staticSiaPath, err)
+	}
+
+	// Check if there's potentially a local file. If there is no local file,
+	// fall back to doing a remote repair.
 	if uc.fileEntry.LocalPath() == "" {
 		return r.managedDownloadLogicalChunkData(uc)
 	}
@@ -623,7 +677,8 @@ func (r *Renter) managedCleanUpUploadChunk(uc *unfinishedUploadChunk) {
 	}
 
 	// Check if the chunk is now available.
-	if uc.piecesCompleted >= uc.staticMinimumPieces && !uc.staticAvailable() && !uc.released {
+	becameAvailable := uc.piecesCompleted >= uc.staticMinimumPieces && !uc.staticAvailable() && !uc.released
+	if becameAvailable {
 		uc.chunkAvailableTime = time.Now()
 		close(uc.staticAvailableChan)
 	}
@@ -642,8 +697,9 @@ func (r *Renter) managedCleanUpUploadChunk(uc *unfinishedUploadChunk) {
 	// yet been released.
 	released := uc.released
 	canceled := uc.canceled
+	repaired := uc.piecesCompleted >= uc.staticPiecesNeeded
 	if chunkComplete && !released {
-		if uc.piecesCompleted >= uc.staticPiecesNeeded {
+		if repaired {
 			r.repairLog.Printf("Completed repair for chunk %v of %s, %v pieces were completed out of %v", uc.staticIndex, uc.staticSiaPath, uc.piecesCompleted, uc.staticPiecesNeeded)
 		} else {
 			r.repairLog.Printf("Repair of chunk %v of %s was unsuccessful, %v pieces were completed out of %v", uc.staticIndex, uc.staticSiaPath, uc.piecesCompleted, uc.staticPiecesNeeded)
@@ -679,6 +735,14 @@ func (r *Renter) managedCleanUpUploadChunk(uc *unfinishedUploadChunk) {
 	workersRemaining := uc.workersRemaining
 	uc.mu.Unlock()
 
+	// Once the chunk is available on the network, its data doesn't need to
+	// be kept on disk for an interrupted upload stream anymore.
+	if becameAvailable {
+		if err := r.callRemoveSpilledChunk(uc.fileEntry.UID(), uc.staticIndex); err != nil {
+			r.log.Println("WARN: unable to remove spilled chunk:", err)
+		}
+	}
+
 	// If there are pieces available, add the standby workers to collect them.
 	// Standby workers are only added to the chunk when piecesAvailable is equal
 	// to zero, meaning this code will only trigger if the number of pieces
@@ -705,8 +769,12 @@ func (r *Renter) managedCleanUpUploadChunk(uc *unfinishedUploadChunk) {
 				r.log.Println("WARN: unable to close file entry for chunk", uc.fileEntry.SiaFilePath())
 			}
 		}
-		// Remove the chunk from the repairingChunks map
+		// Remove the chunk from the repairingChunks map and record the
+		// repair to estimate the throughput.
 		r.uploadHeap.managedMarkRepairDone(uc)
+		if repaired {
+			r.uploadHeap.managedRecordRepair(uc)
+		}
 		// Signal garbage collector to free memory before returning it to the manager.
 		uc.logicalChunkData = nil
 		uc.physicalChunkData = nil
@@ -77,18 +77,22 @@ func (uch uploadChunkHeap) Less(i, j int) bool {
 	//      than all other chunks. An example would be if the upload of a single
 	//      chunk is a blocking task.
 	//
-	//  2) File Recently Successful Chunks
+	//  2) File Repair Priority
+	//    - These are chunks of files for which the user set a higher repair
+	//      priority
+	//
+	//  3) File Recently Successful Chunks
 	//    - These are stuck chunks that are from a file that recently had a
 	//      successful repair
 	//
-	//  3) Stuck Chunks
+	//  4) Stuck Chunks
 	//    - These are chunks added by the stuck loop
 	//
-	//  4) Remote Chunks
+	//  5) Remote Chunks
 	//    - These are chunks of a siafile that do not have a local file to repair
 	//    from
 	//
-	//  5) Worst Health Chunk
+	//  6) Worst Health Chunk
 	//    - The base priority of chunks in the heap is by the worst health
 
 	// Check for Priority chunks
@@ -102,6 +106,14 @@ func (uch uploadChunkHeap) Less(i, j int) bool {
 		return false
 	}
 
+	// Check for the File Repair Priority
+	//
+	// If the files of the chunks have different priorities, prioritize the
+	// chunk of the file with the higher priority.
+	if uch[i].staticRepairPriority != uch[j].staticRepairPriority {
+		return uch[i].staticRepairPriority > uch[j].staticRepairPriority
+	}
+
 	// Check for File Recently Successful Chunks
 	//
 	// If only chunk i's file was recently successful, return true to prioritize
@@ -210,6 +222,10 @@ type uploadHeap struct {
 	pauseStart    time.Time
 	pauseTimer    *time.Timer
 
+	// repairedChunks are the chunks which were repaired within the repair
+	// throughput window.
+	repairedChunks []repairedChunk
+
 	mu sync.Mutex
 }
 
@@ -519,7 +535,7 @@ func (r *Renter) managedBuildUnfinishedChunk(entry *filesystem.FileNode, chunkIn
 		return nil, errors.AddContext(err, "unable to get 'stuck' status")
 	}
 	_, err = os.Stat(entryCopy.LocalPath())
-	onDisk := err == nil
+	onDisk := err == nil || r.staticSpilledChunkExists(entry.UID(), chunkIndex)
 	uuc := &unfinishedUploadChunk{
 		fileEntry: entryCopy,
 
@@ -533,8 +549,9 @@ func (r *Renter) managedBuildUnfinishedChunk(entry *filesystem.FileNode, chunkIn
 		onDisk:         onDisk,
 		staticPriority: priority,
 
-		staticIndex:   chunkIndex,
-		staticSiaPath: entryCopy.SiaFilePath(),
+		staticIndex:          chunkIndex,
+		staticRepairPriority: entry.RepairPriority(),
+		staticSiaPath:        entryCopy.SiaFilePath(),
 
 		staticMemoryManager: mm,
 
@@ -551,6 +568,7 @@ func (r *Renter) managedBuildUnfinishedChunk(entry *filesystem.FileNode, chunkIn
 
 		physicalChunkData:        make([][]byte, entry.ErasureCode().NumPieces()),
 		staticExpectedPieceRoots: make([]crypto.Hash, entry.ErasureCode().NumPieces()),
+		staticFailureDomains:     r.staticFailureDomains.managedDomains(),
 
 		staticAvailableChan:       make(chan struct{}),
 		staticUploadCompletedChan: make(chan struct{}),
@@ -609,6 +627,13 @@ func (r *Renter) managedBuildUnfinishedChunk(entry *filesystem.FileNode, chunkIn
 			uuc.staticExpectedPieceRoots[pieceIndex] = pieceSet[0].MerkleRoot
 		}
 	}
+	// Avoid placing the missing pieces on hosts which share a failure domain
+	// with the hosts that are already storing pieces.
+	for _, pieceSet := range pieces {
+		for _, piece := range pieceSet {
+			uuc.avoidCorrelatedHosts(piece.HostPubKey.String())
+		}
+	}
 	// Now that we have calculated the completed pieces for the chunk we can
 	// calculate the health of the chunk to avoid a call to ChunkHealth
 	uuc.health = 1 - (float64(uuc.piecesCompleted-uuc.staticMinimumPieces) / float64(uuc.staticPiecesNeeded-uuc.staticMinimumPieces))
@@ -625,6 +650,11 @@ func (r *Renter) managedBuildUnfinishedChunk(entry *filesystem.FileNode, chunkIn
 // finish would then close the Entry and consequentially impact the remaining
 // chunks.
 func (r *Renter) managedBuildUnfinishedChunks(entry *filesystem.FileNode, hosts map[string]struct{}, target repairTarget, offline, goodForRenew map[string]bool, mm *memoryManager) []*unfinishedUploadChunk {
+	// Don't repair files which expired. They are removed by the expiry loop.
+	if entry.Expiry().Expired(time.Now()) {
+		return nil
+	}
+
 	// If we don't have enough workers for the file, don't repair it right now.
 	minPieces := entry.ErasureCode().MinPieces()
 	r.staticWorkerPool.mu.RLock()
@@ -719,6 +749,18 @@ func (r *Renter) managedBuildUnfinishedChunks(entry *filesystem.FileNode, hosts
 		// Add chunk to list of incompleteChunks if it is incomplete and
 		// repairable or if we are targeting stuck chunks
 		if needsRepair && (repairable || target == targetStuckChunks) {
+			// Skip the chunk if its repair is restricted to the windows of
+			// the scheduler and none of them is active. Backup chunks are
+			// never restricted.
+			if target != targetBackupChunks {
+				chunk.staticSchedulerJob = chunk.schedulerJob()
+			}
+			if !r.staticScheduler.managedAllowed(chunk.staticSchedulerJob, time.Now()) {
+				if err := chunk.fileEntry.Close(); err != nil {
+					r.log.Println("Error closing file entry:", err)
+				}
+				continue
+			}
 			incompleteChunks = append(incompleteChunks, chunk)
 			continue
 		}
@@ -1338,6 +1380,7 @@ func (r *Renter) managedRefreshHostsAndWorkers() map[string]struct{} {
 	for _, contract := range currentContracts {
 		hosts[contract.HostPublicKey.String()] = struct{}{}
 	}
+	r.staticFailureDomains.managedUpdate(r.hostDB, currentContracts)
 	// Refresh the worker pool as well.
 	r.staticWorkerPool.callUpdate()
 	return hosts
@@ -1393,6 +1436,16 @@ func (r *Renter) managedRepairLoop() error {
 			return nil
 		}
 		chunkPath := nextChunk.staticSiaPath
+
+		// Make sure that the scheduler still allows the repair of the chunk
+		// since the window might have ended or its budget might have been
+		// exhausted while the chunk was queued.
+		if !r.staticScheduler.managedTryStart(nextChunk.staticSchedulerJob, nextChunk.remainingUploadSize(), time.Now()) {
+			r.repairLog.Printf("Postponing repair of chunk %v of %s until the next %v window of the scheduler", nextChunk.staticIndex, chunkPath, nextChunk.staticSchedulerJob)
+			nextChunk.fileEntry.Close()
+			r.uploadHeap.managedMarkRepairDone(nextChunk)
+			continue
+		}
 		r.repairLog.Printf("Repairing chunk %v of %s, currently have %v out of %v pieces", nextChunk.staticIndex, chunkPath, nextChunk.piecesCompleted, nextChunk.staticPiecesNeeded)
 
 		// Make sure we have enough workers for this chunk to reach minimum
@@ -1458,6 +1511,7 @@ func (r *Renter) managedRepairLoop() error {
 // sustained for data upload as long as there is at least one chunk in need of
 // upload or repair.
 func (r *Renter) threadedUploadAndRepair() {
+	defer modules.RecoverPanic("renter")
 	err := r.tg.Add()
 	if err != nil {
 		return
@@ -1496,6 +1550,11 @@ func (r *Renter) threadedUploadAndRepair() {
 			return
 		}
 
+		// Wait until the renter is no longer in read-only mode.
+		if !r.managedBlockUntilWritable() {
+			return
+		}
+
 		// Check if repair process has been paused
 		if r.uploadHeap.managedIsPaused() {
 			r.repairLog.Println("Repairs and Uploads have been paused")
@@ -1554,6 +1613,13 @@ func (r *Renter) threadedUploadAndRepair() {
 			r.repairLog.Printf("Added %v backup chunks to the upload heap", numBackupChunks)
 		}
 
+		// Add the chunks of files with a high repair priority before the
+		// chunks of the worst health directories.
+		numPriorityChunks := r.managedAddPriorityChunksToHeap(hosts, offline, goodForRenew)
+		if numPriorityChunks > 0 {
+			r.repairLog.Printf("Added %v chunks of high priority files to the upload heap", numPriorityChunks)
+		}
+
 		// Check if there is work to do. If the filesystem is healthy and the
 		// heap is empty, there is no work to do and the thread should block
 		// until there is work to do.
@@ -3,6 +3,7 @@ package renter
 import (
 	"fmt"
 	"io"
+	"os"
 	"sync"
 
 	"gitlab.com/NebulousLabs/errors"
@@ -17,18 +18,25 @@ import (
 // Upload Streaming Overview:
 // Most of the logic that enables upload streaming can be found within
 // UploadStreamFromReader and the StreamShard. As seen at the beginning of the
-// big for - loop in UploadStreamFromReader, the streamer currently always
-// assumes that the data provided by the user starts at index 0 of chunk 0. In
-// every iteration the siafile is grown by a single chunk to prepare for the
-// upload of the next chunk. To allow the upload code to repair a chunk from a
-// stream, the stream is passed into the unfinished chunk as a new field. If the
-// upload code detects a stream, it will use that instead of a local file to
-// fetch the chunk's logical data. As soon as the upload code is done fetching
-// the logical data, it will close that streamer to signal the loop that it's
-// save to upload another chunk.
+// big for - loop in UploadStreamFromReader, the streamer assumes that the data
+// provided by the user starts at index 0 of chunk 0, unless it is appended to
+// an existing file in which case it starts at the first chunk after the last
+// chunk of the file. In every iteration the siafile is grown by a single chunk
+// to prepare for the upload of the next chunk. To allow the upload code to
+// repair a chunk from a stream, the stream is passed into the unfinished chunk
+// as a new field. If the upload code detects a stream, it will use that instead
+// of a local file to fetch the chunk's logical data. As soon as the upload code
+// is done fetching the logical data, it will close that streamer to signal the
+// loop that it's save to upload another chunk.
 // This is possible due to the custom StreamShard type which is a wrapper for a
 // io.Reader with a channel which is closed when the StreamShard is closed.
 
+var (
+	// ErrAppendPartialChunk is returned when the user tries to append data to
+	// a file whose last chunk isn't full.
+	ErrAppendPartialChunk = errors.New("can't append to a file whose last chunk isn't full")
+)
+
 // StreamShard is a helper type that allows us to split an io.Reader up into
 // multiple readers, wait for the shard to finish reading and then check the
 // error for that Read. SignalChan will be closed when the shard has been
@@ -125,11 +133,23 @@ func (r *Renter) UploadStreamFromReader(up modules.FileUploadParams, reader io.R
 	}
 	defer r.tg.Done()
 
+	// Uploads are not allowed while the renter is in read-only mode.
+	if r.staticReadOnlyMode.managedActive() {
+		return ErrRenterReadOnly
+	}
+
 	// Perform the upload, close the filenode, and return.
 	fileNode, err := r.callUploadStreamFromReader(up, reader)
+	r.staticChaos.managedRecordOperation(err)
 	if err != nil {
 		return errors.AddContext(err, "unable to stream an upload from a reader")
 	}
+	// The metadata of the directory needs to reflect the grown file.
+	if up.Append {
+		if err := r.managedQueueBubbleForFile(up.SiaPath); err != nil {
+			return errors.Compose(err, fileNode.Close())
+		}
+	}
 	return fileNode.Close()
 }
 
@@ -137,7 +157,31 @@ func (r *Renter) UploadStreamFromReader(up modules.FileUploadParams, reader io.R
 // SiaFile for the upload.
 func (r *Renter) managedInitUploadStream(up modules.FileUploadParams) (*filesystem.FileNode, error) {
 	siaPath, ec, force, repair, cipherType := up.SiaPath, up.ErasureCode, up.Force, up.Repair, up.CipherType
-	// Check if ec was set. If not use defaults.
+	// If resume is set open the file of the interrupted stream.
+	if up.Resume {
+		return r.managedInitResumeStream(up)
+	}
+	// If append is set open the existing file.
+	if up.Append {
+		return r.managedInitAppendStream(up)
+	}
+	// An offset is only supported for repairs.
+	if up.Offset != 0 && !repair {
+		return nil, errors.New("'offset' can only be set when doing repairs")
+	}
+	// Check if ec was set. If not use the defaults of the directory or the
+	// renter's defaults.
+	mode := os.FileMode(defaultFilePerm)
+	if !repair {
+		defaultMode, err := r.managedApplyDirDefaults(&up)
+		if err != nil {
+			return nil, errors.AddContext(err, "unable to apply directory defaults")
+		}
+		if defaultMode != 0 {
+			mode = defaultMode
+		}
+		ec = up.ErasureCode
+	}
 	var err error
 	if ec == nil && !repair {
 		ec = modules.NewRSSubCodeDefault()
@@ -151,9 +195,9 @@ func (r *Renter) managedInitUploadStream(up modules.FileUploadParams) (*filesyst
 		return nil, errors.New("'force' and 'repair' can't both be set")
 	}
 
-	// Delete existing file if overwrite flag is set. Ignore ErrUnknownPath.
+	// Archive existing file if overwrite flag is set. Ignore ErrUnknownPath.
 	if force {
-		err := r.DeleteFile(siaPath)
+		err := r.managedArchiveFile(siaPath)
 		if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
 			return nil, err
 		}
@@ -184,11 +228,54 @@ func (r *Renter) managedInitUploadStream(up modules.FileUploadParams) (*filesyst
 	}
 
 	// Create the Siafile and add to renter
-	err = r.staticFileSystem.NewSiaFile(siaPath, up.Source, up.ErasureCode, cipherKey, 0, defaultFilePerm, up.DisablePartialChunk)
+	err = r.staticFileSystem.NewSiaFile(siaPath, up.Source, up.ErasureCode, cipherKey, 0, mode, up.DisablePartialChunk)
 	if err != nil {
 		return nil, err
 	}
-	return r.staticFileSystem.OpenSiaFile(siaPath)
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := staticSetUserMetadata(entry, up); err != nil {
+		return nil, errors.Compose(err, entry.Close())
+	}
+	r.staticSearchIndex.callAdd(siaPath, up.UserTags)
+	return entry, nil
+}
+
+// managedInitAppendStream verifies the upload parameters of an append and opens
+// the existing SiaFile for the upload.
+func (r *Renter) managedInitAppendStream(up modules.FileUploadParams) (_ *filesystem.FileNode, err error) {
+	if up.Force || up.Repair {
+		return nil, errors.New("'append' can't be set together with 'force' or 'repair'")
+	}
+	if up.ErasureCode != nil {
+		return nil, errors.New("can't provide erasure code settings when appending to a file")
+	}
+	entry, err := r.staticFileSystem.OpenSiaFile(up.SiaPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			err = errors.Compose(err, entry.Close())
+		}
+	}()
+	// Only whole chunks can be appended. If the last chunk isn't full, its
+	// padding would need to be replaced by the new data which requires
+	// uploading the chunk again.
+	if entry.Size() != entry.NumChunks()*entry.ChunkSize() {
+		return nil, ErrAppendPartialChunk
+	}
+	// The local file, if there is one, no longer reflects the contents of the
+	// SiaFile after the append. Remove it to make sure that repairs don't try
+	// to use it.
+	if entry.LocalPath() != "" {
+		if err := entry.SetLocalPath(""); err != nil {
+			return nil, errors.AddContext(err, "failed to unset local path")
+		}
+	}
+	return entry, nil
 }
 
 // callUploadStreamFromReader reads from the provided reader until io.EOF is
@@ -248,8 +335,53 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 	// Read the chunks we want to upload one by one from the input stream using
 	// shards. A shard will signal completion after reading the input but
 	// before the upload is done.
+	// When appending, the data starts after the last chunk of the existing
+	// file. Repairs may start at an offset.
+	var firstChunk uint64
+	chunkSize := fileNode.ChunkSize()
+	uid := fileNode.UID()
+	state := uploadStreamState{SiaPath: up.SiaPath}
+	if up.Resume {
+		state, err = r.callLoadUploadStreamState(uid)
+		if err != nil {
+			return nil, errors.Compose(ErrUploadStreamNotResumable, err)
+		}
+		if fileNode.NumChunks() > state.NextChunk+1 {
+			return nil, errors.AddContext(ErrUploadStreamNotResumable, "file has more chunks than the stream")
+		}
+		firstChunk = state.NextChunk
+	} else if up.Append {
+		firstChunk = fileNode.NumChunks()
+	} else if up.Offset != 0 {
+		if up.Offset%chunkSize != 0 || up.Offset >= fileNode.Size() {
+			return nil, fmt.Errorf("offset %v needs to be a multiple of the chunk size %v and within the file of size %v", up.Offset, chunkSize, fileNode.Size())
+		}
+		firstChunk = up.Offset / chunkSize
+	}
+	// Chunks which already contain data of the file don't change the file's
+	// size.
+	existingChunks := fileNode.Size() / chunkSize
+	if fileNode.Size()%chunkSize != 0 {
+		existingChunks++
+	}
+	// The chunks of a resumed stream after the last durable one are
+	// overwritten.
+	if up.Resume {
+		existingChunks = firstChunk
+	}
+	// Repairs don't need to be resumable since they don't change the file.
+	// Otherwise the chunks are spilled to disk until they become available to
+	// be able to resume the stream after an interruption.
+	resumable := !up.Repair
+	if resumable {
+		state.NextChunk = firstChunk
+		if err := r.callSaveUploadStreamState(uid, state); err != nil {
+			r.log.Println("WARN: failed to save upload stream state, the stream won't be resumable:", err)
+			resumable = false
+		}
+	}
 	var chunks []*unfinishedUploadChunk
-	for chunkIndex := uint64(0); ; chunkIndex++ {
+	for chunkIndex := firstChunk; ; chunkIndex++ {
 		// Disrupt the upload by closing the reader and simulating losing
 		// connectivity during the upload.
 		if r.deps.Disrupt("DisruptUploadStream") {
@@ -260,8 +392,11 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 		}
 		// Grow the SiaFile to the right size. Otherwise buildUnfinishedChunk
 		// won't realize that there are pieces which haven't been repaired yet.
-		if err := fileNode.SiaFile.GrowNumChunks(chunkIndex + 1); err != nil {
-			return nil, err
+		existingChunk := chunkIndex < existingChunks
+		if !existingChunk {
+			if err := fileNode.SiaFile.GrowNumChunks(chunkIndex + 1); err != nil {
+				return nil, err
+			}
 		}
 
 		// Start the chunk upload.
@@ -274,19 +409,27 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 		// Create a new shard set it to be the source reader of the chunk.
 		ss := NewStreamShard(reader, peek)
 		uuc.sourceReader = ss
+		uuc.fixedFileSize = existingChunk
 
 		// Check if the chunk needs any work or if we can skip it.
+		var sr *spillReader
 		if uuc.piecesCompleted < uuc.staticPiecesNeeded {
+			// If the stream is resumable, the chunk is spilled to disk while
+			// reading it.
+			if resumable {
+				sr = r.newSpillReader(ss, uid, chunkIndex)
+				uuc.sourceReader = sr
+			}
 			// Add the chunk to the upload heap's repair map.
 			pushed, err := r.managedPushChunkForRepair(uuc, chunkTypeStreamChunk)
 			if err != nil {
-				return nil, errors.AddContext(err, "unable to push chunk")
+				return nil, errors.Compose(errors.AddContext(err, "unable to push chunk"), uuc.sourceReader.Close())
 			}
 			if !pushed {
 				// The chunk wasn't added to the repair map meaning it must have
 				// already been in the repair map
-				_, _ = io.ReadFull(ss, make([]byte, fileNode.ChunkSize()))
-				if err := ss.Close(); err != nil {
+				_, _ = io.ReadFull(uuc.sourceReader, make([]byte, fileNode.ChunkSize()))
+				if err := uuc.sourceReader.Close(); err != nil {
 					return nil, err
 				}
 			}
@@ -317,6 +460,21 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 			return nil, ss.err
 		}
 
+		// The whole chunk was read. If it is durable, a resumed stream can
+		// start after it. Chunks which didn't need any work aren't spilled.
+		if resumable && sr != nil && !sr.managedSpilled() {
+			r.log.Println("WARN: failed to spill chunk, the stream won't be resumable")
+			resumable = false
+			if err := r.callRemoveUploadStreamState(uid); err != nil {
+				return nil, errors.AddContext(err, "failed to remove upload stream state")
+			}
+		} else if resumable {
+			state.NextChunk = chunkIndex + 1
+			if err := r.callSaveUploadStreamState(uid, state); err != nil {
+				return nil, errors.AddContext(err, "failed to save upload stream state")
+			}
+		}
+
 		// Call Peek to make sure that there's more data for another shard.
 		peek, err = ss.Peek()
 		if errors.Contains(err, io.EOF) || errors.Contains(err, io.ErrUnexpectedEOF) {
@@ -346,5 +504,9 @@ func (r *Renter) callUploadStreamFromReader(up modules.FileUploadParams, reader
 	if r.deps.Disrupt("failUploadStreamFromReader") {
 		return nil, errors.New("disrupted by failUploadStreamFromReader")
 	}
+	// All data is available, the stream doesn't need to be resumed anymore.
+	if err := r.callRemoveUploadStream(uid); err != nil {
+		return nil, errors.AddContext(err, "failed to remove upload stream")
+	}
 	return fileNode, nil
 }
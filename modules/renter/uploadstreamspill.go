@@ -0,0 +1,308 @@
+package renter
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+	"go.sia.tech/siad/modules/renter/filesystem/siafile"
+	"go.sia.tech/siad/persist"
+)
+
+// Upload Stream Spilling Overview:
+// While the upload streamer reads a chunk from the stream, the data is also
+// written to a spill file within the renter's persist dir. Once a chunk was
+// read completely and its spill file was synced, the chunk is durable and the
+// state of the stream is updated to resume after it. If the daemon restarts
+// mid-upload, the repair loop uploads the spilled chunks which didn't become
+// available on the network yet and a client can resume the stream at the
+// chunk after the last durable one. A spill file is removed as soon as its
+// chunk becomes available and the whole stream directory is removed once the
+// upload finished.
+
+const (
+	// uploadStreamsDir is the name of the directory within the renter's
+	// persist dir which contains the spilled chunks of upload streams.
+	uploadStreamsDir = "uploadstreams"
+
+	// uploadStreamStateFile is the name of the file which contains the state
+	// of an upload stream within its directory.
+	uploadStreamStateFile = "stream.json"
+
+	// uploadStreamSpillExtension is the extension of spilled chunks.
+	uploadStreamSpillExtension = ".chunk"
+)
+
+var (
+	// ErrUploadStreamNotResumable is returned when trying to resume a stream
+	// which can't be resumed.
+	ErrUploadStreamNotResumable = errors.New("upload stream can't be resumed")
+
+	// uploadStreamMetadata is the metadata of the upload stream state file.
+	uploadStreamMetadata = persist.Metadata{
+		Header:  "Renter Upload Stream",
+		Version: persistVersion,
+	}
+)
+
+type (
+	// uploadStreamState is the persisted state of an upload stream.
+	uploadStreamState struct {
+		// SiaPath is the siapath of the file the stream was uploaded to.
+		SiaPath modules.SiaPath `json:"siapath"`
+
+		// NextChunk is the index of the first chunk which isn't durable yet.
+		// A resumed stream starts at this chunk.
+		NextChunk uint64 `json:"nextchunk"`
+	}
+
+	// spillReader is a io.ReadCloser which wraps the StreamShard of a chunk
+	// and writes the data read from it to a spill file. The spill file is
+	// only moved into place when the reader is closed.
+	spillReader struct {
+		ss *StreamShard
+
+		f       *os.File
+		err     error
+		spilled bool
+
+		staticPath string
+		staticLog  *persist.Logger
+		mu         sync.Mutex
+	}
+)
+
+// uploadStreamDir returns the directory which contains the state and the
+// spilled chunks of the stream uploaded to the file with the provided UID.
+func (r *Renter) uploadStreamDir(uid siafile.SiafileUID) string {
+	return filepath.Join(r.persistDir, uploadStreamsDir, string(uid))
+}
+
+// uploadStreamSpillPath returns the path of a spilled chunk.
+func (r *Renter) uploadStreamSpillPath(uid siafile.SiafileUID, chunkIndex uint64) string {
+	return filepath.Join(r.uploadStreamDir(uid), fmt.Sprintf("%d%s", chunkIndex, uploadStreamSpillExtension))
+}
+
+// newSpillReader creates a spillReader for the chunk with the provided index.
+// Failing to create the spill file isn't fatal to the upload but the chunk
+// won't be durable.
+func (r *Renter) newSpillReader(ss *StreamShard, uid siafile.SiafileUID, chunkIndex uint64) *spillReader {
+	sr := &spillReader{
+		ss:         ss,
+		staticPath: r.uploadStreamSpillPath(uid, chunkIndex),
+		staticLog:  r.log,
+	}
+	sr.f, sr.err = os.OpenFile(sr.staticPath+"_temp", os.O_RDWR|os.O_CREATE|os.O_TRUNC, modules.DefaultFilePerm)
+	if sr.err != nil {
+		sr.staticLog.Printf("WARN: failed to create spill file for chunk %v: %v", chunkIndex, sr.err)
+	}
+	return sr
+}
+
+// Read implements io.Reader. The data read from the shard is written to the
+// spill file. If reading from the shard fails, the chunk isn't spilled since
+// its data is incomplete.
+func (sr *spillReader) Read(b []byte) (int, error) {
+	n, err := sr.ss.Read(b)
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	if n > 0 && sr.err == nil {
+		_, sr.err = sr.f.Write(b[:n])
+		if sr.err != nil {
+			sr.staticLog.Printf("WARN: failed to write to spill file %v: %v", sr.staticPath, sr.err)
+		}
+	}
+	if err != nil && err != io.EOF && sr.err == nil {
+		sr.err = err
+	}
+	return n, err
+}
+
+// Close implements io.Closer. It syncs the spill file and moves it into place
+// before closing the shard to signal the streamer that the chunk was read.
+func (sr *spillReader) Close() error {
+	sr.mu.Lock()
+	if sr.f != nil {
+		if sr.err == nil {
+			sr.err = sr.f.Sync()
+		}
+		sr.err = errors.Compose(sr.err, sr.f.Close())
+		if sr.err == nil {
+			sr.err = os.Rename(sr.f.Name(), sr.staticPath)
+		}
+		if sr.err != nil {
+			sr.staticLog.Printf("WARN: failed to spill chunk to %v: %v", sr.staticPath, sr.err)
+			_ = os.Remove(sr.f.Name())
+		}
+		sr.spilled = sr.err == nil
+		sr.f = nil
+	}
+	sr.mu.Unlock()
+	return sr.ss.Close()
+}
+
+// managedSpilled returns whether the chunk was spilled successfully.
+func (sr *spillReader) managedSpilled() bool {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	return sr.spilled
+}
+
+// callLoadUploadStreamState loads the state of the stream uploaded to the file
+// with the provided UID.
+func (r *Renter) callLoadUploadStreamState(uid siafile.SiafileUID) (uploadStreamState, error) {
+	var state uploadStreamState
+	err := persist.LoadJSON(uploadStreamMetadata, &state, filepath.Join(r.uploadStreamDir(uid), uploadStreamStateFile))
+	return state, err
+}
+
+// callSaveUploadStreamState saves the state of the stream uploaded to the file
+// with the provided UID.
+func (r *Renter) callSaveUploadStreamState(uid siafile.SiafileUID, state uploadStreamState) error {
+	dir := r.uploadStreamDir(uid)
+	if err := os.MkdirAll(dir, modules.DefaultDirPerm); err != nil {
+		return errors.AddContext(err, "failed to create upload stream dir")
+	}
+	return persist.SaveJSON(uploadStreamMetadata, state, filepath.Join(dir, uploadStreamStateFile))
+}
+
+// callRemoveUploadStreamState removes the state of the stream uploaded to the
+// file with the provided UID. The spilled chunks are kept until they become
+// available but the stream can't be resumed anymore.
+func (r *Renter) callRemoveUploadStreamState(uid siafile.SiafileUID) error {
+	err := os.Remove(filepath.Join(r.uploadStreamDir(uid), uploadStreamStateFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// callRemoveUploadStream removes the state and all spilled chunks of the
+// stream uploaded to the file with the provided UID.
+func (r *Renter) callRemoveUploadStream(uid siafile.SiafileUID) error {
+	return os.RemoveAll(r.uploadStreamDir(uid))
+}
+
+// callRemoveSpilledChunk removes the spilled chunk with the provided index if
+// it exists.
+func (r *Renter) callRemoveSpilledChunk(uid siafile.SiafileUID, chunkIndex uint64) error {
+	err := os.Remove(r.uploadStreamSpillPath(uid, chunkIndex))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// staticSpilledChunkExists returns whether the chunk with the provided index
+// was spilled to disk.
+func (r *Renter) staticSpilledChunkExists(uid siafile.SiafileUID, chunkIndex uint64) bool {
+	_, err := os.Stat(r.uploadStreamSpillPath(uid, chunkIndex))
+	return err == nil
+}
+
+// managedPruneUploadStreams removes the upload streams whose files don't exist
+// anymore. It is called on startup.
+func (r *Renter) managedPruneUploadStreams() error {
+	dir := filepath.Join(r.persistDir, uploadStreamsDir)
+	fis, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return errors.AddContext(err, "failed to read upload streams dir")
+	}
+	var errs error
+	for _, fi := range fis {
+		if !fi.IsDir() {
+			continue
+		}
+		uid := siafile.SiafileUID(fi.Name())
+		state, err := r.callLoadUploadStreamState(uid)
+		if err == nil && r.managedFileHasUID(state.SiaPath, uid) {
+			continue
+		}
+		// Without a state the stream can't be resumed and its spilled chunks
+		// can't be matched to a file anymore.
+		errs = errors.Compose(errs, r.callRemoveUploadStream(uid))
+	}
+	return errs
+}
+
+// managedFileHasUID returns whether the file at the provided siapath exists
+// and has the provided UID.
+func (r *Renter) managedFileHasUID(siaPath modules.SiaPath, uid siafile.SiafileUID) bool {
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return false
+	}
+	defer func() {
+		_ = entry.Close()
+	}()
+	return entry.UID() == uid
+}
+
+// managedInitResumeStream verifies the upload parameters of a resumed stream
+// and opens the SiaFile the stream was uploaded to.
+func (r *Renter) managedInitResumeStream(up modules.FileUploadParams) (_ *filesystem.FileNode, err error) {
+	if up.Force || up.Repair || up.Append {
+		return nil, errors.New("'resume' can't be set together with 'force', 'repair' or 'append'")
+	}
+	if up.ErasureCode != nil {
+		return nil, errors.New("can't provide erasure code settings when resuming an upload")
+	}
+	entry, err := r.staticFileSystem.OpenSiaFile(up.SiaPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			err = errors.Compose(err, entry.Close())
+		}
+	}()
+	if _, err := r.callLoadUploadStreamState(entry.UID()); err != nil {
+		return nil, errors.Compose(ErrUploadStreamNotResumable, err)
+	}
+	return entry, nil
+}
+
+// UploadStreamResumeOffset returns the offset within the stream at which an
+// interrupted upload to the provided siapath can be resumed.
+func (r *Renter) UploadStreamResumeOffset(siaPath modules.SiaPath) (uint64, error) {
+	if err := r.tg.Add(); err != nil {
+		return 0, err
+	}
+	defer r.tg.Done()
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = entry.Close()
+	}()
+	state, err := r.callLoadUploadStreamState(entry.UID())
+	if err != nil {
+		return 0, errors.Compose(ErrUploadStreamNotResumable, err)
+	}
+	return state.NextChunk * entry.ChunkSize(), nil
+}
+
+// readSpilledChunk reads the logical data of a chunk from its spill file.
+func (r *Renter) readSpilledChunk(uc *unfinishedUploadChunk) (err error) {
+	f, err := os.Open(r.uploadStreamSpillPath(uc.fileEntry.UID(), uc.staticIndex))
+	if err != nil {
+		return errors.AddContext(err, "unable to open spilled chunk")
+	}
+	defer func() {
+		err = errors.Compose(err, f.Close())
+	}()
+	if _, err := uc.staticReadLogicalData(io.LimitReader(f, int64(uc.length))); err != nil {
+		return errors.AddContext(err, "unable to read the spilled chunk")
+	}
+	return uc.staticEncryptAndCheckIntegrity()
+}
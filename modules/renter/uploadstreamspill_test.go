@@ -0,0 +1,120 @@
+package renter
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem/siafile"
+	"go.sia.tech/siad/persist"
+)
+
+// errorReader is a reader which returns an error after reading all of its
+// data.
+type errorReader struct {
+	r io.Reader
+}
+
+// Read implements io.Reader.
+func (er *errorReader) Read(b []byte) (int, error) {
+	n, err := er.r.Read(b)
+	if err == io.EOF {
+		err = errors.New("connection reset")
+	}
+	return n, err
+}
+
+// TestSpillReader checks that a spillReader only spills chunks which were read
+// completely.
+func TestSpillReader(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir("renter", t.Name())
+	if err := os.MkdirAll(dir, persist.DefaultDiskPermissionsTest); err != nil {
+		t.Fatal(err)
+	}
+	log, err := persist.NewFileLogger(filepath.Join(dir, "renter.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &Renter{persistDir: dir, log: log}
+	uid := siafile.SiafileUID("uid")
+
+	// Save a state to create the stream's directory.
+	state := uploadStreamState{SiaPath: modules.RandomSiaPath(), NextChunk: 2}
+	if err := r.callSaveUploadStreamState(uid, state); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := r.callLoadUploadStreamState(uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.SiaPath.Equals(state.SiaPath) || loaded.NextChunk != state.NextChunk {
+		t.Fatal("loaded state doesn't match", loaded, state)
+	}
+
+	// A chunk which was read until io.EOF is spilled.
+	data := fastrand.Bytes(100)
+	sr := r.newSpillReader(NewStreamShard(bytes.NewReader(data[1:]), data[:1]), uid, 0)
+	if _, err := ioutil.ReadAll(sr); err != nil {
+		t.Fatal(err)
+	}
+	if r.staticSpilledChunkExists(uid, 0) {
+		t.Fatal("chunk shouldn't be spilled before closing the reader")
+	}
+	if err := sr.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !sr.managedSpilled() || !r.staticSpilledChunkExists(uid, 0) {
+		t.Fatal("chunk should be spilled")
+	}
+	spilled, err := ioutil.ReadFile(r.uploadStreamSpillPath(uid, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(spilled, data) {
+		t.Fatal("spilled data doesn't match")
+	}
+
+	// A chunk which couldn't be read completely isn't spilled.
+	sr = r.newSpillReader(NewStreamShard(&errorReader{bytes.NewReader(data)}, nil), uid, 1)
+	if _, err := ioutil.ReadAll(sr); err == nil {
+		t.Fatal("expected read to fail")
+	}
+	if err := sr.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if sr.managedSpilled() || r.staticSpilledChunkExists(uid, 1) {
+		t.Fatal("chunk shouldn't be spilled")
+	}
+
+	// Removing a spilled chunk keeps the state.
+	if err := r.callRemoveSpilledChunk(uid, 0); err != nil {
+		t.Fatal(err)
+	}
+	if r.staticSpilledChunkExists(uid, 0) {
+		t.Fatal("chunk wasn't removed")
+	}
+	if _, err := r.callLoadUploadStreamState(uid); err != nil {
+		t.Fatal(err)
+	}
+
+	// Removing the stream removes its directory.
+	if err := r.callRemoveUploadStream(uid); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(r.uploadStreamDir(uid)); !os.IsNotExist(err) {
+		t.Fatal("stream dir wasn't removed", err)
+	}
+}
@@ -0,0 +1,175 @@
+package renter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/modules"
+)
+
+// errUploadVerificationMismatch is returned if the data downloaded from the
+// hosts doesn't match the local copy of a file.
+var errUploadVerificationMismatch = errors.New("uploaded data doesn't match the local copy")
+
+// threadedVerifyUploads periodically verifies the uploaded data of fully
+// redundant files against their local copies if the user enabled upload
+// verification. This gives users confidence before deleting the local copies.
+func (r *Renter) threadedVerifyUploads() {
+	defer modules.RecoverPanic("renter")
+	if err := r.tg.Add(); err != nil {
+		return
+	}
+	defer r.tg.Done()
+
+	for {
+		select {
+		case <-r.tg.StopChan():
+			return
+		case <-time.After(uploadVerificationInterval):
+		}
+		id := r.mu.RLock()
+		enabled := r.persist.VerifyUploads
+		r.mu.RUnlock(id)
+		if !enabled || !r.staticScheduler.managedAllowed(modules.RenterSchedulerJobAudit, time.Now()) {
+			continue
+		}
+		err := r.managedVerifyUploads()
+		if errors.Contains(err, errSiaFileWalkInterrupted) {
+			return
+		}
+		if err != nil {
+			r.log.Println("WARN: failed to verify uploads:", err)
+		}
+	}
+}
+
+// managedVerifyUploads walks over all the siafiles of the renter and verifies
+// the ones which reached full redundancy and weren't verified since their last
+// modification.
+func (r *Renter) managedVerifyUploads() error {
+	offline, goodForRenew, _ := r.managedContractUtilityMaps()
+	return r.managedWalkSiaFiles(func(siaPath modules.SiaPath) {
+		err := r.managedVerifyUpload(siaPath, offline, goodForRenew)
+		if err != nil {
+			r.log.Printf("WARN: failed to verify upload of siafile %v: %v", siaPath, err)
+		}
+	})
+}
+
+// managedVerifyUpload downloads a sample of the siafile at the given path from
+// its hosts and compares it against the local copy of the file. If they match,
+// the verification time is recorded in the metadata of the siafile. Files
+// without a local copy, without full redundancy or which were already verified
+// are skipped.
+func (r *Renter) managedVerifyUpload(siaPath modules.SiaPath, offline, goodForRenew map[string]bool) (err error) {
+	entry, err := r.staticFileSystem.OpenSiaFile(siaPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, entry.Close())
+	}()
+
+	// Check whether the file needs to be verified.
+	md := entry.Metadata()
+	if md.LocalPath == "" || md.FileSize == 0 {
+		return nil
+	}
+	if lv := md.LastVerifiedTime; !lv.IsZero() && !lv.Before(md.ModTime) {
+		return nil
+	}
+	_, _, health, _, numStuckChunks, _, _ := entry.Health(offline, goodForRenew)
+	if health > 0 || numStuckChunks > 0 {
+		return nil
+	}
+	local, err := os.Open(md.LocalPath)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return errors.AddContext(err, "failed to open local copy")
+	}
+	defer func() {
+		err = errors.Compose(err, local.Close())
+	}()
+	stat, err := local.Stat()
+	if err != nil {
+		return errors.AddContext(err, "failed to stat local copy")
+	}
+	if stat.Size() != md.FileSize {
+		return fmt.Errorf("local copy has a size of %v but the file has a size of %v", stat.Size(), md.FileSize)
+	}
+
+	// Skip the file if the scheduler doesn't allow audits right now. It will
+	// be verified during one of the next windows.
+	offsets := uploadVerificationOffsets(md.FileSize)
+	var size uint64
+	for _, offset := range offsets {
+		size += uint64(uploadVerificationSampleLength(md.FileSize, offset))
+	}
+	if !r.staticScheduler.managedTryStart(modules.RenterSchedulerJobAudit, size, time.Now()) {
+		return nil
+	}
+
+	// Download the samples without falling back to the local copy. The start
+	// of the verification is recorded to not consider modifications during
+	// the verification to be verified.
+	start := time.Now()
+	streamer, err := r.StreamerByNode(entry, true)
+	if err != nil {
+		return errors.AddContext(err, "failed to create streamer")
+	}
+	defer func() {
+		err = errors.Compose(err, streamer.Close())
+	}()
+	alertID := modules.AlertIDSiafileUploadVerification(string(entry.UID()))
+	for _, offset := range offsets {
+		length := uploadVerificationSampleLength(md.FileSize, offset)
+		remoteData := make([]byte, length)
+		if _, err := streamer.Seek(offset, io.SeekStart); err != nil {
+			return errors.AddContext(err, "failed to seek sample")
+		}
+		if _, err := io.ReadFull(streamer, remoteData); err != nil {
+			return errors.AddContext(err, "failed to download sample")
+		}
+		localData := make([]byte, length)
+		if _, err := local.ReadAt(localData, offset); err != nil {
+			return errors.AddContext(err, "failed to read sample from local copy")
+		}
+		if !bytes.Equal(remoteData, localData) {
+			r.staticAlerter.RegisterAlert(alertID, AlertMSGSiafileUploadVerification,
+				AlertCauseSiafileUploadVerification(siaPath, md.LocalPath, offset),
+				modules.SeverityError)
+			return errors.AddContext(errUploadVerificationMismatch, fmt.Sprintf("offset %v", offset))
+		}
+	}
+	r.staticAlerter.UnregisterAlert(alertID)
+	return entry.SetLastVerifiedTime(start)
+}
+
+// uploadVerificationOffsets returns the offsets of the samples which are
+// compared to verify the upload of a file with the given size.
+func uploadVerificationOffsets(fileSize int64) []int64 {
+	if fileSize <= uploadVerificationSampleSize {
+		return []int64{0}
+	}
+	offsets := make([]int64, uploadVerificationSamples)
+	for i := range offsets {
+		offsets[i] = int64(fastrand.Uint64n(uint64(fileSize-uploadVerificationSampleSize) + 1))
+	}
+	return offsets
+}
+
+// uploadVerificationSampleLength returns the length of the sample at the given
+// offset of a file with the given size.
+func uploadVerificationSampleLength(fileSize, offset int64) int64 {
+	length := fileSize - offset
+	if length > uploadVerificationSampleSize {
+		length = uploadVerificationSampleSize
+	}
+	return length
+}
@@ -0,0 +1,34 @@
+package renter
+
+import (
+	"testing"
+)
+
+// TestUploadVerificationOffsets is a unit test for uploadVerificationOffsets.
+func TestUploadVerificationOffsets(t *testing.T) {
+	t.Parallel()
+
+	// Small files are compared in a single sample.
+	for _, size := range []int64{1, uploadVerificationSampleSize} {
+		offsets := uploadVerificationOffsets(size)
+		if len(offsets) != 1 || offsets[0] != 0 {
+			t.Fatal("unexpected offsets", size, offsets)
+		}
+	}
+
+	// Larger files are sampled at random offsets which are all within the
+	// file.
+	for _, size := range []int64{uploadVerificationSampleSize + 1, 10 * uploadVerificationSampleSize} {
+		for i := 0; i < 100; i++ {
+			offsets := uploadVerificationOffsets(size)
+			if len(offsets) != uploadVerificationSamples {
+				t.Fatal("wrong number of samples", len(offsets))
+			}
+			for _, offset := range offsets {
+				if offset < 0 || offset+uploadVerificationSampleSize > size {
+					t.Fatal("sample out of bounds", size, offset)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,280 @@
+package renter
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errUnknownFileVersion is returned if a version of a file doesn't exist.
+	errUnknownFileVersion = errors.New("unknown file version")
+
+	// errVersionSiaPath is returned when trying to version a file which is
+	// already a version of another file.
+	errVersionSiaPath = errors.New("versions of files can't be versioned themselves")
+)
+
+// fileVersionsDir returns the directory in the versions namespace which
+// contains the versions of the file with the provided siapath. The versions of
+// /home/user/foo are stored as /versions/home/user/foo/<id>.
+func fileVersionsDir(siaPath modules.SiaPath) (modules.SiaPath, error) {
+	if siaPath.IsRoot() {
+		return modules.SiaPath{}, errors.New("the root dir can't be versioned")
+	}
+	if siaPath.Equals(modules.VersionsFolder) || strings.HasPrefix(siaPath.String(), modules.VersionsFolder.String()+"/") {
+		return modules.SiaPath{}, errVersionSiaPath
+	}
+	return modules.VersionsFolder.Join(siaPath.String())
+}
+
+// newFileVersionID returns the ID of a version which is archived at the
+// provided time. IDs are the timestamp of the archival in nanoseconds which
+// means they sort the versions chronologically.
+func newFileVersionID(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// parseFileVersionID parses the archival time from the ID of a version.
+func parseFileVersionID(id string) (time.Time, error) {
+	nanos, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || nanos < 0 {
+		return time.Time{}, errUnknownFileVersion
+	}
+	return time.Unix(0, nanos), nil
+}
+
+// versionsToPrune returns the versions which exceed the number of versions to
+// keep or which were archived before the cutoff. The versions are expected to
+// be sorted from newest to oldest.
+func versionsToPrune(versions []modules.FileVersion, keep uint64, cutoff time.Time) []modules.FileVersion {
+	var prune []modules.FileVersion
+	for i, v := range versions {
+		if uint64(i) >= keep || v.ArchivedAt.Before(cutoff) {
+			prune = append(prune, v)
+		}
+	}
+	return prune
+}
+
+// managedFileVersions returns the versions within the provided directory of
+// the versions namespace, ordered from newest to oldest.
+func (r *Renter) managedFileVersions(versionsDir modules.SiaPath) ([]modules.FileVersion, error) {
+	var versions []modules.FileVersion
+	var mu sync.Mutex
+	flf := func(fi modules.FileInfo) {
+		archivedAt, err := parseFileVersionID(fi.SiaPath.Name())
+		if err != nil {
+			return // not a version
+		}
+		mu.Lock()
+		versions = append(versions, modules.FileVersion{
+			ID:         fi.SiaPath.Name(),
+			SiaPath:    fi.SiaPath,
+			Filesize:   fi.Filesize,
+			ArchivedAt: archivedAt,
+		})
+		mu.Unlock()
+	}
+	err := r.staticFileSystem.CachedList(versionsDir, false, flf, func(modules.DirectoryInfo) {})
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].ArchivedAt.After(versions[j].ArchivedAt)
+	})
+	return versions, nil
+}
+
+// managedArchiveFile removes the file at the provided siapath to make room for
+// a new file. If versioning is enabled, the file is moved into the versions
+// namespace and the oldest versions which exceed the limit are pruned.
+// Otherwise the file is deleted.
+func (r *Renter) managedArchiveFile(siaPath modules.SiaPath) error {
+	id := r.mu.RLock()
+	maxVersions := r.persist.MaxFileVersions
+	r.mu.RUnlock(id)
+	versionsDir, err := fileVersionsDir(siaPath)
+	if maxVersions == 0 || errors.Contains(err, errVersionSiaPath) {
+		return r.DeleteFile(siaPath)
+	}
+	if err != nil {
+		return err
+	}
+	if err := r.managedMoveToVersions(siaPath, versionsDir); err != nil {
+		return err
+	}
+	return r.managedPruneFileVersions(versionsDir, maxVersions, time.Time{})
+}
+
+// managedMoveToVersions moves the file at the provided siapath into its
+// directory of the versions namespace.
+func (r *Renter) managedMoveToVersions(siaPath, versionsDir modules.SiaPath) error {
+	archivedAt := time.Now()
+	for {
+		versionPath, err := versionsDir.Join(newFileVersionID(archivedAt))
+		if err != nil {
+			return err
+		}
+		err = r.RenameFile(siaPath, versionPath)
+		if !errors.Contains(err, filesystem.ErrExists) {
+			return err
+		}
+		// Another version was archived at the same time.
+		archivedAt = archivedAt.Add(time.Nanosecond)
+	}
+}
+
+// managedPruneFileVersions deletes the versions within the provided directory
+// of the versions namespace which exceed the number of versions to keep or
+// which were archived before the cutoff.
+func (r *Renter) managedPruneFileVersions(versionsDir modules.SiaPath, keep uint64, cutoff time.Time) error {
+	versions, err := r.managedFileVersions(versionsDir)
+	if err != nil {
+		return errors.AddContext(err, "failed to list versions")
+	}
+	for _, v := range versionsToPrune(versions, keep, cutoff) {
+		if err := r.DeleteFile(v.SiaPath); err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+			return errors.AddContext(err, fmt.Sprintf("failed to delete version %v", v.ID))
+		}
+	}
+	return nil
+}
+
+// managedCollectFileVersions prunes the versions of all files. Versions which
+// exceed the configured limit or which were archived more than a period ago
+// are deleted. This is done whenever the contracts are renewed for a new
+// period to avoid paying for the storage of stale versions indefinitely.
+func (r *Renter) managedCollectFileVersions() error {
+	id := r.mu.RLock()
+	maxVersions := r.persist.MaxFileVersions
+	r.mu.RUnlock(id)
+	var cutoff time.Time
+	if period := r.hostContractor.Allowance().Period; period > 0 {
+		cutoff = time.Now().Add(-time.Duration(period*types.BlockFrequency) * time.Second)
+	}
+
+	// Collect the directories which contain versions.
+	dirs := make(map[modules.SiaPath]struct{})
+	var mu sync.Mutex
+	flf := func(fi modules.FileInfo) {
+		dir, err := fi.SiaPath.Dir()
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		dirs[dir] = struct{}{}
+		mu.Unlock()
+	}
+	err := r.staticFileSystem.CachedList(modules.VersionsFolder, true, flf, func(modules.DirectoryInfo) {})
+	if errors.Contains(err, filesystem.ErrNotExist) {
+		return nil // no versions yet
+	}
+	if err != nil {
+		return errors.AddContext(err, "failed to list versions folder")
+	}
+	for dir := range dirs {
+		err = errors.Compose(err, r.managedPruneFileVersions(dir, maxVersions, cutoff))
+	}
+	return err
+}
+
+// managedTryCollectFileVersions collects the versions of all files if the
+// renter entered a new period since the last collection.
+func (r *Renter) managedTryCollectFileVersions() {
+	period := r.hostContractor.CurrentPeriod()
+	id := r.mu.Lock()
+	if period == r.versionsCollectedPeriod {
+		r.mu.Unlock(id)
+		return
+	}
+	r.versionsCollectedPeriod = period
+	r.mu.Unlock(id)
+
+	if err := r.managedCollectFileVersions(); err != nil {
+		r.log.Println("WARN: failed to collect file versions:", err)
+	}
+}
+
+// FileVersions returns the retained previous versions of a file, ordered from
+// newest to oldest.
+func (r *Renter) FileVersions(siaPath modules.SiaPath) ([]modules.FileVersion, error) {
+	if err := r.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer r.tg.Done()
+	versionsDir, err := fileVersionsDir(siaPath)
+	if err != nil {
+		return nil, err
+	}
+	return r.managedFileVersions(versionsDir)
+}
+
+// PruneFileVersions deletes all but the 'keep' most recent versions of a file.
+func (r *Renter) PruneFileVersions(siaPath modules.SiaPath, keep uint64) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	versionsDir, err := fileVersionsDir(siaPath)
+	if err != nil {
+		return err
+	}
+	return r.managedPruneFileVersions(versionsDir, keep, time.Time{})
+}
+
+// RestoreFileVersion replaces a file with one of its previous versions. If the
+// file still exists, it is archived like any other overwritten file.
+func (r *Renter) RestoreFileVersion(siaPath modules.SiaPath, versionID string) error {
+	if err := r.tg.Add(); err != nil {
+		return err
+	}
+	defer r.tg.Done()
+	versionsDir, err := fileVersionsDir(siaPath)
+	if err != nil {
+		return err
+	}
+	if _, err := parseFileVersionID(versionID); err != nil {
+		return err
+	}
+	versionPath, err := versionsDir.Join(versionID)
+	if err != nil {
+		return err
+	}
+	if _, err := r.staticFileSystem.CachedFileInfo(versionPath); errors.Contains(err, filesystem.ErrNotExist) {
+		return errUnknownFileVersion
+	} else if err != nil {
+		return err
+	}
+
+	// Archive the current file before restoring the version and only prune
+	// the versions afterwards. Otherwise the version might be pruned before
+	// it is restored.
+	id := r.mu.RLock()
+	maxVersions := r.persist.MaxFileVersions
+	r.mu.RUnlock(id)
+	if maxVersions == 0 {
+		err = r.DeleteFile(siaPath)
+	} else {
+		err = r.managedMoveToVersions(siaPath, versionsDir)
+	}
+	if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+		return errors.AddContext(err, "failed to archive current file")
+	}
+	if err := r.RenameFile(versionPath, siaPath); err != nil {
+		return errors.AddContext(err, "failed to restore version")
+	}
+	return r.managedPruneFileVersions(versionsDir, maxVersions, time.Time{})
+}
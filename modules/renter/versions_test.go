@@ -0,0 +1,173 @@
+package renter
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter/filesystem"
+)
+
+// TestVersionsToPrune is a unit test for versionsToPrune.
+func TestVersionsToPrune(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	var versions []modules.FileVersion
+	for i := 0; i < 5; i++ {
+		archivedAt := now.Add(-time.Duration(i) * time.Hour)
+		versions = append(versions, modules.FileVersion{
+			ID:         newFileVersionID(archivedAt),
+			ArchivedAt: archivedAt,
+		})
+	}
+
+	// Prune by count.
+	if prune := versionsToPrune(versions, 3, time.Time{}); len(prune) != 2 || prune[0].ID != versions[3].ID {
+		t.Fatal("unexpected versions to prune", prune)
+	}
+	// Prune by age.
+	if prune := versionsToPrune(versions, 5, now.Add(-90*time.Minute)); len(prune) != 3 || prune[0].ID != versions[2].ID {
+		t.Fatal("unexpected versions to prune", prune)
+	}
+	// Prune everything.
+	if prune := versionsToPrune(versions, 0, time.Time{}); len(prune) != len(versions) {
+		t.Fatal("unexpected versions to prune", prune)
+	}
+
+	// IDs can be parsed again.
+	archivedAt, err := parseFileVersionID(versions[1].ID)
+	if err != nil || !archivedAt.Equal(versions[1].ArchivedAt) {
+		t.Fatal("failed to parse id", archivedAt, err)
+	}
+	if _, err := parseFileVersionID("foo"); !errors.Contains(err, errUnknownFileVersion) {
+		t.Fatal("expected errUnknownFileVersion", err)
+	}
+
+	// Versions can't be versioned.
+	versionsDir, err := fileVersionsDir(modules.UserFolder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fileVersionsDir(versionsDir); !errors.Contains(err, errVersionSiaPath) {
+		t.Fatal("expected errVersionSiaPath", err)
+	}
+}
+
+// TestFileVersions tests archiving, listing, restoring and pruning the
+// versions of a file.
+func TestFileVersions(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	rt, err := newRenterTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := rt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := rt.renter
+	siaPath, rsc := testingFileParams()
+
+	// overwrite creates a new file at siaPath after archiving the existing
+	// one.
+	overwrite := func() {
+		t.Helper()
+		err := r.managedArchiveFile(siaPath)
+		if err != nil && !errors.Contains(err, filesystem.ErrNotExist) {
+			t.Fatal(err)
+		}
+		entry, err := r.createRenterTestFileWithParams(siaPath, rsc, crypto.TypePlain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := entry.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Without versioning, overwritten files are deleted.
+	overwrite()
+	overwrite()
+	versions, err := r.FileVersions(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 0 {
+		t.Fatal("expected no versions", versions)
+	}
+
+	// Enable versioning and overwrite the file a few times.
+	id := r.mu.Lock()
+	r.persist.MaxFileVersions = 2
+	r.mu.Unlock(id)
+	for i := 0; i < 3; i++ {
+		overwrite()
+	}
+	versions, err = r.FileVersions(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 {
+		t.Fatal("expected 2 versions", versions)
+	}
+	if !versions[0].ArchivedAt.After(versions[1].ArchivedAt) {
+		t.Fatal("versions aren't sorted", versions)
+	}
+
+	// Restore the oldest version. The current file becomes the newest version
+	// and the restored version is no longer a version.
+	restored := versions[1]
+	if err := r.RestoreFileVersion(siaPath, restored.ID); err != nil {
+		t.Fatal(err)
+	}
+	versions, err = r.FileVersions(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 || versions[0].ID == restored.ID || versions[1].ID == restored.ID {
+		t.Fatal("unexpected versions after restore", versions)
+	}
+	if _, err := r.File(siaPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RestoreFileVersion(siaPath, restored.ID); !errors.Contains(err, errUnknownFileVersion) {
+		t.Fatal("expected errUnknownFileVersion", err)
+	}
+
+	// Prune the versions.
+	if err := r.PruneFileVersions(siaPath, 1); err != nil {
+		t.Fatal(err)
+	}
+	versions, err = r.FileVersions(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 1 {
+		t.Fatal("expected 1 version", versions)
+	}
+
+	// Disabling versioning causes the garbage collection to delete the
+	// remaining versions.
+	id = r.mu.Lock()
+	r.persist.MaxFileVersions = 0
+	r.mu.Unlock(id)
+	if err := r.managedCollectFileVersions(); err != nil {
+		t.Fatal(err)
+	}
+	versions, err = r.FileVersions(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 0 {
+		t.Fatal("expected no versions", versions)
+	}
+}
@@ -85,6 +85,7 @@ type (
 		staticJobLowPrioReadQueue      *jobReadQueue
 		staticJobReadRegistryQueue     *jobReadRegistryQueue
 		staticJobRenewQueue            *jobRenewQueue
+		staticJobSectorStatsQueue      *jobSectorStatsQueue
 		staticJobUpdateRegistryQueue   *jobUpdateRegistryQueue
 		staticJobUploadSnapshotQueue   *jobUploadSnapshotQueue
 
@@ -102,6 +103,9 @@ type (
 		staticAccount       *account
 		staticBalanceTarget types.Currency
 
+		// staticBenchmark tracks the periodic benchmarks of the worker's host.
+		staticBenchmark *workerBenchmark
+
 		// The loop state contains information about the worker loop. It is
 		// mostly atomic variables that the worker uses to ratelimit the
 		// launching of async jobs.
@@ -116,6 +120,10 @@ type (
 		// registry entries.
 		staticRegistryCache *registryRevisionCache
 
+		// staticSession tracks the SiaMux session which is reused for the
+		// streams the worker opens to the host.
+		staticSession *workerSession
+
 		// staticSetInitialEstimates is an object that ensures the initial queue
 		// estimates of the HS and RJ queues are only set once.
 		staticSetInitialEstimates sync.Once
@@ -238,6 +246,8 @@ func (r *Renter) newWorker(hostPubKey types.SiaPublicKey) (*worker, error) {
 
 		staticRegistryCache: newRegistryCache(registryCacheSize),
 
+		staticSession: new(workerSession),
+
 		staticSubscriptionInfo: &subscriptionInfos{
 			subscriptions:  make(map[modules.RegistryEntryID]*subscription),
 			staticWakeChan: make(chan struct{}, 1),
@@ -255,12 +265,14 @@ func (r *Renter) newWorker(hostPubKey types.SiaPublicKey) (*worker, error) {
 		wakeChan:          make(chan struct{}, 1),
 		renter:            r,
 	}
+	w.newBenchmark()
 	w.newPriceTable()
 	w.newMaintenanceState()
 	w.initJobHasSectorQueue()
 	w.initJobReadQueue()
 	w.initJobLowPrioReadQueue()
 	w.initJobRenewQueue()
+	w.initJobSectorStatsQueue()
 	w.initJobDownloadSnapshotQueue()
 	w.initJobReadRegistryQueue()
 	w.initJobUpdateRegistryQueue()
@@ -0,0 +1,219 @@
+package renter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+const (
+	// benchmarkThroughputReadSize is the size of the read which is used to
+	// measure the throughput of a host. It is capped at the sector size.
+	benchmarkThroughputReadSize = 1 << 20 // 1 MiB
+
+	// benchmarkTTFBReadSize is the size of the read which is used to measure
+	// the time to first byte of a host. It is capped at the sector size.
+	benchmarkTTFBReadSize = 1 << 12 // 4 KiB
+)
+
+var (
+	// benchmarkInterval is the amount of time that needs to pass between two
+	// benchmarks of a host. Since the benchmarks are persisted in the hostdb,
+	// this interval also applies across restarts.
+	benchmarkInterval = build.Select(build.Var{
+		Dev:      time.Minute * 5,
+		Standard: time.Minute * 30,
+		Testing:  time.Minute,
+	}).(time.Duration)
+
+	// benchmarkTimeout is the amount of time a worker waits for a benchmark to
+	// complete before giving up.
+	benchmarkTimeout = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: time.Minute * 2,
+		Testing:  time.Second * 30,
+	}).(time.Duration)
+)
+
+type (
+	// workerBenchmark tracks the benchmarks the worker performs to measure
+	// the latency and throughput of its host. The results of the benchmarks
+	// are stored in the hostdb where they affect the score of the host and
+	// from where they are loaded into the worker's cache.
+	workerBenchmark struct {
+		lastAttempt time.Time
+		running     bool
+
+		mu sync.Mutex
+	}
+)
+
+// benchmarkExpectedReadTime estimates how long it takes to read the given
+// number of bytes from a host with the provided benchmark. Zero is returned if
+// the host's time to first byte wasn't measured yet.
+func benchmarkExpectedReadTime(b modules.HostBenchmark, length uint64) time.Duration {
+	if b.TTFB == 0 {
+		return 0
+	}
+	expected := b.TTFB
+	if b.Throughput > 0 {
+		expected += time.Duration(float64(length) / float64(b.Throughput) * float64(time.Second))
+	}
+	return expected
+}
+
+// benchmarkThroughput computes the throughput in bytes per second from a small
+// and a large read. Subtracting the small read from the large one removes the
+// latency of the round trip from the measurement. If that isn't possible, the
+// large read is used on its own.
+func benchmarkThroughput(smallLength uint64, smallTime time.Duration, largeLength uint64, largeTime time.Duration) uint64 {
+	length, elapsed := largeLength, largeTime
+	if largeLength > smallLength && largeTime > smallTime {
+		length, elapsed = largeLength-smallLength, largeTime-smallTime
+	}
+	if elapsed <= 0 {
+		return 0
+	}
+	return uint64(float64(length) / elapsed.Seconds())
+}
+
+// newBenchmark initializes the benchmark state of the worker. The first
+// benchmark is delayed by a full interval to avoid benchmarking all hosts at
+// once on startup.
+func (w *worker) newBenchmark() {
+	w.staticBenchmark = &workerBenchmark{
+		lastAttempt: time.Now(),
+	}
+}
+
+// managedBenchmark benchmarks the worker's host and records the sample in the
+// hostdb. The round trip time is measured with a HasSector program for a
+// random root. The time to first byte and throughput are measured with a small
+// and a large ReadOffset program which is only possible once the contract
+// with the host contains data.
+func (w *worker) managedBenchmark() error {
+	ctx, cancel := context.WithTimeout(w.renter.tg.StopCtx(), benchmarkTimeout)
+	defer cancel()
+
+	// Measure the round trip time.
+	var sample modules.HostBenchmarkSample
+	var root crypto.Hash
+	fastrand.Read(root[:])
+	responseChan := make(chan *jobHasSectorResponse, 1)
+	jhs := w.newJobHasSector(ctx, responseChan, root)
+	if !w.staticJobHasSectorQueue.callAdd(jhs) {
+		return errors.New("failed to add HasSector job")
+	}
+	select {
+	case <-ctx.Done():
+		return errors.New("HasSector job timed out")
+	case resp := <-responseChan:
+		if resp.staticErr != nil {
+			return errors.AddContext(resp.staticErr, "HasSector job failed")
+		}
+		sample.RTT = resp.staticJobTime
+	}
+
+	// Measure the time to first byte and the throughput if the contract
+	// contains at least one full sector.
+	contract, ok := w.renter.hostContractor.ContractByPublicKey(w.staticHostPubKey)
+	if ok && contract.Size() >= modules.SectorSize {
+		smallLength := uint64(benchmarkTTFBReadSize)
+		largeLength := uint64(benchmarkThroughputReadSize)
+		if smallLength > modules.SectorSize {
+			smallLength = modules.SectorSize
+		}
+		if largeLength > modules.SectorSize {
+			largeLength = modules.SectorSize
+		}
+		small, err := w.managedRunReadOffsetJob(ctx, categoryDownload, 0, smallLength)
+		if err == nil {
+			err = small.staticErr
+		}
+		if err != nil {
+			return errors.AddContext(err, "small ReadOffset job failed")
+		}
+		large, err := w.managedRunReadOffsetJob(ctx, categoryDownload, 0, largeLength)
+		if err == nil {
+			err = large.staticErr
+		}
+		if err != nil {
+			return errors.AddContext(err, "large ReadOffset job failed")
+		}
+		sample.TTFB = small.staticJobTime
+		sample.Throughput = benchmarkThroughput(smallLength, small.staticJobTime, largeLength, large.staticJobTime)
+	}
+
+	// Record the sample and update the cache to make the new statistics
+	// available to the worker right away.
+	err := w.renter.hostDB.RecordBenchmark(w.staticHostPubKey, sample)
+	if err != nil {
+		return errors.AddContext(err, "failed to record benchmark")
+	}
+	w.managedUpdateCache()
+	return nil
+}
+
+// managedSetInitialEstimates sets the initial job time estimates of the HS and
+// RJ queues. If the host was benchmarked before, the estimates are based on
+// the benchmark. Otherwise, or for the statistics that weren't measured, the
+// provided fallback is used.
+func (w *worker) managedSetInitialEstimates(fallback time.Duration) {
+	var b modules.HostBenchmark
+	if cache := w.staticCache(); cache != nil {
+		b = cache.staticHostBenchmark
+	}
+	estimate := func(d time.Duration) time.Duration {
+		if d == 0 {
+			return fallback
+		}
+		return d
+	}
+	w.staticJobHasSectorQueue.callUpdateJobTimeMetrics(estimate(b.RTT))
+	w.staticJobReadQueue.callUpdateJobTimeMetrics(1<<16, estimate(benchmarkExpectedReadTime(b, 1<<16)))
+	w.staticJobReadQueue.callUpdateJobTimeMetrics(1<<20, estimate(benchmarkExpectedReadTime(b, 1<<20)))
+	w.staticJobReadQueue.callUpdateJobTimeMetrics(1<<24, estimate(benchmarkExpectedReadTime(b, 1<<24)))
+}
+
+// staticTryBenchmark launches a benchmark of the worker's host in a goroutine
+// if the host wasn't benchmarked within the last benchmarkInterval. Benchmarks
+// are only launched while the worker is able to perform async jobs.
+func (w *worker) staticTryBenchmark() {
+	if !w.staticPriceTable().staticValid() || w.managedOnMaintenanceCooldown() {
+		return
+	}
+	if cache := w.staticCache(); cache == nil || time.Since(cache.staticHostBenchmark.LastBenchmark) < benchmarkInterval {
+		return
+	}
+	wb := w.staticBenchmark
+	wb.mu.Lock()
+	if wb.running || time.Since(wb.lastAttempt) < benchmarkInterval {
+		wb.mu.Unlock()
+		return
+	}
+	wb.running = true
+	wb.lastAttempt = time.Now()
+	wb.mu.Unlock()
+
+	err := w.renter.tg.Launch(func() {
+		err := w.managedBenchmark()
+		if err != nil {
+			w.renter.log.Debugf("Worker %v: benchmark failed: %v", w.staticHostPubKeyStr, err)
+		}
+		wb.mu.Lock()
+		wb.running = false
+		wb.mu.Unlock()
+	})
+	if err != nil {
+		wb.mu.Lock()
+		wb.running = false
+		wb.mu.Unlock()
+	}
+}
@@ -0,0 +1,130 @@
+package renter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestBenchmarkEstimates is a unit test for the helpers which compute the
+// throughput and the expected read time from benchmarks.
+func TestBenchmarkEstimates(t *testing.T) {
+	t.Parallel()
+
+	// The round trip of the small read is subtracted from the large one.
+	if tp := benchmarkThroughput(1<<12, 100*time.Millisecond, 1<<20+1<<12, 1100*time.Millisecond); tp != 1<<20 {
+		t.Fatal("wrong throughput", tp)
+	}
+	// If both reads have the same size, the large one is used on its own.
+	if tp := benchmarkThroughput(1<<12, 100*time.Millisecond, 1<<12, 500*time.Millisecond); tp != 1<<12*2 {
+		t.Fatal("wrong throughput", tp)
+	}
+	// Same if the large read was faster than the small one.
+	if tp := benchmarkThroughput(1<<12, time.Second, 1<<20, 500*time.Millisecond); tp != 1<<21 {
+		t.Fatal("wrong throughput", tp)
+	}
+	if tp := benchmarkThroughput(1<<12, 0, 1<<12, 0); tp != 0 {
+		t.Fatal("wrong throughput", tp)
+	}
+
+	// Without a TTFB there is no estimate.
+	if d := benchmarkExpectedReadTime(modules.HostBenchmark{RTT: time.Second}, 1<<20); d != 0 {
+		t.Fatal("expected no estimate", d)
+	}
+	// Without a throughput only the TTFB is known.
+	b := modules.HostBenchmark{TTFB: 100 * time.Millisecond}
+	if d := benchmarkExpectedReadTime(b, 1<<20); d != b.TTFB {
+		t.Fatal("wrong estimate", d)
+	}
+	b.Throughput = 1 << 20
+	if d := benchmarkExpectedReadTime(b, 1<<21); d != 2100*time.Millisecond {
+		t.Fatal("wrong estimate", d)
+	}
+}
+
+// TestWorkerBenchmark tests that the worker benchmarks its host and records
+// the results in the hostdb.
+func TestWorkerBenchmark(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	wt, err := newWorkerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	w := wt.worker
+
+	// Wait for the worker to fund its account.
+	err = build.Retry(600, 100*time.Millisecond, func() error {
+		if w.staticAccount.managedMinExpectedBalance().IsZero() {
+			return errors.New("account not funded yet")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Benchmark the host while the contract is empty. Only the RTT can be
+	// measured.
+	if err := w.managedBenchmark(); err != nil {
+		t.Fatal(err)
+	}
+	host, _, err := wt.rt.renter.hostDB.Host(w.staticHostPubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := host.Benchmark
+	if b.NumSamples != 1 || b.RTT == 0 || b.TTFB != 0 || b.Throughput != 0 || b.LastBenchmark.IsZero() {
+		t.Fatal("unexpected benchmark", b)
+	}
+	if w.staticCache().staticHostBenchmark != b {
+		t.Fatal("cache wasn't updated", w.staticCache().staticHostBenchmark)
+	}
+
+	// Upload a snapshot to fill the first sector of the contract and
+	// benchmark the host again.
+	backup := modules.UploadedBackup{
+		Name:         "foo",
+		CreationDate: types.CurrentTimestamp(),
+		Size:         10,
+	}
+	err = wt.UploadSnapshot(context.Background(), backup, fastrand.Bytes(int(backup.Size)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.managedBenchmark(); err != nil {
+		t.Fatal(err)
+	}
+	host, _, err = wt.rt.renter.hostDB.Host(w.staticHostPubKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b = host.Benchmark
+	if b.NumSamples != 2 || b.RTT == 0 || b.TTFB == 0 || b.Throughput == 0 {
+		t.Fatal("unexpected benchmark", b)
+	}
+
+	// The benchmark should be part of the host's score.
+	sb, err := wt.rt.renter.hostDB.ScoreBreakdown(host)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sb.BenchmarkAdjustment <= 0 || sb.BenchmarkAdjustment > 1 {
+		t.Fatal("unexpected benchmark adjustment", sb.BenchmarkAdjustment)
+	}
+}
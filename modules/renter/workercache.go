@@ -28,6 +28,7 @@ type (
 		staticBlockHeight     types.BlockHeight
 		staticContractID      types.FileContractID
 		staticContractUtility modules.ContractUtility
+		staticHostBenchmark   modules.HostBenchmark
 		staticHostVersion     string
 		staticRenterAllowance modules.Allowance
 		staticHostMuxAddress  string
@@ -74,6 +75,7 @@ func (w *worker) managedUpdateCache() {
 		staticBlockHeight:     w.renter.cs.Height(),
 		staticContractID:      renterContract.ID,
 		staticContractUtility: renterContract.Utility,
+		staticHostBenchmark:   host.Benchmark,
 		staticHostMuxAddress:  host.SiaMuxAddress(),
 		staticHostVersion:     host.Version,
 		staticRenterAllowance: w.renter.hostContractor.Allowance(),
@@ -11,6 +11,7 @@ import (
 	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
 )
 
 const (
@@ -132,14 +133,18 @@ func (w *worker) threadedPerformDownloadChunkJob(udc *unfinishedDownloadChunk) {
 	// Fetch the sector. If fetching the sector fails, the worker needs to be
 	// unregistered with the chunk.
 	fetchOffset, fetchLength := sectorOffsetAndLength(udc.staticFetchOffset, udc.staticFetchLength, udc.erasureCode)
-	root := udc.staticChunkMap[w.staticHostPubKey.String()].root
-	pieceData, err := w.ReadSectorLowPrio(w.renter.tg.StopCtx(), udc.staticSpendingCategory, root, fetchOffset, fetchLength)
+	pieceInfo := udc.staticChunkMap[w.staticHostPubKey.String()]
+	pieceData, err := w.ReadSectorLowPrio(w.renter.tg.StopCtx(), udc.staticSpendingCategory, pieceInfo.root, fetchOffset, fetchLength, pieceInfo.prefetch)
 	if err != nil {
 		w.renter.log.Debugln("worker failed to download sector:", err)
 		udc.managedUnregisterWorker(w)
 		return
 	}
 
+	// Attribute the estimated cost of the read to the file.
+	cost := w.staticJobReadQueue.callExpectedJobCost(fetchLength)
+	w.renter.staticFileSpending.callAdd(udc.download.staticSiaPath, cost, types.ZeroCurrency, types.ZeroCurrency)
+
 	// TODO: Instead of adding the whole sector after the download completes,
 	// have the 'd.Sector' call add to this value ongoing as the sector comes
 	// in. Perhaps even include the data from creating the downloader and other
@@ -200,6 +205,9 @@ func (udc *unfinishedDownloadChunk) managedUnregisterWorker(w *worker) {
 	udc.mu.Lock()
 	udc.piecesRegistered--
 	udc.pieceUsage[udc.staticChunkMap[w.staticHostPubKey.String()].index] = false
+	// The preferred workers weren't sufficient, the standby workers need to be
+	// able to step in.
+	udc.preferredWorkers = nil
 	udc.mu.Unlock()
 }
 
@@ -220,6 +228,11 @@ func (w *worker) managedProcessDownloadChunk(udc *unfinishedDownloadChunk) *unfi
 	pieceData, workerHasPiece := udc.staticChunkMap[w.staticHostPubKey.String()]
 	pieceCompleted := udc.completedPieces[pieceData.index]
 	if chunkComplete || chunkFailed || onCooldown || !workerHasPiece || pieceCompleted {
+		// If a preferred worker drops out, the standby workers need to be able
+		// to step in.
+		if _, preferred := udc.preferredWorkers[w.staticHostPubKeyStr]; preferred {
+			udc.preferredWorkers = nil
+		}
 		udc.mu.Unlock()
 		udc.managedRemoveWorker()
 
@@ -256,13 +269,12 @@ func (w *worker) managedProcessDownloadChunk(udc *unfinishedDownloadChunk) *unfi
 	// metrics, so that we can avoid holding the worker lock and the udc lock
 	// simultaneously (deadlock risk). The 'owned' variables of the worker are
 	// variables that are only accessed by the master worker thread.
-	meetsExtraCriteria := true
-
-	// TODO: There's going to need to be some method for relaxing criteria after
-	// the first wave of workers are sent off. If the first waves of workers
-	// fail, the next wave need to realize that they shouldn't immediately go on
-	// standby because for some reason there were failures in the first wave and
-	// now the second/etc. wave of workers is needed.
+	//
+	// The download's policy determines the set of preferred workers. The
+	// criteria are relaxed by clearing the set once a preferred worker fails,
+	// so that the next wave of workers doesn't immediately go on standby.
+	_, preferred := udc.preferredWorkers[w.staticHostPubKeyStr]
+	meetsExtraCriteria := udc.preferredWorkers == nil || preferred
 
 	// Figure out if this chunk needs another worker actively downloading
 	// pieces. The number of workers that should be active simultaneously on
@@ -17,6 +17,10 @@ const (
 	// decayed each time a new datapoint is added. The jobs use an exponential
 	// weighted average.
 	jobHasSectorPerformanceDecay = 0.9
+
+	// minBatchHasSectorVersion is the minimum version of a host that supports
+	// the BatchHasSector RPC.
+	minBatchHasSectorVersion = "1.5.11"
 )
 
 type (
@@ -148,6 +152,12 @@ func (j *jobHasSector) managedHasSector() ([]bool, error) {
 	bandwidthCost := modules.MDMBandwidthCost(pt, ulBandwidth, dlBandwidth)
 	cost = cost.Add(bandwidthCost)
 
+	// Check all roots in a single batch if the host supports it and it's
+	// cheaper than the program.
+	if useBatchHasSector(w.staticCache().staticHostVersion, &pt, len(j.staticSectors), cost) {
+		return j.managedBatchHasSector(pt)
+	}
+
 	// Execute the program and parse the responses.
 	hasSectors := make([]bool, 0, len(program))
 	var responses []programResponse
@@ -167,6 +177,87 @@ func (j *jobHasSector) managedHasSector() ([]bool, error) {
 	return hasSectors, nil
 }
 
+// managedBatchHasSector checks whether the host has the job's sectors using
+// the BatchHasSector RPC.
+func (j *jobHasSector) managedBatchHasSector(pt modules.RPCPriceTable) (_ []bool, err error) {
+	w := j.staticQueue.staticWorker()
+
+	// Defer a function that schedules a price table update in case we received
+	// an error that indicates the host deems our price table invalid.
+	defer func() {
+		if modules.IsPriceTableInvalidErr(err) {
+			w.staticTryForcePriceTableUpdate()
+		}
+	}()
+
+	// Track the withdrawal.
+	cost := modules.BatchHasSectorRPCCost(&pt)
+	w.staticAccount.managedTrackWithdrawal(cost)
+	defer func() {
+		w.staticAccount.managedCommitWithdrawal(categoryDownload, cost, types.ZeroCurrency, err == nil)
+	}()
+
+	// Get a stream.
+	stream, err := w.staticNewStream()
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to create a new stream")
+	}
+	defer func() {
+		if err := stream.Close(); err != nil {
+			w.renter.log.Println("ERROR: failed to close stream", err)
+		}
+	}()
+
+	// write the specifier
+	err = modules.RPCWrite(stream, modules.RPCBatchHasSector)
+	if err != nil {
+		return nil, err
+	}
+
+	// send price table uid
+	err = modules.RPCWrite(stream, pt.UID)
+	if err != nil {
+		return nil, err
+	}
+
+	// provide payment
+	err = w.staticAccount.ProvidePayment(stream, cost, pt.HostBlockHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	// send the request.
+	err = modules.RPCWrite(stream, modules.BatchHasSectorRequest{Roots: j.staticSectors})
+	if err != nil {
+		return nil, err
+	}
+
+	// read the response
+	var resp modules.BatchHasSectorResponse
+	err = modules.RPCReadMaxLen(stream, &resp, modules.BatchHasSectorResponseMaxLen)
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to read BatchHasSector response")
+	}
+	if len(resp.Availables) != len(j.staticSectors) {
+		return nil, errors.New("received invalid number of responses but no error")
+	}
+	return resp.Availables, nil
+}
+
+// useBatchHasSector returns true if a HasSector job for numRoots roots should
+// use the BatchHasSector RPC instead of a program. That is the case if the
+// host supports the RPC, the roots fit into a single batch and the batch is
+// cheaper than the program.
+func useBatchHasSector(hostVersion string, pt *modules.RPCPriceTable, numRoots int, programCost types.Currency) bool {
+	if build.VersionCmp(hostVersion, minBatchHasSectorVersion) < 0 {
+		return false
+	}
+	if numRoots > modules.MaxBatchHasSectorRoots {
+		return false
+	}
+	return modules.BatchHasSectorRPCCost(pt).Cmp(programCost) < 0
+}
+
 // callAddWithEstimate will add a job to the queue and return a timestamp for
 // when the job is estimated to complete. An error will be returned if the job
 // is not successfully queued.
@@ -1,8 +1,11 @@
 package renter
 
 import (
+	"context"
 	"testing"
 
+	"gitlab.com/NebulousLabs/fastrand"
+
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
@@ -100,3 +103,95 @@ func TestHasSectorJobExpectedBandwidth(t *testing.T) {
 		t.Fatal("unexpected")
 	}
 }
+
+// TestUseBatchHasSector is a unit test for useBatchHasSector.
+func TestUseBatchHasSector(t *testing.T) {
+	t.Parallel()
+
+	pt := &modules.RPCPriceTable{
+		InitBaseCost:       types.NewCurrency64(10),
+		BatchHasSectorCost: types.NewCurrency64(5),
+	}
+	cheap, expensive := types.NewCurrency64(15), types.NewCurrency64(16)
+
+	// The batch is used if it's cheaper than the program.
+	if !useBatchHasSector(minBatchHasSectorVersion, pt, 100, expensive) {
+		t.Fatal("expected batch to be used")
+	}
+	if useBatchHasSector(minBatchHasSectorVersion, pt, 100, cheap) {
+		t.Fatal("expected program to be used")
+	}
+	// Old hosts don't support the RPC.
+	if useBatchHasSector("1.5.10", pt, 100, expensive) {
+		t.Fatal("expected program to be used")
+	}
+	// The roots need to fit into a single batch.
+	if useBatchHasSector(minBatchHasSectorVersion, pt, modules.MaxBatchHasSectorRoots+1, expensive) {
+		t.Fatal("expected program to be used")
+	}
+}
+
+// TestHasSectorJobBatch tests that a HasSector job with many roots is
+// executed using the BatchHasSector RPC.
+func TestHasSectorJobBatch(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	wt, err := newWorkerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	w := wt.worker
+
+	// Add a sector to the host.
+	sectorData := fastrand.Bytes(int(modules.SectorSize))
+	sectorRoot := crypto.MerkleRoot(sectorData)
+	err = wt.host.AddSector(sectorRoot, sectorData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create a job for a lot of random roots and the sector.
+	roots := make([]crypto.Hash, 500)
+	for i := range roots {
+		fastrand.Read(roots[i][:])
+	}
+	roots[100] = sectorRoot
+
+	// The job should use the batch.
+	pt := w.staticPriceTable().staticPriceTable
+	pb := modules.NewProgramBuilder(&pt, 0)
+	for _, root := range roots {
+		pb.AddHasSectorInstruction(root)
+	}
+	programCost, _, _ := pb.Cost(true)
+	if !useBatchHasSector(w.staticCache().staticHostVersion, &pt, len(roots), programCost) {
+		t.Fatal("expected the job to use the batch")
+	}
+
+	// Run the job.
+	responseChan := make(chan *jobHasSectorResponse, 1)
+	jhs := w.newJobHasSector(context.Background(), responseChan, roots...)
+	if !w.staticJobHasSectorQueue.callAdd(jhs) {
+		t.Fatal("could not add job to queue")
+	}
+	resp := <-responseChan
+	if resp.staticErr != nil {
+		t.Fatal(resp.staticErr)
+	}
+	if len(resp.staticAvailables) != len(roots) {
+		t.Fatal("unexpected number of responses", len(resp.staticAvailables))
+	}
+	for i, available := range resp.staticAvailables {
+		if available != (i == 100) {
+			t.Fatal("unexpected response for root", i, available)
+		}
+	}
+}
@@ -99,6 +99,16 @@ func (j *jobReadOffset) managedReadOffset() ([]byte, error) {
 
 // ReadOffset is a helper method to run a ReadOffset job on a worker.
 func (w *worker) ReadOffset(ctx context.Context, category spendingCategory, offset, length uint64) ([]byte, error) {
+	resp, err := w.managedRunReadOffsetJob(ctx, category, offset, length)
+	if err != nil {
+		return nil, err
+	}
+	return resp.staticData, resp.staticErr
+}
+
+// managedRunReadOffsetJob runs a ReadOffset job on the worker and returns the
+// full response of the job, including the time it took to execute.
+func (w *worker) managedRunReadOffsetJob(ctx context.Context, category spendingCategory, offset, length uint64) (*jobReadResponse, error) {
 	readOffsetRespChan := make(chan *jobReadResponse)
 	jro := &jobReadOffset{
 		jobRead: jobRead{
@@ -119,11 +129,10 @@ func (w *worker) ReadOffset(ctx context.Context, category spendingCategory, offs
 	}
 
 	// Wait for the response.
-	var resp *jobReadResponse
 	select {
 	case <-ctx.Done():
 		return nil, errors.New("Read interrupted")
-	case resp = <-readOffsetRespChan:
+	case resp := <-readOffsetRespChan:
+		return resp, nil
 	}
-	return resp.staticData, resp.staticErr
 }
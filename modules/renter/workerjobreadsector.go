@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 )
@@ -16,6 +17,10 @@ type (
 
 		staticOffset uint64
 		staticSector crypto.Hash
+
+		// staticPrefetch contains the roots of the sectors which are likely
+		// to be read from the host next. It is sent to the host as a hint.
+		staticPrefetch []crypto.Hash
 	}
 )
 
@@ -36,7 +41,11 @@ func (j *jobReadSector) managedReadSector() ([]byte, error) {
 	w := j.staticQueue.staticWorker()
 	pt := w.staticPriceTable().staticPriceTable
 	pb := modules.NewProgramBuilder(&pt, 0) // 0 duration since ReadSector doesn't depend on it.
-	pb.AddReadSectorInstruction(j.staticLength, j.staticOffset, j.staticSector, true)
+	if len(j.staticPrefetch) > 0 && build.VersionCmp(w.staticCache().staticHostVersion, minSectorPrefetchVersion) >= 0 {
+		pb.AddReadSectorWithPrefetchInstruction(j.staticLength, j.staticOffset, j.staticSector, j.staticPrefetch, true)
+	} else {
+		pb.AddReadSectorInstruction(j.staticLength, j.staticOffset, j.staticSector, true)
+	}
 	program, programData := pb.Program()
 	cost, _, _ := pb.Cost(true)
 
@@ -62,7 +71,7 @@ func (j *jobReadSector) managedReadSector() ([]byte, error) {
 }
 
 // newJobReadSector creates a new read sector job.
-func (w *worker) newJobReadSector(ctx context.Context, queue *jobReadQueue, respChan chan *jobReadResponse, category spendingCategory, root crypto.Hash, offset, length uint64) *jobReadSector {
+func (w *worker) newJobReadSector(ctx context.Context, queue *jobReadQueue, respChan chan *jobReadResponse, category spendingCategory, root crypto.Hash, offset, length uint64, prefetch []crypto.Hash) *jobReadSector {
 	return &jobReadSector{
 		jobRead: jobRead{
 			staticResponseChan: respChan,
@@ -74,16 +83,17 @@ func (w *worker) newJobReadSector(ctx context.Context, queue *jobReadQueue, resp
 				staticWorker:           w,
 			}),
 		},
-		staticOffset: offset,
-		staticSector: root,
+		staticOffset:   offset,
+		staticSector:   root,
+		staticPrefetch: prefetch,
 	}
 }
 
 // ReadSector is a helper method to run a ReadSector job with low priority on a
-// worker.
-func (w *worker) ReadSectorLowPrio(ctx context.Context, category spendingCategory, root crypto.Hash, offset, length uint64) ([]byte, error) {
+// worker. The host is hinted to prefetch the sectors with the provided roots.
+func (w *worker) ReadSectorLowPrio(ctx context.Context, category spendingCategory, root crypto.Hash, offset, length uint64, prefetch []crypto.Hash) ([]byte, error) {
 	readSectorRespChan := make(chan *jobReadResponse)
-	jro := w.newJobReadSector(ctx, w.staticJobLowPrioReadQueue, readSectorRespChan, category, root, offset, length)
+	jro := w.newJobReadSector(ctx, w.staticJobLowPrioReadQueue, readSectorRespChan, category, root, offset, length, prefetch)
 
 	// Add the job to the queue.
 	if !w.staticJobReadQueue.callAdd(jro) {
@@ -103,7 +113,7 @@ func (w *worker) ReadSectorLowPrio(ctx context.Context, category spendingCategor
 // ReadSector is a helper method to run a ReadSector job on a worker.
 func (w *worker) ReadSector(ctx context.Context, category spendingCategory, root crypto.Hash, offset, length uint64) ([]byte, error) {
 	readSectorRespChan := make(chan *jobReadResponse)
-	jro := w.newJobReadSector(ctx, w.staticJobReadQueue, readSectorRespChan, category, root, offset, length)
+	jro := w.newJobReadSector(ctx, w.staticJobReadQueue, readSectorRespChan, category, root, offset, length, nil)
 
 	// Add the job to the queue.
 	if !w.staticJobReadQueue.callAdd(jro) {
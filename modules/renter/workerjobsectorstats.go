@@ -0,0 +1,248 @@
+package renter
+
+import (
+	"context"
+	"time"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+const (
+	// jobSectorStatsPerformanceDecay defines how much the average performance
+	// is decayed each time a new datapoint is added. The jobs use an
+	// exponential weighted average.
+	jobSectorStatsPerformanceDecay = 0.9
+
+	// minSectorStatsVersion is the minimum version of a host that supports
+	// the SectorStats RPC.
+	minSectorStatsVersion = "1.5.9"
+
+	// sectorStatSize is the encoded size of a single modules.SectorStat.
+	sectorStatSize = 1 + 8
+)
+
+type (
+	// jobSectorStats contains information about a SectorStats query.
+	jobSectorStats struct {
+		staticSectors []crypto.Hash
+
+		staticResponseChan chan *jobSectorStatsResponse
+
+		*jobGeneric
+	}
+
+	// jobSectorStatsQueue is a list of SectorStats queries that have been
+	// assigned to the worker.
+	jobSectorStatsQueue struct {
+		// These variables contain an exponential weighted average of the
+		// worker's recent performance for jobSectorStatsQueue.
+		weightedJobTime float64
+
+		*jobGenericQueue
+	}
+
+	// jobSectorStatsResponse contains the result of a SectorStats query.
+	jobSectorStatsResponse struct {
+		staticStats []modules.SectorStat
+		staticErr   error
+
+		// The worker is included in the response so that the caller can listen
+		// on one channel for a bunch of workers and still know which host the
+		// stats belong to.
+		staticWorker *worker
+	}
+)
+
+// newJobSectorStats is a helper method to create a new SectorStats job.
+func (w *worker) newJobSectorStats(ctx context.Context, responseChan chan *jobSectorStatsResponse, roots ...crypto.Hash) *jobSectorStats {
+	return &jobSectorStats{
+		staticSectors:      roots,
+		staticResponseChan: responseChan,
+		jobGeneric:         newJobGeneric(ctx, w.staticJobSectorStatsQueue, nil),
+	}
+}
+
+// callDiscard will discard a job, sending the provided error.
+func (j *jobSectorStats) callDiscard(err error) {
+	w := j.staticQueue.staticWorker()
+	errLaunch := w.renter.tg.Launch(func() {
+		response := &jobSectorStatsResponse{
+			staticErr:    errors.Extend(err, ErrJobDiscarded),
+			staticWorker: w,
+		}
+		select {
+		case j.staticResponseChan <- response:
+		case <-j.staticCtx.Done():
+		case <-w.renter.tg.StopChan():
+		}
+	})
+	if errLaunch != nil {
+		w.renter.log.Debugln("callDiscard: launch failed", err)
+	}
+}
+
+// callExecute will run the SectorStats job.
+func (j *jobSectorStats) callExecute() {
+	start := time.Now()
+	w := j.staticQueue.staticWorker()
+	stats, err := j.managedSectorStats()
+	jobTime := time.Since(start)
+
+	// Send the response.
+	response := &jobSectorStatsResponse{
+		staticStats:  stats,
+		staticErr:    err,
+		staticWorker: w,
+	}
+	errLaunch := w.renter.tg.Launch(func() {
+		select {
+		case j.staticResponseChan <- response:
+		case <-j.staticCtx.Done():
+		case <-w.renter.tg.StopChan():
+		}
+	})
+	if errLaunch != nil {
+		w.renter.log.Debugln("callExecute: launch failed", err)
+	}
+
+	// Report success or failure to the queue.
+	if err != nil {
+		j.staticQueue.callReportFailure(err)
+		return
+	}
+	j.staticQueue.callReportSuccess()
+
+	// Job was a success, update the performance stats on the queue.
+	jq := j.staticQueue.(*jobSectorStatsQueue)
+	jq.mu.Lock()
+	jq.weightedJobTime = expMovingAvg(jq.weightedJobTime, float64(jobTime), jobSectorStatsPerformanceDecay)
+	jq.mu.Unlock()
+}
+
+// callExpectedBandwidth returns the bandwidth that is expected to be consumed
+// by the job.
+func (j *jobSectorStats) callExpectedBandwidth() (ul, dl uint64) {
+	// sanity check
+	if len(j.staticSectors) == 0 {
+		build.Critical("expected bandwidth requested for a job that has no staticSectors set")
+	}
+	return sectorStatsJobExpectedBandwidth(len(j.staticSectors))
+}
+
+// managedSectorStats performs the SectorStats RPC on the host and returns the
+// stats of the job's sectors.
+func (j *jobSectorStats) managedSectorStats() (_ []modules.SectorStat, err error) {
+	w := j.staticQueue.staticWorker()
+
+	// Defer a function that schedules a price table update in case we received
+	// an error that indicates the host deems our price table invalid.
+	defer func() {
+		if modules.IsPriceTableInvalidErr(err) {
+			w.staticTryForcePriceTableUpdate()
+		}
+	}()
+
+	// Compute the cost.
+	pt := w.staticPriceTable().staticPriceTable
+	cost := modules.SectorStatsCost(&pt, uint64(len(j.staticSectors)))
+
+	// Track the withdrawal.
+	w.staticAccount.managedTrackWithdrawal(cost)
+	defer func() {
+		w.staticAccount.managedCommitWithdrawal(categoryDownload, cost, types.ZeroCurrency, err == nil)
+	}()
+
+	// Get a stream.
+	stream, err := w.staticNewStream()
+	if err != nil {
+		return nil, errors.AddContext(err, "unable to create a new stream")
+	}
+	defer func() {
+		if err := stream.Close(); err != nil {
+			w.renter.log.Println("ERROR: failed to close stream", err)
+		}
+	}()
+
+	// write the specifier
+	err = modules.RPCWrite(stream, modules.RPCSectorStats)
+	if err != nil {
+		return nil, err
+	}
+
+	// send price table uid
+	err = modules.RPCWrite(stream, pt.UID)
+	if err != nil {
+		return nil, err
+	}
+
+	// provide payment
+	err = w.staticAccount.ProvidePayment(stream, cost, pt.HostBlockHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	// send the request.
+	err = modules.RPCWrite(stream, modules.SectorStatsRequest{Roots: j.staticSectors})
+	if err != nil {
+		return nil, err
+	}
+
+	// read the response
+	var resp modules.SectorStatsResponse
+	err = modules.RPCReadMaxLen(stream, &resp, modules.SectorStatsResponseMaxLen)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Stats) != len(j.staticSectors) {
+		return nil, errors.New("received invalid number of sector stats")
+	}
+	return resp.Stats, nil
+}
+
+// initJobSectorStatsQueue will init the queue for the SectorStats jobs.
+func (w *worker) initJobSectorStatsQueue() {
+	// Sanity check that there is no existing job queue.
+	if w.staticJobSectorStatsQueue != nil {
+		w.renter.log.Critical("incorret call on initJobSectorStatsQueue")
+		return
+	}
+
+	w.staticJobSectorStatsQueue = &jobSectorStatsQueue{
+		jobGenericQueue: newJobGenericQueue(w),
+	}
+}
+
+// SectorStats is a helper method to run a SectorStats job on a worker.
+func (w *worker) SectorStats(ctx context.Context, roots []crypto.Hash) ([]modules.SectorStat, error) {
+	sectorStatsRespChan := make(chan *jobSectorStatsResponse)
+	jss := w.newJobSectorStats(ctx, sectorStatsRespChan, roots...)
+
+	// Add the job to the queue.
+	if !w.staticJobSectorStatsQueue.callAdd(jss) {
+		return nil, errors.New("worker unavailable")
+	}
+
+	// Wait for the response.
+	var resp *jobSectorStatsResponse
+	select {
+	case <-ctx.Done():
+		return nil, errors.New("SectorStats interrupted")
+	case resp = <-sectorStatsRespChan:
+	}
+	return resp.staticStats, resp.staticErr
+}
+
+// sectorStatsJobExpectedBandwidth is a helper function that returns the
+// expected bandwidth consumption of a SectorStats job. This helper function
+// enables getting at the expected bandwidth without having to instantiate a
+// job.
+func sectorStatsJobExpectedBandwidth(numRoots int) (ul, dl uint64) {
+	ul = ethernetMTU * (1 + uint64(numRoots)*crypto.HashSize/ethernetMTU)
+	dl = ethernetMTU * (1 + uint64(numRoots)*sectorStatSize/ethernetMTU)
+	return
+}
@@ -0,0 +1,72 @@
+package renter
+
+import (
+	"context"
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+)
+
+// TestSectorStatsJob tests running a SectorStats job on a host.
+func TestSectorStatsJob(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	wt, err := newWorkerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Add a sector to the host.
+	sectorData := fastrand.Bytes(int(modules.SectorSize))
+	sectorRoot := crypto.MerkleRoot(sectorData)
+	err = wt.host.AddSector(sectorRoot, sectorData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Fetch the stats of the sector and a random one.
+	var randomRoot crypto.Hash
+	fastrand.Read(randomRoot[:])
+	stats, err := wt.SectorStats(context.Background(), []crypto.Hash{sectorRoot, randomRoot})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 2 {
+		t.Fatal("unexpected number of stats", len(stats))
+	}
+	if !stats[0].Exists || stats[0].Size != modules.SectorSize {
+		t.Fatal("unexpected stat for existing sector", stats[0])
+	}
+	if stats[1].Exists || stats[1].Size != 0 {
+		t.Fatal("unexpected stat for random sector", stats[1])
+	}
+}
+
+// TestSectorStatsJobExpectedBandwidth is a unit test that verifies the
+// bandwidth estimates of the SectorStats job cover the request and response.
+func TestSectorStatsJobExpectedBandwidth(t *testing.T) {
+	t.Parallel()
+
+	for _, numRoots := range []int{1, 10, 100, modules.MaxSectorStatsRoots} {
+		ul, dl := sectorStatsJobExpectedBandwidth(numRoots)
+		if ul < uint64(numRoots*crypto.HashSize) {
+			t.Fatalf("upload estimate %v too low for %v roots", ul, numRoots)
+		}
+		if dl < uint64(numRoots*sectorStatSize) {
+			t.Fatalf("download estimate %v too low for %v roots", dl, numRoots)
+		}
+		if ul%ethernetMTU != 0 || dl%ethernetMTU != 0 {
+			t.Fatal("estimates should be multiples of the MTU", ul, dl)
+		}
+	}
+}
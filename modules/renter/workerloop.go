@@ -247,6 +247,14 @@ func (w *worker) externTryLaunchAsyncJob() bool {
 			return true
 		}
 	}
+	// Check if sector stats jobs are supported.
+	if build.VersionCmp(cache.staticHostVersion, minSectorStatsVersion) >= 0 {
+		job = w.staticJobSectorStatsQueue.callNext()
+		if job != nil {
+			w.externLaunchAsyncJob(job)
+			return true
+		}
+	}
 	job = w.staticJobReadQueue.callNext()
 	if job != nil {
 		w.externLaunchAsyncJob(job)
@@ -283,6 +291,7 @@ func (w *worker) managedDiscardAsyncJobs(err error) {
 	w.staticJobHasSectorQueue.callDiscardAll(err)
 	w.staticJobUpdateRegistryQueue.callDiscardAll(err)
 	w.staticJobReadRegistryQueue.callDiscardAll(err)
+	w.staticJobSectorStatsQueue.callDiscardAll(err)
 	w.staticJobReadQueue.callDiscardAll(err)
 	w.staticJobLowPrioReadQueue.callDiscardAll(err)
 }
@@ -303,6 +312,7 @@ func (w *worker) threadedWorkLoop() {
 	defer w.staticJobLowPrioReadQueue.callKill()
 	defer w.staticJobHasSectorQueue.callKill()
 	defer w.staticJobUpdateRegistryQueue.callKill()
+	defer w.staticJobSectorStatsQueue.callKill()
 	defer w.staticJobReadQueue.callKill()
 	defer w.staticJobDownloadSnapshotQueue.callKill()
 	defer w.staticJobUploadSnapshotQueue.callKill()
@@ -355,6 +365,14 @@ func (w *worker) threadedWorkLoop() {
 		// to build the cache object.
 		w.staticTryUpdateCache()
 
+		// Keep the session with the host alive if it was used recently. This
+		// is non-blocking.
+		w.staticTryKeepaliveSession()
+
+		// Benchmark the host if the last benchmark is outdated. This is
+		// non-blocking.
+		w.staticTryBenchmark()
+
 		// If the worker needs to sync the account balance, perform a sync
 		// operation. This should be attempted before launching any jobs.
 		if w.managedNeedsToSyncAccountBalanceToHost() {
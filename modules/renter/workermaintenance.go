@@ -79,6 +79,18 @@ func (wms *workerMaintenanceState) tryResetMaintenanceCooldown() time.Time {
 	return wms.cooldownUntil
 }
 
+// managedResetMaintenanceCooldown takes the worker off its maintenance cooldown
+// without marking its maintenance tasks as successful. This is used if the
+// cause of the failures was resolved by the user, so the worker retries its
+// maintenance right away.
+func (w *worker) managedResetMaintenanceCooldown() {
+	wms := w.staticMaintenanceState
+	wms.mu.Lock()
+	defer wms.mu.Unlock()
+	wms.consecutiveFailures = 0
+	wms.cooldownUntil = time.Time{}
+}
+
 // managedMaintenanceRecentError is a helper function that returns the recent
 // maintenance error
 func (w *worker) managedMaintenanceRecentError() error {
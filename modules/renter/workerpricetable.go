@@ -210,7 +210,8 @@ func (w *worker) staticUpdatePriceTable() {
 
 	// If this is the first time we are fetching a price table update from the
 	// host, we use the time it took for a single round trip as an initial
-	// estimate for both the HS and RJ queue job time estimates.
+	// estimate for both the HS and RJ queue job time estimates, unless the
+	// host was benchmarked before.
 	var elapsed time.Duration
 	defer func() {
 		// As a safety precaution, set the elapsed duration to the minimum
@@ -220,10 +221,7 @@ func (w *worker) staticUpdatePriceTable() {
 			elapsed = minInitialEstimate
 		}
 		w.staticSetInitialEstimates.Do(func() {
-			w.staticJobHasSectorQueue.callUpdateJobTimeMetrics(elapsed)
-			w.staticJobReadQueue.callUpdateJobTimeMetrics(1<<16, elapsed)
-			w.staticJobReadQueue.callUpdateJobTimeMetrics(1<<20, elapsed)
-			w.staticJobReadQueue.callUpdateJobTimeMetrics(1<<24, elapsed)
+			w.managedSetInitialEstimates(elapsed)
 		})
 	}()
 
@@ -343,14 +341,19 @@ func (w *worker) staticUpdatePriceTable() {
 	}
 
 	// The price table will not become valid until the host has received and
-	// confirmed our payment. The host will signal this by sending an empty
-	// response object we need to read.
+	// confirmed our payment. The host will signal this by sending a response
+	// object we need to read. Hosts that version their price tables include
+	// the UID of the tracked price table, older hosts send an empty response.
 	var tracked modules.RPCTrackedPriceTableResponse
 	err = modules.RPCRead(stream, &tracked)
 	if err != nil {
 		err = errors.AddContext(err, "unable to read tracked response")
 		return
 	}
+	if tracked.UID != (modules.UniqueID{}) && tracked.UID != pt.UID {
+		err = fmt.Errorf("host tracked price table %v instead of %v", tracked.UID, pt.UID)
+		return
+	}
 
 	// Calculate the expiry time and set the update time to be half of the
 	// expiry window to ensure we update the PT before it expires
@@ -360,6 +363,13 @@ func (w *worker) staticUpdatePriceTable() {
 	expiryHalfTime := time.Duration(expiryHalfTimeInS) * time.Second
 	newUpdateTime := time.Now().Add(expiryHalfTime)
 
+	// If the chaos testing mode decides to drop the price table, we keep the
+	// current one and treat the update as failed.
+	if w.renter.staticChaos.managedDropPriceTable() {
+		err = errChaosDroppedPriceTable
+		return
+	}
+
 	// Update the price table. We preserve the recent error even though there
 	// has not been an error for debugging purposes, if there has been an error
 	// previously the devs like to be able to see what it was.
@@ -37,6 +37,18 @@ var (
 	renewGougingFeeMultiplier = types.NewCurrency64(5)
 )
 
+// minExecuteProgramBatchVersion is the minimum version of a host that supports
+// the ExecuteProgramBatch RPC.
+const minExecuteProgramBatchVersion = "1.5.10"
+
+// batchProgram is a program that is executed as part of a batch, together with
+// the contract it targets and its program data.
+type batchProgram struct {
+	staticFCID    types.FileContractID
+	staticProgram modules.Program
+	staticData    []byte
+}
+
 // programResponse is a helper struct that wraps the RPCExecuteProgramResponse
 // alongside the data output
 type programResponse struct {
@@ -160,6 +172,148 @@ func (w *worker) managedExecuteProgram(p modules.Program, data []byte, fcid type
 	return
 }
 
+// managedExecuteProgramBatch performs the ExecuteProgramBatch RPC on the host.
+// It executes multiple read-only programs, which may target different
+// contracts, using a single payment and stream. The responses are returned per
+// program. If one of the programs fails, the host aborts the batch and the
+// responses of the following programs are omitted.
+func (w *worker) managedExecuteProgramBatch(programs []batchProgram, category spendingCategory, cost types.Currency) (responses [][]programResponse, limit mux.BandwidthLimit, err error) {
+	// Check that the host supports batches.
+	if build.VersionCmp(w.staticCache().staticHostVersion, minExecuteProgramBatchVersion) < 0 {
+		return nil, nil, fmt.Errorf("host version %v doesn't support program batches", w.staticCache().staticHostVersion)
+	}
+
+	// The worker doesn't finalize programs, so only read-only programs are
+	// supported.
+	for _, p := range programs {
+		if !p.staticProgram.ReadOnly() {
+			return nil, nil, errors.New("only read-only programs can be executed in a batch")
+		}
+	}
+
+	// Defer a function that schedules a price table update in case we received
+	// an error that indicates the host deems our price table invalid.
+	defer func() {
+		if modules.IsPriceTableInvalidErr(err) {
+			w.staticTryForcePriceTableUpdate()
+		}
+	}()
+
+	// track the withdrawal
+	var refund types.Currency
+	w.staticAccount.managedTrackWithdrawal(cost)
+	defer func() {
+		withdrawn := cost.Sub(refund)
+		w.staticAccount.managedCommitWithdrawal(category, withdrawn, refund, err == nil)
+	}()
+
+	// create a new stream
+	stream, err := w.staticNewStream()
+	if err != nil {
+		err = errors.AddContext(err, "Unable to create a new stream")
+		return
+	}
+	defer func() {
+		if err := stream.Close(); err != nil {
+			w.renter.log.Println("ERROR: failed to close stream", err)
+		}
+	}()
+
+	// set the limit return var.
+	limit = stream.Limit()
+
+	// prepare a buffer so we can optimize our writes
+	buffer := bytes.NewBuffer(nil)
+
+	// write the specifier
+	err = modules.RPCWrite(buffer, modules.RPCExecuteProgramBatch)
+	if err != nil {
+		return
+	}
+
+	// send price table uid
+	pt := w.staticPriceTable().staticPriceTable
+	err = modules.RPCWrite(buffer, pt.UID)
+	if err != nil {
+		return
+	}
+
+	// provide payment, note that we use the host's block height if we are
+	// making ephemeral account payments
+	err = w.staticAccount.ProvidePayment(buffer, cost, pt.HostBlockHeight)
+	if err != nil {
+		return
+	}
+
+	// prepare and send the request.
+	var req modules.RPCExecuteProgramBatchRequest
+	for _, p := range programs {
+		req.Programs = append(req.Programs, modules.RPCExecuteProgramRequest{
+			FileContractID:    p.staticFCID,
+			Program:           p.staticProgram,
+			ProgramDataLength: uint64(len(p.staticData)),
+		})
+	}
+	err = modules.RPCWrite(buffer, req)
+	if err != nil {
+		return
+	}
+
+	// send the data of all programs.
+	for _, p := range programs {
+		_, err = buffer.Write(p.staticData)
+		if err != nil {
+			return
+		}
+	}
+
+	// write contents of the buffer to the stream
+	_, err = stream.Write(buffer.Bytes())
+	if err != nil {
+		return
+	}
+
+	// read the cancellation token.
+	var ct modules.MDMCancellationToken
+	err = modules.RPCRead(stream, &ct)
+	if err != nil {
+		return
+	}
+
+	// read the responses of every program.
+	responses = make([][]programResponse, 0, len(programs))
+	for _, p := range programs {
+		programResponses := make([]programResponse, 0, len(p.staticProgram))
+		for i := 0; i < len(p.staticProgram); i++ {
+			var response programResponse
+			err = modules.RPCRead(stream, &response)
+			if err != nil {
+				return
+			}
+
+			// Read the output data.
+			response.Output = make([]byte, response.OutputLength)
+			_, err = io.ReadFull(stream, response.Output)
+			if err != nil {
+				return
+			}
+
+			refund = refund.Add(response.FailureRefund)
+
+			// We received a valid response. Append it.
+			programResponses = append(programResponses, response)
+
+			// If the response contains an error, the batch was aborted.
+			if response.Error != nil {
+				responses = append(responses, programResponses)
+				return
+			}
+		}
+		responses = append(responses, programResponses)
+	}
+	return
+}
+
 // staticNewStream returns a new stream to the worker's host
 func (w *worker) staticNewStream() (siamux.Stream, error) {
 	// If disrupt is called we sleep for the specified 'defaultNewStreamTimeout'
@@ -169,9 +323,15 @@ func (w *worker) staticNewStream() (siamux.Stream, error) {
 		time.Sleep(timeout)
 		return nil, errors.New("InterruptNewStreamTimeout")
 	}
+	if err := w.renter.staticInjectChaos(); err != nil {
+		return nil, err
+	}
 
-	// Create a stream with a reasonable dial up timeout.
+	// Create a stream with a reasonable dial up timeout. If the worker already
+	// has a session with the host, the SiaMux reuses it for the new stream.
+	start := time.Now()
 	stream, err := w.renter.staticMux.NewStreamTimeout(modules.HostSiaMuxSubscriberName, w.staticCache().staticHostMuxAddress, timeout, modules.SiaPKToMuxPK(w.staticHostPubKey))
+	w.staticSession.managedTrackStream(stream, time.Since(start), err)
 	if err != nil {
 		return nil, err
 	}
@@ -188,7 +348,13 @@ func (w *worker) staticNewStream() (siamux.Stream, error) {
 	rlStream := ratelimit.NewRLStream(stream, w.renter.rl, w.renter.tg.StopChan())
 
 	// Wrap the stream in global ratelimit.
-	return ratelimit.NewRLStream(rlStream, modules.GlobalRateLimits, w.renter.tg.StopChan()), nil
+	rlStream = ratelimit.NewRLStream(rlStream, modules.GlobalRateLimits, w.renter.tg.StopChan())
+
+	// Wrap the stream in the host's ratelimit if it has one.
+	if hostRL := w.renter.staticHostBandwidthLimits.managedRateLimit(w.staticHostPubKey); hostRL != nil {
+		rlStream = ratelimit.NewRLStream(rlStream, hostRL, w.renter.tg.StopChan())
+	}
+	return rlStream, nil
 }
 
 // managedRenew renews the contract with the worker's host.
@@ -175,7 +175,7 @@ func testExecuteProgramUsedBandwidthReadSector(t *testing.T, wt *workerTester) {
 
 	// create read sector job
 	readSectorRespChan := make(chan *jobReadResponse)
-	jrs := w.newJobReadSector(context.Background(), w.staticJobReadQueue, readSectorRespChan, categoryDownload, sectorRoot, 0, modules.SectorSize)
+	jrs := w.newJobReadSector(context.Background(), w.staticJobReadQueue, readSectorRespChan, categoryDownload, sectorRoot, 0, modules.SectorSize, nil)
 
 	ulBandwidth, dlBandwidth := jrs.callExpectedBandwidth()
 	bandwidthCost := modules.MDMBandwidthCost(pt, ulBandwidth, dlBandwidth)
@@ -0,0 +1,180 @@
+package renter
+
+import (
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/siamux"
+	"gitlab.com/NebulousLabs/siamux/mux"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+)
+
+var (
+	// sessionKeepaliveInterval is the interval at which the worker sends a
+	// keepalive over its session with the host while the session is in use.
+	sessionKeepaliveInterval = build.Select(build.Var{
+		Dev:      time.Second * 30,
+		Standard: time.Minute * 2,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// sessionIdleTimeout is the amount of time after the last stream was
+	// opened on a session that the worker keeps sending keepalives. Once a
+	// session has been idle for longer than that, the SiaMux is responsible
+	// for keeping it alive or closing it.
+	sessionIdleTimeout = build.Select(build.Var{
+		Dev:      time.Minute * 5,
+		Standard: time.Minute * 30,
+		Testing:  time.Second * 10,
+	}).(time.Duration)
+)
+
+type (
+	// workerSession tracks the SiaMux session the worker uses to talk to the
+	// host. The SiaMux already multiplexes all streams to a host over a single
+	// session, but the host closes a stream after every RPC. Reusing the
+	// session means that opening a new stream for small RPCs like HasSector or
+	// registry lookups doesn't require a new TCP connection and handshake.
+	//
+	// The worker keeps the session warm by periodically sending keepalives
+	// while it is in use. If a keepalive fails, the session is closed right
+	// away so that the next job dials a new one instead of timing out on a
+	// dead connection.
+	workerSession struct {
+		session          *mux.Mux
+		lastUsed         time.Time
+		lastKeepalive    time.Time
+		keepaliveRunning bool
+
+		streamsOpened     uint64
+		streamFailures    uint64
+		sessionsOpened    uint64
+		sessionsReused    uint64
+		totalOpenTime     time.Duration
+		keepalivesSent    uint64
+		keepaliveFailures uint64
+
+		recentErr     error
+		recentErrTime time.Time
+
+		mu sync.Mutex
+	}
+)
+
+// managedTrackStream updates the session after the worker tried to open a new
+// stream to the host. A stream is opened on a reused session if its mux is
+// the same as the one of the previously opened stream.
+func (ws *workerSession) managedTrackStream(stream siamux.Stream, elapsed time.Duration, err error) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if err != nil {
+		ws.streamFailures++
+		ws.recentErr = err
+		ws.recentErrTime = time.Now()
+		return
+	}
+	m := stream.Mux()
+	if m == ws.session {
+		ws.sessionsReused++
+	} else {
+		ws.sessionsOpened++
+		ws.session = m
+		ws.lastKeepalive = time.Now()
+	}
+	ws.streamsOpened++
+	ws.totalOpenTime += elapsed
+	ws.lastUsed = time.Now()
+}
+
+// managedStatus returns the status of the session.
+func (ws *workerSession) managedStatus() modules.WorkerSessionStatus {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	var avgOpenStreamMS uint64
+	if ws.streamsOpened > 0 {
+		avgOpenStreamMS = uint64(ws.totalOpenTime.Milliseconds()) / ws.streamsOpened
+	}
+	var recentErrStr string
+	if ws.recentErr != nil {
+		recentErrStr = ws.recentErr.Error()
+	}
+	return modules.WorkerSessionStatus{
+		Active:   ws.session != nil,
+		LastUsed: ws.lastUsed,
+
+		StreamsOpened:   ws.streamsOpened,
+		StreamFailures:  ws.streamFailures,
+		SessionsOpened:  ws.sessionsOpened,
+		SessionsReused:  ws.sessionsReused,
+		AvgOpenStreamMS: avgOpenStreamMS,
+
+		KeepalivesSent:    ws.keepalivesSent,
+		KeepaliveFailures: ws.keepaliveFailures,
+
+		RecentErr:     recentErrStr,
+		RecentErrTime: ws.recentErrTime,
+	}
+}
+
+// managedKeepaliveSession sends a keepalive over the worker's session. If the
+// keepalive fails, the session is closed.
+func (w *worker) managedKeepaliveSession(session *mux.Mux) {
+	ws := w.staticSession
+	err := session.Keepalive()
+	if err != nil {
+		err = errors.Compose(err, session.Close())
+	}
+
+	ws.mu.Lock()
+	ws.keepaliveRunning = false
+	ws.lastKeepalive = time.Now()
+	if err != nil {
+		ws.keepaliveFailures++
+		ws.recentErr = errors.AddContext(err, "failed to send keepalive")
+		ws.recentErrTime = time.Now()
+		if ws.session == session {
+			ws.session = nil
+		}
+	} else {
+		ws.keepalivesSent++
+	}
+	ws.mu.Unlock()
+
+	// Wake the worker when the next keepalive is due.
+	w.renter.tg.AfterFunc(sessionKeepaliveInterval, func() {
+		w.staticWake()
+	})
+}
+
+// staticTryKeepaliveSession will send a keepalive over the worker's session if
+// the session was used recently and the last keepalive is older than the
+// keepalive interval. The keepalive is sent in a goroutine to avoid blocking
+// the worker loop.
+func (w *worker) staticTryKeepaliveSession() {
+	ws := w.staticSession
+	ws.mu.Lock()
+	session := ws.session
+	if session == nil || ws.keepaliveRunning {
+		ws.mu.Unlock()
+		return
+	}
+	if time.Since(ws.lastUsed) > sessionIdleTimeout || time.Since(ws.lastKeepalive) < sessionKeepaliveInterval {
+		ws.mu.Unlock()
+		return
+	}
+	ws.keepaliveRunning = true
+	ws.mu.Unlock()
+
+	err := w.renter.tg.Launch(func() {
+		w.managedKeepaliveSession(session)
+	})
+	if err != nil {
+		ws.mu.Lock()
+		ws.keepaliveRunning = false
+		ws.mu.Unlock()
+	}
+}
@@ -0,0 +1,92 @@
+package renter
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/build"
+)
+
+// TestWorkerSession verifies that the worker reuses its session with the host
+// for new streams and keeps it alive.
+func TestWorkerSession(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	wt, err := newWorkerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		err := wt.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}()
+	w := wt.worker
+
+	// Open a few streams. All of them should use the same session.
+	before := w.staticSession.managedStatus()
+	for i := 0; i < 5; i++ {
+		stream, err := w.staticNewStream()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := stream.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	status := w.staticSession.managedStatus()
+	if !status.Active {
+		t.Fatal("session should be active")
+	}
+	if status.StreamsOpened-before.StreamsOpened != 5 {
+		t.Fatal("wrong number of opened streams", status.StreamsOpened-before.StreamsOpened)
+	}
+	if status.SessionsOpened != 1 {
+		t.Fatal("expected a single session", status.SessionsOpened)
+	}
+	if status.SessionsReused != status.StreamsOpened-1 {
+		t.Fatal("session wasn't reused", status.SessionsReused, status.StreamsOpened)
+	}
+
+	// The worker should send keepalives while the session is in use.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		w.staticWake()
+		if w.staticSession.managedStatus().KeepalivesSent == 0 {
+			return errors.New("no keepalive sent")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Close the session. The next keepalive should fail and the next stream
+	// should open a new session.
+	w.staticSession.mu.Lock()
+	session := w.staticSession.session
+	w.staticSession.mu.Unlock()
+	if err := session.Close(); err != nil {
+		t.Fatal(err)
+	}
+	w.managedKeepaliveSession(session)
+	status = w.staticSession.managedStatus()
+	if status.Active || status.KeepaliveFailures != 1 || status.RecentErr == "" {
+		t.Fatal("keepalive should have failed", status)
+	}
+	stream, err := w.staticNewStream()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	status = w.staticSession.managedStatus()
+	if !status.Active || status.SessionsOpened != 2 {
+		t.Fatal("expected a new session", status)
+	}
+}
@@ -36,6 +36,8 @@ func (w *worker) callStatus() modules.WorkerStatus {
 		mcdErr = maintenanceCoolDownErr.Error()
 	}
 
+	limits, _ := w.renter.staticHostBandwidthLimits.managedHostLimits(w.staticHostPubKey)
+
 	// Update the worker cache before returning a status.
 	w.staticTryUpdateCache()
 	cache := w.staticCache()
@@ -59,6 +61,10 @@ func (w *worker) callStatus() modules.WorkerStatus {
 		UploadQueueSize:     w.unprocessedChunks.Len(),
 		UploadTerminated:    w.uploadTerminated,
 
+		// Bandwidth limits
+		MaxDownloadSpeed: limits.MaxDownloadSpeed,
+		MaxUploadSpeed:   limits.MaxUploadSpeed,
+
 		// Job Queues
 		DownloadSnapshotJobQueueSize: int(w.staticJobDownloadSnapshotQueue.callStatus().size),
 		UploadSnapshotJobQueueSize:   int(w.staticJobUploadSnapshotQueue.callStatus().size),
@@ -75,6 +81,9 @@ func (w *worker) callStatus() modules.WorkerStatus {
 		// Price Table Information
 		PriceTableStatus: w.staticPriceTableStatus(),
 
+		// Session Information
+		SessionStatus: w.staticSession.managedStatus(),
+
 		// Read Job Information
 		ReadJobsStatus: w.callReadJobStatus(),
 
@@ -147,7 +156,14 @@ func (w *worker) callHasSectorJobStatus() modules.WorkerHasSectorJobsStatus {
 		recentErrStr = status.recentErr.Error()
 	}
 
-	avgJobTimeInMs := uint64(hsq.callExpectedJobTime().Milliseconds())
+	// Round the job time up to a millisecond. Jobs which use the
+	// BatchHasSector RPC can take less than that, which shouldn't be reported
+	// as if there was no data yet.
+	jobTime := hsq.callExpectedJobTime()
+	avgJobTimeInMs := uint64(jobTime.Milliseconds())
+	if avgJobTimeInMs == 0 && jobTime > 0 {
+		avgJobTimeInMs = 1
+	}
 
 	return modules.WorkerHasSectorJobsStatus{
 		AvgJobTime:          avgJobTimeInMs,
@@ -60,9 +60,15 @@ var (
 	priceTableRetryInterval = time.Second
 )
 
-// minSubscriptionVersion is the min version required for a host to support the
-// subscription protocol.
-const minSubscriptionVersion = "1.5.5"
+const (
+	// minSubscriptionVersion is the min version required for a host to
+	// support the subscription protocol.
+	minSubscriptionVersion = "1.5.5"
+
+	// minFilteredSubscriptionVersion is the min version required for a host
+	// to support filtered subscriptions and batched notifications.
+	minFilteredSubscriptionVersion = "1.5.8"
+)
 
 type (
 	// subscriptionInfos contains all of the registry subscription related
@@ -152,9 +158,6 @@ func (sub *subscription) active() bool {
 // managedHandleRegistryEntry is called by managedHandleNotification to handle a
 // notification about an updated registry entry.
 func (nh *notificationHandler) managedHandleRegistryEntry(stream siamux.Stream, budget *modules.RPCBudget, limit *modules.BudgetLimit) (err error) {
-	w := nh.staticWorker
-	subInfo := w.staticSubscriptionInfo
-
 	// Add a limit to the stream.
 	err = stream.SetLimit(limit)
 	if err != nil {
@@ -175,6 +178,49 @@ func (nh *notificationHandler) managedHandleRegistryEntry(stream siamux.Stream,
 	if err != nil {
 		return errors.AddContext(err, "failed to read entry update")
 	}
+	return nh.managedUpdateEntry(sneu)
+}
+
+// managedHandleRegistryEntries is called by managedHandleNotification to
+// handle a batched notification about multiple updated registry entries.
+func (nh *notificationHandler) managedHandleRegistryEntries(stream siamux.Stream, budget *modules.RPCBudget, limit *modules.BudgetLimit) (err error) {
+	// Add a limit to the stream.
+	err = stream.SetLimit(limit)
+	if err != nil {
+		return errors.AddContext(err, "failed to set limit on notification stream")
+	}
+
+	// Read the updates.
+	var sneus []modules.RPCRegistrySubscriptionNotificationEntryUpdate
+	err = modules.RPCReadMaxLen(stream, &sneus, modules.SubscriptionNotificationBatchMaxLen)
+	if err != nil {
+		return errors.AddContext(err, "failed to read entry updates")
+	}
+	if len(sneus) > modules.SubscriptionNotificationBatchSize {
+		return errors.Compose(fmt.Errorf("host sent too many updates in a batch %v > %v", len(sneus), modules.SubscriptionNotificationBatchSize), nh.staticStream.Close())
+	}
+
+	// Withdraw the notification cost of every update.
+	nh.mu.Lock()
+	ok := budget.Withdraw(nh.notificationCost.Mul64(uint64(len(sneus))))
+	nh.mu.Unlock()
+	if !ok {
+		return errors.New("failed to withdraw notification cost")
+	}
+
+	for _, sneu := range sneus {
+		if err := nh.managedUpdateEntry(sneu); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// managedUpdateEntry verifies an entry update received from the host and
+// updates the corresponding subscription.
+func (nh *notificationHandler) managedUpdateEntry(sneu modules.RPCRegistrySubscriptionNotificationEntryUpdate) (err error) {
+	w := nh.staticWorker
+	subInfo := w.staticSubscriptionInfo
 
 	// Starting here we close the main subscription stream if an error happens
 	// because it will be the host trying to cheat us.
@@ -282,6 +328,11 @@ func (nh *notificationHandler) managedHandleNotification(stream siamux.Stream, b
 			w.renter.log.Print("managedHandleRegistryEntry:", err)
 		}
 		return
+	case modules.SubscriptionResponseRegistryValues:
+		if err := nh.managedHandleRegistryEntries(stream, budget, limit); err != nil {
+			w.renter.log.Print("managedHandleRegistryEntries:", err)
+		}
+		return
 	default:
 	}
 
@@ -494,12 +545,51 @@ func (w *worker) managedUnsubscribeFromRVs(stream siamux.Stream, toUnsubscribe [
 }
 
 // managedSubscribeToRVs subscribes the workers to multiple registry values.
+// If the host supports it, entries for which the worker already knows a value
+// are subscribed to with a filtered request. That way the host only sends
+// newer revisions, which avoids receiving all values again after a reconnect.
 func (w *worker) managedSubscribeToRVs(stream siamux.Stream, toSubscribe []modules.RPCRegistrySubscriptionRequest, subChans []chan struct{}, budget *modules.RPCBudget, pt *modules.RPCPriceTable) error {
 	subInfo := w.staticSubscriptionInfo
+
+	// Split the requests.
+	var filtered []modules.RPCRegistrySubscriptionFilteredRequest
+	unfiltered := toSubscribe
+	if build.VersionCmp(w.staticCache().staticHostVersion, minFilteredSubscriptionVersion) >= 0 {
+		unfiltered = nil
+		subInfo.mu.Lock()
+		for _, req := range toSubscribe {
+			sub, exists := subInfo.subscriptions[modules.DeriveRegistryEntryID(req.PubKey, req.Tweak)]
+			if !exists || sub.latestRV == nil {
+				unfiltered = append(unfiltered, req)
+				continue
+			}
+			filtered = append(filtered, modules.RPCRegistrySubscriptionFilteredRequest{
+				PubKey:      req.PubKey,
+				Tweak:       req.Tweak,
+				MinRevision: sub.latestRV.Revision,
+			})
+		}
+		subInfo.mu.Unlock()
+	}
+
 	// Subscribe.
-	rvs, err := modules.RPCSubscribeToRVs(stream, toSubscribe)
-	if err != nil {
-		return errors.AddContext(err, "failed to subscribe to registry values")
+	var rvs []modules.RPCRegistrySubscriptionNotificationEntryUpdate
+	var cost types.Currency
+	if len(unfiltered) > 0 {
+		unfilteredRVs, err := modules.RPCSubscribeToRVs(stream, unfiltered)
+		if err != nil {
+			return errors.AddContext(err, "failed to subscribe to registry values")
+		}
+		rvs = append(rvs, unfilteredRVs...)
+		cost = cost.Add(modules.MDMSubscribeCost(pt, uint64(len(unfilteredRVs)), uint64(len(unfiltered))))
+	}
+	if len(filtered) > 0 {
+		filteredRVs, err := modules.RPCSubscribeToRVsFiltered(stream, filtered)
+		if err != nil {
+			return errors.AddContext(err, "failed to subscribe to filtered registry values")
+		}
+		rvs = append(rvs, filteredRVs...)
+		cost = cost.Add(modules.MDMSubscribeCost(pt, uint64(len(filteredRVs)), uint64(len(filtered))))
 	}
 	// Check that the initial values are not outdated and update the cache.
 	for _, rv := range rvs {
@@ -510,14 +600,14 @@ func (w *worker) managedSubscribeToRVs(stream siamux.Stream, toSubscribe []modul
 		w.staticRegistryCache.Set(rv.PubKey, rv.Entry, false)
 	}
 	// Withdraw from budget.
-	if !budget.Withdraw(modules.MDMSubscribeCost(pt, uint64(len(rvs)), uint64(len(toSubscribe)))) {
+	if !budget.Withdraw(cost) {
 		return errors.New("failed to withdraw subscription payment from budget")
 	}
 	// Update the subscriptions with the received values.
 	subInfo.mu.Lock()
 	defer subInfo.mu.Unlock()
-	for _, rv := range rvs {
-		subInfo.subscriptions[modules.DeriveRegistryEntryID(rv.PubKey, rv.Entry.Tweak)].latestRV = &rv.Entry
+	for i := range rvs {
+		subInfo.subscriptions[modules.DeriveRegistryEntryID(rvs[i].PubKey, rvs[i].Entry.Tweak)].latestRV = &rvs[i].Entry
 	}
 	// Close the channels to signal that the subscription is done.
 	for _, c := range subChans {
@@ -8,6 +8,7 @@ import (
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/modules/renter/filesystem/siafile"
+	"go.sia.tech/siad/types"
 
 	"gitlab.com/NebulousLabs/errors"
 )
@@ -225,6 +226,15 @@ func (w *worker) managedPerformUploadChunkJob() {
 		return
 	}
 
+	// Attribute the cost of the piece to the file. The editor doesn't report
+	// what it paid, so the cost is estimated from the host's prices.
+	var duration types.BlockHeight
+	if endHeight, height := e.EndHeight(), w.renter.cs.Height(); endHeight > height {
+		duration = endHeight - height
+	}
+	storage, upload := uploadSpending(hostSettings, uint64(len(uc.physicalChunkData[pieceIndex])), duration)
+	w.renter.staticFileSpending.callAdd(w.renter.staticFileSystem.FileSiaPath(uc.fileEntry), types.ZeroCurrency, storage, upload)
+
 	id := w.renter.mu.Lock()
 	w.renter.mu.Unlock(id)
 
@@ -308,6 +318,7 @@ func (w *worker) managedProcessUploadChunk(uc *unfinishedUploadChunk) (nextChunk
 	}
 	delete(uc.unusedHosts, w.staticHostPubKey.String())
 	uc.piecesRegistered++
+	uc.avoidCorrelatedHosts(w.staticHostPubKey.String())
 	uc.workersRemaining--
 	uc.mu.Unlock()
 	return uc, uint64(index)
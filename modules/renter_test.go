@@ -5,7 +5,9 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
 
 	"go.sia.tech/siad/build"
@@ -282,3 +284,157 @@ func BenchmarkSliceCryptoHashSave(b *testing.B) {
 		}
 	}
 }
+
+// TestDownloadPolicy tests the conversion of download policies from and to
+// strings.
+func TestDownloadPolicy(t *testing.T) {
+	policies := []DownloadPolicy{DownloadPolicyBalanced, DownloadPolicyCheapest, DownloadPolicyLatency}
+	for _, policy := range policies {
+		var dp DownloadPolicy
+		if err := dp.FromString(policy.String()); err != nil {
+			t.Fatal(err)
+		}
+		if dp != policy {
+			t.Fatal("policy mismatch", dp, policy)
+		}
+	}
+	// An empty string results in the default policy.
+	dp := DownloadPolicyLatency
+	if err := dp.FromString(""); err != nil || dp != DownloadPolicyBalanced {
+		t.Fatal("unexpected policy", dp, err)
+	}
+	if err := dp.FromString("fastest"); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+// TestFileHTTPHeadersValidate is a unit test for FileHTTPHeaders.Validate.
+func TestFileHTTPHeadersValidate(t *testing.T) {
+	tests := []struct {
+		headers FileHTTPHeaders
+		valid   bool
+	}{
+		{FileHTTPHeaders{}, true},
+		{FileHTTPHeaders{CacheControl: "no-cache", ContentDisposition: `attachment; filename="a.txt"`, ContentType: "text/html; charset=utf-8"}, true},
+		{FileHTTPHeaders{CacheControl: "no-cache\r\nSet-Cookie: a=b"}, false},
+		{FileHTTPHeaders{ContentType: "text/"}, false},
+		{FileHTTPHeaders{ContentDisposition: "attachment; filename"}, false},
+	}
+	for i, test := range tests {
+		err := test.headers.Validate()
+		if test.valid && err != nil {
+			t.Fatal(i, err)
+		} else if !test.valid && !errors.Contains(err, ErrInvalidHTTPHeader) {
+			t.Fatal(i, "expected ErrInvalidHTTPHeader", err)
+		}
+	}
+}
+
+// TestFileExpiryExpired is a unit test for FileExpiry.Expired.
+func TestFileExpiryExpired(t *testing.T) {
+	now := time.Now()
+	if (FileExpiry{}).Expired(now) {
+		t.Fatal("zero expiry shouldn't expire")
+	}
+	if (FileExpiry{Time: now.Add(time.Second)}).Expired(now) {
+		t.Fatal("expiry in the future shouldn't be expired")
+	}
+	if !(FileExpiry{Time: now}).Expired(now) {
+		t.Fatal("expiry should be expired at its time")
+	}
+	if !(FileExpiry{Time: now.Add(-time.Second)}).Expired(now) {
+		t.Fatal("expiry in the past should be expired")
+	}
+}
+
+// TestParseFileRepairPriority tests parsing and marshaling file repair
+// priorities.
+func TestParseFileRepairPriority(t *testing.T) {
+	for _, priority := range []FileRepairPriority{FileRepairPriorityLow, FileRepairPriorityNormal, FileRepairPriorityHigh} {
+		parsed, err := ParseFileRepairPriority(priority.String())
+		if err != nil || parsed != priority {
+			t.Fatal("wrong priority", priority, parsed, err)
+		}
+		b, err := priority.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var unmarshaled FileRepairPriority
+		if err := unmarshaled.UnmarshalText(b); err != nil || unmarshaled != priority {
+			t.Fatal("wrong priority", priority, unmarshaled, err)
+		}
+	}
+	if _, err := ParseFileRepairPriority("urgent"); !errors.Contains(err, ErrInvalidFileRepairPriority) {
+		t.Fatal("expected invalid priority", err)
+	}
+}
+
+// TestRenterSchedulerWindow is a unit test for the occurrences of the windows
+// of the renter's scheduler.
+func TestRenterSchedulerWindow(t *testing.T) {
+	// 2021-03-01 is a Monday.
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2021, 3, day, hour, min, 0, 0, time.UTC)
+	}
+
+	// A window on weekdays which ends on the following day.
+	w := RenterSchedulerWindow{Days: []string{"mon", "tue", "wed", "thu", "fri"}, Start: "20:00", End: "06:00"}
+	tests := []struct {
+		t     time.Time
+		start time.Time
+		ok    bool
+	}{
+		{at(1, 19, 59), time.Time{}, false},
+		{at(1, 20, 0), at(1, 20, 0), true},
+		{at(2, 5, 59), at(1, 20, 0), true},
+		{at(2, 6, 0), time.Time{}, false},
+		{at(6, 1, 0), at(5, 20, 0), true},  // Saturday morning
+		{at(6, 21, 0), time.Time{}, false}, // Saturday evening
+		{at(1, 1, 0), time.Time{}, false},  // Monday morning
+	}
+	for i, test := range tests {
+		start, end, ok := w.Occurrence(test.t)
+		if ok != test.ok || !start.Equal(test.start) {
+			t.Errorf("%v: expected %v %v, got %v %v", i, test.start, test.ok, start, ok)
+		}
+		if ok && !end.Equal(start.Add(10*time.Hour)) {
+			t.Errorf("%v: wrong end %v", i, end)
+		}
+	}
+	if next := w.NextStart(at(5, 21, 0)); !next.Equal(at(8, 20, 0)) {
+		t.Fatal("wrong next start", next)
+	}
+
+	// A window without days lasting the whole day.
+	w = RenterSchedulerWindow{Start: "00:00", End: "00:00"}
+	if start, end, ok := w.Occurrence(at(3, 12, 0)); !ok || !start.Equal(at(3, 0, 0)) || !end.Equal(at(4, 0, 0)) {
+		t.Fatal("wrong occurrence", start, end, ok)
+	}
+}
+
+// TestRenterSchedulerSettingsValidate is a unit test for validating the
+// settings of the renter's scheduler.
+func TestRenterSchedulerSettingsValidate(t *testing.T) {
+	window := RenterSchedulerWindow{Days: []string{"sat"}, Start: "22:00", End: "06:00"}
+	tests := []struct {
+		settings RenterSchedulerSettings
+		valid    bool
+	}{
+		{RenterSchedulerSettings{}, true},
+		{RenterSchedulerSettings{Jobs: []RenterSchedulerJob{RenterSchedulerJobRepair, RenterSchedulerJobAudit, RenterSchedulerJobArchive}, Windows: []RenterSchedulerWindow{window}}, true},
+		{RenterSchedulerSettings{Jobs: []RenterSchedulerJob{RenterSchedulerJobRepair}}, false},
+		{RenterSchedulerSettings{Jobs: []RenterSchedulerJob{"backup"}, Windows: []RenterSchedulerWindow{window}}, false},
+		{RenterSchedulerSettings{Jobs: []RenterSchedulerJob{RenterSchedulerJobAudit, RenterSchedulerJobAudit}, Windows: []RenterSchedulerWindow{window}}, false},
+		{RenterSchedulerSettings{Windows: []RenterSchedulerWindow{{Days: []string{"saturday"}, Start: "22:00", End: "06:00"}}}, false},
+		{RenterSchedulerSettings{Windows: []RenterSchedulerWindow{{Start: "24:00", End: "06:00"}}}, false},
+		{RenterSchedulerSettings{Windows: []RenterSchedulerWindow{{Start: "22:00"}}}, false},
+	}
+	for i, test := range tests {
+		err := test.settings.Validate()
+		if test.valid && err != nil {
+			t.Errorf("%v: expected settings to be valid: %v", i, err)
+		} else if !test.valid && err == nil {
+			t.Errorf("%v: expected settings to be invalid", i)
+		}
+	}
+}
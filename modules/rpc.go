@@ -4,11 +4,13 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"strings"
 	"time"
 
 	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/fastrand"
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/types"
@@ -20,9 +22,50 @@ const (
 	// DoS attacks on the host.
 	SubscriptionEntrySize = 512
 
+	// SubscriptionNotificationBatchSize is the max number of entry updates a
+	// host sends within a single batched notification.
+	SubscriptionNotificationBatchSize = 50
+
+	// SubscriptionNotificationBatchMaxLen is the maximum length for decoding
+	// a batched notification.
+	SubscriptionNotificationBatchMaxLen = SubscriptionNotificationBatchSize * 2 * RegistryEntrySize
+
 	// RenewDecodeMaxLen is the maximum length for decoding received objects
 	// read during a contract renewal.
 	RenewDecodeMaxLen = 1 << 18 // 256 kib
+
+	// MaxSectorStatsRoots is the maximum number of sector roots a renter can
+	// request the stats for within a single SectorStats RPC.
+	MaxSectorStatsRoots = 1024
+
+	// SectorStatsRequestMaxLen is the maximum length for decoding a
+	// SectorStatsRequest.
+	SectorStatsRequestMaxLen = RPCMinLen + MaxSectorStatsRoots*crypto.HashSize
+
+	// SectorStatsResponseMaxLen is the maximum length for decoding a
+	// SectorStatsResponse.
+	SectorStatsResponseMaxLen = RPCMinLen + MaxSectorStatsRoots*(1+8)
+
+	// MaxBatchHasSectorRoots is the maximum number of sector roots a renter
+	// can check within a single BatchHasSector RPC.
+	MaxBatchHasSectorRoots = 1 << 14
+
+	// BatchHasSectorRequestMaxLen is the maximum length for decoding a
+	// BatchHasSectorRequest.
+	BatchHasSectorRequestMaxLen = RPCMinLen + MaxBatchHasSectorRoots*crypto.HashSize
+
+	// BatchHasSectorResponseMaxLen is the maximum length for decoding a
+	// BatchHasSectorResponse.
+	BatchHasSectorResponseMaxLen = RPCMinLen + MaxBatchHasSectorRoots
+
+	// MaxExecuteProgramBatchSize is the maximum number of programs a renter
+	// can execute within a single ExecuteProgramBatch RPC.
+	MaxExecuteProgramBatchSize = 64
+
+	// MaxExecuteProgramBatchDataLength is the maximum combined length of the
+	// program data of all programs within a single ExecuteProgramBatch RPC.
+	// The host buffers the data of a program before executing it.
+	MaxExecuteProgramBatchDataLength = 1 << 25 // 32 MiB
 )
 
 // Subcription request related enum.
@@ -33,6 +76,8 @@ const (
 	SubscriptionRequestExtend
 	SubscriptionRequestPrepay
 	SubscriptionRequestStop
+	SubscriptionRequestSubscribeFiltered
+	SubscriptionRequestResume
 )
 
 // Subcription response related enum.
@@ -42,6 +87,7 @@ const (
 
 	SubscriptionResponseSubscriptionSuccess
 	SubscriptionResponseUnsubscribeSuccess
+	SubscriptionResponseRegistryValues
 )
 
 var (
@@ -72,6 +118,13 @@ type RPCPriceTable struct {
 	// prices for and are thus considered valid.
 	Validity time.Duration `json:"validity"`
 
+	// GracePeriod is a duration after the renter was issued a newer price
+	// table during which the host still accepts this price table for RPCs
+	// that were already in flight, even if it expired in the meantime. It
+	// only applies if the price table was superseded before it expired.
+	// Renters should not rely on it to initiate new RPCs.
+	GracePeriod time.Duration `json:"graceperiod"`
+
 	// HostBlockHeight is the block height of the host. This allows the renter
 	// to create valid withdrawal messages in case it is not synced yet.
 	HostBlockHeight types.BlockHeight `json:"hostblockheight"`
@@ -124,6 +177,11 @@ type RPCPriceTable struct {
 	// Cost values specific to the HasSector command.
 	HasSectorBaseCost types.Currency `json:"hassectorbasecost"`
 
+	// BatchHasSectorCost is the cost of checking up to
+	// MaxBatchHasSectorRoots sectors with the BatchHasSector RPC. It is
+	// charged once per batch regardless of the number of roots.
+	BatchHasSectorCost types.Currency `json:"batchhassectorcost"`
+
 	// Cost values specific to the Read instruction.
 	ReadBaseCost   types.Currency `json:"readbasecost"`
 	ReadLengthCost types.Currency `json:"readlengthcost"`
@@ -183,6 +241,12 @@ var (
 	// RPCExecuteProgram specifier
 	RPCExecuteProgram = types.NewSpecifier("ExecuteProgram")
 
+	// RPCContractCollateral specifier
+	RPCContractCollateral = types.NewSpecifier("Collateral")
+
+	// RPCExecuteProgramBatch specifier
+	RPCExecuteProgramBatch = types.NewSpecifier("ExecuteBatch")
+
 	// RPCFundAccount specifier
 	RPCFundAccount = types.NewSpecifier("FundAccount")
 
@@ -194,6 +258,63 @@ var (
 
 	// RPCRenewContract specifier
 	RPCRenewContract = types.NewSpecifier("RenewContract")
+
+	// RPCSignedAccountBalance specifier
+	RPCSignedAccountBalance = types.NewSpecifier("SignedAccBalance")
+
+	// RPCSectorStats specifier
+	RPCSectorStats = types.NewSpecifier("SectorStats")
+
+	// RPCBatchHasSector specifier
+	RPCBatchHasSector = types.NewSpecifier("BatchHasSector")
+)
+
+var (
+	// ErrAccountBalanceQueryExpired occurs when the expiry of a signed account
+	// balance query has already passed.
+	ErrAccountBalanceQueryExpired = errors.New("account balance query expired")
+
+	// ErrAccountBalanceQueryExtremeFuture occurs when a signed account balance
+	// query expires too far into the future.
+	ErrAccountBalanceQueryExtremeFuture = errors.New("account balance query expires too far into the future")
+
+	// ErrAccountBalanceQueryInvalidSignature occurs when the signature of a
+	// signed account balance query is invalid.
+	ErrAccountBalanceQueryInvalidSignature = errors.New("account balance query signature is invalid")
+
+	// ErrUnauthorizedAccountBalanceQuery occurs when an unsigned account
+	// balance query is made for an account that didn't pay for the RPC.
+	ErrUnauthorizedAccountBalanceQuery = errors.New("balance of an account other than the paying account requires a signed query")
+
+	// ErrContractCollateralInvalidSignature occurs when the request for the
+	// collateral of a contract isn't signed by the renter of the contract.
+	ErrContractCollateralInvalidSignature = errors.New("contract collateral request isn't signed by the renter of the contract")
+
+	// ErrTooManySectorStatsRoots occurs when a renter requests the stats of
+	// more than MaxSectorStatsRoots sectors at once.
+	ErrTooManySectorStatsRoots = fmt.Errorf("can't request the stats of more than %v sectors at once", MaxSectorStatsRoots)
+
+	// ErrTooManyBatchHasSectorRoots occurs when a renter checks more than
+	// MaxBatchHasSectorRoots sectors within a single BatchHasSector RPC.
+	ErrTooManyBatchHasSectorRoots = fmt.Errorf("can't check more than %v sectors at once", MaxBatchHasSectorRoots)
+
+	// ErrEmptyProgramBatch occurs when a renter executes a batch without any
+	// programs.
+	ErrEmptyProgramBatch = errors.New("program batch doesn't contain any programs")
+
+	// ErrProgramBatchTooLarge occurs when a renter executes a batch with more
+	// than MaxExecuteProgramBatchSize programs or more than
+	// MaxExecuteProgramBatchDataLength bytes of program data.
+	ErrProgramBatchTooLarge = fmt.Errorf("program batch can't contain more than %v programs or %v bytes of program data", MaxExecuteProgramBatchSize, MaxExecuteProgramBatchDataLength)
+
+	// ErrProgramBatchContractConflict occurs when a contract that is modified
+	// by a write program of a batch is targeted by another program of the same
+	// batch.
+	ErrProgramBatchContractConflict = errors.New("contract modified by a write program can't be targeted by another program of the same batch")
+
+	// ErrProgramBatchRenterMismatch occurs when the contracts targeted by the
+	// programs of a batch don't belong to the same renter.
+	ErrProgramBatchRenterMismatch = errors.New("all contracts targeted by a program batch need to belong to the same renter")
 )
 
 type (
@@ -203,12 +324,97 @@ type (
 		Account AccountID
 	}
 
+	// AccountBalanceMessage is the message signed by the owner of an
+	// ephemeral account to query its balance with the SignedAccountBalance
+	// RPC. The nonce makes every query unique, which allows the host to reject
+	// replayed queries.
+	AccountBalanceMessage struct {
+		Account AccountID
+		Expiry  types.BlockHeight
+		Nonce   [WithdrawalNonceSize]byte
+	}
+
+	// SignedAccountBalanceRequest specifies the account for which to retrieve
+	// the balance together with a signature of the account's secret key.
+	SignedAccountBalanceRequest struct {
+		Message   AccountBalanceMessage
+		Signature crypto.Signature
+	}
+
 	// AccountBalanceResponse contains the balance of the previously specified
 	// account.
 	AccountBalanceResponse struct {
 		Balance types.Currency
 	}
 
+	// SectorStatsRequest contains the roots of the sectors for which the
+	// renter wants to know whether the host stores them.
+	SectorStatsRequest struct {
+		Roots []crypto.Hash
+	}
+
+	// SectorStatsResponse contains the stats of the requested sectors in the
+	// same order as the roots of the request.
+	SectorStatsResponse struct {
+		Stats []SectorStat
+	}
+
+	// BatchHasSectorRequest contains the roots of the sectors the renter
+	// wants to check.
+	BatchHasSectorRequest struct {
+		Roots []crypto.Hash
+	}
+
+	// BatchHasSectorResponse indicates for every requested root whether the
+	// host stores the sector, in the same order as the roots of the request.
+	BatchHasSectorResponse struct {
+		Availables []bool
+	}
+
+	// ContractCollateralRequest requests the collateral of a contract. It is
+	// signed with the renter's key of the contract to make sure that only the
+	// renter can request the collateral of its contracts.
+	ContractCollateralRequest struct {
+		FileContractID types.FileContractID
+		Signature      crypto.Signature
+	}
+
+	// ContractCollateralResponse contains the collateral of the requested
+	// contract.
+	ContractCollateralResponse struct {
+		Collateral ContractCollateral
+	}
+
+	// ContractCollateral is the breakdown of the collateral a host put into a
+	// contract.
+	ContractCollateral struct {
+		// LockedCollateral is the collateral the host locked in the contract
+		// when it was formed or renewed.
+		LockedCollateral types.Currency `json:"lockedcollateral"`
+		// RiskedCollateral is the part of the locked collateral the host
+		// risks for the data stored in the contract. It is lost if the host
+		// doesn't submit a storage proof.
+		RiskedCollateral types.Currency `json:"riskedcollateral"`
+
+		// MissedHostPayout and MissedVoidPayout are the payouts of the latest
+		// revision in case the host doesn't submit a storage proof. The void
+		// payout contains the collateral which is burned.
+		MissedHostPayout types.Currency `json:"missedhostpayout"`
+		MissedVoidPayout types.Currency `json:"missedvoidpayout"`
+
+		// FileSize, RevisionNumber and ProofDeadline describe the state of the
+		// contract the collateral is reported for.
+		FileSize       uint64            `json:"filesize"`
+		RevisionNumber uint64            `json:"revisionnumber"`
+		ProofDeadline  types.BlockHeight `json:"proofdeadline"`
+	}
+
+	// SectorStat describes a single sector stored on the host.
+	SectorStat struct {
+		Exists bool   `json:"exists"`
+		Size   uint64 `json:"size"`
+	}
+
 	// FundAccountRequest specifies the ephemeral account id that gets funded.
 	FundAccountRequest struct {
 		Account AccountID
@@ -262,6 +468,28 @@ type (
 		Signature []byte
 	}
 
+	// RPCExecuteProgramBatchRequest is the request sent by the renter to
+	// execute multiple programs on the host's MDM, using a single payment. The
+	// data of the programs follows the request in the same order as the
+	// programs.
+	RPCExecuteProgramBatchRequest struct {
+		Programs []RPCExecuteProgramRequest
+	}
+
+	// RPCExecuteProgramBatchRevisionSigningRequest is the request sent by the
+	// renter after all programs of a batch were executed successfully. It
+	// contains one revision for every write program of the batch, in the same
+	// order as the programs.
+	RPCExecuteProgramBatchRevisionSigningRequest struct {
+		Revisions []RPCExecuteProgramRevisionSigningRequest
+	}
+
+	// RPCExecuteProgramBatchRevisionSigningResponse is the response from the
+	// host, containing the host signatures for the new revisions.
+	RPCExecuteProgramBatchRevisionSigningResponse struct {
+		Signatures [][]byte
+	}
+
 	// RPCLatestRevisionRequest contains the id of the contract for which to
 	// retrieve the latest revision.
 	RPCLatestRevisionRequest struct {
@@ -288,6 +516,38 @@ type (
 		Ratelimit uint32
 	}
 
+	// RPCRegistrySubscriptionFilteredRequest is a request to add a
+	// subscription which only notifies the subscriber about revisions of the
+	// entry which are greater than MinRevision. Subscribing with a filtered
+	// request also causes the host to batch notifications.
+	RPCRegistrySubscriptionFilteredRequest struct {
+		PubKey      types.SiaPublicKey
+		Tweak       crypto.Hash
+		MinRevision uint64
+	}
+
+	// RPCRegistrySubscriptionResumeRequest is a request to bind the session
+	// to the subscription set identified by Token. The host persists the
+	// subscriptions of a bound session in the set, which allows the renter
+	// to resume them after either side restarted. Resuming subscribes the
+	// session to all entries of the set and returns the latest values of the
+	// entries which were updated after the set's revision Since. An unknown
+	// token creates an empty set.
+	RPCRegistrySubscriptionResumeRequest struct {
+		Token crypto.Hash
+		Since uint64
+	}
+
+	// RPCRegistrySubscriptionResumeResponse is the response to a resume
+	// request. It is followed by NumUpdates entry updates. Revision is the
+	// current revision of the set, which is passed as Since when resuming the
+	// next time.
+	RPCRegistrySubscriptionResumeResponse struct {
+		Revision         uint64
+		NumSubscriptions uint64
+		NumUpdates       uint64
+	}
+
 	// RPCRegistrySubscriptionNotificationType contains the type of the
 	// following notification.
 	RPCRegistrySubscriptionNotificationType struct {
@@ -305,10 +565,17 @@ type (
 		PriceTableJSON []byte
 	}
 
-	// RPCTrackedPriceTableResponse is an empty response sent by the host to
-	// signal it has received payment for the price table and has tracked it,
-	// thus considering it valid.
-	RPCTrackedPriceTableResponse struct{}
+	// RPCTrackedPriceTableResponse is sent by the host to signal it has
+	// received payment for the price table and has tracked it, thus
+	// considering it valid. It contains the UID and version of the tracked
+	// price table as well as the UID of the renter's previous price table,
+	// which the host still accepts within the grace period advertised in it.
+	// Older hosts send an empty response.
+	RPCTrackedPriceTableResponse struct {
+		UID         UniqueID
+		PreviousUID UniqueID
+		Version     uint64
+	}
 
 	// RPCRenewContractRequest contains the transaction set with both the final
 	// revision of a contract to be renewed as well as the new contract and the
@@ -390,6 +657,25 @@ func (epr *RPCExecuteProgramResponse) UnmarshalSia(r io.Reader) error {
 	return dc.Err()
 }
 
+// MarshalSia implements the SiaMarshaler interface.
+func (tr RPCTrackedPriceTableResponse) MarshalSia(w io.Writer) error {
+	return encoding.NewEncoder(w).EncodeAll(tr.UID, tr.PreviousUID, tr.Version)
+}
+
+// UnmarshalSia implements the SiaMarshaler interface. An empty response sent
+// by hosts that don't version their price tables decodes into the zero
+// value.
+func (tr *RPCTrackedPriceTableResponse) UnmarshalSia(r io.Reader) error {
+	_, err := io.ReadFull(r, tr.UID[:])
+	if err == io.EOF {
+		*tr = RPCTrackedPriceTableResponse{}
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return encoding.NewDecoder(r, encoding.DefaultAllocLimit).DecodeAll(&tr.PreviousUID, &tr.Version)
+}
+
 // RPCReadMaxLen tries to read the given object from the stream. It will
 // allocate at most maxLen bytes for the object.
 func RPCReadMaxLen(r io.Reader, obj interface{}, maxLen uint64) error {
@@ -508,3 +794,75 @@ func (uid *UniqueID) UnmarshalJSON(b []byte) error {
 func IsPriceTableInvalidErr(err error) bool {
 	return err != nil && (strings.Contains(err.Error(), ErrPriceTableExpired.Error()) || strings.Contains(err.Error(), ErrPriceTableNotFound.Error()))
 }
+
+// SectorStatsCost returns the cost of requesting the stats of numRoots sectors
+// with the SectorStats RPC. Every root is charged like a HasSector instruction.
+func SectorStatsCost(pt *RPCPriceTable, numRoots uint64) types.Currency {
+	return pt.InitBaseCost.Add(pt.HasSectorBaseCost.Mul64(numRoots))
+}
+
+// BatchHasSectorRPCCost returns the cost of checking a batch of up to
+// MaxBatchHasSectorRoots sectors with the BatchHasSector RPC. Unlike a program
+// of HasSector instructions, the cost doesn't depend on the number of roots.
+func BatchHasSectorRPCCost(pt *RPCPriceTable) types.Currency {
+	return pt.InitBaseCost.Add(pt.BatchHasSectorCost)
+}
+
+// ContractCollateralCost returns the cost of requesting the collateral of a
+// contract. It is charged like the LatestRevision RPC which also returns
+// information about a contract.
+func ContractCollateralCost(pt *RPCPriceTable) types.Currency {
+	return pt.LatestRevisionCost
+}
+
+// ContractCollateralRequestHash returns the hash the renter signs to request
+// the collateral of a contract. It includes the price table's UID to prevent
+// the request from being replayed once the price table expired.
+func ContractCollateralRequestHash(fcid types.FileContractID, ptUID UniqueID) crypto.Hash {
+	return crypto.HashAll(RPCContractCollateral, fcid, ptUID)
+}
+
+// NewSignedAccountBalanceRequest creates a SignedAccountBalanceRequest for the
+// given account which expires at the given height.
+func NewSignedAccountBalanceRequest(account AccountID, expiry types.BlockHeight, sk crypto.SecretKey) SignedAccountBalanceRequest {
+	msg := AccountBalanceMessage{
+		Account: account,
+		Expiry:  expiry,
+	}
+	fastrand.Read(msg.Nonce[:])
+	return SignedAccountBalanceRequest{
+		Message:   msg,
+		Signature: crypto.SignHash(msg.Hash(), sk),
+	}
+}
+
+// Hash returns the hash of the message which is signed by the account owner.
+// The RPC specifier is part of the hash to make sure a signed balance query
+// can't be confused with a signed withdrawal.
+func (abm AccountBalanceMessage) Hash() crypto.Hash {
+	return crypto.HashAll(RPCSignedAccountBalance, abm)
+}
+
+// Validate returns an error if the message is expired, expires after the
+// given expiry or if the signature doesn't belong to the message's account.
+func (abm AccountBalanceMessage) Validate(blockHeight, expiry types.BlockHeight, sig crypto.Signature) error {
+	if blockHeight > abm.Expiry {
+		return ErrAccountBalanceQueryExpired
+	}
+	if abm.Expiry > expiry {
+		return ErrAccountBalanceQueryExtremeFuture
+	}
+	if abm.Account.IsZeroAccount() {
+		return ErrInvalidAccount
+	}
+	spk := abm.Account.SPK()
+	if len(spk.Key) != crypto.PublicKeySize {
+		return ErrInvalidAccount
+	}
+	var pk crypto.PublicKey
+	copy(pk[:], spk.Key)
+	if crypto.VerifyHash(abm.Hash(), pk, sig) != nil {
+		return ErrAccountBalanceQueryInvalidSignature
+	}
+	return nil
+}
@@ -188,6 +188,50 @@ func TestRPCExecuteProgramResponseMarshalSia(t *testing.T) {
 	}
 }
 
+// TestRPCTrackedPriceTableResponseMarshalSia tests the custom SiaMarshaler
+// implementation of RPCTrackedPriceTableResponse.
+func TestRPCTrackedPriceTableResponseMarshalSia(t *testing.T) {
+	tr := RPCTrackedPriceTableResponse{
+		Version: fastrand.Uint64n(100),
+	}
+	fastrand.Read(tr.UID[:])
+	fastrand.Read(tr.PreviousUID[:])
+
+	// roundtrip
+	var buf bytes.Buffer
+	if err := RPCWrite(&buf, tr); err != nil {
+		t.Fatal(err)
+	}
+	var decoded RPCTrackedPriceTableResponse
+	if err := RPCRead(&buf, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tr, decoded) {
+		t.Fatal("responses don't match", tr, decoded)
+	}
+
+	// the empty response of older hosts decodes into the zero value
+	buf.Reset()
+	if err := RPCWrite(&buf, struct{}{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := RPCRead(&buf, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded != (RPCTrackedPriceTableResponse{}) {
+		t.Fatal("expected zero value", decoded)
+	}
+
+	// older renters ignore the fields they don't know about
+	if err := RPCWrite(&buf, tr); err != nil {
+		t.Fatal(err)
+	}
+	var empty struct{}
+	if err := RPCRead(&buf, &empty); err != nil {
+		t.Fatal(err)
+	}
+}
+
 // TestIsPriceTableInvalidErr is a small unit test that verifies the
 // functionality of the `IsPriceTableInvalidErr` helper.
 func TestIsPriceTableInvalidErr(t *testing.T) {
@@ -213,3 +257,49 @@ func TestIsPriceTableInvalidErr(t *testing.T) {
 		}
 	}
 }
+
+// TestAccountBalanceMessageValidate is a unit test for validating the message
+// of a signed account balance query.
+func TestAccountBalanceMessageValidate(t *testing.T) {
+	t.Parallel()
+	aid, sk := NewAccountID()
+	sk2, _ := crypto.GenerateKeyPair()
+
+	// A valid request.
+	req := NewSignedAccountBalanceRequest(aid, 10, sk)
+	if err := req.Message.Validate(5, 15, req.Signature); err != nil {
+		t.Fatal(err)
+	}
+
+	// Two requests for the same account use different nonces.
+	if req.Message.Hash() == NewSignedAccountBalanceRequest(aid, 10, sk).Message.Hash() {
+		t.Fatal("expected different hashes")
+	}
+
+	// Expired and extreme future requests are rejected.
+	if err := req.Message.Validate(11, 15, req.Signature); !errors.Contains(err, ErrAccountBalanceQueryExpired) {
+		t.Fatal("expected ErrAccountBalanceQueryExpired", err)
+	}
+	if err := req.Message.Validate(5, 9, req.Signature); !errors.Contains(err, ErrAccountBalanceQueryExtremeFuture) {
+		t.Fatal("expected ErrAccountBalanceQueryExtremeFuture", err)
+	}
+
+	// A signature of another key is rejected.
+	req2 := NewSignedAccountBalanceRequest(aid, 10, sk2)
+	if err := req2.Message.Validate(5, 15, req2.Signature); !errors.Contains(err, ErrAccountBalanceQueryInvalidSignature) {
+		t.Fatal("expected ErrAccountBalanceQueryInvalidSignature", err)
+	}
+
+	// A signed withdrawal message can't be used as a balance query.
+	wm := WithdrawalMessage{Account: aid, Expiry: 10, Nonce: req.Message.Nonce}
+	sig := crypto.SignHash(crypto.HashObject(wm), sk)
+	if err := req.Message.Validate(5, 15, sig); !errors.Contains(err, ErrAccountBalanceQueryInvalidSignature) {
+		t.Fatal("expected ErrAccountBalanceQueryInvalidSignature", err)
+	}
+
+	// The zero account is rejected.
+	req.Message.Account = ZeroAccountID
+	if err := req.Message.Validate(5, 15, req.Signature); !errors.Contains(err, ErrInvalidAccount) {
+		t.Fatal("expected ErrInvalidAccount", err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"gitlab.com/NebulousLabs/ratelimit"
 
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/persist"
 )
 
@@ -19,6 +20,30 @@ type (
 		WriteBPS           int64  `json:"writebps"`
 		PacketSize         uint64 `json:"packetsize"`
 
+		// Alert related fields
+		AcknowledgedAlerts map[AlertID]bool  `json:"acknowledgedalerts"`
+		MutedAlerts        map[AlertID]bool  `json:"mutedalerts"`
+		RoutedAlerts       map[AlertID]Alert `json:"routedalerts"`
+		AlertRoutes        []AlertRoute      `json:"alertroutes"`
+
+		// Event related fields
+		EventRoutes []EventRoute `json:"eventroutes"`
+
+		// API related fields
+		APITokens []APIToken `json:"apitokens"`
+
+		// Disk I/O related fields
+		DiskThrottles []persist.DiskIOLimits `json:"diskthrottles"`
+
+		// Logging related fields
+		Logging persist.LogSettings `json:"logging"`
+
+		// Profile related fields
+		Profile string `json:"profile"`
+
+		// Proxy related fields
+		Proxy ProxySettings `json:"proxy"`
+
 		// path of config on disk.
 		path string
 		mu   sync.Mutex
@@ -59,6 +84,244 @@ func (cfg *SiadConfig) SetRatelimit(readBPS, writeBPS int64) error {
 	return cfg.save()
 }
 
+// AcknowledgeAlert marks the alert with the given id as acknowledged and
+// persists the change.
+func (cfg *SiadConfig) AcknowledgeAlert(id AlertID) error {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	if cfg.AcknowledgedAlerts == nil {
+		cfg.AcknowledgedAlerts = make(map[AlertID]bool)
+	}
+	cfg.AcknowledgedAlerts[id] = true
+	return cfg.save()
+}
+
+// MuteAlert mutes or unmutes the alert with the given id and persists the
+// change.
+func (cfg *SiadConfig) MuteAlert(id AlertID, mute bool) error {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	if cfg.MutedAlerts == nil {
+		cfg.MutedAlerts = make(map[AlertID]bool)
+	}
+	if mute {
+		cfg.MutedAlerts[id] = true
+	} else {
+		delete(cfg.MutedAlerts, id)
+	}
+	return cfg.save()
+}
+
+// AnnotateAlerts sets the Acknowledged and Muted fields of the provided
+// alerts. The alerts are modified in place.
+func (cfg *SiadConfig) AnnotateAlerts(alerts []Alert) {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	for i := range alerts {
+		alerts[i].Acknowledged = cfg.AcknowledgedAlerts[alerts[i].ID]
+		alerts[i].Muted = cfg.MutedAlerts[alerts[i].ID]
+	}
+}
+
+// PruneAlertAcknowledgements removes the acknowledgements of all alerts that
+// are no longer registered. That way an alert which is registered again after
+// being resolved needs to be acknowledged again.
+func (cfg *SiadConfig) PruneAlertAcknowledgements(registered map[AlertID]struct{}) error {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	var pruned bool
+	for id := range cfg.AcknowledgedAlerts {
+		if _, exists := registered[id]; !exists {
+			delete(cfg.AcknowledgedAlerts, id)
+			pruned = true
+		}
+	}
+	if !pruned {
+		return nil
+	}
+	return cfg.save()
+}
+
+// CurrentRoutedAlerts returns the alerts which were already routed to the
+// configured sinks.
+func (cfg *SiadConfig) CurrentRoutedAlerts() map[AlertID]Alert {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	routed := make(map[AlertID]Alert, len(cfg.RoutedAlerts))
+	for id, alert := range cfg.RoutedAlerts {
+		routed[id] = alert
+	}
+	return routed
+}
+
+// SetRoutedAlerts sets the alerts which were already routed to the configured
+// sinks and persists them if they changed. That way alerts aren't routed again
+// after a restart.
+func (cfg *SiadConfig) SetRoutedAlerts(routed map[AlertID]Alert) error {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	changed := len(routed) != len(cfg.RoutedAlerts)
+	for id, alert := range routed {
+		old, exists := cfg.RoutedAlerts[id]
+		changed = changed || !exists || !old.Equals(alert)
+	}
+	if !changed {
+		return nil
+	}
+	cfg.RoutedAlerts = make(map[AlertID]Alert, len(routed))
+	for id, alert := range routed {
+		cfg.RoutedAlerts[id] = alert
+	}
+	return cfg.save()
+}
+
+// CurrentAlertRoutes returns the configured alert routes.
+func (cfg *SiadConfig) CurrentAlertRoutes() []AlertRoute {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	return append([]AlertRoute{}, cfg.AlertRoutes...)
+}
+
+// SetAlertRoutes validates and sets the alert routes and persists them.
+func (cfg *SiadConfig) SetAlertRoutes(routes []AlertRoute) error {
+	for _, route := range routes {
+		if err := route.Validate(); err != nil {
+			return err
+		}
+	}
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	cfg.AlertRoutes = routes
+	return cfg.save()
+}
+
+// CurrentEventRoutes returns the configured event routes.
+func (cfg *SiadConfig) CurrentEventRoutes() []EventRoute {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	return append([]EventRoute{}, cfg.EventRoutes...)
+}
+
+// SetEventRoutes validates and sets the event routes and persists them.
+func (cfg *SiadConfig) SetEventRoutes(routes []EventRoute) error {
+	for _, route := range routes {
+		if err := route.Validate(); err != nil {
+			return err
+		}
+	}
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	cfg.EventRoutes = routes
+	return cfg.save()
+}
+
+// CurrentAPITokens returns the API tokens.
+func (cfg *SiadConfig) CurrentAPITokens() []APIToken {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	return append([]APIToken{}, cfg.APITokens...)
+}
+
+// APITokenBySecret returns the API token with the provided secret.
+func (cfg *SiadConfig) APITokenBySecret(secret string) (APIToken, bool) {
+	hash := crypto.HashBytes([]byte(secret))
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	for _, token := range cfg.APITokens {
+		if token.SecretHash == hash {
+			return token, true
+		}
+	}
+	return APIToken{}, false
+}
+
+// CreateAPIToken creates a new API token with the provided name and scopes,
+// persists it and returns it together with its secret.
+func (cfg *SiadConfig) CreateAPIToken(name string, scopes []APITokenScope) (APIToken, string, error) {
+	token, secret, err := newAPIToken(name, scopes)
+	if err != nil {
+		return APIToken{}, "", err
+	}
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	cfg.APITokens = append(cfg.APITokens, token)
+	if err := cfg.save(); err != nil {
+		cfg.APITokens = cfg.APITokens[:len(cfg.APITokens)-1]
+		return APIToken{}, "", err
+	}
+	return token, secret, nil
+}
+
+// RevokeAPIToken removes the API token with the provided id and persists the
+// change.
+func (cfg *SiadConfig) RevokeAPIToken(id string) error {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	for i, token := range cfg.APITokens {
+		if token.ID != id {
+			continue
+		}
+		cfg.APITokens = append(cfg.APITokens[:i:i], cfg.APITokens[i+1:]...)
+		return cfg.save()
+	}
+	return ErrAPITokenNotFound
+}
+
+// CurrentDiskThrottles returns the configured disk I/O limits.
+func (cfg *SiadConfig) CurrentDiskThrottles() []persist.DiskIOLimits {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	return append([]persist.DiskIOLimits{}, cfg.DiskThrottles...)
+}
+
+// SetDiskThrottles replaces the disk I/O limits of all volumes, applies them to
+// the global disk throttles and persists them.
+func (cfg *SiadConfig) SetDiskThrottles(limits []persist.DiskIOLimits) error {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	if err := persist.GlobalDiskThrottles.SetLimits(limits); err != nil {
+		return err
+	}
+	cfg.DiskThrottles = persist.GlobalDiskThrottles.Limits()
+	return cfg.save()
+}
+
+// SetLogSettings sets the log settings, applies them to the global log config
+// and persists them.
+func (cfg *SiadConfig) SetLogSettings(settings persist.LogSettings) error {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	if err := persist.GlobalLogConfig.SetSettings(settings); err != nil {
+		return err
+	}
+	cfg.Logging = persist.GlobalLogConfig.Settings()
+	return cfg.save()
+}
+
+// SetProfile sets the daemon profile, applies it to the background loops and
+// persists it.
+func (cfg *SiadConfig) SetProfile(name string) error {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	if err := SetProfile(name); err != nil {
+		return err
+	}
+	cfg.Profile = CurrentProfile().Name
+	return cfg.save()
+}
+
+// SetProxy validates and sets the outbound proxy settings, applies them to the
+// global proxy and persists them.
+func (cfg *SiadConfig) SetProxy(settings ProxySettings) error {
+	cfg.mu.Lock()
+	defer cfg.mu.Unlock()
+	if err := GlobalProxy.SetSettings(settings); err != nil {
+		return err
+	}
+	cfg.Proxy = settings
+	return cfg.save()
+}
+
 // save saves the config to disk.
 func (cfg *SiadConfig) save() error {
 	return persist.SaveJSON(configMetadata, cfg, cfg.path)
@@ -99,5 +362,21 @@ func NewConfig(path string) (*SiadConfig, error) {
 	}
 	// Init the global ratelimit.
 	GlobalRateLimits.SetLimits(cfg.ReadBPS, cfg.WriteBPS, cfg.PacketSize)
+	// Init the global disk throttles.
+	if err := persist.GlobalDiskThrottles.SetLimits(cfg.DiskThrottles); err != nil {
+		return nil, err
+	}
+	// Init the global log config.
+	if err := persist.GlobalLogConfig.SetSettings(cfg.Logging); err != nil {
+		return nil, err
+	}
+	// Init the daemon profile.
+	if err := SetProfile(cfg.Profile); err != nil {
+		return nil, err
+	}
+	// Init the global proxy.
+	if err := GlobalProxy.SetSettings(cfg.Proxy); err != nil {
+		return nil, err
+	}
 	return &cfg, nil
 }
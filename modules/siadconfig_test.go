@@ -1,10 +1,13 @@
 package modules
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
 
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/persist"
@@ -60,6 +63,93 @@ func TestSiadConfigPersistCompat(t *testing.T) {
 	}
 }
 
+// TestSiadConfigAlerts tests acknowledging and muting alerts as well as
+// setting alert routes.
+func TestSiadConfigAlerts(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create siadconfig
+	testDir := build.TempDir("siadconfig", t.Name())
+	if err := os.MkdirAll(testDir, persist.DefaultDiskPermissionsTest); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(testDir, ConfigName)
+	sc, err := NewConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Acknowledge one alert and mute another one.
+	ackID, muteID := AlertID(AlertIDGatewayOffline), AlertID(AlertIDHostDiskTrouble)
+	if err := sc.AcknowledgeAlert(ackID); err != nil {
+		t.Fatal(err)
+	}
+	if err := sc.MuteAlert(muteID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// Set a route. An invalid route should be rejected.
+	routes := []AlertRoute{{MinSeverity: SeverityError, Sink: AlertSinkLog}}
+	if err := sc.SetAlertRoutes(routes); err != nil {
+		t.Fatal(err)
+	}
+	if err := sc.SetAlertRoutes([]AlertRoute{{Sink: "unknown"}}); err == nil {
+		t.Fatal("expected invalid route to be rejected")
+	}
+
+	// Reload the config and check the annotations.
+	sc, err = NewConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alerts := []Alert{{ID: ackID}, {ID: muteID}}
+	sc.AnnotateAlerts(alerts)
+	if !alerts[0].Acknowledged || alerts[0].Muted {
+		t.Fatal("unexpected annotation", alerts[0])
+	}
+	if alerts[1].Acknowledged || !alerts[1].Muted {
+		t.Fatal("unexpected annotation", alerts[1])
+	}
+	if current := sc.CurrentAlertRoutes(); len(current) != 1 || current[0].Sink != AlertSinkLog {
+		t.Fatal("unexpected routes", current)
+	}
+
+	// Pruning should remove the acknowledgement of the unregistered alert.
+	if err := sc.PruneAlertAcknowledgements(map[AlertID]struct{}{muteID: {}}); err != nil {
+		t.Fatal(err)
+	}
+	sc.AnnotateAlerts(alerts)
+	if alerts[0].Acknowledged {
+		t.Fatal("acknowledgement should have been pruned")
+	}
+
+	// Unmute the alert.
+	if err := sc.MuteAlert(muteID, false); err != nil {
+		t.Fatal(err)
+	}
+	sc.AnnotateAlerts(alerts)
+	if alerts[1].Muted {
+		t.Fatal("alert should be unmuted")
+	}
+
+	// The routed alerts should survive a reload.
+	routed := map[AlertID]Alert{muteID: {ID: muteID, Module: "host", Msg: "msg", Severity: SeverityWarning}}
+	if err := sc.SetRoutedAlerts(routed); err != nil {
+		t.Fatal(err)
+	}
+	sc, err = NewConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	current := sc.CurrentRoutedAlerts()
+	if len(current) != 1 || !current[muteID].Equals(routed[muteID]) {
+		t.Fatal("unexpected routed alerts", current)
+	}
+}
+
 // saveLoadCheck is a helper to check saving and loading the siad config file
 // and verifying the correct values for the WriteBPS fields
 func saveLoadCheck(sc *SiadConfig, writeBPS, writeBPSDeprepacted int64) error {
@@ -97,3 +187,349 @@ func checkWriteBPS(sc *SiadConfig, writeBPS int64) error {
 	}
 	return nil
 }
+
+// TestSiadConfigDiskThrottles tests setting and persisting the disk throttles
+// and their application to files opened using the production dependencies.
+func TestSiadConfigDiskThrottles(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	// Create siadconfig
+	testDir := build.TempDir("siadconfig", t.Name())
+	if err := os.MkdirAll(testDir, persist.DefaultDiskPermissionsTest); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(testDir, ConfigName)
+	sc, err := NewConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := persist.GlobalDiskThrottles.SetLimits(nil); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Invalid limits are rejected.
+	volume := filepath.Join(testDir, "volume")
+	if err := sc.SetDiskThrottles([]persist.DiskIOLimits{{Path: volume, IOPS: 1}}); err == nil {
+		t.Fatal("expected invalid limits to be rejected")
+	}
+
+	// Set the limits and reload the config.
+	limits := []persist.DiskIOLimits{{Path: volume, WriteBPS: persist.MinDiskIOBPS}}
+	if err := sc.SetDiskThrottles(limits); err != nil {
+		t.Fatal(err)
+	}
+	if err := persist.GlobalDiskThrottles.SetLimits(nil); err != nil {
+		t.Fatal(err)
+	}
+	sc, err = NewConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if current := sc.CurrentDiskThrottles(); len(current) != 1 || current[0] != limits[0] {
+		t.Fatal("unexpected limits", current)
+	}
+	if current := persist.GlobalDiskThrottles.Limits(); len(current) != 1 || current[0] != limits[0] {
+		t.Fatal("limits weren't applied", current)
+	}
+
+	// Writes to a file within the volume are throttled. Writing three times
+	// the limit should take at least a second since the burst only covers the
+	// first write.
+	if err := os.MkdirAll(volume, persist.DefaultDiskPermissionsTest); err != nil {
+		t.Fatal(err)
+	}
+	deps := new(ProductionDependencies)
+	f, err := deps.CreateFile(filepath.Join(volume, "file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	data := make([]byte, persist.MinDiskIOBPS)
+	for i := 0; i < 3; i++ {
+		if _, err := f.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Fatal("writes weren't throttled", elapsed)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	stats := persist.GlobalDiskThrottles.Stats()
+	if len(stats) != 1 || stats[0].BytesWritten != 3*persist.MinDiskIOBPS || stats[0].Ops != 3 {
+		t.Fatal("unexpected stats", stats)
+	}
+}
+
+// TestSiadConfigProfile tests setting and persisting the daemon profile.
+func TestSiadConfigProfile(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	// Create siadconfig
+	testDir := build.TempDir("siadconfig", t.Name())
+	if err := os.MkdirAll(testDir, persist.DefaultDiskPermissionsTest); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(testDir, ConfigName)
+	sc, err := NewConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := SetProfile(ProfileDefault); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// A new config runs with the default profile.
+	if p := CurrentProfile(); p.Name != ProfileDefault || p != profiles[ProfileDefault] {
+		t.Fatal("unexpected profile", p)
+	}
+
+	// Unknown profiles are rejected.
+	if err := sc.SetProfile("unknown"); err == nil {
+		t.Fatal("expected unknown profile to be rejected")
+	}
+
+	// Set the low power profile and reload the config.
+	if err := sc.SetProfile(ProfileLowPower); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetProfile(ProfileDefault); err != nil {
+		t.Fatal(err)
+	}
+	sc, err = NewConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc.Profile != ProfileLowPower {
+		t.Fatal("profile wasn't persisted", sc.Profile)
+	}
+	p := CurrentProfile()
+	if p != profiles[ProfileLowPower] {
+		t.Fatal("profile wasn't applied", p)
+	}
+
+	// The low power profile should reduce the background work.
+	d := profiles[ProfileDefault]
+	if p.HostDBScanningThreads >= d.HostDBScanningThreads || p.HostDBMinScanSleep <= d.HostDBMinScanSleep || p.HostDBMaxScanSleep <= d.HostDBMaxScanSleep || p.HealthCheckInterval <= d.HealthCheckInterval || p.ConnectabilityCheckFrequency <= d.ConnectabilityCheckFrequency || p.AccountSaveBatchInterval <= d.AccountSaveBatchInterval {
+		t.Fatal("low power profile doesn't reduce background work", p, d)
+	}
+	if p.HostDBMinScanSleep >= p.HostDBMaxScanSleep {
+		t.Fatal("invalid scan sleep range", p)
+	}
+
+	// An empty name selects the default profile.
+	if err := sc.SetProfile(""); err != nil {
+		t.Fatal(err)
+	}
+	if sc.Profile != ProfileDefault || CurrentProfile() != profiles[ProfileDefault] {
+		t.Fatal("default profile wasn't set", sc.Profile, CurrentProfile())
+	}
+}
+
+// TestSiadConfigProxy tests setting and persisting the outbound proxy.
+func TestSiadConfigProxy(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	// Create siadconfig
+	testDir := build.TempDir("siadconfig", t.Name())
+	if err := os.MkdirAll(testDir, persist.DefaultDiskPermissionsTest); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(testDir, ConfigName)
+	sc, err := NewConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := GlobalProxy.SetSettings(ProxySettings{}); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Invalid settings are rejected.
+	if err := sc.SetProxy(ProxySettings{Address: "127.0.0.1"}); err == nil {
+		t.Fatal("expected invalid settings to be rejected")
+	}
+	if GlobalProxy.Enabled() {
+		t.Fatal("proxy shouldn't be enabled")
+	}
+
+	// Set the proxy and reload the config.
+	settings := ProxySettings{
+		Address:              "127.0.0.1:9050",
+		Username:             "user",
+		Password:             "pass",
+		DisableAnnouncements: true,
+	}
+	if err := sc.SetProxy(settings); err != nil {
+		t.Fatal(err)
+	}
+	if err := GlobalProxy.SetSettings(ProxySettings{}); err != nil {
+		t.Fatal(err)
+	}
+	sc, err = NewConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc.Proxy != settings {
+		t.Fatal("proxy wasn't persisted", sc.Proxy)
+	}
+	if GlobalProxy.Settings() != settings || !GlobalProxy.AnnouncementsDisabled() {
+		t.Fatal("proxy wasn't applied", GlobalProxy.Settings())
+	}
+
+	// Disable the proxy again.
+	if err := sc.SetProxy(ProxySettings{}); err != nil {
+		t.Fatal(err)
+	}
+	if GlobalProxy.Enabled() || GlobalProxy.AnnouncementsDisabled() {
+		t.Fatal("proxy should be disabled")
+	}
+}
+
+// TestSiadConfigAPITokens tests creating, persisting and revoking API tokens.
+func TestSiadConfigAPITokens(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	testDir := build.TempDir("siadconfig", t.Name())
+	if err := os.MkdirAll(testDir, persist.DefaultDiskPermissionsTest); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(testDir, ConfigName)
+	sc, err := NewConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Invalid tokens are rejected.
+	if _, _, err := sc.CreateAPIToken("", []APITokenScope{APITokenScopeRead}); err == nil {
+		t.Fatal("token without a name should be rejected")
+	}
+	if _, _, err := sc.CreateAPIToken("foo", nil); err == nil {
+		t.Fatal("token without scopes should be rejected")
+	}
+	if _, _, err := sc.CreateAPIToken("foo", []APITokenScope{"admin"}); err == nil {
+		t.Fatal("token with unknown scope should be rejected")
+	}
+
+	// Create a token and look it up by its secret.
+	token, secret, err := sc.CreateAPIToken("dashboard", []APITokenScope{APITokenScopeRead})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found, exists := sc.APITokenBySecret(secret); !exists || found.ID != token.ID {
+		t.Fatal("token wasn't found", found, exists)
+	}
+	if _, exists := sc.APITokenBySecret(secret + "0"); exists {
+		t.Fatal("token shouldn't be found by a wrong secret")
+	}
+
+	// The token is persisted without its secret.
+	sc, err = NewConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens := sc.CurrentAPITokens()
+	if len(tokens) != 1 || tokens[0].ID != token.ID || !tokens[0].HasScope(APITokenScopeRead) || tokens[0].HasScope(APITokenScopeWalletSpend) {
+		t.Fatal("token wasn't persisted", tokens)
+	}
+	if _, exists := sc.APITokenBySecret(secret); !exists {
+		t.Fatal("token wasn't found after reloading the config")
+	}
+
+	// Revoke the token.
+	if err := sc.RevokeAPIToken(token.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, exists := sc.APITokenBySecret(secret); exists {
+		t.Fatal("revoked token shouldn't be found")
+	}
+	if err := sc.RevokeAPIToken(token.ID); !errors.Is(err, ErrAPITokenNotFound) {
+		t.Fatal("expected ErrAPITokenNotFound but got", err)
+	}
+}
+
+// TestParseAPITokenScopes is a unit test for ParseAPITokenScopes.
+func TestParseAPITokenScopes(t *testing.T) {
+	t.Parallel()
+	scopes, err := ParseAPITokenScopes(" read, renter-write,,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scopes) != 2 || scopes[0] != APITokenScopeRead || scopes[1] != APITokenScopeRenterWrite {
+		t.Fatal("wrong scopes", scopes)
+	}
+	if _, err := ParseAPITokenScopes(""); err == nil {
+		t.Fatal("empty scopes should be rejected")
+	}
+	if _, err := ParseAPITokenScopes("read,write"); err == nil {
+		t.Fatal("unknown scope should be rejected")
+	}
+}
+
+// TestSiadConfigLogSettings tests setting and persisting the log settings.
+func TestSiadConfigLogSettings(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	// Create siadconfig
+	testDir := build.TempDir("siadconfig", t.Name())
+	if err := os.MkdirAll(testDir, persist.DefaultDiskPermissionsTest); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(testDir, ConfigName)
+	sc, err := NewConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := persist.GlobalLogConfig.SetSettings(persist.LogSettings{}); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Invalid settings are rejected.
+	if err := sc.SetLogSettings(persist.LogSettings{Level: "verbose"}); err == nil {
+		t.Fatal("expected invalid settings to be rejected")
+	}
+
+	// Set the settings and reload the config.
+	settings := persist.LogSettings{
+		Format:       persist.LogFormatJSON,
+		Level:        persist.LogLevelWarn,
+		ModuleLevels: map[string]persist.LogLevel{"host": persist.LogLevelDebug},
+		MaxSize:      1 << 20,
+		MaxBackups:   3,
+	}
+	if err := sc.SetLogSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	if err := persist.GlobalLogConfig.SetSettings(persist.LogSettings{}); err != nil {
+		t.Fatal(err)
+	}
+	sc, err = NewConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(sc.Logging, settings) {
+		t.Fatal("settings weren't persisted", sc.Logging)
+	}
+	if applied := persist.GlobalLogConfig.Settings(); !reflect.DeepEqual(applied, settings) {
+		t.Fatal("settings weren't applied", applied)
+	}
+}
@@ -1,6 +1,8 @@
 package modules
 
 import (
+	"time"
+
 	"go.sia.tech/siad/crypto"
 )
 
@@ -40,6 +42,36 @@ type (
 		// folder. Progress is always reported in bytes.
 		ProgressNumerator   uint64
 		ProgressDenominator uint64
+
+		// The fields below describe the drain of the storage folder if it is
+		// being drained. DrainRate is the number of sectors migrated per
+		// second, 0 meaning unlimited. DrainError is set if the drain paused
+		// itself because the sectors couldn't be migrated.
+		Draining    bool   `json:"draining"`
+		DrainPaused bool   `json:"drainpaused"`
+		DrainRate   uint64 `json:"drainrate"`
+		DrainError  string `json:"drainerror,omitempty"`
+	}
+
+	// StorageHealth contains the results of the scrubber which periodically
+	// re-reads all sectors of the storage manager and verifies their Merkle
+	// roots. Corrupted sectors are quarantined and can't be read anymore.
+	StorageHealth struct {
+		// The fields below describe the current or most recent scrub cycle.
+		// LastCycleCompleted is before CycleStarted while a cycle is in
+		// progress.
+		CycleStarted         time.Time `json:"cyclestarted"`
+		CycleSectorsScrubbed uint64    `json:"cyclesectorsscrubbed"`
+		CycleSectorsTotal    uint64    `json:"cyclesectorstotal"`
+		LastCycleCompleted   time.Time `json:"lastcyclecompleted"`
+
+		// SectorsScrubbed, UnreadableSectors and CorruptedSectors are totals
+		// since startup. QuarantinedSectors is the number of corrupted
+		// sectors which are still stored by the storage manager.
+		SectorsScrubbed    uint64 `json:"sectorsscrubbed"`
+		UnreadableSectors  uint64 `json:"unreadablesectors"`
+		CorruptedSectors   uint64 `json:"corruptedsectors"`
+		QuarantinedSectors uint64 `json:"quarantinedsectors"`
 	}
 
 	// A StorageManager is responsible for managing storage folders and
@@ -86,6 +118,18 @@ type (
 		// requests to remove data.
 		DeleteSector(sectorRoot crypto.Hash) error
 
+		// DrainStorageFolder starts draining a storage folder in the
+		// background. While the storage folder is drained, it doesn't accept
+		// new sectors and its sectors are migrated to the other storage
+		// folders at the given rate. A rate of 0 migrates the sectors as fast
+		// as possible. Once the storage folder is empty, it is removed. If the
+		// storage folder is already being drained, its rate is updated.
+		DrainStorageFolder(index uint16, sectorsPerSecond uint64) error
+
+		// PauseStorageFolderDrain pauses or resumes the drain of a storage
+		// folder.
+		PauseStorageFolderDrain(index uint16, paused bool) error
+
 		// ReadSector will read a sector from the storage manager, returning the
 		// bytes that match the input sector root.
 		ReadSector(sectorRoot crypto.Hash) ([]byte, error)
@@ -131,5 +175,9 @@ type (
 		// StorageFolders will return a list of storage folders tracked by the
 		// manager.
 		StorageFolders() []StorageFolderMetadata
+
+		// StorageHealth returns the results of the scrubber which verifies
+		// the integrity of the stored sectors.
+		StorageHealth() StorageHealth
 	}
 )
@@ -90,6 +90,17 @@ type (
 )
 
 type (
+	// TransactionPoolFeeEstimate is an estimate of the fee per byte a
+	// transaction needs to pay to be confirmed within TargetBlocks blocks.
+	// Samples is the number of observed transaction sets the estimate is
+	// based on. If there weren't enough observations, Samples is 0 and the
+	// estimate is the maximum recommendation of FeeEstimation.
+	TransactionPoolFeeEstimate struct {
+		TargetBlocks types.BlockHeight `json:"targetblocks"`
+		FeePerByte   types.Currency    `json:"feeperbyte"`
+		Samples      uint64            `json:"samples"`
+	}
+
 	// A TransactionPoolSubscriber receives updates about the confirmed and
 	// unconfirmed set from the transaction pool. Generally, there is no need to
 	// subscribe to both the consensus set and the transaction pool.
@@ -115,6 +126,11 @@ type (
 		// Close is necessary for clean shutdown (e.g. during testing).
 		Close() error
 
+		// EvictTransactionSet removes the transaction set containing the
+		// transaction with the provided id from the pool and returns the ids
+		// of all evicted transactions.
+		EvictTransactionSet(id types.TransactionID) ([]types.TransactionID, error)
+
 		// FeeEstimation returns an estimation for how high the transaction fee
 		// needs to be per byte. The minimum recommended targets getting accepted
 		// in ~3 blocks, and the maximum recommended targets getting accepted
@@ -123,6 +139,12 @@ type (
 		// within 10 blocks.
 		FeeEstimation() (minimumRecommended, maximumRecommended types.Currency)
 
+		// EstimateFee returns an estimate of the fee per byte a transaction
+		// needs to pay to be confirmed within targetBlocks blocks. The
+		// estimate is based on the observed confirmation times of
+		// transactions paying different fees.
+		EstimateFee(targetBlocks types.BlockHeight) TransactionPoolFeeEstimate
+
 		// PurgeTransactionPool is a temporary function available to the miner. In
 		// the event that a miner mines an unacceptable block, the transaction pool
 		// will be purged to clear out the transaction pool and get rid of the
@@ -130,6 +152,11 @@ type (
 		// that make this condition necessary.
 		PurgeTransactionPool()
 
+		// ReplaceTransactionSet replaces the transaction sets in the pool
+		// which conflict with the provided set if the provided set pays
+		// higher fees.
+		ReplaceTransactionSet([]types.Transaction) error
+
 		// Transaction returns the transaction and unconfirmed parents
 		// corresponding to the provided transaction id.
 		Transaction(id types.TransactionID) (txn types.Transaction, unconfirmedParents []types.Transaction, exists bool)
@@ -436,5 +436,17 @@ func (tp *TransactionPool) relayTransactionSet(conn modules.PeerConn) error {
 	if err != nil {
 		return err
 	}
-	return tp.AcceptTransactionSet(ts)
+	err = tp.AcceptTransactionSet(ts)
+	// A set which double-spends a set in the pool might be a replacement
+	// paying higher fees.
+	if err != nil && modules.IsConsensusConflict(err) && tp.ReplaceTransactionSet(ts) == nil {
+		err = nil
+	}
+	// Report the relay to the gateway to score the peer. Sets which are
+	// rejected for other reasons are not counted since they might be
+	// rejected due to the local state of the pool.
+	if err == nil || errors.Contains(err, modules.ErrDuplicateTransactionSet) {
+		tp.gateway.ReportRelay(conn.RPCAddr(), crypto.HashObject(ts))
+	}
+	return err
 }
@@ -76,6 +76,41 @@ var (
 	minEstimation = types.SiacoinPrecision.Div64(100).Div64(1e3)
 )
 
+// Variables related to the fee estimator.
+var (
+	// feeEstimatorDepth is the number of recent blocks whose confirmed
+	// transaction sets are considered by the fee estimator.
+	feeEstimatorDepth = build.Select(build.Var{
+		Standard: types.BlockHeight(144),
+		Dev:      types.BlockHeight(50),
+		Testing:  types.BlockHeight(20),
+	}).(types.BlockHeight)
+
+	// feeEstimatorMinSamples is the number of observed transaction sets
+	// required before the fee estimator bases its estimates on them.
+	feeEstimatorMinSamples = build.Select(build.Var{
+		Standard: 50,
+		Dev:      20,
+		Testing:  10,
+	}).(int)
+)
+
+// Constants related to replacing transaction sets.
+const (
+	// maxReplacedTransactions is the maximum number of transactions a
+	// replacement transaction set can evict from the pool. It limits the work
+	// a peer can cause by relaying replacements.
+	maxReplacedTransactions = 100
+)
+
+// Constants related to the fee estimator.
+const (
+	// feeEstimatorSuccessRate is the fraction of transaction sets paying at
+	// least the estimated fee which need to have been confirmed within the
+	// target number of blocks.
+	feeEstimatorSuccessRate = 0.85
+)
+
 // Variables related to propagating transactions through the network.
 var (
 	// relayTransactionSetTimeout establishes the timeout for a relay
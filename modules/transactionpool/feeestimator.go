@@ -0,0 +1,165 @@
+package transactionpool
+
+import (
+	"math"
+	"sort"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// feeSample describes a transaction set which was confirmed while it was in
+// the transaction pool.
+type feeSample struct {
+	feeRate types.Currency    // fee per byte
+	height  types.BlockHeight // height of the block which confirmed the set
+	wait    types.BlockHeight // number of blocks until the set was confirmed
+}
+
+// EstimateFee returns an estimate of the fee per byte a transaction needs to
+// pay to be confirmed within targetBlocks blocks. The estimate is based on how
+// long transaction sets paying different fees took to be confirmed over the
+// recent blocks and on the sets which are still waiting in the pool. If there
+// aren't enough observations, the maximum recommendation of FeeEstimation is
+// returned instead.
+func (tp *TransactionPool) EstimateFee(targetBlocks types.BlockHeight) modules.TransactionPoolFeeEstimate {
+	if targetBlocks == 0 {
+		targetBlocks = 1
+	}
+	estimate := modules.TransactionPoolFeeEstimate{TargetBlocks: targetBlocks}
+	if err := tp.tg.Add(); err != nil {
+		return estimate
+	}
+	defer tp.tg.Done()
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+
+	min, max := tp.feeEstimation()
+	samples := tp.feeSamplesWithPending(targetBlocks)
+	if len(samples) < feeEstimatorMinSamples {
+		estimate.FeePerByte = max
+		return estimate
+	}
+	feeRate, ok := estimateFeeRate(samples, targetBlocks)
+	if !ok {
+		// Not even the highest fees were confirmed reliably, suggest paying
+		// more than any observed set.
+		feeRate = samples[0].feeRate.Mul64(maxMultiplier)
+		if feeRate.Cmp(max) < 0 {
+			feeRate = max
+		}
+	}
+	// Transactions paying less than the minimum are unlikely to be accepted
+	// by the pool at its current size.
+	if feeRate.Cmp(min) < 0 {
+		feeRate = min
+	}
+	estimate.FeePerByte = feeRate
+	estimate.Samples = uint64(len(samples))
+	return estimate
+}
+
+// feeSamplesWithPending returns the fee samples of the recently confirmed sets
+// together with the sets in the pool which already waited for more than
+// targetBlocks blocks. The pending sets count as sets which weren't confirmed
+// in time. The samples are sorted by fee rate in descending order.
+func (tp *TransactionPool) feeSamplesWithPending(targetBlocks types.BlockHeight) []feeSample {
+	samples := append([]feeSample(nil), tp.feeSamples...)
+	for _, set := range tp.transactionSets {
+		seen, ok := tp.setSeenHeight(set)
+		if !ok || tp.blockHeight-seen < targetBlocks {
+			continue
+		}
+		samples = append(samples, feeSample{
+			feeRate: modules.CalculateFee(set),
+			height:  tp.blockHeight,
+			wait:    math.MaxUint64,
+		})
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i].feeRate.Cmp(samples[j].feeRate) > 0
+	})
+	return samples
+}
+
+// estimateFeeRate returns the lowest fee rate for which at least
+// feeEstimatorSuccessRate of the samples paying the same or a higher fee rate
+// were confirmed within targetBlocks blocks. The samples need to be sorted by
+// fee rate in descending order.
+func estimateFeeRate(samples []feeSample, targetBlocks types.BlockHeight) (types.Currency, bool) {
+	var feeRate types.Currency
+	var found bool
+	var total, confirmed int
+	for i, s := range samples {
+		total++
+		if s.wait <= targetBlocks {
+			confirmed++
+		}
+		// Only consider the rate once all samples with the same rate were
+		// counted.
+		if i+1 < len(samples) && samples[i+1].feeRate.Equals(s.feeRate) {
+			continue
+		}
+		if float64(confirmed)/float64(total) >= feeEstimatorSuccessRate {
+			feeRate = s.feeRate
+			found = true
+		}
+	}
+	return feeRate, found
+}
+
+// setSeenHeight returns the lowest height at which a transaction of the set
+// was added to the pool.
+func (tp *TransactionPool) setSeenHeight(set []types.Transaction) (types.BlockHeight, bool) {
+	var seen types.BlockHeight
+	var ok bool
+	for _, txn := range set {
+		height, exists := tp.transactionHeights[txn.ID()]
+		if exists && (!ok || height < seen) {
+			seen, ok = height, true
+		}
+	}
+	return seen, ok
+}
+
+// recordFeeSamples adds a fee sample for every set in the pool which contains
+// a transaction that was confirmed by the applied blocks. confirmedAt maps the
+// confirmed transactions to the height of the block which confirmed them.
+// Samples of blocks which are too old are pruned.
+func (tp *TransactionPool) recordFeeSamples(confirmedAt map[types.TransactionID]types.BlockHeight, height types.BlockHeight) {
+	for _, set := range tp.transactionSets {
+		var confirmHeight types.BlockHeight
+		var confirmed bool
+		for _, txn := range set {
+			if h, exists := confirmedAt[txn.ID()]; exists && (!confirmed || h > confirmHeight) {
+				confirmHeight, confirmed = h, true
+			}
+		}
+		seen, ok := tp.setSeenHeight(set)
+		if !confirmed || !ok || seen > confirmHeight {
+			continue
+		}
+		wait := confirmHeight - seen
+		if wait == 0 {
+			wait = 1
+		}
+		tp.feeSamples = append(tp.feeSamples, feeSample{
+			feeRate: modules.CalculateFee(set),
+			height:  confirmHeight,
+			wait:    wait,
+		})
+	}
+	tp.pruneFeeSamples(height)
+}
+
+// pruneFeeSamples removes the samples of blocks above the provided height and
+// of blocks which are older than feeEstimatorDepth.
+func (tp *TransactionPool) pruneFeeSamples(height types.BlockHeight) {
+	samples := tp.feeSamples[:0]
+	for _, s := range tp.feeSamples {
+		if s.height <= height && s.height+feeEstimatorDepth > height {
+			samples = append(samples, s)
+		}
+	}
+	tp.feeSamples = samples
+}
@@ -0,0 +1,129 @@
+package transactionpool
+
+import (
+	"math"
+	"testing"
+
+	"go.sia.tech/siad/types"
+)
+
+// TestEstimateFeeRate is a unit test for estimateFeeRate.
+func TestEstimateFeeRate(t *testing.T) {
+	t.Parallel()
+
+	// sample is a helper to create a sample.
+	sample := func(feeRate uint64, wait types.BlockHeight) feeSample {
+		return feeSample{feeRate: types.NewCurrency64(feeRate), wait: wait}
+	}
+	samples := []feeSample{
+		sample(100, 1),
+		sample(90, 1),
+		sample(80, 1),
+		sample(80, 2),
+		sample(70, 3),
+		sample(60, math.MaxUint64),
+		sample(50, 5),
+	}
+
+	// Within 1 block, 3 of the 4 sets paying at least 80 were confirmed,
+	// which is below the success rate.
+	if rate, ok := estimateFeeRate(samples, 1); !ok || !rate.Equals64(90) {
+		t.Fatal("wrong estimate", rate, ok)
+	}
+	// Within 2 blocks, all sets paying at least 80 were confirmed.
+	if rate, ok := estimateFeeRate(samples, 2); !ok || !rate.Equals64(80) {
+		t.Fatal("wrong estimate", rate, ok)
+	}
+	// Within 5 blocks, 6 of the 7 sets were confirmed.
+	if rate, ok := estimateFeeRate(samples, 5); !ok || !rate.Equals64(50) {
+		t.Fatal("wrong estimate", rate, ok)
+	}
+	// If not even the highest fee was confirmed, there is no estimate.
+	if _, ok := estimateFeeRate([]feeSample{sample(100, 2)}, 1); ok {
+		t.Fatal("expected no estimate")
+	}
+}
+
+// TestEstimateFee tests that the transaction pool estimates fees from the
+// confirmation times of the transaction sets it observed.
+func TestEstimateFee(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	tpt, err := createTpoolTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tpt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Without observations, the maximum recommendation is returned.
+	min, max := tpt.tpool.FeeEstimation()
+	estimate := tpt.tpool.EstimateFee(0)
+	if estimate.TargetBlocks != 1 || estimate.Samples != 0 || !estimate.FeePerByte.Equals(max) {
+		t.Fatal("unexpected estimate", estimate)
+	}
+
+	// Create outputs which can be spent by independent transaction sets.
+	numSets := feeEstimatorMinSamples + 2
+	fund := types.SiacoinPrecision.Mul64(100)
+	var outputs []types.SiacoinOutput
+	for i := 0; i < numSets; i++ {
+		outputs = append(outputs, types.SiacoinOutput{
+			UnlockHash: types.UnlockConditions{}.UnlockHash(),
+			Value:      fund,
+		})
+	}
+	txns, err := tpt.wallet.SendSiacoinsMulti(outputs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tpt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Submit a set for every output and confirm them in the next block.
+	fundingTxn := txns[len(txns)-1]
+	for i := 0; i < numSets; i++ {
+		fee := max.Mul64(uint64(1000 * (i + 1)))
+		graph, err := types.TransactionGraph(fundingTxn.SiacoinOutputID(uint64(i)), []types.TransactionGraphEdge{{
+			Dest:   1,
+			Fee:    fee,
+			Source: 0,
+			Value:  fund.Sub(fee),
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tpt.tpool.AcceptTransactionSet(graph); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := tpt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	// All sets were confirmed within a block, so the estimate is based on
+	// them but can't be lower than the minimum.
+	estimate = tpt.tpool.EstimateFee(1)
+	if estimate.Samples < uint64(numSets) {
+		t.Fatal("estimate should be based on the confirmed sets", estimate)
+	}
+	if estimate.FeePerByte.Cmp(min) < 0 {
+		t.Fatal("estimate below minimum", estimate, min)
+	}
+
+	// Once the blocks are older than the estimator's depth, the samples are
+	// dropped again.
+	for i := types.BlockHeight(0); i < feeEstimatorDepth; i++ {
+		if _, err := tpt.miner.AddBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if estimate := tpt.tpool.EstimateFee(1); estimate.Samples != 0 {
+		t.Fatal("samples should have been pruned", estimate)
+	}
+}
@@ -22,6 +22,7 @@ const tpoolSyncRate = time.Minute * 2
 // threadedRegularSync will make sure that sync gets called on the database
 // every once in a while.
 func (tp *TransactionPool) threadedRegularSync() {
+	defer modules.RecoverPanic("transactionpool")
 	if err := tp.tg.Add(); err != nil {
 		return
 	}
@@ -43,7 +44,7 @@ func (tp *TransactionPool) threadedRegularSync() {
 // one.
 func (tp *TransactionPool) syncDB() {
 	// Commit the existing tx.
-	err := tp.dbTx.Commit()
+	err := tp.db.Commit(tp.dbTx)
 	if err != nil {
 		tp.log.Severe("ERROR: failed to apply database update:", err)
 		tp.dbTx.Rollback()
@@ -235,3 +236,16 @@ func (tp *TransactionPool) TransactionConfirmed(id types.TransactionID) (bool, e
 func (tp *TransactionPool) transactionConfirmed(tx *bolt.Tx, id types.TransactionID) bool {
 	return tx.Bucket(bucketConfirmedTransactions).Get(id[:]) != nil
 }
+
+// Quiesce implements the modules.Quiescer interface. It commits the pending
+// changes to the database and blocks further commits until resume is called.
+func (tp *TransactionPool) Quiesce() (resume func(), err error) {
+	if err := tp.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer tp.tg.Done()
+	tp.mu.Lock()
+	tp.syncDB()
+	tp.mu.Unlock()
+	return tp.db.Quiesce(), nil
+}
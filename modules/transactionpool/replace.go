@@ -0,0 +1,214 @@
+package transactionpool
+
+import (
+	"fmt"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	errNothingToReplace     = errors.New("transaction set doesn't conflict with any transaction set in the pool")
+	errReplacementFeeTooLow = errors.New("replacement transaction set doesn't pay enough fees")
+	errTooManyReplacements  = fmt.Errorf("replacement transaction set would evict more than %v transactions", maxReplacedTransactions)
+	errTransactionNotFound  = errors.New("transaction not found in transaction pool")
+)
+
+// setFees returns the sum of the miner fees of a transaction set.
+func setFees(ts []types.Transaction) types.Currency {
+	var fees types.Currency
+	for _, txn := range ts {
+		for _, fee := range txn.MinerFees {
+			fees = fees.Add(fee)
+		}
+	}
+	return fees
+}
+
+// conflictingSets returns the sets in the pool which share an object with the
+// provided transactions. These are the sets which spend the same outputs or
+// revise the same contracts as well as the sets which create outputs that the
+// transactions spend.
+func (tp *TransactionPool) conflictingSets(ts []types.Transaction) map[modules.TransactionSetID]struct{} {
+	conflicts := make(map[modules.TransactionSetID]struct{})
+	for _, oid := range relatedObjectIDs(ts) {
+		if conflict, exists := tp.knownObjects[oid]; exists {
+			conflicts[conflict] = struct{}{}
+		}
+	}
+	return conflicts
+}
+
+// removeTransactionSet removes a transaction set and the objects it is known
+// for from the pool.
+func (tp *TransactionPool) removeTransactionSet(setID modules.TransactionSetID) {
+	set := tp.transactionSets[setID]
+	oids := relatedObjectIDs(set)
+	if cc, exists := tp.transactionSetDiffs[setID]; exists {
+		for _, diff := range cc.SiacoinOutputDiffs {
+			oids = append(oids, ObjectID(diff.ID))
+		}
+		for _, diff := range cc.FileContractDiffs {
+			oids = append(oids, ObjectID(diff.ID))
+		}
+		for _, diff := range cc.SiafundOutputDiffs {
+			oids = append(oids, ObjectID(diff.ID))
+		}
+	}
+	for _, oid := range oids {
+		if tp.knownObjects[oid] == setID {
+			delete(tp.knownObjects, oid)
+		}
+	}
+	for _, txn := range set {
+		delete(tp.transactionHeights, txn.ID())
+	}
+	tp.transactionListSize -= len(encoding.Marshal(set))
+	delete(tp.transactionSets, setID)
+	delete(tp.transactionSetDiffs, setID)
+}
+
+// replaceTransactionSet replaces the sets in the pool which conflict with the
+// provided set. The replacement has to pay a higher fee per byte than every
+// set it replaces and its fees have to cover the fees of the replaced sets
+// plus its own size at the minimum fee rate. The replacement needs to be valid
+// without the replaced sets, which means that it has to contain any
+// unconfirmed parents it depends on.
+func (tp *TransactionPool) replaceTransactionSet(ts []types.Transaction, txnFn func([]types.Transaction) (modules.ConsensusChange, error)) error {
+	if len(ts) == 0 {
+		return errEmptySet
+	}
+	// Remove all transactions that have been confirmed in the transaction set.
+	oldTS := ts
+	ts = []types.Transaction{}
+	for _, txn := range oldTS {
+		if !tp.transactionConfirmed(tp.dbTx, txn.ID()) {
+			ts = append(ts, txn)
+		}
+	}
+	if len(ts) == 0 {
+		return modules.ErrDuplicateTransactionSet
+	}
+	setSize, err := tp.checkTransactionSetComposition(ts)
+	if err != nil {
+		return err
+	}
+	conflicts := tp.conflictingSets(ts)
+	if len(conflicts) == 0 {
+		return errNothingToReplace
+	}
+
+	// Check the fees against the replaced sets.
+	feeRate := modules.CalculateFee(ts)
+	fees := setFees(ts)
+	var replacedFees types.Currency
+	var replacedTxns int
+	for conflict := range conflicts {
+		set := tp.transactionSets[conflict]
+		if feeRate.Cmp(modules.CalculateFee(set)) <= 0 {
+			return errors.AddContext(errReplacementFeeTooLow, "fee per byte needs to be higher than the fee per byte of the replaced sets")
+		}
+		replacedFees = replacedFees.Add(setFees(set))
+		replacedTxns += len(set)
+	}
+	if replacedTxns > maxReplacedTransactions {
+		return errTooManyReplacements
+	}
+	minFeeRate, _ := tp.feeEstimation()
+	if extendFeeRate := tp.requiredFeesToExtendTpool(); extendFeeRate.Cmp(minFeeRate) > 0 {
+		minFeeRate = extendFeeRate
+	}
+	if fees.Cmp(replacedFees.Add(minFeeRate.Mul64(setSize))) < 0 {
+		return errors.AddContext(errReplacementFeeTooLow, "fees need to cover the fees of the replaced sets and the size of the replacement")
+	}
+
+	// Check that the replacement is valid on its own.
+	cc, err := txnFn(ts)
+	if err != nil {
+		return modules.NewConsensusConflict("replacement transaction set is invalid: " + err.Error())
+	}
+
+	// Replace the conflicting sets.
+	for conflict := range conflicts {
+		tp.removeTransactionSet(conflict)
+	}
+	setID := modules.TransactionSetID(crypto.HashObject(ts))
+	tp.transactionSets[setID] = ts
+	for _, oid := range relatedObjectIDs(ts) {
+		tp.knownObjects[oid] = setID
+	}
+	tp.transactionSetDiffs[setID] = &cc
+	tp.transactionListSize += len(encoding.Marshal(ts))
+	for _, txn := range ts {
+		tp.transactionHeights[txn.ID()] = tp.blockHeight
+	}
+	tp.log.Printf("Replaced %v transaction sets with transaction set %v", len(conflicts), setID)
+	return nil
+}
+
+// ReplaceTransactionSet replaces the transaction sets in the pool which
+// conflict with the provided set, e.g. because they spend the same outputs. It
+// allows for replacing a set which is stuck in the pool with a version paying
+// higher fees. If the replacement is accepted, it is relayed to the pool's
+// peers.
+func (tp *TransactionPool) ReplaceTransactionSet(ts []types.Transaction) error {
+	if err := tp.tg.Add(); err != nil {
+		return err
+	}
+	defer tp.tg.Done()
+
+	// assert on consensus set to get special method
+	cs, ok := tp.consensusSet.(interface {
+		LockedTryTransactionSet(fn func(func(txns []types.Transaction) (modules.ConsensusChange, error)) error) error
+	})
+	if !ok {
+		return errors.New("consensus set does not support LockedTryTransactionSet method")
+	}
+	err := cs.LockedTryTransactionSet(func(txnFn func(txns []types.Transaction) (modules.ConsensusChange, error)) error {
+		tp.mu.Lock()
+		defer tp.mu.Unlock()
+		if err := tp.replaceTransactionSet(ts, txnFn); err != nil {
+			return err
+		}
+		tp.updateSubscribersTransactions()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	go tp.gateway.Broadcast("RelayTransactionSet", ts, tp.gateway.Peers())
+	return nil
+}
+
+// EvictTransactionSet removes the transaction set containing the transaction
+// with the provided id from the pool and returns the ids of all evicted
+// transactions. The eviction only affects the local pool, the set might still
+// be confirmed if other nodes know about it.
+func (tp *TransactionPool) EvictTransactionSet(id types.TransactionID) ([]types.TransactionID, error) {
+	if err := tp.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer tp.tg.Done()
+	tp.mu.Lock()
+	defer tp.mu.Unlock()
+
+	for setID, set := range tp.transactionSets {
+		for _, txn := range set {
+			if txn.ID() != id {
+				continue
+			}
+			evicted := make([]types.TransactionID, 0, len(set))
+			for _, txn := range set {
+				evicted = append(evicted, txn.ID())
+			}
+			tp.removeTransactionSet(setID)
+			tp.updateSubscribersTransactions()
+			tp.log.Printf("Evicted transaction set %v containing transaction %v", setID, id)
+			return evicted, nil
+		}
+	}
+	return nil, errTransactionNotFound
+}
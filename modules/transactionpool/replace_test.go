@@ -0,0 +1,103 @@
+package transactionpool
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+	"go.sia.tech/siad/types"
+)
+
+// TestReplaceTransactionSet tests that transaction sets in the pool can be
+// replaced with conflicting sets paying higher fees and that sets can be
+// evicted from the pool.
+func TestReplaceTransactionSet(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	tpt, err := createTpoolTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tpt.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Create an output which can be spent by the sets.
+	fund := types.SiacoinPrecision.Mul64(100)
+	txns, err := tpt.wallet.SendSiacoins(fund, types.UnlockConditions{}.UnlockHash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tpt.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	fundingTxn := txns[len(txns)-1]
+	var outputID types.SiacoinOutputID
+	for i, sco := range fundingTxn.SiacoinOutputs {
+		if sco.Value.Equals(fund) {
+			outputID = fundingTxn.SiacoinOutputID(uint64(i))
+		}
+	}
+
+	// graph is a helper to create a set spending the output with the
+	// provided fee.
+	_, max := tpt.tpool.FeeEstimation()
+	graph := func(fee types.Currency) []types.Transaction {
+		ts, err := types.TransactionGraph(outputID, []types.TransactionGraphEdge{{
+			Dest:   1,
+			Fee:    fee,
+			Source: 0,
+			Value:  fund.Sub(fee),
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ts
+	}
+	original := graph(max.Mul64(1000))
+	if err := tpt.tpool.AcceptTransactionSet(original); err != nil {
+		t.Fatal(err)
+	}
+
+	// A set paying barely more fees is rejected.
+	if err := tpt.tpool.ReplaceTransactionSet(graph(max.Mul64(1000).Add64(1))); !errors.Contains(err, errReplacementFeeTooLow) {
+		t.Fatal("expected replacement to be rejected", err)
+	}
+
+	// A set paying higher fees replaces the original.
+	replacement := graph(max.Mul64(3000))
+	if err := tpt.tpool.ReplaceTransactionSet(replacement); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, exists := tpt.tpool.Transaction(original[0].ID()); exists {
+		t.Fatal("original set should have been replaced")
+	}
+	if _, _, exists := tpt.tpool.Transaction(replacement[0].ID()); !exists {
+		t.Fatal("replacement should be in the pool")
+	}
+	if len(tpt.tpool.TransactionList()) != len(replacement) {
+		t.Fatal("wrong number of transactions in the pool", len(tpt.tpool.TransactionList()))
+	}
+
+	// Evict the replacement.
+	evicted, err := tpt.tpool.EvictTransactionSet(replacement[0].ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evicted) != len(replacement) || evicted[0] != replacement[0].ID() {
+		t.Fatal("wrong transactions evicted", evicted)
+	}
+	if len(tpt.tpool.TransactionList()) != 0 {
+		t.Fatal("pool should be empty")
+	}
+	if _, err := tpt.tpool.EvictTransactionSet(replacement[0].ID()); !errors.Contains(err, errTransactionNotFound) {
+		t.Fatal("expected unknown transaction to be rejected", err)
+	}
+
+	// The output can be spent again after the eviction.
+	if err := tpt.tpool.AcceptTransactionSet(original); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -59,6 +59,7 @@ type (
 		blockHeight     types.BlockHeight
 		recentMedians   []types.Currency
 		recentMedianFee types.Currency // SC per byte
+		feeSamples      []feeSample
 
 		// The consensus change index tracks how many consensus changes have
 		// been sent to the transaction pool. When a new subscriber joins the
@@ -147,7 +148,12 @@ func (tp *TransactionPool) FeeEstimation() (min, max types.Currency) {
 	defer tp.tg.Done()
 	tp.mu.Lock()
 	defer tp.mu.Unlock()
+	return tp.feeEstimation()
+}
 
+// feeEstimation returns the minimum and maximum estimated fee per transaction
+// byte.
+func (tp *TransactionPool) feeEstimation() (min, max types.Currency) {
 	// Use three methods to determine an acceptable fee. The first method looks
 	// at what fee is required to get into a block on the blockchain based on
 	// the actual fees of transactions confirmed in recent blocks. The second
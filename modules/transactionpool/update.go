@@ -330,12 +330,22 @@ func (tp *TransactionPool) ProcessConsensusChange(cc modules.ConsensusChange) {
 	// clean out transactions with no dependencies, such as arbitrary data
 	// transactions from the host.
 	txids := make(map[types.TransactionID]struct{})
-	for _, block := range cc.AppliedBlocks {
+	confirmedAt := make(map[types.TransactionID]types.BlockHeight)
+	for i, block := range cc.AppliedBlocks {
+		height := cc.BlockHeight - types.BlockHeight(len(cc.AppliedBlocks)-1-i)
 		for _, txn := range block.Transactions {
 			txids[txn.ID()] = struct{}{}
+			confirmedAt[txn.ID()] = height
 		}
 	}
 
+	// Update the fee estimator with the confirmation times of the sets which
+	// got confirmed. The samples of reverted blocks are dropped.
+	if len(cc.RevertedBlocks) > 0 {
+		tp.pruneFeeSamples(cc.BlockHeight - types.BlockHeight(len(cc.AppliedBlocks)))
+	}
+	tp.recordFeeSamples(confirmedAt, cc.BlockHeight)
+
 	// Save all of the current unconfirmed transaction sets into a list.
 	var unconfirmedSets [][]types.Transaction
 	for _, tSet := range tp.transactionSets {
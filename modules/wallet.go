@@ -27,6 +27,31 @@ const (
 	WalletDir = "wallet"
 )
 
+// The reasons for which the wallet considers an unconfirmed transaction
+// relevant.
+const (
+	// UnconfirmedReasonSpendsSiacoins indicates that the transaction spends a
+	// siacoin output of the wallet.
+	UnconfirmedReasonSpendsSiacoins = "spends wallet siacoin output"
+
+	// UnconfirmedReasonReceivesSiacoins indicates that the transaction
+	// creates a siacoin output for the wallet.
+	UnconfirmedReasonReceivesSiacoins = "creates wallet siacoin output"
+
+	// UnconfirmedReasonReceivesDust indicates that the transaction creates a
+	// siacoin output for the wallet which is below the dust threshold. Dust
+	// outputs are not counted towards the incoming siacoins.
+	UnconfirmedReasonReceivesDust = "creates wallet dust output"
+
+	// UnconfirmedReasonSpendsSiafunds indicates that the transaction spends a
+	// siafund output of the wallet.
+	UnconfirmedReasonSpendsSiafunds = "spends wallet siafund output"
+
+	// UnconfirmedReasonReceivesSiafunds indicates that the transaction
+	// creates a siafund output or a siafund claim for the wallet.
+	UnconfirmedReasonReceivesSiafunds = "creates wallet siafund output"
+)
+
 var (
 	// ErrBadEncryptionKey is returned if the incorrect encryption key to a
 	// file is provided.
@@ -46,6 +71,14 @@ var (
 	// complete the desired action.
 	ErrLowBalance = errors.New("insufficient balance")
 
+	// ErrNoExternalSigner is returned when signing is delegated to an
+	// external signer but no signer is configured.
+	ErrNoExternalSigner = errors.New("no external signer configured")
+
+	// ErrUnknownWalletAccount is returned when an account isn't known to the
+	// wallet.
+	ErrUnknownWalletAccount = errors.New("unknown wallet account")
+
 	// ErrWalletShutdown is returned when a method can't continue execution due
 	// to the wallet shutting down.
 	ErrWalletShutdown = errors.New("wallet is shutting down")
@@ -91,6 +124,38 @@ type (
 		Value          types.Currency    `json:"value"`
 	}
 
+	// WalletAccount describes an account of the wallet. Every account is
+	// derived from its own seed and its funds are kept separate from the
+	// funds of the primary seed and the other accounts.
+	WalletAccount struct {
+		Name               string `json:"name"`
+		AddressesGenerated uint64 `json:"addressesgenerated"`
+
+		ConfirmedSiacoinBalance types.Currency `json:"confirmedsiacoinbalance"`
+		SiafundBalance          types.Currency `json:"siafundbalance"`
+	}
+
+	// ExternalAddress is an address of the wallet whose key is held by an
+	// external signer. KeyIndex is the index of the key within the signer.
+	ExternalAddress struct {
+		KeyIndex         uint64                 `json:"keyindex"`
+		UnlockConditions types.UnlockConditions `json:"unlockconditions"`
+	}
+
+	// UnconfirmedTransactionBalance describes how a single unconfirmed
+	// transaction contributes to the unconfirmed balance of the wallet.
+	UnconfirmedTransactionBalance struct {
+		TransactionID    types.TransactionID `json:"transactionid"`
+		OutgoingSiacoins types.Currency      `json:"outgoingsiacoins"`
+		IncomingSiacoins types.Currency      `json:"incomingsiacoins"`
+
+		// Addresses are the wallet addresses involved in the transaction.
+		Addresses []types.UnlockHash `json:"addresses"`
+
+		// Reasons lists why the wallet considers the transaction relevant.
+		Reasons []string `json:"reasons"`
+	}
+
 	// A ProcessedTransaction is a transaction that has been processed into
 	// explicit inputs and outputs and tagged with some header data such as
 	// confirmation height + timestamp.
@@ -148,6 +213,15 @@ type (
 		// transaction failed.
 		FundSiacoins(amount types.Currency) error
 
+		// FundSiacoinsFromOutput will add a siacoin input which spends the
+		// provided output of the wallet to the transaction. The output is
+		// marked as spent to prevent the wallet from using it for other
+		// transactions. Its unconfirmed parents are not added to the
+		// transaction but are returned by 'UnconfirmedParents' once they are
+		// in the transaction pool. The siacoin input will not be signed until
+		// 'Sign' is called on the transaction builder.
+		FundSiacoinsFromOutput(id types.SiacoinOutputID, output types.SiacoinOutput) error
+
 		// FundSiafunds will add a siafund input of exactly 'amount' to the
 		// transaction. A parent transaction may be needed to achieve an input
 		// with the correct value. The siafund input will not be signed until
@@ -400,6 +474,10 @@ type (
 		// Signature fields of each TransactionSignature referenced by toSign.
 		SignTransaction(txn *types.Transaction, toSign []crypto.Hash) error
 
+		// SignPartiallySignedTransaction adds the signatures of all the keys
+		// known to the wallet which can still sign an input of psst.
+		SignPartiallySignedTransaction(psst *PartiallySignedTransaction) error
+
 		// SweepSeed scans the blockchain for outputs generated from seed and
 		// creates a transaction that transfers them to the wallet. Note that
 		// this incurs a transaction fee. It returns the total value of the
@@ -408,6 +486,79 @@ type (
 		SweepSeed(seed Seed) (coins, funds types.Currency, err error)
 	}
 
+	// AccountManager manages the accounts of the wallet. Accounts allow for
+	// separating funds, e.g. host income and renter spending, within a single
+	// wallet.
+	AccountManager interface {
+		// Accounts returns the accounts of the wallet together with their
+		// confirmed balances.
+		Accounts() ([]WalletAccount, error)
+
+		// AccountAddress returns a new address of the account with the given
+		// name.
+		AccountAddress(name string) (types.UnlockConditions, error)
+
+		// AccountSendSiacoins sends siacoins from the account with the given
+		// name to an address. Only outputs of the account are used to fund
+		// the transaction and the change is returned to the account.
+		AccountSendSiacoins(name string, amount types.Currency, dest types.UnlockHash) ([]types.Transaction, error)
+
+		// CreateAccount creates a new account with a random seed and returns
+		// the seed. The master key is used to encrypt the seed before saving
+		// it to disk.
+		CreateAccount(masterKey crypto.CipherKey, name string) (Seed, error)
+
+		// LoadAccount creates a new account from an existing seed. The
+		// blockchain is rescanned to find the outputs of the seed.
+		LoadAccount(masterKey crypto.CipherKey, name string, seed Seed) error
+	}
+
+	// ExternalSigner signs transactions using keys which never leave the
+	// signer, e.g. a Ledger hardware wallet. The keys are referenced by their
+	// index within the signer. Calls might block until the user confirmed
+	// them on the device.
+	ExternalSigner interface {
+		// PublicKey returns the public key with the given index.
+		PublicKey(keyIndex uint64) (types.SiaPublicKey, error)
+
+		// VerifyAddress displays the standard address of the key with the
+		// given index on the signer for the user to verify and returns it.
+		VerifyAddress(keyIndex uint64) (types.UnlockHash, error)
+
+		// SignTransaction returns the signature of the key with the given
+		// index for the transaction signature at sigIndex. The height
+		// determines the replay protection of the signature. The signer is
+		// expected to show the outputs of the transaction to the user before
+		// signing.
+		SignTransaction(txn types.Transaction, sigIndex uint64, keyIndex uint64, height types.BlockHeight) (crypto.Signature, error)
+	}
+
+	// ExternalSignerManager delegates signing to an external signer. The
+	// wallet tracks the addresses of the signer and enforces its spend
+	// policy before and after a transaction is passed to the signer, so a
+	// compromised signer can't sign transactions the wallet wouldn't sign.
+	ExternalSignerManager interface {
+		// SetExternalSigner sets the signer transactions are delegated to.
+		// Passing nil removes the signer.
+		SetExternalSigner(signer ExternalSigner)
+
+		// ExternalAddresses returns the addresses of the external signer
+		// which are tracked by the wallet.
+		ExternalAddresses() ([]ExternalAddress, error)
+
+		// AddExternalAddress starts tracking the address of the signer's key
+		// with the given index. If verify is set, the address is displayed
+		// on the signer for the user to verify. The unused flag works like
+		// the one of AddWatchAddresses.
+		AddExternalAddress(keyIndex uint64, verify, unused bool) (ExternalAddress, error)
+
+		// ExternalSignTransaction checks the transaction against the spend
+		// policy of the wallet and has the external signer sign the inputs
+		// referenced by toSign. If toSign is empty, all inputs spending
+		// outputs of external addresses are signed.
+		ExternalSignTransaction(txn *types.Transaction, toSign []crypto.Hash) error
+	}
+
 	// SiacoinSenderMulti is the minimal interface for an object that can send
 	// money to multiple siacoin outputs at once.
 	SiacoinSenderMulti interface {
@@ -419,8 +570,10 @@ type (
 	// encrypted using a user-specified password. Common addresses are all
 	// derived from a single address seed.
 	Wallet interface {
+		AccountManager
 		Alerter
 		EncryptionManager
+		ExternalSignerManager
 		KeyManager
 
 		// AddUnlockConditions adds a set of UnlockConditions to the wallet database.
@@ -438,7 +591,8 @@ type (
 
 		// ConfirmedBalance returns the confirmed balance of the wallet, minus
 		// any outgoing transactions. ConfirmedBalance will include unconfirmed
-		// refund transactions.
+		// refund transactions. The funds of the wallet's accounts are not
+		// included.
 		ConfirmedBalance() (siacoinBalance types.Currency, siafundBalance types.Currency, siacoinClaimBalance types.Currency, err error)
 
 		// UnconfirmedBalance returns the unconfirmed balance of the wallet.
@@ -448,6 +602,10 @@ type (
 		// not considered in the unconfirmed balance.
 		UnconfirmedBalance() (outgoingSiacoins types.Currency, incomingSiacoins types.Currency, err error)
 
+		// UnconfirmedBalanceBreakdown returns the contribution of every
+		// unconfirmed transaction to the unconfirmed balance of the wallet.
+		UnconfirmedBalanceBreakdown() ([]UnconfirmedTransactionBalance, error)
+
 		// Height returns the wallet's internal processed consensus height
 		Height() (types.BlockHeight, error)
 
@@ -0,0 +1,334 @@
+package wallet
+
+import (
+	"sort"
+
+	"gitlab.com/NebulousLabs/bolt"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+const (
+	// maxAccountNameLen is the maximum length of the name of an account.
+	maxAccountNameLen = 64
+)
+
+var (
+	errAccountExists      = errors.New("an account with that name already exists")
+	errInvalidAccountName = errors.New("account names must consist of 1-64 letters, digits, '-' or '_'")
+)
+
+type (
+	// accountFile stores the encrypted seed of an account on disk together
+	// with the number of addresses handed out by the account.
+	accountFile struct {
+		Name     string
+		SeedFile seedFile
+		Progress uint64
+	}
+
+	// walletAccount is the in-memory representation of an account. progress
+	// is the number of addresses handed out by the account and generated is
+	// the number of keys which were integrated into the wallet.
+	walletAccount struct {
+		seed      modules.Seed
+		progress  uint64
+		generated uint64
+	}
+)
+
+// validateAccountName checks that the name of an account can safely be used
+// as part of a URL.
+func validateAccountName(name string) error {
+	if len(name) == 0 || len(name) > maxAccountNameLen {
+		return errInvalidAccountName
+	}
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z') && !(c >= 'A' && c <= 'Z') && !(c >= '0' && c <= '9') && c != '-' && c != '_' {
+			return errInvalidAccountName
+		}
+	}
+	return nil
+}
+
+// integrateAccount generates the keys of an account up to its progress plus
+// the lookahead and loads them into the wallet.
+func (w *Wallet) integrateAccount(name string, acc *walletAccount) {
+	target := acc.progress + accountLookahead
+	if target <= acc.generated {
+		return
+	}
+	for _, sk := range generateKeys(acc.seed, acc.generated, target-acc.generated) {
+		uh := sk.UnlockConditions.UnlockHash()
+		w.keys[uh] = sk
+		w.accountAddrs[uh] = name
+	}
+	acc.generated = target
+}
+
+// addAccount adds a new account to the wallet and persists it.
+func (w *Wallet) addAccount(masterKey crypto.CipherKey, name string, seed modules.Seed, progress uint64) error {
+	if _, exists := w.accounts[name]; exists {
+		return errAccountExists
+	}
+	if w.isKnownSeed(seed) {
+		return errKnownSeed
+	}
+	if err := checkMasterKey(w.dbTx, masterKey); err != nil {
+		return err
+	}
+	afs, err := dbGetAccountFiles(w.dbTx)
+	if err != nil {
+		return err
+	}
+	afs = append(afs, accountFile{
+		Name:     name,
+		SeedFile: createSeedFile(masterKey, seed),
+		Progress: progress,
+	})
+	if err := dbPutAccountFiles(w.dbTx, afs); err != nil {
+		return err
+	}
+	acc := &walletAccount{
+		seed:     seed,
+		progress: progress,
+	}
+	w.integrateAccount(name, acc)
+	w.accounts[name] = acc
+	return nil
+}
+
+// nextAccountAddress fetches the next address of the account with the given
+// name. The primary seed is used if the name is empty.
+func (w *Wallet) nextAccountAddress(tx *bolt.Tx, name string) (types.UnlockConditions, error) {
+	if name == "" {
+		return w.nextPrimarySeedAddress(tx)
+	}
+	if !w.unlocked {
+		return types.UnlockConditions{}, modules.ErrLockedWallet
+	}
+	acc, exists := w.accounts[name]
+	if !exists {
+		return types.UnlockConditions{}, modules.ErrUnknownWalletAccount
+	}
+
+	// Persist the new progress before handing out the address.
+	afs, err := dbGetAccountFiles(tx)
+	if err != nil {
+		return types.UnlockConditions{}, err
+	}
+	for i := range afs {
+		if afs[i].Name == name {
+			afs[i].Progress = acc.progress + 1
+		}
+	}
+	if err := dbPutAccountFiles(tx, afs); err != nil {
+		return types.UnlockConditions{}, err
+	}
+	uc := generateSpendableKey(acc.seed, acc.progress).UnlockConditions
+	acc.progress++
+	w.integrateAccount(name, acc)
+	return uc, nil
+}
+
+// managedStartAccountTransaction starts a new transaction which is funded by
+// the account with the given name. The primary seed is used if the name is
+// empty.
+func (w *Wallet) managedStartAccountTransaction(name string) *transactionBuilder {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	tb := w.registerTransaction(types.Transaction{}, nil)
+	tb.account = name
+	return tb
+}
+
+// Accounts returns the accounts of the wallet together with their confirmed
+// balances.
+func (w *Wallet) Accounts() ([]modules.WalletAccount, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	// dustThreshold has to be obtained separate from the lock
+	dustThreshold, err := w.DustThreshold()
+	if err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	afs, err := dbGetAccountFiles(w.dbTx)
+	if err != nil {
+		return nil, err
+	}
+	accounts := make([]modules.WalletAccount, 0, len(afs))
+	indices := make(map[string]int, len(afs))
+	for _, af := range afs {
+		indices[af.Name] = len(accounts)
+		accounts = append(accounts, modules.WalletAccount{
+			Name:               af.Name,
+			AddressesGenerated: af.Progress,
+		})
+	}
+
+	// Add up the balances.
+	err = dbForEachSiacoinOutput(w.dbTx, func(_ types.SiacoinOutputID, sco types.SiacoinOutput) {
+		i, exists := indices[w.accountAddrs[sco.UnlockHash]]
+		if exists && sco.Value.Cmp(dustThreshold) > 0 {
+			accounts[i].ConfirmedSiacoinBalance = accounts[i].ConfirmedSiacoinBalance.Add(sco.Value)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = dbForEachSiafundOutput(w.dbTx, func(_ types.SiafundOutputID, sfo types.SiafundOutput) {
+		if i, exists := indices[w.accountAddrs[sfo.UnlockHash]]; exists {
+			accounts[i].SiafundBalance = accounts[i].SiafundBalance.Add(sfo.Value)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].Name < accounts[j].Name
+	})
+	return accounts, nil
+}
+
+// AccountAddress returns a new address of the account with the given name.
+func (w *Wallet) AccountAddress(name string) (types.UnlockConditions, error) {
+	if err := w.tg.Add(); err != nil {
+		return types.UnlockConditions{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if name == "" {
+		return types.UnlockConditions{}, modules.ErrUnknownWalletAccount
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	uc, err := w.nextAccountAddress(w.dbTx, name)
+	err = errors.Compose(err, w.syncDB())
+	if err != nil {
+		return types.UnlockConditions{}, err
+	}
+	return uc, nil
+}
+
+// AccountSendSiacoins sends siacoins from the account with the given name to
+// an address. Only outputs of the account are used to fund the transaction
+// and the change is returned to the account. Fees are added to the amount
+// sent.
+func (w *Wallet) AccountSendSiacoins(name string, amount types.Currency, dest types.UnlockHash) ([]types.Transaction, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	w.mu.RLock()
+	_, exists := w.accounts[name]
+	unlocked := w.unlocked
+	w.mu.RUnlock()
+	if !unlocked {
+		return nil, modules.ErrLockedWallet
+	}
+	if !exists {
+		return nil, modules.ErrUnknownWalletAccount
+	}
+
+	fee := w.tpool.EstimateFee(sendFeeTargetBlocks).FeePerByte
+	fee = fee.Mul64(estimatedTransactionSize)
+	return w.managedSendSiacoins(name, amount, fee, dest)
+}
+
+// CreateAccount creates a new account with a random seed and returns the
+// seed. Since none of the account's addresses can have been used yet, no
+// rescan is necessary.
+func (w *Wallet) CreateAccount(masterKey crypto.CipherKey, name string) (modules.Seed, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.Seed{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if err := validateAccountName(name); err != nil {
+		return modules.Seed{}, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return modules.Seed{}, modules.ErrLockedWallet
+	}
+	var seed modules.Seed
+	fastrand.Read(seed[:])
+	err := w.addAccount(masterKey, name, seed, 0)
+	err = errors.Compose(err, w.syncDB())
+	if err != nil {
+		return modules.Seed{}, err
+	}
+	w.log.Printf("INFO: created account %v", name)
+	return seed, nil
+}
+
+// LoadAccount creates a new account from an existing seed. The blockchain is
+// scanned to determine the progress of the seed and rescanned afterwards to
+// find the outputs of the account.
+func (w *Wallet) LoadAccount(masterKey crypto.CipherKey, name string, seed modules.Seed) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if err := validateAccountName(name); err != nil {
+		return err
+	}
+
+	if !w.cs.Synced() {
+		return errors.New("cannot load account until blockchain is synced")
+	}
+
+	if !w.scanLock.TryLock() {
+		return errScanInProgress
+	}
+	defer w.scanLock.Unlock()
+
+	w.mu.RLock()
+	unlocked := w.unlocked
+	_, exists := w.accounts[name]
+	known := w.isKnownSeed(seed)
+	w.mu.RUnlock()
+	if !unlocked {
+		return modules.ErrLockedWallet
+	} else if exists {
+		return errAccountExists
+	} else if known {
+		return errKnownSeed
+	}
+
+	// scan blockchain to determine how many keys to generate for the seed
+	s := newSeedScanner(seed, w.log)
+	if err := s.scan(w.cs, w.tg.StopChan()); err != nil {
+		return err
+	}
+	progress := s.largestIndexSeen + 1
+	w.log.Printf("INFO: found key index %v in blockchain. Setting progress of account %v to %v", s.largestIndexSeen, name, progress)
+
+	err := func() error {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if err := w.addAccount(masterKey, name, seed, progress); err != nil {
+			return err
+		}
+		return w.resetHistory()
+	}()
+	if err != nil {
+		return err
+	}
+
+	// rescan the blockchain
+	return w.managedRescan()
+}
@@ -0,0 +1,163 @@
+package wallet
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// TestValidateAccountName is a unit test for validateAccountName.
+func TestValidateAccountName(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{"hosting", true},
+		{"renter_2-b", true},
+		{"", false},
+		{"a/b", false},
+		{"a b", false},
+		{string(make([]byte, maxAccountNameLen+1)), false},
+	}
+	for _, test := range tests {
+		err := validateAccountName(test.name)
+		if test.valid && err != nil {
+			t.Fatal(test.name, err)
+		} else if !test.valid && !errors.Contains(err, errInvalidAccountName) {
+			t.Fatal(test.name, "expected errInvalidAccountName", err)
+		}
+	}
+}
+
+// TestWalletAccounts tests that the funds of an account are kept separate
+// from the funds of the primary seed.
+func TestWalletAccounts(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	w := wt.wallet
+
+	// mine is a helper to confirm the transactions in the transaction pool.
+	mine := func() {
+		t.Helper()
+		b, _ := wt.miner.FindBlock()
+		if err := wt.cs.AcceptBlock(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// account is a helper to fetch the account with the given name.
+	account := func(name string) modules.WalletAccount {
+		t.Helper()
+		accounts, err := w.Accounts()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, acc := range accounts {
+			if acc.Name == name {
+				return acc
+			}
+		}
+		t.Fatal("account not found", name)
+		return modules.WalletAccount{}
+	}
+
+	// Create an account. Names and seeds must be unique.
+	seed, err := w.CreateAccount(wt.walletMasterKey, "hosting")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.CreateAccount(wt.walletMasterKey, "hosting"); !errors.Contains(err, errAccountExists) {
+		t.Fatal("expected errAccountExists but got", err)
+	}
+	if err := w.LoadSeed(wt.walletMasterKey, seed); !errors.Contains(err, errKnownSeed) {
+		t.Fatal("expected errKnownSeed but got", err)
+	}
+	if _, err := w.CreateAccount(crypto.GenerateSiaKey(crypto.TypeDefaultWallet), "renting"); !errors.Contains(err, modules.ErrBadEncryptionKey) {
+		t.Fatal("expected ErrBadEncryptionKey but got", err)
+	}
+	if _, err := w.AccountAddress("renting"); !errors.Contains(err, modules.ErrUnknownWalletAccount) {
+		t.Fatal("expected ErrUnknownWalletAccount but got", err)
+	}
+
+	// Fund the account from the primary seed.
+	uc, err := w.AccountAddress("hosting")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fund := types.SiacoinPrecision.Mul64(100)
+	if _, err := w.SendSiacoins(fund, uc.UnlockHash()); err != nil {
+		t.Fatal(err)
+	}
+	mine()
+	acc := account("hosting")
+	if !acc.ConfirmedSiacoinBalance.Equals(fund) || acc.AddressesGenerated != 1 {
+		t.Fatal("unexpected account", acc)
+	}
+
+	// Send coins from the account. The fee is paid by the account and the
+	// change is returned to it.
+	primaryBalance, _, _, err := w.ConfirmedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := types.SiacoinPrecision.Mul64(10)
+	_, fee := wt.tpool.FeeEstimation()
+	fee = fee.Mul64(estimatedTransactionSize)
+	if _, err := w.AccountSendSiacoins("hosting", sent, types.UnlockHash{}); err != nil {
+		t.Fatal(err)
+	}
+	mine()
+	acc = account("hosting")
+	if !acc.ConfirmedSiacoinBalance.Equals(fund.Sub(sent).Sub(fee)) {
+		t.Fatal("wrong account balance", acc.ConfirmedSiacoinBalance, fund.Sub(sent).Sub(fee))
+	}
+	newPrimaryBalance, _, _, err := w.ConfirmedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newPrimaryBalance.Cmp(primaryBalance) < 0 {
+		t.Fatal("primary balance was used to fund the account's transaction")
+	}
+
+	// The account can't spend more than its own funds.
+	if _, err := w.AccountSendSiacoins("hosting", fund, types.UnlockHash{}); err == nil {
+		t.Fatal("expected sending more than the account's balance to fail")
+	}
+
+	// Change the key and unlock the wallet again. The account should still
+	// be known.
+	newKey := crypto.GenerateSiaKey(crypto.TypeDefaultWallet)
+	if err := w.ChangeKey(wt.walletMasterKey, newKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Unlock(newKey); err != nil {
+		t.Fatal(err)
+	}
+	progress := account("hosting").AddressesGenerated
+	uc2, err := w.AccountAddress("hosting")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uc2.UnlockHash() == uc.UnlockHash() || account("hosting").AddressesGenerated != progress+1 {
+		t.Fatal("account progress wasn't persisted")
+	}
+	if uc2.UnlockHash() != generateSpendableKey(seed, progress).UnlockConditions.UnlockHash() {
+		t.Fatal("address wasn't derived from the account's seed")
+	}
+}
@@ -15,9 +15,27 @@ const (
 	// defragThreshold is the number of outputs a wallet is allowed before it is
 	// defragmented.
 	defragThreshold = 50
+
+	// externalSignMaxFeeMultiplier is the multiple of the transaction pool's
+	// maximum fee estimate which a transaction signed by the external signer
+	// may pay at most.
+	externalSignMaxFeeMultiplier = 3
+
+	// sendFeeTargetBlocks is the number of blocks within which transactions
+	// sending coins should be confirmed. It determines the fee which is
+	// estimated by the transaction pool.
+	sendFeeTargetBlocks = 3
 )
 
 var (
+	// accountLookahead is the number of keys which are generated for an
+	// account in addition to the keys of the addresses it handed out.
+	accountLookahead = build.Select(build.Var{
+		Dev:      uint64(100),
+		Standard: uint64(1000),
+		Testing:  uint64(10),
+	}).(uint64)
+
 	// lookaheadBuffer together with lookaheadRescanThreshold defines the constant part
 	// of the maxLookahead
 	lookaheadBuffer = build.Select(build.Var{
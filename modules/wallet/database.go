@@ -61,10 +61,12 @@ var (
 	errNoKey = errors.New("key does not exist")
 
 	// these keys are used in bucketWallet
+	keyAccountFiles           = []byte("keyAccountFiles")
 	keyAuxiliarySeedFiles     = []byte("keyAuxiliarySeedFiles")
 	keyConsensusChange        = []byte("keyConsensusChange")
 	keyConsensusHeight        = []byte("keyConsensusHeight")
 	keyEncryptionVerification = []byte("keyEncryptionVerification")
+	keyExternalAddresses      = []byte("keyExternalAddresses")
 	keyPrimarySeedFile        = []byte("keyPrimarySeedFile")
 	keyPrimarySeedProgress    = []byte("keyPrimarySeedProgress")
 	keySiafundPool            = []byte("keySiafundPool")
@@ -77,6 +79,7 @@ var (
 // threadedDBUpdate commits the active database transaction and starts a new
 // transaction.
 func (w *Wallet) threadedDBUpdate() {
+	defer modules.RecoverPanic("wallet")
 	if err := w.tg.Add(); err != nil {
 		return
 	}
@@ -114,7 +117,7 @@ func (w *Wallet) syncDB() error {
 	}
 
 	// commit the current tx
-	err := w.dbTx.Commit()
+	err := w.db.Commit(w.dbTx)
 	if err != nil {
 		w.log.Severe("ERROR: failed to apply database update:", err)
 		err = errors.Compose(err, w.dbTx.Rollback())
@@ -129,6 +132,22 @@ func (w *Wallet) syncDB() error {
 	return nil
 }
 
+// Quiesce implements the modules.Quiescer interface. It commits the pending
+// changes to the database and blocks further commits until resume is called.
+func (w *Wallet) Quiesce() (resume func(), err error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	err = w.syncDB()
+	w.mu.Unlock()
+	if err != nil {
+		return nil, errors.AddContext(err, "failed to sync wallet database")
+	}
+	return w.db.Quiesce(), nil
+}
+
 // dbReset wipes and reinitializes a wallet database.
 func dbReset(tx *bolt.Tx) error {
 	for _, bucket := range dbBuckets {
@@ -146,7 +165,9 @@ func dbReset(tx *bolt.Tx) error {
 	wb := tx.Bucket(bucketWallet)
 	wb.Put(keySalt, fastrand.Bytes(len(walletSalt{})))
 	wb.Put(keyConsensusHeight, encoding.Marshal(uint64(0)))
+	wb.Put(keyAccountFiles, encoding.Marshal([]accountFile{}))
 	wb.Put(keyAuxiliarySeedFiles, encoding.Marshal([]seedFile{}))
+	wb.Put(keyExternalAddresses, encoding.Marshal([]modules.ExternalAddress{}))
 	wb.Put(keySpendableKeyFiles, encoding.Marshal([]spendableKeyFile{}))
 	wb.Put(keyWatchedAddrs, encoding.Marshal([]types.UnlockHash{}))
 	dbPutConsensusHeight(tx, 0)
@@ -204,6 +225,10 @@ func dbForEach(b *bolt.Bucket, fn interface{}) error {
 func dbPutSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID, output types.SiacoinOutput) error {
 	return dbPut(tx.Bucket(bucketSiacoinOutputs), id, output)
 }
+func dbGetSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID) (output types.SiacoinOutput, err error) {
+	err = dbGet(tx.Bucket(bucketSiacoinOutputs), id, &output)
+	return
+}
 func dbDeleteSiacoinOutput(tx *bolt.Tx, id types.SiacoinOutputID) error {
 	return dbDelete(tx.Bucket(bucketSiacoinOutputs), id)
 }
@@ -214,6 +239,10 @@ func dbForEachSiacoinOutput(tx *bolt.Tx, fn func(types.SiacoinOutputID, types.Si
 func dbPutSiafundOutput(tx *bolt.Tx, id types.SiafundOutputID, output types.SiafundOutput) error {
 	return dbPut(tx.Bucket(bucketSiafundOutputs), id, output)
 }
+func dbGetSiafundOutput(tx *bolt.Tx, id types.SiafundOutputID) (output types.SiafundOutput, err error) {
+	err = dbGet(tx.Bucket(bucketSiafundOutputs), id, &output)
+	return
+}
 func dbDeleteSiafundOutput(tx *bolt.Tx, id types.SiafundOutputID) error {
 	return dbDelete(tx.Bucket(bucketSiafundOutputs), id)
 }
@@ -452,6 +481,32 @@ func dbPutPrimarySeedProgress(tx *bolt.Tx, progress uint64) error {
 	return tx.Bucket(bucketWallet).Put(keyPrimarySeedProgress, encoding.Marshal(progress))
 }
 
+// dbGetAccountFiles returns the encrypted seeds and the progress of the
+// wallet's accounts.
+func dbGetAccountFiles(tx *bolt.Tx) (afs []accountFile, err error) {
+	err = encoding.Unmarshal(tx.Bucket(bucketWallet).Get(keyAccountFiles), &afs)
+	return
+}
+
+// dbPutAccountFiles stores the encrypted seeds and the progress of the
+// wallet's accounts.
+func dbPutAccountFiles(tx *bolt.Tx, afs []accountFile) error {
+	return tx.Bucket(bucketWallet).Put(keyAccountFiles, encoding.Marshal(afs))
+}
+
+// dbGetExternalAddresses returns the addresses of the external signer which
+// are tracked by the wallet.
+func dbGetExternalAddresses(tx *bolt.Tx) (addrs []modules.ExternalAddress, err error) {
+	err = encoding.Unmarshal(tx.Bucket(bucketWallet).Get(keyExternalAddresses), &addrs)
+	return
+}
+
+// dbPutExternalAddresses stores the addresses of the external signer which are
+// tracked by the wallet.
+func dbPutExternalAddresses(tx *bolt.Tx, addrs []modules.ExternalAddress) error {
+	return tx.Bucket(bucketWallet).Put(keyExternalAddresses, encoding.Marshal(addrs))
+}
+
 // dbGetConsensusChangeID returns the ID of the last ConsensusChange processed by the wallet.
 func dbGetConsensusChangeID(tx *bolt.Tx) (cc modules.ConsensusChangeID) {
 	copy(cc[:], tx.Bucket(bucketWallet).Get(keyConsensusChange))
@@ -5,6 +5,7 @@ import (
 
 	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
 )
 
@@ -30,9 +31,13 @@ func (w *Wallet) managedCreateDefragTransaction() (_ []types.Transaction, err er
 		return nil, err
 	}
 
-	// Collect a value-sorted set of siacoin outputs.
+	// Collect a value-sorted set of siacoin outputs. The outputs of accounts
+	// are skipped since the defrag transaction pays to the primary seed.
 	var so sortedOutputs
 	err = dbForEachSiacoinOutput(w.dbTx, func(scoid types.SiacoinOutputID, sco types.SiacoinOutput) {
+		if _, isAccount := w.accountAddrs[sco.UnlockHash]; isAccount {
+			return
+		}
 		if w.checkOutput(w.dbTx, consensusHeight, scoid, sco, dustThreshold) == nil {
 			so.ids = append(so.ids, scoid)
 			so.outputs = append(so.outputs, sco)
@@ -141,6 +146,7 @@ func (w *Wallet) managedCreateDefragTransaction() (_ []types.Transaction, err er
 // operation is only performed if the wallet has greater than defragThreshold
 // outputs.
 func (w *Wallet) threadedDefragWallet() {
+	defer modules.RecoverPanic("wallet")
 	// Don't defrag if it was disabled
 	w.mu.RLock()
 	disabled := w.defragDisabled
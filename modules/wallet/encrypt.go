@@ -193,6 +193,7 @@ func (w *Wallet) managedBlockingUnlock(masterKey crypto.CipherKey) (modules.Cons
 	var primarySeedFile seedFile
 	var primarySeedProgress uint64
 	var auxiliarySeedFiles []seedFile
+	var accountFiles []accountFile
 	var unseededKeyFiles []spendableKeyFile
 	var watchedAddrs []types.UnlockHash
 	err := func() error {
@@ -225,6 +226,12 @@ func (w *Wallet) managedBlockingUnlock(masterKey crypto.CipherKey) (modules.Cons
 			return err
 		}
 
+		// accountFiles
+		accountFiles, err = dbGetAccountFiles(w.dbTx)
+		if err != nil {
+			return err
+		}
+
 		// unseededKeyFiles
 		err = encoding.Unmarshal(wb.Get(keySpendableKeyFiles), &unseededKeyFiles)
 		if err != nil {
@@ -267,6 +274,20 @@ func (w *Wallet) managedBlockingUnlock(masterKey crypto.CipherKey) (modules.Cons
 			w.seeds = append(w.seeds, auxSeed)
 		}
 
+		// accountFiles
+		for _, af := range accountFiles {
+			accSeed, err := decryptSeedFile(masterKey, af.SeedFile)
+			if err != nil {
+				return err
+			}
+			acc := &walletAccount{
+				seed:     accSeed,
+				progress: af.Progress,
+			}
+			w.integrateAccount(af.Name, acc)
+			w.accounts[af.Name] = acc
+		}
+
 		// unseededKeyFiles
 		for _, uk := range unseededKeyFiles {
 			sk, err := decryptSpendableKeyFile(masterKey, uk)
@@ -379,8 +400,12 @@ func (w *Wallet) wipeSecrets() {
 	for i := range w.seeds {
 		crypto.SecureWipe(w.seeds[i][:])
 	}
+	for _, acc := range w.accounts {
+		crypto.SecureWipe(acc.seed[:])
+	}
 	crypto.SecureWipe(w.primarySeed[:])
 	w.seeds = w.seeds[:0]
+	w.accounts = make(map[string]*walletAccount)
 }
 
 // Encrypted returns whether or not the wallet has been encrypted.
@@ -453,6 +478,7 @@ func (w *Wallet) Reset() error {
 	w.keys = make(map[types.UnlockHash]spendableKey)
 	w.lookahead = make(map[types.UnlockHash]uint64)
 	w.seeds = []modules.Seed{}
+	w.accountAddrs = make(map[types.UnlockHash]string)
 	w.unconfirmedProcessedTransactions = []modules.ProcessedTransaction{}
 	w.unlocked = false
 	w.encrypted = false
@@ -620,6 +646,7 @@ func (w *Wallet) managedChangeKey(masterKey crypto.CipherKey, newKey crypto.Ciph
 	// grab the current seed files
 	var primarySeedFile seedFile
 	var auxiliarySeedFiles []seedFile
+	var accountFiles []accountFile
 	var unseededKeyFiles []spendableKeyFile
 
 	err := func() error {
@@ -646,6 +673,12 @@ func (w *Wallet) managedChangeKey(masterKey crypto.CipherKey, newKey crypto.Ciph
 			return errors.AddContext(err, "unable to decode auxiliary seed file")
 		}
 
+		// accountFiles
+		accountFiles, err = dbGetAccountFiles(w.dbTx)
+		if err != nil {
+			return errors.AddContext(err, "unable to decode account files")
+		}
+
 		// unseededKeyFiles
 		err = encoding.Unmarshal(wb.Get(keySpendableKeyFiles), &unseededKeyFiles)
 		if err != nil {
@@ -661,6 +694,7 @@ func (w *Wallet) managedChangeKey(masterKey crypto.CipherKey, newKey crypto.Ciph
 	// decrypt key files
 	var primarySeed modules.Seed
 	var auxiliarySeeds []modules.Seed
+	accountSeeds := make(map[string]modules.Seed)
 	var spendableKeys []spendableKey
 
 	primarySeed, err = decryptSeedFile(masterKey, primarySeedFile)
@@ -674,6 +708,13 @@ func (w *Wallet) managedChangeKey(masterKey crypto.CipherKey, newKey crypto.Ciph
 		}
 		auxiliarySeeds = append(auxiliarySeeds, auxSeed)
 	}
+	for _, af := range accountFiles {
+		accSeed, err := decryptSeedFile(masterKey, af.SeedFile)
+		if err != nil {
+			return errors.AddContext(err, "unable to decrypt account seed file")
+		}
+		accountSeeds[af.Name] = accSeed
+	}
 	for _, uk := range unseededKeyFiles {
 		sk, err := decryptSpendableKeyFile(masterKey, uk)
 		if err != nil {
@@ -710,6 +751,24 @@ func (w *Wallet) managedChangeKey(masterKey crypto.CipherKey, newKey crypto.Ciph
 
 		wb := w.dbTx.Bucket(bucketWallet)
 
+		// Re-encrypt the account seeds of the current account files to
+		// preserve the progress of the accounts.
+		afs, err := dbGetAccountFiles(w.dbTx)
+		if err != nil {
+			return errors.AddContext(err, "unable to decode account files")
+		}
+		for i := range afs {
+			accSeed, exists := accountSeeds[afs[i].Name]
+			if !exists {
+				return fmt.Errorf("account %v was added while changing the key", afs[i].Name)
+			}
+			afs[i].SeedFile = createSeedFile(newKey, accSeed)
+		}
+		err = dbPutAccountFiles(w.dbTx, afs)
+		if err != nil {
+			return errors.AddContext(err, "unable to put account seeds into db")
+		}
+
 		err = wb.Put(keyPrimarySeedFile, encoding.Marshal(newPrimarySeedFile))
 		if err != nil {
 			return errors.AddContext(err, "unable to put primary key into db")
@@ -0,0 +1,296 @@
+package wallet
+
+import (
+	"fmt"
+
+	"gitlab.com/NebulousLabs/encoding"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// errExternalAddressMismatch is returned if the address displayed by the
+	// external signer doesn't match the address of its public key.
+	errExternalAddressMismatch = errors.New("address displayed by the external signer doesn't match its public key")
+
+	// errExternalSignFee is returned if a transaction which should be signed
+	// by the external signer pays more fees than the spend policy allows.
+	errExternalSignFee = errors.New("transaction pays more fees than the spend policy allows")
+
+	// errExternalSignNothingToSign is returned if a transaction doesn't
+	// contain any inputs the external signer can sign.
+	errExternalSignNothingToSign = errors.New("transaction doesn't spend any outputs of external addresses")
+
+	// errInvalidExternalSignature is returned if the external signer returns
+	// a signature which doesn't verify.
+	errInvalidExternalSignature = errors.New("external signer returned an invalid signature")
+)
+
+// externalSignature describes a signature which is added to a transaction by
+// the external signer.
+type externalSignature struct {
+	sigIndex  uint64
+	keyIndex  uint64
+	publicKey crypto.PublicKey
+}
+
+// SetExternalSigner sets the signer transactions are delegated to. Passing
+// nil removes the signer.
+func (w *Wallet) SetExternalSigner(signer modules.ExternalSigner) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.externalSigner = signer
+}
+
+// ExternalAddresses returns the addresses of the external signer which are
+// tracked by the wallet.
+func (w *Wallet) ExternalAddresses() ([]modules.ExternalAddress, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return dbGetExternalAddresses(w.dbTx)
+}
+
+// AddExternalAddress starts tracking the address of the external signer's key
+// with the given index. The address is added to the watched addresses to
+// track its outputs and its unlock conditions are stored to be able to spend
+// them. If verify is set, the user needs to confirm the address on the
+// signer.
+func (w *Wallet) AddExternalAddress(keyIndex uint64, verify, unused bool) (modules.ExternalAddress, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.ExternalAddress{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	w.mu.RLock()
+	signer := w.externalSigner
+	unlocked := w.unlocked
+	w.mu.RUnlock()
+	if !unlocked {
+		return modules.ExternalAddress{}, modules.ErrLockedWallet
+	}
+	if signer == nil {
+		return modules.ExternalAddress{}, modules.ErrNoExternalSigner
+	}
+
+	// Fetch the public key and verify the address without holding the lock
+	// since the signer might wait for the user.
+	pk, err := signer.PublicKey(keyIndex)
+	if err != nil {
+		return modules.ExternalAddress{}, errors.AddContext(err, "failed to get public key from external signer")
+	}
+	if pk.Algorithm != types.SignatureEd25519 || len(pk.Key) != crypto.PublicKeySize {
+		return modules.ExternalAddress{}, fmt.Errorf("external signer returned an unsupported public key %v", pk)
+	}
+	ea := modules.ExternalAddress{
+		KeyIndex: keyIndex,
+		UnlockConditions: types.UnlockConditions{
+			PublicKeys:         []types.SiaPublicKey{pk},
+			SignaturesRequired: 1,
+		},
+	}
+	addr := ea.UnlockConditions.UnlockHash()
+	if verify {
+		displayed, err := signer.VerifyAddress(keyIndex)
+		if err != nil {
+			return modules.ExternalAddress{}, errors.AddContext(err, "failed to verify address")
+		}
+		if displayed != addr {
+			return modules.ExternalAddress{}, errExternalAddressMismatch
+		}
+	}
+
+	err = func() error {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		addrs, err := dbGetExternalAddresses(w.dbTx)
+		if err != nil {
+			return err
+		}
+		for _, existing := range addrs {
+			if existing.UnlockConditions.UnlockHash() == addr {
+				return nil
+			}
+		}
+		if err := dbPutExternalAddresses(w.dbTx, append(addrs, ea)); err != nil {
+			return err
+		}
+		if err := dbPutUnlockConditions(w.dbTx, ea.UnlockConditions); err != nil {
+			return err
+		}
+		return w.syncDB()
+	}()
+	if err != nil {
+		return modules.ExternalAddress{}, err
+	}
+
+	// Track the outputs of the address.
+	if err := w.AddWatchAddresses([]types.UnlockHash{addr}, unused); err != nil {
+		return modules.ExternalAddress{}, errors.AddContext(err, "failed to watch address")
+	}
+	return ea, nil
+}
+
+// ExternalSignTransaction checks the transaction against the spend policy of
+// the wallet and has the external signer sign the inputs referenced by
+// toSign. If toSign is empty, all inputs spending outputs of external
+// addresses are signed. Every signature returned by the signer is verified
+// before it is added to the transaction.
+func (w *Wallet) ExternalSignTransaction(txn *types.Transaction, toSign []crypto.Hash) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	_, maxFee := w.tpool.FeeEstimation()
+	w.mu.Lock()
+	signer := w.externalSigner
+	sigs, height, err := w.checkExternalSpendPolicy(*txn, toSign, maxFee)
+	w.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if signer == nil {
+		return modules.ErrNoExternalSigner
+	}
+
+	// Sign the transaction without holding the lock since the signer might
+	// wait for the user. The signer is passed a deep copy of the transaction
+	// to prevent it from modifying the transaction which is verified.
+	for _, es := range sigs {
+		var txnCopy types.Transaction
+		if err := encoding.Unmarshal(encoding.Marshal(*txn), &txnCopy); err != nil {
+			return errors.AddContext(err, "failed to copy transaction")
+		}
+		sig, err := signer.SignTransaction(txnCopy, es.sigIndex, es.keyIndex, height)
+		if err != nil {
+			return errors.AddContext(err, "external signer failed to sign transaction")
+		}
+		sigHash := txn.SigHash(int(es.sigIndex), height)
+		if err := crypto.VerifyHash(sigHash, es.publicKey, sig); err != nil {
+			return errors.Compose(errInvalidExternalSignature, err)
+		}
+		txn.TransactionSignatures[es.sigIndex].Signature = sig[:]
+	}
+	return nil
+}
+
+// checkExternalSpendPolicy checks that the external signer may sign the inputs
+// of txn referenced by toSign and returns the signatures it needs to add
+// together with the current height. The signer may only spend confirmed
+// outputs of external addresses which aren't spent by an unconfirmed
+// transaction yet, its signatures need to cover the whole transaction and the
+// transaction may not pay excessive fees.
+func (w *Wallet) checkExternalSpendPolicy(txn types.Transaction, toSign []crypto.Hash, maxFee types.Currency) ([]externalSignature, types.BlockHeight, error) {
+	if !w.unlocked {
+		return nil, 0, modules.ErrLockedWallet
+	}
+	height, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return nil, 0, err
+	}
+	addrs, err := dbGetExternalAddresses(w.dbTx)
+	if err != nil {
+		return nil, 0, err
+	}
+	externalAddrs := make(map[types.UnlockHash]modules.ExternalAddress, len(addrs))
+	for _, ea := range addrs {
+		externalAddrs[ea.UnlockConditions.UnlockHash()] = ea
+	}
+
+	// Check the fees.
+	var fees types.Currency
+	for _, fee := range txn.MinerFees {
+		fees = fees.Add(fee)
+	}
+	size := uint64(txn.MarshalSiaSize())
+	if size < estimatedTransactionSize {
+		size = estimatedTransactionSize
+	}
+	if fees.Cmp(maxFee.Mul64(size).Mul64(externalSignMaxFeeMultiplier)) > 0 {
+		return nil, 0, errExternalSignFee
+	}
+
+	// Collect the unlock conditions of the inputs.
+	inputs := make(map[crypto.Hash]types.UnlockConditions)
+	for _, sci := range txn.SiacoinInputs {
+		inputs[crypto.Hash(sci.ParentID)] = sci.UnlockConditions
+	}
+	for _, sfi := range txn.SiafundInputs {
+		inputs[crypto.Hash(sfi.ParentID)] = sfi.UnlockConditions
+	}
+	if len(toSign) == 0 {
+		for _, sci := range txn.SiacoinInputs {
+			if _, ok := externalAddrs[sci.UnlockConditions.UnlockHash()]; ok {
+				toSign = append(toSign, crypto.Hash(sci.ParentID))
+			}
+		}
+		for _, sfi := range txn.SiafundInputs {
+			if _, ok := externalAddrs[sfi.UnlockConditions.UnlockHash()]; ok {
+				toSign = append(toSign, crypto.Hash(sfi.ParentID))
+			}
+		}
+	}
+	if len(toSign) == 0 {
+		return nil, 0, errExternalSignNothingToSign
+	}
+
+	// Outputs spent by unconfirmed transactions can't be spent again.
+	pending := make(map[types.OutputID]struct{})
+	for _, pt := range w.unconfirmedProcessedTransactions {
+		for _, input := range pt.Inputs {
+			pending[input.ParentID] = struct{}{}
+		}
+	}
+
+	sigs := make([]externalSignature, 0, len(toSign))
+	for _, id := range toSign {
+		uc, ok := inputs[id]
+		if !ok {
+			return nil, 0, errors.New("toSign references IDs not present in transaction")
+		}
+		ea, ok := externalAddrs[uc.UnlockHash()]
+		if !ok {
+			return nil, 0, fmt.Errorf("input %v doesn't spend an output of an external address", id)
+		}
+		// The output needs to be a confirmed, unspent output of the address.
+		var owner types.UnlockHash
+		if sco, err := dbGetSiacoinOutput(w.dbTx, types.SiacoinOutputID(id)); err == nil {
+			owner = sco.UnlockHash
+		} else if sfo, err := dbGetSiafundOutput(w.dbTx, types.SiafundOutputID(id)); err == nil {
+			owner = sfo.UnlockHash
+		}
+		if _, spent := pending[types.OutputID(id)]; spent || owner != uc.UnlockHash() {
+			return nil, 0, fmt.Errorf("input %v doesn't spend a confirmed, unspent output", id)
+		}
+		// The signature needs to cover the whole transaction.
+		sigIndex := -1
+		for i, sig := range txn.TransactionSignatures {
+			if sig.ParentID == id {
+				sigIndex = i
+				break
+			}
+		}
+		if sigIndex == -1 {
+			return nil, 0, errors.New("toSign references signatures not present in transaction")
+		}
+		sig := txn.TransactionSignatures[sigIndex]
+		if sig.PublicKeyIndex != 0 || !sig.CoveredFields.WholeTransaction {
+			return nil, 0, fmt.Errorf("signature of input %v needs to cover the whole transaction", id)
+		}
+		var pk crypto.PublicKey
+		copy(pk[:], ea.UnlockConditions.PublicKeys[0].Key)
+		sigs = append(sigs, externalSignature{
+			sigIndex:  uint64(sigIndex),
+			keyIndex:  ea.KeyIndex,
+			publicKey: pk,
+		})
+	}
+	return sigs, height, nil
+}
@@ -0,0 +1,180 @@
+package wallet
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// testSigner is an external signer which derives its keys from a seed.
+// tamper and wrongAddress make the signer misbehave.
+type testSigner struct {
+	seed         modules.Seed
+	tamper       bool
+	wrongAddress bool
+}
+
+// key returns the key pair with the given index.
+func (ts *testSigner) key(keyIndex uint64) (crypto.SecretKey, crypto.PublicKey) {
+	var index [8]byte
+	binary.LittleEndian.PutUint64(index[:], keyIndex)
+	return crypto.GenerateKeyPairDeterministic(crypto.HashAll(ts.seed, index))
+}
+
+// PublicKey implements modules.ExternalSigner.
+func (ts *testSigner) PublicKey(keyIndex uint64) (types.SiaPublicKey, error) {
+	_, pk := ts.key(keyIndex)
+	return types.Ed25519PublicKey(pk), nil
+}
+
+// VerifyAddress implements modules.ExternalSigner.
+func (ts *testSigner) VerifyAddress(keyIndex uint64) (types.UnlockHash, error) {
+	if ts.wrongAddress {
+		keyIndex++
+	}
+	_, pk := ts.key(keyIndex)
+	uc := types.UnlockConditions{
+		PublicKeys:         []types.SiaPublicKey{types.Ed25519PublicKey(pk)},
+		SignaturesRequired: 1,
+	}
+	return uc.UnlockHash(), nil
+}
+
+// SignTransaction implements modules.ExternalSigner.
+func (ts *testSigner) SignTransaction(txn types.Transaction, sigIndex uint64, keyIndex uint64, height types.BlockHeight) (crypto.Signature, error) {
+	if ts.tamper {
+		txn.SiacoinOutputs[0].UnlockHash = types.UnlockHash{1}
+	}
+	sk, _ := ts.key(keyIndex)
+	return crypto.SignHash(txn.SigHash(int(sigIndex), height), sk), nil
+}
+
+// TestExternalSigner tests delegating the signing of transactions to an
+// external signer.
+func TestExternalSigner(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	w := wt.wallet
+
+	// Adding an address requires a signer.
+	if _, err := w.AddExternalAddress(0, true, true); !errors.Contains(err, modules.ErrNoExternalSigner) {
+		t.Fatal("expected ErrNoExternalSigner but got", err)
+	}
+	signer := &testSigner{wrongAddress: true}
+	w.SetExternalSigner(signer)
+	if _, err := w.AddExternalAddress(0, true, true); !errors.Contains(err, errExternalAddressMismatch) {
+		t.Fatal("expected errExternalAddressMismatch but got", err)
+	}
+	signer.wrongAddress = false
+	ea, err := w.AddExternalAddress(0, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addrs, err := w.ExternalAddresses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addrs) != 1 || addrs[0].UnlockConditions.UnlockHash() != ea.UnlockConditions.UnlockHash() {
+		t.Fatal("unexpected external addresses", addrs)
+	}
+	addr := ea.UnlockConditions.UnlockHash()
+
+	// Fund the address.
+	fund := types.SiacoinPrecision.Mul64(100)
+	if _, err := w.SendSiacoins(fund, addr); err != nil {
+		t.Fatal(err)
+	}
+	b, _ := wt.miner.FindBlock()
+	if err := wt.cs.AcceptBlock(b); err != nil {
+		t.Fatal(err)
+	}
+	outputs, err := w.UnspentOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parentID types.SiacoinOutputID
+	for _, o := range outputs {
+		if o.UnlockHash == addr && o.Value.Equals(fund) {
+			parentID = types.SiacoinOutputID(o.ID)
+		}
+	}
+	if parentID == (types.SiacoinOutputID{}) {
+		t.Fatal("output of external address not found")
+	}
+
+	// newTxn is a helper to create a transaction which spends the output and
+	// pays the given fee.
+	newTxn := func(fee types.Currency) types.Transaction {
+		return types.Transaction{
+			SiacoinInputs: []types.SiacoinInput{{
+				ParentID:         parentID,
+				UnlockConditions: ea.UnlockConditions,
+			}},
+			SiacoinOutputs: []types.SiacoinOutput{{
+				Value: fund.Sub(fee),
+			}},
+			MinerFees: []types.Currency{fee},
+			TransactionSignatures: []types.TransactionSignature{{
+				ParentID:      crypto.Hash(parentID),
+				CoveredFields: types.FullCoveredFields,
+			}},
+		}
+	}
+	_, maxFee := wt.tpool.FeeEstimation()
+	fee := maxFee.Mul64(estimatedTransactionSize)
+
+	// Transactions violating the spend policy are rejected.
+	txn := newTxn(fund.Div64(2))
+	if err := w.ExternalSignTransaction(&txn, nil); !errors.Contains(err, errExternalSignFee) {
+		t.Fatal("expected errExternalSignFee but got", err)
+	}
+	txn = newTxn(fee)
+	txn.TransactionSignatures[0].CoveredFields = types.CoveredFields{SiacoinOutputs: []uint64{0}}
+	if err := w.ExternalSignTransaction(&txn, nil); err == nil {
+		t.Fatal("expected signature which doesn't cover the whole transaction to be rejected")
+	}
+	txn = newTxn(fee)
+	txn.SiacoinInputs[0].ParentID = types.SiacoinOutputID{1}
+	txn.TransactionSignatures[0].ParentID = crypto.Hash{1}
+	if err := w.ExternalSignTransaction(&txn, nil); err == nil {
+		t.Fatal("expected unknown output to be rejected")
+	}
+
+	// Signatures of a misbehaving signer are rejected.
+	signer.tamper = true
+	txn = newTxn(fee)
+	if err := w.ExternalSignTransaction(&txn, nil); !errors.Contains(err, errInvalidExternalSignature) {
+		t.Fatal("expected errInvalidExternalSignature but got", err)
+	}
+	signer.tamper = false
+
+	// Sign the transaction and submit it.
+	txn = newTxn(fee)
+	if err := w.ExternalSignTransaction(&txn, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.tpool.AcceptTransactionSet([]types.Transaction{txn}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The output can't be spent twice.
+	txn = newTxn(fee)
+	if err := w.ExternalSignTransaction(&txn, nil); err == nil {
+		t.Fatal("expected double spend to be rejected")
+	}
+}
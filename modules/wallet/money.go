@@ -31,7 +31,7 @@ func (w *Wallet) DustThreshold() (types.Currency, error) {
 }
 
 // ConfirmedBalance returns the balance of the wallet according to all of the
-// confirmed transactions.
+// confirmed transactions. The funds of the wallet's accounts are not included.
 func (w *Wallet) ConfirmedBalance() (siacoinBalance types.Currency, siafundBalance types.Currency, siafundClaimBalance types.Currency, err error) {
 	if err := w.tg.Add(); err != nil {
 		return types.ZeroCurrency, types.ZeroCurrency, types.ZeroCurrency, modules.ErrWalletShutdown
@@ -53,6 +53,9 @@ func (w *Wallet) ConfirmedBalance() (siacoinBalance types.Currency, siafundBalan
 	}
 
 	dbForEachSiacoinOutput(w.dbTx, func(_ types.SiacoinOutputID, sco types.SiacoinOutput) {
+		if _, isAccount := w.accountAddrs[sco.UnlockHash]; isAccount {
+			return
+		}
 		if sco.Value.Cmp(dustThreshold) > 0 {
 			siacoinBalance = siacoinBalance.Add(sco.Value)
 		}
@@ -63,6 +66,9 @@ func (w *Wallet) ConfirmedBalance() (siacoinBalance types.Currency, siafundBalan
 		return
 	}
 	dbForEachSiafundOutput(w.dbTx, func(_ types.SiafundOutputID, sfo types.SiafundOutput) {
+		if _, isAccount := w.accountAddrs[sfo.UnlockHash]; isAccount {
+			return
+		}
 		siafundBalance = siafundBalance.Add(sfo.Value)
 		if sfo.ClaimStart.Cmp(siafundPool) > 0 {
 			// Skip claims larger than the siafund pool. This should only
@@ -94,18 +100,88 @@ func (w *Wallet) UnconfirmedBalance() (outgoingSiacoins types.Currency, incoming
 	defer w.mu.Unlock()
 
 	for _, upt := range w.unconfirmedProcessedTransactions {
-		for _, input := range upt.Inputs {
-			if input.FundType == types.SpecifierSiacoinInput && input.WalletAddress {
-				outgoingSiacoins = outgoingSiacoins.Add(input.Value)
-			}
+		utb := unconfirmedTransactionBalance(upt, dustThreshold)
+		outgoingSiacoins = outgoingSiacoins.Add(utb.OutgoingSiacoins)
+		incomingSiacoins = incomingSiacoins.Add(utb.IncomingSiacoins)
+	}
+	return
+}
+
+// UnconfirmedBalanceBreakdown returns the contribution of every unconfirmed
+// transaction to the unconfirmed balance of the wallet together with the
+// wallet addresses involved and the reasons why the wallet considers the
+// transaction relevant.
+func (w *Wallet) UnconfirmedBalanceBreakdown() ([]modules.UnconfirmedTransactionBalance, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	// dustThreshold has to be obtained separate from the lock
+	dustThreshold, err := w.DustThreshold()
+	if err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	utbs := make([]modules.UnconfirmedTransactionBalance, 0, len(w.unconfirmedProcessedTransactions))
+	for _, upt := range w.unconfirmedProcessedTransactions {
+		utbs = append(utbs, unconfirmedTransactionBalance(upt, dustThreshold))
+	}
+	return utbs, nil
+}
+
+// unconfirmedTransactionBalance computes the contribution of an unconfirmed
+// transaction to the unconfirmed balance of the wallet.
+func unconfirmedTransactionBalance(upt modules.ProcessedTransaction, dustThreshold types.Currency) modules.UnconfirmedTransactionBalance {
+	utb := modules.UnconfirmedTransactionBalance{
+		TransactionID: upt.TransactionID,
+		Addresses:     []types.UnlockHash{},
+		Reasons:       []string{},
+	}
+	seenAddrs := make(map[types.UnlockHash]struct{})
+	seenReasons := make(map[string]struct{})
+	addRelevance := func(addr types.UnlockHash, reason string) {
+		if _, seen := seenAddrs[addr]; !seen {
+			seenAddrs[addr] = struct{}{}
+			utb.Addresses = append(utb.Addresses, addr)
+		}
+		if _, seen := seenReasons[reason]; !seen {
+			seenReasons[reason] = struct{}{}
+			utb.Reasons = append(utb.Reasons, reason)
+		}
+	}
+	for _, input := range upt.Inputs {
+		if !input.WalletAddress {
+			continue
 		}
-		for _, output := range upt.Outputs {
-			if output.FundType == types.SpecifierSiacoinOutput && output.WalletAddress && output.Value.Cmp(dustThreshold) > 0 {
-				incomingSiacoins = incomingSiacoins.Add(output.Value)
+		switch input.FundType {
+		case types.SpecifierSiacoinInput:
+			utb.OutgoingSiacoins = utb.OutgoingSiacoins.Add(input.Value)
+			addRelevance(input.RelatedAddress, modules.UnconfirmedReasonSpendsSiacoins)
+		case types.SpecifierSiafundInput:
+			addRelevance(input.RelatedAddress, modules.UnconfirmedReasonSpendsSiafunds)
+		}
+	}
+	for _, output := range upt.Outputs {
+		if !output.WalletAddress {
+			continue
+		}
+		switch output.FundType {
+		case types.SpecifierSiacoinOutput:
+			if output.Value.Cmp(dustThreshold) > 0 {
+				utb.IncomingSiacoins = utb.IncomingSiacoins.Add(output.Value)
+				addRelevance(output.RelatedAddress, modules.UnconfirmedReasonReceivesSiacoins)
+			} else {
+				addRelevance(output.RelatedAddress, modules.UnconfirmedReasonReceivesDust)
 			}
+		case types.SpecifierSiafundOutput, types.SpecifierClaimOutput:
+			addRelevance(output.RelatedAddress, modules.UnconfirmedReasonReceivesSiafunds)
 		}
 	}
-	return
+	return utb
 }
 
 // SendSiacoins creates a transaction sending 'amount' to 'dest'. The
@@ -118,9 +194,9 @@ func (w *Wallet) SendSiacoins(amount types.Currency, dest types.UnlockHash) ([]t
 	}
 	defer w.tg.Done()
 
-	_, fee := w.tpool.FeeEstimation()
+	fee := w.tpool.EstimateFee(sendFeeTargetBlocks).FeePerByte
 	fee = fee.Mul64(estimatedTransactionSize)
-	return w.managedSendSiacoins(amount, fee, dest)
+	return w.managedSendSiacoins("", amount, fee, dest)
 }
 
 // SendSiacoinsFeeIncluded creates a transaction sending 'amount' to 'dest'. The
@@ -133,7 +209,7 @@ func (w *Wallet) SendSiacoinsFeeIncluded(amount types.Currency, dest types.Unloc
 	}
 	defer w.tg.Done()
 
-	_, fee := w.tpool.FeeEstimation()
+	fee := w.tpool.EstimateFee(sendFeeTargetBlocks).FeePerByte
 	fee = fee.Mul64(estimatedTransactionSize)
 	// Don't allow sending an amount equal to the fee, as zero spending is not
 	// allowed and would error out later.
@@ -141,12 +217,13 @@ func (w *Wallet) SendSiacoinsFeeIncluded(amount types.Currency, dest types.Unloc
 		w.log.Println("Attempt to send coins has failed - not enough to cover fee")
 		return nil, errors.AddContext(modules.ErrLowBalance, "not enough coins to cover fee")
 	}
-	return w.managedSendSiacoins(amount.Sub(fee), fee, dest)
+	return w.managedSendSiacoins("", amount.Sub(fee), fee, dest)
 }
 
-// managedSendSiacoins creates a transaction sending 'amount' to 'dest'. The
-// transaction is submitted to the transaction pool and is also returned.
-func (w *Wallet) managedSendSiacoins(amount, fee types.Currency, dest types.UnlockHash) (txns []types.Transaction, err error) {
+// managedSendSiacoins creates a transaction sending 'amount' to 'dest' which
+// is funded by the given account. The transaction is submitted to the
+// transaction pool and is also returned.
+func (w *Wallet) managedSendSiacoins(account string, amount, fee types.Currency, dest types.UnlockHash) (txns []types.Transaction, err error) {
 	// Check if consensus is synced
 	if !w.cs.Synced() || w.deps.Disrupt("UnsyncedConsensus") {
 		return nil, errors.New("cannot send siacoin until fully synced")
@@ -165,10 +242,7 @@ func (w *Wallet) managedSendSiacoins(amount, fee types.Currency, dest types.Unlo
 		UnlockHash: dest,
 	}
 
-	txnBuilder, err := w.StartTransaction()
-	if err != nil {
-		return nil, err
-	}
+	txnBuilder := w.managedStartAccountTransaction(account)
 	defer func() {
 		if err != nil {
 			txnBuilder.Drop()
@@ -236,7 +310,7 @@ func (w *Wallet) SendSiacoinsMulti(outputs []types.SiacoinOutput) (txns []types.
 	}()
 
 	// Add estimated transaction fee.
-	_, tpoolFee := w.tpool.FeeEstimation()
+	tpoolFee := w.tpool.EstimateFee(sendFeeTargetBlocks).FeePerByte
 	tpoolFee = tpoolFee.Mul64(2)                              // We don't want send-to-many transactions to fail.
 	tpoolFee = tpoolFee.Mul64(1000 + 60*uint64(len(outputs))) // Estimated transaction size in bytes
 	txnBuilder.AddMinerFee(tpoolFee)
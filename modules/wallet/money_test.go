@@ -94,6 +94,76 @@ func TestSendSiacoins(t *testing.T) {
 	}
 }
 
+// TestUnconfirmedBalanceBreakdown probes the UnconfirmedBalanceBreakdown
+// method of the wallet.
+func TestUnconfirmedBalanceBreakdown(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// There should be no unconfirmed transactions.
+	utbs, err := wt.wallet.UnconfirmedBalanceBreakdown()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(utbs) != 0 {
+		t.Fatal("expected no unconfirmed transactions but got", len(utbs))
+	}
+
+	// Send siacoins.
+	txns, err := wt.wallet.SendSiacoins(types.SiacoinPrecision.Mul64(3), types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	utbs, err = wt.wallet.UnconfirmedBalanceBreakdown()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(utbs) != len(txns) {
+		t.Fatalf("expected %v unconfirmed transactions but got %v", len(txns), len(utbs))
+	}
+
+	// The breakdown should add up to the unconfirmed balance.
+	unconfirmedOut, unconfirmedIn, err := wt.wallet.UnconfirmedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out, in types.Currency
+	for _, utb := range utbs {
+		out = out.Add(utb.OutgoingSiacoins)
+		in = in.Add(utb.IncomingSiacoins)
+		if len(utb.Addresses) == 0 || len(utb.Reasons) == 0 {
+			t.Fatal("expected addresses and reasons for relevant transaction", utb)
+		}
+		for _, addr := range utb.Addresses {
+			if addr == (types.UnlockHash{}) {
+				t.Fatal("recipient shouldn't be reported as wallet address")
+			}
+		}
+	}
+	if !out.Equals(unconfirmedOut) || !in.Equals(unconfirmedIn) {
+		t.Fatalf("breakdown %v/%v doesn't match balance %v/%v", out, in, unconfirmedOut, unconfirmedIn)
+	}
+
+	// The last transaction spends the wallet's outputs.
+	var spends bool
+	for _, reason := range utbs[len(utbs)-1].Reasons {
+		spends = spends || reason == modules.UnconfirmedReasonSpendsSiacoins
+	}
+	if !spends {
+		t.Fatal("expected transaction to spend wallet outputs", utbs[len(utbs)-1].Reasons)
+	}
+}
+
 // TestSendSiacoinsFeeIncluded probes the SendSiacoins method of the wallet with
 // feeIncluded=true.
 func TestSendSiacoinsFeeIncluded(t *testing.T) {
@@ -182,6 +182,76 @@ func (w *Wallet) SignTransaction(txn *types.Transaction, toSign []crypto.Hash) e
 	return signTransaction(txn, w.keys, toSign, consensusHeight)
 }
 
+// SignPartiallySignedTransaction adds the signatures of all the keys known to
+// the wallet which can still sign an input of psst. Inputs which are already
+// sufficiently signed are skipped. An error is returned if the wallet couldn't
+// add any signature.
+func (w *Wallet) SignPartiallySignedTransaction(psst *modules.PartiallySignedTransaction) error {
+	if err := w.tg.Add(); err != nil {
+		return err
+	}
+	defer w.tg.Done()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return modules.ErrLockedWallet
+	}
+	consensusHeight, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return err
+	}
+
+	// helper function to lookup the secret key of a public key. Keys are
+	// either stored with the standard unlock conditions of the public key or
+	// with the unlock conditions of the input, e.g. for siag keys.
+	findSigningKey := func(uc types.UnlockConditions, pk types.SiaPublicKey) (crypto.SecretKey, bool) {
+		standardUC := types.UnlockConditions{
+			PublicKeys:         []types.SiaPublicKey{pk},
+			SignaturesRequired: 1,
+		}
+		for _, addr := range []types.UnlockHash{standardUC.UnlockHash(), uc.UnlockHash()} {
+			sk, ok := w.keys[addr]
+			if !ok {
+				continue
+			}
+			for _, key := range sk.SecretKeys {
+				pubKey := key.PublicKey()
+				if bytes.Equal(pk.Key, pubKey[:]) {
+					return key, true
+				}
+			}
+		}
+		return crypto.SecretKey{}, false
+	}
+
+	var signed int
+	for _, input := range psst.Inputs {
+		for _, pkIndex := range input.MissingKeys() {
+			sk, ok := findSigningKey(input.UnlockConditions, input.UnlockConditions.PublicKeys[pkIndex])
+			if !ok {
+				continue
+			}
+			sigHash, err := psst.SigHash(input.ParentID, pkIndex, consensusHeight)
+			if errors.Is(err, modules.ErrPSSTInputSigned) {
+				break // input is sufficiently signed
+			}
+			if err != nil {
+				return err
+			}
+			err = psst.AddSignature(input.ParentID, pkIndex, crypto.SignHash(sigHash, sk), consensusHeight)
+			if err != nil {
+				return err
+			}
+			signed++
+		}
+	}
+	if signed == 0 {
+		return errors.New("wallet has no keys which can sign the missing signatures")
+	}
+	return nil
+}
+
 // SignTransaction signs txn using secret keys derived from seed. The
 // transaction should be complete with the exception of the Signature fields
 // of each TransactionSignature referenced by toSign, which must not be empty.
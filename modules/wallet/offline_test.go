@@ -124,6 +124,78 @@ func TestSignTransaction(t *testing.T) {
 	}
 }
 
+// TestSignPartiallySignedTransaction signs a 2-of-2 multisig input using a key
+// of the wallet and an external key.
+func TestSignPartiallySignedTransaction(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := wt.closeWt(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// create a multisig address from a wallet key and an external key
+	walletUC, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sk, pk := crypto.GenerateKeyPair()
+	uc := types.UnlockConditions{
+		PublicKeys:         []types.SiaPublicKey{types.Ed25519PublicKey(pk), walletUC.PublicKeys[0]},
+		SignaturesRequired: 2,
+	}
+
+	// create a partially signed transaction spending an output of the address
+	var parentID types.SiacoinOutputID
+	fastrand.Read(parentID[:])
+	psst, err := modules.NewPartiallySignedTransaction(types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{{
+			ParentID:         parentID,
+			UnlockConditions: uc,
+		}},
+		SiacoinOutputs: []types.SiacoinOutput{{
+			Value: types.SiacoinPrecision,
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the wallet should add its signature
+	if err := wt.wallet.SignPartiallySignedTransaction(&psst); err != nil {
+		t.Fatal(err)
+	}
+	if signed := psst.Inputs[0].SignedKeys; len(signed) != 1 || signed[0] != 1 {
+		t.Fatal("wallet didn't sign with its key", signed)
+	}
+	// signing again should fail since the wallet has no other keys
+	if err := wt.wallet.SignPartiallySignedTransaction(&psst); err == nil {
+		t.Fatal("expected signing without keys to fail")
+	}
+
+	// add the external signature and finalize the transaction
+	height, err := wt.wallet.Height()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sigHash, err := psst.SigHash(crypto.Hash(parentID), 0, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := psst.AddSignature(crypto.Hash(parentID), 0, crypto.SignHash(sigHash, sk), height); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := psst.Finalize(height); err != nil {
+		t.Fatal(err)
+	}
+}
+
 // TestSignTransactionNoWallet tests the SignTransaction function.
 func TestSignTransactionNoWallet(t *testing.T) {
 	// generate a seed
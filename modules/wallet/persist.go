@@ -68,9 +68,15 @@ func (w *Wallet) openDB(filename string) (err error) {
 		if wb.Get(keyConsensusHeight) == nil {
 			wb.Put(keyConsensusHeight, encoding.Marshal(uint64(0)))
 		}
+		if wb.Get(keyAccountFiles) == nil {
+			wb.Put(keyAccountFiles, encoding.Marshal([]accountFile{}))
+		}
 		if wb.Get(keyAuxiliarySeedFiles) == nil {
 			wb.Put(keyAuxiliarySeedFiles, encoding.Marshal([]seedFile{}))
 		}
+		if wb.Get(keyExternalAddresses) == nil {
+			wb.Put(keyExternalAddresses, encoding.Marshal([]modules.ExternalAddress{}))
+		}
 		if wb.Get(keySpendableKeyFiles) == nil {
 			wb.Put(keySpendableKeyFiles, encoding.Marshal([]spendableKeyFile{}))
 		}
@@ -249,6 +249,10 @@ func (w *Wallet) managedMarkAddressUnused(addrs ...types.UnlockConditions) {
 // to be handed out by a subsequent call to `NextAddresses` again.
 func (w *Wallet) markAddressUnused(addrs ...types.UnlockConditions) {
 	for _, addr := range addrs {
+		// Addresses of accounts must not be handed out for the primary seed.
+		if _, isAccount := w.accountAddrs[addr.UnlockHash()]; isAccount {
+			continue
+		}
 		w.unusedKeys[addr.UnlockHash()] = addr
 	}
 }
@@ -320,11 +324,9 @@ func (w *Wallet) LoadSeed(masterKey crypto.CipherKey, seed modules.Seed) error {
 		w.mu.RUnlock()
 		return modules.ErrLockedWallet
 	}
-	for _, wSeed := range append([]modules.Seed{w.primarySeed}, w.seeds...) {
-		if seed == wSeed {
-			w.mu.RUnlock()
-			return errKnownSeed
-		}
+	if w.isKnownSeed(seed) {
+		w.mu.RUnlock()
+		return errKnownSeed
 	}
 	w.mu.RUnlock()
 
@@ -365,29 +367,40 @@ func (w *Wallet) LoadSeed(masterKey crypto.CipherKey, seed modules.Seed) error {
 		// load the seed's keys
 		w.integrateSeed(seed, seedProgress)
 		w.seeds = append(w.seeds, seed)
-
-		// delete the set of processed transactions; they will be recreated
-		// when we rescan
-		if err = w.dbTx.DeleteBucket(bucketProcessedTransactions); err != nil {
-			return err
-		}
-		if _, err = w.dbTx.CreateBucket(bucketProcessedTransactions); err != nil {
-			return err
-		}
-		w.unconfirmedProcessedTransactions = nil
-
-		// reset the consensus change ID and height in preparation for rescan
-		err = dbPutConsensusChangeID(w.dbTx, modules.ConsensusChangeBeginning)
-		if err != nil {
-			return err
-		}
-		return dbPutConsensusHeight(w.dbTx, 0)
+		return w.resetHistory()
 	}()
 	if err != nil {
 		return err
 	}
 
 	// rescan the blockchain
+	return w.managedRescan()
+}
+
+// resetHistory deletes the processed transactions of the wallet and resets
+// its consensus change ID and height in preparation for a rescan.
+func (w *Wallet) resetHistory() error {
+	// delete the set of processed transactions; they will be recreated
+	// when we rescan
+	if err := w.dbTx.DeleteBucket(bucketProcessedTransactions); err != nil {
+		return err
+	}
+	if _, err := w.dbTx.CreateBucket(bucketProcessedTransactions); err != nil {
+		return err
+	}
+	w.unconfirmedProcessedTransactions = nil
+
+	// reset the consensus change ID and height in preparation for rescan
+	err := dbPutConsensusChangeID(w.dbTx, modules.ConsensusChangeBeginning)
+	if err != nil {
+		return err
+	}
+	return dbPutConsensusHeight(w.dbTx, 0)
+}
+
+// managedRescan rescans the blockchain by resubscribing the wallet to the
+// consensus set from the beginning.
+func (w *Wallet) managedRescan() error {
 	w.cs.Unsubscribe(w)
 	w.tpool.Unsubscribe(w)
 
@@ -395,7 +408,7 @@ func (w *Wallet) LoadSeed(masterKey crypto.CipherKey, seed modules.Seed) error {
 	go w.rescanMessage(done)
 	defer close(done)
 
-	err = w.cs.ConsensusSetSubscribe(w, modules.ConsensusChangeBeginning, w.tg.StopChan())
+	err := w.cs.ConsensusSetSubscribe(w, modules.ConsensusChangeBeginning, w.tg.StopChan())
 	if err != nil {
 		return err
 	}
@@ -403,6 +416,22 @@ func (w *Wallet) LoadSeed(masterKey crypto.CipherKey, seed modules.Seed) error {
 	return nil
 }
 
+// isKnownSeed returns whether the seed is the primary seed, an auxiliary seed
+// or the seed of an account of the wallet.
+func (w *Wallet) isKnownSeed(seed modules.Seed) bool {
+	for _, wSeed := range append([]modules.Seed{w.primarySeed}, w.seeds...) {
+		if seed == wSeed {
+			return true
+		}
+	}
+	for _, acc := range w.accounts {
+		if seed == acc.seed {
+			return true
+		}
+	}
+	return false
+}
+
 // SweepSeed scans the blockchain for outputs generated from seed and creates
 // a transaction that transfers them to the wallet. Note that this incurs a
 // transaction fee. It returns the total value of the outputs, minus the fee.
@@ -48,6 +48,10 @@ type transactionBuilder struct {
 	siafundInputs         []int
 	transactionSignatures []int
 
+	// account is the name of the account which funds the transaction. The
+	// primary seed funds the transaction if it is empty.
+	account string
+
 	wallet *Wallet
 }
 
@@ -136,6 +140,7 @@ func (tb *transactionBuilder) Copy() modules.TransactionBuilder {
 	copy(copyBuilder.transactionSignatures, tb.transactionSignatures)
 
 	copyBuilder.signed = tb.signed
+	copyBuilder.account = tb.account
 	return copyBuilder
 }
 
@@ -208,9 +213,12 @@ func (tb *transactionBuilder) FundSiacoins(amount types.Currency) (err error) {
 		return err
 	}
 
-	// Collect a value-sorted set of siacoin outputs.
+	// Collect a value-sorted set of the account's siacoin outputs.
 	var so sortedOutputs
 	err = dbForEachSiacoinOutput(tb.wallet.dbTx, func(scoid types.SiacoinOutputID, sco types.SiacoinOutput) {
+		if tb.wallet.accountAddrs[sco.UnlockHash] != tb.account {
+			return
+		}
 		so.ids = append(so.ids, scoid)
 		so.outputs = append(so.outputs, sco)
 	})
@@ -222,7 +230,7 @@ func (tb *transactionBuilder) FundSiacoins(amount types.Currency) (err error) {
 		for i, sco := range upt.Transaction.SiacoinOutputs {
 			// Determine if the output belongs to the wallet.
 			_, exists := tb.wallet.keys[sco.UnlockHash]
-			if !exists {
+			if !exists || tb.wallet.accountAddrs[sco.UnlockHash] != tb.account {
 				continue
 			}
 			so.ids = append(so.ids, upt.Transaction.SiacoinOutputID(uint64(i)))
@@ -276,7 +284,7 @@ func (tb *transactionBuilder) FundSiacoins(amount types.Currency) (err error) {
 
 	// Create and add the output that will be used to fund the standard
 	// transaction.
-	parentUnlockConditions, err := tb.wallet.nextPrimarySeedAddress(tb.wallet.dbTx)
+	parentUnlockConditions, err := tb.wallet.nextAccountAddress(tb.wallet.dbTx, tb.account)
 	if err != nil {
 		return err
 	}
@@ -294,7 +302,7 @@ func (tb *transactionBuilder) FundSiacoins(amount types.Currency) (err error) {
 
 	// Create a refund output if needed.
 	if !amount.Equals(fund) {
-		refundUnlockConditions, err := tb.wallet.nextPrimarySeedAddress(tb.wallet.dbTx)
+		refundUnlockConditions, err := tb.wallet.nextAccountAddress(tb.wallet.dbTx, tb.account)
 		if err != nil {
 			return err
 		}
@@ -341,6 +349,40 @@ func (tb *transactionBuilder) FundSiacoins(amount types.Currency) (err error) {
 	return nil
 }
 
+// FundSiacoinsFromOutput will add a siacoin input which spends the provided
+// output of the wallet to the transaction. The output is marked as spent to
+// prevent the wallet from using it for other transactions. The siacoin input
+// will not be signed until 'Sign' is called on the transaction builder.
+func (tb *transactionBuilder) FundSiacoinsFromOutput(id types.SiacoinOutputID, output types.SiacoinOutput) error {
+	tb.wallet.mu.Lock()
+	defer tb.wallet.mu.Unlock()
+
+	consensusHeight, err := dbGetConsensusHeight(tb.wallet.dbTx)
+	if err != nil {
+		return err
+	}
+	key, exists := tb.wallet.keys[output.UnlockHash]
+	if !exists {
+		return errors.New("output doesn't belong to the wallet")
+	}
+	if _, err := dbGetSpentOutput(tb.wallet.dbTx, types.OutputID(id)); err == nil {
+		return errors.New("output was already spent by the wallet")
+	}
+	err = dbPutSpentOutput(tb.wallet.dbTx, types.OutputID(id), consensusHeight)
+	if err != nil {
+		return err
+	}
+
+	// Add the input.
+	newInput := types.SiacoinInput{
+		ParentID:         id,
+		UnlockConditions: key.UnlockConditions,
+	}
+	tb.siacoinInputs = append(tb.siacoinInputs, len(tb.transaction.SiacoinInputs))
+	tb.transaction.SiacoinInputs = append(tb.transaction.SiacoinInputs, newInput)
+	return nil
+}
+
 // FundSiafunds will add a siafund input of exactly 'amount' to the
 // transaction. A parent transaction may be needed to achieve an input with the
 // correct value. The siafund input will not be signed until 'Sign' is called
@@ -373,6 +415,9 @@ func (tb *transactionBuilder) FundSiafunds(amount types.Currency) (err error) {
 		} else if err := encoding.Unmarshal(sfoBytes, &sfo); err != nil {
 			return err
 		}
+		if tb.wallet.accountAddrs[sfo.UnlockHash] != tb.account {
+			continue
+		}
 
 		// Check that this output has not recently been spent by the wallet.
 		spendHeight, err := dbGetSpentOutput(tb.wallet.dbTx, types.OutputID(sfoid))
@@ -395,7 +440,7 @@ func (tb *transactionBuilder) FundSiafunds(amount types.Currency) (err error) {
 		}
 
 		// Add a siafund input for this output.
-		parentClaimUnlockConditions, err := tb.wallet.nextPrimarySeedAddress(tb.wallet.dbTx)
+		parentClaimUnlockConditions, err := tb.wallet.nextAccountAddress(tb.wallet.dbTx, tb.account)
 		if err != nil {
 			return err
 		}
@@ -428,7 +473,7 @@ func (tb *transactionBuilder) FundSiafunds(amount types.Currency) (err error) {
 
 	// Create and add the output that will be used to fund the standard
 	// transaction.
-	parentUnlockConditions, err := tb.wallet.nextPrimarySeedAddress(tb.wallet.dbTx)
+	parentUnlockConditions, err := tb.wallet.nextAccountAddress(tb.wallet.dbTx, tb.account)
 	if err != nil {
 		return err
 	}
@@ -445,7 +490,7 @@ func (tb *transactionBuilder) FundSiafunds(amount types.Currency) (err error) {
 
 	// Create a refund output if needed.
 	if !amount.Equals(fund) {
-		refundUnlockConditions, err := tb.wallet.nextPrimarySeedAddress(tb.wallet.dbTx)
+		refundUnlockConditions, err := tb.wallet.nextAccountAddress(tb.wallet.dbTx, tb.account)
 		if err != nil {
 			return err
 		}
@@ -467,7 +512,7 @@ func (tb *transactionBuilder) FundSiafunds(amount types.Currency) (err error) {
 	}
 
 	// Add the exact output.
-	claimUnlockConditions, err := tb.wallet.nextPrimarySeedAddress(tb.wallet.dbTx)
+	claimUnlockConditions, err := tb.wallet.nextAccountAddress(tb.wallet.dbTx, tb.account)
 	if err != nil {
 		return err
 	}
@@ -505,10 +550,14 @@ func (tb *transactionBuilder) Sweep(output types.SiacoinOutput) (txn types.Trans
 }
 
 // UnconfirmedParents returns the unconfirmed parents of the transaction set
-// that is being constructed by the transaction builder.
+// that is being constructed by the transaction builder. This includes the
+// unconfirmed parents of the transaction itself which are already in the
+// transaction pool, e.g. if the transaction was funded using
+// FundSiacoinsFromOutput.
 func (tb *transactionBuilder) UnconfirmedParents() (parents []types.Transaction, err error) {
 	addedParents := make(map[types.TransactionID]struct{})
-	for _, p := range tb.parents {
+	txns := append(append([]types.Transaction(nil), tb.parents...), tb.transaction)
+	for _, p := range txns {
 		for _, sci := range p.SiacoinInputs {
 			tSet := tb.wallet.tpool.TransactionSet(crypto.Hash(sci.ParentID))
 			for _, txn := range tSet {
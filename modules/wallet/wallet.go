@@ -80,6 +80,17 @@ type Wallet struct {
 	lookahead    map[types.UnlockHash]uint64
 	watchedAddrs map[types.UnlockHash]struct{}
 
+	// accounts contains the decrypted seeds of the wallet's accounts and
+	// accountAddrs maps the addresses of the accounts to their names. Like
+	// the public keys, accountAddrs is kept while the wallet is locked to
+	// continue separating the funds of the accounts.
+	accounts     map[string]*walletAccount
+	accountAddrs map[types.UnlockHash]string
+
+	// externalSigner is the signer which signs the inputs spending the
+	// outputs of external addresses.
+	externalSigner modules.ExternalSigner
+
 	// unconfirmedProcessedTransactions tracks unconfirmed transactions.
 	//
 	// TODO: Replace this field with a linked list. Currently when a new
@@ -206,6 +217,9 @@ func NewCustomWallet(cs modules.ConsensusSet, tpool modules.TransactionPool, per
 		unusedKeys:   make(map[types.UnlockHash]types.UnlockConditions),
 		watchedAddrs: make(map[types.UnlockHash]struct{}),
 
+		accounts:     make(map[string]*walletAccount),
+		accountAddrs: make(map[types.UnlockHash]string),
+
 		unconfirmedSets: make(map[modules.TransactionSetID][]types.TransactionID),
 
 		persistDir: persistDir,
@@ -0,0 +1,291 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+)
+
+var (
+	// alertRoutingInterval is the interval at which the alerts of all modules
+	// are polled to route new alerts to the configured sinks.
+	alertRoutingInterval = build.Select(build.Var{
+		Standard: time.Minute,
+		Dev:      10 * time.Second,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// alertWebhookTimeout is the timeout for delivering an alert to a
+	// webhook.
+	alertWebhookTimeout = build.Select(build.Var{
+		Standard: 30 * time.Second,
+		Dev:      10 * time.Second,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+)
+
+type (
+	// DaemonAlertRoutesGet contains the configured alert routes.
+	DaemonAlertRoutesGet struct {
+		Routes []modules.AlertRoute `json:"routes"`
+	}
+
+	// DaemonAlertRoutesPost contains the alert routes to set.
+	DaemonAlertRoutesPost struct {
+		Routes []modules.AlertRoute `json:"routes"`
+	}
+
+	// alertRouter keeps track of the alerts which were already routed to the
+	// configured sinks. The routed alerts are persisted in the siad config.
+	alertRouter struct {
+		routed map[modules.AlertID]modules.Alert
+
+		staticAPI    *API
+		staticClient *http.Client
+		staticLog    *persist.Logger
+	}
+)
+
+// alerts returns the alerts of all loaded modules annotated with their
+// acknowledgement and mute status.
+func (api *API) alerts() (crit, err, warn []modules.Alert) {
+	// initialize slices to avoid "null" in response.
+	crit = make([]modules.Alert, 0, 6)
+	err = make([]modules.Alert, 0, 6)
+	warn = make([]modules.Alert, 0, 6)
+	alerters := []modules.Alerter{}
+	if api.gateway != nil {
+		alerters = append(alerters, api.gateway)
+	}
+	if api.cs != nil {
+		alerters = append(alerters, api.cs)
+	}
+	if api.tpool != nil {
+		alerters = append(alerters, api.tpool)
+	}
+	if api.wallet != nil {
+		alerters = append(alerters, api.wallet)
+	}
+	if api.renter != nil {
+		alerters = append(alerters, api.renter)
+	}
+	if api.host != nil {
+		alerters = append(alerters, api.host)
+	}
+	alerters = append(alerters, modules.CrashDumpAlerter())
+	for _, alerter := range alerters {
+		c, e, w := alerter.Alerts()
+		crit = append(crit, c...)
+		err = append(err, e...)
+		warn = append(warn, w...)
+	}
+	api.siadConfig.AnnotateAlerts(crit)
+	api.siadConfig.AnnotateAlerts(err)
+	api.siadConfig.AnnotateAlerts(warn)
+	return crit, err, warn
+}
+
+// filterMutedAlerts removes all muted alerts from the provided slice.
+func filterMutedAlerts(alerts []modules.Alert) []modules.Alert {
+	filtered := alerts[:0]
+	for _, alert := range alerts {
+		if !alert.Muted {
+			filtered = append(filtered, alert)
+		}
+	}
+	return filtered
+}
+
+// daemonAlertsHandlerGET handles the API call that returns the alerts of all
+// loaded modules.
+func (api *API) daemonAlertsHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var includeMuted bool
+	if includeMutedStr := req.FormValue("includemuted"); includeMutedStr != "" {
+		var err error
+		includeMuted, err = strconv.ParseBool(includeMutedStr)
+		if err != nil {
+			WriteError(w, Error{"unable to parse includemuted: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	crit, err, warn := api.alerts()
+	if !includeMuted {
+		crit = filterMutedAlerts(crit)
+		err = filterMutedAlerts(err)
+		warn = filterMutedAlerts(warn)
+	}
+	// Sort alerts by severity. Critical first, then Error and finally Warning.
+	alerts := append(crit, append(err, warn...)...)
+	WriteJSON(w, DaemonAlertsGet{
+		Alerts:         alerts,
+		CriticalAlerts: crit,
+		ErrorAlerts:    err,
+		WarningAlerts:  warn,
+	})
+}
+
+// daemonAlertsAcknowledgeHandlerPOST handles the API call to acknowledge an
+// alert.
+func (api *API) daemonAlertsAcknowledgeHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	id := req.FormValue("id")
+	if id == "" {
+		WriteError(w, Error{"id needs to be specified"}, http.StatusBadRequest)
+		return
+	}
+	if err := api.siadConfig.AcknowledgeAlert(modules.AlertID(id)); err != nil {
+		WriteError(w, Error{"failed to acknowledge alert: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// daemonAlertsMuteHandlerPOST handles the API call to mute or unmute an alert.
+func (api *API) daemonAlertsMuteHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	id := req.FormValue("id")
+	if id == "" {
+		WriteError(w, Error{"id needs to be specified"}, http.StatusBadRequest)
+		return
+	}
+	mute := true
+	if muteStr := req.FormValue("mute"); muteStr != "" {
+		var err error
+		mute, err = strconv.ParseBool(muteStr)
+		if err != nil {
+			WriteError(w, Error{"unable to parse mute: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if err := api.siadConfig.MuteAlert(modules.AlertID(id), mute); err != nil {
+		WriteError(w, Error{"failed to mute alert: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// daemonAlertsRoutesHandlerGET handles the API call to get the configured
+// alert routes.
+func (api *API) daemonAlertsRoutesHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, DaemonAlertRoutesGet{
+		Routes: api.siadConfig.CurrentAlertRoutes(),
+	})
+}
+
+// daemonAlertsRoutesHandlerPOST handles the API call to replace the configured
+// alert routes.
+func (api *API) daemonAlertsRoutesHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params DaemonAlertRoutesPost
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := api.siadConfig.SetAlertRoutes(params.Routes); err != nil {
+		WriteError(w, Error{"failed to set alert routes: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// ThreadedRouteAlerts periodically polls the alerts of all modules and routes
+// newly registered alerts to the configured sinks until stop is closed.
+func (api *API) ThreadedRouteAlerts(log *persist.Logger, stop <-chan struct{}) {
+	ar := &alertRouter{
+		routed:       api.siadConfig.CurrentRoutedAlerts(),
+		staticAPI:    api,
+		staticClient: &http.Client{Timeout: alertWebhookTimeout},
+		staticLog:    log,
+	}
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(alertRoutingInterval):
+		}
+		ar.managedRouteAlerts()
+	}
+}
+
+// managedRouteAlerts routes all alerts which weren't routed before.
+func (ar *alertRouter) managedRouteAlerts() {
+	crit, err, warn := ar.staticAPI.alerts()
+	alerts := append(crit, append(err, warn...)...)
+	routes := ar.staticAPI.siadConfig.CurrentAlertRoutes()
+
+	// Route the new alerts. Alerts which were acknowledged or muted by the
+	// user aren't routed.
+	registered := make(map[modules.AlertID]struct{}, len(alerts))
+	for _, alert := range alerts {
+		registered[alert.ID] = struct{}{}
+		if alert.Acknowledged || alert.Muted {
+			continue
+		}
+		if routedAlert, routed := ar.routed[alert.ID]; routed && routedAlert.Equals(alert) {
+			continue
+		}
+		ar.routed[alert.ID] = alert
+		ar.staticAPI.staticEventBus.Publish(alert.Module, modules.EventTypeAlertRegistered, alert)
+		for _, route := range routes {
+			if !route.Matches(alert) {
+				continue
+			}
+			if err := ar.managedRouteAlert(route, alert); err != nil {
+				ar.staticLog.Printf("WARN: failed to route alert %v to %v sink: %v", alert.ID, route.Sink, err)
+			}
+		}
+	}
+
+	// Forget about alerts that were unregistered so that they are routed
+	// again if they are registered again. Their acknowledgements are pruned
+	// as well.
+	for id, alert := range ar.routed {
+		if _, exists := registered[id]; !exists {
+			delete(ar.routed, id)
+			ar.staticAPI.staticEventBus.Publish(alert.Module, modules.EventTypeAlertUnregistered, alert)
+		}
+	}
+	if err := ar.staticAPI.siadConfig.PruneAlertAcknowledgements(registered); err != nil {
+		ar.staticLog.Println("WARN: failed to prune alert acknowledgements:", err)
+	}
+
+	// Persist the routed alerts to avoid routing them again after a restart.
+	if err := ar.staticAPI.siadConfig.SetRoutedAlerts(ar.routed); err != nil {
+		ar.staticLog.Println("WARN: failed to persist routed alerts:", err)
+	}
+}
+
+// managedRouteAlert sends a single alert to the sink of a route.
+func (ar *alertRouter) managedRouteAlert(route modules.AlertRoute, alert modules.Alert) error {
+	switch route.Sink {
+	case modules.AlertSinkLog:
+		ar.staticLog.Printf("%v alert from %v (%v) %v: %v - %v", alert.Severity, alert.Module, alert.Category, alert.ID, alert.Msg, alert.Cause)
+		return nil
+	case modules.AlertSinkWebhook:
+		body, err := json.Marshal(alert)
+		if err != nil {
+			return errors.AddContext(err, "failed to marshal alert")
+		}
+		resp, err := ar.staticClient.Post(route.URL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = resp.Body.Close()
+		}()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return fmt.Errorf("webhook returned status %v", resp.StatusCode)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown sink '%v'", route.Sink)
+	}
+}
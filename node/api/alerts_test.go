@@ -0,0 +1,60 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.sia.tech/siad/modules"
+)
+
+// TestRouteAlertWebhook tests routing an alert to a webhook.
+func TestRouteAlertWebhook(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a webhook which forwards the received alerts.
+	received := make(chan modules.Alert, 1)
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var alert modules.Alert
+		if err := json.NewDecoder(req.Body).Decode(&alert); err != nil {
+			t.Error(err)
+		}
+		received <- alert
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	ar := &alertRouter{
+		staticClient: &http.Client{Timeout: alertWebhookTimeout},
+	}
+	route := modules.AlertRoute{
+		MinSeverity: modules.SeverityWarning,
+		Sink:        modules.AlertSinkWebhook,
+		URL:         server.URL,
+	}
+	alert := modules.Alert{
+		Category: modules.AlertCategoryNetwork,
+		ID:       modules.AlertIDGatewayOffline,
+		Module:   "gateway",
+		Msg:      "msg",
+		Severity: modules.SeverityWarning,
+	}
+	if err := ar.managedRouteAlert(route, alert); err != nil {
+		t.Fatal(err)
+	}
+	if routed := <-received; !routed.Equals(alert) || routed.ID != alert.ID || routed.Category != alert.Category {
+		t.Fatal("unexpected alert", routed)
+	}
+
+	// A webhook returning an error status should cause an error.
+	status = http.StatusInternalServerError
+	if err := ar.managedRouteAlert(route, alert); err == nil {
+		t.Fatal("expected error")
+	}
+	<-received
+}
@@ -124,11 +124,20 @@ type (
 		router     http.Handler
 		routerMu   sync.RWMutex
 
+		// publicRouter serves the read-only public subset of the API.
+		publicRouter http.Handler
+
+		dataDirs  []string
+		quiesce   *quiesceWindow
+		quiesceMu sync.Mutex
+
 		requiredUserAgent string
 		requiredPassword  string
 		Shutdown          func() error
 		siadConfig        *modules.SiadConfig
 
+		staticEventBus  *modules.EventBus
+		staticMetrics   *modules.MetricsRegistry
 		staticStartTime time.Time
 
 		staticDeps modules.Dependencies
@@ -151,9 +160,12 @@ type (
 
 // api.ServeHTTP implements the http.Handler interface.
 func (api *API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Don't hold the lock while serving the request since long-lived
+	// requests like event streams would block replacing the router.
 	api.routerMu.RLock()
-	api.router.ServeHTTP(w, r)
+	router := api.router
 	api.routerMu.RUnlock()
+	router.ServeHTTP(w, r)
 }
 
 // SetModules allows for replacing the modules in the API at runtime.
@@ -219,8 +231,11 @@ func NewCustom(cfg *modules.SiadConfig, requiredUserAgent string, requiredPasswo
 		siadConfig:        cfg,
 
 		staticDeps:      deps,
+		staticEventBus:  modules.NewEventBus(),
+		staticMetrics:   modules.NewMetricsRegistry(),
 		staticStartTime: time.Now(),
 	}
+	api.registerMetricsCollectors()
 
 	// Register API handlers
 	api.buildHTTPRoutes()
@@ -254,8 +269,14 @@ func WriteError(w http.ResponseWriter, err Error, code int) {
 
 // WriteJSON writes the object to the ResponseWriter. If the encoding fails, an
 // error is written instead. The Content-Type of the response header is set
-// accordingly.
+// accordingly. If the client enabled strict mode, the object is written using
+// the negotiated schema version.
 func WriteJSON(w http.ResponseWriter, obj interface{}) {
+	if vw, ok := w.(*versionedResponseWriter); ok && vw.staticStrict {
+		if strictObj, err := strictResponse(obj, vw.staticVersion); err == nil {
+			obj = strictObj
+		}
+	}
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	err := json.NewEncoder(w).Encode(obj)
 	if _, isJsonErr := err.(*json.SyntaxError); isJsonErr {
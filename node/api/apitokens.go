@@ -0,0 +1,170 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+)
+
+type (
+	// DaemonTokensGET contains the API tokens of the daemon.
+	DaemonTokensGET struct {
+		Tokens []modules.APIToken `json:"tokens"`
+	}
+
+	// DaemonTokensPOST contains a newly created API token and its secret.
+	// The secret is only returned once.
+	DaemonTokensPOST struct {
+		Token  modules.APIToken `json:"token"`
+		Secret string           `json:"secret"`
+	}
+
+	// apiTokenKey is the key of the API token a request was authenticated
+	// with within the request's context.
+	apiTokenKey struct{}
+
+	// apiTokenRoute describes the scopes which grant access to a set of
+	// password protected routes.
+	apiTokenRoute struct {
+		// method limits the route to a HTTP method. An empty method matches
+		// all methods.
+		method string
+		// path is the path of the route. A path ending with a "/" matches
+		// all paths with that prefix, other paths also match their
+		// subpaths.
+		path   string
+		scopes []modules.APITokenScope
+	}
+)
+
+var (
+	// errAPITokenScope is returned if a request is authenticated with an API
+	// token which doesn't carry the scope required by the call.
+	errAPITokenScope = errors.New("API token doesn't have the scope required by this call")
+
+	// apiTokenRoutes lists the password protected routes which can be
+	// accessed with an API token. The first matching route applies. Routes
+	// which are not covered, e.g. the wallet's seeds, the daemon's settings
+	// and the management of the tokens themselves, are only accessible with
+	// the API password.
+	apiTokenRoutes = []apiTokenRoute{
+		// Host
+		{path: "/host", scopes: []modules.APITokenScope{modules.APITokenScopeHostAdmin}},
+
+		// Renter
+		{path: "/renter/contractkeys"},
+		{method: http.MethodGet, path: "/renter/backups", scopes: []modules.APITokenScope{modules.APITokenScopeRead, modules.APITokenScopeRenterWrite}},
+		{path: "/renter", scopes: []modules.APITokenScope{modules.APITokenScopeRenterWrite}},
+		{path: "/hostdb", scopes: []modules.APITokenScope{modules.APITokenScopeRenterWrite}},
+
+		// Wallet
+		{method: http.MethodGet, path: "/wallet/unspent", scopes: []modules.APITokenScope{modules.APITokenScopeRead, modules.APITokenScopeWalletSpend}},
+		{method: http.MethodGet, path: "/wallet/unlockconditions/", scopes: []modules.APITokenScope{modules.APITokenScopeRead, modules.APITokenScopeWalletSpend}},
+		{method: http.MethodGet, path: "/wallet/watch", scopes: []modules.APITokenScope{modules.APITokenScopeRead, modules.APITokenScopeWalletSpend}},
+		{method: http.MethodGet, path: "/wallet/externalsign/addresses", scopes: []modules.APITokenScope{modules.APITokenScopeRead, modules.APITokenScopeWalletSpend}},
+		{path: "/wallet/accounts/", scopes: []modules.APITokenScope{modules.APITokenScopeWalletSpend}},
+		{path: "/wallet/address", scopes: []modules.APITokenScope{modules.APITokenScopeWalletSpend}},
+		{path: "/wallet/externalsign", scopes: []modules.APITokenScope{modules.APITokenScopeWalletSpend}},
+		{path: "/wallet/psst", scopes: []modules.APITokenScope{modules.APITokenScopeWalletSpend}},
+		{path: "/wallet/siacoins", scopes: []modules.APITokenScope{modules.APITokenScopeWalletSpend}},
+		{path: "/wallet/siafunds", scopes: []modules.APITokenScope{modules.APITokenScopeWalletSpend}},
+		{path: "/wallet/sign", scopes: []modules.APITokenScope{modules.APITokenScopeWalletSpend}},
+		{method: http.MethodPost, path: "/wallet/unlockconditions", scopes: []modules.APITokenScope{modules.APITokenScopeWalletSpend}},
+		{method: http.MethodPost, path: "/wallet/watch", scopes: []modules.APITokenScope{modules.APITokenScopeWalletSpend}},
+		{path: "/tpool/replace", scopes: []modules.APITokenScope{modules.APITokenScopeWalletSpend}},
+	}
+)
+
+// matches returns true if the route covers the provided request.
+func (r apiTokenRoute) matches(req *http.Request) bool {
+	if r.method != "" && r.method != req.Method {
+		return false
+	}
+	path := req.URL.Path
+	if strings.HasSuffix(r.path, "/") {
+		return strings.HasPrefix(path, r.path)
+	}
+	return path == r.path || strings.HasPrefix(path, r.path+"/")
+}
+
+// apiTokenAuthorized returns true if the token grants access to the provided
+// password protected request.
+func apiTokenAuthorized(token modules.APIToken, req *http.Request) bool {
+	for _, route := range apiTokenRoutes {
+		if route.matches(req) {
+			return token.HasScope(route.scopes...)
+		}
+	}
+	return false
+}
+
+// requestAPIToken returns the API token the request was authenticated with.
+func requestAPIToken(req *http.Request) (modules.APIToken, bool) {
+	token, ok := req.Context().Value(apiTokenKey{}).(modules.APIToken)
+	return token, ok
+}
+
+// requireAPIToken is middleware that authenticates requests which use an API
+// token instead of the API password. The token is passed on to RequirePassword
+// which checks its scopes for password protected calls.
+func (api *API) requireAPIToken(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, pass, ok := req.BasicAuth()
+		if !ok || pass == "" || pass == api.requiredPassword || api.siadConfig == nil {
+			h.ServeHTTP(w, req)
+			return
+		}
+		if token, exists := api.siadConfig.APITokenBySecret(pass); exists {
+			req = req.WithContext(context.WithValue(req.Context(), apiTokenKey{}, token))
+		}
+		h.ServeHTTP(w, req)
+	})
+}
+
+// daemonTokensHandlerGET handles the API call to list the API tokens.
+func (api *API) daemonTokensHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, DaemonTokensGET{
+		Tokens: api.siadConfig.CurrentAPITokens(),
+	})
+}
+
+// daemonTokensHandlerPOST handles the API call to create an API token.
+func (api *API) daemonTokensHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	scopes, err := modules.ParseAPITokenScopes(req.FormValue("scopes"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse scopes: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	token, secret, err := api.siadConfig.CreateAPIToken(req.FormValue("name"), scopes)
+	if err != nil {
+		WriteError(w, Error{"failed to create API token: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, DaemonTokensPOST{
+		Token:  token,
+		Secret: secret,
+	})
+}
+
+// daemonTokensRevokeHandlerPOST handles the API call to revoke an API token.
+func (api *API) daemonTokensRevokeHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	id := req.FormValue("id")
+	if id == "" {
+		WriteError(w, Error{"id needs to be specified"}, http.StatusBadRequest)
+		return
+	}
+	err := api.siadConfig.RevokeAPIToken(id)
+	if errors.Contains(err, modules.ErrAPITokenNotFound) {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	} else if err != nil {
+		WriteError(w, Error{"failed to revoke API token: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteSuccess(w)
+}
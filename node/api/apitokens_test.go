@@ -0,0 +1,123 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+)
+
+// TestAPITokenAuthorized is a unit test for apiTokenAuthorized.
+func TestAPITokenAuthorized(t *testing.T) {
+	t.Parallel()
+	read := modules.APIToken{Scopes: []modules.APITokenScope{modules.APITokenScopeRead}}
+	spend := modules.APIToken{Scopes: []modules.APITokenScope{modules.APITokenScopeWalletSpend}}
+	host := modules.APIToken{Scopes: []modules.APITokenScope{modules.APITokenScopeHostAdmin}}
+	renter := modules.APIToken{Scopes: []modules.APITokenScope{modules.APITokenScopeRenterWrite}}
+	tests := []struct {
+		token      modules.APIToken
+		method     string
+		path       string
+		authorized bool
+	}{
+		{read, http.MethodGet, "/wallet/unspent", true},
+		{read, http.MethodGet, "/wallet/unlockconditions/abc", true},
+		{read, http.MethodPost, "/wallet/siacoins", false},
+		{read, http.MethodGet, "/wallet/seeds", false},
+		{read, http.MethodGet, "/renter/backups", true},
+		{read, http.MethodPost, "/renter/backups/create", false},
+		{spend, http.MethodPost, "/wallet/siacoins", true},
+		{spend, http.MethodGet, "/wallet/address", true},
+		{spend, http.MethodPost, "/wallet/watch", true},
+		{spend, http.MethodPost, "/tpool/replace", true},
+		{spend, http.MethodPost, "/wallet/unlock", false},
+		{spend, http.MethodPost, "/host", false},
+		{host, http.MethodPost, "/host", true},
+		{host, http.MethodPost, "/host/storage/folders/add", true},
+		{host, http.MethodPost, "/hostdb/filtermode", false},
+		{host, http.MethodPost, "/hostess", false},
+		{renter, http.MethodPost, "/renter/upload/foo", true},
+		{renter, http.MethodPost, "/hostdb/filtermode", true},
+		{renter, http.MethodGet, "/renter/contractkeys", false},
+		{renter, http.MethodPost, "/daemon/tokens", false},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, test.path, nil)
+		if authorized := apiTokenAuthorized(test.token, req); authorized != test.authorized {
+			t.Errorf("%v %v %v: expected %v but got %v", test.token.Scopes, test.method, test.path, test.authorized, authorized)
+		}
+	}
+}
+
+// TestRequireAPIToken checks that password protected routes can be accessed
+// with API tokens which carry the required scope.
+func TestRequireAPIToken(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	testDir := build.TempDir("api", t.Name())
+	if err := os.MkdirAll(testDir, persist.DefaultDiskPermissionsTest); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := modules.NewConfig(filepath.Join(testDir, modules.ConfigName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, readSecret, err := cfg.CreateAPIToken("read", []modules.APITokenScope{modules.APITokenScopeRead})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, spendSecret, err := cfg.CreateAPIToken("spend", []modules.APITokenScope{modules.APITokenScopeWalletSpend})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	password := "password"
+	api := &API{requiredPassword: password, siadConfig: cfg}
+	success := func(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+		WriteSuccess(w)
+	}
+	router := httprouter.New()
+	router.GET("/wallet/unspent", RequirePassword(success, password))
+	router.POST("/wallet/siacoins", RequirePassword(success, password))
+	router.GET("/wallet/seeds", RequirePassword(success, password))
+	router.GET("/consensus", success)
+	handler := api.requireAPIToken(router)
+
+	tests := []struct {
+		pass   string
+		method string
+		path   string
+		status int
+	}{
+		{password, http.MethodGet, "/wallet/seeds", http.StatusNoContent},
+		{readSecret, http.MethodGet, "/wallet/unspent", http.StatusNoContent},
+		{readSecret, http.MethodPost, "/wallet/siacoins", http.StatusForbidden},
+		{spendSecret, http.MethodGet, "/wallet/unspent", http.StatusNoContent},
+		{spendSecret, http.MethodPost, "/wallet/siacoins", http.StatusNoContent},
+		{spendSecret, http.MethodGet, "/wallet/seeds", http.StatusForbidden},
+		{"unknown", http.MethodGet, "/wallet/unspent", http.StatusUnauthorized},
+		{"", http.MethodGet, "/wallet/unspent", http.StatusUnauthorized},
+		{"unknown", http.MethodGet, "/consensus", http.StatusNoContent},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, test.path, nil)
+		if test.pass != "" {
+			req.SetBasicAuth("", test.pass)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != test.status {
+			t.Errorf("%v %v: expected status %v but got %v", test.method, test.path, test.status, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+const (
+	// APIVersionHeader is the header a client uses to request a version of
+	// the API's response schemas. The server answers with the negotiated
+	// version in the same header.
+	APIVersionHeader = "Sia-API-Version"
+
+	// APIStrictHeader is the header a client sets to "true" to enable strict
+	// mode. In strict mode JSON responses contain the negotiated schema
+	// version and omit the fields deprecated in that version.
+	APIStrictHeader = "Sia-API-Strict"
+
+	// APIVersionLegacy is the schema version used for clients which don't
+	// request a version. It includes all deprecated fields.
+	APIVersionLegacy uint64 = 1
+
+	// APIVersion is the current schema version of the API's responses.
+	APIVersion uint64 = 2
+
+	// deprecatedTag is the struct tag which marks a field of a response as
+	// deprecated. Its value is the schema version the field was deprecated
+	// in, e.g. `deprecated:"2"`. Deprecated fields are kept in responses
+	// unless the client opts into strict mode.
+	deprecatedTag = "deprecated"
+
+	// schemaVersionField is the field of strict JSON responses which contains
+	// the schema version.
+	schemaVersionField = "schemaversion"
+)
+
+var (
+	// errInvalidAPIVersion is returned if the client requests an invalid
+	// schema version.
+	errInvalidAPIVersion = errors.New("invalid " + APIVersionHeader + " header")
+
+	// errInvalidAPIStrict is returned if the strict mode header can't be
+	// parsed.
+	errInvalidAPIStrict = errors.New("invalid " + APIStrictHeader + " header")
+
+	// jsonMarshalerType is the type of the json.Marshaler interface.
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+type (
+	// versionedResponseWriter is a http.ResponseWriter which carries the
+	// schema version negotiated for a request to the response helpers.
+	versionedResponseWriter struct {
+		http.ResponseWriter
+		staticStrict  bool
+		staticVersion uint64
+	}
+
+	// schemaField is a field of a struct as it appears in its JSON encoding.
+	schemaField struct {
+		deprecated uint64
+		name       string
+		typ        reflect.Type
+	}
+)
+
+// Flush implements http.Flusher if the underlying ResponseWriter does.
+func (w *versionedResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker which is required by the WebSocket
+// endpoints.
+func (w *versionedResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer doesn't support hijacking")
+	}
+	return h.Hijack()
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (w *versionedResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// negotiateAPIVersion returns the schema version and strict mode requested by
+// the client. Clients which request a version newer than the current one are
+// served the current version.
+func negotiateAPIVersion(req *http.Request) (version uint64, strict bool, err error) {
+	version = APIVersionLegacy
+	if v := strings.TrimSpace(req.Header.Get(APIVersionHeader)); v != "" {
+		version, err = strconv.ParseUint(v, 10, 64)
+		if err != nil || version < APIVersionLegacy {
+			return 0, false, errInvalidAPIVersion
+		}
+		if version > APIVersion {
+			version = APIVersion
+		}
+	}
+	if s := strings.TrimSpace(req.Header.Get(APIStrictHeader)); s != "" {
+		strict, err = strconv.ParseBool(s)
+		if err != nil {
+			return 0, false, errInvalidAPIStrict
+		}
+	}
+	return version, strict, nil
+}
+
+// RequireAPIVersion is middleware that negotiates the schema version of the
+// responses with the client and reports it in the APIVersionHeader of the
+// response.
+func RequireAPIVersion(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		version, strict, err := negotiateAPIVersion(req)
+		if err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set(APIVersionHeader, strconv.FormatUint(version, 10))
+		h.ServeHTTP(&versionedResponseWriter{
+			ResponseWriter: w,
+			staticStrict:   strict,
+			staticVersion:  version,
+		}, req)
+	})
+}
+
+// schemaFields returns the fields of a struct type as they appear in its JSON
+// encoding. The fields of embedded structs without a JSON name are inlined.
+func schemaFields(t reflect.Type) []schemaField {
+	var fields []schemaField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || (f.PkgPath != "" && !f.Anonymous) {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			fields = append(fields, schemaFields(ft)...)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		deprecated, _ := strconv.ParseUint(f.Tag.Get(deprecatedTag), 10, 64)
+		fields = append(fields, schemaField{
+			deprecated: deprecated,
+			name:       name,
+			typ:        f.Type,
+		})
+	}
+	return fields
+}
+
+// removeDeprecatedFields removes the fields deprecated in the provided schema
+// version from the decoded JSON value v of type t.
+func removeDeprecatedFields(t reflect.Type, v interface{}, version uint64) interface{} {
+	if t == nil || v == nil {
+		return v
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	// Types with a custom encoding don't reflect their fields.
+	if t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType) {
+		return v
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return v
+		}
+		for _, f := range schemaFields(t) {
+			fv, exists := obj[f.name]
+			if !exists {
+				continue
+			}
+			if f.deprecated != 0 && f.deprecated <= version {
+				delete(obj, f.name)
+				continue
+			}
+			obj[f.name] = removeDeprecatedFields(f.typ, fv, version)
+		}
+	case reflect.Array, reflect.Slice:
+		arr, ok := v.([]interface{})
+		if !ok {
+			return v
+		}
+		for i := range arr {
+			arr[i] = removeDeprecatedFields(t.Elem(), arr[i], version)
+		}
+	case reflect.Map:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return v
+		}
+		for k := range obj {
+			obj[k] = removeDeprecatedFields(t.Elem(), obj[k], version)
+		}
+	}
+	return v
+}
+
+// strictResponse returns the strict encoding of a response object for the
+// provided schema version. The deprecated fields are removed and JSON objects
+// contain the schema version.
+func strictResponse(obj interface{}, version uint64) (interface{}, error) {
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	// Decode numbers as json.Number to not lose precision.
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %v", err)
+	}
+	v = removeDeprecatedFields(reflect.TypeOf(obj), v, version)
+	if m, ok := v.(map[string]interface{}); ok {
+		m[schemaVersionField] = version
+	}
+	return v, nil
+}
@@ -0,0 +1,207 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.sia.tech/siad/types"
+)
+
+// TestNegotiateAPIVersion is a unit test for negotiateAPIVersion.
+func TestNegotiateAPIVersion(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		version  string
+		strict   string
+		result   uint64
+		isStrict bool
+		valid    bool
+	}{
+		{"", "", APIVersionLegacy, false, true},
+		{"1", "true", 1, true, true},
+		{"2", "false", 2, false, true},
+		{" 2 ", "", 2, false, true},
+		{"1000", "1", APIVersion, true, true},
+		{"0", "", 0, false, false},
+		{"two", "", 0, false, false},
+		{"", "maybe", 0, false, false},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(APIVersionHeader, test.version)
+		req.Header.Set(APIStrictHeader, test.strict)
+		version, strict, err := negotiateAPIVersion(req)
+		if (err == nil) != test.valid {
+			t.Fatalf("%v %v: expected valid %v but got %v", test.version, test.strict, test.valid, err)
+		}
+		if version != test.result || strict != test.isStrict {
+			t.Fatalf("%v %v: expected %v %v but got %v %v", test.version, test.strict, test.result, test.isStrict, version, strict)
+		}
+	}
+}
+
+// TestStrictResponse checks that strict responses contain their schema version
+// and omit the fields deprecated in it.
+func TestStrictResponse(t *testing.T) {
+	t.Parallel()
+	type inner struct {
+		Old   uint64 `json:"old" deprecated:"2"`
+		Newer uint64 `json:"newer" deprecated:"3"`
+		Value uint64 `json:"value"`
+	}
+	type Embedded struct {
+		OldEmbedded string `json:"oldembedded" deprecated:"2"`
+	}
+	type response struct {
+		Embedded
+		Currency types.Currency   `json:"currency"`
+		Inner    inner            `json:"inner"`
+		List     []inner          `json:"list"`
+		Map      map[string]inner `json:"map"`
+		Pointer  *inner           `json:"pointer"`
+	}
+	i := inner{Old: 1, Newer: 2, Value: 1 << 60}
+	obj := response{
+		Embedded: Embedded{OldEmbedded: "old"},
+		Currency: types.SiacoinPrecision,
+		Inner:    i,
+		List:     []inner{i},
+		Map:      map[string]inner{"foo": i},
+		Pointer:  &i,
+	}
+
+	// decode is a helper to decode a strict response into a map.
+	decode := func(version uint64) map[string]interface{} {
+		v, err := strictResponse(obj, version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var m map[string]interface{}
+		if err := json.Unmarshal(b, &m); err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+
+	// The legacy version keeps all fields.
+	m := decode(APIVersionLegacy)
+	if m[schemaVersionField] != float64(APIVersionLegacy) || m["oldembedded"] != "old" {
+		t.Fatal("wrong legacy response", m)
+	}
+	if inner := m["inner"].(map[string]interface{}); inner["old"] == nil || inner["newer"] == nil {
+		t.Fatal("deprecated fields shouldn't be removed", inner)
+	}
+
+	// Version 2 removes the fields deprecated in version 2 everywhere.
+	m = decode(2)
+	if m[schemaVersionField] != float64(2) {
+		t.Fatal("wrong schema version", m[schemaVersionField])
+	}
+	if _, exists := m["oldembedded"]; exists {
+		t.Fatal("deprecated field of embedded struct wasn't removed")
+	}
+	if m["currency"] != types.SiacoinPrecision.String() {
+		t.Fatal("currency wasn't kept", m["currency"])
+	}
+	for _, v := range []interface{}{
+		m["inner"],
+		m["list"].([]interface{})[0],
+		m["map"].(map[string]interface{})["foo"],
+		m["pointer"],
+	} {
+		inner := v.(map[string]interface{})
+		if _, exists := inner["old"]; exists {
+			t.Fatal("deprecated field wasn't removed", inner)
+		}
+		if _, exists := inner["newer"]; !exists {
+			t.Fatal("field deprecated in a later version was removed", inner)
+		}
+	}
+
+	// Large numbers don't lose precision.
+	v, err := strictResponse(obj, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res response
+	if err := json.Unmarshal(b, &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Inner.Value != i.Value {
+		t.Fatal("value lost precision", res.Inner.Value)
+	}
+}
+
+// TestRequireAPIVersion checks that the middleware negotiates the schema
+// version and that WriteJSON only uses strict responses if requested.
+func TestRequireAPIVersion(t *testing.T) {
+	t.Parallel()
+	ts := httptest.NewServer(RequireAPIVersion(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		WriteJSON(w, GatewayBlocklistGET{
+			Blacklist: []string{},
+			Blocklist: []string{},
+		})
+	})))
+	defer ts.Close()
+
+	// get performs a GET request with the provided headers and returns the
+	// negotiated version and the decoded response.
+	get := func(version, strict string) (string, int, map[string]interface{}) {
+		req, err := http.NewRequest("GET", ts.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set(APIVersionHeader, version)
+		req.Header.Set(APIStrictHeader, strict)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var m map[string]interface{}
+		if err := json.NewDecoder(resp.Body).Decode(&m); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Header.Get(APIVersionHeader), resp.StatusCode, m
+	}
+
+	// Without strict mode the deprecated fields are kept.
+	version, code, m := get("2", "")
+	if code != http.StatusOK || version != "2" {
+		t.Fatal("wrong response", code, version)
+	}
+	if _, exists := m["blacklist"]; !exists {
+		t.Fatal("deprecated field should be kept")
+	}
+	if _, exists := m[schemaVersionField]; exists {
+		t.Fatal("schema version should only be part of strict responses")
+	}
+
+	// In strict mode they are removed.
+	version, code, m = get("", "true")
+	if code != http.StatusOK || version != "1" || m[schemaVersionField] != float64(1) {
+		t.Fatal("wrong response", code, version, m)
+	}
+	if _, exists := m["blacklist"]; !exists {
+		t.Fatal("field isn't deprecated in the legacy version")
+	}
+	_, _, m = get("2", "true")
+	if _, exists := m["blacklist"]; exists || m[schemaVersionField] != float64(2) {
+		t.Fatal("deprecated field should be removed", m)
+	}
+
+	// Invalid versions are rejected.
+	if _, code, _ := get("0", ""); code != http.StatusBadRequest {
+		t.Fatal("expected invalid version to be rejected", code)
+	}
+}
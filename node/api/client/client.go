@@ -7,6 +7,7 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"go.sia.tech/siad/build"
@@ -34,6 +35,14 @@ type (
 		// set, it defaults to "Sia-Agent".
 		UserAgent string
 
+		// APIVersion is the schema version of the responses requested from
+		// the siad server. If not set, the legacy schema is used.
+		APIVersion uint64
+
+		// Strict enables the server's strict mode. Responses then contain
+		// their schema version and omit the fields deprecated in it.
+		Strict bool
+
 		// CheckRedirect is an optional handler to be called if the request
 		// receives a redirect status code.
 		// For more see https://golang.org/pkg/net/http/#Client
@@ -120,6 +129,12 @@ func (c *Client) NewRequest(method, resource string, body io.Reader) (*http.Requ
 		agent = "Sia-Agent"
 	}
 	req.Header.Set("User-Agent", agent)
+	if c.APIVersion != 0 {
+		req.Header.Set(api.APIVersionHeader, strconv.FormatUint(c.APIVersion, 10))
+	}
+	if c.Strict {
+		req.Header.Set(api.APIStrictHeader, "true")
+	}
 	if c.Password != "" {
 		req.SetBasicAuth("", c.Password)
 	}
@@ -6,9 +6,13 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"gitlab.com/NebulousLabs/encoding"
+	"golang.org/x/net/websocket"
+
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node/api"
 	"go.sia.tech/siad/types"
@@ -72,6 +76,32 @@ func (c *Client) ConsensusSubscribeSingle(subscriber modules.ConsensusSetSubscri
 	}
 }
 
+// ConsensusStreamSubscribe opens a WebSocket connection to the
+// /consensus/stream endpoint which streams the consensus changes after the
+// change with the provided id. An empty id streams the changes from now on.
+// The outputs of the provided addresses are listed individually. The changes
+// can be received using websocket.JSON.Receive and the connection needs to be
+// closed by the caller.
+func (c *Client) ConsensusStreamSubscribe(changeID string, addresses ...types.UnlockHash) (*websocket.Conn, error) {
+	strs := make([]string, 0, len(addresses))
+	for _, uh := range addresses {
+		strs = append(strs, uh.String())
+	}
+	values := url.Values{}
+	values.Set("changeid", changeID)
+	values.Set("addresses", strings.Join(strs, ","))
+	config, err := websocket.NewConfig("ws://"+c.Address+"/consensus/stream?"+values.Encode(), "http://"+c.Address)
+	if err != nil {
+		return nil, err
+	}
+	req, err := c.NewRequest("GET", "/consensus/stream", nil)
+	if err != nil {
+		return nil, err
+	}
+	config.Header = req.Header
+	return websocket.DialConfig(config)
+}
+
 // ConsensusSetSubscribe polls the /consensus/subscribe endpoint, streaming
 // consensus changes to the subscriber indefinitely. First, it will stream
 // changes until the subscriber is fully caught up. It will send any error
@@ -1,10 +1,19 @@
 package client
 
 import (
+	"encoding/json"
+	"fmt"
+	"math"
 	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
+	"golang.org/x/net/websocket"
+
+	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node/api"
+	"go.sia.tech/siad/persist"
 )
 
 // DaemonGlobalRateLimitPost uses the /daemon/settings endpoint to change the
@@ -18,12 +27,178 @@ func (c *Client) DaemonGlobalRateLimitPost(downloadSpeed, uploadSpeed int64) (er
 	return
 }
 
+// DaemonLogSettingsPost uses the /daemon/settings endpoint to change the
+// daemon's log settings.
+func (c *Client) DaemonLogSettingsPost(settings persist.LogSettings) (err error) {
+	levels := make([]string, 0, len(settings.ModuleLevels))
+	for module, level := range settings.ModuleLevels {
+		levels = append(levels, fmt.Sprintf("%v:%v", module, level))
+	}
+	values := url.Values{}
+	values.Set("logformat", settings.Format)
+	values.Set("loglevel", string(settings.Level))
+	values.Set("logmodulelevels", strings.Join(levels, ","))
+	values.Set("logmaxsize", strconv.FormatInt(settings.MaxSize, 10))
+	values.Set("logmaxbackups", strconv.Itoa(settings.MaxBackups))
+	err = c.post("/daemon/settings", values.Encode(), nil)
+	return
+}
+
 // DaemonAlertsGet requests the /daemon/alerts resource.
 func (c *Client) DaemonAlertsGet() (dag api.DaemonAlertsGet, err error) {
 	err = c.get("/daemon/alerts", &dag)
 	return
 }
 
+// DaemonAlertsIncludeMutedGet requests the /daemon/alerts resource including
+// muted alerts.
+func (c *Client) DaemonAlertsIncludeMutedGet() (dag api.DaemonAlertsGet, err error) {
+	err = c.get("/daemon/alerts?includemuted=true", &dag)
+	return
+}
+
+// DaemonAlertsAcknowledgePost uses the /daemon/alerts/acknowledge endpoint to
+// acknowledge an alert.
+func (c *Client) DaemonAlertsAcknowledgePost(id modules.AlertID) (err error) {
+	values := url.Values{}
+	values.Set("id", string(id))
+	err = c.post("/daemon/alerts/acknowledge", values.Encode(), nil)
+	return
+}
+
+// DaemonAlertsMutePost uses the /daemon/alerts/mute endpoint to mute or unmute
+// an alert.
+func (c *Client) DaemonAlertsMutePost(id modules.AlertID, mute bool) (err error) {
+	values := url.Values{}
+	values.Set("id", string(id))
+	values.Set("mute", strconv.FormatBool(mute))
+	err = c.post("/daemon/alerts/mute", values.Encode(), nil)
+	return
+}
+
+// DaemonAlertsRoutesGet requests the /daemon/alerts/routes resource.
+func (c *Client) DaemonAlertsRoutesGet() (darg api.DaemonAlertRoutesGet, err error) {
+	err = c.get("/daemon/alerts/routes", &darg)
+	return
+}
+
+// DaemonAlertsRoutesPost uses the /daemon/alerts/routes endpoint to replace
+// the configured alert routes.
+func (c *Client) DaemonAlertsRoutesPost(routes []modules.AlertRoute) (err error) {
+	data, err := json.Marshal(api.DaemonAlertRoutesPost{Routes: routes})
+	if err != nil {
+		return err
+	}
+	err = c.post("/daemon/alerts/routes", string(data), nil)
+	return
+}
+
+// DaemonDiskThrottlesGet requests the /daemon/diskthrottles resource.
+func (c *Client) DaemonDiskThrottlesGet() (ddtg api.DaemonDiskThrottlesGet, err error) {
+	err = c.get("/daemon/diskthrottles", &ddtg)
+	return
+}
+
+// DaemonDiskThrottlesPost uses the /daemon/diskthrottles endpoint to replace
+// the disk I/O limits of all volumes.
+func (c *Client) DaemonDiskThrottlesPost(volumes []persist.DiskIOLimits) (err error) {
+	data, err := json.Marshal(api.DaemonDiskThrottlesPost{Volumes: volumes})
+	if err != nil {
+		return err
+	}
+	err = c.post("/daemon/diskthrottles", string(data), nil)
+	return
+}
+
+// DaemonProfileGet requests the /daemon/profile resource.
+func (c *Client) DaemonProfileGet() (dpg api.DaemonProfileGet, err error) {
+	err = c.get("/daemon/profile", &dpg)
+	return
+}
+
+// DaemonProfilePost uses the /daemon/profile endpoint to set the daemon
+// profile.
+func (c *Client) DaemonProfilePost(profile string) (err error) {
+	data, err := json.Marshal(api.DaemonProfilePost{Profile: profile})
+	if err != nil {
+		return err
+	}
+	err = c.post("/daemon/profile", string(data), nil)
+	return
+}
+
+// DaemonProxyGet requests the /daemon/proxy resource.
+func (c *Client) DaemonProxyGet() (dpg api.DaemonProxyGet, err error) {
+	err = c.get("/daemon/proxy", &dpg)
+	return
+}
+
+// DaemonProxyPost uses the /daemon/proxy endpoint to set the outbound proxy.
+func (c *Client) DaemonProxyPost(settings modules.ProxySettings) (err error) {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	err = c.post("/daemon/proxy", string(data), nil)
+	return
+}
+
+// DaemonEventsSubscribe opens a WebSocket connection to the /daemon/events
+// endpoint which streams the events of the given types. If no types are
+// provided, all events are streamed. The events can be received using
+// websocket.JSON.Receive and the connection needs to be closed by the caller.
+func (c *Client) DaemonEventsSubscribe(eventTypes ...modules.EventType) (*websocket.Conn, error) {
+	strs := make([]string, 0, len(eventTypes))
+	for _, t := range eventTypes {
+		strs = append(strs, string(t))
+	}
+	values := url.Values{}
+	values.Set("types", strings.Join(strs, ","))
+	config, err := websocket.NewConfig("ws://"+c.Address+"/daemon/events?"+values.Encode(), "http://"+c.Address)
+	if err != nil {
+		return nil, err
+	}
+	req, err := c.NewRequest("GET", "/daemon/events", nil)
+	if err != nil {
+		return nil, err
+	}
+	config.Header = req.Header
+	return websocket.DialConfig(config)
+}
+
+// DaemonEventsRoutesGet requests the /daemon/events/routes resource.
+func (c *Client) DaemonEventsRoutesGet() (derg api.DaemonEventRoutesGet, err error) {
+	err = c.get("/daemon/events/routes", &derg)
+	return
+}
+
+// DaemonEventsRoutesPost uses the /daemon/events/routes endpoint to replace
+// the configured event routes.
+func (c *Client) DaemonEventsRoutesPost(routes []modules.EventRoute) (err error) {
+	data, err := json.Marshal(api.DaemonEventRoutesPost{Routes: routes})
+	if err != nil {
+		return err
+	}
+	err = c.post("/daemon/events/routes", string(data), nil)
+	return
+}
+
+// DaemonMaintenanceQuiescePost uses the /daemon/maintenance/quiesce endpoint to
+// quiesce the writers of the daemon for at most the provided duration.
+func (c *Client) DaemonMaintenanceQuiescePost(duration time.Duration) (dmqp api.DaemonMaintenanceQuiescePOST, err error) {
+	values := url.Values{}
+	values.Set("duration", fmt.Sprint(uint64(math.Round(duration.Seconds()))))
+	err = c.post("/daemon/maintenance/quiesce", values.Encode(), &dmqp)
+	return
+}
+
+// DaemonMaintenanceResumePost uses the /daemon/maintenance/resume endpoint to
+// resume the writers of the daemon.
+func (c *Client) DaemonMaintenanceResumePost() (dmrp api.DaemonMaintenanceResumePOST, err error) {
+	err = c.post("/daemon/maintenance/resume", "", &dmrp)
+	return
+}
+
 // DaemonVersionGet requests the /daemon/version resource.
 func (c *Client) DaemonVersionGet() (dvg api.DaemonVersionGet, err error) {
 	err = c.get("/daemon/version", &dvg)
@@ -63,6 +238,35 @@ func (c *Client) DaemonStopGet() (err error) {
 	return
 }
 
+// DaemonTokensGet uses the /daemon/tokens endpoint to list the API tokens.
+func (c *Client) DaemonTokensGet() (dtg api.DaemonTokensGET, err error) {
+	err = c.get("/daemon/tokens", &dtg)
+	return
+}
+
+// DaemonTokensPost uses the /daemon/tokens endpoint to create an API token with
+// the provided name and scopes.
+func (c *Client) DaemonTokensPost(name string, scopes ...modules.APITokenScope) (dtp api.DaemonTokensPOST, err error) {
+	strs := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		strs = append(strs, string(scope))
+	}
+	values := url.Values{}
+	values.Set("name", name)
+	values.Set("scopes", strings.Join(strs, ","))
+	err = c.post("/daemon/tokens", values.Encode(), &dtp)
+	return
+}
+
+// DaemonTokensRevokePost uses the /daemon/tokens/revoke endpoint to revoke the
+// API token with the provided id.
+func (c *Client) DaemonTokensRevokePost(id string) (err error) {
+	values := url.Values{}
+	values.Set("id", id)
+	err = c.post("/daemon/tokens/revoke", values.Encode(), nil)
+	return
+}
+
 // DaemonUpdateGet checks for an available daemon update.
 func (c *Client) DaemonUpdateGet() (dig api.DaemonUpdateGet, err error) {
 	err = c.get("/daemon/update", &dig)
@@ -74,3 +278,10 @@ func (c *Client) DaemonUpdatePost() (err error) {
 	err = c.post("/daemon/update", "", nil)
 	return
 }
+
+// MetricsGet requests the /metrics api resource and returns the metrics of
+// the daemon in the Prometheus text format.
+func (c *Client) MetricsGet() ([]byte, error) {
+	_, metrics, err := c.getRawResponse("/metrics")
+	return metrics, err
+}
@@ -47,6 +47,12 @@ func (c *Client) GatewayGet() (gwg api.GatewayGET, err error) {
 	return
 }
 
+// GatewayPeersGet requests the /gateway/peers api resource
+func (c *Client) GatewayPeersGet() (gpg api.GatewayPeersGET, err error) {
+	err = c.get("/gateway/peers", &gpg)
+	return
+}
+
 // GatewayRateLimitPost uses the /gateway endpoint to change the gateway's
 // bandwidth rate limit. downloadSpeed and uploadSpeed are interpreted as
 // bytes/second.
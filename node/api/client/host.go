@@ -1,9 +1,11 @@
 package client
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"strconv"
+	"time"
 
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
@@ -53,6 +55,9 @@ const (
 	HostParamMaxReviseBatchSize = HostParam("maxrevisebatchsize")
 	// HostParamNetAddress is the announced netaddress of the host.
 	HostParamNetAddress = HostParam("netaddress")
+	// HostParamAdditionalNetAddresses is a comma-separated list of addresses
+	// the host announces in addition to its netaddress.
+	HostParamAdditionalNetAddresses = HostParam("additionalnetaddresses")
 	// HostParamEphemeralAccountExpiry is the maximum amount of time an
 	// ephemeral account can be inactive before it expires and gets deleted.
 	HostParamEphemeralAccountExpiry = HostParam("ephemeralaccountexpiry")
@@ -68,6 +73,43 @@ const (
 	// HostParamCustomRegistryPath is the locataion of the host's registry on
 	// disk.
 	HostParamCustomRegistryPath = HostParam("customregistrypath")
+	// HostParamBatchStorageProofs indicates if the host submits storage
+	// proofs with aligned proof windows in a single transaction.
+	HostParamBatchStorageProofs = HostParam("batchstorageproofs")
+	// HostParamCollateralBudgetAlertThreshold is the remaining collateral
+	// budget in hastings below which the host registers an alert.
+	HostParamCollateralBudgetAlertThreshold = HostParam("collateralbudgetalertthreshold")
+	// HostParamWalletBalanceAlertThreshold is the confirmed wallet balance in
+	// hastings below which the host registers an alert.
+	HostParamWalletBalanceAlertThreshold = HostParam("walletbalancealertthreshold")
+	// HostParamMaxProofFee is the maximum fee of a storage proof or final
+	// revision transaction in hastings.
+	HostParamMaxProofFee = HostParam("maxprooffee")
+	// HostParamProofFeeMultiplier is multiplied with the estimated fee of
+	// the host's storage proof and final revision transactions.
+	HostParamProofFeeMultiplier = HostParam("prooffeemultiplier")
+	// HostParamWriteBatchMaxLatency is the max number of milliseconds a
+	// write program waits to commit its storage obligation together with
+	// other write programs.
+	HostParamWriteBatchMaxLatency = HostParam("writebatchmaxlatency")
+	// HostParamPriceTableGracePeriod is the number of seconds after a price
+	// table expired during which the host still accepts it.
+	HostParamPriceTableGracePeriod = HostParam("pricetablegraceperiod")
+	// HostParamReadCacheSize is the memory budget in bytes of the host's
+	// sector cache.
+	HostParamReadCacheSize = HostParam("readcachesize")
+	// HostParamMaxProgramInstructions is the max number of instructions of
+	// a program the host executes.
+	HostParamMaxProgramInstructions = HostParam("maxprograminstructions")
+	// HostParamMaxProgramDataLength is the max length in bytes of the data
+	// of a program the host executes.
+	HostParamMaxProgramDataLength = HostParam("maxprogramdatalength")
+	// HostParamMaxProgramDuration is the max number of seconds the host
+	// spends executing a single program.
+	HostParamMaxProgramDuration = HostParam("maxprogramduration")
+	// HostParamMonitoringAddress is the address of the host's plain HTTP
+	// monitoring endpoint. An empty address disables it.
+	HostParamMonitoringAddress = HostParam("monitoringaddress")
 )
 
 // HostAnnouncePost uses the /host/announce endpoint to announce the host to
@@ -84,6 +126,19 @@ func (c *Client) HostAnnounceAddrPost(address modules.NetAddress) (err error) {
 	return
 }
 
+// HostAnnounceDryRunPost uses the /host/announce endpoint to build the
+// announcement transaction for the provided address without submitting it. If
+// the address is empty, the host's current address is used.
+func (c *Client) HostAnnounceDryRunPost(address modules.NetAddress) (preview modules.HostAnnouncementPreview, err error) {
+	values := url.Values{}
+	values.Set("dryrun", "true")
+	if address != "" {
+		values.Set("netaddress", string(address))
+	}
+	err = c.post("/host/announce", values.Encode(), &preview)
+	return
+}
+
 // HostContractInfoGet uses the /host/contracts endpoint to get information
 // about contracts on the host.
 func (c *Client) HostContractInfoGet() (cg api.ContractInfoGET, err error) {
@@ -98,6 +153,80 @@ func (c *Client) HostContractGet(obligationID types.FileContractID) (cg api.Host
 	return
 }
 
+// HostMetricsReconciliationGet uses the /host/metrics/reconciliation endpoint
+// to get the result of reconciling the host's financial metrics with the
+// metrics journal at startup.
+func (c *Client) HostMetricsReconciliationGet() (mr modules.MetricsReconciliation, err error) {
+	err = c.get("/host/metrics/reconciliation", &mr)
+	return
+}
+
+// HostAlertsGet uses the /host/alerts endpoint to get the alerts of the host.
+func (c *Client) HostAlertsGet() (hag api.HostAlertsGET, err error) {
+	err = c.get("/host/alerts", &hag)
+	return
+}
+
+// HostMissedProofsGet uses the /host/missedproofs endpoint to get the storage
+// proofs the host missed.
+func (c *Client) HostMissedProofsGet() (mpg api.HostMissedProofsGET, err error) {
+	err = c.get("/host/missedproofs", &mpg)
+	return
+}
+
+// HostObligationsGet uses the /host/obligations endpoint to get a summary of
+// the host's storage obligations with the proof deadlines grouped into windows
+// of the provided number of blocks.
+func (c *Client) HostObligationsGet(window types.BlockHeight) (summary modules.HostObligationsSummary, err error) {
+	values := url.Values{}
+	values.Set("window", fmt.Sprint(window))
+	err = c.get("/host/obligations?"+values.Encode(), &summary)
+	return
+}
+
+// HostProofFeesGet uses the /host/prooffees endpoint to get the fees the host
+// spent on storage proof and final revision transactions between start and
+// end, grouped into periods of the provided number of blocks.
+func (c *Client) HostProofFeesGet(start, end, period types.BlockHeight) (report modules.HostProofFeeReport, err error) {
+	values := url.Values{}
+	values.Set("startheight", fmt.Sprint(start))
+	values.Set("endheight", fmt.Sprint(end))
+	values.Set("period", fmt.Sprint(period))
+	err = c.get("/host/prooffees?"+values.Encode(), &report)
+	return
+}
+
+// HostMDMStatsGet uses the /host/mdmstats endpoint to get the execution
+// statistics of the instructions the host's MDM executed.
+func (c *Client) HostMDMStatsGet() (msg modules.HostMDMMetrics, err error) {
+	err = c.get("/host/mdmstats", &msg)
+	return
+}
+
+// HostRPCStatsGet uses the /host/rpcstats endpoint to get the statistics of
+// the RPCs the host handled.
+func (c *Client) HostRPCStatsGet() (rsg modules.HostRPCTrace, err error) {
+	err = c.get("/host/rpcstats", &rsg)
+	return
+}
+
+// HostRescanGet uses the /host/rescan endpoint to get the progress of the most
+// recent rescan.
+func (c *Client) HostRescanGet() (rs modules.HostRescanStatus, err error) {
+	err = c.get("/host/rescan", &rs)
+	return
+}
+
+// HostRescanPost uses the /host/rescan endpoint to start a rescan of the
+// blocks between start and end.
+func (c *Client) HostRescanPost(start, end types.BlockHeight) (err error) {
+	values := url.Values{}
+	values.Set("startheight", fmt.Sprint(start))
+	values.Set("endheight", fmt.Sprint(end))
+	err = c.post("/host/rescan", values.Encode(), nil)
+	return
+}
+
 // HostEstimateScoreGet requests the /host/estimatescore endpoint.
 func (c *Client) HostEstimateScoreGet(param, value string) (eg api.HostEstimateScoreGET, err error) {
 	err = c.get(fmt.Sprintf("/host/estimatescore?%v=%v", param, value), &eg)
@@ -117,9 +246,54 @@ func (c *Client) HostModifySettingPost(param HostParam, value interface{}) (err
 	return
 }
 
+// HostSettingsPreviewPost uses the /host/settings/preview endpoint to preview
+// the effects of changing the given settings.
+func (c *Client) HostSettingsPreviewPost(values url.Values) (preview modules.HostSettingsPreview, err error) {
+	err = c.post("/host/settings/preview", values.Encode(), &preview)
+	return
+}
+
+// HostApplySettingsPost uses the /host endpoint to change the given settings
+// if the host's settings still match the previewed settings hash.
+func (c *Client) HostApplySettingsPost(values url.Values, settingsHash crypto.Hash) (err error) {
+	values.Set("settingshash", settingsHash.String())
+	err = c.post("/host", values.Encode(), nil)
+	return
+}
+
+// HostAccountTransactionsGet requests the /host/accounttransactions api
+// resource for the transactions of the given ephemeral account.
+func (c *Client) HostAccountTransactionsGet(id modules.AccountID) (hatg api.HostAccountTransactionsGET, err error) {
+	values := url.Values{}
+	values.Set("account", id.String())
+	err = c.get("/host/accounttransactions?"+values.Encode(), &hatg)
+	return
+}
+
+// HostAccountTransactionsExportGet requests the
+// /host/accounttransactions/export api resource and returns the CSV export of
+// the ephemeral account transactions recorded between start and end.
+func (c *Client) HostAccountTransactionsExportGet(start, end time.Time) ([]byte, error) {
+	values := url.Values{}
+	values.Set("start", fmt.Sprint(start.Unix()))
+	values.Set("end", fmt.Sprint(end.Unix()))
+	_, csv, err := c.getRawResponse("/host/accounttransactions/export?" + values.Encode())
+	return csv, err
+}
+
 // HostBandwidthGet requests the /host/bandwidth api resource
-func (c *Client) HostBandwidthGet() (gbg api.GatewayBandwidthGET, err error) {
-	err = c.get("/host/bandwidth", &gbg)
+func (c *Client) HostBandwidthGet() (hbg api.HostBandwidthGET, err error) {
+	err = c.get("/host/bandwidth", &hbg)
+	return
+}
+
+// HostBandwidthPeriodGet requests the /host/bandwidth api resource for the
+// bandwidth used between start and end.
+func (c *Client) HostBandwidthPeriodGet(start, end time.Time) (hbg api.HostBandwidthGET, err error) {
+	values := url.Values{}
+	values.Set("start", fmt.Sprint(start.Unix()))
+	values.Set("end", fmt.Sprint(end.Unix()))
+	err = c.get("/host/bandwidth?"+values.Encode(), &hbg)
 	return
 }
 
@@ -133,6 +307,27 @@ func (c *Client) HostStorageFoldersAddPost(path string, size uint64) (err error)
 	return
 }
 
+// HostStorageFoldersDrainPost uses the /host/storage/folders/drain api
+// endpoint to start draining a storage folder or to update the rate of its
+// drain.
+func (c *Client) HostStorageFoldersDrainPost(path string, sectorsPerSecond uint64) (err error) {
+	values := url.Values{}
+	values.Set("path", path)
+	values.Set("rate", strconv.FormatUint(sectorsPerSecond, 10))
+	err = c.post("/host/storage/folders/drain", values.Encode(), nil)
+	return
+}
+
+// HostStorageFoldersDrainPausePost uses the /host/storage/folders/drain api
+// endpoint to pause or resume the drain of a storage folder.
+func (c *Client) HostStorageFoldersDrainPausePost(path string, paused bool) (err error) {
+	values := url.Values{}
+	values.Set("path", path)
+	values.Set("paused", strconv.FormatBool(paused))
+	err = c.post("/host/storage/folders/drain", values.Encode(), nil)
+	return
+}
+
 // HostStorageFoldersRemovePost uses the /host/storage/folders/remove api
 // endpoint to remove a storage folder from a host.
 func (c *Client) HostStorageFoldersRemovePost(path string, force bool) (err error) {
@@ -159,6 +354,29 @@ func (c *Client) HostStorageGet() (sg api.StorageGET, err error) {
 	return
 }
 
+// HostPricingGet requests the /host/pricing endpoint.
+func (c *Client) HostPricingGet() (ps modules.HostPricingStatus, err error) {
+	err = c.get("/host/pricing", &ps)
+	return
+}
+
+// HostPricingPost uses the /host/pricing endpoint to set the host's pricing
+// policy.
+func (c *Client) HostPricingPost(policy modules.HostPricingPolicy) (err error) {
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+	err = c.post("/host/pricing", string(data), nil)
+	return
+}
+
+// HostStorageHealthGet requests the /host/storage/health endpoint.
+func (c *Client) HostStorageHealthGet() (sh modules.StorageHealth, err error) {
+	err = c.get("/host/storage/health", &sh)
+	return
+}
+
 // HostStorageSectorsDeletePost uses the /host/storage/sectors/delete endpoint
 // to delete a sector from the host.
 func (c *Client) HostStorageSectorsDeletePost(root crypto.Hash) (err error) {
@@ -1,7 +1,10 @@
 package client
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"net/url"
 
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node/api"
@@ -26,6 +29,33 @@ func (c *Client) HostDbAllGet() (hdag api.HostdbAllGET, err error) {
 	return
 }
 
+// HostDbPriceIndexGet requests the /hostdb/priceindex endpoint's resources.
+func (c *Client) HostDbPriceIndexGet(minHeight types.BlockHeight) (hdpig api.HostdbPriceIndexGET, err error) {
+	values := url.Values{}
+	values.Set("minheight", fmt.Sprint(minHeight))
+	err = c.get("/hostdb/priceindex?"+values.Encode(), &hdpig)
+	return
+}
+
+// HostDbExportGet requests the /hostdb/export endpoint's resources.
+func (c *Client) HostDbExportGet() (hdeg api.HostdbExportGET, err error) {
+	err = c.get("/hostdb/export", &hdeg)
+	return
+}
+
+// HostDbImportPost uses the /hostdb/import endpoint to restore the hostdb from
+// a dump or to merge the dump into it.
+func (c *Client) HostDbImportPost(dump modules.HostDBDump, merge bool) (err error) {
+	data, err := json.Marshal(dump)
+	if err != nil {
+		return err
+	}
+	values := url.Values{}
+	values.Set("merge", fmt.Sprint(merge))
+	_, _, err = c.postRawResponse("/hostdb/import?"+values.Encode(), bytes.NewReader(data))
+	return
+}
+
 // HostDbFilterModeGet requests the /hostdb/filtermode GET endpoint
 func (c *Client) HostDbFilterModeGet() (hdfmg api.HostdbFilterModeGET, err error) {
 	err = c.get("/hostdb/filtermode", &hdfmg)
@@ -53,3 +83,34 @@ func (c *Client) HostDbHostsGet(pk types.SiaPublicKey) (hhg api.HostdbHostsGET,
 	err = c.get("/hostdb/hosts/"+pk.String(), &hhg)
 	return
 }
+
+// HostDbHostHistoryGet requests the /hostdb/host/:pubkey/history endpoint's
+// resources.
+func (c *Client) HostDbHostHistoryGet(pk types.SiaPublicKey) (hhhg api.HostdbHostHistoryGET, err error) {
+	err = c.get(fmt.Sprintf("/hostdb/host/%s/history", pk.String()), &hhhg)
+	return
+}
+
+// HostDbSimulateGet requests the /hostdb/simulate endpoint's resources. The
+// allowance is replayed against the recorded settings of the hosts of the
+// last 'weeks' weeks.
+func (c *Client) HostDbSimulateGet(a modules.Allowance, weeks uint64) (hdsg api.HostdbSimulateGET, err error) {
+	values := url.Values{}
+	values.Set("weeks", fmt.Sprint(weeks))
+	values.Set("funds", a.Funds.String())
+	values.Set("hosts", fmt.Sprint(a.Hosts))
+	values.Set("period", fmt.Sprint(a.Period))
+	values.Set("renewwindow", fmt.Sprint(a.RenewWindow))
+	values.Set("expectedstorage", fmt.Sprint(a.ExpectedStorage))
+	values.Set("expectedupload", fmt.Sprint(a.ExpectedUpload))
+	values.Set("expecteddownload", fmt.Sprint(a.ExpectedDownload))
+	values.Set("expectedredundancy", fmt.Sprint(a.ExpectedRedundancy))
+	values.Set("maxrpcprice", a.MaxRPCPrice.String())
+	values.Set("maxcontractprice", a.MaxContractPrice.String())
+	values.Set("maxdownloadbandwidthprice", a.MaxDownloadBandwidthPrice.String())
+	values.Set("maxsectoraccessprice", a.MaxSectorAccessPrice.String())
+	values.Set("maxstorageprice", a.MaxStoragePrice.String())
+	values.Set("maxuploadbandwidthprice", a.MaxUploadBandwidthPrice.String())
+	err = c.get("/hostdb/simulate?"+values.Encode(), &hdsg)
+	return
+}
@@ -1,9 +1,11 @@
 package client
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
+	"net/http"
 	"net/url"
 	"os"
 	"strconv"
@@ -87,6 +89,13 @@ func (a *AllowanceRequestPost) WithMaxPeriodChurn(maxPeriodChurn uint64) *Allowa
 	return a
 }
 
+// WithMaxPeriodChurnContracts adds the maxperiodchurncontracts field to the
+// request.
+func (a *AllowanceRequestPost) WithMaxPeriodChurnContracts(maxPeriodChurnContracts uint64) *AllowanceRequestPost {
+	a.values.Set("maxperiodchurncontracts", fmt.Sprint(maxPeriodChurnContracts))
+	return a
+}
+
 // WithMaxRPCPrice adds the maxrpcprice field to the request.
 func (a *AllowanceRequestPost) WithMaxRPCPrice(price types.Currency) *AllowanceRequestPost {
 	a.values.Set("maxrpcprice", price.String())
@@ -123,6 +132,32 @@ func (a *AllowanceRequestPost) WithMaxUploadBandwidthPrice(price types.Currency)
 	return a
 }
 
+// WithMinHostVersion adds the minhostversion field to the request.
+func (a *AllowanceRequestPost) WithMinHostVersion(version string) *AllowanceRequestPost {
+	a.values.Set("minhostversion", version)
+	return a
+}
+
+// WithMinHostAge adds the minhostage field to the request.
+func (a *AllowanceRequestPost) WithMinHostAge(age types.BlockHeight) *AllowanceRequestPost {
+	a.values.Set("minhostage", fmt.Sprint(age))
+	return a
+}
+
+// WithExcludedCountries adds the excludedcountries field to the request.
+// Calling it without countries clears the excluded countries.
+func (a *AllowanceRequestPost) WithExcludedCountries(countries ...string) *AllowanceRequestPost {
+	a.values.Set("excludedcountries", strings.Join(countries, ","))
+	return a
+}
+
+// WithExcludedSubnets adds the excludedsubnets field to the request. Calling
+// it without subnets clears the excluded subnets.
+func (a *AllowanceRequestPost) WithExcludedSubnets(subnets ...string) *AllowanceRequestPost {
+	a.values.Set("excludedsubnets", strings.Join(subnets, ","))
+	return a
+}
+
 // Send finalizes and sends the request.
 func (a *AllowanceRequestPost) Send() (err error) {
 	if a.sent {
@@ -161,6 +196,86 @@ func (c *Client) RenterContractorChurnStatus() (churnStatus modules.ContractorCh
 	return
 }
 
+// RenterContractorMigrationPlanGet uses the /renter/contractormigrationplan
+// endpoint to get the contracts the contractor queued for replacement.
+func (c *Client) RenterContractorMigrationPlanGet() (plan modules.ContractorMigrationPlan, err error) {
+	err = c.get("/renter/contractormigrationplan", &plan)
+	return
+}
+
+// RenterContractorMigrationPlanApprovePost uses the
+// /renter/contractormigrationplan/approve endpoint to approve churning the
+// provided deferred contracts regardless of the churn budget.
+func (c *Client) RenterContractorMigrationPlanApprovePost(ids []types.FileContractID) (err error) {
+	strs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		strs = append(strs, id.String())
+	}
+	values := url.Values{}
+	values.Set("contracts", strings.Join(strs, ","))
+	err = c.post("/renter/contractormigrationplan/approve", values.Encode(), nil)
+	return
+}
+
+// RenterAlertsGet uses the /renter/alerts endpoint to get the alerts of the
+// renter.
+func (c *Client) RenterAlertsGet() (rag api.RenterAlertsGET, err error) {
+	err = c.get("/renter/alerts", &rag)
+	return
+}
+
+// RenterAllowanceAlertsGet uses the /renter/allowance/alerts endpoint to get
+// the rules used to alert the user about the spending of the allowance.
+func (c *Client) RenterAllowanceAlertsGet() (settings modules.AllowanceAlertSettings, err error) {
+	err = c.get("/renter/allowance/alerts", &settings)
+	return
+}
+
+// RenterAllowanceAlertsPost uses the /renter/allowance/alerts endpoint to set
+// the rules used to alert the user about the spending of the allowance.
+func (c *Client) RenterAllowanceAlertsPost(settings modules.AllowanceAlertSettings) (err error) {
+	values := url.Values{}
+	values.Set("spentthreshold", strconv.FormatFloat(settings.SpentThreshold, 'f', -1, 64))
+	values.Set("renewalexceedsfunds", strconv.FormatBool(settings.RenewalExceedsFunds))
+	err = c.post("/renter/allowance/alerts", values.Encode(), nil)
+	return
+}
+
+// RenterSpendingGet uses the /renter/spending endpoint to get the renter's
+// spending per file and per host.
+func (c *Client) RenterSpendingGet() (spending modules.RenterSpending, err error) {
+	err = c.get("/renter/spending", &spending)
+	return
+}
+
+// RenterSpendingExportGet uses the /renter/spending/export endpoint to export
+// the renter's spending per file or per host as CSV.
+func (c *Client) RenterSpendingExportGet(by string) ([]byte, error) {
+	values := url.Values{}
+	values.Set("by", by)
+	_, csv, err := c.getRawResponse("/renter/spending/export?" + values.Encode())
+	return csv, err
+}
+
+// RenterSpendingForecastGet uses the /renter/spendingforecast endpoint to get
+// the projected spending for the remainder of the current period.
+func (c *Client) RenterSpendingForecastGet() (forecast modules.SpendingForecast, err error) {
+	err = c.get("/renter/spendingforecast", &forecast)
+	return
+}
+
+// RenterSearchGet uses the /renter/search endpoint to search the renter's
+// files by path and user tags.
+func (c *Client) RenterSearchGet(query string, tags []string, offset, limit uint64) (result modules.FileSearchResult, err error) {
+	values := url.Values{}
+	values.Set("query", query)
+	values.Set("tags", strings.Join(tags, ","))
+	values.Set("offset", fmt.Sprint(offset))
+	values.Set("limit", fmt.Sprint(limit))
+	err = c.get("/renter/search?"+values.Encode(), &result)
+	return
+}
+
 // RenterContractCancelPost uses the /renter/contract/cancel endpoint to cancel
 // a contract
 func (c *Client) RenterContractCancelPost(id types.FileContractID) (err error) {
@@ -197,6 +312,31 @@ func (c *Client) RenterContractStatus(fcID types.FileContractID) (status modules
 	return
 }
 
+// RenterContractKeysGet requests the /renter/contractkeys resource.
+func (c *Client) RenterContractKeysGet() (status modules.ContractKeyStoreStatus, err error) {
+	err = c.get("/renter/contractkeys", &status)
+	return
+}
+
+// RenterContractKeysChangePasswordPost uses the /renter/contractkeys/changepassword
+// endpoint to re-encrypt the contract key store with a new password.
+func (c *Client) RenterContractKeysChangePasswordPost(password, newPassword string) (err error) {
+	values := url.Values{}
+	values.Set("password", password)
+	values.Set("newpassword", newPassword)
+	err = c.post("/renter/contractkeys/changepassword", values.Encode(), nil)
+	return
+}
+
+// RenterContractKeysUnlockPost uses the /renter/contractkeys/unlock endpoint
+// to unlock the contract key store with the wallet password.
+func (c *Client) RenterContractKeysUnlockPost(password string) (err error) {
+	values := url.Values{}
+	values.Set("password", password)
+	err = c.post("/renter/contractkeys/unlock", values.Encode(), nil)
+	return
+}
+
 // RenterDisabledContractsGet requests the /renter/contracts resource with the
 // disabled flag set to true
 func (c *Client) RenterDisabledContractsGet() (rc api.RenterContracts, err error) {
@@ -413,6 +553,22 @@ func (c *Client) RenterDownloadsRootGet() (rdq api.RenterDownloadQueue, err erro
 	return
 }
 
+// RenterDownloadPolicyGet uses the /renter/download endpoint to download a
+// full file with the provided download policy.
+func (c *Client) RenterDownloadPolicyGet(siaPath modules.SiaPath, destination string, policy modules.DownloadPolicy, async, root bool) (modules.DownloadID, error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("destination", destination)
+	values.Set("policy", policy.String())
+	values.Set("async", fmt.Sprint(async))
+	values.Set("root", fmt.Sprint(root))
+	h, _, err := c.getRawResponse(fmt.Sprintf("/renter/download/%s?%s", sp, values.Encode()))
+	if err != nil {
+		return "", err
+	}
+	return modules.DownloadID(h.Get("ID")), nil
+}
+
 // RenterDownloadHTTPResponseGet uses the /renter/download endpoint to download
 // a file and return its data.
 func (c *Client) RenterDownloadHTTPResponseGet(siaPath modules.SiaPath, offset, length uint64, disableLocalFetch, root bool) (modules.DownloadID, []byte, error) {
@@ -445,6 +601,36 @@ func (c *Client) RenterFileGet(siaPath modules.SiaPath) (rf api.RenterFile, err
 	return
 }
 
+// RenterVersionsGet uses the /renter/versions/:siapath endpoint to list the
+// previous versions of a file.
+func (c *Client) RenterVersionsGet(siaPath modules.SiaPath) (rfv api.RenterFileVersions, err error) {
+	sp := escapeSiaPath(siaPath)
+	err = c.get("/renter/versions/"+sp, &rfv)
+	return
+}
+
+// RenterVersionRestorePost uses the /renter/versions/:siapath endpoint to
+// replace a file with one of its previous versions.
+func (c *Client) RenterVersionRestorePost(siaPath modules.SiaPath, id string) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("action", "restore")
+	values.Set("version", id)
+	err = c.post("/renter/versions/"+sp, values.Encode(), nil)
+	return
+}
+
+// RenterVersionsPrunePost uses the /renter/versions/:siapath endpoint to delete
+// all but the 'keep' most recent versions of a file.
+func (c *Client) RenterVersionsPrunePost(siaPath modules.SiaPath, keep uint64) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("action", "prune")
+	values.Set("keep", fmt.Sprint(keep))
+	err = c.post("/renter/versions/"+sp, values.Encode(), nil)
+	return
+}
+
 // RenterFilesGet requests the /renter/files resource.
 func (c *Client) RenterFilesGet(cached bool) (rf api.RenterFiles, err error) {
 	err = c.get("/renter/files?cached="+fmt.Sprint(cached), &rf)
@@ -525,6 +711,24 @@ func (c *Client) RenterSetCheckIPViolationPost(enabled bool) (err error) {
 	return
 }
 
+// RenterSetVerifyUploadsPost uses the /renter endpoint to enable/disable the
+// verification of uploaded files against their local copies.
+func (c *Client) RenterSetVerifyUploadsPost(enabled bool) (err error) {
+	values := url.Values{}
+	values.Set("verifyuploads", fmt.Sprint(enabled))
+	err = c.post("/renter", values.Encode(), nil)
+	return
+}
+
+// RenterSetMaxFileVersionsPost uses the /renter endpoint to set the number of
+// previous versions which are retained when a file is overwritten.
+func (c *Client) RenterSetMaxFileVersionsPost(maxVersions uint64) (err error) {
+	values := url.Values{}
+	values.Set("maxfileversions", fmt.Sprint(maxVersions))
+	err = c.post("/renter", values.Encode(), nil)
+	return
+}
+
 // RenterStreamGet uses the /renter/stream endpoint to download data as a
 // stream.
 func (c *Client) RenterStreamGet(siaPath modules.SiaPath, disableLocalFetch, root bool) (resp []byte, err error) {
@@ -536,6 +740,16 @@ func (c *Client) RenterStreamGet(siaPath modules.SiaPath, disableLocalFetch, roo
 	return
 }
 
+// RenterStreamWithHeadersGet uses the /renter/stream endpoint to download a
+// file as a stream and also returns the headers of the response.
+func (c *Client) RenterStreamWithHeadersGet(siaPath modules.SiaPath, disableLocalFetch, root bool) (http.Header, []byte, error) {
+	values := url.Values{}
+	values.Set("disablelocalfetch", fmt.Sprint(disableLocalFetch))
+	values.Set("root", fmt.Sprint(root))
+	sp := escapeSiaPath(siaPath)
+	return c.getRawResponse(fmt.Sprintf("/renter/stream/%s?%s", sp, values.Encode()))
+}
+
 // RenterStreamPartialGet uses the /renter/stream endpoint to download a part
 // of data as a stream.
 func (c *Client) RenterStreamPartialGet(siaPath modules.SiaPath, start, end uint64, disableLocalFetch, root bool) (resp []byte, err error) {
@@ -568,6 +782,85 @@ func (c *Client) RenterSetFileStuckPost(siaPath modules.SiaPath, root, stuck boo
 	return
 }
 
+// RenterSetFileHTTPHeadersPost replaces the HTTP headers which are used when
+// serving the siafile at siaPath.
+func (c *Client) RenterSetFileHTTPHeadersPost(siaPath modules.SiaPath, headers modules.FileHTTPHeaders) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("cachecontrol", headers.CacheControl)
+	values.Set("contentdisposition", headers.ContentDisposition)
+	values.Set("contenttype", headers.ContentType)
+	err = c.post(fmt.Sprintf("/renter/file/%v", sp), values.Encode(), nil)
+	return
+}
+
+// RenterSetFileExpiryPost sets the time after which the siafile at siaPath is
+// removed automatically. A zero time removes the expiry.
+func (c *Client) RenterSetFileExpiryPost(siaPath modules.SiaPath, expiry modules.FileExpiry) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	if expiry.Time.IsZero() {
+		values.Set("expiry", "0")
+	} else {
+		values.Set("expiry", fmt.Sprint(expiry.Time.Unix()))
+	}
+	values.Set("expiryarchive", fmt.Sprint(expiry.Archive))
+	err = c.post(fmt.Sprintf("/renter/file/%v", sp), values.Encode(), nil)
+	return
+}
+
+// RenterSetFileRepairPriorityPost sets the priority with which the siafile at
+// siaPath is repaired.
+func (c *Client) RenterSetFileRepairPriorityPost(siaPath modules.SiaPath, priority modules.FileRepairPriority) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("repairpriority", priority.String())
+	err = c.post(fmt.Sprintf("/renter/file/%v", sp), values.Encode(), nil)
+	return
+}
+
+// RenterRepairsGet uses the /renter/repairs endpoint to get the renter's
+// repair queue.
+func (c *Client) RenterRepairsGet() (rq modules.RepairQueue, err error) {
+	err = c.get("/renter/repairs", &rq)
+	return
+}
+
+// RenterPinnedCacheGet uses the /renter/pinnedcache endpoint to get the
+// ranges of files the renter keeps in memory.
+func (c *Client) RenterPinnedCacheGet() (pc modules.RenterPinnedCache, err error) {
+	err = c.get("/renter/pinnedcache", &pc)
+	return
+}
+
+// RenterSetPinnedBytesBudgetPost uses the /renter/pinnedcache endpoint to set
+// the max number of bytes the renter keeps in memory for pinned ranges.
+func (c *Client) RenterSetPinnedBytesBudgetPost(budget uint64) (err error) {
+	values := url.Values{}
+	values.Set("budget", fmt.Sprint(budget))
+	err = c.post("/renter/pinnedcache", values.Encode(), nil)
+	return
+}
+
+// RenterPinPost uses the /renter/pin endpoint to pin a range of a file in
+// memory. A length of 0 pins the remainder of the file.
+func (c *Client) RenterPinPost(siaPath modules.SiaPath, offset, length uint64) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("offset", fmt.Sprint(offset))
+	values.Set("length", fmt.Sprint(length))
+	err = c.post(fmt.Sprintf("/renter/pin/%v", sp), values.Encode(), nil)
+	return
+}
+
+// RenterUnpinPost uses the /renter/unpin endpoint to remove the pinned ranges
+// of a file from memory.
+func (c *Client) RenterUnpinPost(siaPath modules.SiaPath) (err error) {
+	sp := escapeSiaPath(siaPath)
+	err = c.post(fmt.Sprintf("/renter/unpin/%v", sp), "", nil)
+	return
+}
+
 // RenterUploadPost uses the /renter/upload endpoint to upload a file
 func (c *Client) RenterUploadPost(path string, siaPath modules.SiaPath, dataPieces, parityPieces uint64) (err error) {
 	return c.RenterUploadForcePost(path, siaPath, dataPieces, parityPieces, false)
@@ -620,6 +913,115 @@ func (c *Client) RenterUploadStreamRepairPost(r io.Reader, siaPath modules.SiaPa
 	return err
 }
 
+// RenterUploadStreamAppendPost appends the data provided by r to an existing
+// siafile using a stream. The last chunk of the siafile needs to be full.
+func (c *Client) RenterUploadStreamAppendPost(r io.Reader, siaPath modules.SiaPath) error {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("append", strconv.FormatBool(true))
+	values.Set("stream", strconv.FormatBool(true))
+	_, _, err := c.postRawResponse(fmt.Sprintf("/renter/uploadstream/%s?%s", sp, values.Encode()), r)
+	return err
+}
+
+// RenterUploadStreamGet uses the /renter/uploadstream/:siapath endpoint to get
+// the offset at which an interrupted upload stream can be resumed.
+func (c *Client) RenterUploadStreamGet(siaPath modules.SiaPath) (rus api.RenterUploadStreamGET, err error) {
+	sp := escapeSiaPath(siaPath)
+	err = c.get("/renter/uploadstream/"+sp, &rus)
+	return
+}
+
+// RenterUploadStreamResumePost resumes an interrupted upload stream to a
+// siafile. The data provided by r needs to start at the resume offset returned
+// by RenterUploadStreamGet.
+func (c *Client) RenterUploadStreamResumePost(r io.Reader, siaPath modules.SiaPath) error {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("resume", strconv.FormatBool(true))
+	values.Set("stream", strconv.FormatBool(true))
+	_, _, err := c.postRawResponse(fmt.Sprintf("/renter/uploadstream/%s?%s", sp, values.Encode()), r)
+	return err
+}
+
+// RenterUploadStreamRepairOffsetPost repairs a siafile using a stream starting
+// at the provided offset. This is used to fill the holes of sparse files.
+func (c *Client) RenterUploadStreamRepairOffsetPost(r io.Reader, siaPath modules.SiaPath, offset uint64) error {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("repair", strconv.FormatBool(true))
+	values.Set("offset", strconv.FormatUint(offset, 10))
+	values.Set("stream", strconv.FormatBool(true))
+	_, _, err := c.postRawResponse(fmt.Sprintf("/renter/uploadstream/%s?%s", sp, values.Encode()), r)
+	return err
+}
+
+// RenterSparseCreatePost uses the /renter/sparse endpoint to create a sparse
+// file of the provided size.
+func (c *Client) RenterSparseCreatePost(siaPath modules.SiaPath, size, dataPieces, parityPieces uint64, force bool) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("action", "create")
+	values.Set("size", strconv.FormatUint(size, 10))
+	values.Set("datapieces", strconv.FormatUint(dataPieces, 10))
+	values.Set("paritypieces", strconv.FormatUint(parityPieces, 10))
+	values.Set("force", strconv.FormatBool(force))
+	err = c.post(fmt.Sprintf("/renter/sparse/%s", sp), values.Encode(), nil)
+	return
+}
+
+// RenterSparsePunchHolePost uses the /renter/sparse endpoint to turn a range
+// of a file into a hole.
+func (c *Client) RenterSparsePunchHolePost(siaPath modules.SiaPath, offset, length uint64) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("action", "punchhole")
+	values.Set("offset", strconv.FormatUint(offset, 10))
+	values.Set("length", strconv.FormatUint(length, 10))
+	err = c.post(fmt.Sprintf("/renter/sparse/%s", sp), values.Encode(), nil)
+	return
+}
+
+// RenterFileExportGet uses the /renter/fileexport endpoint to export the
+// metadata of a file.
+func (c *Client) RenterFileExportGet(siaPath modules.SiaPath) ([]byte, error) {
+	sp := escapeSiaPath(siaPath)
+	_, export, err := c.getRawResponse(fmt.Sprintf("/renter/fileexport/%s", sp))
+	return export, err
+}
+
+// RenterFileImportPost uses the /renter/fileimport endpoint to import the
+// metadata of a file which was previously exported.
+func (c *Client) RenterFileImportPost(siaPath modules.SiaPath, r io.Reader) error {
+	sp := escapeSiaPath(siaPath)
+	_, _, err := c.postRawResponse(fmt.Sprintf("/renter/fileimport/%s", sp), r)
+	return err
+}
+
+// RenterFileRecoverPost uses the /renter/filerecover endpoint to recover the
+// data of a file directly from the renter's contracts and write it to dst.
+func (c *Client) RenterFileRecoverPost(siaPath modules.SiaPath, dst string) (report modules.FileRecoveryReport, err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("destination", dst)
+	err = c.post(fmt.Sprintf("/renter/filerecover/%s", sp), values.Encode(), &report)
+	return
+}
+
+// RenterFileRecoverExportPost uses the /renter/filerecoverexport endpoint to
+// recover the data of a file directly from the renter's contracts using the
+// metadata of a file which was previously exported.
+func (c *Client) RenterFileRecoverExportPost(export io.Reader, dst string) (report modules.FileRecoveryReport, err error) {
+	values := url.Values{}
+	values.Set("destination", dst)
+	_, resp, err := c.postRawResponse(fmt.Sprintf("/renter/filerecoverexport?%s", values.Encode()), export)
+	if err != nil {
+		return modules.FileRecoveryReport{}, err
+	}
+	err = json.Unmarshal(resp, &report)
+	return
+}
+
 // RenterDirCreatePost uses the /renter/dir/ endpoint to create a directory for the
 // renter
 func (c *Client) RenterDirCreatePost(siaPath modules.SiaPath) (err error) {
@@ -661,6 +1063,15 @@ func (c *Client) RenterDirRenamePost(siaPath, newSiaPath modules.SiaPath) (err e
 	return
 }
 
+// RenterDirCopyPost uses the /renter/dir/ endpoint to copy a directory for the
+// renter
+func (c *Client) RenterDirCopyPost(siaPath, newSiaPath modules.SiaPath) (err error) {
+	sp := escapeSiaPath(siaPath)
+	nsp := escapeSiaPath(newSiaPath)
+	err = c.post(fmt.Sprintf("/renter/dir/%s?newsiapath=%s", sp, nsp), "action=copy", nil)
+	return
+}
+
 // RenterDirRootGet uses the /renter/dir/ endpoint to query a directory,
 // starting from the root path.
 func (c *Client) RenterDirRootGet(siaPath modules.SiaPath) (rd api.RenterDirectory, err error) {
@@ -676,6 +1087,28 @@ func (c *Client) RenterDirGet(siaPath modules.SiaPath) (rd api.RenterDirectory,
 	return
 }
 
+// RenterDirDefaultsGet uses the /renter/dirdefaults endpoint to get the
+// defaults of a directory.
+func (c *Client) RenterDirDefaultsGet(siaPath modules.SiaPath) (dd api.RenterDirDefaultsGET, err error) {
+	sp := escapeSiaPath(siaPath)
+	err = c.get(fmt.Sprintf("/renter/dirdefaults/%s", sp), &dd)
+	return
+}
+
+// RenterDirDefaultsPost uses the /renter/dirdefaults endpoint to set the
+// defaults of a directory.
+func (c *Client) RenterDirDefaultsPost(siaPath modules.SiaPath, defaults modules.DirDefaults) (err error) {
+	sp := escapeSiaPath(siaPath)
+	values := url.Values{}
+	values.Set("datapieces", strconv.Itoa(defaults.DataPieces))
+	values.Set("paritypieces", strconv.Itoa(defaults.ParityPieces))
+	values.Set("mode", strconv.FormatUint(uint64(defaults.FileMode), 10))
+	values.Set("usertags", strings.Join(defaults.UserTags, ","))
+	values.Set("bucket", defaults.Bucket)
+	err = c.post(fmt.Sprintf("/renter/dirdefaults/%s", sp), values.Encode(), nil)
+	return
+}
+
 // RenterValidateSiaPathPost uses the /renter/validatesiapath endpoint to
 // validate a potential siapath
 //
@@ -749,6 +1182,142 @@ func (c *Client) RenterUploadsResumePost() (err error) {
 	return
 }
 
+// RenterReadOnlyGet uses the /renter/readonly endpoint to get the status of
+// the renter's read-only mode.
+func (c *Client) RenterReadOnlyGet() (status modules.ReadOnlyStatus, err error) {
+	err = c.get("/renter/readonly", &status)
+	return
+}
+
+// RenterReadOnlyPost uses the /renter/readonly endpoint to manually enable or
+// disable the renter's read-only mode.
+func (c *Client) RenterReadOnlyPost(enabled bool) (err error) {
+	values := url.Values{}
+	values.Set("enabled", strconv.FormatBool(enabled))
+	err = c.post("/renter/readonly", values.Encode(), nil)
+	return
+}
+
+// RenterReadOnlySettingsPost uses the /renter/readonly endpoint to set the
+// thresholds which cause the renter to enter read-only mode automatically.
+func (c *Client) RenterReadOnlySettingsPost(settings modules.ReadOnlySettings) (err error) {
+	values := url.Values{}
+	values.Set("minwalletbalance", settings.MinWalletBalance.String())
+	values.Set("minallowanceremaining", settings.MinAllowanceRemaining.String())
+	err = c.post("/renter/readonly", values.Encode(), nil)
+	return
+}
+
+// RenterReplicationGet uses the /renter/replication endpoint to get the status
+// of the replication of the renter's metadata.
+func (c *Client) RenterReplicationGet() (status modules.ReplicationStatus, err error) {
+	err = c.get("/renter/replication", &status)
+	return
+}
+
+// RenterReplicationPost uses the /renter/replication endpoint to set the role
+// of the renter in the replication of its metadata.
+func (c *Client) RenterReplicationPost(settings modules.ReplicationSettings) (err error) {
+	values := url.Values{}
+	values.Set("role", settings.Role)
+	values.Set("path", settings.Path)
+	values.Set("secret", settings.Secret)
+	if settings.Interval != 0 {
+		values.Set("interval", settings.Interval.String())
+	}
+	err = c.post("/renter/replication", values.Encode(), nil)
+	return
+}
+
+// RenterMetadataMirrorGet uses the /renter/metadatamirror endpoint to get the
+// status of the mirroring of the renter's metadata to its hosts.
+func (c *Client) RenterMetadataMirrorGet() (status modules.MetadataMirrorStatus, err error) {
+	err = c.get("/renter/metadatamirror", &status)
+	return
+}
+
+// RenterMetadataMirrorPost uses the /renter/metadatamirror endpoint to enable
+// or disable the mirroring of the renter's metadata to its hosts.
+func (c *Client) RenterMetadataMirrorPost(settings modules.MetadataMirrorSettings) (err error) {
+	values := url.Values{}
+	values.Set("enabled", fmt.Sprint(settings.Enabled))
+	if settings.Interval != 0 {
+		values.Set("interval", settings.Interval.String())
+	}
+	err = c.post("/renter/metadatamirror", values.Encode(), nil)
+	return
+}
+
+// RenterMetadataMirrorRecoverPost uses the /renter/metadatamirror/recover
+// endpoint to recover the renter's siafiles from the metadata mirrored to its
+// hosts.
+func (c *Client) RenterMetadataMirrorRecoverPost() (recovery modules.MetadataMirrorRecovery, err error) {
+	err = c.post("/renter/metadatamirror/recover", "", &recovery)
+	return
+}
+
+// RenterSchedulerGet uses the /renter/scheduler endpoint to get the settings
+// of the renter's scheduler and the state of its windows.
+func (c *Client) RenterSchedulerGet() (status modules.RenterSchedulerStatus, err error) {
+	err = c.get("/renter/scheduler", &status)
+	return
+}
+
+// RenterSchedulerPost uses the /renter/scheduler endpoint to set the jobs which
+// are restricted to the windows of the renter's scheduler.
+func (c *Client) RenterSchedulerPost(settings modules.RenterSchedulerSettings) (err error) {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	err = c.post("/renter/scheduler", string(data), nil)
+	return
+}
+
+// RenterChaosGet uses the /renter/chaos endpoint to get the report of the
+// renter's chaos testing mode.
+func (c *Client) RenterChaosGet() (report modules.RenterChaosReport, err error) {
+	err = c.get("/renter/chaos", &report)
+	return
+}
+
+// RenterChaosPost uses the /renter/chaos endpoint to configure the renter's
+// chaos testing mode.
+func (c *Client) RenterChaosPost(settings modules.RenterChaosSettings) (err error) {
+	values := url.Values{}
+	values.Set("enabled", strconv.FormatBool(settings.Enabled))
+	values.Set("delayprobability", strconv.FormatFloat(settings.DelayProbability, 'f', -1, 64))
+	values.Set("droppricetableprobability", strconv.FormatFloat(settings.DropPriceTableProbability, 'f', -1, 64))
+	values.Set("failureprobability", strconv.FormatFloat(settings.FailureProbability, 'f', -1, 64))
+	values.Set("maxdelay", settings.MaxDelay.String())
+	err = c.post("/renter/chaos", values.Encode(), nil)
+	return
+}
+
+// RenterFailureDomainsGet uses the /renter/failuredomains endpoint to get the
+// files which store several pieces of a chunk on hosts sharing a failure
+// domain.
+func (c *Client) RenterFailureDomainsGet() (report modules.RenterFailureDomainReport, err error) {
+	err = c.get("/renter/failuredomains", &report)
+	return
+}
+
+// RenterFailureDomainsFixPost uses the /renter/failuredomains/fix endpoint to
+// drop the correlated pieces of the provided files, or of all files if none
+// are provided.
+func (c *Client) RenterFailureDomainsFixPost(siaPaths ...modules.SiaPath) (report modules.RenterFailureDomainFixReport, err error) {
+	values := url.Values{}
+	if len(siaPaths) > 0 {
+		strs := make([]string, 0, len(siaPaths))
+		for _, siaPath := range siaPaths {
+			strs = append(strs, siaPath.String())
+		}
+		values.Set("siapaths", strings.Join(strs, ","))
+	}
+	err = c.post("/renter/failuredomains/fix", values.Encode(), &report)
+	return
+}
+
 // RenterPost uses the /renter POST endpoint to set fields of the renter. Values
 // are encoded as a query string in the body
 func (c *Client) RenterPost(values url.Values) (err error) {
@@ -763,6 +1332,25 @@ func (c *Client) RenterWorkersGet() (wps modules.WorkerPoolStatus, err error) {
 	return
 }
 
+// RenterWorkersBandwidthLimitsGet uses the /renter/workers/bandwidthlimits
+// endpoint to get the bandwidth limits of individual hosts.
+func (c *Client) RenterWorkersBandwidthLimitsGet() (wbl api.RenterWorkersBandwidthLimitsGET, err error) {
+	err = c.get("/renter/workers/bandwidthlimits", &wbl)
+	return
+}
+
+// RenterWorkersBandwidthLimitsPost uses the /renter/workers/bandwidthlimits
+// endpoint to set the bandwidth limits of the worker of a host. Limits of 0
+// remove the limits of the host.
+func (c *Client) RenterWorkersBandwidthLimitsPost(limits modules.RenterHostBandwidthLimits) (err error) {
+	values := url.Values{}
+	values.Set("hostkey", limits.HostPublicKey.String())
+	values.Set("maxdownloadspeed", strconv.FormatInt(limits.MaxDownloadSpeed, 10))
+	values.Set("maxuploadspeed", strconv.FormatInt(limits.MaxUploadSpeed, 10))
+	err = c.post("/renter/workers/bandwidthlimits", values.Encode(), nil)
+	return
+}
+
 // RenterBubblePost uses the /renter/bubble endpoint to manually trigger an
 // update to the directories metadata.
 func (c *Client) RenterBubblePost(siaPath modules.SiaPath, force, recursive bool) (err error) {
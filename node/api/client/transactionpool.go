@@ -2,7 +2,9 @@ package client
 
 import (
 	"encoding/base64"
+	"fmt"
 	"net/url"
+	"strings"
 
 	"gitlab.com/NebulousLabs/encoding"
 	"go.sia.tech/siad/node/api"
@@ -15,6 +17,22 @@ func (c *Client) TransactionPoolFeeGet() (tfg api.TpoolFeeGET, err error) {
 	return
 }
 
+// TransactionPoolFeeEstimateGet uses the /tpool/feeestimate endpoint to get
+// the estimated fees for confirming a transaction within the provided numbers
+// of blocks. If no targets are provided, the default targets are used.
+func (c *Client) TransactionPoolFeeEstimateGet(targets ...types.BlockHeight) (tfeg api.TpoolFeeEstimateGET, err error) {
+	values := url.Values{}
+	if len(targets) > 0 {
+		strs := make([]string, 0, len(targets))
+		for _, target := range targets {
+			strs = append(strs, fmt.Sprint(target))
+		}
+		values.Set("targets", strings.Join(strs, ","))
+	}
+	err = c.get("/tpool/feeestimate?"+values.Encode(), &tfeg)
+	return
+}
+
 // TransactionPoolRawPost uses the /tpool/raw endpoint to send a raw
 // transaction to the transaction pool.
 func (c *Client) TransactionPoolRawPost(txn types.Transaction, parents []types.Transaction) (err error) {
@@ -31,3 +49,22 @@ func (c *Client) TransactionPoolTransactionsGet() (tptg api.TpoolTxnsGET, err er
 	err = c.get("/tpool/transactions", &tptg)
 	return
 }
+
+// TransactionPoolReplacePost uses the /tpool/replace endpoint to replace the
+// transaction sets in the transaction pool which conflict with the provided
+// transaction and its parents.
+func (c *Client) TransactionPoolReplacePost(txn types.Transaction, parents []types.Transaction) (err error) {
+	values := url.Values{}
+	values.Set("transaction", base64.StdEncoding.EncodeToString(encoding.Marshal(txn)))
+	values.Set("parents", base64.StdEncoding.EncodeToString(encoding.Marshal(parents)))
+	err = c.post("/tpool/replace", values.Encode(), nil)
+	return
+}
+
+// TransactionPoolEvictPost uses the /tpool/evict/:id endpoint to remove the
+// transaction set containing the specified transaction from the transaction
+// pool.
+func (c *Client) TransactionPoolEvictPost(id types.TransactionID) (tep api.TpoolEvictPOST, err error) {
+	err = c.post("/tpool/evict/"+id.String(), "", &tep)
+	return
+}
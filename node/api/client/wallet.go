@@ -14,6 +14,51 @@ import (
 	"go.sia.tech/siad/types"
 )
 
+// WalletAccountsGet requests the accounts of the wallet from the
+// /wallet/accounts endpoint.
+func (c *Client) WalletAccountsGet() (wag api.WalletAccountsGET, err error) {
+	err = c.get("/wallet/accounts", &wag)
+	return
+}
+
+// WalletAccountCreatePost uses the /wallet/accounts endpoint to create a new
+// account with a random seed.
+func (c *Client) WalletAccountCreatePost(name, password string) (wap api.WalletAccountsPOST, err error) {
+	values := url.Values{}
+	values.Set("name", name)
+	values.Set("encryptionpassword", password)
+	err = c.post("/wallet/accounts", values.Encode(), &wap)
+	return
+}
+
+// WalletAccountLoadPost uses the /wallet/accounts endpoint to create a new
+// account from an existing seed.
+func (c *Client) WalletAccountLoadPost(name, password, seed string) (err error) {
+	values := url.Values{}
+	values.Set("name", name)
+	values.Set("encryptionpassword", password)
+	values.Set("seed", seed)
+	err = c.post("/wallet/accounts", values.Encode(), nil)
+	return
+}
+
+// WalletAccountAddressGet requests a new address of an account from the
+// /wallet/accounts/:name/address endpoint.
+func (c *Client) WalletAccountAddressGet(name string) (wag api.WalletAddressGET, err error) {
+	err = c.get(fmt.Sprintf("/wallet/accounts/%v/address", url.PathEscape(name)), &wag)
+	return
+}
+
+// WalletAccountSiacoinsPost uses the /wallet/accounts/:name/siacoins endpoint
+// to send money from an account.
+func (c *Client) WalletAccountSiacoinsPost(name string, amount types.Currency, destination types.UnlockHash) (wsp api.WalletSiacoinsPOST, err error) {
+	values := url.Values{}
+	values.Set("amount", amount.String())
+	values.Set("destination", destination.String())
+	err = c.post(fmt.Sprintf("/wallet/accounts/%v/siacoins", url.PathEscape(name)), values.Encode(), &wsp)
+	return
+}
+
 // WalletAddressGet requests a new address from the /wallet/address endpoint
 func (c *Client) WalletAddressGet() (wag api.WalletAddressGET, err error) {
 	err = c.get("/wallet/address", &wag)
@@ -97,6 +142,13 @@ func (c *Client) WalletGet() (wg api.WalletGET, err error) {
 	return
 }
 
+// WalletLockedFundsGet requests the /wallet/lockedfunds endpoint to get the
+// wallet's balance together with the renter funds locked in file contracts.
+func (c *Client) WalletLockedFundsGet() (wlfg api.WalletLockedFundsGET, err error) {
+	err = c.get("/wallet/lockedfunds", &wlfg)
+	return
+}
+
 // WalletLastAddressesGet returns the count last addresses generated by the
 // wallet in reverse order. That means the last generated address will be the
 // first one in the slice.
@@ -165,6 +217,110 @@ func (c *Client) WalletSignPost(txn types.Transaction, toSign []crypto.Hash) (ws
 	return
 }
 
+// WalletExternalSignPost uses the /wallet/externalsign api endpoint to have
+// the external signer sign a transaction.
+func (c *Client) WalletExternalSignPost(txn types.Transaction, toSign []crypto.Hash) (wspr api.WalletSignPOSTResp, err error) {
+	json, err := json.Marshal(api.WalletSignPOSTParams{
+		Transaction: txn,
+		ToSign:      toSign,
+	})
+	if err != nil {
+		return
+	}
+	err = c.post("/wallet/externalsign", string(json), &wspr)
+	return
+}
+
+// WalletExternalAddressesGet requests the addresses of the external signer
+// from the /wallet/externalsign/addresses endpoint.
+func (c *Client) WalletExternalAddressesGet() (weag api.WalletExternalAddressesGET, err error) {
+	err = c.get("/wallet/externalsign/addresses", &weag)
+	return
+}
+
+// WalletExternalAddressesPost uses the /wallet/externalsign/addresses endpoint
+// to start tracking the address of the external signer's key with the given
+// index.
+func (c *Client) WalletExternalAddressesPost(keyIndex uint64, verify, unused bool) (ea modules.ExternalAddress, err error) {
+	values := url.Values{}
+	values.Set("keyindex", strconv.FormatUint(keyIndex, 10))
+	values.Set("verify", strconv.FormatBool(verify))
+	values.Set("unused", strconv.FormatBool(unused))
+	err = c.post("/wallet/externalsign/addresses", values.Encode(), &ea)
+	return
+}
+
+// WalletPSSTCreatePost uses the /wallet/psst/create endpoint to create a
+// partially signed transaction for an unsigned transaction.
+func (c *Client) WalletPSSTCreatePost(txn types.Transaction) (resp api.WalletPSSTPOSTResp, err error) {
+	json, err := json.Marshal(api.WalletPSSTCreatePOSTParams{
+		Transaction: txn,
+	})
+	if err != nil {
+		return
+	}
+	err = c.post("/wallet/psst/create", string(json), &resp)
+	return
+}
+
+// WalletPSSTSignPost uses the /wallet/psst/sign endpoint to add the
+// signatures of the wallet's keys to a partially signed transaction.
+func (c *Client) WalletPSSTSignPost(psst modules.PartiallySignedTransaction) (resp api.WalletPSSTPOSTResp, err error) {
+	json, err := json.Marshal(api.WalletPSSTPOSTParams{
+		PSST: psst,
+	})
+	if err != nil {
+		return
+	}
+	err = c.post("/wallet/psst/sign", string(json), &resp)
+	return
+}
+
+// WalletPSSTSigHashPost uses the /wallet/psst/sighash endpoint to get the hash
+// a public key needs to sign to add its signature to an input of a partially
+// signed transaction.
+func (c *Client) WalletPSSTSigHashPost(psst modules.PartiallySignedTransaction, parentID crypto.Hash, pubKeyIndex uint64) (resp api.WalletPSSTSigHashPOSTResp, err error) {
+	json, err := json.Marshal(api.WalletPSSTSigHashPOSTParams{
+		PSST:           psst,
+		ParentID:       parentID,
+		PublicKeyIndex: pubKeyIndex,
+	})
+	if err != nil {
+		return
+	}
+	err = c.post("/wallet/psst/sighash", string(json), &resp)
+	return
+}
+
+// WalletPSSTAddSignaturePost uses the /wallet/psst/addsignature endpoint to add
+// a signature to an input of a partially signed transaction.
+func (c *Client) WalletPSSTAddSignaturePost(psst modules.PartiallySignedTransaction, parentID crypto.Hash, pubKeyIndex uint64, sig crypto.Signature) (resp api.WalletPSSTPOSTResp, err error) {
+	json, err := json.Marshal(api.WalletPSSTAddSignaturePOSTParams{
+		PSST:           psst,
+		ParentID:       parentID,
+		PublicKeyIndex: pubKeyIndex,
+		Signature:      sig[:],
+	})
+	if err != nil {
+		return
+	}
+	err = c.post("/wallet/psst/addsignature", string(json), &resp)
+	return
+}
+
+// WalletPSSTFinalizePost uses the /wallet/psst/finalize endpoint to get the
+// signed transaction of a fully signed partially signed transaction.
+func (c *Client) WalletPSSTFinalizePost(psst modules.PartiallySignedTransaction) (resp api.WalletPSSTFinalizePOSTResp, err error) {
+	json, err := json.Marshal(api.WalletPSSTPOSTParams{
+		PSST: psst,
+	})
+	if err != nil {
+		return
+	}
+	err = c.post("/wallet/psst/finalize", string(json), &resp)
+	return
+}
+
 // WalletSiafundsPost uses the /wallet/siafunds api endpoint to send siafunds
 // to a single address.
 func (c *Client) WalletSiafundsPost(amount types.Currency, destination types.UnlockHash) (wsp api.WalletSiafundsPOST, err error) {
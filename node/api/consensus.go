@@ -126,6 +126,7 @@ func RegisterRoutesConsensus(router *httprouter.Router, cs modules.ConsensusSet)
 	router.GET("/consensus/subscribe/:id", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		consensusSubscribeHandler(cs, w, req, ps)
 	})
+	router.GET("/consensus/stream", newConsensusStreamManager(cs).consensusStreamHandler)
 	router.POST("/consensus/validate/transactionset", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		consensusValidateTransactionsetHandler(cs, w, req, ps)
 	})
@@ -0,0 +1,342 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/julienschmidt/httprouter"
+	"gitlab.com/NebulousLabs/errors"
+	"golang.org/x/net/websocket"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/crypto"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// consensusStreamBufferSize is the number of consensus changes buffered
+	// for a stream. If the client doesn't keep up, the stream is closed.
+	consensusStreamBufferSize = build.Select(build.Var{
+		Standard: 1000,
+		Dev:      100,
+		Testing:  10,
+	}).(int)
+
+	// maxConsensusStreams is the max number of concurrent consensus streams.
+	maxConsensusStreams = build.Select(build.Var{
+		Standard: 100,
+		Dev:      10,
+		Testing:  5,
+	}).(int)
+
+	// errConsensusStreamOverflow is sent to clients which don't keep up with
+	// the consensus changes.
+	errConsensusStreamOverflow = errors.New("client didn't keep up with the consensus changes, resume from the last change id")
+
+	// errTooManyConsensusStreams is returned if the max number of concurrent
+	// consensus streams is reached.
+	errTooManyConsensusStreams = errors.New("too many consensus streams")
+)
+
+type (
+	// ConsensusStreamChange is a consensus change sent to the clients of the
+	// consensus stream.
+	ConsensusStreamChange struct {
+		ID             string                 `json:"id"`
+		BlockHeight    types.BlockHeight      `json:"blockheight"`
+		Synced         bool                   `json:"synced"`
+		RevertedBlocks []ConsensusStreamBlock `json:"revertedblocks"`
+		AppliedBlocks  []ConsensusStreamBlock `json:"appliedblocks"`
+
+		// SiacoinOutputs summarizes the siacoin outputs created and removed by
+		// the change.
+		SiacoinOutputs ConsensusStreamOutputSummary `json:"siacoinoutputs"`
+	}
+
+	// ConsensusStreamBlock is a block applied or reverted by a consensus
+	// change.
+	ConsensusStreamBlock struct {
+		ID           types.BlockID     `json:"id"`
+		Height       types.BlockHeight `json:"height"`
+		ParentID     types.BlockID     `json:"parentid"`
+		Timestamp    types.Timestamp   `json:"timestamp"`
+		Transactions int               `json:"transactions"`
+	}
+
+	// ConsensusStreamOutputSummary summarizes the siacoin outputs created and
+	// removed by a consensus change. Outputs are only listed individually if
+	// the client filters them by address.
+	ConsensusStreamOutputSummary struct {
+		Created      int                     `json:"created"`
+		CreatedValue types.Currency          `json:"createdvalue"`
+		Removed      int                     `json:"removed"`
+		RemovedValue types.Currency          `json:"removedvalue"`
+		Outputs      []ConsensusStreamOutput `json:"outputs,omitempty"`
+	}
+
+	// ConsensusStreamOutput is a siacoin output created or removed by a
+	// consensus change.
+	ConsensusStreamOutput struct {
+		ID         types.SiacoinOutputID `json:"id"`
+		UnlockHash types.UnlockHash      `json:"unlockhash"`
+		Value      types.Currency        `json:"value"`
+		Created    bool                  `json:"created"`
+	}
+
+	// ConsensusStreamError is the last message sent to a client before its
+	// stream is closed due to an error. The client can resume the stream from
+	// the last change it received.
+	ConsensusStreamError struct {
+		Error        string `json:"error"`
+		LastChangeID string `json:"lastchangeid"`
+	}
+
+	// consensusStreamManager manages the consensus subscriptions of the
+	// consensus stream clients. Every client gets its own subscription to be
+	// able to resume from any consensus change.
+	consensusStreamManager struct {
+		streams map[*consensusStream]struct{}
+		mu      sync.Mutex
+
+		staticCS modules.ConsensusSet
+	}
+
+	// consensusStream is the consensus subscription of a single client. The
+	// consensus set sends changes to subscribers while holding its lock which
+	// means the stream can't block. Changes are buffered instead and the
+	// stream is closed if the buffer overflows.
+	consensusStream struct {
+		changes    chan ConsensusStreamChange
+		overflowed bool
+		mu         sync.Mutex
+
+		staticAddresses map[types.UnlockHash]struct{}
+		staticOverflow  chan struct{}
+	}
+)
+
+// newConsensusStreamManager creates a new consensusStreamManager.
+func newConsensusStreamManager(cs modules.ConsensusSet) *consensusStreamManager {
+	return &consensusStreamManager{
+		streams:  make(map[*consensusStream]struct{}),
+		staticCS: cs,
+	}
+}
+
+// managedAdd adds a new stream for consensus changes which touch the provided
+// addresses.
+func (csm *consensusStreamManager) managedAdd(addresses []types.UnlockHash) (*consensusStream, error) {
+	csm.mu.Lock()
+	defer csm.mu.Unlock()
+	if len(csm.streams) >= maxConsensusStreams {
+		return nil, errTooManyConsensusStreams
+	}
+	s := &consensusStream{
+		changes:         make(chan ConsensusStreamChange, consensusStreamBufferSize),
+		staticAddresses: make(map[types.UnlockHash]struct{}),
+		staticOverflow:  make(chan struct{}),
+	}
+	for _, uh := range addresses {
+		s.staticAddresses[uh] = struct{}{}
+	}
+	csm.streams[s] = struct{}{}
+	return s, nil
+}
+
+// managedRemove removes a stream.
+func (csm *consensusStreamManager) managedRemove(s *consensusStream) {
+	csm.mu.Lock()
+	defer csm.mu.Unlock()
+	delete(csm.streams, s)
+}
+
+// ProcessConsensusChange implements the modules.ConsensusSetSubscriber
+// interface. It never blocks.
+func (s *consensusStream) ProcessConsensusChange(cc modules.ConsensusChange) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.overflowed {
+		return
+	}
+	select {
+	case s.changes <- s.summarize(cc):
+	default:
+		s.overflowed = true
+		close(s.staticOverflow)
+	}
+}
+
+// summarize creates the ConsensusStreamChange of a consensus change.
+func (s *consensusStream) summarize(cc modules.ConsensusChange) ConsensusStreamChange {
+	change := ConsensusStreamChange{
+		ID:             cc.ID.String(),
+		BlockHeight:    cc.BlockHeight,
+		Synced:         cc.Synced,
+		RevertedBlocks: make([]ConsensusStreamBlock, 0, len(cc.RevertedBlocks)),
+		AppliedBlocks:  make([]ConsensusStreamBlock, 0, len(cc.AppliedBlocks)),
+	}
+	// The reverted blocks are ordered from the previous tip downwards and the
+	// applied blocks from the fork point upwards.
+	prevHeight := cc.BlockHeight + types.BlockHeight(len(cc.RevertedBlocks)) - types.BlockHeight(len(cc.AppliedBlocks))
+	for i, b := range cc.RevertedBlocks {
+		change.RevertedBlocks = append(change.RevertedBlocks, consensusStreamBlock(b, prevHeight-types.BlockHeight(i)))
+	}
+	for i, b := range cc.AppliedBlocks {
+		height := cc.BlockHeight - types.BlockHeight(len(cc.AppliedBlocks)-1-i)
+		change.AppliedBlocks = append(change.AppliedBlocks, consensusStreamBlock(b, height))
+	}
+
+	summary := &change.SiacoinOutputs
+	for _, diff := range cc.SiacoinOutputDiffs {
+		created := diff.Direction == modules.DiffApply
+		if created {
+			summary.Created++
+			summary.CreatedValue = summary.CreatedValue.Add(diff.SiacoinOutput.Value)
+		} else {
+			summary.Removed++
+			summary.RemovedValue = summary.RemovedValue.Add(diff.SiacoinOutput.Value)
+		}
+		if _, watched := s.staticAddresses[diff.SiacoinOutput.UnlockHash]; watched {
+			summary.Outputs = append(summary.Outputs, ConsensusStreamOutput{
+				ID:         diff.ID,
+				UnlockHash: diff.SiacoinOutput.UnlockHash,
+				Value:      diff.SiacoinOutput.Value,
+				Created:    created,
+			})
+		}
+	}
+	return change
+}
+
+// consensusStreamBlock creates the ConsensusStreamBlock of a block.
+func consensusStreamBlock(b types.Block, height types.BlockHeight) ConsensusStreamBlock {
+	return ConsensusStreamBlock{
+		ID:           b.ID(),
+		Height:       height,
+		ParentID:     b.ParentID,
+		Timestamp:    b.Timestamp,
+		Transactions: len(b.Transactions),
+	}
+}
+
+// parseUnlockHashes parses a comma separated list of unlock hashes.
+func parseUnlockHashes(str string) ([]types.UnlockHash, error) {
+	if str == "" {
+		return nil, nil
+	}
+	var uhs []types.UnlockHash
+	for _, s := range strings.Split(str, ",") {
+		var uh types.UnlockHash
+		if err := uh.LoadString(s); err != nil {
+			return nil, fmt.Errorf("invalid address '%v': %v", s, err)
+		}
+		uhs = append(uhs, uh)
+	}
+	return uhs, nil
+}
+
+// consensusStreamHandler handles the API calls to the /consensus/stream
+// endpoint. It streams the consensus changes after the provided change over a
+// WebSocket connection.
+func (csm *consensusStreamManager) consensusStreamHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	ccid := modules.ConsensusChangeRecent
+	if id := req.FormValue("changeid"); id == "beginning" {
+		ccid = modules.ConsensusChangeBeginning
+	} else if id != "" {
+		if err := (*crypto.Hash)(&ccid).LoadString(id); err != nil {
+			WriteError(w, Error{"could not decode change id: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	addresses, err := parseUnlockHashes(req.FormValue("addresses"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse addresses: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	stream, err := csm.managedAdd(addresses)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusServiceUnavailable)
+		return
+	}
+	defer csm.managedRemove(stream)
+
+	s := websocket.Server{
+		Handler: func(conn *websocket.Conn) {
+			// The client isn't expected to send anything. Reading from the
+			// connection detects when it is closed.
+			closed := make(chan struct{})
+			go func() {
+				_, _ = io.Copy(ioutil.Discard, conn)
+				close(closed)
+			}()
+
+			// Subscribe in a separate goroutine since catching up with the
+			// consensus set blocks until the stream has received all changes
+			// since the provided one.
+			cancel := make(chan struct{})
+			subscribeErr := make(chan error, 1)
+			go func() {
+				subscribeErr <- csm.staticCS.ConsensusSetSubscribe(stream, ccid, cancel)
+			}()
+			var subscribeDone, subscribed bool
+			defer func() {
+				close(cancel)
+				if !subscribeDone {
+					subscribed = <-subscribeErr == nil
+				}
+				if subscribed {
+					csm.staticCS.Unsubscribe(stream)
+				}
+			}()
+
+			lastID := ccid.String()
+			if ccid == modules.ConsensusChangeRecent || ccid == modules.ConsensusChangeBeginning {
+				lastID = ""
+			}
+			sendErr := func(err error) {
+				_ = websocket.JSON.Send(conn, ConsensusStreamError{
+					Error:        err.Error(),
+					LastChangeID: lastID,
+				})
+			}
+			for {
+				// Send all buffered changes before reporting an overflow.
+				select {
+				case change := <-stream.changes:
+					if err := websocket.JSON.Send(conn, change); err != nil {
+						return
+					}
+					lastID = change.ID
+					continue
+				default:
+				}
+				select {
+				case <-closed:
+					return
+				case err := <-subscribeErr:
+					subscribeDone, subscribed = true, err == nil
+					if err != nil {
+						sendErr(err)
+						return
+					}
+				case <-stream.staticOverflow:
+					if len(stream.changes) == 0 {
+						sendErr(errConsensusStreamOverflow)
+						return
+					}
+				case change := <-stream.changes:
+					if err := websocket.JSON.Send(conn, change); err != nil {
+						return
+					}
+					lastID = change.ID
+				}
+			}
+		},
+	}
+	s.ServeHTTP(w, req)
+}
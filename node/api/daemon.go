@@ -25,6 +25,7 @@ import (
 	"gitlab.com/NebulousLabs/errors"
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
 	"go.sia.tech/siad/profile"
 	"go.sia.tech/siad/types"
 )
@@ -117,8 +118,8 @@ type (
 
 		// DEPRECATED: same values as MaxTargetAdjustmentUp and
 		// MaxTargetAdjustmentDown.
-		MaxAdjustmentUp   *big.Rat `json:"maxadjustmentup"`
-		MaxAdjustmentDown *big.Rat `json:"maxadjustmentdown"`
+		MaxAdjustmentUp   *big.Rat `json:"maxadjustmentup" deprecated:"2"`
+		MaxAdjustmentDown *big.Rat `json:"maxadjustmentdown" deprecated:"2"`
 
 		MaxTargetAdjustmentUp   *big.Rat `json:"maxtargetadjustmentup"`
 		MaxTargetAdjustmentDown *big.Rat `json:"maxtargetadjustmentdown"`
@@ -133,9 +134,10 @@ type (
 
 	// DaemonSettingsGet contains information about global daemon settings.
 	DaemonSettingsGet struct {
-		MaxDownloadSpeed int64         `json:"maxdownloadspeed"`
-		MaxUploadSpeed   int64         `json:"maxuploadspeed"`
-		Modules          configModules `json:"modules"`
+		MaxDownloadSpeed int64               `json:"maxdownloadspeed"`
+		MaxUploadSpeed   int64               `json:"maxuploadspeed"`
+		Modules          configModules       `json:"modules"`
+		Logging          persist.LogSettings `json:"logging"`
 	}
 
 	// DaemonVersion holds the version information for siad
@@ -299,59 +301,6 @@ func updateToRelease(version string) (err error) {
 	return nil
 }
 
-// daemonAlertsHandlerGET handles the API call that returns the alerts of all
-// loaded modules.
-func (api *API) daemonAlertsHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
-	// initialize slices to avoid "null" in response.
-	crit := make([]modules.Alert, 0, 6)
-	err := make([]modules.Alert, 0, 6)
-	warn := make([]modules.Alert, 0, 6)
-	if api.gateway != nil {
-		c, e, w := api.gateway.Alerts()
-		crit = append(crit, c...)
-		err = append(err, e...)
-		warn = append(warn, w...)
-	}
-	if api.cs != nil {
-		c, e, w := api.cs.Alerts()
-		crit = append(crit, c...)
-		err = append(err, e...)
-		warn = append(warn, w...)
-	}
-	if api.tpool != nil {
-		c, e, w := api.tpool.Alerts()
-		crit = append(crit, c...)
-		err = append(err, e...)
-		warn = append(warn, w...)
-	}
-	if api.wallet != nil {
-		c, e, w := api.wallet.Alerts()
-		crit = append(crit, c...)
-		err = append(err, e...)
-		warn = append(warn, w...)
-	}
-	if api.renter != nil {
-		c, e, w := api.renter.Alerts()
-		crit = append(crit, c...)
-		err = append(err, e...)
-		warn = append(warn, w...)
-	}
-	if api.host != nil {
-		c, e, w := api.host.Alerts()
-		crit = append(crit, c...)
-		err = append(err, e...)
-		warn = append(warn, w...)
-	}
-	// Sort alerts by severity. Critical first, then Error and finally Warning.
-	alerts := append(crit, append(err, warn...)...)
-	WriteJSON(w, DaemonAlertsGet{
-		Alerts:         alerts,
-		CriticalAlerts: crit,
-		ErrorAlerts:    err,
-		WarningAlerts:  warn,
-	})
-}
-
 // daemonUpdateHandlerGET handles the API call that checks for an update.
 func (api *API) daemonUpdateHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	version, err := fetchLatestVersion()
@@ -505,6 +454,7 @@ func (api *API) daemonSettingsHandlerGET(w http.ResponseWriter, _ *http.Request,
 		MaxDownloadSpeed: gmds,
 		MaxUploadSpeed:   gmus,
 		Modules:          api.staticConfigModules,
+		Logging:          persist.GlobalLogConfig.Settings(),
 	})
 }
 
@@ -530,6 +480,46 @@ func (api *API) daemonSettingsHandlerPOST(w http.ResponseWriter, req *http.Reque
 		}
 		maxUploadSpeed = uploadSpeed
 	}
+	// Scan the log settings. (optional parameters)
+	logSettings := persist.GlobalLogConfig.Settings()
+	var setLogSettings bool
+	if f, ok := req.Form["logformat"]; ok {
+		logSettings.Format = f[0]
+		setLogSettings = true
+	}
+	if l, ok := req.Form["loglevel"]; ok {
+		logSettings.Level = persist.LogLevel(l[0])
+		setLogSettings = true
+	}
+	if l, ok := req.Form["logmodulelevels"]; ok {
+		levels, err := persist.ParseLogModuleLevels(l[0])
+		if err != nil {
+			WriteError(w, Error{"unable to parse logmodulelevels: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		logSettings.ModuleLevels = levels
+		setLogSettings = true
+	}
+	if s := req.FormValue("logmaxsize"); s != "" {
+		if _, err := fmt.Sscan(s, &logSettings.MaxSize); err != nil {
+			WriteError(w, Error{"unable to parse logmaxsize: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		setLogSettings = true
+	}
+	if b := req.FormValue("logmaxbackups"); b != "" {
+		if _, err := fmt.Sscan(b, &logSettings.MaxBackups); err != nil {
+			WriteError(w, Error{"unable to parse logmaxbackups: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		setLogSettings = true
+	}
+	if setLogSettings {
+		if err := api.siadConfig.SetLogSettings(logSettings); err != nil {
+			WriteError(w, Error{"unable to set log settings: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
 	// Set the limit.
 	if err := api.siadConfig.SetRatelimit(maxDownloadSpeed, maxUploadSpeed); err != nil {
 		WriteError(w, Error{"unable to set limits: " + err.Error()}, http.StatusBadRequest)
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"go.sia.tech/siad/persist"
+)
+
+type (
+	// DaemonDiskThrottlesGet contains the configured disk I/O limits of all
+	// volumes and their statistics.
+	DaemonDiskThrottlesGet struct {
+		Volumes []persist.DiskIOStats `json:"volumes"`
+	}
+
+	// DaemonDiskThrottlesPost contains the disk I/O limits to set.
+	DaemonDiskThrottlesPost struct {
+		Volumes []persist.DiskIOLimits `json:"volumes"`
+	}
+)
+
+// daemonDiskThrottlesHandlerGET handles the API call to get the configured
+// disk I/O limits.
+func (api *API) daemonDiskThrottlesHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, DaemonDiskThrottlesGet{
+		Volumes: persist.GlobalDiskThrottles.Stats(),
+	})
+}
+
+// daemonDiskThrottlesHandlerPOST handles the API call to replace the
+// configured disk I/O limits.
+func (api *API) daemonDiskThrottlesHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params DaemonDiskThrottlesPost
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := api.siadConfig.SetDiskThrottles(params.Volumes); err != nil {
+		WriteError(w, Error{"failed to set disk throttles: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
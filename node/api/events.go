@@ -0,0 +1,611 @@
+package api
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+	"gitlab.com/NebulousLabs/threadgroup"
+	"golang.org/x/net/websocket"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+var (
+	// eventPollingInterval is the interval at which the modules without
+	// subscription mechanism are polled for new events.
+	eventPollingInterval = build.Select(build.Var{
+		Standard: 10 * time.Second,
+		Dev:      5 * time.Second,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// eventWebhookTimeout is the timeout for delivering an event to a
+	// webhook.
+	eventWebhookTimeout = build.Select(build.Var{
+		Standard: 30 * time.Second,
+		Dev:      10 * time.Second,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+
+	// eventWebhookAttempts is the number of times the delivery of an event to
+	// a webhook is attempted before the event is dropped.
+	eventWebhookAttempts = build.Select(build.Var{
+		Standard: 5,
+		Dev:      3,
+		Testing:  3,
+	}).(int)
+
+	// eventWebhookRetryInterval is the time waited before retrying a failed
+	// delivery to a webhook. It doubles with every attempt.
+	eventWebhookRetryInterval = build.Select(build.Var{
+		Standard: 10 * time.Second,
+		Dev:      5 * time.Second,
+		Testing:  50 * time.Millisecond,
+	}).(time.Duration)
+
+	// eventWebhookQueueSize is the number of events which are queued for
+	// delivery to a webhook. New events are dropped while the queue is full.
+	eventWebhookQueueSize = build.Select(build.Var{
+		Standard: 1000,
+		Dev:      100,
+		Testing:  10,
+	}).(int)
+)
+
+// The following headers are set on the requests delivering events to a
+// webhook.
+const (
+	// eventHeaderDelivery is a random id of the delivery which stays the same
+	// across retries. It allows webhooks to ignore duplicate deliveries.
+	eventHeaderDelivery = "Sia-Event-Delivery"
+	// eventHeaderSignature is the signature of the event, see
+	// modules.EventSignature. It is only set if the route has a secret.
+	eventHeaderSignature = "Sia-Event-Signature"
+	// eventHeaderTimestamp is the unix timestamp of the delivery attempt
+	// which is covered by the signature.
+	eventHeaderTimestamp = "Sia-Event-Timestamp"
+)
+
+type (
+	// DaemonEventRoutesGet contains the configured event routes.
+	DaemonEventRoutesGet struct {
+		Routes []modules.EventRoute `json:"routes"`
+	}
+
+	// DaemonEventRoutesPost contains the event routes to set.
+	DaemonEventRoutesPost struct {
+		Routes []modules.EventRoute `json:"routes"`
+	}
+
+	// eventConsensusSubscriber publishes the consensus changes it receives
+	// on the event bus.
+	eventConsensusSubscriber struct {
+		staticBus *modules.EventBus
+	}
+
+	// eventPoller keeps track of the state of the modules which are polled
+	// for events to publish only the changes.
+	eventPoller struct {
+		contracts    map[types.FileContractID]modules.EventContract
+		unconfirmed  map[types.TransactionID]struct{}
+		walletHeight types.BlockHeight
+		walletSynced bool
+
+		hostCollateralLow bool
+		hostFolders       map[uint16]modules.EventHostStorageFolder
+		hostMissedProofs  map[types.FileContractID]struct{}
+		hostObligations   map[types.FileContractID]string
+
+		staticAPI *API
+	}
+
+	// eventRouter routes the events of the bus to the configured sinks.
+	eventRouter struct {
+		// workers contains the queues of the workers delivering events to
+		// webhooks. There is one worker per webhook URL. It is only accessed
+		// by ThreadedRouteEvents.
+		workers map[string]chan queuedEvent
+
+		staticAPI    *API
+		staticClient *http.Client
+		staticLog    *persist.Logger
+		tg           threadgroup.ThreadGroup
+	}
+
+	// queuedEvent is an event which is queued for delivery to the webhook of
+	// a route.
+	queuedEvent struct {
+		route modules.EventRoute
+		event modules.Event
+	}
+)
+
+// EventBus returns the bus which the API publishes the events of its modules
+// on. It can be used to publish additional events or to subscribe to them.
+func (api *API) EventBus() *modules.EventBus {
+	return api.staticEventBus
+}
+
+// ProcessConsensusChange implements the modules.ConsensusSetSubscriber
+// interface.
+func (ecs *eventConsensusSubscriber) ProcessConsensusChange(cc modules.ConsensusChange) {
+	data := modules.EventConsensusChange{
+		ID:             cc.ID,
+		BlockHeight:    cc.BlockHeight,
+		AppliedBlocks:  make([]types.BlockID, 0, len(cc.AppliedBlocks)),
+		RevertedBlocks: make([]types.BlockID, 0, len(cc.RevertedBlocks)),
+		Synced:         cc.Synced,
+	}
+	for _, b := range cc.AppliedBlocks {
+		data.AppliedBlocks = append(data.AppliedBlocks, b.ID())
+	}
+	for _, b := range cc.RevertedBlocks {
+		data.RevertedBlocks = append(data.RevertedBlocks, b.ID())
+	}
+	ecs.staticBus.Publish("consensus", modules.EventTypeConsensusChange, data)
+}
+
+// ThreadedPublishEvents publishes the events of the loaded modules on the
+// event bus until stop is closed. Consensus changes are published as they
+// happen, contracts, wallet transactions and the state of the host are polled
+// periodically. Alert events are published by the alert router.
+func (api *API) ThreadedPublishEvents(log *persist.Logger, stop <-chan struct{}) {
+	if api.cs != nil {
+		ecs := &eventConsensusSubscriber{staticBus: api.staticEventBus}
+		err := api.cs.ConsensusSetSubscribe(ecs, modules.ConsensusChangeRecent, stop)
+		if err != nil {
+			log.Println("WARN: failed to subscribe to consensus changes:", err)
+		} else {
+			defer api.cs.Unsubscribe(ecs)
+		}
+	}
+	ep := &eventPoller{
+		staticAPI: api,
+	}
+	ep.managedPollContracts()
+	ep.managedPollWallet()
+	ep.managedPollHost()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(eventPollingInterval):
+		}
+		ep.managedPollContracts()
+		ep.managedPollWallet()
+		ep.managedPollHost()
+	}
+}
+
+// managedPollContracts publishes events for contracts which were formed or
+// archived since the last poll. The first poll only initializes the state.
+func (ep *eventPoller) managedPollContracts() {
+	if ep.staticAPI.renter == nil {
+		return
+	}
+	contracts := make(map[types.FileContractID]modules.EventContract)
+	for _, c := range ep.staticAPI.renter.Contracts() {
+		contracts[c.ID] = modules.EventContract{
+			ID:            c.ID,
+			HostPublicKey: c.HostPublicKey,
+			StartHeight:   c.StartHeight,
+			EndHeight:     c.EndHeight,
+		}
+	}
+	if ep.contracts != nil {
+		bus := ep.staticAPI.staticEventBus
+		for id, c := range contracts {
+			if _, exists := ep.contracts[id]; !exists {
+				bus.Publish("renter", modules.EventTypeContractFormed, c)
+			}
+		}
+		for id, c := range ep.contracts {
+			if _, exists := contracts[id]; !exists {
+				bus.Publish("renter", modules.EventTypeContractArchived, c)
+			}
+		}
+	}
+	ep.contracts = contracts
+}
+
+// managedPollWallet publishes events for wallet transactions which entered
+// the transaction pool or were confirmed since the last poll. A locked wallet
+// is skipped and the first poll of an unlocked wallet only initializes the
+// state.
+func (ep *eventPoller) managedPollWallet() {
+	w := ep.staticAPI.wallet
+	if w == nil {
+		return
+	}
+	if unlocked, err := w.Unlocked(); err != nil || !unlocked {
+		return
+	}
+	height, err := w.Height()
+	if err != nil {
+		return
+	}
+	bus := ep.staticAPI.staticEventBus
+
+	// Publish the confirmed transactions.
+	if ep.walletSynced && height > ep.walletHeight {
+		txns, err := w.Transactions(ep.walletHeight+1, height)
+		if err == nil {
+			for _, txn := range txns {
+				bus.Publish("wallet", modules.EventTypeWalletTransactionConfirmed, txn)
+			}
+		}
+	}
+
+	// Publish the unconfirmed transactions which weren't published before.
+	txns, err := w.UnconfirmedTransactions()
+	if err != nil {
+		return
+	}
+	unconfirmed := make(map[types.TransactionID]struct{}, len(txns))
+	for _, txn := range txns {
+		unconfirmed[txn.TransactionID] = struct{}{}
+		if _, exists := ep.unconfirmed[txn.TransactionID]; exists || !ep.walletSynced {
+			continue
+		}
+		bus.Publish("wallet", modules.EventTypeWalletTransactionUnconfirmed, txn)
+	}
+	ep.unconfirmed = unconfirmed
+	ep.walletHeight = height
+	ep.walletSynced = true
+}
+
+// managedPollHost publishes events for the host's storage obligations which
+// were formed or failed, storage proofs which were missed and storage folders
+// which encountered errors since the last poll. The low collateral event is
+// published when the remaining collateral budget drops below the threshold.
+// The first poll only initializes the state.
+func (ep *eventPoller) managedPollHost() {
+	h := ep.staticAPI.host
+	if h == nil {
+		return
+	}
+	missedProofs, err := h.MissedProofs()
+	if err != nil {
+		return
+	}
+	initialized := ep.hostObligations != nil
+	bus := ep.staticAPI.staticEventBus
+
+	// Publish the formed and failed obligations.
+	obligations := make(map[types.FileContractID]string)
+	for _, so := range h.StorageObligations() {
+		obligations[so.ObligationId] = so.ObligationStatus
+		status, exists := ep.hostObligations[so.ObligationId]
+		if !initialized || status == so.ObligationStatus {
+			continue
+		}
+		data := modules.EventHostObligation{
+			ID:                so.ObligationId,
+			Status:            so.ObligationStatus,
+			DataSize:          so.DataSize,
+			LockedCollateral:  so.LockedCollateral,
+			RiskedCollateral:  so.RiskedCollateral,
+			NegotiationHeight: so.NegotiationHeight,
+			ExpirationHeight:  so.ExpirationHeight,
+			ProofDeadline:     so.ProofDeadLine,
+		}
+		if !exists {
+			bus.Publish("host", modules.EventTypeHostContractFormed, data)
+		}
+		if so.ObligationStatus == "obligationFailed" || so.ObligationStatus == "obligationRejected" {
+			bus.Publish("host", modules.EventTypeHostObligationFailed, data)
+		}
+	}
+	ep.hostObligations = obligations
+
+	// Publish the missed proofs.
+	missed := make(map[types.FileContractID]struct{}, len(missedProofs))
+	for _, mp := range missedProofs {
+		missed[mp.ObligationID] = struct{}{}
+		if _, exists := ep.hostMissedProofs[mp.ObligationID]; initialized && !exists {
+			bus.Publish("host", modules.EventTypeHostStorageProofMissed, mp)
+		}
+	}
+	ep.hostMissedProofs = missed
+
+	// Publish the storage folders with new failures.
+	folders := make(map[uint16]modules.EventHostStorageFolder)
+	for _, sf := range h.StorageFolders() {
+		folder := modules.EventHostStorageFolder{
+			Index:        sf.Index,
+			Path:         sf.Path,
+			FailedReads:  sf.FailedReads,
+			FailedWrites: sf.FailedWrites,
+			DrainError:   sf.DrainError,
+		}
+		folders[sf.Index] = folder
+		old, exists := ep.hostFolders[sf.Index]
+		if !initialized || !exists {
+			continue
+		}
+		if folder.FailedReads > old.FailedReads || folder.FailedWrites > old.FailedWrites || (folder.DrainError != "" && folder.DrainError != old.DrainError) {
+			bus.Publish("host", modules.EventTypeHostFolderError, folder)
+		}
+	}
+	ep.hostFolders = folders
+
+	// Publish the low collateral event once the remaining budget drops below
+	// the threshold.
+	is := h.InternalSettings()
+	locked := h.FinancialMetrics().LockedStorageCollateral
+	remaining := types.ZeroCurrency
+	if is.CollateralBudget.Cmp(locked) > 0 {
+		remaining = is.CollateralBudget.Sub(locked)
+	}
+	low := !is.CollateralBudgetAlertThreshold.IsZero() && remaining.Cmp(is.CollateralBudgetAlertThreshold) < 0
+	if initialized && low && !ep.hostCollateralLow {
+		bus.Publish("host", modules.EventTypeHostCollateralLow, modules.EventHostCollateral{
+			Budget:    is.CollateralBudget,
+			Locked:    locked,
+			Remaining: remaining,
+			Threshold: is.CollateralBudgetAlertThreshold,
+		})
+	}
+	ep.hostCollateralLow = low
+}
+
+// ThreadedRouteEvents routes the events published on the event bus to the
+// configured sinks until stop is closed. Events are delivered to webhooks by a
+// worker per webhook so that retrying a failing webhook doesn't hold up the
+// other routes. The workers are stopped before ThreadedRouteEvents returns.
+func (api *API) ThreadedRouteEvents(log *persist.Logger, stop <-chan struct{}) {
+	er := &eventRouter{
+		workers:      make(map[string]chan queuedEvent),
+		staticAPI:    api,
+		staticClient: &http.Client{Timeout: eventWebhookTimeout},
+		staticLog:    log,
+	}
+	defer func() {
+		if err := er.tg.Stop(); err != nil {
+			log.Println("WARN: failed to stop event routing:", err)
+		}
+	}()
+	sub := api.staticEventBus.Subscribe()
+	defer sub.Close()
+	for {
+		var e modules.Event
+		var ok bool
+		select {
+		case <-stop:
+			return
+		case e, ok = <-sub.C:
+		}
+		if !ok {
+			return
+		}
+		routes := api.siadConfig.CurrentEventRoutes()
+		er.pruneWorkers(routes)
+		for _, route := range routes {
+			if !route.Matches(e) {
+				continue
+			}
+			if route.Sink == modules.EventSinkWebhook {
+				er.queueEvent(route, e)
+				continue
+			}
+			er.managedTryRouteEvent(route, e)
+		}
+	}
+}
+
+// queueEvent queues an event for delivery to the webhook of a route. The
+// worker of the webhook is started if it isn't running yet. The event is
+// dropped if the worker's queue is full.
+func (er *eventRouter) queueEvent(route modules.EventRoute, e modules.Event) {
+	queue, exists := er.workers[route.URL]
+	if !exists {
+		queue = make(chan queuedEvent, eventWebhookQueueSize)
+		er.workers[route.URL] = queue
+		go er.threadedDeliverEvents(queue)
+	}
+	select {
+	case queue <- queuedEvent{route: route, event: e}:
+	default:
+		er.staticLog.Printf("WARN: dropped %v event, the queue of the webhook at %v is full", e.Type, route.URL)
+	}
+}
+
+// pruneWorkers stops the workers of webhooks which are no longer part of the
+// configured routes.
+func (er *eventRouter) pruneWorkers(routes []modules.EventRoute) {
+	webhooks := make(map[string]struct{}, len(routes))
+	for _, route := range routes {
+		if route.Sink == modules.EventSinkWebhook {
+			webhooks[route.URL] = struct{}{}
+		}
+	}
+	for url, queue := range er.workers {
+		if _, exists := webhooks[url]; !exists {
+			close(queue)
+			delete(er.workers, url)
+		}
+	}
+}
+
+// threadedDeliverEvents delivers the events of a queue one after another until
+// the queue is closed or the router is stopped.
+func (er *eventRouter) threadedDeliverEvents(queue <-chan queuedEvent) {
+	if err := er.tg.Add(); err != nil {
+		return
+	}
+	defer er.tg.Done()
+	for {
+		select {
+		case <-er.tg.StopChan():
+			return
+		case qe, ok := <-queue:
+			if !ok {
+				return
+			}
+			er.managedTryRouteEvent(qe.route, qe.event)
+		}
+	}
+}
+
+// managedTryRouteEvent routes a single event to the sink of a route and logs
+// the error if it couldn't be delivered.
+func (er *eventRouter) managedTryRouteEvent(route modules.EventRoute, e modules.Event) {
+	if err := er.managedRouteEvent(route, e); err != nil {
+		er.staticLog.Printf("WARN: failed to route %v event to %v sink: %v", e.Type, route.Sink, err)
+	}
+}
+
+// managedRouteEvent sends a single event to the sink of a route. Failed
+// deliveries to a webhook are retried with an exponential backoff.
+func (er *eventRouter) managedRouteEvent(route modules.EventRoute, e modules.Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return errors.AddContext(err, "failed to marshal event")
+	}
+	switch route.Sink {
+	case modules.EventSinkLog:
+		er.staticLog.Printf("%v event from %v: %s", e.Type, e.Module, body)
+		return nil
+	case modules.EventSinkWebhook:
+		delivery := hex.EncodeToString(fastrand.Bytes(16))
+		interval := eventWebhookRetryInterval
+		for attempt := 1; ; attempt++ {
+			err = er.managedPostWebhook(route, delivery, body)
+			if err == nil || attempt >= eventWebhookAttempts {
+				break
+			}
+			select {
+			case <-er.tg.StopChan():
+				return errors.Compose(err, errors.New("router was stopped"))
+			case <-time.After(interval):
+			}
+			interval *= 2
+		}
+		if err != nil {
+			return errors.AddContext(err, fmt.Sprintf("failed to deliver event after %v attempts", eventWebhookAttempts))
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown sink '%v'", route.Sink)
+	}
+}
+
+// managedPostWebhook makes a single attempt at delivering the body of an
+// event to the webhook of a route. The request is signed if the route has a
+// secret and it is canceled if the router is stopped.
+func (er *eventRouter) managedPostWebhook(route modules.EventRoute, delivery string, body []byte) error {
+	req, err := http.NewRequestWithContext(er.tg.StopCtx(), http.MethodPost, route.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(eventHeaderDelivery, delivery)
+	req.Header.Set(eventHeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	if route.Secret != "" {
+		req.Header.Set(eventHeaderSignature, modules.EventSignature(route.Secret, timestamp, body))
+	}
+	resp, err := er.staticClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %v", resp.StatusCode)
+	}
+	return nil
+}
+
+// parseEventTypes parses a comma separated list of event types.
+func parseEventTypes(typesStr string) ([]modules.EventType, error) {
+	if typesStr == "" {
+		return nil, nil
+	}
+	var eventTypes []modules.EventType
+	for _, t := range strings.Split(typesStr, ",") {
+		eventTypes = append(eventTypes, modules.EventType(t))
+	}
+	return eventTypes, modules.ValidateEventTypes(eventTypes)
+}
+
+// daemonEventsHandlerGET handles the API call that streams the events of the
+// event bus over a WebSocket connection.
+func (api *API) daemonEventsHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	eventTypes, err := parseEventTypes(req.FormValue("types"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse types: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	s := websocket.Server{
+		Handler: func(conn *websocket.Conn) {
+			sub := api.staticEventBus.Subscribe(eventTypes...)
+			defer sub.Close()
+
+			// The client isn't expected to send anything. Reading from the
+			// connection detects when it is closed.
+			closed := make(chan struct{})
+			go func() {
+				_, _ = io.Copy(ioutil.Discard, conn)
+				close(closed)
+			}()
+			for {
+				select {
+				case <-closed:
+					return
+				case e, ok := <-sub.C:
+					if !ok {
+						return
+					}
+					if err := websocket.JSON.Send(conn, e); err != nil {
+						return
+					}
+				}
+			}
+		},
+	}
+	s.ServeHTTP(w, req)
+}
+
+// daemonEventsRoutesHandlerGET handles the API call to get the configured
+// event routes. The secrets of the routes are not returned.
+func (api *API) daemonEventsRoutesHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	routes := api.siadConfig.CurrentEventRoutes()
+	for i := range routes {
+		routes[i].Secret = ""
+	}
+	WriteJSON(w, DaemonEventRoutesGet{
+		Routes: routes,
+	})
+}
+
+// daemonEventsRoutesHandlerPOST handles the API call to replace the configured
+// event routes.
+func (api *API) daemonEventsRoutesHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params DaemonEventRoutesPost
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := api.siadConfig.SetEventRoutes(params.Routes); err != nil {
+		WriteError(w, Error{"failed to set event routes: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
@@ -0,0 +1,272 @@
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/persist"
+	"go.sia.tech/siad/types"
+)
+
+// TestRouteEventWebhook tests routing an event to a webhook.
+func TestRouteEventWebhook(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a webhook which forwards the received events after verifying
+	// their signature.
+	received := make(chan modules.Event, eventWebhookAttempts)
+	deliveries := make(chan string, eventWebhookAttempts)
+	var status int32 = http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		timestamp, err := strconv.ParseInt(req.Header.Get(eventHeaderTimestamp), 10, 64)
+		if err != nil {
+			t.Error(err)
+		}
+		if sig := req.Header.Get(eventHeaderSignature); sig != modules.EventSignature("secret", timestamp, body) {
+			t.Error("invalid signature", sig)
+		}
+		var e modules.Event
+		if err := json.Unmarshal(body, &e); err != nil {
+			t.Error(err)
+		}
+		deliveries <- req.Header.Get(eventHeaderDelivery)
+		received <- e
+		w.WriteHeader(int(atomic.LoadInt32(&status)))
+	}))
+	defer server.Close()
+
+	er := &eventRouter{
+		staticClient: &http.Client{Timeout: eventWebhookTimeout},
+	}
+	route := modules.EventRoute{
+		Sink:   modules.EventSinkWebhook,
+		URL:    server.URL,
+		Secret: "secret",
+	}
+	e := modules.Event{
+		Type:   modules.EventTypeContractFormed,
+		Module: "renter",
+		Data:   modules.EventContract{EndHeight: 100},
+	}
+	if err := er.managedRouteEvent(route, e); err != nil {
+		t.Fatal(err)
+	}
+	<-deliveries
+	routed := <-received
+	if routed.Type != e.Type || routed.Module != e.Module {
+		t.Fatal("unexpected event", routed)
+	}
+	if data, ok := routed.Data.(map[string]interface{}); !ok || data["endheight"] != float64(100) {
+		t.Fatal("unexpected data", routed.Data)
+	}
+
+	// A webhook returning an error status should cause an error after
+	// retrying the delivery. All attempts share the same delivery id.
+	atomic.StoreInt32(&status, http.StatusInternalServerError)
+	if err := er.managedRouteEvent(route, e); err == nil {
+		t.Fatal("expected error")
+	}
+	if len(received) != eventWebhookAttempts {
+		t.Fatalf("expected %v attempts, got %v", eventWebhookAttempts, len(received))
+	}
+	delivery := <-deliveries
+	for i := 1; i < eventWebhookAttempts; i++ {
+		if d := <-deliveries; d != delivery || d == "" {
+			t.Fatal("delivery id should be the same across retries", d, delivery)
+		}
+	}
+}
+
+// TestRouteEventWorkers tests that the events for a webhook are queued for a
+// single worker, that events are dropped while the queue is full and that
+// stopping the router waits for the worker.
+func TestRouteEventWorkers(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a webhook which blocks until it is released.
+	received := make(chan struct{}, 2*eventWebhookQueueSize)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		received <- struct{}{}
+		<-release
+	}))
+	defer server.Close()
+
+	log, err := persist.NewLogger(ioutil.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	er := &eventRouter{
+		workers:      make(map[string]chan queuedEvent),
+		staticClient: &http.Client{Timeout: eventWebhookTimeout},
+		staticLog:    log,
+	}
+	route := modules.EventRoute{
+		Sink: modules.EventSinkWebhook,
+		URL:  server.URL,
+	}
+	e := modules.Event{
+		Type:   modules.EventTypeContractFormed,
+		Module: "renter",
+	}
+
+	// Queue the first event and wait for the worker to deliver it. Then
+	// fill the queue and queue some more events which should be dropped.
+	er.queueEvent(route, e)
+	<-received
+	for i := 0; i < eventWebhookQueueSize+5; i++ {
+		er.queueEvent(route, e)
+	}
+	if len(er.workers) != 1 {
+		t.Fatal("expected a single worker", len(er.workers))
+	}
+	if queued := len(er.workers[route.URL]); queued != eventWebhookQueueSize {
+		t.Fatalf("expected %v queued events, got %v", eventWebhookQueueSize, queued)
+	}
+
+	// Stopping the router cancels the blocked delivery and waits for the
+	// worker.
+	if err := er.tg.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	close(release)
+
+	// Removing the route stops its worker.
+	er.pruneWorkers(nil)
+	if len(er.workers) != 0 {
+		t.Fatal("worker should have been pruned")
+	}
+}
+
+// eventTestHost is a host which returns the provided state to the event
+// poller.
+type eventTestHost struct {
+	modules.Host
+
+	financialMetrics modules.HostFinancialMetrics
+	internalSettings modules.HostInternalSettings
+	missedProofs     []modules.HostMissedProof
+	obligations      []modules.StorageObligation
+	storageFolders   []modules.StorageFolderMetadata
+}
+
+func (h *eventTestHost) FinancialMetrics() modules.HostFinancialMetrics { return h.financialMetrics }
+func (h *eventTestHost) InternalSettings() modules.HostInternalSettings { return h.internalSettings }
+func (h *eventTestHost) MissedProofs() ([]modules.HostMissedProof, error) {
+	return h.missedProofs, nil
+}
+func (h *eventTestHost) StorageObligations() []modules.StorageObligation { return h.obligations }
+func (h *eventTestHost) StorageFolders() []modules.StorageFolderMetadata {
+	return h.storageFolders
+}
+
+// TestPollHost tests that the event poller publishes the host's events.
+func TestPollHost(t *testing.T) {
+	t.Parallel()
+
+	h := &eventTestHost{
+		internalSettings: modules.HostInternalSettings{
+			CollateralBudget:               types.NewCurrency64(100),
+			CollateralBudgetAlertThreshold: types.NewCurrency64(10),
+		},
+		obligations: []modules.StorageObligation{
+			{ObligationId: types.FileContractID{1}, ObligationStatus: "obligationUnresolved"},
+		},
+		storageFolders: []modules.StorageFolderMetadata{{Index: 1, Path: "/foo"}},
+	}
+	api := &API{
+		host:           h,
+		staticEventBus: modules.NewEventBus(),
+	}
+	sub := api.staticEventBus.Subscribe()
+	defer sub.Close()
+	ep := &eventPoller{staticAPI: api}
+
+	// The first poll doesn't publish events.
+	ep.managedPollHost()
+	if len(sub.C) != 0 {
+		t.Fatal("first poll shouldn't publish events")
+	}
+
+	// Form a contract, fail the existing one, miss its proof, fail a read
+	// and lock up most of the collateral budget.
+	h.obligations = []modules.StorageObligation{
+		{ObligationId: types.FileContractID{1}, ObligationStatus: "obligationFailed"},
+		{ObligationId: types.FileContractID{2}, ObligationStatus: "obligationUnresolved"},
+	}
+	h.missedProofs = []modules.HostMissedProof{{ObligationID: types.FileContractID{1}}}
+	h.storageFolders[0].FailedReads = 1
+	h.financialMetrics.LockedStorageCollateral = types.NewCurrency64(95)
+	ep.managedPollHost()
+	published := make(map[modules.EventType]modules.Event)
+	for len(sub.C) > 0 {
+		e := <-sub.C
+		if e.Module != "host" {
+			t.Fatal("unexpected module", e.Module)
+		}
+		if _, exists := published[e.Type]; exists {
+			t.Fatal("event published twice", e.Type)
+		}
+		published[e.Type] = e
+	}
+	if len(published) != 5 {
+		t.Fatal("expected 5 events", published)
+	}
+	if so := published[modules.EventTypeHostContractFormed].Data.(modules.EventHostObligation); so.ID != (types.FileContractID{2}) {
+		t.Fatal("wrong contract formed", so.ID)
+	}
+	if so := published[modules.EventTypeHostObligationFailed].Data.(modules.EventHostObligation); so.ID != (types.FileContractID{1}) {
+		t.Fatal("wrong obligation failed", so.ID)
+	}
+	if mp := published[modules.EventTypeHostStorageProofMissed].Data.(modules.HostMissedProof); mp.ObligationID != (types.FileContractID{1}) {
+		t.Fatal("wrong missed proof", mp.ObligationID)
+	}
+	if sf := published[modules.EventTypeHostFolderError].Data.(modules.EventHostStorageFolder); sf.Index != 1 || sf.FailedReads != 1 {
+		t.Fatal("wrong folder", sf)
+	}
+	if c := published[modules.EventTypeHostCollateralLow].Data.(modules.EventHostCollateral); !c.Remaining.Equals64(5) {
+		t.Fatal("wrong remaining collateral", c.Remaining)
+	}
+
+	// Polling the same state again doesn't publish events.
+	ep.managedPollHost()
+	if len(sub.C) != 0 {
+		t.Fatal("unchanged state shouldn't publish events")
+	}
+}
+
+// TestParseEventTypes is a unit test for parseEventTypes.
+func TestParseEventTypes(t *testing.T) {
+	t.Parallel()
+
+	eventTypes, err := parseEventTypes("")
+	if err != nil || len(eventTypes) != 0 {
+		t.Fatal("empty string should result in no types", eventTypes, err)
+	}
+	eventTypes, err = parseEventTypes("consensus.change,contract.formed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(eventTypes) != 2 || eventTypes[0] != modules.EventTypeConsensusChange || eventTypes[1] != modules.EventTypeContractFormed {
+		t.Fatal("unexpected types", eventTypes)
+	}
+	if _, err := parseEventTypes("consensus.change,unknown"); err == nil {
+		t.Fatal("unknown type should be rejected")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
 	"time"
 
 	"github.com/julienschmidt/httprouter"
@@ -29,6 +30,17 @@ type (
 		StartTime time.Time `json:"starttime"`
 	}
 
+	// GatewayPeersGET contains the peers of the gateway and their scores.
+	GatewayPeersGET struct {
+		Peers []GatewayPeer `json:"peers"`
+	}
+
+	// GatewayPeer is a peer of the gateway together with its score.
+	GatewayPeer struct {
+		modules.Peer
+		Score modules.PeerScore `json:"score"`
+	}
+
 	// GatewayBlocklistPOST contains the information needed to set the Blocklist
 	// of the gateway
 	GatewayBlocklistPOST struct {
@@ -38,7 +50,7 @@ type (
 
 	// GatewayBlocklistGET contains the Blocklist of the gateway
 	GatewayBlocklistGET struct {
-		Blacklist []string `json:"blacklist"` // deprecated, kept for backwards compatibility
+		Blacklist []string `json:"blacklist" deprecated:"2"` // deprecated, kept for backwards compatibility
 		Blocklist []string `json:"blocklist"`
 	}
 )
@@ -60,6 +72,9 @@ func RegisterRoutesGateway(router *httprouter.Router, g modules.Gateway, require
 	router.POST("/gateway/disconnect/:netaddress", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		gatewayDisconnectHandler(g, w, req, ps)
 	}, requiredPassword))
+	router.GET("/gateway/peers", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		gatewayPeersHandlerGET(g, w, req, ps)
+	})
 	router.GET("/gateway/blocklist", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		gatewayBlocklistHandlerGET(g, w, req, ps)
 	})
@@ -89,6 +104,25 @@ func gatewayHandlerGET(gateway modules.Gateway, w http.ResponseWriter, _ *http.R
 	WriteJSON(w, GatewayGET{gateway.Address(), peers, gateway.Online(), mds, mus})
 }
 
+// gatewayPeersHandlerGET handles the API call asking for the gateway's peers
+// and their scores. The peers are sorted by score in descending order.
+func gatewayPeersHandlerGET(gateway modules.Gateway, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	scores := gateway.PeerScores()
+	peers := make([]GatewayPeer, 0, len(scores))
+	for _, p := range gateway.Peers() {
+		score, exists := scores[p.NetAddress]
+		if !exists {
+			// The peer connected after the scores were fetched.
+			continue
+		}
+		peers = append(peers, GatewayPeer{Peer: p, Score: score})
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].Score.Score > peers[j].Score.Score
+	})
+	WriteJSON(w, GatewayPeersGET{Peers: peers})
+}
+
 // gatewayHandlerPOST handles the API call changing gateway specific settings.
 func gatewayHandlerPOST(gateway modules.Gateway, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	maxDownloadSpeed, maxUploadSpeed := gateway.RateLimits()
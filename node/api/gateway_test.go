@@ -66,6 +66,16 @@ func TestGatewayPeerConnect(t *testing.T) {
 	if len(info.Peers) != 1 || info.Peers[0].NetAddress != peer.Address() {
 		t.Fatal("/gateway/connect did not connect to peer", peer.Address())
 	}
+
+	// The peer should be listed with its score.
+	var gpg GatewayPeersGET
+	err = st.getAPI("/gateway/peers", &gpg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gpg.Peers) != 1 || gpg.Peers[0].NetAddress != peer.Address() || gpg.Peers[0].Score.Score <= 0 {
+		t.Fatal("unexpected peers", gpg.Peers)
+	}
 }
 
 // TestGatewayPeerDisconnect checks that /gateway/disconnect removes the
@@ -1,14 +1,20 @@
 package api
 
 import (
+	"bytes"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/julienschmidt/httprouter"
 
+	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/types"
 )
@@ -57,9 +63,39 @@ type (
 		NetworkMetrics       modules.HostNetworkMetrics       `json:"networkmetrics"`
 		PriceTable           modules.RPCPriceTable            `json:"pricetable"`
 		PublicKey            types.SiaPublicKey               `json:"publickey"`
+		ReadCacheMetrics     modules.HostReadCacheMetrics     `json:"readcachemetrics"`
 		WorkingStatus        modules.HostWorkingStatus        `json:"workingstatus"`
 	}
 
+	// HostAccountTransactionsGET contains the information that is returned
+	// after a GET request to /host/accounttransactions - the deposits,
+	// withdrawals and refunds of an ephemeral account.
+	HostAccountTransactionsGET struct {
+		Transactions []modules.HostAccountTransaction `json:"transactions"`
+	}
+
+	// HostAlertsGET contains the information that is returned after a GET
+	// request to /host/alerts - the alerts of the host sorted by severity.
+	HostAlertsGET struct {
+		Alerts         []modules.Alert `json:"alerts"`
+		CriticalAlerts []modules.Alert `json:"criticalalerts"`
+		ErrorAlerts    []modules.Alert `json:"erroralerts"`
+		WarningAlerts  []modules.Alert `json:"warningalerts"`
+	}
+
+	// HostBandwidthGET contains the information that is returned after a GET
+	// request to /host/bandwidth - the bandwidth used since the host was
+	// started and the persisted bandwidth used within a period.
+	HostBandwidthGET struct {
+		Download  uint64    `json:"download"`
+		Upload    uint64    `json:"upload"`
+		StartTime time.Time `json:"starttime"`
+
+		// Ledger contains the bandwidth used within the requested period per
+		// day and per RPC.
+		Ledger modules.HostBandwidthMetrics `json:"ledger"`
+	}
+
 	// HostEstimateScoreGET contains the information that is returned from a
 	// /host/estimatescore call.
 	HostEstimateScoreGET struct {
@@ -67,6 +103,25 @@ type (
 		ConversionRate float64        `json:"conversionrate"`
 	}
 
+	// HostMissedProofsGET contains the information that is returned after a
+	// GET request to /host/missedproofs - the storage proofs the host missed
+	// and a summary of the collateral lost by cause.
+	HostMissedProofsGET struct {
+		MissedProofs []modules.HostMissedProof                                `json:"missedproofs"`
+		Causes       map[modules.MissedProofCause]HostMissedProofCauseSummary `json:"causes"`
+
+		TotalBurntCollateral types.Currency `json:"totalburntcollateral"`
+		TotalLostRevenue     types.Currency `json:"totallostrevenue"`
+	}
+
+	// HostMissedProofCauseSummary summarizes the missed proofs with the same
+	// probable cause.
+	HostMissedProofCauseSummary struct {
+		BurntCollateral types.Currency `json:"burntcollateral"`
+		Count           uint64         `json:"count"`
+		LostRevenue     types.Currency `json:"lostrevenue"`
+	}
+
 	// StorageGET contains the information that is returned after a GET request
 	// to /host/storage - a bunch of information about the status of storage
 	// management on the host.
@@ -87,6 +142,15 @@ func RegisterRoutesHost(router *httprouter.Router, h modules.Host, deps modules.
 	router.POST("/host/announce", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		hostAnnounceHandler(h, w, req, ps)
 	}, requiredPassword))
+	router.GET("/host/accounttransactions", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostAccountTransactionsHandlerGET(h, w, req, ps)
+	})
+	router.GET("/host/accounttransactions/export", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostAccountTransactionsExportHandlerGET(h, w, req, ps)
+	})
+	router.GET("/host/alerts", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostAlertsHandlerGET(h, w, req, ps)
+	})
 	router.GET("/host/contracts", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		hostContractInfoHandler(h, w, req, ps)
 	})
@@ -96,14 +160,53 @@ func RegisterRoutesHost(router *httprouter.Router, h modules.Host, deps modules.
 	router.GET("/host/bandwidth", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		hostBandwidthHandlerGET(h, w, req, ps)
 	})
+	router.GET("/host/mdmstats", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostMDMStatsHandlerGET(h, w, req, ps)
+	})
+	router.GET("/host/metrics/reconciliation", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostMetricsReconciliationHandlerGET(h, w, req, ps)
+	})
+	router.GET("/host/missedproofs", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostMissedProofsHandlerGET(h, w, req, ps)
+	})
+	router.GET("/host/obligations", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostObligationsHandlerGET(h, w, req, ps)
+	})
+	router.GET("/host/prooffees", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostProofFeesHandlerGET(h, w, req, ps)
+	})
+	router.GET("/host/pricing", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostPricingHandlerGET(h, w, req, ps)
+	})
+	router.POST("/host/pricing", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostPricingHandlerPOST(h, w, req, ps)
+	}, requiredPassword))
+	router.GET("/host/rpcstats", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostRPCStatsHandlerGET(h, w, req, ps)
+	})
+	router.GET("/host/rescan", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostRescanHandlerGET(h, w, req, ps)
+	})
+	router.POST("/host/rescan", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostRescanHandlerPOST(h, w, req, ps)
+	}, requiredPassword))
 
 	// Calls pertaining to the storage manager that the host uses.
+	router.POST("/host/settings/preview", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		hostSettingsPreviewHandlerPOST(h, w, req, ps)
+	}, requiredPassword))
 	router.GET("/host/storage", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		storageHandler(h, w, req, ps)
 	})
+	router.GET("/host/storage/health", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		storageHealthHandler(h, w, req, ps)
+	})
 	router.POST("/host/storage/folders/add", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		storageFoldersAddHandler(h, w, req, ps)
 	}, requiredPassword))
+	router.POST("/host/storage/folders/drain", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		storageFoldersDrainHandler(h, w, req, ps)
+	}, requiredPassword))
 	router.POST("/host/storage/folders/remove", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		storageFoldersRemoveHandler(h, w, req, ps)
 	}, requiredPassword))
@@ -159,6 +262,57 @@ func hostContractInfoHandler(host modules.Host, w http.ResponseWriter, _ *http.R
 	WriteJSON(w, cg)
 }
 
+// hostMetricsReconciliationHandlerGET handles GET requests to the
+// /host/metrics/reconciliation API endpoint, returning the result of
+// reconciling the host's financial metrics with the metrics journal at
+// startup.
+func hostMetricsReconciliationHandlerGET(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	mr := host.MetricsReconciliation()
+	// initialize slice to avoid "null" in response.
+	mr.Drifts = append([]modules.MetricsDrift{}, mr.Drifts...)
+	WriteJSON(w, mr)
+}
+
+// hostAlertsHandlerGET handles GET requests to the /host/alerts API endpoint,
+// returning the alerts of the host.
+func hostAlertsHandlerGET(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	crit, err, warn := host.Alerts()
+	// initialize slices to avoid "null" in response.
+	hag := HostAlertsGET{
+		CriticalAlerts: append([]modules.Alert{}, crit...),
+		ErrorAlerts:    append([]modules.Alert{}, err...),
+		WarningAlerts:  append([]modules.Alert{}, warn...),
+	}
+	hag.Alerts = append(append(append([]modules.Alert{}, crit...), err...), warn...)
+	WriteJSON(w, hag)
+}
+
+// hostMissedProofsHandlerGET handles GET requests to the /host/missedproofs
+// API endpoint, returning the storage proofs the host missed.
+func hostMissedProofsHandlerGET(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	mps, err := host.MissedProofs()
+	if err != nil {
+		WriteError(w, Error{"failed to get missed proofs: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	mpg := HostMissedProofsGET{
+		// initialize slice and map to avoid "null" in response.
+		MissedProofs: append([]modules.HostMissedProof{}, mps...),
+		Causes:       make(map[modules.MissedProofCause]HostMissedProofCauseSummary),
+	}
+	for _, mp := range mps {
+		summary := mpg.Causes[mp.Cause]
+		summary.BurntCollateral = summary.BurntCollateral.Add(mp.BurntCollateral)
+		summary.Count++
+		summary.LostRevenue = summary.LostRevenue.Add(mp.LostRevenue)
+		mpg.Causes[mp.Cause] = summary
+
+		mpg.TotalBurntCollateral = mpg.TotalBurntCollateral.Add(mp.BurntCollateral)
+		mpg.TotalLostRevenue = mpg.TotalLostRevenue.Add(mp.LostRevenue)
+	}
+	WriteJSON(w, mpg)
+}
+
 // hostHandlerGET handles GET requests to the /host API endpoint, returning key
 // information about the host.
 func hostHandlerGET(host modules.Host, w http.ResponseWriter, deps modules.Dependencies, _ *http.Request, _ httprouter.Params) {
@@ -170,6 +324,7 @@ func hostHandlerGET(host modules.Host, w http.ResponseWriter, deps modules.Depen
 	ws := host.WorkingStatus()
 	pk := host.PublicKey()
 	pt := host.PriceTable()
+	rcm := host.ReadCacheMetrics()
 	hg := HostGET{
 		ConnectabilityStatus: cs,
 		ExternalSettings:     es,
@@ -178,6 +333,7 @@ func hostHandlerGET(host modules.Host, w http.ResponseWriter, deps modules.Depen
 		NetworkMetrics:       nm,
 		PriceTable:           pt,
 		PublicKey:            pk,
+		ReadCacheMetrics:     rcm,
 		WorkingStatus:        ws,
 	}
 
@@ -188,21 +344,197 @@ func hostHandlerGET(host modules.Host, w http.ResponseWriter, deps modules.Depen
 	WriteJSON(w, hg)
 }
 
+// hostAccountTransactionsHandlerGET handles GET requests to the
+// /host/accounttransactions API endpoint, returning the transactions of an
+// ephemeral account recorded in the host's audit log.
+func hostAccountTransactionsHandlerGET(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var id modules.AccountID
+	if err := id.LoadString(req.FormValue("account")); err != nil {
+		WriteError(w, Error{"unable to parse account: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	txns, err := host.AccountTransactions(id)
+	if err != nil {
+		WriteError(w, Error{"failed to get account transactions: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	// initialize slice to avoid "null" in response.
+	if txns == nil {
+		txns = make([]modules.HostAccountTransaction, 0)
+	}
+	WriteJSON(w, HostAccountTransactionsGET{
+		Transactions: txns,
+	})
+}
+
+// hostAccountTransactionsExportHandlerGET handles GET requests to the
+// /host/accounttransactions/export API endpoint, returning the ephemeral
+// account transactions recorded within a period as CSV.
+func hostAccountTransactionsExportHandlerGET(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Parse the period. It defaults to everything recorded until now.
+	start := time.Unix(0, 0).UTC()
+	end := time.Now().UTC()
+	if startStr := req.FormValue("start"); startStr != "" {
+		startUnix, err := strconv.ParseInt(startStr, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse start: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		start = time.Unix(startUnix, 0).UTC()
+	}
+	if endStr := req.FormValue("end"); endStr != "" {
+		endUnix, err := strconv.ParseInt(endStr, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse end: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		end = time.Unix(endUnix, 0).UTC()
+	}
+
+	// Write the export to a buffer first to be able to return an error.
+	var buf bytes.Buffer
+	if err := host.ExportAccountTransactions(&buf, start, end); err != nil {
+		WriteError(w, Error{"failed to export account transactions: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="accounttransactions.csv"`)
+	_, _ = w.Write(buf.Bytes())
+}
+
 // hostsBandwidthHandlerGET handles GET requests to the /host/bandwidth API endpoint,
 // returning bandwidth usage data from the host module
-func hostBandwidthHandlerGET(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+func hostBandwidthHandlerGET(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Parse the period. It defaults to the current month.
+	now := time.Now().UTC()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	end := now
+	if startStr := req.FormValue("start"); startStr != "" {
+		startUnix, err := strconv.ParseInt(startStr, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse start: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		start = time.Unix(startUnix, 0).UTC()
+	}
+	if endStr := req.FormValue("end"); endStr != "" {
+		endUnix, err := strconv.ParseInt(endStr, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse end: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		end = time.Unix(endUnix, 0).UTC()
+	}
+
 	sent, receive, startTime, err := host.BandwidthCounters()
 	if err != nil {
 		WriteError(w, Error{"failed to get hosts's bandwidth usage: " + err.Error()}, http.StatusBadRequest)
 		return
 	}
-	WriteJSON(w, GatewayBandwidthGET{
+	ledger, err := host.BandwidthMetrics(start, end)
+	if err != nil {
+		WriteError(w, Error{"failed to get hosts's bandwidth ledger: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, HostBandwidthGET{
 		Download:  receive,
 		Upload:    sent,
 		StartTime: startTime,
+		Ledger:    ledger,
 	})
 }
 
+// hostProofFeesHandlerGET handles GET requests to the /host/prooffees API
+// endpoint, returning the fees the host spent on storage proof and final
+// revision transactions per period.
+func hostProofFeesHandlerGET(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Parse the range. It defaults to the whole blockchain split into months.
+	// The host caps the end at its current height.
+	start := types.BlockHeight(0)
+	end := types.BlockHeight(math.MaxUint64)
+	period := types.BlocksPerMonth
+	if startStr := req.FormValue("startheight"); startStr != "" {
+		if _, err := fmt.Sscan(startStr, &start); err != nil {
+			WriteError(w, Error{"unable to parse startheight: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if endStr := req.FormValue("endheight"); endStr != "" {
+		if _, err := fmt.Sscan(endStr, &end); err != nil {
+			WriteError(w, Error{"unable to parse endheight: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if periodStr := req.FormValue("period"); periodStr != "" {
+		if _, err := fmt.Sscan(periodStr, &period); err != nil {
+			WriteError(w, Error{"unable to parse period: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	report, err := host.ProofFees(start, end, period)
+	if err != nil {
+		WriteError(w, Error{"failed to get proof fees: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, report)
+}
+
+// hostObligationsHandlerGET handles GET requests to the /host/obligations API
+// endpoint, returning a summary of the host's storage obligations and their
+// upcoming proof deadlines.
+func hostObligationsHandlerGET(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Parse the deadline window. It defaults to a day.
+	window := types.BlocksPerDay
+	if windowStr := req.FormValue("window"); windowStr != "" {
+		if _, err := fmt.Sscan(windowStr, &window); err != nil {
+			WriteError(w, Error{"unable to parse window: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	summary, err := host.ObligationsSummary(window)
+	if err != nil {
+		WriteError(w, Error{"failed to get obligations summary: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, summary)
+}
+
+// hostMDMStatsHandlerGET handles GET requests to the /host/mdmstats API
+// endpoint, returning the execution statistics of the instructions the host's
+// MDM executed.
+func hostMDMStatsHandlerGET(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, host.MDMMetrics())
+}
+
+// hostPricingHandlerGET handles GET requests to the /host/pricing API
+// endpoint, returning the host's pricing policy and the prices derived from
+// it.
+func hostPricingHandlerGET(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, host.PricingStatus())
+}
+
+// hostPricingHandlerPOST handles POST requests to the /host/pricing API
+// endpoint, setting the host's pricing policy.
+func hostPricingHandlerPOST(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var policy modules.HostPricingPolicy
+	if err := json.NewDecoder(req.Body).Decode(&policy); err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := host.SetPricingPolicy(policy); err != nil {
+		WriteError(w, Error{"failed to set pricing policy: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// hostRPCStatsHandlerGET handles GET requests to the /host/rpcstats API
+// endpoint, returning the latency, bandwidth and error statistics of the RPCs
+// the host handled.
+func hostRPCStatsHandlerGET(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, host.RPCTrace())
+}
+
 // parseHostSettings a request's query strings and returns a
 // modules.HostInternalSettings configured with the request's query string
 // parameters.
@@ -366,6 +698,109 @@ func parseHostSettings(host modules.Host, req *http.Request) (modules.HostIntern
 	if req.FormValue("customregistrypath") != "" {
 		settings.CustomRegistryPath = req.FormValue("customregistrypath")
 	}
+	// The additional addresses are a comma-separated list. Unlike the other
+	// settings, an empty value is valid and removes all additional addresses.
+	if _, exists := req.Form["additionalnetaddresses"]; exists {
+		settings.AdditionalNetAddresses = nil
+		for _, addr := range strings.Split(req.FormValue("additionalnetaddresses"), ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				settings.AdditionalNetAddresses = append(settings.AdditionalNetAddresses, modules.NetAddress(addr))
+			}
+		}
+	}
+
+	if req.FormValue("batchstorageproofs") != "" {
+		var x bool
+		_, err := fmt.Sscan(req.FormValue("batchstorageproofs"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.BatchStorageProofs = x
+	}
+	if req.FormValue("collateralbudgetalertthreshold") != "" {
+		var x types.Currency
+		_, err := fmt.Sscan(req.FormValue("collateralbudgetalertthreshold"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.CollateralBudgetAlertThreshold = x
+	}
+	if req.FormValue("walletbalancealertthreshold") != "" {
+		var x types.Currency
+		_, err := fmt.Sscan(req.FormValue("walletbalancealertthreshold"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.WalletBalanceAlertThreshold = x
+	}
+	if req.FormValue("maxprooffee") != "" {
+		var x types.Currency
+		_, err := fmt.Sscan(req.FormValue("maxprooffee"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaxProofFee = x
+	}
+	if req.FormValue("prooffeemultiplier") != "" {
+		var x float64
+		_, err := fmt.Sscan(req.FormValue("prooffeemultiplier"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.ProofFeeMultiplier = x
+	}
+	if req.FormValue("writebatchmaxlatency") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("writebatchmaxlatency"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.WriteBatchMaxLatency = time.Duration(x) * time.Millisecond
+	}
+	if req.FormValue("pricetablegraceperiod") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("pricetablegraceperiod"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.PriceTableGracePeriod = time.Duration(x) * time.Second
+	}
+	if req.FormValue("readcachesize") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("readcachesize"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.ReadCacheSize = x
+	}
+	if req.FormValue("maxprograminstructions") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("maxprograminstructions"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaxProgramInstructions = x
+	}
+	if req.FormValue("maxprogramdatalength") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("maxprogramdatalength"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaxProgramDataLength = x
+	}
+	if req.FormValue("maxprogramduration") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("maxprogramduration"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaxProgramDuration = time.Duration(x) * time.Second
+	}
+	// An empty monitoring address is valid and disables the endpoint.
+	if _, exists := req.Form["monitoringaddress"]; exists {
+		settings.MonitoringAddress = strings.TrimSpace(req.FormValue("monitoringaddress"))
+	}
 
 	// Validate the RPC, Sector Access, and Download Prices
 	minBaseRPCPrice := settings.MinBaseRPCPrice
@@ -450,7 +885,18 @@ func hostHandlerPOST(host modules.Host, w http.ResponseWriter, req *http.Request
 		return
 	}
 
-	err = host.SetInternalSettings(settings)
+	// If the hash of the previewed settings is provided, the settings are
+	// only applied if they didn't change since the preview.
+	if hashStr := req.FormValue("settingshash"); hashStr != "" {
+		var settingsHash crypto.Hash
+		if err := settingsHash.LoadString(hashStr); err != nil {
+			WriteError(w, Error{"error parsing settingshash: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		err = host.ApplyInternalSettings(settings, settingsHash)
+	} else {
+		err = host.SetInternalSettings(settings)
+	}
 	if err != nil {
 		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
 		return
@@ -458,9 +904,46 @@ func hostHandlerPOST(host modules.Host, w http.ResponseWriter, req *http.Request
 	WriteSuccess(w)
 }
 
+// hostSettingsPreviewHandlerPOST handles POST requests to the
+// /host/settings/preview API endpoint, validating the proposed settings
+// against the host's obligations without applying them.
+func hostSettingsPreviewHandlerPOST(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	settings, err := parseHostSettings(host, req)
+	if err != nil {
+		WriteError(w, Error{"error parsing host settings: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	preview, err := host.PreviewInternalSettings(settings)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, preview)
+}
+
 // hostAnnounceHandler handles the API call to get the host to announce itself
 // to the network.
 func hostAnnounceHandler(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Only build the announcement if requested.
+	var dryRun bool
+	if dryRunStr := req.FormValue("dryrun"); dryRunStr != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(dryRunStr)
+		if err != nil {
+			WriteError(w, Error{"unable to parse dryrun: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if dryRun {
+		preview, err := host.PreviewAnnouncement(modules.NetAddress(req.FormValue("netaddress")))
+		if err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+		WriteJSON(w, preview)
+		return
+	}
+
 	var err error
 	if addr := req.FormValue("netaddress"); addr != "" {
 		err = host.AnnounceAddress(modules.NetAddress(addr))
@@ -474,6 +957,32 @@ func hostAnnounceHandler(host modules.Host, w http.ResponseWriter, req *http.Req
 	WriteSuccess(w)
 }
 
+// hostRescanHandlerGET handles GET requests to the /host/rescan API endpoint,
+// returning the progress of the most recent rescan.
+func hostRescanHandlerGET(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, host.RescanStatus())
+}
+
+// hostRescanHandlerPOST handles POST requests to the /host/rescan API
+// endpoint, starting a rescan of the host's storage obligations and
+// announcements within a range of blocks.
+func hostRescanHandlerPOST(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var start, end types.BlockHeight
+	if _, err := fmt.Sscan(req.FormValue("startheight"), &start); err != nil {
+		WriteError(w, Error{"unable to parse startheight: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if _, err := fmt.Sscan(req.FormValue("endheight"), &end); err != nil {
+		WriteError(w, Error{"unable to parse endheight: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := host.Rescan(start, end); err != nil {
+		WriteError(w, Error{"failed to start rescan: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // storageHandler returns a bunch of information about storage management on
 // the host.
 func storageHandler(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
@@ -482,6 +991,11 @@ func storageHandler(host modules.Host, w http.ResponseWriter, _ *http.Request, _
 	})
 }
 
+// storageHealthHandler returns the results of the host's sector scrubber.
+func storageHealthHandler(host modules.Host, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, host.StorageHealth())
+}
+
 // storageFoldersAddHandler adds a storage folder to the storage manager.
 func storageFoldersAddHandler(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	folderPath := req.FormValue("path")
@@ -528,6 +1042,62 @@ func storageFoldersResizeHandler(host modules.Host, w http.ResponseWriter, req *
 	WriteSuccess(w)
 }
 
+// storageFoldersDrainHandler starts, updates, pauses or resumes the drain of a
+// storage folder.
+func storageFoldersDrainHandler(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	folderPath := req.FormValue("path")
+	if folderPath == "" {
+		WriteError(w, Error{"path parameter is required"}, http.StatusBadRequest)
+		return
+	}
+
+	storageFolders := host.StorageFolders()
+	folderIndex, err := folderIndex(folderPath, storageFolders)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var sf modules.StorageFolderMetadata
+	for _, folder := range storageFolders {
+		if folder.Index == uint16(folderIndex) {
+			sf = folder
+		}
+	}
+
+	// Start the drain or update its rate. If the folder is already being
+	// drained and no rate was provided, the drain is left untouched.
+	rate := sf.DrainRate
+	if r := req.FormValue("rate"); r != "" {
+		_, err = fmt.Sscan(r, &rate)
+		if err != nil {
+			WriteError(w, Error{"unable to parse rate: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if !sf.Draining || req.FormValue("rate") != "" {
+		err = host.DrainStorageFolder(uint16(folderIndex), rate)
+		if err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Pause or resume the drain.
+	if p := req.FormValue("paused"); p != "" {
+		paused, err := strconv.ParseBool(p)
+		if err != nil {
+			WriteError(w, Error{"unable to parse paused: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		err = host.PauseStorageFolderDrain(uint16(folderIndex), paused)
+		if err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	WriteSuccess(w)
+}
+
 // storageFoldersRemoveHandler removes a storage folder from the storage
 // manager.
 func storageFoldersRemoveHandler(host modules.Host, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
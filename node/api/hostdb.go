@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 
@@ -49,6 +51,28 @@ type (
 		Hosts      []string `json:"hosts"`
 	}
 
+	// HostdbHostHistoryGET contains the detailed scan history of a host.
+	HostdbHostHistoryGET struct {
+		Records []modules.HostDBScanRecord `json:"records"`
+	}
+
+	// HostdbPriceIndexGET contains the historic price snapshots of the host
+	// network.
+	HostdbPriceIndexGET struct {
+		Snapshots []modules.HostDBPriceSnapshot `json:"snapshots"`
+	}
+
+	// HostdbSimulateGET contains the outcome of replaying the recorded
+	// settings of the hosts against an allowance.
+	HostdbSimulateGET struct {
+		modules.AllowanceSimulation
+	}
+
+	// HostdbExportGET contains a dump of the state of the hostdb.
+	HostdbExportGET struct {
+		modules.HostDBDump
+	}
+
 	// HostdbFilterModePOST contains the information needed to set the the
 	// FilterMode of the hostDB
 	HostdbFilterModePOST struct {
@@ -164,6 +188,67 @@ func (api *API) hostdbHostsHandler(w http.ResponseWriter, _ *http.Request, ps ht
 	})
 }
 
+// hostdbHostHistoryHandlerGET handles the API call asking for the detailed
+// scan history of a specific host.
+func (api *API) hostdbHostHistoryHandlerGET(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	var pk types.SiaPublicKey
+	if err := pk.LoadString(ps.ByName("pubkey")); err != nil {
+		WriteError(w, Error{"unable to parse pubkey: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	records, err := api.renter.HostDBScanHistory(pk)
+	if err != nil {
+		WriteError(w, Error{"unable to get scan history: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	// initialize slice to avoid "null" in response.
+	if records == nil {
+		records = make([]modules.HostDBScanRecord, 0)
+	}
+	WriteJSON(w, HostdbHostHistoryGET{
+		Records: records,
+	})
+}
+
+// hostdbExportHandlerGET handles the API call to export the state of the
+// hostdb.
+func (api *API) hostdbExportHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	dump, err := api.renter.HostDBExport()
+	if err != nil {
+		WriteError(w, Error{"unable to export hostdb: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, HostdbExportGET{
+		HostDBDump: dump,
+	})
+}
+
+// hostdbImportHandlerPOST handles the API call to restore the hostdb from a
+// dump or to merge the dump into it.
+func (api *API) hostdbImportHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// The body contains the dump so the merge flag is read from the query
+	// string.
+	var merge bool
+	if mergeStr := req.URL.Query().Get("merge"); mergeStr != "" {
+		var err error
+		merge, err = strconv.ParseBool(mergeStr)
+		if err != nil {
+			WriteError(w, Error{"unable to parse merge: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	var dump modules.HostDBDump
+	if err := json.NewDecoder(req.Body).Decode(&dump); err != nil {
+		WriteError(w, Error{"unable to decode hostdb dump: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := api.renter.HostDBImport(dump, merge); err != nil {
+		WriteError(w, Error{"unable to import hostdb: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // hostdbFilterModeHandlerGET handles the API call to get the hostdb's filter
 // mode
 func (api *API) hostdbFilterModeHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
@@ -208,3 +293,110 @@ func (api *API) hostdbFilterModeHandlerPOST(w http.ResponseWriter, req *http.Req
 	}
 	WriteSuccess(w)
 }
+
+// hostdbPriceIndexHandlerGET handles the API call to get the historic price
+// snapshots of the host network.
+func (api *API) hostdbPriceIndexHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var minHeight types.BlockHeight
+	if minHeightStr := req.FormValue("minheight"); minHeightStr != "" {
+		height, err := strconv.ParseUint(minHeightStr, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse minheight: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		minHeight = types.BlockHeight(height)
+	}
+	index, err := api.renter.HostDBPriceIndex()
+	if err != nil {
+		WriteError(w, Error{"unable to get price index: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	// initialize slice to avoid "null" in response.
+	snapshots := make([]modules.HostDBPriceSnapshot, 0, len(index))
+	for _, snapshot := range index {
+		if snapshot.BlockHeight >= minHeight {
+			snapshots = append(snapshots, snapshot)
+		}
+	}
+	WriteJSON(w, HostdbPriceIndexGET{
+		Snapshots: snapshots,
+	})
+}
+
+// hostdbSimulateHandlerGET handles the API call to replay the recorded settings
+// of the hosts against a hypothetical allowance. Fields of the allowance which
+// aren't provided default to the renter's current allowance or the default
+// allowance if none is set.
+func (api *API) hostdbSimulateHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	weeks := uint64(4)
+	if str := req.FormValue("weeks"); str != "" {
+		if _, err := fmt.Sscan(str, &weeks); err != nil || weeks == 0 {
+			WriteError(w, Error{"unable to parse weeks"}, http.StatusBadRequest)
+			return
+		}
+	}
+	settings, err := api.renter.Settings()
+	if err != nil {
+		WriteError(w, Error{"unable to get renter settings: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	allowance := settings.Allowance
+	if !allowance.Active() {
+		allowance = modules.DefaultAllowance
+	}
+
+	// Parse the fields of the allowance to simulate.
+	for _, field := range []struct {
+		name string
+		dst  *types.Currency
+	}{
+		{"funds", &allowance.Funds},
+		{"maxrpcprice", &allowance.MaxRPCPrice},
+		{"maxcontractprice", &allowance.MaxContractPrice},
+		{"maxdownloadbandwidthprice", &allowance.MaxDownloadBandwidthPrice},
+		{"maxsectoraccessprice", &allowance.MaxSectorAccessPrice},
+		{"maxstorageprice", &allowance.MaxStoragePrice},
+		{"maxuploadbandwidthprice", &allowance.MaxUploadBandwidthPrice},
+	} {
+		if str := req.FormValue(field.name); str != "" {
+			amount, ok := scanAmount(str)
+			if !ok {
+				WriteError(w, Error{"unable to parse " + field.name}, http.StatusBadRequest)
+				return
+			}
+			*field.dst = amount
+		}
+	}
+	for _, field := range []struct {
+		name string
+		dst  interface{}
+	}{
+		{"hosts", &allowance.Hosts},
+		{"period", &allowance.Period},
+		{"renewwindow", &allowance.RenewWindow},
+		{"expectedstorage", &allowance.ExpectedStorage},
+		{"expectedupload", &allowance.ExpectedUpload},
+		{"expecteddownload", &allowance.ExpectedDownload},
+		{"expectedredundancy", &allowance.ExpectedRedundancy},
+	} {
+		if str := req.FormValue(field.name); str != "" {
+			if _, err := fmt.Sscan(str, field.dst); err != nil {
+				WriteError(w, Error{fmt.Sprintf("unable to parse %v: %v", field.name, err)}, http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	sim, err := api.renter.HostDBSimulateAllowance(allowance, time.Duration(weeks)*7*24*time.Hour)
+	if err != nil {
+		WriteError(w, Error{"unable to simulate allowance: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	// initialize slice to avoid "null" in response.
+	if sim.Samples == nil {
+		sim.Samples = make([]modules.AllowanceSimulationSample, 0)
+	}
+	WriteJSON(w, HostdbSimulateGET{
+		AllowanceSimulation: sim,
+	})
+}
@@ -0,0 +1,340 @@
+package api
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+)
+
+const (
+	// defaultQuiesceDuration is the duration writers are quiesced for if no
+	// duration is specified.
+	defaultQuiesceDuration = time.Minute
+
+	// maxQuiesceDuration is the maximum duration writers can be quiesced for.
+	maxQuiesceDuration = time.Hour
+)
+
+var (
+	// errAlreadyQuiesced is returned when trying to quiesce the writers while
+	// they are already quiesced.
+	errAlreadyQuiesced = errors.New("writers are already quiesced")
+
+	// errNotQuiesced is returned when trying to resume the writers without
+	// quiescing them first.
+	errNotQuiesced = errors.New("writers aren't quiesced")
+
+	// quiesceModuleTimeout is the amount of time a module has to quiesce its
+	// writers. If it doesn't manage to do so in time, all modules are resumed
+	// to avoid blocking a writer of a module which is required by a pending
+	// write of another module.
+	quiesceModuleTimeout = build.Select(build.Var{
+		Standard: 30 * time.Second,
+		Dev:      10 * time.Second,
+		Testing:  5 * time.Second,
+	}).(time.Duration)
+)
+
+type (
+	// DaemonMaintenanceQuiescePOST contains information about the window in
+	// which the writers of the daemon are quiesced.
+	DaemonMaintenanceQuiescePOST struct {
+		// Start is the time at which all writers were quiesced.
+		Start time.Time `json:"start"`
+		// Deadline is the time at which the writers are resumed if they
+		// weren't resumed before.
+		Deadline time.Time `json:"deadline"`
+		// Modules are the modules which were quiesced.
+		Modules []string `json:"modules"`
+		// UnquiescedModules are the loaded modules which keep writing to disk.
+		UnquiescedModules []string `json:"unquiescedmodules"`
+		// Files are the files within the data directories of the daemon at
+		// the start of the window.
+		Files []DaemonMaintenanceFile `json:"files"`
+	}
+
+	// DaemonMaintenanceFile is a single file which is part of a backup of the
+	// daemon's data.
+	DaemonMaintenanceFile struct {
+		Path    string    `json:"path"`
+		Size    int64     `json:"size"`
+		ModTime time.Time `json:"modtime"`
+	}
+
+	// DaemonMaintenanceResumePOST contains the window in which the writers of
+	// the daemon were quiesced.
+	DaemonMaintenanceResumePOST struct {
+		// Start is the time at which all writers were quiesced.
+		Start time.Time `json:"start"`
+		// End is the time at which the writers were resumed.
+		End time.Time `json:"end"`
+		// Expired indicates that the writers were resumed because the
+		// deadline was reached before they were resumed using the API.
+		Expired bool `json:"expired"`
+	}
+
+	// quiesceWindow is a period during which the writers of the loaded
+	// modules are quiesced.
+	quiesceWindow struct {
+		start   time.Time
+		end     time.Time
+		expired bool
+
+		// resume resumes the writers of all quiesced modules. It is set to nil
+		// once the writers were resumed.
+		resume func()
+		timer  *time.Timer
+	}
+
+	// namedModule is a loaded module together with its name.
+	namedModule struct {
+		name   string
+		module interface{}
+	}
+)
+
+// ResumeWriters resumes the writers of the modules if they are currently
+// quiesced. It needs to be called before closing the modules since closing a
+// quiesced module might block forever.
+func (api *API) ResumeWriters() {
+	api.quiesceMu.Lock()
+	defer api.quiesceMu.Unlock()
+	if api.quiesce != nil && api.quiesce.resume != nil {
+		api.quiesce.timer.Stop()
+		api.quiesce.release(false)
+	}
+	api.quiesce = nil
+}
+
+// SetDataDirs sets the directories which contain the data of the daemon. The
+// files within the directories are reported when quiescing the writers.
+func (api *API) SetDataDirs(dirs ...string) {
+	api.quiesceMu.Lock()
+	defer api.quiesceMu.Unlock()
+	api.dataDirs = append([]string(nil), dirs...)
+}
+
+// release resumes the writers of the window.
+func (qw *quiesceWindow) release(expired bool) {
+	qw.resume()
+	qw.resume = nil
+	qw.end = time.Now()
+	qw.expired = expired
+}
+
+// loadedModules returns the loaded modules in the order they need to be
+// quiesced in. Modules which are subscribed to other modules come after the
+// modules they are subscribed to, which means that their pending writes caused
+// by an update are finished by the time the update source is quiesced.
+func (api *API) loadedModules() []namedModule {
+	candidates := []namedModule{
+		{"consensus", api.cs},
+		{"transactionpool", api.tpool},
+		{"wallet", api.wallet},
+		{"host", api.host},
+		{"explorer", api.explorer},
+		{"renter", api.renter},
+		{"gateway", api.gateway},
+		{"miner", api.miner},
+		{"accounting", api.accounting},
+	}
+	var loaded []namedModule
+	for _, m := range candidates {
+		if m.module != nil {
+			loaded = append(loaded, m)
+		}
+	}
+	return loaded
+}
+
+// managedQuiesce quiesces the writers of all modules which support it for at
+// most the provided duration.
+func (api *API) managedQuiesce(duration time.Duration) (DaemonMaintenanceQuiescePOST, error) {
+	api.quiesceMu.Lock()
+	defer api.quiesceMu.Unlock()
+	if api.quiesce != nil && api.quiesce.resume != nil {
+		return DaemonMaintenanceQuiescePOST{}, errAlreadyQuiesced
+	}
+
+	// Quiesce the modules one at a time.
+	var resumeFuncs []func()
+	resume := func() {
+		for i := len(resumeFuncs) - 1; i >= 0; i-- {
+			resumeFuncs[i]()
+		}
+	}
+	var quiesced, unquiesced []string
+	for _, m := range api.loadedModules() {
+		q, ok := m.module.(modules.Quiescer)
+		if !ok {
+			unquiesced = append(unquiesced, m.name)
+			continue
+		}
+		r, err := quiesceWithTimeout(q, quiesceModuleTimeout)
+		if err != nil {
+			resume()
+			return DaemonMaintenanceQuiescePOST{}, errors.AddContext(err, "failed to quiesce "+m.name)
+		}
+		resumeFuncs = append(resumeFuncs, r)
+		quiesced = append(quiesced, m.name)
+	}
+
+	// Collect the files which are part of the backup.
+	files, err := dataDirFiles(api.dataDirs)
+	if err != nil {
+		resume()
+		return DaemonMaintenanceQuiescePOST{}, errors.AddContext(err, "failed to list files")
+	}
+
+	// Automatically resume the writers once the deadline is reached.
+	qw := &quiesceWindow{
+		start:  time.Now(),
+		resume: resume,
+	}
+	qw.timer = time.AfterFunc(duration, func() {
+		api.quiesceMu.Lock()
+		defer api.quiesceMu.Unlock()
+		if api.quiesce == qw && qw.resume != nil {
+			qw.release(true)
+		}
+	})
+	api.quiesce = qw
+	return DaemonMaintenanceQuiescePOST{
+		Start:             qw.start,
+		Deadline:          qw.start.Add(duration),
+		Modules:           quiesced,
+		UnquiescedModules: unquiesced,
+		Files:             files,
+	}, nil
+}
+
+// managedResume resumes the writers and returns the window in which they were
+// quiesced.
+func (api *API) managedResume() (DaemonMaintenanceResumePOST, error) {
+	api.quiesceMu.Lock()
+	defer api.quiesceMu.Unlock()
+	qw := api.quiesce
+	if qw == nil {
+		return DaemonMaintenanceResumePOST{}, errNotQuiesced
+	}
+	if qw.resume != nil {
+		qw.timer.Stop()
+		qw.release(false)
+	}
+	api.quiesce = nil
+	return DaemonMaintenanceResumePOST{
+		Start:   qw.start,
+		End:     qw.end,
+		Expired: qw.expired,
+	}, nil
+}
+
+// quiesceWithTimeout quiesces the provided module. If the module doesn't
+// manage to do so within the timeout, an error is returned and the module is
+// resumed as soon as it finishes quiescing.
+func quiesceWithTimeout(q modules.Quiescer, timeout time.Duration) (func(), error) {
+	type result struct {
+		resume func()
+		err    error
+	}
+	c := make(chan result, 1)
+	go func() {
+		resume, err := q.Quiesce()
+		c <- result{resume, err}
+	}()
+	select {
+	case r := <-c:
+		return r.resume, r.err
+	case <-time.After(timeout):
+	}
+	go func() {
+		if r := <-c; r.err == nil {
+			r.resume()
+		}
+	}()
+	return nil, errors.New("timed out")
+}
+
+// dataDirFiles returns the regular files within the provided directories
+// sorted by their path. Files which are contained in multiple directories are
+// only returned once.
+func dataDirFiles(dirs []string) ([]DaemonMaintenanceFile, error) {
+	seen := make(map[string]struct{})
+	files := []DaemonMaintenanceFile{}
+	for _, dir := range dirs {
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if os.IsNotExist(err) {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			if _, exists := seen[path]; exists {
+				return nil
+			}
+			seen[path] = struct{}{}
+			files = append(files, DaemonMaintenanceFile{
+				Path:    path,
+				Size:    info.Size(),
+				ModTime: info.ModTime(),
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+	return files, nil
+}
+
+// daemonMaintenanceQuiesceHandlerPOST handles the API call to quiesce the
+// writers of the daemon.
+func (api *API) daemonMaintenanceQuiesceHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	duration := defaultQuiesceDuration
+	if durationStr := req.FormValue("duration"); durationStr != "" {
+		durationInt, err := strconv.ParseUint(durationStr, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"failed to parse duration: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		duration = time.Second * time.Duration(durationInt)
+	}
+	if duration == 0 || duration > maxQuiesceDuration {
+		WriteError(w, Error{"duration needs to be between 1 second and " + maxQuiesceDuration.String()}, http.StatusBadRequest)
+		return
+	}
+	resp, err := api.managedQuiesce(duration)
+	if errors.Contains(err, errAlreadyQuiesced) {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	} else if err != nil {
+		WriteError(w, Error{"failed to quiesce writers: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, resp)
+}
+
+// daemonMaintenanceResumeHandlerPOST handles the API call to resume the
+// writers of the daemon.
+func (api *API) daemonMaintenanceResumeHandlerPOST(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	resp, err := api.managedResume()
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, resp)
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+)
+
+// testQuiescer is a modules.Quiescer which takes a while to quiesce.
+type testQuiescer struct {
+	delay   time.Duration
+	resumed chan struct{}
+}
+
+// Quiesce implements the modules.Quiescer interface.
+func (tq *testQuiescer) Quiesce() (func(), error) {
+	time.Sleep(tq.delay)
+	return func() { close(tq.resumed) }, nil
+}
+
+// TestQuiesceWithTimeout tests that a module which takes too long to quiesce
+// is resumed once it finishes quiescing.
+func TestQuiesceWithTimeout(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// A fast module is quiesced.
+	tq := &testQuiescer{resumed: make(chan struct{})}
+	resume, err := quiesceWithTimeout(tq, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resume()
+	<-tq.resumed
+
+	// A slow module times out and is resumed right away.
+	tq = &testQuiescer{delay: time.Second, resumed: make(chan struct{})}
+	_, err = quiesceWithTimeout(tq, 100*time.Millisecond)
+	if err == nil {
+		t.Fatal("expected timeout")
+	}
+	select {
+	case <-tq.resumed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("module wasn't resumed")
+	}
+}
+
+// TestDataDirFiles is a unit test for dataDirFiles.
+func TestDataDirFiles(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	dir := build.TempDir("api", t.Name())
+	subDir := filepath.Join(dir, "sub")
+	if err := os.MkdirAll(subDir, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{filepath.Join(dir, "b"), filepath.Join(subDir, "a")} {
+		if err := ioutil.WriteFile(path, []byte("data"), modules.DefaultFilePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Overlapping and missing directories are allowed.
+	files, err := dataDirFiles([]string{dir, subDir, filepath.Join(dir, "missing")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatal("wrong number of files", len(files))
+	}
+	if files[0].Path != filepath.Join(dir, "b") || files[1].Path != filepath.Join(subDir, "a") {
+		t.Fatal("wrong files", files)
+	}
+	if files[0].Size != 4 {
+		t.Fatal("wrong size", files[0].Size)
+	}
+}
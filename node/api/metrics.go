@@ -0,0 +1,225 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/types"
+)
+
+// Metrics returns the registry which the API collects the metrics of its
+// modules in. It can be used to register collectors for additional metrics.
+func (api *API) Metrics() *modules.MetricsRegistry {
+	return api.staticMetrics
+}
+
+// registerMetricsCollectors registers the collectors of the modules' metrics.
+// The collectors skip modules which are not loaded at the time the metrics
+// are gathered.
+func (api *API) registerMetricsCollectors() {
+	api.staticMetrics.Register("consensus", api.collectConsensusMetrics)
+	api.staticMetrics.Register("gateway", api.collectGatewayMetrics)
+	api.staticMetrics.Register("host", api.collectHostMetrics)
+	api.staticMetrics.Register("renter", api.collectRenterMetrics)
+	api.staticMetrics.Register("tpool", api.collectTpoolMetrics)
+}
+
+// gauge is a helper to create a gauge sample.
+func gauge(name, help string, value float64, labels ...string) modules.Metric {
+	return metric(modules.MetricTypeGauge, name, help, value, labels...)
+}
+
+// counter is a helper to create a counter sample.
+func counter(name, help string, value float64, labels ...string) modules.Metric {
+	return metric(modules.MetricTypeCounter, name, help, value, labels...)
+}
+
+// metric is a helper to create a sample. The labels are provided as pairs of
+// names and values.
+func metric(t modules.MetricType, name, help string, value float64, labels ...string) modules.Metric {
+	m := modules.Metric{
+		Name:  name,
+		Help:  help,
+		Type:  t,
+		Value: value,
+	}
+	if len(labels) > 0 {
+		m.Labels = make(map[string]string, len(labels)/2)
+		for i := 0; i+1 < len(labels); i += 2 {
+			m.Labels[labels[i]] = labels[i+1]
+		}
+	}
+	return m
+}
+
+// boolToFloat returns 1 for true and 0 for false.
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// collectConsensusMetrics collects the metrics of the consensus set.
+func (api *API) collectConsensusMetrics() []modules.Metric {
+	if api.cs == nil {
+		return nil
+	}
+	return []modules.Metric{
+		gauge("sia_consensus_height", "The height of the current block.", float64(api.cs.Height())),
+		gauge("sia_consensus_synced", "Whether the consensus set is synced, 1 if it is and 0 otherwise.", boolToFloat(api.cs.Synced())),
+	}
+}
+
+// collectGatewayMetrics collects the metrics of the gateway.
+func (api *API) collectGatewayMetrics() []modules.Metric {
+	if api.gateway == nil {
+		return nil
+	}
+	var inbound, outbound float64
+	for _, p := range api.gateway.Peers() {
+		if p.Inbound {
+			inbound++
+		} else {
+			outbound++
+		}
+	}
+	const peersHelp = "The number of peers the gateway is connected to."
+	metrics := []modules.Metric{
+		gauge("sia_gateway_peers", peersHelp, inbound, "direction", "inbound"),
+		gauge("sia_gateway_peers", peersHelp, outbound, "direction", "outbound"),
+	}
+	if up, down, _, err := api.gateway.BandwidthCounters(); err == nil {
+		const bandwidthHelp = "The number of bytes the gateway transferred since it was started."
+		metrics = append(metrics,
+			counter("sia_gateway_bandwidth_bytes_total", bandwidthHelp, float64(up), "direction", "upload"),
+			counter("sia_gateway_bandwidth_bytes_total", bandwidthHelp, float64(down), "direction", "download"),
+		)
+	}
+	return metrics
+}
+
+// collectHostMetrics collects the metrics of the host.
+func (api *API) collectHostMetrics() []modules.Metric {
+	if api.host == nil {
+		return nil
+	}
+	nm := api.host.NetworkMetrics()
+	const rpcHelp = "The number of RPCs the host handled since it was started."
+	metrics := []modules.Metric{
+		counter("sia_host_rpc_calls_total", rpcHelp, float64(nm.DownloadCalls), "rpc", "download"),
+		counter("sia_host_rpc_calls_total", rpcHelp, float64(nm.ErrorCalls), "rpc", "error"),
+		counter("sia_host_rpc_calls_total", rpcHelp, float64(nm.FormContractCalls), "rpc", "formcontract"),
+		counter("sia_host_rpc_calls_total", rpcHelp, float64(nm.RenewCalls), "rpc", "renew"),
+		counter("sia_host_rpc_calls_total", rpcHelp, float64(nm.ReviseCalls), "rpc", "revise"),
+		counter("sia_host_rpc_calls_total", rpcHelp, float64(nm.SettingsCalls), "rpc", "settings"),
+		counter("sia_host_rpc_calls_total", rpcHelp, float64(nm.UnrecognizedCalls), "rpc", "unrecognized"),
+	}
+	if up, down, _, err := api.host.BandwidthCounters(); err == nil {
+		const bandwidthHelp = "The number of bytes the host transferred since it was started."
+		metrics = append(metrics,
+			counter("sia_host_bandwidth_bytes_total", bandwidthHelp, float64(up), "direction", "upload"),
+			counter("sia_host_bandwidth_bytes_total", bandwidthHelp, float64(down), "direction", "download"),
+		)
+	}
+	if summary, err := api.host.ObligationsSummary(types.BlocksPerDay); err == nil {
+		const obligationsHelp = "The number of the host's storage obligations by status."
+		const dataHelp = "The amount of data stored in the host's storage obligations by status."
+		for _, s := range []struct {
+			status  string
+			summary modules.HostObligationStatusSummary
+		}{
+			{"unresolved", summary.Unresolved},
+			{"rejected", summary.Rejected},
+			{"succeeded", summary.Succeeded},
+			{"failed", summary.Failed},
+		} {
+			metrics = append(metrics,
+				gauge("sia_host_obligations", obligationsHelp, float64(s.summary.Count), "status", s.status),
+				gauge("sia_host_obligations_data_bytes", dataHelp, float64(s.summary.DataSize), "status", s.status),
+			)
+		}
+		metrics = append(metrics, gauge("sia_host_obligations_at_risk", "The number of unresolved storage obligations whose storage proof is at risk.", float64(summary.AtRisk)))
+	}
+	return metrics
+}
+
+// collectRenterMetrics collects the metrics of the renter.
+func (api *API) collectRenterMetrics() []modules.Metric {
+	if api.renter == nil {
+		return nil
+	}
+	var metrics []modules.Metric
+	if wps, err := api.renter.WorkerPoolStatus(); err == nil {
+		const cooldownHelp = "The number of the renter's workers which are on cooldown."
+		metrics = append(metrics,
+			gauge("sia_renter_workers", "The number of the renter's workers.", float64(wps.NumWorkers)),
+			gauge("sia_renter_workers_cooldown", cooldownHelp, float64(wps.TotalDownloadCoolDown), "type", "download"),
+			gauge("sia_renter_workers_cooldown", cooldownHelp, float64(wps.TotalMaintenanceCoolDown), "type", "maintenance"),
+			gauge("sia_renter_workers_cooldown", cooldownHelp, float64(wps.TotalUploadCoolDown), "type", "upload"),
+		)
+	}
+
+	rq := api.renter.RepairQueue()
+	var queued, repairing, remaining uint64
+	for _, f := range rq.Files {
+		queued += f.QueuedChunks
+		repairing += f.RepairingChunks
+		remaining += f.RemainingBytes
+	}
+	const chunksHelp = "The number of chunks in the renter's repair queue."
+	metrics = append(metrics,
+		gauge("sia_renter_repair_queue_files", "The number of files in the renter's repair queue.", float64(len(rq.Files))),
+		gauge("sia_renter_repair_queue_chunks", chunksHelp, float64(queued), "state", "queued"),
+		gauge("sia_renter_repair_queue_chunks", chunksHelp, float64(repairing), "state", "repairing"),
+		gauge("sia_renter_repair_queue_remaining_bytes", "The number of bytes the renter still needs to repair.", float64(remaining)),
+		gauge("sia_renter_repair_throughput_bytes_per_second", "The number of bytes per second the renter repaired recently.", float64(rq.Throughput)),
+	)
+
+	if ms, err := api.renter.MemoryStatus(); err == nil {
+		const availableHelp = "The memory available in the renter's memory managers."
+		const baseHelp = "The base memory of the renter's memory managers."
+		const requestedHelp = "The memory requested from the renter's memory managers."
+		for _, m := range []struct {
+			manager string
+			status  modules.MemoryManagerStatus
+		}{
+			{"registry", ms.Registry},
+			{"system", ms.System},
+			{"userdownload", ms.UserDownload},
+			{"userupload", ms.UserUpload},
+		} {
+			metrics = append(metrics,
+				gauge("sia_renter_memory_available_bytes", availableHelp, float64(m.status.Available), "manager", m.manager),
+				gauge("sia_renter_memory_base_bytes", baseHelp, float64(m.status.Base), "manager", m.manager),
+				gauge("sia_renter_memory_requested_bytes", requestedHelp, float64(m.status.Requested), "manager", m.manager),
+			)
+		}
+	}
+	return metrics
+}
+
+// collectTpoolMetrics collects the metrics of the transaction pool.
+func (api *API) collectTpoolMetrics() []modules.Metric {
+	if api.tpool == nil {
+		return nil
+	}
+	txns := api.tpool.Transactions()
+	var size int
+	for _, txn := range txns {
+		size += txn.MarshalSiaSize()
+	}
+	return []modules.Metric{
+		gauge("sia_tpool_transactions", "The number of transactions in the transaction pool.", float64(len(txns))),
+		gauge("sia_tpool_size_bytes", "The encoded size of the transactions in the transaction pool.", float64(size)),
+	}
+}
+
+// metricsHandlerGET handles the API call to get the metrics of the loaded
+// modules in the Prometheus text format.
+func (api *API) metricsHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = modules.WritePrometheus(w, api.staticMetrics.Gather())
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"go.sia.tech/siad/modules"
+)
+
+type (
+	// DaemonProfileGet contains the effective values of the daemon profile
+	// and the names of the available profiles.
+	DaemonProfileGet struct {
+		Profile  modules.ProfileSettings `json:"profile"`
+		Profiles []string                `json:"profiles"`
+	}
+
+	// DaemonProfilePost contains the name of the daemon profile to set.
+	DaemonProfilePost struct {
+		Profile string `json:"profile"`
+	}
+)
+
+// daemonProfileHandlerGET handles the API call to get the daemon profile.
+func (api *API) daemonProfileHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, DaemonProfileGet{
+		Profile:  modules.CurrentProfile(),
+		Profiles: modules.Profiles(),
+	})
+}
+
+// daemonProfileHandlerPOST handles the API call to set the daemon profile.
+func (api *API) daemonProfileHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params DaemonProfilePost
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := api.siadConfig.SetProfile(params.Profile); err != nil {
+		WriteError(w, Error{"failed to set profile: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
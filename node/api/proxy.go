@@ -0,0 +1,45 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"go.sia.tech/siad/modules"
+)
+
+type (
+	// DaemonProxyGet contains the settings of the outbound proxy. The
+	// password is never returned.
+	DaemonProxyGet struct {
+		modules.ProxySettings
+		Enabled bool `json:"enabled"`
+	}
+)
+
+// daemonProxyHandlerGET handles the API call to get the outbound proxy
+// settings.
+func (api *API) daemonProxyHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	settings := modules.GlobalProxy.Settings()
+	settings.Password = ""
+	WriteJSON(w, DaemonProxyGet{
+		ProxySettings: settings,
+		Enabled:       settings.Address != "",
+	})
+}
+
+// daemonProxyHandlerPOST handles the API call to set the outbound proxy.
+func (api *API) daemonProxyHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var settings modules.ProxySettings
+	err := json.NewDecoder(req.Body).Decode(&settings)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := api.siadConfig.SetProxy(settings); err != nil {
+		WriteError(w, Error{"failed to set proxy: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	// publicRateLimitWindow is the window within which a client of the public
+	// API may make the configured number of requests.
+	publicRateLimitWindow = time.Minute
+
+	// publicRateLimitMaxClients is the number of tracked clients after which
+	// the rate limiter prunes clients which haven't made a request within the
+	// last window.
+	publicRateLimitMaxClients = 10e3
+)
+
+type (
+	// publicRateLimiter limits the number of requests per client IP within a
+	// fixed window.
+	publicRateLimiter struct {
+		clients map[string]*publicRateLimitClient
+		limit   uint64
+		window  time.Duration
+		mu      sync.Mutex
+	}
+
+	// publicRateLimitClient tracks the requests of a single client within the
+	// current window.
+	publicRateLimitClient struct {
+		requests    uint64
+		windowStart time.Time
+	}
+)
+
+// newPublicRateLimiter creates a rate limiter which allows limit requests per
+// client within window. A limit of 0 disables rate limiting.
+func newPublicRateLimiter(limit uint64, window time.Duration) *publicRateLimiter {
+	return &publicRateLimiter{
+		clients: make(map[string]*publicRateLimitClient),
+		limit:   limit,
+		window:  window,
+	}
+}
+
+// allow returns true if the client with the given ip may make another request
+// at the given time and records the request.
+func (rl *publicRateLimiter) allow(ip string, now time.Time) bool {
+	if rl.limit == 0 {
+		return true
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	// Prune idle clients to bound the memory used by the limiter.
+	if len(rl.clients) >= publicRateLimitMaxClients {
+		for clientIP, c := range rl.clients {
+			if now.Sub(c.windowStart) >= rl.window {
+				delete(rl.clients, clientIP)
+			}
+		}
+	}
+
+	c, exists := rl.clients[ip]
+	if !exists || now.Sub(c.windowStart) >= rl.window {
+		c = &publicRateLimitClient{windowStart: now}
+		rl.clients[ip] = c
+	}
+	if c.requests >= rl.limit {
+		return false
+	}
+	c.requests++
+	return true
+}
+
+// middleware wraps h and rejects requests of clients which exceeded their
+// limit.
+func (rl *publicRateLimiter) middleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		ip, _, err := net.SplitHostPort(req.RemoteAddr)
+		if err != nil {
+			ip = req.RemoteAddr
+		}
+		if !rl.allow(ip, time.Now()) {
+			w.Header().Set("Retry-After", fmt.Sprint(int(rl.window.Seconds())))
+			WriteError(w, Error{"rate limit exceeded"}, http.StatusTooManyRequests)
+			return
+		}
+		h.ServeHTTP(w, req)
+	})
+}
+
+// buildPublicRoutes returns a router which only contains the unauthenticated,
+// read-only endpoints which are safe to expose publicly.
+func (api *API) buildPublicRoutes() *httprouter.Router {
+	router := httprouter.New()
+	router.NotFound = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		WriteError(w, Error{"endpoint is not available on the public API"}, http.StatusNotFound)
+	})
+	router.RedirectTrailingSlash = false
+
+	router.GET("/daemon/version", api.daemonVersionHandler)
+
+	// Consensus API Calls
+	if api.cs != nil {
+		router.GET("/consensus", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+			consensusHandler(api.cs, w, req, ps)
+		})
+		router.GET("/consensus/blocks", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+			consensusBlocksHandler(api.cs, w, req, ps)
+		})
+	}
+
+	// Explorer API Calls
+	if api.explorer != nil {
+		RegisterRoutesExplorer(router, api.explorer, api.cs)
+	}
+
+	// HostDB API Calls. Only the aggregated views of the host network are
+	// exposed, not the renter's filter mode.
+	if api.renter != nil {
+		router.GET("/hostdb", api.hostdbHandler)
+		router.GET("/hostdb/active", api.hostdbActiveHandler)
+		router.GET("/hostdb/all", api.hostdbAllHandler)
+		router.GET("/hostdb/priceindex", api.hostdbPriceIndexHandlerGET)
+	}
+	return router
+}
+
+// PublicHandler returns a http.Handler which serves the read-only public
+// subset of the API. It requires neither a password nor a user agent and
+// limits the number of requests per client IP to requestsPerMinute. A limit of
+// 0 disables rate limiting.
+func (api *API) PublicHandler(requestsPerMinute uint64) http.Handler {
+	rl := newPublicRateLimiter(requestsPerMinute, publicRateLimitWindow)
+	return rl.middleware(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		// Allow status pages to query the API from the browser.
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		api.routerMu.RLock()
+		router := api.publicRouter
+		api.routerMu.RUnlock()
+		router.ServeHTTP(w, req)
+	}))
+}
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestPublicRateLimiter tests the per-client limits of the publicRateLimiter.
+func TestPublicRateLimiter(t *testing.T) {
+	rl := newPublicRateLimiter(2, time.Minute)
+	now := time.Now()
+	if !rl.allow("a", now) || !rl.allow("a", now) {
+		t.Fatal("requests within the limit should be allowed")
+	}
+	if rl.allow("a", now) {
+		t.Fatal("request exceeding the limit should be rejected")
+	}
+	// Other clients have their own limit.
+	if !rl.allow("b", now) {
+		t.Fatal("request of another client should be allowed")
+	}
+	// The limit resets after the window.
+	if !rl.allow("a", now.Add(time.Minute)) {
+		t.Fatal("request in the next window should be allowed")
+	}
+
+	// A limit of 0 disables rate limiting.
+	rl = newPublicRateLimiter(0, time.Minute)
+	for i := 0; i < 10; i++ {
+		if !rl.allow("a", now) {
+			t.Fatal("request should be allowed without a limit")
+		}
+	}
+}
+
+// TestPublicHandler tests that the public API only serves the read-only
+// endpoints without authentication and enforces the rate limit.
+func TestPublicHandler(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createAuthenticatedServerTester(t.Name(), "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	limit := 5
+	ts := httptest.NewServer(st.server.api.PublicHandler(uint64(limit)))
+	defer ts.Close()
+
+	// get performs a GET request without a user agent or password and returns
+	// the status code.
+	get := func(path string) int {
+		req, err := http.NewRequest("GET", ts.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// Read-only endpoints are served.
+	if code := get("/consensus"); code != http.StatusOK {
+		t.Fatal("expected /consensus to be served but got", code)
+	}
+	if code := get("/hostdb/active"); code != http.StatusOK {
+		t.Fatal("expected /hostdb/active to be served but got", code)
+	}
+	// Other endpoints aren't.
+	if code := get("/wallet/seeds"); code != http.StatusNotFound {
+		t.Fatal("expected /wallet/seeds to not be found but got", code)
+	}
+	if code := get("/hostdb/filtermode"); code != http.StatusNotFound {
+		t.Fatal("expected /hostdb/filtermode to not be found but got", code)
+	}
+	resp, err := http.Post(ts.URL+"/consensus/validate/transactionset", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		t.Fatal("POST request shouldn't be served")
+	}
+
+	// The next request exceeds the limit.
+	if code := get("/consensus"); code != http.StatusTooManyRequests {
+		t.Fatal("expected request to be rate limited but got", code)
+	}
+}
@@ -1,8 +1,12 @@
 package api
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -128,7 +132,7 @@ type (
 		// incorrect capitalization. This field will be removed in the future, so
 		// clients should switch to the StorageSpending field (above) with the
 		// correct lowercase name.
-		StorageSpendingDeprecated types.Currency `json:"StorageSpending,siamismatch"`
+		StorageSpendingDeprecated types.Currency `json:"StorageSpending,siamismatch" deprecated:"2"`
 		// Total cost to the wallet of forming the file contract.
 		TotalCost types.Currency `json:"totalcost"`
 		// Amount of contract funds that have been spent on uploads.
@@ -164,6 +168,16 @@ type (
 		Files       []modules.FileInfo      `json:"files"`
 	}
 
+	// RenterDirDefaultsGET contains the defaults of a directory.
+	RenterDirDefaultsGET struct {
+		// Defaults are the defaults set on the directory itself.
+		Defaults modules.DirDefaults `json:"defaults"`
+		// EffectiveDefaults are the defaults which are applied to new files
+		// created in the directory. They include the defaults inherited
+		// from its parents.
+		EffectiveDefaults modules.DirDefaults `json:"effectivedefaults"`
+	}
+
 	// RenterDownloadQueue contains the renter's download queue.
 	RenterDownloadQueue struct {
 		Downloads []DownloadInfo `json:"downloads"`
@@ -174,6 +188,11 @@ type (
 		File modules.FileInfo `json:"file"`
 	}
 
+	// RenterFileVersions lists the previous versions of a file.
+	RenterFileVersions struct {
+		Versions []modules.FileVersion `json:"versions"`
+	}
+
 	// RenterFiles lists the files known to the renter.
 	RenterFiles struct {
 		Files []modules.FileInfo `json:"files"`
@@ -222,6 +241,12 @@ type (
 		UnsyncedHosts []types.SiaPublicKey   `json:"unsyncedhosts"`
 	}
 
+	// RenterWorkersBandwidthLimitsGET contains the bandwidth limits of the
+	// hosts which have one.
+	RenterWorkersBandwidthLimitsGET struct {
+		Limits []modules.RenterHostBandwidthLimits `json:"limits"`
+	}
+
 	// RenterUploadReadyGet lists the upload ready status of the renter
 	RenterUploadReadyGet struct {
 		// Ready indicates whether of not the renter is ready to successfully
@@ -238,14 +263,30 @@ type (
 		ParityPieces int `json:"paritypieces"`
 	}
 
+	// RenterAlertsGET contains the information that is returned after a GET
+	// request to /renter/alerts - the alerts of the renter, its contractor and
+	// its hostdb sorted by severity.
+	RenterAlertsGET struct {
+		Alerts         []modules.Alert `json:"alerts"`
+		CriticalAlerts []modules.Alert `json:"criticalalerts"`
+		ErrorAlerts    []modules.Alert `json:"erroralerts"`
+		WarningAlerts  []modules.Alert `json:"warningalerts"`
+	}
+
+	// RenterUploadStreamGET contains the offset at which an interrupted
+	// upload stream can be resumed.
+	RenterUploadStreamGET struct {
+		ResumeOffset uint64 `json:"resumeoffset"`
+	}
+
 	// DownloadInfo contains all client-facing information of a file.
 	DownloadInfo struct {
-		Destination     string          `json:"destination"`     // The destination of the download.
-		DestinationType string          `json:"destinationtype"` // Can be "file", "memory buffer", or "http stream".
-		Filesize        uint64          `json:"filesize"`        // DEPRECATED. Same as 'Length'.
-		Length          uint64          `json:"length"`          // The length requested for the download.
-		Offset          uint64          `json:"offset"`          // The offset within the siafile requested for the download.
-		SiaPath         modules.SiaPath `json:"siapath"`         // The siapath of the file used for the download.
+		Destination     string          `json:"destination"`             // The destination of the download.
+		DestinationType string          `json:"destinationtype"`         // Can be "file", "memory buffer", or "http stream".
+		Filesize        uint64          `json:"filesize" deprecated:"2"` // DEPRECATED. Same as 'Length'.
+		Length          uint64          `json:"length"`                  // The length requested for the download.
+		Offset          uint64          `json:"offset"`                  // The offset within the siafile requested for the download.
+		SiaPath         modules.SiaPath `json:"siapath"`                 // The siapath of the file used for the download.
 
 		Completed            bool      `json:"completed"`            // Whether or not the download has completed.
 		EndTime              time.Time `json:"endtime"`              // The time when the download fully completed.
@@ -781,6 +822,14 @@ func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ ht
 		settings.Allowance.MaxPeriodChurn = maxPeriodChurn
 		maxPeriodChurnSet = true
 	}
+	if mpcc := req.FormValue("maxperiodchurncontracts"); mpcc != "" {
+		var maxPeriodChurnContracts uint64
+		if _, err := fmt.Sscan(mpcc, &maxPeriodChurnContracts); err != nil {
+			WriteError(w, Error{"unable to parse new max churned contracts per period: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.MaxPeriodChurnContracts = maxPeriodChurnContracts
+	}
 	if str := req.FormValue("maxrpcprice"); str != "" {
 		price, ok := scanAmount(str)
 		if !ok {
@@ -829,6 +878,45 @@ func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ ht
 		}
 		settings.Allowance.MaxUploadBandwidthPrice = price
 	}
+	if _, ok := req.Form["minhostversion"]; ok {
+		version := req.FormValue("minhostversion")
+		if version != "" && !build.IsVersion(version) {
+			WriteError(w, Error{"unable to parse minhostversion"}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.MinHostVersion = version
+	}
+	if mha := req.FormValue("minhostage"); mha != "" {
+		var minHostAge types.BlockHeight
+		if _, err := fmt.Sscan(mha, &minHostAge); err != nil {
+			WriteError(w, Error{"unable to parse minhostage: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.MinHostAge = minHostAge
+	}
+	if _, ok := req.Form["excludedcountries"]; ok {
+		var countries []string
+		for _, country := range strings.Split(req.FormValue("excludedcountries"), ",") {
+			if country = strings.ToUpper(strings.TrimSpace(country)); country != "" {
+				countries = append(countries, country)
+			}
+		}
+		settings.Allowance.ExcludedCountries = countries
+	}
+	if _, ok := req.Form["excludedsubnets"]; ok {
+		var subnets []string
+		for _, subnet := range strings.Split(req.FormValue("excludedsubnets"), ",") {
+			if subnet = strings.TrimSpace(subnet); subnet == "" {
+				continue
+			}
+			if _, _, err := net.ParseCIDR(subnet); err != nil {
+				WriteError(w, Error{"unable to parse excludedsubnets: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+			subnets = append(subnets, subnet)
+		}
+		settings.Allowance.ExcludedSubnets = subnets
+	}
 
 	// Validate any allowance changes. Funds and Period are the only required
 	// fields.
@@ -955,6 +1043,26 @@ func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ ht
 		settings.IPViolationCheck = ipviolationcheck
 	}
 
+	// Scan the maxfileversions value.
+	if mfv := req.FormValue("maxfileversions"); mfv != "" {
+		maxFileVersions, err := strconv.ParseUint(mfv, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse maxfileversions: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.MaxFileVersions = maxFileVersions
+	}
+
+	// Scan the verifyuploads flag.
+	if vu := req.FormValue("verifyuploads"); vu != "" {
+		var verifyUploads bool
+		if _, err := fmt.Sscan(vu, &verifyUploads); err != nil {
+			WriteError(w, Error{"unable to parse verifyuploads: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.VerifyUploads = verifyUploads
+	}
+
 	// Set the settings in the renter.
 	err = api.renter.SetSettings(settings)
 	if err != nil {
@@ -1264,6 +1372,129 @@ func (api *API) renterContractorChurnStatus(w http.ResponseWriter, _ *http.Reque
 	WriteJSON(w, api.renter.ContractorChurnStatus())
 }
 
+// renterContractorMigrationPlanHandlerGET handles the API call to request the
+// contracts the renter's contractor queued for replacement.
+func (api *API) renterContractorMigrationPlanHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, api.renter.ContractorMigrationPlan())
+}
+
+// renterContractorMigrationPlanApproveHandlerPOST handles the API call to
+// approve churning deferred contracts of the migration plan regardless of the
+// churn budget.
+func (api *API) renterContractorMigrationPlanApproveHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	contracts := req.FormValue("contracts")
+	if contracts == "" {
+		WriteError(w, Error{"contracts must be specified"}, http.StatusBadRequest)
+		return
+	}
+	var ids []types.FileContractID
+	for _, str := range strings.Split(contracts, ",") {
+		var id types.FileContractID
+		if err := id.LoadString(strings.TrimSpace(str)); err != nil {
+			WriteError(w, Error{"unable to parse contract id: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		ids = append(ids, id)
+	}
+	if err := api.renter.ApproveContractMigrations(ids); err != nil {
+		WriteError(w, Error{"unable to approve migrations: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterSpendingForecastHandlerGET handles the API call to
+// /renter/spendingforecast.
+func (api *API) renterSpendingForecastHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	forecast, err := api.renter.SpendingForecast()
+	if err != nil {
+		WriteError(w, Error{"unable to get spending forecast: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, forecast)
+}
+
+// renterSpendingHandlerGET handles the API call to /renter/spending.
+func (api *API) renterSpendingHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	spending, err := api.renter.Spending()
+	if err != nil {
+		WriteError(w, Error{"unable to get spending: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, spending)
+}
+
+// renterSpendingExportHandlerGET handles the API call to
+// /renter/spending/export, returning the renter's spending per file or per
+// host as CSV.
+func (api *API) renterSpendingExportHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	by := req.FormValue("by")
+	if by == "" {
+		by = modules.RenterSpendingByFiles
+	}
+	if by != modules.RenterSpendingByFiles && by != modules.RenterSpendingByHosts {
+		WriteError(w, Error{fmt.Sprintf("by must be either %v or %v", modules.RenterSpendingByFiles, modules.RenterSpendingByHosts)}, http.StatusBadRequest)
+		return
+	}
+
+	// Write the export to a buffer first to be able to return an error.
+	var buf bytes.Buffer
+	if err := api.renter.ExportSpending(&buf, by); err != nil {
+		WriteError(w, Error{"failed to export spending: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="spending-%v.csv"`, by))
+	_, _ = w.Write(buf.Bytes())
+}
+
+// renterAlertsHandlerGET handles the API call to get the alerts of the renter.
+func (api *API) renterAlertsHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	crit, err, warn := api.renter.Alerts()
+	// initialize slices to avoid "null" in response.
+	rag := RenterAlertsGET{
+		CriticalAlerts: append([]modules.Alert{}, crit...),
+		ErrorAlerts:    append([]modules.Alert{}, err...),
+		WarningAlerts:  append([]modules.Alert{}, warn...),
+	}
+	rag.Alerts = append(append(append([]modules.Alert{}, crit...), err...), warn...)
+	WriteJSON(w, rag)
+}
+
+// renterAllowanceAlertsHandlerGET handles the API call to get the allowance
+// alert rules.
+func (api *API) renterAllowanceAlertsHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, api.renter.AllowanceAlertSettings())
+}
+
+// renterAllowanceAlertsHandlerPOST handles the API call to update the
+// allowance alert rules.
+func (api *API) renterAllowanceAlertsHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	settings := api.renter.AllowanceAlertSettings()
+	if thresholdStr := req.FormValue("spentthreshold"); thresholdStr != "" {
+		threshold, err := strconv.ParseFloat(thresholdStr, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse spentthreshold: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.SpentThreshold = threshold
+	}
+	if renewalStr := req.FormValue("renewalexceedsfunds"); renewalStr != "" {
+		renewal, err := strconv.ParseBool(renewalStr)
+		if err != nil {
+			WriteError(w, Error{"unable to parse renewalexceedsfunds: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.RenewalExceedsFunds = renewal
+	}
+	err := api.renter.SetAllowanceAlertSettings(settings)
+	if err != nil {
+		WriteError(w, Error{"failed to set allowance alerts: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // renterDownloadsHandler handles the API call to request the download queue.
 func (api *API) renterDownloadsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	var downloads []DownloadInfo
@@ -1426,6 +1657,92 @@ func (api *API) renterRecoveryScanHandlerGET(w http.ResponseWriter, _ *http.Requ
 	})
 }
 
+// renterRepairsHandlerGET handles the API call to /renter/repairs.
+func (api *API) renterRepairsHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, api.renter.RepairQueue())
+}
+
+// renterPinnedCacheHandlerGET handles the API call to /renter/pinnedcache.
+func (api *API) renterPinnedCacheHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, api.renter.PinnedCache())
+}
+
+// renterPinnedCacheHandlerPOST handles the API call to set the budget of the
+// renter's pinned cache.
+func (api *API) renterPinnedCacheHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	budget, err := strconv.ParseUint(req.FormValue("budget"), 10, 64)
+	if err != nil {
+		WriteError(w, Error{"unable to parse budget: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := api.renter.SetPinnedBytesBudget(budget); err != nil {
+		WriteError(w, Error{"failed to set budget: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterPinHandler handles the API call to pin a range of a file in memory.
+func (api *API) renterPinHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	siaPath, err := parseRenterPinSiaPath(req, ps)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var offset, length uint64
+	if o := req.FormValue("offset"); o != "" {
+		offset, err = strconv.ParseUint(o, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse offset: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if l := req.FormValue("length"); l != "" {
+		length, err = strconv.ParseUint(l, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse length: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if err := api.renter.PinFile(siaPath, offset, length); err != nil {
+		WriteError(w, Error{"failed to pin file: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterUnpinHandler handles the API call to remove the pinned ranges of a
+// file from memory.
+func (api *API) renterUnpinHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	siaPath, err := parseRenterPinSiaPath(req, ps)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := api.renter.UnpinFile(siaPath); err != nil {
+		WriteError(w, Error{"failed to unpin file: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// parseRenterPinSiaPath parses the siapath of the pin endpoints and rebases it
+// to the user folder unless the root flag is set.
+func parseRenterPinSiaPath(req *http.Request, ps httprouter.Params) (modules.SiaPath, error) {
+	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
+	if err != nil {
+		return modules.SiaPath{}, err
+	}
+	root, err := isCalledWithRootFlag(req)
+	if err != nil {
+		return modules.SiaPath{}, err
+	}
+	if root {
+		return siaPath, nil
+	}
+	return rebaseInputSiaPath(siaPath)
+}
+
 // renterRenameHandler handles the API call to rename a file entry in the
 // renter.
 func (api *API) renterRenameHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
@@ -1468,6 +1785,82 @@ func (api *API) renterRenameHandler(w http.ResponseWriter, req *http.Request, ps
 	WriteSuccess(w)
 }
 
+// parseVersionsSiaPath parses the siapath of a /renter/versions/:siapath
+// request, rebasing it to the user folder unless the root flag is set.
+func parseVersionsSiaPath(req *http.Request, ps httprouter.Params) (modules.SiaPath, error) {
+	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
+	if err != nil {
+		return modules.SiaPath{}, err
+	}
+	root, err := isCalledWithRootFlag(req)
+	if err != nil {
+		return modules.SiaPath{}, err
+	}
+	if !root {
+		return rebaseInputSiaPath(siaPath)
+	}
+	return siaPath, nil
+}
+
+// renterVersionsHandlerGET handles GET requests to the /renter/versions/:siapath
+// API endpoint.
+func (api *API) renterVersionsHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	siaPath, err := parseVersionsSiaPath(req, ps)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	versions, err := api.renter.FileVersions(siaPath)
+	if err != nil {
+		WriteError(w, Error{"failed to get versions: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterFileVersions{
+		Versions: versions,
+	})
+}
+
+// renterVersionsHandlerPOST handles POST requests to the
+// /renter/versions/:siapath API endpoint.
+func (api *API) renterVersionsHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	siaPath, err := parseVersionsSiaPath(req, ps)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	switch action := req.FormValue("action"); action {
+	case "restore":
+		version := req.FormValue("version")
+		if version == "" {
+			WriteError(w, Error{"version must be set to restore a version"}, http.StatusBadRequest)
+			return
+		}
+		err = api.renter.RestoreFileVersion(siaPath, version)
+		if err != nil {
+			WriteError(w, Error{"failed to restore version: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	case "prune":
+		var keep uint64
+		if k := req.FormValue("keep"); k != "" {
+			keep, err = strconv.ParseUint(k, 10, 64)
+			if err != nil {
+				WriteError(w, Error{"unable to parse keep: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+		}
+		err = api.renter.PruneFileVersions(siaPath, keep)
+		if err != nil {
+			WriteError(w, Error{"failed to prune versions: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	default:
+		WriteError(w, Error{fmt.Sprintf("unknown action '%v', must be either 'restore' or 'prune'", action)}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // renterFileHandler handles GET requests to the /renter/file/:siapath API endpoint.
 func (api *API) renterFileHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	// Determine the siapath that the user wants to get the file from.
@@ -1555,9 +1948,75 @@ func (api *API) renterFileHandlerPOST(w http.ResponseWriter, req *http.Request,
 			return
 		}
 	}
+	// Handle changing the expiry of a file.
+	_, expiry := req.Form["expiry"]
+	_, ttl := req.Form["ttl"]
+	if expiry || ttl {
+		fe, err := parseFileExpiry(req.Form)
+		if err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if err := api.renter.SetFileExpiry(siaPath, fe); err != nil {
+			WriteError(w, Error{"failed to set expiry: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	// Handle changing the repair priority of a file.
+	if rp := req.FormValue("repairpriority"); rp != "" {
+		priority, err := modules.ParseFileRepairPriority(rp)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'repairpriority' arg: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if err := api.renter.SetFileRepairPriority(siaPath, priority); err != nil {
+			WriteError(w, Error{"failed to set repair priority: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	// Handle changing the HTTP headers of a file. Unlike the other params, an
+	// empty value is valid and removes the header.
+	_, cacheControl := req.Form["cachecontrol"]
+	_, contentDisposition := req.Form["contentdisposition"]
+	_, contentType := req.Form["contenttype"]
+	if cacheControl || contentDisposition || contentType {
+		fi, err := api.renter.FileCached(siaPath)
+		if err != nil {
+			WriteError(w, Error{"unable to get file: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		headers := fi.HTTPHeaders
+		if cacheControl {
+			headers.CacheControl = req.FormValue("cachecontrol")
+		}
+		if contentDisposition {
+			headers.ContentDisposition = req.FormValue("contentdisposition")
+		}
+		if contentType {
+			headers.ContentType = req.FormValue("contenttype")
+		}
+		if err := api.renter.SetFileHTTPHeaders(siaPath, headers); err != nil {
+			WriteError(w, Error{"failed to set HTTP headers: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
 	WriteSuccess(w)
 }
 
+// writeFileHTTPHeaders sets the HTTP headers which were attached to a file on
+// the response.
+func writeFileHTTPHeaders(w http.ResponseWriter, headers modules.FileHTTPHeaders) {
+	if headers.CacheControl != "" {
+		w.Header().Set("Cache-Control", headers.CacheControl)
+	}
+	if headers.ContentDisposition != "" {
+		w.Header().Set("Content-Disposition", headers.ContentDisposition)
+	}
+	if headers.ContentType != "" {
+		w.Header().Set("Content-Type", headers.ContentType)
+	}
+}
+
 // renterFilesHandler handles the API call to list all of the files.
 func (api *API) renterFilesHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	var c bool
@@ -1594,6 +2053,40 @@ func (api *API) renterFilesHandler(w http.ResponseWriter, req *http.Request, _ h
 	})
 }
 
+// renterSearchHandlerGET handles the API call to /renter/search.
+func (api *API) renterSearchHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	params := modules.FileSearchParams{
+		Query: req.FormValue("query"),
+		Tags:  parseUserTags(req.FormValue("tags")),
+	}
+	var err error
+	if o := req.FormValue("offset"); o != "" {
+		params.Offset, err = strconv.ParseUint(o, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'offset' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if l := req.FormValue("limit"); l != "" {
+		params.Limit, err = strconv.ParseUint(l, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'limit' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	result, err := api.renter.SearchFiles(modules.UserFolder, params)
+	if err != nil {
+		WriteError(w, Error{"unable to search files: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	result.Files, err = trimSiaDirFolderOnFiles(result.Files...)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, result)
+}
+
 // renterPricesHandler reports the expected costs of various actions given the
 // renter settings and the set of available hosts.
 func (api *API) renterPricesHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
@@ -1763,6 +2256,15 @@ func (api *API) renterDownloadHandler(w http.ResponseWriter, req *http.Request,
 	}
 	// Set ID before starting download.
 	w.Header().Set("ID", string(id))
+	// Set the headers of the file if it is written to the response body.
+	if params.Httpwriter != nil {
+		fi, err := api.renter.FileCached(params.SiaPath)
+		if err != nil {
+			WriteError(w, Error{"unable to get file: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		writeFileHTTPHeaders(w, fi.HTTPHeaders)
+	}
 	// Start download.
 	if err := start(); err != nil {
 		WriteError(w, Error{"download failed: " + err.Error()}, http.StatusInternalServerError)
@@ -1809,6 +2311,10 @@ func parseDownloadParameters(w http.ResponseWriter, req *http.Request, ps httpro
 	// disk if available.
 	disablelocalfetchparam := req.FormValue("disablelocalfetch")
 
+	// policyparam determines the trade-off between latency and cost of the
+	// download.
+	policyparam := req.FormValue("policy")
+
 	// Parse the offset and length parameters.
 	var offset, length uint64
 	if len(offsetparam) > 0 {
@@ -1863,9 +2369,15 @@ func parseDownloadParameters(w http.ResponseWriter, req *http.Request, ps httpro
 		}
 	}
 
+	var policy modules.DownloadPolicy
+	if err := policy.FromString(policyparam); err != nil {
+		return modules.RenterDownloadParameters{}, errors.AddContext(err, "error parsing the policy")
+	}
+
 	dp := modules.RenterDownloadParameters{
 		Destination:      destination,
 		DisableDiskFetch: disableLocalFetch,
+		Policy:           policy,
 		Async:            async,
 		Length:           length,
 		Offset:           offset,
@@ -1917,6 +2429,13 @@ func (api *API) renterStreamHandler(w http.ResponseWriter, req *http.Request, ps
 	defer func() {
 		_ = streamer.Close()
 	}()
+	fi, err := api.renter.FileCached(siaPath)
+	if err != nil {
+		WriteError(w, Error{fmt.Sprintf("failed to get file: %v", err)}, http.StatusInternalServerError)
+		return
+	}
+	// ServeContent only detects the content type if it isn't set yet.
+	writeFileHTTPHeaders(w, fi.HTTPHeaders)
 	http.ServeContent(w, req, fileName, time.Time{}, streamer)
 }
 
@@ -1946,6 +2465,13 @@ func (api *API) renterUploadHandler(w http.ResponseWriter, req *http.Request, ps
 		return
 	}
 
+	// Parse the expiry.
+	expiry, err := parseFileExpiry(req.Form)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
 	// Call the renter to upload the file.
 	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
 	if err != nil {
@@ -1963,6 +2489,9 @@ func (api *API) renterUploadHandler(w http.ResponseWriter, req *http.Request, ps
 		ErasureCode:         ec,
 		Force:               force,
 		DisablePartialChunk: true, // TODO: remove this
+		UserTags:            parseUserTags(req.FormValue("usertags")),
+		Bucket:              req.FormValue("bucket"),
+		Expiry:              expiry,
 
 		// NOTE: can make this an optional param.
 		CipherType: crypto.TypeDefaultRenter,
@@ -2039,36 +2568,319 @@ func (api *API) renterUploadsResumeHandler(w http.ResponseWriter, _ *http.Reques
 	WriteSuccess(w)
 }
 
-// renterUploadStreamHandler handles the API call to upload a file using a
-// stream.
-func (api *API) renterUploadStreamHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
-	// Parse the query params.
-	queryForm, err := url.ParseQuery(req.URL.RawQuery)
+// renterReadOnlyHandlerGET handles the API call to get the status of the
+// renter's read-only mode.
+func (api *API) renterReadOnlyHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	status, err := api.renter.ReadOnlyStatus()
 	if err != nil {
-		WriteError(w, Error{"failed to parse query params"}, http.StatusBadRequest)
+		WriteError(w, Error{"failed to get read-only status: " + err.Error()}, http.StatusInternalServerError)
 		return
 	}
-	// Check whether existing file should be overwritten
-	force := false
-	if f := queryForm.Get("force"); f != "" {
-		force, err = strconv.ParseBool(f)
+	WriteJSON(w, status)
+}
+
+// renterReadOnlyHandlerPOST handles the API call to enable or disable the
+// renter's read-only mode and to update its thresholds.
+func (api *API) renterReadOnlyHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Update the thresholds if any were provided.
+	minWalletStr := req.FormValue("minwalletbalance")
+	minAllowanceStr := req.FormValue("minallowanceremaining")
+	if minWalletStr != "" || minAllowanceStr != "" {
+		status, err := api.renter.ReadOnlyStatus()
 		if err != nil {
-			WriteError(w, Error{"unable to parse 'force' parameter: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, Error{"failed to get read-only status: " + err.Error()}, http.StatusInternalServerError)
 			return
 		}
-	}
-	// Check whether existing file should be repaired
-	repair := false
-	if r := queryForm.Get("repair"); r != "" {
-		repair, err = strconv.ParseBool(r)
+		settings := status.Settings
+		if minWalletStr != "" {
+			minWallet, ok := scanAmount(minWalletStr)
+			if !ok {
+				WriteError(w, Error{"unable to parse minwalletbalance"}, http.StatusBadRequest)
+				return
+			}
+			settings.MinWalletBalance = minWallet
+		}
+		if minAllowanceStr != "" {
+			minAllowance, ok := scanAmount(minAllowanceStr)
+			if !ok {
+				WriteError(w, Error{"unable to parse minallowanceremaining"}, http.StatusBadRequest)
+				return
+			}
+			settings.MinAllowanceRemaining = minAllowance
+		}
+		err = api.renter.SetReadOnlySettings(settings)
 		if err != nil {
-			WriteError(w, Error{"unable to parse 'repair' parameter: " + err.Error()}, http.StatusBadRequest)
+			WriteError(w, Error{"failed to set read-only settings: " + err.Error()}, http.StatusBadRequest)
 			return
 		}
 	}
-	// Parse the erasure coder.
-	ec, err := parseErasureCodingParameters(queryForm.Get("datapieces"), queryForm.Get("paritypieces"))
-	if err != nil && !repair {
+
+	// Toggle the manual read-only mode if requested.
+	if enabledStr := req.FormValue("enabled"); enabledStr != "" {
+		enabled, err := strconv.ParseBool(enabledStr)
+		if err != nil {
+			WriteError(w, Error{"unable to parse enabled: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		err = api.renter.SetReadOnlyMode(enabled)
+		if err != nil {
+			WriteError(w, Error{"failed to set read-only mode: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	WriteSuccess(w)
+}
+
+// renterReplicationHandlerGET handles the API call to get the status of the
+// replication of the renter's metadata.
+func (api *API) renterReplicationHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	status, err := api.renter.ReplicationStatus()
+	if err != nil {
+		WriteError(w, Error{"failed to get replication status: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	// Don't leak the secret.
+	status.Settings.Secret = ""
+	WriteJSON(w, status)
+}
+
+// renterReplicationHandlerPOST handles the API call to set the role of the
+// renter in the replication of its metadata.
+func (api *API) renterReplicationHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	settings := modules.ReplicationSettings{
+		Role:   req.FormValue("role"),
+		Path:   req.FormValue("path"),
+		Secret: req.FormValue("secret"),
+	}
+	if intervalStr := req.FormValue("interval"); intervalStr != "" {
+		interval, err := time.ParseDuration(intervalStr)
+		if err != nil {
+			WriteError(w, Error{"unable to parse interval: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Interval = interval
+	}
+	err := api.renter.SetReplicationSettings(settings)
+	if err != nil {
+		WriteError(w, Error{"failed to set replication settings: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterMetadataMirrorHandlerGET handles the API call to get the status of the
+// mirroring of the renter's metadata to its hosts.
+func (api *API) renterMetadataMirrorHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	status, err := api.renter.MetadataMirrorStatus()
+	if err != nil {
+		WriteError(w, Error{"failed to get metadata mirror status: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, status)
+}
+
+// renterMetadataMirrorHandlerPOST handles the API call to enable or disable
+// the mirroring of the renter's metadata to its hosts.
+func (api *API) renterMetadataMirrorHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var settings modules.MetadataMirrorSettings
+	enabled, err := strconv.ParseBool(req.FormValue("enabled"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse enabled: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	settings.Enabled = enabled
+	if intervalStr := req.FormValue("interval"); intervalStr != "" {
+		interval, err := time.ParseDuration(intervalStr)
+		if err != nil {
+			WriteError(w, Error{"unable to parse interval: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Interval = interval
+	}
+	err = api.renter.SetMetadataMirrorSettings(settings)
+	if err != nil {
+		WriteError(w, Error{"failed to set metadata mirror settings: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterMetadataMirrorRecoverHandlerPOST handles the API call to recover the
+// renter's siafiles from the metadata mirrored to its hosts.
+func (api *API) renterMetadataMirrorRecoverHandlerPOST(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	recovery, err := api.renter.RecoverMetadataMirror()
+	if err != nil {
+		WriteError(w, Error{"failed to recover metadata: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, recovery)
+}
+
+// renterChaosHandlerGET handles the API call to get the report of the renter's
+// chaos testing mode.
+func (api *API) renterChaosHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	report, err := api.renter.ChaosReport()
+	if err != nil {
+		WriteError(w, Error{"failed to get chaos report: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, report)
+}
+
+// renterChaosHandlerPOST handles the API call to configure the renter's chaos
+// testing mode. Fields which are not provided keep their current value.
+// Updating the settings resets the report.
+func (api *API) renterChaosHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	report, err := api.renter.ChaosReport()
+	if err != nil {
+		WriteError(w, Error{"failed to get chaos report: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	settings := report.Settings
+	if enabledStr := req.FormValue("enabled"); enabledStr != "" {
+		settings.Enabled, err = strconv.ParseBool(enabledStr)
+		if err != nil {
+			WriteError(w, Error{"unable to parse enabled: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	probabilities := []struct {
+		name string
+		p    *float64
+	}{
+		{"delayprobability", &settings.DelayProbability},
+		{"droppricetableprobability", &settings.DropPriceTableProbability},
+		{"failureprobability", &settings.FailureProbability},
+	}
+	for _, prob := range probabilities {
+		pStr := req.FormValue(prob.name)
+		if pStr == "" {
+			continue
+		}
+		*prob.p, err = strconv.ParseFloat(pStr, 64)
+		if err != nil {
+			WriteError(w, Error{fmt.Sprintf("unable to parse %v: %v", prob.name, err)}, http.StatusBadRequest)
+			return
+		}
+	}
+	if maxDelayStr := req.FormValue("maxdelay"); maxDelayStr != "" {
+		settings.MaxDelay, err = time.ParseDuration(maxDelayStr)
+		if err != nil {
+			WriteError(w, Error{"unable to parse maxdelay: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if err := api.renter.SetChaosSettings(settings); err != nil {
+		WriteError(w, Error{"failed to set chaos settings: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterFailureDomainsHandlerGET handles the API call to get the files which
+// store several pieces of a chunk on hosts sharing a failure domain. Only the
+// files in the user folder are listed, relative to that folder.
+func (api *API) renterFailureDomainsHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	report, err := api.renter.FailureDomainReport()
+	if err != nil {
+		WriteError(w, Error{"failed to get failure domain report: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	violations := report.Violations[:0]
+	for _, v := range report.Violations {
+		v.SiaPath, err = v.SiaPath.Rebase(modules.UserFolder, modules.RootSiaPath())
+		if err != nil {
+			continue
+		}
+		violations = append(violations, v)
+	}
+	report.Violations = violations
+	WriteJSON(w, report)
+}
+
+// renterFailureDomainsFixHandlerPOST handles the API call to drop the
+// correlated pieces of the provided files, or of all files if none are
+// provided, to have them re-uploaded to uncorrelated hosts.
+func (api *API) renterFailureDomainsFixHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var siaPaths []modules.SiaPath
+	if siaPathsStr := req.FormValue("siapaths"); siaPathsStr != "" {
+		for _, s := range strings.Split(siaPathsStr, ",") {
+			siaPath, err := modules.NewSiaPath(s)
+			if err == nil {
+				siaPath, err = rebaseInputSiaPath(siaPath)
+			}
+			if err != nil {
+				WriteError(w, Error{"unable to parse siapaths: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+			siaPaths = append(siaPaths, siaPath)
+		}
+	}
+	report, err := api.renter.FixFailureDomainViolations(siaPaths)
+	if err != nil {
+		WriteError(w, Error{"failed to fix failure domain violations: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, report)
+}
+
+// renterUploadStreamHandler handles the API call to upload a file using a
+// stream.
+func (api *API) renterUploadStreamHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	// Parse the query params.
+	queryForm, err := url.ParseQuery(req.URL.RawQuery)
+	if err != nil {
+		WriteError(w, Error{"failed to parse query params"}, http.StatusBadRequest)
+		return
+	}
+	// Check whether existing file should be overwritten
+	force := false
+	if f := queryForm.Get("force"); f != "" {
+		force, err = strconv.ParseBool(f)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'force' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	// Check whether existing file should be repaired
+	repair := false
+	if r := queryForm.Get("repair"); r != "" {
+		repair, err = strconv.ParseBool(r)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'repair' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	// Parse the offset at which a repair starts
+	var offset uint64
+	if o := queryForm.Get("offset"); o != "" {
+		offset, err = strconv.ParseUint(o, 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'offset' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	// Check whether the data should be appended to an existing file
+	appendData := false
+	if a := queryForm.Get("append"); a != "" {
+		appendData, err = strconv.ParseBool(a)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'append' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	// Check whether an interrupted stream should be resumed
+	resume := false
+	if r := queryForm.Get("resume"); r != "" {
+		resume, err = strconv.ParseBool(r)
+		if err != nil {
+			WriteError(w, Error{"unable to parse 'resume' parameter: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	// Parse the erasure coder.
+	ec, err := parseErasureCodingParameters(queryForm.Get("datapieces"), queryForm.Get("paritypieces"))
+	if err != nil && !repair && !appendData && !resume {
 		WriteError(w, Error{"unable to parse erasure code settings: " + err.Error()}, http.StatusBadRequest)
 		return
 	}
@@ -2076,6 +2888,20 @@ func (api *API) renterUploadStreamHandler(w http.ResponseWriter, req *http.Reque
 		WriteError(w, Error{"can't provide erasure code settings when doing a repair"}, http.StatusBadRequest)
 		return
 	}
+	if appendData && ec != nil {
+		WriteError(w, Error{"can't provide erasure code settings when appending to a file"}, http.StatusBadRequest)
+		return
+	}
+	if resume && ec != nil {
+		WriteError(w, Error{"can't provide erasure code settings when resuming an upload"}, http.StatusBadRequest)
+		return
+	}
+	// Parse the expiry.
+	expiry, err := parseFileExpiry(queryForm)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
 
 	// Call the renter to upload the file.
 	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
@@ -2093,6 +2919,12 @@ func (api *API) renterUploadStreamHandler(w http.ResponseWriter, req *http.Reque
 		ErasureCode: ec,
 		Force:       force,
 		Repair:      repair,
+		Append:      appendData,
+		Offset:      offset,
+		Resume:      resume,
+		UserTags:    parseUserTags(queryForm.Get("usertags")),
+		Bucket:      queryForm.Get("bucket"),
+		Expiry:      expiry,
 
 		// NOTE: can make this an optional param.
 		CipherType: crypto.TypeDefaultRenter,
@@ -2105,6 +2937,199 @@ func (api *API) renterUploadStreamHandler(w http.ResponseWriter, req *http.Reque
 	WriteSuccess(w)
 }
 
+// renterUploadStreamHandlerGET handles the API call to get the offset at which
+// an interrupted upload stream can be resumed.
+func (api *API) renterUploadStreamHandlerGET(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	siaPath, err = rebaseInputSiaPath(siaPath)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	offset, err := api.renter.UploadStreamResumeOffset(siaPath)
+	if err != nil {
+		WriteError(w, Error{"failed to get resume offset: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, RenterUploadStreamGET{
+		ResumeOffset: offset,
+	})
+}
+
+// renterSparseHandlerPOST handles the API call to create a sparse file or to
+// punch holes into an existing file.
+func (api *API) renterSparseHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	siaPath, err = rebaseInputSiaPath(siaPath)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	switch action := req.FormValue("action"); action {
+	case "create":
+		size, err := strconv.ParseUint(req.FormValue("size"), 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse size: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		force := false
+		if f := req.FormValue("force"); f != "" {
+			force, err = strconv.ParseBool(f)
+			if err != nil {
+				WriteError(w, Error{"unable to parse force: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+		}
+		ec, err := parseErasureCodingParameters(req.FormValue("datapieces"), req.FormValue("paritypieces"))
+		if err != nil {
+			WriteError(w, Error{"unable to parse erasure code settings: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		err = api.renter.CreateSparseFile(modules.FileUploadParams{
+			SiaPath:     siaPath,
+			ErasureCode: ec,
+			Force:       force,
+			CipherType:  crypto.TypeDefaultRenter,
+		}, size)
+		if err != nil {
+			WriteError(w, Error{"failed to create sparse file: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+	case "punchhole":
+		offset, err := strconv.ParseUint(req.FormValue("offset"), 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse offset: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		length, err := strconv.ParseUint(req.FormValue("length"), 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse length: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if err := api.renter.PunchHoles(siaPath, offset, length); err != nil {
+			WriteError(w, Error{"failed to punch hole: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	case "":
+		WriteError(w, Error{"you must set the action you wish to execute"}, http.StatusBadRequest)
+		return
+	default:
+		WriteError(w, Error{"unknown action: " + action}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterFileExportHandlerGET handles the API call to export the metadata of a
+// file.
+func (api *API) renterFileExportHandlerGET(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	siaPath, err = rebaseInputSiaPath(siaPath)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	// Export the file into a buffer first to be able to return an error
+	// before writing the response.
+	var buf bytes.Buffer
+	if err := api.renter.ExportFile(siaPath, &buf); err != nil {
+		WriteError(w, Error{"failed to export file: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	_, _ = w.Write(buf.Bytes())
+}
+
+// renterFileImportHandlerPOST handles the API call to import the metadata of a
+// file which was previously exported.
+func (api *API) renterFileImportHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	siaPath, err = rebaseInputSiaPath(siaPath)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := api.renter.ImportFile(siaPath, req.Body); err != nil {
+		WriteError(w, Error{"failed to import file: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterFileRecoverHandlerPOST handles the API call to recover the data of a
+// file directly from the renter's contracts.
+func (api *API) renterFileRecoverHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	siaPath, err = rebaseInputSiaPath(siaPath)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	api.writeRecoveredFile(w, req.FormValue("destination"), func(f io.Writer) (modules.FileRecoveryReport, error) {
+		return api.renter.RecoverFile(siaPath, f)
+	})
+}
+
+// renterFileRecoverExportHandlerPOST handles the API call to recover the data
+// of a file directly from the renter's contracts using the metadata of a file
+// which was previously exported.
+func (api *API) renterFileRecoverExportHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// The body contains the export so the destination is read from the
+	// query string.
+	api.writeRecoveredFile(w, req.URL.Query().Get("destination"), func(f io.Writer) (modules.FileRecoveryReport, error) {
+		return api.renter.RecoverExportedFile(req.Body, f)
+	})
+}
+
+// writeRecoveredFile creates the file at dst and writes the data recovered by
+// recoverFn to it. The file is removed again if the recovery fails.
+func (api *API) writeRecoveredFile(w http.ResponseWriter, dst string, recoverFn func(io.Writer) (modules.FileRecoveryReport, error)) {
+	// Check that destination was specified.
+	if dst == "" {
+		WriteError(w, Error{"destination not specified"}, http.StatusBadRequest)
+		return
+	}
+	// The destination needs to be an absolute path.
+	if !filepath.IsAbs(dst) {
+		WriteError(w, Error{"destination must be an absolute path"}, http.StatusBadRequest)
+		return
+	}
+	f, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, modules.DefaultFilePerm)
+	if err != nil {
+		WriteError(w, Error{"failed to create destination: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	report, err := recoverFn(f)
+	err = errors.Compose(err, f.Close())
+	if err != nil {
+		err = errors.Compose(err, os.Remove(dst))
+		WriteError(w, Error{"failed to recover file: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, report)
+}
+
 // renterValidateSiaPathHandler handles the API call that validates a siapath
 func (api *API) renterValidateSiaPathHandler(w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
 	// Try and create a new siapath, this will validate the potential siapath
@@ -2189,7 +3214,7 @@ func (api *API) renterDirHandlerGET(w http.ResponseWriter, req *http.Request, ps
 }
 
 // renterDirHandlerPOST handles POST requests to /renter/dir/:siapath?action=<>
-// in order to create, delete, and rename a directory
+// in order to create, delete, rename and copy a directory
 func (api *API) renterDirHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	// Parse action
 	action := req.FormValue("action")
@@ -2266,12 +3291,149 @@ func (api *API) renterDirHandlerPOST(w http.ResponseWriter, req *http.Request, p
 		WriteSuccess(w)
 		return
 	}
+	if action == "copy" {
+		newSiaPath, err := modules.NewSiaPath(req.FormValue("newsiapath"))
+		if err != nil {
+			WriteError(w, Error{"failed to parse newsiapath: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		newSiaPath, err = rebaseInputSiaPath(newSiaPath)
+		if err != nil {
+			WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+			return
+		}
+		err = api.renter.CopyDir(siaPath, newSiaPath)
+		if err != nil {
+			WriteError(w, Error{"failed to copy directory: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		WriteSuccess(w)
+		return
+	}
 
 	// Report that no calls were made
 	WriteError(w, Error{"no calls were made, please check your submission and try again"}, http.StatusInternalServerError)
 	return
 }
 
+// parseDirSiaPath parses the siapath of a directory endpoint and rebases it to
+// the user folder unless the root flag is set.
+func parseDirSiaPath(req *http.Request, ps httprouter.Params) (modules.SiaPath, error) {
+	siaPath, err := modules.NewSiaPath(ps.ByName("siapath"))
+	if err != nil {
+		return modules.SiaPath{}, err
+	}
+	root, err := isCalledWithRootFlag(req)
+	if err != nil {
+		return modules.SiaPath{}, err
+	}
+	if root {
+		return siaPath, nil
+	}
+	return rebaseInputSiaPath(siaPath)
+}
+
+// parseUserTags parses a comma separated list of user tags.
+func parseUserTags(str string) []string {
+	if str == "" {
+		return nil
+	}
+	return strings.Split(str, ",")
+}
+
+// parseFileExpiry parses the expiry of a file from the 'expiry', 'ttl' and
+// 'expiryarchive' parameters. 'expiry' is a unix timestamp and 'ttl' a
+// duration relative to now. An expiry of 0 means that the file doesn't
+// expire.
+func parseFileExpiry(values url.Values) (modules.FileExpiry, error) {
+	var fe modules.FileExpiry
+	expiry, ttl := values.Get("expiry"), values.Get("ttl")
+	if expiry != "" && ttl != "" {
+		return modules.FileExpiry{}, errors.New("can't specify both 'expiry' and 'ttl'")
+	}
+	if expiry != "" {
+		timestamp, err := strconv.ParseInt(expiry, 10, 64)
+		if err != nil || timestamp < 0 {
+			return modules.FileExpiry{}, fmt.Errorf("unable to parse 'expiry' parameter: %v", expiry)
+		}
+		if timestamp > 0 {
+			fe.Time = time.Unix(timestamp, 0)
+		}
+	}
+	if ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil || d <= 0 {
+			return modules.FileExpiry{}, fmt.Errorf("unable to parse 'ttl' parameter: %v", ttl)
+		}
+		fe.Time = time.Now().Add(d)
+	}
+	if archive := values.Get("expiryarchive"); archive != "" {
+		var err error
+		fe.Archive, err = strconv.ParseBool(archive)
+		if err != nil {
+			return modules.FileExpiry{}, errors.AddContext(err, "unable to parse 'expiryarchive' parameter")
+		}
+	}
+	if fe.Time.IsZero() {
+		fe.Archive = false
+	}
+	return fe, nil
+}
+
+// renterDirDefaultsHandlerGET handles the API call to get the defaults of a
+// directory.
+func (api *API) renterDirDefaultsHandlerGET(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	siaPath, err := parseDirSiaPath(req, ps)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	defaults, effective, err := api.renter.DirDefaults(siaPath)
+	if err != nil {
+		WriteError(w, Error{"failed to get directory defaults: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, RenterDirDefaultsGET{
+		Defaults:          defaults,
+		EffectiveDefaults: effective,
+	})
+}
+
+// renterDirDefaultsHandlerPOST handles the API call to set the defaults of a
+// directory. All of the defaults are replaced by the provided ones.
+func (api *API) renterDirDefaultsHandlerPOST(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	siaPath, err := parseDirSiaPath(req, ps)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var defaults modules.DirDefaults
+	ec, err := parseErasureCodingParameters(req.FormValue("datapieces"), req.FormValue("paritypieces"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse erasure code settings: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if ec != nil {
+		defaults.DataPieces = ec.MinPieces()
+		defaults.ParityPieces = ec.NumPieces() - ec.MinPieces()
+	}
+	if m := req.FormValue("mode"); m != "" {
+		mode, err := strconv.ParseUint(m, 10, 32)
+		if err != nil {
+			WriteError(w, Error{fmt.Sprintf("failed to parse provided mode '%v'", m)}, http.StatusBadRequest)
+			return
+		}
+		defaults.FileMode = os.FileMode(mode)
+	}
+	defaults.UserTags = parseUserTags(req.FormValue("usertags"))
+	defaults.Bucket = req.FormValue("bucket")
+	if err := api.renter.SetDirDefaults(siaPath, defaults); err != nil {
+		WriteError(w, Error{"failed to set directory defaults: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // renterContractStatusHandler  handles the API call to check the status of a
 // contract monitored by the renter.
 func (api *API) renterContractStatusHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
@@ -2290,6 +3452,39 @@ func (api *API) renterContractStatusHandler(w http.ResponseWriter, req *http.Req
 	WriteJSON(w, contractStatus)
 }
 
+// renterContractKeysHandlerGET handles the API call to get the status of the
+// renter's contract key store.
+func (api *API) renterContractKeysHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, api.renter.ContractKeyStoreStatus())
+}
+
+// renterContractKeysChangePasswordHandlerPOST handles the API call to
+// re-encrypt the renter's contract key store with a new password.
+func (api *API) renterContractKeysChangePasswordHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	newPassword := req.FormValue("newpassword")
+	if newPassword == "" {
+		WriteError(w, Error{"a password must be provided to newpassword"}, http.StatusBadRequest)
+		return
+	}
+	err := api.renter.ChangeContractKeyStorePassword(req.FormValue("password"), newPassword)
+	if err != nil {
+		WriteError(w, Error{"unable to change contract key store password: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterContractKeysUnlockHandlerPOST handles the API call to unlock the
+// renter's contract key store.
+func (api *API) renterContractKeysUnlockHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	err := api.renter.UnlockContractKeyStore(req.FormValue("password"))
+	if err != nil {
+		WriteError(w, Error{"unable to unlock contract key store: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // renterWorkersHandler handles the API call to check the status of the renter's
 // workers
 func (api *API) renterWorkersHandler(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
@@ -2301,3 +3496,59 @@ func (api *API) renterWorkersHandler(w http.ResponseWriter, _ *http.Request, _ h
 
 	WriteJSON(w, workerPoolStatus)
 }
+
+// renterWorkersBandwidthLimitsHandlerGET handles the API call to get the
+// bandwidth limits of individual hosts.
+func (api *API) renterWorkersBandwidthLimitsHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, RenterWorkersBandwidthLimitsGET{
+		Limits: api.renter.HostBandwidthLimits(),
+	})
+}
+
+// renterWorkersBandwidthLimitsHandlerPOST handles the API call to set the
+// bandwidth limits of the worker of a host.
+func (api *API) renterWorkersBandwidthLimitsHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var limits modules.RenterHostBandwidthLimits
+	if err := limits.HostPublicKey.LoadString(req.FormValue("hostkey")); err != nil {
+		WriteError(w, Error{"unable to parse hostkey: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if d := req.FormValue("maxdownloadspeed"); d != "" {
+		if _, err := fmt.Sscan(d, &limits.MaxDownloadSpeed); err != nil {
+			WriteError(w, Error{"unable to parse maxdownloadspeed: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if u := req.FormValue("maxuploadspeed"); u != "" {
+		if _, err := fmt.Sscan(u, &limits.MaxUploadSpeed); err != nil {
+			WriteError(w, Error{"unable to parse maxuploadspeed: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if err := api.renter.SetHostBandwidthLimits(limits); err != nil {
+		WriteError(w, Error{"failed to set bandwidth limits: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// renterSchedulerHandlerGET handles the API call to get the settings of the
+// renter's scheduler and the state of its windows.
+func (api *API) renterSchedulerHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	WriteJSON(w, api.renter.SchedulerStatus())
+}
+
+// renterSchedulerHandlerPOST handles the API call to set the jobs which are
+// restricted to the windows of the renter's scheduler.
+func (api *API) renterSchedulerHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var settings modules.RenterSchedulerSettings
+	if err := json.NewDecoder(req.Body).Decode(&settings); err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := api.renter.SetSchedulerSettings(settings); err != nil {
+		WriteError(w, Error{"failed to set scheduler settings: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
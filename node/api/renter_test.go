@@ -8,6 +8,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strconv"
 	"strings"
 	"testing"
@@ -817,6 +818,71 @@ func TestRenterHandlerGetAndPost(t *testing.T) {
 	}
 }
 
+// TestRenterHandlerHostFilters checks that the host filters of the allowance
+// can be set and cleared through /renter.
+func TestRenterHandlerHostFilters(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	// Set an allowance with host filters.
+	allowanceValues := url.Values{}
+	allowanceValues.Set("funds", testFunds)
+	allowanceValues.Set("period", testPeriod)
+	allowanceValues.Set("minhostversion", "1.5.4")
+	allowanceValues.Set("minhostage", "100")
+	allowanceValues.Set("excludedcountries", "us, cn")
+	allowanceValues.Set("excludedsubnets", "10.0.0.0/8")
+	if err = st.stdPostAPI("/renter", allowanceValues); err != nil {
+		t.Fatal(err)
+	}
+	var get RenterGET
+	if err = st.getAPI("/renter", &get); err != nil {
+		t.Fatal(err)
+	}
+	a := get.Settings.Allowance
+	if a.MinHostVersion != "1.5.4" || a.MinHostAge != 100 {
+		t.Fatal("wrong version or age filter", a.MinHostVersion, a.MinHostAge)
+	}
+	if !reflect.DeepEqual(a.ExcludedCountries, []string{"US", "CN"}) || !reflect.DeepEqual(a.ExcludedSubnets, []string{"10.0.0.0/8"}) {
+		t.Fatal("wrong country or subnet filter", a.ExcludedCountries, a.ExcludedSubnets)
+	}
+
+	// Invalid filters are rejected.
+	invalidValues := url.Values{}
+	invalidValues.Set("excludedsubnets", "10.0.0.0")
+	if err = st.stdPostAPI("/renter", invalidValues); err == nil || !strings.Contains(err.Error(), "unable to parse excludedsubnets") {
+		t.Fatal("expected invalid subnet to be rejected", err)
+	}
+	invalidValues = url.Values{}
+	invalidValues.Set("minhostversion", "latest")
+	if err = st.stdPostAPI("/renter", invalidValues); err == nil || !strings.Contains(err.Error(), "unable to parse minhostversion") {
+		t.Fatal("expected invalid version to be rejected", err)
+	}
+
+	// Empty values clear the filters.
+	clearValues := url.Values{}
+	clearValues.Set("minhostversion", "")
+	clearValues.Set("excludedcountries", "")
+	clearValues.Set("excludedsubnets", "")
+	if err = st.stdPostAPI("/renter", clearValues); err != nil {
+		t.Fatal(err)
+	}
+	if err = st.getAPI("/renter", &get); err != nil {
+		t.Fatal(err)
+	}
+	a = get.Settings.Allowance
+	if a.MinHostVersion != "" || len(a.ExcludedCountries) != 0 || len(a.ExcludedSubnets) != 0 || a.MinHostAge != 100 {
+		t.Fatal("filters weren't cleared", a)
+	}
+}
+
 // TestRenterLoadNonexistent checks that attempting to upload or download a
 // nonexistent file triggers the appropriate error.
 func TestRenterLoadNonexistent(t *testing.T) {
@@ -35,17 +35,38 @@ func (api *API) buildHTTPRoutes() {
 
 	// Daemon API Calls
 	router.GET("/daemon/alerts", api.daemonAlertsHandlerGET)
+	router.POST("/daemon/alerts/acknowledge", RequirePassword(api.daemonAlertsAcknowledgeHandlerPOST, requiredPassword))
+	router.POST("/daemon/alerts/mute", RequirePassword(api.daemonAlertsMuteHandlerPOST, requiredPassword))
+	router.GET("/daemon/alerts/routes", api.daemonAlertsRoutesHandlerGET)
+	router.POST("/daemon/alerts/routes", RequirePassword(api.daemonAlertsRoutesHandlerPOST, requiredPassword))
+	router.GET("/daemon/diskthrottles", api.daemonDiskThrottlesHandlerGET)
+	router.POST("/daemon/diskthrottles", RequirePassword(api.daemonDiskThrottlesHandlerPOST, requiredPassword))
+	router.GET("/daemon/events", api.daemonEventsHandlerGET)
+	router.GET("/daemon/events/routes", api.daemonEventsRoutesHandlerGET)
+	router.POST("/daemon/events/routes", RequirePassword(api.daemonEventsRoutesHandlerPOST, requiredPassword))
+	router.POST("/daemon/maintenance/quiesce", RequirePassword(api.daemonMaintenanceQuiesceHandlerPOST, requiredPassword))
+	router.POST("/daemon/maintenance/resume", RequirePassword(api.daemonMaintenanceResumeHandlerPOST, requiredPassword))
 	router.GET("/daemon/constants", api.daemonConstantsHandler)
+	router.GET("/daemon/profile", api.daemonProfileHandlerGET)
+	router.POST("/daemon/profile", RequirePassword(api.daemonProfileHandlerPOST, requiredPassword))
+	router.GET("/daemon/proxy", api.daemonProxyHandlerGET)
+	router.POST("/daemon/proxy", RequirePassword(api.daemonProxyHandlerPOST, requiredPassword))
 	router.GET("/daemon/settings", api.daemonSettingsHandlerGET)
 	router.POST("/daemon/settings", api.daemonSettingsHandlerPOST)
 	router.GET("/daemon/stack", api.daemonStackHandlerGET)
 	router.POST("/daemon/startprofile", api.daemonStartProfileHandlerPOST)
 	router.GET("/daemon/stop", RequirePassword(api.daemonStopHandler, requiredPassword))
+	router.GET("/daemon/tokens", RequirePassword(api.daemonTokensHandlerGET, requiredPassword))
+	router.POST("/daemon/tokens", RequirePassword(api.daemonTokensHandlerPOST, requiredPassword))
+	router.POST("/daemon/tokens/revoke", RequirePassword(api.daemonTokensRevokeHandlerPOST, requiredPassword))
 	router.POST("/daemon/stopprofile", api.daemonStopProfileHandlerPOST)
 	router.GET("/daemon/update", api.daemonUpdateHandlerGET)
 	router.POST("/daemon/update", api.daemonUpdateHandlerPOST)
 	router.GET("/daemon/version", api.daemonVersionHandler)
 
+	// Metrics API Calls
+	router.GET("/metrics", api.metricsHandlerGET)
+
 	// Consensus API Calls
 	if api.cs != nil {
 		RegisterRoutesConsensus(router, api.cs)
@@ -80,6 +101,9 @@ func (api *API) buildHTTPRoutes() {
 	if api.renter != nil {
 		router.GET("/renter", api.renterHandlerGET)
 		router.POST("/renter", RequirePassword(api.renterHandlerPOST, requiredPassword))
+		router.GET("/renter/alerts", api.renterAlertsHandlerGET)
+		router.GET("/renter/allowance/alerts", api.renterAllowanceAlertsHandlerGET)
+		router.POST("/renter/allowance/alerts", RequirePassword(api.renterAllowanceAlertsHandlerPOST, requiredPassword))
 		router.POST("/renter/allowance/cancel", RequirePassword(api.renterAllowanceCancelHandlerPOST, requiredPassword))
 		router.POST("/renter/bubble", api.renterBubbleHandlerPOST)
 		router.GET("/renter/backups", RequirePassword(api.renterBackupsHandlerGET, requiredPassword))
@@ -88,16 +112,47 @@ func (api *API) buildHTTPRoutes() {
 		router.POST("/renter/clean", RequirePassword(api.renterCleanHandlerPOST, requiredPassword))
 		router.POST("/renter/contract/cancel", RequirePassword(api.renterContractCancelHandler, requiredPassword))
 		router.GET("/renter/contracts", api.renterContractsHandler)
+		router.GET("/renter/contractkeys", api.renterContractKeysHandlerGET)
+		router.POST("/renter/contractkeys/changepassword", RequirePassword(api.renterContractKeysChangePasswordHandlerPOST, requiredPassword))
+		router.POST("/renter/contractkeys/unlock", RequirePassword(api.renterContractKeysUnlockHandlerPOST, requiredPassword))
 		router.GET("/renter/contractorchurnstatus", api.renterContractorChurnStatus)
+		router.GET("/renter/contractormigrationplan", api.renterContractorMigrationPlanHandlerGET)
+		router.POST("/renter/contractormigrationplan/approve", RequirePassword(api.renterContractorMigrationPlanApproveHandlerPOST, requiredPassword))
 		router.GET("/renter/downloadinfo/*uid", api.renterDownloadByUIDHandlerGET)
 		router.GET("/renter/downloads", api.renterDownloadsHandler)
 		router.POST("/renter/downloads/clear", RequirePassword(api.renterClearDownloadsHandler, requiredPassword))
 		router.GET("/renter/files", api.renterFilesHandler)
 		router.GET("/renter/file/*siapath", api.renterFileHandlerGET)
 		router.POST("/renter/file/*siapath", RequirePassword(api.renterFileHandlerPOST, requiredPassword))
+		router.GET("/renter/fileexport/*siapath", RequirePassword(api.renterFileExportHandlerGET, requiredPassword))
+		router.POST("/renter/fileimport/*siapath", RequirePassword(api.renterFileImportHandlerPOST, requiredPassword))
+		router.POST("/renter/filerecover/*siapath", RequirePassword(api.renterFileRecoverHandlerPOST, requiredPassword))
+		router.POST("/renter/filerecoverexport", RequirePassword(api.renterFileRecoverExportHandlerPOST, requiredPassword))
+		router.GET("/renter/pinnedcache", api.renterPinnedCacheHandlerGET)
+		router.POST("/renter/pinnedcache", RequirePassword(api.renterPinnedCacheHandlerPOST, requiredPassword))
+		router.POST("/renter/pin/*siapath", RequirePassword(api.renterPinHandler, requiredPassword))
+		router.POST("/renter/unpin/*siapath", RequirePassword(api.renterUnpinHandler, requiredPassword))
 		router.GET("/renter/prices", api.renterPricesHandler)
+		router.GET("/renter/readonly", api.renterReadOnlyHandlerGET)
+		router.POST("/renter/readonly", RequirePassword(api.renterReadOnlyHandlerPOST, requiredPassword))
+		router.GET("/renter/replication", api.renterReplicationHandlerGET)
+		router.POST("/renter/replication", RequirePassword(api.renterReplicationHandlerPOST, requiredPassword))
+		router.GET("/renter/metadatamirror", api.renterMetadataMirrorHandlerGET)
+		router.POST("/renter/metadatamirror", RequirePassword(api.renterMetadataMirrorHandlerPOST, requiredPassword))
+		router.POST("/renter/metadatamirror/recover", RequirePassword(api.renterMetadataMirrorRecoverHandlerPOST, requiredPassword))
+		router.GET("/renter/chaos", api.renterChaosHandlerGET)
+		router.POST("/renter/chaos", RequirePassword(api.renterChaosHandlerPOST, requiredPassword))
+		router.GET("/renter/failuredomains", api.renterFailureDomainsHandlerGET)
+		router.POST("/renter/failuredomains/fix", RequirePassword(api.renterFailureDomainsFixHandlerPOST, requiredPassword))
 		router.POST("/renter/recoveryscan", RequirePassword(api.renterRecoveryScanHandlerPOST, requiredPassword))
 		router.GET("/renter/recoveryscan", api.renterRecoveryScanHandlerGET)
+		router.GET("/renter/repairs", api.renterRepairsHandlerGET)
+		router.GET("/renter/scheduler", api.renterSchedulerHandlerGET)
+		router.POST("/renter/scheduler", RequirePassword(api.renterSchedulerHandlerPOST, requiredPassword))
+		router.GET("/renter/spending", api.renterSpendingHandlerGET)
+		router.GET("/renter/spending/export", api.renterSpendingExportHandlerGET)
+		router.GET("/renter/spendingforecast", api.renterSpendingForecastHandlerGET)
+		router.GET("/renter/search", api.renterSearchHandlerGET)
 		router.GET("/renter/fuse", api.renterFuseHandlerGET)
 		router.POST("/renter/fuse/mount", RequirePassword(api.renterFuseMountHandlerPOST, requiredPassword))
 		router.POST("/renter/fuse/unmount", RequirePassword(api.renterFuseUnmountHandlerPOST, requiredPassword))
@@ -107,26 +162,39 @@ func (api *API) buildHTTPRoutes() {
 		router.POST("/renter/download/cancel", RequirePassword(api.renterCancelDownloadHandler, requiredPassword))
 		router.GET("/renter/downloadasync/*siapath", RequirePassword(api.renterDownloadAsyncHandler, requiredPassword))
 		router.POST("/renter/rename/*siapath", RequirePassword(api.renterRenameHandler, requiredPassword))
+		router.GET("/renter/versions/*siapath", api.renterVersionsHandlerGET)
+		router.POST("/renter/versions/*siapath", RequirePassword(api.renterVersionsHandlerPOST, requiredPassword))
 		router.GET("/renter/stream/*siapath", api.renterStreamHandler)
 		router.POST("/renter/upload/*siapath", RequirePassword(api.renterUploadHandler, requiredPassword))
 		router.GET("/renter/uploadready", api.renterUploadReadyHandler)
 		router.POST("/renter/uploads/pause", RequirePassword(api.renterUploadsPauseHandler, requiredPassword))
 		router.POST("/renter/uploads/resume", RequirePassword(api.renterUploadsResumeHandler, requiredPassword))
+		router.GET("/renter/uploadstream/*siapath", api.renterUploadStreamHandlerGET)
 		router.POST("/renter/uploadstream/*siapath", RequirePassword(api.renterUploadStreamHandler, requiredPassword))
+		router.POST("/renter/sparse/*siapath", RequirePassword(api.renterSparseHandlerPOST, requiredPassword))
 		router.POST("/renter/validatesiapath/*siapath", RequirePassword(api.renterValidateSiaPathHandler, requiredPassword))
 		router.GET("/renter/workers", api.renterWorkersHandler)
+		router.GET("/renter/workers/bandwidthlimits", api.renterWorkersBandwidthLimitsHandlerGET)
+		router.POST("/renter/workers/bandwidthlimits", RequirePassword(api.renterWorkersBandwidthLimitsHandlerPOST, requiredPassword))
 
 		// Directory endpoints
 		router.POST("/renter/dir/*siapath", RequirePassword(api.renterDirHandlerPOST, requiredPassword))
 		router.GET("/renter/dir/*siapath", api.renterDirHandlerGET)
+		router.GET("/renter/dirdefaults/*siapath", api.renterDirDefaultsHandlerGET)
+		router.POST("/renter/dirdefaults/*siapath", RequirePassword(api.renterDirDefaultsHandlerPOST, requiredPassword))
 
 		// HostDB endpoints.
 		router.GET("/hostdb", api.hostdbHandler)
 		router.GET("/hostdb/active", api.hostdbActiveHandler)
 		router.GET("/hostdb/all", api.hostdbAllHandler)
 		router.GET("/hostdb/hosts/:pubkey", api.hostdbHostsHandler)
+		router.GET("/hostdb/host/:pubkey/history", api.hostdbHostHistoryHandlerGET)
+		router.GET("/hostdb/export", api.hostdbExportHandlerGET)
 		router.GET("/hostdb/filtermode", api.hostdbFilterModeHandlerGET)
 		router.POST("/hostdb/filtermode", RequirePassword(api.hostdbFilterModeHandlerPOST, requiredPassword))
+		router.POST("/hostdb/import", RequirePassword(api.hostdbImportHandlerPOST, requiredPassword))
+		router.GET("/hostdb/priceindex", api.hostdbPriceIndexHandlerGET)
+		router.GET("/hostdb/simulate", api.hostdbSimulateHandlerGET)
 
 		// Renter watchdog endpoints.
 		router.GET("/renter/contractstatus", api.renterContractStatusHandler)
@@ -138,22 +206,39 @@ func (api *API) buildHTTPRoutes() {
 
 	// Transaction pool API Calls
 	if api.tpool != nil {
-		RegisterRoutesTransactionPool(router, api.tpool)
+		RegisterRoutesTransactionPool(router, api.tpool, requiredPassword)
 	}
 
 	// Wallet API Calls
 	if api.wallet != nil {
 		RegisterRoutesWallet(router, api.wallet, requiredPassword)
+		// The locked funds are reported by the renter's contractor.
+		if api.renter != nil {
+			router.GET("/wallet/lockedfunds", api.walletLockedFundsHandlerGET)
+		}
 	}
 
-	// Apply UserAgent middleware and return the Router
+	// Apply UserAgent, API version and API token middleware and return the
+	// Router
 	timeoutErr := Error{fmt.Sprintf("HTTP call exceeded the timeout of %v", httpServerTimeout)}
 	jsonErr, err := json.Marshal(timeoutErr)
 	if err != nil {
 		build.Critical("marshalling error on object that should be safe to marshal:", err)
 	}
+	userAgentRouter := RequireUserAgent(RequireAPIVersion(api.requireAPIToken(router)), requiredUserAgent)
+	timeoutRouter := http.TimeoutHandler(userAgentRouter, httpServerTimeout, string(jsonErr))
+	publicRouter := http.TimeoutHandler(RequireAPIVersion(api.buildPublicRoutes()), httpServerTimeout, string(jsonErr))
 	api.routerMu.Lock()
-	api.router = http.TimeoutHandler(RequireUserAgent(router, requiredUserAgent), httpServerTimeout, string(jsonErr))
+	api.publicRouter = publicRouter
+	api.router = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		// Event and consensus streams are long-lived WebSocket connections
+		// which can't be hijacked through the TimeoutHandler.
+		if req.URL.Path == "/daemon/events" || req.URL.Path == "/consensus/stream" {
+			userAgentRouter.ServeHTTP(w, req)
+			return
+		}
+		timeoutRouter.ServeHTTP(w, req)
+	})
 	api.routerMu.Unlock()
 	return
 }
@@ -172,7 +257,8 @@ func RequireUserAgent(h http.Handler, ua string) http.Handler {
 
 // RequirePassword is middleware that requires a request to authenticate with a
 // password using HTTP basic auth. Usernames are ignored. Empty passwords
-// indicate no authentication is required.
+// indicate no authentication is required. Instead of the password, an API
+// token carrying the scope of the call can be used.
 func RequirePassword(h httprouter.Handle, password string) httprouter.Handle {
 	// An empty password is equivalent to no password.
 	if password == "" {
@@ -180,16 +266,27 @@ func RequirePassword(h httprouter.Handle, password string) httprouter.Handle {
 	}
 	return func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		_, pass, ok := req.BasicAuth()
-		if !ok || pass != password {
-			w.Header().Set("WWW-Authenticate", "Basic realm=\"SiaAPI\"")
-			WriteError(w, Error{"API authentication failed."}, http.StatusUnauthorized)
+		if ok && pass == password {
+			h(w, req, ps)
+			return
+		}
+		// Requests authenticated with an API token need the scope of the
+		// call.
+		if token, ok := requestAPIToken(req); ok {
+			if !apiTokenAuthorized(token, req) {
+				WriteError(w, Error{errAPITokenScope.Error()}, http.StatusForbidden)
+				return
+			}
+			h(w, req, ps)
 			return
 		}
-		h(w, req, ps)
+		w.Header().Set("WWW-Authenticate", "Basic realm=\"SiaAPI\"")
+		WriteError(w, Error{"API authentication failed."}, http.StatusUnauthorized)
 	}
 }
 
-// isUnrestricted checks if a request may bypass the useragent check.
+// isUnrestricted checks if a request may bypass the useragent check. Metrics
+// are unrestricted since Prometheus can't set a custom useragent.
 func isUnrestricted(req *http.Request) bool {
-	return strings.HasPrefix(req.URL.Path, "/renter/stream/")
+	return strings.HasPrefix(req.URL.Path, "/renter/stream/") || req.URL.Path == "/metrics"
 }
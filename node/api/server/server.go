@@ -22,6 +22,7 @@ import (
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node"
 	"go.sia.tech/siad/node/api"
+	"go.sia.tech/siad/persist"
 	"go.sia.tech/siad/types"
 )
 
@@ -38,8 +39,29 @@ type Server struct {
 	serveChan chan struct{}
 	serveErr  error
 
+	// publicServer serves the read-only public API if it was enabled using
+	// ServePublicAPI. publicServeChan is closed once it stopped serving.
+	publicServer    *http.Server
+	publicListener  net.Listener
+	publicServeChan chan struct{}
+	publicServeErr  error
+
 	closeChan chan struct{}
 
+	// alertLog is the logger used by the alert routing thread.
+	// stopAlertRouting is closed to stop the thread and alertRoutingDone is
+	// closed once it returned.
+	alertLog         *persist.Logger
+	alertRoutingDone chan struct{}
+	stopAlertRouting chan struct{}
+
+	// eventLog is the logger used by the event publishing and routing
+	// threads. stopEvents is closed to stop the threads and eventsDone is
+	// closed once both returned.
+	eventLog   *persist.Logger
+	eventsDone chan struct{}
+	stopEvents chan struct{}
+
 	closeMu sync.Mutex
 }
 
@@ -67,7 +89,29 @@ func (srv *Server) Close() error {
 	if !errors.Contains(srv.serveErr, http.ErrServerClosed) {
 		err = errors.Compose(err, srv.serveErr)
 	}
-	// Shutdown modules.
+	// Stop accepting public API requests.
+	if srv.publicServer != nil {
+		err = errors.Compose(err, srv.publicServer.Shutdown(context.Background()))
+		<-srv.publicServeChan
+		if !errors.Contains(srv.publicServeErr, http.ErrServerClosed) {
+			err = errors.Compose(err, srv.publicServeErr)
+		}
+	}
+	// Stop routing alerts.
+	if srv.alertLog != nil {
+		close(srv.stopAlertRouting)
+		<-srv.alertRoutingDone
+		err = errors.Compose(err, srv.alertLog.Close())
+	}
+	// Stop publishing and routing events and end all event streams.
+	if srv.eventLog != nil {
+		close(srv.stopEvents)
+		<-srv.eventsDone
+		srv.api.EventBus().Close()
+		err = errors.Compose(err, srv.eventLog.Close())
+	}
+	// Shutdown modules. Quiesced writers need to be resumed first.
+	srv.api.ResumeWriters()
 	if srv.node != nil {
 		err = errors.Compose(err, srv.node.Close())
 	}
@@ -84,6 +128,58 @@ func (srv *Server) APIAddress() string {
 	return srv.listener.Addr().String()
 }
 
+// PublicAPIAddress returns the address of the public API or an empty string
+// if it isn't served.
+func (srv *Server) PublicAPIAddress() string {
+	srv.closeMu.Lock()
+	defer srv.closeMu.Unlock()
+	if srv.publicListener == nil {
+		return ""
+	}
+	return srv.publicListener.Addr().String()
+}
+
+// ServePublicAPI starts serving the unauthenticated, read-only public subset
+// of the API on the provided address. Every client IP may make up to
+// requestsPerMinute requests per minute. A limit of 0 disables rate limiting.
+func (srv *Server) ServePublicAPI(addr string, requestsPerMinute uint64) error {
+	srv.closeMu.Lock()
+	defer srv.closeMu.Unlock()
+	select {
+	case <-srv.serveChan:
+		return errors.New("server was already closed")
+	default:
+	}
+	if srv.publicServer != nil {
+		return errors.New("public API is already served")
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.AddContext(err, "failed to listen on public API address")
+	}
+	srv.publicListener = listener
+	srv.publicServer = &http.Server{
+		Handler: srv.api.PublicHandler(requestsPerMinute),
+
+		// The public API only serves small read-only requests. Use tighter
+		// timeouts than the regular API to not leak connections to slow
+		// clients.
+		ReadTimeout:       time.Minute,
+		ReadHeaderTimeout: 30 * time.Second,
+		IdleTimeout:       time.Minute,
+	}
+	srv.publicServeChan = make(chan struct{})
+	go func() {
+		err := srv.publicServer.Serve(listener)
+		if err != nil && strings.HasSuffix(err.Error(), "use of closed network connection") {
+			err = nil
+		}
+		srv.publicServeErr = err
+		close(srv.publicServeChan)
+	}()
+	return nil
+}
+
 // GatewayAddress returns the underlying node's gateway address
 func (srv *Server) GatewayAddress() modules.NetAddress {
 	return srv.node.Gateway.Address()
@@ -236,7 +332,48 @@ func NewAsync(APIaddr string, requiredUserAgent string, requiredPassword string,
 
 		// Server wasn't shut down. Add node and replace modules.
 		srv.node = n
+		api.SetDataDirs(n.Dir, n.Paths.ConsensusDir, n.Paths.HostDir, n.Paths.RenterDir, n.Paths.LogDir)
 		api.SetModules(n.Accounting, n.ConsensusSet, n.Explorer, n.Gateway, n.Host, n.Miner, n.Renter, n.TransactionPool, n.Wallet)
+
+		// Start routing alerts to the configured sinks.
+		if err := os.MkdirAll(n.Paths.LogDir, modules.DefaultDirPerm); err != nil {
+			return srv, errors.AddContext(err, "failed to create log directory")
+		}
+		alertLog, err := persist.NewFileLogger(filepath.Join(n.Paths.LogDir, node.AlertLogFile))
+		if err != nil {
+			return srv, errors.AddContext(err, "failed to create alert log")
+		}
+		srv.alertLog = alertLog
+		srv.alertRoutingDone = make(chan struct{})
+		srv.stopAlertRouting = make(chan struct{})
+		go func() {
+			api.ThreadedRouteAlerts(alertLog, srv.stopAlertRouting)
+			close(srv.alertRoutingDone)
+		}()
+
+		// Start publishing the events of the modules and routing them to the
+		// configured sinks.
+		eventLog, err := persist.NewFileLogger(filepath.Join(n.Paths.LogDir, node.EventLogFile))
+		if err != nil {
+			return srv, errors.AddContext(err, "failed to create event log")
+		}
+		srv.eventLog = eventLog
+		srv.eventsDone = make(chan struct{})
+		srv.stopEvents = make(chan struct{})
+		go func() {
+			var wg sync.WaitGroup
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				api.ThreadedPublishEvents(eventLog, srv.stopEvents)
+			}()
+			go func() {
+				defer wg.Done()
+				api.ThreadedRouteEvents(eventLog, srv.stopEvents)
+			}()
+			wg.Wait()
+			close(srv.eventsDone)
+		}()
 		return srv, nil
 	}()
 	if err != nil {
@@ -3,7 +3,10 @@ package api
 import (
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/julienschmidt/httprouter"
 
@@ -21,6 +24,12 @@ type (
 		Maximum types.Currency `json:"maximum"`
 	}
 
+	// TpoolFeeEstimateGET contains the fee estimates for confirming a
+	// transaction within different numbers of blocks.
+	TpoolFeeEstimateGET struct {
+		Estimates []modules.TransactionPoolFeeEstimate `json:"estimates"`
+	}
+
 	// TpoolRawGET contains the requested transaction encoded to the raw
 	// format, along with the id of that transaction.
 	TpoolRawGET struct {
@@ -29,6 +38,12 @@ type (
 		Transaction []byte              `json:"transaction"`
 	}
 
+	// TpoolEvictPOST contains the ids of the transactions which were evicted
+	// from the transaction pool.
+	TpoolEvictPOST struct {
+		Evicted []types.TransactionID `json:"evicted"`
+	}
+
 	// TpoolConfirmedGET contains information about whether or not
 	// the transaction has been seen on the blockhain
 	TpoolConfirmedGET struct {
@@ -41,18 +56,31 @@ type (
 	}
 )
 
+// defaultFeeEstimateTargets are the numbers of blocks /tpool/feeestimate
+// returns estimates for if no targets are specified.
+var defaultFeeEstimateTargets = []types.BlockHeight{1, 3, 6, 12}
+
 // RegisterRoutesTransactionPool is a helper function to register all
 // transaction pool routes.
-func RegisterRoutesTransactionPool(router *httprouter.Router, tpool modules.TransactionPool) {
+func RegisterRoutesTransactionPool(router *httprouter.Router, tpool modules.TransactionPool, requiredPassword string) {
 	router.GET("/tpool/fee", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		tpoolFeeHandlerGET(tpool, w, req, ps)
 	})
+	router.GET("/tpool/feeestimate", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		tpoolFeeEstimateHandlerGET(tpool, w, req, ps)
+	})
 	router.GET("/tpool/raw/:id", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		tpoolRawHandlerGET(tpool, w, req, ps)
 	})
 	router.POST("/tpool/raw", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		tpoolRawHandlerPOST(tpool, w, req, ps)
 	})
+	router.POST("/tpool/replace", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		tpoolReplaceHandlerPOST(tpool, w, req, ps)
+	}, requiredPassword))
+	router.POST("/tpool/evict/:id", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		tpoolEvictHandlerPOST(tpool, w, req, ps)
+	}, requiredPassword))
 	router.GET("/tpool/confirmed/:id", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		tpoolConfirmedGET(tpool, w, req, ps)
 	})
@@ -81,6 +109,28 @@ func tpoolFeeHandlerGET(tpool modules.TransactionPool, w http.ResponseWriter, _
 	})
 }
 
+// tpoolFeeEstimateHandlerGET returns the estimated fees for confirming a
+// transaction within the requested numbers of blocks.
+func tpoolFeeEstimateHandlerGET(tpool modules.TransactionPool, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	targets := defaultFeeEstimateTargets
+	if str := req.FormValue("targets"); str != "" {
+		targets = nil
+		for _, s := range strings.Split(str, ",") {
+			target, err := strconv.ParseUint(strings.TrimSpace(s), 10, 64)
+			if err != nil || target == 0 {
+				WriteError(w, Error{fmt.Sprintf("invalid target %q, targets need to be positive integers", s)}, http.StatusBadRequest)
+				return
+			}
+			targets = append(targets, types.BlockHeight(target))
+		}
+	}
+	var tfeg TpoolFeeEstimateGET
+	for _, target := range targets {
+		tfeg.Estimates = append(tfeg.Estimates, tpool.EstimateFee(target))
+	}
+	WriteJSON(w, tfeg)
+}
+
 // tpoolRawHandlerGET will provide the raw byte representation of a
 // transaction that matches the input id.
 func tpoolRawHandlerGET(tpool modules.TransactionPool, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
@@ -102,22 +152,18 @@ func tpoolRawHandlerGET(tpool modules.TransactionPool, w http.ResponseWriter, _
 	})
 }
 
-// tpoolRawHandlerPOST takes a raw encoded transaction set and posts
-// it to the transaction pool, relaying it to the transaction pool's peers
-// regardless of if the set is accepted.
-func tpoolRawHandlerPOST(tpool modules.TransactionPool, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+// parseTransactionSet parses the parents and transaction form values of a
+// request into a transaction set. JSON, base64, and raw binary are accepted.
+func parseTransactionSet(req *http.Request) ([]types.Transaction, error) {
 	var parents []types.Transaction
 	var txn types.Transaction
-
-	// JSON, base64, and raw binary are accepted
 	if err := json.Unmarshal([]byte(req.FormValue("parents")), &parents); err != nil {
 		rawParents, err := base64.StdEncoding.DecodeString(req.FormValue("parents"))
 		if err != nil {
 			rawParents = []byte(req.FormValue("parents"))
 		}
 		if err := encoding.Unmarshal(rawParents, &parents); err != nil {
-			WriteError(w, Error{"error decoding parents: " + err.Error()}, http.StatusBadRequest)
-			return
+			return nil, errors.AddContext(err, "error decoding parents")
 		}
 	}
 	if err := json.Unmarshal([]byte(req.FormValue("transaction")), &txn); err != nil {
@@ -126,15 +172,25 @@ func tpoolRawHandlerPOST(tpool modules.TransactionPool, w http.ResponseWriter, r
 			rawTransaction = []byte(req.FormValue("transaction"))
 		}
 		if err := encoding.Unmarshal(rawTransaction, &txn); err != nil {
-			WriteError(w, Error{"error decoding transaction: " + err.Error()}, http.StatusBadRequest)
-			return
+			return nil, errors.AddContext(err, "error decoding transaction")
 		}
 	}
+	return append(parents, txn), nil
+}
+
+// tpoolRawHandlerPOST takes a raw encoded transaction set and posts
+// it to the transaction pool, relaying it to the transaction pool's peers
+// regardless of if the set is accepted.
+func tpoolRawHandlerPOST(tpool modules.TransactionPool, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	txnSet, err := parseTransactionSet(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
 	// Broadcast the transaction set, so that they are passed to any peers that
 	// may have rejected them earlier.
-	txnSet := append(parents, txn)
 	tpool.Broadcast(txnSet)
-	err := tpool.AcceptTransactionSet(txnSet)
+	err = tpool.AcceptTransactionSet(txnSet)
 	if err != nil && !errors.Contains(err, modules.ErrDuplicateTransactionSet) {
 		WriteError(w, Error{"error accepting transaction set: " + err.Error()}, http.StatusBadRequest)
 		return
@@ -142,6 +198,39 @@ func tpoolRawHandlerPOST(tpool modules.TransactionPool, w http.ResponseWriter, r
 	WriteSuccess(w)
 }
 
+// tpoolReplaceHandlerPOST takes a raw encoded transaction set and replaces the
+// sets in the transaction pool which conflict with it if it pays higher fees.
+func tpoolReplaceHandlerPOST(tpool modules.TransactionPool, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	txnSet, err := parseTransactionSet(req)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := tpool.ReplaceTransactionSet(txnSet); err != nil {
+		WriteError(w, Error{"error replacing transaction set: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// tpoolEvictHandlerPOST removes the transaction set containing the specified
+// transaction from the transaction pool.
+func tpoolEvictHandlerPOST(tpool modules.TransactionPool, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	txid, err := decodeTransactionID(ps.ByName("id"))
+	if err != nil {
+		WriteError(w, Error{"error decoding transaction id: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	evicted, err := tpool.EvictTransactionSet(txid)
+	if err != nil {
+		WriteError(w, Error{"error evicting transaction set: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, TpoolEvictPOST{
+		Evicted: evicted,
+	})
+}
+
 // tpoolConfirmedGET returns whether the specified transaction has
 // been seen on the blockchain.
 func tpoolConfirmedGET(tpool modules.TransactionPool, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
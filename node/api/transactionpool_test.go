@@ -205,6 +205,47 @@ func TestTransactionPoolFee(t *testing.T) {
 	}
 }
 
+// TestTransactionPoolFeeEstimate tests the /tpool/feeestimate endpoint.
+func TestTransactionPoolFeeEstimate(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	// Without targets, the default targets are used.
+	var tfeg TpoolFeeEstimateGET
+	if err := st.getAPI("/tpool/feeestimate", &tfeg); err != nil {
+		t.Fatal(err)
+	}
+	if len(tfeg.Estimates) != len(defaultFeeEstimateTargets) {
+		t.Fatal("wrong number of estimates", len(tfeg.Estimates))
+	}
+	for i, estimate := range tfeg.Estimates {
+		expected := st.tpool.EstimateFee(defaultFeeEstimateTargets[i])
+		if estimate.TargetBlocks != expected.TargetBlocks || !estimate.FeePerByte.Equals(expected.FeePerByte) {
+			t.Fatal("estimate mismatch", estimate)
+		}
+	}
+
+	// Request specific targets.
+	if err := st.getAPI("/tpool/feeestimate?targets=2,10", &tfeg); err != nil {
+		t.Fatal(err)
+	}
+	if len(tfeg.Estimates) != 2 || tfeg.Estimates[0].TargetBlocks != 2 || tfeg.Estimates[1].TargetBlocks != 10 {
+		t.Fatal("wrong estimates", tfeg.Estimates)
+	}
+
+	// Invalid targets are rejected.
+	if err := st.getAPI("/tpool/feeestimate?targets=0", &tfeg); err == nil {
+		t.Fatal("expected invalid target to be rejected")
+	}
+}
+
 // TestTransactionPoolConfirmed tests the /tpool/confirmed endpoint.
 func TestTransactionPoolConfirmed(t *testing.T) {
 	if testing.Short() {
@@ -256,3 +297,42 @@ func TestTransactionPoolConfirmed(t *testing.T) {
 		t.Fatal("transaction should not be confirmed")
 	}
 }
+
+// TestTransactionPoolEvict tests the /tpool/evict endpoint.
+func TestTransactionPoolEvict(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	st, err := createServerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer st.server.panicClose()
+
+	// Create a transaction.
+	txns, err := st.wallet.SendSiacoins(types.SiacoinPrecision.Mul64(1000), types.UnlockHash{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	txnID := txns[len(txns)-1].ID()
+
+	// Evict it from the pool.
+	var tep TpoolEvictPOST
+	err = st.postAPI("/tpool/evict/"+txnID.String(), url.Values{}, &tep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tep.Evicted) != len(txns) {
+		t.Fatal("wrong number of evicted transactions", len(tep.Evicted))
+	}
+	if _, _, exists := st.tpool.Transaction(txnID); exists {
+		t.Fatal("transaction should have been evicted")
+	}
+
+	// Evicting it again fails.
+	err = st.postAPI("/tpool/evict/"+txnID.String(), url.Values{}, &tep)
+	if err == nil || !strings.Contains(err.Error(), "transaction not found in transaction pool") {
+		t.Fatal("expected eviction of unknown transaction to fail", err)
+	}
+}
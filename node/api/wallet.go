@@ -30,12 +30,41 @@ type (
 		UnconfirmedOutgoingSiacoins types.Currency `json:"unconfirmedoutgoingsiacoins"`
 		UnconfirmedIncomingSiacoins types.Currency `json:"unconfirmedincomingsiacoins"`
 
+		UnconfirmedTransactions []modules.UnconfirmedTransactionBalance `json:"unconfirmedtransactions"`
+
 		SiacoinClaimBalance types.Currency `json:"siacoinclaimbalance"`
 		SiafundBalance      types.Currency `json:"siafundbalance"`
 
 		DustThreshold types.Currency `json:"dustthreshold"`
 	}
 
+	// WalletLockedFundsGET contains the wallet's balance together with the
+	// renter funds locked in unexpired file contracts returned by a GET call
+	// to /wallet/lockedfunds. SpendableSiacoins is the confirmed balance minus
+	// the unconfirmed outgoing siacoins and TotalSiacoins the confirmed
+	// balance plus the locked funds.
+	WalletLockedFundsGET struct {
+		ConfirmedSiacoinBalance     types.Currency `json:"confirmedsiacoinbalance"`
+		UnconfirmedOutgoingSiacoins types.Currency `json:"unconfirmedoutgoingsiacoins"`
+		SpendableSiacoins           types.Currency `json:"spendablesiacoins"`
+		TotalSiacoins               types.Currency `json:"totalsiacoins"`
+
+		LockedFunds modules.ContractorLockedFunds `json:"lockedfunds"`
+	}
+
+	// WalletAccountsGET contains the accounts of the wallet returned by a GET
+	// call to /wallet/accounts.
+	WalletAccountsGET struct {
+		Accounts []modules.WalletAccount `json:"accounts"`
+	}
+
+	// WalletAccountsPOST contains the seed of an account created by a POST
+	// call to /wallet/accounts. The seed is only set if it was generated by
+	// the wallet.
+	WalletAccountsPOST struct {
+		Seed string `json:"seed,omitempty"`
+	}
+
 	// WalletAddressGET contains an address returned by a GET call to
 	// /wallet/address.
 	WalletAddressGET struct {
@@ -68,6 +97,12 @@ type (
 		TransactionIDs []types.TransactionID `json:"transactionids"`
 	}
 
+	// WalletExternalAddressesGET contains the addresses of the external
+	// signer which are tracked by the wallet.
+	WalletExternalAddressesGET struct {
+		Addresses []modules.ExternalAddress `json:"addresses"`
+	}
+
 	// WalletSignPOSTParams contains the unsigned transaction and a set of
 	// inputs to sign.
 	WalletSignPOSTParams struct {
@@ -80,6 +115,53 @@ type (
 		Transaction types.Transaction `json:"transaction"`
 	}
 
+	// WalletPSSTCreatePOSTParams contains the unsigned transaction a
+	// partially signed transaction is created for.
+	WalletPSSTCreatePOSTParams struct {
+		Transaction types.Transaction `json:"transaction"`
+	}
+
+	// WalletPSSTPOSTParams contains a partially signed transaction.
+	WalletPSSTPOSTParams struct {
+		PSST modules.PartiallySignedTransaction `json:"psst"`
+	}
+
+	// WalletPSSTSigHashPOSTParams contains a partially signed transaction and
+	// the public key of an input the sighash is requested for.
+	WalletPSSTSigHashPOSTParams struct {
+		PSST           modules.PartiallySignedTransaction `json:"psst"`
+		ParentID       crypto.Hash                        `json:"parentid"`
+		PublicKeyIndex uint64                             `json:"publickeyindex"`
+	}
+
+	// WalletPSSTSigHashPOSTResp contains the hash a public key needs to sign
+	// to add its signature to a partially signed transaction.
+	WalletPSSTSigHashPOSTResp struct {
+		SigHash crypto.Hash `json:"sighash"`
+	}
+
+	// WalletPSSTAddSignaturePOSTParams contains a partially signed
+	// transaction and a signature which is added to one of its inputs.
+	WalletPSSTAddSignaturePOSTParams struct {
+		PSST           modules.PartiallySignedTransaction `json:"psst"`
+		ParentID       crypto.Hash                        `json:"parentid"`
+		PublicKeyIndex uint64                             `json:"publickeyindex"`
+		Signature      []byte                             `json:"signature"`
+	}
+
+	// WalletPSSTPOSTResp contains a partially signed transaction and whether
+	// all of its inputs are sufficiently signed.
+	WalletPSSTPOSTResp struct {
+		PSST     modules.PartiallySignedTransaction `json:"psst"`
+		Complete bool                               `json:"complete"`
+	}
+
+	// WalletPSSTFinalizePOSTResp contains the signed transaction of a
+	// finalized partially signed transaction.
+	WalletPSSTFinalizePOSTResp struct {
+		Transaction types.Transaction `json:"transaction"`
+	}
+
 	// WalletSeedsGET contains the seeds used by the wallet.
 	WalletSeedsGET struct {
 		PrimarySeed        string   `json:"primaryseed"`
@@ -168,6 +250,18 @@ func RegisterRoutesWallet(router *httprouter.Router, wallet modules.Wallet, requ
 	router.POST("/wallet/033x", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		wallet033xHandler(wallet, w, req, ps)
 	}, requiredPassword))
+	router.GET("/wallet/accounts", func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletAccountsHandlerGET(wallet, w, req, ps)
+	})
+	router.POST("/wallet/accounts", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletAccountsHandlerPOST(wallet, w, req, ps)
+	}, requiredPassword))
+	router.GET("/wallet/accounts/:name/address", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletAccountAddressHandler(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/accounts/:name/siacoins", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletAccountSiacoinsHandler(wallet, w, req, ps)
+	}, requiredPassword))
 	router.GET("/wallet/address", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletAddressHandler(wallet, w, req, ps)
 	}, requiredPassword))
@@ -240,6 +334,30 @@ func RegisterRoutesWallet(router *httprouter.Router, wallet modules.Wallet, requ
 	router.POST("/wallet/sign", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletSignHandler(wallet, w, req, ps)
 	}, requiredPassword))
+	router.POST("/wallet/externalsign", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletExternalSignHandler(wallet, w, req, ps)
+	}, requiredPassword))
+	router.GET("/wallet/externalsign/addresses", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletExternalAddressesHandlerGET(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/externalsign/addresses", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletExternalAddressesHandlerPOST(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/psst/create", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletPSSTCreateHandler(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/psst/sign", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletPSSTSignHandler(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/psst/sighash", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletPSSTSigHashHandler(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/psst/addsignature", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletPSSTAddSignatureHandler(wallet, w, req, ps)
+	}, requiredPassword))
+	router.POST("/wallet/psst/finalize", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		walletPSSTFinalizeHandler(wallet, w, req, ps)
+	}, requiredPassword))
 	router.GET("/wallet/watch", RequirePassword(func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 		walletWatchHandlerGET(wallet, w, req, ps)
 	}, requiredPassword))
@@ -264,6 +382,38 @@ func encryptionKeys(seedStr string) (validKeys []crypto.CipherKey, seeds []modul
 	return
 }
 
+// walletLockedFundsHandlerGET handles API calls to /wallet/lockedfunds,
+// returning the wallet's balance together with the renter funds locked in
+// file contracts and when they are released.
+func (api *API) walletLockedFundsHandlerGET(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	siacoinBal, _, _, err := api.wallet.ConfirmedBalance()
+	if err != nil {
+		WriteError(w, Error{"unable to get confirmed balance: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	siacoinsOut, _, err := api.wallet.UnconfirmedBalance()
+	if err != nil {
+		WriteError(w, Error{"unable to get unconfirmed balance: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	lf, err := api.renter.LockedFunds()
+	if err != nil {
+		WriteError(w, Error{"unable to get locked funds: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var spendable types.Currency
+	if siacoinBal.Cmp(siacoinsOut) > 0 {
+		spendable = siacoinBal.Sub(siacoinsOut)
+	}
+	WriteJSON(w, WalletLockedFundsGET{
+		ConfirmedSiacoinBalance:     siacoinBal,
+		UnconfirmedOutgoingSiacoins: siacoinsOut,
+		SpendableSiacoins:           spendable,
+		TotalSiacoins:               siacoinBal.Add(lf.Total),
+		LockedFunds:                 lf,
+	})
+}
+
 // walletHander handles API calls to /wallet.
 func walletHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	siacoinBal, siafundBal, siaclaimBal, err := wallet.ConfirmedBalance()
@@ -276,6 +426,11 @@ func walletHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request
 		WriteError(w, Error{fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
 		return
 	}
+	unconfirmedTxns, err := wallet.UnconfirmedBalanceBreakdown()
+	if err != nil {
+		WriteError(w, Error{fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
+		return
+	}
 	dustThreshold, err := wallet.DustThreshold()
 	if err != nil {
 		WriteError(w, Error{fmt.Sprintf("Error when calling /wallet: %v", err)}, http.StatusBadRequest)
@@ -311,6 +466,8 @@ func walletHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request
 		UnconfirmedOutgoingSiacoins: siacoinsOut,
 		UnconfirmedIncomingSiacoins: siacoinsIn,
 
+		UnconfirmedTransactions: unconfirmedTxns,
+
 		SiafundBalance:      siafundBal,
 		SiacoinClaimBalance: siaclaimBal,
 
@@ -341,6 +498,119 @@ func wallet033xHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.R
 	WriteError(w, Error{modules.ErrBadEncryptionKey.Error()}, http.StatusBadRequest)
 }
 
+// walletAccountsHandlerGET handles GET calls to /wallet/accounts.
+func walletAccountsHandlerGET(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	accounts, err := wallet.Accounts()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/accounts: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletAccountsGET{
+		Accounts: accounts,
+	})
+}
+
+// walletAccountsHandlerPOST handles POST calls to /wallet/accounts. A new seed
+// is generated for the account unless an existing seed is provided.
+func walletAccountsHandlerPOST(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	name := req.FormValue("name")
+	dictID := mnemonics.DictionaryID(req.FormValue("dictionary"))
+	if dictID == "" {
+		dictID = "english"
+	}
+	var seed modules.Seed
+	loadSeed := req.FormValue("seed") != ""
+	if loadSeed {
+		var err error
+		seed, err = modules.StringToSeed(req.FormValue("seed"), dictID)
+		if err != nil {
+			WriteError(w, Error{"error when calling /wallet/accounts: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	potentialKeys, _ := encryptionKeys(req.FormValue("encryptionpassword"))
+	for _, key := range potentialKeys {
+		var err error
+		if loadSeed {
+			err = wallet.LoadAccount(key, name, seed)
+		} else {
+			seed, err = wallet.CreateAccount(key, name)
+		}
+		if errors.Contains(err, modules.ErrBadEncryptionKey) {
+			continue
+		}
+		if err != nil {
+			WriteError(w, Error{"error when calling /wallet/accounts: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if loadSeed {
+			WriteJSON(w, WalletAccountsPOST{})
+			return
+		}
+		seedStr, err := modules.SeedToString(seed, dictID)
+		if err != nil {
+			WriteError(w, Error{"error when calling /wallet/accounts: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		WriteJSON(w, WalletAccountsPOST{
+			Seed: seedStr,
+		})
+		return
+	}
+	WriteError(w, Error{"error when calling /wallet/accounts: " + modules.ErrBadEncryptionKey.Error()}, http.StatusBadRequest)
+}
+
+// walletAccountAddressHandler handles API calls to
+// /wallet/accounts/:name/address.
+func walletAccountAddressHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, ps httprouter.Params) {
+	uc, err := wallet.AccountAddress(ps.ByName("name"))
+	if errors.Contains(err, modules.ErrUnknownWalletAccount) {
+		WriteError(w, Error{"error when calling /wallet/accounts/:name/address: " + err.Error()}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/accounts/:name/address: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletAddressGET{
+		Address: uc.UnlockHash(),
+	})
+}
+
+// walletAccountSiacoinsHandler handles API calls to
+// /wallet/accounts/:name/siacoins.
+func walletAccountSiacoinsHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	amount, ok := scanAmount(req.FormValue("amount"))
+	if !ok {
+		WriteError(w, Error{"could not read 'amount' from POST call to /wallet/accounts/:name/siacoins"}, http.StatusBadRequest)
+		return
+	}
+	dest, err := scanAddress(req.FormValue("destination"))
+	if err != nil {
+		WriteError(w, Error{"could not read 'destination' from POST call to /wallet/accounts/:name/siacoins"}, http.StatusBadRequest)
+		return
+	}
+
+	txns, err := wallet.AccountSendSiacoins(ps.ByName("name"), amount, dest)
+	if errors.Contains(err, modules.ErrUnknownWalletAccount) {
+		WriteError(w, Error{"error when calling /wallet/accounts/:name/siacoins: " + err.Error()}, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/accounts/:name/siacoins: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	var txids []types.TransactionID
+	for _, txn := range txns {
+		txids = append(txids, txn.ID())
+	}
+	WriteJSON(w, WalletSiacoinsPOST{
+		Transactions:   txns,
+		TransactionIDs: txids,
+	})
+}
+
 // walletAddressHandler handles API calls to /wallet/address.
 func walletAddressHandler(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	unlockConditions, err := wallet.NextAddress()
@@ -927,6 +1197,183 @@ func walletSignHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.R
 	})
 }
 
+// walletExternalSignHandler handles API calls to /wallet/externalsign.
+func walletExternalSignHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletSignPOSTParams
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = wallet.ExternalSignTransaction(&params.Transaction, params.ToSign)
+	if err != nil {
+		WriteError(w, Error{"failed to sign transaction: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletSignPOSTResp{
+		Transaction: params.Transaction,
+	})
+}
+
+// walletExternalAddressesHandlerGET handles GET calls to
+// /wallet/externalsign/addresses.
+func walletExternalAddressesHandlerGET(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	addrs, err := wallet.ExternalAddresses()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/externalsign/addresses: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletExternalAddressesGET{
+		Addresses: addrs,
+	})
+}
+
+// walletExternalAddressesHandlerPOST handles POST calls to
+// /wallet/externalsign/addresses.
+func walletExternalAddressesHandlerPOST(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	keyIndex, err := strconv.ParseUint(req.FormValue("keyindex"), 10, 64)
+	if err != nil {
+		WriteError(w, Error{"unable to parse keyindex: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var verify, unused bool
+	if v := req.FormValue("verify"); v != "" {
+		verify, err = strconv.ParseBool(v)
+		if err != nil {
+			WriteError(w, Error{"unable to parse verify: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if u := req.FormValue("unused"); u != "" {
+		unused, err = strconv.ParseBool(u)
+		if err != nil {
+			WriteError(w, Error{"unable to parse unused: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	ea, err := wallet.AddExternalAddress(keyIndex, verify, unused)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/externalsign/addresses: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, ea)
+}
+
+// walletPSSTCreateHandler handles API calls to /wallet/psst/create.
+func walletPSSTCreateHandler(_ modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletPSSTCreatePOSTParams
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	psst, err := modules.NewPartiallySignedTransaction(params.Transaction)
+	if err != nil {
+		WriteError(w, Error{"failed to create partially signed transaction: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletPSSTPOSTResp{
+		PSST:     psst,
+		Complete: psst.Complete(),
+	})
+}
+
+// walletPSSTSignHandler handles API calls to /wallet/psst/sign.
+func walletPSSTSignHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletPSSTPOSTParams
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	err = wallet.SignPartiallySignedTransaction(&params.PSST)
+	if err != nil {
+		WriteError(w, Error{"failed to sign partially signed transaction: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletPSSTPOSTResp{
+		PSST:     params.PSST,
+		Complete: params.PSST.Complete(),
+	})
+}
+
+// walletPSSTSigHashHandler handles API calls to /wallet/psst/sighash.
+func walletPSSTSigHashHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletPSSTSigHashPOSTParams
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	height, err := wallet.Height()
+	if err != nil {
+		WriteError(w, Error{"failed to get wallet height: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	sigHash, err := params.PSST.SigHash(params.ParentID, params.PublicKeyIndex, height)
+	if err != nil {
+		WriteError(w, Error{"failed to compute sighash: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletPSSTSigHashPOSTResp{
+		SigHash: sigHash,
+	})
+}
+
+// walletPSSTAddSignatureHandler handles API calls to
+// /wallet/psst/addsignature.
+func walletPSSTAddSignatureHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletPSSTAddSignaturePOSTParams
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var sig crypto.Signature
+	if len(params.Signature) != len(sig) {
+		WriteError(w, Error{fmt.Sprintf("invalid signature length %v, expected %v", len(params.Signature), len(sig))}, http.StatusBadRequest)
+		return
+	}
+	copy(sig[:], params.Signature)
+	height, err := wallet.Height()
+	if err != nil {
+		WriteError(w, Error{"failed to get wallet height: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	err = params.PSST.AddSignature(params.ParentID, params.PublicKeyIndex, sig, height)
+	if err != nil {
+		WriteError(w, Error{"failed to add signature: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletPSSTPOSTResp{
+		PSST:     params.PSST,
+		Complete: params.PSST.Complete(),
+	})
+}
+
+// walletPSSTFinalizeHandler handles API calls to /wallet/psst/finalize.
+func walletPSSTFinalizeHandler(wallet modules.Wallet, w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletPSSTPOSTParams
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	height, err := wallet.Height()
+	if err != nil {
+		WriteError(w, Error{"failed to get wallet height: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	txn, err := params.PSST.Finalize(height)
+	if err != nil {
+		WriteError(w, Error{"failed to finalize partially signed transaction: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletPSSTFinalizePOSTResp{
+		Transaction: txn,
+	})
+}
+
 // walletWatchHandlerGET handles GET calls to /wallet/watch.
 func walletWatchHandlerGET(wallet modules.Wallet, w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	addrs, err := wallet.WatchAddresses()
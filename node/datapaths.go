@@ -0,0 +1,255 @@
+package node
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/modules"
+)
+
+const (
+	// AlertLogFile is the name of the log file alerts are routed to.
+	AlertLogFile = "alerts.log"
+
+	// EventLogFile is the name of the log file events are routed to.
+	EventLogFile = "events.log"
+)
+
+var (
+	// ErrDataPathsNeedMigration is returned when a custom data path is
+	// configured while the data is still stored in the default location.
+	ErrDataPathsNeedMigration = errors.New("data needs to be migrated to the configured paths first, run 'siad migrate-datadir' with the same flags")
+
+	// logFiles are the daemon logs which are stored in the log directory.
+	logFiles = []string{AlertLogFile, EventLogFile}
+)
+
+type (
+	// DataPaths allows for storing the data of some modules outside of the
+	// node's directory. Empty paths default to the corresponding location
+	// within the node's directory.
+	DataPaths struct {
+		// ConsensusDir is the directory of the consensus database.
+		ConsensusDir string
+		// HostDir is the directory of the host's metadata, including the
+		// metadata of its storage folders.
+		HostDir string
+		// RenterDir is the directory of the renter's metadata, including its
+		// contracts and the siafile and siadir metadata.
+		RenterDir string
+		// LogDir is the directory of the daemon logs. The logs of the modules
+		// are stored with their data.
+		LogDir string
+	}
+
+	// DataPathMove describes moving a file or directory from its default
+	// location to a configured data path.
+	DataPathMove struct {
+		Name string
+		From string
+		To   string
+	}
+)
+
+// ResolveDataPaths returns the absolute data paths of a node with the
+// provided directory. Empty paths are replaced with their defaults.
+func ResolveDataPaths(dir string, paths DataPaths) (DataPaths, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return DataPaths{}, err
+	}
+	resolve := func(path, def string) (string, error) {
+		if path == "" {
+			return def, nil
+		}
+		return filepath.Abs(path)
+	}
+	var err1, err2, err3, err4 error
+	paths.ConsensusDir, err1 = resolve(paths.ConsensusDir, filepath.Join(dir, modules.ConsensusDir))
+	paths.HostDir, err2 = resolve(paths.HostDir, filepath.Join(dir, modules.HostDir))
+	paths.RenterDir, err3 = resolve(paths.RenterDir, filepath.Join(dir, modules.RenterDir))
+	paths.LogDir, err4 = resolve(paths.LogDir, dir)
+	if err := errors.Compose(err1, err2, err3, err4); err != nil {
+		return DataPaths{}, err
+	}
+	return paths, nil
+}
+
+// PlanDataPathMigration returns the moves which are necessary to migrate the
+// data of a node from the default locations within dir to the configured
+// paths. It returns an error if data exists in both locations.
+func PlanDataPathMigration(dir string, paths DataPaths) ([]DataPathMove, error) {
+	defaults, err := ResolveDataPaths(dir, DataPaths{})
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := ResolveDataPaths(dir, paths)
+	if err != nil {
+		return nil, err
+	}
+	candidates := []DataPathMove{
+		{Name: "consensus", From: defaults.ConsensusDir, To: resolved.ConsensusDir},
+		{Name: "host", From: defaults.HostDir, To: resolved.HostDir},
+		{Name: "renter", From: defaults.RenterDir, To: resolved.RenterDir},
+	}
+	for _, f := range logFiles {
+		candidates = append(candidates, DataPathMove{
+			Name: f,
+			From: filepath.Join(defaults.LogDir, f),
+			To:   filepath.Join(resolved.LogDir, f),
+		})
+	}
+	var moves []DataPathMove
+	for _, m := range candidates {
+		if m.From == m.To {
+			continue
+		}
+		fromEmpty, err := isEmptyPath(m.From)
+		if err != nil {
+			return nil, errors.AddContext(err, "failed to check "+m.From)
+		}
+		if fromEmpty {
+			continue
+		}
+		toEmpty, err := isEmptyPath(m.To)
+		if err != nil {
+			return nil, errors.AddContext(err, "failed to check "+m.To)
+		}
+		if !toEmpty {
+			return nil, fmt.Errorf("%v data exists in both %v and %v", m.Name, m.From, m.To)
+		}
+		moves = append(moves, m)
+	}
+	return moves, nil
+}
+
+// MigrateDataPath performs a single move of a migration. The data is renamed
+// if possible. Otherwise, e.g. if the destination is on a different
+// filesystem, it is copied to a temporary location next to the destination
+// first and only removed from its old location once the copy is complete.
+func MigrateDataPath(m DataPathMove) error {
+	toEmpty, err := isEmptyPath(m.To)
+	if err != nil {
+		return err
+	}
+	if !toEmpty {
+		return fmt.Errorf("destination %v is not empty", m.To)
+	}
+	if err := os.MkdirAll(filepath.Dir(m.To), modules.DefaultDirPerm); err != nil {
+		return errors.AddContext(err, "failed to create parent of destination")
+	}
+	// Remove the empty destination to be able to rename the source.
+	if err := os.Remove(m.To); err != nil && !os.IsNotExist(err) {
+		return errors.AddContext(err, "failed to remove empty destination")
+	}
+	if err := os.Rename(m.From, m.To); err == nil {
+		return nil
+	}
+
+	// Fall back to copying the data.
+	tmp := m.To + ".migrating"
+	if err := os.RemoveAll(tmp); err != nil {
+		return errors.AddContext(err, "failed to remove leftovers of previous migration")
+	}
+	if err := copyPath(m.From, tmp); err != nil {
+		return errors.Compose(errors.AddContext(err, "failed to copy data"), os.RemoveAll(tmp))
+	}
+	if err := os.Rename(tmp, m.To); err != nil {
+		return errors.Compose(errors.AddContext(err, "failed to move copied data into place"), os.RemoveAll(tmp))
+	}
+	return errors.AddContext(os.RemoveAll(m.From), "failed to remove data from old location")
+}
+
+// checkDataPaths returns ErrDataPathsNeedMigration if any data of the node is
+// still stored in its default location while a different path is configured.
+func checkDataPaths(dir string, paths DataPaths) error {
+	moves, err := PlanDataPathMigration(dir, paths)
+	if err != nil {
+		return err
+	}
+	if len(moves) > 0 {
+		return errors.AddContext(ErrDataPathsNeedMigration, fmt.Sprintf("found %v data in %v", moves[0].Name, moves[0].From))
+	}
+	return nil
+}
+
+// isEmptyPath returns true if the path doesn't exist or is an empty
+// directory.
+func isEmptyPath(path string) (bool, error) {
+	fi, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+	if !fi.IsDir() {
+		return false, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	_, err = f.Readdirnames(1)
+	if err == io.EOF {
+		return true, nil
+	}
+	return false, err
+}
+
+// copyPath recursively copies a file or directory and syncs the copied files
+// to disk.
+func copyPath(from, to string) error {
+	fi, err := os.Lstat(from)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return copyFile(from, to, fi.Mode())
+	}
+	if err := os.Mkdir(to, fi.Mode().Perm()); err != nil {
+		return err
+	}
+	fis, err := ioutil.ReadDir(from)
+	if err != nil {
+		return err
+	}
+	for _, fi := range fis {
+		if err := copyPath(filepath.Join(from, fi.Name()), filepath.Join(to, fi.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyFile copies a single file and syncs it to disk.
+func copyFile(from, to string, mode os.FileMode) (err error) {
+	if !mode.IsRegular() {
+		return fmt.Errorf("can't copy %v since it is not a regular file", from)
+	}
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, src.Close())
+	}()
+	dst, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode.Perm())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Compose(err, dst.Close())
+	}()
+	if _, err := io.Copy(dst, src); err != nil {
+		return err
+	}
+	return dst.Sync()
+}
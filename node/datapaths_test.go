@@ -0,0 +1,221 @@
+package node
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+
+	"go.sia.tech/siad/build"
+	"go.sia.tech/siad/modules"
+)
+
+// TestResolveDataPaths is a unit test for ResolveDataPaths.
+func TestResolveDataPaths(t *testing.T) {
+	dir := build.TempDir("node", t.Name())
+	custom := filepath.Join(dir, "custom")
+
+	// Empty paths default to the node's directory.
+	paths, err := ResolveDataPaths(dir, DataPaths{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := DataPaths{
+		ConsensusDir: filepath.Join(dir, modules.ConsensusDir),
+		HostDir:      filepath.Join(dir, modules.HostDir),
+		RenterDir:    filepath.Join(dir, modules.RenterDir),
+		LogDir:       dir,
+	}
+	if paths != expected {
+		t.Fatal("unexpected paths", paths)
+	}
+
+	// Custom paths are used as they are.
+	paths, err = ResolveDataPaths(dir, DataPaths{RenterDir: custom, LogDir: custom})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected.RenterDir = custom
+	expected.LogDir = custom
+	if paths != expected {
+		t.Fatal("unexpected paths", paths)
+	}
+}
+
+// TestMigrateDataPaths tests planning and performing a migration of a node's
+// data to custom paths.
+func TestMigrateDataPaths(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	dir := build.TempDir("node", t.Name())
+	custom := build.TempDir("node", t.Name()+"-custom")
+	if err := os.MkdirAll(custom, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+
+	// Create some fake data in the default locations.
+	renterFile := filepath.Join(dir, modules.RenterDir, "contracts", "contract")
+	if err := os.MkdirAll(filepath.Dir(renterFile), modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(renterFile, []byte("renter"), modules.DefaultFilePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, AlertLogFile), []byte("alerts"), modules.DefaultFilePerm); err != nil {
+		t.Fatal(err)
+	}
+	// The consensus dir is empty and doesn't need to be migrated.
+	if err := os.MkdirAll(filepath.Join(dir, modules.ConsensusDir), modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without custom paths nothing needs to be migrated.
+	if err := checkDataPaths(dir, DataPaths{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// With custom paths the renter data and the alert log need to be moved.
+	paths := DataPaths{
+		ConsensusDir: filepath.Join(custom, "consensus"),
+		HostDir:      filepath.Join(custom, "host"),
+		RenterDir:    filepath.Join(custom, "renter"),
+		LogDir:       filepath.Join(custom, "logs"),
+	}
+	if err := checkDataPaths(dir, paths); !errors.Contains(err, ErrDataPathsNeedMigration) {
+		t.Fatal("expected migration to be necessary", err)
+	}
+	moves, err := PlanDataPathMigration(dir, paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(moves) != 2 || moves[0].Name != "renter" || moves[1].Name != AlertLogFile {
+		t.Fatal("unexpected moves", moves)
+	}
+
+	// If there is data in both locations, the migration can't be planned.
+	if err := os.MkdirAll(paths.RenterDir, modules.DefaultDirPerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(paths.RenterDir, "file"), nil, modules.DefaultFilePerm); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := PlanDataPathMigration(dir, paths); err == nil {
+		t.Fatal("expected error")
+	}
+	if err := MigrateDataPath(moves[0]); err == nil {
+		t.Fatal("moving data into non-empty directory should fail")
+	}
+	if err := os.Remove(filepath.Join(paths.RenterDir, "file")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Perform the migration.
+	for _, m := range moves {
+		if err := MigrateDataPath(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := checkDataPaths(dir, paths); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(paths.RenterDir, "contracts", "contract"))
+	if err != nil || string(data) != "renter" {
+		t.Fatal("renter data wasn't moved", string(data), err)
+	}
+	data, err = ioutil.ReadFile(filepath.Join(paths.LogDir, AlertLogFile))
+	if err != nil || string(data) != "alerts" {
+		t.Fatal("alert log wasn't moved", string(data), err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, modules.RenterDir)); !os.IsNotExist(err) {
+		t.Fatal("renter data wasn't removed from the old location", err)
+	}
+}
+
+// TestCopyPath tests the copy used to migrate data across filesystems.
+func TestCopyPath(t *testing.T) {
+	dir := build.TempDir("node", t.Name())
+	from := filepath.Join(dir, "from")
+	to := filepath.Join(dir, "to")
+	if err := os.MkdirAll(filepath.Join(from, "sub"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(from, "sub", "file"), []byte("data"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := copyPath(from, to); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(to, "sub", "file"))
+	if err != nil || string(data) != "data" {
+		t.Fatal("file wasn't copied", string(data), err)
+	}
+	fi, err := os.Stat(filepath.Join(to, "sub", "file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Fatal("wrong mode", fi.Mode())
+	}
+	// Copying to an existing destination fails.
+	if err := copyPath(from, to); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+// TestNewDataPaths tests that a node refuses to start with custom data paths
+// until its existing data was migrated.
+func TestNewDataPaths(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	dir := build.TempDir("node", t.Name())
+	custom := build.TempDir("node", t.Name()+"-consensus")
+	params := Gateway(dir)
+	params.CreateConsensusSet = true
+
+	// Start and stop a node to create the consensus data.
+	n, errChan := New(params, time.Now())
+	if err := <-errChan; err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Starting the node with a custom consensus dir should fail.
+	params.Paths.ConsensusDir = custom
+	_, errChan = New(params, time.Now())
+	if err := <-errChan; !errors.Contains(err, ErrDataPathsNeedMigration) {
+		t.Fatal("expected ErrDataPathsNeedMigration, got", err)
+	}
+
+	// Migrate the data and try again.
+	moves, err := PlanDataPathMigration(dir, params.Paths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range moves {
+		if err := MigrateDataPath(m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	n, errChan = New(params, time.Now())
+	if err := <-errChan; err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := n.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if n.Paths.ConsensusDir != custom {
+		t.Fatal("wrong consensus dir", n.Paths.ConsensusDir)
+	}
+	if empty, err := isEmptyPath(custom); err != nil || empty {
+		t.Fatal("consensus data wasn't migrated", err)
+	}
+}
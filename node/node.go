@@ -119,6 +119,9 @@ type NodeParams struct {
 	// The high level directory where all the persistence gets stored for the
 	// modules.
 	Dir string
+
+	// Paths allows for storing the data of some modules outside of Dir.
+	Paths DataPaths
 }
 
 // Node is a collection of Sia modules operating together as a Sia node.
@@ -140,6 +143,10 @@ type Node struct {
 	// The high level directory where all the persistence gets stored for the
 	// modules.
 	Dir string
+
+	// Paths are the resolved locations of the data which can be stored
+	// outside of Dir.
+	Paths DataPaths
 }
 
 // NumModules returns how many of the major modules the given NodeParams would
@@ -249,6 +256,40 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		return nil, errChan
 	}
 
+	// Resolve the data paths and make sure that no data needs to be migrated
+	// to them. Otherwise the modules would start from scratch.
+	paths, err := ResolveDataPaths(dir, params.Paths)
+	if err != nil {
+		errChan <- errors.AddContext(err, "unable to resolve data paths")
+		return nil, errChan
+	}
+	if err := checkDataPaths(dir, params.Paths); err != nil {
+		errChan <- err
+		return nil, errChan
+	}
+
+	// Enable the crash dumps and register the module logs which are added
+	// to the bundles.
+	if err := modules.EnableCrashDumps(filepath.Join(dir, modules.CrashDumpDir)); err != nil {
+		errChan <- errors.AddContext(err, "unable to enable crash dumps")
+		return nil, errChan
+	}
+	for name, path := range map[string]string{
+		"gateway.log":         filepath.Join(dir, modules.GatewayDir, "gateway.log"),
+		"consensus.log":       filepath.Join(paths.ConsensusDir, "consensus.log"),
+		"transactionpool.log": filepath.Join(dir, modules.TransactionPoolDir, "transactionpool.log"),
+		"wallet.log":          filepath.Join(dir, modules.WalletDir, "wallet.log"),
+		"miner.log":           filepath.Join(dir, modules.MinerDir, "miner.log"),
+		"host.log":            filepath.Join(paths.HostDir, "host.log"),
+		"contractmanager.log": filepath.Join(paths.HostDir, modules.ContractManagerDir, "contractmanager.log"),
+		"renter.log":          filepath.Join(paths.RenterDir, "renter.log"),
+		"repair.log":          filepath.Join(paths.RenterDir, "repair.log"),
+		"hostdb.log":          filepath.Join(paths.RenterDir, "hostdb.log"),
+		"contractor.log":      filepath.Join(paths.RenterDir, "contractor.log"),
+	} {
+		modules.RegisterCrashDumpLog(name, path)
+	}
+
 	// Create the siamux.
 	mux, err := modules.NewSiaMux(filepath.Join(dir, modules.SiaMuxDir), dir, params.SiaMuxTCPAddress, params.SiaMuxWSAddress)
 	if err != nil {
@@ -307,7 +348,7 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		if consensusSetDeps == nil {
 			consensusSetDeps = modules.ProdDependencies
 		}
-		return consensus.NewCustomConsensusSet(g, params.Bootstrap, filepath.Join(dir, modules.ConsensusDir), consensusSetDeps)
+		return consensus.NewCustomConsensusSet(g, params.Bootstrap, paths.ConsensusDir, consensusSetDeps)
 	}()
 	if err := modules.PeekErr(errChanCS); err != nil {
 		errChan <- errors.Extend(err, errors.New("unable to create consensus set"))
@@ -434,7 +475,7 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		}
 		i++
 		printfRelease("(%d/%d) Loading host...\n", i, numModules)
-		host, err := host.NewCustomTestHost(hostDeps, smDeps, cs, g, tp, w, mux, params.HostAddress, filepath.Join(dir, modules.HostDir))
+		host, err := host.NewCustomTestHost(hostDeps, smDeps, cs, g, tp, w, mux, params.HostAddress, paths.HostDir)
 		return host, err
 	}()
 	if err != nil {
@@ -474,7 +515,7 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		if renterDeps == nil {
 			renterDeps = modules.ProdDependencies
 		}
-		persistDir := filepath.Join(dir, modules.RenterDir)
+		persistDir := paths.RenterDir
 
 		i++
 		printfRelease("(%d/%d) Loading renter...\n", i, numModules)
@@ -556,6 +597,19 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		return nil, errChan
 	}
 
+	// Register the settings of the modules which are added to crash dump
+	// bundles.
+	if h != nil {
+		modules.RegisterCrashDumpSettings("host", func() (interface{}, error) {
+			return h.InternalSettings(), nil
+		})
+	}
+	if r != nil {
+		modules.RegisterCrashDumpSettings("renter", func() (interface{}, error) {
+			return r.Settings()
+		})
+	}
+
 	// Setup complete
 	printfRelease("API is now available, synchronous startup completed in %.3f seconds\n", time.Since(loadStartTime).Seconds())
 	go func() {
@@ -576,6 +630,7 @@ func New(params NodeParams, loadStartTime time.Time) (*Node, <-chan error) {
 		TransactionPool: tp,
 		Wallet:          w,
 
-		Dir: dir,
+		Dir:   dir,
+		Paths: paths,
 	}, errChan
 }
@@ -1,6 +1,7 @@
 package persist
 
 import (
+	"sync"
 	"time"
 
 	"gitlab.com/NebulousLabs/bolt"
@@ -11,6 +12,14 @@ import (
 type BoltDatabase struct {
 	Metadata
 	*bolt.DB
+
+	// staticPath is the path of the database file. It is used to throttle
+	// the writes of committed transactions.
+	staticPath string
+
+	// quiesceMu is held as a readlock by all writes to the database and as a
+	// writelock while the database is quiesced.
+	quiesceMu sync.RWMutex
 }
 
 // checkMetadata confirms that the metadata in the database is
@@ -60,6 +69,57 @@ func (db *BoltDatabase) updateMetadata(tx *bolt.Tx) error {
 	return nil
 }
 
+// Update wraps bolt's Update and blocks while the database is quiesced. The
+// writes of the transaction are throttled after it was committed.
+func (db *BoltDatabase) Update(fn func(*bolt.Tx) error) error {
+	var committed *bolt.Tx
+	err := func() error {
+		db.quiesceMu.RLock()
+		defer db.quiesceMu.RUnlock()
+		return db.DB.Update(func(tx *bolt.Tx) error {
+			tx.OnCommit(func() { committed = tx })
+			return fn(tx)
+		})
+	}()
+	db.throttleCommit(committed)
+	return err
+}
+
+// Commit commits a writable transaction which was created using Begin. Like
+// Update it blocks while the database is quiesced.
+func (db *BoltDatabase) Commit(tx *bolt.Tx) error {
+	var committed *bolt.Tx
+	tx.OnCommit(func() { committed = tx })
+	err := func() error {
+		db.quiesceMu.RLock()
+		defer db.quiesceMu.RUnlock()
+		return tx.Commit()
+	}()
+	db.throttleCommit(committed)
+	return err
+}
+
+// throttleCommit throttles the writes of a committed transaction. Bolt doesn't
+// allow for wrapping its file which is why the writes are throttled after the
+// fact, delaying the following I/O of the volume instead. Reads go through
+// bolt's mmap and are not throttled.
+func (db *BoltDatabase) throttleCommit(tx *bolt.Tx) {
+	if tx == nil {
+		return
+	}
+	writes := tx.Stats().Write
+	GlobalDiskThrottles.Throttle(db.staticPath, 0, writes*db.Info().PageSize, writes)
+}
+
+// Quiesce waits for ongoing writes to finish and blocks new ones until the
+// returned function is called. Bolt only writes to disk when a transaction is
+// committed, which means that the database file on disk is consistent while
+// the database is quiesced.
+func (db *BoltDatabase) Quiesce() (resume func()) {
+	db.quiesceMu.Lock()
+	return db.quiesceMu.Unlock
+}
+
 // Close closes the database.
 func (db *BoltDatabase) Close() error {
 	return db.DB.Close()
@@ -76,8 +136,9 @@ func OpenDatabase(md Metadata, filename string) (*BoltDatabase, error) {
 
 	// Check the metadata.
 	boltDB := &BoltDatabase{
-		Metadata: md,
-		DB:       db,
+		Metadata:   md,
+		DB:         db,
+		staticPath: filename,
 	}
 	err = boltDB.checkMetadata(md)
 	if err != nil {
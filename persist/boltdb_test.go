@@ -368,3 +368,86 @@ func TestErrIntegratedCheckMetadata(t *testing.T) {
 		}
 	}
 }
+
+// TestBoltDatabaseQuiesce tests that writes to a quiesced database are blocked
+// until it is resumed.
+func TestBoltDatabaseQuiesce(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	testDir := build.TempDir(persistDir, t.Name())
+	err := os.MkdirAll(testDir, defaultDirPermissions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := OpenDatabase(Metadata{"Fake Header", "Fake Version"}, filepath.Join(testDir, "fake_filename"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Quiesce the database and start a write as well as a commit of a
+	// writable transaction.
+	resume := db.Quiesce()
+	updated := make(chan error, 1)
+	go func() {
+		updated <- db.Update(func(tx *bolt.Tx) error {
+			_, err := tx.CreateBucket([]byte("bucket"))
+			return err
+		})
+	}()
+	select {
+	case <-updated:
+		t.Fatal("update wasn't blocked")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Reads aren't blocked.
+	err = db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket([]byte("bucket")) != nil {
+			t.Error("bucket was created while quiesced")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Resume the database. The update should go through.
+	resume()
+	select {
+	case err := <-updated:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("update wasn't resumed")
+	}
+
+	// Commits of writable transactions are blocked as well.
+	tx, err := db.Begin(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.CreateBucket([]byte("bucket2")); err != nil {
+		t.Fatal(err)
+	}
+	resume = db.Quiesce()
+	committed := make(chan error, 1)
+	go func() {
+		committed <- db.Commit(tx)
+	}()
+	select {
+	case <-committed:
+		t.Fatal("commit wasn't blocked")
+	case <-time.After(100 * time.Millisecond):
+	}
+	resume()
+	if err := <-committed; err != nil {
+		t.Fatal(err)
+	}
+}
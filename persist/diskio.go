@@ -0,0 +1,257 @@
+package persist
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+const (
+	// MinDiskIOBPS is the lowest throughput limit in bytes per second which
+	// can be configured for a volume. Lower limits would block single writes
+	// of a sector for minutes and thereby stall shutdown.
+	MinDiskIOBPS = 1 << 20 // 1 MiB/s
+
+	// MinDiskIOPS is the lowest IOPS limit which can be configured for a
+	// volume.
+	MinDiskIOPS = 10
+)
+
+var (
+	// GlobalDiskThrottles is the process-wide set of disk I/O throttles. It is
+	// shared by all modules which perform disk I/O through the persist package
+	// or the modules' dependencies.
+	GlobalDiskThrottles = NewDiskThrottler()
+
+	// ErrDiskIOLimitTooLow is returned if a configured disk I/O limit is
+	// lower than the min limit.
+	ErrDiskIOLimitTooLow = fmt.Errorf("throughput limits need to be at least %v bytes/s and IOPS limits at least %v", MinDiskIOBPS, MinDiskIOPS)
+)
+
+type (
+	// DiskIOLimits are the throughput and IOPS ceilings of a volume. A volume
+	// is identified by a path and covers all files below it. A limit of 0
+	// means unlimited.
+	DiskIOLimits struct {
+		Path     string `json:"path"`
+		ReadBPS  int64  `json:"readbps"`
+		WriteBPS int64  `json:"writebps"`
+		IOPS     int64  `json:"iops"`
+	}
+
+	// DiskIOStats are the statistics of a throttled volume since its limits
+	// were set.
+	DiskIOStats struct {
+		DiskIOLimits
+		BytesRead    uint64        `json:"bytesread"`
+		BytesWritten uint64        `json:"byteswritten"`
+		Ops          uint64        `json:"ops"`
+		Throttled    time.Duration `json:"throttled"`
+	}
+
+	// DiskThrottler throttles the disk I/O of the configured volumes. The
+	// volume of a file is the configured volume with the longest path that
+	// contains the file. The volume is looked up on every operation which
+	// means that new limits apply to files which are already open.
+	DiskThrottler struct {
+		// volumes are sorted by the length of their path in descending order
+		// for the longest prefix match.
+		volumes []*diskVolume
+		mu      sync.RWMutex
+	}
+
+	// diskVolume is a throttled volume.
+	diskVolume struct {
+		readBucket  tokenBucket
+		writeBucket tokenBucket
+		opsBucket   tokenBucket
+		stats       DiskIOStats
+		mu          sync.Mutex
+	}
+
+	// tokenBucket is a token bucket which allows for a burst of one second
+	// worth of tokens. Tokens can be borrowed which makes the following
+	// reservations wait until the debt is paid off.
+	tokenBucket struct {
+		rate      float64
+		available float64
+		last      time.Time
+	}
+)
+
+// NewDiskThrottler creates a DiskThrottler without any limits.
+func NewDiskThrottler() *DiskThrottler {
+	return &DiskThrottler{}
+}
+
+// Validate checks the limits of a volume.
+func (l DiskIOLimits) Validate() error {
+	if !filepath.IsAbs(l.Path) {
+		return fmt.Errorf("volume path '%v' needs to be absolute", l.Path)
+	}
+	if l.ReadBPS < 0 || l.WriteBPS < 0 || l.IOPS < 0 {
+		return errors.New("limits can't be negative")
+	}
+	if (l.ReadBPS > 0 && l.ReadBPS < MinDiskIOBPS) || (l.WriteBPS > 0 && l.WriteBPS < MinDiskIOBPS) || (l.IOPS > 0 && l.IOPS < MinDiskIOPS) {
+		return ErrDiskIOLimitTooLow
+	}
+	return nil
+}
+
+// reserve takes n tokens from the bucket and returns how long the caller
+// needs to wait before using them.
+func (tb *tokenBucket) reserve(n float64, now time.Time) time.Duration {
+	if tb.rate == 0 {
+		return 0
+	}
+	tb.available += tb.rate * now.Sub(tb.last).Seconds()
+	if tb.available > tb.rate {
+		tb.available = tb.rate
+	}
+	tb.last = now
+	tb.available -= n
+	if tb.available >= 0 {
+		return 0
+	}
+	return time.Duration(-tb.available / tb.rate * float64(time.Second))
+}
+
+// newDiskVolume creates a volume with full buckets.
+func newDiskVolume(l DiskIOLimits) *diskVolume {
+	now := time.Now()
+	bucket := func(rate int64) tokenBucket {
+		return tokenBucket{
+			rate:      float64(rate),
+			available: float64(rate),
+			last:      now,
+		}
+	}
+	return &diskVolume{
+		readBucket:  bucket(l.ReadBPS),
+		writeBucket: bucket(l.WriteBPS),
+		opsBucket:   bucket(l.IOPS),
+		stats:       DiskIOStats{DiskIOLimits: l},
+	}
+}
+
+// reserve reserves the bandwidth and ops of an operation and returns how long
+// the caller needs to wait before performing it.
+func (v *diskVolume) reserve(read, written, ops int) time.Duration {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	now := time.Now()
+	wait := v.opsBucket.reserve(float64(ops), now)
+	if w := v.readBucket.reserve(float64(read), now); w > wait {
+		wait = w
+	}
+	if w := v.writeBucket.reserve(float64(written), now); w > wait {
+		wait = w
+	}
+	v.stats.BytesRead += uint64(read)
+	v.stats.BytesWritten += uint64(written)
+	v.stats.Ops += uint64(ops)
+	v.stats.Throttled += wait
+	return wait
+}
+
+// SetLimits replaces the limits of all volumes. The statistics of the volumes
+// are reset.
+func (dt *DiskThrottler) SetLimits(limits []DiskIOLimits) error {
+	volumes := make([]*diskVolume, 0, len(limits))
+	seen := make(map[string]struct{})
+	for _, l := range limits {
+		if err := l.Validate(); err != nil {
+			return err
+		}
+		l.Path = filepath.Clean(l.Path)
+		if _, exists := seen[l.Path]; exists {
+			return fmt.Errorf("volume '%v' is configured more than once", l.Path)
+		}
+		seen[l.Path] = struct{}{}
+		volumes = append(volumes, newDiskVolume(l))
+	}
+	sort.SliceStable(volumes, func(i, j int) bool {
+		return len(volumes[i].stats.Path) > len(volumes[j].stats.Path)
+	})
+	dt.mu.Lock()
+	defer dt.mu.Unlock()
+	dt.volumes = volumes
+	return nil
+}
+
+// Limits returns the limits of all volumes.
+func (dt *DiskThrottler) Limits() []DiskIOLimits {
+	stats := dt.Stats()
+	limits := make([]DiskIOLimits, 0, len(stats))
+	for _, s := range stats {
+		limits = append(limits, s.DiskIOLimits)
+	}
+	return limits
+}
+
+// Stats returns the statistics of all volumes sorted by path.
+func (dt *DiskThrottler) Stats() []DiskIOStats {
+	dt.mu.RLock()
+	defer dt.mu.RUnlock()
+	stats := make([]DiskIOStats, 0, len(dt.volumes))
+	for _, v := range dt.volumes {
+		v.mu.Lock()
+		stats = append(stats, v.stats)
+		v.mu.Unlock()
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Path < stats[j].Path
+	})
+	return stats
+}
+
+// volume returns the volume which contains the file at path or nil if the
+// file isn't throttled.
+func (dt *DiskThrottler) volume(path string) *diskVolume {
+	dt.mu.RLock()
+	defer dt.mu.RUnlock()
+	if len(dt.volumes) == 0 {
+		return nil
+	}
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil
+	}
+	for _, v := range dt.volumes {
+		p := v.stats.Path
+		if path == p || strings.HasPrefix(path, strings.TrimSuffix(p, string(os.PathSeparator))+string(os.PathSeparator)) {
+			return v
+		}
+	}
+	return nil
+}
+
+// Throttle blocks until the volume of the file at path has the capacity for
+// an operation which reads and writes the provided number of bytes using the
+// provided number of I/O operations. Operations which exceed the capacity are
+// still admitted but delay the following operations.
+func (dt *DiskThrottler) Throttle(path string, read, written, ops int) {
+	v := dt.volume(path)
+	if v == nil {
+		return
+	}
+	if wait := v.reserve(read, written, ops); wait > 0 {
+		time.Sleep(wait)
+	}
+}
+
+// ThrottleRead throttles a single read of n bytes from the file at path.
+func (dt *DiskThrottler) ThrottleRead(path string, n int) {
+	dt.Throttle(path, n, 0, 1)
+}
+
+// ThrottleWrite throttles a single write of n bytes to the file at path.
+func (dt *DiskThrottler) ThrottleWrite(path string, n int) {
+	dt.Throttle(path, 0, n, 1)
+}
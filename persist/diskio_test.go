@@ -0,0 +1,131 @@
+package persist
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestTokenBucket tests reserving tokens from a tokenBucket.
+func TestTokenBucket(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	tb := tokenBucket{rate: 100, available: 100, last: now}
+
+	// The burst is served without waiting.
+	if wait := tb.reserve(100, now); wait != 0 {
+		t.Fatal("burst shouldn't wait", wait)
+	}
+	// Borrowing 50 tokens requires waiting half a second.
+	if wait := tb.reserve(50, now); wait != 500*time.Millisecond {
+		t.Fatal("unexpected wait", wait)
+	}
+	// After a second the debt is paid off and there are 50 tokens.
+	now = now.Add(time.Second)
+	if wait := tb.reserve(50, now); wait != 0 {
+		t.Fatal("unexpected wait", wait)
+	}
+	// The bucket never holds more than a second worth of tokens.
+	now = now.Add(time.Hour)
+	if wait := tb.reserve(150, now); wait != 500*time.Millisecond {
+		t.Fatal("unexpected wait", wait)
+	}
+
+	// An unlimited bucket never waits.
+	tb = tokenBucket{}
+	if wait := tb.reserve(1e9, now); wait != 0 {
+		t.Fatal("unlimited bucket shouldn't wait", wait)
+	}
+}
+
+// TestDiskThrottler tests setting the limits of a DiskThrottler and looking up
+// the volumes of files.
+func TestDiskThrottler(t *testing.T) {
+	t.Parallel()
+
+	root, err := filepath.Abs(filepath.Join("testdata", t.Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	disk := filepath.Join(root, "disk")
+	nested := filepath.Join(disk, "nested")
+
+	// Invalid limits are rejected.
+	dt := NewDiskThrottler()
+	invalid := [][]DiskIOLimits{
+		{{Path: "relative", IOPS: MinDiskIOPS}},
+		{{Path: disk, ReadBPS: -1}},
+		{{Path: disk, WriteBPS: MinDiskIOBPS - 1}},
+		{{Path: disk, IOPS: MinDiskIOPS - 1}},
+		{{Path: disk}, {Path: disk + string(filepath.Separator)}},
+	}
+	for _, limits := range invalid {
+		if err := dt.SetLimits(limits); err == nil {
+			t.Fatal("expected limits to be rejected", limits)
+		}
+	}
+
+	// Set limits for two nested volumes.
+	limits := []DiskIOLimits{
+		{Path: disk, ReadBPS: MinDiskIOBPS},
+		{Path: nested, IOPS: MinDiskIOPS},
+	}
+	if err := dt.SetLimits(limits); err != nil {
+		t.Fatal(err)
+	}
+	if l := dt.Limits(); len(l) != 2 || l[0] != limits[0] || l[1] != limits[1] {
+		t.Fatal("unexpected limits", l)
+	}
+
+	// Files belong to the volume with the longest matching path.
+	tests := []struct {
+		path   string
+		volume string
+	}{
+		{filepath.Join(disk, "file"), disk},
+		{filepath.Join(nested, "dir", "file"), nested},
+		{nested, nested},
+		{disk + "2", ""},
+		{filepath.Join(root, "file"), ""},
+	}
+	for _, test := range tests {
+		v := dt.volume(test.path)
+		if test.volume == "" && v != nil {
+			t.Fatalf("%v shouldn't be throttled", test.path)
+		} else if test.volume != "" && (v == nil || v.stats.Path != test.volume) {
+			t.Fatalf("%v should belong to %v", test.path, test.volume)
+		}
+	}
+
+	// Perform some I/O within the burst and check the stats.
+	dt.ThrottleRead(filepath.Join(disk, "file"), 1000)
+	dt.ThrottleWrite(filepath.Join(nested, "file"), 2000)
+	dt.ThrottleWrite(filepath.Join(root, "file"), 3000)
+	stats := dt.Stats()
+	if stats[0].BytesRead != 1000 || stats[0].BytesWritten != 0 || stats[0].Ops != 1 {
+		t.Fatal("unexpected stats", stats[0])
+	}
+	if stats[1].BytesRead != 0 || stats[1].BytesWritten != 2000 || stats[1].Ops != 1 {
+		t.Fatal("unexpected stats", stats[1])
+	}
+
+	// Exceeding the IOPS limit should throttle the following operation.
+	start := time.Now()
+	dt.Throttle(filepath.Join(nested, "file"), 0, 0, MinDiskIOPS)
+	dt.ThrottleWrite(filepath.Join(nested, "file"), 0)
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Fatal("operation wasn't throttled", elapsed)
+	}
+	if stats := dt.Stats(); stats[1].Throttled == 0 {
+		t.Fatal("throttled time wasn't tracked", stats[1])
+	}
+
+	// Removing the limits removes the throttles.
+	if err := dt.SetLimits(nil); err != nil {
+		t.Fatal(err)
+	}
+	if v := dt.volume(filepath.Join(disk, "file")); v != nil {
+		t.Fatal("file shouldn't be throttled")
+	}
+}
@@ -1,18 +1,110 @@
 package persist
 
 import (
+	"encoding/json"
+	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
 
 	"gitlab.com/NebulousLabs/log"
 	"go.sia.tech/siad/build"
 )
 
-// Logger is a wrapper for log.Logger.
+// The following consts are the levels of log messages, ordered by severity.
+const (
+	// LogLevelDebug is the level of debug messages.
+	LogLevelDebug LogLevel = "debug"
+	// LogLevelInfo is the level of regular messages.
+	LogLevelInfo LogLevel = "info"
+	// LogLevelWarn is the level of warnings, i.e. messages starting with
+	// "WARN".
+	LogLevelWarn LogLevel = "warn"
+	// LogLevelError is the level of errors, i.e. messages starting with
+	// "ERROR", and of critical and severe messages.
+	LogLevelError LogLevel = "error"
+)
+
+// The following consts are the formats messages are logged in.
+const (
+	// LogFormatText logs messages as lines of text prefixed with the time and
+	// caller.
+	LogFormatText = "text"
+	// LogFormatJSON logs messages as JSON objects, one per line.
+	LogFormatJSON = "json"
+)
+
+// Logger is a wrapper for log.Logger which filters messages by the log level
+// of its module and supports logging as JSON. The module of a file logger is
+// the name of its log file without extension, e.g. "host" for "host.log".
 type Logger struct {
 	*log.Logger
+
+	staticConfig *LogConfig
+	staticModule string
+
+	// mu serializes the messages logged as JSON.
+	mu sync.Mutex
 }
 
+type (
+	// LogLevel is the level of a log message.
+	LogLevel string
+
+	// LogSettings are the settings of all loggers of the daemon.
+	LogSettings struct {
+		// Format is the format messages are logged in. Defaults to text.
+		Format string `json:"format"`
+		// Level is the minimum level of the messages which are logged.
+		// Defaults to debug for debug builds and info otherwise.
+		Level LogLevel `json:"level"`
+		// ModuleLevels overrides Level for individual modules.
+		ModuleLevels map[string]LogLevel `json:"modulelevels"`
+		// MaxSize is the size in bytes at which a log file is rotated. Zero
+		// disables rotation.
+		MaxSize int64 `json:"maxsize"`
+		// MaxBackups is the number of rotated log files which are kept.
+		MaxBackups int `json:"maxbackups"`
+	}
+
+	// LogConfig holds the LogSettings. The settings are looked up on every
+	// message which means that new settings apply to existing loggers.
+	LogConfig struct {
+		settings LogSettings
+		mu       sync.RWMutex
+	}
+
+	// logMessage is a message logged in the JSON format.
+	logMessage struct {
+		Time    time.Time `json:"time"`
+		Level   LogLevel  `json:"level"`
+		Module  string    `json:"module,omitempty"`
+		Caller  string    `json:"caller"`
+		Message string    `json:"msg"`
+	}
+
+	// rotatingFile is a log file which is rotated once it exceeds the max
+	// size of the LogSettings.
+	rotatingFile struct {
+		closed       bool
+		f            *os.File
+		size         int64
+		staticConfig *LogConfig
+		staticPath   string
+		mu           sync.Mutex
+	}
+)
+
 var (
+	// GlobalLogConfig is the process-wide LogConfig shared by all loggers.
+	GlobalLogConfig = NewLogConfig()
+
 	// options contains log options with Sia- and build-specific information.
 	options = log.Options{
 		BinaryName:   build.BinaryName,
@@ -21,10 +113,226 @@ var (
 		Release:      buildReleaseType(),
 		Version:      build.NodeVersion,
 	}
+
+	// logLevelSeverity maps the known log levels to their severity.
+	logLevelSeverity = map[LogLevel]int{
+		LogLevelDebug: 0,
+		LogLevelInfo:  1,
+		LogLevelWarn:  2,
+		LogLevelError: 3,
+	}
 )
 
+// ParseLogModuleLevels parses a comma separated list of module:level pairs.
+func ParseLogModuleLevels(s string) (map[string]LogLevel, error) {
+	levels := make(map[string]LogLevel)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		split := strings.Split(pair, ":")
+		if len(split) != 2 || split[0] == "" {
+			return nil, fmt.Errorf("invalid module level '%v', expected module:level", pair)
+		}
+		levels[split[0]] = LogLevel(split[1])
+	}
+	return levels, nil
+}
+
+// Validate checks the settings for errors.
+func (s LogSettings) Validate() error {
+	switch s.Format {
+	case "", LogFormatText, LogFormatJSON:
+	default:
+		return fmt.Errorf("unknown log format '%v'", s.Format)
+	}
+	if _, known := logLevelSeverity[s.Level]; s.Level != "" && !known {
+		return fmt.Errorf("unknown log level '%v'", s.Level)
+	}
+	for module, level := range s.ModuleLevels {
+		if _, known := logLevelSeverity[level]; !known {
+			return fmt.Errorf("unknown log level '%v' for module '%v'", level, module)
+		}
+	}
+	if s.MaxSize < 0 || s.MaxBackups < 0 {
+		return fmt.Errorf("max size and max backups can't be negative")
+	}
+	return nil
+}
+
+// NewLogConfig creates a LogConfig with the default settings.
+func NewLogConfig() *LogConfig {
+	return &LogConfig{}
+}
+
+// Settings returns the current settings.
+func (lc *LogConfig) Settings() LogSettings {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+	s := lc.settings
+	s.ModuleLevels = make(map[string]LogLevel, len(lc.settings.ModuleLevels))
+	for module, level := range lc.settings.ModuleLevels {
+		s.ModuleLevels[module] = level
+	}
+	return s
+}
+
+// SetSettings validates and applies the provided settings.
+func (lc *LogConfig) SetSettings(s LogSettings) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	levels := make(map[string]LogLevel, len(s.ModuleLevels))
+	for module, level := range s.ModuleLevels {
+		levels[module] = level
+	}
+	s.ModuleLevels = levels
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	lc.settings = s
+	return nil
+}
+
+// enabled returns true if a message of the given level should be logged for
+// the module and the format the message should be logged in.
+func (lc *LogConfig) enabled(module string, level LogLevel) (bool, string) {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+	min, exists := lc.settings.ModuleLevels[module]
+	if !exists {
+		min = lc.settings.Level
+	}
+	if min == "" && build.DEBUG {
+		min = LogLevelDebug
+	} else if min == "" {
+		min = LogLevelInfo
+	}
+	return logLevelSeverity[level] >= logLevelSeverity[min], lc.settings.Format
+}
+
+// rotation returns the max size and max backups of the log files.
+func (lc *LogConfig) rotation() (int64, int) {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+	return lc.settings.MaxSize, lc.settings.MaxBackups
+}
+
+// messageLevel infers the level of a message which was logged without an
+// explicit level from its prefix.
+func messageLevel(msg string) LogLevel {
+	switch {
+	case strings.HasPrefix(msg, "WARN"):
+		return LogLevelWarn
+	case strings.HasPrefix(msg, "ERROR"):
+		return LogLevelError
+	default:
+		return LogLevelInfo
+	}
+}
+
+// output writes a message of the given level if it is enabled for the
+// logger's module. calldepth is the number of frames between output and the
+// caller of the logger.
+func (l *Logger) output(calldepth int, level LogLevel, msg string) {
+	enabled, format := l.staticConfig.enabled(l.staticModule, level)
+	if !enabled {
+		return
+	}
+	if format != LogFormatJSON {
+		_ = l.Logger.Output(calldepth+1, msg)
+		return
+	}
+	caller := "???"
+	if _, file, line, ok := runtime.Caller(calldepth); ok {
+		caller = fmt.Sprintf("%v:%v", filepath.Base(file), line)
+	}
+	b, err := json.Marshal(logMessage{
+		Time:    time.Now().UTC(),
+		Level:   level,
+		Module:  l.staticModule,
+		Caller:  caller,
+		Message: strings.TrimSuffix(msg, "\n"),
+	})
+	if err != nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = l.Logger.Writer().Write(append(b, '\n'))
+}
+
+// Print logs a message. Its level is inferred from its prefix.
+func (l *Logger) Print(v ...interface{}) {
+	msg := fmt.Sprint(v...)
+	l.output(2, messageLevel(msg), msg)
+}
+
+// Printf logs a formatted message. Its level is inferred from its prefix.
+func (l *Logger) Printf(format string, v ...interface{}) {
+	msg := fmt.Sprintf(format, v...)
+	l.output(2, messageLevel(msg), msg)
+}
+
+// Println logs a message. Its level is inferred from its prefix.
+func (l *Logger) Println(v ...interface{}) {
+	msg := fmt.Sprintln(v...)
+	l.output(2, messageLevel(msg), msg)
+}
+
+// Debug logs a message with the debug level.
+func (l *Logger) Debug(v ...interface{}) {
+	l.output(2, LogLevelDebug, fmt.Sprint(v...))
+}
+
+// Debugf logs a formatted message with the debug level.
+func (l *Logger) Debugf(format string, v ...interface{}) {
+	l.output(2, LogLevelDebug, fmt.Sprintf(format, v...))
+}
+
+// Debugln logs a message with the debug level.
+func (l *Logger) Debugln(v ...interface{}) {
+	l.output(2, LogLevelDebug, "[DEBUG] "+fmt.Sprintln(v...))
+}
+
+// Critical logs a message with a CRITICAL prefix that guides the user to the
+// github tracker. If debug mode is enabled, it will also write the message to
+// os.Stderr and panic. Critical should only be called if there has been a
+// developer error, otherwise Severe should be called.
+func (l *Logger) Critical(v ...interface{}) {
+	l.output(2, LogLevelError, "CRITICAL: "+fmt.Sprintln(v...))
+	options.Critical(v...)
+}
+
+// Severe logs a message with a SEVERE prefix. If debug mode is enabled, it
+// will also write the message to os.Stderr and panic. Severe should be called
+// if there is a severe problem with the user's machine or setup that should be
+// addressed ASAP but does not necessarily require that the machine crash or
+// exit.
+func (l *Logger) Severe(v ...interface{}) {
+	l.output(2, LogLevelError, "SEVERE: "+fmt.Sprintln(v...))
+	s := fmt.Sprintf("Severe error: %v %v", l.BuildInfoString(), fmt.Sprintln(v...))
+	if options.Release != log.Testing {
+		debug.PrintStack()
+		_, _ = os.Stderr.WriteString(s)
+	}
+	if options.Debug {
+		panic(s)
+	}
+}
+
+// Close logs a shutdown message and closes the logger's underlying
+// io.Writer, if it is also an io.Closer.
+func (l *Logger) Close() error {
+	l.output(2, LogLevelInfo, "SHUTDOWN: Logging has terminated.")
+	if c, ok := l.Logger.Writer().(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
 // printCommitHash logs build.GitRevision at startup.
-func printCommitHash(logger *log.Logger) {
+func printCommitHash(logger *Logger) {
 	if build.GitRevision != "" {
 		logger.Printf("STARTUP: Commit hash %v", build.GitRevision)
 	} else {
@@ -32,26 +340,132 @@ func printCommitHash(logger *log.Logger) {
 	}
 }
 
+// newLogger creates a logger for the module which writes to w.
+func newLogger(w io.Writer, module string) (*Logger, error) {
+	// The startup message of the wrapped logger is replaced with one in the
+	// configured format.
+	logger, err := log.NewLogger(ioutil.Discard, options)
+	if err != nil {
+		return nil, err
+	}
+	logger.SetOutput(w)
+	l := &Logger{
+		Logger:       logger,
+		staticConfig: GlobalLogConfig,
+		staticModule: module,
+	}
+	l.output(2, LogLevelInfo, fmt.Sprintf("STARTUP: Logging has started. %v Version %v", options.BinaryName, options.Version))
+	printCommitHash(l)
+	return l, nil
+}
+
 // NewFileLogger returns a logger that logs to logFilename. The file is opened
-// in append mode, and created if it does not exist.
+// in append mode, and created if it does not exist. It is rotated according
+// to the GlobalLogConfig.
 func NewFileLogger(logFilename string) (*Logger, error) {
-	logger, err := log.NewFileLogger(logFilename, options)
+	rf, err := openRotatingFile(logFilename, GlobalLogConfig)
 	if err != nil {
 		return nil, err
 	}
-	printCommitHash(logger)
-	return &Logger{logger}, nil
+	module := strings.TrimSuffix(filepath.Base(logFilename), filepath.Ext(logFilename))
+	return newLogger(rf, module)
 }
 
 // NewLogger returns a logger that can be closed. Calls should not be made to
 // the logger after 'Close' has been called.
 func NewLogger(w io.Writer) (*Logger, error) {
-	logger, err := log.NewLogger(w, options)
+	return newLogger(w, "")
+}
+
+// openRotatingFile opens the log file at path in append mode.
+func openRotatingFile(path string, config *LogConfig) (*rotatingFile, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0660)
 	if err != nil {
 		return nil, err
 	}
-	printCommitHash(logger)
-	return &Logger{logger}, nil
+	fi, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return &rotatingFile{
+		f:            f,
+		size:         fi.Size(),
+		staticConfig: config,
+		staticPath:   path,
+	}, nil
+}
+
+// Close syncs and closes the file.
+func (rf *rotatingFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	// Sanity check - close should not have been called yet.
+	if rf.closed {
+		options.Critical("cannot close the file; already closed")
+	}
+	if err := rf.f.Sync(); err != nil {
+		return err
+	}
+	rf.closed = true
+	return rf.f.Close()
+}
+
+// Write writes b to the file, rotating the file first if b would push it
+// beyond the max size.
+func (rf *rotatingFile) Write(b []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+	// Sanity check - close should not have been called yet.
+	if rf.closed {
+		options.Critical("cannot write to the file after it has been closed")
+	}
+	maxSize, maxBackups := rf.staticConfig.rotation()
+	if maxSize > 0 && rf.size > 0 && rf.size+int64(len(b)) > maxSize {
+		if err := rf.rotate(maxBackups); err != nil {
+			return 0, err
+		}
+	}
+	n, err := rf.f.Write(b)
+	rf.size += int64(n)
+	return n, err
+}
+
+// rotate moves the current file to the first backup, shifting the existing
+// backups and removing the ones exceeding maxBackups, and opens a new file.
+func (rf *rotatingFile) rotate(maxBackups int) error {
+	if err := rf.f.Close(); err != nil {
+		return err
+	}
+	backup := func(i int) string {
+		return fmt.Sprintf("%v.%v", rf.staticPath, i)
+	}
+	var err error
+	if maxBackups == 0 {
+		err = os.Remove(rf.staticPath)
+	} else {
+		err = os.Remove(backup(maxBackups))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		for i := maxBackups - 1; i >= 1; i-- {
+			err := os.Rename(backup(i), backup(i+1))
+			if err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		err = os.Rename(rf.staticPath, backup(1))
+	}
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(rf.staticPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0660)
+	if err != nil {
+		return err
+	}
+	rf.f = f
+	rf.size = 0
+	return nil
 }
 
 // buildReleaseType returns the release type for this build, defaulting to
@@ -0,0 +1,190 @@
+package persist
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.sia.tech/siad/build"
+)
+
+// newTestLogger creates a logger for the module which writes to the returned
+// buffer and uses its own LogConfig.
+func newTestLogger(t *testing.T, module string, settings LogSettings) (*Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	l, err := newLogger(&buf, module)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.staticConfig = NewLogConfig()
+	if err := l.staticConfig.SetSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	return l, &buf
+}
+
+// TestLoggerLevels tests that messages are filtered by the level of the
+// logger's module.
+func TestLoggerLevels(t *testing.T) {
+	t.Parallel()
+
+	settings := LogSettings{
+		Level:        LogLevelWarn,
+		ModuleLevels: map[string]LogLevel{"host": LogLevelDebug},
+	}
+	renter, renterBuf := newTestLogger(t, "renter", settings)
+	host, hostBuf := newTestLogger(t, "host", settings)
+	for _, l := range []*Logger{renter, host} {
+		l.Debugln("debug message")
+		l.Println("info message")
+		l.Printf("WARN: warn message")
+		l.Println("ERROR: error message")
+	}
+	for _, msg := range []string{"debug message", "info message", "warn message", "error message"} {
+		if !strings.Contains(hostBuf.String(), msg) {
+			t.Errorf("host should log %q", msg)
+		}
+	}
+	for _, msg := range []string{"debug message", "info message"} {
+		if strings.Contains(renterBuf.String(), msg) {
+			t.Errorf("renter shouldn't log %q", msg)
+		}
+	}
+	for _, msg := range []string{"warn message", "error message"} {
+		if !strings.Contains(renterBuf.String(), msg) {
+			t.Errorf("renter should log %q", msg)
+		}
+	}
+
+	// Changing the settings applies to the existing logger.
+	if err := renter.staticConfig.SetSettings(LogSettings{Level: LogLevelError}); err != nil {
+		t.Fatal(err)
+	}
+	renterBuf.Reset()
+	renter.Println("WARN: another warning")
+	if renterBuf.Len() != 0 {
+		t.Fatal("warning shouldn't be logged", renterBuf.String())
+	}
+}
+
+// TestLoggerJSON tests logging messages as JSON.
+func TestLoggerJSON(t *testing.T) {
+	t.Parallel()
+
+	l, buf := newTestLogger(t, "host", LogSettings{Format: LogFormatJSON, Level: LogLevelDebug})
+	l.Println("WARN: failed to submit storage proof")
+	l.Debugf("rpc %v", "settings")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %v", len(lines))
+	}
+	var msgs []logMessage
+	for _, line := range lines {
+		var msg logMessage
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			t.Fatal(err)
+		}
+		msgs = append(msgs, msg)
+	}
+	if msgs[0].Level != LogLevelWarn || msgs[0].Message != "WARN: failed to submit storage proof" || msgs[0].Module != "host" {
+		t.Fatal("unexpected message", msgs[0])
+	}
+	if msgs[1].Level != LogLevelDebug || msgs[1].Message != "rpc settings" {
+		t.Fatal("unexpected message", msgs[1])
+	}
+	if !strings.HasPrefix(msgs[1].Caller, "log_test.go:") {
+		t.Fatal("caller should be the test", msgs[1].Caller)
+	}
+}
+
+// TestRotatingFile tests that log files are rotated once they exceed the
+// max size.
+func TestRotatingFile(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	dir := build.TempDir(persistDir, t.Name())
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "host.log")
+
+	config := NewLogConfig()
+	if err := config.SetSettings(LogSettings{MaxSize: 10, MaxBackups: 2}); err != nil {
+		t.Fatal(err)
+	}
+	rf, err := openRotatingFile(path, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"aaaaaaaa\n", "bbbbbbbb\n", "cccccccc\n", "dddddddd\n"} {
+		if _, err := rf.Write([]byte(s)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rf.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The oldest file should have been removed.
+	for file, expected := range map[string]string{
+		path:        "dddddddd\n",
+		path + ".1": "cccccccc\n",
+		path + ".2": "bbbbbbbb\n",
+	} {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != expected {
+			t.Fatalf("%v: expected %q, got %q", file, expected, b)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Fatal("third backup shouldn't exist", err)
+	}
+}
+
+// TestLogSettingsValidate is a unit test for validating and parsing the log
+// settings.
+func TestLogSettingsValidate(t *testing.T) {
+	t.Parallel()
+
+	levels, err := ParseLogModuleLevels("host:debug, renter:warn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(levels) != 2 || levels["host"] != LogLevelDebug || levels["renter"] != LogLevelWarn {
+		t.Fatal("unexpected levels", levels)
+	}
+	if _, err := ParseLogModuleLevels("host"); err == nil {
+		t.Fatal("expected error")
+	}
+
+	tests := []struct {
+		settings LogSettings
+		valid    bool
+	}{
+		{LogSettings{}, true},
+		{LogSettings{Format: LogFormatJSON, Level: LogLevelInfo, ModuleLevels: levels, MaxSize: 1 << 20, MaxBackups: 3}, true},
+		{LogSettings{Format: "xml"}, false},
+		{LogSettings{Level: "verbose"}, false},
+		{LogSettings{ModuleLevels: map[string]LogLevel{"host": "verbose"}}, false},
+		{LogSettings{MaxSize: -1}, false},
+	}
+	for i, test := range tests {
+		err := test.settings.Validate()
+		if test.valid && err != nil {
+			t.Errorf("%v: expected settings to be valid: %v", i, err)
+		} else if !test.valid && err == nil {
+			t.Errorf("%v: expected settings to be invalid", i)
+		}
+	}
+}
@@ -11,10 +11,13 @@ import (
 	"time"
 
 	"gitlab.com/NebulousLabs/encoding"
+	"golang.org/x/net/websocket"
+
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node"
+	"go.sia.tech/siad/node/api"
 	"go.sia.tech/siad/siatest"
 	"go.sia.tech/siad/types"
 )
@@ -293,6 +296,136 @@ func TestConsensusSubscribe(t *testing.T) {
 	}
 }
 
+// consensusStreamMessage is a message received from the /consensus/stream
+// endpoint which is either a change or an error.
+type consensusStreamMessage struct {
+	api.ConsensusStreamChange
+	api.ConsensusStreamError
+}
+
+// TestConsensusStream tests the /consensus/stream endpoint
+func TestConsensusStream(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	// Create a testgroup
+	groupParams := siatest.GroupParams{
+		Miners: 1,
+	}
+	tg, err := siatest.NewGroupFromTemplate(consensusTestDir(t.Name()), groupParams)
+	if err != nil {
+		t.Fatal("Failed to create group: ", err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	testNode := tg.Miners()[0]
+
+	// An invalid change id should be rejected.
+	if _, err := testNode.ConsensusStreamSubscribe("invalid"); err == nil {
+		t.Fatal("expected invalid change id to be rejected")
+	}
+
+	// Watch a new address of the wallet.
+	wag, err := testNode.WalletAddressGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := wag.Address
+	cg, err := testNode.ConsensusGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// receive reads the next message from a stream.
+	receive := func(conn *websocket.Conn) consensusStreamMessage {
+		t.Helper()
+		if err := conn.SetReadDeadline(time.Now().Add(time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+		var msg consensusStreamMessage
+		if err := websocket.JSON.Receive(conn, &msg); err != nil {
+			t.Fatal(err)
+		}
+		return msg
+	}
+
+	// Stream all changes from the beginning. The chain is longer than the
+	// stream's buffer which means the stream might overflow in which case it
+	// is resumed from the last received change.
+	conn, err := testNode.ConsensusStreamSubscribe("beginning", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lastID string
+	var lastBlock api.ConsensusStreamBlock
+	for lastBlock.ID != cg.CurrentBlock {
+		msg := receive(conn)
+		if msg.Error != "" {
+			if msg.LastChangeID != lastID {
+				t.Fatalf("expected last change id %v, got %v", lastID, msg.LastChangeID)
+			}
+			if err := conn.Close(); err != nil {
+				t.Fatal(err)
+			}
+			conn, err = testNode.ConsensusStreamSubscribe(lastID, addr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		if len(msg.RevertedBlocks) != 0 || len(msg.AppliedBlocks) != 1 {
+			t.Fatalf("unexpected change %+v", msg.ConsensusStreamChange)
+		}
+		b := msg.AppliedBlocks[0]
+		if lastID != "" && (b.ParentID != lastBlock.ID || b.Height != lastBlock.Height+1) {
+			t.Fatalf("block %v doesn't follow block %v", b, lastBlock)
+		}
+		if b.Height != msg.BlockHeight {
+			t.Fatalf("expected block height %v, got %v", msg.BlockHeight, b.Height)
+		}
+		lastID, lastBlock = msg.ID, b
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Send coins to the watched address and mine a block. The stream should
+	// list the new output.
+	amount := types.SiacoinPrecision.Mul64(100)
+	if _, err := testNode.WalletSiacoinsPost(amount, addr, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := testNode.MineBlock(); err != nil {
+		t.Fatal(err)
+	}
+	msg := receive(conn)
+	if msg.Error != "" {
+		t.Fatal(msg.Error)
+	}
+	cg, err = testNode.ConsensusGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msg.AppliedBlocks) != 1 || msg.AppliedBlocks[0].ID != cg.CurrentBlock || msg.AppliedBlocks[0].ParentID != lastBlock.ID {
+		t.Fatalf("unexpected change %+v", msg.ConsensusStreamChange)
+	}
+	var found bool
+	for _, sco := range msg.SiacoinOutputs.Outputs {
+		if sco.UnlockHash != addr {
+			t.Fatal("output of unwatched address was listed", sco.UnlockHash)
+		}
+		found = found || (sco.Created && sco.Value.Equals(amount))
+	}
+	if !found {
+		t.Fatalf("output wasn't listed %+v", msg.SiacoinOutputs)
+	}
+}
+
 // TestFoundationHardfork tests the foundation hardfork, ensuring that upgraded
 // nodes have the ability to follow the hardfork, and ensuring that the
 // mechanisms for spending the foundation coins are functional.
@@ -2,21 +2,29 @@ package daemon
 
 import (
 	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
 	"gitlab.com/NebulousLabs/fastrand"
+	"golang.org/x/net/websocket"
 
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
 	"go.sia.tech/siad/node"
 	"go.sia.tech/siad/node/api/client"
+	"go.sia.tech/siad/persist"
 	"go.sia.tech/siad/profile"
 	"go.sia.tech/siad/siatest"
+	"go.sia.tech/siad/types"
 )
 
 // TestDaemonAPIPassword makes sure that the daemon rejects requests with the
@@ -437,3 +445,355 @@ func TestDaemonProfile(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// TestDaemonEvents tests streaming the events of a node using the WebSocket
+// API and configuring the event routes.
+func TestDaemonEvents(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	testDir := daemonTestDir(t.Name())
+
+	// Create a new server
+	testNode, err := siatest.NewNode(node.Miner(testDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := testNode.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Set the event routes.
+	routes := []modules.EventRoute{{
+		Types: []modules.EventType{modules.EventTypeConsensusChange},
+		Sink:  modules.EventSinkLog,
+	}}
+	if err := testNode.DaemonEventsRoutesPost(routes); err != nil {
+		t.Fatal(err)
+	}
+	derg, err := testNode.DaemonEventsRoutesGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(derg.Routes, routes) {
+		t.Fatal("routes weren't set", derg.Routes)
+	}
+	invalid := []modules.EventRoute{{Types: []modules.EventType{"unknown"}, Sink: modules.EventSinkLog}}
+	if err := testNode.DaemonEventsRoutesPost(invalid); err == nil {
+		t.Fatal("invalid routes shouldn't be accepted")
+	}
+
+	// Subscribe to consensus changes and wallet transactions.
+	conn, err := testNode.DaemonEventsSubscribe(modules.EventTypeConsensusChange, modules.EventTypeWalletTransactionUnconfirmed, modules.EventTypeWalletTransactionConfirmed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := conn.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if _, err := testNode.DaemonEventsSubscribe("unknown"); err == nil {
+		t.Fatal("subscribing to unknown events should fail")
+	}
+
+	// receive waits for the next event of the given type and returns its
+	// data.
+	receive := func(eventType modules.EventType, data interface{}) {
+		t.Helper()
+		if err := conn.SetReadDeadline(time.Now().Add(time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+		for {
+			var e struct {
+				Type modules.EventType `json:"type"`
+				Data json.RawMessage   `json:"data"`
+			}
+			if err := websocket.JSON.Receive(conn, &e); err != nil {
+				t.Fatal(err)
+			}
+			if e.Type != eventType {
+				continue
+			}
+			if err := json.Unmarshal(e.Data, data); err != nil {
+				t.Fatal(err)
+			}
+			return
+		}
+	}
+
+	// Mining a block should result in a consensus change event.
+	if err := testNode.MineBlock(); err != nil {
+		t.Fatal(err)
+	}
+	cg, err := testNode.ConsensusGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cc modules.EventConsensusChange
+	receive(modules.EventTypeConsensusChange, &cc)
+	if cc.BlockHeight != cg.Height || len(cc.AppliedBlocks) != 1 || cc.AppliedBlocks[0] != cg.CurrentBlock {
+		t.Fatal("unexpected consensus change", cc)
+	}
+
+	// Sending money should result in an unconfirmed and a confirmed wallet
+	// transaction event. Wait for the wallet to be polled once first since the
+	// first poll only initializes the state of the poller.
+	time.Sleep(3 * time.Second)
+	wag, err := testNode.WalletAddressGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wsp, err := testNode.WalletSiacoinsPost(types.SiacoinPrecision, wag.Address, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txnID := wsp.TransactionIDs[len(wsp.TransactionIDs)-1]
+	var pt modules.ProcessedTransaction
+	receive(modules.EventTypeWalletTransactionUnconfirmed, &pt)
+	for pt.TransactionID != txnID {
+		receive(modules.EventTypeWalletTransactionUnconfirmed, &pt)
+	}
+	if err := testNode.MineBlock(); err != nil {
+		t.Fatal(err)
+	}
+	receive(modules.EventTypeWalletTransactionConfirmed, &pt)
+	for pt.TransactionID != txnID {
+		receive(modules.EventTypeWalletTransactionConfirmed, &pt)
+	}
+}
+
+// TestDaemonMaintenanceQuiesce tests quiescing and resuming the writers of a
+// daemon.
+func TestDaemonMaintenanceQuiesce(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a testgroup with a host and a miner.
+	groupParams := siatest.GroupParams{
+		Hosts:  1,
+		Miners: 1,
+	}
+	testDir := daemonTestDir(t.Name())
+	tg, err := siatest.NewGroupFromTemplate(testDir, groupParams)
+	if err != nil {
+		t.Fatal("Failed to create group: ", err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	host := tg.Hosts()[0]
+	miner := tg.Miners()[0]
+
+	// Resuming without quiescing should fail.
+	if _, err := host.DaemonMaintenanceResumePost(); err == nil {
+		t.Fatal("expected resume to fail")
+	}
+
+	// Quiesce the host's writers.
+	dmqp, err := host.DaemonMaintenanceQuiescePost(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(dmqp.Modules, []string{"consensus", "transactionpool", "wallet", "host"}) {
+		t.Fatal("unexpected quiesced modules", dmqp.Modules)
+	}
+	if dmqp.Deadline.Sub(dmqp.Start) != time.Minute {
+		t.Fatal("wrong deadline", dmqp.Start, dmqp.Deadline)
+	}
+	var foundConsensus, foundHost bool
+	for _, f := range dmqp.Files {
+		foundConsensus = foundConsensus || filepath.Base(f.Path) == "consensus.db"
+		foundHost = foundHost || filepath.Base(f.Path) == "host.db"
+	}
+	if !foundConsensus || !foundHost {
+		t.Fatal("databases missing from files", foundConsensus, foundHost)
+	}
+
+	// Quiescing again should fail.
+	if _, err := host.DaemonMaintenanceQuiescePost(time.Minute); err == nil {
+		t.Fatal("expected second quiesce to fail")
+	}
+
+	// Mine a block. The host shouldn't accept it while quiesced but it should
+	// still be possible to read its consensus.
+	cg, err := host.ConsensusGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := miner.MineBlock(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Second)
+	cg2, err := host.ConsensusGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cg2.Height != cg.Height {
+		t.Fatal("host accepted block while quiesced")
+	}
+
+	// Resume the writers. The host should catch up.
+	dmrp, err := host.DaemonMaintenanceResumePost()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dmrp.Expired || dmrp.Start != dmqp.Start || dmrp.End.Before(dmrp.Start) {
+		t.Fatal("unexpected window", dmrp)
+	}
+	if err := tg.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Quiesce the writers for a second and wait for the window to expire.
+	if _, err := host.DaemonMaintenanceQuiescePost(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(2 * time.Second)
+	if err := miner.MineBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tg.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	dmrp, err = host.DaemonMaintenanceResumePost()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dmrp.Expired {
+		t.Fatal("window should have expired")
+	}
+}
+
+// TestDaemonMetrics tests the /metrics endpoint.
+func TestDaemonMetrics(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	testDir := daemonTestDir(t.Name())
+
+	// Create a new server
+	testNode, err := siatest.NewNode(node.AllModules(testDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := testNode.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// The metrics of all modules should be returned.
+	metrics, err := testNode.MetricsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cg, err := testNode.ConsensusGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"# TYPE sia_consensus_height gauge",
+		fmt.Sprintf("sia_consensus_height %v\n", cg.Height),
+		"sia_consensus_synced 1\n",
+		`sia_gateway_peers{direction="outbound"}`,
+		`sia_host_rpc_calls_total{rpc="settings"}`,
+		`sia_host_obligations{status="unresolved"} 0`,
+		"sia_renter_workers ",
+		`sia_renter_memory_available_bytes{manager="userupload"}`,
+		"sia_renter_repair_queue_files 0\n",
+		"sia_tpool_transactions ",
+	}
+	for _, e := range expected {
+		if !strings.Contains(string(metrics), e) {
+			t.Fatalf("metrics don't contain %q:\n%s", e, metrics)
+		}
+	}
+
+	// Prometheus doesn't set the Sia-Agent useragent.
+	resp, err := http.Get("http://" + testNode.Address + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("unexpected status", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Fatal("unexpected content type", ct)
+	}
+}
+
+// TestDaemonLogSettings tests changing the log settings of the daemon.
+func TestDaemonLogSettings(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	testDir := daemonTestDir(t.Name())
+
+	// Create a new server
+	testNode, err := siatest.NewCleanNode(node.Gateway(testDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := persist.GlobalLogConfig.SetSettings(persist.LogSettings{}); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Invalid settings are rejected.
+	if err := testNode.DaemonLogSettingsPost(persist.LogSettings{Level: "verbose"}); err == nil {
+		t.Fatal("expected invalid settings to be rejected")
+	}
+
+	// Log the gateway's messages as JSON.
+	settings := persist.LogSettings{
+		Format:       persist.LogFormatJSON,
+		Level:        persist.LogLevelWarn,
+		ModuleLevels: map[string]persist.LogLevel{"gateway": persist.LogLevelInfo},
+	}
+	if err := testNode.DaemonLogSettingsPost(settings); err != nil {
+		t.Fatal(err)
+	}
+	dsg, err := testNode.DaemonSettingsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(dsg.Logging, settings) {
+		t.Fatal("settings weren't set", dsg.Logging)
+	}
+
+	// The shutdown message of the gateway should be logged as JSON.
+	if err := testNode.Close(); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(testDir, modules.GatewayDir, "gateway.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	var msg struct {
+		Level   persist.LogLevel `json:"level"`
+		Module  string           `json:"module"`
+		Message string           `json:"msg"`
+	}
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Level != persist.LogLevelInfo || msg.Module != "gateway" || !strings.HasPrefix(msg.Message, "SHUTDOWN") {
+		t.Fatal("unexpected message", msg)
+	}
+}
@@ -284,6 +284,23 @@ func TestHostBandwidth(t *testing.T) {
 	if hbw.Upload <= lastUpload || hbw.Download <= lastDownload {
 		t.Fatal("Expected host to use more bandwidth from downloaded file")
 	}
+
+	// The ledger should contain the bandwidth of today split up by RPC.
+	if len(hbw.Ledger.Days) == 0 || hbw.Ledger.Upload == 0 || hbw.Ledger.Download == 0 {
+		t.Fatal("Expected ledger to contain the bandwidth of today", hbw.Ledger)
+	}
+	if len(hbw.Ledger.RPCs) == 0 {
+		t.Fatal("Expected ledger to contain the bandwidth per RPC")
+	}
+
+	// A period in the past doesn't contain any bandwidth.
+	hbw, err = hostNode.HostBandwidthPeriodGet(time.Unix(0, 0), time.Unix(0, 0).Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hbw.Ledger.Days) != 0 || hbw.Ledger.Upload != 0 {
+		t.Fatal("Expected empty ledger", hbw.Ledger)
+	}
 }
 
 // TestHostContracts confirms that the host contracts endpoint returns the expected values
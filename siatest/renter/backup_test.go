@@ -751,3 +751,255 @@ func TestBackupRenew(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// TestRenterReplication tests that a secondary renter mirrors the files of a
+// primary renter, stays read-only and serves downloads using its own
+// contracts.
+func TestRenterReplication(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a testgroup.
+	groupParams := siatest.GroupParams{
+		Hosts:   2,
+		Miners:  1,
+		Renters: 2,
+	}
+	testDir := renterTestDir(t.Name())
+	tg, err := siatest.NewGroupFromTemplate(testDir, groupParams)
+	if err != nil {
+		t.Fatal("Failed to create group: ", err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	primary, secondary := tg.Renters()[0], tg.Renters()[1]
+
+	// Upload a file to the primary.
+	_, rf, err := primary.UploadNewFileBlocking(100, 1, 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Configure the replication.
+	settings := modules.ReplicationSettings{
+		Role:     modules.ReplicationRolePrimary,
+		Path:     filepath.Join(testDir, "replica.backup"),
+		Secret:   "secret",
+		Interval: time.Second,
+	}
+	if err := primary.RenterReplicationPost(settings); err != nil {
+		t.Fatal(err)
+	}
+	settings.Role = modules.ReplicationRoleSecondary
+	if err := secondary.RenterReplicationPost(settings); err != nil {
+		t.Fatal(err)
+	}
+
+	// The secondary should import the file and be read-only.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		status, err := secondary.RenterReplicationGet()
+		if err != nil {
+			return err
+		}
+		if status.LastSyncError != "" {
+			return errors.New(status.LastSyncError)
+		}
+		if status.ImportedFiles != 1 {
+			return fmt.Errorf("expected 1 imported file, got %v", status.ImportedFiles)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, err := secondary.RenterReplicationGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Settings.Secret != "" {
+		t.Fatal("secret shouldn't be returned")
+	}
+	if len(status.MissingHosts) != 0 {
+		t.Fatal("secondary should have contracts with all hosts", status.MissingHosts)
+	}
+	ros, err := secondary.RenterReadOnlyGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ros.Active || !ros.Replica {
+		t.Fatal("secondary should be read-only", ros)
+	}
+
+	// The secondary should be able to download the file from the hosts.
+	if _, _, err := secondary.DownloadByStream(rf); err != nil {
+		t.Fatal(err)
+	}
+
+	// Uploading to or deleting from the secondary should fail.
+	if _, _, err := secondary.UploadNewFile(100, 1, 1, false); err == nil {
+		t.Fatal("upload to secondary should fail")
+	}
+	if err := secondary.RenterFileDeletePost(rf.SiaPath()); err == nil {
+		t.Fatal("delete from secondary should fail")
+	}
+
+	// Deleting the file from the primary should delete it from the
+	// secondary.
+	if err := primary.RenterFileDeletePost(rf.SiaPath()); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		files, err := secondary.Files(false)
+		if err != nil {
+			return err
+		}
+		if len(files) != 0 {
+			return fmt.Errorf("expected 0 files, got %v", len(files))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Resetting the role should leave read-only mode.
+	if err := secondary.RenterReplicationPost(modules.ReplicationSettings{}); err != nil {
+		t.Fatal(err)
+	}
+	ros, err = secondary.RenterReadOnlyGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ros.Active || ros.Replica {
+		t.Fatal("secondary shouldn't be read-only", ros)
+	}
+}
+
+// TestRenterMetadataMirror tests mirroring the renter's metadata to its hosts
+// and recovering the siafiles from the mirror.
+func TestRenterMetadataMirror(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a testgroup.
+	groupParams := siatest.GroupParams{
+		Hosts:   3,
+		Miners:  1,
+		Renters: 1,
+	}
+	testDir := renterTestDir(t.Name())
+	tg, err := siatest.NewGroupFromTemplate(testDir, groupParams)
+	if err != nil {
+		t.Fatal("Failed to create group: ", err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := tg.Renters()[0]
+
+	// waitForSequence waits for the mirror to mirror a blob after the one
+	// with the provided sequence number.
+	waitForSequence := func(sequence uint64) modules.MetadataMirrorStatus {
+		var status modules.MetadataMirrorStatus
+		err := build.Retry(200, 100*time.Millisecond, func() error {
+			status, err = r.RenterMetadataMirrorGet()
+			if err != nil {
+				return err
+			}
+			if status.LastMirrorError != "" {
+				return errors.New(status.LastMirrorError)
+			}
+			if status.Sequence <= sequence {
+				return fmt.Errorf("expected sequence > %v, got %v", sequence, status.Sequence)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return status
+	}
+
+	// Upload a file and enable the mirror.
+	_, rf1, err := r.UploadNewFileBlocking(100, 1, 2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := modules.MetadataMirrorSettings{
+		Enabled:  true,
+		Interval: time.Second,
+	}
+	if err := r.RenterMetadataMirrorPost(settings); err != nil {
+		t.Fatal(err)
+	}
+	status := waitForSequence(0)
+	if !status.Settings.Enabled || status.ChainLength != 1 || status.MirroredFiles != 1 {
+		t.Fatal("unexpected status after base mirror", status)
+	}
+
+	// Upload another file which should be mirrored as a delta.
+	_, rf2, err := r.UploadNewFileBlocking(100, 1, 2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(200, 100*time.Millisecond, func() error {
+		status, err = r.RenterMetadataMirrorGet()
+		if err != nil {
+			return err
+		}
+		if status.ChainLength < 2 {
+			return fmt.Errorf("expected chain length >= 2, got %v", status.ChainLength)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Disable the mirror and delete the files.
+	if err := r.RenterMetadataMirrorPost(modules.MetadataMirrorSettings{}); err != nil {
+		t.Fatal(err)
+	}
+	for _, rf := range []*siatest.RemoteFile{rf1, rf2} {
+		if err := r.RenterFileDeletePost(rf.SiaPath()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Recover the files from the mirror.
+	recovery, err := r.RenterMetadataMirrorRecoverPost()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recovery.RecoveredFiles != 2 || recovery.SkippedFiles != 0 {
+		t.Fatal("unexpected recovery", recovery)
+	}
+	if recovery.Contracts != uint64(len(tg.Hosts())) || len(recovery.MissingContracts) != 0 {
+		t.Fatal("unexpected contract index", recovery)
+	}
+
+	// The recovered files should be downloadable.
+	for _, rf := range []*siatest.RemoteFile{rf1, rf2} {
+		if _, _, err := r.DownloadByStream(rf); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Recovering again should skip the existing files.
+	recovery, err = r.RenterMetadataMirrorRecoverPost()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recovery.RecoveredFiles != 0 || recovery.SkippedFiles != 2 {
+		t.Fatal("unexpected recovery", recovery)
+	}
+}
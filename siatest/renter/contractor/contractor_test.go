@@ -2878,3 +2878,103 @@ func TestLargeRenewWindow(t *testing.T) {
 		t.Errorf("Expected NextPeriod to be %v but was %v", originalNextPeriod+allowance.Period, rg.NextPeriod)
 	}
 }
+
+// TestContractKeyStore tests moving the renter's contract keys into the
+// encrypted key store and unlocking it after a restart.
+func TestContractKeyStore(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a group for the test.
+	groupParams := siatest.GroupParams{
+		Hosts:   2,
+		Renters: 1,
+		Miners:  1,
+	}
+	tg, err := siatest.NewGroupFromTemplate(contractorTestDir(t.Name()), groupParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+	r := tg.Renters()[0]
+
+	// The keys of the contracts should still be stored in the contracts.
+	status, err := r.RenterContractKeysGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Initialized || status.Unlocked || status.NumUnprotectedKeys != 2 {
+		t.Fatal("unexpected status", status)
+	}
+
+	// The key store can only be created with the wallet password.
+	if err := r.RenterContractKeysUnlockPost("wrong password"); err == nil {
+		t.Fatal("expected unlock with wrong password to fail")
+	}
+	wsg, err := r.WalletSeedsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RenterContractKeysUnlockPost(wsg.PrimarySeed); err != nil {
+		t.Fatal(err)
+	}
+	status, err = r.RenterContractKeysGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Initialized || !status.Unlocked || status.NumKeys != 2 || status.NumUnprotectedKeys != 0 {
+		t.Fatal("unexpected status", status)
+	}
+
+	// Change the password and restart the renter. The key store should be
+	// locked.
+	newPassword := "new password"
+	if err := r.RenterContractKeysChangePasswordPost(wsg.PrimarySeed, newPassword); err != nil {
+		t.Fatal(err)
+	}
+	if err := tg.RestartNode(r); err != nil {
+		t.Fatal(err)
+	}
+	status, err = r.RenterContractKeysGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Initialized || status.Unlocked || status.NumUnprotectedKeys != 0 {
+		t.Fatal("unexpected status", status)
+	}
+
+	// Only the new password unlocks the store. Afterwards, the contracts can
+	// be used again.
+	if err := r.RenterContractKeysUnlockPost(wsg.PrimarySeed); err == nil {
+		t.Fatal("expected unlock with old password to fail")
+	}
+	if err := r.RenterContractKeysUnlockPost(newPassword); err != nil {
+		t.Fatal(err)
+	}
+	status, err = r.RenterContractKeysGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Unlocked || status.NumKeys != 2 {
+		t.Fatal("unexpected status", status)
+	}
+	_, rf, err := r.UploadNewFileBlocking(int(modules.SectorSize), 1, 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The workers might need a moment to refill their accounts after being
+	// unable to pay while the key store was locked.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		_, _, err := r.DownloadByStream(rf)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -969,3 +969,63 @@ func testFilterMode(tg *siatest.TestGroup, renter *siatest.TestNode, fm modules.
 
 	return nil
 }
+
+// TestSimulateAllowance tests replaying the recorded settings of the hosts
+// against an allowance through the API.
+func TestSimulateAllowance(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+
+	// Create a group.
+	groupParams := siatest.GroupParams{
+		Hosts:   2,
+		Renters: 1,
+		Miners:  1,
+	}
+	tg, err := siatest.NewGroupFromTemplate(hostdbTestDir(t.Name()), groupParams)
+	if err != nil {
+		t.Fatal("Failed to create group: ", err)
+	}
+	defer func() {
+		if err := tg.Close(); err != nil {
+			t.Error(err)
+		}
+	}()
+	renter := tg.Renters()[0]
+	allowance := siatest.DefaultAllowance
+	allowance.Hosts = uint64(len(tg.Hosts()))
+
+	// Once the hosts were scanned, they should qualify.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		hdsg, err := renter.HostDbSimulateGet(allowance, 1)
+		if err != nil {
+			return err
+		}
+		if len(hdsg.Samples) == 0 {
+			return errors.New("no samples")
+		}
+		sample := hdsg.Samples[len(hdsg.Samples)-1]
+		if sample.KnownHosts != allowance.Hosts || sample.QualifyingHosts != allowance.Hosts || !sample.EnoughHosts {
+			return fmt.Errorf("unexpected sample %v", sample)
+		}
+		if sample.TotalCost.IsZero() {
+			return errors.New("expected a total cost")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Hosts which are too expensive don't qualify.
+	allowance.MaxStoragePrice = types.NewCurrency64(1)
+	hdsg, err := renter.HostDbSimulateGet(allowance, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hdsg.MinQualifyingHosts != 0 || hdsg.SufficientSamples != 0 {
+		t.Fatal("hosts shouldn't qualify", hdsg.MinQualifyingHosts, hdsg.SufficientSamples)
+	}
+}
@@ -1,6 +1,7 @@
 package renter
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -60,6 +61,7 @@ func TestRenterOne(t *testing.T) {
 		{Name: "TestDirectories", Test: testDirectories},
 		{Name: "TestAlertsSorted", Test: testAlertsSorted},
 		{Name: "TestPriceTablesUpdated", Test: testPriceTablesUpdated},
+		{Name: "TestFileRecovery", Test: testFileRecovery},
 		{Name: "TestFileAvailableAndRecoverable", Test: testFileAvailableAndRecoverable},
 		{Name: "TestReceivedFieldEqualsFileSize", Test: testReceivedFieldEqualsFileSize},
 	}
@@ -90,6 +92,9 @@ func TestRenterTwo(t *testing.T) {
 	subTests := []siatest.SubTest{
 		{Name: "TestRemoteRepair", Test: testRemoteRepair},
 		{Name: "TestSingleFileGet", Test: testSingleFileGet},
+		{Name: "TestDownloadPolicies", Test: testDownloadPolicies},
+		{Name: "TestFileVersions", Test: testFileVersions},
+		{Name: "TestCopyDir", Test: testCopyDir},
 		{Name: "TestSiaFileTimestamps", Test: testSiafileTimestamps},
 		{Name: "TestZeroByteFile", Test: testZeroByteFile},
 		{Name: "TestUploadWithAndWithoutForceParameter", Test: testUploadWithAndWithoutForceParameter},
@@ -247,6 +252,7 @@ func TestRenterThree(t *testing.T) {
 		{Name: "TestAllowanceDefaultSet", Test: testAllowanceDefaultSet},
 		{Name: "TestSetFileStuck", Test: testSetFileStuck},
 		{Name: "TestCancelAsyncDownload", Test: testCancelAsyncDownload},
+		{Name: "TestFailureDomains", Test: testFailureDomains},
 		{Name: "TestUploadDownload", Test: testUploadDownload}, // Needs to be last as it impacts hosts
 	}
 
@@ -311,6 +317,108 @@ func testAllowanceDefaultSet(t *testing.T, tg *siatest.TestGroup) {
 	}
 }
 
+// testFileRecovery tests recovering the data of a file directly from the
+// renter's contracts.
+func testFileRecovery(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+
+	// Upload a file which spans multiple chunks.
+	dataPieces := uint64(2)
+	parityPieces := uint64(2)
+	chunkSize := siatest.ChunkSize(dataPieces, crypto.TypeDefaultRenter)
+	fileSize := int(2*chunkSize) + fastrand.Intn(int(chunkSize))
+	lf, rf, err := r.UploadNewFileBlocking(fileSize, dataPieces, parityPieces, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Recover the file using its metadata.
+	dst := filepath.Join(r.FilesDir().Path(), "recovered")
+	report, err := r.RenterFileRecoverPost(rf.SiaPath(), dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Size != uint64(fileSize) || report.RecoveredChunks != 3 || len(report.LostChunks) != 0 {
+		t.Fatal("unexpected report", report)
+	}
+	recovered, err := ioutil.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lf.Equal(recovered); err != nil {
+		t.Fatal(err)
+	}
+
+	// The destination must not exist yet.
+	if _, err := r.RenterFileRecoverPost(rf.SiaPath(), dst); err == nil {
+		t.Fatal("expected recovery to an existing destination to fail")
+	}
+
+	// Export the file, delete it and recover it using the export.
+	export, err := r.RenterFileExportGet(rf.SiaPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.RenterFileDeletePost(rf.SiaPath()); err != nil {
+		t.Fatal(err)
+	}
+	dst = filepath.Join(r.FilesDir().Path(), "recoveredexport")
+	report, err = r.RenterFileRecoverExportPost(bytes.NewReader(export), dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.RecoveredChunks != 3 || len(report.LostChunks) != 0 {
+		t.Fatal("unexpected report", report)
+	}
+	recovered, err = ioutil.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lf.Equal(recovered); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// testFailureDomains tests the failure domain report and fix-up of the
+// renter. The hosts of the test group don't share any failure domains, so
+// neither is expected to find any violations.
+func testFailureDomains(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+	_, rf, err := r.UploadNewFileBlocking(100, 1, 2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := r.RenterFailureDomainsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.FilesChecked == 0 || len(report.Violations) != 0 {
+		t.Fatal("unexpected report", report)
+	}
+
+	// Fix a specific file and all files.
+	fixReport, err := r.RenterFailureDomainsFixPost(rf.SiaPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fixReport.FixedChunks != 0 || fixReport.DroppedPieces != 0 {
+		t.Fatal("unexpected fix report", fixReport)
+	}
+	fixReport, err = r.RenterFailureDomainsFixPost()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fixReport.FixedChunks != 0 || fixReport.DroppedPieces != 0 {
+		t.Fatal("unexpected fix report", fixReport)
+	}
+
+	// Fixing a file which doesn't exist fails.
+	if _, err := r.RenterFailureDomainsFixPost(modules.RandomSiaPath()); err == nil {
+		t.Fatal("expected fixing a missing file to fail")
+	}
+}
+
 // testReceivedFieldEqualsFileSize tests that the bug that caused finished
 // downloads to stall in the UI and siac is gone.
 func testReceivedFieldEqualsFileSize(t *testing.T, tg *siatest.TestGroup) {
@@ -1273,6 +1381,173 @@ func testRemoteRepair(t *testing.T, tg *siatest.TestGroup) {
 
 // testSingleFileGet is a subtest that uses an existing TestGroup to test if
 // using the single file API endpoint works
+// testDownloadPolicies tests that files can be downloaded with all download
+// policies.
+func testDownloadPolicies(t *testing.T, tg *siatest.TestGroup) {
+	// Grab the first of the group's renters
+	renter := tg.Renters()[0]
+	// Upload a file and delete the local copy to make sure it is downloaded
+	// from the hosts.
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+	lf, rf, err := renter.UploadNewFileBlocking(int(modules.SectorSize)+siatest.Fuzz(), dataPieces, parityPieces, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := lf.Data()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lf.Delete(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Download the file with every policy.
+	policies := []modules.DownloadPolicy{modules.DownloadPolicyBalanced, modules.DownloadPolicyCheapest, modules.DownloadPolicyLatency}
+	for _, policy := range policies {
+		dest := filepath.Join(renter.DownloadDir().Path(), policy.String())
+		_, err := renter.RenterDownloadPolicyGet(rf.SiaPath(), dest, policy, false, false)
+		if err != nil {
+			t.Fatal(policy, err)
+		}
+		downloaded, err := ioutil.ReadFile(dest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, downloaded) {
+			t.Fatal("downloaded data doesn't match for policy", policy)
+		}
+	}
+}
+
+// testCopyDir tests copying a directory and downloading the copied files.
+func testCopyDir(t *testing.T, tg *siatest.TestGroup) {
+	// Grab the first of the group's renters
+	renter := tg.Renters()[0]
+
+	// Upload a file into a new directory.
+	dir := modules.RandomSiaPath()
+	siaPath, err := dir.Join("sub/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+	fileSize := 100 + siatest.Fuzz()
+	lf, err := renter.FilesDir().NewFile(fileSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rf, err := renter.Upload(lf, siaPath, dataPieces, parityPieces, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := renter.WaitForUploadHealth(rf); err != nil {
+		t.Fatal(err)
+	}
+
+	// Copy the directory.
+	dst := modules.RandomSiaPath()
+	if err := renter.RenterDirCopyPost(dir, dst); err != nil {
+		t.Fatal(err)
+	}
+	if err := renter.RenterDirCopyPost(dir, dst); err == nil {
+		t.Fatal("copying to an existing directory should fail")
+	}
+
+	// Both the original and the copy should be downloadable.
+	copyPath, err := dst.Join("sub/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sp := range []modules.SiaPath{siaPath, copyPath} {
+		_, data, err := renter.RenterDownloadHTTPResponseGet(sp, 0, uint64(fileSize), true, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := lf.Equal(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// testFileVersions tests that overwritten files are retained as versions which
+// can be restored and pruned.
+func testFileVersions(t *testing.T, tg *siatest.TestGroup) {
+	// Grab the first of the group's renters
+	renter := tg.Renters()[0]
+	err := renter.RenterSetMaxFileVersionsPost(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := renter.RenterSetMaxFileVersionsPost(0); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Upload a file and overwrite it.
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+	fileSize := 100 + siatest.Fuzz()
+	_, rf, err := renter.UploadNewFileBlocking(fileSize, dataPieces, parityPieces, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lf, err := renter.FilesDir().NewFile(fileSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rfNew, err := renter.Upload(lf, rf.SiaPath(), dataPieces, parityPieces, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := renter.WaitForUploadHealth(rfNew); err != nil {
+		t.Fatal(err)
+	}
+
+	// The original file should be a version now.
+	rfv, err := renter.RenterVersionsGet(rf.SiaPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rfv.Versions) != 1 || rfv.Versions[0].Filesize != uint64(fileSize) {
+		t.Fatal("unexpected versions", rfv.Versions)
+	}
+
+	// Restore it and check that the original data is downloaded.
+	if err := renter.RenterVersionRestorePost(rf.SiaPath(), rfv.Versions[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := renter.DownloadByStreamWithDiskFetch(rf, true); err != nil {
+		t.Fatal(err)
+	}
+	rfv, err = renter.RenterVersionsGet(rf.SiaPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rfv.Versions) != 1 {
+		t.Fatal("expected the overwritten file to be a version", rfv.Versions)
+	}
+
+	// Prune the versions.
+	if err := renter.RenterVersionsPrunePost(rf.SiaPath(), 0); err != nil {
+		t.Fatal(err)
+	}
+	rfv, err = renter.RenterVersionsGet(rf.SiaPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rfv.Versions) != 0 {
+		t.Fatal("expected no versions", rfv.Versions)
+	}
+
+	// Unknown versions can't be restored.
+	if err := renter.RenterVersionRestorePost(rf.SiaPath(), "1"); err == nil {
+		t.Fatal("expected restoring an unknown version to fail")
+	}
+}
+
 func testSingleFileGet(t *testing.T, tg *siatest.TestGroup) {
 	if len(tg.Hosts()) < 2 {
 		t.Fatal("This test requires at least 2 hosts")
@@ -3,16 +3,20 @@ package renter
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"gitlab.com/NebulousLabs/errors"
 	"gitlab.com/NebulousLabs/fastrand"
 
 	"go.sia.tech/siad/build"
 	"go.sia.tech/siad/crypto"
 	"go.sia.tech/siad/modules"
+	"go.sia.tech/siad/modules/renter"
 	"go.sia.tech/siad/node"
 	"go.sia.tech/siad/siatest"
 	"go.sia.tech/siad/siatest/dependencies"
@@ -178,9 +182,14 @@ func TestRenterStream(t *testing.T) {
 
 	// Specify subtests to run
 	subTests := []siatest.SubTest{
+		{Name: "TestStreamHTTPHeaders", Test: testStreamHTTPHeaders},
 		{Name: "TestStreamLargeFile", Test: testStreamLargeFile},
 		{Name: "TestStreamRepair", Test: testStreamRepair},
 		{Name: "TestUploadStreaming", Test: testUploadStreaming},
+		{Name: "TestUploadStreamingAppend", Test: testUploadStreamingAppend},
+		{Name: "TestUploadStreamingChunked", Test: testUploadStreamingChunked},
+		{Name: "TestUploadStreamingResume", Test: testUploadStreamingResume},
+		{Name: "TestUploadStreamingSparse", Test: testUploadStreamingSparse},
 		{Name: "TestUploadStreamingWithBadDeps", Test: testUploadStreamingWithBadDeps},
 	}
 
@@ -215,6 +224,58 @@ func testStreamLargeFile(t *testing.T, tg *siatest.TestGroup) {
 	}
 }
 
+// testStreamHTTPHeaders tests that the HTTP headers attached to a file are
+// used by the streaming endpoint.
+func testStreamHTTPHeaders(t *testing.T, tg *siatest.TestGroup) {
+	r := tg.Renters()[0]
+	_, remoteFile, err := r.UploadNewFileBlocking(100, 1, 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	siaPath := remoteFile.SiaPath()
+
+	// Without headers, the content type is detected from the data.
+	header, _, err := r.RenterStreamWithHeadersGet(siaPath, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Get("Content-Type") != "application/octet-stream" || header.Get("Cache-Control") != "" {
+		t.Fatal("unexpected headers", header)
+	}
+
+	// Attach headers to the file.
+	headers := modules.FileHTTPHeaders{
+		CacheControl:       "max-age=3600",
+		ContentDisposition: `attachment; filename="data.txt"`,
+		ContentType:        "text/plain; charset=utf-8",
+	}
+	if err := r.RenterSetFileHTTPHeadersPost(siaPath, headers); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := r.File(remoteFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.HTTPHeaders != headers {
+		t.Fatal("wrong headers", fi.HTTPHeaders)
+	}
+	header, _, err = r.RenterStreamWithHeadersGet(siaPath, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Get("Cache-Control") != headers.CacheControl ||
+		header.Get("Content-Disposition") != headers.ContentDisposition ||
+		header.Get("Content-Type") != headers.ContentType {
+		t.Fatal("unexpected headers", header)
+	}
+
+	// Headers which can't be sent are rejected.
+	headers.CacheControl = "no-cache\r\nSet-Cookie: a=b"
+	if err := r.RenterSetFileHTTPHeadersPost(siaPath, headers); err == nil || !strings.Contains(err.Error(), modules.ErrInvalidHTTPHeader.Error()) {
+		t.Fatal("expected ErrInvalidHTTPHeader", err)
+	}
+}
+
 // testStreamRepair tests if repairing a file using the streaming endpoint
 // works.
 func testStreamRepair(t *testing.T, tg *siatest.TestGroup) {
@@ -344,6 +405,322 @@ func testUploadStreaming(t *testing.T, tg *siatest.TestGroup) {
 	}
 }
 
+// testUploadStreamingChunked uploads data of unknown length which arrives in
+// small pieces. Since the length is unknown, the request body is sent with
+// chunked transfer encoding.
+func testUploadStreamingChunked(t *testing.T, tg *siatest.TestGroup) {
+	if len(tg.Renters()) == 0 {
+		t.Fatal("Test requires at least 1 renter")
+	}
+	// Create some random data and write it to a pipe in small pieces.
+	data := fastrand.Bytes(int(2*modules.SectorSize) + siatest.Fuzz() + 2)
+	pr, pw := io.Pipe()
+	go func() {
+		for remaining := data; len(remaining) > 0; {
+			n := fastrand.Intn(4096) + 1
+			if n > len(remaining) {
+				n = len(remaining)
+			}
+			if _, err := pw.Write(remaining[:n]); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			remaining = remaining[n:]
+		}
+		pw.Close()
+	}()
+
+	// Upload the data.
+	siaPath, err := modules.NewSiaPath("/chunked")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := tg.Renters()[0]
+	err = r.RenterUploadStreamPost(pr, siaPath, 1, uint64(len(tg.Hosts())-1), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The file should have the size of the data and no local path.
+	rfg, err := r.RenterFileGet(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rfg.File.Filesize != uint64(len(data)) {
+		t.Fatalf("expected uploaded file to have size %v but was %v", len(data), rfg.File.Filesize)
+	}
+	if rfg.File.LocalPath != "" {
+		t.Fatal("streamed file shouldn't have a local path", rfg.File.LocalPath)
+	}
+
+	// Download the file again and compare it to the original data.
+	_, downloadedData, err := r.RenterDownloadHTTPResponseGet(siaPath, 0, uint64(len(data)), true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, downloadedData) {
+		t.Fatal("Downloaded data doesn't match uploaded data")
+	}
+}
+
+// testUploadStreamingAppend tests appending data to an existing file using the
+// upload streaming API.
+func testUploadStreamingAppend(t *testing.T, tg *siatest.TestGroup) {
+	if len(tg.Renters()) == 0 {
+		t.Fatal("Test requires at least 1 renter")
+	}
+	r := tg.Renters()[0]
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+	chunkSize := int(siatest.ChunkSize(dataPieces, crypto.TypeDefaultRenter))
+
+	// Upload a file consisting of a single full chunk.
+	siaPath, err := modules.NewSiaPath("append")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := fastrand.Bytes(chunkSize)
+	err = r.RenterUploadStreamPost(bytes.NewReader(data), siaPath, dataPieces, parityPieces, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Append a chunk and a half.
+	appended := fastrand.Bytes(chunkSize + chunkSize/2)
+	err = r.RenterUploadStreamAppendPost(bytes.NewReader(appended), siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = append(data, appended...)
+
+	// Make sure the file grew and reached full redundancy.
+	err = build.Retry(100, 600*time.Millisecond, func() error {
+		rfg, err := r.RenterFileGet(siaPath)
+		if err != nil {
+			return err
+		}
+		if rfg.File.Redundancy < float64(len(tg.Hosts())) {
+			return fmt.Errorf("expected redundancy %v but was %v",
+				len(tg.Hosts()), rfg.File.Redundancy)
+		}
+		if rfg.File.Filesize != uint64(len(data)) {
+			return fmt.Errorf("expected file to have size %v but was %v",
+				len(data), rfg.File.Filesize)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, downloadedData, err := r.RenterDownloadHTTPResponseGet(siaPath, 0, uint64(len(data)), true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, downloadedData) {
+		t.Fatal("Downloaded data doesn't match uploaded data")
+	}
+
+	// The last chunk isn't full anymore so appending should fail.
+	err = r.RenterUploadStreamAppendPost(bytes.NewReader(appended), siaPath)
+	if err == nil || !strings.Contains(err.Error(), renter.ErrAppendPartialChunk.Error()) {
+		t.Fatal("expected ErrAppendPartialChunk but got", err)
+	}
+}
+
+// blockingReader is a reader which blocks after reading all of its data until
+// it is unblocked and then fails.
+type blockingReader struct {
+	r       *bytes.Reader
+	unblock chan struct{}
+}
+
+// Read implements io.Reader.
+func (br *blockingReader) Read(b []byte) (int, error) {
+	if br.r.Len() > 0 {
+		return br.r.Read(b)
+	}
+	<-br.unblock
+	return 0, errors.New("connection reset")
+}
+
+// testUploadStreamingResume tests resuming an upload stream which was
+// interrupted by a restart of the renter.
+func testUploadStreamingResume(t *testing.T, tg *siatest.TestGroup) {
+	if len(tg.Renters()) == 0 {
+		t.Fatal("Test requires at least 1 renter")
+	}
+	r := tg.Renters()[0]
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+	chunkSize := int(siatest.ChunkSize(dataPieces, crypto.TypeDefaultRenter))
+	siaPath, err := modules.NewSiaPath("resume")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Start uploading 3.5 chunks but block after 2.5 chunks.
+	data := fastrand.Bytes(3*chunkSize + chunkSize/2)
+	br := &blockingReader{
+		r:       bytes.NewReader(data[:2*chunkSize+chunkSize/2]),
+		unblock: make(chan struct{}),
+	}
+	errChan := make(chan error)
+	go func() {
+		errChan <- r.RenterUploadStreamPost(br, siaPath, dataPieces, parityPieces, false)
+	}()
+
+	// Wait for the first two chunks to become durable.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		rus, err := r.RenterUploadStreamGet(siaPath)
+		if err != nil {
+			return err
+		}
+		if rus.ResumeOffset != uint64(2*chunkSize) {
+			return fmt.Errorf("expected resume offset %v but was %v", 2*chunkSize, rus.ResumeOffset)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Interrupt the upload and restart the renter.
+	close(br.unblock)
+	if err := <-errChan; err == nil {
+		t.Fatal("interrupted upload should fail")
+	}
+	if err := tg.RestartNode(r); err != nil {
+		t.Fatal(err)
+	}
+
+	// The stream can still be resumed after the restart.
+	rus, err := r.RenterUploadStreamGet(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rus.ResumeOffset != uint64(2*chunkSize) {
+		t.Fatalf("expected resume offset %v but was %v", 2*chunkSize, rus.ResumeOffset)
+	}
+
+	// Resume the upload with the remaining data.
+	err = r.RenterUploadStreamResumePost(bytes.NewReader(data[rus.ResumeOffset:]), siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The upload finished so it can't be resumed anymore.
+	if _, err := r.RenterUploadStreamGet(siaPath); err == nil || !strings.Contains(err.Error(), renter.ErrUploadStreamNotResumable.Error()) {
+		t.Fatal("expected ErrUploadStreamNotResumable but got", err)
+	}
+
+	// Make sure the file reached full redundancy and contains all the data.
+	err = build.Retry(100, 600*time.Millisecond, func() error {
+		rfg, err := r.RenterFileGet(siaPath)
+		if err != nil {
+			return err
+		}
+		if rfg.File.Redundancy < float64(len(tg.Hosts())) {
+			return fmt.Errorf("expected redundancy %v but was %v",
+				len(tg.Hosts()), rfg.File.Redundancy)
+		}
+		if rfg.File.Filesize != uint64(len(data)) {
+			return fmt.Errorf("expected file to have size %v but was %v",
+				len(data), rfg.File.Filesize)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, downloadedData, err := r.RenterDownloadHTTPResponseGet(siaPath, 0, uint64(len(data)), true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, downloadedData) {
+		t.Fatal("Downloaded data doesn't match uploaded data")
+	}
+}
+
+// testUploadStreamingSparse tests creating a sparse file, filling one of its
+// holes using the upload streaming API and punching it again.
+func testUploadStreamingSparse(t *testing.T, tg *siatest.TestGroup) {
+	if len(tg.Renters()) == 0 {
+		t.Fatal("Test requires at least 1 renter")
+	}
+	r := tg.Renters()[0]
+	dataPieces := uint64(1)
+	parityPieces := uint64(len(tg.Hosts())) - dataPieces
+	chunkSize := siatest.ChunkSize(dataPieces, crypto.TypeDefaultRenter)
+
+	// Create a sparse file of 2.5 chunks.
+	siaPath, err := modules.NewSiaPath("sparse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileSize := 2*chunkSize + chunkSize/2
+	err = r.RenterSparseCreatePost(siaPath, fileSize, dataPieces, parityPieces, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rfg, err := r.RenterFileGet(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rfg.File.Filesize != fileSize {
+		t.Fatalf("expected size %v but was %v", fileSize, rfg.File.Filesize)
+	}
+
+	// Downloading it returns zeroes.
+	expected := make([]byte, fileSize)
+	_, downloadedData, err := r.RenterDownloadHTTPResponseGet(siaPath, 0, fileSize, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(expected, downloadedData) {
+		t.Fatal("sparse file didn't download as zeroes")
+	}
+
+	// Fill the second chunk.
+	data := fastrand.Bytes(int(chunkSize))
+	err = r.RenterUploadStreamRepairOffsetPost(bytes.NewReader(data), siaPath, chunkSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copy(expected[chunkSize:], data)
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		_, downloadedData, err = r.RenterDownloadHTTPResponseGet(siaPath, 0, fileSize, true, false)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(expected, downloadedData) {
+			return errors.New("downloaded data doesn't match")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rfg, err = r.RenterFileGet(siaPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rfg.File.Filesize != fileSize {
+		t.Fatalf("expected size %v but was %v", fileSize, rfg.File.Filesize)
+	}
+
+	// Punch the chunk again.
+	if err := r.RenterSparsePunchHolePost(siaPath, chunkSize, chunkSize); err != nil {
+		t.Fatal(err)
+	}
+	_, downloadedData, err = r.RenterDownloadHTTPResponseGet(siaPath, 0, fileSize, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(make([]byte, fileSize), downloadedData) {
+		t.Fatal("punched chunk didn't download as zeroes")
+	}
+}
+
 // testUploadStreamingWithBadDeps uploads random data using the upload streaming
 // API, depending on a disrupt to cause a failure. This is a regression test
 // that would have caused a production build panic.